@@ -0,0 +1,29 @@
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Python client for Aetheris: submit agent jobs, wait, stream events, signal, export evidence."""
+
+from .client import TERMINAL_STATUSES, Client
+from .errors import AetherisError, APIError, JobFailed, WaitTimeout
+
+__version__ = "0.1.0"
+
+__all__ = [
+    "Client",
+    "TERMINAL_STATUSES",
+    "AetherisError",
+    "APIError",
+    "JobFailed",
+    "WaitTimeout",
+]
@@ -0,0 +1,214 @@
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Thin client for the Aetheris HTTP API.
+
+Endpoint methods are generated from docs/openapi.json into _generated.py
+(scripts/generate.py); this module adds the transport, friendlier
+defaults for a few endpoints and the helpers at the bottom
+(submit_and_wait, stream_events, export_evidence) for notebook use. Only
+the standard library is used.
+"""
+
+import json
+import time
+import urllib.error
+import urllib.parse
+import urllib.request
+
+from ._generated import GeneratedClient
+from .errors import APIError, JobFailed, WaitTimeout
+
+TERMINAL_STATUSES = ("completed", "failed", "cancelled")
+
+# GET /api/jobs/:id/wait caps a single long poll at 2m server-side.
+_MAX_WAIT_SECONDS = 120
+
+
+class Client(GeneratedClient):
+    """Aetheris API client.
+
+    token is sent as ``Authorization: Bearer <token>`` (JWT from /api/login
+    or a service account token); tenant_id as ``X-Tenant-ID``; locale as
+    ``Accept-Language`` for localized error messages.
+    """
+
+    def __init__(self, base_url="http://localhost:8080", token=None, tenant_id=None,
+                 locale=None, timeout=30.0):
+        self.base_url = base_url.rstrip("/")
+        self.token = token
+        self.tenant_id = tenant_id
+        self.locale = locale
+        self.timeout = timeout
+
+    # -- transport -------------------------------------------------------
+
+    def request(self, method, path, body=None, query=None, headers=None, raw=False,
+                timeout=None):
+        """Send a request and return the decoded JSON (or bytes when raw)."""
+        url = self.base_url + path
+        if query:
+            query = {k: v for k, v in query.items() if v is not None}
+            if query:
+                url += "?" + urllib.parse.urlencode(query)
+        hdrs = {"Accept": "application/json", "X-Aetheris-Client": "sdk"}
+        if self.token:
+            hdrs["Authorization"] = "Bearer " + self.token
+        if self.tenant_id:
+            hdrs["X-Tenant-ID"] = self.tenant_id
+        if self.locale:
+            hdrs["Accept-Language"] = self.locale
+        data = None
+        if body is not None:
+            data = json.dumps(body).encode("utf-8")
+            hdrs["Content-Type"] = "application/json"
+        if headers:
+            hdrs.update(headers)
+        req = urllib.request.Request(url, data=data, headers=hdrs, method=method)
+        try:
+            with urllib.request.urlopen(req, timeout=timeout or self.timeout) as resp:
+                payload = resp.read()
+        except urllib.error.HTTPError as e:
+            raise _api_error(e) from None
+        if raw:
+            return payload
+        if not payload:
+            return {}
+        return json.loads(payload)
+
+    # -- endpoints with client-side defaults ----------------------------
+
+    def send_message(self, agent_id, message=None, template=None, params=None,
+                     required_features=None, budget=None, idempotency_key=None,
+                     interactive=False, profile=None):
+        """POST /api/agents/:id/message; returns the 202 body with job_id.
+
+        interactive=True schedules the job on the reserved chat lane.
+        """
+        return super().send_message(
+            agent_id, message=message or "", template=template or None,
+            params=(params or {}) if template else None,
+            required_features=list(required_features) if required_features else None,
+            budget=budget or None, interactive=True if interactive else None,
+            profile=profile or None, idempotency_key=idempotency_key)
+
+    def wait_job(self, job_id, timeout=30):
+        """GET /api/jobs/:id/wait: long-poll until terminal or timeout seconds.
+
+        The result carries ``terminal`` and ``timed_out``.
+        """
+        seconds = max(0, min(int(timeout), _MAX_WAIT_SECONDS))
+        return super().wait_job(job_id, timeout="%ds" % seconds,
+                                _request_timeout=seconds + self.timeout)
+
+    def list_events(self, job_id):
+        """GET /api/jobs/:id/events; returns the event list."""
+        return super().list_events(job_id).get("events") or []
+
+    def signal(self, job_id, correlation_key=None, payload=None):
+        """POST /api/jobs/:id/signal.
+
+        When correlation_key is omitted it is read from the job's current
+        wait (``wait_correlation_key`` of GET /api/jobs/:id).
+        """
+        if correlation_key is None:
+            job = self.get_job(job_id)
+            correlation_key = job.get("wait_correlation_key")
+            if not correlation_key:
+                raise APIError(400, "job %s is not waiting" % job_id, job)
+        return super().signal(job_id, correlation_key, payload=payload or {})
+
+    # -- helpers ---------------------------------------------------------
+
+    def wait(self, job_id, timeout=600.0, poll=60):
+        """Block until the job is terminal; raises WaitTimeout after timeout seconds."""
+        deadline = time.monotonic() + timeout
+        while True:
+            left = deadline - time.monotonic()
+            job = self.wait_job(job_id, timeout=max(1, min(poll, left)))
+            if job.get("terminal") or job.get("status") in TERMINAL_STATUSES:
+                return job
+            if time.monotonic() >= deadline:
+                raise WaitTimeout(job)
+
+    def submit_and_wait(self, agent_id, message=None, timeout=600.0, raise_on_failure=True,
+                        **kwargs):
+        """Send a message, wait for the created job and return its final detail.
+
+        Raises JobFailed when the job fails or is cancelled (unless
+        raise_on_failure is False) and WaitTimeout when it does not finish
+        within timeout seconds. Extra kwargs go to send_message.
+        """
+        accepted = self.send_message(agent_id, message, **kwargs)
+        job_id = accepted.get("job_id")
+        if not job_id:
+            raise APIError(202, "no job_id in response (job store disabled?)", accepted)
+        job = self.wait(job_id, timeout=timeout)
+        if raise_on_failure and job.get("status") != "completed":
+            raise JobFailed(job)
+        return job
+
+    def stream_events(self, job_id, poll_interval=1.0, stop_on_terminal=True):
+        """Yield job events in order as they are appended.
+
+        Polls GET /api/jobs/:id/events and yields events not seen yet; when
+        stop_on_terminal is set the generator ends once the job is terminal
+        and its final events have been yielded.
+        """
+        seen = 0
+        while True:
+            terminal = False
+            if stop_on_terminal:
+                terminal = self.get_job(job_id).get("status") in TERMINAL_STATUSES
+            events = self.list_events(job_id)
+            for event in events[seen:]:
+                yield event
+            seen = max(seen, len(events))
+            if terminal:
+                return
+            time.sleep(poll_interval)
+
+    def export_evidence(self, job_id, path=None, regenerate=False):
+        """Download the job's evidence package (ZIP) and return its bytes.
+
+        Uses the stored package (GET /evidence) when the server has evidence
+        storage, otherwise builds it on the fly (POST /export). When path is
+        given the package is also written there.
+        """
+        try:
+            ref = self.get_evidence(job_id, regenerate=regenerate or None)
+            with urllib.request.urlopen(ref["url"], timeout=self.timeout) as resp:
+                data = resp.read()
+        except APIError as e:
+            if e.status != 503:
+                raise
+            data = self.export_forensics(job_id)
+        if path:
+            with open(path, "wb") as f:
+                f.write(data)
+        return data
+
+
+def _api_error(e):
+    raw = e.read()
+    body = None
+    message = e.reason
+    try:
+        body = json.loads(raw)
+        if isinstance(body, dict) and body.get("error"):
+            message = body["error"]
+    except (ValueError, TypeError):
+        if raw:
+            message = raw.decode("utf-8", "replace")
+    return APIError(e.code, str(message), body if isinstance(body, dict) else None)
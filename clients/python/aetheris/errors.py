@@ -0,0 +1,49 @@
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Errors raised by the Aetheris client."""
+
+
+class AetherisError(Exception):
+    """Base class for client errors."""
+
+
+class APIError(AetherisError):
+    """The API answered with a non-2xx status.
+
+    ``message`` is the server's ``error`` field (localized by Accept-Language);
+    ``body`` is the decoded JSON body when there is one.
+    """
+
+    def __init__(self, status, message, body=None):
+        super().__init__("HTTP %d: %s" % (status, message))
+        self.status = status
+        self.message = message
+        self.body = body or {}
+
+
+class JobFailed(AetherisError):
+    """submit_and_wait: the job ended as failed or cancelled."""
+
+    def __init__(self, job):
+        super().__init__("job %s %s" % (job.get("id"), job.get("status")))
+        self.job = job
+
+
+class WaitTimeout(AetherisError):
+    """submit_and_wait / wait: the job did not reach a terminal state in time."""
+
+    def __init__(self, job):
+        super().__init__("job %s still %s" % (job.get("id"), job.get("status")))
+        self.job = job
@@ -0,0 +1,58 @@
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Submit a goal to an agent, print its events, then save the evidence package.
+
+    AETHERIS_URL=http://localhost:8080 AETHERIS_TOKEN=... \
+        python examples/submit_and_wait.py <agent_id> "summarize last week's incidents"
+"""
+
+import os
+import sys
+
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), ".."))
+
+from aetheris import Client, JobFailed  # noqa: E402
+
+
+def run(client, agent_id, goal, evidence_dir=None, poll_interval=1.0):
+    accepted = client.send_message(agent_id, goal)
+    job_id = accepted["job_id"]
+    print("job", job_id)
+    for event in client.stream_events(job_id, poll_interval=poll_interval):
+        print(" ", event["type"])
+    job = client.get_job(job_id)
+    if evidence_dir:
+        evidence_path = os.path.join(evidence_dir, "evidence-%s.zip" % job_id)
+        client.export_evidence(job_id, path=evidence_path)
+        print("evidence written to", evidence_path)
+    if job["status"] != "completed":
+        raise JobFailed(job)
+    return job
+
+
+def main():
+    if len(sys.argv) < 3:
+        print(__doc__)
+        return 2
+    client = Client(os.environ.get("AETHERIS_URL", "http://localhost:8080"),
+                    token=os.environ.get("AETHERIS_TOKEN"),
+                    tenant_id=os.environ.get("AETHERIS_TENANT"))
+    job = run(client, sys.argv[1], sys.argv[2], evidence_dir=".")
+    print("status", job["status"])
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
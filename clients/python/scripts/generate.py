@@ -0,0 +1,165 @@
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Generate aetheris/_generated.py from the OpenAPI spec (docs/openapi.json).
+
+Each operation becomes a method named after its operationId: path
+parameters are positional, required body fields follow, and optional
+body fields, query parameters and headers are keyword arguments. Run
+with --check to fail when the committed module is out of date (CI).
+Only the standard library is used.
+
+    python clients/python/scripts/generate.py [--check]
+"""
+
+import json
+import os
+import re
+import sys
+
+ROOT = os.path.join(os.path.dirname(os.path.abspath(__file__)), "..")
+SPEC = os.path.join(ROOT, "..", "..", "docs", "openapi.json")
+OUTPUT = os.path.join(ROOT, "aetheris", "_generated.py")
+METHODS = ("get", "post", "put", "patch", "delete")
+
+
+def _license():
+    with open(os.path.abspath(__file__), encoding="utf-8") as f:
+        lines = []
+        for line in f:
+            if not line.startswith("#"):
+                break
+            lines.append(line)
+    return "".join(lines)
+
+
+def _ident(name):
+    return re.sub(r"[^0-9a-zA-Z]+", "_", name).strip("_").lower()
+
+
+def _resolve(spec, schema):
+    ref = schema.get("$ref")
+    if not ref:
+        return schema
+    node = spec
+    for part in ref.lstrip("#/").split("/"):
+        node = node[part]
+    return node
+
+
+def _operation(spec, path, method, op):
+    params = op.get("parameters", [])
+    path_params = [p["name"] for p in params if p["in"] == "path"]
+    query = [p["name"] for p in params if p["in"] == "query"]
+    headers = [p["name"] for p in params if p["in"] == "header"]
+    body_fields, required_fields, free_body = [], [], False
+    rb = op.get("requestBody")
+    if rb:
+        schema = _resolve(spec, rb["content"]["application/json"]["schema"])
+        if schema.get("properties"):
+            body_fields = list(schema["properties"])
+            required_fields = [f for f in body_fields if f in schema.get("required", [])]
+        else:
+            free_body = True
+    raw = any("application/json" not in r.get("content", {"application/json": None})
+              for code, r in op.get("responses", {}).items() if code.startswith("2"))
+
+    args = ["self"] + path_params + required_fields
+    args += ["%s=None" % f for f in body_fields if f not in required_fields]
+    if free_body:
+        args.append("body=None")
+    args += ["%s=None" % _ident(q) for q in query]
+    args += ["%s=None" % _ident(h) for h in headers]
+    args.append("_request_timeout=None")
+
+    fmt_path = re.sub(r"\{[^}]+\}", "%s", path)
+    lines = ['    def %s(%s):' % (op["operationId"], ", ".join(args)),
+             '        """%s %s: %s."""' % (method.upper(), path, op.get("summary", "").rstrip("."))]
+    if path_params:
+        lines.append('        path = "%s" %% (%s,)' % (fmt_path, ", ".join("_q(%s)" % p for p in path_params)))
+    else:
+        lines.append('        path = "%s"' % path)
+    call = ['"%s"' % method.upper(), "path"]
+    if body_fields:
+        lines.append("        body = _compact({%s})" % ", ".join('"%s": %s' % (f, f) for f in body_fields))
+        if not rb.get("required"):
+            lines.append("        body = body or None")
+    if body_fields or free_body:
+        call.append("body=body")
+    if query:
+        call.append("query={%s}" % ", ".join('"%s": _fmt(%s)' % (q, _ident(q)) for q in query))
+    if headers:
+        call.append("headers=_compact({%s}) or None" % ", ".join('"%s": %s' % (h, _ident(h)) for h in headers))
+    if raw:
+        call.append("raw=True")
+    call.append("timeout=_request_timeout")
+    lines.append("        return self.request(%s)" % ", ".join(call))
+    return "\n".join(lines)
+
+
+def render(spec):
+    ops = []
+    for path, item in spec["paths"].items():
+        for method in METHODS:
+            if method in item:
+                ops.append(_operation(spec, path, method, item[method]))
+    return _license() + '''
+# Code generated by scripts/generate.py from docs/openapi.json. DO NOT EDIT.
+
+"""Endpoint methods generated from the Aetheris OpenAPI spec (%s %s)."""
+
+import urllib.parse
+
+
+class GeneratedClient:
+    """One method per API operation; subclasses implement request()."""
+
+    def request(self, method, path, body=None, query=None, headers=None, raw=False,
+                timeout=None):
+        raise NotImplementedError
+
+%s
+
+
+def _q(segment):
+    return urllib.parse.quote(str(segment), safe="")
+
+
+def _fmt(value):
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    return value
+
+
+def _compact(d):
+    return {k: v for k, v in d.items() if v is not None}
+''' % (spec["info"]["title"], spec["info"]["version"], "\n\n".join(ops))
+
+
+def main(argv):
+    with open(SPEC, encoding="utf-8") as f:
+        out = render(json.load(f))
+    if "--check" in argv:
+        with open(OUTPUT, encoding="utf-8") as f:
+            if f.read() != out:
+                sys.stderr.write("%s is out of date; run scripts/generate.py\n" % OUTPUT)
+                return 1
+        return 0
+    with open(OUTPUT, "w", encoding="utf-8") as f:
+        f.write(out)
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main(sys.argv[1:]))
@@ -0,0 +1,194 @@
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Client tests against an in-process stub of the Aetheris API."""
+
+import contextlib
+import io
+import json
+import os
+import sys
+import tempfile
+import threading
+import unittest
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), ".."))
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "examples"))
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "scripts"))
+
+import generate  # noqa: E402
+import submit_and_wait  # noqa: E402
+from aetheris import APIError, Client, JobFailed  # noqa: E402
+
+
+class StubAPI:
+    """Minimal job lifecycle: created pending, completes after N polls."""
+
+    def __init__(self, polls_to_finish=2, final_status="completed", evidence_storage=False):
+        self.polls_to_finish = polls_to_finish
+        self.final_status = final_status
+        self.evidence_storage = evidence_storage
+        self.polls = 0
+        self.requests = []
+        self.events = [{"id": "e1", "type": "job_created"}, {"id": "e2", "type": "plan_generated"}]
+
+    def status(self):
+        return self.final_status if self.polls >= self.polls_to_finish else "waiting"
+
+    def job(self):
+        j = {"id": "job-1", "agent_id": "a1", "status": self.status()}
+        if j["status"] == "waiting":
+            j["wait_correlation_key"] = "ck-1"
+        return j
+
+    def handle(self, method, path, headers, body):
+        self.requests.append((method, path, headers, body))
+        if method == "POST" and path == "/api/agents/a1/message":
+            if not body.get("message"):
+                return 400, {"error": "Invalid request"}
+            return 202, {"status": "accepted", "agent_id": "a1", "job_id": "job-1"}
+        if method == "GET" and path.startswith("/api/jobs/job-1/wait"):
+            self.polls += 1
+            if self.polls == self.polls_to_finish:
+                self.events.append({"id": "e3", "type": "job_" + self.final_status})
+            j = self.job()
+            j["terminal"] = j["status"] != "waiting"
+            j["timed_out"] = not j["terminal"]
+            return 200, j
+        if method == "GET" and path == "/api/jobs/job-1":
+            self.polls += 1
+            if self.polls == self.polls_to_finish:
+                self.events.append({"id": "e3", "type": "job_" + self.final_status})
+            return 200, self.job()
+        if method == "GET" and path == "/api/jobs/job-1/events":
+            return 200, {"job_id": "job-1", "events": list(self.events)}
+        if method == "POST" and path == "/api/jobs/job-1/signal":
+            if body.get("correlation_key") != "ck-1":
+                return 400, {"error": "correlation_key mismatch"}
+            return 200, {"job_id": "job-1", "status": "pending"}
+        if method == "GET" and path.startswith("/api/jobs/job-1/evidence"):
+            if not self.evidence_storage:
+                return 503, {"error": "Evidence storage is not configured"}
+            return 200, {"job_id": "job-1", "url": self.base_url + "/blob/evidence.zip"}
+        if method == "GET" and path == "/blob/evidence.zip":
+            return 200, b"PK-stored"
+        if method == "POST" and path == "/api/jobs/job-1/export":
+            return 200, b"PK-export"
+        return 404, {"error": "Not found"}
+
+
+def serve(stub):
+    class Handler(BaseHTTPRequestHandler):
+        def _dispatch(self, method):
+            length = int(self.headers.get("Content-Length") or 0)
+            raw = self.rfile.read(length) if length else b""
+            body = json.loads(raw) if raw else None
+            status, out = stub.handle(method, self.path, self.headers, body or {})
+            data = out if isinstance(out, bytes) else json.dumps(out).encode()
+            self.send_response(status)
+            self.send_header("Content-Length", str(len(data)))
+            self.end_headers()
+            self.wfile.write(data)
+
+        def do_GET(self):
+            self._dispatch("GET")
+
+        def do_POST(self):
+            self._dispatch("POST")
+
+        def log_message(self, *args):
+            pass
+
+    server = ThreadingHTTPServer(("127.0.0.1", 0), Handler)
+    stub.base_url = "http://127.0.0.1:%d" % server.server_address[1]
+    threading.Thread(target=server.serve_forever, daemon=True).start()
+    return server
+
+
+class ClientTest(unittest.TestCase):
+    def start(self, stub):
+        server = serve(stub)
+        self.addCleanup(server.server_close)
+        self.addCleanup(server.shutdown)
+        return Client(stub.base_url, token="tok", tenant_id="t1", locale="zh")
+
+    def test_submit_and_wait(self):
+        stub = StubAPI()
+        client = self.start(stub)
+        job = client.submit_and_wait("a1", "summarize", idempotency_key="k1", timeout=10)
+        self.assertEqual(job["status"], "completed")
+        method, path, headers, body = stub.requests[0]
+        self.assertEqual(body, {"message": "summarize"})
+        self.assertEqual(headers.get("Authorization"), "Bearer tok")
+        self.assertEqual(headers.get("X-Tenant-ID"), "t1")
+        self.assertEqual(headers.get("Accept-Language"), "zh")
+        self.assertEqual(headers.get("Idempotency-Key"), "k1")
+        self.assertIn("timeout=", stub.requests[1][1])
+
+    def test_submit_and_wait_failed(self):
+        client = self.start(StubAPI(polls_to_finish=1, final_status="failed"))
+        with self.assertRaises(JobFailed) as ctx:
+            client.submit_and_wait("a1", "x", timeout=10)
+        self.assertEqual(ctx.exception.job["status"], "failed")
+        job = client.submit_and_wait("a1", "x", timeout=10, raise_on_failure=False)
+        self.assertEqual(job["status"], "failed")
+
+    def test_send_message_interactive(self):
+        stub = StubAPI()
+        client = self.start(stub)
+        client.send_message("a1", "hi", interactive=True)
+        self.assertEqual(stub.requests[0][3], {"message": "hi", "interactive": True})
+
+    def test_api_error(self):
+        client = self.start(StubAPI())
+        with self.assertRaises(APIError) as ctx:
+            client.send_message("a1", "")
+        self.assertEqual(ctx.exception.status, 400)
+        self.assertEqual(ctx.exception.message, "Invalid request")
+
+    def test_stream_events_until_terminal(self):
+        client = self.start(StubAPI(polls_to_finish=3))
+        types = [e["type"] for e in client.stream_events("job-1", poll_interval=0)]
+        self.assertEqual(types, ["job_created", "plan_generated", "job_completed"])
+
+    def test_signal_uses_current_wait(self):
+        stub = StubAPI(polls_to_finish=5)
+        client = self.start(stub)
+        client.signal("job-1", payload={"approved": True})
+        self.assertEqual(stub.requests[-1][3], {"correlation_key": "ck-1", "payload": {"approved": True}})
+
+    def test_export_evidence(self):
+        client = self.start(StubAPI())
+        self.assertEqual(client.export_evidence("job-1"), b"PK-export")
+        client = self.start(StubAPI(evidence_storage=True))
+        self.assertEqual(client.export_evidence("job-1"), b"PK-stored")
+
+    def test_example_submit_and_wait(self):
+        client = self.start(StubAPI(polls_to_finish=3))
+        with tempfile.TemporaryDirectory() as d, contextlib.redirect_stdout(io.StringIO()) as out:
+            job = submit_and_wait.run(client, "a1", "summarize", evidence_dir=d, poll_interval=0)
+            self.assertTrue(os.path.exists(os.path.join(d, "evidence-job-1.zip")))
+        self.assertEqual(job["status"], "completed")
+        self.assertIn("job_completed", out.getvalue())
+
+
+class GeneratedTest(unittest.TestCase):
+    def test_generated_module_up_to_date(self):
+        self.assertEqual(generate.main(["--check"]), 0,
+                         "run clients/python/scripts/generate.py after editing docs/openapi.json")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -18,10 +18,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/goaltemplate"
+	"rag-platform/internal/agent/workerinspect"
 )
 
 func apiBaseURL() string {
@@ -45,10 +51,14 @@ func newClient() *resty.Client {
 	c := resty.New().
 		SetBaseURL(apiBaseURL()).
 		SetTimeout(30*time.Second).
-		SetHeader("Content-Type", "application/json")
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-Aetheris-Client", "cli")
 	if t := tenantID(); t != "" {
 		c.SetHeader("X-Tenant-ID", t)
 	}
+	if cliLocale != "" {
+		c.SetHeader("Accept-Language", cliLocale)
+	}
 	return c
 }
 
@@ -66,6 +76,24 @@ func getJob(jobID string) (map[string]interface{}, error) {
 	return out, nil
 }
 
+// waitJob 长轮询 GET /api/jobs/:id/wait：Job 进入终态或 timeout 到期时返回 Job 详情（含 terminal / timed_out）
+func waitJob(jobID string, timeout time.Duration) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	resp, err := newClient().
+		SetTimeout(timeout+10*time.Second).
+		R().
+		SetQueryParam("timeout", timeout.String()).
+		SetResult(&out).
+		Get("/api/jobs/" + jobID + "/wait")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET /api/jobs/%s/wait: %s", jobID, resp.String())
+	}
+	return out, nil
+}
+
 func getJobTrace(jobID string) (map[string]interface{}, error) {
 	var out map[string]interface{}
 	resp, err := newClient().R().
@@ -117,6 +145,41 @@ func createAgent(name string) (string, error) {
 	return out.ID, nil
 }
 
+func exportAgent(agentID string) ([]byte, error) {
+	resp, err := newClient().R().
+		Get("/api/agents/" + agentID + "/export")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET /api/agents/%s/export: %s", agentID, resp.String())
+	}
+	return resp.Body(), nil
+}
+
+// importAgent 提交导入请求；409（冲突）时同样返回响应体中的冲突明细
+func importAgent(bundleJSON []byte, targetAgentID, name, onConflict string) (map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"bundle":          json.RawMessage(bundleJSON),
+		"target_agent_id": targetAgentID,
+		"name":            name,
+		"on_conflict":     onConflict,
+	}
+	var out map[string]interface{}
+	resp, err := newClient().R().
+		SetBody(body).
+		SetResult(&out).
+		SetError(&out).
+		Post("/api/agents/import")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return out, fmt.Errorf("POST /api/agents/import: HTTP %d", resp.StatusCode())
+	}
+	return out, nil
+}
+
 func postMessage(agentID, message string) (jobID string, err error) {
 	body := map[string]string{"message": message}
 	var out struct {
@@ -135,6 +198,82 @@ func postMessage(agentID, message string) (jobID string, err error) {
 	return out.JobID, nil
 }
 
+// previewPlan 仅规划不创建 Job，返回任务图、预估与历史对比
+func previewPlan(agentID, goal string) (*planPreview, error) {
+	var out planPreview
+	resp, err := newClient().R().
+		SetBody(map[string]string{"message": goal}).
+		SetResult(&out).
+		Post("/api/agents/" + agentID + "/plan/preview")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("POST plan/preview: %s", resp.String())
+	}
+	return &out, nil
+}
+
+// listGoalTemplates 列出 Agent 的目标模板（含参数 schema）
+func listGoalTemplates(agentID string) ([]*goaltemplate.Template, error) {
+	var out struct {
+		Templates []*goaltemplate.Template `json:"templates"`
+	}
+	resp, err := newClient().R().
+		SetResult(&out).
+		Get("/api/agents/" + agentID + "/templates")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET /api/agents/%s/templates: %s", agentID, resp.String())
+	}
+	return out.Templates, nil
+}
+
+// renderGoalTemplate 由服务端校验参数并渲染目标；校验失败时返回逐参数错误（err 为 nil）
+func renderGoalTemplate(agentID, name string, params map[string]any) (goal string, fields []goaltemplate.FieldError, err error) {
+	var out struct {
+		Goal   string                    `json:"goal"`
+		Error  string                    `json:"error"`
+		Fields []goaltemplate.FieldError `json:"fields"`
+	}
+	resp, err := newClient().R().
+		SetBody(map[string]interface{}{"params": params}).
+		SetResult(&out).
+		SetError(&out).
+		Post("/api/agents/" + agentID + "/templates/" + name + "/render")
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.StatusCode() == http.StatusBadRequest && len(out.Fields) > 0 {
+		return "", out.Fields, nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", nil, fmt.Errorf("POST /api/agents/%s/templates/%s/render: %s", agentID, name, resp.String())
+	}
+	return out.Goal, nil, nil
+}
+
+// postTemplateMessage 以目标模板 + 参数提交消息，服务端渲染目标并创建 Job
+func postTemplateMessage(agentID, name string, params map[string]any) (jobID string, err error) {
+	body := map[string]interface{}{"template": name, "params": params}
+	var out struct {
+		JobID string `json:"job_id"`
+	}
+	resp, err := newClient().R().
+		SetBody(body).
+		SetResult(&out).
+		Post("/api/agents/" + agentID + "/message")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode() != http.StatusAccepted && resp.StatusCode() != http.StatusOK {
+		return "", fmt.Errorf("POST message: %s", resp.String())
+	}
+	return out.JobID, nil
+}
+
 func listTools() ([]map[string]interface{}, error) {
 	var out struct {
 		Tools []map[string]interface{} `json:"tools"`
@@ -199,6 +338,27 @@ func listWorkers() ([]string, error) {
 	return out.Workers, nil
 }
 
+// getWorkerInspect 经 API 代理获取 Worker 自省快照；addr 非空时直连 Worker 自省端点（令牌取 AETHERIS_WORKER_INSPECT_TOKEN）
+func getWorkerInspect(workerID, addr string) (*workerinspect.Snapshot, error) {
+	var out workerinspect.Snapshot
+	req := newClient().SetTimeout(10 * time.Second).R().SetResult(&out)
+	path := "/api/system/workers/" + url.PathEscape(workerID) + "/inspect"
+	if addr != "" {
+		path = addr
+		if t := os.Getenv("AETHERIS_WORKER_INSPECT_TOKEN"); t != "" {
+			req.SetAuthToken(t)
+		}
+	}
+	resp, err := req.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", path, resp.String())
+	}
+	return &out, nil
+}
+
 func getObservabilitySummary() (map[string]interface{}, error) {
 	var out map[string]interface{}
 	resp, err := newClient().R().
@@ -231,3 +391,104 @@ func prettyJSON(v interface{}) string {
 	b, _ := json.MarshalIndent(v, "", "  ")
 	return string(b)
 }
+
+func migrateTenantDocuments(tenant, toRegion string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	resp, err := newClient().SetTimeout(10 * time.Minute).R().
+		SetBody(map[string]string{"tenant_id": tenant, "to_region": toRegion}).
+		SetResult(&out).
+		Post("/api/admin/residency/migrate")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("POST residency/migrate: %s", resp.String())
+	}
+	return out, nil
+}
+
+// getEvalSuite 获取 Agent 的回归集
+func getEvalSuite(agentID string) (*evalsuite.Suite, error) {
+	var out evalsuite.Suite
+	resp, err := newClient().R().SetResult(&out).Get("/api/agents/" + agentID + "/eval/suite")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET /api/agents/%s/eval/suite: %s", agentID, resp.String())
+	}
+	return &out, nil
+}
+
+// putEvalSuite 上传回归集 JSON（cases、case_timeout），整体替换
+func putEvalSuite(agentID string, suiteJSON []byte) (*evalsuite.Suite, error) {
+	var out evalsuite.Suite
+	resp, err := newClient().R().SetBody(suiteJSON).SetResult(&out).Put("/api/agents/" + agentID + "/eval/suite")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("PUT /api/agents/%s/eval/suite: %s", agentID, resp.String())
+	}
+	return &out, nil
+}
+
+// assertJob 对已结束的 Job 评估轨迹断言（body 为 {"assertions": [...]}）
+func assertJob(jobID string, body []byte) (*evalsuite.TraceReport, error) {
+	var out evalsuite.TraceReport
+	resp, err := newClient().R().SetBody(body).SetResult(&out).Post("/api/jobs/" + jobID + "/assert")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("POST /api/jobs/%s/assert: %s", jobID, resp.String())
+	}
+	return &out, nil
+}
+
+// startEvalRun 启动回归集运行，返回运行中的报告
+func startEvalRun(agentID, label string) (*evalsuite.Report, error) {
+	var out evalsuite.Report
+	resp, err := newClient().R().
+		SetBody(map[string]string{"label": label}).
+		SetResult(&out).
+		Post("/api/agents/" + agentID + "/eval/runs")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusAccepted {
+		return nil, fmt.Errorf("POST /api/agents/%s/eval/runs: %s", agentID, resp.String())
+	}
+	return &out, nil
+}
+
+// getEvalRun 获取单次运行报告
+func getEvalRun(agentID, runID string) (*evalsuite.Report, error) {
+	var out evalsuite.Report
+	resp, err := newClient().R().SetResult(&out).Get("/api/agents/" + agentID + "/eval/runs/" + runID)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET /api/agents/%s/eval/runs/%s: %s", agentID, runID, resp.String())
+	}
+	return &out, nil
+}
+
+// listEvalRuns 列出 Agent 回归集运行历史（新到旧）
+func listEvalRuns(agentID string, limit int) ([]*evalsuite.Report, error) {
+	var out struct {
+		Runs []*evalsuite.Report `json:"runs"`
+	}
+	resp, err := newClient().R().
+		SetQueryParam("limit", strconv.Itoa(limit)).
+		SetResult(&out).
+		Get("/api/agents/" + agentID + "/eval/runs")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("GET /api/agents/%s/eval/runs: %s", agentID, resp.String())
+	}
+	return out.Runs, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"rag-platform/pkg/config"
+)
+
+const configValidateUsage = "aetheris config validate [--config configs/api.yaml] [--component api|worker] [--strict] [--json]"
+
+// probeTimeout 单个连通性探测的超时
+const probeTimeout = 5 * time.Second
+
+// runConfigValidate 校验配置文件：结构（未知字段、类型）、时长格式与交叉约束，问题带 YAML 路径与行号；
+// --strict 另外探测 Postgres 与向量存储的连通性。存在 error 时退出码为 1
+func runConfigValidate(args []string) {
+	cfgPath, component := "", ""
+	strict, asJSON := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config", "--component":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", configValidateUsage))
+				os.Exit(1)
+			}
+			if args[i] == "--config" {
+				cfgPath = args[i+1]
+			} else {
+				component = args[i+1]
+			}
+			i++
+		case "--strict":
+			strict = true
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", configValidateUsage))
+			os.Exit(1)
+		}
+	}
+	if component == "" {
+		component = string(config.ComponentAPI)
+		if strings.HasPrefix(filepath.Base(cfgPath), "worker") {
+			component = string(config.ComponentWorker)
+		}
+	}
+	var cfg *config.Config
+	var err error
+	switch config.Component(component) {
+	case config.ComponentAPI:
+		if cfgPath == "" {
+			cfgPath = "configs/api.yaml"
+			cfg, err = config.LoadAPIConfigWithModel()
+		}
+	case config.ComponentWorker:
+		if cfgPath == "" {
+			cfgPath = "configs/worker.yaml"
+			cfg, err = config.LoadWorkerConfigWithModel()
+		}
+	default:
+		fmt.Fprintln(os.Stderr, tr("cli.usage", configValidateUsage))
+		os.Exit(1)
+	}
+	if cfg == nil && err == nil {
+		cfg, err = config.LoadConfig(cfgPath)
+	}
+
+	var report *config.Report
+	if err != nil {
+		// 无法解码时仍做结构检查，给出类型错误所在行
+		report = &config.Report{}
+		if issues, errCheck := config.CheckFile(cfgPath); errCheck == nil {
+			report.Issues = issues
+		}
+		report.Issues = append(report.Issues, config.Issue{Severity: config.SeverityError, File: cfgPath, Message: err.Error()})
+	} else {
+		report = config.Validate(cfg, config.Component(component))
+		if strict {
+			report.Issues = append(report.Issues, probeConfig(cfg)...)
+			report.Locate(cfg.SourceFiles())
+		}
+	}
+
+	if asJSON {
+		fmt.Println(prettyJSON(report))
+	} else {
+		renderConfigReport(os.Stdout, cfgPath, report)
+	}
+	if report.Err() != nil {
+		os.Exit(1)
+	}
+}
+
+// renderConfigReport 逐条输出问题（error 在前）与汇总
+func renderConfigReport(w io.Writer, cfgPath string, r *config.Report) {
+	errs, warns := r.Errors(), r.Warnings()
+	for _, is := range append(errs, warns...) {
+		fmt.Fprintln(w, "  "+is.String())
+	}
+	if len(errs) > 0 {
+		fmt.Fprintln(w, tr("cli.config.validate_failed", cfgPath, len(errs), len(warns)))
+		return
+	}
+	fmt.Fprintln(w, tr("cli.config.validate_ok", cfgPath, len(warns)))
+}
+
+// probeConfig 连通性探测：已配置的 Postgres DSN 建连并 Ping，非内存向量存储 TCP 拨号；同一目标只探测一次
+func probeConfig(cfg *config.Config) []config.Issue {
+	type target struct{ path, kind, addr string }
+	var targets []target
+	pg := func(path, typ, dsn string) {
+		if typ == "postgres" && dsn != "" {
+			targets = append(targets, target{path, "postgres", dsn})
+		}
+	}
+	pg("jobstore.dsn", cfg.JobStore.Type, cfg.JobStore.DSN)
+	pg("effect_store.dsn", cfg.EffectStore.Type, cfg.EffectStore.DSN)
+	pg("checkpoint_store.dsn", cfg.CheckpointStore.Type, cfg.CheckpointStore.DSN)
+	if cfg.Residency.Enable {
+		for name, region := range cfg.Residency.Regions {
+			pg("residency.regions."+name+".postgres_dsn", "postgres", region.PostgresDSN)
+		}
+	}
+	if v := cfg.Storage.Vector; v.Type != "" && v.Type != "memory" && v.Addr != "" {
+		targets = append(targets, target{"storage.vector.addr", "tcp", v.Addr})
+	}
+
+	results := make(map[string]error)
+	var issues []config.Issue
+	for _, t := range targets {
+		err, seen := results[t.addr]
+		if !seen {
+			ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+			if t.kind == "postgres" {
+				err = probePostgres(ctx, t.addr)
+			} else {
+				var conn net.Conn
+				conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", t.addr)
+				if err == nil {
+					conn.Close()
+				}
+			}
+			cancel()
+			results[t.addr] = err
+		}
+		if err != nil {
+			issues = append(issues, config.Issue{Severity: config.SeverityError, Path: t.path, Message: tr("cli.config.probe_failed", err)})
+		}
+	}
+	return issues
+}
+
+func probePostgres(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	return conn.Ping(ctx)
+}
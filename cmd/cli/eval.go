@@ -0,0 +1,272 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/evalsuite"
+)
+
+const evalUsage = "aetheris eval run <agent_id> [--label L] [--no-wait] [--json] | eval suite <agent_id> [--set suite.json] | eval history <agent_id> [--limit N] | eval assert <job_id> <assertions.json> [--json]"
+
+// evalPollInterval 等待回归集运行结束时的轮询间隔
+const evalPollInterval = 2 * time.Second
+
+// runEval 黄金目标回归集：run 在 AETHERIS_API_URL 指向的环境（如 staging）运行并等待报告，有用例未通过时退出码为 1，便于接入 CI；
+// suite 查看或上传回归集；history 列出历史运行；assert 对已结束的 Job 评估轨迹断言，有断言未通过时退出码为 1
+func runEval(args []string) {
+	if len(args) < 2 || strings.HasPrefix(args[1], "--") {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+		os.Exit(1)
+	}
+	sub, agentID, rest := args[0], args[1], args[2:]
+	switch sub {
+	case "assert":
+		runEvalAssert(agentID, rest)
+	case "run":
+		runEvalRun(agentID, rest)
+	case "suite":
+		runEvalSuite(agentID, rest)
+	case "history":
+		runEvalHistory(agentID, rest)
+	default:
+		fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+		os.Exit(1)
+	}
+}
+
+func runEvalRun(agentID string, args []string) {
+	label, wait, asJSON := "", true, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--label":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+				os.Exit(1)
+			}
+			label = args[i+1]
+			i++
+		case "--no-wait":
+			wait = false
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+			os.Exit(1)
+		}
+	}
+	rep, err := startEvalRun(agentID, label)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.eval.start_failed", err))
+		os.Exit(1)
+	}
+	if !asJSON {
+		fmt.Println(tr("cli.eval.started", rep.ID, rep.Total))
+	}
+	if !wait {
+		if asJSON {
+			fmt.Println(prettyJSON(rep))
+		}
+		return
+	}
+	for rep.Status == evalsuite.ReportRunning {
+		time.Sleep(evalPollInterval)
+		latest, err := getEvalRun(agentID, rep.ID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.eval.fetch_failed", err))
+			os.Exit(1)
+		}
+		rep = latest
+	}
+	if asJSON {
+		fmt.Println(prettyJSON(rep))
+	} else {
+		renderEvalReport(os.Stdout, rep)
+	}
+	if rep.Status != evalsuite.ReportCompleted || rep.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// renderEvalReport 逐用例输出结果与未满足的断言，最后输出汇总与相对上次运行的回归
+func renderEvalReport(w io.Writer, rep *evalsuite.Report) {
+	for _, c := range rep.Cases {
+		mark := "PASS"
+		if !c.Passed {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(w, "  %s %s job=%s cost=%.4f duration=%s\n", mark, c.Name, c.Outcome.JobID, c.Outcome.Cost, msDuration(c.Outcome.DurationMs))
+		for _, f := range c.Failures {
+			fmt.Fprintf(w, "      - %s\n", f)
+		}
+	}
+	fmt.Fprintln(w, tr("cli.eval.summary", rep.Passed, rep.Total, rep.Status))
+	if rep.Error != "" {
+		fmt.Fprintf(w, "  error: %s\n", rep.Error)
+	}
+	if len(rep.Regressions) > 0 {
+		fmt.Fprintln(w, tr("cli.eval.regressions", strings.Join(rep.Regressions, ", ")))
+	}
+}
+
+func runEvalSuite(agentID string, args []string) {
+	if len(args) == 0 {
+		suite, err := getEvalSuite(agentID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.eval.fetch_failed", err))
+			os.Exit(1)
+		}
+		fmt.Println(prettyJSON(suite))
+		return
+	}
+	if len(args) != 2 || args[0] != "--set" {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.eval.read_suite_failed", err))
+		os.Exit(1)
+	}
+	suite, err := putEvalSuite(agentID, data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.eval.save_failed", err))
+		os.Exit(1)
+	}
+	fmt.Println(tr("cli.eval.suite_saved", len(suite.Cases)))
+}
+
+func runEvalHistory(agentID string, args []string) {
+	limit := 20
+	if len(args) == 2 && args[0] == "--limit" {
+		n, err := parsePositiveInt(args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+			os.Exit(1)
+		}
+		limit = n
+	} else if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+		os.Exit(1)
+	}
+	runs, err := listEvalRuns(agentID, limit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.eval.fetch_failed", err))
+		os.Exit(1)
+	}
+	if len(runs) == 0 {
+		fmt.Println(tr("cli.eval.no_runs"))
+		return
+	}
+	for _, r := range runs {
+		line := fmt.Sprintf("%s  %s  %-9s %d/%d", r.StartedAt.Local().Format(time.RFC3339), r.ID, r.Status, r.Passed, r.Total)
+		if r.Label != "" {
+			line += "  label=" + r.Label
+		}
+		if len(r.Regressions) > 0 {
+			line += "  regressions=" + strings.Join(r.Regressions, ",")
+		}
+		fmt.Println(line)
+	}
+}
+
+// runEvalAssert 读取断言文件（{"assertions": [...]} 或断言数组）并调用 POST /api/jobs/:id/assert
+func runEvalAssert(jobID string, args []string) {
+	var file string
+	asJSON := false
+	for _, a := range args {
+		switch {
+		case a == "--json":
+			asJSON = true
+		case file == "" && !strings.HasPrefix(a, "--"):
+			file = a
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+			os.Exit(1)
+		}
+	}
+	if file == "" {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", evalUsage))
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.eval.read_assertions_failed", err))
+		os.Exit(1)
+	}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		data = []byte(`{"assertions":` + trimmed + `}`)
+	}
+	rep, err := assertJob(jobID, data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.eval.assert_failed", err))
+		os.Exit(1)
+	}
+	if asJSON {
+		fmt.Println(prettyJSON(rep))
+	} else {
+		renderTraceReport(os.Stdout, rep)
+	}
+	if !rep.Passed {
+		os.Exit(1)
+	}
+}
+
+// renderTraceReport 逐条输出断言结果与未通过原因，最后输出汇总
+func renderTraceReport(w io.Writer, rep *evalsuite.TraceReport) {
+	for _, r := range rep.Results {
+		mark := "PASS"
+		if !r.Passed {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(w, "  %s #%d %s\n", mark, r.Index+1, traceAssertionLabel(r.Assertion))
+		if r.Message != "" {
+			fmt.Fprintf(w, "      - %s\n", r.Message)
+		}
+	}
+	fmt.Fprintln(w, tr("cli.eval.assert_summary", rep.Total-rep.Failed, rep.Total, rep.JobID, rep.Status))
+}
+
+// traceAssertionLabel 断言类型及其主要参数
+func traceAssertionLabel(a evalsuite.TraceAssertion) string {
+	switch a.Type {
+	case evalsuite.AssertStatus:
+		return a.Type + " " + a.Status
+	case evalsuite.AssertStepCompleted:
+		return a.Type + " " + a.Step
+	case evalsuite.AssertToolCalled:
+		label := a.Type + " " + a.Tool
+		if a.Min != nil {
+			label += fmt.Sprintf(" min=%d", *a.Min)
+		}
+		if a.Max != nil {
+			label += fmt.Sprintf(" max=%d", *a.Max)
+		}
+		return label
+	case evalsuite.AssertMaxCost:
+		return fmt.Sprintf("%s %.4f", a.Type, a.Value)
+	case evalsuite.AssertMaxDuration:
+		return a.Type + " " + a.Duration
+	case evalsuite.AssertAnswerContains:
+		return fmt.Sprintf("%s %q", a.Type, a.Text)
+	default:
+		return a.Type
+	}
+}
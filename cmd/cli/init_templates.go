@@ -0,0 +1,229 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+//go:embed all:templates
+var initTemplatesFS embed.FS
+
+// initTemplate 内置脚手架；base 为 true 时先写入 templates/base（共享的 go.mod 与 API 客户端），再叠加模板自身文件
+type initTemplate struct {
+	name string
+	dir  string
+	key  string // 描述的 i18n key
+	base bool
+}
+
+var initTemplates = []initTemplate{
+	{name: "minimal", dir: "templates/agent-minimal", key: "cli.init.template.minimal"},
+	{name: "rag-agent", dir: "templates/rag-agent", key: "cli.init.template.rag_agent", base: true},
+	{name: "approval-agent", dir: "templates/approval-agent", key: "cli.init.template.approval_agent", base: true},
+	{name: "scheduled-report", dir: "templates/scheduled-report", key: "cli.init.template.scheduled_report", base: true},
+	{name: "multi-agent-pipeline", dir: "templates/multi-agent-pipeline", key: "cli.init.template.multi_agent_pipeline", base: true},
+}
+
+const (
+	defaultInitTemplate = "minimal"
+	initUsage           = "aetheris init [dir] [--template name | git-url[//subdir][#ref]] [--list]"
+	// templateSuffix 以该后缀结尾的文件按 text/template 渲染并去掉后缀写出（.go 源码以 .go.tmpl 保存，避免被本仓库编译）
+	templateSuffix = ".tmpl"
+)
+
+// templateData 渲染 .tmpl 文件时可用的字段
+type templateData struct {
+	Name     string // 项目名（目标目录名）
+	Module   string // go.mod 模块名
+	Template string // 使用的模板
+}
+
+func lookupInitTemplate(name string) (initTemplate, bool) {
+	for _, t := range initTemplates {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return initTemplate{}, false
+}
+
+func runInit(args []string) {
+	dir, name := ".", defaultInitTemplate
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--list":
+			for _, t := range initTemplates {
+				fmt.Printf("  %-22s %s\n", t.name, tr(t.key))
+			}
+			return
+		case a == "--template" && i+1 < len(args):
+			name = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--template="):
+			name = strings.TrimPrefix(a, "--template=")
+		case strings.HasPrefix(a, "-"):
+			fmt.Fprintln(os.Stderr, tr("cli.usage", initUsage))
+			os.Exit(1)
+		default:
+			dir = a
+		}
+	}
+	if err := scaffold(dir, name); err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.init.failed", err))
+		os.Exit(1)
+	}
+	fmt.Println(tr("cli.init.created", dir, name))
+	fmt.Println(tr("cli.init.next"))
+	fmt.Println(tr("cli.init.see_readme"))
+}
+
+// scaffold 将模板 name（内置模板名或远程 git 地址）写入 dir
+func scaffold(dir, name string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data := newTemplateData(dir, name)
+	if isRemoteTemplate(name) {
+		return scaffoldRemote(dir, name, data)
+	}
+	t, ok := lookupInitTemplate(name)
+	if !ok {
+		return fmt.Errorf("unknown template %q (see aetheris init --list)", name)
+	}
+	if t.base {
+		if err := writeTemplateTree(initTemplatesFS, "templates/base", dir, data); err != nil {
+			return err
+		}
+	}
+	return writeTemplateTree(initTemplatesFS, t.dir, dir, data)
+}
+
+var moduleNameInvalid = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+func newTemplateData(dir, name string) templateData {
+	base := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		base = abs
+	}
+	project := filepath.Base(base)
+	module := strings.Trim(moduleNameInvalid.ReplaceAllString(strings.ToLower(project), "-"), "-.")
+	if module == "" {
+		module = "aetheris-agent"
+	}
+	return templateData{Name: project, Module: module, Template: name}
+}
+
+// writeTemplateTree 将 fsys 中 root 下的文件写入 dir；跳过 .git 与 .gitkeep，.tmpl 文件渲染后去掉后缀
+func writeTemplateTree(fsys fs.FS, root, dir string, data templateData) error {
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if d.IsDir() || d.Name() == ".gitkeep" {
+			return nil
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(rel, templateSuffix) {
+			rel = strings.TrimSuffix(rel, templateSuffix)
+			tpl, err := template.New(rel).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			var buf bytes.Buffer
+			if err := tpl.Execute(&buf, data); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			content = buf.Bytes()
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0644)
+	})
+}
+
+// isRemoteTemplate 是否为远程 git 模板（URL 或 scp 形式的 git@host:org/repo）
+func isRemoteTemplate(name string) bool {
+	return strings.Contains(name, "://") || strings.HasPrefix(name, "git@")
+}
+
+// parseRemoteTemplate 解析 repo[//subdir][#ref]，如 https://github.com/acme/templates.git//reporting#v1
+func parseRemoteTemplate(spec string) (repo, subdir, ref string, err error) {
+	if i := strings.LastIndex(spec, "#"); i >= 0 {
+		spec, ref = spec[:i], spec[i+1:]
+	}
+	start := 0
+	if i := strings.Index(spec, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	repo = spec
+	if i := strings.Index(spec[start:], "//"); i >= 0 {
+		repo, subdir = spec[:start+i], strings.Trim(spec[start+i+2:], "/")
+		if subdir == "" || !fs.ValidPath(path.Clean(subdir)) {
+			return "", "", "", fmt.Errorf("invalid template subdir %q", subdir)
+		}
+		subdir = path.Clean(subdir)
+	}
+	return repo, subdir, ref, nil
+}
+
+// scaffoldRemote 浅克隆远程仓库（需本机 git）到临时目录，再按内置模板相同的规则写入 dir
+func scaffoldRemote(dir, spec string, data templateData) error {
+	repo, subdir, ref, err := parseRemoteTemplate(spec)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp("", "aetheris-template-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	cmd := exec.Command("git", append(args, repo, tmp)...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git clone %s: %w", repo, err)
+	}
+	root := "."
+	if subdir != "" {
+		root = subdir
+	}
+	if _, err := fs.Stat(os.DirFS(tmp), root); err != nil {
+		return fmt.Errorf("template subdir %q not found in %s", subdir, repo)
+	}
+	return writeTemplateTree(os.DirFS(tmp), root, dir, data)
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/bundle"
+	"rag-platform/pkg/config"
+)
+
+func TestScaffold_EmbeddedTemplates(t *testing.T) {
+	for _, tpl := range initTemplates {
+		t.Run(tpl.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "My Agent")
+			if err := scaffold(dir, tpl.name); err != nil {
+				t.Fatal(err)
+			}
+			_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if strings.HasSuffix(p, templateSuffix) || d.Name() == ".gitkeep" {
+					t.Errorf("unrendered template file %s", p)
+				}
+				return nil
+			})
+			if _, err := config.LoadConfig(filepath.Join(dir, "configs", "api.yaml")); err != nil {
+				t.Fatalf("configs/api.yaml: %v", err)
+			}
+			if !tpl.base {
+				return
+			}
+			mod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+			if !strings.HasPrefix(string(mod), "module my-agent\n") {
+				t.Fatalf("go.mod = %q", mod)
+			}
+			raw, err := os.ReadFile(filepath.Join(dir, "agent.bundle.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bundle.Bundle
+			if err := json.Unmarshal(raw, &b); err != nil {
+				t.Fatalf("agent.bundle.json: %v", err)
+			}
+			if err := b.Validate(); err != nil {
+				t.Fatalf("agent.bundle.json: %v", err)
+			}
+			if b.Agent.Name != "My Agent" || len(b.Exemplars) == 0 {
+				t.Fatalf("unexpected bundle: %+v", b)
+			}
+			for _, e := range b.Exemplars {
+				nodes := map[string]bool{}
+				for _, n := range e.Graph.Nodes {
+					nodes[n.ID] = true
+				}
+				for _, edge := range e.Graph.Edges {
+					if !nodes[edge.From] || !nodes[edge.To] {
+						t.Fatalf("exemplar %s: edge %+v references an unknown node", e.ID, edge)
+					}
+				}
+			}
+		})
+	}
+	if err := scaffold(t.TempDir(), "no-such-template"); err == nil || !strings.Contains(err.Error(), "--list") {
+		t.Fatalf("unknown template err = %v", err)
+	}
+}
+
+func TestParseRemoteTemplate(t *testing.T) {
+	cases := []struct{ spec, repo, subdir, ref string }{
+		{"https://github.com/acme/templates.git", "https://github.com/acme/templates.git", "", ""},
+		{"https://github.com/acme/templates.git//reporting#v1", "https://github.com/acme/templates.git", "reporting", "v1"},
+		{"git@github.com:acme/templates.git//a/b/#main", "git@github.com:acme/templates.git", "a/b", "main"},
+	}
+	for _, c := range cases {
+		repo, subdir, ref, err := parseRemoteTemplate(c.spec)
+		if err != nil || repo != c.repo || subdir != c.subdir || ref != c.ref {
+			t.Fatalf("parseRemoteTemplate(%q) = %q %q %q %v", c.spec, repo, subdir, ref, err)
+		}
+	}
+	if _, _, _, err := parseRemoteTemplate("https://example.com/repo.git//../etc"); err == nil {
+		t.Fatal("expected an error for a subdir outside the repository")
+	}
+}
+
+func TestScaffold_RemoteGitTemplate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "report"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(repo, "report", "README.md.tmpl"), []byte("# {{.Name}} from {{.Template}}\n"), 0644)
+	_ = os.WriteFile(filepath.Join(repo, "report", "data.txt"), []byte("{{.Name}}"), 0644)
+	for _, args := range [][]string{
+		{"init", "--quiet", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", "template"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "weekly")
+	spec := "file://" + filepath.ToSlash(repo) + "//report#main"
+	if err := scaffold(dir, spec); err != nil {
+		t.Fatal(err)
+	}
+	readme, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	if string(readme) != "# weekly from "+spec+"\n" {
+		t.Fatalf("README.md = %q", readme)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "data.txt"))
+	if string(data) != "{{.Name}}" {
+		t.Fatalf("non-.tmpl files must be copied verbatim, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !os.IsNotExist(err) {
+		t.Fatal(".git must not be copied")
+	}
+	if err := scaffold(t.TempDir(), "file://"+filepath.ToSlash(repo)+"//missing"); err == nil {
+		t.Fatal("expected an error for a missing subdir")
+	}
+}
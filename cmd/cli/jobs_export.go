@@ -0,0 +1,90 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const jobsExportUsage = "aetheris jobs export [--agent X[,Y]] [--since 7d | --from RFC3339 --to RFC3339] [--status s[,s]] [--format csv|json] [--output file]"
+
+// runJobsExport 调用 GET /api/jobs/export，将流式结果原样写到 stdout 或 --output 文件；不在内存中缓存整个导出
+func runJobsExport(args []string) {
+	params := url.Values{}
+	output := ""
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			fmt.Fprintln(os.Stderr, tr("cli.usage", jobsExportUsage))
+			os.Exit(1)
+		}
+		v := args[i+1]
+		switch args[i] {
+		case "--agent":
+			params["agent_ids"] = append(params["agent_ids"], v)
+		case "--since", "--from", "--to", "--status", "--format":
+			params.Set(strings.TrimPrefix(args[i], "--"), v)
+		case "--output":
+			output = v
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", jobsExportUsage))
+			os.Exit(1)
+		}
+		i++
+	}
+	if ids := params["agent_ids"]; len(ids) > 1 {
+		params.Set("agent_ids", strings.Join(ids, ","))
+	}
+	var out io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.write_file_failed", err))
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	n, err := exportJobs(params, out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.jobs.export_failed", err))
+		os.Exit(1)
+	}
+	if output != "" {
+		fmt.Fprintln(os.Stderr, tr("cli.jobs.export_done", output, n))
+	}
+}
+
+// exportJobs 流式复制导出响应到 w，返回写出的字节数；导出可能较久，不设整体超时
+func exportJobs(params url.Values, w io.Writer) (int64, error) {
+	resp, err := newClient().SetTimeout(0).R().
+		SetQueryParamsFromValues(params).
+		SetDoNotParseResponse(true).
+		Get("/api/jobs/export")
+	if err != nil {
+		return 0, err
+	}
+	body := resp.RawBody()
+	defer body.Close()
+	if resp.StatusCode() != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(body, 4096))
+		return 0, fmt.Errorf("GET /api/jobs/export: %d %s", resp.StatusCode(), strings.TrimSpace(string(msg)))
+	}
+	return io.Copy(w, body)
+}
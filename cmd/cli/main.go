@@ -16,27 +16,52 @@ package main
 
 import (
 	"bufio"
-	"embed"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/fs"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"rag-platform/internal/agent/goaltemplate"
 	"rag-platform/pkg/config"
+	"rag-platform/pkg/i18n"
 	"rag-platform/pkg/proof"
 )
 
-//go:embed templates/agent-minimal
-var agentMinimalTemplate embed.FS
-
 // cliTenantID 由 --tenant 或 AETHERIS_TENANT_ID 设置，API 请求时带 X-Tenant-ID
 var cliTenantID string
 
+// cliLocale 为 CLI 输出语言，由 --lang 或 AETHERIS_LANG/LC_ALL/LC_MESSAGES/LANG 决定；同时作为 Accept-Language 发给 API
+var cliLocale string
+
+// tr 按 cliLocale 翻译消息目录中的 key（见 pkg/i18n）
+func tr(key string, args ...interface{}) string {
+	return i18n.Translate(cliLocale, key, args...)
+}
+
+// resolveCLILocale 返回 --lang 指定的语言，未指定时依次读取 AETHERIS_LANG、LC_ALL、LC_MESSAGES、LANG（如 zh_CN.UTF-8）
+func resolveCLILocale(flag string) string {
+	if flag != "" {
+		if l := i18n.Match(flag); l != "" {
+			return l
+		}
+		return i18n.DefaultLocale()
+	}
+	for _, env := range []string{"AETHERIS_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		if l := i18n.Match(v); l != "" {
+			return l
+		}
+	}
+	return i18n.DefaultLocale()
+}
+
 func main() {
 	args := os.Args[1:]
 	// 解析全局 --tenant <id>
@@ -47,6 +72,16 @@ func main() {
 			break
 		}
 	}
+	// 解析全局 --lang <locale>
+	langFlag := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--lang" && i+1 < len(args) {
+			langFlag = args[i+1]
+			args = append(args[:i], args[i+2:]...)
+			break
+		}
+	}
+	cliLocale = resolveCLILocale(langFlag)
 	if len(args) < 1 {
 		printUsage()
 		os.Exit(0)
@@ -59,47 +94,70 @@ func main() {
 	case "health":
 		fmt.Println("ok")
 	case "config":
-		runConfig()
+		if len(args) > 0 && args[0] == "validate" {
+			runConfigValidate(args[1:])
+		} else {
+			runConfig()
+		}
 	case "server":
 		if len(args) > 0 && args[0] == "start" {
 			runServerStart()
 		} else {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris server start\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris server start"))
 			os.Exit(1)
 		}
 	case "worker":
 		if len(args) > 0 && args[0] == "start" {
 			runWorkerStart()
+		} else if len(args) > 0 && args[0] == "inspect" {
+			runWorkerInspect(args[1:])
 		} else {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris worker start\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris worker <start|inspect>"))
 			os.Exit(1)
 		}
 	case "agent":
-		if len(args) > 0 && args[0] == "create" {
+		sub := ""
+		if len(args) > 0 {
+			sub = args[0]
+		}
+		switch sub {
+		case "create":
 			name := ""
 			if len(args) > 1 {
 				name = args[1]
 			}
 			runAgentCreate(name)
-		} else {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris agent create [name]\n")
+		case "export":
+			runAgentExport(args[1:])
+		case "import":
+			runAgentImport(args[1:])
+		case "preview":
+			runAgentPreview(args[1:])
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris agent create [name] | agent export <agent_id> [--output bundle.json] | agent import <bundle.json> [--target agent_id] [--name name] [--on-conflict fail|skip|overwrite] | agent preview <agent_id> <goal> [--json]"))
 			os.Exit(1)
 		}
 	case "chat":
 		runChat(args)
 	case "jobs":
 		runJobs(args)
+	case "eval":
+		runEval(args)
 	case "trace":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris trace <job_id>\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris trace <job_id> | aetheris trace view <evidence.zip>"))
 			os.Exit(1)
 		}
-		runTrace(args[0])
+		if args[0] == "view" {
+			runTraceView(args[1:])
+		} else {
+			runTrace(args[0])
+		}
 	case "workers":
 		runWorkers()
 	case "replay":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris replay <job_id>\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris replay <job_id>"))
 			os.Exit(1)
 		}
 		runReplay(args[0])
@@ -109,13 +167,13 @@ func main() {
 		runMigrate(args)
 	case "cancel":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris cancel <job_id>\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris cancel <job_id>"))
 			os.Exit(1)
 		}
 		runCancel(args[0])
 	case "debug":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris debug <job_id> [--compare-replay]\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris debug <job_id> [--compare-replay]"))
 			os.Exit(1)
 		}
 		compareReplay := false
@@ -125,7 +183,7 @@ func main() {
 		runDebug(args[0], compareReplay)
 	case "verify":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris verify <job_id> | aetheris verify <evidence.zip>\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris verify <job_id> | aetheris verify <evidence.zip>"))
 			os.Exit(1)
 		}
 		if strings.HasSuffix(args[0], ".zip") {
@@ -135,85 +193,63 @@ func main() {
 		}
 	case "export":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "Usage: aetheris export <job_id> [--output evidence.zip]\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris export <job_id> [--output evidence.zip] [--since-snapshot] [--max-payload-bytes N] [--include tool_invocations|failed_steps]"))
 			os.Exit(1)
 		}
 		runExport(args)
 	case "init":
-		dir := "."
-		if len(args) > 0 {
-			dir = args[0]
-		}
-		runInit(dir)
+		runInit(args)
 	default:
 		printUsage()
 		os.Exit(1)
 	}
 }
 
+// usageCommands 为 printUsage 的命令列表；说明文案见 cli.help.* 目录项
+var usageCommands = []struct{ usage, key string }{
+	{"version", "cli.help.version"},
+	{"health", "cli.help.health"},
+	{"config", "cli.help.config"},
+	{"config validate [--config file] [--component api|worker] [--strict] [--json]", "cli.help.config_validate"},
+	{"server start", "cli.help.server_start"},
+	{"worker start", "cli.help.worker_start"},
+	{"worker inspect <worker_id> [--watch] [--interval N] [--addr URL] [--json]", "cli.help.worker_inspect"},
+	{"agent create [name]", "cli.help.agent_create"},
+	{"agent export <agent_id> [--output bundle.json]", "cli.help.agent_export"},
+	{"agent import <bundle.json> [--target agent_id] [--name name] [--on-conflict fail|skip|overwrite]", "cli.help.agent_import"},
+	{"agent preview <agent_id> <goal> [--json]", "cli.help.agent_preview"},
+	{"chat [agent_id] [--template name]", "cli.help.chat"},
+	{"jobs <agent_id>", "cli.help.jobs"},
+	{"jobs export [--agent X] [--since 7d] [--format csv|json] [--output file]", "cli.help.jobs_export"},
+	{"eval run <agent_id> [--label L] [--no-wait] [--json]", "cli.help.eval_run"},
+	{"eval suite <agent_id> [--set suite.json]", "cli.help.eval_suite"},
+	{"eval history <agent_id> [--limit N]", "cli.help.eval_history"},
+	{"eval assert <job_id> <assertions.json> [--json]", "cli.help.eval_assert"},
+	{"trace <job_id>", "cli.help.trace"},
+	{"trace view <evidence.zip> [--output trace.html] [--no-open]", "cli.help.trace_view"},
+	{"workers", "cli.help.workers"},
+	{"replay <job_id>", "cli.help.replay"},
+	{"monitor [--watch] [--interval N]", "cli.help.monitor"},
+	{"migrate <subcommand>", "cli.help.migrate"},
+	{"cancel <job_id>", "cli.help.cancel"},
+	{"debug <job_id> [--compare-replay]", "cli.help.debug"},
+	{"verify <job_id>", "cli.help.verify"},
+	{"verify <evidence.zip>", "cli.help.verify_package"},
+	{"export <job_id> [--output evidence.zip]", "cli.help.export"},
+	{"init [dir] [--template name|git-url] [--list]", "cli.help.init"},
+}
+
 func printUsage() {
-	fmt.Println("Usage: aetheris <command> [args]")
-	fmt.Println("  version         - 显示版本")
-	fmt.Println("  health          - 健康检查")
-	fmt.Println("  config          - 显示配置概要")
-	fmt.Println("  server start    - 启动 API 服务（go run ./cmd/api）")
-	fmt.Println("  worker start    - 启动 Worker 服务（go run ./cmd/worker）")
-	fmt.Println("  agent create [name] - 创建 Agent，返回 agent_id")
-	fmt.Println("  chat [agent_id] - 交互式对话（未传 agent_id 时需环境 AETHERIS_AGENT_ID）")
-	fmt.Println("  jobs <agent_id> - 列出该 Agent 的 Jobs")
-	fmt.Println("  trace <job_id>  - 输出 Job 执行时间线，并打印 Trace 页面 URL")
-	fmt.Println("  workers         - 列出当前活跃 Worker（Postgres 模式）")
-	fmt.Println("  replay <job_id> - 输出 Job 事件流（重放用）")
-	fmt.Println("  monitor [--watch] [--interval N] - 输出运行期可观测性摘要")
-	fmt.Println("  migrate <subcommand> - 迁移辅助命令（如 m1-sql、backfill-hashes）")
-	fmt.Println("  cancel <job_id> - 请求取消执行中的 Job")
-	fmt.Println("  debug <job_id> [--compare-replay] - Agent 调试器：timeline + evidence + replay verification")
-	fmt.Println("  verify <job_id> - 执行验证：输出 execution_hash、event_chain_root、ledger proof、replay proof")
-	fmt.Println("  verify <evidence.zip> - 离线验证证据包完整性")
-	fmt.Println("  export <job_id> [--output evidence.zip] - 导出 Job 证据包（2.0-M1）")
-	fmt.Println("  init [dir]      - Scaffold a minimal agent project (templates + config) into current dir or dir")
-}
-
-func runInit(dir string) {
-	prefix := "templates/agent-minimal"
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "init: mkdir %s: %v\n", dir, err)
-		os.Exit(1)
-	}
-	err := fs.WalkDir(agentMinimalTemplate, prefix, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		rel, _ := filepath.Rel(prefix, path)
-		if rel == "." {
-			return nil
-		}
-		target := filepath.Join(dir, filepath.FromSlash(rel))
-		if d.IsDir() {
-			return os.MkdirAll(target, 0755)
-		}
-		data, err := agentMinimalTemplate.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-			return err
-		}
-		return os.WriteFile(target, data, 0644)
-	})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "init: %v\n", err)
-		os.Exit(1)
+	fmt.Println(tr("cli.help.header"))
+	for _, c := range usageCommands {
+		fmt.Printf("  %-15s - %s\n", c.usage, tr(c.key))
 	}
-	fmt.Println("Created minimal agent project in", dir)
-	fmt.Println("Next: edit configs/api.yaml if needed, then run 'make run' or 'aetheris server start' and 'aetheris worker start'.")
-	fmt.Println("See README in that directory and docs/getting-started-agents.md for a full agent example.")
 }
 
 func runConfig() {
 	cfg, err := config.LoadAPIConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.config.load_failed", err))
 		os.Exit(1)
 	}
 	if cfg != nil {
@@ -250,22 +286,96 @@ func runAgentCreate(name string) {
 	}
 	id, err := createAgent(name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "创建 Agent 失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.agent.create_failed", err))
 		os.Exit(1)
 	}
 	fmt.Println(id)
 }
 
+func runAgentExport(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris agent export <agent_id> [--output bundle.json]"))
+		os.Exit(1)
+	}
+	agentID := args[0]
+	outputPath := fmt.Sprintf("agent-%s.json", agentID)
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--output" && i+1 < len(args) {
+			outputPath = args[i+1]
+			i++
+		}
+	}
+	data, err := exportAgent(agentID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.agent.export_failed", err))
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outputPath, data, 0600); err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.write_file_failed", err))
+		os.Exit(1)
+	}
+	fmt.Println(tr("cli.agent.exported", outputPath))
+	fmt.Println(tr("cli.agent.import_hint", outputPath))
+}
+
+func runAgentImport(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris agent import <bundle.json> [--target agent_id] [--name name] [--on-conflict fail|skip|overwrite]"))
+		os.Exit(1)
+	}
+	var target, name, onConflict string
+	for i := 1; i+1 < len(args); i++ {
+		switch args[i] {
+		case "--target":
+			target = args[i+1]
+			i++
+		case "--name":
+			name = args[i+1]
+			i++
+		case "--on-conflict":
+			onConflict = args[i+1]
+			i++
+		}
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.agent.read_bundle_failed", err))
+		os.Exit(1)
+	}
+	if !json.Valid(data) {
+		fmt.Fprintln(os.Stderr, tr("cli.agent.invalid_bundle", args[0]))
+		os.Exit(1)
+	}
+	out, err := importAgent(data, target, name, onConflict)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.agent.import_failed", err))
+		if out != nil {
+			fmt.Fprintln(os.Stderr, prettyJSON(out))
+		}
+		os.Exit(1)
+	}
+	fmt.Println(prettyJSON(out))
+}
+
 func runChat(args []string) {
 	agentID := os.Getenv("AETHERIS_AGENT_ID")
-	if len(args) > 0 {
-		agentID = args[0]
+	templateName := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--template" && i+1 < len(args) {
+			templateName = args[i+1]
+			i++
+			continue
+		}
+		agentID = args[i]
 	}
 	if agentID == "" {
-		fmt.Fprintf(os.Stderr, "请指定 agent_id: aetheris chat <agent_id> 或设置 AETHERIS_AGENT_ID\n")
+		fmt.Fprintln(os.Stderr, tr("cli.chat.missing_agent_id"))
 		os.Exit(1)
 	}
 	reader := bufio.NewReader(os.Stdin)
+	if templateName != "" {
+		runTemplateChat(reader, agentID, templateName)
+	}
 	for {
 		fmt.Print("> ")
 		line, err := reader.ReadString('\n')
@@ -279,37 +389,156 @@ func runChat(args []string) {
 		if msg == "exit" || msg == "quit" {
 			break
 		}
+		if msg == "/templates" {
+			printGoalTemplates(agentID)
+			continue
+		}
+		if name, ok := strings.CutPrefix(msg, "/use "); ok {
+			runTemplateChat(reader, agentID, strings.TrimSpace(name))
+			continue
+		}
 		jobID, err := postMessage(agentID, msg)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "发送失败: %v\n", err)
+			fmt.Fprintln(os.Stderr, tr("cli.chat.send_failed", err))
 			continue
 		}
-		fmt.Printf("Job: %s (轮询状态中...)\n", jobID)
-		for i := 0; i < 60; i++ {
-			time.Sleep(1 * time.Second)
-			j, err := getJob(jobID)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "查询失败: %v\n", err)
-				break
-			}
-			status, _ := j["status"].(string)
-			fmt.Printf("  status: %s\n", status)
-			if status == "completed" || status == "failed" {
-				break
+		waitChatJob(jobID)
+	}
+}
+
+// waitChatJob 长轮询等待 Job 完成并打印状态
+func waitChatJob(jobID string) {
+	fmt.Println(tr("cli.chat.waiting", jobID))
+	for i := 0; i < 3; i++ {
+		j, err := waitJob(jobID, 20*time.Second)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.chat.query_failed", err))
+			break
+		}
+		status, _ := j["status"].(string)
+		fmt.Printf("  status: %s\n", status)
+		if terminal, _ := j["terminal"].(bool); terminal {
+			break
+		}
+	}
+}
+
+func printGoalTemplates(agentID string) {
+	list, err := listGoalTemplates(agentID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.template.fetch_failed", err))
+		return
+	}
+	if len(list) == 0 {
+		fmt.Println(tr("cli.template.none"))
+		return
+	}
+	for _, t := range list {
+		fmt.Printf("  %s - %s\n", t.Name, t.Description)
+	}
+	fmt.Println(tr("cli.template.use_hint"))
+}
+
+// runTemplateChat 按模板参数 schema 逐项收集参数，经服务端校验（失败时仅重填出错项）后确认提交
+func runTemplateChat(reader *bufio.Reader, agentID, name string) {
+	list, err := listGoalTemplates(agentID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.template.fetch_failed", err))
+		return
+	}
+	var tpl *goaltemplate.Template
+	for _, t := range list {
+		if t.Name == name {
+			tpl = t
+		}
+	}
+	if tpl == nil {
+		fmt.Fprintln(os.Stderr, tr("cli.template.not_found", name))
+		return
+	}
+	params := map[string]any{}
+	var only map[string]bool
+	for {
+		if err := promptTemplateParams(reader, os.Stdout, tpl, params, only); err != nil {
+			return
+		}
+		goal, fields, err := renderGoalTemplate(agentID, name, params)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.template.validate_failed", err))
+			return
+		}
+		if len(fields) > 0 {
+			only = make(map[string]bool, len(fields))
+			for _, f := range fields {
+				fmt.Printf("  ✗ %s: %s\n", f.Param, f.Message)
+				only[f.Param] = true
+				delete(params, f.Param)
 			}
+			continue
+		}
+		fmt.Print(tr("cli.chat.confirm_goal", goal))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
 		}
+		if ans := strings.ToLower(strings.TrimSpace(line)); ans == "n" || ans == "no" {
+			fmt.Println(tr("cli.chat.cancelled"))
+			return
+		}
+		break
+	}
+	jobID, err := postTemplateMessage(agentID, name, params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.chat.send_failed", err))
+		return
 	}
+	waitChatJob(jobID)
+}
+
+// promptTemplateParams 逐项提示输入模板参数写入 params（only 非空时仅提示其中的参数）；空输入表示使用默认值或跳过可选参数
+func promptTemplateParams(reader *bufio.Reader, out io.Writer, t *goaltemplate.Template, params map[string]any, only map[string]bool) error {
+	for i := range t.Params {
+		p := &t.Params[i]
+		if only != nil && !only[p.Name] {
+			continue
+		}
+		prompt := p.DisplayLabel()
+		if p.Description != "" {
+			prompt += " (" + p.Description + ")"
+		}
+		if len(p.Options) > 0 {
+			prompt += " [" + strings.Join(p.Options, "/") + "]"
+		}
+		if p.Default != nil {
+			prompt += " " + tr("cli.chat.param_default", p.Default)
+		} else if p.Required {
+			prompt += " *"
+		}
+		fmt.Fprintf(out, "%s: ", prompt)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		if v := strings.TrimSpace(line); v != "" {
+			params[p.Name] = v
+		}
+	}
+	return nil
 }
 
 func runJobs(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: aetheris jobs <agent_id>\n")
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris jobs <agent_id> | "+jobsExportUsage))
 		os.Exit(1)
 	}
+	if args[0] == "export" {
+		runJobsExport(args[1:])
+		return
+	}
 	agentID := args[0]
 	jobs, err := listAgentJobs(agentID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "列出 Jobs 失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.jobs.list_failed", err))
 		os.Exit(1)
 	}
 	fmt.Println(prettyJSON(jobs))
@@ -318,18 +547,18 @@ func runJobs(args []string) {
 func runTrace(jobID string) {
 	trace, err := getJobTrace(jobID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "获取 Trace 失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.trace.fetch_failed", err))
 		os.Exit(1)
 	}
 	fmt.Println(prettyJSON(trace))
 	fmt.Println()
-	fmt.Println("Trace 页面:", tracePageURL(jobID))
+	fmt.Println(tr("cli.trace.page_url", tracePageURL(jobID)))
 }
 
 func runWorkers() {
 	workers, err := listWorkers()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "列出 Worker 失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.workers.list_failed", err))
 		os.Exit(1)
 	}
 	if len(workers) == 0 {
@@ -342,12 +571,12 @@ func runWorkers() {
 func runReplay(jobID string) {
 	ev, err := getJobEvents(jobID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "获取事件流失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.replay.fetch_failed", err))
 		os.Exit(1)
 	}
 	fmt.Println(prettyJSON(ev))
 	fmt.Println()
-	fmt.Println("Trace 页面:", tracePageURL(jobID))
+	fmt.Println(tr("cli.trace.page_url", tracePageURL(jobID)))
 }
 
 func runMonitor(args []string) {
@@ -359,18 +588,18 @@ func runMonitor(args []string) {
 			watch = true
 		case "--interval":
 			if i+1 >= len(args) {
-				fmt.Fprintf(os.Stderr, "Usage: aetheris monitor [--watch] [--interval N]\n")
+				fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris monitor [--watch] [--interval N]"))
 				os.Exit(1)
 			}
 			n, err := parsePositiveInt(args[i+1])
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "invalid --interval: %v\n", err)
+				fmt.Fprintln(os.Stderr, tr("cli.monitor.invalid_interval", err))
 				os.Exit(1)
 			}
 			intervalSeconds = n
 			i++
 		default:
-			fmt.Fprintf(os.Stderr, "Usage: aetheris monitor [--watch] [--interval N]\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris monitor [--watch] [--interval N]"))
 			os.Exit(1)
 		}
 	}
@@ -378,7 +607,7 @@ func runMonitor(args []string) {
 	printSnapshot := func() {
 		summary, err := getObservabilitySummary()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "获取 observability summary 失败: %v\n", err)
+			fmt.Fprintln(os.Stderr, tr("cli.monitor.fetch_failed", err))
 			os.Exit(1)
 		}
 		workers, err := listWorkers()
@@ -403,7 +632,7 @@ func runMonitor(args []string) {
 
 func runMigrate(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: aetheris migrate <m1-sql|backfill-hashes> [args]\n")
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris migrate <m1-sql|backfill-hashes|tenant-region|compress-events> [args]"))
 		os.Exit(1)
 	}
 	switch args[0] {
@@ -414,8 +643,12 @@ ALTER TABLE job_events ADD COLUMN IF NOT EXISTS hash TEXT DEFAULT '';
 CREATE INDEX IF NOT EXISTS idx_job_events_hash ON job_events (hash);`)
 	case "backfill-hashes":
 		runMigrateBackfillHashes(args[1:])
+	case "tenant-region":
+		runMigrateTenantRegion(args[1:])
+	case "compress-events":
+		runMigrateCompressEvents(args[1:])
 	default:
-		fmt.Fprintf(os.Stderr, "Usage: aetheris migrate <m1-sql|backfill-hashes> [args]\n")
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris migrate <m1-sql|backfill-hashes|tenant-region|compress-events> [args]"))
 		os.Exit(1)
 	}
 }
@@ -427,33 +660,33 @@ func runMigrateBackfillHashes(args []string) {
 		switch args[i] {
 		case "--input":
 			if i+1 >= len(args) {
-				fmt.Fprintf(os.Stderr, "Usage: aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson\n")
+				fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson"))
 				os.Exit(1)
 			}
 			input = args[i+1]
 			i++
 		case "--output":
 			if i+1 >= len(args) {
-				fmt.Fprintf(os.Stderr, "Usage: aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson\n")
+				fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson"))
 				os.Exit(1)
 			}
 			output = args[i+1]
 			i++
 		default:
-			fmt.Fprintf(os.Stderr, "Usage: aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson\n")
+			fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson"))
 			os.Exit(1)
 		}
 	}
 	if input == "" || output == "" {
-		fmt.Fprintf(os.Stderr, "Usage: aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson\n")
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris migrate backfill-hashes --input events.ndjson --output out.ndjson"))
 		os.Exit(1)
 	}
 	count, err := backfillHashesFile(input, output)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "backfill failed: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.backfill_failed", err))
 		os.Exit(1)
 	}
-	fmt.Printf("✓ backfill completed: %d events written to %s\n", count, output)
+	fmt.Println(tr("cli.migrate.backfill_done", count, output))
 }
 
 func backfillHashesFile(inputPath, outputPath string) (int, error) {
@@ -563,7 +796,7 @@ func parsePositiveInt(s string) (int, error) {
 func runCancel(jobID string) {
 	out, err := cancelJob(jobID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "取消失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.cancel.failed", err))
 		os.Exit(1)
 	}
 	fmt.Println(prettyJSON(out))
@@ -573,14 +806,14 @@ func runDebug(jobID string, compareReplay bool) {
 	// Fetch job metadata
 	jobData, err := getJob(jobID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "获取 Job 失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.job.fetch_failed", err))
 		os.Exit(1)
 	}
 
 	// Fetch trace
 	trace, err := getJobTrace(jobID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "获取 Trace 失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.trace.fetch_failed", err))
 		os.Exit(1)
 	}
 
@@ -598,7 +831,7 @@ func runDebug(jobID string, compareReplay bool) {
 	fmt.Println()
 
 	// Execution timeline
-	fmt.Println("=== Execution Timeline ===")
+	fmt.Println(tr("cli.debug.timeline"))
 	if steps, ok := trace["steps"].([]interface{}); ok {
 		for _, stepData := range steps {
 			step, ok := stepData.(map[string]interface{})
@@ -646,7 +879,7 @@ func runDebug(jobID string, compareReplay bool) {
 	fmt.Println()
 
 	// Evidence chain
-	fmt.Println("=== Evidence Chain ===")
+	fmt.Println(tr("cli.debug.evidence"))
 	hasEvidence := false
 	if steps, ok := trace["steps"].([]interface{}); ok {
 		for _, stepData := range steps {
@@ -681,16 +914,16 @@ func runDebug(jobID string, compareReplay bool) {
 		}
 	}
 	if !hasEvidence {
-		fmt.Println("(No evidence recorded)")
+		fmt.Println(tr("cli.debug.no_evidence"))
 	}
 	fmt.Println()
 
 	// Replay verification
 	if compareReplay {
-		fmt.Println("=== Replay Verification ===")
+		fmt.Println(tr("cli.debug.replay"))
 		replayData, err := getJobEvents(jobID)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "获取 Replay 数据失败: %v\n", err)
+			fmt.Fprintln(os.Stderr, tr("cli.debug.replay_fetch_failed", err))
 		} else {
 			completedNodes := 0
 			completedCmds := 0
@@ -706,102 +939,155 @@ func runDebug(jobID string, compareReplay bool) {
 				completedTools = len(tools)
 			}
 
-			fmt.Printf("✓ Completed nodes: %d\n", completedNodes)
-			fmt.Printf("✓ Completed commands: %d\n", completedCmds)
-			fmt.Printf("✓ Completed tool invocations: %d\n", completedTools)
-			fmt.Println("✓ Replay deterministic (results injected, not re-executed)")
-			fmt.Println("✓ LLM NOT re-called (from Effect Store)")
-			fmt.Println("✓ Tools NOT re-executed (from Ledger)")
+			fmt.Println(tr("cli.debug.completed_nodes", completedNodes))
+			fmt.Println(tr("cli.debug.completed_commands", completedCmds))
+			fmt.Println(tr("cli.debug.completed_tools", completedTools))
+			fmt.Println(tr("cli.debug.replay_deterministic"))
+			fmt.Println(tr("cli.debug.llm_not_recalled"))
+			fmt.Println(tr("cli.debug.tools_not_reexecuted"))
 		}
 		fmt.Println()
 	}
 
 	// Summary
-	fmt.Println("=== Debug Summary ===")
-	fmt.Println("✓ Execution history complete")
-	fmt.Println("✓ Evidence traceable")
-	fmt.Println("✓ Audit-ready")
+	fmt.Println(tr("cli.debug.summary"))
+	fmt.Println(tr("cli.debug.history_complete"))
+	fmt.Println(tr("cli.debug.evidence_traceable"))
+	fmt.Println(tr("cli.debug.audit_ready"))
 	fmt.Println()
 	baseURL := os.Getenv("AETHERIS_API_URL")
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
 	}
-	fmt.Printf("Detailed trace: %s/api/jobs/%s/trace\n", baseURL, jobID)
+	fmt.Println(tr("cli.debug.detailed_trace", baseURL, jobID))
 }
 
-// runVerifyJob 对 job_id 调用 GET /api/jobs/:id/verify，输出 execution_hash、event_chain_root、ledger proof、replay proof
+// runVerifyJob 对 job_id 调用 GET /api/jobs/:id/verify，输出保证级别、execution_hash、event_chain_root、ledger proof、replay proof
 func runVerifyJob(jobID string) {
 	v, err := getJobVerify(jobID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "获取验证结果失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.verify.fetch_failed", err))
 		os.Exit(1)
 	}
-	fmt.Printf("=== Verification: %s ===\n\n", jobID)
+	fmt.Println(tr("cli.verify.header", jobID))
+	if level, ok := v["assurance_level"].(string); ok && level != "" {
+		fmt.Println(tr("cli.verify.assurance", level))
+		if att, ok := v["attestation"].(map[string]interface{}); ok {
+			if note, ok := att["note"].(string); ok && note != "" {
+				fmt.Println(tr("cli.verify.attestation_note", note))
+			}
+		}
+	}
 	if h, ok := v["execution_hash"].(string); ok {
-		fmt.Printf("Execution hash:          %s\n", h)
+		fmt.Println(tr("cli.verify.execution_hash", h))
 	}
 	if h, ok := v["event_chain_root_hash"].(string); ok {
-		fmt.Printf("Event chain root hash:   %s\n", h)
+		fmt.Println(tr("cli.verify.chain_root", h))
 	}
 	if ledger, ok := v["tool_invocation_ledger_proof"].(map[string]interface{}); ok {
 		okVal, _ := ledger["ok"].(bool)
-		fmt.Printf("Ledger proof (at-most-once): %v\n", okVal)
+		fmt.Println(tr("cli.verify.ledger_proof", okVal))
 		if keys, ok := ledger["pending_idempotency_keys"].([]interface{}); ok && len(keys) > 0 {
-			fmt.Printf("  Pending keys: %v\n", keys)
+			fmt.Println(tr("cli.verify.pending_keys", keys))
 		}
 	}
 	if replayP, ok := v["replay_proof_result"].(map[string]interface{}); ok {
 		okVal, _ := replayP["ok"].(bool)
-		fmt.Printf("Replay proof (consistent):   %v\n", okVal)
+		fmt.Println(tr("cli.verify.replay_proof", okVal))
 		if errStr, ok := replayP["error"].(string); ok && errStr != "" {
-			fmt.Printf("  Error: %s\n", errStr)
+			fmt.Println(tr("cli.verify.error", errStr))
 		}
 	}
+	printEnvironmentCheck(v["environment"])
 	fmt.Println()
 	fmt.Println(prettyJSON(v))
 }
 
+// printEnvironmentCheck 输出 Job 固化执行环境与当前环境的比对（replay / verify 响应中的 environment 字段）
+func printEnvironmentCheck(raw interface{}) {
+	env, ok := raw.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if pinned, _ := env["pinned"].(bool); !pinned {
+		fmt.Println(tr("cli.verify.environment_unpinned"))
+		return
+	}
+	if match, _ := env["match"].(bool); match {
+		h, _ := env["pinned_hash"].(string)
+		fmt.Println(tr("cli.verify.environment_match", h))
+		return
+	}
+	mismatches, _ := env["mismatches"].([]interface{})
+	fmt.Println(tr("cli.verify.environment_mismatch", len(mismatches)))
+	for _, m := range mismatches {
+		mm, _ := m.(map[string]interface{})
+		field, _ := mm["field"].(string)
+		pinned, _ := mm["pinned"].(string)
+		current, _ := mm["current"].(string)
+		fmt.Println(tr("cli.verify.environment_diff", field, pinned, current))
+	}
+}
+
 // runExport 导出 job 的证据包（2.0-M1）
 func runExport(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: aetheris export <job_id> [--output evidence.zip]\n")
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris export <job_id> [--output evidence.zip] [--since-snapshot] [--max-payload-bytes N] [--include tool_invocations|failed_steps]"))
 		os.Exit(1)
 	}
 
 	jobID := args[0]
 	outputPath := fmt.Sprintf("evidence-%s.zip", jobID)
 
-	// 解析 --output 参数
+	// 解析 --output 与导出选项（快照基线、payload 截断、选择性导出）
+	body := map[string]interface{}{}
 	for i := 1; i < len(args); i++ {
-		if args[i] == "--output" && i+1 < len(args) {
+		switch {
+		case args[i] == "--output" && i+1 < len(args):
 			outputPath = args[i+1]
-			break
+			i++
+		case args[i] == "--since-snapshot":
+			body["since_snapshot"] = true
+		case args[i] == "--max-payload-bytes" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", `aetheris export <job_id> --max-payload-bytes N (N > 0)`))
+				os.Exit(1)
+			}
+			body["max_payload_bytes"] = n
+			i++
+		case args[i] == "--include" && i+1 < len(args):
+			body["include"] = args[i+1]
+			i++
 		}
 	}
 
-	fmt.Printf("Exporting evidence package for job %s...\n", jobID)
+	fmt.Println(tr("cli.export.exporting", jobID))
 
 	// 调用 API 导出证据包
-	resp, err := newClient().R().
-		Post("/api/jobs/" + jobID + "/export")
+	req := newClient().R()
+	if len(body) > 0 {
+		req = req.SetBody(body)
+	}
+	resp, err := req.Post("/api/jobs/" + jobID + "/export")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.export.failed", err))
 		os.Exit(1)
 	}
 
 	if resp.StatusCode() != 200 {
-		fmt.Fprintf(os.Stderr, "Export failed: HTTP %d\n", resp.StatusCode())
+		fmt.Fprintln(os.Stderr, tr("cli.export.failed_status", resp.StatusCode()))
 		os.Exit(1)
 	}
 
 	// 写入文件
 	if err := os.WriteFile(outputPath, resp.Body(), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to write file: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("cli.write_file_failed", err))
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Evidence package exported to: %s\n", outputPath)
-	fmt.Printf("  To verify: aetheris verify %s\n", outputPath)
+	fmt.Println(tr("cli.export.done", outputPath))
+	fmt.Println(tr("cli.export.verify_hint", outputPath))
 }
 
 // runVerifyEvidenceZip 验证证据包（2.0-M1）
@@ -813,30 +1099,40 @@ func runVerifyEvidenceZip(zipPath string) {
 func verifyEvidenceZip(zipPath string, stdout, stderr io.Writer) int {
 	zipBytes, err := os.ReadFile(zipPath)
 	if err != nil {
-		fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+		fmt.Fprintln(stderr, tr("cli.read_file_failed", err))
 		return 1
 	}
 
 	result := proof.VerifyEvidenceZip(zipBytes)
-	fmt.Fprintf(stdout, "Verifying evidence package: %s\n\n", zipPath)
-	fmt.Fprintln(stdout, "=== Verification Results ===")
+	fmt.Fprintln(stdout, tr("cli.verify.verifying", zipPath))
+	fmt.Fprintln(stdout, tr("cli.verify.results"))
 
 	if result.OK {
-		fmt.Fprintln(stdout, "✓ Verification PASSED")
-		fmt.Fprintf(stdout, "  - Events: %d valid\n", len(result.Events))
+		fmt.Fprintln(stdout, tr("cli.verify.passed"))
+		fmt.Fprintln(stdout, tr("cli.verify.events_valid", len(result.Events)))
 		if result.HashChainValid {
-			fmt.Fprintln(stdout, "  - Hash chain: OK")
+			fmt.Fprintln(stdout, tr("cli.verify.hash_chain_ok"))
 		}
 		if result.LedgerValid {
-			fmt.Fprintln(stdout, "  - Ledger consistency: OK")
+			fmt.Fprintln(stdout, tr("cli.verify.ledger_ok"))
 		}
 		if result.ManifestValid {
-			fmt.Fprintln(stdout, "  - Manifest: OK")
+			fmt.Fprintln(stdout, tr("cli.verify.manifest_ok"))
+		}
+		m := result.Manifest
+		if m.BaseSnapshot != nil {
+			fmt.Fprintln(stdout, tr("cli.verify.snapshot_base", m.BaseSnapshot.Version, m.BaseSnapshot.EventHash))
+		}
+		if m.Include != "" {
+			fmt.Fprintln(stdout, tr("cli.verify.include", m.Include, m.EventCount, m.SourceEventCount))
+		}
+		if m.TruncatedEvents > 0 {
+			fmt.Fprintln(stdout, tr("cli.verify.truncated", m.TruncatedEvents))
 		}
 		return 0
 	}
 
-	fmt.Fprintln(stdout, "✗ Verification FAILED")
+	fmt.Fprintln(stdout, tr("cli.verify.failed"))
 	for _, e := range result.Errors {
 		fmt.Fprintf(stdout, "  - %s\n", e)
 	}
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/goaltemplate"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/workerinspect"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/i18n"
 	"rag-platform/pkg/proof"
 )
 
@@ -159,3 +167,265 @@ func TestBackfillHashesFile(t *testing.T) {
 		t.Fatalf("second prev_hash = %q, want %q", secondPrev, firstHash)
 	}
 }
+
+func TestTraceViewEvidenceZip_RendersOffline(t *testing.T) {
+	jobID := "job_cli_trace_view"
+	payloads := []struct{ typ, payload string }{
+		{"job_created", `{"agent_id":"agent-1","goal":"summarize report"}`},
+		{"node_started", `{"node_id":"n1","trace_span_id":"n1"}`},
+		{"node_finished", `{"node_id":"n1","trace_span_id":"n1"}`},
+		{"job_completed", `{}`},
+	}
+	events := make([]proof.Event, 0, len(payloads))
+	prevHash := ""
+	for i, p := range payloads {
+		e := proof.Event{
+			ID:        strconv.Itoa(i + 1),
+			JobID:     jobID,
+			Type:      p.typ,
+			Payload:   p.payload,
+			CreatedAt: time.Now().UTC().Add(time.Duration(i) * time.Second),
+			PrevHash:  prevHash,
+		}
+		e.Hash = proof.ComputeEventHash(e)
+		prevHash = e.Hash
+		events = append(events, e)
+	}
+	zipBytes, err := proof.ExportEvidenceZip(context.Background(), jobID,
+		testJobStore{events: events}, testLedger{},
+		proof.ExportOptions{RuntimeVersion: "test", SchemaVersion: "2.0"},
+	)
+	if err != nil {
+		t.Fatalf("export evidence zip: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	zipPath := filepath.Join(tmpDir, "evidence.zip")
+	if err := os.WriteFile(zipPath, zipBytes, 0644); err != nil {
+		t.Fatalf("write zip: %v", err)
+	}
+	outPath := filepath.Join(tmpDir, "trace.html")
+
+	var stdout, stderr bytes.Buffer
+	if code := traceViewEvidenceZip(zipPath, outPath, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d, stderr=%s", code, stderr.String())
+	}
+	page, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read html: %v", err)
+	}
+	for _, want := range []string{"window.__TRACE__", "summarize report", "completed", "verification PASSED", "dag-container"} {
+		if !bytes.Contains(page, []byte(want)) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+	// 离线页面不得发起任何 API 请求
+	if bytes.Contains(page, []byte("fetch(")) || bytes.Contains(page, []byte("/api/")) {
+		t.Error("offline trace page must not reference the API")
+	}
+}
+
+func TestPromptTemplateParams(t *testing.T) {
+	tpl := &goaltemplate.Template{Name: "refund", Params: []goaltemplate.Param{
+		{Name: "order", Label: "Order ID", Required: true},
+		{Name: "reason", Type: goaltemplate.TypeEnum, Options: []string{"late", "damaged"}, Default: "late"},
+		{Name: "note"},
+	}}
+	prev := cliLocale
+	cliLocale = i18n.Chinese
+	defer func() { cliLocale = prev }()
+	var out bytes.Buffer
+	params := map[string]any{}
+	reader := bufio.NewReader(strings.NewReader("ORD-1\n\n  thanks \n"))
+	if err := promptTemplateParams(reader, &out, tpl, params, nil); err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if len(params) != 2 || params["order"] != "ORD-1" || params["note"] != "thanks" {
+		t.Fatalf("params = %v", params)
+	}
+	if !strings.Contains(out.String(), "Order ID *:") || !strings.Contains(out.String(), "[late/damaged] <默认 late>") {
+		t.Fatalf("prompts = %q", out.String())
+	}
+
+	// 仅重填校验失败的参数
+	out.Reset()
+	reader = bufio.NewReader(strings.NewReader("ORD-2\n"))
+	if err := promptTemplateParams(reader, &out, tpl, params, map[string]bool{"order": true}); err != nil {
+		t.Fatalf("re-prompt: %v", err)
+	}
+	if params["order"] != "ORD-2" || strings.Count(out.String(), ":") != 1 {
+		t.Fatalf("re-prompt params = %v, out = %q", params, out.String())
+	}
+}
+
+func TestResolveCLILocale(t *testing.T) {
+	for _, env := range []string{"AETHERIS_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		t.Setenv(env, "")
+	}
+	if got := resolveCLILocale(""); got != i18n.English {
+		t.Fatalf("default = %q", got)
+	}
+	t.Setenv("LANG", "zh_CN.UTF-8")
+	if got := resolveCLILocale(""); got != i18n.Chinese {
+		t.Fatalf("LANG=zh_CN.UTF-8 -> %q", got)
+	}
+	t.Setenv("AETHERIS_LANG", "en")
+	if got := resolveCLILocale(""); got != i18n.English {
+		t.Fatalf("AETHERIS_LANG should win over LANG, got %q", got)
+	}
+	if got := resolveCLILocale("zh-CN"); got != i18n.Chinese {
+		t.Fatalf("--lang zh-CN -> %q", got)
+	}
+
+	prev := cliLocale
+	defer func() { cliLocale = prev }()
+	cliLocale = i18n.Chinese
+	if got := tr("cli.usage", "aetheris jobs <agent_id>"); got != "用法: aetheris jobs <agent_id>" {
+		t.Fatalf("zh usage = %q", got)
+	}
+	cliLocale = i18n.English
+	if got := tr("cli.usage", "aetheris jobs <agent_id>"); got != "Usage: aetheris jobs <agent_id>" {
+		t.Fatalf("en usage = %q", got)
+	}
+}
+
+func TestRenderWorkerInspect(t *testing.T) {
+	prev := cliLocale
+	defer func() { cliLocale = prev }()
+	cliLocale = i18n.English
+
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	snap := &workerinspect.Snapshot{
+		WorkerID: "w1", GeneratedAt: now, StartedAt: now.Add(-time.Hour),
+		RunningJobs: 1, MaxConcurrency: 4, LeaseDurationMs: 30000,
+		Claims: []workerinspect.Claim{{
+			JobID: "job-1", AgentID: "a1", TenantID: "acme", ElapsedMs: 65000, LeaseRemainingMs: -2000, HeartbeatFailures: 3,
+			Steps: []workerinspect.StepProgress{{NodeID: "n2", Attempt: 1, ElapsedMs: 12000}},
+		}},
+		Limiters:     []workerinspect.LimiterUsage{{Kind: "llm", Key: "openai", InFlight: 3, MaxConcurrent: 4, Saturation: 0.75}},
+		RecentErrors: []workerinspect.ErrorEntry{{At: now, JobID: "job-1", Op: "heartbeat", Error: "lease lost"}},
+	}
+	var out bytes.Buffer
+	renderWorkerInspect(&out, snap)
+	for _, want := range []string{"worker=w1 running=1/4", "job-1 agent=a1 tenant=acme running=1m5s LEASE EXPIRED 2s ago heartbeat_failures=3",
+		"step n2 attempt=1 elapsed=12s", "llm/openai in_flight=3/4 saturation=75%", "heartbeat job=job-1: lease lost"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if got := inspectEndpoint("worker-1:9095"); got != "http://worker-1:9095/debug/inspect" {
+		t.Fatalf("inspectEndpoint = %q", got)
+	}
+	if got := inspectEndpoint("https://w/custom/path"); got != "https://w/custom/path" {
+		t.Fatalf("inspectEndpoint with path = %q", got)
+	}
+}
+
+func TestRenderEvalReport(t *testing.T) {
+	prev := cliLocale
+	defer func() { cliLocale = prev }()
+	cliLocale = i18n.English
+
+	rep := &evalsuite.Report{
+		Status: evalsuite.ReportCompleted, Total: 2, Passed: 1, Failed: 1, Regressions: []string{"refund"},
+		Cases: []evalsuite.CaseResult{
+			{Name: "greet", Passed: true, Outcome: evalsuite.Outcome{JobID: "job-1", Cost: 0.01, DurationMs: 1500}},
+			{Name: "refund", Failures: []string{"tool refund.create was not called"}, Outcome: evalsuite.Outcome{JobID: "job-2"}},
+		},
+	}
+	var out bytes.Buffer
+	renderEvalReport(&out, rep)
+	for _, want := range []string{"PASS greet job=job-1 cost=0.0100 duration=1.5s", "FAIL refund job=job-2",
+		"- tool refund.create was not called", "1/2 passed (completed)", "Regressions since the previous run: refund"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRenderPlanPreview(t *testing.T) {
+	prev := cliLocale
+	defer func() { cliLocale = prev }()
+	cliLocale = i18n.English
+
+	fetch := planner.TaskNode{ID: "fetch", Type: planner.NodeTool, ToolName: "http.get"}
+	p := &planPreview{
+		TaskGraph: &planner.TaskGraph{Nodes: []planner.TaskNode{fetch, {ID: "post", Type: planner.NodeTool, ToolName: "slack.post"}}},
+		Estimate:  &planner.PlanEstimate{TotalCost: 0.02, Currency: "USD", ETAMs: 3000},
+		History: &planPreviewHistory{
+			BaselineJobID: "job-1", Similarity: 0.9, BaselineCost: 0.015,
+			Diff: &planner.PlanDiff{
+				Added:   []planner.TaskNode{{ID: "post", Type: planner.NodeTool, ToolName: "slack.post"}},
+				Removed: []planner.TaskNode{{ID: "mail", Type: planner.NodeTool, ToolName: "email.send"}},
+				Changed: []planner.NodeChange{{ID: "fetch", Fields: []string{"config"}, Before: fetch, After: fetch}},
+			},
+			SimilarRuns: 4, Succeeded: 3, SuccessRate: 0.75, AvgCost: 0.0125, AvgDurationMs: 2500,
+		},
+	}
+	var out bytes.Buffer
+	renderPlanPreview(&out, p)
+	for _, want := range []string{"Plan (2 nodes):", "cost 0.0200 USD, ETA 3s", "past successful job job-1 (similarity 0.90",
+		"+ post tool slack.post", "- mail tool email.send", "~ fetch tool http.get (config)", "Similar runs: 3/4 succeeded (75%), avg cost 0.0125, avg duration 2.5s"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	renderPlanPreview(&out, &planPreview{})
+	if !strings.Contains(out.String(), "No similar past runs.") {
+		t.Fatalf("output without history:\n%s", out.String())
+	}
+}
+
+func TestRenderTraceReport(t *testing.T) {
+	prev := cliLocale
+	defer func() { cliLocale = prev }()
+	cliLocale = i18n.English
+
+	one := 1
+	rep := &evalsuite.TraceReport{
+		JobID: "job-1", Status: "completed", Total: 2, Failed: 1,
+		Results: []evalsuite.TraceAssertionResult{
+			{Index: 0, Assertion: evalsuite.TraceAssertion{Type: evalsuite.AssertStepCompleted, Step: "n1"}, Passed: true},
+			{Index: 1, Assertion: evalsuite.TraceAssertion{Type: evalsuite.AssertToolCalled, Tool: "refund.create", Max: &one},
+				Message: "tool refund.create was called 2 times, want at most 1"},
+		},
+	}
+	var out bytes.Buffer
+	renderTraceReport(&out, rep)
+	for _, want := range []string{"PASS #1 step_completed n1", "FAIL #2 tool_called refund.create max=1",
+		"- tool refund.create was called 2 times, want at most 1", "1/2 assertions passed (job job-1, completed)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRenderConfigReport(t *testing.T) {
+	prev := cliLocale
+	defer func() { cliLocale = prev }()
+	cliLocale = i18n.English
+
+	r := &config.Report{Issues: []config.Issue{
+		{Severity: config.SeverityWarning, Path: "api.timout", File: "api.yaml", Line: 4, Message: "unknown"},
+		{Severity: config.SeverityError, Path: "worker.timeout", File: "api.yaml", Line: 9, Message: "too short"},
+	}}
+	var out bytes.Buffer
+	renderConfigReport(&out, "api.yaml", r)
+	got := out.String()
+	errAt, warnAt := strings.Index(got, "[error] api.yaml:9 worker.timeout: too short"), strings.Index(got, "[warning] api.yaml:4 api.timout")
+	if errAt < 0 || warnAt < errAt {
+		t.Fatalf("errors should be listed first:\n%s", got)
+	}
+	if !strings.Contains(got, "Config invalid: api.yaml (1 error(s), 1 warning(s))") {
+		t.Fatalf("summary missing:\n%s", got)
+	}
+
+	out.Reset()
+	renderConfigReport(&out, "api.yaml", &config.Report{})
+	if !strings.Contains(out.String(), "Config OK: api.yaml (0 warning(s))") {
+		t.Fatalf("ok summary missing:\n%s", out.String())
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/config"
+)
+
+const migrateCompressEventsUsage = "aetheris migrate compress-events [--min-bytes N] [--level fastest|default|better|best] [--batch N] [--dry-run] [--decompress] [--config configs/api.yaml]"
+
+// runMigrateCompressEvents 压缩 jobstore.dsn 中存量未压缩的事件载荷（阈值与级别默认取 jobstore.compression）；
+// --decompress 还原所有压缩行（回滚压缩前执行）；--dry-run 只统计不写回
+func runMigrateCompressEvents(args []string) {
+	cfgPath := "configs/api.yaml"
+	minBytes, batch := 0, 500
+	level := ""
+	dryRun, decompress := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config", "--min-bytes", "--level", "--batch":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", migrateCompressEventsUsage))
+				os.Exit(1)
+			}
+			switch args[i] {
+			case "--config":
+				cfgPath = args[i+1]
+			case "--level":
+				level = args[i+1]
+			default:
+				n, err := parsePositiveInt(args[i+1])
+				if err != nil {
+					fmt.Fprintln(os.Stderr, tr("cli.usage", migrateCompressEventsUsage))
+					os.Exit(1)
+				}
+				if args[i] == "--batch" {
+					batch = n
+				} else {
+					minBytes = n
+				}
+			}
+			i++
+		case "--dry-run":
+			dryRun = true
+		case "--decompress":
+			decompress = true
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", migrateCompressEventsUsage))
+			os.Exit(1)
+		}
+	}
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.config.load_failed", err))
+		os.Exit(1)
+	}
+	if cfg.JobStore.Type != "postgres" || cfg.JobStore.DSN == "" {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.compress_requires_postgres"))
+		os.Exit(1)
+	}
+	if minBytes == 0 {
+		minBytes = cfg.JobStore.Compression.MinBytes
+	}
+	if level == "" {
+		level = cfg.JobStore.Compression.Level
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.JobStore.DSN)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.compress_failed", err))
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	var stats jobstore.CompressionMigrationStats
+	if decompress {
+		stats, err = jobstore.DecompressExistingPayloads(ctx, pool, batch, dryRun)
+	} else {
+		var c *jobstore.PayloadCompressor
+		c, err = jobstore.NewPayloadCompressor(minBytes, level)
+		if err == nil {
+			stats, err = jobstore.CompressExistingPayloads(ctx, pool, c, batch, dryRun)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.compress_failed", err))
+		os.Exit(1)
+	}
+	key := "cli.migrate.compress_done"
+	if decompress {
+		key = "cli.migrate.decompress_done"
+	}
+	fmt.Println(tr(key, stats.Rewritten, stats.Scanned, stats.BytesBefore, stats.BytesAfter))
+	if dryRun {
+		fmt.Println(tr("cli.migrate.dry_run"))
+	}
+}
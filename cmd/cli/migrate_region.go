@@ -0,0 +1,114 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"rag-platform/internal/storage/residency"
+	"rag-platform/pkg/config"
+)
+
+const migrateTenantRegionUsage = "aetheris migrate tenant-region --tenant <id> --from <region> --to <region> [--delete-source] [--documents] [--config configs/api.yaml]"
+
+// runMigrateTenantRegion 将租户的 Postgres 数据（Job、事件、工具调用账本、Agent 实例等）从 from 区域集群复制到 to 区域集群；
+// --delete-source 复制成功后删除源集群中的行；--documents 再经目标区域 API 迁移文档与向量（AETHERIS_API_URL）
+func runMigrateTenantRegion(args []string) {
+	var tenant, from, to string
+	cfgPath := "configs/api.yaml"
+	deleteSource, documents := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--tenant", "--from", "--to", "--config":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", migrateTenantRegionUsage))
+				os.Exit(1)
+			}
+			switch args[i] {
+			case "--tenant":
+				tenant = args[i+1]
+			case "--from":
+				from = args[i+1]
+			case "--to":
+				to = args[i+1]
+			case "--config":
+				cfgPath = args[i+1]
+			}
+			i++
+		case "--delete-source":
+			deleteSource = true
+		case "--documents":
+			documents = true
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", migrateTenantRegionUsage))
+			os.Exit(1)
+		}
+	}
+	if tenant == "" || from == "" || to == "" || from == to {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", migrateTenantRegionUsage))
+		os.Exit(1)
+	}
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.config.load_failed", err))
+		os.Exit(1)
+	}
+	src, dst := cfg.Residency.Regions[from], cfg.Residency.Regions[to]
+	if src.PostgresDSN == "" || dst.PostgresDSN == "" {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.region_dsn_missing", from, to))
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	srcPool, err := pgxpool.New(ctx, src.PostgresDSN)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.region_failed", err))
+		os.Exit(1)
+	}
+	defer srcPool.Close()
+	dstPool, err := pgxpool.New(ctx, dst.PostgresDSN)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.region_failed", err))
+		os.Exit(1)
+	}
+	defer dstPool.Close()
+
+	tables, err := residency.MigratePostgres(ctx, srcPool, dstPool, tenant, deleteSource)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.migrate.region_failed", err))
+		os.Exit(1)
+	}
+	var total int64
+	for _, t := range tables {
+		if t.Rows > 0 {
+			fmt.Printf("  %-24s %d\n", t.Table, t.Rows)
+		}
+		total += t.Rows
+	}
+	fmt.Println(tr("cli.migrate.region_done", tenant, from, to, total))
+	if documents {
+		out, err := migrateTenantDocuments(tenant, to)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.migrate.region_failed", err))
+			os.Exit(1)
+		}
+		fmt.Println(prettyJSON(out))
+	}
+	fmt.Println(tr("cli.migrate.region_next", tenant, to))
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"rag-platform/internal/agent/planner"
+)
+
+const agentPreviewUsage = "aetheris agent preview <agent_id> <goal> [--json]"
+
+// planPreview POST /api/agents/:id/plan/preview 的响应
+type planPreview struct {
+	AgentID   string                `json:"agent_id"`
+	Goal      string                `json:"goal"`
+	TaskGraph *planner.TaskGraph    `json:"task_graph"`
+	Estimate  *planner.PlanEstimate `json:"estimate"`
+	History   *planPreviewHistory   `json:"history,omitempty"`
+}
+
+// planPreviewHistory 与相似历史运行的对比（见 API 文档 Plan preview history）
+type planPreviewHistory struct {
+	BaselineJobID string            `json:"baseline_job_id"`
+	BaselineGoal  string            `json:"baseline_goal"`
+	Similarity    float64           `json:"similarity"`
+	BaselineCost  float64           `json:"baseline_cost"`
+	Diff          *planner.PlanDiff `json:"diff"`
+	SimilarRuns   int               `json:"similar_runs"`
+	Succeeded     int               `json:"succeeded"`
+	SuccessRate   float64           `json:"success_rate"`
+	AvgCost       float64           `json:"avg_cost"`
+	AvgDurationMs int64             `json:"avg_duration_ms"`
+}
+
+// runAgentPreview 仅规划不创建 Job：输出任务图、成本/ETA 预估，以及与相似目标的过往成功计划的差异和历史成功率
+func runAgentPreview(args []string) {
+	var positional []string
+	asJSON := false
+	for _, a := range args {
+		if a == "--json" {
+			asJSON = true
+			continue
+		}
+		positional = append(positional, a)
+	}
+	if len(positional) < 2 {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", agentPreviewUsage))
+		os.Exit(1)
+	}
+	p, err := previewPlan(positional[0], strings.Join(positional[1:], " "))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("cli.preview.failed", err))
+		os.Exit(1)
+	}
+	if asJSON {
+		fmt.Println(prettyJSON(p))
+		return
+	}
+	renderPlanPreview(os.Stdout, p)
+}
+
+// renderPlanPreview 输出计划节点与预估，随后是与历史成功计划的差异（+ 新增、- 删除、~ 变化）和相似运行统计
+func renderPlanPreview(w io.Writer, p *planPreview) {
+	nodes := 0
+	if p.TaskGraph != nil {
+		nodes = len(p.TaskGraph.Nodes)
+	}
+	fmt.Fprintln(w, tr("cli.preview.plan", nodes))
+	if p.TaskGraph != nil {
+		for _, n := range p.TaskGraph.Nodes {
+			fmt.Fprintf(w, "  %s %s\n", n.ID, previewNodeLabel(n))
+		}
+	}
+	if e := p.Estimate; e != nil {
+		fmt.Fprintln(w, tr("cli.preview.estimate", e.TotalCost, e.Currency, msDuration(e.ETAMs)))
+	}
+	h := p.History
+	if h == nil {
+		fmt.Fprintln(w, tr("cli.preview.no_history"))
+		return
+	}
+	if d := h.Diff; d != nil {
+		fmt.Fprintln(w, tr("cli.preview.baseline", h.BaselineJobID, h.Similarity, h.BaselineCost))
+		if d.Empty() {
+			fmt.Fprintln(w, "  "+tr("cli.preview.identical"))
+		}
+		for _, n := range d.Added {
+			fmt.Fprintf(w, "  + %s %s\n", n.ID, previewNodeLabel(n))
+		}
+		for _, n := range d.Removed {
+			fmt.Fprintf(w, "  - %s %s\n", n.ID, previewNodeLabel(n))
+		}
+		for _, c := range d.Changed {
+			fmt.Fprintf(w, "  ~ %s %s (%s)\n", c.ID, previewNodeLabel(c.After), strings.Join(c.Fields, ", "))
+		}
+		if len(d.AddedEdges)+len(d.RemovedEdges) > 0 {
+			fmt.Fprintln(w, "  "+tr("cli.preview.edges", len(d.AddedEdges), len(d.RemovedEdges)))
+		}
+	}
+	fmt.Fprintln(w, tr("cli.preview.runs", h.Succeeded, h.SimilarRuns, h.SuccessRate*100, h.AvgCost, msDuration(h.AvgDurationMs)))
+}
+
+// previewNodeLabel 节点类型及工具/工作流名
+func previewNodeLabel(n planner.TaskNode) string {
+	switch {
+	case n.ToolName != "":
+		return n.Type + " " + n.ToolName
+	case n.Workflow != "":
+		return n.Type + " " + n.Workflow
+	default:
+		return n.Type
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/branding"
+	apihttp "rag-platform/internal/api/http"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/proof"
+)
+
+// runTraceView 离线查看证据包：在本地渲染与 Trace 页面相同的 timeline/DAG HTML，不访问任何 API
+func runTraceView(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", "aetheris trace view <evidence.zip> [--output trace.html] [--no-open]"))
+		os.Exit(1)
+	}
+	zipPath := args[0]
+	outputPath := strings.TrimSuffix(zipPath, ".zip") + ".trace.html"
+	open := true
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--output":
+			if i+1 < len(args) {
+				outputPath = args[i+1]
+				i++
+			}
+		case "--no-open":
+			open = false
+		}
+	}
+	if code := traceViewEvidenceZip(zipPath, outputPath, os.Stdout, os.Stderr); code != 0 {
+		os.Exit(code)
+	}
+	if open {
+		if err := openInBrowser(outputPath); err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.trace_view.open_failed", err, outputPath))
+		}
+	}
+}
+
+// traceViewEvidenceZip 读取证据包并写出自包含的 Trace HTML；证据包校验失败时仍渲染，但在页面顶部标注
+func traceViewEvidenceZip(zipPath, outputPath string, stdout, stderr io.Writer) int {
+	zipBytes, err := os.ReadFile(zipPath)
+	if err != nil {
+		fmt.Fprintln(stderr, tr("cli.read_file_failed", err))
+		return 1
+	}
+	pkg, err := proof.ReadEvidenceZip(zipBytes)
+	if err != nil {
+		fmt.Fprintln(stderr, tr("cli.trace_view.invalid_package", err))
+		return 1
+	}
+
+	verify := proof.VerifyEvidenceZip(zipBytes)
+	notice := tr("cli.trace_view.notice",
+		pkg.Manifest.JobID, pkg.Manifest.ExportedAt.Format(time.RFC3339), len(pkg.Events))
+	if verify.OK {
+		notice += tr("cli.trace_view.notice_passed")
+	} else {
+		notice += tr("cli.trace_view.notice_failed", strings.Join(verify.Errors, "; "))
+	}
+	if pkg.Manifest.Redacted {
+		notice += tr("cli.trace_view.notice_redacted")
+	}
+	if ref := pkg.Manifest.BaseSnapshot; ref != nil {
+		notice += tr("cli.trace_view.notice_snapshot", ref.Version)
+	}
+	if pkg.Manifest.Include != "" {
+		notice += tr("cli.trace_view.notice_include", pkg.Manifest.Include)
+	}
+	if pkg.Manifest.TruncatedEvents > 0 {
+		notice += tr("cli.trace_view.notice_truncated", pkg.Manifest.TruncatedEvents)
+	}
+
+	events := make([]jobstore.JobEvent, 0, len(pkg.Events))
+	for _, e := range pkg.Events {
+		events = append(events, jobstore.JobEvent{
+			ID:        e.ID,
+			JobID:     e.JobID,
+			Type:      jobstore.EventType(e.Type),
+			Payload:   []byte(e.Payload),
+			CreatedAt: e.CreatedAt,
+			PrevHash:  e.PrevHash,
+			Hash:      e.Hash,
+		})
+	}
+	opts := apihttp.TraceHTMLOptions{Offline: true, Notice: notice, Locale: cliLocale}
+	if len(pkg.Branding) > 0 {
+		var br branding.Branding
+		if err := json.Unmarshal(pkg.Branding, &br); err == nil && br.Validate() == nil {
+			opts.Branding = &br
+		}
+	}
+	page := apihttp.RenderTraceHTML(pkg.Metadata.JobID, pkg.Metadata.Goal, pkg.Metadata.Status, events, opts)
+	if err := os.WriteFile(outputPath, []byte(page), 0644); err != nil {
+		fmt.Fprintln(stderr, tr("cli.write_file_failed", err))
+		return 1
+	}
+
+	if !verify.OK {
+		fmt.Fprintln(stdout, tr("cli.trace_view.verify_failed"))
+	}
+	fmt.Fprintln(stdout, tr("cli.trace_view.written", pkg.Metadata.JobID, outputPath))
+	return 0
+}
+
+// openInBrowser 使用系统默认程序打开本地文件
+func openInBrowser(path string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", path)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		c = exec.Command("xdg-open", path)
+	}
+	return c.Start()
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/workerinspect"
+)
+
+const workerInspectUsage = "aetheris worker inspect <worker_id> [--watch] [--interval N] [--addr URL] [--json]"
+
+// runWorkerInspect 查看 Worker 内部状态：当前认领、执行中的 step、租约剩余时间、最近错误与限流器占用；--watch 时按间隔持续刷新
+func runWorkerInspect(args []string) {
+	if len(args) < 1 || strings.HasPrefix(args[0], "--") {
+		fmt.Fprintln(os.Stderr, tr("cli.usage", workerInspectUsage))
+		os.Exit(1)
+	}
+	workerID := args[0]
+	watch := false
+	asJSON := false
+	addr := ""
+	intervalSeconds := 2
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--watch":
+			watch = true
+		case "--json":
+			asJSON = true
+		case "--addr":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", workerInspectUsage))
+				os.Exit(1)
+			}
+			addr = inspectEndpoint(args[i+1])
+			i++
+		case "--interval":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, tr("cli.usage", workerInspectUsage))
+				os.Exit(1)
+			}
+			n, err := parsePositiveInt(args[i+1])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, tr("cli.monitor.invalid_interval", err))
+				os.Exit(1)
+			}
+			intervalSeconds = n
+			i++
+		default:
+			fmt.Fprintln(os.Stderr, tr("cli.usage", workerInspectUsage))
+			os.Exit(1)
+		}
+	}
+
+	printSnapshot := func() {
+		snap, err := getWorkerInspect(workerID, addr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("cli.worker_inspect.fetch_failed", err))
+			if !watch {
+				os.Exit(1)
+			}
+			return
+		}
+		if asJSON {
+			fmt.Println(prettyJSON(snap))
+			return
+		}
+		renderWorkerInspect(os.Stdout, snap)
+		fmt.Println()
+	}
+
+	printSnapshot()
+	if !watch {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		printSnapshot()
+	}
+}
+
+// inspectEndpoint 补全 --addr：仅给出 host:port 或根路径时追加 /debug/inspect
+func inspectEndpoint(addr string) string {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	rest := addr[strings.Index(addr, "://")+3:]
+	if !strings.Contains(strings.TrimSuffix(rest, "/"), "/") {
+		addr = strings.TrimSuffix(addr, "/") + "/debug/inspect"
+	}
+	return addr
+}
+
+// renderWorkerInspect 以便于值班人员阅读的文本输出自省快照
+func renderWorkerInspect(w io.Writer, s *workerinspect.Snapshot) {
+	fmt.Fprintf(w, "[%s] worker=%s running=%d/%d lease=%s uptime=%s\n",
+		s.GeneratedAt.Format(time.RFC3339), s.WorkerID, s.RunningJobs, s.MaxConcurrency,
+		msDuration(s.LeaseDurationMs), s.GeneratedAt.Sub(s.StartedAt).Truncate(time.Second))
+
+	fmt.Fprintln(w, "claims:")
+	if len(s.Claims) == 0 {
+		fmt.Fprintln(w, "  "+tr("cli.worker_inspect.no_claims"))
+	}
+	for _, c := range s.Claims {
+		lease := tr("cli.worker_inspect.lease_expires_in", msDuration(c.LeaseRemainingMs))
+		if c.LeaseRemainingMs <= 0 {
+			lease = tr("cli.worker_inspect.lease_expired", msDuration(-c.LeaseRemainingMs))
+		}
+		fmt.Fprintf(w, "  %s agent=%s tenant=%s running=%s %s", c.JobID, c.AgentID, c.TenantID, msDuration(c.ElapsedMs), lease)
+		if c.HeartbeatFailures > 0 {
+			fmt.Fprintf(w, " heartbeat_failures=%d", c.HeartbeatFailures)
+		}
+		fmt.Fprintln(w)
+		for _, st := range c.Steps {
+			fmt.Fprintf(w, "    step %s attempt=%d elapsed=%s\n", st.NodeID, st.Attempt, msDuration(st.ElapsedMs))
+		}
+	}
+
+	if len(s.Limiters) > 0 {
+		fmt.Fprintln(w, "limiters:")
+		for _, l := range s.Limiters {
+			fmt.Fprintf(w, "  %s/%s in_flight=%d", l.Kind, l.Key, l.InFlight)
+			if l.MaxConcurrent > 0 {
+				fmt.Fprintf(w, "/%d", l.MaxConcurrent)
+			}
+			if l.TokensPerMinute > 0 {
+				fmt.Fprintf(w, " tokens_minute=%d/%d", l.TokensUsedMinute, l.TokensPerMinute)
+			}
+			fmt.Fprintf(w, " saturation=%.0f%%\n", l.Saturation*100)
+		}
+	}
+
+	if t := s.Throttle; t != nil {
+		fmt.Fprintf(w, "throttle: throttled=%t cpu=%.1f%% memory=%.1f%% llm_in_flight=%d tool_in_flight=%d",
+			t.Throttled, t.CPUPercent, t.MemoryPercent, t.LLMInFlight, t.ToolInFlight)
+		if len(t.Reasons) > 0 {
+			fmt.Fprintf(w, " reasons=%s", strings.Join(t.Reasons, ","))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "recent errors:")
+	if len(s.RecentErrors) == 0 {
+		fmt.Fprintln(w, "  "+tr("cli.worker_inspect.no_errors"))
+	}
+	for _, e := range s.RecentErrors {
+		fmt.Fprintf(w, "  %s %s", e.At.Format(time.RFC3339), e.Op)
+		if e.JobID != "" {
+			fmt.Fprintf(w, " job=%s", e.JobID)
+		}
+		fmt.Fprintf(w, ": %s\n", e.Error)
+	}
+}
+
+func msDuration(ms int64) time.Duration {
+	return (time.Duration(ms) * time.Millisecond).Truncate(100 * time.Millisecond)
+}
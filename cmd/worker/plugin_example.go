@@ -0,0 +1,21 @@
+//go:build nodeplugin_example
+
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// 自定义节点插件以 build tag 编入 Worker：每个插件一个文件，空导入插件包（其 init 调用 nodeplugin.Register）。
+// 本文件编入示例插件 template_render：go build -tags nodeplugin_example ./cmd/worker
+import _ "rag-platform/examples/node_plugin"
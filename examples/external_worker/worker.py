@@ -0,0 +1,101 @@
+#!/usr/bin/env python3
+# Copyright 2026 fanjia1024
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Minimal external worker (design/external-worker-protocol.md).
+
+Register with:
+
+    agent:
+      external_workers:
+        - name: "py-example"
+          command: "python3"
+          args: ["examples/external_worker/worker.py"]
+"""
+
+import json
+import sys
+
+PROTOCOL_VERSION = "aetheris.worker/1"
+
+TOOLS = [
+    {
+        "name": "py.word_count",
+        "description": "Count words in text",
+        "schema": {"type": "object", "properties": {"text": {"type": "string"}}, "required": ["text"]},
+        "expected_latency_ms": 5,
+    },
+]
+
+
+def word_count(inp, ctx):
+    text = inp.get("text")
+    if not isinstance(text, str):
+        return {"error": {"type": "permanent", "message": "text must be a string"}}
+    return {"done": True, "output": str(len(text.split()))}
+
+
+HANDLERS = {"py.word_count": word_count}
+
+
+def send(msg):
+    sys.stdout.write(json.dumps(msg) + "\n")
+    sys.stdout.flush()
+
+
+def log(level, message):
+    send({"jsonrpc": "2.0", "method": "log", "params": {"level": level, "message": message}})
+
+
+def handle(msg):
+    method, params = msg.get("method"), msg.get("params") or {}
+    if method == "initialize":
+        return {"protocol_version": PROTOCOL_VERSION, "name": "py-example", "version": "0.1.0", "tools": TOOLS}
+    if method == "step.execute":
+        ctx = params.get("context") or {}
+        handler = HANDLERS.get(params.get("tool"))
+        if handler is None:
+            raise LookupError("unknown tool: %s" % params.get("tool"))
+        try:
+            result = handler(params.get("input") or {}, ctx)
+        except Exception as exc:  # 未预期异常按可重试处理
+            result = {"error": {"type": "retryable", "message": str(exc)}}
+        # Step Contract：必须回显 idempotency_key
+        result["idempotency_key"] = ctx.get("idempotency_key", "")
+        return result
+    raise NotImplementedError(method)
+
+
+def main():
+    for line in sys.stdin:
+        line = line.strip()
+        if not line:
+            continue
+        msg = json.loads(line)
+        method, mid = msg.get("method"), msg.get("id")
+        if method == "shutdown":
+            return
+        if mid is None:  # 其他通知（如 step.cancel）：同步实现无需处理
+            continue
+        try:
+            send({"jsonrpc": "2.0", "id": mid, "result": handle(msg)})
+        except LookupError as exc:
+            send({"jsonrpc": "2.0", "id": mid, "error": {"code": -32601, "message": str(exc)}})
+        except Exception as exc:
+            log("error", "worker failed: %s" % exc)
+            send({"jsonrpc": "2.0", "id": mid, "error": {"code": -32603, "message": str(exc)}})
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,53 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodeplugin 示例自定义节点类型 template_render：用上游节点结果渲染文本模板。
+// 以 `go build -tags nodeplugin_example ./cmd/worker` 编入 Worker（见 cmd/worker/plugin_example.go），
+// API 侧放置 render.json 到 agent.node_plugins.manifest_dir 即可校验含该节点的计划。
+package nodeplugin
+
+import (
+	"context"
+	"strings"
+	"text/template"
+
+	"rag-platform/pkg/nodeplugin"
+)
+
+// Manifest template_render 节点声明；与 render.json 保持一致
+var Manifest = nodeplugin.Manifest{
+	Type:        "template_render",
+	Version:     "1.0.0",
+	Description: "用上游节点结果渲染 Go text/template，{{.Results.<节点ID>}} 取节点输出",
+	Effect:      nodeplugin.EffectPure,
+	Config: []nodeplugin.Field{
+		{Name: "template", Type: nodeplugin.FieldString, Required: true, Description: "Go text/template 模板"},
+	},
+}
+
+func init() {
+	nodeplugin.Register(Manifest, nodeplugin.ExecutorFunc(render))
+}
+
+func render(ctx context.Context, req *nodeplugin.Request) (any, error) {
+	tmpl, err := template.New(req.NodeID).Option("missingkey=error").Parse(req.Config["template"].(string))
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, req); err != nil {
+		return nil, err
+	}
+	return map[string]any{"output": b.String()}, nil
+}
@@ -0,0 +1,339 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analytics 跨租户聚合使用指标（热门工具、各模型失败率、计划规模），供平台运营改进产品；
+// 以 k-匿名阈值与单租户占比上限抑制可能暴露个别租户活动的分组，输出中不含租户 ID、Job ID 与事件内容
+package analytics
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/utils"
+)
+
+// 默认阈值：分组至少覆盖 5 个租户、10 个 Job，且单个租户贡献不超过 50% 才输出
+const (
+	DefaultMinTenants     = 5
+	DefaultMinJobs        = 10
+	DefaultMaxTenantShare = 0.5
+	DefaultTop            = 20
+)
+
+// Options 聚合与抑制阈值；零值字段取默认
+type Options struct {
+	// MinTenants k-匿名阈值：整体与每个分组至少覆盖的不同租户数
+	MinTenants int
+	// MinJobs 每个分组至少包含的 Job 数
+	MinJobs int
+	// MaxTenantShare 单个租户在分组 Job 数与输出计数中的占比上限（0~1），超过则抑制，防止分组实际反映某个大租户
+	MaxTenantShare float64
+	// Top 各排行榜最多条数
+	Top int
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinTenants <= 0 {
+		o.MinTenants = DefaultMinTenants
+	}
+	if o.MinJobs <= 0 {
+		o.MinJobs = DefaultMinJobs
+	}
+	if o.MaxTenantShare <= 0 || o.MaxTenantShare > 1 {
+		o.MaxTenantShare = DefaultMaxTenantShare
+	}
+	if o.Top <= 0 {
+		o.Top = DefaultTop
+	}
+	return o
+}
+
+// ToolUsage 单个工具的跨租户使用量
+type ToolUsage struct {
+	Tool    string `json:"tool"`
+	Calls   int    `json:"calls"`
+	Jobs    int    `json:"jobs"`
+	Tenants int    `json:"tenants"`
+}
+
+// ModelFailureRate 使用某模型的 Job 中失败的比例
+type ModelFailureRate struct {
+	Model       string  `json:"model"`
+	Jobs        int     `json:"jobs"`
+	FailedJobs  int     `json:"failed_jobs"`
+	FailureRate float64 `json:"failure_rate"`
+	Tenants     int     `json:"tenants"`
+}
+
+// PlanSizeStat 计划节点数分布；分位数仅在不小于该值的计划同样满足抑制阈值时输出，否则为空
+type PlanSizeStat struct {
+	Plans    int     `json:"plans"`
+	AvgNodes float64 `json:"avg_nodes"`
+	P50Nodes *int    `json:"p50_nodes,omitempty"`
+	P95Nodes *int    `json:"p95_nodes,omitempty"`
+}
+
+// Thresholds 本次聚合使用的抑制阈值（随报告返回，便于解读被抑制的分组）
+type Thresholds struct {
+	MinTenants     int     `json:"min_tenants"`
+	MinJobs        int     `json:"min_jobs"`
+	MaxTenantShare float64 `json:"max_tenant_share"`
+}
+
+// Report 跨租户聚合报告；Suppressed 为 true 时窗口内租户数不足 MinTenants，不输出任何分组
+type Report struct {
+	From                time.Time          `json:"from"`
+	To                  time.Time          `json:"to"`
+	Tenants             int                `json:"tenants"`
+	JobsAnalyzed        int                `json:"jobs_analyzed"`
+	Suppressed          bool               `json:"suppressed"`
+	SuppressedGroups    int                `json:"suppressed_groups"`
+	PopularTools        []ToolUsage        `json:"popular_tools"`
+	FailureRatesByModel []ModelFailureRate `json:"failure_rates_by_model"`
+	PlanSize            *PlanSizeStat      `json:"plan_size,omitempty"`
+	Thresholds          Thresholds         `json:"thresholds"`
+}
+
+// group 一个分组按租户累计的 Job 数与计数（抑制判定）
+type group struct {
+	jobsByTenant  map[string]int
+	countByTenant map[string]int
+	jobs          int
+	count         int // 工具调用数 / 失败 Job 数 / 计划节点数
+}
+
+func (g *group) add(tenant string, count int) {
+	if g.jobsByTenant == nil {
+		g.jobsByTenant = make(map[string]int)
+		g.countByTenant = make(map[string]int)
+	}
+	g.jobsByTenant[tenant]++
+	g.countByTenant[tenant] += count
+	g.jobs++
+	g.count += count
+}
+
+// releasable 分组是否满足 k-匿名阈值，且单个租户在输出的 Job 数与计数中的占比都不超过上限
+func (g *group) releasable(o Options) bool {
+	if len(g.jobsByTenant) < o.MinTenants || g.jobs < o.MinJobs {
+		return false
+	}
+	return withinShare(g.jobsByTenant, g.jobs, o.MaxTenantShare) && withinShare(g.countByTenant, g.count, o.MaxTenantShare)
+}
+
+func withinShare(byTenant map[string]int, total int, share float64) bool {
+	for _, n := range byTenant {
+		if float64(n) > share*float64(total) {
+			return false
+		}
+	}
+	return true
+}
+
+// planSample 单个 Job 的计划节点数
+type planSample struct {
+	tenant string
+	nodes  int
+}
+
+// releasedPercentile 计划节点数的 p 分位；不小于该值的计划本身须满足分组抑制阈值，
+// 避免小分组的高分位直接等于个别 Job 的计划规模
+func releasedPercentile(samples []planSample, sorted []int, p float64, o Options) *int {
+	v := utils.Percentile(sorted, p)
+	tail := &group{}
+	for _, s := range samples {
+		if s.nodes >= v {
+			tail.add(s.tenant, s.nodes)
+		}
+	}
+	if !tail.releasable(o) {
+		return nil
+	}
+	return &v
+}
+
+// Aggregate 由窗口内 Job 及其事件流计算跨租户报告；eventsByJob 中缺少的 Job 仅计入租户数与 Job 数
+func Aggregate(jobs []*job.Job, eventsByJob map[string][]jobstore.JobEvent, from, to time.Time, opts Options) *Report {
+	o := opts.withDefaults()
+	r := &Report{
+		From:                from,
+		To:                  to,
+		PopularTools:        []ToolUsage{},
+		FailureRatesByModel: []ModelFailureRate{},
+		Thresholds:          Thresholds{MinTenants: o.MinTenants, MinJobs: o.MinJobs, MaxTenantShare: o.MaxTenantShare},
+	}
+	tenants := make(map[string]struct{})
+	tools := make(map[string]*group)
+	models := make(map[string]*group)
+	plans := &group{}
+	var planSamples []planSample
+	for _, j := range jobs {
+		if j == nil {
+			continue
+		}
+		tenant := j.TenantID
+		if tenant == "" {
+			tenant = "default"
+		}
+		tenants[tenant] = struct{}{}
+		r.JobsAnalyzed++
+		s := summarize(eventsByJob[j.ID])
+		for tool, calls := range s.toolCalls {
+			if tools[tool] == nil {
+				tools[tool] = &group{}
+			}
+			tools[tool].add(tenant, calls)
+		}
+		failed := 0
+		if j.Status == job.StatusFailed {
+			failed = 1
+		}
+		for model := range s.models {
+			if models[model] == nil {
+				models[model] = &group{}
+			}
+			models[model].add(tenant, failed)
+		}
+		if s.planNodes > 0 {
+			plans.add(tenant, s.planNodes)
+			planSamples = append(planSamples, planSample{tenant: tenant, nodes: s.planNodes})
+		}
+	}
+	r.Tenants = len(tenants)
+	if r.Tenants < o.MinTenants {
+		// 租户数不足时连租户数本身也不输出精确值
+		r.Suppressed = true
+		r.Tenants = 0
+		r.JobsAnalyzed = 0
+		r.SuppressedGroups = len(tools) + len(models)
+		if plans.jobs > 0 {
+			r.SuppressedGroups++
+		}
+		return r
+	}
+	for name, g := range tools {
+		if !g.releasable(o) {
+			r.SuppressedGroups++
+			continue
+		}
+		r.PopularTools = append(r.PopularTools, ToolUsage{Tool: name, Calls: g.count, Jobs: g.jobs, Tenants: len(g.jobsByTenant)})
+	}
+	sort.Slice(r.PopularTools, func(i, k int) bool {
+		a, b := r.PopularTools[i], r.PopularTools[k]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.Tool < b.Tool
+	})
+	for name, g := range models {
+		if !g.releasable(o) {
+			r.SuppressedGroups++
+			continue
+		}
+		r.FailureRatesByModel = append(r.FailureRatesByModel, ModelFailureRate{
+			Model: name, Jobs: g.jobs, FailedJobs: g.count, FailureRate: round3(float64(g.count) / float64(g.jobs)), Tenants: len(g.jobsByTenant),
+		})
+	}
+	sort.Slice(r.FailureRatesByModel, func(i, k int) bool {
+		a, b := r.FailureRatesByModel[i], r.FailureRatesByModel[k]
+		if a.Jobs != b.Jobs {
+			return a.Jobs > b.Jobs
+		}
+		return a.Model < b.Model
+	})
+	r.PopularTools = truncate(r.PopularTools, o.Top)
+	r.FailureRatesByModel = truncate(r.FailureRatesByModel, o.Top)
+	if plans.jobs > 0 {
+		if plans.releasable(o) {
+			sizes := make([]int, len(planSamples))
+			for i, s := range planSamples {
+				sizes[i] = s.nodes
+			}
+			sort.Ints(sizes)
+			r.PlanSize = &PlanSizeStat{
+				Plans:    plans.jobs,
+				AvgNodes: round3(float64(plans.count) / float64(plans.jobs)),
+				P50Nodes: releasedPercentile(planSamples, sizes, 0.50, o),
+				P95Nodes: releasedPercentile(planSamples, sizes, 0.95, o),
+			}
+		} else {
+			r.SuppressedGroups++
+		}
+	}
+	return r
+}
+
+// jobSummary 单个 Job 事件流中的工具调用数、使用的模型与计划节点数
+type jobSummary struct {
+	toolCalls map[string]int
+	models    map[string]struct{}
+	planNodes int
+}
+
+func summarize(events []jobstore.JobEvent) jobSummary {
+	s := jobSummary{toolCalls: make(map[string]int), models: make(map[string]struct{})}
+	for _, e := range events {
+		switch e.Type {
+		case jobstore.ToolInvocationStarted:
+			var pl struct {
+				ToolName string `json:"tool_name"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.ToolName != "" {
+				s.toolCalls[pl.ToolName]++
+			}
+		case jobstore.CommandCommitted:
+			// LLM 步的 command_committed 携带 llm_model（可能嵌在 result 内）
+			var pl struct {
+				LLMModel string `json:"llm_model"`
+				Result   struct {
+					LLMModel string `json:"llm_model"`
+				} `json:"result"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			if pl.LLMModel != "" {
+				s.models[pl.LLMModel] = struct{}{}
+			} else if pl.Result.LLMModel != "" {
+				s.models[pl.Result.LLMModel] = struct{}{}
+			}
+		case jobstore.PlanGenerated:
+			// 以最后一次规划（含重规划）为准
+			var pl struct {
+				TaskGraph struct {
+					Nodes []json.RawMessage `json:"nodes"`
+				} `json:"task_graph"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && len(pl.TaskGraph.Nodes) > 0 {
+				s.planNodes = len(pl.TaskGraph.Nodes)
+			}
+		}
+	}
+	return s
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func truncate[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
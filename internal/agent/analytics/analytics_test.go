@@ -0,0 +1,131 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+type fixture struct {
+	jobs   []*job.Job
+	events map[string][]jobstore.JobEvent
+}
+
+// add 为 tenant 添加一个 Job：nodes 个计划节点，依次调用 tools，使用 model（为空不调用 LLM）
+func (f *fixture) add(tenant string, failed bool, model string, nodes int, tools ...string) {
+	id := fmt.Sprintf("job-%d", len(f.jobs))
+	status := job.StatusCompleted
+	if failed {
+		status = job.StatusFailed
+	}
+	f.jobs = append(f.jobs, &job.Job{ID: id, TenantID: tenant, Status: status})
+	graph := map[string]any{"nodes": make([]map[string]string, nodes)}
+	plan, _ := json.Marshal(map[string]any{"task_graph": graph})
+	events := []jobstore.JobEvent{{Type: jobstore.PlanGenerated, Payload: plan}}
+	for _, tool := range tools {
+		pl, _ := json.Marshal(map[string]string{"tool_name": tool, "node_id": "n1"})
+		events = append(events, jobstore.JobEvent{Type: jobstore.ToolInvocationStarted, Payload: pl})
+	}
+	if model != "" {
+		pl, _ := json.Marshal(map[string]any{"node_id": "llm", "result": map[string]string{"llm_model": model}})
+		events = append(events, jobstore.JobEvent{Type: jobstore.CommandCommitted, Payload: pl})
+	}
+	if f.events == nil {
+		f.events = make(map[string][]jobstore.JobEvent)
+	}
+	f.events[id] = events
+}
+
+func TestAggregate_ReleasesOnlyAnonymousGroups(t *testing.T) {
+	f := &fixture{}
+	for i := 0; i < 5; i++ {
+		tenant := fmt.Sprintf("t%d", i)
+		f.add(tenant, false, "gpt-4o", 3, "web_search", "web_search")
+		f.add(tenant, i%2 == 0, "gpt-4o", 5, "web_search")
+	}
+	// 仅一个租户使用的内部工具与模型，不得出现在报告中
+	for i := 0; i < 20; i++ {
+		f.add("t0", true, "acme-finetune", 2, "acme_ledger")
+	}
+	now := time.Now()
+	r := Aggregate(f.jobs, f.events, now.Add(-time.Hour), now, Options{MinTenants: 5, MinJobs: 10, MaxTenantShare: 0.5})
+	if r.Suppressed || r.Tenants != 5 || r.JobsAnalyzed != 30 {
+		t.Fatalf("report = %+v", r)
+	}
+	if len(r.PopularTools) != 1 || r.PopularTools[0] != (ToolUsage{Tool: "web_search", Calls: 15, Jobs: 10, Tenants: 5}) {
+		t.Fatalf("popular tools = %+v", r.PopularTools)
+	}
+	if len(r.FailureRatesByModel) != 1 || r.FailureRatesByModel[0] != (ModelFailureRate{Model: "gpt-4o", Jobs: 10, FailedJobs: 3, FailureRate: 0.3, Tenants: 5}) {
+		t.Fatalf("failure rates = %+v", r.FailureRatesByModel)
+	}
+	// acme_ledger / acme-finetune 租户数不足；计划规模由 t0 主导（22/30 个 Job）
+	if r.SuppressedGroups != 3 || r.PlanSize != nil {
+		t.Fatalf("suppressed = %d, plan size = %+v", r.SuppressedGroups, r.PlanSize)
+	}
+	r = Aggregate(f.jobs[:10], f.events, now.Add(-time.Hour), now, Options{MinTenants: 5, MinJobs: 10})
+	if r.PlanSize == nil || r.PlanSize.Plans != 10 || r.PlanSize.AvgNodes != 4 || r.PlanSize.P50Nodes == nil || *r.PlanSize.P50Nodes != 3 {
+		t.Fatalf("plan size = %+v", r.PlanSize)
+	}
+	// P95 = 5 仅由 5 个 Job 支撑，低于 MinJobs，不输出
+	if r.PlanSize.P95Nodes != nil {
+		t.Fatalf("p95 released from %d plans: %d", r.PlanSize.Plans, *r.PlanSize.P95Nodes)
+	}
+}
+
+func TestAggregate_TenantShareAppliesToReportedCounts(t *testing.T) {
+	f := &fixture{}
+	for i := 0; i < 5; i++ {
+		tenant := fmt.Sprintf("t%d", i)
+		f.add(tenant, false, "gpt-4o", 3, "web_search")
+		f.add(tenant, false, "gpt-4o", 3, "web_search")
+	}
+	// Job 数均匀分布，但 t0 的一个 Job 贡献了绝大多数调用
+	calls := make([]string, 40)
+	for i := range calls {
+		calls[i] = "web_search"
+	}
+	f.add("t0", false, "gpt-4o", 3, calls...)
+	now := time.Now()
+	r := Aggregate(f.jobs, f.events, now.Add(-time.Hour), now, Options{MinTenants: 5, MinJobs: 10, MaxTenantShare: 0.5})
+	if len(r.PopularTools) != 0 {
+		t.Fatalf("tool dominated by one tenant's calls released: %+v", r.PopularTools)
+	}
+	if len(r.FailureRatesByModel) != 1 || r.PlanSize == nil {
+		t.Fatalf("failure rates = %+v, plan size = %+v", r.FailureRatesByModel, r.PlanSize)
+	}
+}
+
+func TestAggregate_SuppressesWholeReportBelowMinTenants(t *testing.T) {
+	f := &fixture{}
+	for i := 0; i < 4; i++ {
+		for k := 0; k < 5; k++ {
+			f.add(fmt.Sprintf("t%d", i), false, "gpt-4o", 3, "web_search")
+		}
+	}
+	now := time.Now()
+	r := Aggregate(f.jobs, f.events, now.Add(-time.Hour), now, Options{})
+	if !r.Suppressed || r.Tenants != 0 || r.JobsAnalyzed != 0 || len(r.PopularTools) != 0 || len(r.FailureRatesByModel) != 0 || r.PlanSize != nil {
+		t.Fatalf("report = %+v", r)
+	}
+	if r.Thresholds.MinTenants != DefaultMinTenants {
+		t.Fatalf("thresholds = %+v", r.Thresholds)
+	}
+}
@@ -0,0 +1,449 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anomaly 维护每个 Agent 的行为基线（常用工具、工具调用次数、成本、时长），
+// 对明显偏离基线的 Job（首次使用破坏性工具、工具调用量暴增等）标记为异常。
+package anomaly
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// 异常类型
+const (
+	KindNewTool         = "new_tool"         // 使用了基线中从未出现的工具
+	KindDestructiveTool = "destructive_tool" // 首次使用破坏性类别的工具（network-write、payments、code-exec 等）
+	KindToolCallsSpike  = "tool_calls_spike" // 工具调用次数远超基线
+	KindCostSpike       = "cost_spike"       // 预估成本远超基线
+	KindDurationSpike   = "duration_spike"   // 时长远超基线
+)
+
+// 严重程度
+const (
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+const (
+	// DefaultMinSamples 基线至少学习的 Job 数，不足时只学习不判定
+	DefaultMinSamples = 10
+	// DefaultFactor 数值类指标超过基线均值的倍数才视为异常
+	DefaultFactor = 3.0
+	// DefaultSigma 数值类指标同时需超过均值 + Sigma 倍标准差
+	DefaultSigma = 3.0
+	// DefaultAlpha 指数滑动均值/方差系数
+	DefaultAlpha = 0.1
+	// DefaultMaxAnomalies 内存中保留的最近异常数
+	DefaultMaxAnomalies = 1000
+	// highRatio 数值类指标达到基线均值该倍数时为 high
+	highRatio = 10.0
+)
+
+// DefaultDestructiveCategories 默认的破坏性工具类别（与全局熔断的类别一致）
+var DefaultDestructiveCategories = []string{"network-write", "payments", "code-exec"}
+
+// Options 检测参数；零值字段使用默认值
+type Options struct {
+	MinSamples            int
+	Factor                float64
+	Sigma                 float64
+	Alpha                 float64
+	DestructiveCategories []string
+	MaxAnomalies          int
+}
+
+// Anomaly 单条异常；ID 由 job_id/kind[/tool] 组成，同一 Job 重复检测结果一致
+type Anomaly struct {
+	ID         string    `json:"id"`
+	JobID      string    `json:"job_id"`
+	AgentID    string    `json:"agent_id"`
+	TenantID   string    `json:"tenant_id,omitempty"`
+	Kind       string    `json:"kind"`
+	Severity   string    `json:"severity"`
+	Tool       string    `json:"tool,omitempty"`
+	Observed   float64   `json:"observed,omitempty"`
+	Baseline   float64   `json:"baseline,omitempty"`
+	Message    string    `json:"message"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Profile 单个 Job 的行为画像
+type Profile struct {
+	ToolCalls  map[string]int // 工具名 → 调用次数
+	Calls      int
+	Cost       float64
+	DurationMs float64
+}
+
+// Stat 基线中数值指标的均值与标准差
+type Stat struct {
+	Mean float64 `json:"mean"`
+	Std  float64 `json:"std"`
+}
+
+// Baseline 单个 Agent 的行为基线
+type Baseline struct {
+	AgentID    string         `json:"agent_id"`
+	Samples    int            `json:"samples"`
+	Tools      map[string]int `json:"tools"` // 工具名 → 使用过该工具的 Job 数
+	ToolCalls  Stat           `json:"tool_calls"`
+	Cost       Stat           `json:"cost"`
+	DurationMs Stat           `json:"duration_ms"`
+}
+
+// Filter 异常列表过滤条件；零值字段不过滤
+type Filter struct {
+	TenantID string
+	AgentID  string
+	Kind     string
+	Since    time.Time
+	Limit    int
+}
+
+// rolling 指数加权均值与方差
+type rolling struct {
+	mean     float64
+	variance float64
+	count    int
+}
+
+func (r *rolling) add(v, alpha float64) {
+	if r.count == 0 {
+		r.mean = v
+	} else {
+		diff := v - r.mean
+		incr := alpha * diff
+		r.mean += incr
+		r.variance = (1 - alpha) * (r.variance + diff*incr)
+	}
+	r.count++
+}
+
+func (r *rolling) stat() Stat {
+	return Stat{Mean: r.mean, Std: math.Sqrt(r.variance)}
+}
+
+type baseline struct {
+	jobs     int
+	tools    map[string]int
+	calls    rolling
+	cost     rolling
+	duration rolling
+}
+
+// Detector 按 Agent 维护行为基线并判定异常；并发安全
+type Detector struct {
+	opts        Options
+	costs       planner.CostModel
+	categories  func(tool string) []string
+	destructive map[string]struct{}
+
+	mu        sync.RWMutex
+	baselines map[string]*baseline
+	recent    []Anomaly
+	index     map[string]struct{} // 已记录的异常 ID
+}
+
+// NewDetector 创建检测器；costs 用于估算 Job 成本，categories 返回工具类别（可为 nil）
+func NewDetector(opts Options, costs planner.CostModel, categories func(tool string) []string) *Detector {
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = DefaultMinSamples
+	}
+	if opts.Factor <= 1 {
+		opts.Factor = DefaultFactor
+	}
+	if opts.Sigma <= 0 {
+		opts.Sigma = DefaultSigma
+	}
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = DefaultAlpha
+	}
+	if opts.MaxAnomalies <= 0 {
+		opts.MaxAnomalies = DefaultMaxAnomalies
+	}
+	cats := opts.DestructiveCategories
+	if len(cats) == 0 {
+		cats = DefaultDestructiveCategories
+	}
+	destructive := make(map[string]struct{}, len(cats))
+	for _, c := range cats {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			destructive[c] = struct{}{}
+		}
+	}
+	return &Detector{
+		opts:        opts,
+		costs:       costs,
+		categories:  categories,
+		destructive: destructive,
+		baselines:   make(map[string]*baseline),
+		index:       make(map[string]struct{}),
+	}
+}
+
+// ProfileOf 从 Job 事件流提取行为画像：工具调用来自 tool_invocation_started，LLM 调用按计划中 llm 节点的 node_finished 计
+func (d *Detector) ProfileOf(j *job.Job, events []jobstore.JobEvent) Profile {
+	p := Profile{ToolCalls: make(map[string]int)}
+	llmNodes := make(map[string]bool)
+	llmCalls := 0
+	var end time.Time
+	for _, e := range events {
+		switch e.Type {
+		case jobstore.PlanGenerated, jobstore.PlanEvolution:
+			var pl struct {
+				TaskGraph *planner.TaskGraph `json:"task_graph"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.TaskGraph == nil {
+				continue
+			}
+			for _, n := range pl.TaskGraph.Nodes {
+				llmNodes[n.ID] = n.Type == planner.NodeLLM
+			}
+		case jobstore.ToolInvocationStarted:
+			var pl struct {
+				ToolName string `json:"tool_name"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.ToolName == "" {
+				continue
+			}
+			p.ToolCalls[pl.ToolName]++
+			p.Calls++
+		case jobstore.NodeFinished:
+			var pl struct {
+				NodeID string `json:"node_id"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && llmNodes[pl.NodeID] {
+				llmCalls++
+			}
+		case jobstore.JobCompleted, jobstore.JobFailed, jobstore.JobCancelled:
+			end = e.CreatedAt
+		}
+	}
+	for name, n := range p.ToolCalls {
+		p.Cost += float64(n) * d.costs.Tools[name].CostPerCall
+	}
+	p.Cost += float64(llmCalls) * d.costs.LLM.CostPerCall
+	if j != nil {
+		if end.IsZero() {
+			end = j.UpdatedAt
+		}
+		if !j.CreatedAt.IsZero() && end.After(j.CreatedAt) {
+			p.DurationMs = float64(end.Sub(j.CreatedAt).Milliseconds())
+		}
+	}
+	return p
+}
+
+func (d *Detector) isDestructive(tool string) bool {
+	if d.categories == nil {
+		return false
+	}
+	for _, c := range d.categories(tool) {
+		if _, ok := d.destructive[strings.ToLower(c)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate 将画像与 Agent 基线比较；基线样本不足 MinSamples 时返回 nil。不修改基线
+func (d *Detector) Evaluate(j *job.Job, p Profile, now time.Time) []Anomaly {
+	if j == nil {
+		return nil
+	}
+	d.mu.RLock()
+	b := d.baselines[j.AgentID]
+	var (
+		known            map[string]bool
+		calls, cost, dur Stat
+		samples          int
+	)
+	if b != nil {
+		samples = b.jobs
+		known = make(map[string]bool, len(b.tools))
+		for t := range b.tools {
+			known[t] = true
+		}
+		calls, cost, dur = b.calls.stat(), b.cost.stat(), b.duration.stat()
+	}
+	d.mu.RUnlock()
+	if samples < d.opts.MinSamples {
+		return nil
+	}
+
+	var out []Anomaly
+	add := func(kind, severity, tool string, observed, base float64, msg string) {
+		id := j.ID + "/" + kind
+		if tool != "" {
+			id += "/" + tool
+		}
+		out = append(out, Anomaly{
+			ID: id, JobID: j.ID, AgentID: j.AgentID, TenantID: j.TenantID,
+			Kind: kind, Severity: severity, Tool: tool,
+			Observed: observed, Baseline: base, Message: msg, DetectedAt: now,
+		})
+	}
+	tools := make([]string, 0, len(p.ToolCalls))
+	for t := range p.ToolCalls {
+		if !known[t] {
+			tools = append(tools, t)
+		}
+	}
+	sort.Strings(tools)
+	for _, t := range tools {
+		if d.isDestructive(t) {
+			add(KindDestructiveTool, SeverityHigh, t, float64(p.ToolCalls[t]), 0,
+				fmt.Sprintf("first use of destructive tool %q (not seen in %d baseline jobs)", t, samples))
+			continue
+		}
+		add(KindNewTool, SeverityMedium, t, float64(p.ToolCalls[t]), 0,
+			fmt.Sprintf("first use of tool %q (not seen in %d baseline jobs)", t, samples))
+	}
+	if sev, ok := d.spike(float64(p.Calls), calls); ok {
+		add(KindToolCallsSpike, sev, "", float64(p.Calls), calls.Mean,
+			fmt.Sprintf("%d tool calls vs baseline mean %.1f", p.Calls, calls.Mean))
+	}
+	if sev, ok := d.spike(p.Cost, cost); ok {
+		add(KindCostSpike, sev, "", p.Cost, cost.Mean,
+			fmt.Sprintf("estimated cost %.4f vs baseline mean %.4f", p.Cost, cost.Mean))
+	}
+	if sev, ok := d.spike(p.DurationMs, dur); ok {
+		add(KindDurationSpike, sev, "", p.DurationMs, dur.Mean,
+			fmt.Sprintf("duration %.0fms vs baseline mean %.0fms", p.DurationMs, dur.Mean))
+	}
+	return out
+}
+
+// spike 数值需同时超过 mean*Factor 与 mean+Sigma*std；均值为 0 的指标（如未配置成本）不判定
+func (d *Detector) spike(v float64, s Stat) (string, bool) {
+	if s.Mean <= 0 || v < s.Mean*d.opts.Factor || v <= s.Mean+d.opts.Sigma*s.Std {
+		return "", false
+	}
+	if v >= s.Mean*highRatio {
+		return SeverityHigh, true
+	}
+	return SeverityMedium, true
+}
+
+// Learn 将画像计入 Agent 基线
+func (d *Detector) Learn(agentID string, p Profile) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b := d.baselines[agentID]
+	if b == nil {
+		b = &baseline{tools: make(map[string]int)}
+		d.baselines[agentID] = b
+	}
+	b.jobs++
+	for t := range p.ToolCalls {
+		b.tools[t]++
+	}
+	b.calls.add(float64(p.Calls), d.opts.Alpha)
+	b.cost.add(p.Cost, d.opts.Alpha)
+	b.duration.add(p.DurationMs, d.opts.Alpha)
+}
+
+// Observe 处理一个终态 Job：detect 为 true 时先判定异常并记录；无异常的已完成 Job 计入基线（异常 Job 不污染基线）。
+// 返回本次新记录的异常（此前已记录过的同 ID 异常不重复返回）
+func (d *Detector) Observe(j *job.Job, events []jobstore.JobEvent, detect bool, now time.Time) []Anomaly {
+	if j == nil || !j.Status.IsTerminal() {
+		return nil
+	}
+	p := d.ProfileOf(j, events)
+	var found []Anomaly
+	if detect {
+		found = d.Evaluate(j, p, now)
+	}
+	if len(found) == 0 {
+		if j.Status == job.StatusCompleted {
+			d.Learn(j.AgentID, p)
+		}
+		return nil
+	}
+	return d.record(found)
+}
+
+func (d *Detector) record(found []Anomaly) []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var added []Anomaly
+	for _, a := range found {
+		if _, ok := d.index[a.ID]; ok {
+			continue
+		}
+		d.index[a.ID] = struct{}{}
+		d.recent = append(d.recent, a)
+		added = append(added, a)
+	}
+	if over := len(d.recent) - d.opts.MaxAnomalies; over > 0 {
+		for _, a := range d.recent[:over] {
+			delete(d.index, a.ID)
+		}
+		d.recent = append([]Anomaly(nil), d.recent[over:]...)
+	}
+	return added
+}
+
+// List 按时间倒序返回最近的异常
+func (d *Detector) List(f Filter) []Anomaly {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]Anomaly, 0)
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		a := d.recent[i]
+		if (f.TenantID != "" && a.TenantID != f.TenantID) ||
+			(f.AgentID != "" && a.AgentID != f.AgentID) ||
+			(f.Kind != "" && a.Kind != f.Kind) ||
+			(!f.Since.IsZero() && a.DetectedAt.Before(f.Since)) {
+			continue
+		}
+		out = append(out, a)
+		if f.Limit > 0 && len(out) >= f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// Baseline 返回 Agent 的行为基线快照；尚无样本时 ok=false
+func (d *Detector) Baseline(agentID string) (*Baseline, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	b := d.baselines[agentID]
+	if b == nil {
+		return nil, false
+	}
+	tools := make(map[string]int, len(b.tools))
+	for t, n := range b.tools {
+		tools[t] = n
+	}
+	return &Baseline{
+		AgentID:    agentID,
+		Samples:    b.jobs,
+		Tools:      tools,
+		ToolCalls:  b.calls.stat(),
+		Cost:       b.cost.stat(),
+		DurationMs: b.duration.stat(),
+	}, true
+}
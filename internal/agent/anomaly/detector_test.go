@@ -0,0 +1,209 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+var t0 = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ev(t *testing.T, typ jobstore.EventType, payload map[string]interface{}) jobstore.JobEvent {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobstore.JobEvent{Type: typ, CreatedAt: t0, Payload: b}
+}
+
+// trace 生成按 calls 顺序调用工具、总时长 total 的完成事件流
+func trace(t *testing.T, total time.Duration, calls ...string) []jobstore.JobEvent {
+	events := []jobstore.JobEvent{ev(t, jobstore.PlanGenerated, map[string]interface{}{
+		"task_graph": map[string]interface{}{"nodes": []map[string]interface{}{{"id": "n1", "type": "llm"}}},
+	})}
+	for _, c := range calls {
+		events = append(events, ev(t, jobstore.ToolInvocationStarted, map[string]interface{}{"tool_name": c}))
+	}
+	events = append(events, ev(t, jobstore.NodeFinished, map[string]interface{}{"node_id": "n1"}))
+	done := ev(t, jobstore.JobCompleted, nil)
+	done.CreatedAt = t0.Add(total)
+	return append(events, done)
+}
+
+func completed(id string) *job.Job {
+	return &job.Job{ID: id, AgentID: "a1", TenantID: "t1", Status: job.StatusCompleted, CreatedAt: t0}
+}
+
+func categories(tool string) []string {
+	if tool == "stripe.charge" {
+		return []string{"payments"}
+	}
+	return nil
+}
+
+func newTestDetector() *Detector {
+	costs := planner.CostModel{
+		Tools: map[string]planner.ToolCostHint{"search": {CostPerCall: 0.01}, "stripe.charge": {CostPerCall: 5}},
+		LLM:   planner.ToolCostHint{CostPerCall: 0.1},
+	}
+	return NewDetector(Options{MinSamples: 3}, costs, categories)
+}
+
+func TestDetector_ProfileOf(t *testing.T) {
+	d := newTestDetector()
+	p := d.ProfileOf(completed("j1"), trace(t, 10*time.Second, "search", "search", "http"))
+	if p.Calls != 3 || p.ToolCalls["search"] != 2 || p.ToolCalls["http"] != 1 {
+		t.Fatalf("profile = %+v", p)
+	}
+	if p.Cost < 0.1199 || p.Cost > 0.1201 || p.DurationMs != 10000 {
+		t.Fatalf("cost = %v duration = %v", p.Cost, p.DurationMs)
+	}
+}
+
+func TestDetector_FlagsDeviationsAfterWarmup(t *testing.T) {
+	d := newTestDetector()
+	for i := 0; i < 2; i++ {
+		if got := d.Observe(completed("warm"), trace(t, 10*time.Second, "search", "stripe.charge"), true, t0); got != nil {
+			t.Fatalf("no anomalies expected below min samples: %+v", got)
+		}
+	}
+	d.Observe(completed("warm"), trace(t, 10*time.Second, "search"), true, t0)
+	if b, ok := d.Baseline("a1"); !ok || b.Samples != 3 || b.Tools["search"] != 3 {
+		t.Fatalf("baseline = %+v", b)
+	}
+
+	normal := d.Observe(completed("ok"), trace(t, 12*time.Second, "search", "search"), true, t0)
+	if len(normal) != 0 {
+		t.Fatalf("normal job flagged: %+v", normal)
+	}
+
+	d2 := newTestDetector()
+	for i := 0; i < 3; i++ {
+		d2.Observe(completed("warm"), trace(t, 10*time.Second, "search"), true, t0)
+	}
+	calls := make([]string, 0, 12)
+	for i := 0; i < 10; i++ {
+		calls = append(calls, "search")
+	}
+	calls = append(calls, "stripe.charge", "http")
+	found := d2.Observe(completed("bad"), trace(t, 10*time.Second, calls...), true, t0)
+	kinds := map[string]Anomaly{}
+	for _, a := range found {
+		kinds[a.Kind] = a
+	}
+	if a, ok := kinds[KindDestructiveTool]; !ok || a.Tool != "stripe.charge" || a.Severity != SeverityHigh {
+		t.Fatalf("destructive tool not flagged: %+v", found)
+	}
+	if a, ok := kinds[KindNewTool]; !ok || a.Tool != "http" || a.Severity != SeverityMedium {
+		t.Fatalf("new tool not flagged: %+v", found)
+	}
+	if a, ok := kinds[KindToolCallsSpike]; !ok || a.Observed != 12 || a.Baseline != 1 || a.Severity != SeverityHigh {
+		t.Fatalf("call spike not flagged: %+v", found)
+	}
+	if _, ok := kinds[KindCostSpike]; !ok {
+		t.Fatalf("cost spike not flagged: %+v", found)
+	}
+	if _, ok := kinds[KindDurationSpike]; ok {
+		t.Fatalf("duration unchanged but flagged: %+v", found)
+	}
+	// 异常 Job 不计入基线
+	if b, _ := d2.Baseline("a1"); b.Samples != 3 || b.Tools["stripe.charge"] != 0 {
+		t.Fatalf("anomalous job polluted baseline: %+v", b)
+	}
+	// 同一 Job 再次处理不重复记录
+	if again := d2.Observe(completed("bad"), trace(t, 10*time.Second, calls...), true, t0); len(again) != 0 {
+		t.Fatalf("duplicate anomalies: %+v", again)
+	}
+	if got := d2.List(Filter{AgentID: "a1", Kind: KindNewTool}); len(got) != 1 || got[0].ID != "bad/new_tool/http" {
+		t.Fatalf("list = %+v", got)
+	}
+	if got := d2.List(Filter{AgentID: "other"}); len(got) != 0 {
+		t.Fatalf("list other agent = %+v", got)
+	}
+}
+
+type fakeLister struct{ jobs []*job.Job }
+
+func (f *fakeLister) ListUpdatedSince(ctx context.Context, tenantID string, since time.Time, limit int) ([]*job.Job, error) {
+	return f.jobs, nil
+}
+
+func TestLearner_EmitsEventAndWebhook(t *testing.T) {
+	var got WebhookPayload
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	lister := &fakeLister{}
+	seed := func(id string, calls ...string) {
+		for i, e := range trace(t, 10*time.Second, calls...) {
+			e.JobID = id
+			if _, err := store.Append(ctx, id, i, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		j := completed(id)
+		j.UpdatedAt = t0
+		lister.jobs = append(lister.jobs, j)
+	}
+	for _, id := range []string{"w1", "w2", "w3"} {
+		seed(id, "search")
+	}
+	det := newTestDetector()
+	l := NewLearner(det, lister, store, NewWebhookNotifier(srv.URL, "s3cret", time.Second), nil)
+	l.now = func() time.Time { return t0.Add(time.Hour) }
+	if n, err := l.RunOnce(ctx); err != nil || n != 0 {
+		t.Fatalf("warmup run = %d, %v", n, err)
+	}
+
+	lister.jobs = nil
+	// 首次调用支付工具：破坏性工具 + 成本暴增
+	seed("bad", "search", "stripe.charge")
+	if n, err := l.RunOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("run = %d, %v", n, err)
+	}
+	events, _, err := store.ListEvents(ctx, "bad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := events[len(events)-1]
+	var payload EventPayload
+	if last.Type != jobstore.AgentAnomalyDetected || json.Unmarshal(last.Payload, &payload) != nil || len(payload.Anomalies) != 2 {
+		t.Fatalf("last event = %s %s", last.Type, last.Payload)
+	}
+	if got.Type != "agent_anomaly" || len(got.Anomalies) != 2 || got.Anomalies[0].Kind != KindDestructiveTool {
+		t.Fatalf("webhook payload = %+v", got)
+	}
+	if len(signature) != len("sha256=")+64 {
+		t.Fatalf("signature = %q", signature)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+	"rag-platform/pkg/metrics"
+)
+
+const (
+	// DefaultScanInterval 默认扫描间隔
+	DefaultScanInterval = time.Minute
+	// DefaultWarmup 首轮回溯窗口：启动时从该时长内已完成的 Job 学习基线（首轮只学习、不判定）
+	DefaultWarmup = 7 * 24 * time.Hour
+	// DefaultBatch 单轮最多扫描的 Job 数
+	DefaultBatch = 1000
+	// scanOverlap 相邻两轮扫描窗口的重叠，避免 updated_at 与扫描时刻交错时漏读
+	scanOverlap = time.Minute
+)
+
+// EventPayload agent_anomaly_detected 事件 payload
+type EventPayload struct {
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// Learner 周期扫描最近进入终态的 Job：判定异常、写入 agent_anomaly_detected 事件并告警，正常完成的 Job 计入基线
+type Learner struct {
+	det      *Detector
+	jobs     job.RecentJobLister
+	events   jobstore.JobStore
+	notifier Notifier
+	logger   *log.Logger
+	now      func() time.Time
+
+	mu    sync.Mutex
+	since time.Time
+	seen  map[string]time.Time // job_id → 处理时的 updated_at
+}
+
+// NewLearner 创建扫描器；notifier 可为 nil
+func NewLearner(det *Detector, jobs job.RecentJobLister, events jobstore.JobStore, notifier Notifier, logger *log.Logger) *Learner {
+	return &Learner{det: det, jobs: jobs, events: events, notifier: notifier, logger: logger, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// Run 按 interval 周期扫描直到 ctx 取消；启动时立即执行一轮
+func (l *Learner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultScanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := l.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			l.logf("Agent 行为异常扫描failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 扫描上轮以来更新的终态 Job；返回本轮新发现的异常数。首轮（预热）只学习基线
+func (l *Learner) RunOnce(ctx context.Context) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start := l.now()
+	since := l.since
+	warmup := since.IsZero()
+	if warmup {
+		since = start.Add(-DefaultWarmup)
+	}
+	jobs, err := l.jobs.ListUpdatedSince(ctx, "", since, DefaultBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list recent jobs: %w", err)
+	}
+	found := 0
+	for _, j := range jobs {
+		if j == nil || !j.Status.IsTerminal() {
+			continue
+		}
+		if _, ok := l.seen[j.ID]; ok {
+			continue
+		}
+		events, version, err := l.events.ListEvents(ctx, j.ID)
+		if err != nil {
+			l.logf("读取 Job 事件failed", "job_id", j.ID, "error", err)
+			continue
+		}
+		l.seen[j.ID] = j.UpdatedAt
+		anomalies := l.det.Observe(j, events, !warmup, start)
+		if len(anomalies) == 0 {
+			continue
+		}
+		found += len(anomalies)
+		for _, a := range anomalies {
+			metrics.AnomalyDetectedTotal.WithLabelValues(a.Kind, a.Severity).Inc()
+		}
+		// 其他 API 实例已写过异常事件时不重复写入与告警
+		if hasAnomalyEvent(events) {
+			continue
+		}
+		l.emit(ctx, j.ID, version, anomalies)
+	}
+	l.since = start.Add(-scanOverlap)
+	for id, at := range l.seen {
+		if at.Before(l.since) {
+			delete(l.seen, id)
+		}
+	}
+	return found, nil
+}
+
+func hasAnomalyEvent(events []jobstore.JobEvent) bool {
+	for _, e := range events {
+		if e.Type == jobstore.AgentAnomalyDetected {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Learner) emit(ctx context.Context, jobID string, version int, anomalies []Anomaly) {
+	payload, err := json.Marshal(EventPayload{Anomalies: anomalies})
+	if err == nil {
+		_, err = l.events.Append(ctx, jobID, version, jobstore.JobEvent{
+			JobID: jobID, Type: jobstore.AgentAnomalyDetected, Payload: payload,
+		})
+	}
+	if err != nil {
+		l.logf("写入 agent_anomaly_detected 事件failed", "job_id", jobID, "error", err)
+	}
+	if l.notifier != nil {
+		if err := l.notifier.Notify(ctx, anomalies); err != nil {
+			l.logf("异常告警投递failed", "job_id", jobID, "error", err)
+		}
+	}
+}
+
+func (l *Learner) logf(msg string, args ...any) {
+	if l.logger != nil {
+		l.logger.Warn(msg, args...)
+	}
+}
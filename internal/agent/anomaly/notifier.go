@@ -0,0 +1,92 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultWebhookTimeout 单次告警投递超时
+const DefaultWebhookTimeout = 5 * time.Second
+
+// SignatureHeader 配置 secret 时携带的请求体签名头：sha256=<hex(HMAC-SHA256(secret, body))>
+const SignatureHeader = "X-Aetheris-Signature"
+
+// Notifier 异常告警出口
+type Notifier interface {
+	Notify(ctx context.Context, anomalies []Anomaly) error
+}
+
+// WebhookPayload 告警请求体
+type WebhookPayload struct {
+	Type      string    `json:"type"` // 固定为 agent_anomaly
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// WebhookNotifier 以 JSON POST 投递异常告警
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookNotifier 创建 Webhook 告警；timeout<=0 时使用 DefaultWebhookTimeout
+func NewWebhookNotifier(url, secret string, timeout time.Duration) *WebhookNotifier {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &WebhookNotifier{url: url, secret: secret, client: &http.Client{Timeout: timeout}}
+}
+
+// Notify 投递一批异常；非 2xx 视为失败
+func (n *WebhookNotifier) Notify(ctx context.Context, anomalies []Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(WebhookPayload{Type: "agent_anomaly", Anomalies: anomalies})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("anomaly webhook: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package branding 租户品牌设置（标题、Logo、自定义字段），渲染 Trace 页面页头与证据包离线报告时注入
+package branding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotFound 租户未配置品牌
+var ErrNotFound = errors.New("branding: not found")
+
+// 校验上限
+const (
+	MaxTitleLen    = 200
+	MaxFields      = 20
+	MaxLabelLen    = 64
+	MaxValueLen    = 500
+	MaxLogoDataLen = 256 << 10
+)
+
+// Field 页头展示的自定义字段（如合同编号、客户名称），按配置顺序显示
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Branding 租户品牌；LogoURL 为 https URL 或 data:image/...;base64 URI（后者使离线报告保持自包含）
+type Branding struct {
+	TenantID  string    `json:"-"`
+	Title     string    `json:"title,omitempty"`
+	LogoURL   string    `json:"logo_url,omitempty"`
+	Fields    []Field   `json:"fields,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsZero 未设置任何可展示内容
+func (b *Branding) IsZero() bool {
+	return b == nil || (b.Title == "" && b.LogoURL == "" && len(b.Fields) == 0)
+}
+
+// Validate 校验长度与 Logo 格式
+func (b *Branding) Validate() error {
+	if utf8.RuneCountInString(b.Title) > MaxTitleLen {
+		return fmt.Errorf("title exceeds %d characters", MaxTitleLen)
+	}
+	if err := validateLogo(b.LogoURL); err != nil {
+		return err
+	}
+	if len(b.Fields) > MaxFields {
+		return fmt.Errorf("at most %d fields are allowed", MaxFields)
+	}
+	for i, f := range b.Fields {
+		if strings.TrimSpace(f.Label) == "" {
+			return fmt.Errorf("fields[%d].label is required", i)
+		}
+		if utf8.RuneCountInString(f.Label) > MaxLabelLen {
+			return fmt.Errorf("fields[%d].label exceeds %d characters", i, MaxLabelLen)
+		}
+		if utf8.RuneCountInString(f.Value) > MaxValueLen {
+			return fmt.Errorf("fields[%d].value exceeds %d characters", i, MaxValueLen)
+		}
+	}
+	return nil
+}
+
+// logoImageTypes data URI 允许的图片类型
+var logoImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "image/svg+xml"}
+
+func validateLogo(logo string) error {
+	switch {
+	case logo == "":
+		return nil
+	case strings.HasPrefix(logo, "https://"):
+		if strings.ContainsAny(logo, " \"'<>") {
+			return errors.New("logo_url contains invalid characters")
+		}
+		return nil
+	case strings.HasPrefix(logo, "data:"):
+		if len(logo) > MaxLogoDataLen {
+			return fmt.Errorf("logo_url data URI exceeds %d bytes", MaxLogoDataLen)
+		}
+		mediaType, ok := strings.CutSuffix(strings.SplitN(strings.TrimPrefix(logo, "data:"), ",", 2)[0], ";base64")
+		if !ok {
+			return errors.New("logo_url data URI must be base64 encoded")
+		}
+		for _, t := range logoImageTypes {
+			if mediaType == t {
+				return nil
+			}
+		}
+		return fmt.Errorf("logo_url media type %q is not an allowed image type", mediaType)
+	default:
+		return errors.New("logo_url must be an https URL or a data:image URI")
+	}
+}
+
+// Store 租户品牌存储
+type Store interface {
+	// Get 读取租户品牌；未配置返回 ErrNotFound
+	Get(ctx context.Context, tenantID string) (*Branding, error)
+	// Put 创建或整体替换租户品牌
+	Put(ctx context.Context, b *Branding) error
+	// Delete 删除租户品牌；不存在返回 ErrNotFound
+	Delete(ctx context.Context, tenantID string) error
+}
+
+// StoreMem 内存实现
+type StoreMem struct {
+	mu    sync.RWMutex
+	items map[string]*Branding
+}
+
+// NewStoreMem 创建内存品牌存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{items: make(map[string]*Branding)}
+}
+
+func cloneBranding(b *Branding) *Branding {
+	cp := *b
+	cp.Fields = append([]Field(nil), b.Fields...)
+	return &cp
+}
+
+func (s *StoreMem) Get(ctx context.Context, tenantID string) (*Branding, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.items[tenantID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneBranding(b), nil
+}
+
+func (s *StoreMem) Put(ctx context.Context, b *Branding) error {
+	if b == nil {
+		return errors.New("branding is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := cloneBranding(b)
+	cp.UpdatedAt = time.Now()
+	s.items[cp.TenantID] = cp
+	return nil
+}
+
+func (s *StoreMem) Delete(ctx context.Context, tenantID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[tenantID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.items, tenantID)
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 tenant_branding 表
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的品牌存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+func (s *StorePg) Get(ctx context.Context, tenantID string) (*Branding, error) {
+	var data []byte
+	var updatedAt time.Time
+	err := s.pool.QueryRow(ctx,
+		`SELECT branding, updated_at FROM tenant_branding WHERE tenant_id = $1`, tenantID).Scan(&data, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := &Branding{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	b.TenantID, b.UpdatedAt = tenantID, updatedAt
+	return b, nil
+}
+
+func (s *StorePg) Put(ctx context.Context, b *Branding) error {
+	if b == nil {
+		return errors.New("branding is nil")
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO tenant_branding (tenant_id, branding) VALUES ($1, $2)
+		 ON CONFLICT (tenant_id) DO UPDATE SET branding = EXCLUDED.branding, updated_at = now()`,
+		b.TenantID, data)
+	return err
+}
+
+func (s *StorePg) Delete(ctx context.Context, tenantID string) error {
+	cmd, err := s.pool.Exec(ctx, `DELETE FROM tenant_branding WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	ok := []*Branding{
+		{},
+		{Title: "ACME 审计报告", LogoURL: "https://cdn.example.com/logo.png", Fields: []Field{{Label: "合同编号", Value: "C-2026-001"}}},
+		{LogoURL: "data:image/svg+xml;base64,PHN2Zy8+"},
+	}
+	for i, b := range ok {
+		if err := b.Validate(); err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+		}
+	}
+	bad := map[string]*Branding{
+		"title too long":    {Title: strings.Repeat("x", MaxTitleLen+1)},
+		"http logo":         {LogoURL: "http://example.com/logo.png"},
+		"javascript logo":   {LogoURL: "javascript:alert(1)"},
+		"quoted logo":       {LogoURL: "https://example.com/a\"onerror=\"x"},
+		"non-image data":    {LogoURL: "data:text/html;base64,PGgxPg=="},
+		"data not base64":   {LogoURL: "data:image/png,abc"},
+		"empty label":       {Fields: []Field{{Label: " ", Value: "v"}}},
+		"value too long":    {Fields: []Field{{Label: "l", Value: strings.Repeat("v", MaxValueLen+1)}}},
+		"too many fields":   {Fields: make([]Field, MaxFields+1)},
+		"data logo too big": {LogoURL: "data:image/png;base64," + strings.Repeat("A", MaxLogoDataLen)},
+	}
+	for name, b := range bad {
+		if err := b.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestStoreMem(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	if _, err := s.Get(ctx, "t1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Put: got %v, want ErrNotFound", err)
+	}
+	b := &Branding{TenantID: "t1", Title: "ACME", Fields: []Field{{Label: "客户", Value: "ACME Corp"}}}
+	if err := s.Put(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	b.Fields[0].Value = "mutated"
+	got, err := s.Get(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "ACME" || got.Fields[0].Value != "ACME Corp" || got.UpdatedAt.IsZero() {
+		t.Fatalf("Get = %+v", got)
+	}
+	if _, err := s.Get(ctx, "t2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("other tenant: got %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "t1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete: got %v, want ErrNotFound", err)
+	}
+}
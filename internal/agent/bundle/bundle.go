@@ -0,0 +1,270 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bundle Agent 状态的可移植包：导出 Agent 规格、会话记忆快照、Agent 级配置与规划示例（不含 Job 历史），
+// 导入到另一环境时重映射 ID 并按冲突策略合并，用于将调优后的 Agent 从 staging 推广到 production。
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/agentconfig"
+)
+
+// Format 当前包格式；导入时不一致则拒绝
+const Format = "aetheris.agent-bundle/v1"
+
+// ErrConflict 冲突策略为 fail 时目标 Agent 已有同名状态；详见 ImportResult.Conflicts
+var ErrConflict = errors.New("bundle: conflicts with target agent state")
+
+// ConflictPolicy 导入到已有 Agent 时同名状态的处理方式
+type ConflictPolicy string
+
+const (
+	// ConflictFail 存在任何冲突即中止，不写入任何状态（默认）
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictSkip 保留目标已有状态，仅写入无冲突部分
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite 以包内状态覆盖目标
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
+
+// ParseConflictPolicy 解析冲突策略；空为 fail
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return ConflictFail, nil
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (fail|skip|overwrite)", s)
+	}
+}
+
+// AgentSpec Agent 规格（不含运行期状态）
+type AgentSpec struct {
+	Name       string         `json:"name"`
+	BehaviorID string         `json:"behavior_id,omitempty"`
+	Meta       map[string]any `json:"meta,omitempty"`
+}
+
+// ConfigEntry Agent 级配置项；secret 仅导出引用，值由目标环境的 secret 存储解析
+type ConfigEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value,omitempty"`
+	SecretRef string `json:"secret_ref,omitempty"`
+}
+
+// Exemplar 规划示例（导入时分配新 ID）
+type Exemplar struct {
+	ID       string             `json:"id"`
+	Goal     string             `json:"goal"`
+	Graph    *planner.TaskGraph `json:"graph"`
+	Note     string             `json:"note,omitempty"`
+	Disabled bool               `json:"disabled,omitempty"`
+}
+
+// Bundle 可移植的 Agent 状态包
+type Bundle struct {
+	Format        string                   `json:"format"`
+	ExportedAt    time.Time                `json:"exported_at"`
+	SourceAgentID string                   `json:"source_agent_id"`
+	Agent         AgentSpec                `json:"agent"`
+	Memory        *agentruntime.AgentState `json:"memory,omitempty"`
+	Config        []ConfigEntry            `json:"config,omitempty"`
+	Exemplars     []Exemplar               `json:"planner_exemplars,omitempty"`
+}
+
+// Validate 校验格式与配置键
+func (b *Bundle) Validate() error {
+	if b == nil {
+		return errors.New("bundle is empty")
+	}
+	if b.Format != Format {
+		return fmt.Errorf("unsupported bundle format %q (want %q)", b.Format, Format)
+	}
+	for _, e := range b.Config {
+		if err := agentconfig.ValidateKey(e.Key); err != nil {
+			return err
+		}
+	}
+	for i, e := range b.Exemplars {
+		if strings.TrimSpace(e.Goal) == "" || e.Graph == nil {
+			return fmt.Errorf("planner exemplar #%d: goal and graph are required", i)
+		}
+	}
+	return nil
+}
+
+// Sources 导出/导入涉及的存储；为 nil 的部分跳过
+type Sources struct {
+	Config    agentconfig.Store
+	Exemplars planner.ExemplarStore
+}
+
+// Export 由 Agent 当前状态构建包；spec 由调用方提供（名称与实例元数据）
+func Export(ctx context.Context, agent *agentruntime.Agent, spec AgentSpec, src Sources) (*Bundle, error) {
+	b := &Bundle{Format: Format, ExportedAt: time.Now().UTC(), SourceAgentID: agent.ID, Agent: spec}
+	if state := agentruntime.SessionToAgentState(agent.Session); state != nil {
+		// 检查点属于源环境的 Job 历史，不随包迁移
+		state.LastCheckpoint = ""
+		b.Memory = state
+	}
+	if src.Config != nil {
+		entries, err := src.Config.List(ctx, agent.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list agent config: %w", err)
+		}
+		for _, e := range entries {
+			b.Config = append(b.Config, ConfigEntry{Key: e.Key, Value: e.Value, SecretRef: e.SecretRef})
+		}
+	}
+	if src.Exemplars != nil {
+		list, err := src.Exemplars.List(ctx, agent.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list planner exemplars: %w", err)
+		}
+		for _, e := range list {
+			b.Exemplars = append(b.Exemplars, Exemplar{ID: e.ID, Goal: e.Goal, Graph: e.Graph, Note: e.Note, Disabled: e.Disabled})
+		}
+	}
+	return b, nil
+}
+
+// Conflict 一处与目标已有状态的冲突
+type Conflict struct {
+	Section    string `json:"section"` // memory | config | planner_exemplars
+	Key        string `json:"key,omitempty"`
+	Resolution string `json:"resolution"` // 按策略的处理：failed | skipped | overwritten
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	AgentID       string `json:"agent_id"`
+	SourceAgentID string `json:"source_agent_id"`
+	Created       bool   `json:"created"`
+	// IDMap 源 ID → 目标 ID（Agent 与规划示例）
+	IDMap     map[string]string `json:"id_map"`
+	Applied   map[string]int    `json:"applied"`
+	Conflicts []Conflict        `json:"conflicts"`
+}
+
+func normalizeGoal(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Import 将包写入目标 Agent：会话记忆、配置（按 key）与规划示例（按规范化 goal）与目标已有状态冲突时按 policy 处理。
+// created 表示目标 Agent 为本次新建（无冲突可言）。policy 为 fail 且存在冲突时不写入任何状态，返回 ErrConflict 与冲突明细
+func Import(ctx context.Context, b *Bundle, agent *agentruntime.Agent, created bool, dst Sources, policy ConflictPolicy) (*ImportResult, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	res := &ImportResult{
+		AgentID:       agent.ID,
+		SourceAgentID: b.SourceAgentID,
+		Created:       created,
+		IDMap:         map[string]string{},
+		Applied:       map[string]int{},
+		Conflicts:     []Conflict{},
+	}
+	if b.SourceAgentID != "" {
+		res.IDMap[b.SourceAgentID] = agent.ID
+	}
+	resolution := map[ConflictPolicy]string{ConflictFail: "failed", ConflictSkip: "skipped", ConflictOverwrite: "overwritten"}[policy]
+	conflict := func(section, key string) bool {
+		res.Conflicts = append(res.Conflicts, Conflict{Section: section, Key: key, Resolution: resolution})
+		return policy == ConflictOverwrite
+	}
+
+	// 先收集冲突再写入，保证 fail 策略下不产生部分导入
+	applyMemory := b.Memory != nil
+	if applyMemory && !created && len(agent.Session.CopyMessages()) > 0 {
+		applyMemory = conflict("memory", "")
+	}
+	var configWrites []ConfigEntry
+	if dst.Config != nil && len(b.Config) > 0 {
+		existing, err := dst.Config.List(ctx, agent.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list agent config: %w", err)
+		}
+		have := make(map[string]bool, len(existing))
+		for _, e := range existing {
+			have[e.Key] = true
+		}
+		for _, e := range b.Config {
+			if have[e.Key] && !conflict("config", e.Key) {
+				continue
+			}
+			configWrites = append(configWrites, e)
+		}
+	}
+	type exemplarWrite struct {
+		src      Exemplar
+		targetID string // 空为新建，由存储分配 ID
+	}
+	var exemplarWrites []exemplarWrite
+	if dst.Exemplars != nil && len(b.Exemplars) > 0 {
+		existing, err := dst.Exemplars.List(ctx, agent.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list planner exemplars: %w", err)
+		}
+		byGoal := make(map[string]string, len(existing))
+		for _, e := range existing {
+			byGoal[normalizeGoal(e.Goal)] = e.ID
+		}
+		for _, e := range b.Exemplars {
+			targetID, exists := byGoal[normalizeGoal(e.Goal)]
+			if exists && !conflict("planner_exemplars", e.Goal) {
+				if e.ID != "" {
+					res.IDMap[e.ID] = targetID
+				}
+				continue
+			}
+			exemplarWrites = append(exemplarWrites, exemplarWrite{src: e, targetID: targetID})
+		}
+	}
+	if policy == ConflictFail && len(res.Conflicts) > 0 {
+		return res, ErrConflict
+	}
+
+	if applyMemory {
+		state := *b.Memory
+		state.AgentID, state.SessionID, state.LastCheckpoint = agent.ID, agent.Session.ID, ""
+		agentruntime.ApplyAgentState(agent.Session, &state)
+		res.Applied["memory"] = 1
+	}
+	for _, e := range configWrites {
+		if err := dst.Config.Set(ctx, &agentconfig.Entry{AgentID: agent.ID, Key: e.Key, Value: e.Value, SecretRef: e.SecretRef}); err != nil {
+			return res, fmt.Errorf("set agent config %s: %w", e.Key, err)
+		}
+		res.Applied["config"]++
+	}
+	for _, w := range exemplarWrites {
+		id, err := dst.Exemplars.Put(ctx, &planner.Exemplar{ID: w.targetID, AgentID: agent.ID, Goal: w.src.Goal, Graph: w.src.Graph, Note: w.src.Note, Disabled: w.src.Disabled})
+		if err != nil {
+			return res, fmt.Errorf("put planner exemplar: %w", err)
+		}
+		if w.src.ID != "" {
+			res.IDMap[w.src.ID] = id
+		}
+		res.Applied["planner_exemplars"]++
+	}
+	return res, nil
+}
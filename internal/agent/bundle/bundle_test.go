@@ -0,0 +1,147 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/agentconfig"
+)
+
+func graph() *planner.TaskGraph {
+	return &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n1", Type: "llm"}}}
+}
+
+func sourceAgent(t *testing.T, m *agentruntime.Manager, src Sources) *agentruntime.Agent {
+	t.Helper()
+	ctx := context.Background()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	a.Session.AddMessage("user", "remember my region is eu")
+	a.Session.SetVariable("region", "eu")
+	a.Session.SetLastCheckpoint("cp-staging")
+	_ = src.Config.Set(ctx, &agentconfig.Entry{AgentID: a.ID, Key: "api_base", Value: "https://staging"})
+	_ = src.Config.Set(ctx, &agentconfig.Entry{AgentID: a.ID, Key: "api_token", SecretRef: "support/token"})
+	if _, err := src.Exemplars.Put(ctx, &planner.Exemplar{AgentID: a.ID, Goal: "Summarize ticket", Graph: graph()}); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestExportImport_NewAgent(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	src := Sources{Config: agentconfig.NewStoreMem(), Exemplars: planner.NewExemplarStoreMem()}
+	a := sourceAgent(t, m, src)
+
+	b, err := Export(ctx, a, AgentSpec{Name: a.Name}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Format != Format || b.SourceAgentID != a.ID || len(b.Config) != 2 || len(b.Exemplars) != 1 {
+		t.Fatalf("unexpected bundle: %+v", b)
+	}
+	if b.Memory == nil || len(b.Memory.Messages) != 1 || b.Memory.LastCheckpoint != "" {
+		t.Fatalf("memory snapshot = %+v", b.Memory)
+	}
+
+	dst := Sources{Config: agentconfig.NewStoreMem(), Exemplars: planner.NewExemplarStoreMem()}
+	target, _ := agentruntime.NewManager().Create(ctx, "support", nil, nil, nil, nil)
+	res, err := Import(ctx, b, target, true, dst, ConflictFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IDMap[a.ID] != target.ID || res.Applied["config"] != 2 || res.Applied["planner_exemplars"] != 1 || res.Applied["memory"] != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if v, _ := target.Session.GetVariable("region"); v != "eu" || target.Session.GetLastCheckpoint() != "" {
+		t.Fatalf("memory not applied: region=%v checkpoint=%q", v, target.Session.GetLastCheckpoint())
+	}
+	entries, _ := dst.Config.List(ctx, target.ID)
+	if len(entries) != 2 || entries[1].SecretRef != "support/token" {
+		t.Fatalf("config = %+v", entries)
+	}
+	list, _ := dst.Exemplars.List(ctx, target.ID)
+	if len(list) != 1 || res.IDMap[b.Exemplars[0].ID] != list[0].ID {
+		t.Fatalf("exemplar id map = %+v, exemplars = %+v", res.IDMap, list)
+	}
+}
+
+func TestImport_ConflictPolicies(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	src := Sources{Config: agentconfig.NewStoreMem(), Exemplars: planner.NewExemplarStoreMem()}
+	b, err := Export(ctx, sourceAgent(t, m, src), AgentSpec{Name: "support"}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTarget := func() (*agentruntime.Agent, Sources) {
+		dst := Sources{Config: agentconfig.NewStoreMem(), Exemplars: planner.NewExemplarStoreMem()}
+		target, _ := m.Create(ctx, "support-prod", nil, nil, nil, nil)
+		target.Session.AddMessage("user", "prod history")
+		_ = dst.Config.Set(ctx, &agentconfig.Entry{AgentID: target.ID, Key: "api_base", Value: "https://prod"})
+		_, _ = dst.Exemplars.Put(ctx, &planner.Exemplar{AgentID: target.ID, Goal: "summarize  TICKET", Graph: graph()})
+		return target, dst
+	}
+
+	target, dst := newTarget()
+	res, err := Import(ctx, b, target, false, dst, ConflictFail)
+	if !errors.Is(err, ErrConflict) || len(res.Conflicts) != 3 {
+		t.Fatalf("fail policy: err=%v result=%+v", err, res)
+	}
+	if entries, _ := dst.Config.List(ctx, target.ID); len(entries) != 1 {
+		t.Fatalf("fail policy must not write, config = %+v", entries)
+	}
+
+	target, dst = newTarget()
+	res, err = Import(ctx, b, target, false, dst, ConflictSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := dst.Config.List(ctx, target.ID)
+	if len(entries) != 2 || entries[0].Value != "https://prod" || res.Applied["config"] != 1 || res.Applied["memory"] != 0 {
+		t.Fatalf("skip policy: config=%+v result=%+v", entries, res)
+	}
+	if msgs := target.Session.CopyMessages(); len(msgs) != 1 || msgs[0].Content != "prod history" {
+		t.Fatalf("skip policy must keep memory: %+v", msgs)
+	}
+
+	target, dst = newTarget()
+	res, err = Import(ctx, b, target, false, dst, ConflictOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = dst.Config.List(ctx, target.ID)
+	list, _ := dst.Exemplars.List(ctx, target.ID)
+	if entries[0].Value != "https://staging" || len(list) != 1 || list[0].Goal != "Summarize ticket" || res.Applied["memory"] != 1 {
+		t.Fatalf("overwrite policy: config=%+v exemplars=%+v result=%+v", entries, list, res)
+	}
+}
+
+func TestBundle_Validate(t *testing.T) {
+	if err := (&Bundle{Format: "other"}).Validate(); err == nil {
+		t.Fatal("unknown format should be rejected")
+	}
+	if err := (&Bundle{Format: Format, Config: []ConfigEntry{{Key: "bad key"}}}).Validate(); err == nil {
+		t.Fatal("invalid config key should be rejected")
+	}
+	if _, err := ParseConflictPolicy("merge"); err == nil {
+		t.Fatal("unknown policy should be rejected")
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compat Worker/API 版本协商：Worker 心跳时声明支持的事件 schema 版本与特性，
+// API 创建 Job 时按在线 Worker 协商 Job 的 schema 与特性，并以 RequiredCapabilities 路由到具备能力的 Worker。
+// 滚动升级期间混合集群据此降级（如并行 DAG 退化为顺序执行），而不是产生旧 Worker 无法识别的事件。
+package compat
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EventSchemaVersion 本二进制写入/读取的事件 schema 版本；能读 N 的 Worker 可处理 schema <= N 的 Job
+const EventSchemaVersion = 2
+
+// 需要 Worker 支持的特性；Job 要求某特性时仅由声明该特性的 Worker 认领
+const (
+	FeatureParallelDAG  = "parallel_dag"  // 同层节点并行执行（并行批次事件）
+	FeatureRecordedHTTP = "recorded_http" // Step 内 HTTP 经 Recorded Effects 记录、Replay 注入
+	FeatureScratchpad   = "scratchpad"    // 步骤间共享 scratchpad（payload._scratchpad）
+	FeatureReviewGate   = "review_gate"   // llm 节点审阅门（wait_kind=review）
+)
+
+// LocalFeatures 本二进制支持的全部特性
+var LocalFeatures = []string{FeatureParallelDAG, FeatureRecordedHTTP, FeatureReviewGate, FeatureScratchpad}
+
+// degradable 可降级特性：无在线 Worker 支持时从 Job 中去掉并以兼容方式执行，而不是拒绝
+var degradable = map[string]bool{
+	FeatureParallelDAG: true,
+}
+
+const (
+	featureCapPrefix = "feature:"
+	schemaCapPrefix  = "schema:"
+)
+
+// ErrFeatureUnavailable 在线 Worker 均不支持 Job 要求的（不可降级）特性
+var ErrFeatureUnavailable = errors.New("compat: no worker supports required features")
+
+// Handshake Worker 注册（状态心跳）时声明的版本信息；EventSchemaVersion 为 0 表示升级前的旧 Worker（视为 schema 1、无特性）
+type Handshake struct {
+	WorkerID           string   `json:"worker_id"`
+	Version            string   `json:"version,omitempty"`
+	EventSchemaVersion int      `json:"event_schema_version"`
+	Features           []string `json:"features,omitempty"`
+}
+
+// Local 返回本进程的握手信息
+func Local(workerID, version string) Handshake {
+	return Handshake{WorkerID: workerID, Version: version, EventSchemaVersion: EventSchemaVersion, Features: append([]string(nil), LocalFeatures...)}
+}
+
+func (h Handshake) schema() int {
+	if h.EventSchemaVersion <= 0 {
+		return 1
+	}
+	return h.EventSchemaVersion
+}
+
+func (h Handshake) supports(feature string) bool {
+	for _, f := range h.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureCapability 特性对应的 RequiredCapabilities 项
+func FeatureCapability(feature string) string { return featureCapPrefix + feature }
+
+// SchemaCapability schema 版本对应的 RequiredCapabilities 项
+func SchemaCapability(version int) string { return schemaCapPrefix + strconv.Itoa(version) }
+
+// AdvertisedCapabilities Worker 认领时追加的能力：schema:1..schema:N 与 feature:*，与配置的能力合并（去重）
+func AdvertisedCapabilities(configured []string, h Handshake) []string {
+	out := append([]string(nil), configured...)
+	for v := 1; v <= h.schema(); v++ {
+		out = append(out, SchemaCapability(v))
+	}
+	for _, f := range h.Features {
+		out = append(out, FeatureCapability(f))
+	}
+	return dedupe(out)
+}
+
+// JobFeatures 从 Job 的 RequiredCapabilities 还原协商结果；negotiated 为 false 表示协商前创建的旧 Job
+func JobFeatures(requiredCapabilities []string) (features []string, negotiated bool) {
+	features = []string{}
+	for _, c := range requiredCapabilities {
+		c = strings.TrimSpace(c)
+		switch {
+		case strings.HasPrefix(c, schemaCapPrefix):
+			negotiated = true
+		case strings.HasPrefix(c, featureCapPrefix):
+			features = append(features, strings.TrimPrefix(c, featureCapPrefix))
+		}
+	}
+	if !negotiated {
+		return nil, false
+	}
+	return features, true
+}
+
+// Decision Job 的协商结果
+type Decision struct {
+	SchemaVersion int      `json:"schema_version"`
+	Features      []string `json:"features"`           // Job 使用的特性（路由条件）
+	Degraded      []string `json:"degraded,omitempty"` // 因无 Worker 支持而降级去掉的特性
+	Workers       int      `json:"workers"`            // 可认领该 Job 的在线 Worker 数；0 且 fleet 为空时表示未知
+}
+
+// Capabilities Job 的 RequiredCapabilities（schema 与特性）
+func (d Decision) Capabilities() []string {
+	caps := []string{SchemaCapability(d.SchemaVersion)}
+	for _, f := range d.Features {
+		caps = append(caps, FeatureCapability(f))
+	}
+	return caps
+}
+
+// Negotiate 按在线 Worker 协商 Job 的 schema 与特性：
+//   - schema 取本地版本与在线 Worker 最高版本的较小值；
+//   - 要求的特性须至少有一个 Worker 同时支持（及该 schema），可降级特性不满足时去掉，不可降级时返回 ErrFeatureUnavailable；
+//   - fleet 为空（无心跳数据）时不做判断，按本地版本与要求的特性创建，等待具备能力的 Worker 认领。
+func Negotiate(fleet []Handshake, requested []string) (Decision, error) {
+	requested = dedupe(requested)
+	if len(fleet) == 0 {
+		return Decision{SchemaVersion: EventSchemaVersion, Features: requested}, nil
+	}
+	schema := 0
+	for _, w := range fleet {
+		if s := w.schema(); s > schema {
+			schema = s
+		}
+	}
+	if schema > EventSchemaVersion {
+		schema = EventSchemaVersion
+	}
+	var mandatory, optional []string
+	for _, f := range requested {
+		if degradable[f] {
+			optional = append(optional, f)
+		} else {
+			mandatory = append(mandatory, f)
+		}
+	}
+	candidates := capable(fleet, schema, mandatory)
+	if len(candidates) == 0 {
+		return Decision{}, fmt.Errorf("%w: %s", ErrFeatureUnavailable, strings.Join(unsupported(fleet, schema, mandatory), ", "))
+	}
+	d := Decision{SchemaVersion: schema, Features: mandatory}
+	for _, f := range optional {
+		if next := capable(candidates, schema, []string{f}); len(next) > 0 {
+			candidates = next
+			d.Features = append(d.Features, f)
+		} else {
+			d.Degraded = append(d.Degraded, f)
+		}
+	}
+	sort.Strings(d.Features)
+	if d.Features == nil {
+		d.Features = []string{}
+	}
+	d.Workers = len(candidates)
+	return d, nil
+}
+
+func capable(fleet []Handshake, schema int, features []string) []Handshake {
+	var out []Handshake
+	for _, w := range fleet {
+		if w.schema() < schema {
+			continue
+		}
+		ok := true
+		for _, f := range features {
+			if !w.supports(f) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// unsupported 返回在线 Worker 均不支持的特性；均有支持者但无 Worker 同时支持时返回全部（组合不可满足）
+func unsupported(fleet []Handshake, schema int, features []string) []string {
+	var missing []string
+	for _, f := range features {
+		if len(capable(fleet, schema, []string{f})) == 0 {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) == 0 {
+		return features
+	}
+	return missing
+}
+
+func dedupe(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// KnownFeature 是否为本版本识别的特性
+func KnownFeature(feature string) bool {
+	for _, f := range LocalFeatures {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildVersion 当前二进制的模块版本（go build 信息）；未知时为 "dev"
+func BuildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
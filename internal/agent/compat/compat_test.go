@@ -0,0 +1,78 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compat_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"rag-platform/internal/agent/compat"
+	"rag-platform/internal/agent/job"
+)
+
+func TestNegotiate_MixedFleet(t *testing.T) {
+	legacy := compat.Handshake{WorkerID: "old"} // 升级前 Worker：schema 1、无特性
+	upgraded := compat.Local("new", "v2")
+
+	// 无心跳数据：按本地版本与要求的特性创建
+	d, err := compat.Negotiate(nil, []string{compat.FeatureRecordedHTTP, compat.FeatureRecordedHTTP})
+	if err != nil || d.SchemaVersion != compat.EventSchemaVersion || !reflect.DeepEqual(d.Features, []string{compat.FeatureRecordedHTTP}) {
+		t.Fatalf("empty fleet: %+v %v", d, err)
+	}
+
+	// 全部为旧 Worker：schema 降到 1，并行 DAG 降级，不可降级特性拒绝
+	d, err = compat.Negotiate([]compat.Handshake{legacy}, []string{compat.FeatureParallelDAG})
+	if err != nil || d.SchemaVersion != 1 || len(d.Features) != 0 || !reflect.DeepEqual(d.Degraded, []string{compat.FeatureParallelDAG}) {
+		t.Fatalf("legacy fleet: %+v %v", d, err)
+	}
+	if _, err = compat.Negotiate([]compat.Handshake{legacy}, []string{compat.FeatureRecordedHTTP}); !errors.Is(err, compat.ErrFeatureUnavailable) {
+		t.Fatalf("legacy fleet recorded_http err = %v", err)
+	}
+
+	// 滚动升级中：路由到已升级 Worker
+	d, err = compat.Negotiate([]compat.Handshake{legacy, upgraded}, []string{compat.FeatureParallelDAG, compat.FeatureRecordedHTTP})
+	if err != nil || d.SchemaVersion != compat.EventSchemaVersion || d.Workers != 1 || len(d.Degraded) != 0 ||
+		!reflect.DeepEqual(d.Features, []string{compat.FeatureParallelDAG, compat.FeatureRecordedHTTP}) {
+		t.Fatalf("mixed fleet: %+v %v", d, err)
+	}
+}
+
+func TestAdvertisedCapabilities_RoutesNegotiatedJobs(t *testing.T) {
+	ctx := context.Background()
+	store := job.NewJobStoreMem()
+	d, _ := compat.Negotiate([]compat.Handshake{compat.Local("new", "v2")}, []string{compat.FeatureRecordedHTTP})
+	if _, err := store.Create(ctx, &job.Job{AgentID: "a1", Goal: "g", RequiredCapabilities: d.Capabilities()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 配置了能力的旧 Worker 不声明 schema/feature，无法认领
+	if j, _ := store.ClaimNextPendingForWorker(ctx, "", []string{"llm"}, ""); j != nil {
+		t.Fatalf("legacy worker claimed negotiated job %s", j.ID)
+	}
+	caps := compat.AdvertisedCapabilities([]string{"llm", "llm"}, compat.Local("new", "v2"))
+	j, _ := store.ClaimNextPendingForWorker(ctx, "", caps, "")
+	if j == nil {
+		t.Fatalf("upgraded worker (caps %v) should claim the job", caps)
+	}
+	features, negotiated := compat.JobFeatures(j.RequiredCapabilities)
+	if !negotiated || !reflect.DeepEqual(features, []string{compat.FeatureRecordedHTTP}) {
+		t.Fatalf("compat.JobFeatures = %v %v", features, negotiated)
+	}
+	if f, negotiated := compat.JobFeatures([]string{"llm"}); negotiated || f != nil {
+		t.Fatalf("legacy job should not be negotiated: %v", f)
+	}
+}
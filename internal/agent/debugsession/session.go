@@ -0,0 +1,279 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debugsession 限时调试会话（break-glass）：管理员填写理由后为指定用户开启对某个 Job 的临时 payload 访问，
+// 到期自动失效；开启、每次提权访问与提前关闭都写入按租户哈希链接的审计记录，篡改或删除任一记录都会使链校验失败
+package debugsession
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound 会话不存在
+	ErrNotFound = errors.New("debugsession: not found")
+	// ErrClosed 会话已关闭或已过期
+	ErrClosed = errors.New("debugsession: session already closed or expired")
+)
+
+// 会话时长
+const (
+	DefaultDuration = 30 * time.Minute
+	MaxDuration     = 4 * time.Hour
+	// MinJustificationLen 理由最少字符数，避免 "debug" 之类的占位理由
+	MinJustificationLen = 10
+)
+
+// 审计动作
+const (
+	ActionOpened   = "opened"
+	ActionAccessed = "accessed"
+	ActionClosed   = "closed"
+)
+
+// Session 一次限时调试会话：Grantee 在 ExpiresAt 前查看 JobID 的 trace/events/replay 时不遮蔽 payload
+type Session struct {
+	ID            string     `json:"id"`
+	TenantID      string     `json:"tenant_id"`
+	JobID         string     `json:"job_id"`
+	Grantee       string     `json:"grantee"`
+	OpenedBy      string     `json:"opened_by"`
+	Justification string     `json:"justification"`
+	OpenedAt      time.Time  `json:"opened_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	ClosedBy      string     `json:"closed_by,omitempty"`
+}
+
+// Active 会话在 now 时是否有效（未关闭且未过期）
+func (s *Session) Active(now time.Time) bool {
+	return s != nil && s.ClosedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// AuditEntry 审计记录；同租户的记录按 Seq 组成哈希链（Hash = sha256(PrevHash + 记录内容)），首条 PrevHash 为空
+type AuditEntry struct {
+	TenantID  string    `json:"tenant_id"`
+	Seq       int64     `json:"seq"`
+	SessionID string    `json:"session_id"`
+	JobID     string    `json:"job_id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// hashTimeLayout 哈希中的时间取微秒精度，与 Postgres timestamptz 往返一致
+const hashTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// ComputeHash 计算记录的链上哈希（不含 Hash 字段本身）
+func (e *AuditEntry) ComputeHash() string {
+	h := sha256.New()
+	for _, f := range []string{
+		e.PrevHash, e.TenantID, strconv.FormatInt(e.Seq, 10), e.SessionID, e.JobID, e.Action, e.Actor, e.Detail,
+		e.CreatedAt.UTC().Format(hashTimeLayout),
+	} {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyChain 校验一个租户自首条起完整的审计链：Seq 连续、PrevHash 衔接、Hash 与内容一致
+func VerifyChain(entries []*AuditEntry) error {
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != int64(i+1) {
+			return fmt.Errorf("entry %d: seq %d, want %d", i, e.Seq, i+1)
+		}
+		if e.PrevHash != prev {
+			return fmt.Errorf("seq %d: prev_hash does not match previous entry", e.Seq)
+		}
+		if e.Hash != e.ComputeHash() {
+			return fmt.Errorf("seq %d: hash mismatch", e.Seq)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+// ValidateJustification 理由去空白后至少 MinJustificationLen 个字符
+func ValidateJustification(s string) error {
+	if len([]rune(strings.TrimSpace(s))) < MinJustificationLen {
+		return fmt.Errorf("justification must be at least %d characters", MinJustificationLen)
+	}
+	return nil
+}
+
+// Store 调试会话与审计链存储
+type Store interface {
+	// Open 创建会话并追加 opened 审计记录
+	Open(ctx context.Context, s *Session) error
+	// Close 提前关闭有效会话并追加 closed 审计记录；不存在返回 ErrNotFound，已关闭或过期返回 ErrClosed
+	Close(ctx context.Context, tenantID, jobID, id, actor string) (*Session, error)
+	// List 列出 Job 的全部会话（新 → 旧）
+	List(ctx context.Context, tenantID, jobID string) ([]*Session, error)
+	// ActiveFor 返回 grantee 在 Job 上当前有效的会话；没有时返回 nil, nil
+	ActiveFor(ctx context.Context, tenantID, jobID, grantee string) (*Session, error)
+	// RecordAccess 为会话追加 accessed 审计记录，detail 为被访问的接口
+	RecordAccess(ctx context.Context, s *Session, detail string) error
+	// Audit 返回租户自首条起的完整审计链（按 Seq 升序）
+	Audit(ctx context.Context, tenantID string) ([]*AuditEntry, error)
+}
+
+// NewSession 按请求构造待开启的会话；duration<=0 时使用 DefaultDuration，超过 MaxDuration 返回错误
+func NewSession(tenantID, jobID, grantee, openedBy, justification string, duration time.Duration, now time.Time) (*Session, error) {
+	if strings.TrimSpace(grantee) == "" {
+		return nil, errors.New("user_id is required")
+	}
+	if err := ValidateJustification(justification); err != nil {
+		return nil, err
+	}
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+	if duration > MaxDuration {
+		return nil, fmt.Errorf("duration must not exceed %s", MaxDuration)
+	}
+	return &Session{
+		ID:            "dbg-" + uuid.New().String(),
+		TenantID:      tenantID,
+		JobID:         jobID,
+		Grantee:       strings.TrimSpace(grantee),
+		OpenedBy:      openedBy,
+		Justification: strings.TrimSpace(justification),
+		OpenedAt:      now,
+		ExpiresAt:     now.Add(duration),
+	}, nil
+}
+
+// openedDetail opened 记录的 detail：授权对象、到期时间与理由
+func openedDetail(s *Session) string {
+	return fmt.Sprintf("grantee=%s expires_at=%s justification=%s", s.Grantee, s.ExpiresAt.UTC().Format(time.RFC3339), s.Justification)
+}
+
+// StoreMem 内存实现（单进程 / 开发模式）
+type StoreMem struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	audit    map[string][]*AuditEntry
+}
+
+// NewStoreMem 创建内存调试会话存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{sessions: make(map[string]*Session), audit: make(map[string][]*AuditEntry)}
+}
+
+func cloneSession(s *Session) *Session {
+	cp := *s
+	if s.ClosedAt != nil {
+		t := *s.ClosedAt
+		cp.ClosedAt = &t
+	}
+	return &cp
+}
+
+// appendLocked 追加一条审计记录并链接到租户链尾；调用方持有 mu
+func (m *StoreMem) appendLocked(s *Session, action, actor, detail string, at time.Time) {
+	chain := m.audit[s.TenantID]
+	e := &AuditEntry{TenantID: s.TenantID, Seq: int64(len(chain) + 1), SessionID: s.ID, JobID: s.JobID, Action: action, Actor: actor, Detail: detail, CreatedAt: at.UTC().Truncate(time.Microsecond)}
+	if len(chain) > 0 {
+		e.PrevHash = chain[len(chain)-1].Hash
+	}
+	e.Hash = e.ComputeHash()
+	m.audit[s.TenantID] = append(chain, e)
+}
+
+func (m *StoreMem) Open(ctx context.Context, s *Session) error {
+	if s == nil || s.ID == "" {
+		return errors.New("debugsession: session id is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = cloneSession(s)
+	m.appendLocked(s, ActionOpened, s.OpenedBy, openedDetail(s), s.OpenedAt)
+	return nil
+}
+
+func (m *StoreMem) Close(ctx context.Context, tenantID, jobID, id, actor string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || s.TenantID != tenantID || s.JobID != jobID {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	if !s.Active(now) {
+		return nil, ErrClosed
+	}
+	s.ClosedAt, s.ClosedBy = &now, actor
+	m.appendLocked(s, ActionClosed, actor, "", now)
+	return cloneSession(s), nil
+}
+
+func (m *StoreMem) List(ctx context.Context, tenantID, jobID string) ([]*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Session
+	for _, s := range m.sessions {
+		if s.TenantID == tenantID && s.JobID == jobID {
+			out = append(out, cloneSession(s))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OpenedAt.After(out[j].OpenedAt) })
+	return out, nil
+}
+
+func (m *StoreMem) ActiveFor(ctx context.Context, tenantID, jobID, grantee string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, s := range m.sessions {
+		if s.TenantID == tenantID && s.JobID == jobID && s.Grantee == grantee && s.Active(now) {
+			return cloneSession(s), nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *StoreMem) RecordAccess(ctx context.Context, s *Session, detail string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.appendLocked(s, ActionAccessed, s.Grantee, detail, time.Now())
+	return nil
+}
+
+func (m *StoreMem) Audit(ctx context.Context, tenantID string) ([]*AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	chain := m.audit[tenantID]
+	out := make([]*AuditEntry, 0, len(chain))
+	for _, e := range chain {
+		cp := *e
+		out = append(out, &cp)
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugsession
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewSession_Validation(t *testing.T) {
+	now := time.Now()
+	if _, err := NewSession("t1", "job-1", "", "admin", "customer ticket #4711 escalation", 0, now); err == nil {
+		t.Fatal("expected error for empty grantee")
+	}
+	if _, err := NewSession("t1", "job-1", "alice", "admin", "debug", 0, now); err == nil {
+		t.Fatal("expected error for short justification")
+	}
+	if _, err := NewSession("t1", "job-1", "alice", "admin", "customer ticket #4711 escalation", MaxDuration+time.Minute, now); err == nil {
+		t.Fatal("expected error for duration above max")
+	}
+	s, err := NewSession("t1", "job-1", "alice", "admin", "customer ticket #4711 escalation", 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.ExpiresAt.Equal(now.Add(DefaultDuration)) || !s.Active(now) || s.Active(s.ExpiresAt) {
+		t.Fatalf("unexpected session window: %+v", s)
+	}
+}
+
+func TestStoreMem_LifecycleAndAuditChain(t *testing.T) {
+	ctx := context.Background()
+	st := NewStoreMem()
+	s, _ := NewSession("t1", "job-1", "alice", "admin", "customer ticket #4711 escalation", time.Hour, time.Now())
+	if err := st.Open(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.ActiveFor(ctx, "t1", "job-1", "bob"); got != nil {
+		t.Fatal("session must only apply to its grantee")
+	}
+	if got, _ := st.ActiveFor(ctx, "t2", "job-1", "alice"); got != nil {
+		t.Fatal("session must only apply to its tenant")
+	}
+	active, err := st.ActiveFor(ctx, "t1", "job-1", "alice")
+	if err != nil || active == nil {
+		t.Fatalf("ActiveFor = %v, %v", active, err)
+	}
+	if err := st.RecordAccess(ctx, active, "GET /api/jobs/job-1/events"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Close(ctx, "t1", "job-2", s.ID, "admin"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("close on other job: %v", err)
+	}
+	if _, err := st.Close(ctx, "t1", "job-1", s.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Close(ctx, "t1", "job-1", s.ID, "admin"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("second close: %v", err)
+	}
+	if got, _ := st.ActiveFor(ctx, "t1", "job-1", "alice"); got != nil {
+		t.Fatal("closed session still active")
+	}
+
+	chain, _ := st.Audit(ctx, "t1")
+	if len(chain) != 3 || chain[0].Action != ActionOpened || chain[1].Action != ActionAccessed || chain[2].Action != ActionClosed {
+		t.Fatalf("audit chain = %+v", chain)
+	}
+	if err := VerifyChain(chain); err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	chain[1].Detail = "GET /api/jobs/job-1/trace"
+	if err := VerifyChain(chain); err == nil {
+		t.Fatal("edited entry must break the chain")
+	}
+	chain, _ = st.Audit(ctx, "t1")
+	if err := VerifyChain(append(chain[:1], chain[2:]...)); err == nil {
+		t.Fatal("deleted entry must break the chain")
+	}
+}
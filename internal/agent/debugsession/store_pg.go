@@ -0,0 +1,171 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugsession
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 debug_sessions（会话）与 debug_session_audit（按租户哈希链接的审计记录）表；
+// 追加审计记录在租户级 advisory 事务锁内完成，多 API 实例并发写入时链不分叉
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的调试会话存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+const sessionColumns = `id, tenant_id, job_id, grantee, opened_by, justification, opened_at, expires_at, closed_at, closed_by`
+
+func scanSession(row pgx.Row) (*Session, error) {
+	var s Session
+	if err := row.Scan(&s.ID, &s.TenantID, &s.JobID, &s.Grantee, &s.OpenedBy, &s.Justification, &s.OpenedAt, &s.ExpiresAt, &s.ClosedAt, &s.ClosedBy); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// appendAudit 在 tx 内追加一条审计记录并链接到租户链尾
+func appendAudit(ctx context.Context, tx pgx.Tx, s *Session, action, actor, detail string, at time.Time) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('debug_session_audit:' || $1))`, s.TenantID); err != nil {
+		return err
+	}
+	e := &AuditEntry{TenantID: s.TenantID, SessionID: s.ID, JobID: s.JobID, Action: action, Actor: actor, Detail: detail, CreatedAt: at.UTC().Truncate(time.Microsecond)}
+	err := tx.QueryRow(ctx,
+		`SELECT seq, hash FROM debug_session_audit WHERE tenant_id = $1 ORDER BY seq DESC LIMIT 1`, s.TenantID).Scan(&e.Seq, &e.PrevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	e.Seq++
+	e.Hash = e.ComputeHash()
+	_, err = tx.Exec(ctx,
+		`INSERT INTO debug_session_audit (tenant_id, seq, session_id, job_id, action, actor, detail, created_at, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		e.TenantID, e.Seq, e.SessionID, e.JobID, e.Action, e.Actor, e.Detail, e.CreatedAt, e.PrevHash, e.Hash)
+	return err
+}
+
+// inTx 在事务中执行 fn
+func (p *StorePg) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (p *StorePg) Open(ctx context.Context, s *Session) error {
+	if s == nil || s.ID == "" {
+		return errors.New("debugsession: session id is required")
+	}
+	return p.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO debug_sessions (`+sessionColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULL, '')`,
+			s.ID, s.TenantID, s.JobID, s.Grantee, s.OpenedBy, s.Justification, s.OpenedAt, s.ExpiresAt); err != nil {
+			return err
+		}
+		return appendAudit(ctx, tx, s, ActionOpened, s.OpenedBy, openedDetail(s), s.OpenedAt)
+	})
+}
+
+func (p *StorePg) Close(ctx context.Context, tenantID, jobID, id, actor string) (*Session, error) {
+	var out *Session
+	err := p.inTx(ctx, func(tx pgx.Tx) error {
+		s, err := scanSession(tx.QueryRow(ctx,
+			`SELECT `+sessionColumns+` FROM debug_sessions WHERE id = $1 AND tenant_id = $2 AND job_id = $3 FOR UPDATE`, id, tenantID, jobID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !s.Active(now) {
+			return ErrClosed
+		}
+		if _, err := tx.Exec(ctx, `UPDATE debug_sessions SET closed_at = $2, closed_by = $3 WHERE id = $1`, id, now, actor); err != nil {
+			return err
+		}
+		s.ClosedAt, s.ClosedBy = &now, actor
+		out = s
+		return appendAudit(ctx, tx, s, ActionClosed, actor, "", now)
+	})
+	return out, err
+}
+
+func (p *StorePg) List(ctx context.Context, tenantID, jobID string) ([]*Session, error) {
+	rows, err := p.pool.Query(ctx,
+		`SELECT `+sessionColumns+` FROM debug_sessions WHERE tenant_id = $1 AND job_id = $2 ORDER BY opened_at DESC`, tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (p *StorePg) ActiveFor(ctx context.Context, tenantID, jobID, grantee string) (*Session, error) {
+	s, err := scanSession(p.pool.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM debug_sessions
+		 WHERE tenant_id = $1 AND job_id = $2 AND grantee = $3 AND closed_at IS NULL AND expires_at > now()
+		 ORDER BY expires_at DESC LIMIT 1`, tenantID, jobID, grantee))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (p *StorePg) RecordAccess(ctx context.Context, s *Session, detail string) error {
+	return p.inTx(ctx, func(tx pgx.Tx) error {
+		return appendAudit(ctx, tx, s, ActionAccessed, s.Grantee, detail, time.Now())
+	})
+}
+
+func (p *StorePg) Audit(ctx context.Context, tenantID string) ([]*AuditEntry, error) {
+	rows, err := p.pool.Query(ctx,
+		`SELECT tenant_id, seq, session_id, job_id, action, actor, detail, created_at, prev_hash, hash
+		 FROM debug_session_audit WHERE tenant_id = $1 ORDER BY seq`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.TenantID, &e.Seq, &e.SessionID, &e.JobID, &e.Action, &e.Actor, &e.Detail, &e.CreatedAt, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
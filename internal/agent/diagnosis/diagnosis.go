@@ -0,0 +1,207 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diagnosis Job 失败诊断：Job 失败后由 LLM 根据失败步骤的输入、错误与最近事件给出结构化诊断
+// （可能原因、修复建议、重试是否可能有效），写入 job_diagnosis 事件，并在 Trace 页头与失败 Webhook 中展示
+package diagnosis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/model/llm"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// 诊断上下文默认值：最近 20 个事件，单个 payload 截断到 2000 字符，单次 LLM 调用 30s
+const (
+	DefaultMaxEvents       = 20
+	DefaultMaxPayloadChars = 2000
+	DefaultTimeout         = 30 * time.Second
+)
+
+// Diagnosis job_diagnosis 事件 payload
+type Diagnosis struct {
+	ProbableCause     string    `json:"probable_cause"`
+	SuggestedFix      string    `json:"suggested_fix"`
+	RetryLikelyToHelp bool      `json:"retry_likely_to_help"`
+	FailedNodeID      string    `json:"failed_node_id,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	Model             string    `json:"model,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// FromEvents 返回事件流中最后一条 job_diagnosis 的诊断；没有时返回 nil
+func FromEvents(events []jobstore.JobEvent) *Diagnosis {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type != jobstore.JobDiagnosis {
+			continue
+		}
+		var d Diagnosis
+		if err := json.Unmarshal(events[i].Payload, &d); err != nil {
+			return nil
+		}
+		return &d
+	}
+	return nil
+}
+
+// Options 诊断参数；零值字段使用默认值
+type Options struct {
+	MaxEvents       int
+	MaxPayloadChars int
+	Timeout         time.Duration
+}
+
+// Diagnoser 调用 LLM 生成失败诊断
+type Diagnoser struct {
+	client llm.Client
+	opts   Options
+}
+
+// NewDiagnoser 创建诊断器
+func NewDiagnoser(client llm.Client, opts Options) *Diagnoser {
+	if opts.MaxEvents <= 0 {
+		opts.MaxEvents = DefaultMaxEvents
+	}
+	if opts.MaxPayloadChars <= 0 {
+		opts.MaxPayloadChars = DefaultMaxPayloadChars
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	return &Diagnoser{client: client, opts: opts}
+}
+
+// Diagnose 根据失败 Job 的事件流生成诊断；LLM 输出无法解析为约定 JSON 时返回错误
+func (d *Diagnoser) Diagnose(ctx context.Context, j *job.Job, events []jobstore.JobEvent) (*Diagnosis, error) {
+	nodeID, errMsg := FailurePoint(j, events)
+	prompt := d.prompt(j, events, nodeID, errMsg)
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+	out, err := d.client.GenerateWithContext(ctx, prompt, llm.GenerateOptions{Temperature: 0, MaxTokens: 512})
+	if err != nil {
+		return nil, fmt.Errorf("diagnosis: llm: %w", err)
+	}
+	diag, err := parse(out)
+	if err != nil {
+		return nil, err
+	}
+	diag.FailedNodeID = nodeID
+	diag.Error = errMsg
+	diag.Model = d.client.Model()
+	diag.CreatedAt = time.Now().UTC()
+	return diag, nil
+}
+
+// FailurePoint 返回失败节点与错误信息：优先取终态元数据与 job_failed 事件，否则取最后一个未成功的 node_finished
+func FailurePoint(j *job.Job, events []jobstore.JobEvent) (nodeID, errMsg string) {
+	if j != nil && j.Terminal != nil {
+		nodeID = j.Terminal.FailedNodeID
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		pl := payloadMap(e.Payload)
+		switch e.Type {
+		case jobstore.JobFailed:
+			if errMsg == "" {
+				errMsg, _ = pl["error"].(string)
+			}
+			if nodeID == "" {
+				nodeID, _ = pl["node_id"].(string)
+			}
+		case jobstore.NodeFinished:
+			if rt, _ := pl["result_type"].(string); rt != "" && rt != "success" && nodeID == "" {
+				nodeID, _ = pl["node_id"].(string)
+				if errMsg == "" {
+					errMsg, _ = pl["reason"].(string)
+				}
+			}
+		}
+	}
+	return nodeID, errMsg
+}
+
+func (d *Diagnoser) prompt(j *job.Job, events []jobstore.JobEvent, nodeID, errMsg string) string {
+	var b strings.Builder
+	b.WriteString("You are diagnosing a failed agent job. Based on the failing step's inputs, the error and the recent events, ")
+	b.WriteString("reply with ONLY a JSON object: {\"probable_cause\": string, \"suggested_fix\": string, \"retry_likely_to_help\": boolean}. ")
+	b.WriteString("retry_likely_to_help is true only for transient causes (timeouts, rate limits, unavailable dependencies).\n\n")
+	if j != nil {
+		fmt.Fprintf(&b, "Goal: %s\n", j.Goal)
+		if j.Terminal != nil && j.Terminal.FailureClass != "" {
+			fmt.Fprintf(&b, "Failure class: %s\n", j.Terminal.FailureClass)
+		}
+	}
+	fmt.Fprintf(&b, "Failed step: %s\nError: %s\n", orNone(nodeID), orNone(errMsg))
+	if nodeID != "" {
+		b.WriteString("\nFailing step events:\n")
+		for _, e := range events {
+			if id, _ := payloadMap(e.Payload)["node_id"].(string); id == nodeID {
+				d.writeEvent(&b, e)
+			}
+		}
+	}
+	recent := events
+	if len(recent) > d.opts.MaxEvents {
+		recent = recent[len(recent)-d.opts.MaxEvents:]
+	}
+	b.WriteString("\nRecent events:\n")
+	for _, e := range recent {
+		d.writeEvent(&b, e)
+	}
+	return b.String()
+}
+
+func (d *Diagnoser) writeEvent(b *strings.Builder, e jobstore.JobEvent) {
+	payload := string(e.Payload)
+	if r := []rune(payload); len(r) > d.opts.MaxPayloadChars {
+		payload = string(r[:d.opts.MaxPayloadChars]) + "…"
+	}
+	fmt.Fprintf(b, "- %s %s %s\n", e.CreatedAt.UTC().Format(time.RFC3339), e.Type, payload)
+}
+
+// parse 从 LLM 输出中取第一个 JSON 对象（容忍 ``` 代码块包裹）
+func parse(out string) (*Diagnosis, error) {
+	start, end := strings.Index(out, "{"), strings.LastIndex(out, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("diagnosis: no JSON object in llm output")
+	}
+	var d Diagnosis
+	if err := json.Unmarshal([]byte(out[start:end+1]), &d); err != nil {
+		return nil, fmt.Errorf("diagnosis: decode llm output: %w", err)
+	}
+	if strings.TrimSpace(d.ProbableCause) == "" {
+		return nil, errors.New("diagnosis: llm output missing probable_cause")
+	}
+	return &d, nil
+}
+
+func payloadMap(payload []byte) map[string]interface{} {
+	var m map[string]interface{}
+	_ = json.Unmarshal(payload, &m)
+	return m
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(unknown)"
+	}
+	return s
+}
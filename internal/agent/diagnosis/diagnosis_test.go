@@ -0,0 +1,148 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnosis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/model/llm"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+type fakeLLM struct {
+	out     string
+	err     error
+	prompts []string
+}
+
+func (f *fakeLLM) Generate(prompt string, o llm.GenerateOptions) (string, error) {
+	return f.GenerateWithContext(context.Background(), prompt, o)
+}
+func (f *fakeLLM) GenerateWithContext(ctx context.Context, prompt string, o llm.GenerateOptions) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	return f.out, f.err
+}
+func (f *fakeLLM) Chat(m []llm.Message, o llm.GenerateOptions) (string, error) { return f.out, f.err }
+func (f *fakeLLM) ChatWithContext(ctx context.Context, m []llm.Message, o llm.GenerateOptions) (string, error) {
+	return f.out, f.err
+}
+func (f *fakeLLM) Model() string    { return "fake-model" }
+func (f *fakeLLM) Provider() string { return "fake" }
+func (f *fakeLLM) SetModel(string)  {}
+func (f *fakeLLM) SetAPIKey(string) {}
+
+func failedJob(t *testing.T, jobs *job.JobStoreMem, events jobstore.JobStore) string {
+	t.Helper()
+	ctx := context.Background()
+	jobID, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "fetch report"})
+	ver := 0
+	for _, e := range []jobstore.JobEvent{
+		{Type: jobstore.NodeStarted, Payload: []byte(`{"node_id":"n1"}`)},
+		{Type: jobstore.CustomEvent, Payload: []byte(`{"name":"report_requested","node_id":"n1","data":{"report":"q3"}}`)},
+		{Type: jobstore.ToolInvocationStarted, Payload: []byte(`{"node_id":"n1","tool_name":"http_get","input":{"url":"https://example.com/report"}}`)},
+		{Type: jobstore.NodeFinished, Payload: []byte(`{"node_id":"n1","result_type":"permanent_failure","reason":"404 not found"}`)},
+		{Type: jobstore.JobFailed, Payload: []byte(`{"node_id":"n1","error":"step n1: 404 not found"}`)},
+	} {
+		e.JobID = jobID
+		v, err := events.Append(ctx, jobID, ver, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ver = v
+	}
+	_ = jobs.UpdateStatus(ctx, jobID, job.StatusFailed)
+	return jobID
+}
+
+func TestScanner_DiagnosesFailedJobAndNotifies(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	jobID := failedJob(t, jobs, events)
+	okID, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "ok"})
+	_ = jobs.UpdateStatus(ctx, okID, job.StatusRunning)
+	_ = jobs.UpdateStatus(ctx, okID, job.StatusCompleted)
+
+	var notices []FailureNotice
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n FailureNotice
+		_ = json.NewDecoder(r.Body).Decode(&n)
+		notices = append(notices, n)
+	}))
+	defer srv.Close()
+	client := &fakeLLM{out: "```json\n{\"probable_cause\":\"report URL does not exist\",\"suggested_fix\":\"fix the URL\",\"retry_likely_to_help\":false}\n```"}
+	s := NewScanner(NewDiagnoser(client, Options{}), jobs, events, NewWebhookNotifier(srv.URL, "", 0), nil)
+
+	n, err := s.RunOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if len(client.prompts) != 1 || !strings.Contains(client.prompts[0], "https://example.com/report") || !strings.Contains(client.prompts[0], "Failed step: n1") {
+		t.Fatalf("prompt = %q", client.prompts)
+	}
+	list, _, _ := events.ListEvents(ctx, jobID)
+	d := FromEvents(list)
+	if d == nil || d.ProbableCause != "report URL does not exist" || d.RetryLikelyToHelp || d.FailedNodeID != "n1" || d.Model != "fake-model" {
+		t.Fatalf("diagnosis = %+v", d)
+	}
+	if len(notices) != 1 || notices[0].Type != "job_failed" || notices[0].JobID != jobID || notices[0].Diagnosis == nil || notices[0].Error != "step n1: 404 not found" ||
+		len(notices[0].CustomEvents) != 1 || notices[0].CustomEvents[0].Name != "report_requested" {
+		t.Fatalf("notices = %+v", notices)
+	}
+
+	// 已诊断的 Job 不重复诊断与通知（含新建扫描器，如其他 API 实例）
+	if n, _ := NewScanner(NewDiagnoser(client, Options{}), jobs, events, NewWebhookNotifier(srv.URL, "", 0), nil).RunOnce(ctx); n != 0 || len(notices) != 1 {
+		t.Fatalf("rescan wrote %d diagnoses, %d notices", n, len(notices))
+	}
+}
+
+func TestScanner_NotifiesWithoutDiagnosisOnLLMError(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	jobID := failedJob(t, jobs, events)
+	var got []FailureNotice
+	notifier := notifierFunc(func(ctx context.Context, n FailureNotice) error {
+		got = append(got, n)
+		return nil
+	})
+	s := NewScanner(NewDiagnoser(&fakeLLM{err: errors.New("rate limited")}, Options{}), jobs, events, notifier, nil)
+	if n, _ := s.RunOnce(ctx); n != 0 {
+		t.Fatalf("wrote %d diagnoses", n)
+	}
+	if len(got) != 1 || got[0].JobID != jobID || got[0].Diagnosis != nil || got[0].FailedNodeID != "n1" {
+		t.Fatalf("notices = %+v", got)
+	}
+}
+
+type notifierFunc func(ctx context.Context, n FailureNotice) error
+
+func (f notifierFunc) NotifyFailure(ctx context.Context, n FailureNotice) error { return f(ctx, n) }
+
+func TestParse_RejectsMissingCause(t *testing.T) {
+	if _, err := parse(`{"suggested_fix":"x"}`); err == nil {
+		t.Fatal("expected error for missing probable_cause")
+	}
+	if _, err := parse("no json here"); err == nil {
+		t.Fatal("expected error for non-JSON output")
+	}
+}
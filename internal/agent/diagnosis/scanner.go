@@ -0,0 +1,175 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnosis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+	"rag-platform/pkg/metrics"
+)
+
+const (
+	// DefaultScanInterval 默认扫描间隔
+	DefaultScanInterval = 30 * time.Second
+	// DefaultLookback 首轮回溯窗口：重启后只诊断该时长内失败的 Job，不补诊历史积压
+	DefaultLookback = 10 * time.Minute
+	// DefaultBatch 单轮最多扫描的 Job 数
+	DefaultBatch = 200
+	// scanOverlap 相邻两轮扫描窗口的重叠，避免 updated_at 与扫描时刻交错时漏读
+	scanOverlap = time.Minute
+)
+
+// Scanner 周期扫描最近失败的 Job：生成诊断写入 job_diagnosis 事件，并投递失败 Webhook（无论诊断是否成功）
+type Scanner struct {
+	diagnoser *Diagnoser
+	jobs      job.RecentJobLister
+	events    jobstore.JobStore
+	notifier  Notifier
+	logger    *log.Logger
+	now       func() time.Time
+
+	mu    sync.Mutex
+	since time.Time
+	seen  map[string]time.Time // job_id → 处理时的 updated_at
+}
+
+// NewScanner 创建扫描器；diagnoser 为 nil 时只投递失败通知，notifier 可为 nil
+func NewScanner(diagnoser *Diagnoser, jobs job.RecentJobLister, events jobstore.JobStore, notifier Notifier, logger *log.Logger) *Scanner {
+	return &Scanner{diagnoser: diagnoser, jobs: jobs, events: events, notifier: notifier, logger: logger, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// Run 按 interval 周期扫描直到 ctx 取消；启动时立即执行一轮
+func (s *Scanner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultScanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logf("Job 失败诊断扫描failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 处理上轮以来进入 Failed 的 Job；返回本轮写入的诊断数
+func (s *Scanner) RunOnce(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := s.now()
+	since := s.since
+	if since.IsZero() {
+		since = start.Add(-DefaultLookback)
+	}
+	jobs, err := s.jobs.ListUpdatedSince(ctx, "", since, DefaultBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list recent jobs: %w", err)
+	}
+	written := 0
+	for _, j := range jobs {
+		if j == nil || j.Status != job.StatusFailed {
+			continue
+		}
+		if _, ok := s.seen[j.ID]; ok {
+			continue
+		}
+		events, version, err := s.events.ListEvents(ctx, j.ID)
+		if err != nil {
+			s.logf("读取 Job 事件failed", "job_id", j.ID, "error", err)
+			continue
+		}
+		s.seen[j.ID] = j.UpdatedAt
+		// 其他 API 实例已诊断过时不重复诊断与通知
+		if FromEvents(events) != nil {
+			continue
+		}
+		if s.handle(ctx, j, events, version) {
+			written++
+		}
+	}
+	s.since = start.Add(-scanOverlap)
+	for id, at := range s.seen {
+		if at.Before(s.since) {
+			delete(s.seen, id)
+		}
+	}
+	return written, nil
+}
+
+// handle 诊断单个失败 Job 并投递通知；返回是否写入了 job_diagnosis
+func (s *Scanner) handle(ctx context.Context, j *job.Job, events []jobstore.JobEvent, version int) bool {
+	var diag *Diagnosis
+	if s.diagnoser != nil {
+		start := time.Now()
+		d, err := s.diagnoser.Diagnose(ctx, j, events)
+		metrics.JobDiagnosisSeconds.Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.JobDiagnosesTotal.WithLabelValues("error").Inc()
+			s.logf("Job 失败诊断failed", "job_id", j.ID, "error", err)
+		} else {
+			diag = d
+		}
+	}
+	written := false
+	if diag != nil {
+		payload, err := json.Marshal(diag)
+		if err == nil {
+			_, err = s.events.Append(ctx, j.ID, version, jobstore.JobEvent{JobID: j.ID, Type: jobstore.JobDiagnosis, Payload: payload})
+		}
+		if err != nil {
+			metrics.JobDiagnosesTotal.WithLabelValues("error").Inc()
+			s.logf("写入 job_diagnosis 事件failed", "job_id", j.ID, "error", err)
+		} else {
+			metrics.JobDiagnosesTotal.WithLabelValues("ok").Inc()
+			written = true
+		}
+	}
+	if s.notifier != nil {
+		if err := s.notifier.NotifyFailure(ctx, noticeOf(j, events, diag)); err != nil {
+			s.logf("失败通知投递failed", "job_id", j.ID, "error", err)
+		}
+	}
+	return written
+}
+
+func noticeOf(j *job.Job, events []jobstore.JobEvent, diag *Diagnosis) FailureNotice {
+	nodeID, errMsg := FailurePoint(j, events)
+	n := FailureNotice{JobID: j.ID, TenantID: j.TenantID, AgentID: j.AgentID, Goal: j.Goal, FailedNodeID: nodeID, Error: errMsg, FailedAt: j.UpdatedAt, Diagnosis: diag, CustomEvents: jobstore.CustomEventsOf(events)}
+	if j.Terminal != nil {
+		n.FailureClass = j.Terminal.FailureClass
+		if !j.Terminal.At.IsZero() {
+			n.FailedAt = j.Terminal.At
+		}
+	}
+	return n
+}
+
+func (s *Scanner) logf(msg string, args ...any) {
+	if s.logger != nil {
+		s.logger.Warn(msg, args...)
+	}
+}
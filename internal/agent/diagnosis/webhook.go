@@ -0,0 +1,100 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnosis
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/anomaly"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// DefaultWebhookTimeout 单次失败通知投递超时
+const DefaultWebhookTimeout = 5 * time.Second
+
+// FailureNotice 失败 Webhook 请求体；诊断failed或未生成时 diagnosis 为 null
+type FailureNotice struct {
+	Type         string     `json:"type"` // 固定为 job_failed
+	JobID        string     `json:"job_id"`
+	TenantID     string     `json:"tenant_id"`
+	AgentID      string     `json:"agent_id"`
+	Goal         string     `json:"goal"`
+	FailureClass string     `json:"failure_class,omitempty"`
+	FailedNodeID string     `json:"failed_node_id,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	FailedAt     time.Time  `json:"failed_at"`
+	Diagnosis    *Diagnosis `json:"diagnosis"`
+	// CustomEvents 失败前步骤经 sdk.EmitEvent 发出的业务里程碑
+	CustomEvents []jobstore.CustomEventPayload `json:"custom_events,omitempty"`
+}
+
+// Notifier Job 失败通知出口
+type Notifier interface {
+	NotifyFailure(ctx context.Context, notice FailureNotice) error
+}
+
+// WebhookNotifier 以 JSON POST 投递失败通知；签名方式与异常告警相同（X-Aetheris-Signature）
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookNotifier 创建失败 Webhook；timeout<=0 时使用 DefaultWebhookTimeout
+func NewWebhookNotifier(url, secret string, timeout time.Duration) *WebhookNotifier {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &WebhookNotifier{url: url, secret: secret, client: &http.Client{Timeout: timeout}}
+}
+
+// NotifyFailure 投递一次失败通知；非 2xx 视为失败
+func (n *WebhookNotifier) NotifyFailure(ctx context.Context, notice FailureNotice) error {
+	notice.Type = "job_failed"
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set(anomaly.SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failure webhook: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package environment 固化 Job 创建时解析出的执行环境（模型、工具版本、策略与配置哈希、平台版本），
+// 供 Replay 与 Verify 比对当前环境，明确"因模型变更而回放结果不同"这类差异。
+package environment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// Model 某一用途使用的模型；Rank 为故障切换链中的位置（0 为主模型）
+type Model struct {
+	Role     string `json:"role"` // default | planner | generation | embedding
+	Rank     int    `json:"rank,omitempty"`
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+}
+
+func (m Model) key() string {
+	if m.Rank == 0 {
+		return "model." + m.Role
+	}
+	return fmt.Sprintf("model.%s[%d]", m.Role, m.Rank)
+}
+
+func (m Model) String() string {
+	s := m.Provider + "/" + m.Name
+	if m.Version != "" {
+		s += "@" + m.Version
+	}
+	return s
+}
+
+// Tool 已注册工具的版本与输入 schema 摘要
+type Tool struct {
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	SchemaHash string `json:"schema_hash,omitempty"`
+}
+
+func (t Tool) String() string {
+	return t.Version + " schema=" + t.SchemaHash
+}
+
+// Environment 执行环境快照；Hash 覆盖除 CapturedAt 外的全部字段
+type Environment struct {
+	PlatformVersion string    `json:"platform_version"`
+	Models          []Model   `json:"models,omitempty"`
+	Tools           []Tool    `json:"tools,omitempty"`
+	PolicyHash      string    `json:"policy_hash,omitempty"`
+	ConfigHash      string    `json:"config_hash,omitempty"`
+	Hash            string    `json:"hash"`
+	CapturedAt      time.Time `json:"captured_at"`
+}
+
+// Seal 排序模型与工具并计算 Hash
+func (e *Environment) Seal() {
+	slices.SortFunc(e.Models, func(a, b Model) int {
+		if c := strings.Compare(a.Role, b.Role); c != 0 {
+			return c
+		}
+		return a.Rank - b.Rank
+	})
+	slices.SortFunc(e.Tools, func(a, b Tool) int { return strings.Compare(a.Name, b.Name) })
+	e.Hash = ""
+	e.Hash = HashJSON(struct {
+		PlatformVersion string  `json:"platform_version"`
+		Models          []Model `json:"models"`
+		Tools           []Tool  `json:"tools"`
+		PolicyHash      string  `json:"policy_hash"`
+		ConfigHash      string  `json:"config_hash"`
+	}{e.PlatformVersion, e.Models, e.Tools, e.PolicyHash, e.ConfigHash})
+}
+
+// HashJSON 值的 JSON 编码的 sha256（hex）；map 键由 encoding/json 排序，结果稳定
+func HashJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Mismatch 固化环境与当前环境的一处差异；Pinned 或 Current 为空表示该项仅存在于另一侧
+type Mismatch struct {
+	Field   string `json:"field"` // platform_version | model.<role>[rank] | tool.<name> | policy_hash | config_hash
+	Pinned  string `json:"pinned,omitempty"`
+	Current string `json:"current,omitempty"`
+}
+
+// Diff 比对固化环境与当前环境，按字段名排序返回差异
+func Diff(pinned, current Environment) []Mismatch {
+	var out []Mismatch
+	add := func(field, p, c string) {
+		if p != c {
+			out = append(out, Mismatch{Field: field, Pinned: p, Current: c})
+		}
+	}
+	add("platform_version", pinned.PlatformVersion, current.PlatformVersion)
+	add("policy_hash", pinned.PolicyHash, current.PolicyHash)
+	add("config_hash", pinned.ConfigHash, current.ConfigHash)
+	diffSet(pinned.Models, current.Models, Model.key, Model.String, add)
+	diffSet(pinned.Tools, current.Tools, func(t Tool) string { return "tool." + t.Name }, Tool.String, add)
+	slices.SortFunc(out, func(a, b Mismatch) int { return strings.Compare(a.Field, b.Field) })
+	return out
+}
+
+func diffSet[T any](pinned, current []T, key, str func(T) string, add func(field, p, c string)) {
+	cur := make(map[string]string, len(current))
+	for _, v := range current {
+		cur[key(v)] = str(v)
+	}
+	seen := make(map[string]struct{}, len(pinned))
+	for _, v := range pinned {
+		k := key(v)
+		seen[k] = struct{}{}
+		add(k, str(v), cur[k])
+	}
+	for _, v := range current {
+		if _, ok := seen[key(v)]; !ok {
+			add(key(v), "", str(v))
+		}
+	}
+}
+
+// Check 固化环境与当前环境的比对结果，随 replay / verify 响应返回
+type Check struct {
+	// Pinned 为 false 表示该 Job 早于环境固化功能或创建时未记录，无法比对
+	Pinned      bool         `json:"pinned"`
+	Match       bool         `json:"match"`
+	PinnedHash  string       `json:"pinned_hash,omitempty"`
+	CurrentHash string       `json:"current_hash"`
+	CapturedAt  *time.Time   `json:"captured_at,omitempty"`
+	Mismatches  []Mismatch   `json:"mismatches,omitempty"`
+	Environment *Environment `json:"environment,omitempty"` // 固化的环境
+}
+
+// Compare 比对事件流中固化的环境与当前环境
+func Compare(events []jobstore.JobEvent, current Environment) *Check {
+	c := &Check{CurrentHash: current.Hash}
+	pinned, ok := FromEvents(events)
+	if !ok {
+		return c
+	}
+	c.Pinned = true
+	c.PinnedHash = pinned.Hash
+	c.CapturedAt = &pinned.CapturedAt
+	c.Environment = pinned
+	c.Mismatches = Diff(*pinned, current)
+	c.Match = len(c.Mismatches) == 0
+	return c
+}
+
+// FromEvents 取事件流中第一条 job_environment 事件固化的环境
+func FromEvents(events []jobstore.JobEvent) (*Environment, bool) {
+	for _, e := range events {
+		if e.Type != jobstore.JobEnvironment {
+			continue
+		}
+		var env Environment
+		if json.Unmarshal(e.Payload, &env) != nil {
+			return nil, false
+		}
+		return &env, true
+	}
+	return nil, false
+}
+
+// Resolver 解析当前进程的执行环境；Tools 在每次调用时读取，动态注册的工具即时反映
+type Resolver struct {
+	PlatformVersion string
+	Models          []Model
+	PolicyHash      string
+	ConfigHash      string
+	Tools           func() []Tool
+}
+
+// Current 返回当前环境快照（已 Seal）
+func (r *Resolver) Current() Environment {
+	env := Environment{
+		PlatformVersion: r.PlatformVersion,
+		Models:          slices.Clone(r.Models),
+		PolicyHash:      r.PolicyHash,
+		ConfigHash:      r.ConfigHash,
+		CapturedAt:      time.Now().UTC(),
+	}
+	if r.Tools != nil {
+		env.Tools = r.Tools()
+	}
+	env.Seal()
+	return env
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func sampleEnv() Environment {
+	env := Environment{
+		PlatformVersion: "v1.2.0",
+		Models: []Model{
+			{Role: "planner", Rank: 1, Provider: "openai", Name: "gpt-4o-mini"},
+			{Role: "default", Provider: "openai", Name: "gpt-4o", Version: "2024-08-06"},
+		},
+		Tools:      []Tool{{Name: "search", Version: "1.0", SchemaHash: "aa"}, {Name: "http", Version: "2.0", SchemaHash: "bb"}},
+		PolicyHash: "p1",
+		ConfigHash: "c1",
+	}
+	env.Seal()
+	return env
+}
+
+func TestSeal_SortsAndIsStable(t *testing.T) {
+	a := sampleEnv()
+	if a.Models[0].Role != "default" || a.Tools[0].Name != "http" {
+		t.Fatalf("not sorted: %+v %+v", a.Models, a.Tools)
+	}
+	b := sampleEnv()
+	b.Models[0], b.Models[1] = b.Models[1], b.Models[0]
+	b.Seal()
+	if a.Hash == "" || a.Hash != b.Hash {
+		t.Fatalf("hash should be order-independent: %q vs %q", a.Hash, b.Hash)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	pinned := sampleEnv()
+	current := sampleEnv()
+	if d := Diff(pinned, current); len(d) != 0 {
+		t.Fatalf("expected no diff, got %+v", d)
+	}
+	current.Models[0].Version = "2024-11-20"
+	current.Tools = current.Tools[:1] // 移除 search
+	current.Tools = append(current.Tools, Tool{Name: "shell", Version: "1.0"})
+	current.Seal()
+	d := Diff(pinned, current)
+	want := []string{"model.default", "tool.search", "tool.shell"}
+	if len(d) != len(want) {
+		t.Fatalf("diff = %+v", d)
+	}
+	for i, f := range want {
+		if d[i].Field != f {
+			t.Fatalf("diff[%d].Field = %q, want %q", i, d[i].Field, f)
+		}
+	}
+	if d[0].Pinned != "openai/gpt-4o@2024-08-06" || d[0].Current != "openai/gpt-4o@2024-11-20" {
+		t.Fatalf("model diff = %+v", d[0])
+	}
+	if d[1].Current != "" || d[2].Pinned != "" {
+		t.Fatalf("one-sided diffs = %+v %+v", d[1], d[2])
+	}
+}
+
+func TestCompare(t *testing.T) {
+	pinned := sampleEnv()
+	payload, _ := json.Marshal(pinned)
+	events := []jobstore.JobEvent{
+		{Type: jobstore.JobCreated, Payload: []byte(`{}`)},
+		{Type: jobstore.JobEnvironment, Payload: payload},
+	}
+	c := Compare(events, sampleEnv())
+	if !c.Pinned || !c.Match || c.PinnedHash != pinned.Hash {
+		t.Fatalf("check = %+v", c)
+	}
+
+	current := sampleEnv()
+	current.PlatformVersion = "v1.3.0"
+	current.Seal()
+	c = Compare(events, current)
+	if c.Match || len(c.Mismatches) != 1 || c.Mismatches[0].Field != "platform_version" {
+		t.Fatalf("check = %+v", c)
+	}
+	if c.CurrentHash == c.PinnedHash {
+		t.Fatal("hashes should differ")
+	}
+
+	c = Compare(events[:1], current)
+	if c.Pinned || c.Match || c.CurrentHash != current.Hash {
+		t.Fatalf("unpinned check = %+v", c)
+	}
+}
+
+func TestResolver_Current(t *testing.T) {
+	calls := 0
+	r := &Resolver{
+		PlatformVersion: "dev",
+		Models:          []Model{{Role: "default", Provider: "openai", Name: "gpt-4o"}},
+		Tools: func() []Tool {
+			calls++
+			return []Tool{{Name: "search", Version: "1.0"}}
+		},
+	}
+	a, b := r.Current(), r.Current()
+	if calls != 2 {
+		t.Fatalf("tools should be read on each call, got %d", calls)
+	}
+	if a.Hash != b.Hash || a.CapturedAt.IsZero() {
+		t.Fatalf("a=%+v b=%+v", a, b)
+	}
+}
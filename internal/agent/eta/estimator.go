@@ -0,0 +1,357 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eta 在线估计 Job 时长：按 Agent 与计划形状（PlanGenerated 节点类型序列）维护滚动统计，
+// 对运行中的 Job 预测总时长、剩余时长与逐步 ETA；统计由已完成 Job 的事件流增量学习。
+package eta
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/metrics"
+)
+
+// DefaultAlpha 指数滑动平均系数：越大越偏向近期样本
+const DefaultAlpha = 0.2
+
+// 统计键中的通配部分：agent|* 为该 Agent 全部计划，*|shape 为跨 Agent 的同形状计划
+const wildcard = "*"
+
+// Basis 取值：预测所依据的统计
+const (
+	BasisAgentPlan = "agent_plan" // 同 Agent、同计划形状
+	BasisAgent     = "agent"      // 同 Agent 任意计划
+	BasisPlan      = "plan"       // 跨 Agent 同计划形状
+	BasisSteps     = "steps"      // 无整体统计，按步骤类型累加
+	BasisNone      = "none"       // 无可用样本
+)
+
+// StepETA 单步预测
+type StepETA struct {
+	NodeID      string     `json:"node_id"`
+	Key         string     `json:"key"`   // 节点类型，工具节点为 tool:<name>
+	State       string     `json:"state"` // done | running | pending
+	PredictedMs int64      `json:"predicted_ms"`
+	ActualMs    int64      `json:"actual_ms,omitempty"`
+	ETA         *time.Time `json:"eta,omitempty"`
+}
+
+// Estimate Job 时长预测
+type Estimate struct {
+	PredictedDurationMs int64      `json:"predicted_duration_ms"`
+	ElapsedMs           int64      `json:"elapsed_ms"`
+	RemainingMs         int64      `json:"remaining_ms"`
+	ETA                 *time.Time `json:"eta,omitempty"`
+	Basis               string     `json:"basis"`
+	Samples             int        `json:"samples"`
+	Confidence          string     `json:"confidence"` // low | medium | high
+	Steps               []StepETA  `json:"steps,omitempty"`
+}
+
+type rolling struct {
+	mean  float64
+	count int
+}
+
+func (r *rolling) add(v, alpha float64) {
+	if r.count == 0 {
+		r.mean = v
+	} else {
+		r.mean += alpha * (v - r.mean)
+	}
+	r.count++
+}
+
+// Estimator 按 Agent/计划形状与步骤类型维护滚动时长统计；并发安全
+type Estimator struct {
+	mu    sync.RWMutex
+	alpha float64
+	jobs  map[string]*rolling // agent|shape → Job 总时长(ms)
+	steps map[string]*rolling // 步骤键 → 单步时长(ms)
+}
+
+// NewEstimator 创建估计器；alpha<=0 或 >1 时使用 DefaultAlpha
+func NewEstimator(alpha float64) *Estimator {
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultAlpha
+	}
+	return &Estimator{alpha: alpha, jobs: make(map[string]*rolling), steps: make(map[string]*rolling)}
+}
+
+type planNode struct {
+	id  string
+	key string
+}
+
+// trace 从事件流中提取的时长相关信息
+type trace struct {
+	nodes    []planNode
+	shape    string
+	end      time.Time // 终态事件时间，未结束为零值
+	finished map[string]int64
+	started  map[string]time.Time
+}
+
+func nodeKey(n planner.TaskNode) string {
+	key := n.Type
+	if key == "" {
+		key = "unknown"
+	}
+	if n.ToolName != "" {
+		key += ":" + n.ToolName
+	}
+	return key
+}
+
+func parseTrace(events []jobstore.JobEvent) trace {
+	t := trace{finished: make(map[string]int64), started: make(map[string]time.Time)}
+	for _, e := range events {
+		switch e.Type {
+		case jobstore.PlanGenerated, jobstore.PlanEvolution:
+			var p struct {
+				TaskGraph *planner.TaskGraph `json:"task_graph"`
+			}
+			if json.Unmarshal(e.Payload, &p) != nil || p.TaskGraph == nil {
+				continue
+			}
+			t.nodes = t.nodes[:0]
+			keys := make([]string, 0, len(p.TaskGraph.Nodes))
+			for _, n := range p.TaskGraph.Nodes {
+				k := nodeKey(n)
+				t.nodes = append(t.nodes, planNode{id: n.ID, key: k})
+				keys = append(keys, k)
+			}
+			t.shape = strings.Join(keys, ">")
+		case jobstore.NodeStarted, jobstore.NodeFinished:
+			var p struct {
+				NodeID     string  `json:"node_id"`
+				DurationMs float64 `json:"duration_ms"`
+			}
+			if json.Unmarshal(e.Payload, &p) != nil || p.NodeID == "" {
+				continue
+			}
+			if e.Type == jobstore.NodeStarted {
+				t.started[p.NodeID] = e.CreatedAt
+				continue
+			}
+			ms := int64(p.DurationMs)
+			if ms <= 0 {
+				if at, ok := t.started[p.NodeID]; ok {
+					ms = e.CreatedAt.Sub(at).Milliseconds()
+				}
+			}
+			t.finished[p.NodeID] = ms
+		case jobstore.JobCompleted, jobstore.JobFailed, jobstore.JobCancelled:
+			t.end = e.CreatedAt
+		case jobstore.JobQueued, jobstore.JobRequeued:
+			// 重新入队后以新的终态为准
+			t.end = time.Time{}
+		}
+	}
+	return t
+}
+
+func keyOf(agentID, shape string) string {
+	return agentID + "|" + shape
+}
+
+// lookupJob 依次尝试 agent|shape、agent|*、*|shape
+func (e *Estimator) lookupJob(agentID, shape string) (*rolling, string) {
+	if shape != "" {
+		if r := e.jobs[keyOf(agentID, shape)]; r != nil {
+			return r, BasisAgentPlan
+		}
+	}
+	if r := e.jobs[keyOf(agentID, wildcard)]; r != nil {
+		return r, BasisAgent
+	}
+	if shape != "" {
+		if r := e.jobs[keyOf(wildcard, shape)]; r != nil {
+			return r, BasisPlan
+		}
+	}
+	return nil, BasisNone
+}
+
+// lookupStep 先按完整步骤键（tool:<name>），再按节点类型
+func (e *Estimator) lookupStep(key string) (*rolling, bool) {
+	if r := e.steps[key]; r != nil {
+		return r, true
+	}
+	if typ, _, ok := strings.Cut(key, ":"); ok {
+		if r := e.steps[typ]; r != nil {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Observe 以已完成 Job 的实际时长更新统计；非 Completed 或无终态事件时忽略并返回 false。
+// 更新前先以当前统计对该 Job 做一次预测，记录预测误差指标
+func (e *Estimator) Observe(j *job.Job, events []jobstore.JobEvent) bool {
+	if j == nil || j.Status != job.StatusCompleted {
+		return false
+	}
+	t := parseTrace(events)
+	end := t.end
+	if end.IsZero() {
+		end = j.UpdatedAt
+	}
+	if j.CreatedAt.IsZero() || end.Before(j.CreatedAt) {
+		return false
+	}
+	durMs := float64(end.Sub(j.CreatedAt).Milliseconds())
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if prev, _ := e.lookupJob(j.AgentID, t.shape); prev != nil {
+		diff := prev.mean - durMs
+		if diff < 0 {
+			diff = -diff
+		}
+		metrics.JobETAAbsErrorSeconds.Observe(diff / 1000)
+	}
+	keys := []string{keyOf(j.AgentID, wildcard)}
+	if t.shape != "" {
+		keys = append(keys, keyOf(j.AgentID, t.shape), keyOf(wildcard, t.shape))
+	}
+	for _, k := range keys {
+		r := e.jobs[k]
+		if r == nil {
+			r = &rolling{}
+			e.jobs[k] = r
+		}
+		r.add(durMs, e.alpha)
+	}
+	for _, n := range t.nodes {
+		ms, ok := t.finished[n.id]
+		if !ok {
+			continue
+		}
+		stepKeys := []string{n.key}
+		if typ, _, found := strings.Cut(n.key, ":"); found {
+			stepKeys = append(stepKeys, typ)
+		}
+		for _, k := range stepKeys {
+			r := e.steps[k]
+			if r == nil {
+				r = &rolling{}
+				e.steps[k] = r
+			}
+			r.add(float64(ms), e.alpha)
+		}
+	}
+	metrics.JobPredictedDurationSeconds.WithLabelValues(j.AgentID).Set(e.jobs[keyOf(j.AgentID, wildcard)].mean / 1000)
+	return true
+}
+
+func confidence(samples int) string {
+	switch {
+	case samples >= 20:
+		return "high"
+	case samples >= 5:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Estimate 预测 Job 总时长、剩余时长与逐步 ETA。整体统计优先；整体预测已耗尽但仍有未完成步骤时改用步骤累加。
+// 已结束的 Job 剩余为 0、ETA 为终态时间
+func (e *Estimator) Estimate(j *job.Job, events []jobstore.JobEvent, now time.Time) *Estimate {
+	if j == nil {
+		return nil
+	}
+	t := parseTrace(events)
+	terminal := j.Status == job.StatusCompleted || j.Status == job.StatusFailed || j.Status == job.StatusCancelled
+	end := now
+	if terminal {
+		end = t.end
+		if end.IsZero() {
+			end = j.UpdatedAt
+		}
+	}
+	est := &Estimate{Basis: BasisNone}
+	if !j.CreatedAt.IsZero() && end.After(j.CreatedAt) {
+		est.ElapsedMs = end.Sub(j.CreatedAt).Milliseconds()
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	cursor := now
+	allStepsKnown := len(t.nodes) > 0
+	var pendingSteps int
+	for _, n := range t.nodes {
+		s := StepETA{NodeID: n.id, Key: n.key, State: "pending"}
+		r, ok := e.lookupStep(n.key)
+		if ok {
+			s.PredictedMs = int64(r.mean)
+		} else {
+			allStepsKnown = false
+		}
+		if ms, done := t.finished[n.id]; done {
+			s.State = "done"
+			s.ActualMs = ms
+		} else if !terminal {
+			pendingSteps++
+			remaining := s.PredictedMs
+			if at, running := t.started[n.id]; running {
+				s.State = "running"
+				remaining = max(remaining-now.Sub(at).Milliseconds(), 0)
+			}
+			cursor = cursor.Add(time.Duration(remaining) * time.Millisecond)
+			if ok {
+				eta := cursor
+				s.ETA = &eta
+			}
+		}
+		est.Steps = append(est.Steps, s)
+	}
+	stepRemaining := cursor.Sub(now).Milliseconds()
+
+	if r, basis := e.lookupJob(j.AgentID, t.shape); r != nil {
+		est.Basis = basis
+		est.Samples = r.count
+		est.PredictedDurationMs = int64(r.mean)
+		est.RemainingMs = max(est.PredictedDurationMs-est.ElapsedMs, 0)
+		if est.RemainingMs == 0 && pendingSteps > 0 {
+			est.RemainingMs = stepRemaining
+		}
+	} else if allStepsKnown {
+		est.Basis = BasisSteps
+		est.RemainingMs = stepRemaining
+		est.PredictedDurationMs = est.ElapsedMs + stepRemaining
+		for _, n := range t.nodes {
+			if r, ok := e.lookupStep(n.key); ok && (est.Samples == 0 || r.count < est.Samples) {
+				est.Samples = r.count
+			}
+		}
+	}
+	est.Confidence = confidence(est.Samples)
+	if terminal {
+		est.RemainingMs = 0
+		eta := end
+		est.ETA = &eta
+	} else if est.Basis != BasisNone {
+		eta := now.Add(time.Duration(est.RemainingMs) * time.Millisecond)
+		est.ETA = &eta
+	}
+	return est
+}
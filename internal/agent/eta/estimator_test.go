@@ -0,0 +1,144 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eta
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+var t0 = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ev(t *testing.T, typ jobstore.EventType, at time.Duration, payload map[string]interface{}) jobstore.JobEvent {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobstore.JobEvent{Type: typ, CreatedAt: t0.Add(at), Payload: b}
+}
+
+func planEvent(t *testing.T) jobstore.JobEvent {
+	return ev(t, jobstore.PlanGenerated, time.Second, map[string]interface{}{
+		"task_graph": map[string]interface{}{"nodes": []map[string]interface{}{
+			{"id": "n1", "type": "tool", "tool_name": "search"},
+			{"id": "n2", "type": "llm"},
+		}},
+	})
+}
+
+// completedTrace 生成一次 n1 耗时 s1、n2 耗时 s2、总时长 total 的完成事件流
+func completedTrace(t *testing.T, s1, s2, total time.Duration) []jobstore.JobEvent {
+	return []jobstore.JobEvent{
+		planEvent(t),
+		ev(t, jobstore.NodeStarted, time.Second, map[string]interface{}{"node_id": "n1"}),
+		ev(t, jobstore.NodeFinished, time.Second+s1, map[string]interface{}{"node_id": "n1", "duration_ms": s1.Milliseconds()}),
+		ev(t, jobstore.NodeStarted, time.Second+s1, map[string]interface{}{"node_id": "n2"}),
+		ev(t, jobstore.NodeFinished, time.Second+s1+s2, map[string]interface{}{"node_id": "n2", "duration_ms": s2.Milliseconds()}),
+		ev(t, jobstore.JobCompleted, total, nil),
+	}
+}
+
+func TestEstimator_ObserveAndEstimate(t *testing.T) {
+	e := NewEstimator(0.5)
+	done := &job.Job{ID: "j1", AgentID: "a1", Status: job.StatusCompleted, CreatedAt: t0}
+	if !e.Observe(done, completedTrace(t, 4*time.Second, 2*time.Second, 10*time.Second)) {
+		t.Fatal("completed job should be observed")
+	}
+	if !e.Observe(done, completedTrace(t, 8*time.Second, 2*time.Second, 20*time.Second)) {
+		t.Fatal("completed job should be observed")
+	}
+	if e.Observe(&job.Job{AgentID: "a1", Status: job.StatusFailed, CreatedAt: t0}, completedTrace(t, time.Second, time.Second, time.Minute)) {
+		t.Fatal("failed job must not be observed")
+	}
+
+	// 运行中：n1 已完成，n2 已开始 1s
+	running := &job.Job{ID: "j2", AgentID: "a1", Status: job.StatusRunning, CreatedAt: t0}
+	events := completedTrace(t, 4*time.Second, 0, 0)[:4]
+	now := t0.Add(6 * time.Second)
+	est := e.Estimate(running, events, now)
+	if est.Basis != BasisAgentPlan || est.Samples != 2 {
+		t.Fatalf("basis = %s samples = %d", est.Basis, est.Samples)
+	}
+	if est.PredictedDurationMs != 15000 || est.ElapsedMs != 6000 || est.RemainingMs != 9000 {
+		t.Fatalf("unexpected estimate: %+v", est)
+	}
+	if est.ETA == nil || !est.ETA.Equal(now.Add(9*time.Second)) {
+		t.Fatalf("eta = %v", est.ETA)
+	}
+	if len(est.Steps) != 2 || est.Steps[0].State != "done" || est.Steps[0].ActualMs != 4000 || est.Steps[1].State != "running" {
+		t.Fatalf("unexpected steps: %+v", est.Steps)
+	}
+	if est.Steps[1].PredictedMs != 2000 || est.Steps[1].ETA == nil || !est.Steps[1].ETA.Equal(now.Add(time.Second)) {
+		t.Fatalf("running step = %+v", est.Steps[1])
+	}
+
+	// 其他 Agent 同形状计划回退到跨 Agent 统计
+	other := e.Estimate(&job.Job{AgentID: "a2", Status: job.StatusRunning, CreatedAt: t0}, events, now)
+	if other.Basis != BasisPlan {
+		t.Fatalf("basis = %s, want %s", other.Basis, BasisPlan)
+	}
+
+	// 无计划、无样本
+	none := e.Estimate(&job.Job{AgentID: "a3", Status: job.StatusPending, CreatedAt: t0}, nil, now)
+	if none.Basis != BasisNone || none.ETA != nil || none.Confidence != "low" {
+		t.Fatalf("unexpected estimate without samples: %+v", none)
+	}
+}
+
+func TestEstimator_StepFallbackAfterOverrun(t *testing.T) {
+	e := NewEstimator(0)
+	e.Observe(&job.Job{AgentID: "a1", Status: job.StatusCompleted, CreatedAt: t0}, completedTrace(t, 2*time.Second, 3*time.Second, 6*time.Second))
+	// 已超过整体预测但 n2 未开始：剩余按步骤统计累加
+	events := completedTrace(t, 20*time.Second, 0, 0)[:3]
+	now := t0.Add(30 * time.Second)
+	est := e.Estimate(&job.Job{AgentID: "a1", Status: job.StatusRunning, CreatedAt: t0}, events, now)
+	if est.RemainingMs != 3000 || est.Steps[1].State != "pending" {
+		t.Fatalf("unexpected estimate: %+v", est)
+	}
+}
+
+func TestLearner_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	id, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g"})
+	_, _ = jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g2"}) // 未完成，不参与学习
+	_, ver, _ := events.ListEvents(ctx, id)
+	if _, err := events.Append(ctx, id, ver, jobstore.JobEvent{JobID: id, Type: jobstore.JobCompleted}); err != nil {
+		t.Fatal(err)
+	}
+	_ = jobs.UpdateStatus(ctx, id, job.StatusRunning)
+	_ = jobs.UpdateStatus(ctx, id, job.StatusCompleted)
+
+	est := NewEstimator(0)
+	l := NewLearner(est, jobs, events, nil)
+	n, err := l.RunOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v; want 1", n, err)
+	}
+	if n, _ = l.RunOnce(ctx); n != 0 {
+		t.Fatalf("second RunOnce learned %d jobs, want 0", n)
+	}
+	got := est.Estimate(&job.Job{AgentID: "a1", Status: job.StatusRunning, CreatedAt: time.Now()}, nil, time.Now())
+	if got.Basis != BasisAgent || got.Samples != 1 {
+		t.Fatalf("unexpected estimate after learning: %+v", got)
+	}
+}
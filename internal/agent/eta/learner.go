@@ -0,0 +1,120 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eta
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+)
+
+const (
+	// DefaultLearnInterval 默认学习间隔
+	DefaultLearnInterval = time.Minute
+	// DefaultWarmup 首轮回溯窗口：启动时从该时长内已完成的 Job 预热统计
+	DefaultWarmup = 7 * 24 * time.Hour
+	// DefaultBatch 单轮最多扫描的 Job 数
+	DefaultBatch = 1000
+	// scanOverlap 相邻两轮扫描窗口的重叠，避免 updated_at 与扫描时刻交错时漏读
+	scanOverlap = time.Minute
+)
+
+// Learner 周期扫描最近更新的 Job，将新完成的 Job 喂给 Estimator
+type Learner struct {
+	est    *Estimator
+	jobs   job.RecentJobLister
+	events jobstore.JobStore
+	logger *log.Logger
+	now    func() time.Time
+
+	mu    sync.Mutex
+	since time.Time
+	seen  map[string]time.Time // job_id → 学习时的 updated_at
+}
+
+// NewLearner 创建学习器
+func NewLearner(est *Estimator, jobs job.RecentJobLister, events jobstore.JobStore, logger *log.Logger) *Learner {
+	return &Learner{est: est, jobs: jobs, events: events, logger: logger, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// Run 按 interval 周期学习直到 ctx 取消；启动时立即执行一轮
+func (l *Learner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultLearnInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := l.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			l.logf("Job 时长学习failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 扫描上轮以来更新的 Job，学习其中新完成的；返回本轮学习的 Job 数
+func (l *Learner) RunOnce(ctx context.Context) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start := l.now()
+	since := l.since
+	if since.IsZero() {
+		since = start.Add(-DefaultWarmup)
+	}
+	jobs, err := l.jobs.ListUpdatedSince(ctx, "", since, DefaultBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list recent jobs: %w", err)
+	}
+	learned := 0
+	for _, j := range jobs {
+		if j == nil || j.Status != job.StatusCompleted {
+			continue
+		}
+		if _, ok := l.seen[j.ID]; ok {
+			continue
+		}
+		events, _, err := l.events.ListEvents(ctx, j.ID)
+		if err != nil {
+			l.logf("读取 Job 事件failed", "job_id", j.ID, "error", err)
+			continue
+		}
+		l.seen[j.ID] = j.UpdatedAt
+		if l.est.Observe(j, events) {
+			learned++
+		}
+	}
+	l.since = start.Add(-scanOverlap)
+	for id, at := range l.seen {
+		if at.Before(l.since) {
+			delete(l.seen, id)
+		}
+	}
+	return learned, nil
+}
+
+func (l *Learner) logf(msg string, args ...any) {
+	if l.logger != nil {
+		l.logger.Warn(msg, args...)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evalsuite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 报告状态
+const (
+	ReportRunning   = "running"
+	ReportCompleted = "completed"
+	ReportFailed    = "failed" // 运行本身出错（如回归集无法读取），与用例未通过区分
+)
+
+// Report 一次回归集运行的报告；运行中逐用例更新，供轮询进度与历史对比
+type Report struct {
+	ID       string `json:"id"`
+	AgentID  string `json:"agent_id"`
+	TenantID string `json:"tenant_id"`
+	// Label 可选的运行标签（如 prompt 版本、提交号），便于在历史中对比
+	Label  string       `json:"label,omitempty"`
+	Status string       `json:"status"`
+	Total  int          `json:"total"`
+	Passed int          `json:"passed"`
+	Failed int          `json:"failed"`
+	Cases  []CaseResult `json:"cases"`
+	// Regressions 上一次完成的运行中通过、本次未通过的用例
+	Regressions []string   `json:"regressions,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// NewReport 创建运行中的报告
+func NewReport(s *Suite, tenantID, label string) *Report {
+	return &Report{
+		ID:        "eval-" + uuid.New().String(),
+		AgentID:   s.AgentID,
+		TenantID:  tenantID,
+		Label:     label,
+		Status:    ReportRunning,
+		Total:     len(s.Cases),
+		Cases:     []CaseResult{},
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// Executor 提交用例目标并等待 Job 终态；API 内置实现走与 POST /api/agents/:id/message 相同的受理路径
+type Executor interface {
+	// Submit 以 goal 为 Agent 创建 Job
+	Submit(ctx context.Context, agentID, goal string) (jobID string, err error)
+	// Await 等待 Job 进入终态并提取结果；ctx 到期时返回 ctx.Err()
+	Await(ctx context.Context, jobID string) (Outcome, error)
+}
+
+// Runner 顺序执行回归集用例并持久化报告
+type Runner struct {
+	exec  Executor
+	store Store
+}
+
+// NewRunner 创建 Runner
+func NewRunner(exec Executor, store Store) *Runner {
+	return &Runner{exec: exec, store: store}
+}
+
+// Run 顺序执行全部用例，每个用例结束后保存一次报告；结束时与同租户上一次完成的报告对比得出 Regressions。
+// 单个用例提交失败或超时记为未通过，不中断后续用例
+func (r *Runner) Run(ctx context.Context, s *Suite, rep *Report) *Report {
+	previous := r.lastCompleted(ctx, rep)
+	timeout := s.Timeout()
+	for _, c := range s.Cases {
+		if ctx.Err() != nil {
+			rep.Status, rep.Error = ReportFailed, ctx.Err().Error()
+			break
+		}
+		rep.Cases = append(rep.Cases, r.runCase(ctx, s.AgentID, c, timeout))
+		rep.Passed, rep.Failed = tally(rep.Cases)
+		_ = r.store.SaveReport(ctx, rep)
+	}
+	if rep.Status == ReportRunning {
+		rep.Status = ReportCompleted
+	}
+	rep.Regressions = regressions(previous, rep)
+	now := time.Now().UTC()
+	rep.FinishedAt = &now
+	_ = r.store.SaveReport(context.WithoutCancel(ctx), rep)
+	return rep
+}
+
+func (r *Runner) runCase(ctx context.Context, agentID string, c Case, timeout time.Duration) CaseResult {
+	caseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	jobID, err := r.exec.Submit(caseCtx, agentID, c.Goal)
+	if err != nil {
+		return CaseResult{Name: c.Name, Goal: c.Goal, Failures: []string{"submit: " + err.Error()}, Outcome: Outcome{Status: "error", Error: err.Error()}}
+	}
+	o, err := r.exec.Await(caseCtx, jobID)
+	if err != nil {
+		msg := err.Error()
+		if caseCtx.Err() != nil && ctx.Err() == nil {
+			msg = fmt.Sprintf("timed out after %s", timeout)
+		}
+		return CaseResult{Name: c.Name, Goal: c.Goal, Failures: []string{msg}, Outcome: Outcome{JobID: jobID, Status: "timeout", Error: msg}}
+	}
+	o.JobID = jobID
+	return Evaluate(c, o)
+}
+
+func (r *Runner) lastCompleted(ctx context.Context, rep *Report) *Report {
+	list, err := r.store.ListReports(ctx, rep.TenantID, rep.AgentID, 20)
+	if err != nil {
+		return nil
+	}
+	for _, prev := range list {
+		if prev.ID != rep.ID && prev.Status == ReportCompleted {
+			return prev
+		}
+	}
+	return nil
+}
+
+func tally(cases []CaseResult) (passed, failed int) {
+	for _, c := range cases {
+		if c.Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+func regressions(previous, current *Report) []string {
+	if previous == nil {
+		return nil
+	}
+	passedBefore := make(map[string]bool, len(previous.Cases))
+	for _, c := range previous.Cases {
+		passedBefore[c.Name] = c.Passed
+	}
+	var out []string
+	for _, c := range current.Cases {
+		if !c.Passed && passedBefore[c.Name] {
+			out = append(out, c.Name)
+		}
+	}
+	return out
+}
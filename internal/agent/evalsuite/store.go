@@ -0,0 +1,124 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evalsuite
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store 回归集与运行报告存储；回归集按 Agent 唯一，报告按租户隔离
+type Store interface {
+	// PutSuite 创建或整体替换 Agent 的回归集
+	PutSuite(ctx context.Context, s *Suite) error
+	// GetSuite 获取回归集；不存在返回 ErrNotFound
+	GetSuite(ctx context.Context, agentID string) (*Suite, error)
+	// SaveReport 创建或更新报告（按 ID）
+	SaveReport(ctx context.Context, r *Report) error
+	// GetReport 获取报告；不存在返回 ErrNotFound
+	GetReport(ctx context.Context, id string) (*Report, error)
+	// ListReports 列出租户下 Agent 的报告（新到旧）
+	ListReports(ctx context.Context, tenantID, agentID string, limit int) ([]*Report, error)
+}
+
+// StoreMem 内存实现
+type StoreMem struct {
+	mu      sync.RWMutex
+	suites  map[string]*Suite
+	reports map[string]*Report
+}
+
+// NewStoreMem 创建内存回归集存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{suites: make(map[string]*Suite), reports: make(map[string]*Report)}
+}
+
+func (s *StoreMem) PutSuite(ctx context.Context, suite *Suite) error {
+	if suite == nil {
+		return errors.New("suite is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *suite
+	cp.Cases = append([]Case(nil), suite.Cases...)
+	now := time.Now()
+	if prev, ok := s.suites[cp.AgentID]; ok {
+		cp.CreatedAt = prev.CreatedAt
+	} else {
+		cp.CreatedAt = now
+	}
+	cp.UpdatedAt = now
+	s.suites[cp.AgentID] = &cp
+	return nil
+}
+
+func (s *StoreMem) GetSuite(ctx context.Context, agentID string) (*Suite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	suite, ok := s.suites[agentID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *suite
+	cp.Cases = append([]Case(nil), suite.Cases...)
+	return &cp, nil
+}
+
+func (s *StoreMem) SaveReport(ctx context.Context, r *Report) error {
+	if r == nil {
+		return errors.New("report is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[r.ID] = copyReport(r)
+	return nil
+}
+
+func (s *StoreMem) GetReport(ctx context.Context, id string) (*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.reports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyReport(r), nil
+}
+
+func (s *StoreMem) ListReports(ctx context.Context, tenantID, agentID string, limit int) ([]*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Report
+	for _, r := range s.reports {
+		if r.TenantID == tenantID && r.AgentID == agentID {
+			out = append(out, copyReport(r))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func copyReport(r *Report) *Report {
+	cp := *r
+	cp.Cases = slices.Clone(r.Cases)
+	cp.Regressions = slices.Clone(r.Regressions)
+	return &cp
+}
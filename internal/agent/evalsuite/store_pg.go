@@ -0,0 +1,124 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evalsuite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 agent_eval_suites 与 agent_eval_reports 表
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的回归集存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+func (s *StorePg) PutSuite(ctx context.Context, suite *Suite) error {
+	if suite == nil {
+		return errors.New("suite is nil")
+	}
+	cases, err := json.Marshal(suite.Cases)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO agent_eval_suites (agent_id, cases, case_timeout) VALUES ($1, $2, $3)
+		 ON CONFLICT (agent_id) DO UPDATE SET cases = EXCLUDED.cases, case_timeout = EXCLUDED.case_timeout, updated_at = now()`,
+		suite.AgentID, cases, suite.CaseTimeout)
+	return err
+}
+
+func (s *StorePg) GetSuite(ctx context.Context, agentID string) (*Suite, error) {
+	var suite Suite
+	var cases []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT agent_id, cases, case_timeout, created_at, updated_at FROM agent_eval_suites WHERE agent_id = $1`, agentID).
+		Scan(&suite.AgentID, &cases, &suite.CaseTimeout, &suite.CreatedAt, &suite.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(cases, &suite.Cases); err != nil {
+		return nil, err
+	}
+	return &suite, nil
+}
+
+func (s *StorePg) SaveReport(ctx context.Context, r *Report) error {
+	if r == nil {
+		return errors.New("report is nil")
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO agent_eval_reports (id, tenant_id, agent_id, status, report, started_at) VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, report = EXCLUDED.report`,
+		r.ID, r.TenantID, r.AgentID, r.Status, body, r.StartedAt)
+	return err
+}
+
+func (s *StorePg) GetReport(ctx context.Context, id string) (*Report, error) {
+	var body []byte
+	err := s.pool.QueryRow(ctx, `SELECT report FROM agent_eval_reports WHERE id = $1`, id).Scan(&body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *StorePg) ListReports(ctx context.Context, tenantID, agentID string, limit int) ([]*Report, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.pool.Query(ctx,
+		`SELECT report FROM agent_eval_reports WHERE tenant_id = $1 AND agent_id = $2 ORDER BY started_at DESC LIMIT $3`,
+		tenantID, agentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Report
+	for rows.Next() {
+		var body []byte
+		if err := rows.Scan(&body); err != nil {
+			return nil, err
+		}
+		var r Report
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, &r)
+	}
+	return out, rows.Err()
+}
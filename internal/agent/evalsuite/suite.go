@@ -0,0 +1,254 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package evalsuite 按 Agent 维护黄金目标回归集：每个用例是一个目标加期望结果断言（必须调用的工具、回答须包含的内容、成本上限等）；
+// 在 staging 环境批量运行并按时间保存通过/失败报告，planner 或 prompt 变更前后可据此做回归对比
+package evalsuite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// ErrNotFound 回归集或报告不存在
+var ErrNotFound = errors.New("evalsuite: not found")
+
+// DefaultCaseTimeout 单个用例从提交到 Job 终态的默认等待上限
+const DefaultCaseTimeout = 10 * time.Minute
+
+// Assertions 用例的期望结果；零值字段不检查
+type Assertions struct {
+	// Status 期望的 Job 终态，默认 completed
+	Status string `json:"status,omitempty"`
+	// MustCallTools 必须至少调用一次的工具
+	MustCallTools []string `json:"must_call_tools,omitempty"`
+	// MustNotCallTools 不得调用的工具
+	MustNotCallTools []string `json:"must_not_call_tools,omitempty"`
+	// AnswerContains 回答须包含的片段（不区分大小写）
+	AnswerContains []string `json:"answer_contains,omitempty"`
+	// AnswerNotContains 回答不得包含的片段（不区分大小写）
+	AnswerNotContains []string `json:"answer_not_contains,omitempty"`
+	// MaxCost 按工具/LLM 成本标注估算的执行成本上限（USD）
+	MaxCost float64 `json:"max_cost,omitempty"`
+	// MaxDuration 执行耗时上限（Go duration，如 "2m"）
+	MaxDuration string `json:"max_duration,omitempty"`
+}
+
+// Case 单个黄金目标
+type Case struct {
+	Name   string     `json:"name"`
+	Goal   string     `json:"goal"`
+	Assert Assertions `json:"assert"`
+}
+
+// Suite Agent 的回归集（每个 Agent 一个）
+type Suite struct {
+	AgentID string `json:"agent_id"`
+	Cases   []Case `json:"cases"`
+	// CaseTimeout 单个用例等待上限，空为 DefaultCaseTimeout
+	CaseTimeout string    `json:"case_timeout,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var validStatuses = map[string]bool{"completed": true, "failed": true, "cancelled": true}
+
+// Validate 校验用例名唯一、目标非空、断言可解析
+func (s *Suite) Validate() error {
+	if s.AgentID == "" {
+		return errors.New("agent_id is required")
+	}
+	if len(s.Cases) == 0 {
+		return errors.New("cases must not be empty")
+	}
+	if s.CaseTimeout != "" {
+		if d, err := time.ParseDuration(s.CaseTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("case_timeout %q is not a positive duration", s.CaseTimeout)
+		}
+	}
+	seen := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("cases[%d]: name is required", i)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("cases[%d]: duplicate name %q", i, c.Name)
+		}
+		seen[c.Name] = true
+		if strings.TrimSpace(c.Goal) == "" {
+			return fmt.Errorf("case %q: goal is required", c.Name)
+		}
+		if c.Assert.Status != "" && !validStatuses[c.Assert.Status] {
+			return fmt.Errorf("case %q: status must be completed, failed or cancelled", c.Name)
+		}
+		if c.Assert.MaxCost < 0 {
+			return fmt.Errorf("case %q: max_cost must not be negative", c.Name)
+		}
+		if c.Assert.MaxDuration != "" {
+			if d, err := time.ParseDuration(c.Assert.MaxDuration); err != nil || d <= 0 {
+				return fmt.Errorf("case %q: max_duration %q is not a positive duration", c.Name, c.Assert.MaxDuration)
+			}
+		}
+	}
+	return nil
+}
+
+// Timeout 单个用例的等待上限
+func (s *Suite) Timeout() time.Duration {
+	if d, err := time.ParseDuration(s.CaseTimeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultCaseTimeout
+}
+
+// Outcome 一次用例执行的可观测结果，由 Job 终态与事件流提取
+type Outcome struct {
+	JobID      string         `json:"job_id,omitempty"`
+	Status     string         `json:"status"`
+	Answer     string         `json:"answer,omitempty"`
+	ToolCalls  map[string]int `json:"tool_calls,omitempty"`
+	Cost       float64        `json:"cost"`
+	DurationMs int64          `json:"duration_ms"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// OutcomeFromEvents 从 Job 事件流提取结果：工具调用来自 tool_invocation_started，成本按 costs 标注对工具调用与 llm 节点计费，
+// 回答取最后一个完成节点的结果（字符串原样，其他类型为 JSON），耗时为 job_created 到终态事件
+func OutcomeFromEvents(jobID, status string, events []jobstore.JobEvent, costs planner.CostModel) Outcome {
+	o := Outcome{JobID: jobID, Status: status, ToolCalls: make(map[string]int)}
+	llmNodes := make(map[string]bool)
+	llmCalls := 0
+	var start, end time.Time
+	for _, e := range events {
+		switch e.Type {
+		case jobstore.JobCreated:
+			start = e.CreatedAt
+		case jobstore.PlanGenerated, jobstore.PlanEvolution:
+			var pl struct {
+				TaskGraph *planner.TaskGraph `json:"task_graph"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.TaskGraph == nil {
+				continue
+			}
+			for _, n := range pl.TaskGraph.Nodes {
+				llmNodes[n.ID] = n.Type == planner.NodeLLM
+			}
+		case jobstore.ToolInvocationStarted:
+			var pl struct {
+				ToolName string `json:"tool_name"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.ToolName != "" {
+				o.ToolCalls[pl.ToolName]++
+			}
+		case jobstore.NodeFinished:
+			var pl struct {
+				NodeID         string                     `json:"node_id"`
+				PayloadResults map[string]json.RawMessage `json:"payload_results"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			if llmNodes[pl.NodeID] {
+				llmCalls++
+			}
+			if raw, ok := pl.PayloadResults[pl.NodeID]; ok {
+				o.Answer = answerText(raw)
+			}
+		case jobstore.JobCompleted, jobstore.JobFailed, jobstore.JobCancelled:
+			end = e.CreatedAt
+			var pl struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.Error != "" {
+				o.Error = pl.Error
+			}
+		}
+	}
+	for name, n := range o.ToolCalls {
+		o.Cost += float64(n) * costs.Tools[name].CostPerCall
+	}
+	o.Cost += float64(llmCalls) * costs.LLM.CostPerCall
+	if !start.IsZero() && end.After(start) {
+		o.DurationMs = end.Sub(start).Milliseconds()
+	}
+	return o
+}
+
+func answerText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// CaseResult 单个用例的评估结果
+type CaseResult struct {
+	Name     string   `json:"name"`
+	Goal     string   `json:"goal"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+	Outcome  Outcome  `json:"outcome"`
+}
+
+// Evaluate 按断言检查结果；每条未满足的断言记一条 failure
+func Evaluate(c Case, o Outcome) CaseResult {
+	r := CaseResult{Name: c.Name, Goal: c.Goal, Outcome: o}
+	fail := func(format string, args ...interface{}) {
+		r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+	}
+	a := c.Assert
+	want := a.Status
+	if want == "" {
+		want = "completed"
+	}
+	if o.Status != want {
+		fail("status %s, want %s", o.Status, want)
+	}
+	for _, tool := range a.MustCallTools {
+		if o.ToolCalls[tool] == 0 {
+			fail("tool %s was not called", tool)
+		}
+	}
+	for _, tool := range a.MustNotCallTools {
+		if n := o.ToolCalls[tool]; n > 0 {
+			fail("tool %s was called %d times", tool, n)
+		}
+	}
+	answer := strings.ToLower(o.Answer)
+	for _, s := range a.AnswerContains {
+		if !strings.Contains(answer, strings.ToLower(s)) {
+			fail("answer does not contain %q", s)
+		}
+	}
+	for _, s := range a.AnswerNotContains {
+		if strings.Contains(answer, strings.ToLower(s)) {
+			fail("answer contains %q", s)
+		}
+	}
+	if a.MaxCost > 0 && o.Cost > a.MaxCost {
+		fail("cost %.4f > max %.4f", o.Cost, a.MaxCost)
+	}
+	if d, err := time.ParseDuration(a.MaxDuration); err == nil && d > 0 && time.Duration(o.DurationMs)*time.Millisecond > d {
+		fail("duration %s > max %s", time.Duration(o.DurationMs)*time.Millisecond, d)
+	}
+	r.Passed = len(r.Failures) == 0
+	return r
+}
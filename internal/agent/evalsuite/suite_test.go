@@ -0,0 +1,158 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evalsuite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func evalEvent(t *testing.T, typ jobstore.EventType, at time.Time, payload map[string]interface{}) jobstore.JobEvent {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobstore.JobEvent{Type: typ, CreatedAt: at, Payload: b}
+}
+
+func TestSuite_Validate(t *testing.T) {
+	ok := &Suite{AgentID: "a", Cases: []Case{{Name: "refund", Goal: "refund order 1", Assert: Assertions{MaxDuration: "1m"}}}}
+	if err := ok.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	bad := []*Suite{
+		{AgentID: "a"},
+		{AgentID: "a", Cases: []Case{{Name: "x", Goal: "g"}, {Name: "x", Goal: "g"}}},
+		{AgentID: "a", Cases: []Case{{Name: "x"}}},
+		{AgentID: "a", Cases: []Case{{Name: "x", Goal: "g", Assert: Assertions{Status: "running"}}}},
+		{AgentID: "a", Cases: []Case{{Name: "x", Goal: "g", Assert: Assertions{MaxDuration: "soon"}}}},
+		{AgentID: "a", CaseTimeout: "-1s", Cases: []Case{{Name: "x", Goal: "g"}}},
+	}
+	for i, s := range bad {
+		if s.Validate() == nil {
+			t.Errorf("suite %d should be invalid", i)
+		}
+	}
+}
+
+func TestOutcomeFromEvents_AndEvaluate(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	graph := map[string]interface{}{"nodes": []map[string]interface{}{
+		{"id": "n1", "type": "tool", "tool_name": "order.get"},
+		{"id": "n2", "type": "llm"},
+	}}
+	events := []jobstore.JobEvent{
+		evalEvent(t, jobstore.JobCreated, t0, map[string]interface{}{"goal": "g"}),
+		evalEvent(t, jobstore.PlanGenerated, t0, map[string]interface{}{"task_graph": graph}),
+		evalEvent(t, jobstore.ToolInvocationStarted, t0.Add(time.Second), map[string]interface{}{"tool_name": "order.get"}),
+		evalEvent(t, jobstore.NodeFinished, t0.Add(2*time.Second), map[string]interface{}{"node_id": "n1", "payload_results": map[string]interface{}{"n1": map[string]interface{}{"status": "paid"}}}),
+		evalEvent(t, jobstore.NodeFinished, t0.Add(3*time.Second), map[string]interface{}{"node_id": "n2", "payload_results": map[string]interface{}{"n2": "Refund issued for order 1"}}),
+		evalEvent(t, jobstore.JobCompleted, t0.Add(4*time.Second), map[string]interface{}{"goal": "g"}),
+	}
+	costs := planner.CostModel{Tools: map[string]planner.ToolCostHint{"order.get": {CostPerCall: 0.01}}, LLM: planner.ToolCostHint{CostPerCall: 0.02}}
+	o := OutcomeFromEvents("job-1", "completed", events, costs)
+	if o.Answer != "Refund issued for order 1" || o.ToolCalls["order.get"] != 1 || o.DurationMs != 4000 {
+		t.Fatalf("outcome = %+v", o)
+	}
+	if o.Cost < 0.0299 || o.Cost > 0.0301 {
+		t.Fatalf("cost = %v, want 0.03", o.Cost)
+	}
+
+	pass := Evaluate(Case{Name: "ok", Assert: Assertions{MustCallTools: []string{"order.get"}, AnswerContains: []string{"REFUND issued"}, MaxCost: 0.05, MaxDuration: "10s"}}, o)
+	if !pass.Passed {
+		t.Fatalf("failures = %v", pass.Failures)
+	}
+	fail := Evaluate(Case{Name: "bad", Assert: Assertions{
+		Status:           "failed",
+		MustCallTools:    []string{"refund.create"},
+		MustNotCallTools: []string{"order.get"},
+		AnswerContains:   []string{"declined"},
+		MaxCost:          0.01,
+		MaxDuration:      "1s",
+	}}, o)
+	if fail.Passed || len(fail.Failures) != 6 {
+		t.Fatalf("failures = %v", fail.Failures)
+	}
+}
+
+type fakeExecutor struct {
+	outcomes map[string]Outcome // goal -> outcome
+	rejected map[string]bool
+}
+
+func (f *fakeExecutor) Submit(ctx context.Context, agentID, goal string) (string, error) {
+	if f.rejected[goal] {
+		return "", errors.New("over budget")
+	}
+	return "job-" + goal, nil
+}
+
+func (f *fakeExecutor) Await(ctx context.Context, jobID string) (Outcome, error) {
+	o, ok := f.outcomes[jobID[len("job-"):]]
+	if !ok {
+		<-ctx.Done()
+		return Outcome{}, ctx.Err()
+	}
+	return o, nil
+}
+
+func TestRunner_RunAndRegressions(t *testing.T) {
+	ctx := context.Background()
+	store := NewStoreMem()
+	suite := &Suite{AgentID: "a", CaseTimeout: "50ms", Cases: []Case{
+		{Name: "greet", Goal: "hello", Assert: Assertions{AnswerContains: []string{"hi"}}},
+		{Name: "refund", Goal: "refund", Assert: Assertions{MustCallTools: []string{"refund.create"}}},
+	}}
+	exec := &fakeExecutor{outcomes: map[string]Outcome{
+		"hello":  {Status: "completed", Answer: "hi there"},
+		"refund": {Status: "completed", ToolCalls: map[string]int{"refund.create": 1}},
+	}}
+	first := NewRunner(exec, store).Run(ctx, suite, NewReport(suite, "t1", "v1"))
+	if first.Status != ReportCompleted || first.Passed != 2 || first.Failed != 0 || first.FinishedAt == nil {
+		t.Fatalf("first run = %+v", first)
+	}
+	if first.Cases[0].Outcome.JobID != "job-hello" {
+		t.Fatalf("job id not recorded: %+v", first.Cases[0])
+	}
+
+	// 第二次：refund 提交被拒，greet 超时；两者都记为回归
+	time.Sleep(time.Millisecond)
+	exec.rejected = map[string]bool{"refund": true}
+	delete(exec.outcomes, "hello")
+	second := NewRunner(exec, store).Run(ctx, suite, NewReport(suite, "t1", "v2"))
+	if second.Passed != 0 || second.Failed != 2 {
+		t.Fatalf("second run = %+v", second)
+	}
+	if second.Cases[0].Outcome.Status != "timeout" || second.Cases[1].Outcome.Status != "error" {
+		t.Fatalf("cases = %+v", second.Cases)
+	}
+	if len(second.Regressions) != 2 {
+		t.Fatalf("regressions = %v", second.Regressions)
+	}
+	list, _ := store.ListReports(ctx, "t1", "a", 0)
+	if len(list) != 2 || list[0].Label != "v2" {
+		t.Fatalf("history = %+v", list)
+	}
+	if other, _ := store.ListReports(ctx, "t2", "a", 0); len(other) != 0 {
+		t.Fatalf("reports leaked across tenants: %d", len(other))
+	}
+}
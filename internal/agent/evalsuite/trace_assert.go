@@ -0,0 +1,227 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evalsuite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// 轨迹断言类型（POST /api/jobs/:id/assert）
+const (
+	// AssertStatus Job 终态等于 status
+	AssertStatus = "status"
+	// AssertStepCompleted 节点 step 成功完成（node_finished 且 result_type 为成功类）
+	AssertStepCompleted = "step_completed"
+	// AssertToolCalled 工具 tool 的调用次数在 [min, max] 内；min、max 均未给出时为至少一次
+	AssertToolCalled = "tool_called"
+	// AssertNoPermanentFailures 无 permanent_failure 的节点或 Job failed
+	AssertNoPermanentFailures = "no_permanent_failures"
+	// AssertMaxCost 估算执行成本不超过 value（USD）
+	AssertMaxCost = "max_cost"
+	// AssertMaxDuration 执行耗时不超过 duration
+	AssertMaxDuration = "max_duration"
+	// AssertAnswerContains 回答包含 text（不区分大小写）
+	AssertAnswerContains = "answer_contains"
+)
+
+// MaxTraceAssertions 单次请求的断言条数上限
+const MaxTraceAssertions = 100
+
+// TraceAssertion 针对单个 Job 事件流的声明式断言；按 Type 使用对应字段
+type TraceAssertion struct {
+	Type     string  `json:"type"`
+	Status   string  `json:"status,omitempty"`
+	Step     string  `json:"step,omitempty"`
+	Tool     string  `json:"tool,omitempty"`
+	Min      *int    `json:"min,omitempty"`
+	Max      *int    `json:"max,omitempty"`
+	Value    float64 `json:"value,omitempty"`
+	Duration string  `json:"duration,omitempty"`
+	Text     string  `json:"text,omitempty"`
+}
+
+// TraceAssertionResult 单条断言的评估结果；Actual 为观察到的值
+type TraceAssertionResult struct {
+	Index     int            `json:"index"`
+	Assertion TraceAssertion `json:"assertion"`
+	Passed    bool           `json:"passed"`
+	Message   string         `json:"message,omitempty"`
+	Actual    interface{}    `json:"actual,omitempty"`
+}
+
+// TraceReport 一组轨迹断言的评估结果；Passed 为全部通过
+type TraceReport struct {
+	JobID   string                 `json:"job_id"`
+	Status  string                 `json:"status"`
+	Passed  bool                   `json:"passed"`
+	Total   int                    `json:"total"`
+	Failed  int                    `json:"failed"`
+	Results []TraceAssertionResult `json:"results"`
+	Outcome Outcome                `json:"outcome"`
+}
+
+// ValidateTraceAssertions 校验断言类型与必填字段
+func ValidateTraceAssertions(list []TraceAssertion) error {
+	if len(list) == 0 {
+		return errors.New("assertions must not be empty")
+	}
+	if len(list) > MaxTraceAssertions {
+		return fmt.Errorf("at most %d assertions are allowed", MaxTraceAssertions)
+	}
+	for i, a := range list {
+		var err error
+		switch a.Type {
+		case AssertStatus:
+			if !validStatuses[a.Status] {
+				err = errors.New("status must be completed, failed or cancelled")
+			}
+		case AssertStepCompleted:
+			if a.Step == "" {
+				err = errors.New("step is required")
+			}
+		case AssertToolCalled:
+			switch {
+			case a.Tool == "":
+				err = errors.New("tool is required")
+			case (a.Min != nil && *a.Min < 0) || (a.Max != nil && *a.Max < 0):
+				err = errors.New("min and max must not be negative")
+			case a.Min != nil && a.Max != nil && *a.Min > *a.Max:
+				err = errors.New("min must not exceed max")
+			}
+		case AssertNoPermanentFailures:
+		case AssertMaxCost:
+			if a.Value < 0 {
+				err = errors.New("value must not be negative")
+			}
+		case AssertMaxDuration:
+			if d, perr := time.ParseDuration(a.Duration); perr != nil || d <= 0 {
+				err = fmt.Errorf("duration %q is not a positive duration", a.Duration)
+			}
+		case AssertAnswerContains:
+			if a.Text == "" {
+				err = errors.New("text is required")
+			}
+		default:
+			err = fmt.Errorf("unknown type %q", a.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("assertions[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// stepSucceeded 视为步骤成功完成的 result_type（空为旧事件，按成功处理）
+var stepSucceeded = map[string]bool{"": true, "success": true, "pure": true, "side_effect_committed": true}
+
+// traceFacts 断言所需、Outcome 之外的事件流事实
+type traceFacts struct {
+	completedSteps    map[string]bool
+	permanentFailures []string
+}
+
+func traceFactsOf(events []jobstore.JobEvent) traceFacts {
+	f := traceFacts{completedSteps: make(map[string]bool)}
+	for _, e := range events {
+		if e.Type != jobstore.NodeFinished && e.Type != jobstore.JobFailed {
+			continue
+		}
+		var pl struct {
+			NodeID     string `json:"node_id"`
+			StepID     string `json:"step_id"`
+			ResultType string `json:"result_type"`
+		}
+		if json.Unmarshal(e.Payload, &pl) != nil {
+			continue
+		}
+		if e.Type == jobstore.NodeFinished && stepSucceeded[pl.ResultType] {
+			f.completedSteps[pl.NodeID] = true
+			if pl.StepID != "" {
+				f.completedSteps[pl.StepID] = true
+			}
+		}
+		if pl.ResultType == "permanent_failure" {
+			where := pl.NodeID
+			if where == "" {
+				where = string(e.Type)
+			}
+			f.permanentFailures = append(f.permanentFailures, where)
+		}
+	}
+	return f
+}
+
+// EvaluateTrace 按事件流评估轨迹断言；status 为 Job 当前状态，成本按 costs 估算（与回归集相同）
+func EvaluateTrace(jobID, status string, events []jobstore.JobEvent, list []TraceAssertion, costs planner.CostModel) *TraceReport {
+	o := OutcomeFromEvents(jobID, status, events, costs)
+	facts := traceFactsOf(events)
+	rep := &TraceReport{JobID: jobID, Status: status, Total: len(list), Results: make([]TraceAssertionResult, 0, len(list)), Outcome: o}
+	for i, a := range list {
+		r := TraceAssertionResult{Index: i, Assertion: a, Passed: true}
+		fail := func(actual interface{}, format string, args ...interface{}) {
+			r.Passed, r.Actual, r.Message = false, actual, fmt.Sprintf(format, args...)
+		}
+		switch a.Type {
+		case AssertStatus:
+			if o.Status != a.Status {
+				fail(o.Status, "status %s, want %s", o.Status, a.Status)
+			}
+		case AssertStepCompleted:
+			if !facts.completedSteps[a.Step] {
+				fail(false, "step %s did not complete", a.Step)
+			}
+		case AssertToolCalled:
+			n := o.ToolCalls[a.Tool]
+			switch {
+			case a.Min == nil && a.Max == nil && n == 0:
+				fail(n, "tool %s was not called", a.Tool)
+			case a.Min != nil && n < *a.Min:
+				fail(n, "tool %s was called %d times, want at least %d", a.Tool, n, *a.Min)
+			case a.Max != nil && n > *a.Max:
+				fail(n, "tool %s was called %d times, want at most %d", a.Tool, n, *a.Max)
+			}
+		case AssertNoPermanentFailures:
+			if len(facts.permanentFailures) > 0 {
+				fail(facts.permanentFailures, "permanent failures: %s", strings.Join(facts.permanentFailures, ", "))
+			}
+		case AssertMaxCost:
+			if o.Cost > a.Value {
+				fail(o.Cost, "cost %.4f > max %.4f", o.Cost, a.Value)
+			}
+		case AssertMaxDuration:
+			d, _ := time.ParseDuration(a.Duration)
+			if actual := time.Duration(o.DurationMs) * time.Millisecond; actual > d {
+				fail(o.DurationMs, "duration %s > max %s", actual, d)
+			}
+		case AssertAnswerContains:
+			if !strings.Contains(strings.ToLower(o.Answer), strings.ToLower(a.Text)) {
+				fail(nil, "answer does not contain %q", a.Text)
+			}
+		}
+		if !r.Passed {
+			rep.Failed++
+		}
+		rep.Results = append(rep.Results, r)
+	}
+	rep.Passed = rep.Failed == 0
+	return rep
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evalsuite
+
+import (
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestValidateTraceAssertions(t *testing.T) {
+	one, three := 1, 3
+	ok := []TraceAssertion{
+		{Type: AssertStatus, Status: "completed"},
+		{Type: AssertStepCompleted, Step: "n1"},
+		{Type: AssertToolCalled, Tool: "order.get", Min: &one, Max: &three},
+		{Type: AssertNoPermanentFailures},
+		{Type: AssertMaxCost, Value: 0.5},
+		{Type: AssertMaxDuration, Duration: "2m"},
+		{Type: AssertAnswerContains, Text: "refunded"},
+	}
+	if err := ValidateTraceAssertions(ok); err != nil {
+		t.Fatal(err)
+	}
+	bad := [][]TraceAssertion{
+		nil,
+		{{Type: "step_started"}},
+		{{Type: AssertStatus, Status: "running"}},
+		{{Type: AssertStepCompleted}},
+		{{Type: AssertToolCalled, Tool: "x", Min: &three, Max: &one}},
+		{{Type: AssertMaxDuration, Duration: "soon"}},
+		{{Type: AssertAnswerContains}},
+	}
+	for i, list := range bad {
+		if ValidateTraceAssertions(list) == nil {
+			t.Errorf("assertions %d should be invalid", i)
+		}
+	}
+}
+
+func TestEvaluateTrace(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	events := []jobstore.JobEvent{
+		evalEvent(t, jobstore.JobCreated, t0, map[string]interface{}{}),
+		evalEvent(t, jobstore.ToolInvocationStarted, t0, map[string]interface{}{"tool_name": "order.get"}),
+		evalEvent(t, jobstore.ToolInvocationStarted, t0, map[string]interface{}{"tool_name": "order.get"}),
+		evalEvent(t, jobstore.NodeFinished, t0, map[string]interface{}{"node_id": "n1", "result_type": "success", "payload_results": map[string]interface{}{"n1": "Order 1 refunded"}}),
+		evalEvent(t, jobstore.NodeFinished, t0, map[string]interface{}{"node_id": "n2", "result_type": "permanent_failure"}),
+		evalEvent(t, jobstore.JobFailed, t0.Add(90*time.Second), map[string]interface{}{"error": "boom"}),
+	}
+	one := 1
+	list := []TraceAssertion{
+		{Type: AssertStepCompleted, Step: "n1"},
+		{Type: AssertStepCompleted, Step: "n2"},
+		{Type: AssertToolCalled, Tool: "order.get", Max: &one},
+		{Type: AssertToolCalled, Tool: "order.get"},
+		{Type: AssertToolCalled, Tool: "refund.create"},
+		{Type: AssertNoPermanentFailures},
+		{Type: AssertMaxCost, Value: 0.1},
+		{Type: AssertMaxDuration, Duration: "1m"},
+		{Type: AssertAnswerContains, Text: "REFUNDED"},
+		{Type: AssertStatus, Status: "completed"},
+	}
+	costs := planner.CostModel{Tools: map[string]planner.ToolCostHint{"order.get": {CostPerCall: 0.02}}}
+	rep := EvaluateTrace("job-1", "failed", events, list, costs)
+	want := []bool{true, false, false, true, false, false, true, false, true, false}
+	for i, r := range rep.Results {
+		if r.Passed != want[i] {
+			t.Errorf("assertion %d (%s): passed = %v, want %v (%s)", i, r.Assertion.Type, r.Passed, want[i], r.Message)
+		}
+	}
+	if rep.Passed || rep.Total != 10 || rep.Failed != 6 {
+		t.Fatalf("report = passed %v total %d failed %d", rep.Passed, rep.Total, rep.Failed)
+	}
+	if got := rep.Results[2].Actual; got != 2 {
+		t.Fatalf("tool call actual = %v", got)
+	}
+	if got := rep.Results[5].Message; got != "permanent failures: n2" {
+		t.Fatalf("permanent failure message = %q", got)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extworker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxMessageBytes 单条消息上限（含 output），超出视为协议错误
+const maxMessageBytes = 16 << 20
+
+// ErrConnClosed 连接已关闭（外部进程退出或宿主关闭）
+var ErrConnClosed = errors.New("extworker: connection closed")
+
+// Conn 基于行分隔 JSON 的 JSON-RPC 2.0 客户端连接；支持并发 Call，按 id 匹配响应
+type Conn struct {
+	w       io.Writer
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *Message
+	closed  bool
+	err     error
+	done    chan struct{}
+
+	onNotify func(method string, params json.RawMessage)
+}
+
+// NewConn 创建连接并启动读循环；r 读到 EOF 或出错时连接关闭，所有待决 Call 返回 ErrConnClosed
+func NewConn(r io.Reader, w io.Writer, onNotify func(method string, params json.RawMessage)) *Conn {
+	c := &Conn{w: w, pending: make(map[int64]chan *Message), done: make(chan struct{}), onNotify: onNotify}
+	go c.readLoop(r)
+	return c
+}
+
+func (c *Conn) readLoop(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue // 非协议输出（如误打印到 stdout 的日志）忽略
+		}
+		if msg.ID == nil {
+			if msg.Method != "" && c.onNotify != nil {
+				c.onNotify(msg.Method, msg.Params)
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- &msg
+		}
+	}
+	err := sc.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	c.shutdown(err)
+}
+
+func (c *Conn) shutdown(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.err = err
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	close(c.done)
+}
+
+// Done 连接关闭时关闭
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err 连接关闭原因；未关闭时为 nil
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Conn) write(msg *Message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.w.Write(b)
+	return err
+}
+
+// Notify 发送通知（无响应）
+func (c *Conn) Notify(method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&Message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// Call 发送请求并等待响应，结果解码到 result；step.execute 在 ctx 取消时发送 step.cancel 通知并返回 ctx.Err()
+func (c *Conn) Call(ctx context.Context, method string, params any, result any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("extworker: encode params: %w", err)
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrConnClosed
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *Message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(&Message{JSONRPC: "2.0", ID: &id, Method: method, Params: raw}); err != nil {
+		c.forget(id)
+		return fmt.Errorf("%w: %v", ErrConnClosed, err)
+	}
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return ErrConnClosed
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("extworker: decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.forget(id)
+		if method == MethodExecute {
+			_ = c.Notify(MethodCancel, CancelParams{ID: id})
+		}
+		return ctx.Err()
+	}
+}
+
+func (c *Conn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
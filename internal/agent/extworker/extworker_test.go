@@ -0,0 +1,179 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extworker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/agent/tools"
+)
+
+// TestHelperProcess 作为外部 Worker 子进程运行（仅 EXTWORKER_HELPER=1 时），实现协议的最小 Worker
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("EXTWORKER_HELPER") != "1" {
+		return
+	}
+	runFakeWorker()
+	os.Exit(0)
+}
+
+func runFakeWorker() {
+	sc := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	reply := func(id *int64, result any) {
+		raw, _ := json.Marshal(result)
+		_ = out.Encode(&Message{JSONRPC: "2.0", ID: id, Result: raw})
+	}
+	for sc.Scan() {
+		var msg Message
+		if json.Unmarshal(sc.Bytes(), &msg) != nil {
+			continue
+		}
+		switch msg.Method {
+		case MethodInitialize:
+			reply(msg.ID, InitializeResult{ProtocolVersion: ProtocolVersion, Name: "fake", Version: "0.1", Tools: []ToolDescriptor{
+				{Name: "py.echo", Description: "echo", Capability: "py.echo", ExpectedLatencyMs: 50},
+				{Name: "py.flaky"}, {Name: "py.badkey"}, {Name: "py.slow"}, {Name: "py.crash"},
+			}})
+		case MethodExecute:
+			var p ExecuteParams
+			_ = json.Unmarshal(msg.Params, &p)
+			key := p.Context.IdempotencyKey
+			switch p.Tool {
+			case "py.echo":
+				reply(msg.ID, ExecuteResult{IdempotencyKey: key, Done: true, Output: fmt.Sprintf("%v|%s|%s", p.Input["text"], p.Context.JobID, key)})
+			case "py.flaky":
+				reply(msg.ID, ExecuteResult{IdempotencyKey: key, Error: &StepError{Type: StepErrorRetryable, Message: "upstream 503"}})
+			case "py.badkey":
+				reply(msg.ID, ExecuteResult{IdempotencyKey: "other", Done: true})
+			case "py.slow":
+				// 不回复，等待宿主取消
+			case "py.crash":
+				os.Exit(3)
+			default:
+				_ = out.Encode(&Message{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: CodeMethodNotFound, Message: "unknown tool"}})
+			}
+		case MethodShutdown:
+			return
+		}
+	}
+}
+
+func startFakeHost(t *testing.T) (*Host, *tools.Registry) {
+	t.Helper()
+	h := &Host{}
+	h.workers = append(h.workers, NewWorker(Config{
+		Name:    "fake",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestHelperProcess"},
+		Env:     []string{"EXTWORKER_HELPER=1"},
+	}, "test-host", nil))
+	reg := tools.NewRegistry()
+	if n := h.Start(context.Background(), reg); n != 5 {
+		t.Fatalf("registered %d tools, want 5", n)
+	}
+	t.Cleanup(h.Close)
+	return h, reg
+}
+
+func stepCtx() context.Context {
+	ctx := executor.WithJobID(context.Background(), "job-1")
+	ctx = executor.WithExecutionStepID(ctx, "step-1")
+	return executor.WithToolExecutionKey(ctx, "idem-1")
+}
+
+func TestHost_RegistersToolsAndForwardsContext(t *testing.T) {
+	_, reg := startFakeHost(t)
+	tool, ok := reg.Get("py.echo")
+	if !ok {
+		t.Fatal("py.echo not registered")
+	}
+	if got := reg.GetCapability("py.echo"); got != "py.echo" {
+		t.Errorf("capability = %q", got)
+	}
+	if hint, ok := reg.CostHint("py.echo"); !ok || hint.ExpectedLatency != 50*time.Millisecond {
+		t.Errorf("cost hint = %+v, %v", hint, ok)
+	}
+	out, err := tool.Execute(stepCtx(), nil, map[string]any{"text": "hi"}, nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	res, ok := out.(tools.ToolResult)
+	if !ok || !res.Done || res.Output != "hi|job-1|idem-1" {
+		t.Fatalf("result = %#v", out)
+	}
+}
+
+func TestWorker_StepContract(t *testing.T) {
+	_, reg := startFakeHost(t)
+	cases := []struct {
+		tool string
+		want executor.StepResultType
+	}{
+		{"py.flaky", executor.StepResultRetryableFailure},
+		{"py.badkey", executor.StepResultPermanentFailure},
+	}
+	for _, tc := range cases {
+		tool, _ := reg.Get(tc.tool)
+		_, err := tool.Execute(stepCtx(), nil, map[string]any{}, nil)
+		var sf *executor.StepFailure
+		if !errors.As(err, &sf) || sf.Type != tc.want {
+			t.Errorf("%s: err = %v, want %s", tc.tool, err, tc.want)
+		}
+	}
+	tool, _ := reg.Get("py.badkey")
+	if _, err := tool.Execute(stepCtx(), nil, nil, nil); err == nil || !strings.Contains(err.Error(), "step contract violation") {
+		t.Errorf("badkey err = %v", err)
+	}
+}
+
+func TestWorker_TimeoutIsRetryable(t *testing.T) {
+	_, reg := startFakeHost(t)
+	tool, _ := reg.Get("py.slow")
+	ctx, cancel := context.WithTimeout(stepCtx(), 100*time.Millisecond)
+	defer cancel()
+	_, err := tool.Execute(ctx, nil, nil, nil)
+	var sf *executor.StepFailure
+	if !errors.As(err, &sf) || sf.Type != executor.StepResultRetryableFailure || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want retryable deadline exceeded", err)
+	}
+}
+
+func TestWorker_RestartsAfterCrash(t *testing.T) {
+	_, reg := startFakeHost(t)
+	crash, _ := reg.Get("py.crash")
+	_, err := crash.Execute(stepCtx(), nil, nil, nil)
+	var sf *executor.StepFailure
+	if !errors.As(err, &sf) || sf.Type != executor.StepResultRetryableFailure {
+		t.Fatalf("crash err = %v, want retryable", err)
+	}
+	echo, _ := reg.Get("py.echo")
+	out, err := echo.Execute(stepCtx(), nil, map[string]any{"text": "again"}, nil)
+	if err != nil {
+		t.Fatalf("Execute after crash: %v", err)
+	}
+	if res := out.(tools.ToolResult); res.Output != "again|job-1|idem-1" {
+		t.Errorf("output = %q", res.Output)
+	}
+}
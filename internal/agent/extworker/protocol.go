@@ -0,0 +1,144 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extworker 外部语言 Worker 协议（JSON-RPC 2.0 over stdio）：Go Worker 作为宿主拉起外部进程，
+// 将工具/Agent 步执行连同完整上下文与幂等键转发给外部实现，并在返回时校验 Step Contract（design/external-worker-protocol.md）。
+package extworker
+
+import (
+	"encoding/json"
+)
+
+// ProtocolVersion 宿主与外部 Worker 握手时协商的协议版本
+const ProtocolVersion = "aetheris.worker/1"
+
+// JSON-RPC 方法
+const (
+	// MethodInitialize 宿主 → Worker：握手，Worker 返回名称、版本与提供的工具清单
+	MethodInitialize = "initialize"
+	// MethodExecute 宿主 → Worker：执行一步（工具或 Agent 调用）
+	MethodExecute = "step.execute"
+	// MethodCancel 宿主 → Worker（通知）：取消进行中的 step.execute（超时或 Job 取消）
+	MethodCancel = "step.cancel"
+	// MethodShutdown 宿主 → Worker（通知）：优雅退出
+	MethodShutdown = "shutdown"
+)
+
+// JSON-RPC 错误码（-32768..-32000 为协议保留）
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Step 错误类型，与 executor StepResultType 的失败语义对应
+const (
+	StepErrorRetryable     = "retryable"
+	StepErrorPermanent     = "permanent"
+	StepErrorCompensatable = "compensatable"
+)
+
+// Message 单条 JSON-RPC 2.0 消息（请求、通知或响应）；每条消息占一行（newline-delimited JSON）
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"` // 通知无 id
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError JSON-RPC 错误对象
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// InitializeParams initialize 请求参数
+type InitializeParams struct {
+	ProtocolVersion string `json:"protocol_version"`
+	Host            string `json:"host"` // 宿主标识（worker_id）
+}
+
+// InitializeResult initialize 响应：Worker 自述与工具清单
+type InitializeResult struct {
+	ProtocolVersion string           `json:"protocol_version"`
+	Name            string           `json:"name"`
+	Version         string           `json:"version,omitempty"`
+	Tools           []ToolDescriptor `json:"tools"`
+}
+
+// ToolDescriptor 外部 Worker 提供的工具（或以工具形式暴露的 Agent）声明
+type ToolDescriptor struct {
+	Name              string         `json:"name"`
+	Description       string         `json:"description,omitempty"`
+	Schema            map[string]any `json:"schema,omitempty"`
+	Capability        string         `json:"capability,omitempty"`
+	ExpectedLatencyMs int64          `json:"expected_latency_ms,omitempty"`
+	CostPerCall       float64        `json:"cost_per_call,omitempty"`
+	// Semaphores 调用前需获取的集群级命名信号量（如 [{"name":"erp","limit":2}]）
+	Semaphores []SemaphoreDescriptor `json:"semaphores,omitempty"`
+}
+
+// SemaphoreDescriptor 工具声明的命名信号量；Limit 为全集群并发上限
+type SemaphoreDescriptor struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// StepContext 随 step.execute 下发的执行上下文；IdempotencyKey 为 Runtime 保证最多一次真实执行的键，Worker 应传给下游作幂等
+type StepContext struct {
+	JobID          string `json:"job_id,omitempty"`
+	StepID         string `json:"step_id,omitempty"`
+	TenantID       string `json:"tenant_id,omitempty"`
+	AgentID        string `json:"agent_id,omitempty"`
+	SessionID      string `json:"session_id,omitempty"`
+	IdempotencyKey string `json:"idempotency_key"`
+	DeadlineUnixMs int64  `json:"deadline_unix_ms,omitempty"` // 0 表示无截止
+}
+
+// ExecuteParams step.execute 请求参数
+type ExecuteParams struct {
+	Tool    string          `json:"tool"`
+	Input   map[string]any  `json:"input"`
+	State   json.RawMessage `json:"state,omitempty"` // 上次返回 done=false 时的 state，再入时原样带回
+	Context StepContext     `json:"context"`
+}
+
+// ExecuteResult step.execute 响应；IdempotencyKey 必须回显请求中的值（Step Contract）
+type ExecuteResult struct {
+	IdempotencyKey string          `json:"idempotency_key"`
+	Done           bool            `json:"done"`
+	Output         string          `json:"output,omitempty"`
+	State          json.RawMessage `json:"state,omitempty"`
+	Error          *StepError      `json:"error,omitempty"`
+}
+
+// StepError Worker 报告的步失败；Type 决定 Runtime 的失败语义（重试/永久/补偿）
+type StepError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// CancelParams step.cancel 通知参数
+type CancelParams struct {
+	ID int64 `json:"id"` // 被取消请求的 JSON-RPC id
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extworker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/agent/tools"
+	"rag-platform/internal/runtime/session"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/log"
+)
+
+// RemoteTool 将外部 Worker 声明的工具适配为 tools.Tool；Execute 从 ctx 取 Job/Step/幂等键组装 StepContext 后转发
+type RemoteTool struct {
+	worker *Worker
+	desc   ToolDescriptor
+}
+
+// NewRemoteTool 创建外部工具适配
+func NewRemoteTool(w *Worker, desc ToolDescriptor) *RemoteTool {
+	return &RemoteTool{worker: w, desc: desc}
+}
+
+func (t *RemoteTool) Name() string           { return t.desc.Name }
+func (t *RemoteTool) Description() string    { return t.desc.Description }
+func (t *RemoteTool) Schema() map[string]any { return t.desc.Schema }
+
+// RequiredCapability 实现 tools.ToolWithCapability
+func (t *RemoteTool) RequiredCapability() string { return t.desc.Capability }
+
+// ExpectedLatency 实现 tools.ToolWithCostHint
+func (t *RemoteTool) ExpectedLatency() time.Duration {
+	return time.Duration(t.desc.ExpectedLatencyMs) * time.Millisecond
+}
+
+// CostPerCall 实现 tools.ToolWithCostHint
+func (t *RemoteTool) CostPerCall() float64 { return t.desc.CostPerCall }
+
+// Semaphores 实现 tools.ToolWithSemaphores
+func (t *RemoteTool) Semaphores() []tools.SemaphoreRef {
+	out := make([]tools.SemaphoreRef, 0, len(t.desc.Semaphores))
+	for _, s := range t.desc.Semaphores {
+		out = append(out, tools.SemaphoreRef{Name: s.Name, Limit: s.Limit})
+	}
+	return out
+}
+
+// Execute 实现 tools.Tool；done=false 时返回的 state 在再入时原样带回给 Worker
+func (t *RemoteTool) Execute(ctx context.Context, sess *session.Session, input map[string]any, state interface{}) (any, error) {
+	params := &ExecuteParams{Tool: t.desc.Name, Input: input, Context: StepContextFrom(ctx, sess)}
+	if state != nil {
+		raw, err := json.Marshal(state)
+		if err != nil {
+			return nil, fmt.Errorf("extworker: encode state: %w", err)
+		}
+		params.State = raw
+	}
+	res, err := t.worker.Execute(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	out := tools.ToolResult{Done: res.Done, Output: res.Output}
+	if len(res.State) > 0 {
+		out.State = res.State
+	}
+	return out, nil
+}
+
+// StepContextFrom 从 Runner 注入的 ctx 组装 StepContext；幂等键取 executor.ExecutionKeyFromContext，无则退化为 job+step
+func StepContextFrom(ctx context.Context, sess *session.Session) StepContext {
+	sc := StepContext{
+		JobID:          executor.JobIDFromContext(ctx),
+		StepID:         executor.ExecutionStepIDFromContext(ctx),
+		TenantID:       executor.TenantIDFromContext(ctx),
+		IdempotencyKey: executor.ExecutionKeyFromContext(ctx),
+	}
+	if agent := executor.AgentFromContext(ctx); agent != nil {
+		sc.AgentID = agent.ID
+	}
+	if sess != nil {
+		sc.SessionID = sess.ID
+	}
+	if sc.IdempotencyKey == "" && sc.JobID != "" {
+		sc.IdempotencyKey = sc.JobID + ":" + sc.StepID
+	}
+	return sc
+}
+
+// Host 管理一组外部 Worker，并将其工具注册到 tools.Registry
+type Host struct {
+	workers []*Worker
+	logger  *log.Logger
+}
+
+// NewHostFromConfig 按 agent.external_workers 配置创建 Host（未启动）
+func NewHostFromConfig(cfgs []config.ExternalWorkerConfig, hostID string, logger *log.Logger) *Host {
+	h := &Host{logger: logger}
+	for _, c := range cfgs {
+		env := make([]string, 0, len(c.Env))
+		for k, v := range c.Env {
+			env = append(env, k+"="+v)
+		}
+		h.workers = append(h.workers, NewWorker(Config{
+			Name:           c.Name,
+			Command:        c.Command,
+			Args:           c.Args,
+			Env:            env,
+			Dir:            c.Dir,
+			StartTimeout:   parseDuration(c.StartTimeout),
+			CallTimeout:    parseDuration(c.CallTimeout),
+			MaxOutputBytes: c.MaxOutputBytes,
+		}, hostID, logger))
+	}
+	return h
+}
+
+// Start 启动全部 Worker 并注册其工具；单个 Worker 启动失败仅记录日志，同名工具已注册时跳过。返回注册的工具数
+func (h *Host) Start(ctx context.Context, reg *tools.Registry) int {
+	registered := 0
+	for _, w := range h.workers {
+		if err := w.Start(ctx); err != nil {
+			if h.logger != nil {
+				h.logger.Warn("外部 Worker 启动失败，其工具不可用", "worker", w.Name(), "error", err)
+			}
+			continue
+		}
+		info := w.Info()
+		for _, d := range info.Tools {
+			if d.Name == "" {
+				continue
+			}
+			if reg != nil {
+				if _, exists := reg.Get(d.Name); exists {
+					if h.logger != nil {
+						h.logger.Warn("外部 Worker 工具与已注册工具同名，已跳过", "worker", w.Name(), "tool", d.Name)
+					}
+					continue
+				}
+				reg.Register(NewRemoteTool(w, d))
+			}
+			registered++
+		}
+		if h.logger != nil {
+			h.logger.Info("外部 Worker 已就绪", "worker", w.Name(), "version", info.Version, "tools", len(info.Tools))
+		}
+	}
+	return registered
+}
+
+// Workers 返回受管 Worker
+func (h *Host) Workers() []*Worker {
+	return h.workers
+}
+
+// Close 关闭全部 Worker
+func (h *Host) Close() {
+	if h == nil {
+		return
+	}
+	for _, w := range h.workers {
+		_ = w.Close()
+	}
+}
+
+func parseDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,288 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extworker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/pkg/log"
+)
+
+const (
+	defaultStartTimeout   = 10 * time.Second
+	defaultCallTimeout    = 60 * time.Second
+	defaultMaxOutputBytes = 1 << 20
+	shutdownGrace         = 3 * time.Second
+)
+
+// Config 单个外部 Worker 进程配置
+type Config struct {
+	Name           string        // 逻辑名，用于日志与指标；空则使用 initialize 返回的 name
+	Command        string        // 可执行文件，如 python3
+	Args           []string      // 参数，如 ["-m", "my_tools.worker"]
+	Env            []string      // 追加环境变量 "K=V"
+	Dir            string        // 工作目录
+	StartTimeout   time.Duration // initialize 握手超时，<=0 为 10s
+	CallTimeout    time.Duration // step.execute 超时（ctx 无更早截止时），<=0 为 60s
+	MaxOutputBytes int           // output 上限，超出视为违反 Step Contract，<=0 为 1MiB
+}
+
+// Worker 宿主侧的外部 Worker 句柄：管理进程生命周期与 JSON-RPC 连接；进程意外退出后下一次调用时重启
+type Worker struct {
+	cfg    Config
+	hostID string
+	logger *log.Logger
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *Conn
+	info   *InitializeResult
+	closed bool
+}
+
+// NewWorker 创建外部 Worker 句柄（未启动）；hostID 为宿主标识，握手时下发
+func NewWorker(cfg Config, hostID string, logger *log.Logger) *Worker {
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = defaultStartTimeout
+	}
+	if cfg.CallTimeout <= 0 {
+		cfg.CallTimeout = defaultCallTimeout
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = defaultMaxOutputBytes
+	}
+	return &Worker{cfg: cfg, hostID: hostID, logger: logger}
+}
+
+// Name Worker 逻辑名
+func (w *Worker) Name() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cfg.Name == "" && w.info != nil {
+		return w.info.Name
+	}
+	return w.cfg.Name
+}
+
+// Info 最近一次握手结果；未启动时为 nil
+func (w *Worker) Info() *InitializeResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.info
+}
+
+// Start 拉起进程并完成 initialize 握手
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.ensureRunningLocked(ctx)
+	return err
+}
+
+func (w *Worker) ensureRunningLocked(ctx context.Context) (*Conn, error) {
+	if w.closed {
+		return nil, ErrConnClosed
+	}
+	if w.conn != nil {
+		select {
+		case <-w.conn.Done():
+			w.reapLocked()
+		default:
+			return w.conn, nil
+		}
+	}
+	if w.cfg.Command == "" {
+		return nil, fmt.Errorf("extworker: %s 未配置 command", w.cfg.Name)
+	}
+	cmd := exec.Command(w.cfg.Command, w.cfg.Args...)
+	cmd.Dir = w.cfg.Dir
+	cmd.Env = append(os.Environ(), w.cfg.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("extworker: 启动 %s failed: %w", w.cfg.Command, err)
+	}
+	go w.pipeStderr(stderr)
+	conn := NewConn(stdout, stdin, w.onNotify)
+	w.cmd, w.stdin, w.conn = cmd, stdin, conn
+
+	initCtx, cancel := context.WithTimeout(ctx, w.cfg.StartTimeout)
+	defer cancel()
+	var info InitializeResult
+	if err := conn.Call(initCtx, MethodInitialize, InitializeParams{ProtocolVersion: ProtocolVersion, Host: w.hostID}, &info); err != nil {
+		w.reapLocked()
+		return nil, fmt.Errorf("extworker: %s initialize failed: %w", w.cfg.Command, err)
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		w.reapLocked()
+		return nil, fmt.Errorf("extworker: %s 协议版本 %q 不兼容（宿主 %s）", w.cfg.Command, info.ProtocolVersion, ProtocolVersion)
+	}
+	w.info = &info
+	return conn, nil
+}
+
+// reapLocked 关闭连接并回收进程
+func (w *Worker) reapLocked() {
+	if w.stdin != nil {
+		_ = w.stdin.Close()
+	}
+	if w.cmd != nil && w.cmd.Process != nil {
+		done := make(chan struct{})
+		go func(cmd *exec.Cmd) {
+			_ = cmd.Wait()
+			close(done)
+		}(w.cmd)
+		select {
+		case <-done:
+		case <-time.After(shutdownGrace):
+			_ = w.cmd.Process.Kill()
+			<-done
+		}
+	}
+	w.cmd, w.stdin, w.conn = nil, nil, nil
+}
+
+func (w *Worker) pipeStderr(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if w.logger != nil {
+			w.logger.Info("外部 Worker 输出", "worker", w.cfg.Name, "line", sc.Text())
+		}
+	}
+}
+
+// onNotify 处理 Worker → 宿主的通知；目前仅支持 log
+func (w *Worker) onNotify(method string, params json.RawMessage) {
+	if method != "log" || w.logger == nil {
+		return
+	}
+	var p struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(params, &p) != nil {
+		return
+	}
+	switch p.Level {
+	case "error":
+		w.logger.Error(p.Message, "worker", w.cfg.Name)
+	case "warn":
+		w.logger.Warn(p.Message, "worker", w.cfg.Name)
+	default:
+		w.logger.Info(p.Message, "worker", w.cfg.Name)
+	}
+}
+
+// Execute 转发一步执行并校验 Step Contract；传输失败与超时为 retryable_failure，契约违反为 permanent_failure
+func (w *Worker) Execute(ctx context.Context, params *ExecuteParams) (*ExecuteResult, error) {
+	w.mu.Lock()
+	conn, err := w.ensureRunningLocked(ctx)
+	w.mu.Unlock()
+	if err != nil {
+		return nil, &executor.StepFailure{Type: executor.StepResultRetryableFailure, Inner: err}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.cfg.CallTimeout)
+		defer cancel()
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		params.Context.DeadlineUnixMs = dl.UnixMilli()
+	}
+	var res ExecuteResult
+	if err := conn.Call(ctx, MethodExecute, params, &res); err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			return nil, &executor.StepFailure{Type: executor.StepResultPermanentFailure, Inner: fmt.Errorf("extworker %s: %s", w.Name(), rpcErr.Message)}
+		}
+		return nil, &executor.StepFailure{Type: executor.StepResultRetryableFailure, Inner: fmt.Errorf("extworker %s: %w", w.Name(), err)}
+	}
+	if err := w.checkContract(params, &res); err != nil {
+		return nil, &executor.StepFailure{Type: executor.StepResultPermanentFailure, Inner: err}
+	}
+	if res.Error != nil {
+		return &res, &executor.StepFailure{Type: stepResultTypeOf(res.Error.Type), Inner: fmt.Errorf("extworker %s: %s", w.Name(), res.Error.Message)}
+	}
+	return &res, nil
+}
+
+// checkContract Step Contract 校验（design/external-worker-protocol.md § Step Contract）
+func (w *Worker) checkContract(params *ExecuteParams, res *ExecuteResult) error {
+	if res.IdempotencyKey != params.Context.IdempotencyKey {
+		return fmt.Errorf("extworker %s: step contract violation: idempotency_key 回显 %q 与请求 %q 不一致", w.Name(), res.IdempotencyKey, params.Context.IdempotencyKey)
+	}
+	if len(res.Output) > w.cfg.MaxOutputBytes {
+		return fmt.Errorf("extworker %s: step contract violation: output %d 字节超过上限 %d", w.Name(), len(res.Output), w.cfg.MaxOutputBytes)
+	}
+	if res.Error != nil {
+		switch res.Error.Type {
+		case StepErrorRetryable, StepErrorPermanent, StepErrorCompensatable:
+		default:
+			return fmt.Errorf("extworker %s: step contract violation: 未知错误类型 %q", w.Name(), res.Error.Type)
+		}
+		return nil
+	}
+	if !res.Done && len(res.State) == 0 {
+		return fmt.Errorf("extworker %s: step contract violation: done=false 时必须返回 state", w.Name())
+	}
+	return nil
+}
+
+func stepResultTypeOf(t string) executor.StepResultType {
+	switch t {
+	case StepErrorRetryable:
+		return executor.StepResultRetryableFailure
+	case StepErrorCompensatable:
+		return executor.StepResultCompensatableFailure
+	default:
+		return executor.StepResultPermanentFailure
+	}
+}
+
+// Close 发送 shutdown 通知并回收进程；之后的调用返回 ErrConnClosed
+func (w *Worker) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn != nil {
+		_ = w.conn.Notify(MethodShutdown, struct{}{})
+	}
+	w.reapLocked()
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goaltemplate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 模板不存在
+var ErrNotFound = errors.New("goaltemplate: not found")
+
+// Store 目标模板存储（按 Agent 隔离，Agent 内按 Name 唯一）
+type Store interface {
+	// Put 创建或整体替换模板（按 AgentID+Name）
+	Put(ctx context.Context, t *Template) error
+	// Get 获取模板；不存在返回 ErrNotFound
+	Get(ctx context.Context, agentID, name string) (*Template, error)
+	// List 列出 Agent 的全部模板（按名称排序）
+	List(ctx context.Context, agentID string) ([]*Template, error)
+	// Delete 删除模板；不存在返回 ErrNotFound
+	Delete(ctx context.Context, agentID, name string) error
+}
+
+// StoreMem 内存实现
+type StoreMem struct {
+	mu    sync.RWMutex
+	items map[string]*Template // agentID + "\x00" + name -> template
+}
+
+// NewStoreMem 创建内存目标模板存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{items: make(map[string]*Template)}
+}
+
+func memKey(agentID, name string) string { return agentID + "\x00" + name }
+
+func (s *StoreMem) Put(ctx context.Context, t *Template) error {
+	if t == nil {
+		return errors.New("template is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *t
+	cp.Params = append([]Param(nil), t.Params...)
+	now := time.Now()
+	k := memKey(cp.AgentID, cp.Name)
+	if prev, ok := s.items[k]; ok {
+		cp.CreatedAt = prev.CreatedAt
+	} else {
+		cp.CreatedAt = now
+	}
+	cp.UpdatedAt = now
+	s.items[k] = &cp
+	return nil
+}
+
+func (s *StoreMem) Get(ctx context.Context, agentID, name string) (*Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.items[memKey(agentID, name)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (s *StoreMem) List(ctx context.Context, agentID string) ([]*Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Template
+	for _, t := range s.items {
+		if t.AgentID == agentID {
+			cp := *t
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (s *StoreMem) Delete(ctx context.Context, agentID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := memKey(agentID, name)
+	if _, ok := s.items[k]; !ok {
+		return ErrNotFound
+	}
+	delete(s.items, k)
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goaltemplate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 agent_goal_templates 表
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的目标模板存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+const templateColumns = `agent_id, name, COALESCE(description, ''), goal, params, created_at, updated_at`
+
+func (s *StorePg) Put(ctx context.Context, t *Template) error {
+	if t == nil {
+		return errors.New("template is nil")
+	}
+	params := t.Params
+	if params == nil {
+		params = []Param{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	var desc interface{}
+	if t.Description != "" {
+		desc = t.Description
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO agent_goal_templates (agent_id, name, description, goal, params) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (agent_id, name) DO UPDATE SET description = EXCLUDED.description, goal = EXCLUDED.goal, params = EXCLUDED.params, updated_at = now()`,
+		t.AgentID, t.Name, desc, t.Goal, paramsJSON)
+	return err
+}
+
+func (s *StorePg) Get(ctx context.Context, agentID, name string) (*Template, error) {
+	row := s.pool.QueryRow(ctx,
+		`SELECT `+templateColumns+` FROM agent_goal_templates WHERE agent_id = $1 AND name = $2`, agentID, name)
+	t, err := scanTemplate(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+func (s *StorePg) List(ctx context.Context, agentID string) ([]*Template, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+templateColumns+` FROM agent_goal_templates WHERE agent_id = $1 ORDER BY name`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (s *StorePg) Delete(ctx context.Context, agentID, name string) error {
+	cmd, err := s.pool.Exec(ctx, `DELETE FROM agent_goal_templates WHERE agent_id = $1 AND name = $2`, agentID, name)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanTemplate(row pgx.Row) (*Template, error) {
+	var t Template
+	var paramsJSON []byte
+	if err := row.Scan(&t.AgentID, &t.Name, &t.Description, &t.Goal, &paramsJSON, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(paramsJSON, &t.Params); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
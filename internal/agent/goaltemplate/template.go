@@ -0,0 +1,298 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package goaltemplate Agent 目标模板：为 Agent 定义具名目标模板及参数 schema，chat/API 先收集并校验参数，
+// 再将参数渲染进目标文本提交 Job，使自由文本 Agent 成为可靠的参数化工作流。
+package goaltemplate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 参数类型
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeEnum    = "enum"
+)
+
+var (
+	namePattern        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,63}$`)
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// Param 模板参数定义；Goal 中以 {{name}} 引用
+type Param struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label,omitempty"` // 表单提示文本，空时使用 Name
+	Type        string   `json:"type"`            // string（默认）| number | integer | boolean | enum
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required"`
+	Default     any      `json:"default,omitempty"`
+	Options     []string `json:"options,omitempty"`    // enum 可选值
+	Pattern     string   `json:"pattern,omitempty"`    // string 正则约束
+	MaxLength   int      `json:"max_length,omitempty"` // string 最大长度（字符数），0 不限
+	Min         *float64 `json:"min,omitempty"`        // number/integer 下限
+	Max         *float64 `json:"max,omitempty"`        // number/integer 上限
+}
+
+// Template 具名目标模板
+type Template struct {
+	AgentID     string    `json:"agent_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Goal        string    `json:"goal"` // 目标文本，{{param}} 占位
+	Params      []Param   `json:"params"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// FieldError 单个参数的校验错误
+type FieldError struct {
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+// ValidationError 参数校验失败；Fields 按模板参数顺序排列，供表单逐项提示
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Param+": "+f.Message)
+	}
+	return "goaltemplate: invalid params: " + strings.Join(parts, "; ")
+}
+
+// DisplayLabel 返回表单展示用的参数名
+func (p *Param) DisplayLabel() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Name
+}
+
+// Validate 校验模板定义：名称、参数定义合法，Goal 中的占位符均已声明
+func (t *Template) Validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf("goaltemplate: invalid name %q", t.Name)
+	}
+	if strings.TrimSpace(t.Goal) == "" {
+		return fmt.Errorf("goaltemplate: goal is required")
+	}
+	declared := make(map[string]bool, len(t.Params))
+	for i := range t.Params {
+		p := &t.Params[i]
+		if !placeholderPattern.MatchString("{{" + p.Name + "}}") {
+			return fmt.Errorf("goaltemplate: invalid param name %q", p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("goaltemplate: duplicate param %q", p.Name)
+		}
+		declared[p.Name] = true
+		switch p.Type {
+		case "":
+			p.Type = TypeString
+		case TypeString, TypeNumber, TypeInteger, TypeBoolean:
+		case TypeEnum:
+			if len(p.Options) == 0 {
+				return fmt.Errorf("goaltemplate: enum param %q requires options", p.Name)
+			}
+		default:
+			return fmt.Errorf("goaltemplate: param %q has unknown type %q", p.Name, p.Type)
+		}
+		if p.Pattern != "" {
+			if _, err := regexp.Compile(p.Pattern); err != nil {
+				return fmt.Errorf("goaltemplate: param %q pattern: %w", p.Name, err)
+			}
+		}
+		if p.Default != nil {
+			if _, msg := coerce(p, p.Default); msg != "" {
+				return fmt.Errorf("goaltemplate: param %q default: %s", p.Name, msg)
+			}
+		}
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(t.Goal, -1) {
+		if !declared[m[1]] {
+			return fmt.Errorf("goaltemplate: goal references undeclared param %q", m[1])
+		}
+	}
+	return nil
+}
+
+// Resolve 按参数 schema 校验并规范化 values（字符串输入按类型解析，缺省值回填）；未声明的参数视为错误
+func (t *Template) Resolve(values map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(t.Params))
+	var fields []FieldError
+	declared := make(map[string]bool, len(t.Params))
+	for i := range t.Params {
+		p := &t.Params[i]
+		declared[p.Name] = true
+		v, ok := values[p.Name]
+		if s, isStr := v.(string); ok && isStr && strings.TrimSpace(s) == "" {
+			ok = false
+		}
+		if !ok || v == nil {
+			if p.Default != nil {
+				v = p.Default
+			} else if p.Required {
+				fields = append(fields, FieldError{Param: p.Name, Message: "required"})
+				continue
+			} else {
+				continue
+			}
+		}
+		nv, msg := coerce(p, v)
+		if msg != "" {
+			fields = append(fields, FieldError{Param: p.Name, Message: msg})
+			continue
+		}
+		out[p.Name] = nv
+	}
+	var unknown []string
+	for k := range values {
+		if !declared[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			fields = append(fields, FieldError{Param: k, Message: "unknown param"})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+	return out, nil
+}
+
+// Render 校验参数并渲染目标文本；未提供的可选参数渲染为空串
+func (t *Template) Render(values map[string]any) (string, map[string]any, error) {
+	resolved, err := t.Resolve(values)
+	if err != nil {
+		return "", nil, err
+	}
+	goal := placeholderPattern.ReplaceAllStringFunc(t.Goal, func(m string) string {
+		name := placeholderPattern.FindStringSubmatch(m)[1]
+		v, ok := resolved[name]
+		if !ok {
+			return ""
+		}
+		return formatValue(v)
+	})
+	return strings.TrimSpace(goal), resolved, nil
+}
+
+// coerce 将输入值转换为参数类型；返回非空 msg 表示不合法
+func coerce(p *Param, v any) (any, string) {
+	switch p.Type {
+	case TypeNumber, TypeInteger:
+		var f float64
+		switch x := v.(type) {
+		case float64:
+			f = x
+		case int:
+			f = float64(x)
+		case int64:
+			f = float64(x)
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return nil, "must be a " + p.Type
+			}
+			f = parsed
+		default:
+			return nil, "must be a " + p.Type
+		}
+		if p.Type == TypeInteger && f != float64(int64(f)) {
+			return nil, "must be an integer"
+		}
+		if p.Min != nil && f < *p.Min {
+			return nil, "must be >= " + formatValue(*p.Min)
+		}
+		if p.Max != nil && f > *p.Max {
+			return nil, "must be <= " + formatValue(*p.Max)
+		}
+		if p.Type == TypeInteger {
+			return int64(f), ""
+		}
+		return f, ""
+	case TypeBoolean:
+		switch x := v.(type) {
+		case bool:
+			return x, ""
+		case string:
+			switch strings.ToLower(strings.TrimSpace(x)) {
+			case "true", "yes", "y", "1", "是":
+				return true, ""
+			case "false", "no", "n", "0", "否":
+				return false, ""
+			}
+		}
+		return nil, "must be a boolean"
+	case TypeEnum:
+		s, ok := v.(string)
+		if !ok {
+			return nil, "must be one of " + strings.Join(p.Options, ", ")
+		}
+		s = strings.TrimSpace(s)
+		for _, o := range p.Options {
+			if o == s {
+				return s, ""
+			}
+		}
+		return nil, "must be one of " + strings.Join(p.Options, ", ")
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return nil, "must be a string"
+		}
+		s = strings.TrimSpace(s)
+		if p.MaxLength > 0 && len([]rune(s)) > p.MaxLength {
+			return nil, "must be at most " + strconv.Itoa(p.MaxLength) + " characters"
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile(p.Pattern)
+			if err != nil || !re.MatchString(s) {
+				return nil, "must match " + p.Pattern
+			}
+		}
+		return s, ""
+	}
+}
+
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goaltemplate
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func refundTemplate() *Template {
+	maxAmount := 5000.0
+	return &Template{
+		AgentID: "a1",
+		Name:    "refund",
+		Goal:    "为订单 {{order_id}} 办理 {{ amount }} 元退款，原因：{{reason}}。通知客户：{{notify}}",
+		Params: []Param{
+			{Name: "order_id", Required: true, Pattern: `^ORD-\d+$`},
+			{Name: "amount", Type: TypeNumber, Required: true, Max: &maxAmount},
+			{Name: "reason", Type: TypeEnum, Options: []string{"damaged", "late", "other"}, Default: "other"},
+			{Name: "notify", Type: TypeBoolean, Default: true},
+		},
+	}
+}
+
+func TestTemplate_Validate(t *testing.T) {
+	if err := refundTemplate().Validate(); err != nil {
+		t.Fatalf("valid template: %v", err)
+	}
+	bad := []*Template{
+		{Name: "x y", Goal: "g"},
+		{Name: "t", Goal: "  "},
+		{Name: "t", Goal: "hi {{who}}"},
+		{Name: "t", Goal: "g", Params: []Param{{Name: "a"}, {Name: "a"}}},
+		{Name: "t", Goal: "g", Params: []Param{{Name: "a", Type: "date"}}},
+		{Name: "t", Goal: "g", Params: []Param{{Name: "a", Type: TypeEnum}}},
+		{Name: "t", Goal: "g", Params: []Param{{Name: "a", Pattern: "("}}},
+		{Name: "t", Goal: "g", Params: []Param{{Name: "a", Type: TypeInteger, Default: "x"}}},
+	}
+	for i, tpl := range bad {
+		if err := tpl.Validate(); err == nil {
+			t.Errorf("case %d: expected validation error", i)
+		}
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	tpl := refundTemplate()
+	_ = tpl.Validate()
+	goal, params, err := tpl.Render(map[string]any{"order_id": "ORD-42", "amount": "199.5", "notify": "no"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if goal != "为订单 ORD-42 办理 199.5 元退款，原因：other。通知客户：false" {
+		t.Fatalf("goal = %q", goal)
+	}
+	if params["amount"] != 199.5 || params["notify"] != false || params["reason"] != "other" {
+		t.Fatalf("params = %v", params)
+	}
+}
+
+func TestTemplate_RenderValidationErrors(t *testing.T) {
+	tpl := refundTemplate()
+	_ = tpl.Validate()
+	_, _, err := tpl.Render(map[string]any{"order_id": "42", "amount": 9000.0, "reason": "bored", "extra": 1})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range verr.Fields {
+		got[f.Param] = f.Message
+	}
+	if len(got) != 4 || got["order_id"] == "" || got["amount"] == "" || got["reason"] == "" || got["extra"] != "unknown param" {
+		t.Fatalf("fields = %+v", verr.Fields)
+	}
+	_, _, err = tpl.Render(map[string]any{"amount": "  "})
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 || verr.Fields[0].Message != "required" {
+		t.Fatalf("missing required: %v", err)
+	}
+}
+
+func TestStoreMem(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	tpl := refundTemplate()
+	if err := s.Put(ctx, tpl); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ctx, &Template{AgentID: "a1", Name: "a-first", Goal: "g"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	list, _ := s.List(ctx, "a1")
+	if len(list) != 2 || list[0].Name != "a-first" {
+		t.Fatalf("List = %+v", list)
+	}
+	if _, err := s.Get(ctx, "a2", "refund"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get other agent: %v", err)
+	}
+	if err := s.Delete(ctx, "a1", "refund"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "a1", "refund"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete twice: %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inbound 外部系统回调（Stripe、GitHub 等自带签名的 Webhook）入站适配：
+// 按通道配置验签（HMAC / JWT / 静态 token）与字段映射，翻译为 Job signal 或 message，无需自建桥接服务。
+package inbound
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Action 回调翻译成的 Job 操作
+type Action string
+
+const (
+	// ActionSignal 等价于 POST /api/jobs/:id/signal；correlation_key 须与当前 job_waiting 一致
+	ActionSignal Action = "signal"
+	// ActionMessage 等价于 POST /api/jobs/:id/message；wait_type=message 且 channel/correlation_key 匹配时解除等待
+	ActionMessage Action = "message"
+)
+
+// 验签方式
+const (
+	VerifyHMAC  = "hmac"
+	VerifyJWT   = "jwt"
+	VerifyToken = "token"
+)
+
+var (
+	// ErrUnknownChannel 通道未配置
+	ErrUnknownChannel = errors.New("inbound: unknown channel")
+	// ErrVerification 签名/令牌校验失败
+	ErrVerification = errors.New("inbound: verification failed")
+	// ErrMapping 映射后缺少必需字段（如 job_id）
+	ErrMapping = errors.New("inbound: mapping failed")
+)
+
+// Verification 通道验签配置；Secret 支持 "${ENV}" 从环境变量读取
+type Verification struct {
+	Type      string // hmac | jwt | token
+	Header    string // 携带签名/令牌的请求头；空则 hmac 为 X-Signature（stripe 为 Stripe-Signature），jwt/token 为 Authorization
+	Secret    string
+	Algorithm string        // hmac: sha256（默认）| sha1 | sha512；jwt: HS256（默认）| HS384 | HS512
+	Encoding  string        // hmac 签名编码：hex（默认）| base64
+	Prefix    string        // 签名值前缀，如 GitHub 的 "sha256="
+	Scheme    string        // hmac 的签名格式：空为对请求体直接签名；stripe 为 "t=<ts>,v1=<sig>"，签名内容为 "<ts>.<body>"
+	Tolerance time.Duration // stripe 时间戳容差（空为 5m）；jwt exp/nbf 的时钟偏差
+	Issuer    string        // jwt 非空时校验 iss
+	Audience  string        // jwt 非空时校验 aud
+}
+
+// Mapping 字段映射表达式："$.a.b" 取 JSON 请求体字段（"$" 为整个请求体），"header:Name" 取请求头，
+// "query:name" 取查询参数，"claim:name" 取 JWT claim，其他值按字面量使用
+type Mapping struct {
+	JobID          string // 空则 "$.job_id"
+	CorrelationKey string // 空则 "$.correlation_key"
+	Channel        string // 仅 message；空则使用通道名
+	MessageID      string // 仅 message；映射到 message_id 后重复投递按幂等处理
+	Payload        string // 空则整个请求体；非对象值包装为 {"value": ...}
+}
+
+// Channel 一个入站通道：POST /api/webhooks/inbound/:name
+type Channel struct {
+	Name     string
+	TenantID string // 非空时只投递到该租户的 Job
+	Action   Action // 空为 signal
+	Verify   Verification
+	Mapping  Mapping
+}
+
+// Request 入站请求的原始内容；Header/Query 为 nil 时视为空
+type Request struct {
+	Body   []byte
+	Header func(name string) string
+	Query  func(name string) string
+}
+
+// Delivery 验签并映射后的投递内容
+type Delivery struct {
+	Channel        string // 通道名
+	Action         Action
+	TenantID       string
+	JobID          string
+	CorrelationKey string
+	MessageChannel string
+	MessageID      string
+	Payload        map[string]interface{}
+}
+
+// Registry 已校验的通道集合
+type Registry struct {
+	channels map[string]*Channel
+}
+
+// NewRegistry 校验通道配置并解析密钥；配置错误（重名、未知验签方式、缺少密钥等）时返回错误
+func NewRegistry(channels []Channel) (*Registry, error) {
+	r := &Registry{channels: make(map[string]*Channel, len(channels))}
+	for i := range channels {
+		ch := channels[i]
+		if ch.Name == "" {
+			return nil, fmt.Errorf("inbound: channel #%d: name required", i)
+		}
+		if _, dup := r.channels[ch.Name]; dup {
+			return nil, fmt.Errorf("inbound: duplicate channel %q", ch.Name)
+		}
+		if ch.Action == "" {
+			ch.Action = ActionSignal
+		}
+		if ch.Action != ActionSignal && ch.Action != ActionMessage {
+			return nil, fmt.Errorf("inbound: channel %q: unknown action %q", ch.Name, ch.Action)
+		}
+		if err := ch.Verify.normalize(); err != nil {
+			return nil, fmt.Errorf("inbound: channel %q: %w", ch.Name, err)
+		}
+		if ch.Mapping.JobID == "" {
+			ch.Mapping.JobID = "$.job_id"
+		}
+		if ch.Mapping.CorrelationKey == "" {
+			ch.Mapping.CorrelationKey = "$.correlation_key"
+		}
+		if ch.Mapping.Channel == "" {
+			ch.Mapping.Channel = ch.Name
+		}
+		r.channels[ch.Name] = &ch
+	}
+	return r, nil
+}
+
+// Len 已配置的通道数
+func (r *Registry) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.channels)
+}
+
+// Handle 校验 name 通道上的请求并映射为投递内容；错误包装 ErrUnknownChannel / ErrVerification / ErrMapping
+func (r *Registry) Handle(name string, req Request, now time.Time) (*Delivery, error) {
+	if r == nil {
+		return nil, ErrUnknownChannel
+	}
+	ch, ok := r.channels[name]
+	if !ok {
+		return nil, ErrUnknownChannel
+	}
+	if req.Header == nil {
+		req.Header = func(string) string { return "" }
+	}
+	if req.Query == nil {
+		req.Query = func(string) string { return "" }
+	}
+	claims, err := ch.Verify.verify(req, now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	return ch.mapDelivery(req, claims)
+}
+
+// resolveSecret 支持 "${ENV}" 形式从环境变量读取
+func resolveSecret(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
+		s = strings.TrimSpace(os.Getenv(strings.TrimSuffix(strings.TrimPrefix(s, "${"), "}")))
+	}
+	return s
+}
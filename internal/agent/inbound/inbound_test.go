@@ -0,0 +1,173 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func hmacHex(secret, content string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func headers(kv map[string]string) func(string) string {
+	return func(name string) string { return kv[name] }
+}
+
+func signJWT(secret string, claims string) string {
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signing))
+	return signing + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestRegistry_GitHubStyleHMAC(t *testing.T) {
+	reg, err := NewRegistry([]Channel{{
+		Name:    "github",
+		Verify:  Verification{Type: VerifyHMAC, Header: "X-Hub-Signature-256", Prefix: "sha256=", Secret: "s3cret"},
+		Mapping: Mapping{JobID: "$.pull_request.head.ref", CorrelationKey: "header:X-GitHub-Delivery", Payload: "$.pull_request"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"action":"closed","pull_request":{"number":7,"merged":true,"head":{"ref":"job-1"}}}`
+	req := Request{Body: []byte(body), Header: headers(map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hmacHex("s3cret", body),
+		"X-GitHub-Delivery":   "wait-key",
+	})}
+	d, err := reg.Handle("github", req, time.Now())
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if d.Action != ActionSignal || d.JobID != "job-1" || d.CorrelationKey != "wait-key" {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if d.Payload["merged"] != true || fmt.Sprint(d.Payload["number"]) != "7" {
+		t.Fatalf("payload = %v", d.Payload)
+	}
+
+	req.Body = []byte(strings.Replace(body, "true", "false", 1))
+	if _, err := reg.Handle("github", req, time.Now()); !errors.Is(err, ErrVerification) {
+		t.Fatalf("tampered body: err = %v, want ErrVerification", err)
+	}
+	if _, err := reg.Handle("gitlab", req, time.Now()); !errors.Is(err, ErrUnknownChannel) {
+		t.Fatalf("unknown channel: err = %v", err)
+	}
+}
+
+func TestRegistry_StripeScheme(t *testing.T) {
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "whsec_test")
+	reg, err := NewRegistry([]Channel{{
+		Name:     "stripe",
+		TenantID: "acme",
+		Action:   ActionMessage,
+		Verify:   Verification{Type: VerifyHMAC, Scheme: "stripe", Secret: "${STRIPE_WEBHOOK_SECRET}"},
+		Mapping:  Mapping{JobID: "$.data.object.metadata.job_id", MessageID: "$.id", Payload: "$.data.object"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	body := `{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"amount":1200,"metadata":{"job_id":"job-9"}}}}`
+	sign := func(ts time.Time) string {
+		t := fmt.Sprint(ts.Unix())
+		return "t=" + t + ",v1=deadbeef,v1=" + hmacHex("whsec_test", t+"."+body)
+	}
+	d, err := reg.Handle("stripe", Request{Body: []byte(body), Header: headers(map[string]string{"Stripe-Signature": sign(now)})}, now)
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if d.Action != ActionMessage || d.TenantID != "acme" || d.JobID != "job-9" || d.MessageID != "evt_1" || d.MessageChannel != "stripe" {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if fmt.Sprint(d.Payload["amount"]) != "1200" {
+		t.Fatalf("payload = %v", d.Payload)
+	}
+	stale := Request{Body: []byte(body), Header: headers(map[string]string{"Stripe-Signature": sign(now.Add(-10 * time.Minute))})}
+	if _, err := reg.Handle("stripe", stale, now); !errors.Is(err, ErrVerification) {
+		t.Fatalf("stale timestamp: err = %v, want ErrVerification", err)
+	}
+}
+
+func TestRegistry_JWTAndToken(t *testing.T) {
+	reg, err := NewRegistry([]Channel{
+		{
+			Name:    "partner",
+			Verify:  Verification{Type: VerifyJWT, Secret: "k", Issuer: "partner.example", Audience: "aetheris"},
+			Mapping: Mapping{JobID: "claim:job_id", CorrelationKey: "query:key"},
+		},
+		{
+			Name:   "ci",
+			Verify: Verification{Type: VerifyToken, Header: "X-Token", Secret: "t0k"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	claims := fmt.Sprintf(`{"iss":"partner.example","aud":["aetheris"],"exp":%d,"job_id":"job-3"}`, now.Add(time.Minute).Unix())
+	query := headers(map[string]string{"key": "approve"})
+	d, err := reg.Handle("partner", Request{Header: headers(map[string]string{"Authorization": "Bearer " + signJWT("k", claims)}), Query: query}, now)
+	if err != nil {
+		t.Fatalf("Handle jwt: %v", err)
+	}
+	if d.JobID != "job-3" || d.CorrelationKey != "approve" || len(d.Payload) != 0 {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if _, err := reg.Handle("partner", Request{Header: headers(map[string]string{"Authorization": "Bearer " + signJWT("k", claims)}), Query: query}, now.Add(time.Hour)); !errors.Is(err, ErrVerification) {
+		t.Fatalf("expired jwt: err = %v", err)
+	}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + "."
+	if _, err := reg.Handle("partner", Request{Header: headers(map[string]string{"Authorization": none}), Query: query}, now); !errors.Is(err, ErrVerification) {
+		t.Fatalf("alg none: err = %v", err)
+	}
+
+	body := []byte(`{"job_id":"job-4"}`)
+	if _, err := reg.Handle("ci", Request{Body: body, Header: headers(map[string]string{"X-Token": "t0k"})}, now); !errors.Is(err, ErrMapping) {
+		t.Fatalf("missing correlation_key: err = %v, want ErrMapping", err)
+	}
+	if _, err := reg.Handle("ci", Request{Body: body, Header: headers(map[string]string{"X-Token": "nope"})}, now); !errors.Is(err, ErrVerification) {
+		t.Fatalf("wrong token: err = %v", err)
+	}
+}
+
+func TestNewRegistry_InvalidConfig(t *testing.T) {
+	cases := []Channel{
+		{Name: "a", Verify: Verification{Type: VerifyHMAC}},
+		{Name: "a", Verify: Verification{Type: "basic", Secret: "x"}},
+		{Name: "a", Action: "start", Verify: Verification{Type: VerifyToken, Secret: "x"}},
+		{Name: "a", Verify: Verification{Type: VerifyJWT, Secret: "x", Algorithm: "RS256"}},
+	}
+	for i, ch := range cases {
+		if _, err := NewRegistry([]Channel{ch}); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	ok := Channel{Name: "a", Verify: Verification{Type: VerifyToken, Secret: "x"}}
+	if _, err := NewRegistry([]Channel{ok, ok}); err == nil {
+		t.Error("duplicate channel: expected error")
+	}
+}
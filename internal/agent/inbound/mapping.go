@@ -0,0 +1,132 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mapDelivery 按映射表达式从请求体/请求头/查询参数/JWT claims 提取投递字段
+func (ch *Channel) mapDelivery(req Request, claims map[string]interface{}) (*Delivery, error) {
+	body := decodeBody(req.Body)
+	src := source{req: req, body: body, claims: claims}
+	d := &Delivery{
+		Channel:        ch.Name,
+		Action:         ch.Action,
+		TenantID:       ch.TenantID,
+		JobID:          src.str(ch.Mapping.JobID),
+		CorrelationKey: src.str(ch.Mapping.CorrelationKey),
+	}
+	if d.JobID == "" {
+		return nil, fmt.Errorf("%w: job_id (%s) not found", ErrMapping, ch.Mapping.JobID)
+	}
+	if ch.Action == ActionSignal && d.CorrelationKey == "" {
+		return nil, fmt.Errorf("%w: correlation_key (%s) not found", ErrMapping, ch.Mapping.CorrelationKey)
+	}
+	if ch.Action == ActionMessage {
+		d.MessageChannel = src.str(ch.Mapping.Channel)
+		d.MessageID = src.str(ch.Mapping.MessageID)
+	}
+	var payload interface{} = body
+	if ch.Mapping.Payload != "" {
+		payload = src.value(ch.Mapping.Payload)
+	}
+	switch p := payload.(type) {
+	case map[string]interface{}:
+		d.Payload = p
+	case nil:
+		d.Payload = map[string]interface{}{}
+	default:
+		d.Payload = map[string]interface{}{"value": p}
+	}
+	return d, nil
+}
+
+// decodeBody JSON 请求体解码为任意值（数字保留原文）；非 JSON 时为原始字符串
+func decodeBody(b []byte) interface{} {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return string(b)
+	}
+	return v
+}
+
+type source struct {
+	req    Request
+	body   interface{}
+	claims map[string]interface{}
+}
+
+// value 解析映射表达式；见 Mapping
+func (s source) value(expr string) interface{} {
+	switch {
+	case expr == "":
+		return nil
+	case expr == "$":
+		return s.body
+	case strings.HasPrefix(expr, "$."):
+		return lookup(s.body, strings.Split(strings.TrimPrefix(expr, "$."), "."))
+	case strings.HasPrefix(expr, "header:"):
+		return s.req.Header(strings.TrimPrefix(expr, "header:"))
+	case strings.HasPrefix(expr, "query:"):
+		return s.req.Query(strings.TrimPrefix(expr, "query:"))
+	case strings.HasPrefix(expr, "claim:"):
+		return lookup(s.claims, strings.Split(strings.TrimPrefix(expr, "claim:"), "."))
+	}
+	return expr
+}
+
+// str 解析映射表达式并转为字符串；对象/数组等非标量视为未命中
+func (s source) str(expr string) string {
+	switch v := s.value(expr).(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// lookup 按路径取嵌套字段；数组用数字下标，如 "items.0.id"
+func lookup(v interface{}, path []string) interface{} {
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
@@ -0,0 +1,264 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultStripeTolerance stripe 签名时间戳与当前时间的最大偏差
+const defaultStripeTolerance = 5 * time.Minute
+
+// normalize 补全默认值并校验验签配置
+func (v *Verification) normalize() error {
+	v.Type = strings.ToLower(strings.TrimSpace(v.Type))
+	v.Secret = resolveSecret(v.Secret)
+	if v.Secret == "" {
+		return errors.New("verify secret required")
+	}
+	switch v.Type {
+	case VerifyHMAC:
+		v.Scheme = strings.ToLower(v.Scheme)
+		if v.Scheme != "" && v.Scheme != "stripe" {
+			return fmt.Errorf("unknown hmac scheme %q", v.Scheme)
+		}
+		if v.Header == "" {
+			v.Header = "X-Signature"
+			if v.Scheme == "stripe" {
+				v.Header = "Stripe-Signature"
+			}
+		}
+		v.Algorithm = strings.ToLower(v.Algorithm)
+		if v.Algorithm == "" {
+			v.Algorithm = "sha256"
+		}
+		if hmacHash(v.Algorithm) == nil {
+			return fmt.Errorf("unsupported hmac algorithm %q", v.Algorithm)
+		}
+		v.Encoding = strings.ToLower(v.Encoding)
+		if v.Encoding == "" {
+			v.Encoding = "hex"
+		}
+		if v.Encoding != "hex" && v.Encoding != "base64" {
+			return fmt.Errorf("unsupported signature encoding %q", v.Encoding)
+		}
+		if v.Scheme == "stripe" && v.Tolerance <= 0 {
+			v.Tolerance = defaultStripeTolerance
+		}
+	case VerifyJWT:
+		if v.Header == "" {
+			v.Header = "Authorization"
+		}
+		v.Algorithm = strings.ToUpper(v.Algorithm)
+		if v.Algorithm == "" {
+			v.Algorithm = "HS256"
+		}
+		if jwtHash(v.Algorithm) == nil {
+			return fmt.Errorf("unsupported jwt algorithm %q", v.Algorithm)
+		}
+	case VerifyToken:
+		if v.Header == "" {
+			v.Header = "Authorization"
+		}
+	default:
+		return fmt.Errorf("unknown verify type %q", v.Type)
+	}
+	return nil
+}
+
+// verify 按配置校验请求；jwt 校验通过时返回其 claims 供映射使用
+func (v *Verification) verify(req Request, now time.Time) (map[string]interface{}, error) {
+	value := strings.TrimSpace(req.Header(v.Header))
+	if value == "" {
+		return nil, fmt.Errorf("missing %s header", v.Header)
+	}
+	switch v.Type {
+	case VerifyToken:
+		value = strings.TrimPrefix(stripBearer(value), v.Prefix)
+		if subtle.ConstantTimeCompare([]byte(value), []byte(v.Secret)) != 1 {
+			return nil, errors.New("token mismatch")
+		}
+		return nil, nil
+	case VerifyJWT:
+		return v.verifyJWT(stripBearer(value), now)
+	case VerifyHMAC:
+		if v.Scheme == "stripe" {
+			return nil, v.verifyStripe(value, req.Body, now)
+		}
+		if !v.matchHMAC(strings.TrimPrefix(value, v.Prefix), req.Body) {
+			return nil, errors.New("signature mismatch")
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown verify type %q", v.Type)
+}
+
+func stripBearer(s string) string {
+	if len(s) > 7 && strings.EqualFold(s[:7], "bearer ") {
+		return strings.TrimSpace(s[7:])
+	}
+	return s
+}
+
+func hmacHash(alg string) func() hash.Hash {
+	switch alg {
+	case "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	}
+	return nil
+}
+
+func jwtHash(alg string) func() hash.Hash {
+	switch alg {
+	case "HS256":
+		return sha256.New
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	}
+	return nil
+}
+
+// matchHMAC 以常数时间比较 sig 与 content 的 HMAC
+func (v *Verification) matchHMAC(sig string, content []byte) bool {
+	var got []byte
+	var err error
+	if v.Encoding == "base64" {
+		got, err = base64.StdEncoding.DecodeString(sig)
+	} else {
+		got, err = hex.DecodeString(strings.ToLower(sig))
+	}
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(hmacHash(v.Algorithm), []byte(v.Secret))
+	mac.Write(content)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// verifyStripe 校验 "t=<ts>,v1=<sig>[,v1=<sig>...]"：任一 v1 匹配 "<ts>.<body>" 的签名且时间戳在容差内
+func (v *Verification) verifyStripe(header string, body []byte, now time.Time) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = val
+		case "v1":
+			sigs = append(sigs, val)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return errors.New("malformed stripe signature header")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > v.Tolerance || d < -v.Tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	content := append([]byte(ts+"."), body...)
+	for _, sig := range sigs {
+		if v.matchHMAC(sig, content) {
+			return nil
+		}
+	}
+	return errors.New("signature mismatch")
+}
+
+// verifyJWT 校验 HMAC 签名的 JWT：alg 须与配置一致（拒绝 none 与算法替换），并检查 exp/nbf/iss/aud
+func (v *Verification) verifyJWT(token string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed jwt")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed jwt header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if json.Unmarshal(headerJSON, &header) != nil || header.Alg != v.Algorithm {
+		return nil, fmt.Errorf("unexpected jwt alg %q", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed jwt signature")
+	}
+	mac := hmac.New(jwtHash(v.Algorithm), []byte(v.Secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errors.New("jwt signature mismatch")
+	}
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed jwt claims")
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, errors.New("malformed jwt claims")
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(v.Tolerance)) {
+		return nil, errors.New("jwt expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.Tolerance).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("jwt not yet valid")
+	}
+	if v.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.Issuer {
+			return nil, errors.New("jwt issuer mismatch")
+		}
+	}
+	if v.Audience != "" && !audienceContains(claims["aud"], v.Audience) {
+		return nil, errors.New("jwt audience mismatch")
+	}
+	return claims, nil
+}
+
+// audienceContains aud 可为字符串或字符串数组
+func audienceContains(aud interface{}, want string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == want
+	case []interface{}:
+		for _, x := range a {
+			if s, _ := x.(string); s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"sync"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// AttributingStore JobStore 装饰器：Append 时按 Job 元数据为事件填充发起归属（JobEvent.Attribution），
+// 使 API、Worker、子 Job 等各处追加的事件都能追溯到发起请求的用户；已带归属的事件保持不变
+type AttributingStore struct {
+	jobstore.JobStore
+	jobs JobStore
+
+	mu    sync.Mutex
+	cache map[string]*jobstore.Attribution
+}
+
+// NewAttributingStore 包装 inner；jobs 用于查询 Job 的归属
+func NewAttributingStore(inner jobstore.JobStore, jobs JobStore) *AttributingStore {
+	return &AttributingStore{JobStore: inner, jobs: jobs, cache: make(map[string]*jobstore.Attribution)}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *AttributingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+// attribution 查询 Job 的归属并缓存（归属在创建后不再变化）；Job 不存在或查询failed时不缓存
+func (s *AttributingStore) attribution(ctx context.Context, jobID string) *jobstore.Attribution {
+	s.mu.Lock()
+	a, ok := s.cache[jobID]
+	s.mu.Unlock()
+	if ok || s.jobs == nil {
+		return a
+	}
+	j, err := s.jobs.Get(ctx, jobID)
+	if err != nil || j == nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.cache[jobID] = j.Attribution
+	s.mu.Unlock()
+	return j.Attribution
+}
+
+func (s *AttributingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	if event.Attribution == nil {
+		event.Attribution = s.attribution(ctx, jobID)
+	}
+	return s.JobStore.Append(ctx, jobID, expectedVersion, event)
+}
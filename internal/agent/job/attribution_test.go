@@ -0,0 +1,92 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestAttributingStore_FillsFromJob(t *testing.T) {
+	ctx := context.Background()
+	jobs := NewJobStoreMem()
+	attr := &jobstore.Attribution{UserID: "alice", Client: jobstore.ClientCLI, RequestID: "req-1"}
+	jobID, _ := jobs.Create(ctx, &Job{AgentID: "a1", Goal: "g", Attribution: attr})
+	store := NewAttributingStore(jobstore.NewMemoryStore(), jobs)
+
+	ver, err := store.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCreated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explicit := &jobstore.Attribution{UserID: "bob", Client: jobstore.ClientUI}
+	if _, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCompleted, Attribution: explicit}); err != nil {
+		t.Fatal(err)
+	}
+	// 未登记的 Job 不带归属
+	if _, err := store.Append(ctx, "unknown", 0, jobstore.JobEvent{JobID: "unknown", Type: jobstore.JobCreated}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, _, _ := store.ListEvents(ctx, jobID)
+	if len(events) != 2 || events[0].Attribution == nil || *events[0].Attribution != *attr {
+		t.Fatalf("first event attribution = %+v", events[0].Attribution)
+	}
+	if events[1].Attribution.UserID != "bob" {
+		t.Fatalf("explicit attribution overwritten: %+v", events[1].Attribution)
+	}
+	other, _, _ := store.ListEvents(ctx, "unknown")
+	if len(other) != 1 || !other[0].Attribution.IsZero() {
+		t.Fatalf("unknown job attribution = %+v", other[0].Attribution)
+	}
+}
+
+func TestSupervisor_ChildInheritsAttribution(t *testing.T) {
+	ctx := context.Background()
+	jobs := NewJobStoreMem()
+	plan := func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+		return &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeLLM}}}, nil
+	}
+	events := NewAttributingStore(jobstore.NewMemoryStore(), jobs)
+	sup := NewSupervisor(jobs, events, NewChildJobStoreMem(), plan)
+	attr := &jobstore.Attribution{UserID: "alice", Client: jobstore.ClientSDK, RequestID: "req-9"}
+	parentID, _ := jobs.Create(ctx, &Job{AgentID: "sup", Goal: "report", Attribution: attr})
+
+	out, err := sup.SpawnChildren(ctx, parentID, "s1", []executor.ChildSpec{{Key: "a", AgentID: "researcher", Goal: "research"}})
+	if err != nil || len(out) != 1 {
+		t.Fatalf("spawn: %v %+v", err, out)
+	}
+	child, _ := jobs.Get(ctx, out[0].JobID)
+	if child.Attribution == nil || *child.Attribution != *attr {
+		t.Fatalf("child attribution = %+v", child.Attribution)
+	}
+	childEvents, _, _ := events.ListEvents(ctx, child.ID)
+	for _, e := range childEvents {
+		if e.Attribution == nil || e.Attribution.RequestID != "req-9" {
+			t.Fatalf("child event %s attribution = %+v", e.Type, e.Attribution)
+		}
+	}
+}
+
+func TestNormalizeClient(t *testing.T) {
+	for in, want := range map[string]string{"": "api", "CLI": "cli", " ui ": "ui", "sdk": "sdk", "curl": "api"} {
+		if got := jobstore.NormalizeClient(in); got != want {
+			t.Errorf("NormalizeClient(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// BacklogCounter 返回 Pending 积压数（ObservabilityReader 满足该接口）
+type BacklogCounter interface {
+	CountPending(ctx context.Context, queue string) (int, error)
+}
+
+// BackpressureLevel 背压级别，按积压阈值逐级升高
+type BackpressureLevel int
+
+const (
+	BackpressureNormal     BackpressureLevel = iota // 正常受理
+	BackpressureShedding                            // 跳过可选工作（计划预览、DecisionSnapshot 事件）
+	BackpressureThrottling                          // 低优先级租户返回 429
+	BackpressureOverloaded                          // 仅高优先级租户可新建 Job
+)
+
+func (l BackpressureLevel) String() string {
+	switch l {
+	case BackpressureShedding:
+		return "shedding"
+	case BackpressureThrottling:
+		return "throttling"
+	case BackpressureOverloaded:
+		return "overloaded"
+	default:
+		return "normal"
+	}
+}
+
+// 租户优先级
+const (
+	TenantPriorityHigh   = "high"
+	TenantPriorityNormal = "normal"
+	TenantPriorityLow    = "low"
+)
+
+// BackpressurePolicy 背压阈值与租户优先级；阈值 <=0 表示不启用该级别
+type BackpressurePolicy struct {
+	ShedBacklog     int
+	ThrottleBacklog int
+	RejectBacklog   int
+	RetryAfter      time.Duration     // 429 的 Retry-After；<=0 时默认 30s
+	TenantPriority  map[string]string // 租户 → high | normal | low
+	DefaultPriority string            // 未列出的租户，空则 normal
+}
+
+// LevelFor 返回积压 backlog 对应的级别
+func (p BackpressurePolicy) LevelFor(backlog int) BackpressureLevel {
+	switch {
+	case p.RejectBacklog > 0 && backlog >= p.RejectBacklog:
+		return BackpressureOverloaded
+	case p.ThrottleBacklog > 0 && backlog >= p.ThrottleBacklog:
+		return BackpressureThrottling
+	case p.ShedBacklog > 0 && backlog >= p.ShedBacklog:
+		return BackpressureShedding
+	default:
+		return BackpressureNormal
+	}
+}
+
+// PriorityOf 返回租户优先级；未知取值按 normal 处理
+func (p BackpressurePolicy) PriorityOf(tenantID string) string {
+	prio, ok := p.TenantPriority[tenantID]
+	if !ok {
+		prio = p.DefaultPriority
+	}
+	switch prio = strings.ToLower(strings.TrimSpace(prio)); prio {
+	case TenantPriorityHigh, TenantPriorityLow:
+		return prio
+	default:
+		return TenantPriorityNormal
+	}
+}
+
+// BackpressureState 当前背压状态（GET /api/observability/summary 的 backpressure 字段）
+type BackpressureState struct {
+	Level           string    `json:"level"`
+	Backlog         int       `json:"backlog"`
+	SampledAt       time.Time `json:"sampled_at"`
+	ShedBacklog     int       `json:"shed_backlog,omitempty"`
+	ThrottleBacklog int       `json:"throttle_backlog,omitempty"`
+	RejectBacklog   int       `json:"reject_backlog,omitempty"`
+	// SampleError 最近一次积压采样失败的原因；失败时沿用上次采样的级别
+	SampleError string `json:"sample_error,omitempty"`
+}
+
+// BackpressureGate 按 Pending 积压决定是否受理新消息；积压按 ttl 采样缓存，避免每个请求都 count(*)
+type BackpressureGate struct {
+	counter BacklogCounter
+	policy  BackpressurePolicy
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	sampledAt time.Time
+	backlog   int
+	level     BackpressureLevel
+	sampleErr error
+}
+
+// NewBackpressureGate 创建 Gate；ttl<=0 时默认 5s
+func NewBackpressureGate(counter BacklogCounter, policy BackpressurePolicy, ttl time.Duration) *BackpressureGate {
+	if ttl <= 0 {
+		ttl = 5 * time.Second
+	}
+	if policy.RetryAfter <= 0 {
+		policy.RetryAfter = 30 * time.Second
+	}
+	return &BackpressureGate{counter: counter, policy: policy, ttl: ttl}
+}
+
+// sample 刷新积压采样（需持有 mu）；采样失败时保留上次级别（fail open 于正常，不因观测故障拒绝流量）
+func (g *BackpressureGate) sample(ctx context.Context) {
+	now := time.Now()
+	if !g.sampledAt.IsZero() && now.Sub(g.sampledAt) < g.ttl {
+		return
+	}
+	g.sampledAt = now
+	n, err := g.counter.CountPending(ctx, "")
+	g.sampleErr = err
+	if err != nil {
+		return
+	}
+	g.backlog = n
+	g.level = g.policy.LevelFor(n)
+	metrics.BackpressureLevel.Set(float64(g.level))
+}
+
+// Level 返回当前背压级别
+func (g *BackpressureGate) Level(ctx context.Context) BackpressureLevel {
+	if g == nil || g.counter == nil {
+		return BackpressureNormal
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sample(ctx)
+	return g.level
+}
+
+// State 返回当前背压状态
+func (g *BackpressureGate) State(ctx context.Context) BackpressureState {
+	if g == nil || g.counter == nil {
+		return BackpressureState{Level: BackpressureNormal.String()}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sample(ctx)
+	st := BackpressureState{
+		Level:           g.level.String(),
+		Backlog:         g.backlog,
+		SampledAt:       g.sampledAt,
+		ShedBacklog:     g.policy.ShedBacklog,
+		ThrottleBacklog: g.policy.ThrottleBacklog,
+		RejectBacklog:   g.policy.RejectBacklog,
+	}
+	if g.sampleErr != nil {
+		st.SampleError = g.sampleErr.Error()
+	}
+	return st
+}
+
+// Admit 判断租户的新消息是否受理；拒绝时返回建议的 Retry-After
+func (g *BackpressureGate) Admit(ctx context.Context, tenantID string) (bool, time.Duration, BackpressureLevel) {
+	level := g.Level(ctx)
+	prio := TenantPriorityNormal
+	if g != nil {
+		prio = g.policy.PriorityOf(tenantID)
+	}
+	reject := (level == BackpressureThrottling && prio == TenantPriorityLow) ||
+		(level == BackpressureOverloaded && prio != TenantPriorityHigh)
+	if !reject {
+		return true, 0, level
+	}
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	metrics.BackpressureRejectionsTotal.WithLabelValues(tenantID, prio).Inc()
+	return false, g.policy.RetryAfter, level
+}
+
+// ShouldShed 可选工作是否应跳过；work 用于统计被跳过的工作类型
+func (g *BackpressureGate) ShouldShed(ctx context.Context, work string) bool {
+	if g.Level(ctx) < BackpressureShedding {
+		return false
+	}
+	metrics.BackpressureShedTotal.WithLabelValues(work).Inc()
+	return true
+}
+
+// RetryAfter 返回 429 响应建议的 Retry-After
+func (g *BackpressureGate) RetryAfter() time.Duration {
+	if g == nil {
+		return 30 * time.Second
+	}
+	return g.policy.RetryAfter
+}
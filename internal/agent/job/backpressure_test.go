@@ -0,0 +1,98 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeBacklog struct {
+	n   int
+	err error
+}
+
+func (f *fakeBacklog) CountPending(ctx context.Context, queue string) (int, error) {
+	return f.n, f.err
+}
+
+func testBackpressurePolicy() BackpressurePolicy {
+	return BackpressurePolicy{
+		ShedBacklog:     10,
+		ThrottleBacklog: 20,
+		RejectBacklog:   30,
+		RetryAfter:      15 * time.Second,
+		TenantPriority:  map[string]string{"vip": "high", "batch": "LOW"},
+	}
+}
+
+func TestBackpressurePolicy_LevelFor(t *testing.T) {
+	p := testBackpressurePolicy()
+	cases := map[int]BackpressureLevel{0: BackpressureNormal, 10: BackpressureShedding, 25: BackpressureThrottling, 30: BackpressureOverloaded}
+	for backlog, want := range cases {
+		if got := p.LevelFor(backlog); got != want {
+			t.Errorf("LevelFor(%d) = %s, want %s", backlog, got, want)
+		}
+	}
+	if got := (BackpressurePolicy{}).LevelFor(1 << 20); got != BackpressureNormal {
+		t.Errorf("阈值未配置时应为 normal，got %s", got)
+	}
+	if got := p.PriorityOf("batch"); got != TenantPriorityLow {
+		t.Errorf("PriorityOf(batch) = %s", got)
+	}
+	if got := p.PriorityOf("unknown"); got != TenantPriorityNormal {
+		t.Errorf("PriorityOf(unknown) = %s", got)
+	}
+}
+
+func TestBackpressureGate_Admit(t *testing.T) {
+	ctx := context.Background()
+	counter := &fakeBacklog{n: 25}
+	gate := NewBackpressureGate(counter, testBackpressurePolicy(), time.Nanosecond)
+
+	if ok, retry, level := gate.Admit(ctx, "batch"); ok || retry != 15*time.Second || level != BackpressureThrottling {
+		t.Errorf("throttling 时低优先级租户应被拒绝: ok=%v retry=%s level=%s", ok, retry, level)
+	}
+	if ok, _, _ := gate.Admit(ctx, "acme"); !ok {
+		t.Error("throttling 时 normal 租户应受理")
+	}
+	if !gate.ShouldShed(ctx, "plan_preview") {
+		t.Error("throttling 时应跳过可选工作")
+	}
+
+	counter.n = 40
+	time.Sleep(time.Millisecond)
+	if ok, _, _ := gate.Admit(ctx, "acme"); ok {
+		t.Error("overloaded 时 normal 租户应被拒绝")
+	}
+	if ok, _, _ := gate.Admit(ctx, "vip"); !ok {
+		t.Error("overloaded 时高优先级租户应受理")
+	}
+
+	// 采样失败沿用上次级别，并在状态中暴露错误
+	counter.err = errors.New("db down")
+	time.Sleep(time.Millisecond)
+	st := gate.State(ctx)
+	if st.Level != "overloaded" || st.Backlog != 40 || st.SampleError == "" {
+		t.Errorf("State after sample error: %+v", st)
+	}
+
+	var nilGate *BackpressureGate
+	if ok, _, _ := nilGate.Admit(ctx, "batch"); !ok || nilGate.ShouldShed(ctx, "plan_preview") {
+		t.Error("nil gate should admit everything")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChildJob 监督者 Job 与其派发的子 Job 的关联（spawn / join 节点写入）；同一子任务每次重派为一条记录
+type ChildJob struct {
+	ParentJobID string    `json:"parent_job_id"`
+	ChildJobID  string    `json:"child_job_id"`
+	NodeID      string    `json:"node_id"` // 派发该子任务的 spawn 节点
+	Key         string    `json:"key"`     // 子任务键，在 spawn 节点内唯一
+	Attempt     int       `json:"attempt"` // 派发次数，从 1 开始，重派时递增
+	AgentID     string    `json:"agent_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ChildJobStore 子 Job 关联存储
+type ChildJobStore interface {
+	// AddChild 记录子 Job；同一 (parent, node, key, attempt) 重复写入忽略
+	AddChild(ctx context.Context, c *ChildJob) error
+	// ListChildren 列出监督者的全部子 Job（含历次重派），按创建时间升序
+	ListChildren(ctx context.Context, parentJobID string) ([]*ChildJob, error)
+	// GetParent 返回子 Job 的关联记录；不是子 Job 时返回 nil, nil
+	GetParent(ctx context.Context, childJobID string) (*ChildJob, error)
+}
+
+// LatestChildren 每个子任务（node + key）只保留最近一次派发，按首次派发顺序排列
+func LatestChildren(all []*ChildJob) []*ChildJob {
+	idx := make(map[string]int, len(all))
+	var out []*ChildJob
+	for _, c := range all {
+		k := c.NodeID + "\x00" + c.Key
+		if i, ok := idx[k]; ok {
+			if c.Attempt > out[i].Attempt {
+				out[i] = c
+			}
+			continue
+		}
+		idx[k] = len(out)
+		out = append(out, c)
+	}
+	return out
+}
+
+// ChildJobStoreMem 内存实现
+type ChildJobStoreMem struct {
+	mu       sync.Mutex
+	byParent map[string][]*ChildJob
+	byChild  map[string]*ChildJob
+}
+
+// NewChildJobStoreMem 创建内存子 Job 关联存储
+func NewChildJobStoreMem() *ChildJobStoreMem {
+	return &ChildJobStoreMem{byParent: make(map[string][]*ChildJob), byChild: make(map[string]*ChildJob)}
+}
+
+func (s *ChildJobStoreMem) AddChild(ctx context.Context, c *ChildJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.byParent[c.ParentJobID] {
+		if existing.NodeID == c.NodeID && existing.Key == c.Key && existing.Attempt == c.Attempt {
+			return nil
+		}
+	}
+	cp := *c
+	if cp.CreatedAt.IsZero() {
+		cp.CreatedAt = time.Now()
+	}
+	s.byParent[c.ParentJobID] = append(s.byParent[c.ParentJobID], &cp)
+	s.byChild[c.ChildJobID] = &cp
+	return nil
+}
+
+func (s *ChildJobStoreMem) ListChildren(ctx context.Context, parentJobID string) ([]*ChildJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*ChildJob, 0, len(s.byParent[parentJobID]))
+	for _, c := range s.byParent[parentJobID] {
+		cp := *c
+		out = append(out, &cp)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *ChildJobStoreMem) GetParent(ctx context.Context, childJobID string) (*ChildJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.byChild[childJobID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *c
+	return &cp, nil
+}
+
+// ChildRollup 子 Job 汇总状态：Status 为 running（仍有未终态）、failed（存在失败/取消）或 completed
+type ChildRollup struct {
+	Total     int    `json:"total"`
+	Active    int    `json:"active"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+	Status    string `json:"status"`
+}
+
+// HierarchyNode 层级中的一个子 Job（仅最近一次派发）；子 Job 本身也是监督者时递归展开
+type HierarchyNode struct {
+	JobID    string          `json:"job_id"`
+	Key      string          `json:"key"`
+	NodeID   string          `json:"node_id"`
+	AgentID  string          `json:"agent_id"`
+	Attempt  int             `json:"attempt"`
+	Status   string          `json:"status"`
+	Goal     string          `json:"goal,omitempty"`
+	Children []HierarchyNode `json:"children,omitempty"`
+	Rollup   *ChildRollup    `json:"rollup,omitempty"`
+}
+
+// JobHierarchy Job 在监督者层级中的位置：Parent 为其监督者关联（非子 Job 时为 nil），Children 为其子 Job
+type JobHierarchy struct {
+	Parent   *ChildJob       `json:"parent,omitempty"`
+	Children []HierarchyNode `json:"children,omitempty"`
+	Rollup   *ChildRollup    `json:"rollup,omitempty"`
+}
+
+// BuildHierarchy 构建 Job 的监督者层级，子 Job 最多展开 depth 层；既无监督者也无子 Job 时返回 nil
+func BuildHierarchy(ctx context.Context, jobs JobStore, children ChildJobStore, jobID string, depth int) (*JobHierarchy, error) {
+	parent, err := children.GetParent(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, rollup, err := buildChildNodes(ctx, jobs, children, jobID, depth)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil && len(nodes) == 0 {
+		return nil, nil
+	}
+	return &JobHierarchy{Parent: parent, Children: nodes, Rollup: rollup}, nil
+}
+
+func buildChildNodes(ctx context.Context, jobs JobStore, children ChildJobStore, jobID string, depth int) ([]HierarchyNode, *ChildRollup, error) {
+	if depth <= 0 {
+		return nil, nil, nil
+	}
+	all, err := children.ListChildren(ctx, jobID)
+	if err != nil || len(all) == 0 {
+		return nil, nil, err
+	}
+	latest := LatestChildren(all)
+	nodes := make([]HierarchyNode, 0, len(latest))
+	rollup := &ChildRollup{Total: len(latest)}
+	for _, c := range latest {
+		n := HierarchyNode{JobID: c.ChildJobID, Key: c.Key, NodeID: c.NodeID, AgentID: c.AgentID, Attempt: c.Attempt, Status: "unknown"}
+		j, err := jobs.Get(ctx, c.ChildJobID)
+		if err != nil {
+			return nil, nil, err
+		}
+		status := StatusPending
+		if j != nil {
+			status = j.Status
+			n.Status = j.Status.String()
+			n.Goal = j.Goal
+		}
+		switch status {
+		case StatusCompleted:
+			rollup.Completed++
+		case StatusFailed:
+			rollup.Failed++
+		case StatusCancelled:
+			rollup.Cancelled++
+		default:
+			rollup.Active++
+		}
+		if n.Children, n.Rollup, err = buildChildNodes(ctx, jobs, children, c.ChildJobID, depth-1); err != nil {
+			return nil, nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	switch {
+	case rollup.Active > 0:
+		rollup.Status = "running"
+	case rollup.Failed > 0 || rollup.Cancelled > 0:
+		rollup.Status = "failed"
+	default:
+		rollup.Status = "completed"
+	}
+	return nodes, rollup, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ChildJobStorePg PostgreSQL 实现，使用 job_children 表
+type ChildJobStorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewChildJobStorePg 创建基于 PostgreSQL 的子 Job 关联存储
+func NewChildJobStorePg(pool *pgxpool.Pool) *ChildJobStorePg {
+	return &ChildJobStorePg{pool: pool}
+}
+
+const childJobColumns = `parent_job_id, child_job_id, node_id, child_key, attempt, agent_id, created_at`
+
+func (s *ChildJobStorePg) AddChild(ctx context.Context, c *ChildJob) error {
+	if c == nil {
+		return errors.New("child job is nil")
+	}
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO job_children (parent_job_id, child_job_id, node_id, child_key, attempt, agent_id)
+		 VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+		c.ParentJobID, c.ChildJobID, c.NodeID, c.Key, c.Attempt, c.AgentID)
+	return err
+}
+
+func (s *ChildJobStorePg) ListChildren(ctx context.Context, parentJobID string) ([]*ChildJob, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+childJobColumns+` FROM job_children WHERE parent_job_id = $1 ORDER BY created_at, attempt`, parentJobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*ChildJob
+	for rows.Next() {
+		var c ChildJob
+		if err := rows.Scan(&c.ParentJobID, &c.ChildJobID, &c.NodeID, &c.Key, &c.Attempt, &c.AgentID, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &c)
+	}
+	return out, rows.Err()
+}
+
+func (s *ChildJobStorePg) GetParent(ctx context.Context, childJobID string) (*ChildJob, error) {
+	var c ChildJob
+	err := s.pool.QueryRow(ctx, `SELECT `+childJobColumns+` FROM job_children WHERE child_job_id = $1`, childJobID).
+		Scan(&c.ParentJobID, &c.ChildJobID, &c.NodeID, &c.Key, &c.Attempt, &c.AgentID, &c.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
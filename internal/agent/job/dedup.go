@@ -0,0 +1,33 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// GoalHash 目标的规范化哈希（去首尾空白、折叠连续空白、统一小写后 sha256），用于服务端按目标去重
+func GoalHash(goal string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(goal), " "))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// dedupEligible 可作为去重命中的 Job：Failed / Cancelled 视为客户端可合理重新提交，不参与去重
+func dedupEligible(status JobStatus) bool {
+	return status != StatusFailed && status != StatusCancelled
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGoalHash_Normalizes(t *testing.T) {
+	if GoalHash("  Summarize   the Report\n") != GoalHash("summarize the report") {
+		t.Error("whitespace/case variants should hash equal")
+	}
+	if GoalHash("summarize the report") == GoalHash("summarize the reports") {
+		t.Error("different goals should hash differently")
+	}
+}
+
+func TestJobStoreMem_FindRecentByGoalHash(t *testing.T) {
+	ctx := context.Background()
+	s := NewJobStoreMem()
+	id, _ := s.Create(ctx, &Job{AgentID: "a1", TenantID: "t1", Goal: "Run the audit"})
+	since := time.Now().Add(-time.Minute)
+
+	got, err := s.FindRecentByGoalHash(ctx, "a1", "t1", GoalHash("run  the AUDIT"), since)
+	if err != nil || got == nil || got.ID != id {
+		t.Fatalf("FindRecentByGoalHash = %+v, %v; want %s", got, err, id)
+	}
+	for name, args := range map[string][]string{
+		"other agent":  {"a2", "t1", "run the audit"},
+		"other tenant": {"a1", "t2", "run the audit"},
+		"other goal":   {"a1", "t1", "run the backup"},
+	} {
+		if got, _ := s.FindRecentByGoalHash(ctx, args[0], args[1], GoalHash(args[2]), since); got != nil {
+			t.Errorf("%s: unexpected match %s", name, got.ID)
+		}
+	}
+	if got, _ := s.FindRecentByGoalHash(ctx, "a1", "t1", GoalHash("run the audit"), time.Now().Add(time.Minute)); got != nil {
+		t.Error("job outside window should not match")
+	}
+	_ = s.UpdateStatus(ctx, id, StatusFailed)
+	if got, _ := s.FindRecentByGoalHash(ctx, "a1", "t1", GoalHash("run the audit"), since); got != nil {
+		t.Error("failed job should not be a dedup target")
+	}
+}
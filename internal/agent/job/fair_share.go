@@ -0,0 +1,233 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// 公平调度默认值：未配置租户权重为 1，其他租户持续被认领而自身 5m 未被认领视为饥饿，租户负载缓存 1s
+const (
+	DefaultFairShareWeight      = 1.0
+	DefaultStarvationThreshold  = 5 * time.Minute
+	defaultFairShareLoadRefresh = time.Second
+)
+
+// TenantLoad 租户当前 Pending 与 Running 的 Job 数
+type TenantLoad struct {
+	Pending int
+	Running int
+}
+
+// TenantLoadReader 可选：按租户统计 Pending/Running Job 数（全局视图，多 Worker 共享）；实现：JobStoreMem、JobStorePg
+type TenantLoadReader interface {
+	TenantLoads(ctx context.Context) (map[string]TenantLoad, error)
+}
+
+// TenantShare 单个租户的调度权重与最低份额
+type TenantShare struct {
+	Weight float64 // <=0 取 FairShareConfig.DefaultWeight
+	// MinShare 最低份额（0~1，占全部 Running 的比例）；有 Pending 且低于最低份额时优先于其他租户认领
+	MinShare float64
+}
+
+// FairShareConfig 租户加权公平调度配置
+type FairShareConfig struct {
+	Tenants       map[string]TenantShare
+	DefaultWeight float64 // 未配置租户的权重，<=0 为 1
+	// StarvationThreshold 有 Pending 期间其他租户仍被认领、自身却超过该时长未被认领视为饥饿（整体满载无人被认领不计），<=0 为 5m
+	StarvationThreshold time.Duration
+}
+
+// FairShare 按租户加权公平排队（weighted fair queuing）：每次认领前按各租户 Running 占比与目标份额（权重 / 活跃租户权重和）之比
+// 从低到高依次尝试认领，低于最低份额的租户最先；可并发使用
+type FairShare struct {
+	cfg   FairShareConfig
+	loads TenantLoadReader
+	now   func() time.Time
+
+	mu           sync.Mutex
+	cached       map[string]TenantLoad
+	cachedAt     time.Time
+	waitingSince map[string]time.Time // 租户有 Pending 且自上次被认领以来的起点
+	lastServed   time.Time            // 最近一次任一租户被认领的时刻
+	starved      map[string]bool
+}
+
+// NewFairShare 创建公平调度器；loads 通常为实现了 TenantLoadReader 的 JobStore
+func NewFairShare(loads TenantLoadReader, cfg FairShareConfig) *FairShare {
+	if cfg.DefaultWeight <= 0 {
+		cfg.DefaultWeight = DefaultFairShareWeight
+	}
+	if cfg.StarvationThreshold <= 0 {
+		cfg.StarvationThreshold = DefaultStarvationThreshold
+	}
+	return &FairShare{cfg: cfg, loads: loads, now: time.Now, waitingSince: make(map[string]time.Time), starved: make(map[string]bool)}
+}
+
+func (f *FairShare) share(tenant string) TenantShare {
+	s := f.cfg.Tenants[tenant]
+	if s.Weight <= 0 {
+		s.Weight = f.cfg.DefaultWeight
+	}
+	return s
+}
+
+// Order 返回本轮应依次尝试认领的租户（仅含有 Pending 的租户），并更新份额与饥饿指标
+func (f *FairShare) Order(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	if f.cached == nil || now.Sub(f.cachedAt) >= defaultFairShareLoadRefresh {
+		loads, err := f.loads.TenantLoads(ctx)
+		if err != nil {
+			return nil, err
+		}
+		f.cached, f.cachedAt = loads, now
+	}
+	totalRunning, totalWeight := 0, 0.0
+	for tenant, l := range f.cached {
+		totalRunning += l.Running
+		if l.Pending > 0 || l.Running > 0 {
+			totalWeight += f.share(tenant).Weight
+		}
+	}
+	type candidate struct {
+		tenant     string
+		belowMin   bool
+		attainment float64
+		since      time.Time
+	}
+	var cands []candidate
+	for tenant, l := range f.cached {
+		if l.Pending == 0 && l.Running == 0 {
+			continue
+		}
+		s := f.share(tenant)
+		target := s.Weight / totalWeight
+		actual := 0.0
+		if totalRunning > 0 {
+			actual = float64(l.Running) / float64(totalRunning)
+		}
+		metrics.TenantFairShareTarget.WithLabelValues(tenant).Set(target)
+		metrics.TenantShareAttainment.WithLabelValues(tenant).Set(actual / target)
+		if l.Pending == 0 {
+			f.clearWaiting(tenant)
+			continue
+		}
+		since, ok := f.waitingSince[tenant]
+		if !ok {
+			since = now
+			f.waitingSince[tenant] = now
+		}
+		var waited time.Duration
+		if f.lastServed.After(since) {
+			waited = f.lastServed.Sub(since)
+		}
+		f.observeStarvation(tenant, waited)
+		cands = append(cands, candidate{tenant: tenant, belowMin: s.MinShare > 0 && actual < s.MinShare, attainment: actual / target, since: since})
+	}
+	for tenant := range f.waitingSince {
+		if l := f.cached[tenant]; l.Pending == 0 {
+			f.clearWaiting(tenant)
+		}
+	}
+	sort.Slice(cands, func(i, k int) bool {
+		a, b := cands[i], cands[k]
+		if a.belowMin != b.belowMin {
+			return a.belowMin
+		}
+		if a.attainment != b.attainment {
+			return a.attainment < b.attainment
+		}
+		if !a.since.Equal(b.since) {
+			return a.since.Before(b.since)
+		}
+		return a.tenant < b.tenant
+	})
+	out := make([]string, len(cands))
+	for i, c := range cands {
+		out[i] = c.tenant
+	}
+	return out, nil
+}
+
+// observeStarvation 记录租户在其他租户被认领期间的等待时长；首次超过阈值时计一次饥饿
+func (f *FairShare) observeStarvation(tenant string, waited time.Duration) {
+	metrics.TenantStarvationSeconds.WithLabelValues(tenant).Set(waited.Seconds())
+	if waited >= f.cfg.StarvationThreshold && !f.starved[tenant] {
+		f.starved[tenant] = true
+		metrics.TenantStarvationTotal.WithLabelValues(tenant).Inc()
+	}
+}
+
+func (f *FairShare) clearWaiting(tenant string) {
+	delete(f.waitingSince, tenant)
+	delete(f.starved, tenant)
+	metrics.TenantStarvationSeconds.WithLabelValues(tenant).Set(0)
+}
+
+// Served 记录租户刚被认领一条 Job：重置等待起点，并在缓存的负载中计入（下次刷新前的认领据此继续公平分配）
+func (f *FairShare) Served(tenant string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if l, ok := f.cached[tenant]; ok {
+		if l.Pending > 0 {
+			l.Pending--
+		}
+		l.Running++
+		f.cached[tenant] = l
+	}
+	f.lastServed = f.now()
+	if _, ok := f.waitingSince[tenant]; ok {
+		f.waitingSince[tenant] = f.lastServed
+	}
+	delete(f.starved, tenant)
+}
+
+// Claim 按公平顺序依次以租户过滤认领；各租户都未认领到（如 Pending 不满足队列/能力）时回退为不区分租户的认领
+func (f *FairShare) Claim(ctx context.Context, store JobStore, queueClass string, workerCapabilities []string) (*Job, error) {
+	order, err := f.Order(ctx)
+	if err != nil {
+		return store.ClaimNextPendingForWorker(ctx, queueClass, workerCapabilities, "")
+	}
+	for _, tenant := range order {
+		j, err := store.ClaimNextPendingForWorker(ctx, queueClass, workerCapabilities, tenant)
+		if err != nil {
+			return nil, err
+		}
+		if j != nil {
+			f.Served(tenant)
+			return j, nil
+		}
+	}
+	j, err := store.ClaimNextPendingForWorker(ctx, queueClass, workerCapabilities, "")
+	if j != nil {
+		f.Served(tenantOf(j))
+	}
+	return j, err
+}
+
+func tenantOf(j *Job) string {
+	if j.TenantID == "" {
+		return "default"
+	}
+	return j.TenantID
+}
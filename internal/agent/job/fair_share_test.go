@@ -0,0 +1,106 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func seedTenants(t *testing.T, store *JobStoreMem, pending map[string]int) {
+	t.Helper()
+	for tenant, n := range pending {
+		for i := 0; i < n; i++ {
+			if _, err := store.Create(context.Background(), &Job{AgentID: "a", TenantID: tenant, Goal: "g"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func claimCounts(t *testing.T, f *FairShare, store *JobStoreMem, n int) map[string]int {
+	t.Helper()
+	got := make(map[string]int)
+	for i := 0; i < n; i++ {
+		j, err := f.Claim(context.Background(), store, "", nil)
+		if err != nil || j == nil {
+			t.Fatalf("claim %d: %v %v", i, j, err)
+		}
+		got[j.TenantID]++
+	}
+	return got
+}
+
+func TestFairShare_NoisyTenantDoesNotStarveOthers(t *testing.T) {
+	store := NewJobStoreMem()
+	// noisy 先入队大量 Job；FIFO 下 quiet 要等 noisy 全部认领完
+	seedTenants(t, store, map[string]int{"noisy": 20})
+	seedTenants(t, store, map[string]int{"quiet": 3})
+	got := claimCounts(t, NewFairShare(store, FairShareConfig{}), store, 6)
+	if got["quiet"] != 3 || got["noisy"] != 3 {
+		t.Fatalf("claims = %v, want 3/3", got)
+	}
+}
+
+func TestFairShare_WeightsAndMinShare(t *testing.T) {
+	store := NewJobStoreMem()
+	seedTenants(t, store, map[string]int{"gold": 20, "free": 20})
+	f := NewFairShare(store, FairShareConfig{Tenants: map[string]TenantShare{"gold": {Weight: 3}}})
+	if got := claimCounts(t, f, store, 8); got["gold"] != 6 || got["free"] != 2 {
+		t.Fatalf("weighted claims = %v, want gold 6 / free 2", got)
+	}
+
+	// 最低份额优先于权重：minor 权重低，但 Running 占比低于 0.3 时先被认领
+	store = NewJobStoreMem()
+	seedTenants(t, store, map[string]int{"big": 20, "minor": 20})
+	f = NewFairShare(store, FairShareConfig{Tenants: map[string]TenantShare{"big": {Weight: 9}, "minor": {Weight: 1, MinShare: 0.3}}})
+	if got := claimCounts(t, f, store, 10); got["minor"] != 3 {
+		t.Fatalf("min share claims = %v, want minor 3", got)
+	}
+}
+
+func TestFairShare_StarvationDetected(t *testing.T) {
+	ctx := context.Background()
+	store := NewJobStoreMem()
+	// gpu 租户的 Job 需要本调度器不具备的能力，只能一直等待
+	if _, err := store.Create(ctx, &Job{AgentID: "a", TenantID: "gpu", Goal: "g", RequiredCapabilities: []string{"gpu"}}); err != nil {
+		t.Fatal(err)
+	}
+	seedTenants(t, store, map[string]int{"cpu": 5})
+	now := time.Now()
+	f := NewFairShare(store, FairShareConfig{StarvationThreshold: time.Minute})
+	f.now = func() time.Time { return now }
+	if j, _ := f.Claim(ctx, store, "", []string{"cpu"}); j == nil || j.TenantID != "cpu" {
+		t.Fatalf("claim = %+v, want cpu job via fallback", j)
+	}
+	if f.starved["gpu"] {
+		t.Fatal("gpu should not be starved yet")
+	}
+	// 整体无认领（如槽位占满）不算饥饿
+	now = now.Add(2 * time.Minute)
+	if _, err := f.Order(ctx); err != nil || f.starved["gpu"] {
+		t.Fatalf("starved = %v, err = %v; idle scheduler should not flag starvation", f.starved, err)
+	}
+	if _, err := f.Claim(ctx, store, "", []string{"cpu"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Order(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !f.starved["gpu"] || f.starved["cpu"] {
+		t.Fatalf("starved = %v, want only gpu", f.starved)
+	}
+}
@@ -14,7 +14,11 @@
 
 package job
 
-import "time"
+import (
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
 
 // JobStatus 任务状态；与 design/job-state-machine.md 一致，可由事件流推导（DeriveStatusFromEvents）
 type JobStatus int
@@ -31,6 +35,8 @@ const (
 	StatusParked
 	// StatusRetrying failed后等待重试（可选显式状态）
 	StatusRetrying
+	// StatusDeferred 租户维护窗口内暂缓：新建 Job 或在安全边界暂停的 Job，scheduler 跳过；窗口结束后自动恢复为 Pending
+	StatusDeferred
 )
 
 func (s JobStatus) String() string {
@@ -51,11 +57,18 @@ func (s JobStatus) String() string {
 		return "parked"
 	case StatusRetrying:
 		return "retrying"
+	case StatusDeferred:
+		return "deferred"
 	default:
 		return "unknown"
 	}
 }
 
+// IsTerminal 是否为终态（Completed / Failed / Cancelled）
+func (s JobStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
 // Job Agent 任务实体：message 创建 Job，由 JobRunner 拉取并执行
 type Job struct {
 	ID        string
@@ -85,4 +98,12 @@ type Job struct {
 	ExecutionVersion string
 	// PlannerVersion Planner 版本（可选）；记录生成 Plan 时的 Planner 版本
 	PlannerVersion string
+	// Terminal 终态元数据（取消原因、发起者、失败节点、失败分类、补偿状态）；请求取消时即写入原因与发起者
+	Terminal *TerminalInfo
+	// Attribution 发起归属（创建 Job 的用户、客户端、原始请求 ID）；创建时写入，经 AttributingStore 随每条事件记录
+	Attribution *jobstore.Attribution
+	// Interactive 交互式对话 Job：由预留的 interactive 调度通道优先认领，并使用更紧的 step 超时（见 lane.go）
+	Interactive bool
+	// Profile 执行档位（如 conservative / aggressive，agent.execution_profiles）；提交时解析并持久化，Worker 据此设置并行、超时、审批等行为
+	Profile string
 }
@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"rag-platform/internal/agent/compat"
 	"rag-platform/internal/agent/runtime"
 	agentexec "rag-platform/internal/agent/runtime/executor"
 )
@@ -69,8 +70,9 @@ func (r *JobRunner) Start(ctx context.Context) {
 			if tenantID == "" {
 				tenantID = "default"
 			}
+			features, _ := compat.JobFeatures(j.RequiredCapabilities)
 			err := r.runner.RunForJob(runCtx, agent, &agentexec.JobForRunner{
-				ID: j.ID, AgentID: j.AgentID, Goal: j.Goal, Cursor: j.Cursor, TenantID: tenantID,
+				ID: j.ID, AgentID: j.AgentID, Goal: j.Goal, Cursor: j.Cursor, TenantID: tenantID, Features: features,
 			})
 			if err != nil {
 				_ = r.store.UpdateStatus(runCtx, j.ID, StatusFailed)
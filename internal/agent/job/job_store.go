@@ -16,10 +16,11 @@ package job
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"rag-platform/pkg/idgen"
 )
 
 // JobStore 任务存储：创建、查询、更新状态、拉取 Pending、更新恢复游标；多租户时 tenantID 过滤
@@ -28,6 +29,8 @@ type JobStore interface {
 	Get(ctx context.Context, jobID string) (*Job, error)
 	// GetByAgentAndIdempotencyKey 按 Agent 与幂等键查已有 Job，用于 Idempotency-Key 去重；无则返回 nil, nil；tenantID 为空时不过滤
 	GetByAgentAndIdempotencyKey(ctx context.Context, agentID, idempotencyKey string) (*Job, error)
+	// FindRecentByGoalHash 查同 Agent、同租户在 since 之后创建且目标哈希（GoalHash）相同的最新 Job，用于去重窗口；Failed/Cancelled 不参与；无则返回 nil, nil
+	FindRecentByGoalHash(ctx context.Context, agentID, tenantID, goalHash string, since time.Time) (*Job, error)
 	// ListByAgent 按 Agent 列出 Job；tenantID 非空时仅返回该租户下的 Job
 	ListByAgent(ctx context.Context, agentID string, tenantID string) ([]*Job, error)
 	UpdateStatus(ctx context.Context, jobID string, status JobStatus) error
@@ -73,13 +76,16 @@ type JobStoreMem struct {
 	byID    map[string]*Job
 	pending []string
 	cond    *sync.Cond
+	// transitions 每个 Job 已生效的状态迁移（见 TransitionLister）
+	transitions map[string][]Transition
 }
 
 // NewJobStoreMem 创建内存 JobStore
 func NewJobStoreMem() *JobStoreMem {
 	j := &JobStoreMem{
-		byID:    make(map[string]*Job),
-		pending: nil,
+		byID:        make(map[string]*Job),
+		pending:     nil,
+		transitions: make(map[string][]Transition),
 	}
 	j.cond = sync.NewCond(&j.mu)
 	return j
@@ -89,18 +95,23 @@ func (s *JobStoreMem) Create(ctx context.Context, job *Job) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if job.ID == "" {
-		job.ID = "job-" + uuid.New().String()
+		job.ID = "job-" + idgen.NewID()
 	}
 	if job.TenantID == "" {
 		job.TenantID = "default"
 	}
-	job.Status = StatusPending
+	// 仅允许以 Pending 或 Deferred（租户维护窗口内）创建；Deferred 不入 pending 队列，由 ResumeDeferred 入队
+	if job.Status != StatusDeferred {
+		job.Status = StatusPending
+	}
 	job.CreatedAt = time.Now()
 	job.UpdatedAt = job.CreatedAt
 	cp := *job
 	s.byID[job.ID] = &cp
-	s.pending = append(s.pending, job.ID)
-	s.cond.Signal()
+	if job.Status == StatusPending {
+		s.pending = append(s.pending, job.ID)
+		s.cond.Signal()
+	}
 	return job.ID, nil
 }
 
@@ -130,6 +141,34 @@ func (s *JobStoreMem) GetByAgentAndIdempotencyKey(ctx context.Context, agentID,
 	return nil, nil
 }
 
+func (s *JobStoreMem) FindRecentByGoalHash(ctx context.Context, agentID, tenantID, goalHash string, since time.Time) (*Job, error) {
+	if goalHash == "" {
+		return nil, nil
+	}
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest *Job
+	for _, j := range s.byID {
+		if j.AgentID != agentID || j.TenantID != tenantID || j.CreatedAt.Before(since) || !dedupEligible(j.Status) {
+			continue
+		}
+		if latest != nil && !j.CreatedAt.After(latest.CreatedAt) {
+			continue
+		}
+		if GoalHash(j.Goal) == goalHash {
+			latest = j
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	cp := *latest
+	return &cp, nil
+}
+
 func (s *JobStoreMem) ListByAgent(ctx context.Context, agentID string, tenantID string) ([]*Job, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -147,18 +186,123 @@ func (s *JobStoreMem) ListByAgent(ctx context.Context, agentID string, tenantID
 	return list, nil
 }
 
+// ListUpdatedSince 实现 RecentJobLister
+func (s *JobStoreMem) ListUpdatedSince(ctx context.Context, tenantID string, since time.Time, limit int) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*Job
+	for _, j := range s.byID {
+		if tenantID != "" && j.TenantID != tenantID {
+			continue
+		}
+		if j.UpdatedAt.Before(since) {
+			continue
+		}
+		cp := *j
+		list = append(list, &cp)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].UpdatedAt.After(list[b].UpdatedAt) })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// ListTerminalAfter 实现 TerminalJobScanner
+func (s *JobStoreMem) ListTerminalAfter(ctx context.Context, after time.Time, afterID string, until time.Time, limit int) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*Job
+	for _, j := range s.byID {
+		if !j.Status.IsTerminal() || !j.UpdatedAt.Before(until) {
+			continue
+		}
+		if j.UpdatedAt.Before(after) || (j.UpdatedAt.Equal(after) && j.ID <= afterID) {
+			continue
+		}
+		cp := *j
+		list = append(list, &cp)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].UpdatedAt.Equal(list[b].UpdatedAt) {
+			return list[a].UpdatedAt.Before(list[b].UpdatedAt)
+		}
+		return list[a].ID < list[b].ID
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// ListActive 实现 ActiveJobLister
+func (s *JobStoreMem) ListActive(ctx context.Context, limit int) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*Job
+	for _, j := range s.byID {
+		switch j.Status {
+		case StatusCompleted, StatusFailed, StatusCancelled:
+			continue
+		}
+		cp := *j
+		list = append(list, &cp)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].UpdatedAt.Before(list[b].UpdatedAt) })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
 func (s *JobStoreMem) UpdateStatus(ctx context.Context, jobID string, status JobStatus) error {
+	if status != StatusPending {
+		return s.transition(ctx, jobID, status, nil)
+	}
+	// 置回 Pending（signal / 审阅唤醒等）须重新入队，否则 ClaimNextPending 认领不到
+	return s.transition(ctx, jobID, status, func(j *Job) {
+		for _, id := range s.pending {
+			if id == jobID {
+				return
+			}
+		}
+		s.pending = append(s.pending, jobID)
+		s.cond.Signal()
+	})
+}
+
+// transition 按状态机校验并迁移到 to，apply 可选（在锁内随迁移一并修改 Job）；Job 不存在时静默。
+// 非同状态的迁移与 status 在同一把锁内记录
+func (s *JobStoreMem) transition(ctx context.Context, jobID string, to JobStatus, apply func(j *Job)) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	j, ok := s.byID[jobID]
 	if !ok {
 		return nil
 	}
-	j.Status = status
+	t, err := ValidateTransition(ctx, j, to)
+	if err != nil {
+		return err
+	}
+	j.Status = to
 	j.UpdatedAt = time.Now()
+	if apply != nil {
+		apply(j)
+	}
+	if t.From != t.To {
+		t.At = j.UpdatedAt
+		s.transitions[jobID] = append(s.transitions[jobID], t)
+	}
 	return nil
 }
 
+// ListTransitions 实现 TransitionLister
+func (s *JobStoreMem) ListTransitions(ctx context.Context, jobID string) ([]Transition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Transition(nil), s.transitions[jobID]...), nil
+}
+
 func (s *JobStoreMem) UpdateCursor(ctx context.Context, jobID string, cursor string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -180,6 +324,46 @@ func (s *JobStoreMem) ClaimNextPendingFromQueue(ctx context.Context, queueClass
 }
 
 func (s *JobStoreMem) ClaimNextPendingForWorker(ctx context.Context, queueClass string, workerCapabilities []string, tenantID string) (*Job, error) {
+	return s.claimBest(func(j *Job) bool {
+		if tenantID != "" && j.TenantID != tenantID {
+			return false
+		}
+		if queueClass != "" && j.QueueClass != "" && j.QueueClass != queueClass {
+			return false
+		}
+		return jobMatchesCapabilities(j.RequiredCapabilities, workerCapabilities)
+	})
+}
+
+// ClaimNextInteractive 实现 InteractiveClaimer；仅认领交互式 Job
+func (s *JobStoreMem) ClaimNextInteractive(ctx context.Context, workerCapabilities []string) (*Job, error) {
+	return s.claimBest(func(j *Job) bool {
+		return j.Interactive && jobMatchesCapabilities(j.RequiredCapabilities, workerCapabilities)
+	})
+}
+
+// TenantLoads 实现 TenantLoadReader
+func (s *JobStoreMem) TenantLoads(ctx context.Context) (map[string]TenantLoad, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]TenantLoad)
+	for _, j := range s.byID {
+		if j.Status != StatusPending && j.Status != StatusRunning {
+			continue
+		}
+		l := out[tenantOf(j)]
+		if j.Status == StatusPending {
+			l.Pending++
+		} else {
+			l.Running++
+		}
+		out[tenantOf(j)] = l
+	}
+	return out, nil
+}
+
+// claimBest 在满足 match 的 Pending 中认领优先级最高者（同优先级按入队顺序）
+func (s *JobStoreMem) claimBest(match func(*Job) bool) (*Job, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	var bestID string
@@ -190,13 +374,7 @@ func (s *JobStoreMem) ClaimNextPendingForWorker(ctx context.Context, queueClass
 		if !ok || j.Status != StatusPending {
 			continue
 		}
-		if tenantID != "" && j.TenantID != tenantID {
-			continue
-		}
-		if queueClass != "" && j.QueueClass != "" && j.QueueClass != queueClass {
-			continue
-		}
-		if !jobMatchesCapabilities(j.RequiredCapabilities, workerCapabilities) {
+		if !match(j) {
 			continue
 		}
 		if bestIdx < 0 || j.Priority > bestPriority {
@@ -221,29 +399,36 @@ func (s *JobStoreMem) Requeue(ctx context.Context, job *Job) error {
 	if job == nil {
 		return nil
 	}
+	return s.transition(ctx, job.ID, StatusPending, func(j *Job) {
+		j.RetryCount = job.RetryCount + 1
+		s.pending = append(s.pending, job.ID)
+		s.cond.Signal()
+	})
+}
+
+func (s *JobStoreMem) RequestCancel(ctx context.Context, jobID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	j, ok := s.byID[job.ID]
+	j, ok := s.byID[jobID]
 	if !ok {
 		return nil
 	}
-	j.RetryCount = job.RetryCount + 1
-	j.Status = StatusPending
-	j.UpdatedAt = time.Now()
-	s.pending = append(s.pending, job.ID)
-	s.cond.Signal()
+	j.CancelRequestedAt = time.Now()
+	j.UpdatedAt = j.CancelRequestedAt
 	return nil
 }
 
-func (s *JobStoreMem) RequestCancel(ctx context.Context, jobID string) error {
+// SetTerminalInfo 实现 TerminalInfoStore
+func (s *JobStoreMem) SetTerminalInfo(ctx context.Context, jobID string, info *TerminalInfo) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	j, ok := s.byID[jobID]
 	if !ok {
 		return nil
 	}
-	j.CancelRequestedAt = time.Now()
-	j.UpdatedAt = j.CancelRequestedAt
+	cp := *info
+	j.Terminal = &cp
+	j.UpdatedAt = time.Now()
 	return nil
 }
 
@@ -285,3 +470,65 @@ func (s *JobStoreMem) WaitNextPending(ctx context.Context) (*Job, error) {
 		return &cp, nil
 	}
 }
+
+// DeferPending 将租户下 Pending 的 Job 置为 Deferred 并移出 pending 队列（维护窗口生效）
+func (s *JobStoreMem) DeferPending(ctx context.Context, tenantID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	n := 0
+	kept := s.pending[:0]
+	for _, id := range s.pending {
+		j, ok := s.byID[id]
+		if ok && j.Status == StatusPending && j.TenantID == tenantID {
+			j.Status = StatusDeferred
+			j.UpdatedAt = now
+			n++
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.pending = kept
+	return n, nil
+}
+
+// ResumeDeferred 将租户下 Deferred 的 Job 恢复为 Pending 并重新入队（维护窗口结束）
+func (s *JobStoreMem) ResumeDeferred(ctx context.Context, tenantID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var ids []string
+	for id, j := range s.byID {
+		if j.Status == StatusDeferred && j.TenantID == tenantID {
+			ids = append(ids, id)
+		}
+	}
+	// 按创建时间恢复，保持原有先后顺序
+	sort.Slice(ids, func(a, b int) bool { return s.byID[ids[a]].CreatedAt.Before(s.byID[ids[b]].CreatedAt) })
+	for _, id := range ids {
+		j := s.byID[id]
+		j.Status = StatusPending
+		j.UpdatedAt = now
+		s.pending = append(s.pending, id)
+	}
+	if len(ids) > 0 {
+		s.cond.Broadcast()
+	}
+	return len(ids), nil
+}
+
+// ListTenantsWithDeferred 返回存在 Deferred Job 的租户
+func (s *JobStoreMem) ListTenantsWithDeferred(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	var out []string
+	for _, j := range s.byID {
+		if j.Status == StatusDeferred && !seen[j.TenantID] {
+			seen[j.TenantID] = true
+			out = append(out, j.TenantID)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -121,6 +121,27 @@ func TestJobStoreMem_Requeue(t *testing.T) {
 	}
 }
 
+func TestJobStoreMem_UpdateStatusPendingRequeues(t *testing.T) {
+	ctx := context.Background()
+	s := NewJobStoreMem()
+	id, _ := s.Create(ctx, &Job{AgentID: "a1", Goal: "g"})
+	_, _ = s.ClaimNextPending(ctx)
+	if err := s.UpdateStatus(ctx, id, StatusWaiting); err != nil {
+		t.Fatal(err)
+	}
+	// signal 唤醒：Waiting → Pending 后应能再次被 Claim，且只入队一次
+	if err := s.UpdateStatus(ctx, id, StatusPending); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.UpdateStatus(ctx, id, StatusPending)
+	if j, _ := s.ClaimNextPending(ctx); j == nil || j.ID != id {
+		t.Fatalf("woken job not claimable: %+v", j)
+	}
+	if j, _ := s.ClaimNextPending(ctx); j != nil {
+		t.Fatalf("job enqueued twice: %+v", j)
+	}
+}
+
 func TestJobStoreMem_Get_NotFound(t *testing.T) {
 	ctx := context.Background()
 	s := NewJobStoreMem()
@@ -0,0 +1,71 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// 调度通道：interactive 为交互式对话预留的低延迟通道，batch 为其余 Job 共享的常规通道
+const (
+	LaneInteractive = "interactive"
+	LaneBatch       = "batch"
+)
+
+// InteractiveClaimer 可选：仅认领 Interactive=true 的 Pending Job，供预留通道使用；workerCapabilities 语义同 ClaimNextPendingForWorker。实现：JobStoreMem、JobStorePg
+type InteractiveClaimer interface {
+	ClaimNextInteractive(ctx context.Context, workerCapabilities []string) (*Job, error)
+}
+
+// MarkInteractive 将 Job 标记为交互式：进入 realtime 队列并使用 realtime 优先级，常规通道中也先于批处理 Job 被认领
+func MarkInteractive(j *Job) {
+	if j == nil {
+		return
+	}
+	j.Interactive = true
+	j.QueueClass = QueueRealtime
+	j.Priority = PriorityRealtime
+}
+
+// LaneOf 返回 Job 所属调度通道
+func LaneOf(j *Job) string {
+	if j != nil && j.Interactive {
+		return LaneInteractive
+	}
+	return LaneBatch
+}
+
+// ObserveLaneClaimed 记录 Job 被认领时的排队时间
+func ObserveLaneClaimed(j *Job) {
+	if j == nil || j.CreatedAt.IsZero() {
+		return
+	}
+	metrics.LaneQueueWaitSeconds.WithLabelValues(LaneOf(j)).Observe(time.Since(j.CreatedAt).Seconds())
+}
+
+// ObserveLaneFinished 记录 Job 到达终态时的端到端耗时与通道吞吐；status 为终态字符串（completed / failed / cancelled）
+func ObserveLaneFinished(j *Job, status string) {
+	if j == nil {
+		return
+	}
+	lane := LaneOf(j)
+	metrics.LaneJobsFinishedTotal.WithLabelValues(lane, status).Inc()
+	if !j.CreatedAt.IsZero() {
+		metrics.LaneJobLatencySeconds.WithLabelValues(lane).Observe(time.Since(j.CreatedAt).Seconds())
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestJobStoreMem_ClaimNextInteractive(t *testing.T) {
+	ctx := context.Background()
+	store := NewJobStoreMem()
+	batchID, _ := store.Create(ctx, &Job{AgentID: "a1", Goal: "batch"})
+	chat := &Job{AgentID: "a1", Goal: "chat"}
+	MarkInteractive(chat)
+	chatID, _ := store.Create(ctx, chat)
+
+	j, err := store.ClaimNextInteractive(ctx, nil)
+	if err != nil || j == nil || j.ID != chatID {
+		t.Fatalf("ClaimNextInteractive = %+v, %v; want %s", j, err, chatID)
+	}
+	if LaneOf(j) != LaneInteractive {
+		t.Errorf("LaneOf = %s", LaneOf(j))
+	}
+	// 仅剩批处理 Job：预留通道不认领
+	if j, _ := store.ClaimNextInteractive(ctx, nil); j != nil {
+		t.Fatalf("batch job claimed by interactive lane: %+v", j)
+	}
+	if j, _ := store.ClaimNextPending(ctx); j == nil || j.ID != batchID {
+		t.Fatalf("ClaimNextPending = %+v, want %s", j, batchID)
+	}
+}
+
+func TestJobStoreMem_InteractiveFirstInSharedLane(t *testing.T) {
+	ctx := context.Background()
+	store := NewJobStoreMem()
+	_, _ = store.Create(ctx, &Job{AgentID: "a1", Goal: "batch"})
+	chat := &Job{AgentID: "a1", Goal: "chat"}
+	MarkInteractive(chat)
+	chatID, _ := store.Create(ctx, chat)
+	if j, _ := store.ClaimNextPendingForWorker(ctx, "", nil, ""); j == nil || j.ID != chatID {
+		t.Fatalf("shared lane claimed %+v, want interactive %s first", j, chatID)
+	}
+}
+
+func TestScheduler_InteractiveReservedLane(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewJobStoreMem()
+	// 常规槽位被长时间运行的批处理 Job 占满
+	batchID, _ := store.Create(ctx, &Job{AgentID: "a1", Goal: "batch"})
+	release := make(chan struct{})
+	runJob := func(_ context.Context, j *Job) error {
+		if j.ID == batchID {
+			<-release
+		}
+		return nil
+	}
+	sched := NewScheduler(store, runJob, SchedulerConfig{MaxConcurrency: 1, InteractiveReserved: 1})
+	sched.Start(ctx)
+	defer sched.Stop()
+	defer close(release)
+	waitStatus(t, store, batchID, StatusRunning)
+
+	chat := &Job{AgentID: "a1", Goal: "chat"}
+	MarkInteractive(chat)
+	chatID, _ := store.Create(ctx, chat)
+	waitStatus(t, store, chatID, StatusCompleted)
+}
+
+func waitStatus(t *testing.T, store JobStore, id string, want JobStatus) {
+	t.Helper()
+	for i := 0; i < 60; i++ {
+		if j, _ := store.Get(context.Background(), id); j != nil && j.Status == want {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	j, _ := store.Get(context.Background(), id)
+	t.Fatalf("job %s status = %v, want %v", id, j.Status, want)
+}
@@ -0,0 +1,294 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rag-platform/pkg/metrics"
+)
+
+// ErrMaintenanceWindowNotFound 维护窗口不存在或不属于该租户
+var ErrMaintenanceWindowNotFound = errors.New("job: maintenance window not found")
+
+// MaintenanceWindow 租户维护窗口：窗口内新建 Job 进入 Deferred；ParkRunning 为 true 时运行中的 Job 在下一个 step 边界暂停，否则允许执行完成
+type MaintenanceWindow struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Reason      string    `json:"reason,omitempty"`
+	ParkRunning bool      `json:"park_running"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ActiveAt 判断 t 是否落在窗口内（[StartsAt, EndsAt)）
+func (w *MaintenanceWindow) ActiveAt(t time.Time) bool {
+	return w != nil && !t.Before(w.StartsAt) && t.Before(w.EndsAt)
+}
+
+// MaintenanceStore 维护窗口存储
+type MaintenanceStore interface {
+	Create(ctx context.Context, w *MaintenanceWindow) (string, error)
+	// List 列出租户的窗口（按 StartsAt 升序）；tenantID 为空时列出全部
+	List(ctx context.Context, tenantID string) ([]*MaintenanceWindow, error)
+	// Delete 删除窗口（提前结束或取消）；不存在返回 ErrMaintenanceWindowNotFound
+	Delete(ctx context.Context, tenantID, id string) error
+	// ListActive 返回 now 时刻生效中的窗口
+	ListActive(ctx context.Context, now time.Time) ([]*MaintenanceWindow, error)
+}
+
+// DeferredJobStore 维护窗口所需的批量状态迁移（JobStoreMem / JobStorePg 实现，可选）
+type DeferredJobStore interface {
+	// DeferPending 将租户下 Pending 的 Job 置为 Deferred，返回迁移数量
+	DeferPending(ctx context.Context, tenantID string) (int, error)
+	// ResumeDeferred 将租户下 Deferred 的 Job 恢复为 Pending 并重新入队，返回恢复数量
+	ResumeDeferred(ctx context.Context, tenantID string) (int, error)
+	// ListTenantsWithDeferred 返回存在 Deferred Job 的租户
+	ListTenantsWithDeferred(ctx context.Context) ([]string, error)
+}
+
+// MaintenanceStoreMem 内存实现
+type MaintenanceStoreMem struct {
+	mu   sync.RWMutex
+	byID map[string]*MaintenanceWindow
+}
+
+// NewMaintenanceStoreMem 创建内存维护窗口存储
+func NewMaintenanceStoreMem() *MaintenanceStoreMem {
+	return &MaintenanceStoreMem{byID: make(map[string]*MaintenanceWindow)}
+}
+
+func (s *MaintenanceStoreMem) Create(ctx context.Context, w *MaintenanceWindow) (string, error) {
+	if w == nil {
+		return "", errors.New("maintenance window is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *w
+	if cp.ID == "" {
+		cp.ID = "mw-" + uuid.New().String()
+	}
+	if cp.TenantID == "" {
+		cp.TenantID = "default"
+	}
+	if cp.CreatedAt.IsZero() {
+		cp.CreatedAt = time.Now()
+	}
+	s.byID[cp.ID] = &cp
+	return cp.ID, nil
+}
+
+func (s *MaintenanceStoreMem) List(ctx context.Context, tenantID string) ([]*MaintenanceWindow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*MaintenanceWindow
+	for _, w := range s.byID {
+		if tenantID != "" && w.TenantID != tenantID {
+			continue
+		}
+		cp := *w
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartsAt.Before(out[j].StartsAt) })
+	return out, nil
+}
+
+func (s *MaintenanceStoreMem) Delete(ctx context.Context, tenantID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.byID[id]
+	if !ok || (tenantID != "" && w.TenantID != tenantID) {
+		return ErrMaintenanceWindowNotFound
+	}
+	delete(s.byID, id)
+	return nil
+}
+
+func (s *MaintenanceStoreMem) ListActive(ctx context.Context, now time.Time) ([]*MaintenanceWindow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*MaintenanceWindow
+	for _, w := range s.byID {
+		if w.ActiveAt(now) {
+			cp := *w
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+// MaintenanceGate 判断租户当前是否处于维护窗口；结果按 ttl 缓存，避免每次 Claim / step 边界都查库
+type MaintenanceGate struct {
+	store MaintenanceStore
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	active   map[string]*MaintenanceWindow // tenant_id -> 生效窗口（多个时取 ParkRunning 优先、EndsAt 最晚）
+}
+
+// NewMaintenanceGate 创建 Gate；ttl<=0 时默认 5s
+func NewMaintenanceGate(store MaintenanceStore, ttl time.Duration) *MaintenanceGate {
+	if ttl <= 0 {
+		ttl = 5 * time.Second
+	}
+	return &MaintenanceGate{store: store, ttl: ttl}
+}
+
+// Active 返回租户当前生效的窗口；无则 nil
+func (g *MaintenanceGate) Active(ctx context.Context, tenantID string) *MaintenanceWindow {
+	if g == nil || g.store == nil {
+		return nil
+	}
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active == nil || now.Sub(g.loadedAt) >= g.ttl {
+		windows, err := g.store.ListActive(ctx, now)
+		if err == nil {
+			g.active = make(map[string]*MaintenanceWindow)
+			for _, w := range windows {
+				cur := g.active[w.TenantID]
+				if cur == nil || (w.ParkRunning && !cur.ParkRunning) || (w.ParkRunning == cur.ParkRunning && w.EndsAt.After(cur.EndsAt)) {
+					g.active[w.TenantID] = w
+				}
+			}
+			g.loadedAt = now
+		}
+	}
+	w := g.active[tenantID]
+	if !w.ActiveAt(now) {
+		return nil
+	}
+	return w
+}
+
+// InMaintenance 租户是否处于维护窗口（新建 Job 应 Deferred，不应再 Claim）
+func (g *MaintenanceGate) InMaintenance(ctx context.Context, tenantID string) bool {
+	return g.Active(ctx, tenantID) != nil
+}
+
+// ShouldPark 运行中的 Job 是否应在 step 边界暂停
+func (g *MaintenanceGate) ShouldPark(ctx context.Context, tenantID string) bool {
+	w := g.Active(ctx, tenantID)
+	return w != nil && w.ParkRunning
+}
+
+// Invalidate 清除缓存（窗口增删后由 API 调用，使变更立即生效）
+func (g *MaintenanceGate) Invalidate() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.active = nil
+	g.mu.Unlock()
+}
+
+// MaintenanceController 周期性推进窗口状态：窗口生效时将租户 Pending Job 置为 Deferred，窗口结束后恢复 Deferred Job 为 Pending
+type MaintenanceController struct {
+	windows  MaintenanceStore
+	jobs     DeferredJobStore
+	interval time.Duration
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewMaintenanceController 创建控制器；interval<=0 时默认 15s
+func NewMaintenanceController(windows MaintenanceStore, jobs DeferredJobStore, interval time.Duration) *MaintenanceController {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return &MaintenanceController{windows: windows, jobs: jobs, interval: interval, stopCh: make(chan struct{})}
+}
+
+// Reconcile 执行一次窗口推进，返回 (deferred, resumed) 数量
+func (c *MaintenanceController) Reconcile(ctx context.Context) (int, int, error) {
+	active, err := c.windows.ListActive(ctx, time.Now())
+	if err != nil {
+		return 0, 0, err
+	}
+	inWindow := make(map[string]bool, len(active))
+	for _, w := range active {
+		inWindow[w.TenantID] = true
+	}
+	var deferred, resumed int
+	for tenantID := range inWindow {
+		n, err := c.jobs.DeferPending(ctx, tenantID)
+		if err != nil {
+			return deferred, resumed, err
+		}
+		deferred += n
+		if n > 0 {
+			metrics.MaintenanceDeferredTotal.WithLabelValues(tenantID, "deferred").Add(float64(n))
+		}
+	}
+	tenants, err := c.jobs.ListTenantsWithDeferred(ctx)
+	if err != nil {
+		return deferred, resumed, err
+	}
+	for _, tenantID := range tenants {
+		if inWindow[tenantID] {
+			continue
+		}
+		n, err := c.jobs.ResumeDeferred(ctx, tenantID)
+		if err != nil {
+			return deferred, resumed, err
+		}
+		resumed += n
+		if n > 0 {
+			metrics.MaintenanceDeferredTotal.WithLabelValues(tenantID, "resumed").Add(float64(n))
+		}
+	}
+	metrics.MaintenanceWindowActive.Reset()
+	for tenantID := range inWindow {
+		metrics.MaintenanceWindowActive.WithLabelValues(tenantID).Set(1)
+	}
+	return deferred, resumed, nil
+}
+
+// Start 启动后台循环
+func (c *MaintenanceController) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			_, _, _ = c.Reconcile(ctx)
+			select {
+			case <-c.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(c.interval):
+			}
+		}
+	}()
+}
+
+// Stop 优雅退出：关闭 stopCh，等待后台 goroutine 结束
+func (c *MaintenanceController) Stop() {
+	close(c.stopCh)
+	c.wg.Wait()
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MaintenanceStorePg PostgreSQL 实现，使用 maintenance_windows 表
+type MaintenanceStorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewMaintenanceStorePg 创建基于 PostgreSQL 的维护窗口存储
+func NewMaintenanceStorePg(pool *pgxpool.Pool) *MaintenanceStorePg {
+	return &MaintenanceStorePg{pool: pool}
+}
+
+const maintenanceColumns = `id, tenant_id, starts_at, ends_at, COALESCE(reason, ''), park_running, created_at`
+
+func (s *MaintenanceStorePg) Create(ctx context.Context, w *MaintenanceWindow) (string, error) {
+	if w == nil {
+		return "", errors.New("maintenance window is nil")
+	}
+	id := w.ID
+	if id == "" {
+		id = "mw-" + uuid.New().String()
+	}
+	tenantID := w.TenantID
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO maintenance_windows (id, tenant_id, starts_at, ends_at, reason, park_running) VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, tenantID, w.StartsAt, w.EndsAt, nullStr(w.Reason), w.ParkRunning)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *MaintenanceStorePg) List(ctx context.Context, tenantID string) ([]*MaintenanceWindow, error) {
+	query := `SELECT ` + maintenanceColumns + ` FROM maintenance_windows`
+	args := []interface{}{}
+	if tenantID != "" {
+		query += ` WHERE tenant_id = $1`
+		args = append(args, tenantID)
+	}
+	query += ` ORDER BY starts_at`
+	return s.query(ctx, query, args...)
+}
+
+func (s *MaintenanceStorePg) Delete(ctx context.Context, tenantID, id string) error {
+	cmd, err := s.pool.Exec(ctx,
+		`DELETE FROM maintenance_windows WHERE id = $1 AND ($2 = '' OR tenant_id = $2)`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrMaintenanceWindowNotFound
+	}
+	return nil
+}
+
+func (s *MaintenanceStorePg) ListActive(ctx context.Context, now time.Time) ([]*MaintenanceWindow, error) {
+	return s.query(ctx,
+		`SELECT `+maintenanceColumns+` FROM maintenance_windows WHERE starts_at <= $1 AND ends_at > $1`, now)
+}
+
+func (s *MaintenanceStorePg) query(ctx context.Context, query string, args ...interface{}) ([]*MaintenanceWindow, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*MaintenanceWindow
+	for rows.Next() {
+		var w MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.TenantID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.ParkRunning, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &w)
+	}
+	return out, rows.Err()
+}
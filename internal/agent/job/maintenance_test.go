@@ -0,0 +1,128 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceGate_ActiveWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMaintenanceStoreMem()
+	now := time.Now()
+	_, _ = store.Create(ctx, &MaintenanceWindow{TenantID: "t1", StartsAt: now.Add(-time.Minute), EndsAt: now.Add(time.Hour)})
+	_, _ = store.Create(ctx, &MaintenanceWindow{TenantID: "t2", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour), ParkRunning: true})
+	gate := NewMaintenanceGate(store, time.Minute)
+	if !gate.InMaintenance(ctx, "t1") {
+		t.Error("t1 should be in maintenance")
+	}
+	if gate.ShouldPark(ctx, "t1") {
+		t.Error("t1 window does not park running jobs")
+	}
+	if gate.InMaintenance(ctx, "t2") {
+		t.Error("t2 window has not started yet")
+	}
+	var nilGate *MaintenanceGate
+	if nilGate.InMaintenance(ctx, "t1") {
+		t.Error("nil gate should never report maintenance")
+	}
+}
+
+func TestMaintenanceStoreMem_DeleteChecksTenant(t *testing.T) {
+	ctx := context.Background()
+	store := NewMaintenanceStoreMem()
+	id, _ := store.Create(ctx, &MaintenanceWindow{TenantID: "t1", StartsAt: time.Now(), EndsAt: time.Now().Add(time.Hour)})
+	if err := store.Delete(ctx, "t2", id); err != ErrMaintenanceWindowNotFound {
+		t.Fatalf("Delete other tenant: err = %v, want ErrMaintenanceWindowNotFound", err)
+	}
+	if err := store.Delete(ctx, "t1", id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	list, _ := store.List(ctx, "t1")
+	if len(list) != 0 {
+		t.Fatalf("List after delete = %d windows", len(list))
+	}
+}
+
+func TestMaintenanceController_DefersAndResumes(t *testing.T) {
+	ctx := context.Background()
+	jobs := NewJobStoreMem()
+	windows := NewMaintenanceStoreMem()
+	inWindow, _ := jobs.Create(ctx, &Job{AgentID: "a1", TenantID: "t1", Goal: "g"})
+	other, _ := jobs.Create(ctx, &Job{AgentID: "a1", TenantID: "t2", Goal: "g"})
+	wid, _ := windows.Create(ctx, &MaintenanceWindow{TenantID: "t1", StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour)})
+	ctrl := NewMaintenanceController(windows, jobs, time.Second)
+
+	deferred, resumed, err := ctrl.Reconcile(ctx)
+	if err != nil || deferred != 1 || resumed != 0 {
+		t.Fatalf("Reconcile in window = (%d, %d, %v), want (1, 0, nil)", deferred, resumed, err)
+	}
+	if j, _ := jobs.Get(ctx, inWindow); j.Status != StatusDeferred {
+		t.Errorf("job in window status = %v, want deferred", j.Status)
+	}
+	if j, _ := jobs.Get(ctx, other); j.Status != StatusPending {
+		t.Errorf("other tenant status = %v, want pending", j.Status)
+	}
+	// 窗口内不应被认领
+	claimed, _ := jobs.ClaimNextPendingForWorker(ctx, "", nil, "t1")
+	if claimed != nil {
+		t.Fatalf("deferred job should not be claimable, got %s", claimed.ID)
+	}
+
+	_ = windows.Delete(ctx, "t1", wid)
+	deferred, resumed, err = ctrl.Reconcile(ctx)
+	if err != nil || deferred != 0 || resumed != 1 {
+		t.Fatalf("Reconcile after window = (%d, %d, %v), want (0, 1, nil)", deferred, resumed, err)
+	}
+	claimed, _ = jobs.ClaimNextPendingForWorker(ctx, "", nil, "t1")
+	if claimed == nil || claimed.ID != inWindow {
+		t.Fatalf("resumed job should be claimable, got %v", claimed)
+	}
+}
+
+func TestScheduler_MaintenanceDefersClaimedJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewJobStoreMem()
+	windows := NewMaintenanceStoreMem()
+	_, _ = windows.Create(ctx, &MaintenanceWindow{TenantID: "t1", StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour)})
+	id, _ := store.Create(ctx, &Job{AgentID: "a1", TenantID: "t1", Goal: "g"})
+	var runCount int32
+	sched := NewScheduler(store, func(_ context.Context, j *Job) error {
+		atomic.AddInt32(&runCount, 1)
+		return nil
+	}, SchedulerConfig{MaxConcurrency: 1, Backoff: 10 * time.Millisecond})
+	sched.SetMaintenanceGate(NewMaintenanceGate(windows, time.Minute))
+	sched.Start(ctx)
+	defer sched.Stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		j, _ := store.Get(ctx, id)
+		if j != nil && j.Status == StatusDeferred {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	j, _ := store.Get(ctx, id)
+	if j.Status != StatusDeferred {
+		t.Fatalf("status = %v, want deferred", j.Status)
+	}
+	if n := atomic.LoadInt32(&runCount); n != 0 {
+		t.Fatalf("runJob called %d times during maintenance", n)
+	}
+}
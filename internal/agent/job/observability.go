@@ -28,3 +28,19 @@ type ObservabilityReader interface {
 	// CountByStatus 返回各状态的 Job 数量，用于 job_state gauge（P0 SLO）；key 为 status 字符串（pending/running/waiting/parked/completed/failed/cancelled）
 	CountByStatus(ctx context.Context) (map[string]int64, error)
 }
+
+// RecentJobLister 可选：列出 updated_at 不早于 since 的 Job（跨 Agent），供瓶颈分析等窗口聚合；按 updated_at 倒序，limit<=0 表示不限。实现：JobStoreMem、JobStorePg
+type RecentJobLister interface {
+	ListUpdatedSince(ctx context.Context, tenantID string, since time.Time, limit int) ([]*Job, error)
+}
+
+// TerminalJobScanner 可选：按 (updated_at, id) 升序列出 (updated_at, id) 大于 (after, afterID) 且 updated_at 早于 until 的终态 Job（跨租户），
+// 供仓库导出等增量任务按水位线键集续扫；limit<=0 表示不限。实现：JobStoreMem、JobStorePg
+type TerminalJobScanner interface {
+	ListTerminalAfter(ctx context.Context, after time.Time, afterID string, until time.Time, limit int) ([]*Job, error)
+}
+
+// ActiveJobLister 可选：列出非终态（非 Completed/Failed/Cancelled）的 Job，供漂移对账等周期任务；按 updated_at 升序，limit<=0 表示不限。实现：JobStoreMem、JobStorePg
+type ActiveJobLister interface {
+	ListActive(ctx context.Context, limit int) ([]*Job, error)
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPageCursor 分页游标无法解析
+var ErrInvalidPageCursor = errors.New("job: invalid page cursor")
+
+// MaxPageLimit 单页最多返回的 Job 数
+const MaxPageLimit = 200
+
+// PageQuery 服务端分页查询：按 created_at 倒序（同一时刻按 id 倒序）的键集分页，条件间为 AND；空条件不过滤
+type PageQuery struct {
+	TenantID string
+	AgentIDs []string
+	Statuses []JobStatus
+	From     time.Time // created_at >= From；零值不限
+	To       time.Time // created_at < To；零值不限
+	Limit    int       // <=0 时默认 50，上限 MaxPageLimit
+	Cursor   string    // 上一页的 NextCursor；空为第一页
+}
+
+// Page 一页结果；NextCursor 为空表示没有更多
+type Page struct {
+	Jobs       []*Job
+	NextCursor string
+}
+
+// PageLister 可选：服务端分页列出 Job，供 Trace 概览等在单 Agent 数万 Job 时仍可用。实现：JobStoreMem、JobStorePg
+type PageLister interface {
+	ListPage(ctx context.Context, q PageQuery) (*Page, error)
+}
+
+// ParseStatus 解析 JobStatus.String() 的结果
+func ParseStatus(s string) (JobStatus, bool) {
+	for st := StatusPending; st <= StatusDeferred; st++ {
+		if st.String() == s {
+			return st, true
+		}
+	}
+	return 0, false
+}
+
+func (q PageQuery) limit() int {
+	if q.Limit <= 0 {
+		return 50
+	}
+	return min(q.Limit, MaxPageLimit)
+}
+
+type pageCursor struct {
+	createdAt time.Time
+	id        string
+}
+
+// encodePageCursor 游标为 base64("<created_at unix nano>|<id>")，对调用方不透明
+func encodePageCursor(j *Job) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(j.CreatedAt.UnixNano(), 10) + "|" + j.ID))
+}
+
+func decodePageCursor(s string) (*pageCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidPageCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidPageCursor
+	}
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidPageCursor
+	}
+	return &pageCursor{createdAt: time.Unix(0, n), id: id}, nil
+}
+
+// before 是否排在游标之后（created_at 倒序、id 倒序）
+func (c *pageCursor) before(j *Job) bool {
+	if c == nil {
+		return true
+	}
+	if !j.CreatedAt.Equal(c.createdAt) {
+		return j.CreatedAt.Before(c.createdAt)
+	}
+	return j.ID < c.id
+}
+
+// matches 是否满足除游标外的查询条件
+func (q PageQuery) matches(j *Job) bool {
+	if q.TenantID != "" && j.TenantID != q.TenantID {
+		return false
+	}
+	if len(q.AgentIDs) > 0 && !slices.Contains(q.AgentIDs, j.AgentID) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, j.Status) {
+		return false
+	}
+	if !q.From.IsZero() && j.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !j.CreatedAt.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// PageOf 在内存中对 list 按 q 过滤、排序并分页；供内存实现与未实现 PageLister 的 JobStore 回退使用（list 不会被修改）
+func PageOf(list []*Job, q PageQuery) (*Page, error) {
+	cur, err := decodePageCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Job
+	for _, j := range list {
+		if q.matches(j) && cur.before(j) {
+			matched = append(matched, j)
+		}
+	}
+	sort.Slice(matched, func(a, b int) bool {
+		if !matched[a].CreatedAt.Equal(matched[b].CreatedAt) {
+			return matched[a].CreatedAt.After(matched[b].CreatedAt)
+		}
+		return matched[a].ID > matched[b].ID
+	})
+	return pageFrom(matched, q.limit()), nil
+}
+
+// pageFrom rows 已排序且最多比 limit 多一条；多出的一条表示还有下一页
+func pageFrom(rows []*Job, limit int) *Page {
+	p := &Page{Jobs: rows}
+	if len(rows) > limit {
+		p.Jobs = rows[:limit]
+		p.NextCursor = encodePageCursor(p.Jobs[limit-1])
+	}
+	if p.Jobs == nil {
+		p.Jobs = []*Job{}
+	}
+	return p
+}
+
+// ListPage 实现 PageLister
+func (s *JobStoreMem) ListPage(ctx context.Context, q PageQuery) (*Page, error) {
+	s.mu.Lock()
+	list := make([]*Job, 0, len(s.byID))
+	for _, j := range s.byID {
+		cp := *j
+		list = append(list, &cp)
+	}
+	s.mu.Unlock()
+	return PageOf(list, q)
+}
+
+// ListPage 实现 PageLister；利用 (agent_id, created_at, id) 索引做键集分页，不做 OFFSET 扫描
+func (s *JobStorePg) ListPage(ctx context.Context, q PageQuery) (*Page, error) {
+	cur, err := decodePageCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive FROM jobs WHERE true`
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if q.TenantID != "" {
+		p := arg(q.TenantID)
+		query += ` AND (tenant_id = ` + p + ` OR (tenant_id IS NULL AND ` + p + ` = 'default'))`
+	}
+	if len(q.AgentIDs) > 0 {
+		query += ` AND agent_id = ANY(` + arg(q.AgentIDs) + `)`
+	}
+	if len(q.Statuses) > 0 {
+		codes := make([]int, 0, len(q.Statuses))
+		for _, st := range q.Statuses {
+			codes = append(codes, statusToPg(st))
+		}
+		query += ` AND status = ANY(` + arg(codes) + `)`
+	}
+	if !q.From.IsZero() {
+		query += ` AND created_at >= ` + arg(q.From)
+	}
+	if !q.To.IsZero() {
+		query += ` AND created_at < ` + arg(q.To)
+	}
+	if cur != nil {
+		query += ` AND (created_at, id) < (` + arg(cur.createdAt) + `, ` + arg(cur.id) + `)`
+	}
+	limit := q.limit()
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT %d`, limit+1)
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.scanJobs(rows)
+	if err != nil {
+		return nil, err
+	}
+	return pageFrom(list, limit), nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestJobStoreMem_ListPage(t *testing.T) {
+	ctx := context.Background()
+	s := NewJobStoreMem()
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		agent := "a1"
+		if i%2 == 1 {
+			agent = "a2"
+		}
+		id, err := s.Create(ctx, &Job{ID: fmt.Sprintf("job-%d", i), AgentID: agent, Goal: "g"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 固定时间，其中 job-5、job-6 同一时刻，用于验证 id 次序
+		s.byID[id].CreatedAt = base.Add(time.Duration(min(i, 5)) * time.Minute)
+	}
+	_ = s.UpdateStatus(ctx, "job-2", StatusFailed)
+
+	var got []string
+	q := PageQuery{AgentIDs: []string{"a1", "a2"}, Limit: 3}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		p, err := s.ListPage(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, j := range p.Jobs {
+			got = append(got, j.ID)
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		q.Cursor = p.NextCursor
+	}
+	want := []string{"job-6", "job-5", "job-4", "job-3", "job-2", "job-1", "job-0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("pages = %v, want %v", got, want)
+	}
+
+	p, err := s.ListPage(ctx, PageQuery{AgentIDs: []string{"a1"}, Statuses: []JobStatus{StatusPending}, From: base.Add(time.Minute), To: base.Add(5 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Jobs) != 1 || p.Jobs[0].ID != "job-4" || p.NextCursor != "" {
+		t.Fatalf("filtered page = %+v", p)
+	}
+
+	if _, err := s.ListPage(ctx, PageQuery{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidPageCursor) {
+		t.Fatalf("invalid cursor err = %v", err)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for st := StatusPending; st <= StatusDeferred; st++ {
+		if got, ok := ParseStatus(st.String()); !ok || got != st {
+			t.Fatalf("ParseStatus(%q) = %v, %v", st.String(), got, ok)
+		}
+	}
+	if _, ok := ParseStatus("bogus"); ok {
+		t.Fatal("bogus status should not parse")
+	}
+}
@@ -16,16 +16,22 @@ package job
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/pgretry"
+	"rag-platform/pkg/idgen"
 )
 
-// status 与 JobStatus 一致：0=Pending, 1=Running, 2=Completed, 3=Failed, 4=Cancelled, 5=Waiting, 6=Retrying
+// status 与 JobStatus 一致：0=Pending, 1=Running, 2=Completed, 3=Failed, 4=Cancelled, 5=Waiting, 6=Parked, 7=Retrying, 8=Deferred
 const (
 	pgStatusPending   = 0
 	pgStatusRunning   = 1
@@ -35,11 +41,42 @@ const (
 	pgStatusWaiting   = 5
 	pgStatusParked    = 6 // 长时间等待，scheduler 跳过
 	pgStatusRetrying  = 7
+	pgStatusDeferred  = 8 // 租户维护窗口内暂缓，scheduler 跳过
 )
 
+// GoalSealer 目标载荷级加密（见 payloadcrypt.Keyring）：写入 jobs.goal 前按租户加密，读取时解密
+type GoalSealer interface {
+	Seal(tenantID, plaintext string) (string, error)
+	Open(value string) (string, error)
+}
+
 // JobStorePg Postgres 实现：jobs 表，供 API 与 Worker 共享
 type JobStorePg struct {
 	pool *pgxpool.Pool
+	// sealer 可选；非 nil 时 jobs.goal 只保存密文，goal_hash 仍按明文计算以保持去重窗口可用
+	sealer GoalSealer
+	// guard 可选；非 nil 时短暂的数据库错误按操作类别重试，认领在降级期间暂停
+	guard *pgretry.Guard
+}
+
+// SetResilienceGuard 设置重试与熔断（可选）；可与事件存储共享同一 Guard
+func (s *JobStorePg) SetResilienceGuard(g *pgretry.Guard) {
+	s.guard = g
+}
+
+// SetGoalSealer 设置目标加密（可选）
+func (s *JobStorePg) SetGoalSealer(sealer GoalSealer) {
+	s.sealer = sealer
+}
+
+// openGoal 解密读出的目标；无法解密时保留密文（缺少密钥的进程不应因此无法调度）
+func (s *JobStorePg) openGoal(j *Job) {
+	if s.sealer == nil {
+		return
+	}
+	if plain, err := s.sealer.Open(j.Goal); err == nil {
+		j.Goal = plain
+	}
 }
 
 // NewJobStorePg 创建基于 PostgreSQL 的 JobStore；dsn 为连接串（与 jobstore 事件表同库）
@@ -59,6 +96,11 @@ func NewJobStorePg(ctx context.Context, dsn string) (*JobStorePg, error) {
 	return &JobStorePg{pool: pool}, nil
 }
 
+// NewJobStorePgWithPool 基于已有连接池创建 JobStorePg（连接池由 pgpool.Manager 统一管理）
+func NewJobStorePgWithPool(pool *pgxpool.Pool) *JobStorePg {
+	return &JobStorePg{pool: pool}
+}
+
 // Close 关闭连接池
 func (s *JobStorePg) Close() {
 	s.pool.Close()
@@ -82,6 +124,8 @@ func statusToPg(s JobStatus) int {
 		return pgStatusParked
 	case StatusRetrying:
 		return pgStatusRetrying
+	case StatusDeferred:
+		return pgStatusDeferred
 	default:
 		return pgStatusPending
 	}
@@ -105,6 +149,8 @@ func pgToStatus(i int) JobStatus {
 		return StatusParked
 	case pgStatusRetrying:
 		return StatusRetrying
+	case pgStatusDeferred:
+		return StatusDeferred
 	default:
 		return StatusPending
 	}
@@ -124,6 +170,28 @@ func nullTime(t time.Time) interface{} {
 	return t
 }
 
+func pgToTerminal(b []byte) *TerminalInfo {
+	if len(b) == 0 {
+		return nil
+	}
+	var t TerminalInfo
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil
+	}
+	return &t
+}
+
+func attributionToPg(a *jobstore.Attribution) interface{} {
+	if a.IsZero() {
+		return nil
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
 func capsToPg(caps []string) interface{} {
 	if len(caps) == 0 {
 		return nil
@@ -151,7 +219,7 @@ func (s *JobStorePg) Create(ctx context.Context, j *Job) (string, error) {
 	}
 	id := j.ID
 	if id == "" {
-		id = "job-" + uuid.New().String()
+		id = "job-" + idgen.NewID()
 	}
 	now := time.Now()
 	if j.CreatedAt.IsZero() {
@@ -164,10 +232,26 @@ func (s *JobStorePg) Create(ctx context.Context, j *Job) (string, error) {
 	if tenantID == "" {
 		tenantID = "default"
 	}
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO jobs (id, agent_id, tenant_id, goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
-		id, j.AgentID, nullStr(tenantID), j.Goal, statusToPg(StatusPending), j.Cursor, j.RetryCount, nullStr(j.SessionID), nullTime(j.CancelRequestedAt), j.CreatedAt, j.UpdatedAt, nullStr(j.IdempotencyKey), capsToPg(j.RequiredCapabilities))
+	// 仅允许以 Pending 或 Deferred（租户维护窗口内）创建
+	initial := StatusPending
+	if j.Status == StatusDeferred {
+		initial = StatusDeferred
+	}
+	storedGoal := j.Goal
+	if s.sealer != nil {
+		sealed, err := s.sealer.Seal(tenantID, j.Goal)
+		if err != nil {
+			return "", fmt.Errorf("加密 Job 目标failed: %w", err)
+		}
+		storedGoal = sealed
+	}
+	err := s.guard.Do(ctx, "create", pgretry.NonIdempotent, func() error {
+		_, err := s.pool.Exec(ctx,
+			`INSERT INTO jobs (id, agent_id, tenant_id, goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, goal_hash, attribution, interactive, execution_profile)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+			id, j.AgentID, nullStr(tenantID), storedGoal, statusToPg(initial), j.Cursor, j.RetryCount, nullStr(j.SessionID), nullTime(j.CancelRequestedAt), j.CreatedAt, j.UpdatedAt, nullStr(j.IdempotencyKey), capsToPg(j.RequiredCapabilities), GoalHash(j.Goal), attributionToPg(j.Attribution), j.Interactive, nullStr(j.Profile))
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -181,9 +265,12 @@ func (s *JobStorePg) Get(ctx context.Context, jobID string) (*Job, error) {
 	var retryCount int
 	var cancelRequestedAt *time.Time
 	var createdAt, updatedAt time.Time
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities FROM jobs WHERE id = $1`,
-		jobID).Scan(&j.ID, &j.AgentID, &tenantID, &j.Goal, &status, &cursor, &retryCount, &sessionID, &cancelRequestedAt, &createdAt, &updatedAt, &idempotencyKey, &requiredCaps)
+	var terminalInfo, attribution []byte
+	err := s.guard.Do(ctx, "get", pgretry.Idempotent, func() error {
+		return s.pool.QueryRow(ctx,
+			`SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive, COALESCE(execution_profile, '') FROM jobs WHERE id = $1`,
+			jobID).Scan(&j.ID, &j.AgentID, &tenantID, &j.Goal, &status, &cursor, &retryCount, &sessionID, &cancelRequestedAt, &createdAt, &updatedAt, &idempotencyKey, &requiredCaps, &terminalInfo, &attribution, &j.Interactive, &j.Profile)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -212,6 +299,9 @@ func (s *JobStorePg) Get(ctx context.Context, jobID string) (*Job, error) {
 		j.IdempotencyKey = *idempotencyKey
 	}
 	j.RequiredCapabilities = pgToCaps(requiredCaps)
+	j.Terminal = pgToTerminal(terminalInfo)
+	j.Attribution = jobstore.ParseAttribution(attribution)
+	s.openGoal(&j)
 	return &j, nil
 }
 
@@ -225,9 +315,12 @@ func (s *JobStorePg) GetByAgentAndIdempotencyKey(ctx context.Context, agentID, i
 	var retryCount int
 	var cancelRequestedAt *time.Time
 	var createdAt, updatedAt time.Time
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities FROM jobs WHERE agent_id = $1 AND idempotency_key = $2`,
-		agentID, idempotencyKey).Scan(&j.ID, &j.AgentID, &tenantID, &j.Goal, &status, &cursor, &retryCount, &sessionID, &cancelRequestedAt, &createdAt, &updatedAt, &key, &requiredCaps)
+	var terminalInfo, attribution []byte
+	err := s.guard.Do(ctx, "get_by_idempotency_key", pgretry.Idempotent, func() error {
+		return s.pool.QueryRow(ctx,
+			`SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive, COALESCE(execution_profile, '') FROM jobs WHERE agent_id = $1 AND idempotency_key = $2`,
+			agentID, idempotencyKey).Scan(&j.ID, &j.AgentID, &tenantID, &j.Goal, &status, &cursor, &retryCount, &sessionID, &cancelRequestedAt, &createdAt, &updatedAt, &key, &requiredCaps, &terminalInfo, &attribution, &j.Interactive, &j.Profile)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -256,78 +349,146 @@ func (s *JobStorePg) GetByAgentAndIdempotencyKey(ctx context.Context, agentID, i
 	j.CreatedAt = createdAt
 	j.UpdatedAt = updatedAt
 	j.RequiredCapabilities = pgToCaps(requiredCaps)
+	j.Terminal = pgToTerminal(terminalInfo)
+	j.Attribution = jobstore.ParseAttribution(attribution)
+	s.openGoal(&j)
 	return &j, nil
 }
 
+func (s *JobStorePg) FindRecentByGoalHash(ctx context.Context, agentID, tenantID, goalHash string, since time.Time) (*Job, error) {
+	if goalHash == "" {
+		return nil, nil
+	}
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	var id string
+	err := s.guard.Do(ctx, "find_by_goal_hash", pgretry.Idempotent, func() error {
+		return s.pool.QueryRow(ctx,
+			`SELECT id FROM jobs
+			 WHERE agent_id = $1 AND goal_hash = $2 AND created_at >= $3
+			   AND (tenant_id = $4 OR (tenant_id IS NULL AND $4 = 'default'))
+			   AND status NOT IN ($5, $6)
+			 ORDER BY created_at DESC LIMIT 1`,
+			agentID, goalHash, since, tenantID, statusToPg(StatusFailed), statusToPg(StatusCancelled)).Scan(&id)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
 func (s *JobStorePg) ListByAgent(ctx context.Context, agentID string, tenantID string) ([]*Job, error) {
-	query := `SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities FROM jobs WHERE agent_id = $1`
+	query := `SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive, COALESCE(execution_profile, '') FROM jobs WHERE agent_id = $1`
 	args := []interface{}{agentID}
 	if tenantID != "" {
 		query += ` AND (tenant_id = $2 OR (tenant_id IS NULL AND $2 = 'default'))`
 		args = append(args, tenantID)
 	}
 	query += ` ORDER BY created_at DESC`
-	rows, err := s.pool.Query(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var list []*Job
-	for rows.Next() {
-		var j Job
+	var jobs []*Job
+	err := s.guard.Do(ctx, "list_by_agent", pgretry.Idempotent, func() error {
+		rows, err := s.pool.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		jobs, err = s.scanJobs(rows)
+		return err
+	})
+	return jobs, err
+}
+
+func (s *JobStorePg) UpdateStatus(ctx context.Context, jobID string, status JobStatus) error {
+	return s.transition(ctx, jobID, status, "")
+}
+
+// pgTransitionAttempts 条件更新因并发修改未命中时的最大尝试次数
+const pgTransitionAttempts = 3
+
+// transition 按状态机校验并以 WHERE status = from 的条件更新迁移到 to；set 为额外的 SET 子句（参数从 $4 起）。
+// 条件未命中说明状态已被并发修改，按最新状态重新校验；Job 不存在时静默。
+// 条件更新可安全重放：上一次已生效时重试读到的状态即为 to（同状态写入总是允许）。
+// 非同状态的迁移在同一条语句中写入 job_transitions，二者同时生效
+func (s *JobStorePg) transition(ctx context.Context, jobID string, to JobStatus, set string, args ...interface{}) error {
+	var from JobStatus
+	for attempt := 0; attempt < pgTransitionAttempts; attempt++ {
 		var status int
-		var cursor, sessionID, idempotencyKey, requiredCaps, tid *string
-		var retryCount int
 		var cancelRequestedAt *time.Time
-		var createdAt, updatedAt time.Time
-		if err := rows.Scan(&j.ID, &j.AgentID, &tid, &j.Goal, &status, &cursor, &retryCount, &sessionID, &cancelRequestedAt, &createdAt, &updatedAt, &idempotencyKey, &requiredCaps); err != nil {
-			return nil, err
+		err := s.guard.Do(ctx, "read_status", pgretry.Idempotent, func() error {
+			return s.pool.QueryRow(ctx, `SELECT status, cancel_requested_at FROM jobs WHERE id = $1`, jobID).Scan(&status, &cancelRequestedAt)
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
 		}
-		if tid != nil {
-			j.TenantID = *tid
-		} else {
-			j.TenantID = "default"
+		from = pgToStatus(status)
+		j := &Job{ID: jobID, Status: from}
+		if cancelRequestedAt != nil {
+			j.CancelRequestedAt = *cancelRequestedAt
 		}
-		j.Status = pgToStatus(status)
-		if cursor != nil {
-			j.Cursor = *cursor
+		t, err := ValidateTransition(ctx, j, to)
+		if err != nil {
+			return err
 		}
-		if sessionID != nil {
-			j.SessionID = *sessionID
+		query := `UPDATE jobs SET status = $1, updated_at = now()` + set + ` WHERE id = $2 AND status = $3`
+		params := append([]interface{}{statusToPg(to), jobID, status}, args...)
+		if t.From != t.To {
+			query = `WITH upd AS (` + query + ` RETURNING id)
+INSERT INTO job_transitions (job_id, from_status, to_status, reason) SELECT id, $3, $1, ` + fmt.Sprintf("$%d", len(params)+1) + ` FROM upd`
+			params = append(params, t.Reason)
 		}
-		if cancelRequestedAt != nil {
-			j.CancelRequestedAt = *cancelRequestedAt
+		var cmd pgconn.CommandTag
+		err = s.guard.Do(ctx, "update_status", pgretry.Idempotent, func() error {
+			var err error
+			cmd, err = s.pool.Exec(ctx, query, params...)
+			return err
+		})
+		if err != nil {
+			return err
 		}
-		if idempotencyKey != nil {
-			j.IdempotencyKey = *idempotencyKey
+		if cmd.RowsAffected() > 0 {
+			return nil
 		}
-		j.RetryCount = retryCount
-		j.CreatedAt = createdAt
-		j.UpdatedAt = updatedAt
-		j.RequiredCapabilities = pgToCaps(requiredCaps)
-		list = append(list, &j)
 	}
-	return list, rows.Err()
+	return &IllegalTransitionError{JobID: jobID, From: from, To: to, Reason: RejectConcurrentUpdate}
 }
 
-func (s *JobStorePg) UpdateStatus(ctx context.Context, jobID string, status JobStatus) error {
-	cmd, err := s.pool.Exec(ctx,
-		`UPDATE jobs SET status = $1, updated_at = now() WHERE id = $2`,
-		statusToPg(status), jobID)
-	if err != nil {
-		return err
-	}
-	if cmd.RowsAffected() == 0 {
-		return nil // not found则静默
-	}
-	return nil
+// ListTransitions 实现 TransitionLister；按写入顺序返回
+func (s *JobStorePg) ListTransitions(ctx context.Context, jobID string) (out []Transition, err error) {
+	err = s.guard.Do(ctx, "list_transitions", pgretry.Idempotent, func() error {
+		out = nil
+		rows, err := s.pool.Query(ctx,
+			`SELECT from_status, to_status, reason, created_at FROM job_transitions WHERE job_id = $1 ORDER BY id`, jobID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var from, to int
+			t := Transition{JobID: jobID}
+			if err := rows.Scan(&from, &to, &t.Reason, &t.At); err != nil {
+				return err
+			}
+			t.From, t.To = pgToStatus(from), pgToStatus(to)
+			out = append(out, t)
+		}
+		return rows.Err()
+	})
+	return out, err
 }
 
 func (s *JobStorePg) UpdateCursor(ctx context.Context, jobID string, cursor string) error {
-	_, err := s.pool.Exec(ctx,
-		`UPDATE jobs SET cursor = $1, updated_at = now() WHERE id = $2`,
-		cursor, jobID)
-	return err
+	return s.guard.Do(ctx, "update_cursor", pgretry.Idempotent, func() error {
+		_, err := s.pool.Exec(ctx,
+			`UPDATE jobs SET cursor = $1, updated_at = now() WHERE id = $2`,
+			cursor, jobID)
+		return err
+	})
 }
 
 func (s *JobStorePg) ClaimNextPending(ctx context.Context) (*Job, error) {
@@ -340,24 +501,48 @@ func (s *JobStorePg) ClaimNextPendingFromQueue(ctx context.Context, queueClass s
 
 func (s *JobStorePg) ClaimNextPendingForWorker(ctx context.Context, queueClass string, workerCapabilities []string, tenantID string) (*Job, error) {
 	_ = queueClass // 当前 PG 未按队列过滤，与 ClaimNextPendingFromQueue 一致
-	if len(workerCapabilities) == 0 {
-		return s.claimNextPendingPg(ctx, tenantID)
-	}
+	return s.claim(ctx, workerCapabilities, tenantID, false)
+}
+
+// ClaimNextInteractive 实现 InteractiveClaimer；仅认领交互式 Job
+func (s *JobStorePg) ClaimNextInteractive(ctx context.Context, workerCapabilities []string) (*Job, error) {
+	return s.claim(ctx, workerCapabilities, "", true)
+}
+
+// claim 认领入口；降级期间返回 pgretry.ErrDegraded
+func (s *JobStorePg) claim(ctx context.Context, workerCapabilities []string, tenantID string, interactiveOnly bool) (j *Job, err error) {
+	err = s.guard.Do(ctx, "claim", pgretry.Claim, func() error {
+		if len(workerCapabilities) == 0 {
+			j, err = s.claimNextPendingPg(ctx, tenantID, interactiveOnly)
+		} else {
+			j, err = s.claimForWorkerPg(ctx, workerCapabilities, tenantID, interactiveOnly)
+		}
+		return err
+	})
+	return j, err
+}
+
+// claimForWorkerPg 按能力认领；交互式 Job 优先，interactiveOnly 时仅认领交互式 Job
+func (s *JobStorePg) claimForWorkerPg(ctx context.Context, workerCapabilities []string, tenantID string, interactiveOnly bool) (*Job, error) {
 	var j Job
 	var status int
 	var cursor, sessionID, requiredCaps, tid *string
 	var retryCount int
 	var createdAt, updatedAt time.Time
+	var attribution []byte
 	subWhere := `status = $2 AND (required_capabilities IS NULL OR trim(required_capabilities) = '' OR (SELECT bool_and(trim(c) = ANY($3)) FROM unnest(string_to_array(required_capabilities, ',')) AS c))`
 	args := []interface{}{pgStatusRunning, pgStatusPending, workerCapabilities}
 	if tenantID != "" {
 		subWhere += ` AND (tenant_id = $4 OR (tenant_id IS NULL AND $4 = 'default'))`
 		args = append(args, tenantID)
 	}
+	if interactiveOnly {
+		subWhere += ` AND interactive`
+	}
 	query := `UPDATE jobs SET status = $1, updated_at = now()
-		 WHERE id = (SELECT id FROM jobs WHERE ` + subWhere + ` ORDER BY created_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED)
-		 RETURNING id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, created_at, updated_at, required_capabilities`
-	err := s.pool.QueryRow(ctx, query, args...).Scan(&j.ID, &j.AgentID, &tid, &j.Goal, &status, &cursor, &retryCount, &sessionID, &createdAt, &updatedAt, &requiredCaps)
+		 WHERE id = (SELECT id FROM jobs WHERE ` + subWhere + ` ORDER BY interactive DESC, created_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED)
+		 RETURNING id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, created_at, updated_at, required_capabilities, attribution, interactive, COALESCE(execution_profile, '')`
+	err := s.pool.QueryRow(ctx, query, args...).Scan(&j.ID, &j.AgentID, &tid, &j.Goal, &status, &cursor, &retryCount, &sessionID, &createdAt, &updatedAt, &requiredCaps, &attribution, &j.Interactive, &j.Profile)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -380,15 +565,19 @@ func (s *JobStorePg) ClaimNextPendingForWorker(ctx context.Context, queueClass s
 	j.CreatedAt = createdAt
 	j.UpdatedAt = updatedAt
 	j.RequiredCapabilities = pgToCaps(requiredCaps)
+	j.Attribution = jobstore.ParseAttribution(attribution)
+	s.openGoal(&j)
 	return &j, nil
 }
 
-func (s *JobStorePg) claimNextPendingPg(ctx context.Context, tenantID string) (*Job, error) {
+// claimNextPendingPg 认领最早的 Pending；交互式 Job 优先，interactiveOnly 时仅认领交互式 Job
+func (s *JobStorePg) claimNextPendingPg(ctx context.Context, tenantID string, interactiveOnly bool) (*Job, error) {
 	var j Job
 	var status int
 	var cursor, sessionID, requiredCaps, tid *string
 	var retryCount int
 	var createdAt, updatedAt time.Time
+	var attribution []byte
 	query := `UPDATE jobs SET status = $1, updated_at = now()
 		 WHERE id = (SELECT id FROM jobs WHERE status = $2`
 	args := []interface{}{pgStatusRunning, pgStatusPending}
@@ -396,9 +585,12 @@ func (s *JobStorePg) claimNextPendingPg(ctx context.Context, tenantID string) (*
 		query += ` AND (tenant_id = $3 OR (tenant_id IS NULL AND $3 = 'default'))`
 		args = append(args, tenantID)
 	}
-	query += ` ORDER BY created_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED)
-		 RETURNING id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, created_at, updated_at, required_capabilities`
-	err := s.pool.QueryRow(ctx, query, args...).Scan(&j.ID, &j.AgentID, &tid, &j.Goal, &status, &cursor, &retryCount, &sessionID, &createdAt, &updatedAt, &requiredCaps)
+	if interactiveOnly {
+		query += ` AND interactive`
+	}
+	query += ` ORDER BY interactive DESC, created_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED)
+		 RETURNING id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, created_at, updated_at, required_capabilities, attribution, interactive, COALESCE(execution_profile, '')`
+	err := s.pool.QueryRow(ctx, query, args...).Scan(&j.ID, &j.AgentID, &tid, &j.Goal, &status, &cursor, &retryCount, &sessionID, &createdAt, &updatedAt, &requiredCaps, &attribution, &j.Interactive, &j.Profile)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -421,6 +613,8 @@ func (s *JobStorePg) claimNextPendingPg(ctx context.Context, tenantID string) (*
 	j.CreatedAt = createdAt
 	j.UpdatedAt = updatedAt
 	j.RequiredCapabilities = pgToCaps(requiredCaps)
+	j.Attribution = jobstore.ParseAttribution(attribution)
+	s.openGoal(&j)
 	return &j, nil
 }
 
@@ -428,17 +622,28 @@ func (s *JobStorePg) Requeue(ctx context.Context, j *Job) error {
 	if j == nil {
 		return nil
 	}
-	_, err := s.pool.Exec(ctx,
-		`UPDATE jobs SET status = $1, retry_count = $2, updated_at = now() WHERE id = $3`,
-		pgStatusPending, j.RetryCount+1, j.ID)
-	return err
+	return s.transition(ctx, j.ID, StatusPending, `, retry_count = $4`, j.RetryCount+1)
 }
 
 func (s *JobStorePg) RequestCancel(ctx context.Context, jobID string) error {
-	_, err := s.pool.Exec(ctx,
-		`UPDATE jobs SET cancel_requested_at = now(), updated_at = now() WHERE id = $1`,
-		jobID)
-	return err
+	return s.guard.Do(ctx, "request_cancel", pgretry.Idempotent, func() error {
+		_, err := s.pool.Exec(ctx,
+			`UPDATE jobs SET cancel_requested_at = now(), updated_at = now() WHERE id = $1`,
+			jobID)
+		return err
+	})
+}
+
+// SetTerminalInfo 实现 TerminalInfoStore：终态元数据以 JSON 写入 jobs.terminal_info
+func (s *JobStorePg) SetTerminalInfo(ctx context.Context, jobID string, info *TerminalInfo) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return s.guard.Do(ctx, "set_terminal_info", pgretry.Idempotent, func() error {
+		_, err := s.pool.Exec(ctx, `UPDATE jobs SET terminal_info = $2, updated_at = now() WHERE id = $1`, jobID, b)
+		return err
+	})
 }
 
 // ReclaimOrphanedJobs 将 status=Running 且 updated_at 早于 (now - olderThan) 的 Job 置回 Pending；olderThan 应 ≥ event store 的 lease_ttl
@@ -479,6 +684,117 @@ func (s *JobStorePg) ListStuckRunningJobIDs(ctx context.Context, olderThan time.
 	return ids, rows.Err()
 }
 
+// ListUpdatedSince 实现 RecentJobLister；tenantID 为空时不过滤
+func (s *JobStorePg) ListUpdatedSince(ctx context.Context, tenantID string, since time.Time, limit int) ([]*Job, error) {
+	query := `SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive, COALESCE(execution_profile, '') FROM jobs WHERE updated_at >= $1`
+	args := []interface{}{since}
+	if tenantID != "" {
+		query += ` AND (tenant_id = $2 OR (tenant_id IS NULL AND $2 = 'default'))`
+		args = append(args, tenantID)
+	}
+	query += ` ORDER BY updated_at DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanJobs(rows)
+}
+
+// ListTerminalAfter 实现 TerminalJobScanner；按 (updated_at, id) 键集分页
+func (s *JobStorePg) ListTerminalAfter(ctx context.Context, after time.Time, afterID string, until time.Time, limit int) ([]*Job, error) {
+	query := `SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive, COALESCE(execution_profile, '') FROM jobs WHERE status IN ($1, $2, $3) AND (updated_at, id) > ($4, $5) AND updated_at < $6 ORDER BY updated_at ASC, id ASC`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+	rows, err := s.pool.Query(ctx, query, pgStatusCompleted, pgStatusFailed, pgStatusCancelled, after, afterID, until)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanJobs(rows)
+}
+
+// ListActive 实现 ActiveJobLister；非终态（非 completed/failed/cancelled）按 updated_at 升序
+func (s *JobStorePg) ListActive(ctx context.Context, limit int) ([]*Job, error) {
+	query := `SELECT id, agent_id, COALESCE(tenant_id, 'default'), goal, status, cursor, retry_count, session_id, cancel_requested_at, created_at, updated_at, idempotency_key, required_capabilities, terminal_info, attribution, interactive, COALESCE(execution_profile, '') FROM jobs WHERE status NOT IN ($1, $2, $3) ORDER BY updated_at ASC`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+	rows, err := s.pool.Query(ctx, query, pgStatusCompleted, pgStatusFailed, pgStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanJobs(rows)
+}
+
+// scanJobs 扫描 jobs 列表查询（列顺序同 ListByAgent）并关闭 rows
+func (s *JobStorePg) scanJobs(rows pgx.Rows) ([]*Job, error) {
+	defer rows.Close()
+	var list []*Job
+	for rows.Next() {
+		var j Job
+		var status int
+		var cursor, sessionID, idempotencyKey, requiredCaps, tid *string
+		var retryCount int
+		var cancelRequestedAt *time.Time
+		var createdAt, updatedAt time.Time
+		var terminalInfo, attribution []byte
+		if err := rows.Scan(&j.ID, &j.AgentID, &tid, &j.Goal, &status, &cursor, &retryCount, &sessionID, &cancelRequestedAt, &createdAt, &updatedAt, &idempotencyKey, &requiredCaps, &terminalInfo, &attribution, &j.Interactive, &j.Profile); err != nil {
+			return nil, err
+		}
+		if tid != nil {
+			j.TenantID = *tid
+		} else {
+			j.TenantID = "default"
+		}
+		j.Status = pgToStatus(status)
+		if cursor != nil {
+			j.Cursor = *cursor
+		}
+		if sessionID != nil {
+			j.SessionID = *sessionID
+		}
+		if cancelRequestedAt != nil {
+			j.CancelRequestedAt = *cancelRequestedAt
+		}
+		if idempotencyKey != nil {
+			j.IdempotencyKey = *idempotencyKey
+		}
+		j.RetryCount = retryCount
+		j.CreatedAt = createdAt
+		j.UpdatedAt = updatedAt
+		j.RequiredCapabilities = pgToCaps(requiredCaps)
+		j.Terminal = pgToTerminal(terminalInfo)
+		j.Attribution = jobstore.ParseAttribution(attribution)
+		s.openGoal(&j)
+		list = append(list, &j)
+	}
+	return list, rows.Err()
+}
+
+// TenantLoads 实现 TenantLoadReader
+func (s *JobStorePg) TenantLoads(ctx context.Context) (map[string]TenantLoad, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT COALESCE(tenant_id, 'default'), count(*) FILTER (WHERE status = $1), count(*) FILTER (WHERE status = $2)
+		 FROM jobs WHERE status IN ($1, $2) GROUP BY 1`, pgStatusPending, pgStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]TenantLoad)
+	for rows.Next() {
+		var tenant string
+		var l TenantLoad
+		if err := rows.Scan(&tenant, &l.Pending, &l.Running); err != nil {
+			return nil, err
+		}
+		out[tenant] = l
+	}
+	return out, rows.Err()
+}
+
 // CountByStatus 实现 ObservabilityReader；返回各状态 Job 数量，用于 job_state gauge（P0 SLO）
 func (s *JobStorePg) CountByStatus(ctx context.Context) (map[string]int64, error) {
 	rows, err := s.pool.Query(ctx, `SELECT status, count(*) FROM jobs GROUP BY status`)
@@ -497,3 +813,44 @@ func (s *JobStorePg) CountByStatus(ctx context.Context) (map[string]int64, error
 	}
 	return out, rows.Err()
 }
+
+// DeferPending 实现 DeferredJobStore；将租户下 Pending 的 Job 置为 Deferred
+func (s *JobStorePg) DeferPending(ctx context.Context, tenantID string) (int, error) {
+	cmd, err := s.pool.Exec(ctx,
+		`UPDATE jobs SET status = $1, updated_at = now() WHERE status = $2 AND COALESCE(tenant_id, 'default') = $3`,
+		pgStatusDeferred, pgStatusPending, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return int(cmd.RowsAffected()), nil
+}
+
+// ResumeDeferred 实现 DeferredJobStore；将租户下 Deferred 的 Job 恢复为 Pending
+func (s *JobStorePg) ResumeDeferred(ctx context.Context, tenantID string) (int, error) {
+	cmd, err := s.pool.Exec(ctx,
+		`UPDATE jobs SET status = $1, updated_at = now() WHERE status = $2 AND COALESCE(tenant_id, 'default') = $3`,
+		pgStatusPending, pgStatusDeferred, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return int(cmd.RowsAffected()), nil
+}
+
+// ListTenantsWithDeferred 实现 DeferredJobStore
+func (s *JobStorePg) ListTenantsWithDeferred(ctx context.Context) ([]string, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT DISTINCT COALESCE(tenant_id, 'default') FROM jobs WHERE status = $1`, pgStatusDeferred)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
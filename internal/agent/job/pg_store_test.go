@@ -76,3 +76,29 @@ func TestJobStorePg_ClaimNextPending(t *testing.T) {
 		t.Errorf("expected nil second claim, got %+v", claimed2)
 	}
 }
+
+func TestJobStorePg_ListTransitions(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := newTestJobStorePg(t, ctx)
+	defer cleanup()
+	id, err := store.Create(ctx, &Job{AgentID: "a1", Goal: "hello"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := store.UpdateStatus(ctx, id, StatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateStatus(ctx, id, StatusRunning); err != nil {
+		t.Fatalf("same-status write: %v", err)
+	}
+	if err := store.UpdateStatus(WithTransitionReason(ctx, "done"), id, StatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.ListTransitions(ctx, id)
+	if err != nil {
+		t.Fatalf("ListTransitions: %v", err)
+	}
+	if len(got) != 2 || got[0].To != StatusRunning || got[1].From != StatusRunning || got[1].To != StatusCompleted || got[1].Reason != "done" {
+		t.Errorf("transitions = %+v", got)
+	}
+}
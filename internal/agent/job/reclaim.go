@@ -46,7 +46,7 @@ func ReclaimOrphanedFromEventStore(ctx context.Context, metadata JobStore, event
 		if j.Status != StatusRunning {
 			continue
 		}
-		if err := metadata.UpdateStatus(ctx, jobID, StatusPending); err != nil {
+		if err := metadata.UpdateStatus(WithTransitionReason(ctx, "orphan_reclaimed"), jobID, StatusPending); err != nil {
 			continue
 		}
 		reclaimed++
@@ -39,17 +39,22 @@ type SchedulerConfig struct {
 	Queues []string
 	// Capabilities 调度器（Worker）能力列表；非空时仅认领 Job.RequiredCapabilities 满足的 Job
 	Capabilities []string
+	// InteractiveReserved 为交互式 Job 额外预留的并发槽位（不占 MaxConcurrency）；<=0 不启用预留通道，交互式 Job 仅按优先级在常规通道中先被认领
+	InteractiveReserved int
 }
 
 // Scheduler 在 JobStore 之上提供排队、并发限制与重试；形态为 API→Job Queue→Scheduler→Worker→Executor
 type Scheduler struct {
-	store      JobStore
-	runJob     RunJobFunc
-	config     SchedulerConfig
-	compensate CompensateFunc // optional; called on CompensatableFailure before marking job failed
-	stopCh     chan struct{}
-	wg         sync.WaitGroup
-	limiter    chan struct{} // 信号量，限制并发
+	store       JobStore
+	runJob      RunJobFunc
+	config      SchedulerConfig
+	compensate  CompensateFunc // optional; called on CompensatableFailure before marking job failed
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+	limiter     chan struct{}    // 信号量，限制并发
+	laneLimiter chan struct{}    // 交互式预留通道信号量；nil 表示未启用
+	maintenance *MaintenanceGate // optional; 租户维护窗口内认领到的 Job 置为 Deferred 不执行
+	fair        *FairShare       // optional; 非 nil 时常规通道按租户加权公平认领
 }
 
 // NewScheduler 创建调度器；config 为并发与重试策略
@@ -58,13 +63,17 @@ func NewScheduler(store JobStore, runJob RunJobFunc, config SchedulerConfig) *Sc
 	if max <= 0 {
 		max = 1
 	}
-	return &Scheduler{
+	s := &Scheduler{
 		store:   store,
 		runJob:  runJob,
 		config:  config,
 		stopCh:  make(chan struct{}),
 		limiter: make(chan struct{}, max),
 	}
+	if config.InteractiveReserved > 0 {
+		s.laneLimiter = make(chan struct{}, config.InteractiveReserved)
+	}
+	return s
 }
 
 // SetCompensate 设置 CompensatableFailure 时的补偿回调（可选）
@@ -72,8 +81,60 @@ func (s *Scheduler) SetCompensate(fn CompensateFunc) {
 	s.compensate = fn
 }
 
-// Start 启动调度循环：最多 MaxConcurrency 个 worker 拉取 Pending、执行、成功则 UpdateStatus(Completed)，failed则按 RetryMax/Backoff 重试或 UpdateStatus(Failed)
+// SetMaintenanceGate 设置租户维护窗口判定（可选）；窗口内认领到的 Job 置为 Deferred，窗口结束后由 MaintenanceController 恢复
+func (s *Scheduler) SetMaintenanceGate(g *MaintenanceGate) {
+	s.maintenance = g
+}
+
+// SetFairShare 设置租户加权公平调度（可选）；未设置时常规通道按优先级 FIFO 认领，不区分租户
+func (s *Scheduler) SetFairShare(f *FairShare) {
+	s.fair = f
+}
+
+// Start 启动调度循环：最多 MaxConcurrency 个 worker 拉取 Pending、执行、成功则 UpdateStatus(Completed)，failed则按 RetryMax/Backoff 重试或 UpdateStatus(Failed)；
+// 配置 InteractiveReserved 且 store 实现 InteractiveClaimer 时另起交互式预留通道，仅认领交互式 Job
 func (s *Scheduler) Start(ctx context.Context) {
+	s.loop(ctx, s.limiter, s.claim)
+	if s.laneLimiter != nil {
+		if ic, ok := s.store.(InteractiveClaimer); ok {
+			s.loop(ctx, s.laneLimiter, func(ctx context.Context) *Job {
+				j, _ := ic.ClaimNextInteractive(ctx, s.config.Capabilities)
+				return j
+			})
+		}
+	}
+}
+
+// claim 常规通道认领：若配置了 Queues 则按队列优先级依次尝试；Capabilities 非空时按能力派发；设置 FairShare 时同一队列内按租户公平认领
+func (s *Scheduler) claim(ctx context.Context) *Job {
+	var j *Job
+	if len(s.config.Queues) > 0 {
+		for _, q := range s.config.Queues {
+			if s.fair != nil {
+				j, _ = s.fair.Claim(ctx, s.store, q, s.config.Capabilities)
+			} else if len(s.config.Capabilities) > 0 {
+				j, _ = s.store.ClaimNextPendingForWorker(ctx, q, s.config.Capabilities, "")
+			} else {
+				j, _ = s.store.ClaimNextPendingFromQueue(ctx, q)
+			}
+			if j != nil {
+				break
+			}
+		}
+	} else {
+		if s.fair != nil {
+			j, _ = s.fair.Claim(ctx, s.store, "", s.config.Capabilities)
+		} else if len(s.config.Capabilities) > 0 {
+			j, _ = s.store.ClaimNextPendingForWorker(ctx, "", s.config.Capabilities, "")
+		} else {
+			j, _ = s.store.ClaimNextPending(ctx)
+		}
+	}
+	return j
+}
+
+// loop 单条调度通道：占 limiter 一个槽位后 claim，认领到则异步执行并在结束时释放槽位
+func (s *Scheduler) loop(ctx context.Context, limiter chan struct{}, claim func(context.Context) *Job) {
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
@@ -83,86 +144,124 @@ func (s *Scheduler) Start(ctx context.Context) {
 				return
 			case <-ctx.Done():
 				return
-			case s.limiter <- struct{}{}:
+			case limiter <- struct{}{}:
 				tickStart := time.Now()
-				// 占一个槽位后拉取；若配置了 Queues 则按队列优先级依次尝试；Capabilities 非空时按能力派发
-				var j *Job
-				if len(s.config.Queues) > 0 {
-					for _, q := range s.config.Queues {
-						if len(s.config.Capabilities) > 0 {
-							j, _ = s.store.ClaimNextPendingForWorker(ctx, q, s.config.Capabilities, "")
-						} else {
-							j, _ = s.store.ClaimNextPendingFromQueue(ctx, q)
-						}
-						if j != nil {
-							break
-						}
-					}
-				} else {
-					if len(s.config.Capabilities) > 0 {
-						j, _ = s.store.ClaimNextPendingForWorker(ctx, "", s.config.Capabilities, "")
-					} else {
-						j, _ = s.store.ClaimNextPending(ctx)
-					}
-				}
+				j := claim(ctx)
 				metrics.SchedulerTickDurationSeconds.Observe(time.Since(tickStart).Seconds())
 				if j == nil {
 					metrics.LeaseAcquireTotal.WithLabelValues("default", "false").Inc()
-					<-s.limiter
+					<-limiter
 					time.Sleep(200 * time.Millisecond)
 					continue
 				}
-				tenant := j.TenantID
-				if tenant == "" {
-					tenant = "default"
+				tenant, ok := s.admit(ctx, j)
+				if !ok {
+					<-limiter
+					continue
 				}
-				metrics.LeaseAcquireTotal.WithLabelValues(tenant, "true").Inc()
-				go func(job *Job) {
-					defer func() { <-s.limiter }()
-					runCtx := context.Background()
-					err := s.runJob(runCtx, job)
-					if err != nil {
-						var sf *agentexec.StepFailure
-						if errors.As(err, &sf) {
-							switch sf.Type {
-							case agentexec.StepResultRetryableFailure:
-								if job.RetryCount < s.config.RetryMax {
-									time.Sleep(s.config.Backoff)
-									_ = s.store.Requeue(runCtx, job)
-								} else {
-									_ = s.store.UpdateStatus(runCtx, job.ID, StatusFailed)
-								}
-							case agentexec.StepResultPermanentFailure:
-								_ = s.store.UpdateStatus(runCtx, job.ID, StatusFailed)
-							case agentexec.StepResultCompensatableFailure:
-								if s.compensate != nil {
-									_ = s.compensate(runCtx, job.ID, sf.FailedNodeID())
-								}
-								_ = s.store.UpdateStatus(runCtx, job.ID, StatusFailed)
-							case agentexec.StepResultSideEffectCommitted, agentexec.StepResultCompensated:
-								// 不应以错误返回；若出现则不再重试，直接failed
-								_ = s.store.UpdateStatus(runCtx, job.ID, StatusFailed)
-							default:
-								_ = s.store.UpdateStatus(runCtx, job.ID, StatusFailed)
-							}
-						} else {
-							// No step outcome: backward compat, retry up to RetryMax
-							if job.RetryCount < s.config.RetryMax {
-								time.Sleep(s.config.Backoff)
-								_ = s.store.Requeue(runCtx, job)
-							} else {
-								_ = s.store.UpdateStatus(runCtx, job.ID, StatusFailed)
-							}
-						}
-					} else {
-						_ = s.store.UpdateStatus(runCtx, job.ID, StatusCompleted)
-					}
-				}(j)
+				go s.run(j, tenant, limiter)
 			}
 		}
 	}()
 }
 
+// admit 认领后的准入：记录认领指标；租户处于维护窗口时置为 Deferred 并返回 false
+func (s *Scheduler) admit(ctx context.Context, j *Job) (tenant string, ok bool) {
+	tenant = j.TenantID
+	if tenant == "" {
+		tenant = "default"
+	}
+	metrics.LeaseAcquireTotal.WithLabelValues(tenant, "true").Inc()
+	if s.maintenance.InMaintenance(ctx, tenant) {
+		_ = s.store.UpdateStatus(ctx, j.ID, StatusDeferred)
+		metrics.MaintenanceDeferredTotal.WithLabelValues(tenant, "deferred").Inc()
+		return tenant, false
+	}
+	ObserveLaneClaimed(j)
+	return tenant, true
+}
+
+// RunOnce 同步认领并执行一条 Job（不占用并发槽位、不启动循环），无可认领 Job 时返回 nil；
+// 供进程内集成测试确定性地推进执行（internal/testutil），生产路径使用 Start
+func (s *Scheduler) RunOnce(ctx context.Context) *Job {
+	j := s.claim(ctx)
+	if j == nil {
+		return nil
+	}
+	if tenant, ok := s.admit(ctx, j); ok {
+		s.execute(j, tenant)
+	}
+	return j
+}
+
+// run 执行单条 Job；结束时释放 limiter 槽位
+func (s *Scheduler) run(job *Job, tenant string, limiter chan struct{}) {
+	defer func() { <-limiter }()
+	s.execute(job, tenant)
+}
+
+// execute 执行单条 Job 并按 StepFailure 类型决定重试或终态
+func (s *Scheduler) execute(job *Job, tenant string) {
+	runCtx := context.Background()
+	err := s.runJob(runCtx, job)
+	if errors.Is(err, agentexec.ErrJobWaiting) {
+		// Wait 节点挂起（或计划待审阅），Runner 已写 job_waiting 并置为 Waiting/Parked；由 signal 重新入队，不重试、不置终态
+		return
+	}
+	if errors.Is(err, agentexec.ErrJobDeferred) {
+		// 维护窗口内在 step 边界暂停，Runner 已置为 Deferred；不重试、不标记failed
+		metrics.MaintenanceDeferredTotal.WithLabelValues(tenant, "parked").Inc()
+		return
+	}
+	if errors.Is(err, agentexec.ErrTransactionRolledBack) {
+		// 事务组已回滚，已提交成员均已补偿；重试会在已撤销的副作用上继续执行
+		s.finish(runCtx, job, StatusFailed)
+		return
+	}
+	if err != nil {
+		var sf *agentexec.StepFailure
+		if errors.As(err, &sf) {
+			switch sf.Type {
+			case agentexec.StepResultRetryableFailure:
+				if job.RetryCount < s.config.RetryMax {
+					time.Sleep(s.config.Backoff)
+					_ = s.store.Requeue(runCtx, job)
+				} else {
+					s.finish(runCtx, job, StatusFailed)
+				}
+			case agentexec.StepResultPermanentFailure:
+				s.finish(runCtx, job, StatusFailed)
+			case agentexec.StepResultCompensatableFailure:
+				if s.compensate != nil {
+					_ = s.compensate(runCtx, job.ID, sf.FailedNodeID())
+				}
+				s.finish(runCtx, job, StatusFailed)
+			case agentexec.StepResultSideEffectCommitted, agentexec.StepResultCompensated:
+				// 不应以错误返回；若出现则不再重试，直接failed
+				s.finish(runCtx, job, StatusFailed)
+			default:
+				s.finish(runCtx, job, StatusFailed)
+			}
+		} else {
+			// No step outcome: backward compat, retry up to RetryMax
+			if job.RetryCount < s.config.RetryMax {
+				time.Sleep(s.config.Backoff)
+				_ = s.store.Requeue(runCtx, job)
+			} else {
+				s.finish(runCtx, job, StatusFailed)
+			}
+		}
+	} else {
+		s.finish(runCtx, job, StatusCompleted)
+	}
+}
+
+// finish 置终态并记录调度通道延迟与吞吐
+func (s *Scheduler) finish(ctx context.Context, job *Job, status JobStatus) {
+	_ = s.store.UpdateStatus(ctx, job.ID, status)
+	ObserveLaneFinished(job, status.String())
+}
+
 // Stop 优雅退出：关闭 stopCh，等待当前循环结束（不等待已在执行的 job 完成）
 func (s *Scheduler) Stop() {
 	close(s.stopCh)
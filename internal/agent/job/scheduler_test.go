@@ -19,6 +19,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	agentexec "rag-platform/internal/agent/runtime/executor"
 )
 
 func TestScheduler_Success(t *testing.T) {
@@ -121,3 +123,33 @@ func TestScheduler_MaxConcurrency(t *testing.T) {
 		t.Errorf("expected max concurrency 2, saw %d", maxSeen)
 	}
 }
+
+func TestScheduler_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewJobStoreMem()
+	done, _ := store.Create(ctx, &Job{AgentID: "a1", Goal: "done"})
+	sched := NewScheduler(store, func(_ context.Context, j *Job) error {
+		if j.ID != done {
+			return agentexec.ErrJobWaiting
+		}
+		return nil
+	}, SchedulerConfig{RetryMax: 0})
+
+	j := sched.RunOnce(ctx)
+	if j == nil || j.ID != done {
+		t.Fatalf("RunOnce = %+v, want job %s", j, done)
+	}
+	if got, _ := store.Get(ctx, done); got.Status != StatusCompleted {
+		t.Errorf("status = %v, want completed", got.Status)
+	}
+	if j := sched.RunOnce(ctx); j != nil {
+		t.Fatalf("RunOnce on empty queue = %s, want nil", j.ID)
+	}
+
+	// ErrJobWaiting：Runner 已自行挂起，不重试也不置终态
+	waiting, _ := store.Create(ctx, &Job{AgentID: "a1", Goal: "wait"})
+	sched.RunOnce(ctx)
+	if got, _ := store.Get(ctx, waiting); got.Status == StatusFailed || got.Status == StatusPending {
+		t.Errorf("waiting job status = %v, want untouched", got.Status)
+	}
+}
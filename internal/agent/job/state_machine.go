@@ -0,0 +1,188 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// ErrIllegalTransition 状态机拒绝的 Job 状态迁移；具体信息见 *IllegalTransitionError
+var ErrIllegalTransition = errors.New("job: illegal status transition")
+
+// 非法迁移的拒绝原因（IllegalTransitionError.Reason）
+const (
+	// RejectTerminal 源状态为终态，不再接受迁移（Failed 仅可重新入队）
+	RejectTerminal = "terminal"
+	// RejectNotAllowed 迁移表中无此边
+	RejectNotAllowed = "not_allowed"
+	// RejectCancelRequested 已请求取消的 Job 不得再进入 Running
+	RejectCancelRequested = "cancel_requested"
+	// RejectConcurrentUpdate 条件更新时状态已被并发修改且多次重试仍未成功
+	RejectConcurrentUpdate = "concurrent_update"
+)
+
+// IllegalTransitionError 非法迁移的结构化错误；errors.Is(err, ErrIllegalTransition) 为 true
+type IllegalTransitionError struct {
+	JobID  string
+	From   JobStatus
+	To     JobStatus
+	Reason string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("job: illegal status transition %s -> %s (job %s): %s", e.From, e.To, e.JobID, e.Reason)
+}
+
+func (e *IllegalTransitionError) Unwrap() error { return ErrIllegalTransition }
+
+// Transition 一次合法的状态迁移；Reason 为调用方通过 WithTransitionReason 指定的原因，未指定时取迁移表中的默认原因
+type Transition struct {
+	JobID  string
+	From   JobStatus
+	To     JobStatus
+	Reason string
+	At     time.Time // 迁移生效时间；ValidateTransition 返回时为零值
+}
+
+// transitionRule 迁移表的一条边：reason 为默认原因；guard 可选，返回非空拒绝原因时拒绝迁移
+type transitionRule struct {
+	reason string
+	guard  func(j *Job) string
+}
+
+// notCancelRequested 已请求取消的 Job 只能走向 Cancelled/Failed，不得重新进入 Running
+func notCancelRequested(j *Job) string {
+	if !j.CancelRequestedAt.IsZero() {
+		return RejectCancelRequested
+	}
+	return ""
+}
+
+// transitions Job 状态机（design/formal-state-machine.md §3 的 metadata 投影）：from -> to -> rule。
+// 同状态写入视为幂等，不在表中；Completed、Cancelled 无出边，Failed 仅可重新入队。
+var transitions = map[JobStatus]map[JobStatus]transitionRule{
+	StatusPending: {
+		StatusRunning:   {reason: "claimed", guard: notCancelRequested},
+		StatusDeferred:  {reason: "maintenance_deferred"},
+		StatusFailed:    {reason: "rejected"},
+		StatusCancelled: {reason: "cancelled"},
+	},
+	StatusRunning: {
+		StatusPending:   {reason: "requeued"},
+		StatusWaiting:   {reason: "waiting"},
+		StatusParked:    {reason: "parked"},
+		StatusRetrying:  {reason: "retry_scheduled"},
+		StatusDeferred:  {reason: "maintenance_paused"},
+		StatusCompleted: {reason: "completed"},
+		StatusFailed:    {reason: "failed"},
+		StatusCancelled: {reason: "cancelled"},
+	},
+	StatusWaiting: {
+		StatusPending:   {reason: "wait_completed"},
+		StatusRunning:   {reason: "resumed", guard: notCancelRequested},
+		StatusParked:    {reason: "parked"},
+		StatusFailed:    {reason: "failed"},
+		StatusCancelled: {reason: "cancelled"},
+	},
+	StatusParked: {
+		StatusPending:   {reason: "wait_completed"},
+		StatusRunning:   {reason: "resumed", guard: notCancelRequested},
+		StatusWaiting:   {reason: "unparked"},
+		StatusFailed:    {reason: "failed"},
+		StatusCancelled: {reason: "cancelled"},
+	},
+	StatusRetrying: {
+		StatusPending:   {reason: "requeued"},
+		StatusRunning:   {reason: "claimed", guard: notCancelRequested},
+		StatusFailed:    {reason: "retries_exhausted"},
+		StatusCancelled: {reason: "cancelled"},
+	},
+	StatusDeferred: {
+		StatusPending:   {reason: "maintenance_resumed"},
+		StatusRunning:   {reason: "claimed", guard: notCancelRequested},
+		StatusFailed:    {reason: "failed"},
+		StatusCancelled: {reason: "cancelled"},
+	},
+	StatusFailed: {
+		StatusPending:  {reason: "requeued"},
+		StatusRetrying: {reason: "retry_scheduled"},
+	},
+}
+
+// CanTransition 迁移表是否允许 from -> to（不含 guard）；同状态写入总是允许
+func CanTransition(from, to JobStatus) bool {
+	if from == to {
+		return true
+	}
+	_, ok := transitions[from][to]
+	return ok
+}
+
+// AllowedTransitions 返回 from 可迁移到的状态（按状态值升序，不含自身）
+func AllowedTransitions(from JobStatus) []JobStatus {
+	out := make([]JobStatus, 0, len(transitions[from]))
+	for to := range transitions[from] {
+		out = append(out, to)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a] < out[b] })
+	return out
+}
+
+type transitionReasonKey struct{}
+
+// WithTransitionReason 为本次状态迁移指定原因（随迁移记录保存），覆盖迁移表中的默认原因
+func WithTransitionReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, transitionReasonKey{}, reason)
+}
+
+// ValidateTransition 按状态机校验 j 迁移到 to；同状态返回 From == To 的 Transition（调用方视为幂等写入，不记录迁移）。
+// 非法迁移返回 *IllegalTransitionError 并计入 aetheris_job_illegal_transitions_total
+func ValidateTransition(ctx context.Context, j *Job, to JobStatus) (Transition, error) {
+	t := Transition{JobID: j.ID, From: j.Status, To: to}
+	if t.From == to {
+		return t, nil
+	}
+	rule, ok := transitions[t.From][to]
+	reject := ""
+	switch {
+	case !ok && (t.From.IsTerminal() || len(transitions[t.From]) == 0):
+		reject = RejectTerminal
+	case !ok:
+		reject = RejectNotAllowed
+	case rule.guard != nil:
+		reject = rule.guard(j)
+	}
+	if reject != "" {
+		metrics.JobIllegalTransitionsTotal.WithLabelValues(t.From.String(), to.String()).Inc()
+		return t, &IllegalTransitionError{JobID: j.ID, From: t.From, To: to, Reason: reject}
+	}
+	t.Reason = rule.reason
+	if reason, _ := ctx.Value(transitionReasonKey{}).(string); reason != "" {
+		t.Reason = reason
+	}
+	return t, nil
+}
+
+// TransitionLister 可列出已记录状态迁移的 JobStore（JobStoreMem / JobStorePg 实现）；迁移与 status 在同一次写入中保存，
+// 同状态写入不记录。迁移不进入版本化的事件流，不会与 Runner 追加事件争用 version
+type TransitionLister interface {
+	ListTransitions(ctx context.Context, jobID string) ([]Transition, error)
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// designTable design/formal-state-machine.md §3 中的合法 (state × event) 组合
+var designTable = map[JobStatus][]jobstore.EventType{
+	StatusPending:  {jobstore.JobRequeued, jobstore.JobLeased, jobstore.JobRunning, jobstore.WaitCompleted},
+	StatusRunning:  {jobstore.JobRequeued, jobstore.JobWaiting, jobstore.WaitCompleted, jobstore.JobCompleted, jobstore.JobFailed, jobstore.JobCancelled},
+	StatusWaiting:  {jobstore.WaitCompleted, jobstore.JobCancelled},
+	StatusParked:   {jobstore.WaitCompleted, jobstore.JobCancelled},
+	StatusRetrying: {jobstore.JobRequeued, jobstore.JobLeased, jobstore.JobRunning, jobstore.WaitCompleted},
+	StatusFailed:   {jobstore.JobRequeued},
+}
+
+func TestStateMachine_CoversDesignTable(t *testing.T) {
+	for from, evs := range designTable {
+		for _, ev := range evs {
+			to := DeriveStatusFromEvents([]jobstore.JobEvent{{Type: jobstore.JobCreated}, {Type: ev}})
+			if !CanTransition(from, to) {
+				t.Errorf("design allows %s --%s--> %s, state machine rejects it", from, ev, to)
+			}
+		}
+	}
+	for _, s := range []JobStatus{StatusCompleted, StatusCancelled} {
+		if got := AllowedTransitions(s); len(got) != 0 {
+			t.Errorf("terminal %s has transitions %v", s, got)
+		}
+	}
+	if got := AllowedTransitions(StatusFailed); len(got) != 2 || got[0] != StatusPending || got[1] != StatusRetrying {
+		t.Errorf("failed transitions = %v, want only requeue/retry", got)
+	}
+}
+
+func TestValidateTransition_Rejections(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		job    Job
+		to     JobStatus
+		reason string
+	}{
+		{Job{ID: "j", Status: StatusCompleted}, StatusRunning, RejectTerminal},
+		{Job{ID: "j", Status: StatusCancelled}, StatusPending, RejectTerminal},
+		{Job{ID: "j", Status: StatusFailed}, StatusCompleted, RejectTerminal},
+		{Job{ID: "j", Status: StatusPending}, StatusWaiting, RejectNotAllowed},
+		{Job{ID: "j", Status: StatusWaiting, CancelRequestedAt: time.Now()}, StatusRunning, RejectCancelRequested},
+	}
+	for _, c := range cases {
+		_, err := ValidateTransition(ctx, &c.job, c.to)
+		var ite *IllegalTransitionError
+		if !errors.Is(err, ErrIllegalTransition) || !errors.As(err, &ite) {
+			t.Fatalf("%s -> %s: err = %v, want IllegalTransitionError", c.job.Status, c.to, err)
+		}
+		if ite.JobID != "j" || ite.From != c.job.Status || ite.To != c.to || ite.Reason != c.reason {
+			t.Errorf("error = %+v, want reason %s", ite, c.reason)
+		}
+	}
+	tr, err := ValidateTransition(WithTransitionReason(ctx, "operator"), &Job{ID: "j", Status: StatusRunning}, StatusFailed)
+	if err != nil || tr.Reason != "operator" {
+		t.Errorf("transition = %+v, %v; want reason from context", tr, err)
+	}
+}
+
+// 性质：任意状态写入序列下，JobStoreMem 只接受迁移表允许的迁移，拒绝时状态不变；记录的迁移首尾相接且不离开 Completed/Cancelled
+func TestJobStoreMem_TransitionProperty(t *testing.T) {
+	ctx := context.Background()
+	prop := func(seq []uint8) bool {
+		s := NewJobStoreMem()
+		id, _ := s.Create(ctx, &Job{AgentID: "a"})
+		for _, b := range seq {
+			to := JobStatus(b % 9)
+			before, _ := s.Get(ctx, id)
+			var err error
+			if b >= 128 && to == StatusPending {
+				err = s.Requeue(ctx, before)
+			} else {
+				err = s.UpdateStatus(ctx, id, to)
+			}
+			after, _ := s.Get(ctx, id)
+			if CanTransition(before.Status, to) {
+				if err != nil || after.Status != to {
+					return false
+				}
+			} else if !errors.Is(err, ErrIllegalTransition) || after.Status != before.Status {
+				return false
+			}
+		}
+		got, _ := s.ListTransitions(ctx, id)
+		for i, tr := range got {
+			if tr.From == tr.To || !CanTransition(tr.From, tr.To) || tr.Reason == "" || tr.At.IsZero() {
+				return false
+			}
+			if i > 0 && got[i-1].To != tr.From {
+				return false
+			}
+			if tr.From == StatusCompleted || tr.From == StatusCancelled {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+// 迁移随 status 一并记录，不写入事件流：同状态写入与非法迁移不记录
+func TestJobStoreMem_ListTransitions(t *testing.T) {
+	ctx := context.Background()
+	s := NewJobStoreMem()
+	id, _ := s.Create(ctx, &Job{AgentID: "a"})
+	if err := s.UpdateStatus(ctx, id, StatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateStatus(ctx, id, StatusRunning); err != nil {
+		t.Fatalf("same-status write: %v", err)
+	}
+	if err := s.UpdateStatus(WithTransitionReason(ctx, "wait_node"), id, StatusWaiting); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateStatus(ctx, id, StatusCompleted); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("waiting -> completed err = %v", err)
+	}
+	got, err := s.ListTransitions(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Transition{
+		{JobID: id, From: StatusPending, To: StatusRunning, Reason: "claimed"},
+		{JobID: id, From: StatusRunning, To: StatusWaiting, Reason: "wait_node"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("transitions = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		got[i].At = time.Time{}
+		if got[i] != want[i] {
+			t.Errorf("transition[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
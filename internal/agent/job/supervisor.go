@@ -0,0 +1,415 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/metrics"
+)
+
+// SupervisorPlanFunc 为子 Job 生成计划（与 API 创建 Job 时的规划一致）
+type SupervisorPlanFunc func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error)
+
+// Supervisor 监督者运行时：实现 executor.ChildJobRuntime，负责子 Job 的创建（含计划事件）、状态汇合与重派，
+// 以及子 Job 结束后唤醒在 join 节点等待的监督者
+type Supervisor struct {
+	jobs     JobStore
+	events   jobstore.JobStore
+	children ChildJobStore
+	plan     SupervisorPlanFunc
+	wakeup   WakeupQueue
+	now      func() time.Time
+}
+
+// NewSupervisor 创建监督者运行时
+func NewSupervisor(jobs JobStore, events jobstore.JobStore, children ChildJobStore, plan SupervisorPlanFunc) *Supervisor {
+	return &Supervisor{jobs: jobs, events: events, children: children, plan: plan, now: time.Now}
+}
+
+// SetWakeupQueue 设置唤醒队列；监督者重新入队时通知，Worker 可立即认领
+func (s *Supervisor) SetWakeupQueue(q WakeupQueue) {
+	s.wakeup = q
+}
+
+var _ executor.ChildJobRuntime = (*Supervisor)(nil)
+
+// SpawnChildren 实现 executor.ChildJobRuntime；已派发过的子任务（含重跑 spawn 步）直接返回最近一次派发
+func (s *Supervisor) SpawnChildren(ctx context.Context, parentJobID, nodeID string, specs []executor.ChildSpec) ([]executor.ChildOutcome, error) {
+	parent, err := s.jobs.Get(ctx, parentJobID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("supervisor job not found: %s", parentJobID)
+	}
+	all, err := s.children.ListChildren(ctx, parentJobID)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*ChildJob)
+	for _, c := range LatestChildren(all) {
+		if c.NodeID == nodeID {
+			latest[c.Key] = c
+		}
+	}
+	out := make([]executor.ChildOutcome, 0, len(specs))
+	for _, spec := range specs {
+		link := latest[spec.Key]
+		if link == nil {
+			link, err = s.dispatch(ctx, parent, nodeID, spec, 1)
+			if err != nil {
+				return out, err
+			}
+		}
+		oc, _ := s.outcome(ctx, link, false)
+		out = append(out, oc)
+	}
+	return out, nil
+}
+
+// JoinChildren 实现 executor.ChildJobRuntime
+func (s *Supervisor) JoinChildren(ctx context.Context, parentJobID string, policy executor.JoinPolicy) (*executor.JoinResult, error) {
+	parent, err := s.jobs.Get(ctx, parentJobID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("supervisor job not found: %s", parentJobID)
+	}
+	all, err := s.children.ListChildren(ctx, parentJobID)
+	if err != nil {
+		return nil, err
+	}
+	var dispatched int
+	var startedAt time.Time
+	for _, c := range all {
+		if policy.SpawnNodeID != "" && c.NodeID != policy.SpawnNodeID {
+			continue
+		}
+		dispatched++
+		if startedAt.IsZero() || c.CreatedAt.Before(startedAt) {
+			startedAt = c.CreatedAt
+		}
+	}
+	res := &executor.JoinResult{Done: true}
+	var active []*ChildJob
+	for _, link := range LatestChildren(all) {
+		if policy.SpawnNodeID != "" && link.NodeID != policy.SpawnNodeID {
+			continue
+		}
+		child, err := s.jobs.Get(ctx, link.ChildJobID)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, fmt.Errorf("child job not found: %s", link.ChildJobID)
+		}
+		if (child.Status == StatusFailed || child.Status == StatusCancelled) && link.Attempt <= policy.MaxRedispatch {
+			if policy.MaxChildren > 0 && dispatched >= policy.MaxChildren {
+				s.cancelActive(ctx, active)
+				return nil, fmt.Errorf("%w: 子 Job 数已达上限 %d", executor.ErrSupervisorBudgetExceeded, policy.MaxChildren)
+			}
+			spec := executor.ChildSpec{Key: link.Key, AgentID: link.AgentID, Goal: child.Goal}
+			next, err := s.dispatch(ctx, parent, link.NodeID, spec, link.Attempt+1)
+			if err != nil {
+				return nil, err
+			}
+			dispatched++
+			metrics.SupervisorRedispatchTotal.WithLabelValues(tenantLabel(parent.TenantID)).Inc()
+			link = next
+			if child, err = s.jobs.Get(ctx, link.ChildJobID); err != nil || child == nil {
+				return nil, fmt.Errorf("child job not found: %s", link.ChildJobID)
+			}
+		}
+		if !child.Status.IsTerminal() {
+			res.Done = false
+			active = append(active, link)
+		}
+		oc, _ := s.outcomeOf(ctx, link, child)
+		res.Children = append(res.Children, oc)
+	}
+	if !res.Done && policy.Timeout > 0 && !startedAt.IsZero() && s.now().Sub(startedAt) > policy.Timeout {
+		s.cancelActive(ctx, active)
+		return nil, fmt.Errorf("%w: 已超过总时长 %s", executor.ErrSupervisorBudgetExceeded, policy.Timeout)
+	}
+	// Settled 按全部子任务计数（不区分 spawn 节点），与 Resume 的判断口径一致
+	res.Settled, err = s.settled(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// cancelActive 超出预算时请求取消未结束的子 Job
+func (s *Supervisor) cancelActive(ctx context.Context, active []*ChildJob) {
+	for _, c := range active {
+		_ = s.jobs.RequestCancel(ctx, c.ChildJobID)
+	}
+}
+
+// settled 返回最近一次派发已终态的子任务数
+func (s *Supervisor) settled(ctx context.Context, all []*ChildJob) (int, error) {
+	n := 0
+	for _, link := range LatestChildren(all) {
+		child, err := s.jobs.Get(ctx, link.ChildJobID)
+		if err != nil {
+			return 0, err
+		}
+		if child != nil && child.Status.IsTerminal() {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// dispatch 创建一次子任务派发：子 Job、job_created 与 plan_generated 事件、关联记录；按幂等键重入，中途崩溃后重跑可补齐缺失的事件
+func (s *Supervisor) dispatch(ctx context.Context, parent *Job, nodeID string, spec executor.ChildSpec, attempt int) (*ChildJob, error) {
+	agentID := spec.AgentID
+	if agentID == "" {
+		agentID = parent.AgentID
+	}
+	idemKey := fmt.Sprintf("supervisor:%s:%s:%s:%d", parent.ID, nodeID, spec.Key, attempt)
+	child, err := s.jobs.GetByAgentAndIdempotencyKey(ctx, agentID, idemKey)
+	if err != nil {
+		return nil, err
+	}
+	childID := ""
+	if child != nil {
+		childID = child.ID
+	} else {
+		childID, err = s.jobs.Create(ctx, &Job{
+			AgentID:              agentID,
+			TenantID:             parent.TenantID,
+			Goal:                 spec.Goal,
+			Status:               StatusPending,
+			SessionID:            "supervisor-" + parent.ID + "-" + spec.Key,
+			IdempotencyKey:       idemKey,
+			RequiredCapabilities: parent.RequiredCapabilities,
+			Attribution:          parent.Attribution, // 子 Job 的副作用同样归属到发起监督者 Job 的用户
+		})
+		if err != nil {
+			return nil, fmt.Errorf("创建子 Job failed: %w", err)
+		}
+		metrics.SupervisorChildrenTotal.WithLabelValues(tenantLabel(parent.TenantID)).Inc()
+	}
+	link := &ChildJob{ParentJobID: parent.ID, ChildJobID: childID, NodeID: nodeID, Key: spec.Key, Attempt: attempt, AgentID: agentID, CreatedAt: s.now()}
+	if err := s.children.AddChild(ctx, link); err != nil {
+		return nil, err
+	}
+	if err := s.ensureChildEvents(ctx, parent, link, spec.Goal); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// ensureChildEvents 补齐子 Job 的 job_created 与 plan_generated；规划失败时子 Job 直接失败，由 join 按策略重派
+func (s *Supervisor) ensureChildEvents(ctx context.Context, parent *Job, link *ChildJob, goal string) error {
+	events, ver, err := s.events.ListEvents(ctx, link.ChildJobID)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"agent_id":      link.AgentID,
+			"goal":          goal,
+			"parent_job_id": parent.ID,
+			"parent_node":   link.NodeID,
+			"child_key":     link.Key,
+			"attempt":       link.Attempt,
+		})
+		if ver, err = s.events.Append(ctx, link.ChildJobID, ver, jobstore.JobEvent{JobID: link.ChildJobID, Type: jobstore.JobCreated, Payload: payload}); err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		if e.Type == jobstore.PlanGenerated || e.Type == jobstore.JobFailed {
+			return nil
+		}
+	}
+	var graph *planner.TaskGraph
+	if s.plan == nil {
+		err = errors.New("supervisor planner not configured")
+	} else {
+		graph, err = s.plan(ctx, link.AgentID, goal)
+		if err == nil && graph == nil {
+			err = errors.New("planner returned empty plan")
+		}
+	}
+	if err != nil {
+		info := &TerminalInfo{Reason: "plan failed: " + err.Error(), Actor: "supervisor:" + parent.ID, FailureClass: FailureClassError, At: s.now()}
+		pl := info.EventFields()
+		pl["goal"] = goal
+		pl["error"] = err.Error()
+		payload, _ := json.Marshal(pl)
+		if _, errAppend := s.events.Append(ctx, link.ChildJobID, ver, jobstore.JobEvent{JobID: link.ChildJobID, Type: jobstore.JobFailed, Payload: payload}); errAppend != nil {
+			return errAppend
+		}
+		_ = s.jobs.UpdateStatus(WithTransitionReason(ctx, "plan_failed"), link.ChildJobID, StatusFailed)
+		return RecordTerminalInfo(ctx, s.jobs, link.ChildJobID, info)
+	}
+	graphBytes, err := graph.Marshal()
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(graphBytes)
+	payload, _ := json.Marshal(map[string]interface{}{
+		"task_graph": json.RawMessage(graphBytes),
+		"goal":       goal,
+		"plan_hash":  hex.EncodeToString(sum[:]),
+	})
+	_, err = s.events.Append(ctx, link.ChildJobID, ver, jobstore.JobEvent{JobID: link.ChildJobID, Type: jobstore.PlanGenerated, Payload: payload})
+	return err
+}
+
+// outcome 读取子 Job 状态；withResult 为 true 时附带已完成子 Job 的输出
+func (s *Supervisor) outcome(ctx context.Context, link *ChildJob, withResult bool) (executor.ChildOutcome, error) {
+	child, err := s.jobs.Get(ctx, link.ChildJobID)
+	if err != nil || child == nil {
+		return executor.ChildOutcome{Key: link.Key, JobID: link.ChildJobID, AgentID: link.AgentID, Attempt: link.Attempt, Status: "unknown"}, err
+	}
+	if !withResult {
+		return executor.ChildOutcome{Key: link.Key, JobID: link.ChildJobID, AgentID: link.AgentID, Attempt: link.Attempt, Status: child.Status.String()}, nil
+	}
+	return s.outcomeOf(ctx, link, child)
+}
+
+func (s *Supervisor) outcomeOf(ctx context.Context, link *ChildJob, child *Job) (executor.ChildOutcome, error) {
+	oc := executor.ChildOutcome{Key: link.Key, JobID: link.ChildJobID, AgentID: link.AgentID, Attempt: link.Attempt, Status: child.Status.String()}
+	if child.Status != StatusCompleted {
+		return oc, nil
+	}
+	events, _, err := s.events.ListEvents(ctx, link.ChildJobID)
+	if err != nil {
+		return oc, err
+	}
+	oc.Result = ChildResultFromEvents(events)
+	return oc, nil
+}
+
+// ChildResultFromEvents 子 Job 的结果：最后一个成功 node_finished 中该节点的输出（llm 节点取 output 字段）
+func ChildResultFromEvents(events []jobstore.JobEvent) any {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type != jobstore.NodeFinished {
+			continue
+		}
+		var pl struct {
+			NodeID         string                     `json:"node_id"`
+			ResultType     string                     `json:"result_type"`
+			PayloadResults map[string]json.RawMessage `json:"payload_results"`
+		}
+		if json.Unmarshal(events[i].Payload, &pl) != nil || (pl.ResultType != "" && pl.ResultType != string(executor.StepResultSuccess)) {
+			continue
+		}
+		raw, ok := pl.PayloadResults[pl.NodeID]
+		if !ok {
+			continue
+		}
+		var v any
+		if json.Unmarshal(raw, &v) != nil {
+			continue
+		}
+		if m, ok := v.(map[string]any); ok {
+			if out, ok := m["output"]; ok {
+				return out
+			}
+		}
+		return v
+	}
+	return nil
+}
+
+// NotifyTerminal 子 Job 进入终态后调用：若其监督者在 join 节点等待，则尝试唤醒
+func (s *Supervisor) NotifyTerminal(ctx context.Context, childJobID string) error {
+	if s == nil {
+		return nil
+	}
+	link, err := s.children.GetParent(ctx, childJobID)
+	if err != nil || link == nil {
+		return err
+	}
+	_, err = s.Resume(ctx, link.ParentJobID)
+	return err
+}
+
+// Resume 监督者在 join 节点等待且挂起后又有子任务进入终态时，写 job_requeued 并置回 Pending，join 节点重新执行（汇合或重派）。
+// 子 Job 结束（NotifyTerminal）与监督者挂起后（Worker 在 ErrJobWaiting 后调用）两侧都会检查，避免子 Job 恰在挂起前结束时漏唤醒
+func (s *Supervisor) Resume(ctx context.Context, parentJobID string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	parent, err := s.jobs.Get(ctx, parentJobID)
+	if err != nil || parent == nil {
+		return false, err
+	}
+	if parent.Status != StatusWaiting && parent.Status != StatusParked {
+		return false, nil
+	}
+	events, ver, err := s.events.ListEvents(ctx, parentJobID)
+	if err != nil || len(events) == 0 {
+		return false, err
+	}
+	last := events[len(events)-1]
+	if last.Type != jobstore.JobWaiting {
+		return false, nil
+	}
+	wait, err := jobstore.ParseJobWaitingPayload(last.Payload)
+	if err != nil || wait.WaitKind != planner.WaitKindChildren {
+		return false, nil
+	}
+	before, ok := executor.ParseJoinSettled(wait.CorrelationKey)
+	if !ok {
+		return false, nil
+	}
+	all, err := s.children.ListChildren(ctx, parentJobID)
+	if err != nil {
+		return false, err
+	}
+	now, err := s.settled(ctx, all)
+	if err != nil || now <= before {
+		return false, err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{"reason": "children_settled", "node_id": wait.NodeID, "settled": now})
+	if _, err := s.events.Append(ctx, parentJobID, ver, jobstore.JobEvent{JobID: parentJobID, Type: jobstore.JobRequeued, Payload: payload}); err != nil {
+		if errors.Is(err, jobstore.ErrVersionMismatch) {
+			// 另一侧已唤醒
+			return false, nil
+		}
+		return false, err
+	}
+	if err := s.jobs.UpdateStatus(WithTransitionReason(ctx, "children_settled"), parentJobID, StatusPending); err != nil {
+		return false, err
+	}
+	if s.wakeup != nil {
+		_ = s.wakeup.NotifyReady(ctx, parentJobID)
+	}
+	return true, nil
+}
+
+func tenantLabel(tenantID string) string {
+	if tenantID == "" {
+		return "default"
+	}
+	return tenantID
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func newTestSupervisor() (*Supervisor, *JobStoreMem, jobstore.JobStore) {
+	jobs := NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	plan := func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+		return &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeLLM}}}, nil
+	}
+	return NewSupervisor(jobs, events, NewChildJobStoreMem(), plan), jobs, events
+}
+
+func hasEvent(events []jobstore.JobEvent, t jobstore.EventType) bool {
+	for _, e := range events {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSupervisor_SpawnJoinRedispatchAndResume(t *testing.T) {
+	ctx := context.Background()
+	sup, jobs, events := newTestSupervisor()
+	parentID, _ := jobs.Create(ctx, &Job{AgentID: "sup", TenantID: "t1", Goal: "report"})
+	specs := []executor.ChildSpec{{Key: "a", AgentID: "researcher", Goal: "research"}, {Key: "b", AgentID: "writer", Goal: "write"}}
+
+	out, err := sup.SpawnChildren(ctx, parentID, "s1", specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[0].Attempt != 1 {
+		t.Fatalf("unexpected spawn outcome: %+v", out)
+	}
+	again, _ := sup.SpawnChildren(ctx, parentID, "s1", specs)
+	if again[0].JobID != out[0].JobID || again[1].JobID != out[1].JobID {
+		t.Fatal("spawn should be idempotent per parent/node/key")
+	}
+	childA, _ := jobs.Get(ctx, out[0].JobID)
+	if childA.TenantID != "t1" || childA.AgentID != "researcher" {
+		t.Fatalf("child should inherit tenant and use its agent: %+v", childA)
+	}
+	evA, _, _ := events.ListEvents(ctx, out[0].JobID)
+	if !hasEvent(evA, jobstore.JobCreated) || !hasEvent(evA, jobstore.PlanGenerated) {
+		t.Fatal("child job should have job_created and plan_generated events")
+	}
+
+	policy := executor.JoinPolicy{SpawnNodeID: "s1", MaxRedispatch: 1, RequireAll: true}
+	res, err := sup.JoinChildren(ctx, parentID, policy)
+	if err != nil || res.Done || res.Settled != 0 {
+		t.Fatalf("children still running: res=%+v err=%v", res, err)
+	}
+
+	// 监督者在 join 节点挂起
+	_ = jobs.UpdateStatus(ctx, parentID, StatusRunning)
+	_ = jobs.UpdateStatus(ctx, parentID, StatusWaiting)
+	_, ver, _ := events.ListEvents(ctx, parentID)
+	waitPayload, _ := json.Marshal(map[string]string{"node_id": "j1", "correlation_key": executor.JoinCorrelationKey(parentID, "j1", 0), "wait_kind": planner.WaitKindChildren})
+	_, _ = events.Append(ctx, parentID, ver, jobstore.JobEvent{JobID: parentID, Type: jobstore.JobWaiting, Payload: waitPayload})
+	if woke, _ := sup.Resume(ctx, parentID); woke {
+		t.Fatal("no child settled yet, supervisor should keep waiting")
+	}
+
+	// 子任务 a 完成：唤醒监督者
+	_, ver, _ = events.ListEvents(ctx, out[0].JobID)
+	finished, _ := json.Marshal(map[string]any{"node_id": "n1", "result_type": "success", "payload_results": map[string]any{"n1": map[string]any{"output": "A done"}}})
+	_, _ = events.Append(ctx, out[0].JobID, ver, jobstore.JobEvent{JobID: out[0].JobID, Type: jobstore.NodeFinished, Payload: finished})
+	_ = jobs.UpdateStatus(ctx, out[0].JobID, StatusRunning)
+	_ = jobs.UpdateStatus(ctx, out[0].JobID, StatusCompleted)
+	if err := sup.NotifyTerminal(ctx, out[0].JobID); err != nil {
+		t.Fatal(err)
+	}
+	parent, _ := jobs.Get(ctx, parentID)
+	if parent.Status != StatusPending {
+		t.Fatalf("supervisor should be requeued, got %s", parent.Status)
+	}
+	evP, _, _ := events.ListEvents(ctx, parentID)
+	if evP[len(evP)-1].Type != jobstore.JobRequeued {
+		t.Fatalf("expected job_requeued, got %s", evP[len(evP)-1].Type)
+	}
+
+	// 子任务 b 失败：join 重派一次
+	_ = jobs.UpdateStatus(ctx, out[1].JobID, StatusFailed)
+	res, err = sup.JoinChildren(ctx, parentID, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Done || res.Children[1].Attempt != 2 || res.Children[1].JobID == out[1].JobID {
+		t.Fatalf("failed child should be redispatched: %+v", res)
+	}
+	if res.Children[0].Result != "A done" {
+		t.Fatalf("completed child result = %v", res.Children[0].Result)
+	}
+
+	// 重派的子任务再次失败：重派次数用尽，join 完成（由节点按 require_all 判定）
+	_ = jobs.UpdateStatus(ctx, res.Children[1].JobID, StatusFailed)
+	res, err = sup.JoinChildren(ctx, parentID, policy)
+	if err != nil || !res.Done || res.Children[1].Status != "failed" || res.Settled != 2 {
+		t.Fatalf("join should settle after redispatch exhausted: res=%+v err=%v", res, err)
+	}
+
+	hier, err := BuildHierarchy(ctx, jobs, NewChildJobStoreMem(), parentID, 3)
+	if err != nil || hier != nil {
+		t.Fatalf("empty child store should yield no hierarchy: %+v %v", hier, err)
+	}
+	hier, err = BuildHierarchy(ctx, jobs, sup.children, parentID, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hier.Rollup.Total != 2 || hier.Rollup.Completed != 1 || hier.Rollup.Failed != 1 || hier.Rollup.Status != "failed" {
+		t.Fatalf("unexpected rollup: %+v", hier.Rollup)
+	}
+	childHier, _ := BuildHierarchy(ctx, jobs, sup.children, out[0].JobID, 3)
+	if childHier == nil || childHier.Parent == nil || childHier.Parent.ParentJobID != parentID {
+		t.Fatalf("child hierarchy should link to supervisor: %+v", childHier)
+	}
+}
+
+func TestSupervisor_BudgetExceeded(t *testing.T) {
+	ctx := context.Background()
+	sup, jobs, _ := newTestSupervisor()
+	parentID, _ := jobs.Create(ctx, &Job{AgentID: "sup", Goal: "report"})
+	out, err := sup.SpawnChildren(ctx, parentID, "s1", []executor.ChildSpec{{Key: "a", Goal: "x"}, {Key: "b", Goal: "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 总时长超限：未结束的子 Job 请求取消
+	sup.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, err = sup.JoinChildren(ctx, parentID, executor.JoinPolicy{Timeout: time.Minute})
+	if !errors.Is(err, executor.ErrSupervisorBudgetExceeded) {
+		t.Fatalf("expected budget exceeded, got %v", err)
+	}
+	child, _ := jobs.Get(ctx, out[0].JobID)
+	if child.CancelRequestedAt.IsZero() {
+		t.Fatal("active child should be cancelled when budget is exceeded")
+	}
+
+	// 子 Job 数超限：不再重派
+	sup.now = time.Now
+	_ = jobs.UpdateStatus(ctx, out[0].JobID, StatusFailed)
+	_, err = sup.JoinChildren(ctx, parentID, executor.JoinPolicy{MaxRedispatch: 1, MaxChildren: 2})
+	if !errors.Is(err, executor.ErrSupervisorBudgetExceeded) {
+		t.Fatalf("expected max_children budget exceeded, got %v", err)
+	}
+}
+
+func TestSupervisor_PlanFailureFailsChild(t *testing.T) {
+	ctx := context.Background()
+	jobs := NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	sup := NewSupervisor(jobs, events, NewChildJobStoreMem(), func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+		return nil, errors.New("planner down")
+	})
+	parentID, _ := jobs.Create(ctx, &Job{AgentID: "sup", Goal: "report"})
+	out, err := sup.SpawnChildren(ctx, parentID, "s1", []executor.ChildSpec{{Key: "a", Goal: "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, _ := jobs.Get(ctx, out[0].JobID)
+	if child.Status != StatusFailed || child.Terminal == nil {
+		t.Fatalf("child with failed plan should be failed with terminal info: %+v", child)
+	}
+	ev, _, _ := events.ListEvents(ctx, out[0].JobID)
+	if !hasEvent(ev, jobstore.JobFailed) {
+		t.Fatal("child with failed plan should have job_failed event")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// 失败分类（TerminalInfo.FailureClass）：与 executor.StepResultType 的失败类型一致，另有以下取值
+const (
+	// FailureClassError 未分类的执行错误
+	FailureClassError = "error"
+	// FailureClassMaxAttempts 达到最大尝试次数（毒任务保护）
+	FailureClassMaxAttempts = "max_attempts_exceeded"
+	// FailureClassCancelled 被取消
+	FailureClassCancelled = "cancelled"
+	// FailureClassCompensatable 可补偿失败（对应 executor.StepResultCompensatableFailure）
+	FailureClassCompensatable = "compensatable_failure"
+	// FailureClassPlanOverBudget Job 创建时计划预估成本/ETA 超出租户或 Job 预算而被拒绝
+	FailureClassPlanOverBudget = "plan_over_budget"
+)
+
+// 补偿状态（TerminalInfo.Compensation）
+const (
+	// CompensationNotRequired 终态无需补偿
+	CompensationNotRequired = "not_required"
+	// CompensationCompleted 已有步骤执行补偿（存在 step_compensated 事件）
+	CompensationCompleted = "compensated"
+	// CompensationNotRun 可补偿失败但未执行任何补偿（无补偿回调或回调失败）
+	CompensationNotRun = "not_compensated"
+)
+
+// TerminalInfo 终态元数据：取消原因、发起者、失败节点、失败分类与补偿状态；持久化在 Job 行并写入终态事件
+type TerminalInfo struct {
+	Reason       string    `json:"reason,omitempty"`
+	Actor        string    `json:"actor,omitempty"` // 取消时为请求用户，失败时为 worker:<id>
+	FailedNodeID string    `json:"failed_node_id,omitempty"`
+	FailureClass string    `json:"failure_class,omitempty"`
+	Compensation string    `json:"compensation,omitempty"`
+	At           time.Time `json:"at,omitempty"`
+}
+
+// EventFields 返回写入终态事件 payload 的字段（空值省略）
+func (t *TerminalInfo) EventFields() map[string]interface{} {
+	out := map[string]interface{}{}
+	if t == nil {
+		return out
+	}
+	if t.Reason != "" {
+		out["reason"] = t.Reason
+	}
+	if t.Actor != "" {
+		out["actor"] = t.Actor
+	}
+	if t.FailedNodeID != "" {
+		out["node_id"] = t.FailedNodeID
+	}
+	if t.FailureClass != "" {
+		out["failure_class"] = t.FailureClass
+	}
+	if t.Compensation != "" {
+		out["compensation"] = t.Compensation
+	}
+	return out
+}
+
+// TerminalInfoStore 可选接口：持久化 Job 终态元数据（JobStoreMem 与 JobStorePg 实现）
+type TerminalInfoStore interface {
+	SetTerminalInfo(ctx context.Context, jobID string, info *TerminalInfo) error
+}
+
+// RecordTerminalInfo 若 store 实现 TerminalInfoStore 则持久化终态元数据，否则忽略
+func RecordTerminalInfo(ctx context.Context, store JobStore, jobID string, info *TerminalInfo) error {
+	s, ok := store.(TerminalInfoStore)
+	if !ok || info == nil {
+		return nil
+	}
+	if info.At.IsZero() {
+		info.At = time.Now().UTC()
+	}
+	return s.SetTerminalInfo(ctx, jobID, info)
+}
+
+// CompensationStatus 由事件流判定补偿状态：事务组回滚中有补偿failed为 not_compensated；存在 step_compensated 为 compensated；
+// 可补偿失败而无补偿为 not_compensated；否则 not_required
+func CompensationStatus(events []jobstore.JobEvent, failureClass string) string {
+	for _, tx := range jobstore.TransactionsOf(events) {
+		for _, c := range tx.Compensations {
+			if c.Status == jobstore.CompensationStatusFailed {
+				return CompensationNotRun
+			}
+		}
+	}
+	for _, e := range events {
+		if e.Type == jobstore.StepCompensated {
+			return CompensationCompleted
+		}
+	}
+	if failureClass == FailureClassCompensatable {
+		return CompensationNotRun
+	}
+	return CompensationNotRequired
+}
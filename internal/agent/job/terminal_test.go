@@ -0,0 +1,74 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestCompensationStatus(t *testing.T) {
+	compensated := []jobstore.JobEvent{{Type: jobstore.NodeStarted}, {Type: jobstore.StepCompensated}}
+	if got := CompensationStatus(compensated, FailureClassCompensatable); got != CompensationCompleted {
+		t.Errorf("with step_compensated: got %q, want %q", got, CompensationCompleted)
+	}
+	if got := CompensationStatus(nil, FailureClassCompensatable); got != CompensationNotRun {
+		t.Errorf("compensatable without compensation: got %q, want %q", got, CompensationNotRun)
+	}
+	if got := CompensationStatus(nil, FailureClassError); got != CompensationNotRequired {
+		t.Errorf("plain error: got %q, want %q", got, CompensationNotRequired)
+	}
+	rolledBack := append(compensated, jobstore.JobEvent{
+		Type:    jobstore.TransactionRolledBack,
+		Payload: []byte(`{"transaction_id":"tx1","members":["a","b"],"compensations":[{"node_id":"a","status":"failed","error":"refund failed"}]}`),
+	})
+	if got := CompensationStatus(rolledBack, FailureClassError); got != CompensationNotRun {
+		t.Errorf("transaction with failed compensation: got %q, want %q", got, CompensationNotRun)
+	}
+}
+
+func TestTerminalInfo_EventFields(t *testing.T) {
+	var nilInfo *TerminalInfo
+	if got := nilInfo.EventFields(); len(got) != 0 {
+		t.Errorf("nil info fields = %v, want empty", got)
+	}
+	info := &TerminalInfo{Reason: "user_abort", Actor: "u1", FailedNodeID: "n2", FailureClass: FailureClassCancelled}
+	got := info.EventFields()
+	if got["reason"] != "user_abort" || got["actor"] != "u1" || got["node_id"] != "n2" || got["failure_class"] != FailureClassCancelled {
+		t.Errorf("EventFields = %v", got)
+	}
+	if _, ok := got["compensation"]; ok {
+		t.Errorf("empty compensation should be omitted: %v", got)
+	}
+}
+
+func TestRecordTerminalInfo_Mem(t *testing.T) {
+	ctx := context.Background()
+	s := NewJobStoreMem()
+	id, err := s.Create(ctx, &Job{AgentID: "agent-1", Goal: "g", Status: StatusRunning})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	info := &TerminalInfo{Reason: "boom", Actor: "worker:w1", FailedNodeID: "n1", FailureClass: FailureClassError}
+	if err := RecordTerminalInfo(ctx, s, id, info); err != nil {
+		t.Fatalf("RecordTerminalInfo: %v", err)
+	}
+	got, _ := s.Get(ctx, id)
+	if got.Terminal == nil || got.Terminal.Reason != "boom" || got.Terminal.FailedNodeID != "n1" || got.Terminal.At.IsZero() {
+		t.Fatalf("Terminal = %+v", got.Terminal)
+	}
+}
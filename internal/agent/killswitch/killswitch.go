@@ -0,0 +1,219 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package killswitch 全局工具熔断：按工具类别（network-write、payments、code-exec 等）跨租户禁止执行，
+// 状态由 POST /api/admin/killswitch 维护，Worker 经 Gate 短周期刷新；被熔断的步骤以 killswitch_active 永久失败（fail closed）
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rag-platform/pkg/metrics"
+)
+
+// 内置工具类别；工具经 Categories 声明或由配置 agent.tool_categories 标注，也可使用自定义类别
+const (
+	CategoryNetworkWrite = "network-write" // 对外部系统的写操作（HTTP POST/PUT/DELETE、发送消息等）
+	CategoryPayments     = "payments"      // 支付、退款、转账
+	CategoryCodeExec     = "code-exec"     // 执行任意代码或命令
+)
+
+// ReasonCode 步骤因熔断失败时错误信息的前缀
+const ReasonCode = "killswitch_active"
+
+// BuiltinCategories 内置类别，GET /api/admin/killswitch 总是列出
+var BuiltinCategories = []string{CategoryNetworkWrite, CategoryPayments, CategoryCodeExec}
+
+// NormalizeCategory 规整类别名：去空白、小写
+func NormalizeCategory(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
+
+// Switch 某一工具类别的熔断状态
+type Switch struct {
+	Category  string    `json:"category"`
+	Active    bool      `json:"active"`
+	Reason    string    `json:"reason,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuditEntry 熔断开关的一次变更（开启或解除），供审计
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	Category  string    `json:"category"`
+	Active    bool      `json:"active"`
+	Reason    string    `json:"reason,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store 熔断状态存储；全局（不分租户）
+type Store interface {
+	// Set 写入类别的当前状态并追加一条变更记录
+	Set(ctx context.Context, s *Switch) error
+	// List 返回已记录的全部类别状态（按类别名排序）
+	List(ctx context.Context) ([]*Switch, error)
+	// History 返回最近 limit 条变更记录（新 → 旧）；limit<=0 时默认 50
+	History(ctx context.Context, limit int) ([]*AuditEntry, error)
+}
+
+const defaultHistoryLimit = 50
+
+// StoreMem 内存实现（单进程 / 开发模式）
+type StoreMem struct {
+	mu       sync.RWMutex
+	switches map[string]*Switch
+	history  []*AuditEntry
+}
+
+// NewStoreMem 创建内存熔断存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{switches: make(map[string]*Switch)}
+}
+
+func (s *StoreMem) Set(ctx context.Context, sw *Switch) error {
+	if sw == nil || NormalizeCategory(sw.Category) == "" {
+		return errors.New("killswitch: category is required")
+	}
+	cp := *sw
+	cp.Category = NormalizeCategory(cp.Category)
+	if cp.UpdatedAt.IsZero() {
+		cp.UpdatedAt = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.switches[cp.Category] = &cp
+	s.history = append(s.history, &AuditEntry{
+		ID:        "ks-" + uuid.New().String(),
+		Category:  cp.Category,
+		Active:    cp.Active,
+		Reason:    cp.Reason,
+		Actor:     cp.UpdatedBy,
+		CreatedAt: cp.UpdatedAt,
+	})
+	return nil
+}
+
+func (s *StoreMem) List(ctx context.Context) ([]*Switch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Switch, 0, len(s.switches))
+	for _, sw := range s.switches {
+		cp := *sw
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Category < out[j].Category })
+	return out, nil
+}
+
+func (s *StoreMem) History(ctx context.Context, limit int) ([]*AuditEntry, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*AuditEntry, 0, limit)
+	for i := len(s.history) - 1; i >= 0 && len(out) < limit; i-- {
+		cp := *s.history[i]
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+// Gate 执行侧的熔断判定；状态按 ttl 缓存，避免每次工具调用都查库。刷新失败时沿用上次状态
+type Gate struct {
+	store Store
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	active   map[string]*Switch // category -> 生效中的熔断
+}
+
+// NewGate 创建 Gate；ttl<=0 时默认 2s（开关在各 Worker 上的最大生效延迟）
+func NewGate(store Store, ttl time.Duration) *Gate {
+	if ttl <= 0 {
+		ttl = 2 * time.Second
+	}
+	return &Gate{store: store, ttl: ttl}
+}
+
+// Active 返回当前生效的熔断（按类别名排序）
+func (g *Gate) Active(ctx context.Context) []*Switch {
+	if g == nil || g.store == nil {
+		return nil
+	}
+	active := g.load(ctx)
+	out := make([]*Switch, 0, len(active))
+	for _, sw := range active {
+		cp := *sw
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Category < out[j].Category })
+	return out
+}
+
+// BlockedCategory 返回 categories 中处于熔断状态的第一个类别及原因；实现 executor.ToolKillSwitch
+func (g *Gate) BlockedCategory(ctx context.Context, categories []string) (string, string, bool) {
+	if g == nil || g.store == nil || len(categories) == 0 {
+		return "", "", false
+	}
+	active := g.load(ctx)
+	for _, c := range categories {
+		if sw, ok := active[NormalizeCategory(c)]; ok {
+			return sw.Category, sw.Reason, true
+		}
+	}
+	return "", "", false
+}
+
+// Invalidate 清除缓存（API 变更开关后调用，使本进程立即生效）
+func (g *Gate) Invalidate() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.active = nil
+	g.mu.Unlock()
+}
+
+func (g *Gate) load(ctx context.Context) map[string]*Switch {
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active == nil || now.Sub(g.loadedAt) >= g.ttl {
+		switches, err := g.store.List(ctx)
+		if err == nil {
+			active := make(map[string]*Switch)
+			metrics.ToolKillSwitchActive.Reset()
+			for _, sw := range switches {
+				if sw.Active {
+					active[sw.Category] = sw
+					metrics.ToolKillSwitchActive.WithLabelValues(sw.Category).Set(1)
+				}
+			}
+			g.active = active
+			g.loadedAt = now
+		}
+	}
+	return g.active
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package killswitch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStoreMem_SetListHistory(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	if err := s.Set(ctx, &Switch{Category: " Payments ", Active: true, Reason: "fraud spike", UpdatedBy: "alice"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, &Switch{Category: CategoryCodeExec, Active: true}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, &Switch{Category: CategoryPayments, Active: false, UpdatedBy: "bob"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, &Switch{Category: "  "}); err == nil {
+		t.Fatal("empty category should be rejected")
+	}
+
+	list, _ := s.List(ctx)
+	if len(list) != 2 || list[0].Category != CategoryCodeExec || list[1].Category != CategoryPayments || list[1].Active {
+		t.Fatalf("list = %+v", list)
+	}
+	history, _ := s.History(ctx, 0)
+	if len(history) != 3 || history[0].Actor != "bob" || history[0].Active || history[2].Reason != "fraud spike" {
+		t.Fatalf("history (newest first) = %+v", history)
+	}
+	if h, _ := s.History(ctx, 1); len(h) != 1 {
+		t.Fatalf("history limit: %d", len(h))
+	}
+}
+
+func TestGate_BlockedCategoryAndInvalidate(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	g := NewGate(s, time.Hour)
+
+	if _, _, blocked := g.BlockedCategory(ctx, []string{CategoryNetworkWrite}); blocked {
+		t.Fatal("nothing should be blocked initially")
+	}
+	_ = s.Set(ctx, &Switch{Category: CategoryNetworkWrite, Active: true, Reason: "outbound incident"})
+	// 缓存未过期：变更尚不可见
+	if _, _, blocked := g.BlockedCategory(ctx, []string{CategoryNetworkWrite}); blocked {
+		t.Fatal("cached state should not see the change before ttl or Invalidate")
+	}
+	g.Invalidate()
+	category, reason, blocked := g.BlockedCategory(ctx, []string{CategoryPayments, "Network-Write"})
+	if !blocked || category != CategoryNetworkWrite || reason != "outbound incident" {
+		t.Fatalf("BlockedCategory = %q %q %v", category, reason, blocked)
+	}
+	if active := g.Active(ctx); len(active) != 1 || active[0].Category != CategoryNetworkWrite {
+		t.Fatalf("Active = %+v", active)
+	}
+	if _, _, blocked := g.BlockedCategory(ctx, nil); blocked {
+		t.Fatal("tool without categories must not be blocked")
+	}
+}
+
+func TestGate_RefreshesAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	g := NewGate(s, 10*time.Millisecond)
+	_ = g.Active(ctx)
+	_ = s.Set(ctx, &Switch{Category: CategoryPayments, Active: true})
+	time.Sleep(20 * time.Millisecond)
+	if _, _, blocked := g.BlockedCategory(ctx, []string{CategoryPayments}); !blocked {
+		t.Fatal("gate should pick up the switch after ttl")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 tool_killswitches（当前状态）与 tool_killswitch_audit（变更记录）表
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的熔断存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+func (s *StorePg) Set(ctx context.Context, sw *Switch) error {
+	if sw == nil || NormalizeCategory(sw.Category) == "" {
+		return errors.New("killswitch: category is required")
+	}
+	category := NormalizeCategory(sw.Category)
+	updatedAt := sw.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO tool_killswitches (category, active, reason, updated_by, updated_at) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (category) DO UPDATE SET active = EXCLUDED.active, reason = EXCLUDED.reason, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		category, sw.Active, sw.Reason, sw.UpdatedBy, updatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO tool_killswitch_audit (id, category, active, reason, actor, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		"ks-"+uuid.New().String(), category, sw.Active, sw.Reason, sw.UpdatedBy, updatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *StorePg) List(ctx context.Context) ([]*Switch, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT category, active, reason, updated_by, updated_at FROM tool_killswitches ORDER BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Switch
+	for rows.Next() {
+		var sw Switch
+		if err := rows.Scan(&sw.Category, &sw.Active, &sw.Reason, &sw.UpdatedBy, &sw.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &sw)
+	}
+	return out, rows.Err()
+}
+
+func (s *StorePg) History(ctx context.Context, limit int) ([]*AuditEntry, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, category, active, reason, actor, created_at FROM tool_killswitch_audit ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Category, &e.Active, &e.Reason, &e.Actor, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+
+	"rag-platform/internal/agent/runtime"
+)
+
+// ScopedLongTerm 限定在单个租户/Agent（或其租户内共享空间）的长期记忆访问器；键由 runtime.MemoryScope 构造
+type ScopedLongTerm struct {
+	store  LongTermMemoryStore
+	scope  runtime.MemoryScope
+	policy runtime.SharingPolicy
+	owner  string
+}
+
+// NewScopedLongTerm 以 scope 包装 store；policy 决定 Shared 可打开的共享空间，nil 表示不允许共享
+func NewScopedLongTerm(store LongTermMemoryStore, scope runtime.MemoryScope, policy runtime.SharingPolicy) (*ScopedLongTerm, error) {
+	if err := scope.Validate(); err != nil {
+		return nil, err
+	}
+	return &ScopedLongTerm{store: store, scope: scope, policy: policy, owner: scope.Owner()}, nil
+}
+
+// Shared 打开租户内的命名共享空间；策略未放行时返回 runtime.ErrSharedSpaceDenied
+func (m *ScopedLongTerm) Shared(space string) (*ScopedLongTerm, error) {
+	if err := runtime.CheckShared(m.policy, m.scope, space); err != nil {
+		return nil, err
+	}
+	return &ScopedLongTerm{store: m.store, scope: m.scope, owner: m.scope.SharedOwner(space)}, nil
+}
+
+// Get 读取本作用域的 namespace/key
+func (m *ScopedLongTerm) Get(ctx context.Context, namespace, key string) ([]byte, error) {
+	return m.store.Get(ctx, m.owner, namespace, key)
+}
+
+// Set 写入本作用域的 namespace/key
+func (m *ScopedLongTerm) Set(ctx context.Context, namespace, key string, value []byte) error {
+	return m.store.Set(ctx, m.owner, namespace, key, value)
+}
+
+// List 列出本作用域的条目；namespace 为空时列出全部
+func (m *ScopedLongTerm) List(ctx context.Context, namespace string, limit int) ([]KeyValue, error) {
+	return m.store.ListByAgent(ctx, m.owner, namespace, limit)
+}
+
+// ScopedEpisodic 限定在单个租户/Agent（或其租户内共享空间）的情景记忆访问器
+type ScopedEpisodic struct {
+	store  EpisodicMemoryStore
+	scope  runtime.MemoryScope
+	policy runtime.SharingPolicy
+	owner  string
+}
+
+// NewScopedEpisodic 以 scope 包装 store；policy 同 NewScopedLongTerm
+func NewScopedEpisodic(store EpisodicMemoryStore, scope runtime.MemoryScope, policy runtime.SharingPolicy) (*ScopedEpisodic, error) {
+	if err := scope.Validate(); err != nil {
+		return nil, err
+	}
+	return &ScopedEpisodic{store: store, scope: scope, policy: policy, owner: scope.Owner()}, nil
+}
+
+// Shared 打开租户内的命名共享空间；策略未放行时返回 runtime.ErrSharedSpaceDenied
+func (m *ScopedEpisodic) Shared(space string) (*ScopedEpisodic, error) {
+	if err := runtime.CheckShared(m.policy, m.scope, space); err != nil {
+		return nil, err
+	}
+	return &ScopedEpisodic{store: m.store, scope: m.scope, owner: m.scope.SharedOwner(space)}, nil
+}
+
+// Append 追加一条；entry.AgentID 为空时记为当前 Agent，属于其他 Agent 时返回 runtime.ErrCrossScopeAccess。
+// 共享空间内的条目保留写入者 AgentID
+func (m *ScopedEpisodic) Append(ctx context.Context, entry *EpisodicEntry) error {
+	if entry == nil {
+		return nil
+	}
+	if entry.AgentID != "" && entry.AgentID != m.scope.AgentID {
+		return runtime.ErrCrossScopeAccess
+	}
+	cp := *entry
+	cp.AgentID = m.owner
+	if cp.Payload == nil {
+		cp.Payload = map[string]any{}
+	} else {
+		cp.Payload = copyPayload(entry.Payload)
+	}
+	cp.Payload[episodicWriterKey] = m.scope.AgentID
+	if err := m.store.Append(ctx, &cp); err != nil {
+		return err
+	}
+	entry.ID, entry.CreatedAt = cp.ID, cp.CreatedAt
+	return nil
+}
+
+// List 返回本作用域最近的条目（新到旧）
+func (m *ScopedEpisodic) List(ctx context.Context, limit int) ([]*EpisodicEntry, error) {
+	list, err := m.store.ListByAgent(ctx, m.owner, limit)
+	if err != nil {
+		return nil, err
+	}
+	return m.unscope(list)
+}
+
+// ListBySession 返回本作用域某会话最近的条目（新到旧）
+func (m *ScopedEpisodic) ListBySession(ctx context.Context, sessionID string, limit int) ([]*EpisodicEntry, error) {
+	list, err := m.store.ListBySession(ctx, m.owner, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return m.unscope(list)
+}
+
+// episodicWriterKey Payload 中记录写入 Agent 的保留键；读取时还原为 AgentID
+const episodicWriterKey = "_agent_id"
+
+// unscope 校验存储返回的条目均属于本作用域，并把 AgentID 还原为写入者
+func (m *ScopedEpisodic) unscope(list []*EpisodicEntry) ([]*EpisodicEntry, error) {
+	for _, e := range list {
+		if e.AgentID != m.owner {
+			return nil, runtime.ErrCrossScopeAccess
+		}
+		writer, _ := e.Payload[episodicWriterKey].(string)
+		if writer == "" {
+			writer = m.scope.AgentID
+		}
+		e.AgentID = writer
+		delete(e.Payload, episodicWriterKey)
+	}
+	return list, nil
+}
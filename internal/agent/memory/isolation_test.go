@@ -0,0 +1,109 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"rag-platform/internal/agent/runtime"
+)
+
+func TestScopedLongTerm_CrossAccess(t *testing.T) {
+	ctx := context.Background()
+	store := NewLongTermMemoryStoreMem()
+	policy := runtime.SharedSpaces{"research": {"agent-1", "agent-2"}}
+	a1, _ := NewScopedLongTerm(store, runtime.MemoryScope{TenantID: "tenant-a", AgentID: "agent-1"}, policy)
+	a2, _ := NewScopedLongTerm(store, runtime.MemoryScope{TenantID: "tenant-a", AgentID: "agent-2"}, policy)
+	b1, _ := NewScopedLongTerm(store, runtime.MemoryScope{TenantID: "tenant-b", AgentID: "agent-1"}, policy)
+	a3, _ := NewScopedLongTerm(store, runtime.MemoryScope{TenantID: "tenant-a", AgentID: "agent-3"}, policy)
+
+	_ = a1.Set(ctx, "facts", "k", []byte("a1"))
+	for name, m := range map[string]*ScopedLongTerm{"other agent": a2, "other tenant": b1} {
+		if v, _ := m.Get(ctx, "facts", "k"); v != nil {
+			t.Errorf("%s read %q", name, v)
+		}
+		if list, _ := m.List(ctx, "", 0); len(list) != 0 {
+			t.Errorf("%s listed %v", name, list)
+		}
+	}
+	// 原始存储中其他租户不能通过拼接 agentID 命中
+	if v, _ := store.Get(ctx, "agent-1", "facts", "k"); v != nil {
+		t.Fatalf("tenant-a memory stored under bare agent id")
+	}
+
+	s1, err := a1.Shared("research")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s1.Set(ctx, "notes", "k", []byte("shared"))
+	s2, _ := a2.Shared("research")
+	if v, _ := s2.Get(ctx, "notes", "k"); string(v) != "shared" {
+		t.Fatalf("shared space read = %q", v)
+	}
+	if _, err := a3.Shared("research"); !errors.Is(err, runtime.ErrSharedSpaceDenied) {
+		t.Fatalf("agent not in space: err = %v", err)
+	}
+	sb, _ := b1.Shared("research")
+	if v, _ := sb.Get(ctx, "notes", "k"); v != nil {
+		t.Fatalf("shared space leaked across tenants: %q", v)
+	}
+	if v, _ := a1.Get(ctx, "notes", "k"); v != nil {
+		t.Fatalf("shared entry visible in private memory")
+	}
+}
+
+func TestScopedEpisodic_CrossAccess(t *testing.T) {
+	ctx := context.Background()
+	store := NewEpisodicMemoryStoreMem()
+	policy := runtime.SharedSpaces{"team": {"agent-1", "agent-2"}}
+	a1, _ := NewScopedEpisodic(store, runtime.MemoryScope{TenantID: "tenant-a", AgentID: "agent-1"}, policy)
+	a2, _ := NewScopedEpisodic(store, runtime.MemoryScope{TenantID: "tenant-a", AgentID: "agent-2"}, policy)
+	b1, _ := NewScopedEpisodic(store, runtime.MemoryScope{TenantID: "tenant-b", AgentID: "agent-1"}, nil)
+
+	if err := a1.Append(ctx, &EpisodicEntry{SessionID: "s1", Summary: "private"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a1.Append(ctx, &EpisodicEntry{AgentID: "agent-2", Summary: "forged"}); !errors.Is(err, runtime.ErrCrossScopeAccess) {
+		t.Fatalf("append for another agent: err = %v", err)
+	}
+	for name, m := range map[string]*ScopedEpisodic{"other agent": a2, "other tenant": b1} {
+		if list, _ := m.List(ctx, 0); len(list) != 0 {
+			t.Errorf("%s listed %d entries", name, len(list))
+		}
+		if list, _ := m.ListBySession(ctx, "s1", 0); len(list) != 0 {
+			t.Errorf("%s listed %d session entries", name, len(list))
+		}
+	}
+	own, _ := a1.ListBySession(ctx, "s1", 0)
+	if len(own) != 1 || own[0].AgentID != "agent-1" || own[0].Summary != "private" {
+		t.Fatalf("own entries = %+v", own)
+	}
+	if _, ok := own[0].Payload[episodicWriterKey]; ok {
+		t.Fatal("writer key should be stripped")
+	}
+
+	s2, _ := a2.Shared("team")
+	_ = s2.Append(ctx, &EpisodicEntry{Summary: "from agent-2"})
+	s1, _ := a1.Shared("team")
+	shared, _ := s1.List(ctx, 0)
+	if len(shared) != 1 || shared[0].AgentID != "agent-2" {
+		t.Fatalf("shared entries = %+v", shared)
+	}
+	if _, err := b1.Shared("team"); !errors.Is(err, runtime.ErrSharedSpaceDenied) {
+		t.Fatalf("nil policy: err = %v", err)
+	}
+}
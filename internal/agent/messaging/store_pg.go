@@ -46,6 +46,11 @@ func NewStorePg(ctx context.Context, dsn string) (*StorePg, error) {
 	return &StorePg{pool: pool}, nil
 }
 
+// NewStorePgWithPool 基于已有连接池创建消息存储（连接池由 pgpool.Manager 统一管理）
+func NewStorePgWithPool(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
 // Close 关闭连接池
 func (s *StorePg) Close() {
 	s.pool.Close()
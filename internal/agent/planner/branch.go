@@ -0,0 +1,707 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// BranchDefault 条件边 When 的兜底标签：分支结果未命中该分支其它条件边时走此边
+const BranchDefault = "default"
+
+// BranchExpr 分支表达式：对已执行节点的结果（payload.Results）确定性求值，不读时钟/随机数/外部状态。
+//
+// 语法：路径 search.output.items.0（首段为节点 ID，途经 JSON 字符串时按 JSON 解析）、
+// 字面量（数字、'str' / "str"、true、false、null）、比较 == != < <= > >=、逻辑 && || !、括号，
+// 以及函数 empty(x)、len(x)、contains(x, y)、exists(x)
+type BranchExpr struct {
+	src  string
+	root exprNode
+}
+
+// ParseBranchExpr 解析分支表达式
+func ParseBranchExpr(src string) (*BranchExpr, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("expr: unexpected %q", p.toks[p.pos].text)
+	}
+	return &BranchExpr{src: src, root: root}, nil
+}
+
+// String 返回表达式原文
+func (e *BranchExpr) String() string { return e.src }
+
+// Nodes 返回表达式引用的节点 ID（路径首段），按出现顺序去重
+func (e *BranchExpr) Nodes() []string {
+	var out []string
+	seen := make(map[string]bool)
+	walkExpr(e.root, func(n exprNode) {
+		if p, ok := n.(*pathExpr); ok && !seen[p.segs[0]] {
+			seen[p.segs[0]] = true
+			out = append(out, p.segs[0])
+		}
+	})
+	return out
+}
+
+// Eval 按 results 求值
+func (e *BranchExpr) Eval(results map[string]any) (any, error) {
+	return e.root.eval(results)
+}
+
+// BranchOutcome 将表达式的值规范为分支标签：布尔为 "true"/"false"，字符串原样，null 为 "null"，其余按 JSON
+func BranchOutcome(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(t)
+	case string:
+		return t
+	}
+	if f, ok := exprNumber(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// BranchResultOutcome 读取 branch 节点写入 Results 的 outcome；节点尚未执行（或已被跳过）时 ok=false
+func BranchResultOutcome(result any) (string, bool) {
+	m, ok := result.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	outcome, ok := m["outcome"].(string)
+	return outcome, ok
+}
+
+// HasBranches 图中是否含 branch 节点
+func (g *TaskGraph) HasBranches() bool {
+	if g == nil {
+		return false
+	}
+	for i := range g.Nodes {
+		if g.Nodes[i].Type == NodeBranch {
+			return true
+		}
+	}
+	return false
+}
+
+// SkippedNodes 按已决分支的 outcome 计算未被选中、应跳过的节点。
+// 节点有入边且入边全部失效时跳过；入边失效指源节点已跳过，或源为已决 branch 节点且边的 When 未命中其 outcome。
+// 汇合节点只要任一入边有效即执行；不含 branch 节点时返回 nil
+func (g *TaskGraph) SkippedNodes(results map[string]any) map[string]bool {
+	if !g.HasBranches() {
+		return nil
+	}
+	types := make(map[string]string, len(g.Nodes))
+	for i := range g.Nodes {
+		types[g.Nodes[i].ID] = g.Nodes[i].Type
+	}
+	incoming := make(map[string][]TaskEdge)
+	labels := make(map[string]map[string]bool)
+	for _, e := range g.Edges {
+		incoming[e.To] = append(incoming[e.To], e)
+		if e.When != "" && e.When != BranchDefault {
+			if labels[e.From] == nil {
+				labels[e.From] = make(map[string]bool)
+			}
+			labels[e.From][e.When] = true
+		}
+	}
+	skipped := make(map[string]bool)
+	state := make(map[string]int) // 0 未访问 1 访问中 2 已完成；校验过的图无环，访问中按未跳过处理
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case 1:
+			return false
+		case 2:
+			return skipped[id]
+		}
+		state[id] = 1
+		in := incoming[id]
+		skip := len(in) > 0
+		for _, e := range in {
+			if visit(e.From) {
+				continue
+			}
+			if e.When != "" && types[e.From] == NodeBranch {
+				if outcome, decided := BranchResultOutcome(results[e.From]); decided {
+					taken := e.When == outcome || (e.When == BranchDefault && !labels[e.From][outcome])
+					if !taken {
+						continue
+					}
+				}
+			}
+			skip = false
+			break
+		}
+		state[id] = 2
+		skipped[id] = skip
+		return skip
+	}
+	out := make(map[string]bool)
+	for i := range g.Nodes {
+		if visit(g.Nodes[i].ID) {
+			out[g.Nodes[i].ID] = true
+		}
+	}
+	return out
+}
+
+// validateBranches 校验 branch 节点的表达式与条件边：When 只能用于 branch 节点的出边，表达式只能引用 inScope 的节点
+func validateBranches(g *TaskGraph, inScope func(id string) bool) error {
+	types := make(map[string]string, len(g.Nodes))
+	for _, n := range g.Nodes {
+		types[n.ID] = n.Type
+		if n.Type != NodeBranch {
+			continue
+		}
+		src, _ := n.Config["expr"].(string)
+		if strings.TrimSpace(src) == "" {
+			return fmt.Errorf("branch node %q has no expr", n.ID)
+		}
+		expr, err := ParseBranchExpr(src)
+		if err != nil {
+			return fmt.Errorf("branch node %q: %w", n.ID, err)
+		}
+		for _, ref := range expr.Nodes() {
+			if !inScope(ref) {
+				return fmt.Errorf("branch node %q references unknown node %q", n.ID, ref)
+			}
+			if ref == n.ID {
+				return fmt.Errorf("branch node %q references itself", n.ID)
+			}
+		}
+	}
+	seen := make(map[TaskEdge]bool)
+	for _, e := range g.Edges {
+		if e.When == "" {
+			continue
+		}
+		if types[e.From] != NodeBranch {
+			return fmt.Errorf("edge %s -> %s has when %q but %q is not a branch node", e.From, e.To, e.When, e.From)
+		}
+		if seen[e] {
+			return fmt.Errorf("duplicate edge %s -> %s when %q", e.From, e.To, e.When)
+		}
+		seen[e] = true
+	}
+	return nil
+}
+
+// ---- 表达式求值 ----
+
+type exprNode interface {
+	eval(results map[string]any) (any, error)
+}
+
+type litExpr struct{ v any }
+
+type pathExpr struct{ segs []string }
+
+type notExpr struct{ x exprNode }
+
+type binExpr struct {
+	op   string
+	l, r exprNode
+}
+
+type callExpr struct {
+	fn   string
+	args []exprNode
+}
+
+func walkExpr(n exprNode, fn func(exprNode)) {
+	fn(n)
+	switch t := n.(type) {
+	case *notExpr:
+		walkExpr(t.x, fn)
+	case *binExpr:
+		walkExpr(t.l, fn)
+		walkExpr(t.r, fn)
+	case *callExpr:
+		for _, a := range t.args {
+			walkExpr(a, fn)
+		}
+	}
+}
+
+func (e *litExpr) eval(map[string]any) (any, error) { return e.v, nil }
+
+func (e *pathExpr) eval(results map[string]any) (any, error) {
+	v := results[e.segs[0]]
+	for _, key := range e.segs[1:] {
+		v = decodeJSONString(v)
+		switch t := v.(type) {
+		case map[string]any:
+			v = t[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, nil
+			}
+			v = t[i]
+		default:
+			return nil, nil
+		}
+	}
+	return v, nil
+}
+
+func (e *notExpr) eval(results map[string]any) (any, error) {
+	v, err := e.x.eval(results)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+func (e *binExpr) eval(results map[string]any) (any, error) {
+	l, err := e.l.eval(results)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "&&":
+		if !truthy(l) {
+			return false, nil
+		}
+		r, err := e.r.eval(results)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r), nil
+	case "||":
+		if truthy(l) {
+			return true, nil
+		}
+		r, err := e.r.eval(results)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r), nil
+	}
+	r, err := e.r.eval(results)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==":
+		return exprEqual(l, r), nil
+	case "!=":
+		return !exprEqual(l, r), nil
+	}
+	c, err := exprCompare(l, r)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %s: %w", e.op, err)
+	}
+	switch e.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func (e *callExpr) eval(results map[string]any) (any, error) {
+	args := make([]any, len(e.args))
+	for i, a := range e.args {
+		v, err := a.eval(results)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch e.fn {
+	case "exists":
+		return args[0] != nil, nil
+	case "len":
+		return float64(exprLen(args[0])), nil
+	case "empty":
+		return exprLen(args[0]) == 0, nil
+	default: // contains
+		return exprContains(args[0], args[1]), nil
+	}
+}
+
+// exprFuncs 支持的函数及参数个数
+var exprFuncs = map[string]int{"empty": 1, "len": 1, "contains": 2, "exists": 1}
+
+// decodeJSONString 字符串内容为 JSON 数组/对象时解析（工具与 LLM 输出常以 JSON 字符串存放）
+func decodeJSONString(v any) any {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+		return v
+	}
+	var decoded any
+	if json.Unmarshal([]byte(s), &decoded) != nil {
+		return v
+	}
+	return decoded
+}
+
+// exprLen 长度：null 为 0，字符串按 JSON 数组/对象解析后计元素数，否则计字符数（忽略首尾空白）；数字与布尔视为 1
+func exprLen(v any) int {
+	v = decodeJSONString(v)
+	switch t := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return len([]rune(strings.TrimSpace(t)))
+	case []any:
+		return len(t)
+	case map[string]any:
+		return len(t)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len()
+	}
+	return 1
+}
+
+func exprContains(haystack, needle any) bool {
+	haystack = decodeJSONString(haystack)
+	switch t := haystack.(type) {
+	case string:
+		s, ok := needle.(string)
+		return ok && strings.Contains(t, s)
+	case []any:
+		for _, item := range t {
+			if exprEqual(item, needle) {
+				return true
+			}
+		}
+	case map[string]any:
+		s, ok := needle.(string)
+		if ok {
+			_, has := t[s]
+			return has
+		}
+	}
+	return false
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	}
+	if f, ok := exprNumber(v); ok {
+		return f != 0
+	}
+	return exprLen(v) > 0
+}
+
+func exprNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func exprEqual(l, r any) bool {
+	if lf, ok := exprNumber(l); ok {
+		rf, ok := exprNumber(r)
+		return ok && lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func exprCompare(l, r any) (int, error) {
+	if lf, ok := exprNumber(l); ok {
+		if rf, ok := exprNumber(r); ok {
+			switch {
+			case lf < rf:
+				return -1, nil
+			case lf > rf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if ls, ok := l.(string); ok {
+		if rs, ok := r.(string); ok {
+			return strings.Compare(ls, rs), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T with %T", l, r)
+}
+
+// ---- 词法与语法 ----
+
+type exprToken struct {
+	kind byte // i 标识符/路径, n 数字, s 字符串, o 运算符/标点
+	text string
+}
+
+func lexExpr(src string) ([]exprToken, error) {
+	var toks []exprToken
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(rs) && rs[j] != c; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				sb.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("expr: unterminated string")
+			}
+			toks = append(toks, exprToken{kind: 's', text: sb.String()})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			toks = append(toks, exprToken{kind: 'n', text: string(rs[i:j])})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '-' || rs[j] == '.') {
+				j++
+			}
+			toks = append(toks, exprToken{kind: 'i', text: string(rs[i:j])})
+			i = j
+		default:
+			if i+1 < len(rs) {
+				two := string(rs[i : i+2])
+				switch two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					toks = append(toks, exprToken{kind: 'o', text: two})
+					i += 2
+					continue
+				}
+			}
+			switch c {
+			case '<', '>', '!', '(', ')', ',':
+				toks = append(toks, exprToken{kind: 'o', text: string(c)})
+				i++
+			default:
+				return nil, fmt.Errorf("expr: unexpected character %q", c)
+			}
+		}
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("expr: empty expression")
+	}
+	return toks, nil
+}
+
+type exprParser struct {
+	toks []exprToken
+	pos  int
+}
+
+func (p *exprParser) peek(text string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == 'o' && p.toks[p.pos].text == text
+}
+
+func (p *exprParser) expect(text string) error {
+	if !p.peek(text) {
+		if p.pos < len(p.toks) {
+			return fmt.Errorf("expr: expected %q, got %q", text, p.toks[p.pos].text)
+		}
+		return fmt.Errorf("expr: expected %q at end", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &binExpr{op: "||", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &binExpr{op: "&&", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek("!") {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.peek(op) {
+			p.pos++
+			r, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &binExpr{op: op, l: l, r: r}, nil
+		}
+	}
+	return l, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("expr: unexpected end")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	switch t.kind {
+	case 's':
+		return &litExpr{v: t.text}, nil
+	case 'n':
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expr: bad number %q", t.text)
+		}
+		return &litExpr{v: f}, nil
+	case 'i':
+		switch t.text {
+		case "true":
+			return &litExpr{v: true}, nil
+		case "false":
+			return &litExpr{v: false}, nil
+		case "null":
+			return &litExpr{v: nil}, nil
+		}
+		if p.peek("(") {
+			return p.parseCall(t.text)
+		}
+		segs := strings.Split(t.text, ".")
+		for _, s := range segs {
+			if s == "" {
+				return nil, fmt.Errorf("expr: bad path %q", t.text)
+			}
+		}
+		return &pathExpr{segs: segs}, nil
+	}
+	if t.text == "(" {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+	return nil, fmt.Errorf("expr: unexpected %q", t.text)
+}
+
+func (p *exprParser) parseCall(fn string) (exprNode, error) {
+	arity, ok := exprFuncs[fn]
+	if !ok {
+		return nil, fmt.Errorf("expr: unknown function %q", fn)
+	}
+	p.pos++ // (
+	var args []exprNode
+	for !p.peek(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		a, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	p.pos++ // )
+	if len(args) != arity {
+		return nil, fmt.Errorf("expr: %s expects %d argument(s), got %d", fn, arity, len(args))
+	}
+	return &callExpr{fn: fn, args: args}, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBranchExpr_Eval(t *testing.T) {
+	results := map[string]any{
+		"search": map[string]any{"output": `{"items":[{"title":"a"},{"title":"b"}],"total":2}`},
+		"empty":  map[string]any{"output": "[]"},
+		"llm":    map[string]any{"output": "approved by reviewer"},
+	}
+	cases := []struct {
+		expr string
+		want any
+	}{
+		{"empty(search.output.items)", false},
+		{"empty(empty.output)", true},
+		{"empty(missing.output)", true},
+		{"len(search.output.items) >= 2", true},
+		{"search.output.total == 2", true},
+		{"search.output.items.1.title == 'b'", true},
+		{`contains(llm.output, "approved") && !exists(missing)`, true},
+		{"search.output.total > 5 || (len(empty.output) == 0 && true)", true},
+		{"search.output.items.9.title", nil},
+		{"llm.output != null", true},
+	}
+	for _, c := range cases {
+		e, err := ParseBranchExpr(c.expr)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", c.expr, err)
+		}
+		got, err := e.Eval(results)
+		if err != nil {
+			t.Fatalf("%s: eval: %v", c.expr, err)
+		}
+		if got != c.want {
+			t.Errorf("%s = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestParseBranchExpr_Errors(t *testing.T) {
+	for _, src := range []string{"", "a ==", "len(a, b)", "unknown(a)", "(a", "a = b", "'open"} {
+		if _, err := ParseBranchExpr(src); err == nil {
+			t.Errorf("ParseBranchExpr(%q) should fail", src)
+		}
+	}
+	if _, err := ParseBranchExpr("a.output < b"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := ParseBranchExpr("a.output < 1")
+	if _, err := e.Eval(map[string]any{"a": map[string]any{"output": true}}); err == nil {
+		t.Error("comparing bool with number should fail")
+	}
+}
+
+func TestBranchOutcome(t *testing.T) {
+	for v, want := range map[any]string{true: "true", false: "false", "ask": "ask", 2.0: "2", nil: "null"} {
+		if got := BranchOutcome(v); got != want {
+			t.Errorf("BranchOutcome(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func branchTestGraph() *TaskGraph {
+	return &TaskGraph{
+		Nodes: []TaskNode{
+			{ID: "search", Type: NodeTool, ToolName: "search"},
+			{ID: "check", Type: NodeBranch, Config: map[string]any{"expr": "empty(search.output)"}},
+			{ID: "ask", Type: NodeWait, Config: map[string]any{"wait_kind": WaitKindUserInput}},
+			{ID: "summarize", Type: NodeLLM},
+			{ID: "cite", Type: NodeLLM},
+			{ID: "reply", Type: NodeLLM},
+		},
+		Edges: []TaskEdge{
+			{From: "search", To: "check"},
+			{From: "check", To: "ask", When: "true"},
+			{From: "check", To: "summarize", When: BranchDefault},
+			{From: "summarize", To: "cite"},
+			{From: "ask", To: "reply"},
+			{From: "cite", To: "reply"},
+		},
+	}
+}
+
+func TestTaskGraph_SkippedNodes(t *testing.T) {
+	g := branchTestGraph()
+	if err := ValidateTaskGraph(g, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 分支未决时不跳过任何节点
+	if s := g.SkippedNodes(map[string]any{}); len(s) != 0 {
+		t.Fatalf("undecided: %v", s)
+	}
+	s := g.SkippedNodes(map[string]any{"check": map[string]any{"outcome": "true"}})
+	if !s["summarize"] || !s["cite"] || s["ask"] || s["reply"] || len(s) != 2 {
+		t.Fatalf("outcome true: %v", s)
+	}
+	s = g.SkippedNodes(map[string]any{"check": map[string]any{"outcome": "false"}})
+	if !s["ask"] || s["summarize"] || s["cite"] || s["reply"] || len(s) != 1 {
+		t.Fatalf("outcome false falls back to default: %v", s)
+	}
+	if (&TaskGraph{Nodes: []TaskNode{{ID: "a", Type: NodeLLM}}}).SkippedNodes(nil) != nil {
+		t.Fatal("graph without branches should return nil")
+	}
+}
+
+func TestValidateTaskGraph_Branch(t *testing.T) {
+	cases := map[string]func(g *TaskGraph){
+		"has no expr":          func(g *TaskGraph) { g.Nodes[1].Config = nil },
+		"references unknown":   func(g *TaskGraph) { g.Nodes[1].Config["expr"] = "empty(nope.output)" },
+		"unknown function":     func(g *TaskGraph) { g.Nodes[1].Config["expr"] = "size(search)" },
+		"is not a branch node": func(g *TaskGraph) { g.Edges[0].When = "true" },
+		"duplicate edge":       func(g *TaskGraph) { g.Edges = append(g.Edges, g.Edges[1]) },
+		"references itself":    func(g *TaskGraph) { g.Nodes[1].Config["expr"] = "check.outcome" },
+	}
+	for want, mutate := range cases {
+		g := branchTestGraph()
+		mutate(g)
+		err := ValidateTaskGraph(g, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrPlanOverBudget 计划预估成本或 ETA 超出预算
+var ErrPlanOverBudget = errors.New("plan exceeds cost/latency budget")
+
+// ToolCostHint 单次调用的预期延迟与费用（USD）
+type ToolCostHint struct {
+	ExpectedLatency time.Duration
+	CostPerCall     float64
+}
+
+// CostModel 计划成本模型：按工具名的标注，LLM 节点统一使用 LLM 标注
+type CostModel struct {
+	Tools map[string]ToolCostHint
+	LLM   ToolCostHint
+}
+
+// PlanBudget 计划预算；<=0 表示不限制
+type PlanBudget struct {
+	MaxCost float64
+	MaxETA  time.Duration
+}
+
+// IsZero 未设置任何限制
+func (b PlanBudget) IsZero() bool {
+	return b.MaxCost <= 0 && b.MaxETA <= 0
+}
+
+// Tighten 与 other 合并取更严格者：任一方未限制的维度取另一方
+func (b PlanBudget) Tighten(other PlanBudget) PlanBudget {
+	if other.MaxCost > 0 && (b.MaxCost <= 0 || other.MaxCost < b.MaxCost) {
+		b.MaxCost = other.MaxCost
+	}
+	if other.MaxETA > 0 && (b.MaxETA <= 0 || other.MaxETA < b.MaxETA) {
+		b.MaxETA = other.MaxETA
+	}
+	return b
+}
+
+// MarshalJSON 以 max_cost / max_eta_ms 输出，未限制的维度省略
+func (b PlanBudget) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{}
+	if b.MaxCost > 0 {
+		out["max_cost"] = b.MaxCost
+	}
+	if b.MaxETA > 0 {
+		out["max_eta_ms"] = b.MaxETA.Milliseconds()
+	}
+	return json.Marshal(out)
+}
+
+// BudgetPolicy 计划预算策略：全局默认与按租户覆盖；租户预算与全局合并取更严格者
+type BudgetPolicy struct {
+	Default PlanBudget
+	Tenants map[string]PlanBudget
+}
+
+// For 返回租户的有效预算，再与 Job 自带预算合并（Job 只能收紧，不能放宽）
+func (p BudgetPolicy) For(tenantID string, jobBudget PlanBudget) PlanBudget {
+	b := p.Default
+	if t, ok := p.Tenants[tenantID]; ok {
+		b = b.Tighten(t)
+	}
+	return b.Tighten(jobBudget)
+}
+
+// PlanBudgetError 计划超出预算的结构化错误；errors.Is(err, ErrPlanOverBudget) 成立。
+// Graph 为超出预算的计划（LLMPlanner 重新规划仍超出时附带），供调用方转为审批而非直接拒绝
+type PlanBudgetError struct {
+	Exceeded string // "cost" 或 "eta"
+	Estimate *PlanEstimate
+	Budget   PlanBudget
+	Graph    *TaskGraph
+}
+
+func (e *PlanBudgetError) Error() string {
+	if e.Exceeded == "eta" {
+		return fmt.Sprintf("%s: estimated eta %s > max %s", ErrPlanOverBudget, e.Estimate.ETA(), e.Budget.MaxETA)
+	}
+	return fmt.Sprintf("%s: estimated cost %.4f %s > max %.4f", ErrPlanOverBudget, e.Estimate.TotalCost, e.Estimate.Currency, e.Budget.MaxCost)
+}
+
+func (e *PlanBudgetError) Unwrap() error { return ErrPlanOverBudget }
+
+// NodeEstimate 单节点预估
+type NodeEstimate struct {
+	NodeID     string  `json:"node_id"`
+	Type       string  `json:"type"`
+	Tool       string  `json:"tool,omitempty"`
+	LatencyMs  int64   `json:"latency_ms"`
+	Cost       float64 `json:"cost"`
+	Annotated  bool    `json:"annotated"`
+	FinishAtMs int64   `json:"finish_at_ms"` // 依赖全部完成后开始时的预计完成时刻（相对计划开始）
+}
+
+// PlanEstimate 计划预估：总费用为各节点之和，ETA 为 DAG 关键路径延迟之和
+type PlanEstimate struct {
+	TotalCost   float64        `json:"total_cost"`
+	Currency    string         `json:"currency"`
+	ETAMs       int64          `json:"eta_ms"`
+	Nodes       []NodeEstimate `json:"nodes"`
+	Unannotated []string       `json:"unannotated,omitempty"` // 无成本/延迟标注的节点（预估偏低）
+}
+
+// ETA 以 time.Duration 返回关键路径延迟
+func (e *PlanEstimate) ETA() time.Duration {
+	return time.Duration(e.ETAMs) * time.Millisecond
+}
+
+// CostModelFromSchemaJSON 从 tools.Registry.SchemasForLLM() 的输出解析各工具的成本/延迟标注
+func CostModelFromSchemaJSON(schemaJSON []byte) CostModel {
+	m := CostModel{Tools: make(map[string]ToolCostHint)}
+	var items []toolSchemaItem
+	if err := json.Unmarshal(schemaJSON, &items); err != nil {
+		return m
+	}
+	for _, it := range items {
+		if it.Name == "" || (it.ExpectedLatencyMs <= 0 && it.CostPerCall <= 0) {
+			continue
+		}
+		m.Tools[it.Name] = ToolCostHint{
+			ExpectedLatency: time.Duration(it.ExpectedLatencyMs) * time.Millisecond,
+			CostPerCall:     it.CostPerCall,
+		}
+	}
+	return m
+}
+
+func (m CostModel) hintFor(n *TaskNode) (ToolCostHint, bool) {
+	switch n.Type {
+	case NodeTool:
+		h, ok := m.Tools[n.ToolName]
+		return h, ok
+	case NodeLLM:
+		return m.LLM, m.LLM.ExpectedLatency > 0 || m.LLM.CostPerCall > 0
+	case NodeWait, NodeApproval, NodeCondition:
+		// 等待类节点耗时取决于外部信号，不计入 ETA
+		return ToolCostHint{}, true
+	case NodeBranch:
+		// 分支节点只对已有结果求值，无调用成本
+		return ToolCostHint{}, true
+	default:
+		return ToolCostHint{}, false
+	}
+}
+
+// EstimateTaskGraph 按成本模型预估计划总费用与 ETA；ETA 取 DAG 关键路径（存在环时按节点顺序退化为串行）
+func EstimateTaskGraph(g *TaskGraph, model CostModel) *PlanEstimate {
+	est := &PlanEstimate{Currency: "USD", Nodes: []NodeEstimate{}}
+	if g == nil {
+		return est
+	}
+	index := make(map[string]int, len(g.Nodes))
+	for i := range g.Nodes {
+		index[g.Nodes[i].ID] = i
+	}
+	preds := make(map[string][]string, len(g.Nodes))
+	for _, e := range g.Edges {
+		if _, ok := index[e.From]; !ok {
+			continue
+		}
+		if _, ok := index[e.To]; !ok {
+			continue
+		}
+		preds[e.To] = append(preds[e.To], e.From)
+	}
+	finish := make(map[string]int64, len(g.Nodes))
+	visiting := make(map[string]bool, len(g.Nodes))
+	var finishAt func(id string) int64
+	finishAt = func(id string) int64 {
+		if f, ok := finish[id]; ok {
+			return f
+		}
+		if visiting[id] {
+			return 0
+		}
+		visiting[id] = true
+		var start int64
+		for _, p := range preds[id] {
+			if f := finishAt(p); f > start {
+				start = f
+			}
+		}
+		visiting[id] = false
+		h, _ := model.hintFor(&g.Nodes[index[id]])
+		f := start + h.ExpectedLatency.Milliseconds()
+		finish[id] = f
+		return f
+	}
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		h, ok := model.hintFor(n)
+		f := finishAt(n.ID)
+		est.Nodes = append(est.Nodes, NodeEstimate{
+			NodeID:     n.ID,
+			Type:       n.Type,
+			Tool:       n.ToolName,
+			LatencyMs:  h.ExpectedLatency.Milliseconds(),
+			Cost:       h.CostPerCall,
+			Annotated:  ok,
+			FinishAtMs: f,
+		})
+		est.TotalCost += h.CostPerCall
+		if f > est.ETAMs {
+			est.ETAMs = f
+		}
+		if !ok {
+			est.Unannotated = append(est.Unannotated, n.ID)
+		}
+	}
+	sort.Strings(est.Unannotated)
+	return est
+}
+
+// ValidatePlanBudget 计划校验：预估成本或 ETA 超出预算时返回 *PlanBudgetError（包装 ErrPlanOverBudget）
+func ValidatePlanBudget(est *PlanEstimate, budget PlanBudget) error {
+	if est == nil {
+		return nil
+	}
+	if budget.MaxCost > 0 && est.TotalCost > budget.MaxCost {
+		return &PlanBudgetError{Exceeded: "cost", Estimate: est, Budget: budget}
+	}
+	if budget.MaxETA > 0 && est.ETA() > budget.MaxETA {
+		return &PlanBudgetError{Exceeded: "eta", Estimate: est, Budget: budget}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/memory"
+)
+
+func TestEstimateTaskGraph_CriticalPathAndCost(t *testing.T) {
+	model := CostModelFromSchemaJSON([]byte(`[
+		{"name":"search","description":"s","expected_latency_ms":1000,"cost_per_call":0.01},
+		{"name":"crawl","description":"c","expected_latency_ms":3000,"cost_per_call":0.05},
+		{"name":"free","description":"no hints"}
+	]`))
+	model.LLM = ToolCostHint{ExpectedLatency: 2 * time.Second, CostPerCall: 0.02}
+	if _, ok := model.Tools["free"]; ok {
+		t.Fatal("tool without hints should not be in cost model")
+	}
+	// search ─┐
+	//         ├─> llm
+	// crawl  ─┘      └─> approval, unknown
+	g := &TaskGraph{
+		Nodes: []TaskNode{
+			{ID: "a", Type: NodeTool, ToolName: "search"},
+			{ID: "b", Type: NodeTool, ToolName: "crawl"},
+			{ID: "c", Type: NodeLLM},
+			{ID: "d", Type: NodeApproval},
+			{ID: "e", Type: NodeTool, ToolName: "free"},
+		},
+		Edges: []TaskEdge{{From: "a", To: "c"}, {From: "b", To: "c"}, {From: "c", To: "d"}, {From: "c", To: "e"}},
+	}
+	est := EstimateTaskGraph(g, model)
+	if est.ETAMs != 5000 {
+		t.Errorf("eta = %dms, want 5000 (crawl 3s + llm 2s)", est.ETAMs)
+	}
+	if diff := est.TotalCost - 0.08; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("total cost = %v, want 0.08", est.TotalCost)
+	}
+	if len(est.Unannotated) != 1 || est.Unannotated[0] != "e" {
+		t.Errorf("unannotated = %v, want [e]", est.Unannotated)
+	}
+
+	if err := ValidatePlanBudget(est, PlanBudget{MaxCost: 0.1, MaxETA: 10 * time.Second}); err != nil {
+		t.Errorf("within budget: %v", err)
+	}
+	if err := ValidatePlanBudget(est, PlanBudget{MaxETA: 4 * time.Second}); !errors.Is(err, ErrPlanOverBudget) {
+		t.Errorf("expected ErrPlanOverBudget for eta, got %v", err)
+	}
+	if err := ValidatePlanBudget(est, PlanBudget{MaxCost: 0.05}); !errors.Is(err, ErrPlanOverBudget) {
+		t.Errorf("expected ErrPlanOverBudget for cost, got %v", err)
+	}
+}
+
+func TestLLMPlanner_PlanGoal_CostHintsAndBudget(t *testing.T) {
+	mock := &mockLLMClient{reply: `{"nodes":[{"id":"n1","type":"tool","tool_name":"crawl"}],"edges":[]}`}
+	p := NewLLMPlanner(mock)
+	p.SetToolsSchemaForGoal([]byte(`[{"name":"crawl","description":"抓取网页","expected_latency_ms":1500,"cost_per_call":0.05}]`))
+	p.SetPlanCost(ToolCostHint{}, PlanBudget{MaxCost: 0.01})
+	_, err := p.PlanGoal(context.Background(), "goal", memory.NewCompositeMemory())
+	if !errors.Is(err, ErrPlanOverBudget) {
+		t.Fatalf("expected ErrPlanOverBudget after re-plan, got %v", err)
+	}
+	var be *PlanBudgetError
+	if !errors.As(err, &be) || be.Exceeded != "cost" || be.Graph == nil || len(be.Graph.Nodes) != 1 {
+		t.Fatalf("expected PlanBudgetError carrying the over-budget graph, got %#v", err)
+	}
+	if !strings.Contains(mock.lastSystemPrompt, "约 1.5s，$0.0500/次") {
+		t.Errorf("system prompt should contain cost hints, got: %s", mock.lastSystemPrompt)
+	}
+	if !strings.Contains(mock.lastSystemPrompt, "计划预算：总费用不超过 $0.0100") {
+		t.Errorf("system prompt should contain budget, got: %s", mock.lastSystemPrompt)
+	}
+}
+
+func TestBudgetPolicy_For(t *testing.T) {
+	policy := BudgetPolicy{
+		Default: PlanBudget{MaxCost: 1, MaxETA: time.Minute},
+		Tenants: map[string]PlanBudget{"t1": {MaxCost: 0.5}},
+	}
+	if got := policy.For("other", PlanBudget{}); got != policy.Default {
+		t.Errorf("unknown tenant = %+v, want default", got)
+	}
+	if got := policy.For("t1", PlanBudget{}); got.MaxCost != 0.5 || got.MaxETA != time.Minute {
+		t.Errorf("tenant budget = %+v", got)
+	}
+	// Job 预算只能收紧
+	if got := policy.For("t1", PlanBudget{MaxCost: 2, MaxETA: 10 * time.Second}); got.MaxCost != 0.5 || got.MaxETA != 10*time.Second {
+		t.Errorf("job budget = %+v", got)
+	}
+	if got := (BudgetPolicy{}).For("t1", PlanBudget{MaxCost: 0.2}); got.MaxCost != 0.2 || got.MaxETA != 0 {
+		t.Errorf("job budget without policy = %+v", got)
+	}
+}
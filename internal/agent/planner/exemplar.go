@@ -0,0 +1,405 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"rag-platform/pkg/nodeplugin"
+)
+
+// ErrExemplarNotFound 示例不存在或不属于该 Agent
+var ErrExemplarNotFound = errors.New("planner: exemplar not found")
+
+// Exemplar 规划 few-shot 示例：运维为某 Agent 精选的「目标 → 优质 TaskGraph」对，PlanGoal 时按相似度选取注入 prompt
+type Exemplar struct {
+	ID        string     `json:"id"`
+	AgentID   string     `json:"agent_id"`
+	Goal      string     `json:"goal"`
+	Graph     *TaskGraph `json:"graph"`
+	Note      string     `json:"note,omitempty"`
+	Disabled  bool       `json:"disabled"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ExemplarStore 规划示例存储（按 Agent 隔离）
+type ExemplarStore interface {
+	// Put 创建或更新示例；ID 为空时生成，返回示例 ID
+	Put(ctx context.Context, e *Exemplar) (string, error)
+	// Get 获取示例；不存在返回 ErrExemplarNotFound
+	Get(ctx context.Context, agentID, id string) (*Exemplar, error)
+	// List 列出 Agent 的全部示例（按创建时间升序）
+	List(ctx context.Context, agentID string) ([]*Exemplar, error)
+	// Delete 删除示例；不存在返回 ErrExemplarNotFound
+	Delete(ctx context.Context, agentID, id string) error
+}
+
+// ExemplarStoreMem 内存实现
+type ExemplarStoreMem struct {
+	mu    sync.RWMutex
+	items map[string]*Exemplar // id -> exemplar
+}
+
+// NewExemplarStoreMem 创建内存规划示例存储
+func NewExemplarStoreMem() *ExemplarStoreMem {
+	return &ExemplarStoreMem{items: make(map[string]*Exemplar)}
+}
+
+func (s *ExemplarStoreMem) Put(ctx context.Context, e *Exemplar) (string, error) {
+	if e == nil {
+		return "", errors.New("exemplar is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *e
+	now := time.Now()
+	if cp.ID == "" {
+		cp.ID = "ex-" + uuid.New().String()
+	}
+	if prev, ok := s.items[cp.ID]; ok {
+		if prev.AgentID != cp.AgentID {
+			return "", ErrExemplarNotFound
+		}
+		cp.CreatedAt = prev.CreatedAt
+	} else if cp.CreatedAt.IsZero() {
+		cp.CreatedAt = now
+	}
+	cp.UpdatedAt = now
+	s.items[cp.ID] = &cp
+	return cp.ID, nil
+}
+
+func (s *ExemplarStoreMem) Get(ctx context.Context, agentID, id string) (*Exemplar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.items[id]
+	if !ok || e.AgentID != agentID {
+		return nil, ErrExemplarNotFound
+	}
+	cp := *e
+	return &cp, nil
+}
+
+func (s *ExemplarStoreMem) List(ctx context.Context, agentID string) ([]*Exemplar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Exemplar
+	for _, e := range s.items {
+		if e.AgentID == agentID {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *ExemplarStoreMem) Delete(ctx context.Context, agentID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[id]
+	if !ok || e.AgentID != agentID {
+		return ErrExemplarNotFound
+	}
+	delete(s.items, id)
+	return nil
+}
+
+// ScoredExemplar 按目标相似度选中的示例
+type ScoredExemplar struct {
+	Exemplar *Exemplar
+	Score    float64
+}
+
+// SelectExemplars 按与 goal 的词项相似度（余弦，英文按词、中文按字二元组）选取最相近的 k 个启用示例；相似度为 0 的不选
+func SelectExemplars(goal string, candidates []*Exemplar, k int) []ScoredExemplar {
+	if k <= 0 || len(candidates) == 0 {
+		return nil
+	}
+	terms := goalTermsOf(goal)
+	if len(terms) == 0 {
+		return nil
+	}
+	var scored []ScoredExemplar
+	for _, e := range candidates {
+		if e == nil || e.Disabled || e.Graph == nil {
+			continue
+		}
+		if score := termCosine(terms, goalTermsOf(e.Goal)); score > 0 {
+			scored = append(scored, ScoredExemplar{Exemplar: e, Score: score})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored
+}
+
+// goalTermsOf 将目标切分为词项：连续字母/数字为一个英文词（小写），中日韩字符取相邻二元组（单字时取单字）
+func goalTermsOf(s string) map[string]int {
+	terms := make(map[string]int)
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			terms[string(word)]++
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			terms[string(cjk)]++
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				terms[string(cjk[i:i+2])]++
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+// termCosine 两个词频向量的余弦相似度
+func termCosine(a, b map[string]int) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for t, x := range a {
+		na += float64(x * x)
+		if y, ok := b[t]; ok {
+			dot += float64(x * y)
+		}
+	}
+	for _, y := range b {
+		nb += float64(y * y)
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// ValidateTaskGraph 计划有效性校验：至少一个节点、节点 ID 唯一、类型已知、tool 节点有 tool_name（knownTools 非空时须在其中）、事务组仅含 tool 节点、边引用存在的节点且无环
+func ValidateTaskGraph(g *TaskGraph, knownTools map[string]bool) error {
+	return validateTaskGraph(g, knownTools, nil)
+}
+
+// validateTaskGraph refOK 非 nil 时为表达式可额外引用的图外节点（map body 可读外层结果与当前项）
+func validateTaskGraph(g *TaskGraph, knownTools map[string]bool, refOK func(id string) bool) error {
+	if g == nil || len(g.Nodes) == 0 {
+		return errors.New("task graph has no nodes")
+	}
+	ids := make(map[string]bool, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if n.ID == "" {
+			return errors.New("node id is empty")
+		}
+		if ids[n.ID] {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		ids[n.ID] = true
+		switch n.Type {
+		case NodeTool:
+			if n.ToolName == "" {
+				return fmt.Errorf("tool node %q has no tool_name", n.ID)
+			}
+			if len(knownTools) > 0 && !knownTools[n.ToolName] {
+				return fmt.Errorf("tool node %q references unknown tool %q", n.ID, n.ToolName)
+			}
+		case NodeWorkflow, NodeLLM, NodeWait, NodeApproval, NodeCondition, NodeLangGraph, NodeReflect, NodeSpawn, NodeJoin, NodeBranch, NodeMap:
+		default:
+			// 自定义节点类型按已注册 Manifest 校验 config
+			if err := nodeplugin.ValidateNode(n.Type, n.Config); err != nil {
+				if errors.Is(err, nodeplugin.ErrUnknownType) {
+					return fmt.Errorf("node %q has unknown type %q", n.ID, n.Type)
+				}
+				return fmt.Errorf("node %q (%s): %w", n.ID, n.Type, err)
+			}
+		}
+		if n.Transaction != "" && n.Type != NodeTool {
+			return fmt.Errorf("node %q: only tool nodes can join transaction %q", n.ID, n.Transaction)
+		}
+		if c := n.Compensate; c != nil {
+			if c.Tool == "" {
+				return fmt.Errorf("node %q has compensate without tool", n.ID)
+			}
+			if len(knownTools) > 0 && !knownTools[c.Tool] {
+				return fmt.Errorf("node %q compensates with unknown tool %q", n.ID, c.Tool)
+			}
+		}
+	}
+	next := make(map[string][]string)
+	for _, e := range g.Edges {
+		if !ids[e.From] || !ids[e.To] {
+			return fmt.Errorf("edge %s -> %s references unknown node", e.From, e.To)
+		}
+		next[e.From] = append(next[e.From], e.To)
+	}
+	inScope := func(id string) bool { return ids[id] || (refOK != nil && refOK(id)) }
+	if err := validateBranches(g, inScope); err != nil {
+		return err
+	}
+	for i := range g.Nodes {
+		if g.Nodes[i].Type != NodeMap {
+			continue
+		}
+		if _, err := parseMapConfig(&g.Nodes[i], knownTools, inScope); err != nil {
+			return err
+		}
+	}
+	// 三色 DFS 判环
+	state := make(map[string]int, len(ids))
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		state[id] = 1
+		for _, to := range next[id] {
+			if state[to] == 1 || (state[to] == 0 && visit(to)) {
+				return true
+			}
+		}
+		state[id] = 2
+		return false
+	}
+	for _, n := range g.Nodes {
+		if state[n.ID] == 0 && visit(n.ID) {
+			return errors.New("task graph has a cycle")
+		}
+	}
+	return nil
+}
+
+// ExemplarOptions few-shot 注入参数
+type ExemplarOptions struct {
+	// TopK 每次规划最多注入的示例数；<=0 时默认 3
+	TopK int
+	// ControlRatio A/B 对照组比例（0~1）：按 agent+goal 哈希分桶，落入对照组的规划不注入示例，用于比较计划有效率
+	ControlRatio float64
+}
+
+// 计划有效率 A/B 分组（aetheris_planner_plans_total 的 variant 标签）
+const (
+	PlanVariantExemplars = "exemplars" // 注入了示例
+	PlanVariantControl   = "control"   // 有可用示例但落入对照组，未注入
+	PlanVariantNone      = "none"      // 无可用示例
+)
+
+// inControlGroup 按 agent+goal 的哈希确定性分桶，同一目标始终落在同一组
+func inControlGroup(agentID, goal string, ratio float64) bool {
+	if ratio <= 0 {
+		return false
+	}
+	if ratio >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(agentID + "\x00" + goal))
+	return float64(h.Sum32()%10000) < ratio*10000
+}
+
+type agentIDCtxKey struct{}
+
+// WithAgentID 在 context 中携带发起规划的 Agent ID，供 PlanGoal 选取该 Agent 的规划示例
+func WithAgentID(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, agentIDCtxKey{}, agentID)
+}
+
+// AgentIDFromContext 取出 WithAgentID 设置的 Agent ID；未设置返回空
+func AgentIDFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(agentIDCtxKey{}).(string)
+	return v
+}
+
+// VariantStats 某 A/B 分组的规划次数与通过校验次数
+type VariantStats struct {
+	Total     int64   `json:"total"`
+	Valid     int64   `json:"valid"`
+	ValidRate float64 `json:"valid_rate"`
+}
+
+// PlanValidityTracker 按 Agent 与 A/B 分组统计计划有效率（进程内，供 API 展示；全局指标见 aetheris_planner_plans_total）
+type PlanValidityTracker struct {
+	mu    sync.Mutex
+	stats map[string]map[string]*VariantStats // agentID -> variant -> stats
+}
+
+// NewPlanValidityTracker 创建计划有效率统计
+func NewPlanValidityTracker() *PlanValidityTracker {
+	return &PlanValidityTracker{stats: make(map[string]map[string]*VariantStats)}
+}
+
+// Record 记录一次规划结果
+func (t *PlanValidityTracker) Record(agentID, variant string, valid bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byVariant, ok := t.stats[agentID]
+	if !ok {
+		byVariant = make(map[string]*VariantStats)
+		t.stats[agentID] = byVariant
+	}
+	s, ok := byVariant[variant]
+	if !ok {
+		s = &VariantStats{}
+		byVariant[variant] = s
+	}
+	s.Total++
+	if valid {
+		s.Valid++
+	}
+	s.ValidRate = float64(s.Valid) / float64(s.Total)
+}
+
+// Stats 返回 Agent 各分组的统计快照
+func (t *PlanValidityTracker) Stats(agentID string) map[string]VariantStats {
+	out := make(map[string]VariantStats)
+	if t == nil {
+		return out
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for variant, s := range t.stats[agentID] {
+		out[variant] = *s
+	}
+	return out
+}
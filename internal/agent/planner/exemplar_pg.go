@@ -0,0 +1,121 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExemplarStorePg PostgreSQL 实现，使用 planner_exemplars 表
+type ExemplarStorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewExemplarStorePg 创建基于 PostgreSQL 的规划示例存储
+func NewExemplarStorePg(pool *pgxpool.Pool) *ExemplarStorePg {
+	return &ExemplarStorePg{pool: pool}
+}
+
+const exemplarColumns = `id, agent_id, goal, graph, COALESCE(note, ''), disabled, created_at, updated_at`
+
+func (s *ExemplarStorePg) Put(ctx context.Context, e *Exemplar) (string, error) {
+	if e == nil {
+		return "", errors.New("exemplar is nil")
+	}
+	graphJSON, err := json.Marshal(e.Graph)
+	if err != nil {
+		return "", err
+	}
+	id := e.ID
+	if id == "" {
+		id = "ex-" + uuid.New().String()
+	}
+	var note interface{}
+	if e.Note != "" {
+		note = e.Note
+	}
+	// 同 ID 但属于其他 Agent 时不覆盖（WHERE 不满足，RowsAffected 为 0）
+	cmd, err := s.pool.Exec(ctx,
+		`INSERT INTO planner_exemplars (id, agent_id, goal, graph, note, disabled) VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (id) DO UPDATE SET goal = EXCLUDED.goal, graph = EXCLUDED.graph, note = EXCLUDED.note, disabled = EXCLUDED.disabled, updated_at = now()
+		 WHERE planner_exemplars.agent_id = EXCLUDED.agent_id`,
+		id, e.AgentID, e.Goal, graphJSON, note, e.Disabled)
+	if err != nil {
+		return "", err
+	}
+	if cmd.RowsAffected() == 0 {
+		return "", ErrExemplarNotFound
+	}
+	return id, nil
+}
+
+func (s *ExemplarStorePg) Get(ctx context.Context, agentID, id string) (*Exemplar, error) {
+	row := s.pool.QueryRow(ctx,
+		`SELECT `+exemplarColumns+` FROM planner_exemplars WHERE id = $1 AND agent_id = $2`, id, agentID)
+	e, err := scanExemplar(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrExemplarNotFound
+	}
+	return e, err
+}
+
+func (s *ExemplarStorePg) List(ctx context.Context, agentID string) ([]*Exemplar, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+exemplarColumns+` FROM planner_exemplars WHERE agent_id = $1 ORDER BY created_at`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Exemplar
+	for rows.Next() {
+		e, err := scanExemplar(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *ExemplarStorePg) Delete(ctx context.Context, agentID, id string) error {
+	cmd, err := s.pool.Exec(ctx, `DELETE FROM planner_exemplars WHERE id = $1 AND agent_id = $2`, id, agentID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrExemplarNotFound
+	}
+	return nil
+}
+
+func scanExemplar(row pgx.Row) (*Exemplar, error) {
+	var e Exemplar
+	var graphJSON []byte
+	if err := row.Scan(&e.ID, &e.AgentID, &e.Goal, &graphJSON, &e.Note, &e.Disabled, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	var g TaskGraph
+	if err := json.Unmarshal(graphJSON, &g); err != nil {
+		return nil, err
+	}
+	e.Graph = &g
+	return &e, nil
+}
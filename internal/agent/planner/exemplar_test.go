@@ -0,0 +1,172 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/memory"
+	"rag-platform/pkg/nodeplugin"
+)
+
+func exemplarGraph(tool string) *TaskGraph {
+	return &TaskGraph{
+		Nodes: []TaskNode{{ID: "n1", Type: NodeTool, ToolName: tool}, {ID: "n2", Type: NodeLLM}},
+		Edges: []TaskEdge{{From: "n1", To: "n2"}},
+	}
+}
+
+func TestSelectExemplars_RanksBySimilarity(t *testing.T) {
+	candidates := []*Exemplar{
+		{ID: "a", Goal: "Summarize the quarterly sales report", Graph: exemplarGraph("knowledge.search")},
+		{ID: "b", Goal: "Book a meeting room for tomorrow", Graph: exemplarGraph("calendar.book")},
+		{ID: "c", Goal: "Summarize the sales report", Graph: exemplarGraph("knowledge.search"), Disabled: true},
+		{ID: "d", Goal: "总结销售报告要点", Graph: exemplarGraph("knowledge.search")},
+	}
+	got := SelectExemplars("summarize Q3 sales report", candidates, 2)
+	if len(got) != 1 || got[0].Exemplar.ID != "a" {
+		t.Fatalf("expected only exemplar a (disabled and unrelated skipped), got %+v", got)
+	}
+	got = SelectExemplars("请总结这份销售报告", candidates, 3)
+	if len(got) != 1 || got[0].Exemplar.ID != "d" {
+		t.Fatalf("expected CJK bigram match on d, got %+v", got)
+	}
+	if got := SelectExemplars("anything", candidates, 0); got != nil {
+		t.Fatalf("k=0 should select nothing, got %+v", got)
+	}
+}
+
+func TestValidateTaskGraph(t *testing.T) {
+	known := map[string]bool{"knowledge.search": true}
+	cases := []struct {
+		name  string
+		g     *TaskGraph
+		valid bool
+	}{
+		{"ok", exemplarGraph("knowledge.search"), true},
+		{"empty", &TaskGraph{}, false},
+		{"unknown tool", exemplarGraph("web.crawl"), false},
+		{"missing tool name", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: NodeTool}}}, false},
+		{"unknown type", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: "magic"}}}, false},
+		{"dangling edge", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: NodeLLM}}, Edges: []TaskEdge{{From: "n1", To: "n9"}}}, false},
+		{"cycle", &TaskGraph{
+			Nodes: []TaskNode{{ID: "n1", Type: NodeLLM}, {ID: "n2", Type: NodeLLM}},
+			Edges: []TaskEdge{{From: "n1", To: "n2"}, {From: "n2", To: "n1"}},
+		}, false},
+		{"transaction", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: NodeTool, ToolName: "knowledge.search", Transaction: "tx1", Compensate: &Compensation{Tool: "knowledge.search"}}}}, true},
+		{"transaction on llm", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: NodeLLM, Transaction: "tx1"}}}, false},
+		{"compensate without tool", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: NodeTool, ToolName: "knowledge.search", Transaction: "tx1", Compensate: &Compensation{}}}}, false},
+		{"unknown compensate tool", &TaskGraph{Nodes: []TaskNode{{ID: "n1", Type: NodeTool, ToolName: "knowledge.search", Transaction: "tx1", Compensate: &Compensation{Tool: "refund"}}}}, false},
+	}
+	for _, tc := range cases {
+		err := ValidateTaskGraph(tc.g, known)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: valid=%v, err=%v", tc.name, tc.valid, err)
+		}
+	}
+}
+
+func TestValidateTaskGraph_CustomNodeType(t *testing.T) {
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{
+		Type:   "planner_test.migration",
+		Config: []nodeplugin.Field{{Name: "target", Type: nodeplugin.FieldString, Required: true}},
+	})
+	ok := &TaskGraph{Nodes: []TaskNode{{ID: "m", Type: "planner_test.migration", Config: map[string]any{"target": "v42"}}}}
+	if err := ValidateTaskGraph(ok, nil); err != nil {
+		t.Fatalf("registered custom node rejected: %v", err)
+	}
+	bad := &TaskGraph{Nodes: []TaskNode{{ID: "m", Type: "planner_test.migration", Config: map[string]any{"target": 42}}}}
+	if err := ValidateTaskGraph(bad, nil); err == nil || !strings.Contains(err.Error(), "config.target must be string") {
+		t.Fatalf("invalid custom config err = %v", err)
+	}
+}
+
+func TestExemplarStoreMem_AgentIsolation(t *testing.T) {
+	ctx := context.Background()
+	s := NewExemplarStoreMem()
+	id, err := s.Put(ctx, &Exemplar{AgentID: "agent-1", Goal: "g", Graph: exemplarGraph("t")})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := s.Get(ctx, "agent-2", id); !errors.Is(err, ErrExemplarNotFound) {
+		t.Fatalf("other agent should not see exemplar, err=%v", err)
+	}
+	if _, err := s.Put(ctx, &Exemplar{ID: id, AgentID: "agent-2", Goal: "x", Graph: exemplarGraph("t")}); !errors.Is(err, ErrExemplarNotFound) {
+		t.Fatalf("other agent should not overwrite exemplar, err=%v", err)
+	}
+	if err := s.Delete(ctx, "agent-1", id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if list, _ := s.List(ctx, "agent-1"); len(list) != 0 {
+		t.Fatalf("expected empty list after delete, got %d", len(list))
+	}
+}
+
+func TestLLMPlanner_PlanGoal_InjectsExemplarsAndTracksValidity(t *testing.T) {
+	ctx := context.Background()
+	store := NewExemplarStoreMem()
+	_, _ = store.Put(ctx, &Exemplar{AgentID: "agent-1", Goal: "summarize the weekly report", Graph: exemplarGraph("knowledge.search")})
+	tracker := NewPlanValidityTracker()
+	client := &mockLLMClient{}
+	p := NewLLMPlanner(client)
+	p.SetExemplars(store, ExemplarOptions{TopK: 2}, tracker)
+	mem := memory.NewCompositeMemory()
+
+	if _, err := p.PlanGoal(WithAgentID(ctx, "agent-1"), "summarize the monthly report", mem); err != nil {
+		t.Fatalf("PlanGoal: %v", err)
+	}
+	if !strings.Contains(client.lastSystemPrompt, "summarize the weekly report") || !strings.Contains(client.lastSystemPrompt, "knowledge.search") {
+		t.Fatalf("expected exemplar in system prompt, got: %s", client.lastSystemPrompt)
+	}
+	stats := tracker.Stats("agent-1")
+	if s := stats[PlanVariantExemplars]; s.Total != 1 || s.Valid != 1 {
+		t.Fatalf("expected one valid exemplars-variant plan, got %+v", stats)
+	}
+
+	// 无法解析的输出退化为单 LLM 节点，但计为无效计划
+	client.reply = "not json"
+	if _, err := p.PlanGoal(WithAgentID(ctx, "agent-1"), "book a flight", mem); err != nil {
+		t.Fatalf("PlanGoal: %v", err)
+	}
+	if strings.Contains(client.lastSystemPrompt, "参考示例") {
+		t.Fatal("unrelated goal should not inject exemplars")
+	}
+	if s := tracker.Stats("agent-1")[PlanVariantNone]; s.Total != 1 || s.Valid != 0 {
+		t.Fatalf("expected one invalid none-variant plan, got %+v", s)
+	}
+}
+
+func TestLLMPlanner_PlanGoal_ControlGroupSkipsExemplars(t *testing.T) {
+	ctx := context.Background()
+	store := NewExemplarStoreMem()
+	_, _ = store.Put(ctx, &Exemplar{AgentID: "agent-1", Goal: "summarize the weekly report", Graph: exemplarGraph("knowledge.search")})
+	tracker := NewPlanValidityTracker()
+	client := &mockLLMClient{}
+	p := NewLLMPlanner(client)
+	p.SetExemplars(store, ExemplarOptions{ControlRatio: 1}, tracker)
+
+	if _, err := p.PlanGoal(WithAgentID(ctx, "agent-1"), "summarize the monthly report", memory.NewCompositeMemory()); err != nil {
+		t.Fatalf("PlanGoal: %v", err)
+	}
+	if strings.Contains(client.lastSystemPrompt, "参考示例") {
+		t.Fatal("control group should not receive exemplars")
+	}
+	if s := tracker.Stats("agent-1")[PlanVariantControl]; s.Total != 1 {
+		t.Fatalf("expected one control-variant plan, got %+v", tracker.Stats("agent-1"))
+	}
+}
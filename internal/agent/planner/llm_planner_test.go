@@ -21,6 +21,7 @@ import (
 
 	"rag-platform/internal/agent/memory"
 	"rag-platform/internal/model/llm"
+	"rag-platform/pkg/nodeplugin"
 )
 
 // mockLLMClient 记录 ChatWithContext 收到的第一条 system 消息，并返回预定义 TaskGraph JSON
@@ -108,3 +109,18 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func TestLLMPlanner_PlanGoal_InjectsCustomNodeTypes(t *testing.T) {
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{
+		Type:        "planner_test.spark",
+		Description: "提交 Spark 作业",
+		Config:      []nodeplugin.Field{{Name: "cluster", Type: nodeplugin.FieldString, Required: true}},
+	})
+	mock := &mockLLMClient{}
+	if _, err := NewLLMPlanner(mock).PlanGoal(context.Background(), "跑一次日报 ETL", memory.NewCompositeMemory()); err != nil {
+		t.Fatalf("PlanGoal: %v", err)
+	}
+	if !strings.Contains(mock.lastSystemPrompt, "planner_test.spark - 提交 Spark 作业，config: {cluster:string（必填）}") {
+		t.Errorf("custom node type missing from prompt: %s", mock.lastSystemPrompt)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// map 节点限额
+const (
+	// DefaultMapMaxIterations 未配置 max_iterations 时的迭代上限
+	DefaultMapMaxIterations = 100
+	// MaxMapIterations max_iterations 的硬上限
+	MaxMapIterations = 1000
+	// MaxMapParallelism parallelism 上限
+	MaxMapParallelism = 16
+	// MaxMapRetries 每项 max_retries 上限
+	MaxMapRetries = 5
+)
+
+// MapConfig map 节点配置（TaskNode.Config）：
+//
+//	{"items":"search.output.items","item_key":"id","max_iterations":50,"parallelism":4,"max_retries":1,
+//	 "allow_partial":false,"body":{"nodes":[...],"edges":[...]}}
+//
+// items 为分支表达式语法，须求值为数组；body 节点 config 中的 "${item.xxx}" / "${index}" 按当前项替换，
+// 整个字符串仅为一个占位符时保留原始类型；body 内 branch 表达式可经 "<map 节点 ID>.item" 读取当前项
+type MapConfig struct {
+	Items *BranchExpr
+	// ItemKey 取每项中该路径的值作为幂等键（集合顺序变化时已完成项仍可命中）；空时用下标
+	ItemKey       string
+	keyExpr       *BranchExpr
+	MaxIterations int
+	Parallelism   int
+	MaxRetries    int
+	// AllowPartial 允许部分项失败（默认任一项重试耗尽即节点失败）
+	AllowPartial bool
+	Body         *TaskGraph
+}
+
+// mapBodyForbidden body 子图不允许的节点类型：会挂起 Job 或改写计划的节点不能放在迭代内
+var mapBodyForbidden = map[string]bool{
+	NodeWait: true, NodeApproval: true, NodeCondition: true, NodeReflect: true,
+	NodeSpawn: true, NodeJoin: true, NodeMap: true,
+}
+
+// ParseMapConfig 解析并校验 map 节点配置；knownTools 非空时校验 body 中的工具名。
+// 不检查表达式对外层节点的引用（由 ValidateTaskGraph 结合整张图校验）
+func ParseMapConfig(n *TaskNode, knownTools map[string]bool) (*MapConfig, error) {
+	return parseMapConfig(n, knownTools, nil)
+}
+
+// parseMapConfig inScope 为 nil 时不限制表达式引用的外层节点
+func parseMapConfig(n *TaskNode, knownTools map[string]bool, inScope func(id string) bool) (*MapConfig, error) {
+	src, _ := n.Config["items"].(string)
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("map node %q has no items", n.ID)
+	}
+	items, err := ParseBranchExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("map node %q: items: %w", n.ID, err)
+	}
+	for _, ref := range items.Nodes() {
+		if ref == n.ID || (inScope != nil && !inScope(ref)) {
+			return nil, fmt.Errorf("map node %q: items references unknown node %q", n.ID, ref)
+		}
+	}
+	cfg := &MapConfig{Items: items, MaxIterations: DefaultMapMaxIterations, Parallelism: 1}
+	if cfg.ItemKey, _ = n.Config["item_key"].(string); cfg.ItemKey != "" {
+		if cfg.keyExpr, err = ParseBranchExpr("item." + cfg.ItemKey); err != nil {
+			return nil, fmt.Errorf("map node %q: item_key: %w", n.ID, err)
+		}
+	}
+	cfg.AllowPartial, _ = n.Config["allow_partial"].(bool)
+	for _, f := range []struct {
+		key      string
+		dst      *int
+		min, max int
+	}{
+		{"max_iterations", &cfg.MaxIterations, 1, MaxMapIterations},
+		{"parallelism", &cfg.Parallelism, 1, MaxMapParallelism},
+		{"max_retries", &cfg.MaxRetries, 0, MaxMapRetries},
+	} {
+		v, ok := n.Config[f.key]
+		if !ok {
+			continue
+		}
+		i, isInt := configInt(v)
+		if !isInt || i < f.min || i > f.max {
+			return nil, fmt.Errorf("map node %q: %s must be an integer in [%d, %d]", n.ID, f.key, f.min, f.max)
+		}
+		*f.dst = i
+	}
+	raw, ok := n.Config["body"]
+	if !ok || raw == nil {
+		return nil, fmt.Errorf("map node %q has no body", n.ID)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("map node %q: body: %w", n.ID, err)
+	}
+	body := &TaskGraph{}
+	if err := json.Unmarshal(b, body); err != nil {
+		return nil, fmt.Errorf("map node %q: body: %w", n.ID, err)
+	}
+	for _, bn := range body.Nodes {
+		if mapBodyForbidden[bn.Type] {
+			return nil, fmt.Errorf("map node %q: body node %q: type %q is not allowed in a map body", n.ID, bn.ID, bn.Type)
+		}
+		if bn.ID == n.ID || (inScope != nil && inScope(bn.ID)) {
+			return nil, fmt.Errorf("map node %q: body node id %q clashes with a node outside the body", n.ID, bn.ID)
+		}
+		if bn.Transaction != "" {
+			return nil, fmt.Errorf("map node %q: body node %q cannot join a transaction", n.ID, bn.ID)
+		}
+		if err := checkPlaceholders(bn.Config); err != nil {
+			return nil, fmt.Errorf("map node %q: body node %q: %w", n.ID, bn.ID, err)
+		}
+	}
+	bodyScope := func(id string) bool { return id == n.ID || inScope == nil || inScope(id) }
+	if err := validateTaskGraph(body, knownTools, bodyScope); err != nil {
+		return nil, fmt.Errorf("map node %q: body: %w", n.ID, err)
+	}
+	cfg.Body = body
+	return cfg, nil
+}
+
+// ItemsOf 按 results 求值 items；结果须为数组（JSON 字符串按 JSON 解析），null 视为空集合
+func (c *MapConfig) ItemsOf(results map[string]any) ([]any, error) {
+	v, err := c.Items.Eval(results)
+	if err != nil {
+		return nil, err
+	}
+	switch t := decodeJSONString(v).(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return t, nil
+	default:
+		return nil, fmt.Errorf("items %q is %T, not a list", c.Items.String(), v)
+	}
+}
+
+// KeyOf 返回第 index 项的幂等键：配置 item_key 时取该字段，否则为下标
+func (c *MapConfig) KeyOf(item any, index int) (string, error) {
+	if c.keyExpr == nil {
+		return strconv.Itoa(index), nil
+	}
+	v, err := c.keyExpr.Eval(map[string]any{"item": item})
+	if err != nil {
+		return "", err
+	}
+	if v == nil || v == "" {
+		return "", fmt.Errorf("item %d has no %s", index, c.ItemKey)
+	}
+	return BranchOutcome(v), nil
+}
+
+var itemPlaceholder = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// MapItemConfig 返回 body 节点配置的副本，字符串中的 "${item...}" / "${index}" 按当前项求值替换；
+// 整个字符串仅为一个占位符时保留值的原始类型，否则按文本拼接
+func MapItemConfig(cfg map[string]any, item any, index int) (map[string]any, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	scope := map[string]any{"item": item, "index": float64(index)}
+	out, err := substituteItem(cfg, scope)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]any), nil
+}
+
+func substituteItem(v any, scope map[string]any) (any, error) {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, x := range t {
+			y, err := substituteItem(x, scope)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = y
+		}
+		return m, nil
+	case []any:
+		s := make([]any, len(t))
+		for i, x := range t {
+			y, err := substituteItem(x, scope)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = y
+		}
+		return s, nil
+	case string:
+		locs := itemPlaceholder.FindAllStringSubmatchIndex(t, -1)
+		if len(locs) == 0 {
+			return t, nil
+		}
+		var sb strings.Builder
+		last := 0
+		for _, loc := range locs {
+			expr, err := ParseBranchExpr(t[loc[2]:loc[3]])
+			if err != nil {
+				return nil, fmt.Errorf("placeholder %q: %w", t[loc[0]:loc[1]], err)
+			}
+			val, err := expr.Eval(scope)
+			if err != nil {
+				return nil, fmt.Errorf("placeholder %q: %w", t[loc[0]:loc[1]], err)
+			}
+			if loc[0] == 0 && loc[1] == len(t) {
+				return val, nil
+			}
+			sb.WriteString(t[last:loc[0]])
+			sb.WriteString(BranchOutcome(val))
+			last = loc[1]
+		}
+		sb.WriteString(t[last:])
+		return sb.String(), nil
+	}
+	return v, nil
+}
+
+// checkPlaceholders 校验配置中的占位符可解析，且只引用 item / index
+func checkPlaceholders(v any) error {
+	switch t := v.(type) {
+	case map[string]any:
+		for _, x := range t {
+			if err := checkPlaceholders(x); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, x := range t {
+			if err := checkPlaceholders(x); err != nil {
+				return err
+			}
+		}
+	case string:
+		for _, m := range itemPlaceholder.FindAllStringSubmatch(t, -1) {
+			expr, err := ParseBranchExpr(m[1])
+			if err != nil {
+				return fmt.Errorf("placeholder %q: %w", m[0], err)
+			}
+			for _, ref := range expr.Nodes() {
+				if ref != "item" && ref != "index" {
+					return fmt.Errorf("placeholder %q can only reference item or index", m[0])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// configInt 读取整数配置（JSON 数字为 float64）
+func configInt(v any) (int, bool) {
+	switch t := v.(type) {
+	case int:
+		return t, true
+	case int64:
+		return int(t), true
+	case float64:
+		if t == float64(int(t)) {
+			return int(t), true
+		}
+	}
+	return 0, false
+}
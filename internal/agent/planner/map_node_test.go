@@ -0,0 +1,120 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mapTestGraph(cfg map[string]any) *TaskGraph {
+	return &TaskGraph{
+		Nodes: []TaskNode{
+			{ID: "search", Type: NodeTool, ToolName: "search"},
+			{ID: "each", Type: NodeMap, Config: cfg},
+		},
+		Edges: []TaskEdge{{From: "search", To: "each"}},
+	}
+}
+
+func mapTestBody() map[string]any {
+	return map[string]any{
+		"nodes": []any{
+			map[string]any{"id": "fetch", "type": "tool", "tool_name": "http.get", "config": map[string]any{"url": "${item.url}"}},
+			map[string]any{"id": "worth", "type": "branch", "config": map[string]any{"expr": "each.item.score > 3 && !empty(fetch.output)"}},
+			map[string]any{"id": "sum", "type": "llm", "config": map[string]any{"goal": "总结第 ${index} 篇：${item.title}"}},
+		},
+		"edges": []any{
+			map[string]any{"from": "fetch", "to": "worth"},
+			map[string]any{"from": "worth", "to": "sum", "when": "true"},
+		},
+	}
+}
+
+func TestParseMapConfig(t *testing.T) {
+	g := mapTestGraph(map[string]any{"items": "search.output.items", "item_key": "id", "parallelism": float64(4), "max_retries": 1, "body": mapTestBody()})
+	if err := ValidateTaskGraph(g, nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ParseMapConfig(&g.Nodes[1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxIterations != DefaultMapMaxIterations || cfg.Parallelism != 4 || cfg.MaxRetries != 1 || len(cfg.Body.Nodes) != 3 {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	items, err := cfg.ItemsOf(map[string]any{"search": map[string]any{"output": `{"items":[{"id":"a"},{"id":7}]}`}})
+	if err != nil || len(items) != 2 {
+		t.Fatalf("items = %v, %v", items, err)
+	}
+	if k, _ := cfg.KeyOf(items[1], 1); k != "7" {
+		t.Fatalf("key = %q", k)
+	}
+	if _, err := cfg.KeyOf(map[string]any{}, 0); err == nil {
+		t.Fatal("item without key should fail")
+	}
+	if items, err := cfg.ItemsOf(map[string]any{}); err != nil || items != nil {
+		t.Fatalf("missing items = %v, %v", items, err)
+	}
+	if _, err := cfg.ItemsOf(map[string]any{"search": map[string]any{"output": map[string]any{"items": "text"}}}); err == nil {
+		t.Fatal("non-list items should fail")
+	}
+}
+
+func TestValidateTaskGraph_Map(t *testing.T) {
+	cases := map[string]map[string]any{
+		"has no items":                 {"body": mapTestBody()},
+		"has no body":                  {"items": "search.output"},
+		"items references unknown":     {"items": "nope.output", "body": mapTestBody()},
+		"max_iterations must be":       {"items": "search.output", "max_iterations": MaxMapIterations + 1, "body": mapTestBody()},
+		"parallelism must be":          {"items": "search.output", "parallelism": 0, "body": mapTestBody()},
+		"max_retries must be":          {"items": "search.output", "max_retries": 1.5, "body": mapTestBody()},
+		"not allowed in a map body":    {"items": "search.output", "body": map[string]any{"nodes": []any{map[string]any{"id": "w", "type": "wait"}}}},
+		"clashes with a node":          {"items": "search.output", "body": map[string]any{"nodes": []any{map[string]any{"id": "search", "type": "llm"}}}},
+		"can only reference item":      {"items": "search.output", "body": map[string]any{"nodes": []any{map[string]any{"id": "s", "type": "llm", "config": map[string]any{"goal": "${search.output}"}}}}},
+		"references unknown node":      {"items": "search.output", "body": map[string]any{"nodes": []any{map[string]any{"id": "b", "type": "branch", "config": map[string]any{"expr": "nope.x"}}}}},
+		"body: tool node \"t\" has no": {"items": "search.output", "body": map[string]any{"nodes": []any{map[string]any{"id": "t", "type": "tool"}}}},
+	}
+	for want, cfg := range cases {
+		err := ValidateTaskGraph(mapTestGraph(cfg), nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v", want, err)
+		}
+	}
+}
+
+func TestMapItemConfig(t *testing.T) {
+	item := map[string]any{"title": "Go", "tags": []any{"a", "b"}, "n": 2.0}
+	got, err := MapItemConfig(map[string]any{
+		"goal":  "总结 ${item.title}（第 ${index} 项，${len(item.tags)} 个标签）",
+		"input": map[string]any{"tags": "${item.tags}", "n": "${item.n}", "raw": "plain"},
+		"list":  []any{"${item.title}", 1.0},
+	}, item, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"goal":  "总结 Go（第 3 项，2 个标签）",
+		"input": map[string]any{"tags": []any{"a", "b"}, "n": 2.0, "raw": "plain"},
+		"list":  []any{"Go", 1.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MapItemConfig = %#v", got)
+	}
+	if cfg, err := MapItemConfig(nil, item, 0); err != nil || cfg != nil {
+		t.Fatalf("nil config = %v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PlanDiff 两个任务图的差异：节点按 ID 对齐，Changed 列出同 ID 节点上变化的字段（规划理由不参与比较）
+type PlanDiff struct {
+	Added        []TaskNode   `json:"added"`
+	Removed      []TaskNode   `json:"removed"`
+	Changed      []NodeChange `json:"changed"`
+	Unchanged    int          `json:"unchanged"`
+	AddedEdges   []TaskEdge   `json:"added_edges,omitempty"`
+	RemovedEdges []TaskEdge   `json:"removed_edges,omitempty"`
+}
+
+// NodeChange 同 ID 节点的变化；Fields 为变化的字段名（type、tool_name、workflow、config、transaction、compensate）
+type NodeChange struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+	Before TaskNode `json:"before"`
+	After  TaskNode `json:"after"`
+}
+
+// Empty 两个任务图在节点与边上完全一致
+func (d *PlanDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
+
+// DiffTaskGraphs 比较 prior（历史计划）与 next（新计划）；nil 视为空图。结果按各自图中的节点/边顺序排列
+func DiffTaskGraphs(prior, next *TaskGraph) *PlanDiff {
+	if prior == nil {
+		prior = &TaskGraph{}
+	}
+	if next == nil {
+		next = &TaskGraph{}
+	}
+	d := &PlanDiff{Added: []TaskNode{}, Removed: []TaskNode{}, Changed: []NodeChange{}}
+	before := make(map[string]TaskNode, len(prior.Nodes))
+	for _, n := range prior.Nodes {
+		before[n.ID] = n
+	}
+	after := make(map[string]bool, len(next.Nodes))
+	for _, n := range next.Nodes {
+		after[n.ID] = true
+		old, ok := before[n.ID]
+		if !ok {
+			d.Added = append(d.Added, n)
+			continue
+		}
+		if fields := changedNodeFields(old, n); len(fields) > 0 {
+			d.Changed = append(d.Changed, NodeChange{ID: n.ID, Fields: fields, Before: old, After: n})
+		} else {
+			d.Unchanged++
+		}
+	}
+	for _, n := range prior.Nodes {
+		if !after[n.ID] {
+			d.Removed = append(d.Removed, n)
+		}
+	}
+	d.AddedEdges = edgesMissingFrom(next.Edges, prior.Edges)
+	d.RemovedEdges = edgesMissingFrom(prior.Edges, next.Edges)
+	return d
+}
+
+// changedNodeFields 返回两个同 ID 节点上不同的字段；Config/Compensate 按 JSON 规范化后比较
+func changedNodeFields(a, b TaskNode) []string {
+	var fields []string
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if a.ToolName != b.ToolName {
+		fields = append(fields, "tool_name")
+	}
+	if a.Workflow != b.Workflow {
+		fields = append(fields, "workflow")
+	}
+	if !jsonEqual(a.Config, b.Config) {
+		fields = append(fields, "config")
+	}
+	if a.Transaction != b.Transaction {
+		fields = append(fields, "transaction")
+	}
+	if !jsonEqual(a.Compensate, b.Compensate) {
+		fields = append(fields, "compensate")
+	}
+	return fields
+}
+
+// jsonEqual 按 JSON 编码比较（map 键有序）；空 map 与 nil 视为相同
+func jsonEqual(a, b any) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	empty := func(v []byte) bool { return bytes.Equal(v, []byte("null")) || bytes.Equal(v, []byte("{}")) }
+	return bytes.Equal(ab, bb) || (empty(ab) && empty(bb))
+}
+
+// edgesMissingFrom 返回 edges 中不在 other 里的边
+func edgesMissingFrom(edges, other []TaskEdge) []TaskEdge {
+	set := make(map[TaskEdge]bool, len(other))
+	for _, e := range other {
+		set[e] = true
+	}
+	var out []TaskEdge
+	for _, e := range edges {
+		if !set[e] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// GoalSimilarity 两个目标的词项余弦相似度（0~1），切分方式与 SelectExemplars 相同
+func GoalSimilarity(a, b string) float64 {
+	return termCosine(goalTermsOf(a), goalTermsOf(b))
+}
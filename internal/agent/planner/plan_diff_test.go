@@ -0,0 +1,67 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffTaskGraphs(t *testing.T) {
+	prior := &TaskGraph{
+		Nodes: []TaskNode{
+			{ID: "fetch", Type: NodeTool, ToolName: "http.get", Config: map[string]any{"url": "a"}},
+			{ID: "sum", Type: NodeLLM, Rationale: "summarize"},
+			{ID: "old", Type: NodeTool, ToolName: "email.send"},
+		},
+		Edges: []TaskEdge{{From: "fetch", To: "sum"}, {From: "sum", To: "old"}},
+	}
+	next := &TaskGraph{
+		Nodes: []TaskNode{
+			{ID: "fetch", Type: NodeTool, ToolName: "http.get", Config: map[string]any{"url": "b"}},
+			{ID: "sum", Type: NodeLLM, Rationale: "different rationale"},
+			{ID: "post", Type: NodeTool, ToolName: "slack.post"},
+		},
+		Edges: []TaskEdge{{From: "fetch", To: "sum"}, {From: "sum", To: "post"}},
+	}
+	d := DiffTaskGraphs(prior, next)
+	if len(d.Added) != 1 || d.Added[0].ID != "post" || len(d.Removed) != 1 || d.Removed[0].ID != "old" {
+		t.Fatalf("added/removed = %+v / %+v", d.Added, d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].ID != "fetch" || !reflect.DeepEqual(d.Changed[0].Fields, []string{"config"}) {
+		t.Fatalf("changed = %+v", d.Changed)
+	}
+	if d.Unchanged != 1 {
+		t.Fatalf("unchanged = %d, rationale must not count as a change", d.Unchanged)
+	}
+	if !reflect.DeepEqual(d.AddedEdges, []TaskEdge{{From: "sum", To: "post"}}) || !reflect.DeepEqual(d.RemovedEdges, []TaskEdge{{From: "sum", To: "old"}}) {
+		t.Fatalf("edges = +%v -%v", d.AddedEdges, d.RemovedEdges)
+	}
+	if d.Empty() || !DiffTaskGraphs(prior, prior).Empty() {
+		t.Fatal("Empty mismatch")
+	}
+	if d := DiffTaskGraphs(nil, next); len(d.Added) != 3 || len(d.AddedEdges) != 2 {
+		t.Fatalf("nil prior: %+v", d)
+	}
+}
+
+func TestGoalSimilarity(t *testing.T) {
+	if s := GoalSimilarity("crawl two sites", "crawl two sites"); s < 0.99 {
+		t.Fatalf("identical goals = %v", s)
+	}
+	if s := GoalSimilarity("crawl two sites", "translate a document"); s != 0 {
+		t.Fatalf("unrelated goals = %v", s)
+	}
+}
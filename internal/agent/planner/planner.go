@@ -17,12 +17,18 @@ package planner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"rag-platform/internal/agent/memory"
 	"rag-platform/internal/model/llm"
 	"rag-platform/internal/runtime/session"
+	"rag-platform/pkg/metrics"
+	"rag-platform/pkg/nodeplugin"
 )
 
 // PlanStep 计划中的单步：调用的工具及入参
@@ -54,16 +60,24 @@ type Planner interface {
 	PlanGoal(ctx context.Context, goal string, mem memory.Memory) (*TaskGraph, error)
 }
 
-// toolSchemaItem 用于解析 SchemasForLLM 输出的单项（仅取 name、description 注入 PlanGoal prompt）
+// toolSchemaItem 用于解析 SchemasForLLM 输出的单项（取 name、description 及成本/延迟标注注入 PlanGoal prompt）
 type toolSchemaItem struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name              string  `json:"name"`
+	Description       string  `json:"description"`
+	ExpectedLatencyMs int64   `json:"expected_latency_ms,omitempty"`
+	CostPerCall       float64 `json:"cost_per_call,omitempty"`
 }
 
 // LLMPlanner 基于 LLM 的最小实现：生成 JSON Plan
 type LLMPlanner struct {
 	client             llm.Client
-	toolsSchemaForGoal []byte // 可选；由应用层通过 SetToolsSchemaForGoal 注入，PlanGoal 时写入 prompt 便于 LLM 选择工具
+	toolsSchemaForGoal []byte               // 可选；由应用层通过 SetToolsSchemaForGoal 注入，PlanGoal 时写入 prompt 便于 LLM 选择工具
+	llmCost            ToolCostHint         // 可选；LLM 节点的单次成本/延迟，用于计划预估
+	budget             PlanBudget           // 可选；PlanGoal 产出的计划超出预算时要求 LLM 重新规划一次
+	exemplars          ExemplarStore        // 可选；按 ctx 中的 Agent ID 选取相似示例注入 PlanGoal prompt
+	exemplarOpts       ExemplarOptions      // few-shot 注入参数（TopK、A/B 对照组比例）
+	validity           *PlanValidityTracker // 可选；按 Agent 与 A/B 分组统计计划有效率
+	requireRationale   bool                 // 为 true 时计划中有节点缺少 rationale 则要求 LLM 补全一次
 }
 
 // NewLLMPlanner 创建基于 LLM 的 Planner
@@ -77,6 +91,32 @@ func (p *LLMPlanner) SetToolsSchemaForGoal(schemaJSON []byte) {
 	p.toolsSchemaForGoal = schemaJSON
 }
 
+// SetPlanCost 设置 LLM 节点成本标注与计划预算；预算非零时 PlanGoal 会校验预估成本/ETA，超出则带反馈重新规划一次
+func (p *LLMPlanner) SetPlanCost(llmCost ToolCostHint, budget PlanBudget) {
+	p.llmCost = llmCost
+	p.budget = budget
+}
+
+// SetRequireRationale 设置是否强制每个节点给出规划理由（agent.plan_rationale.required）；未强制时 LLM 可省略
+func (p *LLMPlanner) SetRequireRationale(required bool) {
+	p.requireRationale = required
+}
+
+// SetExemplars 设置规划示例库：PlanGoal 时按 ctx 中的 Agent ID（WithAgentID）选取与目标最相似的示例注入 prompt，
+// 并按 opts.ControlRatio 保留对照组；tracker 非 nil 时记录各分组的计划有效率
+func (p *LLMPlanner) SetExemplars(store ExemplarStore, opts ExemplarOptions, tracker *PlanValidityTracker) {
+	p.exemplars = store
+	p.exemplarOpts = opts
+	p.validity = tracker
+}
+
+// CostModel 返回当前工具列表与 LLM 标注构成的成本模型（供计划预估）
+func (p *LLMPlanner) CostModel() CostModel {
+	m := CostModelFromSchemaJSON(p.toolsSchemaForGoal)
+	m.LLM = p.llmCost
+	return m
+}
+
 // Plan 实现 Planner
 func (p *LLMPlanner) Plan(ctx context.Context, query string, toolsSchemaJSON []byte, history []llm.Message) (*PlanResult, error) {
 	if p.client == nil {
@@ -105,7 +145,7 @@ func (p *LLMPlanner) Plan(ctx context.Context, query string, toolsSchemaJSON []b
 	messages = append(messages, llm.Message{Role: "user", Content: "用户问题：" + query})
 
 	opts := llm.GenerateOptions{MaxTokens: 2048, Temperature: 0.2}
-	reply, err := p.client.ChatWithContext(ctx, messages, opts)
+	reply, err := p.client.ChatWithContext(llm.WithBucket(ctx, llm.BucketPlanning), messages, opts)
 	if err != nil {
 		return nil, fmt.Errorf("Planner LLM 调用failed: %w", err)
 	}
@@ -157,7 +197,7 @@ func (p *LLMPlanner) Next(ctx context.Context, sess *session.Session, userQuery
 	messages = append(messages, llm.Message{Role: "user", Content: "请输出下一步（单个 JSON 对象）："})
 
 	opts := llm.GenerateOptions{MaxTokens: 1024, Temperature: 0.2}
-	reply, err := p.client.ChatWithContext(ctx, messages, opts)
+	reply, err := p.client.ChatWithContext(llm.WithBucket(ctx, llm.BucketPlanning), messages, opts)
 	if err != nil {
 		return nil, fmt.Errorf("Planner Next LLM 调用failed: %w", err)
 	}
@@ -187,33 +227,162 @@ func (p *LLMPlanner) PlanGoal(ctx context.Context, goal string, mem memory.Memor
 	for _, it := range items {
 		contextStr += it.Content + "\n"
 	}
-	systemPrompt := `根据用户目标生成任务图（JSON）。格式：{"nodes":[{"id":"n1","type":"tool|workflow|llm","tool_name":"xxx 或 workflow 名"}],"edges":[{"from":"n1","to":"n2"}]}。若单步可完成，一个节点即可。`
+	systemPrompt := `根据用户目标生成任务图（JSON）。格式：{"nodes":[{"id":"n1","type":"tool|workflow|llm","tool_name":"xxx 或 workflow 名"}],"edges":[{"from":"n1","to":"n2"}]}。若单步可完成，一个节点即可。` +
+		`多个 tool 节点须"全部成功或全部撤销"时（如扣款+下单），为它们设置相同的 "transaction":"tx1"，并给出 "compensate":{"tool":"撤销用的工具","input":{...}}；input 中 "$result.output.xxx" 引用该节点的结果。` +
+		`需按中间结果走不同路径时（如检索无结果则询问用户、否则总结），加入 {"id":"check","type":"branch","config":{"expr":"empty(n1.output)"}}，` +
+		`其出边用 "when" 标注分支结果：{"from":"check","to":"ask","when":"true"}、{"from":"check","to":"sum","when":"false"}；expr 以节点 ID 开头的路径引用结果，支持 == != < > && || ! 与 empty/len/contains/exists。` +
+		`需对上一步产出的列表逐项处理时，用 {"id":"each","type":"map","config":{"items":"n1.output.items","max_iterations":20,"body":{"nodes":[...],"edges":[...]}}} 代替逐项展开的节点；` +
+		`body 节点 config 中 "${item.xxx}"、"${index}" 取当前项，可选 "parallelism" 并行与 "max_retries" 每项重试。` +
+		`llm 节点可在 "config" 中声明 "quality":"low|standard|high" 与 "max_latency":"2s"，摘要、分类等低风险步骤用 low 以选用便宜快速的模型。` +
+		rationalePrompt
 	if len(p.toolsSchemaForGoal) > 0 {
 		var toolList []toolSchemaItem
 		if err := json.Unmarshal(p.toolsSchemaForGoal, &toolList); err == nil && len(toolList) > 0 {
 			var parts []string
+			annotated := false
 			for _, t := range toolList {
 				if t.Name != "" {
 					desc := t.Description
 					if len(desc) > 80 {
 						desc = desc[:77] + "..."
 					}
+					if hint := costHintText(t.ExpectedLatencyMs, t.CostPerCall); hint != "" {
+						desc += "（" + hint + "）"
+						annotated = true
+					}
 					parts = append(parts, t.Name+" - "+desc)
 				}
 			}
 			if len(parts) > 0 {
 				systemPrompt += "\n可用工具（type=tool 时 tool_name 取以下之一）：" + strings.Join(parts, "；")
 			}
+			if annotated {
+				systemPrompt += "\n括号内为单次调用的预期延迟与费用；在能完成目标的前提下优先选择更便宜、更快的工具，并避免不必要的步骤。"
+			}
+		}
+	}
+	systemPrompt += customNodesText()
+	if budget := budgetText(p.budget); budget != "" {
+		systemPrompt += "\n计划预算：" + budget + "。"
+	}
+	agentID := AgentIDFromContext(ctx)
+	variant := PlanVariantNone
+	if selected := p.selectExemplars(ctx, agentID, goal); len(selected) > 0 {
+		if inControlGroup(agentID, goal, p.exemplarOpts.ControlRatio) {
+			variant = PlanVariantControl
+		} else {
+			variant = PlanVariantExemplars
+			systemPrompt += exemplarsText(selected)
 		}
 	}
 	messages := []llm.Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: "目标：" + goal + "\n上下文：\n" + contextStr},
 	}
+	g, parsed, err := p.planGoalOnce(ctx, messages, goal)
+	if err != nil {
+		return nil, err
+	}
+	// 计划有效率只看首次输出（预算重规划是另一条反馈回路），便于比较示例注入与对照组
+	p.recordPlanValidity(agentID, variant, parsed && ValidateTaskGraph(g, p.knownTools()) == nil)
+	// 规划理由：强制时缺少 rationale 的节点要求 LLM 补全一次，只按节点 ID 取回理由、不采用改动后的计划（仍缺失则 Trace 中显示为空）
+	if missing := g.MissingRationale(); p.requireRationale && parsed && len(missing) > 0 {
+		prev, _ := g.Marshal()
+		retry := append(slices.Clone(messages),
+			llm.Message{Role: "assistant", Content: string(prev)},
+			llm.Message{Role: "user", Content: "节点 " + strings.Join(missing, "、") + " 缺少 rationale，请为每个节点补充规划理由后重新输出完整任务图 JSON（节点与边不变）。"},
+		)
+		if g2, ok, err := p.planGoalOnce(ctx, retry, goal); err == nil && ok {
+			g.mergeRationale(g2)
+		}
+	}
+	// 计划校验：超出预算时把预估结果反馈给 LLM 重新规划一次，仍超出则返回 ErrPlanOverBudget
+	if p.budget.MaxCost > 0 || p.budget.MaxETA > 0 {
+		model := p.CostModel()
+		if errBudget := ValidatePlanBudget(EstimateTaskGraph(g, model), p.budget); errBudget != nil {
+			prev, _ := g.Marshal()
+			messages = append(messages,
+				llm.Message{Role: "assistant", Content: string(prev)},
+				llm.Message{Role: "user", Content: "该计划超出预算（" + errBudget.Error() + "），请改用更便宜、更快的工具或减少步骤后重新输出任务图 JSON。"},
+			)
+			g, _, err = p.planGoalOnce(ctx, messages, goal)
+			if err != nil {
+				return nil, err
+			}
+			if errBudget := ValidatePlanBudget(EstimateTaskGraph(g, model), p.budget); errBudget != nil {
+				var be *PlanBudgetError
+				if errors.As(errBudget, &be) {
+					be.Graph = g
+				}
+				return nil, errBudget
+			}
+		}
+	}
+	return g, nil
+}
+
+// selectExemplars 取 Agent 的示例并按目标相似度选取 TopK；未配置示例库或无 Agent ID 时返回 nil
+func (p *LLMPlanner) selectExemplars(ctx context.Context, agentID, goal string) []ScoredExemplar {
+	if p.exemplars == nil || agentID == "" {
+		return nil
+	}
+	list, err := p.exemplars.List(ctx, agentID)
+	if err != nil {
+		return nil
+	}
+	k := p.exemplarOpts.TopK
+	if k <= 0 {
+		k = 3
+	}
+	return SelectExemplars(goal, list, k)
+}
+
+// exemplarsText few-shot 示例的 prompt 文本
+func exemplarsText(selected []ScoredExemplar) string {
+	var b strings.Builder
+	b.WriteString("\n参考示例（运维为该 Agent 精选的同类目标与优质任务图，按相关度排序；参考其结构与工具选择，按当前目标调整，不要照抄无关节点）：")
+	for i, s := range selected {
+		graphJSON, err := s.Exemplar.Graph.Marshal()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\n示例 %d 目标：%s\n任务图：%s", i+1, s.Exemplar.Goal, graphJSON)
+	}
+	return b.String()
+}
+
+// knownTools 由 SetToolsSchemaForGoal 的工具列表得到工具名集合；未设置时返回 nil（不校验工具名）
+func (p *LLMPlanner) knownTools() map[string]bool {
+	if len(p.toolsSchemaForGoal) == 0 {
+		return nil
+	}
+	var toolList []toolSchemaItem
+	if err := json.Unmarshal(p.toolsSchemaForGoal, &toolList); err != nil {
+		return nil
+	}
+	known := make(map[string]bool, len(toolList))
+	for _, t := range toolList {
+		if t.Name != "" {
+			known[t.Name] = true
+		}
+	}
+	return known
+}
+
+// recordPlanValidity 记录计划有效率（Prometheus 指标与按 Agent 的进程内统计）
+func (p *LLMPlanner) recordPlanValidity(agentID, variant string, valid bool) {
+	metrics.PlannerPlansTotal.WithLabelValues(variant, strconv.FormatBool(valid)).Inc()
+	if agentID != "" {
+		p.validity.Record(agentID, variant, valid)
+	}
+}
+
+// planGoalOnce 调用 LLM 生成一次任务图；输出无法解析时退化为单 LLM 节点（parsed=false）
+func (p *LLMPlanner) planGoalOnce(ctx context.Context, messages []llm.Message, goal string) (*TaskGraph, bool, error) {
 	opts := llm.GenerateOptions{MaxTokens: 1024, Temperature: 0.2}
-	reply, err := p.client.ChatWithContext(ctx, messages, opts)
+	reply, err := p.client.ChatWithContext(llm.WithBucket(ctx, llm.BucketPlanning), messages, opts)
 	if err != nil {
-		return nil, fmt.Errorf("PlanGoal LLM 调用failed: %w", err)
+		return nil, false, fmt.Errorf("PlanGoal LLM 调用failed: %w", err)
 	}
 	reply = strings.TrimSpace(reply)
 	if idx := strings.Index(reply, "{"); idx >= 0 {
@@ -226,7 +395,61 @@ func (p *LLMPlanner) PlanGoal(ctx context.Context, goal string, mem memory.Memor
 		return &TaskGraph{
 			Nodes: []TaskNode{{ID: "n1", Type: NodeLLM, Config: map[string]any{"goal": goal}}},
 			Edges: nil,
-		}, nil
+		}, false, nil
+	}
+	normalizeRationale(&g)
+	return &g, true, nil
+}
+
+// costHintText 工具成本/延迟标注的 prompt 文本，如 "约 1.5s，$0.0020/次"；均未知时返回空
+func costHintText(latencyMs int64, cost float64) string {
+	var parts []string
+	if latencyMs > 0 {
+		parts = append(parts, "约 "+(time.Duration(latencyMs)*time.Millisecond).String())
+	}
+	if cost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.4f/次", cost))
+	}
+	return strings.Join(parts, "，")
+}
+
+// budgetText 计划预算的 prompt 文本；未设置时返回空
+func budgetText(b PlanBudget) string {
+	var parts []string
+	if b.MaxCost > 0 {
+		parts = append(parts, fmt.Sprintf("总费用不超过 $%.4f", b.MaxCost))
+	}
+	if b.MaxETA > 0 {
+		parts = append(parts, "预计耗时不超过 "+b.MaxETA.String())
+	}
+	return strings.Join(parts, "，")
+}
+
+// customNodesText 已注册的自定义节点类型（nodeplugin）及其 config 字段，供 LLM 规划时选用
+func customNodesText() string {
+	plugins := nodeplugin.Plugins()
+	if len(plugins) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(plugins))
+	for _, pl := range plugins {
+		m := pl.Manifest
+		s := m.Type
+		if m.Description != "" {
+			s += " - " + m.Description
+		}
+		if len(m.Config) > 0 {
+			fields := make([]string, 0, len(m.Config))
+			for _, f := range m.Config {
+				field := f.Name + ":" + f.Type
+				if f.Required {
+					field += "（必填）"
+				}
+				fields = append(fields, field)
+			}
+			s += "，config: {" + strings.Join(fields, ", ") + "}"
+		}
+		parts = append(parts, s)
 	}
-	return &g, nil
+	return "\n自定义节点（type 取以下之一，参数写入 config）：" + strings.Join(parts, "；")
 }
@@ -0,0 +1,91 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxRationaleRunes 单个节点规划理由的最大字符数；超出部分截断
+const MaxRationaleRunes = 300
+
+// rationalePrompt PlanGoal system prompt 中要求 LLM 为每个节点给出规划理由
+const rationalePrompt = `每个节点给出 "rationale"：一两句话说明为何选用该工具/节点、为何排在此顺序（依赖哪一步的结果），供审计查看。`
+
+// NodeRationale 一个节点的规划理由（计划预览、Trace 与证据包展示）
+type NodeRationale struct {
+	NodeID    string `json:"node_id"`
+	Type      string `json:"type"`
+	Tool      string `json:"tool,omitempty"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// Rationales 按节点顺序返回各节点的规划理由（含未给出理由的节点，便于审计发现缺失）
+func (g *TaskGraph) Rationales() []NodeRationale {
+	if g == nil {
+		return nil
+	}
+	out := make([]NodeRationale, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		tool := n.ToolName
+		if tool == "" {
+			tool = n.Workflow
+		}
+		out = append(out, NodeRationale{NodeID: n.ID, Type: n.Type, Tool: tool, Rationale: n.Rationale})
+	}
+	return out
+}
+
+// MissingRationale 返回未给出规划理由的节点 ID
+func (g *TaskGraph) MissingRationale() []string {
+	if g == nil {
+		return nil
+	}
+	var missing []string
+	for _, n := range g.Nodes {
+		if strings.TrimSpace(n.Rationale) == "" {
+			missing = append(missing, n.ID)
+		}
+	}
+	return missing
+}
+
+// mergeRationale 对 g 中缺少理由的节点，取 other 中同 ID 节点的理由
+func (g *TaskGraph) mergeRationale(other *TaskGraph) {
+	byID := make(map[string]string, len(other.Nodes))
+	for _, n := range other.Nodes {
+		byID[n.ID] = n.Rationale
+	}
+	for i := range g.Nodes {
+		if g.Nodes[i].Rationale == "" {
+			g.Nodes[i].Rationale = byID[g.Nodes[i].ID]
+		}
+	}
+}
+
+// normalizeRationale 去除首尾空白并截断过长的规划理由
+func normalizeRationale(g *TaskGraph) {
+	if g == nil {
+		return
+	}
+	for i := range g.Nodes {
+		r := strings.TrimSpace(g.Nodes[i].Rationale)
+		if utf8.RuneCountInString(r) > MaxRationaleRunes {
+			r = string([]rune(r)[:MaxRationaleRunes-1]) + "…"
+		}
+		g.Nodes[i].Rationale = r
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/memory"
+	"rag-platform/internal/model/llm"
+)
+
+// sequenceLLMClient 依次返回 replies，并记录 system prompt 与每次调用的最后一条消息
+type sequenceLLMClient struct {
+	mockLLMClient
+	replies []string
+	lastMsg []string
+}
+
+func (m *sequenceLLMClient) ChatWithContext(ctx context.Context, messages []llm.Message, opts llm.GenerateOptions) (string, error) {
+	m.lastSystemPrompt = messages[0].Content
+	m.lastMsg = append(m.lastMsg, messages[len(messages)-1].Content)
+	reply := m.replies[min(len(m.lastMsg), len(m.replies))-1]
+	return reply, nil
+}
+
+func TestLLMPlanner_PlanGoal_Rationale(t *testing.T) {
+	first := `{"nodes":[{"id":"n1","type":"tool","tool_name":"knowledge.search","rationale":"  先检索文档，为总结提供依据  "},{"id":"n2","type":"llm"}],"edges":[{"from":"n1","to":"n2"}]}`
+	filled := `{"nodes":[{"id":"n1","type":"tool","tool_name":"web.search","rationale":"换了工具"},{"id":"n2","type":"llm","rationale":"依赖 n1 的检索结果生成总结"},{"id":"n3","type":"llm","rationale":"多出的节点"}],"edges":[]}`
+
+	t.Run("allowed", func(t *testing.T) {
+		client := &sequenceLLMClient{replies: []string{first, filled}}
+		g, err := NewLLMPlanner(client).PlanGoal(context.Background(), "总结文档", memory.NewCompositeMemory())
+		if err != nil {
+			t.Fatalf("PlanGoal: %v", err)
+		}
+		if len(client.lastMsg) != 1 || !strings.Contains(client.lastSystemPrompt, "rationale") {
+			t.Fatalf("calls = %d, prompt mentions rationale = %v", len(client.lastMsg), strings.Contains(client.lastSystemPrompt, "rationale"))
+		}
+		if g.Nodes[0].Rationale != "先检索文档，为总结提供依据" {
+			t.Fatalf("rationale not trimmed: %q", g.Nodes[0].Rationale)
+		}
+		if missing := g.MissingRationale(); len(missing) != 1 || missing[0] != "n2" {
+			t.Fatalf("missing = %v", missing)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		client := &sequenceLLMClient{replies: []string{first, filled}}
+		p := NewLLMPlanner(client)
+		p.SetRequireRationale(true)
+		g, err := p.PlanGoal(context.Background(), "总结文档", memory.NewCompositeMemory())
+		if err != nil {
+			t.Fatalf("PlanGoal: %v", err)
+		}
+		if len(client.lastMsg) != 2 || !strings.Contains(client.lastMsg[1], "n2") {
+			t.Fatalf("follow-up = %v", client.lastMsg)
+		}
+		// 只取回缺失节点的理由，计划本身不变
+		if len(g.Nodes) != 2 || g.Nodes[0].ToolName != "knowledge.search" || len(g.Edges) != 1 {
+			t.Fatalf("plan changed: %+v", g)
+		}
+		got := g.Rationales()
+		if got[0].Rationale != "先检索文档，为总结提供依据" || got[0].Tool != "knowledge.search" || got[1].Rationale != "依赖 n1 的检索结果生成总结" {
+			t.Fatalf("rationales = %+v", got)
+		}
+	})
+}
+
+func TestNormalizeRationale_Truncates(t *testing.T) {
+	g := &TaskGraph{Nodes: []TaskNode{{ID: "n1", Rationale: strings.Repeat("理", MaxRationaleRunes+10)}}}
+	normalizeRationale(g)
+	if n := len([]rune(g.Nodes[0].Rationale)); n != MaxRationaleRunes || !strings.HasSuffix(g.Nodes[0].Rationale, "…") {
+		t.Fatalf("len = %d", n)
+	}
+}
@@ -32,6 +32,16 @@ const (
 	NodeCondition = "condition"
 	// NodeLangGraph LangGraph 桥接节点：通过 LangGraph Adapter 调用外部图执行器（invoke/stream/state）
 	NodeLangGraph = "langgraph"
+	// NodeReflect 反思节点：内建节点，执行到此处时由 Runner 让 LLM 复盘已执行轨迹，可提出计划修订（plan_evolution）
+	NodeReflect = "reflect"
+	// NodeSpawn 监督者派发节点：按 Config["children"] 为每个子任务创建子 Job（子 Agent），输出子 Job 列表
+	NodeSpawn = "spawn"
+	// NodeJoin 监督者汇合节点：等待 spawn 节点的子 Job 全部终态并汇总结果；失败子任务按 max_redispatch 重派，超出 budget 时失败
+	NodeJoin = "join"
+	// NodeBranch 分支节点：按 Config["expr"] 对已有节点结果确定性求值，出边 TaskEdge.When 匹配结果者执行、其余分支跳过；选择写入 decision_made
+	NodeBranch = "branch"
+	// NodeMap 映射节点：对前序步骤产出的集合逐项执行 Config["body"] 子图（有迭代上限、每项独立幂等键、可选并行），结果按项汇总
+	NodeMap = "map"
 )
 
 // WaitKind 等待类型（NodeWait 时 Config["wait_kind"]）
@@ -42,21 +52,40 @@ const (
 	WaitKindCondition = "condition"
 	// WaitKindMessage 信箱：等待 agent_message 事件，channel 或 correlation_key 匹配即解除（design/agent-process-model.md Mailbox）
 	WaitKindMessage = "message"
+	// WaitKindReview 审阅门：llm 节点 Config["review"]=true 时生成后挂起，等待人类通过或编辑输出再继续
+	WaitKindReview = "review"
+	// WaitKindChildren 子 Job：join 节点等待子 Job 终态，子 Job 结束时由 Worker 重新入队监督者
+	WaitKindChildren = "children"
 )
 
 // TaskNode 任务图中的节点
 type TaskNode struct {
 	ID       string         `json:"id"`
-	Type     string         `json:"type"` // tool / workflow / llm / wait / approval / condition / langgraph
+	Type     string         `json:"type"` // tool / workflow / llm / wait / approval / condition / langgraph / reflect / spawn / join / branch / map
 	Config   map[string]any `json:"config,omitempty"`
 	ToolName string         `json:"tool_name,omitempty"` // Type=tool 时使用
 	Workflow string         `json:"workflow,omitempty"`  // Type=workflow 时使用
+	// Transaction 事务组 ID（仅 tool 节点）：同组节点全部提交，或任一失败时由 Runner 对已提交成员执行补偿
+	Transaction string `json:"transaction,omitempty"`
+	// Compensate 事务组成员的补偿动作；回滚时按提交逆序执行
+	Compensate *Compensation `json:"compensate,omitempty"`
+	// Rationale 规划理由：为何选用该工具/节点、为何在此顺序；随 PlanGenerated 持久化，供 Trace、计划预览与证据包审计
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// Compensation 补偿动作：调用 Tool 撤销已提交的副作用（如退款、取消预订）；
+// Input 中形如 "$result.output.id" 的字符串取被补偿节点的结果字段（output 为 JSON 字符串时按 JSON 解析）
+type Compensation struct {
+	Tool  string         `json:"tool"`
+	Input map[string]any `json:"input,omitempty"`
 }
 
 // TaskEdge 任务图中的边
 type TaskEdge struct {
 	From string `json:"from"`
 	To   string `json:"to"`
+	// When 条件边（仅 branch 节点的出边）：分支结果等于该标签时生效，"default" 在未命中其它条件边时生效；空为无条件
+	When string `json:"when,omitempty"`
 }
 
 // TaskGraph 任务图：可序列化供 Checkpoint 保存
@@ -65,6 +94,21 @@ type TaskGraph struct {
 	Edges []TaskEdge `json:"edges"`
 }
 
+// TransactionGroups 返回事务组 ID → 成员节点 ID（按 Nodes 顺序）；无事务组时返回 nil
+func (g *TaskGraph) TransactionGroups() map[string][]string {
+	var groups map[string][]string
+	for _, n := range g.Nodes {
+		if n.Transaction == "" {
+			continue
+		}
+		if groups == nil {
+			groups = make(map[string][]string)
+		}
+		groups[n.Transaction] = append(groups[n.Transaction], n.ID)
+	}
+	return groups
+}
+
 // Marshal 序列化为字节（供 Checkpoint.TaskGraphState）
 func (g *TaskGraph) Marshal() ([]byte, error) {
 	return json.Marshal(g)
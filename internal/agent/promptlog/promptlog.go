@@ -0,0 +1,256 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package promptlog LLM prompt 留存：按租户采样或仅失败时留存，脱敏后写入对象存储，llm 事件只记录引用
+package promptlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/metrics"
+	"rag-platform/pkg/pii"
+)
+
+// 留存原因
+const (
+	ReasonSampled = "sampled"
+	ReasonFailure = "failure"
+)
+
+// DefaultPrefix 对象键默认前缀
+const DefaultPrefix = "prompts/"
+
+// Policy 留存策略：失败调用总是留存；成功调用按租户采样率留存，FailureOnly 时不留存
+type Policy struct {
+	SampleRate  float64
+	TenantRates map[string]float64
+	FailureOnly bool
+}
+
+// RateFor 返回租户的成功调用采样率（0–1）
+func (p Policy) RateFor(tenantID string) float64 {
+	if p.FailureOnly {
+		return 0
+	}
+	rate := p.SampleRate
+	if r, ok := p.TenantRates[tenantID]; ok {
+		rate = r
+	}
+	if rate < 0 {
+		return 0
+	}
+	if rate > 1 {
+		return 1
+	}
+	return rate
+}
+
+type namedPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Redactor prompt 脱敏：先按自定义正则替换为 [REDACTED:<规则名>]，再按 PII 类别替换
+type Redactor struct {
+	pii      *pii.Detector
+	patterns []namedPattern
+}
+
+// NewRedactor 创建脱敏器；正则无效时返回错误
+func NewRedactor(cfg config.PromptRedactConfig) (*Redactor, error) {
+	r := &Redactor{pii: pii.NewDetector(cfg.PIICategories)}
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(cfg.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("prompt_log.redact.patterns.%s: %w", name, err)
+		}
+		r.patterns = append(r.patterns, namedPattern{name: name, re: re})
+	}
+	return r, nil
+}
+
+// Redact 返回脱敏后的文本与命中的规则名（去重、有序）
+func (r *Redactor) Redact(s string) (string, []string) {
+	if r == nil || s == "" {
+		return s, nil
+	}
+	var hits []string
+	for _, p := range r.patterns {
+		if p.re.MatchString(s) {
+			s = p.re.ReplaceAllLiteralString(s, "[REDACTED:"+p.name+"]")
+			hits = append(hits, p.name)
+		}
+	}
+	out, cats := r.pii.RedactText(s)
+	for _, c := range cats {
+		hits = append(hits, string(c))
+	}
+	sort.Strings(hits)
+	return out, hits
+}
+
+// Entry 对象存储中的留存内容（prompt 与响应均已脱敏）
+type Entry struct {
+	JobID      string    `json:"job_id"`
+	NodeID     string    `json:"node_id"`
+	TenantID   string    `json:"tenant_id"`
+	Reason     string    `json:"reason"`
+	Model      string    `json:"model,omitempty"`
+	Provider   string    `json:"provider,omitempty"`
+	Prompt     string    `json:"prompt"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Redactions []string  `json:"redactions,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Logger 实现 executor.PromptLogger
+type Logger struct {
+	store    object.Store
+	prefix   string
+	policy   Policy
+	redactor *Redactor
+	sample   func() float64
+	now      func() time.Time
+}
+
+var _ executor.PromptLogger = (*Logger)(nil)
+
+// NewLogger 创建 prompt 留存器；prefix 为空时使用 DefaultPrefix
+func NewLogger(store object.Store, prefix string, policy Policy, redactor *Redactor) *Logger {
+	return &Logger{store: store, prefix: object.KeyPrefix(prefix, DefaultPrefix), policy: policy, redactor: redactor, sample: rand.Float64, now: time.Now}
+}
+
+// NewFromConfig 按配置创建留存器与对象存储；未启用时返回 nil, nil
+func NewFromConfig(ctx context.Context, cfg config.PromptLogConfig) (*Logger, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	redactor, err := NewRedactor(cfg.Redact)
+	if err != nil {
+		return nil, err
+	}
+	store, err := object.NewStore(cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 prompt 留存对象存储failed: %w", err)
+	}
+	l := NewLogger(store, cfg.Prefix, Policy{SampleRate: cfg.SampleRate, TenantRates: cfg.TenantSampleRates, FailureOnly: cfg.FailureOnly}, redactor)
+	if lc, ok := store.(object.LifecycleConfigurer); ok && cfg.RetentionDays > 0 {
+		rule := object.LifecycleRule{ID: "aetheris-prompts", Prefix: l.prefix, ExpirationDays: cfg.RetentionDays}
+		if err := lc.PutLifecycle(ctx, []object.LifecycleRule{rule}); err != nil {
+			return l, fmt.Errorf("设置 prompt 留存生命周期规则failed: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// Store 返回留存使用的对象存储（API 读取留存内容）
+func (l *Logger) Store() object.Store {
+	return l.store
+}
+
+// Log 实现 executor.PromptLogger：按策略决定是否留存，脱敏后写入对象存储
+func (l *Logger) Log(ctx context.Context, rec executor.PromptRecord) (*executor.PromptRef, error) {
+	if l == nil {
+		return nil, nil
+	}
+	reason := ReasonFailure
+	if rec.Error == "" {
+		rate := l.policy.RateFor(rec.TenantID)
+		if rate <= 0 || l.sample() >= rate {
+			return nil, nil
+		}
+		reason = ReasonSampled
+	}
+	prompt, hits := l.redactor.Redact(rec.Prompt)
+	response, respHits := l.redactor.Redact(rec.Response)
+	errMsg, errHits := l.redactor.Redact(rec.Error)
+	hits = mergeSorted(hits, respHits, errHits)
+	now := l.now().UTC()
+	entry := Entry{
+		JobID: rec.JobID, NodeID: rec.NodeID, TenantID: rec.TenantID, Reason: reason,
+		Model: rec.Model, Provider: rec.Provider,
+		Prompt: prompt, Response: response, Error: errMsg, Redactions: hits, CreatedAt: now,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	key := l.prefix + rec.TenantID + "/" + rec.JobID + "/" + rec.NodeID + "-" + strconv.FormatInt(now.UnixNano(), 10) + ".json"
+	meta := map[string]string{"job_id": rec.JobID, "node_id": rec.NodeID, "reason": reason}
+	if err := l.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), meta); err != nil {
+		return nil, err
+	}
+	metrics.LLMPromptsLoggedTotal.WithLabelValues(rec.TenantID, reason).Inc()
+	metrics.LLMPromptLogBytesTotal.WithLabelValues(rec.TenantID).Add(float64(len(data)))
+	sum := sha256.Sum256([]byte(rec.Prompt))
+	return &executor.PromptRef{Key: key, SHA256: hex.EncodeToString(sum[:]), Bytes: len(rec.Prompt), Reason: reason, Redactions: hits}, nil
+}
+
+// Read 读取留存内容；key 须位于 prefix 下，防止经引用读取任意对象
+func Read(ctx context.Context, store object.Store, prefix, key string) (*Entry, error) {
+	prefix = object.KeyPrefix(prefix, DefaultPrefix)
+	if !strings.HasPrefix(key, prefix) || strings.Contains(key, "..") {
+		return nil, fmt.Errorf("invalid prompt ref: %s", key)
+	}
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func mergeSorted(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, l := range lists {
+		for _, s := range l {
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promptlog
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/config"
+)
+
+func TestPolicy_RateFor(t *testing.T) {
+	p := Policy{SampleRate: 0.1, TenantRates: map[string]float64{"vip": 1, "bad": 3}}
+	if p.RateFor("t1") != 0.1 || p.RateFor("vip") != 1 || p.RateFor("bad") != 1 {
+		t.Fatalf("unexpected rates: %v %v %v", p.RateFor("t1"), p.RateFor("vip"), p.RateFor("bad"))
+	}
+	p.FailureOnly = true
+	if p.RateFor("vip") != 0 {
+		t.Fatal("failure_only should disable sampling")
+	}
+}
+
+func TestRedactor_PatternsAndPII(t *testing.T) {
+	r, err := NewRedactor(config.PromptRedactConfig{Patterns: map[string]string{"api_key": `sk-[A-Za-z0-9]{8,}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, hits := r.Redact("use sk-abcdef123456 and mail bob@example.com")
+	if out != "use [REDACTED:api_key] and mail [REDACTED:email]" {
+		t.Fatalf("Redact = %q", out)
+	}
+	if !reflect.DeepEqual(hits, []string{"api_key", "email"}) {
+		t.Fatalf("hits = %v", hits)
+	}
+	if _, err := NewRedactor(config.PromptRedactConfig{Patterns: map[string]string{"bad": "("}}); err == nil {
+		t.Fatal("invalid pattern should be rejected")
+	}
+}
+
+func TestLogger_SamplingFailureAndRead(t *testing.T) {
+	ctx := context.Background()
+	store := object.NewMemoryStore()
+	r, _ := NewRedactor(config.PromptRedactConfig{})
+	l := NewLogger(store, "", Policy{SampleRate: 0.5}, r)
+
+	l.sample = func() float64 { return 0.9 }
+	ref, err := l.Log(ctx, executor.PromptRecord{JobID: "job-1", NodeID: "n1", TenantID: "t1", Prompt: "p", Response: "ok"})
+	if err != nil || ref != nil {
+		t.Fatalf("call outside the sample should not be logged: %+v %v", ref, err)
+	}
+
+	l.sample = func() float64 { return 0.1 }
+	ref, err = l.Log(ctx, executor.PromptRecord{JobID: "job-1", NodeID: "n1", TenantID: "t1", Prompt: "mail bob@example.com", Response: "ok"})
+	if err != nil || ref == nil || ref.Reason != ReasonSampled {
+		t.Fatalf("sampled call should be logged: %+v %v", ref, err)
+	}
+	if !strings.HasPrefix(ref.Key, "prompts/t1/job-1/n1-") || ref.Bytes != len("mail bob@example.com") {
+		t.Fatalf("unexpected ref: %+v", ref)
+	}
+	entry, err := Read(ctx, store, "", ref.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Prompt != "mail [REDACTED:email]" || !reflect.DeepEqual(entry.Redactions, []string{"email"}) {
+		t.Fatalf("stored prompt should be redacted: %+v", entry)
+	}
+
+	l.policy.FailureOnly = true
+	if ref, _ := l.Log(ctx, executor.PromptRecord{JobID: "job-1", NodeID: "n2", TenantID: "t1", Prompt: "p"}); ref != nil {
+		t.Fatal("failure_only should skip successful calls")
+	}
+	ref, _ = l.Log(ctx, executor.PromptRecord{JobID: "job-1", NodeID: "n2", TenantID: "t1", Prompt: "p", Error: "timeout"})
+	if ref == nil || ref.Reason != ReasonFailure {
+		t.Fatalf("failed call should always be logged: %+v", ref)
+	}
+
+	if _, err := Read(ctx, store, "", "evidence/other.json"); err == nil {
+		t.Fatal("keys outside the prefix should be rejected")
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reconcile 周期对账：对每个非终态 Job 交叉校验事件流、工具调用账本与检查点，
+// 在 Replay 之前发现漂移（已提交命令无账本记录、检查点超前于事件、超过租约仍未完成的调用）。
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/runtime"
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+	"rag-platform/pkg/metrics"
+)
+
+// Kind 漂移类型
+type Kind string
+
+const (
+	// KindCommittedWithoutLedger 事件流中已提交的工具命令在账本中无已提交记录
+	KindCommittedWithoutLedger Kind = "committed_without_ledger"
+	// KindCheckpointAhead Job 游标指向的检查点记录了事件流中未完成的节点
+	KindCheckpointAhead Kind = "checkpoint_ahead_of_events"
+	// KindCheckpointMissing Job 游标指向的检查点不存在
+	KindCheckpointMissing Kind = "checkpoint_missing"
+	// KindStalePendingInvocation 账本中 started 状态的调用超过租约时长仍未完成
+	KindStalePendingInvocation Kind = "stale_pending_invocation"
+)
+
+// Kinds 全部漂移类型（用于指标清零与报告计数）
+var Kinds = []Kind{KindCommittedWithoutLedger, KindCheckpointAhead, KindCheckpointMissing, KindStalePendingInvocation}
+
+const (
+	// DefaultInterval 默认对账间隔
+	DefaultInterval = 5 * time.Minute
+	// DefaultLease 默认租约时长：started 调用超过此时长未完成视为漂移
+	DefaultLease = 30 * time.Second
+	// DefaultMaxJobs 单轮最多对账的非终态 Job 数
+	DefaultMaxJobs = 1000
+)
+
+// Finding 单条漂移
+type Finding struct {
+	JobID        string    `json:"job_id"`
+	AgentID      string    `json:"agent_id,omitempty"`
+	Kind         Kind      `json:"kind"`
+	StepID       string    `json:"step_id,omitempty"`
+	InvocationID string    `json:"invocation_id,omitempty"`
+	CheckpointID string    `json:"checkpoint_id,omitempty"`
+	Detail       string    `json:"detail"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// Report 一轮对账结果
+type Report struct {
+	StartedAt   time.Time    `json:"started_at"`
+	FinishedAt  time.Time    `json:"finished_at"`
+	JobsChecked int          `json:"jobs_checked"`
+	Counts      map[Kind]int `json:"counts"`
+	Findings    []Finding    `json:"findings"`
+	Errors      []string     `json:"errors,omitempty"`
+}
+
+// Options 对账参数；零值字段使用默认值
+type Options struct {
+	Lease   time.Duration
+	MaxJobs int
+}
+
+// Reconciler 事件/账本/检查点对账器；ledger、checkpoints 为 nil 时跳过对应校验
+type Reconciler struct {
+	jobs        job.ActiveJobLister
+	events      jobstore.JobStore
+	ledger      agentexec.ToolInvocationStore
+	checkpoints runtime.CheckpointStore
+	opts        Options
+	logger      *log.Logger
+	now         func() time.Time
+
+	mu   sync.RWMutex
+	last *Report
+}
+
+// NewReconciler 创建对账器
+func NewReconciler(jobs job.ActiveJobLister, events jobstore.JobStore, ledger agentexec.ToolInvocationStore, checkpoints runtime.CheckpointStore, opts Options, logger *log.Logger) *Reconciler {
+	if opts.Lease <= 0 {
+		opts.Lease = DefaultLease
+	}
+	if opts.MaxJobs <= 0 {
+		opts.MaxJobs = DefaultMaxJobs
+	}
+	return &Reconciler{jobs: jobs, events: events, ledger: ledger, checkpoints: checkpoints, opts: opts, logger: logger, now: time.Now}
+}
+
+// Last 返回最近一轮对账结果；尚未执行返回 nil
+func (r *Reconciler) Last() *Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.last
+}
+
+// Run 按 interval 周期对账，直到 ctx 取消；启动时先执行一轮
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logf("对账failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 对全部非终态 Job 执行一轮对账并更新指标；单个 Job 读取失败记入 Report.Errors 不中断本轮
+func (r *Reconciler) RunOnce(ctx context.Context) (*Report, error) {
+	report := &Report{StartedAt: r.now(), Counts: make(map[Kind]int), Findings: []Finding{}}
+	jobs, err := r.jobs.ListActive(ctx, r.opts.MaxJobs)
+	if err != nil {
+		metrics.ReconcileRunsTotal.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("list active jobs: %w", err)
+	}
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		findings, err := r.CheckJob(ctx, j)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", j.ID, err))
+			continue
+		}
+		report.JobsChecked++
+		report.Findings = append(report.Findings, findings...)
+	}
+	for _, k := range Kinds {
+		report.Counts[k] = 0
+	}
+	for _, f := range report.Findings {
+		report.Counts[f.Kind]++
+	}
+	for k, n := range report.Counts {
+		metrics.ReconcileDriftFindings.WithLabelValues(string(k)).Set(float64(n))
+	}
+	metrics.ReconcileRunsTotal.WithLabelValues("ok").Inc()
+	report.FinishedAt = r.now()
+	if len(report.Findings) > 0 {
+		r.logf("对账发现漂移", "findings", len(report.Findings), "jobs_checked", report.JobsChecked)
+	}
+	r.mu.Lock()
+	r.last = report
+	r.mu.Unlock()
+	return report, nil
+}
+
+// CheckJob 校验单个 Job 的事件流、账本与检查点
+func (r *Reconciler) CheckJob(ctx context.Context, j *job.Job) ([]Finding, error) {
+	events, _, err := r.events.ListEvents(ctx, j.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	now := r.now()
+	var out []Finding
+	add := func(f Finding) {
+		f.JobID, f.AgentID, f.DetectedAt = j.ID, j.AgentID, now
+		out = append(out, f)
+	}
+
+	finishedNodes := make(map[string]bool)
+	toolCommands := make(map[string]bool)
+	var committedTools []string
+	for _, e := range events {
+		var pl struct {
+			NodeID    string `json:"node_id"`
+			CommandID string `json:"command_id"`
+			Kind      string `json:"kind"`
+		}
+		switch e.Type {
+		case jobstore.NodeFinished:
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.NodeID != "" {
+				finishedNodes[pl.NodeID] = true
+			}
+		case jobstore.CommandEmitted:
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.Kind == "tool" {
+				toolCommands[pl.CommandID] = true
+			}
+		case jobstore.CommandCommitted:
+			if json.Unmarshal(e.Payload, &pl) == nil && toolCommands[pl.CommandID] {
+				committedTools = append(committedTools, pl.CommandID)
+			}
+		}
+	}
+
+	if r.ledger != nil {
+		records, err := r.ledger.ListByJobID(ctx, j.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list ledger: %w", err)
+		}
+		committedSteps := make(map[string]bool, len(records))
+		for _, rec := range records {
+			if rec.Committed {
+				committedSteps[rec.StepID] = true
+			}
+		}
+		for _, stepID := range committedTools {
+			if !committedSteps[stepID] {
+				add(Finding{Kind: KindCommittedWithoutLedger, StepID: stepID, Detail: "command_committed 事件存在但账本无已提交记录"})
+			}
+		}
+		sort.Slice(records, func(a, b int) bool { return records[a].InvocationID < records[b].InvocationID })
+		for _, rec := range records {
+			if rec.Committed || rec.Status != agentexec.ToolInvocationStatusStarted {
+				continue
+			}
+			last := rec.UpdatedAt
+			if last.IsZero() {
+				last = rec.CreatedAt
+			}
+			if last.IsZero() || now.Sub(last) <= r.opts.Lease {
+				continue
+			}
+			add(Finding{
+				Kind:         KindStalePendingInvocation,
+				StepID:       rec.StepID,
+				InvocationID: rec.InvocationID,
+				Detail:       fmt.Sprintf("工具 %s 调用 started 已 %s，超过租约 %s", rec.ToolName, now.Sub(last).Round(time.Second), r.opts.Lease),
+			})
+		}
+	}
+
+	if r.checkpoints != nil && j.Cursor != "" {
+		cp, err := r.checkpoints.Load(ctx, j.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("load checkpoint: %w", err)
+		}
+		switch {
+		case cp == nil:
+			add(Finding{Kind: KindCheckpointMissing, CheckpointID: j.Cursor, Detail: "Job 游标指向的检查点不存在"})
+		case cp.CursorNode != "" && !finishedNodes[cp.CursorNode]:
+			add(Finding{Kind: KindCheckpointAhead, CheckpointID: cp.ID, StepID: cp.CursorNode, Detail: "检查点记录节点已完成，但事件流无对应 node_finished"})
+		}
+	}
+	return out, nil
+}
+
+func (r *Reconciler) logf(msg string, args ...any) {
+	if r.logger != nil {
+		r.logger.Warn(msg, args...)
+	}
+}
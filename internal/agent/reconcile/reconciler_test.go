@@ -0,0 +1,121 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/runtime"
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func appendEvent(t *testing.T, store jobstore.JobStore, jobID string, typ jobstore.EventType, payload map[string]interface{}) {
+	t.Helper()
+	ctx := context.Background()
+	_, ver, err := store.ListEvents(ctx, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(payload)
+	if _, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: b}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconciler_DetectsDrift(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	ledger := agentexec.NewToolInvocationStoreMem()
+	checkpoints := runtime.NewCheckpointStoreMem()
+
+	// 健康 Job：命令已提交且账本有记录，检查点与事件一致
+	healthy, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "ok"})
+	appendEvent(t, events, healthy, jobstore.CommandEmitted, map[string]interface{}{"node_id": "n1", "command_id": "n1", "kind": "tool"})
+	appendEvent(t, events, healthy, jobstore.CommandCommitted, map[string]interface{}{"node_id": "n1", "command_id": "n1"})
+	appendEvent(t, events, healthy, jobstore.NodeFinished, map[string]interface{}{"node_id": "n1"})
+	_ = ledger.SetStarted(ctx, &agentexec.ToolInvocationRecord{InvocationID: "inv-ok", JobID: healthy, StepID: "n1", IdempotencyKey: "k-ok", Status: agentexec.ToolInvocationStatusStarted})
+	_ = ledger.SetFinished(ctx, "k-ok", agentexec.ToolInvocationStatusSuccess, nil, true, "")
+	cpID, _ := checkpoints.Save(ctx, runtime.NewNodeCheckpoint("a1", "", healthy, "n1", nil, nil, nil))
+	_ = jobs.UpdateCursor(ctx, healthy, cpID)
+
+	// 漂移 Job：命令已提交但账本无记录；检查点超前；started 调用超过租约
+	drifted, _ := jobs.Create(ctx, &job.Job{AgentID: "a2", Goal: "bad"})
+	appendEvent(t, events, drifted, jobstore.CommandEmitted, map[string]interface{}{"node_id": "n1", "command_id": "n1", "kind": "tool"})
+	appendEvent(t, events, drifted, jobstore.CommandCommitted, map[string]interface{}{"node_id": "n1", "command_id": "n1"})
+	appendEvent(t, events, drifted, jobstore.CommandEmitted, map[string]interface{}{"node_id": "n2", "command_id": "n2", "kind": "llm"})
+	appendEvent(t, events, drifted, jobstore.CommandCommitted, map[string]interface{}{"node_id": "n2", "command_id": "n2"})
+	_ = ledger.SetStarted(ctx, &agentexec.ToolInvocationRecord{InvocationID: "inv-stale", JobID: drifted, StepID: "n3", ToolName: "http", IdempotencyKey: "k-stale", Status: agentexec.ToolInvocationStatusStarted})
+	cpID, _ = checkpoints.Save(ctx, runtime.NewNodeCheckpoint("a2", "", drifted, "n2", nil, nil, nil))
+	_ = jobs.UpdateCursor(ctx, drifted, cpID)
+
+	// 游标指向不存在的检查点
+	missing, _ := jobs.Create(ctx, &job.Job{AgentID: "a3", Goal: "missing"})
+	_ = jobs.UpdateCursor(ctx, missing, "cp-gone")
+
+	// 终态 Job 不参与对账
+	done, _ := jobs.Create(ctx, &job.Job{AgentID: "a4", Goal: "done"})
+	_ = jobs.UpdateCursor(ctx, done, "cp-gone")
+	_ = jobs.UpdateStatus(ctx, done, job.StatusRunning)
+	_ = jobs.UpdateStatus(ctx, done, job.StatusCompleted)
+
+	r := NewReconciler(jobs, events, ledger, checkpoints, Options{Lease: time.Minute}, nil)
+	r.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	report, err := r.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.JobsChecked != 3 {
+		t.Fatalf("jobs checked = %d, want 3", report.JobsChecked)
+	}
+	want := map[Kind]int{KindCommittedWithoutLedger: 1, KindCheckpointAhead: 1, KindCheckpointMissing: 1, KindStalePendingInvocation: 1}
+	for k, n := range want {
+		if report.Counts[k] != n {
+			t.Fatalf("count[%s] = %d, want %d (findings %+v)", k, report.Counts[k], n, report.Findings)
+		}
+	}
+	for _, f := range report.Findings {
+		if f.JobID == healthy || f.JobID == done {
+			t.Fatalf("unexpected finding for clean job: %+v", f)
+		}
+		if f.Kind == KindStalePendingInvocation && f.InvocationID != "inv-stale" {
+			t.Fatalf("stale finding = %+v", f)
+		}
+	}
+	if r.Last() != report {
+		t.Fatal("Last() should return latest report")
+	}
+}
+
+func TestReconciler_WithinLeaseIsNotDrift(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	ledger := agentexec.NewToolInvocationStoreMem()
+	jobID, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g"})
+	_ = ledger.SetStarted(ctx, &agentexec.ToolInvocationRecord{InvocationID: "inv", JobID: jobID, StepID: "n1", IdempotencyKey: "k", Status: agentexec.ToolInvocationStatusStarted})
+
+	report, err := NewReconciler(jobs, jobstore.NewMemoryStore(), ledger, nil, Options{Lease: time.Minute}, nil).RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Findings) != 0 {
+		t.Fatalf("unexpected findings: %+v", report.Findings)
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"encoding/json"
+	"sync"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// parallelDecodeMinEvents 并行解析的最小事件数；事件较少时 goroutine 调度开销大于收益，始终串行
+const parallelDecodeMinEvents = 256
+
+// decodedEvent 单条事件解析后的载荷；v 为 nil 表示该事件不影响 ReplayContext（类型无关或载荷无效）。
+// 解析（JSON 反序列化，可并行）与应用（写入 ReplayContext，必须按序）分离，BuildFromEvents 与增量构建共用同一套规则
+type decodedEvent struct {
+	typ jobstore.EventType
+	v   interface{}
+}
+
+type toolStartedPayload struct {
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type planGeneratedPayload struct {
+	TaskGraph      json.RawMessage `json:"task_graph"`
+	PlanHash       string          `json:"plan_hash"`
+	ReviewRequired bool            `json:"review_required"`
+}
+
+type nodeFinishedPayload struct {
+	NodeID         string          `json:"node_id"`
+	StepID         string          `json:"step_id"` // 确定性步身份（design/step-identity.md）；有则用其作为 CompletedNodeIDs 的 key，否则用 node_id 向后兼容
+	PayloadResults json.RawMessage `json:"payload_results"`
+	ResultType     string          `json:"result_type"` // Phase A: only success (or empty for old events) advances CompletedNodeIDs
+}
+
+type commandCommittedPayload struct {
+	NodeID    string          `json:"node_id"`
+	CommandID string          `json:"command_id"`
+	Result    json.RawMessage `json:"result"`
+}
+
+type toolFinishedPayload struct {
+	IdempotencyKey string          `json:"idempotency_key"`
+	Outcome        string          `json:"outcome"`
+	Result         json.RawMessage `json:"result"`
+}
+
+type toolProgressPayload struct {
+	IdempotencyKey string          `json:"idempotency_key"`
+	State          json.RawMessage `json:"state"`
+}
+
+type stateChangedPayload struct {
+	NodeID       string              `json:"node_id"`
+	StateChanges []StateChangeRecord `json:"state_changes"`
+}
+
+// workingMemoryPayload job_waiting 中解析出的 working_memory（AgentState JSON）
+type workingMemoryPayload []byte
+
+type waitCompletedPayload struct {
+	NodeID         string          `json:"node_id"`
+	Payload        json.RawMessage `json:"payload"`
+	CorrelationKey string          `json:"correlation_key"`
+}
+
+type timerFiredPayload struct {
+	EffectID string `json:"effect_id"`
+	UnixNano int64  `json:"unix_nano"`
+}
+
+type randomRecordedPayload struct {
+	EffectID string          `json:"effect_id"`
+	Values   json.RawMessage `json:"values"`
+}
+
+type uuidRecordedPayload struct {
+	EffectID string `json:"effect_id"`
+	UUID     string `json:"uuid"`
+}
+
+type httpRecordedPayload struct {
+	EffectID string          `json:"effect_id"`
+	Response json.RawMessage `json:"response"`
+}
+
+// decodeEvent 解析单条事件载荷；不读写共享状态，可并发调用
+func decodeEvent(e jobstore.JobEvent) decodedEvent {
+	d := decodedEvent{typ: e.Type}
+	switch e.Type {
+	case jobstore.ToolInvocationStarted:
+		var pl toolStartedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.IdempotencyKey == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.PlanGenerated:
+		var pl planGeneratedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || len(pl.TaskGraph) == 0 {
+			return d
+		}
+		if pl.PlanHash == "" {
+			pl.PlanHash = jobstore.PlanHash(pl.TaskGraph)
+		}
+		d.v = &pl
+	case jobstore.CheckpointRolledBack:
+		var pl jobstore.CheckpointRolledBackPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.CheckpointID == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.DecisionMade:
+		var pl jobstore.DecisionMadePayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.Kind != jobstore.DecisionKindBranch || pl.NodeID == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.PlanReviewed:
+		var pl jobstore.PlanReviewedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.PlanHash == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.NodeFinished:
+		var pl nodeFinishedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil {
+			return d
+		}
+		// pure / success / side_effect_committed / compensated 均视为节点完成；缺省为 success 以兼容旧事件
+		switch pl.ResultType {
+		case "", "success", "pure", "side_effect_committed", "compensated":
+			d.v = &pl
+		}
+	case jobstore.CommandCommitted:
+		var pl commandCommittedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil {
+			return d
+		}
+		d.v = &pl
+	case jobstore.ToolInvocationFinished:
+		var pl toolFinishedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil {
+			return d
+		}
+		d.v = &pl
+	case jobstore.ToolProgressState:
+		var pl toolProgressPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.IdempotencyKey == "" || len(pl.State) == 0 {
+			return d
+		}
+		d.v = &pl
+	case jobstore.StateChanged:
+		var pl stateChangedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.NodeID == "" || len(pl.StateChanges) == 0 {
+			return d
+		}
+		d.v = &pl
+	case jobstore.JobWaiting:
+		if wm := decodeWorkingMemory(e.Payload); wm != nil {
+			d.v = wm
+		}
+	case jobstore.WaitCompleted:
+		var pl waitCompletedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.NodeID == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.TimerFired:
+		var pl timerFiredPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.RandomRecorded:
+		var pl randomRecordedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.UUIDRecorded:
+		var pl uuidRecordedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
+			return d
+		}
+		d.v = &pl
+	case jobstore.HTTPRecorded:
+		var pl httpRecordedPayload
+		if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
+			return d
+		}
+		d.v = &pl
+	}
+	return d
+}
+
+// decodeWorkingMemory 从 job_waiting 的 resumption_context.memory_snapshot.working_memory 取出 AgentState JSON；无则返回 nil
+func decodeWorkingMemory(payload []byte) workingMemoryPayload {
+	p, err := jobstore.ParseJobWaitingPayload(payload)
+	if err != nil || len(p.ResumptionContext) == 0 {
+		return nil
+	}
+	var resumption map[string]interface{}
+	if json.Unmarshal(p.ResumptionContext, &resumption) != nil {
+		return nil
+	}
+	ms, _ := resumption["memory_snapshot"].(map[string]interface{})
+	if ms == nil {
+		return nil
+	}
+	wm, ok := ms["working_memory"]
+	if !ok || wm == nil {
+		return nil
+	}
+	switch v := wm.(type) {
+	case string:
+		return workingMemoryPayload(v)
+	default:
+		if b, err := json.Marshal(wm); err == nil {
+			return workingMemoryPayload(b)
+		}
+	}
+	return nil
+}
+
+// decodeEvents 解析事件载荷；workers > 1 且事件数不少于 parallelDecodeMinEvents 时分块并行，结果顺序与 events 一致
+func decodeEvents(events []jobstore.JobEvent, workers int) []decodedEvent {
+	out := make([]decodedEvent, len(events))
+	if workers <= 1 || len(events) < parallelDecodeMinEvents {
+		for i := range events {
+			out[i] = decodeEvent(events[i])
+		}
+		return out
+	}
+	chunk := (len(events) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(events); start += chunk {
+		end := start + chunk
+		if end > len(events) {
+			end = len(events)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				out[i] = decodeEvent(events[i])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return out
+}
+
+// applyEvents 按序把事件应用到 rc，并由最后一条事件推导 Phase（plan 3.4）；events 为空时 rc 不变
+func applyEvents(rc *ReplayContext, events []jobstore.JobEvent, workers int) {
+	if len(events) == 0 {
+		return
+	}
+	for _, d := range decodeEvents(events, workers) {
+		applyDecoded(rc, d)
+	}
+	switch events[len(events)-1].Type {
+	case jobstore.JobCompleted:
+		rc.Phase = PhaseCompleted
+	case jobstore.JobFailed:
+		rc.Phase = PhaseFailed
+	case jobstore.JobCancelled:
+		rc.Phase = PhaseCancelled
+	default:
+		if len(rc.TaskGraphState) > 0 {
+			rc.Phase = PhaseExecuting
+		} else {
+			rc.Phase = PhasePlanning
+		}
+	}
+}
+
+// applyDecoded 将单条已解析事件写入 rc
+func applyDecoded(rc *ReplayContext, d decodedEvent) {
+	switch pl := d.v.(type) {
+	case *toolStartedPayload:
+		rc.PendingToolInvocations[pl.IdempotencyKey] = struct{}{}
+	case *planGeneratedPayload:
+		rc.TaskGraphState = []byte(pl.TaskGraph)
+		rc.PlanHash = pl.PlanHash
+		rc.PlanReviewRequired = pl.ReviewRequired
+	case *jobstore.PlanReviewedPayload:
+		if pl.Decision == jobstore.PlanReviewApprove || pl.Decision == jobstore.PlanReviewEdit {
+			rc.PlanApprovedHash = pl.PlanHash
+		}
+	case *jobstore.CheckpointRolledBackPayload:
+		// 回滚作废检查点之后完成的节点：移出完成集合与已提交命令，游标与累积结果回到检查点
+		for _, nodeID := range pl.InvalidatedNodes {
+			delete(rc.CompletedNodeIDs, nodeID)
+			delete(rc.PayloadResultsByNode, nodeID)
+			delete(rc.CompletedCommandIDs, nodeID)
+			delete(rc.CommandResults, nodeID)
+			delete(rc.StateChangesByStep, nodeID)
+		}
+		for _, stepID := range pl.InvalidatedSteps {
+			delete(rc.CompletedNodeIDs, stepID)
+		}
+		rc.CursorNode = pl.CursorNode
+		rc.PayloadResults = []byte(pl.PayloadResults)
+		rc.RollbackCheckpointID = pl.CheckpointID
+	case *jobstore.DecisionMadePayload:
+		// 分支选择按已记录结果注入（同 command_committed），Replay 不重新求值
+		value := pl.Value
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		result, _ := json.Marshal(map[string]any{"outcome": pl.Outcome, "value": value})
+		rc.CompletedCommandIDs[pl.NodeID] = struct{}{}
+		rc.CommandResults[pl.NodeID] = result
+	case *nodeFinishedPayload:
+		completedKey := pl.NodeID
+		if pl.StepID != "" {
+			completedKey = pl.StepID
+		}
+		rc.CompletedNodeIDs[completedKey] = struct{}{}
+		rc.CursorNode = pl.NodeID
+		if len(pl.PayloadResults) > 0 {
+			rc.PayloadResults = []byte(pl.PayloadResults)
+			rc.PayloadResultsByNode[pl.NodeID] = []byte(pl.PayloadResults)
+		}
+	case *commandCommittedPayload:
+		cmdID := pl.CommandID
+		if cmdID == "" {
+			cmdID = pl.NodeID
+		}
+		rc.CompletedCommandIDs[cmdID] = struct{}{}
+		if len(pl.Result) > 0 {
+			rc.CommandResults[cmdID] = []byte(pl.Result)
+		}
+	case *toolFinishedPayload:
+		if pl.IdempotencyKey != "" {
+			delete(rc.PendingToolInvocations, pl.IdempotencyKey)
+			delete(rc.ToolProgressStates, pl.IdempotencyKey)
+		}
+		if pl.Outcome != "success" || pl.IdempotencyKey == "" {
+			return
+		}
+		if len(pl.Result) > 0 {
+			rc.CompletedToolInvocations[pl.IdempotencyKey] = []byte(pl.Result)
+		} else {
+			rc.CompletedToolInvocations[pl.IdempotencyKey] = []byte("{}")
+		}
+	case *toolProgressPayload:
+		rc.ToolProgressStates[pl.IdempotencyKey] = []byte(pl.State)
+	case *stateChangedPayload:
+		rc.StateChangesByStep[pl.NodeID] = append(rc.StateChangesByStep[pl.NodeID], pl.StateChanges...)
+	case workingMemoryPayload:
+		rc.WorkingMemorySnapshot = []byte(pl)
+	case *waitCompletedPayload:
+		rc.CompletedNodeIDs[pl.NodeID] = struct{}{}
+		rc.CursorNode = pl.NodeID
+		rc.CompletedCommandIDs[pl.NodeID] = struct{}{}
+		if pl.CorrelationKey != "" {
+			rc.ApprovedCorrelationKeys[pl.CorrelationKey] = struct{}{}
+		}
+		// Continuation: 恢复时优先从 resumption_context 读取 wait 点的 payload_results（design/agent-process-model.md § Continuation）
+		// 若 signal payload 非空，合并到 command result；resumption_context 在对应 job_waiting 中，需二次查找（Phase 2）
+		if len(pl.Payload) > 0 {
+			rc.CommandResults[pl.NodeID] = []byte(pl.Payload)
+		} else {
+			rc.CommandResults[pl.NodeID] = []byte("{}")
+		}
+	case *timerFiredPayload:
+		rc.RecordedTime[pl.EffectID] = pl.UnixNano
+	case *randomRecordedPayload:
+		rc.RecordedRandom[pl.EffectID] = []byte(pl.Values)
+	case *uuidRecordedPayload:
+		rc.RecordedUUID[pl.EffectID] = pl.UUID
+	case *httpRecordedPayload:
+		rc.RecordedHTTP[pl.EffectID] = []byte(pl.Response)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"sync"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// defaultMaxCachedJobs 增量缓存默认保留的 job 数
+const defaultMaxCachedJobs = 1024
+
+// BuilderOptions ReplayContextBuilder 性能选项；零值等价于 NewReplayContextBuilder（每次全量串行解析）
+type BuilderOptions struct {
+	// Incremental 按 job 缓存已构建的 ReplayContext 与已见 version，后续构建仅解析并应用新增事件；
+	// Runner 在每步之间重建状态，长 Job（上万事件）下每步开销由 O(全部事件) 降为 O(新增事件)
+	Incremental bool
+	// MaxCachedJobs 增量缓存保留的 job 数上限，超出时淘汰最久未使用的；<=0 时默认 1024
+	MaxCachedJobs int
+	// DecodeWorkers 并行解析事件 payload 的 goroutine 数；<=1 时串行。仅在单次解析事件数较多（全量重建）时生效，应用仍按序
+	DecodeWorkers int
+}
+
+// NewReplayContextBuilderWithOptions 创建带增量缓存 / 并行解析的 Builder
+func NewReplayContextBuilderWithOptions(store jobstore.JobStore, opts BuilderOptions) ReplayContextBuilder {
+	b := &replayBuilder{store: store, decodeWorkers: opts.DecodeWorkers}
+	if opts.Incremental {
+		limit := opts.MaxCachedJobs
+		if limit <= 0 {
+			limit = defaultMaxCachedJobs
+		}
+		b.cache = &replayCache{max: limit, entries: make(map[string]*replayCacheEntry)}
+	}
+	return b
+}
+
+// replayCache 按 job 缓存的 ReplayContext；缓存中的 rc 仅由构建器修改，对外返回副本
+type replayCache struct {
+	mu      sync.Mutex
+	max     int
+	tick    uint64
+	entries map[string]*replayCacheEntry
+}
+
+type replayCacheEntry struct {
+	mu       sync.Mutex
+	rc       *ReplayContext
+	version  int
+	lastUsed uint64
+}
+
+// get 返回 job 的缓存项并刷新其使用时间；无则返回 nil
+func (c *replayCache) get(jobID string) *replayCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[jobID]
+	if e != nil {
+		c.tick++
+		e.lastUsed = c.tick
+	}
+	return e
+}
+
+// put 记录 job 在 version 处的 rc；超出上限时淘汰最久未使用的项
+func (c *replayCache) put(jobID string, rc *ReplayContext, version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[jobID]; !ok && len(c.entries) >= c.max {
+		var oldestID string
+		var oldest uint64
+		for id, e := range c.entries {
+			if oldestID == "" || e.lastUsed < oldest {
+				oldestID, oldest = id, e.lastUsed
+			}
+		}
+		delete(c.entries, oldestID)
+	}
+	c.tick++
+	c.entries[jobID] = &replayCacheEntry{rc: rc, version: version, lastUsed: c.tick}
+}
+
+// remove 丢弃 job 的缓存项（终态或事件流回退）
+func (c *replayCache) remove(jobID string, e *replayCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.entries[jobID]; ok && (e == nil || cur == e) {
+		delete(c.entries, jobID)
+	}
+}
+
+// len 当前缓存的 job 数
+func (c *replayCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// buildCached 命中缓存时仅拉取并应用 version 之后的事件；ok 为 false 表示未命中，调用方走全量构建
+func (b *replayBuilder) buildCached(ctx context.Context, jobID string, requireGraph bool) (rc *ReplayContext, ok bool, err error) {
+	e := b.cache.get(jobID)
+	if e == nil {
+		return nil, false, nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	events, version, err := jobstore.EventsSince(ctx, b.store, jobID, e.version)
+	if err != nil {
+		return nil, true, err
+	}
+	if version < e.version {
+		// 事件流比缓存短（store 被重置等），缓存不可信
+		b.cache.remove(jobID, e)
+		return nil, false, nil
+	}
+	applyEvents(e.rc, events, b.decodeWorkers)
+	e.version = version
+	if isTerminalPhase(e.rc.Phase) {
+		b.cache.remove(jobID, e)
+	}
+	if requireGraph && len(e.rc.TaskGraphState) == 0 {
+		return nil, true, nil
+	}
+	return e.rc.clone(), true, nil
+}
+
+// remember 将全量构建得到的 rc 放入缓存（终态 Job 不缓存），返回交给调用方的实例
+func (b *replayBuilder) remember(jobID string, rc *ReplayContext, version int) *ReplayContext {
+	if b.cache == nil || rc == nil {
+		return rc
+	}
+	if isTerminalPhase(rc.Phase) {
+		return rc
+	}
+	b.cache.put(jobID, rc, version)
+	return rc.clone()
+}
+
+func isTerminalPhase(p ExecutionPhase) bool {
+	return p == PhaseCompleted || p == PhaseFailed || p == PhaseCancelled
+}
+
+// clone 深拷贝 ReplayContext 的 map 与切片容器（[]byte 值在构建后不会被原地修改，共享即可）
+func (r *ReplayContext) clone() *ReplayContext {
+	out := *r
+	out.CompletedNodeIDs = cloneSet(r.CompletedNodeIDs)
+	out.PayloadResultsByNode = cloneMap(r.PayloadResultsByNode)
+	out.CompletedCommandIDs = cloneSet(r.CompletedCommandIDs)
+	out.CommandResults = cloneMap(r.CommandResults)
+	out.CompletedToolInvocations = cloneMap(r.CompletedToolInvocations)
+	out.PendingToolInvocations = cloneSet(r.PendingToolInvocations)
+	out.ApprovedCorrelationKeys = cloneSet(r.ApprovedCorrelationKeys)
+	out.RecordedTime = cloneMap(r.RecordedTime)
+	out.RecordedRandom = cloneMap(r.RecordedRandom)
+	out.RecordedUUID = cloneMap(r.RecordedUUID)
+	out.RecordedHTTP = cloneMap(r.RecordedHTTP)
+	out.ToolProgressStates = cloneMap(r.ToolProgressStates)
+	out.StateChangesByStep = make(map[string][]StateChangeRecord, len(r.StateChangesByStep))
+	for k, v := range r.StateChangesByStep {
+		out.StateChangesByStep[k] = append([]StateChangeRecord(nil), v...)
+	}
+	return &out
+}
+
+func cloneSet(m map[string]struct{}) map[string]struct{} {
+	out := make(map[string]struct{}, len(m))
+	for k := range m {
+		out[k] = struct{}{}
+	}
+	return out
+}
+
+func cloneMap[V any](m map[string]V) map[string]V {
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,419 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// appendReplayEvents 向 store 追加 plan_generated 后的 n 个节点（每节点 tool_invocation_started/finished、command_committed、node_finished）
+func appendReplayEvents(t testing.TB, store jobstore.JobStore, jobID string, from, n int) {
+	t.Helper()
+	ctx := context.Background()
+	_, ver, err := store.ListEvents(ctx, jobID)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	appendOne := func(typ jobstore.EventType, payload interface{}) {
+		b, _ := json.Marshal(payload)
+		newVer, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: b})
+		if err != nil {
+			t.Fatalf("Append %s: %v", typ, err)
+		}
+		ver = newVer
+	}
+	if ver == 0 {
+		appendOne(jobstore.PlanGenerated, map[string]interface{}{"task_graph": json.RawMessage(`{"nodes":[{"id":"n0","type":"tool"}],"edges":[]}`)})
+	}
+	for i := from; i < from+n; i++ {
+		node := fmt.Sprintf("n%d", i)
+		key := "idem-" + node
+		result := json.RawMessage(fmt.Sprintf(`{"i":%d,"text":"result of step %d"}`, i, i))
+		appendOne(jobstore.ToolInvocationStarted, map[string]interface{}{"idempotency_key": key, "node_id": node})
+		appendOne(jobstore.ToolInvocationFinished, map[string]interface{}{"idempotency_key": key, "outcome": "success", "result": result})
+		appendOne(jobstore.CommandCommitted, map[string]interface{}{"node_id": node, "command_id": node, "result": result})
+		appendOne(jobstore.NodeFinished, map[string]interface{}{"node_id": node, "payload_results": result, "result_type": "success"})
+	}
+}
+
+func TestIncrementalBuilder_MatchesFullBuild(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-incremental"
+	appendReplayEvents(t, store, jobID, 0, 10)
+
+	inc := NewReplayContextBuilderWithOptions(store, BuilderOptions{Incremental: true})
+	full := NewReplayContextBuilder(store)
+	for step := 0; step < 3; step++ {
+		got, err := inc.BuildFromSnapshot(ctx, jobID)
+		if err != nil {
+			t.Fatalf("incremental build: %v", err)
+		}
+		want, err := full.BuildFromEvents(ctx, jobID)
+		if err != nil {
+			t.Fatalf("full build: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("step %d: incremental context differs from full rebuild", step)
+		}
+		appendReplayEvents(t, store, jobID, 10+step*5, 5)
+	}
+	if n := inc.(*replayBuilder).cache.len(); n != 1 {
+		t.Errorf("cached jobs = %d, want 1", n)
+	}
+}
+
+func TestIncrementalBuilder_ReturnsIndependentCopies(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-copy"
+	appendReplayEvents(t, store, jobID, 0, 2)
+
+	b := NewReplayContextBuilderWithOptions(store, BuilderOptions{Incremental: true})
+	rc, err := b.BuildFromEvents(ctx, jobID)
+	if err != nil || rc == nil {
+		t.Fatalf("BuildFromEvents: %v, %v", rc, err)
+	}
+	rc.CompletedNodeIDs["bogus"] = struct{}{}
+	delete(rc.CommandResults, "n0")
+
+	again, err := b.BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatalf("BuildFromEvents: %v", err)
+	}
+	if _, ok := again.CompletedNodeIDs["bogus"]; ok {
+		t.Error("mutation of a returned context leaked into the cache")
+	}
+	if _, ok := again.CommandResults["n0"]; !ok {
+		t.Error("cached CommandResults lost n0")
+	}
+}
+
+func TestIncrementalBuilder_DropsTerminalJobs(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-terminal"
+	appendReplayEvents(t, store, jobID, 0, 1)
+
+	b := NewReplayContextBuilderWithOptions(store, BuilderOptions{Incremental: true})
+	if _, err := b.BuildFromEvents(ctx, jobID); err != nil {
+		t.Fatalf("BuildFromEvents: %v", err)
+	}
+	_, ver, _ := store.ListEvents(ctx, jobID)
+	if _, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCompleted}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	rc, err := b.BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatalf("BuildFromEvents: %v", err)
+	}
+	if rc.Phase != PhaseCompleted {
+		t.Errorf("Phase = %d, want PhaseCompleted", rc.Phase)
+	}
+	if n := b.(*replayBuilder).cache.len(); n != 0 {
+		t.Errorf("cached jobs = %d, want 0 after job_completed", n)
+	}
+}
+
+func TestIncrementalBuilder_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	for _, id := range []string{"a", "b", "c"} {
+		appendReplayEvents(t, store, id, 0, 1)
+	}
+	b := NewReplayContextBuilderWithOptions(store, BuilderOptions{Incremental: true, MaxCachedJobs: 2}).(*replayBuilder)
+	for _, id := range []string{"a", "b", "a", "c"} {
+		if _, err := b.BuildFromEvents(ctx, id); err != nil {
+			t.Fatalf("BuildFromEvents(%s): %v", id, err)
+		}
+	}
+	if b.cache.get("b") != nil {
+		t.Error("b should have been evicted")
+	}
+	if b.cache.get("a") == nil || b.cache.get("c") == nil {
+		t.Error("a and c should remain cached")
+	}
+}
+
+// snapshotStore 返回固定快照的 JobStore，用于验证快照之后的增量事件被应用
+type snapshotStore struct {
+	jobstore.JobStore
+	snapshot *jobstore.JobSnapshot
+}
+
+func (s *snapshotStore) GetLatestSnapshot(ctx context.Context, jobID string) (*jobstore.JobSnapshot, error) {
+	return s.snapshot, nil
+}
+
+func TestBuildFromSnapshot_AppliesEventsAfterSnapshot(t *testing.T) {
+	ctx := context.Background()
+	mem := jobstore.NewMemoryStore()
+	jobID := "job-snapshot"
+	appendReplayEvents(t, mem, jobID, 0, 2)
+
+	base, err := NewReplayContextBuilder(mem).BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatalf("BuildFromEvents: %v", err)
+	}
+	data, err := SerializeReplayContext(base)
+	if err != nil {
+		t.Fatalf("SerializeReplayContext: %v", err)
+	}
+	_, ver, _ := mem.ListEvents(ctx, jobID)
+	store := &snapshotStore{JobStore: mem, snapshot: &jobstore.JobSnapshot{JobID: jobID, Version: ver, Snapshot: data}}
+	appendReplayEvents(t, mem, jobID, 2, 1)
+
+	rc, err := NewReplayContextBuilder(store).BuildFromSnapshot(ctx, jobID)
+	if err != nil {
+		t.Fatalf("BuildFromSnapshot: %v", err)
+	}
+	if _, ok := rc.CompletedNodeIDs["n2"]; !ok {
+		t.Error("node n2 finished after the snapshot should be completed")
+	}
+	if _, ok := rc.CompletedNodeIDs["n0"]; !ok {
+		t.Error("node n0 from the snapshot should be completed")
+	}
+	if string(rc.CommandResults["n2"]) == "" {
+		t.Error("command result of n2 missing")
+	}
+}
+
+func TestDecodeEvents_ParallelMatchesSerial(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-parallel"
+	appendReplayEvents(t, store, jobID, 0, 200)
+	events, _, _ := store.ListEvents(ctx, jobID)
+
+	serial := newReplayContext()
+	applyEvents(serial, events, 1)
+	parallel := newReplayContext()
+	applyEvents(parallel, events, 8)
+	if !reflect.DeepEqual(serial, parallel) {
+		t.Fatal("parallel decoding produced a different context")
+	}
+	if len(parallel.CompletedNodeIDs) != 200 || len(parallel.PendingToolInvocations) != 0 {
+		t.Errorf("completed=%d pending=%d", len(parallel.CompletedNodeIDs), len(parallel.PendingToolInvocations))
+	}
+}
+
+// benchmarkReplayPerStep 模拟 Runner 每步之间的状态重建：Job 已有约 10k 事件，每次迭代追加一个节点（4 个事件）后重建
+// TestBuildFromEvents_ToolProgressState 未完成调用保留最近一次步内检查点，finished 后清除
+func TestBuildFromEvents_ToolProgressState(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-progress"
+	appendReplayEvents(t, store, jobID, 0, 1)
+	appendOne := func(typ jobstore.EventType, payload interface{}) {
+		_, ver, _ := store.ListEvents(ctx, jobID)
+		b, _ := json.Marshal(payload)
+		if _, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: b}); err != nil {
+			t.Fatalf("Append %s: %v", typ, err)
+		}
+	}
+	appendOne(jobstore.ToolInvocationStarted, map[string]interface{}{"idempotency_key": "idem-n1", "node_id": "n1"})
+	appendOne(jobstore.ToolProgressState, jobstore.ToolProgressStatePayload{NodeID: "n1", IdempotencyKey: "idem-n1", Seq: 1, State: json.RawMessage(`{"page":1}`)})
+	appendOne(jobstore.ToolProgressState, jobstore.ToolProgressStatePayload{NodeID: "n1", IdempotencyKey: "idem-n1", Seq: 2, State: json.RawMessage(`{"page":2}`)})
+
+	builder := NewReplayContextBuilder(store)
+	rc, err := builder.BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatalf("BuildFromEvents: %v", err)
+	}
+	if _, ok := rc.PendingToolInvocations["idem-n1"]; !ok {
+		t.Fatalf("idem-n1 should be pending")
+	}
+	if got := string(rc.ToolProgressStates["idem-n1"]); got != `{"page":2}` {
+		t.Fatalf("progress state = %q", got)
+	}
+
+	appendOne(jobstore.ToolInvocationFinished, map[string]interface{}{"idempotency_key": "idem-n1", "outcome": "success", "result": json.RawMessage(`{}`)})
+	rc, err = builder.BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatalf("BuildFromEvents: %v", err)
+	}
+	if len(rc.ToolProgressStates) != 0 {
+		t.Fatalf("progress states after finish = %v", rc.ToolProgressStates)
+	}
+}
+
+func benchmarkReplayPerStep(b *testing.B, opts BuilderOptions) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-bench"
+	appendReplayEvents(b, store, jobID, 0, 2500)
+	builder := NewReplayContextBuilderWithOptions(store, opts)
+	if _, err := builder.BuildFromSnapshot(ctx, jobID); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		appendReplayEvents(b, store, jobID, 2500+i, 1)
+		b.StartTimer()
+		if _, err := builder.BuildFromSnapshot(ctx, jobID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReplayPerStep10k_Full(b *testing.B) {
+	benchmarkReplayPerStep(b, BuilderOptions{})
+}
+
+func BenchmarkReplayPerStep10k_FullParallelDecode(b *testing.B) {
+	benchmarkReplayPerStep(b, BuilderOptions{DecodeWorkers: 8})
+}
+
+func BenchmarkReplayPerStep10k_Incremental(b *testing.B) {
+	benchmarkReplayPerStep(b, BuilderOptions{Incremental: true})
+}
+
+func TestBuildFromEvents_PlanReviewGate(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-plan-review"
+	graph := json.RawMessage(`{"nodes":[{"id":"n0","type":"tool"}],"edges":[]}`)
+	edited := json.RawMessage(`{"nodes":[{"id":"n1","type":"tool"}],"edges":[]}`)
+	ver := 0
+	appendOne := func(typ jobstore.EventType, payload interface{}) {
+		b, _ := json.Marshal(payload)
+		v, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: b})
+		if err != nil {
+			t.Fatalf("Append %s: %v", typ, err)
+		}
+		ver = v
+	}
+	build := func() *ReplayContext {
+		rc, err := NewReplayContextBuilder(store).BuildFromEvents(ctx, jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rc
+	}
+	appendOne(jobstore.PlanGenerated, map[string]interface{}{"task_graph": graph, "review_required": true})
+	if rc := build(); !rc.PlanReviewPending() || rc.PlanHash != jobstore.PlanHash(graph) {
+		t.Fatalf("fresh plan should await review: %+v", rc)
+	}
+	// 审阅的是旧计划时不放行
+	appendOne(jobstore.PlanReviewed, jobstore.PlanReviewedPayload{Decision: jobstore.PlanReviewApprove, PlanHash: "other"})
+	if !build().PlanReviewPending() {
+		t.Fatal("approval of a different plan hash must not release the gate")
+	}
+	appendOne(jobstore.PlanGenerated, map[string]interface{}{"task_graph": edited, "review_required": true})
+	appendOne(jobstore.PlanReviewed, jobstore.PlanReviewedPayload{Decision: jobstore.PlanReviewEdit, PlanHash: jobstore.PlanHash(edited), OriginalPlanHash: jobstore.PlanHash(graph)})
+	rc := build()
+	if rc.PlanReviewPending() || string(rc.TaskGraphState) != string(edited) {
+		t.Fatalf("edited plan should be approved and current: pending=%v graph=%s", rc.PlanReviewPending(), rc.TaskGraphState)
+	}
+	data, err := SerializeReplayContext(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := deserializeSnapshot(data)
+	if err != nil || restored.PlanApprovedHash != rc.PlanApprovedHash || !restored.PlanReviewRequired {
+		t.Fatalf("snapshot lost plan review state: %+v, %v", restored, err)
+	}
+}
+
+func TestBuildFromEvents_CheckpointRolledBack(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-rollback"
+	ver := 0
+	appendOne := func(typ jobstore.EventType, payload interface{}) {
+		b, _ := json.Marshal(payload)
+		v, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: b})
+		if err != nil {
+			t.Fatalf("Append %s: %v", typ, err)
+		}
+		ver = v
+	}
+	appendOne(jobstore.PlanGenerated, map[string]interface{}{"task_graph": json.RawMessage(`{"nodes":[{"id":"n1","type":"llm"},{"id":"n2","type":"llm"}],"edges":[]}`)})
+	appendOne(jobstore.CommandCommitted, map[string]interface{}{"node_id": "n1", "command_id": "n1", "result": map[string]string{"output": "outline"}})
+	appendOne(jobstore.NodeFinished, map[string]interface{}{"node_id": "n1", "step_id": "s1", "payload_results": map[string]string{"n1": "outline"}})
+	appendOne(jobstore.CommandCommitted, map[string]interface{}{"node_id": "n2", "command_id": "n2", "result": map[string]string{"output": "bad"}})
+	appendOne(jobstore.NodeFinished, map[string]interface{}{"node_id": "n2", "step_id": "s2", "payload_results": map[string]string{"n1": "outline", "n2": "bad"}})
+	appendOne(jobstore.CheckpointRolledBack, jobstore.CheckpointRolledBackPayload{
+		CheckpointID:     "cp-1",
+		CursorNode:       "n1",
+		PayloadResults:   json.RawMessage(`{"n1":"outline"}`),
+		InvalidatedNodes: []string{"n2"},
+		InvalidatedSteps: []string{"s2"},
+	})
+
+	rc, err := NewReplayContextBuilder(store).BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rc.CompletedNodeIDs["s2"]; ok {
+		t.Fatal("invalidated step still completed")
+	}
+	if _, ok := rc.CompletedCommandIDs["n2"]; ok {
+		t.Fatal("invalidated command still committed")
+	}
+	if _, ok := rc.CommandResults["n1"]; !ok {
+		t.Fatal("command before the checkpoint should be kept")
+	}
+	if rc.CursorNode != "n1" || string(rc.PayloadResults) != `{"n1":"outline"}` || rc.RollbackCheckpointID != "cp-1" {
+		t.Fatalf("cursor = %s, results = %s, rollback = %s", rc.CursorNode, rc.PayloadResults, rc.RollbackCheckpointID)
+	}
+	data, err := SerializeReplayContext(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := deserializeSnapshot(data); err != nil || restored.RollbackCheckpointID != "cp-1" {
+		t.Fatalf("snapshot lost rollback checkpoint: %+v, %v", restored, err)
+	}
+}
+
+func TestBuildFromEvents_BranchDecision(t *testing.T) {
+	ctx := context.Background()
+	store := jobstore.NewMemoryStore()
+	jobID := "job-branch"
+	ver := 0
+	appendOne := func(typ jobstore.EventType, payload interface{}) {
+		b, _ := json.Marshal(payload)
+		v, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: b})
+		if err != nil {
+			t.Fatalf("Append %s: %v", typ, err)
+		}
+		ver = v
+	}
+	appendOne(jobstore.PlanGenerated, map[string]interface{}{"task_graph": json.RawMessage(`{"nodes":[{"id":"check","type":"branch","config":{"expr":"true"}}],"edges":[]}`)})
+	appendOne(jobstore.DecisionMade, jobstore.DecisionMadePayload{NodeID: "check", Content: "true => true", Kind: jobstore.DecisionKindBranch, Expr: "true", Outcome: "true", Value: json.RawMessage("true")})
+	// 非分支决策不参与 Replay
+	appendOne(jobstore.DecisionMade, jobstore.DecisionMadePayload{NodeID: "other", Content: "call tool", Kind: "tool_call"})
+
+	rc, err := NewReplayContextBuilder(store).BuildFromEvents(ctx, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(rc.CommandResults["check"]); got != `{"outcome":"true","value":true}` {
+		t.Fatalf("branch result = %s", got)
+	}
+	if _, ok := rc.CompletedCommandIDs["check"]; !ok {
+		t.Fatal("branch decision should be committed")
+	}
+	if _, ok := rc.CommandResults["other"]; ok {
+		t.Fatal("non-branch decision should be ignored")
+	}
+}
@@ -60,6 +60,14 @@ type ReplayContext struct {
 	RecordedUUID map[string]string
 	// RecordedHTTP effect_id -> 记录的 HTTP 响应 body（JSON）；来自 http_recorded 事件
 	RecordedHTTP map[string][]byte
+	// PlanHash 当前计划（最后一条 PlanGenerated）的哈希；PlanReviewRequired 该计划须经审阅；PlanApprovedHash 最近一次审阅通过的计划哈希
+	PlanHash           string
+	PlanReviewRequired bool
+	PlanApprovedHash   string
+	// ToolProgressStates idempotency_key -> 未完成工具调用最近一次 tool_progress_state 的 state JSON；Worker 崩溃后工具从该 state 续跑而非重新开始
+	ToolProgressStates map[string][]byte
+	// RollbackCheckpointID 最近一次 checkpoint_rolled_back 的检查点 ID；Job 游标仍指向它时 Runner 从该检查点重新真实执行被作废的节点
+	RollbackCheckpointID string
 }
 
 // ExecutionPhase 执行阶段（可选显式状态机，plan 3.4）：由事件流推导
@@ -99,6 +107,10 @@ type ReplayContextBuilder interface {
 // replayBuilder 基于 jobstore.JobStore 的 ReplayContext 构建器
 type replayBuilder struct {
 	store jobstore.JobStore
+	// cache 非 nil 时启用增量构建（见 BuilderOptions.Incremental）
+	cache *replayCache
+	// decodeWorkers 并行解析事件 payload 的 goroutine 数；<=1 时串行
+	decodeWorkers int
 }
 
 // NewReplayContextBuilder 创建从事件流构建 ReplayContext 的 Builder
@@ -106,17 +118,9 @@ func NewReplayContextBuilder(store jobstore.JobStore) ReplayContextBuilder {
 	return &replayBuilder{store: store}
 }
 
-// BuildFromEvents 从 job 的事件列表重建执行上下文；事件流为权威来源
-// PlanGenerated 得到 TaskGraph；每条 NodeFinished 加入 CompletedNodeIDs 并更新最后 CursorNode/PayloadResults；按 node 存 PayloadResultsByNode
-func (b *replayBuilder) BuildFromEvents(ctx context.Context, jobID string) (*ReplayContext, error) {
-	if b.store == nil {
-		return nil, nil
-	}
-	events, _, err := b.store.ListEvents(ctx, jobID)
-	if err != nil || len(events) == 0 {
-		return nil, err
-	}
-	out := ReplayContext{
+// newReplayContext 返回各 map 已初始化的空 ReplayContext
+func newReplayContext() *ReplayContext {
+	return &ReplayContext{
 		CompletedNodeIDs:         make(map[string]struct{}),
 		PayloadResultsByNode:     make(map[string][]byte),
 		CompletedCommandIDs:      make(map[string]struct{}),
@@ -129,208 +133,38 @@ func (b *replayBuilder) BuildFromEvents(ctx context.Context, jobID string) (*Rep
 		RecordedRandom:           make(map[string][]byte),
 		RecordedUUID:             make(map[string]string),
 		RecordedHTTP:             make(map[string][]byte),
+		ToolProgressStates:       make(map[string][]byte),
 	}
-	var lastType jobstore.EventType
-	for _, e := range events {
-		lastType = e.Type
-		switch e.Type {
-		case jobstore.ToolInvocationStarted:
-			var pl struct {
-				IdempotencyKey string `json:"idempotency_key"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.IdempotencyKey == "" {
-				continue
-			}
-			out.PendingToolInvocations[pl.IdempotencyKey] = struct{}{}
-		case jobstore.PlanGenerated:
-			var payload struct {
-				TaskGraph json.RawMessage `json:"task_graph"`
-			}
-			if err := json.Unmarshal(e.Payload, &payload); err != nil || len(payload.TaskGraph) == 0 {
-				continue
-			}
-			out.TaskGraphState = []byte(payload.TaskGraph)
-		case jobstore.NodeFinished:
-			var payload struct {
-				NodeID         string          `json:"node_id"`
-				StepID         string          `json:"step_id"` // 确定性步身份（design/step-identity.md）；有则用其作为 CompletedNodeIDs 的 key，否则用 node_id 向后兼容
-				PayloadResults json.RawMessage `json:"payload_results"`
-				ResultType     string          `json:"result_type"` // Phase A: only success (or empty for old events) advances CompletedNodeIDs
-			}
-			if err := json.Unmarshal(e.Payload, &payload); err != nil {
-				continue
-			}
-			// pure / success / side_effect_committed / compensated 均视为节点完成；缺省为 success 以兼容旧事件
-			switch payload.ResultType {
-			case "", "success", "pure", "side_effect_committed", "compensated":
-				// advance
-			default:
-				continue
-			}
-			completedKey := payload.NodeID
-			if payload.StepID != "" {
-				completedKey = payload.StepID
-			}
-			out.CompletedNodeIDs[completedKey] = struct{}{}
-			out.CursorNode = payload.NodeID
-			if len(payload.PayloadResults) > 0 {
-				out.PayloadResults = []byte(payload.PayloadResults)
-				out.PayloadResultsByNode[payload.NodeID] = []byte(payload.PayloadResults)
-			}
-		case jobstore.CommandCommitted:
-			var payload struct {
-				NodeID    string          `json:"node_id"`
-				CommandID string          `json:"command_id"`
-				Result    json.RawMessage `json:"result"`
-			}
-			if err := json.Unmarshal(e.Payload, &payload); err != nil {
-				continue
-			}
-			cmdID := payload.CommandID
-			if cmdID == "" {
-				cmdID = payload.NodeID
-			}
-			out.CompletedCommandIDs[cmdID] = struct{}{}
-			if len(payload.Result) > 0 {
-				out.CommandResults[cmdID] = []byte(payload.Result)
-			}
-		case jobstore.ToolInvocationFinished:
-			var pl struct {
-				IdempotencyKey string          `json:"idempotency_key"`
-				Outcome        string          `json:"outcome"`
-				Result         json.RawMessage `json:"result"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil {
-				continue
-			}
-			if pl.IdempotencyKey != "" {
-				delete(out.PendingToolInvocations, pl.IdempotencyKey)
-			}
-			if pl.Outcome != "success" || pl.IdempotencyKey == "" {
-				continue
-			}
-			if len(pl.Result) > 0 {
-				out.CompletedToolInvocations[pl.IdempotencyKey] = []byte(pl.Result)
-			} else {
-				out.CompletedToolInvocations[pl.IdempotencyKey] = []byte("{}")
-			}
-		case jobstore.StateChanged:
-			var pl struct {
-				NodeID       string              `json:"node_id"`
-				StateChanges []StateChangeRecord `json:"state_changes"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil {
-				continue
-			}
-			if pl.NodeID == "" || len(pl.StateChanges) == 0 {
-				continue
-			}
-			out.StateChangesByStep[pl.NodeID] = append(out.StateChangesByStep[pl.NodeID], pl.StateChanges...)
-		case jobstore.JobWaiting:
-			p, err := jobstore.ParseJobWaitingPayload(e.Payload)
-			if err != nil || len(p.ResumptionContext) == 0 {
-				continue
-			}
-			var resumption map[string]interface{}
-			if json.Unmarshal(p.ResumptionContext, &resumption) != nil {
-				continue
-			}
-			ms, _ := resumption["memory_snapshot"].(map[string]interface{})
-			if ms != nil {
-				if wm, ok := ms["working_memory"]; ok && wm != nil {
-					switch v := wm.(type) {
-					case string:
-						out.WorkingMemorySnapshot = []byte(v)
-					case []byte:
-						out.WorkingMemorySnapshot = v
-					case json.RawMessage:
-						out.WorkingMemorySnapshot = v
-					default:
-						if b, err := json.Marshal(wm); err == nil {
-							out.WorkingMemorySnapshot = b
-						}
-					}
-				}
-			}
-		case jobstore.WaitCompleted:
-			var pl struct {
-				NodeID         string          `json:"node_id"`
-				Payload        json.RawMessage `json:"payload"`
-				CorrelationKey string          `json:"correlation_key"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.NodeID == "" {
-				continue
-			}
-			out.CompletedNodeIDs[pl.NodeID] = struct{}{}
-			out.CursorNode = pl.NodeID
-			out.CompletedCommandIDs[pl.NodeID] = struct{}{}
-			if pl.CorrelationKey != "" {
-				out.ApprovedCorrelationKeys[pl.CorrelationKey] = struct{}{}
-			}
-			// Continuation: 恢复时优先从 resumption_context 读取 wait 点的 payload_results（design/agent-process-model.md § Continuation）
-			// 若 signal payload 非空，合并到 command result；resumption_context 在对应 job_waiting 中，需二次查找（Phase 2）
-			if len(pl.Payload) > 0 {
-				out.CommandResults[pl.NodeID] = []byte(pl.Payload)
-			} else {
-				out.CommandResults[pl.NodeID] = []byte("{}")
-			}
-		case jobstore.TimerFired:
-			var pl struct {
-				EffectID string `json:"effect_id"`
-				UnixNano int64  `json:"unix_nano"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			out.RecordedTime[pl.EffectID] = pl.UnixNano
-		case jobstore.RandomRecorded:
-			var pl struct {
-				EffectID string          `json:"effect_id"`
-				Values   json.RawMessage `json:"values"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			out.RecordedRandom[pl.EffectID] = []byte(pl.Values)
-		case jobstore.UUIDRecorded:
-			var pl struct {
-				EffectID string `json:"effect_id"`
-				UUID     string `json:"uuid"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			out.RecordedUUID[pl.EffectID] = pl.UUID
-		case jobstore.HTTPRecorded:
-			var pl struct {
-				EffectID string          `json:"effect_id"`
-				Response json.RawMessage `json:"response"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			out.RecordedHTTP[pl.EffectID] = []byte(pl.Response)
-		}
+}
+
+// BuildFromEvents 从 job 的事件列表重建执行上下文；事件流为权威来源
+// PlanGenerated 得到 TaskGraph；每条 NodeFinished 加入 CompletedNodeIDs 并更新最后 CursorNode/PayloadResults；按 node 存 PayloadResultsByNode
+// 启用增量构建时，命中缓存仅应用上次构建之后的新事件
+func (b *replayBuilder) BuildFromEvents(ctx context.Context, jobID string) (*ReplayContext, error) {
+	if b.store == nil {
+		return nil, nil
 	}
-	// 推导 Phase（plan 3.4）
-	switch lastType {
-	case jobstore.JobCompleted:
-		out.Phase = PhaseCompleted
-	case jobstore.JobFailed:
-		out.Phase = PhaseFailed
-	case jobstore.JobCancelled:
-		out.Phase = PhaseCancelled
-	default:
-		if len(out.TaskGraphState) > 0 {
-			out.Phase = PhaseExecuting
-		} else {
-			out.Phase = PhasePlanning
+	if b.cache != nil {
+		if rc, ok, err := b.buildCached(ctx, jobID, true); ok {
+			return rc, err
 		}
 	}
+	events, version, err := b.store.ListEvents(ctx, jobID)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	out := newReplayContext()
+	applyEvents(out, events, b.decodeWorkers)
+	out = b.remember(jobID, out, version)
 	if len(out.TaskGraphState) == 0 {
 		return nil, nil
 	}
-	return &out, nil
+	return out, nil
+}
+
+// PlanReviewPending 当前计划须经审阅且尚未通过；Runner 据此在开始执行前挂起
+func (r *ReplayContext) PlanReviewPending() bool {
+	return r != nil && r.PlanReviewRequired && r.PlanApprovedHash != r.PlanHash
 }
 
 // TaskGraph 反序列化 ReplayContext 中的 TaskGraph
@@ -346,10 +180,16 @@ func (r *ReplayContext) TaskGraph() (*planner.TaskGraph, error) {
 }
 
 // BuildFromSnapshot 从快照开始构建 ReplayContext，然后叠加快照之后的增量事件（2.0 性能优化）
+// 启用增量构建且命中缓存时直接在缓存上叠加新事件，不再读取快照
 func (b *replayBuilder) BuildFromSnapshot(ctx context.Context, jobID string) (*ReplayContext, error) {
 	if b.store == nil {
 		return nil, nil
 	}
+	if b.cache != nil {
+		if rc, ok, err := b.buildCached(ctx, jobID, false); ok {
+			return rc, err
+		}
+	}
 
 	// 尝试获取最新快照
 	snapshot, err := b.store.GetLatestSnapshot(ctx, jobID)
@@ -369,33 +209,17 @@ func (b *replayBuilder) BuildFromSnapshot(ctx context.Context, jobID string) (*R
 		return b.BuildFromEvents(ctx, jobID)
 	}
 
-	// 获取快照之后的增量事件
-	events, _, err := b.store.ListEvents(ctx, jobID)
+	// 快照覆盖到 version N，只处理 version > N 的增量事件
+	events, version, err := jobstore.EventsSince(ctx, b.store, jobID, snapshot.Version)
 	if err != nil {
 		return nil, err
 	}
-
-	// 只处理快照版本之后的事件
-	incrementalEvents := []jobstore.JobEvent{}
-	for _, e := range events {
-		// 事件按 version 排序，version 从 1 开始
-		// 快照覆盖到 version N，则处理 version > N 的事件
-		// 这里简化处理：使用事件数量作为版本号判断
-		if len(incrementalEvents) >= snapshot.Version {
-			incrementalEvents = append(incrementalEvents, e)
-		} else if len(events) > snapshot.Version {
-			// 如果总事件数大于快照版本，说明有增量
-			continue
-		}
-	}
-
-	// 如果没有增量事件，直接返回快照
-	if len(incrementalEvents) == 0 {
-		return rc, nil
+	if version < snapshot.Version {
+		// 快照领先于事件流，不可信，降级到全事件重放
+		return b.BuildFromEvents(ctx, jobID)
 	}
-
-	// 应用增量事件到快照状态
-	return b.applyIncrementalEvents(rc, incrementalEvents), nil
+	applyEvents(rc, events, b.decodeWorkers)
+	return b.remember(jobID, rc, version), nil
 }
 
 // deserializeSnapshot 反序列化快照数据为 ReplayContext
@@ -427,6 +251,17 @@ func deserializeSnapshot(snapshotData []byte) (*ReplayContext, error) {
 		RecordedRandom:           make(map[string][]byte),
 		RecordedUUID:             payload.RecordedUUID,
 		RecordedHTTP:             make(map[string][]byte),
+		ToolProgressStates:       make(map[string][]byte),
+		PlanHash:                 payload.PlanHash,
+		PlanReviewRequired:       payload.PlanReviewRequired,
+		PlanApprovedHash:         payload.PlanApprovedHash,
+		RollbackCheckpointID:     payload.RollbackCheckpointID,
+	}
+	if rc.RecordedTime == nil {
+		rc.RecordedTime = make(map[string]int64)
+	}
+	if rc.RecordedUUID == nil {
+		rc.RecordedUUID = make(map[string]string)
 	}
 
 	// 转换 slice 为 map
@@ -459,6 +294,9 @@ func deserializeSnapshot(snapshotData []byte) (*ReplayContext, error) {
 	for k, v := range payload.RecordedHTTP {
 		rc.RecordedHTTP[k] = []byte(v)
 	}
+	for k, v := range payload.ToolProgressStates {
+		rc.ToolProgressStates[k] = []byte(v)
+	}
 
 	// 反序列化 StateChangesByStep
 	for nodeID, changes := range payload.StateChangesByStep {
@@ -471,225 +309,6 @@ func deserializeSnapshot(snapshotData []byte) (*ReplayContext, error) {
 	return rc, nil
 }
 
-// applyIncrementalEvents 将增量事件应用到已有的 ReplayContext
-func (b *replayBuilder) applyIncrementalEvents(rc *ReplayContext, events []jobstore.JobEvent) *ReplayContext {
-	if rc == nil {
-		rc = &ReplayContext{
-			CompletedNodeIDs:         make(map[string]struct{}),
-			PayloadResultsByNode:     make(map[string][]byte),
-			CompletedCommandIDs:      make(map[string]struct{}),
-			CommandResults:           make(map[string][]byte),
-			CompletedToolInvocations: make(map[string][]byte),
-			PendingToolInvocations:   make(map[string]struct{}),
-			StateChangesByStep:       make(map[string][]StateChangeRecord),
-			ApprovedCorrelationKeys:  make(map[string]struct{}),
-			RecordedTime:             make(map[string]int64),
-			RecordedRandom:           make(map[string][]byte),
-			RecordedUUID:             make(map[string]string),
-			RecordedHTTP:             make(map[string][]byte),
-		}
-	}
-
-	// 使用与 BuildFromEvents 相同的逻辑处理增量事件
-	var lastType jobstore.EventType
-	for _, e := range events {
-		lastType = e.Type
-		switch e.Type {
-		case jobstore.ToolInvocationStarted:
-			var pl struct {
-				IdempotencyKey string `json:"idempotency_key"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.IdempotencyKey == "" {
-				continue
-			}
-			rc.PendingToolInvocations[pl.IdempotencyKey] = struct{}{}
-		case jobstore.PlanGenerated:
-			var payload struct {
-				TaskGraph json.RawMessage `json:"task_graph"`
-			}
-			if err := json.Unmarshal(e.Payload, &payload); err != nil || len(payload.TaskGraph) == 0 {
-				continue
-			}
-			rc.TaskGraphState = []byte(payload.TaskGraph)
-		case jobstore.NodeFinished:
-			var payload struct {
-				NodeID         string          `json:"node_id"`
-				StepID         string          `json:"step_id"`
-				PayloadResults json.RawMessage `json:"payload_results"`
-				ResultType     string          `json:"result_type"`
-			}
-			if err := json.Unmarshal(e.Payload, &payload); err != nil {
-				continue
-			}
-			switch payload.ResultType {
-			case "", "success", "pure", "side_effect_committed", "compensated":
-				// advance
-			default:
-				continue
-			}
-			completedKey := payload.NodeID
-			if payload.StepID != "" {
-				completedKey = payload.StepID
-			}
-			rc.CompletedNodeIDs[completedKey] = struct{}{}
-			rc.CursorNode = payload.NodeID
-			if len(payload.PayloadResults) > 0 {
-				rc.PayloadResults = []byte(payload.PayloadResults)
-				rc.PayloadResultsByNode[payload.NodeID] = []byte(payload.PayloadResults)
-			}
-		case jobstore.CommandCommitted:
-			var payload struct {
-				NodeID    string          `json:"node_id"`
-				CommandID string          `json:"command_id"`
-				Result    json.RawMessage `json:"result"`
-			}
-			if err := json.Unmarshal(e.Payload, &payload); err != nil {
-				continue
-			}
-			cmdID := payload.CommandID
-			if cmdID == "" {
-				cmdID = payload.NodeID
-			}
-			rc.CompletedCommandIDs[cmdID] = struct{}{}
-			if len(payload.Result) > 0 {
-				rc.CommandResults[cmdID] = []byte(payload.Result)
-			}
-		case jobstore.ToolInvocationFinished:
-			var pl struct {
-				IdempotencyKey string          `json:"idempotency_key"`
-				Outcome        string          `json:"outcome"`
-				Result         json.RawMessage `json:"result"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil {
-				continue
-			}
-			if pl.IdempotencyKey != "" {
-				delete(rc.PendingToolInvocations, pl.IdempotencyKey)
-			}
-			if pl.Outcome != "success" || pl.IdempotencyKey == "" {
-				continue
-			}
-			if len(pl.Result) > 0 {
-				rc.CompletedToolInvocations[pl.IdempotencyKey] = []byte(pl.Result)
-			} else {
-				rc.CompletedToolInvocations[pl.IdempotencyKey] = []byte("{}")
-			}
-		case jobstore.StateChanged:
-			var pl struct {
-				NodeID       string              `json:"node_id"`
-				StateChanges []StateChangeRecord `json:"state_changes"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil {
-				continue
-			}
-			if pl.NodeID == "" || len(pl.StateChanges) == 0 {
-				continue
-			}
-			rc.StateChangesByStep[pl.NodeID] = append(rc.StateChangesByStep[pl.NodeID], pl.StateChanges...)
-		case jobstore.JobWaiting:
-			p, err := jobstore.ParseJobWaitingPayload(e.Payload)
-			if err != nil || len(p.ResumptionContext) == 0 {
-				continue
-			}
-			var resumption map[string]interface{}
-			if json.Unmarshal(p.ResumptionContext, &resumption) != nil {
-				continue
-			}
-			ms, _ := resumption["memory_snapshot"].(map[string]interface{})
-			if ms != nil {
-				if wm, ok := ms["working_memory"]; ok && wm != nil {
-					switch v := wm.(type) {
-					case string:
-						rc.WorkingMemorySnapshot = []byte(v)
-					case []byte:
-						rc.WorkingMemorySnapshot = v
-					case json.RawMessage:
-						rc.WorkingMemorySnapshot = v
-					default:
-						if b, err := json.Marshal(wm); err == nil {
-							rc.WorkingMemorySnapshot = b
-						}
-					}
-				}
-			}
-		case jobstore.WaitCompleted:
-			var pl struct {
-				NodeID         string          `json:"node_id"`
-				Payload        json.RawMessage `json:"payload"`
-				CorrelationKey string          `json:"correlation_key"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.NodeID == "" {
-				continue
-			}
-			rc.CompletedNodeIDs[pl.NodeID] = struct{}{}
-			rc.CursorNode = pl.NodeID
-			rc.CompletedCommandIDs[pl.NodeID] = struct{}{}
-			if pl.CorrelationKey != "" {
-				rc.ApprovedCorrelationKeys[pl.CorrelationKey] = struct{}{}
-			}
-			if len(pl.Payload) > 0 {
-				rc.CommandResults[pl.NodeID] = []byte(pl.Payload)
-			} else {
-				rc.CommandResults[pl.NodeID] = []byte("{}")
-			}
-		case jobstore.TimerFired:
-			var pl struct {
-				EffectID string `json:"effect_id"`
-				UnixNano int64  `json:"unix_nano"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			rc.RecordedTime[pl.EffectID] = pl.UnixNano
-		case jobstore.RandomRecorded:
-			var pl struct {
-				EffectID string          `json:"effect_id"`
-				Values   json.RawMessage `json:"values"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			rc.RecordedRandom[pl.EffectID] = []byte(pl.Values)
-		case jobstore.UUIDRecorded:
-			var pl struct {
-				EffectID string `json:"effect_id"`
-				UUID     string `json:"uuid"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			rc.RecordedUUID[pl.EffectID] = pl.UUID
-		case jobstore.HTTPRecorded:
-			var pl struct {
-				EffectID string          `json:"effect_id"`
-				Response json.RawMessage `json:"response"`
-			}
-			if err := json.Unmarshal(e.Payload, &pl); err != nil || pl.EffectID == "" {
-				continue
-			}
-			rc.RecordedHTTP[pl.EffectID] = []byte(pl.Response)
-		}
-	}
-
-	// 更新 Phase
-	switch lastType {
-	case jobstore.JobCompleted:
-		rc.Phase = PhaseCompleted
-	case jobstore.JobFailed:
-		rc.Phase = PhaseFailed
-	case jobstore.JobCancelled:
-		rc.Phase = PhaseCancelled
-	default:
-		if len(rc.TaskGraphState) > 0 {
-			rc.Phase = PhaseExecuting
-		} else {
-			rc.Phase = PhasePlanning
-		}
-	}
-
-	return rc
-}
-
 // SerializeReplayContext 将 ReplayContext 序列化为快照数据
 func SerializeReplayContext(rc *ReplayContext) ([]byte, error) {
 	if rc == nil {
@@ -714,6 +333,11 @@ func SerializeReplayContext(rc *ReplayContext) ([]byte, error) {
 		RecordedRandom:           make(map[string]json.RawMessage),
 		RecordedUUID:             rc.RecordedUUID,
 		RecordedHTTP:             make(map[string]json.RawMessage),
+		ToolProgressStates:       make(map[string]json.RawMessage),
+		PlanHash:                 rc.PlanHash,
+		PlanReviewRequired:       rc.PlanReviewRequired,
+		PlanApprovedHash:         rc.PlanApprovedHash,
+		RollbackCheckpointID:     rc.RollbackCheckpointID,
 	}
 
 	// 转换 map 为 slice
@@ -746,6 +370,9 @@ func SerializeReplayContext(rc *ReplayContext) ([]byte, error) {
 	for k, v := range rc.RecordedHTTP {
 		payload.RecordedHTTP[k] = json.RawMessage(v)
 	}
+	for k, v := range rc.ToolProgressStates {
+		payload.ToolProgressStates[k] = json.RawMessage(v)
+	}
 
 	// 序列化 StateChangesByStep
 	for nodeID, changes := range rc.StateChangesByStep {
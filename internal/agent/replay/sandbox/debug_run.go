@@ -0,0 +1,428 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	agenteffects "rag-platform/internal/agent/runtime/effects"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// ErrStepNotRecorded 事件流中没有该节点的 state_checkpointed 记录（节点未完成或 Job 早于 state 记录）
+var ErrStepNotRecorded = errors.New("sandbox: 该节点没有录制的 state_before")
+
+// ErrToolNotSandboxed 工具未声明沙箱安全，且修改后的入参没有匹配的录制结果
+var ErrToolNotSandboxed = errors.New("sandbox: 工具未声明沙箱安全且无匹配的录制结果")
+
+// 调试运行结果来源
+const (
+	DebugSourceRecorded = "recorded" // 入参与录制一致，直接返回录制结果，未执行
+	DebugSourceSandbox  = "sandbox"  // 在沙箱中真实执行（工具声明无副作用，HTTP 仅用录制）
+	DebugSourceLLM      = "llm"      // 修改后的 prompt 重新调用 LLM（纯生成，无副作用）
+)
+
+// maxLineDiffLines 行级 diff 的最大行数，超过时只给出 key 级 diff
+const maxLineDiffLines = 2000
+
+// RecordedToolCall 录制的一次工具调用（tool_called + tool_returned）
+type RecordedToolCall struct {
+	NodeID   string         `json:"node_id"`
+	ToolName string         `json:"tool_name"`
+	Input    map[string]any `json:"input,omitempty"`
+	Output   string         `json:"output,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Done     bool           `json:"done"`
+}
+
+// DebugStep 从事件流加载的单步录制：执行前后状态、节点类型与工具调用，供 time-travel debug-run 使用
+type DebugStep struct {
+	JobID       string
+	NodeID      string
+	NodeType    string
+	ToolName    string
+	ToolInput   map[string]any
+	StateBefore json.RawMessage
+	StateAfter  json.RawMessage
+	// ToolCalls 整个 Job 录制的工具调用，沙箱中入参相同的调用直接返回录制结果
+	ToolCalls []RecordedToolCall
+	// Recorded 录制的 HTTP/time/uuid 效应，沙箱中仅从此注入
+	Recorded *replay.ReplayContext
+}
+
+// OriginalOutput 该节点录制的原始结果（state_after[node_id]），去掉 _evidence 等内部字段
+func (s *DebugStep) OriginalOutput() json.RawMessage {
+	var after map[string]json.RawMessage
+	if len(s.StateAfter) == 0 || json.Unmarshal(s.StateAfter, &after) != nil {
+		return nil
+	}
+	return stripInternalKeys(after[s.NodeID])
+}
+
+// LoadDebugStep 从事件流加载 nodeID 的录制状态；节点多次 checkpoint 时取最后一次
+func LoadDebugStep(jobID, nodeID string, events []jobstore.JobEvent) (*DebugStep, error) {
+	step := &DebugStep{
+		JobID:    jobID,
+		NodeID:   nodeID,
+		Recorded: &replay.ReplayContext{RecordedHTTP: make(map[string][]byte), RecordedTime: make(map[string]int64), RecordedUUID: make(map[string]string)},
+	}
+	found := false
+	pending := make(map[string]int) // node_id -> 尚未配对 tool_returned 的 ToolCalls 下标
+	for _, e := range events {
+		var pl map[string]json.RawMessage
+		if len(e.Payload) > 0 {
+			_ = json.Unmarshal(e.Payload, &pl)
+		}
+		var evNodeID string
+		_ = json.Unmarshal(pl["node_id"], &evNodeID)
+		switch e.Type {
+		case jobstore.StateCheckpointed:
+			if evNodeID == nodeID {
+				step.StateBefore = pl["state_before"]
+				step.StateAfter = pl["state_after"]
+				found = true
+			}
+		case jobstore.ReasoningSnapshot:
+			if evNodeID == nodeID {
+				var nodeType string
+				_ = json.Unmarshal(pl["node_type"], &nodeType)
+				if nodeType != "" {
+					step.NodeType = nodeType
+				}
+			}
+		case jobstore.ToolCalled:
+			call := RecordedToolCall{NodeID: evNodeID}
+			_ = json.Unmarshal(pl["tool_name"], &call.ToolName)
+			_ = json.Unmarshal(pl["input"], &call.Input)
+			step.ToolCalls = append(step.ToolCalls, call)
+			pending[evNodeID] = len(step.ToolCalls) - 1
+			if evNodeID == nodeID {
+				step.ToolName = call.ToolName
+				step.ToolInput = call.Input
+			}
+		case jobstore.ToolReturned:
+			idx, ok := pending[evNodeID]
+			if !ok {
+				continue
+			}
+			delete(pending, evNodeID)
+			var out struct {
+				Output string `json:"output"`
+				Error  string `json:"error"`
+				Done   bool   `json:"done"`
+			}
+			_ = json.Unmarshal(pl["output"], &out)
+			step.ToolCalls[idx].Output, step.ToolCalls[idx].Error, step.ToolCalls[idx].Done = out.Output, out.Error, out.Done
+		case jobstore.HTTPRecorded, jobstore.TimerFired, jobstore.UUIDRecorded:
+			var effectID string
+			_ = json.Unmarshal(pl["effect_id"], &effectID)
+			if effectID == "" {
+				continue
+			}
+			switch e.Type {
+			case jobstore.HTTPRecorded:
+				step.Recorded.RecordedHTTP[effectID] = []byte(pl["response"])
+			case jobstore.TimerFired:
+				var unixNano int64
+				if json.Unmarshal(pl["unix_nano"], &unixNano) == nil {
+					step.Recorded.RecordedTime[effectID] = unixNano
+				}
+			case jobstore.UUIDRecorded:
+				var id string
+				if json.Unmarshal(pl["uuid"], &id) == nil {
+					step.Recorded.RecordedUUID[effectID] = id
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, ErrStepNotRecorded
+	}
+	if step.NodeType == "" && step.ToolName != "" {
+		step.NodeType = planner.NodeTool
+	}
+	return step, nil
+}
+
+// DebugRunRequest 用户提交的修改：工具节点可替换工具名与入参，LLM 节点可替换 prompt
+type DebugRunRequest struct {
+	ToolName string         `json:"tool_name,omitempty"`
+	Config   map[string]any `json:"config,omitempty"`
+	Prompt   string         `json:"prompt,omitempty"`
+}
+
+// ToolOutcome 沙箱内工具调用结果，字段与 tool_returned 的 output/error/done 对齐
+type ToolOutcome struct {
+	Output string
+	Err    string
+	Done   bool
+	State  interface{}
+}
+
+// OutputDiff 原始结果与假设结果的差异：顶层 key 级 diff + 格式化 JSON 的行级 diff
+type OutputDiff struct {
+	Changed     bool     `json:"changed"`
+	AddedKeys   []string `json:"added_keys,omitempty"`
+	RemovedKeys []string `json:"removed_keys,omitempty"`
+	ChangedKeys []string `json:"changed_keys,omitempty"`
+	// Lines 行级 diff，前缀 "  " 未变、"- " 原始、"+ " 假设；超过 maxLineDiffLines 时为空
+	Lines []string `json:"lines,omitempty"`
+}
+
+// DebugRunResult 一次 debug-run 的假设结果
+type DebugRunResult struct {
+	NodeID       string          `json:"node_id"`
+	NodeType     string          `json:"node_type"`
+	ToolName     string          `json:"tool_name,omitempty"`
+	Source       string          `json:"source"`
+	Original     json.RawMessage `json:"original_output,omitempty"`
+	Hypothetical json.RawMessage `json:"hypothetical_output,omitempty"`
+	Diff         OutputDiff      `json:"diff"`
+	DurationMs   int64           `json:"duration_ms"`
+}
+
+// DebugRunner 在沙箱中执行修改后的单步：forbidden副作用，HTTP 只使用录制结果，不写任何事件
+type DebugRunner struct {
+	// LLM 生成文本；nil 时 LLM 节点不可调试
+	LLM func(ctx context.Context, prompt string) (string, error)
+	// Tool 执行工具；仅对 SandboxSafe 返回 true 的工具调用
+	Tool func(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolOutcome, error)
+	// SandboxSafe 工具是否声明可在沙箱中真实执行
+	SandboxSafe func(toolName string) bool
+	// Timeout 单次 debug-run 超时；0 表示不额外限制
+	Timeout time.Duration
+}
+
+// Run 以 step.StateBefore 为输入执行修改后的节点，返回假设结果及与原始结果的 diff
+func (d *DebugRunner) Run(ctx context.Context, step *DebugStep, req DebugRunRequest) (*DebugRunResult, error) {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+	ctx = agenteffects.WithSandboxEffects(ctx, step.JobID, step.NodeID, step.Recorded)
+	res := &DebugRunResult{NodeID: step.NodeID, NodeType: step.NodeType, Original: step.OriginalOutput()}
+	start := time.Now()
+	var hypothetical map[string]any
+	switch step.NodeType {
+	case planner.NodeLLM:
+		if req.Prompt == "" {
+			return nil, fmt.Errorf("sandbox: llm 节点需要 prompt")
+		}
+		if d.LLM == nil {
+			return nil, fmt.Errorf("sandbox: LLM 未配置")
+		}
+		prompt := strings.ReplaceAll(req.Prompt, "{{state_before}}", string(step.StateBefore))
+		out, err := d.LLM(ctx, prompt)
+		if err != nil {
+			hypothetical = map[string]any{"error": err.Error()}
+		} else {
+			hypothetical = map[string]any{"output": out}
+		}
+		res.Source = DebugSourceLLM
+	case planner.NodeTool:
+		toolName := req.ToolName
+		if toolName == "" {
+			toolName = step.ToolName
+		}
+		input := req.Config
+		if input == nil {
+			input = step.ToolInput
+		}
+		res.ToolName = toolName
+		outcome, source, err := d.runTool(ctx, step, toolName, input)
+		if err != nil {
+			return nil, err
+		}
+		res.Source = source
+		hypothetical = map[string]any{"done": outcome.Done, "state": outcome.State, "output": outcome.Output, "error": outcome.Err}
+	default:
+		return nil, fmt.Errorf("sandbox: 不支持调试的节点类型 %q", step.NodeType)
+	}
+	res.DurationMs = time.Since(start).Milliseconds()
+	res.Hypothetical, _ = json.Marshal(hypothetical)
+	res.Diff = DiffOutputs(res.Original, res.Hypothetical)
+	return res, nil
+}
+
+// runTool 入参与录制一致时直接返回录制结果；否则仅执行声明沙箱安全的工具
+func (d *DebugRunner) runTool(ctx context.Context, step *DebugStep, toolName string, input map[string]any) (ToolOutcome, string, error) {
+	for _, call := range step.ToolCalls {
+		if call.ToolName == toolName && reflect.DeepEqual(normalizeJSON(call.Input), normalizeJSON(input)) {
+			return ToolOutcome{Output: call.Output, Err: call.Error, Done: call.Done}, DebugSourceRecorded, nil
+		}
+	}
+	if d.Tool == nil || d.SandboxSafe == nil || !d.SandboxSafe(toolName) {
+		return ToolOutcome{}, "", fmt.Errorf("%w: %s", ErrToolNotSandboxed, toolName)
+	}
+	// 与执行器一致：再入状态取自 state_before[node_id].state
+	var state interface{}
+	var before map[string]map[string]any
+	if len(step.StateBefore) > 0 && json.Unmarshal(step.StateBefore, &before) == nil {
+		state = before[step.NodeID]["state"]
+	}
+	outcome, err := d.Tool(ctx, toolName, input, state)
+	if err != nil && outcome.Err == "" {
+		outcome.Err = err.Error()
+	}
+	return outcome, DebugSourceSandbox, nil
+}
+
+// DiffOutputs 比较原始与假设结果（JSON）；对象给出顶层 key 变化，并附格式化 JSON 的行级 diff
+func DiffOutputs(original, hypothetical json.RawMessage) OutputDiff {
+	var a, b interface{}
+	_ = json.Unmarshal(original, &a)
+	_ = json.Unmarshal(hypothetical, &b)
+	a, b = dropEmpty(a), dropEmpty(b)
+	diff := OutputDiff{Changed: !reflect.DeepEqual(a, b)}
+	if !diff.Changed {
+		return diff
+	}
+	if am, ok := a.(map[string]interface{}); ok {
+		if bm, ok := b.(map[string]interface{}); ok {
+			for k, av := range am {
+				bv, exists := bm[k]
+				if !exists {
+					diff.RemovedKeys = append(diff.RemovedKeys, k)
+				} else if !reflect.DeepEqual(av, bv) {
+					diff.ChangedKeys = append(diff.ChangedKeys, k)
+				}
+			}
+			for k := range bm {
+				if _, exists := am[k]; !exists {
+					diff.AddedKeys = append(diff.AddedKeys, k)
+				}
+			}
+			sort.Strings(diff.AddedKeys)
+			sort.Strings(diff.RemovedKeys)
+			sort.Strings(diff.ChangedKeys)
+		}
+	}
+	diff.Lines = lineDiff(prettyLines(a), prettyLines(b))
+	return diff
+}
+
+// dropEmpty 去掉对象中的 null/空串/false 字段，避免 {"error": ""} 与缺省字段被判为差异
+func dropEmpty(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		if val == nil || val == "" || val == false {
+			continue
+		}
+		out[k] = val
+	}
+	return out
+}
+
+func prettyLines(v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(b), "\n")
+}
+
+// lineDiff 基于 LCS 的行级 diff；总行数超过 maxLineDiffLines 时返回 nil
+func lineDiff(a, b []string) []string {
+	if len(a)+len(b) > maxLineDiffLines {
+		return nil
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	out := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+ "+b[j])
+	}
+	return out
+}
+
+// stripInternalKeys 去掉结果对象中以 "_" 开头的内部字段（如 _evidence）
+func stripInternalKeys(raw json.RawMessage) json.RawMessage {
+	var m map[string]json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &m) != nil {
+		return raw
+	}
+	for k := range m {
+		if strings.HasPrefix(k, "_") {
+			delete(m, k)
+		}
+	}
+	out, err := json.Marshal(m)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// normalizeJSON 经 JSON 往返统一数值/嵌套类型，便于与录制入参比较
+func normalizeJSON(v map[string]any) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
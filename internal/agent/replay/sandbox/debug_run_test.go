@@ -0,0 +1,151 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func debugEvents(t *testing.T) []jobstore.JobEvent {
+	t.Helper()
+	ev := func(typ jobstore.EventType, pl map[string]interface{}) jobstore.JobEvent {
+		b, err := json.Marshal(pl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jobstore.JobEvent{JobID: "job-1", Type: typ, Payload: b}
+	}
+	return []jobstore.JobEvent{
+		ev(jobstore.ToolCalled, map[string]interface{}{"node_id": "n1", "tool_name": "search", "input": map[string]interface{}{"q": "a"}}),
+		ev(jobstore.ToolReturned, map[string]interface{}{"node_id": "n1", "output": map[string]interface{}{"output": "orig", "done": true}}),
+		ev(jobstore.HTTPRecorded, map[string]interface{}{"effect_id": "n1:http:0", "response": map[string]interface{}{"ok": true}}),
+		ev(jobstore.StateCheckpointed, map[string]interface{}{
+			"node_id":      "n1",
+			"state_before": map[string]interface{}{},
+			"state_after":  map[string]interface{}{"n1": map[string]interface{}{"output": "orig", "done": true, "_evidence": map[string]interface{}{"x": 1}}},
+		}),
+		ev(jobstore.StateCheckpointed, map[string]interface{}{
+			"node_id":      "n2",
+			"state_before": map[string]interface{}{"n1": map[string]interface{}{"output": "orig"}},
+			"state_after":  map[string]interface{}{"n2": map[string]interface{}{"output": "answer"}},
+		}),
+		ev(jobstore.ReasoningSnapshot, map[string]interface{}{"node_id": "n2", "node_type": "llm"}),
+	}
+}
+
+func TestLoadDebugStep(t *testing.T) {
+	events := debugEvents(t)
+	step, err := LoadDebugStep("job-1", "n1", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step.NodeType != "tool" || step.ToolName != "search" || step.ToolInput["q"] != "a" {
+		t.Fatalf("step = %+v", step)
+	}
+	if len(step.ToolCalls) != 1 || step.ToolCalls[0].Output != "orig" || !step.ToolCalls[0].Done {
+		t.Fatalf("tool calls = %+v", step.ToolCalls)
+	}
+	if string(step.Recorded.RecordedHTTP["n1:http:0"]) != `{"ok":true}` {
+		t.Fatalf("recorded http = %s", step.Recorded.RecordedHTTP["n1:http:0"])
+	}
+	if string(step.OriginalOutput()) != `{"done":true,"output":"orig"}` {
+		t.Fatalf("original = %s", step.OriginalOutput())
+	}
+	if _, err := LoadDebugStep("job-1", "missing", events); !errors.Is(err, ErrStepNotRecorded) {
+		t.Fatalf("err = %v, want ErrStepNotRecorded", err)
+	}
+}
+
+func TestDebugRunner_ToolRecordedAndSandbox(t *testing.T) {
+	step, err := LoadDebugStep("job-1", "n1", debugEvents(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	d := &DebugRunner{
+		Tool: func(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolOutcome, error) {
+			calls++
+			return ToolOutcome{Output: "new:" + input["q"].(string), Done: true}, nil
+		},
+		SandboxSafe: func(toolName string) bool { return toolName == "search" },
+	}
+
+	// 入参未修改：直接使用录制结果，不执行工具
+	res, err := d.Run(context.Background(), step, DebugRunRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != DebugSourceRecorded || res.Diff.Changed || calls != 0 {
+		t.Fatalf("unmodified run = %+v (calls=%d)", res, calls)
+	}
+
+	res, err = d.Run(context.Background(), step, DebugRunRequest{Config: map[string]any{"q": "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != DebugSourceSandbox || calls != 1 {
+		t.Fatalf("modified run = %+v (calls=%d)", res, calls)
+	}
+	if !res.Diff.Changed || len(res.Diff.ChangedKeys) != 1 || res.Diff.ChangedKeys[0] != "output" {
+		t.Fatalf("diff = %+v", res.Diff)
+	}
+
+	// 未声明沙箱安全的工具不执行
+	if _, err := d.Run(context.Background(), step, DebugRunRequest{ToolName: "send_email", Config: map[string]any{"to": "x"}}); !errors.Is(err, ErrToolNotSandboxed) {
+		t.Fatalf("err = %v, want ErrToolNotSandboxed", err)
+	}
+	if calls != 1 {
+		t.Fatalf("unsafe tool executed, calls=%d", calls)
+	}
+}
+
+func TestDebugRunner_LLMPrompt(t *testing.T) {
+	step, err := LoadDebugStep("job-1", "n2", debugEvents(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotPrompt string
+	d := &DebugRunner{LLM: func(ctx context.Context, prompt string) (string, error) {
+		gotPrompt = prompt
+		return "better answer", nil
+	}}
+	res, err := d.Run(context.Background(), step, DebugRunRequest{Prompt: "fix: {{state_before}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPrompt != `fix: {"n1":{"output":"orig"}}` {
+		t.Fatalf("prompt = %q", gotPrompt)
+	}
+	if res.Source != DebugSourceLLM || !res.Diff.Changed {
+		t.Fatalf("res = %+v", res)
+	}
+	want := []string{"  {", `-   "output": "answer"`, `+   "output": "better answer"`, "  }"}
+	if len(res.Diff.Lines) != len(want) {
+		t.Fatalf("lines = %q", res.Diff.Lines)
+	}
+	for i := range want {
+		if res.Diff.Lines[i] != want[i] {
+			t.Fatalf("lines = %q", res.Diff.Lines)
+		}
+	}
+	if _, err := d.Run(context.Background(), step, DebugRunRequest{}); err == nil {
+		t.Fatal("expected error without prompt")
+	}
+}
@@ -19,6 +19,7 @@ package sandbox
 import (
 	"rag-platform/internal/agent/planner"
 	"rag-platform/internal/agent/replay"
+	"rag-platform/pkg/nodeplugin"
 )
 
 // OperationKind 表示 Replay 时该操作的允许行为
@@ -31,6 +32,8 @@ const (
 	SideEffect OperationKind = "side_effect"
 	// External 依赖外部世界，replay 时forbidden执行，仅从 event 恢复
 	External OperationKind = "external"
+	// Idempotent Tool manifest 显式声明幂等的工具：有已记录结果时仍注入，无结果时 replay 允许重新执行
+	Idempotent OperationKind = "idempotent"
 )
 
 // ReplayDecision 策略对单步的决策结果
@@ -38,6 +41,8 @@ type ReplayDecision struct {
 	Kind   OperationKind // 操作类型
 	Inject bool          // true 表示应从 ReplayContext 注入结果并跳过执行
 	Result []byte        // 注入时使用的 result（来自 CommandResults 或 CompletedToolInvocations）
+	// VerificationHint Kind=Idempotent 且重新执行时，来自 Tool manifest 的结果核对方式（写入 replay_reexecuted 供审计）
+	VerificationHint string
 }
 
 // ReplayPolicy 给定节点信息与 ReplayContext，返回 Replay 时应执行还是注入
@@ -46,6 +51,47 @@ type ReplayPolicy interface {
 	Decide(nodeID, commandID, nodeType string, replayCtx *replay.ReplayContext) ReplayDecision
 }
 
+// ToolReplayPolicy 可选接口：Tool 节点按工具名决策（Decide 仅知节点类型）；Runner 对 tool 节点优先使用
+type ToolReplayPolicy interface {
+	DecideTool(nodeID, commandID, toolName string, replayCtx *replay.ReplayContext) ReplayDecision
+}
+
+// IdempotencyLookup 返回工具是否显式声明幂等及其校验提示（如 tools.Registry.Idempotency）
+type IdempotencyLookup func(toolName string) (verificationHint string, idempotent bool)
+
+// IdempotentToolPolicy 在 Base 之上放开显式声明幂等的工具：有已记录结果仍注入，无结果时重新执行而非拒绝；
+// 其余节点与未声明的工具沿用 Base（默认 DefaultPolicy）
+type IdempotentToolPolicy struct {
+	Base   ReplayPolicy
+	Lookup IdempotencyLookup
+}
+
+// NewIdempotentToolPolicy 创建幂等工具策略；base 为 nil 时使用 DefaultPolicy
+func NewIdempotentToolPolicy(base ReplayPolicy, lookup IdempotencyLookup) *IdempotentToolPolicy {
+	if base == nil {
+		base = DefaultPolicy{}
+	}
+	return &IdempotentToolPolicy{Base: base, Lookup: lookup}
+}
+
+// Decide 实现 ReplayPolicy，委托 Base
+func (p *IdempotentToolPolicy) Decide(nodeID, commandID, nodeType string, replayCtx *replay.ReplayContext) ReplayDecision {
+	return p.Base.Decide(nodeID, commandID, nodeType, replayCtx)
+}
+
+// DecideTool 实现 ToolReplayPolicy
+func (p *IdempotentToolPolicy) DecideTool(nodeID, commandID, toolName string, replayCtx *replay.ReplayContext) ReplayDecision {
+	d := p.Base.Decide(nodeID, commandID, planner.NodeTool, replayCtx)
+	if replayCtx == nil || d.Inject || p.Lookup == nil {
+		return d
+	}
+	hint, ok := p.Lookup(toolName)
+	if !ok {
+		return d
+	}
+	return ReplayDecision{Kind: Idempotent, Inject: false, VerificationHint: hint}
+}
+
 // DefaultPolicy 默认策略：llm/tool/workflow 均为 SideEffect（replay 时仅注入，不重执行）
 type DefaultPolicy struct{}
 
@@ -68,11 +114,12 @@ func (DefaultPolicy) Decide(nodeID, commandID, nodeType string, replayCtx *repla
 		}
 		return ReplayDecision{Kind: SideEffect, Inject: false, Result: nil}
 	default:
-		// 未知类型视为 SideEffect，有则注入
+		// 未知类型视为 SideEffect，有则注入；声明 effect=pure 的自定义节点无结果时可重新执行
+		kind := kindForNodeType(nodeType)
 		if result, ok := replayCtx.CommandResults[commandID]; ok && len(result) > 0 {
-			return ReplayDecision{Kind: SideEffect, Inject: true, Result: result}
+			return ReplayDecision{Kind: kind, Inject: true, Result: result}
 		}
-		return ReplayDecision{Kind: SideEffect, Inject: false, Result: nil}
+		return ReplayDecision{Kind: kind, Inject: false, Result: nil}
 	}
 }
 
@@ -80,7 +127,13 @@ func kindForNodeType(nodeType string) OperationKind {
 	switch nodeType {
 	case planner.NodeTool, planner.NodeLLM, planner.NodeWorkflow:
 		return SideEffect
+	case planner.NodeBranch:
+		// 分支表达式只读已有结果，无记录时可重新求值
+		return Deterministic
 	default:
+		if p, ok := nodeplugin.Lookup(nodeType); ok && !p.Manifest.SideEffect() {
+			return Deterministic
+		}
 		return SideEffect
 	}
 }
@@ -0,0 +1,62 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"testing"
+
+	"rag-platform/internal/agent/replay"
+	"rag-platform/pkg/nodeplugin"
+)
+
+func TestIdempotentToolPolicy_DecideTool(t *testing.T) {
+	p := NewIdempotentToolPolicy(nil, func(name string) (string, bool) { return "check status", name == "status.get" })
+	rc := &replay.ReplayContext{CommandResults: map[string][]byte{"n1": []byte(`{"ok":true}`)}}
+
+	// 有已记录结果时幂等工具也注入，不重执行
+	if d := p.DecideTool("n1", "n1", "status.get", rc); !d.Inject || d.Kind != SideEffect {
+		t.Fatalf("recorded result: %+v", d)
+	}
+	d := p.DecideTool("n2", "n2", "status.get", rc)
+	if d.Inject || d.Kind != Idempotent || d.VerificationHint != "check status" {
+		t.Fatalf("idempotent without result: %+v", d)
+	}
+	if d := p.DecideTool("n2", "n2", "payment.charge", rc); d.Inject || d.Kind != SideEffect {
+		t.Fatalf("undeclared tool: %+v", d)
+	}
+	// 非 Replay 路径沿用 Base
+	if d := p.DecideTool("n2", "n2", "status.get", nil); d.Kind != SideEffect || d.Inject {
+		t.Fatalf("no replay ctx: %+v", d)
+	}
+}
+
+func TestDefaultPolicy_CustomNodeTypes(t *testing.T) {
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{Type: "sandbox_test.render", Effect: nodeplugin.EffectPure})
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{Type: "sandbox_test.migrate"})
+	rc := &replay.ReplayContext{CommandResults: map[string][]byte{"n1": []byte(`"done"`)}}
+	var p DefaultPolicy
+
+	// 已提交结果一律注入
+	if d := p.Decide("n1", "n1", "sandbox_test.render", rc); !d.Inject || d.Kind != Deterministic {
+		t.Fatalf("pure with result: %+v", d)
+	}
+	// 无结果：pure 可重新执行，副作用节点仍为 SideEffect（Runner 拒绝重执行）
+	if d := p.Decide("n2", "n2", "sandbox_test.render", rc); d.Inject || d.Kind != Deterministic {
+		t.Fatalf("pure without result: %+v", d)
+	}
+	if d := p.Decide("n2", "n2", "sandbox_test.migrate", rc); d.Inject || d.Kind != SideEffect {
+		t.Fatalf("side effect without result: %+v", d)
+	}
+}
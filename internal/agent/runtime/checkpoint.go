@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -47,6 +48,11 @@ type CheckpointStore interface {
 	ListByAgent(ctx context.Context, agentID string) ([]*Checkpoint, error)
 }
 
+// JobCheckpointLister 可选接口：按 Job 列出检查点（按创建时间升序），供手动回滚选择检查点
+type JobCheckpointLister interface {
+	ListByJob(ctx context.Context, jobID string) ([]*Checkpoint, error)
+}
+
 // NewCheckpoint 创建检查点（ID 可在 Save 时生成）
 func NewCheckpoint(agentID, sessionID string, taskGraphState, memoryState []byte) *Checkpoint {
 	return &Checkpoint{
@@ -156,3 +162,25 @@ func (s *checkpointStoreMem) ListByAgent(ctx context.Context, agentID string) ([
 	}
 	return list, nil
 }
+
+// ListByJob 实现 JobCheckpointLister
+func (s *checkpointStoreMem) ListByJob(ctx context.Context, jobID string) ([]*Checkpoint, error) {
+	s.mu.RLock()
+	var list []*Checkpoint
+	for _, cp := range s.byID {
+		if jobID != "" && cp.JobID == jobID {
+			list = append(list, cp)
+		}
+	}
+	s.mu.RUnlock()
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	out := make([]*Checkpoint, 0, len(list))
+	for _, cp := range list {
+		cpCopy := *cp
+		cpCopy.TaskGraphState = append([]byte(nil), cp.TaskGraphState...)
+		cpCopy.MemoryState = append([]byte(nil), cp.MemoryState...)
+		cpCopy.PayloadResults = append([]byte(nil), cp.PayloadResults...)
+		out = append(out, &cpCopy)
+	}
+	return out, nil
+}
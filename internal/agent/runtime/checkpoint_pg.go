@@ -147,3 +147,35 @@ func (s *CheckpointStorePg) ListByAgent(ctx context.Context, agentID string) ([]
 	}
 	return out, rows.Err()
 }
+
+// ListByJob 实现 JobCheckpointLister。
+func (s *CheckpointStorePg) ListByJob(ctx context.Context, jobID string) ([]*Checkpoint, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, agent_id, session_id, job_id, task_graph_state, memory_state, cursor_node, payload_results, created_at
+		 FROM checkpoints
+		 WHERE job_id = $1
+		 ORDER BY created_at ASC`,
+		jobID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]*Checkpoint, 0)
+	for rows.Next() {
+		var cp Checkpoint
+		var cpJobID *string
+		var cursorNode *string
+		if err := rows.Scan(&cp.ID, &cp.AgentID, &cp.SessionID, &cpJobID, &cp.TaskGraphState, &cp.MemoryState, &cursorNode, &cp.PayloadResults, &cp.CreatedAt); err != nil {
+			return nil, err
+		}
+		if cpJobID != nil {
+			cp.JobID = *cpJobID
+		}
+		if cursorNode != nil {
+			cp.CursorNode = *cursorNode
+		}
+		out = append(out, cloneCheckpoint(&cp))
+	}
+	return out, rows.Err()
+}
@@ -27,6 +27,7 @@ type contextKey int
 const (
 	ctxKeyClock contextKey = iota
 	ctxKeyRNG
+	ctxKeyScratchpad
 )
 
 // ClockFunc returns the current time. When injected by the Runner during replay,
@@ -28,9 +28,11 @@ type effectsCtx struct {
 	StepID   string
 	Replay   *replay.ReplayContext
 	Recorder RecordedEffectsRecorder
-	timeIdx  int
-	uuidIdx  int
-	mu       sync.Mutex
+	// Sandbox 调试沙箱：仅使用录制结果，不记录、不发起真实外部请求
+	Sandbox bool
+	timeIdx int
+	uuidIdx int
+	mu      sync.Mutex
 }
 
 // WithRecordedEffects 在调用 step 前注入；Replay 时 Replay 非空，Recorder 可为 nil；非 Replay 时 Recorder 非空。
@@ -51,3 +53,22 @@ func getEffectsCtx(ctx context.Context) *effectsCtx {
 	ec, _ := v.(*effectsCtx)
 	return ec
 }
+
+// WithSandboxEffects 注入调试沙箱上下文（time-travel debug-run）：HTTP 仅从 replayCtx.RecordedHTTP 注入，
+// 未录制时返回 ErrHTTPNotRecorded；不写入任何效应事件。
+func WithSandboxEffects(ctx context.Context, jobID, stepID string, replayCtx *replay.ReplayContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, &effectsCtx{
+		JobID: jobID, StepID: stepID, Replay: replayCtx, Sandbox: true,
+	})
+}
+
+// IsSandbox 当前 ctx 是否处于调试沙箱（工具可据此拒绝真实副作用）
+func IsSandbox(ctx context.Context) bool {
+	ec := getEffectsCtx(ctx)
+	return ec != nil && ec.Sandbox
+}
+
+// Active 当前 ctx 是否注入了 RecordedEffects（执行、Replay 或调试沙箱）；工具据此决定是否经 HTTP 效应录制
+func Active(ctx context.Context) bool {
+	return getEffectsCtx(ctx) != nil
+}
@@ -16,12 +16,16 @@ package effects
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrHTTPNotRecorded 调试沙箱中请求的 HTTP 效应没有录制结果；沙箱forbidden发起真实请求
+var ErrHTTPNotRecorded = errors.New("effects: HTTP 未录制，沙箱中forbidden真实请求")
+
 // Now 返回当前时间；Replay 时从事件流注入，否则经 Recorder 记录后返回。Step 内forbidden直接使用 time.Now()。
 func Now(ctx context.Context) time.Time {
 	ec := getEffectsCtx(ctx)
@@ -84,6 +88,9 @@ func HTTP(ctx context.Context, effectID string, doRequest func() (reqJSON, respJ
 			return nil, resp, nil
 		}
 	}
+	if ec.Sandbox {
+		return nil, nil, fmt.Errorf("%w: %s", ErrHTTPNotRecorded, effectID)
+	}
 	req, resp, err := doRequest()
 	if err != nil {
 		return req, nil, err
@@ -0,0 +1,108 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/pkg/agent/sdk"
+)
+
+type staticConfigResolver struct {
+	values map[string]string
+	err    error
+	agents []string
+}
+
+func (r *staticConfigResolver) ResolveAgentConfig(ctx context.Context, agentID string) (map[string]string, error) {
+	r.agents = append(r.agents, agentID)
+	return r.values, r.err
+}
+
+type configCapturingTool struct {
+	endpoint string
+}
+
+func (t *configCapturingTool) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	t.endpoint = sdk.ConfigFromContext(ctx).String("endpoint", "")
+	return ToolResult{Done: true, Output: "ok"}, nil
+}
+
+type startedCapturingSink struct {
+	started []*ToolInvocationStartedPayload
+}
+
+func (s *startedCapturingSink) AppendToolCalled(ctx context.Context, jobID, nodeID, toolName string, input []byte) error {
+	return nil
+}
+func (s *startedCapturingSink) AppendToolReturned(ctx context.Context, jobID, nodeID string, output []byte) error {
+	return nil
+}
+func (s *startedCapturingSink) AppendToolResultSummarized(ctx context.Context, jobID, nodeID, toolName, summary, errMsg string, idempotent bool) error {
+	return nil
+}
+func (s *startedCapturingSink) AppendToolInvocationStarted(ctx context.Context, jobID, nodeID string, payload *ToolInvocationStartedPayload) error {
+	s.started = append(s.started, payload)
+	return nil
+}
+func (s *startedCapturingSink) AppendToolInvocationFinished(ctx context.Context, jobID, nodeID string, payload *ToolInvocationFinishedPayload) error {
+	return nil
+}
+
+// TestToolNodeAdapter_InjectsAgentConfig 验证 Agent 配置经 sdk.ConfigFromContext 注入工具，事件只记录 key
+func TestToolNodeAdapter_InjectsAgentConfig(t *testing.T) {
+	tool := &configCapturingTool{}
+	sink := &startedCapturingSink{}
+	resolver := &staticConfigResolver{values: map[string]string{"endpoint": "https://staging.example.com", "api_key": "s3cret"}}
+	adapter := &ToolNodeAdapter{Tools: tool, ToolEventSink: sink, AgentConfig: resolver}
+	payload := &AgentDAGPayload{Goal: "g", Results: map[string]any{}}
+	ctx := WithJobID(context.Background(), "job-1")
+
+	if _, err := adapter.runNode(ctx, "n1", "http_get", nil, &runtime.Agent{ID: "agent-staging"}, payload); err != nil {
+		t.Fatalf("runNode: %v", err)
+	}
+	if tool.endpoint != "https://staging.example.com" {
+		t.Fatalf("tool saw endpoint %q", tool.endpoint)
+	}
+	if len(resolver.agents) != 1 || resolver.agents[0] != "agent-staging" {
+		t.Fatalf("resolver called with %v", resolver.agents)
+	}
+	if len(sink.started) != 1 {
+		t.Fatalf("tool_invocation_started events = %d", len(sink.started))
+	}
+	keys := sink.started[0].ConfigKeys
+	if len(keys) != 2 || keys[0] != "api_key" || keys[1] != "endpoint" {
+		t.Fatalf("config_keys = %v", keys)
+	}
+}
+
+// TestToolNodeAdapter_AgentConfigResolveError 验证配置解析失败时不执行工具
+func TestToolNodeAdapter_AgentConfigResolveError(t *testing.T) {
+	tools := &sequenceToolExec{successOut: "ok"}
+	adapter := &ToolNodeAdapter{Tools: tools, AgentConfig: &staticConfigResolver{err: errors.New("secret missing")}}
+	payload := &AgentDAGPayload{Goal: "g", Results: map[string]any{}}
+
+	_, err := adapter.runNode(context.Background(), "n1", "http_get", nil, &runtime.Agent{ID: "a1"}, payload)
+	var sf *StepFailure
+	if !errors.As(err, &sf) {
+		t.Fatalf("expected StepFailure, got %v", err)
+	}
+	if tools.Calls() != 0 {
+		t.Fatalf("tool executed %d times despite config error", tools.Calls())
+	}
+}
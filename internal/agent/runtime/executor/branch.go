@@ -0,0 +1,104 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// DecisionEventSink 可选：CommandEventSink 实现时，branch 节点的分支选择写入 decision_made（Trace 决策树，Replay 按记录注入）
+type DecisionEventSink interface {
+	AppendDecisionMade(ctx context.Context, jobID string, pl *jobstore.DecisionMadePayload) error
+}
+
+// BranchNodeAdapter 分支节点适配器：按 Config["expr"] 对 payload.Results 确定性求值，
+// 结果写入 Results[节点]={"outcome","value"}，下游按出边 When 选择分支（未选中的节点由 Compiler 包装跳过）
+type BranchNodeAdapter struct {
+	DecisionSink DecisionEventSink
+}
+
+func (a *BranchNodeAdapter) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, err := a.ToNodeRunner(task, agent)
+	if err != nil {
+		return nil, err
+	}
+	return compose.InvokableLambda(compose.InvokeWOOpt[*AgentDAGPayload, *AgentDAGPayload](run)), nil
+}
+
+func (a *BranchNodeAdapter) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	src, _ := task.Config["expr"].(string)
+	expr, err := planner.ParseBranchExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("branch 节点 %s: %w", task.ID, err)
+	}
+	nodeID := task.ID
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		if p.Results == nil {
+			p.Results = make(map[string]any)
+		}
+		v, err := expr.Eval(p.Results)
+		if err != nil {
+			return nil, fmt.Errorf("branch 节点 %s 求值failed: %w", nodeID, err)
+		}
+		outcome := planner.BranchOutcome(v)
+		p.Results[nodeID] = map[string]any{"outcome": outcome, "value": v}
+		if a.DecisionSink != nil {
+			if jobID := JobIDFromContext(ctx); jobID != "" {
+				value, _ := json.Marshal(v)
+				_ = a.DecisionSink.AppendDecisionMade(ctx, jobID, &jobstore.DecisionMadePayload{
+					NodeID:  nodeID,
+					Content: fmt.Sprintf("%s => %s", expr.String(), outcome),
+					Kind:    jobstore.DecisionKindBranch,
+					Expr:    expr.String(),
+					Outcome: outcome,
+					Value:   value,
+				})
+			}
+		}
+		return p, nil
+	}, nil
+}
+
+// branchSkippedResult 未被分支选中的节点写入的结果：节点本身不执行、无副作用
+func branchSkippedResult() map[string]any {
+	return map[string]any{"skipped": true, "reason": "branch_not_taken"}
+}
+
+// isBranchSkipped 按已决分支判断节点是否未被选中
+func isBranchSkipped(g *planner.TaskGraph, nodeID string, results map[string]any) bool {
+	return g.SkippedNodes(results)[nodeID]
+}
+
+// guardBranchRoute 含 branch 节点的计划中为每个节点包一层路由判定：未被选中的节点只写入跳过标记
+func guardBranchRoute(g *planner.TaskGraph, nodeID string, run NodeRunner) NodeRunner {
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		if p != nil && isBranchSkipped(g, nodeID, p.Results) {
+			if p.Results == nil {
+				p.Results = make(map[string]any)
+			}
+			p.Results[nodeID] = branchSkippedResult()
+			return p, nil
+		}
+		return run(ctx, p)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// echoNodeAdapterForTest 记录执行过的节点，结果取 Config["output"]
+type echoNodeAdapterForTest struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (a *echoNodeAdapterForTest) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, _ := a.ToNodeRunner(task, agent)
+	return compose.InvokableLambda(compose.InvokeWOOpt[*AgentDAGPayload, *AgentDAGPayload](run)), nil
+}
+
+func (a *echoNodeAdapterForTest) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		a.mu.Lock()
+		a.ran = append(a.ran, task.ID)
+		a.mu.Unlock()
+		p.Results[task.ID] = map[string]any{"output": task.Config["output"]}
+		return p, nil
+	}, nil
+}
+
+type decisionSinkForTest struct {
+	decisions []*jobstore.DecisionMadePayload
+}
+
+func (s *decisionSinkForTest) AppendDecisionMade(_ context.Context, _ string, pl *jobstore.DecisionMadePayload) error {
+	s.decisions = append(s.decisions, pl)
+	return nil
+}
+
+func branchGraphForTest(searchOutput string) *planner.TaskGraph {
+	return &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "search", Type: "echo", Config: map[string]any{"output": searchOutput}},
+			{ID: "check", Type: planner.NodeBranch, Config: map[string]any{"expr": "empty(search.output)"}},
+			{ID: "ask", Type: "echo"},
+			{ID: "summarize", Type: "echo"},
+			{ID: "reply", Type: "echo"},
+		},
+		Edges: []planner.TaskEdge{
+			{From: "search", To: "check"},
+			{From: "check", To: "ask", When: "true"},
+			{From: "check", To: "summarize", When: "false"},
+			{From: "ask", To: "reply"},
+			{From: "summarize", To: "reply"},
+		},
+	}
+}
+
+func TestCompileSteppable_BranchSkipsUnchosenPath(t *testing.T) {
+	for _, tc := range []struct {
+		search, outcome, ran, skipped string
+	}{
+		{search: "[]", outcome: "true", ran: "ask", skipped: "summarize"},
+		{search: `[{"title":"doc"}]`, outcome: "false", ran: "summarize", skipped: "ask"},
+	} {
+		echo := &echoNodeAdapterForTest{}
+		sink := &decisionSinkForTest{}
+		c := NewCompiler(map[string]NodeAdapter{"echo": echo, planner.NodeBranch: &BranchNodeAdapter{DecisionSink: sink}})
+		steps, err := c.CompileSteppable(context.Background(), branchGraphForTest(tc.search), &runtime.Agent{ID: "a1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := WithJobID(context.Background(), "job-1")
+		p := NewAgentDAGPayload("goal", "a1", "")
+		for _, s := range steps {
+			if p, err = s.Run(ctx, p); err != nil {
+				t.Fatalf("%s: %v", s.NodeID, err)
+			}
+		}
+		if len(echo.ran) != 3 || echo.ran[1] != tc.ran || echo.ran[2] != "reply" {
+			t.Fatalf("search=%s: ran %v", tc.search, echo.ran)
+		}
+		if got, _ := planner.BranchResultOutcome(p.Results["check"]); got != tc.outcome {
+			t.Fatalf("outcome = %q, want %q", got, tc.outcome)
+		}
+		if m, _ := p.Results[tc.skipped].(map[string]any); m["skipped"] != true {
+			t.Fatalf("%s result = %v, want skipped marker", tc.skipped, p.Results[tc.skipped])
+		}
+		if len(sink.decisions) != 1 || sink.decisions[0].Outcome != tc.outcome || sink.decisions[0].Kind != jobstore.DecisionKindBranch || sink.decisions[0].NodeID != "check" {
+			t.Fatalf("decisions = %+v", sink.decisions)
+		}
+	}
+}
+
+func TestCompile_BranchSkipsUnchosenPath(t *testing.T) {
+	echo := &echoNodeAdapterForTest{}
+	c := NewCompiler(map[string]NodeAdapter{"echo": echo, planner.NodeBranch: &BranchNodeAdapter{}})
+	// eino 图不支持多前驱合并 *AgentDAGPayload，只保留单条分支路径
+	tg := branchGraphForTest("[]")
+	tg.Nodes = []planner.TaskNode{tg.Nodes[0], tg.Nodes[1], tg.Nodes[3]}
+	tg.Edges = []planner.TaskEdge{tg.Edges[0], tg.Edges[2]}
+	g, err := c.Compile(context.Background(), tg, &runtime.Agent{ID: "a1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := g.Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := r.Invoke(context.Background(), NewAgentDAGPayload("goal", "a1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(echo.ran) != 1 || echo.ran[0] != "search" {
+		t.Fatalf("summarize should be skipped, ran %v", echo.ran)
+	}
+	if m, _ := out.Results["summarize"].(map[string]any); m["skipped"] != true {
+		t.Fatalf("summarize result = %v", out.Results["summarize"])
+	}
+}
+
+func TestBranchNodeAdapter_InvalidExpr(t *testing.T) {
+	a := &BranchNodeAdapter{}
+	if _, err := a.ToNodeRunner(&planner.TaskNode{ID: "b", Type: planner.NodeBranch, Config: map[string]any{"expr": "len("}}, nil); err == nil {
+		t.Fatal("invalid expr should fail to compile")
+	}
+}
@@ -14,7 +14,11 @@
 
 package executor
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // CapabilityPolicyChecker 执行前校验：按能力/工具返回 allow / require_approval / deny（design/capability-policy.md）
 type CapabilityPolicyChecker interface {
@@ -30,6 +34,69 @@ func (PolicyAllowAll) Check(_ context.Context, _, _, _, _ string, _ map[string]s
 	return true, false, nil
 }
 
+// 策略取值（design/capability-policy.md）
+const (
+	CapabilityPolicyAllow           = "allow"
+	CapabilityPolicyRequireApproval = "require_approval"
+	CapabilityPolicyRequireHuman    = "require_human"
+	CapabilityPolicyDeny            = "deny"
+)
+
+// StaticCapabilityPolicy 按 capability 查表的策略（agent.capability_policy）；未列出的 capability 使用默认策略
+type StaticCapabilityPolicy struct {
+	defaultPolicy string
+	policies      map[string]string
+}
+
+// NewStaticCapabilityPolicy 创建查表策略；defaultPolicy 为空视为 allow，取值非法时返回错误
+func NewStaticCapabilityPolicy(defaultPolicy string, policies map[string]string) (*StaticCapabilityPolicy, error) {
+	p := &StaticCapabilityPolicy{policies: make(map[string]string, len(policies))}
+	var err error
+	if p.defaultPolicy, err = normalizeCapabilityPolicy(defaultPolicy); err != nil {
+		return nil, err
+	}
+	for capability, policy := range policies {
+		if p.policies[capability], err = normalizeCapabilityPolicy(policy); err != nil {
+			return nil, fmt.Errorf("capability %q: %w", capability, err)
+		}
+	}
+	return p, nil
+}
+
+func normalizeCapabilityPolicy(policy string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(policy)); p {
+	case "":
+		return CapabilityPolicyAllow, nil
+	case CapabilityPolicyAllow, CapabilityPolicyRequireApproval, CapabilityPolicyRequireHuman, CapabilityPolicyDeny:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid capability policy %q (want allow, require_approval, require_human or deny)", policy)
+	}
+}
+
+// Policy 返回 capability 生效的策略
+func (p *StaticCapabilityPolicy) Policy(capability string) string {
+	if policy, ok := p.policies[capability]; ok {
+		return policy
+	}
+	return p.defaultPolicy
+}
+
+// Check 实现 CapabilityPolicyChecker；require_approval / require_human 在该步的审批 key 已批准后放行
+func (p *StaticCapabilityPolicy) Check(_ context.Context, _, _, capability, idempotencyKey string, approvedKeys map[string]struct{}) (bool, bool, error) {
+	switch p.Policy(capability) {
+	case CapabilityPolicyDeny:
+		return false, false, nil
+	case CapabilityPolicyRequireApproval, CapabilityPolicyRequireHuman:
+		if _, ok := approvedKeys["cap-approval-"+idempotencyKey]; ok {
+			return true, false, nil
+		}
+		return false, true, nil
+	default:
+		return true, false, nil
+	}
+}
+
 // CapabilityRequiresApproval 表示该步需人工/系统审批后才可执行；Runner 应写 job_waiting 并返回 ErrJobWaiting
 type CapabilityRequiresApproval struct {
 	CorrelationKey string
@@ -0,0 +1,65 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStaticCapabilityPolicy(t *testing.T) {
+	p, err := NewStaticCapabilityPolicy("", map[string]string{
+		"code_exec": "require_approval",
+		"payment":   "REQUIRE_HUMAN",
+		"drop_db":   "deny",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cases := []struct {
+		capability   string
+		approved     map[string]struct{}
+		wantAllowed  bool
+		wantApproval bool
+	}{
+		{"read_docs", nil, true, false},
+		{"drop_db", nil, false, false},
+		{"code_exec", nil, false, true},
+		{"code_exec", map[string]struct{}{"cap-approval-other": {}}, false, true},
+		{"code_exec", map[string]struct{}{"cap-approval-idem-1": {}}, true, false},
+		{"payment", nil, false, true},
+	}
+	for _, tc := range cases {
+		allowed, approval, err := p.Check(ctx, "job-1", "tool", tc.capability, "idem-1", tc.approved)
+		if err != nil || allowed != tc.wantAllowed || approval != tc.wantApproval {
+			t.Errorf("%s approved=%v: got (%v,%v,%v), want (%v,%v)", tc.capability, tc.approved, allowed, approval, err, tc.wantAllowed, tc.wantApproval)
+		}
+	}
+
+	deny, err := NewStaticCapabilityPolicy("deny", map[string]string{"read_docs": "allow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deny.Policy("anything") != CapabilityPolicyDeny || deny.Policy("read_docs") != CapabilityPolicyAllow {
+		t.Fatalf("default/override policy mismatch")
+	}
+	if _, err := NewStaticCapabilityPolicy("maybe", nil); err == nil {
+		t.Fatal("expected invalid default policy error")
+	}
+	if _, err := NewStaticCapabilityPolicy("", map[string]string{"x": "sometimes"}); err == nil {
+		t.Fatal("expected invalid capability policy error")
+	}
+}
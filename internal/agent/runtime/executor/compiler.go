@@ -42,6 +42,14 @@ func (c *Compiler) Register(nodeType string, adapter NodeAdapter) {
 	c.registry.Register(nodeType, adapter)
 }
 
+// Adapter 返回某类型已注册的 NodeAdapter
+func (c *Compiler) Adapter(nodeType string) (NodeAdapter, bool) {
+	if c == nil || c.registry == nil {
+		return nil, false
+	}
+	return c.registry.Get(nodeType)
+}
+
 // RegisteredNodeTypes 返回当前已注册节点类型（按字典序），用于 custom node discovery。
 func (c *Compiler) RegisteredNodeTypes() []string {
 	if c == nil || c.registry == nil {
@@ -61,6 +69,7 @@ func (c *Compiler) Compile(ctx context.Context, g *planner.TaskGraph, agent *run
 	graph := compose.NewGraph[*AgentDAGPayload, *AgentDAGPayload]()
 
 	nodeIDs := make(map[string]struct{})
+	branched := g.HasBranches()
 	for i := range g.Nodes {
 		node := &g.Nodes[i]
 		nodeIDs[node.ID] = struct{}{}
@@ -68,7 +77,17 @@ func (c *Compiler) Compile(ctx context.Context, g *planner.TaskGraph, agent *run
 		if !ok || adapter == nil {
 			return nil, fmt.Errorf("executor: 未知节点类型 %q (节点 %s)", node.Type, node.ID)
 		}
-		lambda, err := adapter.ToDAGNode(node, agent)
+		var lambda *compose.Lambda
+		var err error
+		if branched {
+			// 含分支时经 NodeRunner 包装路由判定，未被选中的节点不执行
+			var run NodeRunner
+			if run, err = adapter.ToNodeRunner(node, agent); err == nil {
+				lambda = compose.InvokableLambda(compose.InvokeWOOpt[*AgentDAGPayload, *AgentDAGPayload](guardBranchRoute(g, node.ID, run)))
+			}
+		} else {
+			lambda, err = adapter.ToDAGNode(node, agent)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("executor: 节点 %s 适配failed: %w", node.ID, err)
 		}
@@ -0,0 +1,63 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+	"rag-platform/pkg/metrics"
+)
+
+// CustomEventSink 可选：NodeEventSink 实现时，步骤经 sdk.EmitEvent 发出的业务事件写入 custom_event
+type CustomEventSink interface {
+	AppendCustomEvent(ctx context.Context, jobID string, pl *jobstore.CustomEventPayload) error
+}
+
+// customEventEmitter 实现 sdk.EventEmitter，固定 jobID/nodeID/stepID
+type customEventEmitter struct {
+	sink   CustomEventSink
+	jobID  string
+	nodeID string
+	stepID string
+}
+
+func (e *customEventEmitter) EmitEvent(ctx context.Context, eventType string, payload json.RawMessage) error {
+	err := e.sink.AppendCustomEvent(ctx, e.jobID, &jobstore.CustomEventPayload{
+		Name:   eventType,
+		NodeID: e.nodeID,
+		StepID: e.stepID,
+		Data:   payload,
+		At:     time.Now(),
+	})
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.CustomEventsTotal.WithLabelValues(TenantIDFromContext(ctx), result).Inc()
+	return err
+}
+
+// attachEventEmitter 注入 sdk.EventEmitter（NodeEventSink 未实现 CustomEventSink 时原样返回）
+func (r *Runner) attachEventEmitter(ctx context.Context, jobID, nodeID, stepID string) context.Context {
+	sink, ok := r.nodeEventSink.(CustomEventSink)
+	if !ok || jobID == "" {
+		return ctx
+	}
+	return sdk.WithEventEmitter(ctx, &customEventEmitter{sink: sink, jobID: jobID, nodeID: nodeID, stepID: stepID})
+}
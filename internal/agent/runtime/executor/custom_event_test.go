@@ -0,0 +1,94 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+)
+
+// customEventSink 在 timeoutNodeSink 基础上记录 custom_event
+type customEventSink struct {
+	timeoutNodeSink
+	events []jobstore.CustomEventPayload
+}
+
+func (s *customEventSink) AppendCustomEvent(ctx context.Context, jobID string, pl *jobstore.CustomEventPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *pl)
+	return nil
+}
+
+// emittingToolExec 发出一条合法事件，并记录非法类型与超大 payload 的错误
+type emittingToolExec struct {
+	errs []error
+}
+
+func (e *emittingToolExec) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	if err := sdk.EmitEvent(ctx, "billing.invoice_drafted", map[string]any{"invoice_id": "inv-1", "amount": 42}); err != nil {
+		return ToolResult{}, err
+	}
+	e.errs = append(e.errs,
+		sdk.EmitEvent(ctx, "Invoice Drafted", nil),
+		sdk.EmitEvent(ctx, "big", strings.Repeat("x", sdk.MaxEventPayloadBytes)),
+	)
+	return ToolResult{Done: true, Output: "ok"}, nil
+}
+
+func TestRunForJob_EmitCustomEvent(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job-custom-event"
+	eventStore := jobstore.NewMemoryStore()
+	graphBytes, _ := (&planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeTool, ToolName: "draft"}}}).Marshal()
+	planPayload, _ := json.Marshal(map[string]interface{}{"task_graph": json.RawMessage(graphBytes), "goal": "g"})
+	if _, err := eventStore.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: planPayload}); err != nil {
+		t.Fatalf("append plan_generated: %v", err)
+	}
+
+	tools := &emittingToolExec{}
+	runner := NewRunner(NewCompiler(map[string]NodeAdapter{planner.NodeTool: &ToolNodeAdapter{Tools: tools}}))
+	runner.SetCheckpointStores(runtime.NewCheckpointStoreMem(), &fakeJobStoreForRunner{})
+	runner.SetReplayContextBuilder(replay.NewReplayContextBuilder(eventStore))
+	sink := &customEventSink{}
+	runner.SetNodeEventSink(sink)
+
+	if err := runner.RunForJob(ctx, &runtime.Agent{ID: "a1"}, &JobForRunner{ID: jobID, AgentID: "a1", Goal: "g"}); err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if len(sink.events) != 1 {
+		t.Fatalf("custom events = %+v", sink.events)
+	}
+	got := sink.events[0]
+	if got.Name != "billing.invoice_drafted" || got.NodeID != "n1" || got.StepID == "" || string(got.Data) != `{"amount":42,"invoice_id":"inv-1"}` {
+		t.Fatalf("payload = %+v (data %s)", got, got.Data)
+	}
+	if len(tools.errs) != 2 || !errors.Is(tools.errs[0], sdk.ErrInvalidEventType) || !errors.Is(tools.errs[1], sdk.ErrEventPayloadTooLarge) {
+		t.Fatalf("validation errors = %v", tools.errs)
+	}
+	// 不在 Job 执行中
+	if err := sdk.EmitEvent(ctx, "invoice_drafted", nil); !errors.Is(err, sdk.ErrEventsUnavailable) {
+		t.Fatalf("outside job: %v", err)
+	}
+}
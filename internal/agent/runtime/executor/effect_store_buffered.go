@@ -0,0 +1,424 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// ErrEffectBufferClosed 本地 effect 缓冲已关闭
+var ErrEffectBufferClosed = errors.New("executor: effect buffer closed")
+
+const (
+	effectWALFile        = "effects.wal"
+	effectCheckpointFile = "effects.flushed"
+	// effectWALCompactBytes 队列清空且 WAL 超过该大小时截断，避免本地文件无限增长
+	effectWALCompactBytes = 16 << 20
+)
+
+// BufferedEffectStoreConfig Worker 本地 effect 缓冲配置
+type BufferedEffectStoreConfig struct {
+	// Dir WAL 所在目录，须为本地持久磁盘（同一 Worker 重启后复用以完成崩溃恢复）
+	Dir string
+	// MaxPending 尚未刷入下游的最大条数；达到上限时 PutEffect 阻塞直到刷写腾出空间（背压），<=0 为 10000
+	MaxPending int
+	// FlushInterval 刷写失败后的重试与兜底轮询间隔，<=0 为 200ms
+	FlushInterval time.Duration
+}
+
+// bufferedEffect WAL 中的一条记录
+type bufferedEffect struct {
+	Seq      uint64        `json:"seq"`
+	Record   *EffectRecord `json:"record"`
+	queuedAt time.Time
+}
+
+// BufferedEffectStore Worker 本地预写缓冲的 EffectStore：PutEffect 追加到本地 WAL 并 fsync 后即返回，
+// 后台按写入顺序逐条刷入下游 EffectStore（通常为 EffectStorePg）。未刷出的记录可从本地读到（catch-up 不受影响）；
+// 进程崩溃后由同一目录重建队列继续刷写。刷写为至少一次，依赖下游 PutEffect 按 job_id+idempotency_key/command_id 幂等 upsert。
+// 注意：未刷出的 effect 在原 Worker 重启前对其他 Worker 不可见，MaxPending 同时限定了这一窗口的大小。
+type BufferedEffectStore struct {
+	next          EffectStore
+	dir           string
+	flushInterval time.Duration
+
+	mu         sync.Mutex
+	file       *os.File
+	offset     int64
+	seq        uint64
+	flushedSeq uint64
+	pending    []*bufferedEffect
+	index      map[string]*bufferedEffect // keyID/keyCmd -> 最新未刷出记录
+	closed     bool
+
+	slots chan struct{} // 背压信号量：每条未刷出记录占一个
+	wake  chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewBufferedEffectStore 打开（或恢复）本地 WAL 并启动后台刷写；next 为下游持久化 EffectStore
+func NewBufferedEffectStore(next EffectStore, cfg BufferedEffectStoreConfig) (*BufferedEffectStore, error) {
+	if next == nil {
+		return nil, errors.New("executor: buffered effect store requires a downstream store")
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("executor: buffered effect store requires dir")
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = 10000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 200 * time.Millisecond
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create effect buffer dir: %w", err)
+	}
+	s := &BufferedEffectStore{
+		next:          next,
+		dir:           cfg.Dir,
+		flushInterval: cfg.FlushInterval,
+		index:         make(map[string]*bufferedEffect),
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	capacity := cfg.MaxPending
+	if len(s.pending) > capacity {
+		capacity = len(s.pending)
+	}
+	s.slots = make(chan struct{}, capacity)
+	for range s.pending {
+		s.slots <- struct{}{}
+	}
+	s.updateGauges()
+	go s.loop()
+	return s, nil
+}
+
+// recover 读取刷写检查点与 WAL，重建未刷出队列；末尾不完整的记录（写入中途崩溃）被截掉
+func (s *BufferedEffectStore) recover() error {
+	if data, err := os.ReadFile(filepath.Join(s.dir, effectCheckpointFile)); err == nil {
+		if n, errParse := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); errParse == nil {
+			s.flushedSeq = n
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("read effect buffer checkpoint: %w", err)
+	}
+	s.seq = s.flushedSeq
+
+	f, err := os.OpenFile(filepath.Join(s.dir, effectWALFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open effect wal: %w", err)
+	}
+	reader := bufio.NewReader(f)
+	var good int64
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if errRead != nil {
+			// EOF：len(line)>0 表示最后一行未写完
+			if errRead != io.EOF {
+				_ = f.Close()
+				return fmt.Errorf("read effect wal: %w", errRead)
+			}
+			break
+		}
+		var e bufferedEffect
+		if err := json.Unmarshal(line, &e); err != nil || e.Record == nil {
+			break
+		}
+		good += int64(len(line))
+		if e.Seq > s.seq {
+			s.seq = e.Seq
+		}
+		if e.Seq > s.flushedSeq {
+			e.queuedAt = time.Now()
+			s.enqueueLocked(&e)
+		}
+	}
+	if err := f.Truncate(good); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("truncate effect wal: %w", err)
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("seek effect wal: %w", err)
+	}
+	s.file = f
+	s.offset = good
+	return nil
+}
+
+func (s *BufferedEffectStore) enqueueLocked(e *bufferedEffect) {
+	s.pending = append(s.pending, e)
+	for _, k := range effectKeys(e.Record) {
+		s.index[k] = e
+	}
+}
+
+func effectKeys(r *EffectRecord) []string {
+	var keys []string
+	if r.IdempotencyKey != "" {
+		keys = append(keys, keyID(r.JobID, r.IdempotencyKey))
+	}
+	if r.CommandID != "" {
+		keys = append(keys, keyCmd(r.JobID, r.CommandID))
+	}
+	return keys
+}
+
+// PutEffect 实现 EffectStore：写入本地 WAL（fsync）后返回；未刷出条数达到 MaxPending 时阻塞直到腾出空间或 ctx 结束
+func (s *BufferedEffectStore) PutEffect(ctx context.Context, r *EffectRecord) error {
+	if r == nil || r.JobID == "" {
+		return nil
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		metrics.EffectBufferBackpressureTotal.Inc()
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stop:
+			return ErrEffectBufferClosed
+		}
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		<-s.slots
+		return ErrEffectBufferClosed
+	}
+	e := &bufferedEffect{Seq: s.seq + 1, Record: copyRecord(r), queuedAt: time.Now()}
+	if err := s.appendLocked(e); err != nil {
+		s.mu.Unlock()
+		<-s.slots
+		return err
+	}
+	s.seq = e.Seq
+	s.enqueueLocked(e)
+	s.mu.Unlock()
+
+	s.updateGauges()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// appendLocked 追加一行到 WAL 并 fsync；失败时截回原长度，保证 WAL 只含完整记录
+func (s *BufferedEffectStore) appendLocked(e *bufferedEffect) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		s.rollbackLocked()
+		return fmt.Errorf("write effect wal: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.rollbackLocked()
+		return fmt.Errorf("sync effect wal: %w", err)
+	}
+	s.offset += int64(len(line))
+	return nil
+}
+
+func (s *BufferedEffectStore) rollbackLocked() {
+	_ = s.file.Truncate(s.offset)
+	_, _ = s.file.Seek(s.offset, io.SeekStart)
+}
+
+// GetEffectByJobAndIdempotencyKey 实现 EffectStore：优先返回本地未刷出的记录
+func (s *BufferedEffectStore) GetEffectByJobAndIdempotencyKey(ctx context.Context, jobID, idempotencyKey string) (*EffectRecord, error) {
+	if jobID == "" || idempotencyKey == "" {
+		return nil, nil
+	}
+	if r := s.lookup(keyID(jobID, idempotencyKey)); r != nil {
+		return r, nil
+	}
+	return s.next.GetEffectByJobAndIdempotencyKey(ctx, jobID, idempotencyKey)
+}
+
+// GetEffectByJobAndCommandID 实现 EffectStore：优先返回本地未刷出的记录
+func (s *BufferedEffectStore) GetEffectByJobAndCommandID(ctx context.Context, jobID, commandID string) (*EffectRecord, error) {
+	if jobID == "" || commandID == "" {
+		return nil, nil
+	}
+	if r := s.lookup(keyCmd(jobID, commandID)); r != nil {
+		return r, nil
+	}
+	return s.next.GetEffectByJobAndCommandID(ctx, jobID, commandID)
+}
+
+func (s *BufferedEffectStore) lookup(key string) *EffectRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.index[key]; ok {
+		return copyRecord(e.Record)
+	}
+	return nil
+}
+
+// Pending 返回尚未刷入下游的记录数
+func (s *BufferedEffectStore) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// FlushLag 返回最早一条未刷出记录的排队时长；队列为空时为 0
+func (s *BufferedEffectStore) FlushLag() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return 0
+	}
+	return time.Since(s.pending[0].queuedAt)
+}
+
+func (s *BufferedEffectStore) updateGauges() {
+	metrics.EffectBufferPending.Set(float64(s.Pending()))
+	metrics.EffectBufferFlushLagSeconds.Set(s.FlushLag().Seconds())
+}
+
+func (s *BufferedEffectStore) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+		_ = s.flush(context.Background())
+	}
+}
+
+// flush 按写入顺序逐条刷入下游；遇错即停（保持严格顺序），由下一轮重试
+func (s *BufferedEffectStore) flush(ctx context.Context) error {
+	defer s.updateGauges()
+	flushed := 0
+	defer func() {
+		if flushed > 0 {
+			s.checkpoint()
+		}
+	}()
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		e := s.pending[0]
+		s.mu.Unlock()
+
+		if err := s.next.PutEffect(ctx, copyRecord(e.Record)); err != nil {
+			metrics.EffectBufferFlushErrorsTotal.Inc()
+			return err
+		}
+		metrics.EffectBufferFlushDelaySeconds.Observe(time.Since(e.queuedAt).Seconds())
+
+		s.mu.Lock()
+		s.pending = s.pending[1:]
+		for _, k := range effectKeys(e.Record) {
+			if s.index[k] == e {
+				delete(s.index, k)
+			}
+		}
+		s.flushedSeq = e.Seq
+		s.mu.Unlock()
+		<-s.slots
+		flushed++
+	}
+}
+
+// checkpoint 持久化已刷出的最大序号（写临时文件后 rename）；队列为空且 WAL 过大时截断 WAL
+func (s *BufferedEffectStore) checkpoint() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := filepath.Join(s.dir, effectCheckpointFile+".tmp")
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(s.flushedSeq, 10)), 0o644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, effectCheckpointFile)); err != nil {
+		return
+	}
+	if len(s.pending) == 0 && s.offset > effectWALCompactBytes && !s.closed {
+		if err := s.file.Truncate(0); err == nil {
+			_, _ = s.file.Seek(0, io.SeekStart)
+			s.offset = 0
+		}
+	}
+}
+
+// Close 停止后台刷写并在 ctx 截止前尽量刷完剩余记录；未刷完的记录保留在本地 WAL，下次启动时恢复
+func (s *BufferedEffectStore) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.stop)
+	<-s.done
+
+	var lastErr error
+	for s.Pending() > 0 {
+		if lastErr = s.flush(ctx); lastErr == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			lastErr = fmt.Errorf("effect buffer closed with %d pending: %w", s.Pending(), ctx.Err())
+		case <-time.After(s.flushInterval):
+			continue
+		}
+		break
+	}
+	s.mu.Lock()
+	errClose := s.file.Close()
+	s.mu.Unlock()
+	if lastErr != nil {
+		return lastErr
+	}
+	return errClose
+}
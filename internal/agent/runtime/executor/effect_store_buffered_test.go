@@ -0,0 +1,175 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedEffectStore 下游 EffectStore：按顺序记录写入；fail 为 true 时写入失败，gate 非 nil 时写入阻塞直到关闭
+type gatedEffectStore struct {
+	*EffectStoreMem
+	mu    sync.Mutex
+	order []string
+	fail  bool
+	gate  chan struct{}
+}
+
+func newGatedEffectStore() *gatedEffectStore {
+	return &gatedEffectStore{EffectStoreMem: NewEffectStoreMem()}
+}
+
+func (g *gatedEffectStore) PutEffect(ctx context.Context, r *EffectRecord) error {
+	g.mu.Lock()
+	fail, gate := g.fail, g.gate
+	g.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+	if fail {
+		return errors.New("downstream unavailable")
+	}
+	g.mu.Lock()
+	g.order = append(g.order, r.IdempotencyKey)
+	g.mu.Unlock()
+	return g.EffectStoreMem.PutEffect(ctx, r)
+}
+
+func (g *gatedEffectStore) flushed() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.order...)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBufferedEffectStore_ReadsLocalThenFlushesInOrder(t *testing.T) {
+	ctx := context.Background()
+	down := newGatedEffectStore()
+	down.gate = make(chan struct{})
+	s, err := NewBufferedEffectStore(down, BufferedEffectStoreConfig{Dir: t.TempDir(), FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewBufferedEffectStore: %v", err)
+	}
+	defer s.Close(ctx)
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		if err := s.PutEffect(ctx, &EffectRecord{JobID: "j1", IdempotencyKey: key, CommandID: "c-" + key, Kind: EffectKindTool, Output: []byte(key)}); err != nil {
+			t.Fatalf("PutEffect: %v", err)
+		}
+	}
+	// 下游尚未写入时，catch-up 读取仍命中本地缓冲
+	rec, err := s.GetEffectByJobAndCommandID(ctx, "j1", "c-k2")
+	if err != nil || rec == nil || string(rec.Output) != "k2" {
+		t.Fatalf("expected local record for c-k2, got %+v err=%v", rec, err)
+	}
+
+	close(down.gate)
+	waitFor(t, func() bool { return s.Pending() == 0 })
+	got := down.flushed()
+	if len(got) != 3 || got[0] != "k1" || got[1] != "k2" || got[2] != "k3" {
+		t.Fatalf("flush order = %v, want [k1 k2 k3]", got)
+	}
+	if rec, _ := down.GetEffectByJobAndIdempotencyKey(ctx, "j1", "k3"); rec == nil {
+		t.Fatal("expected k3 in downstream store")
+	}
+}
+
+func TestBufferedEffectStore_RecoversPendingAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	down := newGatedEffectStore()
+	down.fail = true
+	s, err := NewBufferedEffectStore(down, BufferedEffectStoreConfig{Dir: dir, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewBufferedEffectStore: %v", err)
+	}
+	for _, key := range []string{"k1", "k2"} {
+		if err := s.PutEffect(ctx, &EffectRecord{JobID: "j1", IdempotencyKey: key, Kind: EffectKindLLM}); err != nil {
+			t.Fatalf("PutEffect: %v", err)
+		}
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.Close(cancelled); err == nil {
+		t.Fatal("expected Close to report unflushed records")
+	}
+	// 模拟写入中途崩溃留下的半行
+	f, err := os.OpenFile(filepath.Join(dir, effectWALFile), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open wal: %v", err)
+	}
+	_, _ = f.WriteString(`{"seq":3,"record":{"JobID":"j1"`)
+	_ = f.Close()
+
+	down2 := newGatedEffectStore()
+	s2, err := NewBufferedEffectStore(down2, BufferedEffectStoreConfig{Dir: dir, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s2.Close(ctx)
+	waitFor(t, func() bool { return s2.Pending() == 0 })
+	if got := down2.flushed(); len(got) != 2 || got[0] != "k1" || got[1] != "k2" {
+		t.Fatalf("recovered flush = %v, want [k1 k2]", got)
+	}
+	// 新写入的序号接在恢复的记录之后，且 WAL 中的半行已被截掉
+	if err := s2.PutEffect(ctx, &EffectRecord{JobID: "j1", IdempotencyKey: "k3"}); err != nil {
+		t.Fatalf("PutEffect after recovery: %v", err)
+	}
+	waitFor(t, func() bool { return len(down2.flushed()) == 3 })
+}
+
+func TestBufferedEffectStore_BackPressure(t *testing.T) {
+	ctx := context.Background()
+	down := newGatedEffectStore()
+	down.gate = make(chan struct{})
+	s, err := NewBufferedEffectStore(down, BufferedEffectStoreConfig{Dir: t.TempDir(), MaxPending: 1, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewBufferedEffectStore: %v", err)
+	}
+	if err := s.PutEffect(ctx, &EffectRecord{JobID: "j1", IdempotencyKey: "k1"}); err != nil {
+		t.Fatalf("PutEffect: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := s.PutEffect(short, &EffectRecord{JobID: "j1", IdempotencyKey: "k2"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected back-pressure timeout, got %v", err)
+	}
+	close(down.gate)
+	if err := s.PutEffect(ctx, &EffectRecord{JobID: "j1", IdempotencyKey: "k2"}); err != nil {
+		t.Fatalf("PutEffect after drain: %v", err)
+	}
+	if err := s.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := down.flushed(); len(got) != 2 {
+		t.Fatalf("flushed = %v, want 2 records", got)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rag-platform/internal/runtime/egress"
+	"rag-platform/pkg/agent/sdk"
+)
+
+// httpToolExec 经注入的 sdk.HTTPClient 请求 url，拨号错误写入结果而不返回 error（模拟吞错的工具）
+type httpToolExec struct {
+	url   string
+	calls atomic.Int32
+}
+
+func (h *httpToolExec) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	h.calls.Add(1)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	resp, err := sdk.HTTPClient(ctx).Do(req)
+	if err != nil {
+		return ToolResult{Done: true, Err: err.Error()}, nil
+	}
+	resp.Body.Close()
+	return ToolResult{Done: true, Output: "ok"}, nil
+}
+
+func TestToolNodeAdapter_EgressPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	policies, err := egress.NewPolicies(nil, map[string]egress.TenantPolicy{
+		"trusted":  {Trusted: true},
+		"loopback": {Allow: []string{"127.0.0.0/8"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := &httpToolExec{url: srv.URL}
+	adapter := &ToolNodeAdapter{
+		Tools:        tools,
+		Egress:       policies,
+		EgressClient: egress.NewClient(5 * time.Second),
+		RetryPolicy:  &RetryPolicy{MaxRetries: 2},
+	}
+	run := func(tenant string) error {
+		ctx := WithTenantID(WithJobID(context.Background(), "job-"+tenant), tenant)
+		_, err := adapter.runNode(ctx, "n1", "fetch", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}})
+		return err
+	}
+
+	for _, tenant := range []string{"trusted", "loopback"} {
+		if err := run(tenant); err != nil {
+			t.Fatalf("tenant %s: %v", tenant, err)
+		}
+	}
+
+	// 未配置的租户默认 deny-all：即使工具吞掉错误，该步仍以 policy_denied 永久失败且不重试
+	tools.calls.Store(0)
+	err = run("untrusted")
+	if !egress.IsPolicyDenied(err) {
+		t.Fatalf("err = %v, want policy_denied", err)
+	}
+	var sf *StepFailure
+	if !errors.As(err, &sf) || sf.Type != StepResultPermanentFailure {
+		t.Fatalf("err = %#v, want permanent StepFailure", err)
+	}
+	if got := tools.calls.Load(); got != 1 {
+		t.Fatalf("tool executed %d times, want 1", got)
+	}
+}
@@ -67,13 +67,14 @@ func canonicalizeMap(m map[string]any) map[string]any {
 
 // ToolInvocationStartedPayload tool_invocation_started 事件 payload
 type ToolInvocationStartedPayload struct {
-	InvocationID      string `json:"invocation_id"`
-	ToolName          string `json:"tool_name"`
-	ArgumentsHash     string `json:"arguments_hash,omitempty"`
-	IdempotencyKey    string `json:"idempotency_key"`
-	StartedAt         string `json:"started_at"`                    // RFC3339
-	ToolVersion       string `json:"tool_version,omitempty"`        // Tool 实现版本（用于审计与版本追踪）
-	RequestSchemaHash string `json:"request_schema_hash,omitempty"` // 输入 schema hash（用于检测 schema 漂移）
+	InvocationID      string   `json:"invocation_id"`
+	ToolName          string   `json:"tool_name"`
+	ArgumentsHash     string   `json:"arguments_hash,omitempty"`
+	IdempotencyKey    string   `json:"idempotency_key"`
+	StartedAt         string   `json:"started_at"`                    // RFC3339
+	ToolVersion       string   `json:"tool_version,omitempty"`        // Tool 实现版本（用于审计与版本追踪）
+	RequestSchemaHash string   `json:"request_schema_hash,omitempty"` // 输入 schema hash（用于检测 schema 漂移）
+	ConfigKeys        []string `json:"config_keys,omitempty"`         // 注入的 Agent 配置 key（不含 value）
 }
 
 // ToolInvocationFinishedPayload tool_invocation_finished 事件 payload
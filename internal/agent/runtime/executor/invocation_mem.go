@@ -17,6 +17,7 @@ package executor
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 // ToolInvocationStoreMem 内存实现，单进程有效；多 worker 时需用 PG 等持久化实现
@@ -70,6 +71,11 @@ func (s *ToolInvocationStoreMem) SetStarted(ctx context.Context, r *ToolInvocati
 		cp.Result = make([]byte, len(r.Result))
 		copy(cp.Result, r.Result)
 	}
+	now := time.Now()
+	if cp.CreatedAt.IsZero() {
+		cp.CreatedAt = now
+	}
+	cp.UpdatedAt = now
 	s.byKey[key] = &cp
 	return nil
 }
@@ -88,6 +94,7 @@ func (s *ToolInvocationStoreMem) SetFinished(ctx context.Context, idempotencyKey
 	copy(r.Result, result)
 	r.Committed = committed
 	r.ExternalID = externalID
+	r.UpdatedAt = time.Now()
 	return nil
 }
 
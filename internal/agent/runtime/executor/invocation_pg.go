@@ -17,6 +17,7 @@ package executor
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -105,7 +106,7 @@ func (s *ToolInvocationStorePg) ListByJobID(ctx context.Context, jobID string) (
 	for rows.Next() {
 		var r ToolInvocationRecord
 		var result []byte
-		var createdAt, updatedAt, confirmedAt interface{}
+		var createdAt, updatedAt, confirmedAt *time.Time
 		var externalID *string
 
 		err := rows.Scan(&r.InvocationID, &r.JobID, &r.StepID, &r.ToolName, &r.ArgsHash,
@@ -119,6 +120,16 @@ func (s *ToolInvocationStorePg) ListByJobID(ctx context.Context, jobID string) (
 			r.Result = make([]byte, len(result))
 			copy(r.Result, result)
 		}
+		if createdAt != nil {
+			r.CreatedAt = *createdAt
+		}
+		if updatedAt != nil {
+			r.UpdatedAt = *updatedAt
+		}
+		r.ConfirmedAt = confirmedAt
+		if externalID != nil {
+			r.ExternalID = *externalID
+		}
 
 		records = append(records, r)
 	}
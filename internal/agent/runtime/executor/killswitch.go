@@ -0,0 +1,97 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ToolKillSwitch 全局工具熔断（POST /api/admin/killswitch）：工具任一类别处于熔断时该步不执行并永久失败（fail closed）
+type ToolKillSwitch interface {
+	// BlockedCategory 返回 categories 中处于熔断状态的类别及原因；均未熔断时 blocked=false
+	BlockedCategory(ctx context.Context, categories []string) (category, reason string, blocked bool)
+}
+
+// KillSwitchActiveError 工具因所属类别被熔断而拒绝执行（或执行中被中止）
+type KillSwitchActiveError struct {
+	ToolName string
+	Category string
+	Reason   string
+}
+
+func (e *KillSwitchActiveError) Error() string {
+	msg := fmt.Sprintf("killswitch_active: tool %s blocked, category %s is disabled", e.ToolName, e.Category)
+	if e.Reason != "" {
+		msg += " (" + e.Reason + ")"
+	}
+	return msg
+}
+
+// IsKillSwitchActive 判断错误是否由全局工具熔断导致
+func IsKillSwitchActive(err error) bool {
+	var e *KillSwitchActiveError
+	return errors.As(err, &e)
+}
+
+// killSwitchPollInterval 工具执行期间复查熔断状态的间隔
+var killSwitchPollInterval = time.Second
+
+// watchKillSwitch 在工具执行期间周期复查熔断；类别被熔断时取消返回的 ctx，tripped 返回触发的熔断（未触发为 nil）。
+// 调用方执行完成后必须调用 stop
+func watchKillSwitch(ctx context.Context, ks ToolKillSwitch, toolName string, categories []string) (watched context.Context, tripped func() *KillSwitchActiveError, stop func()) {
+	watched, cancel := context.WithCancel(ctx)
+	var (
+		mu  sync.Mutex
+		hit *KillSwitchActiveError
+	)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(killSwitchPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-watched.Done():
+				return
+			case <-ticker.C:
+				if category, reason, blocked := ks.BlockedCategory(watched, categories); blocked {
+					mu.Lock()
+					hit = &KillSwitchActiveError{ToolName: toolName, Category: category, Reason: reason}
+					mu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	tripped = func() *KillSwitchActiveError {
+		mu.Lock()
+		defer mu.Unlock()
+		return hit
+	}
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+	return watched, tripped, stop
+}
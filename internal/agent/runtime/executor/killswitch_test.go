@@ -0,0 +1,120 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type staticKillSwitch struct {
+	mu      sync.Mutex
+	blocked map[string]string // category -> reason
+}
+
+func (k *staticKillSwitch) set(category, reason string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.blocked == nil {
+		k.blocked = make(map[string]string)
+	}
+	k.blocked[category] = reason
+}
+
+func (k *staticKillSwitch) BlockedCategory(_ context.Context, categories []string) (string, string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, c := range categories {
+		if reason, ok := k.blocked[c]; ok {
+			return c, reason, true
+		}
+	}
+	return "", "", false
+}
+
+// blockingToolExec 阻塞直到 ctx 取消
+type blockingToolExec struct {
+	started chan struct{}
+}
+
+func (b *blockingToolExec) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	close(b.started)
+	<-ctx.Done()
+	return ToolResult{}, ctx.Err()
+}
+
+func TestToolNodeAdapter_KillSwitchBlocksBeforeExecute(t *testing.T) {
+	tools := &sequenceToolExec{successOut: "ok"}
+	ks := &staticKillSwitch{}
+	ks.set("payments", "incident-42")
+	adapter := &ToolNodeAdapter{
+		Tools:              tools,
+		KillSwitch:         ks,
+		ToolCategoriesFunc: func(name string) []string { return map[string][]string{"charge": {"payments"}}[name] },
+	}
+
+	_, err := adapter.runNode(context.Background(), "n1", "charge", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}})
+	if !IsKillSwitchActive(err) {
+		t.Fatalf("err = %v, want killswitch_active", err)
+	}
+	var sf *StepFailure
+	if !errors.As(err, &sf) || sf.Type != StepResultPermanentFailure {
+		t.Fatalf("err = %#v, want permanent StepFailure", err)
+	}
+	if tools.Calls() != 0 {
+		t.Fatalf("tool executed %d times while category disabled", tools.Calls())
+	}
+
+	// 未归类的工具不受影响
+	if _, err := adapter.runNode(context.Background(), "n2", "search", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}}); err != nil {
+		t.Fatalf("uncategorized tool: %v", err)
+	}
+}
+
+func TestToolNodeAdapter_KillSwitchCancelsRunningTool(t *testing.T) {
+	prev := killSwitchPollInterval
+	killSwitchPollInterval = 10 * time.Millisecond
+	defer func() { killSwitchPollInterval = prev }()
+
+	tools := &blockingToolExec{started: make(chan struct{})}
+	ks := &staticKillSwitch{}
+	adapter := &ToolNodeAdapter{
+		Tools:              tools,
+		KillSwitch:         ks,
+		ToolCategoriesFunc: func(string) []string { return []string{"code-exec"} },
+		RetryPolicy:        &RetryPolicy{MaxRetries: 3},
+	}
+	go func() {
+		<-tools.started
+		ks.set("code-exec", "")
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := adapter.runNode(context.Background(), "n1", "python.run", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !IsKillSwitchActive(err) {
+			t.Fatalf("err = %v, want killswitch_active", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("running tool was not cancelled by kill switch")
+	}
+}
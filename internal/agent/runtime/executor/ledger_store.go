@@ -17,7 +17,7 @@ package executor
 import (
 	"context"
 
-	"github.com/google/uuid"
+	"rag-platform/pkg/idgen"
 )
 
 // AttemptValidator 校验当前 writer 是否仍为该 job 的租约持有者；用于 Ledger Commit 等写操作的 Lease fencing（design/scheduler-correctness.md）
@@ -65,7 +65,7 @@ func (l *ledgerStore) Acquire(ctx context.Context, jobID, stepID, toolName, args
 	if rec != nil && !rec.Committed {
 		return InvocationDecisionWaitOtherWorker, nil, nil
 	}
-	invocationID := uuid.New().String()
+	invocationID := idgen.NewID()
 	r := &ToolInvocationRecord{
 		InvocationID:   invocationID,
 		JobID:          jobID,
@@ -0,0 +1,94 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LLMRoute llm 节点声明的模型要求（Config: quality, max_latency, prefer）；由实现 LLMRoutedGen 的应用层按要求选择模型
+type LLMRoute struct {
+	// Quality low | standard | high；空为不限
+	Quality string
+	// MaxLatency 期望的单次调用延迟上限；0 为不限
+	MaxLatency time.Duration
+	// Prefer 满足要求的候选中优先 cost（默认）或 latency
+	Prefer string
+}
+
+// LLMRoutedGen 可选接口：按节点声明的要求选择模型后生成；返回的 LLMModelInfo.Routing 为路由决策（所选模型与理由）
+type LLMRoutedGen interface {
+	GenerateRouted(ctx context.Context, prompt string, route LLMRoute) (string, LLMModelInfo, error)
+}
+
+// llmRouteFromConfig 解析 llm 节点的路由要求；未声明任何要求时返回 nil
+func llmRouteFromConfig(cfg map[string]any) (*LLMRoute, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var r LLMRoute
+	if v, ok := cfg["quality"].(string); ok {
+		switch q := strings.ToLower(strings.TrimSpace(v)); q {
+		case "", "low", "standard", "high":
+			r.Quality = q
+		default:
+			return nil, fmt.Errorf("config.quality 无效: %q（应为 low、standard 或 high）", v)
+		}
+	}
+	if v, ok := cfg["max_latency"].(string); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("config.max_latency 无效: %q", v)
+		}
+		r.MaxLatency = d
+	}
+	if v, ok := cfg["prefer"].(string); ok {
+		switch v {
+		case "", "cost", "latency":
+			r.Prefer = v
+		default:
+			return nil, fmt.Errorf("config.prefer 无效: %q（应为 cost 或 latency）", v)
+		}
+	}
+	if r == (LLMRoute{}) {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// generateForNode 节点声明了路由要求且 gen 实现 LLMRoutedGen 时按要求路由，否则按默认模型生成
+func generateForNode(ctx context.Context, gen LLMGen, prompt string, route *LLMRoute) (string, LLMModelInfo, error) {
+	rg, ok := gen.(LLMRoutedGen)
+	if route == nil || !ok {
+		return generateServed(ctx, gen, prompt)
+	}
+	resp, served, err := rg.GenerateRouted(ctx, prompt, *route)
+	if err != nil {
+		return "", LLMModelInfo{}, err
+	}
+	info := resolveLLMModelInfo(ctx, gen)
+	if served.Model != "" {
+		info.Model = served.Model
+	}
+	if served.Provider != "" {
+		info.Provider = served.Provider
+	}
+	info.FailoverFrom = served.FailoverFrom
+	info.Routing = served.Routing
+	return resp, info, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type fakeLLMGenRouted struct {
+	fakeLLMGenWithMeta
+	got *LLMRoute
+}
+
+func (f *fakeLLMGenRouted) GenerateRouted(ctx context.Context, prompt string, route LLMRoute) (string, LLMModelInfo, error) {
+	f.got = &route
+	return "short", LLMModelInfo{Model: "gpt-4o-mini", Provider: "openai", Routing: json.RawMessage(`{"model":"gpt-4o-mini","rationale":"cheapest"}`)}, nil
+}
+
+func TestLLMRouteFromConfig(t *testing.T) {
+	r, err := llmRouteFromConfig(map[string]any{"quality": "low", "max_latency": "2s", "prefer": "latency"})
+	if err != nil || r == nil || r.Quality != "low" || r.MaxLatency != 2*time.Second || r.Prefer != "latency" {
+		t.Fatalf("route = %+v, err = %v", r, err)
+	}
+	if r, err := llmRouteFromConfig(map[string]any{"goal": "x"}); r != nil || err != nil {
+		t.Fatalf("no requirement: %+v %v", r, err)
+	}
+	for _, cfg := range []map[string]any{{"quality": "ultra"}, {"max_latency": "soon"}, {"prefer": "vibes"}} {
+		if _, err := llmRouteFromConfig(cfg); err == nil {
+			t.Errorf("expected error for %v", cfg)
+		}
+	}
+}
+
+func TestGenerateForNode_Routed(t *testing.T) {
+	gen := &fakeLLMGenRouted{}
+	resp, info, err := generateForNode(context.Background(), gen, "p", &LLMRoute{Quality: "low"})
+	if err != nil || resp != "short" || gen.got == nil || gen.got.Quality != "low" {
+		t.Fatalf("generateForNode: %q %+v %v", resp, gen.got, err)
+	}
+	if info.Model != "gpt-4o-mini" || info.Temperature != 0.2 || len(info.Routing) == 0 {
+		t.Fatalf("info = %+v", info)
+	}
+	if d := llmDecision(info); d["routing"] == nil {
+		t.Fatalf("decision missing routing: %+v", d)
+	}
+
+	// 未声明要求时不路由
+	gen.got = nil
+	if _, _, err := generateForNode(context.Background(), gen, "p", nil); err != nil || gen.got != nil {
+		t.Fatalf("unrouted call went through GenerateRouted: %+v %v", gen.got, err)
+	}
+}
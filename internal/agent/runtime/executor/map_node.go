@@ -0,0 +1,296 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// MapIterationEventSink 可选：CommandEventSink 实现时，map 节点每项每次尝试的开始与结束写入 map_iteration_started / map_iteration_finished
+type MapIterationEventSink interface {
+	AppendMapIterationStarted(ctx context.Context, jobID string, pl *jobstore.MapIterationPayload) error
+	AppendMapIterationFinished(ctx context.Context, jobID string, pl *jobstore.MapIterationPayload) error
+}
+
+// MapNodeAdapter map 节点适配器：对 items 集合逐项执行 body 子图，body 节点经 Compiler 中已注册的适配器运行。
+// 每项的 body 节点以 "<map>[<key>].<节点>" 为 ID 执行，工具幂等键与 LLM Effect 均按项区分，重跑时已完成的项直接取回记录结果
+type MapNodeAdapter struct {
+	Compiler  *Compiler
+	EventSink MapIterationEventSink
+}
+
+func (a *MapNodeAdapter) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, err := a.ToNodeRunner(task, agent)
+	if err != nil {
+		return nil, err
+	}
+	return compose.InvokableLambda(compose.InvokeWOOpt[*AgentDAGPayload, *AgentDAGPayload](run)), nil
+}
+
+func (a *MapNodeAdapter) ToNodeRunner(task *planner.TaskNode, agent *runtime.Agent) (NodeRunner, error) {
+	if a.Compiler == nil {
+		return nil, fmt.Errorf("MapNodeAdapter: Compiler not configured")
+	}
+	cfg, err := planner.ParseMapConfig(task, nil)
+	if err != nil {
+		return nil, err
+	}
+	order, err := TopoOrder(cfg.Body)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*planner.TaskNode, len(cfg.Body.Nodes))
+	for i := range cfg.Body.Nodes {
+		n := &cfg.Body.Nodes[i]
+		if _, ok := a.Compiler.Adapter(n.Type); !ok {
+			return nil, fmt.Errorf("executor: map 节点 %s 的 body 节点 %s 类型 %q 未注册", task.ID, n.ID, n.Type)
+		}
+		nodes[n.ID] = n
+	}
+	m := &mapRun{adapter: a, task: task, cfg: cfg, order: order, nodes: nodes, agent: agent}
+	return m.run, nil
+}
+
+// mapRun 单个 map 节点的执行
+type mapRun struct {
+	adapter *MapNodeAdapter
+	task    *planner.TaskNode
+	cfg     *planner.MapConfig
+	order   []string
+	nodes   map[string]*planner.TaskNode
+	agent   *runtime.Agent
+	eventMu sync.Mutex // 并行迭代的事件串行写入，避免事件流版本冲突
+}
+
+// mapItemResult 单项汇总结果
+type mapItemResult struct {
+	Index  int    `json:"index"`
+	Key    string `json:"key"`
+	Status string `json:"status"` // ok | failed
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	err    error
+}
+
+func (m *mapRun) run(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+	if p.Results == nil {
+		p.Results = make(map[string]any)
+	}
+	items, err := m.cfg.ItemsOf(p.Results)
+	if err != nil {
+		return nil, &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("map 节点 %s: %w", m.task.ID, err), NodeID: m.task.ID}
+	}
+	if len(items) > m.cfg.MaxIterations {
+		return nil, &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("map 节点 %s: %d items exceed max_iterations %d", m.task.ID, len(items), m.cfg.MaxIterations), NodeID: m.task.ID}
+	}
+	keys := make([]string, len(items))
+	seen := make(map[string]int, len(items))
+	for i, item := range items {
+		key, err := m.cfg.KeyOf(item, i)
+		if err != nil {
+			return nil, &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("map 节点 %s: %w", m.task.ID, err), NodeID: m.task.ID}
+		}
+		if j, dup := seen[key]; dup {
+			return nil, &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("map 节点 %s: items %d and %d share key %q", m.task.ID, j, i, key), NodeID: m.task.ID}
+		}
+		seen[key] = i
+		keys[i] = key
+	}
+
+	outer := make(map[string]any, len(p.Results))
+	for k, v := range p.Results {
+		outer[k] = v
+	}
+	results := make([]mapItemResult, len(items))
+	var mu sync.Mutex
+	stop := false // 不允许部分失败时，有项失败后不再开始新的项
+	sem := make(chan struct{}, m.cfg.Parallelism)
+	var wg sync.WaitGroup
+	for i := range items {
+		sem <- struct{}{}
+		mu.Lock()
+		halted := stop
+		mu.Unlock()
+		if halted {
+			<-sem
+			results[i] = mapItemResult{Index: i, Key: keys[i], Status: "failed", Error: "not started: an earlier item failed"}
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			res := m.runItem(ctx, p, outer, items[i], i, keys[i])
+			mu.Lock()
+			results[i] = res
+			if res.err != nil && !m.cfg.AllowPartial {
+				stop = true
+			}
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	summary := make([]any, len(results))
+	succeeded, failed := 0, 0
+	var firstErr *mapItemResult
+	for i := range results {
+		if results[i].Status == "ok" {
+			succeeded++
+		} else {
+			failed++
+			if firstErr == nil && results[i].err != nil {
+				firstErr = &results[i]
+			}
+		}
+		summary[i] = results[i]
+	}
+	// 经 JSON 往返规范化，与 checkpoint 恢复后的形态一致
+	aggregated := map[string]any{"items": summary, "count": len(items), "succeeded": succeeded, "failed": failed}
+	if b, err := json.Marshal(aggregated); err == nil {
+		var normalized map[string]any
+		if json.Unmarshal(b, &normalized) == nil {
+			p.Results[m.task.ID] = normalized
+		}
+	}
+	if failed > 0 && !m.cfg.AllowPartial && firstErr != nil {
+		rt, _ := ClassifyError(firstErr.err)
+		return p, &StepFailure{Type: rt, Inner: fmt.Errorf("map 节点 %s: item %d (%s) failed: %w", m.task.ID, firstErr.Index, firstErr.Key, firstErr.err), NodeID: m.task.ID}
+	}
+	return p, nil
+}
+
+// runItem 执行一项；失败时最多重试 max_retries 次，重试从失败的 body 节点继续，已成功的节点不重跑
+func (m *mapRun) runItem(ctx context.Context, p *AgentDAGPayload, outer map[string]any, item any, index int, key string) mapItemResult {
+	iterID := fmt.Sprintf("%s[%s]", m.task.ID, key)
+	local := make(map[string]any)
+	nodeAttempts := make(map[string]int)
+	var err error
+	for attempt := 0; attempt <= m.cfg.MaxRetries; attempt++ {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		pl := &jobstore.MapIterationPayload{NodeID: m.task.ID, Index: index, Key: iterID, Attempt: attempt}
+		m.emit(ctx, pl, false)
+		start := time.Now()
+		err = m.runBody(ctx, p, outer, local, nodeAttempts, item, index, iterID)
+		done := *pl
+		done.DurationMs = time.Since(start).Milliseconds()
+		if err == nil {
+			done.Status = "ok"
+			done.Result, _ = json.Marshal(m.itemResult(local))
+			m.emit(ctx, &done, true)
+			return mapItemResult{Index: index, Key: key, Status: "ok", Result: m.itemResult(local)}
+		}
+		done.Status, done.Error = "failed", err.Error()
+		m.emit(ctx, &done, true)
+	}
+	return mapItemResult{Index: index, Key: key, Status: "failed", Error: err.Error(), err: err}
+}
+
+// runBody 按拓扑序执行 body；每个节点看到外层结果、本项已完成节点的结果（按原 ID）与 Results[<map>]={"item","index","key"}
+func (m *mapRun) runBody(ctx context.Context, p *AgentDAGPayload, outer, local map[string]any, nodeAttempts map[string]int, item any, index int, iterID string) error {
+	baseStepID := ExecutionStepIDFromContext(ctx)
+	if baseStepID == "" {
+		baseStepID = m.task.ID
+	}
+	for _, id := range m.order {
+		if _, done := local[id]; done {
+			continue
+		}
+		scoped := make(map[string]any, len(outer)+len(local)+1)
+		for k, v := range outer {
+			scoped[k] = v
+		}
+		for k, v := range local {
+			scoped[k] = v
+		}
+		scoped[m.task.ID] = map[string]any{"item": item, "index": index, "key": iterID}
+		if m.cfg.Body.SkippedNodes(scoped)[id] {
+			local[id] = branchSkippedResult()
+			continue
+		}
+		node := *m.nodes[id]
+		conf, err := planner.MapItemConfig(node.Config, item, index)
+		if err != nil {
+			return &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("body 节点 %s: %w", id, err), NodeID: id}
+		}
+		node.ID, node.Config = iterID+"."+id, conf
+		adapter, _ := m.adapter.Compiler.Adapter(node.Type)
+		run, err := adapter.ToNodeRunner(&node, m.agent)
+		if err != nil {
+			return &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("body 节点 %s: %w", id, err), NodeID: id}
+		}
+		// 执行 ID 决定工具幂等键：首次尝试稳定为 <步>/<map>[<key>].<节点>，该节点重试时追加 #n，避免命中失败尝试的账本记录
+		stepID := baseStepID + "/" + node.ID
+		if n := nodeAttempts[id]; n > 0 {
+			stepID += fmt.Sprintf("#%d", n)
+		}
+		nodeAttempts[id]++
+		out, err := run(WithExecutionStepID(ctx, stepID), &AgentDAGPayload{Goal: p.Goal, AgentID: p.AgentID, SessionID: p.SessionID, Results: scoped})
+		if err != nil {
+			return fmt.Errorf("body 节点 %s: %w", id, err)
+		}
+		if out != nil {
+			local[id] = out.Results[node.ID]
+		}
+	}
+	return nil
+}
+
+// itemResult 单项结果：body 只有一个末端节点时取其结果，否则为末端节点 ID → 结果
+func (m *mapRun) itemResult(local map[string]any) any {
+	hasOutgoing := make(map[string]bool)
+	for _, e := range m.cfg.Body.Edges {
+		hasOutgoing[e.From] = true
+	}
+	sinks := make(map[string]any)
+	last := ""
+	for _, id := range m.order {
+		if !hasOutgoing[id] {
+			sinks[id] = local[id]
+			last = id
+		}
+	}
+	if len(sinks) == 1 {
+		return sinks[last]
+	}
+	return sinks
+}
+
+func (m *mapRun) emit(ctx context.Context, pl *jobstore.MapIterationPayload, finished bool) {
+	sink := m.adapter.EventSink
+	jobID := JobIDFromContext(ctx)
+	if sink == nil || jobID == "" {
+		return
+	}
+	m.eventMu.Lock()
+	defer m.eventMu.Unlock()
+	if finished {
+		_ = sink.AppendMapIterationFinished(ctx, jobID, pl)
+	} else {
+		_ = sink.AppendMapIterationStarted(ctx, jobID, pl)
+	}
+}
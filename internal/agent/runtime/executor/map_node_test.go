@@ -0,0 +1,225 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// flakyNodeAdapterForTest 每个节点 ID 前 failures 次执行失败，记录执行 ID
+type flakyNodeAdapterForTest struct {
+	failures int
+	failKey  string // 非空时仅 ID 含该串的节点失败
+
+	mu      sync.Mutex
+	calls   map[string]int
+	stepIDs []string
+}
+
+func (a *flakyNodeAdapterForTest) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, _ := a.ToNodeRunner(task, agent)
+	return compose.InvokableLambda(compose.InvokeWOOpt[*AgentDAGPayload, *AgentDAGPayload](run)), nil
+}
+
+func (a *flakyNodeAdapterForTest) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		a.mu.Lock()
+		if a.calls == nil {
+			a.calls = make(map[string]int)
+		}
+		a.calls[task.ID]++
+		n := a.calls[task.ID]
+		a.stepIDs = append(a.stepIDs, ExecutionStepIDFromContext(ctx))
+		a.mu.Unlock()
+		if (a.failKey == "" || strings.Contains(task.ID, a.failKey)) && n <= a.failures {
+			return nil, &StepFailure{Type: StepResultRetryableFailure, Inner: errors.New("flaky"), NodeID: task.ID}
+		}
+		p.Results[task.ID] = map[string]any{"output": task.Config["output"]}
+		return p, nil
+	}, nil
+}
+
+type mapSinkForTest struct {
+	mu       sync.Mutex
+	started  []jobstore.MapIterationPayload
+	finished []jobstore.MapIterationPayload
+}
+
+func (s *mapSinkForTest) AppendMapIterationStarted(_ context.Context, _ string, pl *jobstore.MapIterationPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = append(s.started, *pl)
+	return nil
+}
+
+func (s *mapSinkForTest) AppendMapIterationFinished(_ context.Context, _ string, pl *jobstore.MapIterationPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = append(s.finished, *pl)
+	return nil
+}
+
+func mapNodeForTest(extra map[string]any) *planner.TaskNode {
+	cfg := map[string]any{
+		"items":    "search.output",
+		"item_key": "id",
+		"body": map[string]any{
+			"nodes": []any{
+				map[string]any{"id": "fetch", "type": "tool", "tool_name": "http.get", "config": map[string]any{"output": "${item.id}"}},
+				map[string]any{"id": "sum", "type": "llm", "config": map[string]any{"output": "sum of ${item.id}"}},
+			},
+			"edges": []any{map[string]any{"from": "fetch", "to": "sum"}},
+		},
+	}
+	for k, v := range extra {
+		cfg[k] = v
+	}
+	return &planner.TaskNode{ID: "each", Type: planner.NodeMap, Config: cfg}
+}
+
+func runMapForTest(t *testing.T, flaky *flakyNodeAdapterForTest, sink MapIterationEventSink, node *planner.TaskNode, items string) (*AgentDAGPayload, error) {
+	t.Helper()
+	compiler := NewCompiler(map[string]NodeAdapter{planner.NodeLLM: &echoNodeAdapterForTest{}, planner.NodeTool: flaky})
+	adapter := &MapNodeAdapter{Compiler: compiler, EventSink: sink}
+	compiler.Register(planner.NodeMap, adapter)
+	run, err := adapter.ToNodeRunner(node, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithExecutionStepID(WithJobID(context.Background(), "job-1"), "step-each")
+	return run(ctx, &AgentDAGPayload{Results: map[string]any{"search": map[string]any{"output": items}}})
+}
+
+func TestMapNodeAdapter_RunsEachItem(t *testing.T) {
+	flaky := &flakyNodeAdapterForTest{}
+	sink := &mapSinkForTest{}
+	p, err := runMapForTest(t, flaky, sink, mapNodeForTest(map[string]any{"parallelism": 2}), `[{"id":"a"},{"id":"b"},{"id":"c"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := p.Results["each"].(map[string]any)
+	if res["count"] != 3.0 || res["succeeded"] != 3.0 || res["failed"] != 0.0 {
+		t.Fatalf("result = %v", res)
+	}
+	items := res["items"].([]any)
+	second := items[1].(map[string]any)
+	if second["key"] != "b" || second["status"] != "ok" || second["result"].(map[string]any)["output"] != "sum of b" {
+		t.Fatalf("item 1 = %v", second)
+	}
+	sort.Strings(flaky.stepIDs)
+	want := []string{"step-each/each[a].fetch", "step-each/each[b].fetch", "step-each/each[c].fetch"}
+	if fmt.Sprint(flaky.stepIDs) != fmt.Sprint(want) {
+		t.Fatalf("step IDs = %v", flaky.stepIDs)
+	}
+	if len(sink.started) != 3 || len(sink.finished) != 3 {
+		t.Fatalf("events = %d started, %d finished", len(sink.started), len(sink.finished))
+	}
+	for _, f := range sink.finished {
+		if f.Status != "ok" || f.NodeID != "each" || len(f.Result) == 0 {
+			t.Fatalf("finished = %+v", f)
+		}
+	}
+}
+
+func TestMapNodeAdapter_RetriesFailedNode(t *testing.T) {
+	flaky := &flakyNodeAdapterForTest{failures: 1, failKey: "[b]"}
+	sink := &mapSinkForTest{}
+	p, err := runMapForTest(t, flaky, sink, mapNodeForTest(map[string]any{"max_retries": 1}), `[{"id":"a"},{"id":"b"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := p.Results["each"].(map[string]any); res["succeeded"] != 2.0 {
+		t.Fatalf("result = %v", res)
+	}
+	if flaky.calls["each[b].fetch"] != 2 || flaky.calls["each[a].fetch"] != 1 {
+		t.Fatalf("calls = %v", flaky.calls)
+	}
+	if last := flaky.stepIDs[len(flaky.stepIDs)-1]; last != "step-each/each[b].fetch#1" {
+		t.Fatalf("retry step ID = %q", last)
+	}
+	if len(sink.finished) != 3 || sink.finished[1].Status != "failed" || sink.finished[2].Attempt != 1 {
+		t.Fatalf("finished = %+v", sink.finished)
+	}
+}
+
+func TestMapNodeAdapter_ItemFailure(t *testing.T) {
+	items := `[{"id":"a"},{"id":"b"},{"id":"c"}]`
+	t.Run("fails fast", func(t *testing.T) {
+		flaky := &flakyNodeAdapterForTest{failures: 1, failKey: "[a]"}
+		p, err := runMapForTest(t, flaky, nil, mapNodeForTest(nil), items)
+		var sf *StepFailure
+		if !errors.As(err, &sf) || sf.Type != StepResultRetryableFailure || sf.NodeID != "each" {
+			t.Fatalf("err = %v", err)
+		}
+		if res := p.Results["each"].(map[string]any); res["failed"] != 3.0 {
+			t.Fatalf("result = %v", res)
+		}
+		if len(flaky.calls) != 1 {
+			t.Fatalf("later items should not start: %v", flaky.calls)
+		}
+	})
+	t.Run("allow partial", func(t *testing.T) {
+		flaky := &flakyNodeAdapterForTest{failures: 1, failKey: "[b]"}
+		p, err := runMapForTest(t, flaky, nil, mapNodeForTest(map[string]any{"allow_partial": true}), items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := p.Results["each"].(map[string]any)
+		if res["succeeded"] != 2.0 || res["failed"] != 1.0 {
+			t.Fatalf("result = %v", res)
+		}
+		if failed := res["items"].([]any)[1].(map[string]any); failed["status"] != "failed" || failed["error"] == "" {
+			t.Fatalf("item 1 = %v", failed)
+		}
+	})
+}
+
+func TestMapNodeAdapter_InvalidItems(t *testing.T) {
+	for name, tc := range map[string]struct {
+		extra map[string]any
+		items string
+		want  string
+	}{
+		"too many":      {map[string]any{"max_iterations": 2}, `[{"id":"a"},{"id":"b"},{"id":"c"}]`, "exceed max_iterations"},
+		"duplicate key": {nil, `[{"id":"a"},{"id":"a"}]`, "share key"},
+		"not a list":    {nil, `{"id":"a"}`, "each"},
+	} {
+		_, err := runMapForTest(t, &flakyNodeAdapterForTest{}, nil, mapNodeForTest(tc.extra), tc.items)
+		var sf *StepFailure
+		if !errors.As(err, &sf) || sf.Type != StepResultPermanentFailure || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestMapNodeAdapter_UnknownBodyType(t *testing.T) {
+	compiler := NewCompiler(map[string]NodeAdapter{planner.NodeLLM: &echoNodeAdapterForTest{}})
+	adapter := &MapNodeAdapter{Compiler: compiler}
+	if _, err := adapter.ToNodeRunner(mapNodeForTest(nil), nil); err == nil {
+		t.Fatal("expected error for body node type without adapter")
+	}
+}
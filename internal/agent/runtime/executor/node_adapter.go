@@ -18,14 +18,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/cloudwego/eino/compose"
-	"github.com/google/uuid"
 
 	"rag-platform/internal/agent/planner"
 	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/egress"
+	"rag-platform/pkg/agent/sdk"
+	"rag-platform/pkg/evidence"
+	"rag-platform/pkg/idgen"
 	"rag-platform/pkg/metrics"
 )
 
@@ -49,11 +53,20 @@ type LLMMetadataProvider interface {
 	ModelInfo(ctx context.Context) LLMModelInfo
 }
 
+// LLMServedGen 可选接口：生成的同时返回实际提供服务的模型（故障切换链可能不是主模型），供事件记录以保证回放可还原。
+type LLMServedGen interface {
+	GenerateServed(ctx context.Context, prompt string) (string, LLMModelInfo, error)
+}
+
 // LLMModelInfo LLM 审计元信息。
 type LLMModelInfo struct {
 	Model       string
 	Provider    string
 	Temperature float64
+	// FailoverFrom 故障切换时的主模型（provider/model）；由主模型服务时为空
+	FailoverFrom string
+	// Routing 按节点要求路由时的决策（所选模型、候选评估与理由）；未路由时为空
+	Routing json.RawMessage
 }
 
 // ToolExec 执行单工具调用（由应用层注入）；state 为再入时传入的上次状态，返回 ToolResult 支持 Done/State/Output
@@ -69,12 +82,16 @@ type ToolResult struct {
 	Err    string
 }
 
-// attachToolEvidence 在工具步的 nodeResult 上附加 _evidence（tool_invocation_ids），供 Runner 写入 reasoning_snapshot 的 Evidence Graph（design/execution-forensics.md）
+// attachToolEvidence 在工具步的 nodeResult 上附加 _evidence（tool_invocation_ids，及结果中的 citations），供 Runner 写入 reasoning_snapshot 的 Evidence Graph（design/execution-forensics.md）
 func attachToolEvidence(m map[string]any, idempotencyKey string) {
 	if m == nil || idempotencyKey == "" {
 		return
 	}
-	m["_evidence"] = map[string]interface{}{"tool_invocation_ids": []string{idempotencyKey}}
+	ev := map[string]interface{}{"tool_invocation_ids": []string{idempotencyKey}}
+	if citations, ok := m["citations"]; ok && citations != nil {
+		ev["citations"] = citations
+	}
+	m["_evidence"] = ev
 }
 
 // extractExternalIDFromToolResult 从工具返回的 output（JSON）或 state 中提取 external_id（design/effect-log-and-provenance.md）
@@ -108,6 +125,7 @@ type LLMNodeAdapter struct {
 	CommandEventSink   CommandEventSink // 可选；执行成功后立即写 command_committed，保证副作用安全
 	EffectStore        EffectStore      // 可选；非 nil 时写入完整 LLM effect（prompt+response）并 Replay 时从 store 注入不重调（design/effect-system LLM Effect Capture）
 	RequireEffectStore bool             // 生产模式下要求必须配置 EffectStore，否则返回error
+	PromptLog          PromptLogger     // 可选；非 nil 时按采样/失败留存 prompt 到对象存储，command_emitted 只记录 prompt 摘要
 }
 
 func (a *LLMNodeAdapter) runNode(ctx context.Context, taskID string, cfg map[string]any, agent *runtime.Agent, p *AgentDAGPayload) (*AgentDAGPayload, error) {
@@ -122,9 +140,15 @@ func (a *LLMNodeAdapter) runNode(ctx context.Context, taskID string, cfg map[str
 			prompt = g
 		}
 	}
+	prompt = renderScratchpadPrompt(ctx, prompt)
+	route, err := llmRouteFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("llm 节点 %s: %w", taskID, err)
+	}
 	jobID := JobIDFromContext(ctx)
-	// Replay 防御：若 Effect Store 已有该 command 的结果，直接注入不调用 LLM（Runner 层已跳过已提交命令，此处为 defence in depth）
-	if a.EffectStore != nil && jobID != "" {
+	// Replay 防御：若 Effect Store 已有该 command 的结果，直接注入不调用 LLM（Runner 层已跳过已提交命令，此处为 defence in depth）；
+	// 手动回滚后重新执行时已有结果属于被作废的执行，须重新生成
+	if a.EffectStore != nil && jobID != "" && !IsRollbackResume(ctx) {
 		eff, err := a.EffectStore.GetEffectByJobAndCommandID(ctx, jobID, taskID)
 		if err == nil && eff != nil && len(eff.Output) > 0 {
 			var resp string
@@ -142,39 +166,62 @@ func (a *LLMNodeAdapter) runNode(ctx context.Context, taskID string, cfg map[str
 				"_evidence": evidence,
 			}
 			p.Results[taskID] = resultMap
+			if err := setLLMScratchpadOutput(ctx, cfg, resp); err != nil {
+				return nil, err
+			}
 			return p, nil
 		}
 	}
 	if a.CommandEventSink != nil && jobID != "" {
 		inputBytes, _ := json.Marshal(map[string]any{"prompt": prompt})
+		if a.PromptLog != nil {
+			inputBytes = promptDigest(prompt)
+		}
 		_ = a.CommandEventSink.AppendCommandEmitted(ctx, jobID, taskID, taskID, "llm", inputBytes)
 	}
-	resp, err := a.LLM.Generate(ctx, prompt)
+	rec := PromptRecord{JobID: jobID, NodeID: taskID, TenantID: TenantIDFromContext(ctx), Prompt: prompt}
+	resp, llmInfo, err := generateForNode(ctx, a.LLM, prompt, route)
 	if err != nil {
+		info := resolveLLMModelInfo(ctx, a.LLM)
+		rec.Error, rec.Model, rec.Provider = err.Error(), info.Model, info.Provider
+		a.logPrompt(ctx, rec)
 		return nil, err
 	}
+	rec.Response, rec.Model, rec.Provider = resp, llmInfo.Model, llmInfo.Provider
+	promptRef := a.logPrompt(ctx, rec)
 	resultBytes, _ := json.Marshal(resp)
 	// LLM Effect Capture：完整写入 Effect Store 供审计与 Replay 防御（design/effect-system）
 	if a.EffectStore != nil && jobID != "" {
 		inputBytes, _ := json.Marshal(map[string]any{"prompt": prompt})
+		if a.PromptLog != nil {
+			inputBytes = promptDigest(prompt)
+		}
 		_ = a.EffectStore.PutEffect(ctx, &EffectRecord{
 			JobID:     jobID,
 			CommandID: taskID,
 			Kind:      EffectKindLLM,
 			Input:     inputBytes,
 			Output:    resultBytes,
-			Metadata:  map[string]any{}, // model/temperature 可由 LLM 实现方扩展
+			Metadata:  llmDecision(llmInfo),
 		})
 	}
 	if a.CommandEventSink != nil && jobID != "" {
-		// LLM command_committed payload 包含 model/provider/temperature 供审计与 trace（design/versioning.md）
-		llmInfo := resolveLLMModelInfo(ctx, a.LLM)
+		// LLM command_committed payload 包含实际服务的 model/provider/temperature 供审计、trace 与回放（design/versioning.md）
 		commitPayload := map[string]interface{}{
 			"result":          resp,
 			"llm_model":       llmInfo.Model,
 			"llm_provider":    llmInfo.Provider,
 			"llm_temperature": llmInfo.Temperature,
 		}
+		if llmInfo.FailoverFrom != "" {
+			commitPayload["llm_failover_from"] = llmInfo.FailoverFrom
+		}
+		if len(llmInfo.Routing) > 0 {
+			commitPayload["llm_routing"] = llmInfo.Routing
+		}
+		if promptRef != nil {
+			commitPayload["prompt_ref"] = promptRef
+		}
 		commitBytes, _ := json.Marshal(commitPayload)
 		_ = a.CommandEventSink.AppendCommandCommitted(ctx, jobID, taskID, taskID, commitBytes, "")
 	}
@@ -183,25 +230,82 @@ func (a *LLMNodeAdapter) runNode(ctx context.Context, taskID string, cfg map[str
 	}
 
 	// Evidence: LLM decision metadata for audit (design/execution-forensics.md)
-	llmInfo := resolveLLMModelInfo(ctx, a.LLM)
 	resultMap := map[string]any{
 		"output": resp,
 		"_evidence": map[string]interface{}{
-			"llm_decision": map[string]interface{}{
-				"model":       llmInfo.Model,
-				"provider":    llmInfo.Provider,
-				"temperature": llmInfo.Temperature,
-			},
+			"llm_decision": llmDecision(llmInfo),
 		},
 	}
 	p.Results[taskID] = resultMap
+	if err := setLLMScratchpadOutput(ctx, cfg, resp); err != nil {
+		return nil, err
+	}
 
+	// 审阅门：生成结果已提交，挂起等待人类通过或编辑；恢复时由 wait_completed 注入审阅后的结果，不再进入本节点
+	if llmReviewRequired(cfg) && jobID != "" {
+		key := ReviewCorrelationKey(jobID, taskID)
+		if _, approved := ApprovedCorrelationKeysFromContext(ctx)[key]; !approved {
+			return p, &SignalWaitRequired{CorrelationKey: key, Reason: "review_required", WaitKind: planner.WaitKindReview, Park: true}
+		}
+	}
 	if agent != nil && agent.Session != nil {
 		agent.Session.AddMessage("assistant", resp)
 	}
 	return p, nil
 }
 
+// ReviewCorrelationKey 返回 llm 节点审阅门的 correlation_key；POST /api/jobs/:id/nodes/:node_id/review 以此匹配 job_waiting
+func ReviewCorrelationKey(jobID, nodeID string) string {
+	return "review-" + jobID + "-" + nodeID
+}
+
+// llmReviewRequired 判断 llm 节点是否配置审阅门（Config["review"]=true）
+func llmReviewRequired(cfg map[string]any) bool {
+	if cfg == nil {
+		return false
+	}
+	v, _ := cfg["review"].(bool)
+	return v
+}
+
+// generateServed 调用 LLM 并返回实际服务的模型信息；实现 LLMServedGen 时以其返回为准，否则回退 ModelInfo
+func generateServed(ctx context.Context, gen LLMGen, prompt string) (string, LLMModelInfo, error) {
+	sg, ok := gen.(LLMServedGen)
+	if !ok {
+		resp, err := gen.Generate(ctx, prompt)
+		return resp, resolveLLMModelInfo(ctx, gen), err
+	}
+	resp, served, err := sg.GenerateServed(ctx, prompt)
+	if err != nil {
+		return "", LLMModelInfo{}, err
+	}
+	info := resolveLLMModelInfo(ctx, gen)
+	if served.Model != "" {
+		info.Model = served.Model
+	}
+	if served.Provider != "" {
+		info.Provider = served.Provider
+	}
+	info.FailoverFrom = served.FailoverFrom
+	return resp, info, nil
+}
+
+// llmDecision 返回写入 evidence / effect 元数据的 LLM 决策信息
+func llmDecision(info LLMModelInfo) map[string]interface{} {
+	d := map[string]interface{}{
+		"model":       info.Model,
+		"provider":    info.Provider,
+		"temperature": info.Temperature,
+	}
+	if info.FailoverFrom != "" {
+		d["failover_from"] = info.FailoverFrom
+	}
+	if len(info.Routing) > 0 {
+		d["routing"] = info.Routing
+	}
+	return d
+}
+
 func resolveLLMModelInfo(ctx context.Context, llm LLMGen) LLMModelInfo {
 	info := LLMModelInfo{
 		Model:       "llm-model-default",
@@ -269,6 +373,25 @@ type ToolNodeAdapter struct {
 	RetryPolicy *RetryPolicy
 	// RateLimiter 可选；Tool 执行前限流，防止打爆外部 API（2.0 Operational）
 	RateLimiter *ToolRateLimiter
+	// AgentConfig 可选；非 nil 时执行前解析 Agent 级配置并经 sdk.WithConfig 注入 ctx，tool_invocation_started 只记录 key
+	AgentConfig AgentConfigResolver
+	// KillSwitch 可选；全局工具熔断，工具任一类别熔断时该步以 killswitch_active 永久失败，执行中被熔断时取消工具 ctx
+	KillSwitch ToolKillSwitch
+	// ToolCategoriesFunc 按工具名解析其类别，供 KillSwitch 判定；未设置时不做熔断检查
+	ToolCategoriesFunc func(toolName string) []string
+	// OutputLimits 工具输出大小上限；超限输出写入 Job 工作区，结果替换为摘要 + 引用（零值不限）
+	OutputLimits ToolOutputLimits
+	// Semaphores 可选；集群级命名信号量，执行前排队获取（外部系统并发上限，跨所有 Worker 生效）
+	Semaphores *ToolSemaphores
+	// Egress 可选；租户出网白名单，执行时注入 ctx，经受限 HTTP 客户端拨号时校验，违规则该步以 policy_denied 永久失败（不重试）
+	Egress *egress.Policies
+	// EgressClient 受限 HTTP 客户端，Egress 非 nil 时经 sdk.WithHTTPClient 注入供自定义工具使用
+	EgressClient *http.Client
+}
+
+// AgentConfigResolver 解析 Agent 级配置（secret 引用已解析为明文），供工具经 sdk.ConfigFromContext 读取
+type AgentConfigResolver interface {
+	ResolveAgentConfig(ctx context.Context, agentID string) (map[string]string, error)
 }
 
 // runConfirmation 在注入前校验本步的 StateChanged；若 verifier 存在且有待校验项且任一项failed则按 ReplayVerificationMode 处理
@@ -374,6 +497,7 @@ func (a *ToolNodeAdapter) runNode(ctx context.Context, taskID, toolName string,
 				if err := a.writeCatchUpFinished(ctx, jobID, taskID, stepIDForLedger, idempotencyKey, argsHash, resultBytes); err != nil {
 					return nil, err
 				}
+				recordCatchUp(ctx, CatchUpSourceLedger)
 				var nodeResult map[string]any
 				_ = json.Unmarshal(resultBytes, &nodeResult)
 				if nodeResult == nil {
@@ -386,6 +510,15 @@ func (a *ToolNodeAdapter) runNode(ctx context.Context, taskID, toolName string,
 				p.Results[taskID] = nodeResult
 				return p, nil
 			}
+			// 步内检查点：工具在崩溃前经 sdk.CheckpointToolState 持久化过 state 时，从最近一次检查点续跑而非重新开始
+			if raw, ok := ToolProgressStatesFromContext(ctx)[idempotencyKey]; ok && len(raw) > 0 {
+				var rec *ToolInvocationRecord
+				if a.InvocationStore != nil && jobID != "" {
+					rec, _ = a.InvocationStore.GetByJobAndIdempotencyKey(ctx, jobID, idempotencyKey)
+				}
+				metrics.ToolResumesTotal.WithLabelValues(TenantIDFromContext(ctx), toolName).Inc()
+				return a.runNodeExecute(sdk.WithResumedToolState(ctx, raw), jobID, taskID, toolName, cfg, idempotencyKey, argsHash, rec, stepChanges, agent, p)
+			}
 			return nil, &StepFailure{
 				Type:   StepResultPermanentFailure,
 				Inner:  fmt.Errorf("invocation in flight or lost, idempotency_key=%s", idempotencyKey),
@@ -493,6 +626,7 @@ func (a *ToolNodeAdapter) runNode(ctx context.Context, taskID, toolName string,
 			if err := a.writeCatchUpFinished(ctx, jobID, taskID, stepIDForLedger, idempotencyKey, argsHash, eff.Output); err != nil {
 				return nil, err
 			}
+			recordCatchUp(ctx, CatchUpSourceEffectStore)
 			if a.InvocationLedger != nil {
 				_ = a.InvocationLedger.Commit(ctx, "catchup-"+idempotencyKey, idempotencyKey, eff.Output)
 			}
@@ -538,10 +672,24 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 			state = m["state"]
 		}
 	}
-	invocationID := uuid.New().String()
+	var resumed interface{}
+	if ok, _ := sdk.ResumedToolState(ctx, &resumed); ok && resumed != nil {
+		state = resumed
+	}
+	invocationID := idgen.NewID()
 	if ledgerRec != nil {
 		invocationID = ledgerRec.InvocationID
 	}
+	var configKeys []string
+	if a.AgentConfig != nil && agent != nil {
+		values, err := a.AgentConfig.ResolveAgentConfig(ctx, agent.ID)
+		if err != nil {
+			return nil, &StepFailure{Type: StepResultRetryableFailure, Inner: fmt.Errorf("resolve agent config: %w", err), NodeID: taskID}
+		}
+		agentCfg := sdk.NewConfig(values)
+		ctx = sdk.WithConfig(ctx, agentCfg)
+		configKeys = agentCfg.Keys()
+	}
 	startedAt := time.Now().UTC()
 	if a.InvocationLedger == nil && a.InvocationStore != nil && jobID != "" {
 		_ = a.InvocationStore.SetStarted(ctx, &ToolInvocationRecord{
@@ -561,6 +709,7 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 			ArgumentsHash:  argsHash,
 			IdempotencyKey: idempotencyKey,
 			StartedAt:      FormatStartedAt(startedAt),
+			ConfigKeys:     configKeys,
 		})
 	}
 	if a.CommandEventSink != nil && jobID != "" {
@@ -572,6 +721,8 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 		_ = a.ToolEventSink.AppendToolCalled(ctx, jobID, nodeIDForEvent, toolName, inputBytes)
 	}
 	ctx = WithToolExecutionKey(ctx, idempotencyKey)
+	// RAG 引用：Generator 在 ctx 的收集器中记录参与回答的片段，随工具结果持久化，Replay 时从结果恢复
+	ctx, citationCollector := evidence.WithCitationCollector(ctx)
 	// Capability 执行前校验（design/capability-policy.md）
 	if a.CapabilityPolicyChecker != nil && jobID != "" {
 		approvedKeys := ApprovedCorrelationKeysFromContext(ctx)
@@ -601,6 +752,38 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 			return nil, &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("%s", denyMsg), NodeID: taskID}
 		}
 	}
+	// 执行档位（如 conservative）要求风险类别工具审批后执行；与 capability 审批共用 correlation key
+	if profile := ExecutionProfileFromContext(ctx); profile != nil && len(profile.ApprovalCategories) > 0 && a.ToolCategoriesFunc != nil && jobID != "" {
+		if _, ok := profile.approvalCategory(a.ToolCategoriesFunc(toolName)); ok {
+			key := "cap-approval-" + idempotencyKey
+			if _, approved := ApprovedCorrelationKeysFromContext(ctx)[key]; !approved {
+				return nil, &CapabilityRequiresApproval{CorrelationKey: key}
+			}
+		}
+	}
+
+	// 全局工具熔断：执行前检查，执行期间持续复查（fail closed）
+	var categories []string
+	if a.KillSwitch != nil && a.ToolCategoriesFunc != nil {
+		categories = a.ToolCategoriesFunc(toolName)
+	}
+	if len(categories) > 0 {
+		if category, reason, blocked := a.KillSwitch.BlockedCategory(ctx, categories); blocked {
+			ksErr := &KillSwitchActiveError{ToolName: toolName, Category: category, Reason: reason}
+			metrics.ToolKillSwitchBlockedTotal.WithLabelValues(category, toolName).Inc()
+			if a.ToolEventSink != nil && jobID != "" {
+				_ = a.ToolEventSink.AppendToolInvocationFinished(ctx, jobID, nodeIDForEvent, &ToolInvocationFinishedPayload{
+					InvocationID:   invocationID,
+					IdempotencyKey: idempotencyKey,
+					Outcome:        ToolInvocationOutcomeFailure,
+					Error:          ksErr.Error(),
+					FinishedAt:     FormatStartedAt(time.Now().UTC()),
+				})
+				_ = a.ToolEventSink.AppendToolResultSummarized(ctx, jobID, nodeIDForEvent, toolName, ksErr.Error(), ksErr.Error(), false)
+			}
+			return nil, &StepFailure{Type: StepResultPermanentFailure, Inner: ksErr, NodeID: taskID}
+		}
+	}
 
 	// 2.0: Rate limiting（防止打爆外部 API）
 	if a.RateLimiter != nil {
@@ -615,8 +798,32 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 		defer a.RateLimiter.Release(toolName)
 	}
 
+	// 集群级信号量：排队超时或存储错误按可重试失败处理（与限流等待一致）
+	if a.Semaphores != nil {
+		release, err := a.Semaphores.Acquire(ctx, toolName, jobID+"/"+invocationID)
+		if err != nil {
+			return nil, &StepFailure{Type: StepResultRetryableFailure, Inner: err, NodeID: taskID}
+		}
+		defer release()
+	}
+
 	var result ToolResult
 	var err error
+	execCtx := ctx
+	killSwitchTripped := func() *KillSwitchActiveError { return nil }
+	if len(categories) > 0 {
+		var stopWatch func()
+		execCtx, killSwitchTripped, stopWatch = watchKillSwitch(ctx, a.KillSwitch, toolName, categories)
+		defer stopWatch()
+	}
+	if a.Egress != nil {
+		execCtx = egress.WithPolicy(execCtx, a.Egress.For(TenantIDFromContext(ctx)), jobID, toolName)
+		if a.EgressClient != nil {
+			execCtx = sdk.WithHTTPClient(execCtx, a.EgressClient)
+		}
+	}
+	var checkpointer *toolCheckpointer
+	execCtx, checkpointer = a.attachToolCheckpointer(execCtx, jobID, taskID, nodeIDForEvent, toolName, idempotencyKey)
 	maxAttempts := 1
 	if a.RetryPolicy != nil && a.RetryPolicy.MaxRetries > 0 {
 		maxAttempts = 1 + a.RetryPolicy.MaxRetries
@@ -625,17 +832,35 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 		if attempt > 0 && a.RetryPolicy != nil && a.RetryPolicy.Backoff > 0 {
 			time.Sleep(a.RetryPolicy.Backoff)
 		}
-		result, err = a.Tools.Execute(ctx, toolName, cfg, state)
+		toolsInFlight.Add(1)
+		result, err = a.Tools.Execute(execCtx, toolName, cfg, state)
+		toolsInFlight.Add(-1)
 		if err == nil {
 			break
 		}
+		if ksErr := killSwitchTripped(); ksErr != nil {
+			// 执行中类别被熔断：工具 ctx 已取消，不再重试
+			metrics.ToolKillSwitchBlockedTotal.WithLabelValues(ksErr.Category, toolName).Inc()
+			err = &StepFailure{Type: StepResultPermanentFailure, Inner: ksErr, NodeID: taskID}
+			break
+		}
+		if egress.Denied(execCtx) != nil {
+			break
+		}
 		if !IsRetryable(err, a.RetryPolicy) {
 			break
 		}
 		if result.State != nil {
 			state = result.State
+		} else if last, ok := checkpointer.lastState(); ok {
+			state = last
 		}
 	}
+	// 出网违规：工具可能把拨号错误写进结果而不返回 error，一律按 policy_denied 失败
+	if denied := egress.Denied(execCtx); denied != nil {
+		metrics.ToolEgressDeniedTotal.WithLabelValues(denied.Tenant, toolName).Inc()
+		err = &StepFailure{Type: StepResultPermanentFailure, Inner: denied, NodeID: taskID}
+	}
 	finishedAt := time.Now().UTC()
 	if err != nil {
 		metrics.ToolInvocationTotal.WithLabelValues("err").Inc()
@@ -672,9 +897,14 @@ func (a *ToolNodeAdapter) runNodeExecute(ctx context.Context, jobID, taskID, too
 		}
 		return nil, err
 	}
+	// 输出大小上限：在写 Effect/事件/Ledger 之前替换为摘要，Replay 注入的也是摘要
+	result.Output = a.limitToolOutput(ctx, jobID, taskID, nodeIDForEvent, toolName, idempotencyKey, result.Output)
 	nodeResult := map[string]any{
 		"done": result.Done, "state": result.State, "output": result.Output, "error": result.Err,
 	}
+	if citations := citationCollector.Citations(); len(citations) > 0 {
+		nodeResult["citations"] = citations
+	}
 	resultBytes, _ := json.Marshal(nodeResult)
 	externalID := extractExternalIDFromToolResult(result.Output, result.State)
 	// 两步提交 Phase 1：先写 Effect Store；Metadata 写入 tool_name / external_id（design/effect-log-and-provenance.md）
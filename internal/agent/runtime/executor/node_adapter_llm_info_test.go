@@ -34,3 +34,22 @@ func TestResolveLLMModelInfo_FromProvider(t *testing.T) {
 		t.Fatalf("unexpected llm info: %+v", info)
 	}
 }
+
+type fakeLLMGenServed struct{ fakeLLMGenWithMeta }
+
+func (f *fakeLLMGenServed) GenerateServed(ctx context.Context, prompt string) (string, LLMModelInfo, error) {
+	return "ok", LLMModelInfo{Model: "sonnet", Provider: "claude", FailoverFrom: "openai/gpt-test"}, nil
+}
+
+func TestGenerateServed_RecordsFailoverModel(t *testing.T) {
+	resp, info, err := generateServed(context.Background(), &fakeLLMGenServed{}, "p")
+	if err != nil || resp != "ok" {
+		t.Fatalf("generateServed: %q %v", resp, err)
+	}
+	if info.Model != "sonnet" || info.Provider != "claude" || info.FailoverFrom != "openai/gpt-test" || info.Temperature != 0.2 {
+		t.Fatalf("unexpected served info: %+v", info)
+	}
+	if d := llmDecision(info); d["failover_from"] != "openai/gpt-test" {
+		t.Fatalf("decision missing failover_from: %+v", d)
+	}
+}
@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+)
+
+func TestLLMNodeAdapter_ReviewGateParksAfterGenerate(t *testing.T) {
+	a := &LLMNodeAdapter{LLM: &fakeLLMGen{}}
+	ctx := WithJobID(context.Background(), "job-1")
+	p, err := a.runNode(ctx, "draft", map[string]any{"review": true}, nil, &AgentDAGPayload{Goal: "write email"})
+	var sw *SignalWaitRequired
+	if !errors.As(err, &sw) {
+		t.Fatalf("expected SignalWaitRequired, got %v", err)
+	}
+	if sw.CorrelationKey != ReviewCorrelationKey("job-1", "draft") || sw.WaitKind != planner.WaitKindReview || !sw.Park {
+		t.Fatalf("unexpected wait request: %+v", sw)
+	}
+	res, _ := p.Results["draft"].(map[string]any)
+	if res["output"] != "ok" {
+		t.Fatalf("draft result should be kept in payload for resumption, got %v", p.Results)
+	}
+	w, ok := signalWaitFromError(err)
+	if !ok || w.WaitKind != planner.WaitKindReview || w.Reason != "review_required" || !w.Park {
+		t.Fatalf("signalWaitFromError: %+v ok=%v", w, ok)
+	}
+}
+
+func TestLLMNodeAdapter_ReviewGateApprovedKeyPassesThrough(t *testing.T) {
+	a := &LLMNodeAdapter{LLM: &fakeLLMGen{}}
+	ctx := WithJobID(context.Background(), "job-1")
+	ctx = WithApprovedCorrelationKeys(ctx, map[string]struct{}{ReviewCorrelationKey("job-1", "draft"): {}})
+	if _, err := a.runNode(ctx, "draft", map[string]any{"review": true}, nil, &AgentDAGPayload{}); err != nil {
+		t.Fatalf("approved review gate should not wait: %v", err)
+	}
+	if _, err := a.runNode(WithJobID(context.Background(), "job-1"), "plain", nil, nil, &AgentDAGPayload{}); err != nil {
+		t.Fatalf("node without review should not wait: %v", err)
+	}
+}
+
+func TestSignalWaitFromError_Defaults(t *testing.T) {
+	w, ok := signalWaitFromError(&SignalWaitRequired{CorrelationKey: "ck"})
+	if !ok || w.Reason != "signal_wait" || w.WaitKind != "signal" || w.Park {
+		t.Fatalf("unexpected defaults: %+v ok=%v", w, ok)
+	}
+	if _, ok := signalWaitFromError(errors.New("boom")); ok {
+		t.Fatal("plain error should not be a wait")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/pkg/nodeplugin"
+)
+
+// PluginNodeAdapter 自定义节点类型适配器：按 Manifest 校验 config，执行 nodeplugin.Executor；
+// 与 workflow 节点相同写 command_emitted / command_committed，Replay 时注入已提交结果而不重执行
+type PluginNodeAdapter struct {
+	Plugin           nodeplugin.Plugin
+	CommandEventSink CommandEventSink // 可选
+}
+
+// PluginNodeAdapters 为全部已注册的自定义节点类型创建适配器；仅注册了 Manifest 的类型编译时报错（本进程未编入插件）
+func PluginNodeAdapters(sink CommandEventSink) map[string]NodeAdapter {
+	out := make(map[string]NodeAdapter)
+	for _, p := range nodeplugin.Plugins() {
+		out[p.Manifest.Type] = &PluginNodeAdapter{Plugin: p, CommandEventSink: sink}
+	}
+	return out
+}
+
+// successResultType 成功步的结果类型：tool 与声明副作用的自定义节点为 side_effect_committed，其余为 pure
+func successResultType(nodeType string) StepResultType {
+	if nodeType == planner.NodeTool {
+		return StepResultSideEffectCommitted
+	}
+	if p, ok := nodeplugin.Lookup(nodeType); ok && p.Manifest.SideEffect() {
+		return StepResultSideEffectCommitted
+	}
+	return StepResultPure
+}
+
+func (a *PluginNodeAdapter) prepare(task *planner.TaskNode) (map[string]any, error) {
+	m := a.Plugin.Manifest
+	if a.Plugin.Executor == nil {
+		return nil, fmt.Errorf("PluginNodeAdapter: 节点类型 %q 未编入本 Worker（仅注册了 Manifest）", m.Type)
+	}
+	cfg := task.Config
+	if cfg == nil {
+		cfg = make(map[string]any)
+	}
+	if err := m.ValidateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("PluginNodeAdapter: 节点 %s (%s): %w", task.ID, m.Type, err)
+	}
+	return cfg, nil
+}
+
+func (a *PluginNodeAdapter) runNode(ctx context.Context, taskID string, cfg map[string]any, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+	nodeType := a.Plugin.Manifest.Type
+	jobID := JobIDFromContext(ctx)
+	if a.CommandEventSink != nil && jobID != "" {
+		inputBytes, _ := json.Marshal(map[string]any{"type": nodeType, "config": cfg})
+		_ = a.CommandEventSink.AppendCommandEmitted(ctx, jobID, taskID, taskID, nodeType, inputBytes)
+	}
+	if p.Results == nil {
+		p.Results = make(map[string]any)
+	}
+	upstream := make(map[string]any, len(p.Results))
+	for k, v := range p.Results {
+		upstream[k] = v
+	}
+	result, err := a.Plugin.Executor.Execute(ctx, &nodeplugin.Request{
+		JobID:   jobID,
+		AgentID: p.AgentID,
+		NodeID:  taskID,
+		Type:    nodeType,
+		Goal:    p.Goal,
+		Config:  cfg,
+		Results: upstream,
+	})
+	if err != nil {
+		p.Results[taskID] = map[string]any{"error": err.Error(), "at": time.Now()}
+		return nil, err
+	}
+	if a.CommandEventSink != nil && jobID != "" {
+		resultBytes, _ := json.Marshal(result)
+		_ = a.CommandEventSink.AppendCommandCommitted(ctx, jobID, taskID, taskID, resultBytes, "")
+	}
+	p.Results[taskID] = result
+	return p, nil
+}
+
+// ToDAGNode 实现 NodeAdapter
+func (a *PluginNodeAdapter) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	cfg, err := a.prepare(task)
+	if err != nil {
+		return nil, err
+	}
+	taskID := task.ID
+	return compose.InvokableLambda[*AgentDAGPayload, *AgentDAGPayload](func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		return a.runNode(ctx, taskID, cfg, p)
+	}), nil
+}
+
+// ToNodeRunner 实现 NodeAdapter
+func (a *PluginNodeAdapter) ToNodeRunner(task *planner.TaskNode, agent *runtime.Agent) (NodeRunner, error) {
+	cfg, err := a.prepare(task)
+	if err != nil {
+		return nil, err
+	}
+	taskID := task.ID
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		return a.runNode(ctx, taskID, cfg, p)
+	}, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/pkg/nodeplugin"
+)
+
+var migrationManifest = nodeplugin.Manifest{
+	Type:   "db_migration",
+	Config: []nodeplugin.Field{{Name: "target", Type: nodeplugin.FieldString, Required: true}},
+}
+
+func TestPluginNodeAdapter_Run(t *testing.T) {
+	var got *nodeplugin.Request
+	sink := &recordingCommandSink{}
+	adapter := &PluginNodeAdapter{
+		Plugin: nodeplugin.Plugin{Manifest: migrationManifest, Executor: nodeplugin.ExecutorFunc(func(ctx context.Context, req *nodeplugin.Request) (any, error) {
+			got = req
+			return map[string]any{"applied": req.Config["target"]}, nil
+		})},
+		CommandEventSink: sink,
+	}
+	task := &planner.TaskNode{ID: "m1", Type: "db_migration", Config: map[string]any{"target": "v42"}}
+	run, err := adapter.ToNodeRunner(task, &runtime.Agent{ID: "a1"})
+	if err != nil {
+		t.Fatalf("ToNodeRunner: %v", err)
+	}
+	p := NewAgentDAGPayload("migrate", "a1", "")
+	p.Results["plan"] = "ok"
+	out, err := run(WithJobID(context.Background(), "job-1"), p)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got.JobID != "job-1" || got.NodeID != "m1" || got.Goal != "migrate" || got.Results["plan"] != "ok" {
+		t.Fatalf("request = %+v", got)
+	}
+	if res, _ := out.Results["m1"].(map[string]any); res["applied"] != "v42" {
+		t.Fatalf("results = %+v", out.Results)
+	}
+	if !strings.Contains(string(sink.emitted), `"type":"db_migration"`) || string(sink.committed) != `{"applied":"v42"}` {
+		t.Fatalf("command events: emitted=%s committed=%s", sink.emitted, sink.committed)
+	}
+}
+
+func TestPluginNodeAdapter_Errors(t *testing.T) {
+	fail := &PluginNodeAdapter{Plugin: nodeplugin.Plugin{Manifest: migrationManifest, Executor: nodeplugin.ExecutorFunc(func(ctx context.Context, req *nodeplugin.Request) (any, error) {
+		return nil, errors.New("lock timeout")
+	})}}
+	if _, err := fail.ToNodeRunner(&planner.TaskNode{ID: "m1", Type: "db_migration"}, nil); err == nil || !strings.Contains(err.Error(), "config.target is required") {
+		t.Fatalf("invalid config err = %v", err)
+	}
+	run, err := fail.ToNodeRunner(&planner.TaskNode{ID: "m1", Type: "db_migration", Config: map[string]any{"target": "v1"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewAgentDAGPayload("", "", "")
+	if _, err := run(context.Background(), p); err == nil {
+		t.Fatal("executor error not returned")
+	}
+	if res, _ := p.Results["m1"].(map[string]any); res["error"] != "lock timeout" {
+		t.Fatalf("failure result = %+v", p.Results)
+	}
+
+	manifestOnly := &PluginNodeAdapter{Plugin: nodeplugin.Plugin{Manifest: migrationManifest}}
+	if _, err := manifestOnly.ToDAGNode(&planner.TaskNode{ID: "m1", Type: "db_migration", Config: map[string]any{"target": "v1"}}, nil); err == nil || !strings.Contains(err.Error(), "未编入本 Worker") {
+		t.Fatalf("manifest-only err = %v", err)
+	}
+}
+
+func TestSuccessResultType(t *testing.T) {
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{Type: "executor_test.render", Effect: nodeplugin.EffectPure})
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{Type: "executor_test.deploy"})
+	cases := map[string]StepResultType{
+		planner.NodeTool:        StepResultSideEffectCommitted,
+		planner.NodeLLM:         StepResultPure,
+		"executor_test.render":  StepResultPure,
+		"executor_test.deploy":  StepResultSideEffectCommitted,
+		"executor_test.unknown": StepResultPure,
+	}
+	for nodeType, want := range cases {
+		if got := successResultType(nodeType); got != want {
+			t.Errorf("successResultType(%q) = %s, want %s", nodeType, got, want)
+		}
+	}
+	if _, ok := PluginNodeAdapters(nil)["executor_test.deploy"]; !ok {
+		t.Error("PluginNodeAdapters should include registered manifests")
+	}
+}
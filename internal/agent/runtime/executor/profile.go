@@ -0,0 +1,159 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 内置执行档位：conservative 顺序执行、严格校验计划、风险工具需审批；aggressive 同层并行、预取、放宽单步超时
+const (
+	ProfileConservative = "conservative"
+	ProfileAggressive   = "aggressive"
+)
+
+// aggressiveStepTimeout aggressive 档位的单步超时（覆盖 Runner 全局超时）
+const aggressiveStepTimeout = 10 * time.Minute
+
+// ExecutionProfile 命名执行档位：提交 Job 时选择，同一 Agent 可在生产谨慎执行、在开发环境快速执行
+type ExecutionProfile struct {
+	Name string
+	// Parallel 同层节点并行执行（仍需 Job 协商 parallel_dag）；false 时强制顺序执行
+	Parallel bool
+	// Prefetch 并行批次含 wait/join 节点时先并行执行其余就绪节点再挂起，而非整层退化为顺序执行
+	Prefetch bool
+	// StepTimeout >0 时替代 Runner 全局单步超时（可放宽）；Job 级超时（如交互式）仍取较小者
+	StepTimeout time.Duration
+	// ValidatePlan 执行前按 planner.ValidateTaskGraph 严格校验计划，不通过时 Job 永久失败
+	ValidatePlan bool
+	// ApprovalCategories 属于这些类别的工具执行前需审批（复用 capability 审批等待流程）
+	ApprovalCategories []string
+}
+
+// approvalCategory 返回工具类别中第一个需审批的类别
+func (p *ExecutionProfile) approvalCategory(categories []string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	for _, c := range categories {
+		for _, want := range p.ApprovalCategories {
+			if strings.EqualFold(c, want) {
+				return c, true
+			}
+		}
+	}
+	return "", false
+}
+
+// DefaultExecutionProfiles 返回内置档位（conservative / aggressive）
+func DefaultExecutionProfiles() map[string]*ExecutionProfile {
+	return map[string]*ExecutionProfile{
+		ProfileConservative: {
+			Name:               ProfileConservative,
+			ValidatePlan:       true,
+			ApprovalCategories: []string{"network-write", "code-exec"},
+		},
+		ProfileAggressive: {
+			Name:        ProfileAggressive,
+			Parallel:    true,
+			Prefetch:    true,
+			StepTimeout: aggressiveStepTimeout,
+		},
+	}
+}
+
+// ExecutionProfiles 档位表：内置档位与配置合并，配置中同名档位整体覆盖内置定义
+type ExecutionProfiles struct {
+	defaultName string
+	profiles    map[string]*ExecutionProfile
+}
+
+// NewExecutionProfiles 以内置档位为基础合并 overrides；defaultName 非空时须为已知档位
+func NewExecutionProfiles(defaultName string, overrides map[string]*ExecutionProfile) (*ExecutionProfiles, error) {
+	p := &ExecutionProfiles{profiles: DefaultExecutionProfiles()}
+	for name, prof := range overrides {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || prof == nil {
+			continue
+		}
+		cp := *prof
+		cp.Name = name
+		p.profiles[name] = &cp
+	}
+	defaultName = strings.ToLower(strings.TrimSpace(defaultName))
+	if defaultName != "" {
+		if _, ok := p.profiles[defaultName]; !ok {
+			return nil, fmt.Errorf("unknown default execution profile %q", defaultName)
+		}
+	}
+	p.defaultName = defaultName
+	return p, nil
+}
+
+// Resolve 返回 Job 生效的档位名：name 为空时用默认档位；未知档位返回 ok=false
+func (p *ExecutionProfiles) Resolve(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if p == nil {
+		return "", name == ""
+	}
+	if name == "" {
+		return p.defaultName, true
+	}
+	if _, ok := p.profiles[name]; !ok {
+		return "", false
+	}
+	return name, true
+}
+
+// Get 按名称返回档位；空名或未知档位返回 nil
+func (p *ExecutionProfiles) Get(name string) *ExecutionProfile {
+	if p == nil || name == "" {
+		return nil
+	}
+	return p.profiles[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// Names 返回全部档位名（按字母序）
+func (p *ExecutionProfiles) Names() []string {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.profiles))
+	for name := range p.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type executionProfileContextKey struct{}
+
+// WithExecutionProfile 将 Job 的执行档位放入 ctx，供 Tool 适配器按档位判定审批
+func WithExecutionProfile(ctx context.Context, p *ExecutionProfile) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, executionProfileContextKey{}, p)
+}
+
+// ExecutionProfileFromContext 从 ctx 取执行档位；未设置时返回 nil
+func ExecutionProfileFromContext(ctx context.Context) *ExecutionProfile {
+	p, _ := ctx.Value(executionProfileContextKey{}).(*ExecutionProfile)
+	return p
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecutionProfiles_MergeAndResolve(t *testing.T) {
+	profiles, err := NewExecutionProfiles("Conservative", map[string]*ExecutionProfile{
+		"aggressive": {Parallel: true, StepTimeout: time.Hour},
+		"staging":    {ValidatePlan: true},
+	})
+	if err != nil {
+		t.Fatalf("NewExecutionProfiles: %v", err)
+	}
+	if name, ok := profiles.Resolve(""); !ok || name != ProfileConservative {
+		t.Fatalf("Resolve(\"\") = %q, %v; want default conservative", name, ok)
+	}
+	if name, ok := profiles.Resolve("Staging"); !ok || name != "staging" {
+		t.Fatalf("Resolve(Staging) = %q, %v", name, ok)
+	}
+	if _, ok := profiles.Resolve("turbo"); ok {
+		t.Fatal("unknown profile should not resolve")
+	}
+	// 配置同名档位整体覆盖内置定义
+	if p := profiles.Get(ProfileAggressive); p == nil || p.Prefetch || p.StepTimeout != time.Hour || p.Name != ProfileAggressive {
+		t.Fatalf("aggressive override = %+v", p)
+	}
+	if got := profiles.Names(); len(got) != 3 {
+		t.Fatalf("Names() = %v", got)
+	}
+	if _, err := NewExecutionProfiles("turbo", nil); err == nil {
+		t.Fatal("unknown default profile should fail")
+	}
+}
+
+func TestRunner_StepTimeoutForProfile(t *testing.T) {
+	r := NewRunner(nil)
+	r.SetStepTimeout(time.Minute)
+	relaxed := &ExecutionProfile{StepTimeout: 10 * time.Minute}
+	if got := r.stepTimeoutFor(&JobForRunner{Profile: relaxed}); got != 10*time.Minute {
+		t.Fatalf("profile timeout = %v, want 10m", got)
+	}
+	// 交互式 Job 的更紧超时仍优先
+	if got := r.stepTimeoutFor(&JobForRunner{Profile: relaxed, StepTimeout: 30 * time.Second}); got != 30*time.Second {
+		t.Fatalf("interactive timeout = %v, want 30s", got)
+	}
+	if got := r.stepTimeoutFor(&JobForRunner{Profile: &ExecutionProfile{}}); got != time.Minute {
+		t.Fatalf("profile without timeout = %v, want runner 1m", got)
+	}
+}
+
+func TestRunner_ParallelForProfile(t *testing.T) {
+	r := NewRunner(nil)
+	if r.parallelFor(&JobForRunner{}) {
+		t.Fatal("runner without max parallel should run sequentially")
+	}
+	if !r.parallelFor(&JobForRunner{Profile: &ExecutionProfile{Parallel: true}}) {
+		t.Fatal("parallel profile should enable parallel levels")
+	}
+	r.SetMaxParallelSteps(4)
+	if r.parallelFor(&JobForRunner{Profile: &ExecutionProfile{}}) {
+		t.Fatal("sequential profile should override runner parallelism")
+	}
+	if r.parallelFor(&JobForRunner{Profile: &ExecutionProfile{Parallel: true}, Features: []string{}}) {
+		t.Fatal("parallel requires negotiated parallel_dag")
+	}
+}
+
+func TestToolNodeAdapter_ProfileRequiresApproval(t *testing.T) {
+	tools := &sequenceToolExec{successOut: "ok"}
+	adapter := &ToolNodeAdapter{
+		Tools:              tools,
+		ToolCategoriesFunc: func(name string) []string { return map[string][]string{"http": {"network-write"}}[name] },
+	}
+	ctx := WithExecutionProfile(WithJobID(context.Background(), "job-1"), DefaultExecutionProfiles()[ProfileConservative])
+
+	_, err := adapter.runNode(ctx, "n1", "http", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}})
+	var approval *CapabilityRequiresApproval
+	if !errors.As(err, &approval) {
+		t.Fatalf("err = %v, want CapabilityRequiresApproval", err)
+	}
+	if tools.Calls() != 0 {
+		t.Fatalf("tool executed %d times before approval", tools.Calls())
+	}
+
+	approved := WithApprovedCorrelationKeys(ctx, map[string]struct{}{approval.CorrelationKey: {}})
+	if _, err := adapter.runNode(approved, "n1", "http", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}}); err != nil {
+		t.Fatalf("approved run: %v", err)
+	}
+	// 非风险类别工具不需审批
+	if _, err := adapter.runNode(ctx, "n2", "search", map[string]any{}, nil, &AgentDAGPayload{Results: map[string]any{}}); err != nil {
+		t.Fatalf("uncategorized tool: %v", err)
+	}
+	if tools.Calls() != 2 {
+		t.Fatalf("tool calls = %d, want 2", tools.Calls())
+	}
+}
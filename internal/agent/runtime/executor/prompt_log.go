@@ -0,0 +1,76 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// PromptRecord 一次 LLM 调用的留存内容；Error 非空表示调用失败
+type PromptRecord struct {
+	JobID    string
+	NodeID   string
+	TenantID string
+	Prompt   string
+	Response string
+	Error    string
+	Model    string
+	Provider string
+}
+
+// PromptRef llm 事件中引用已留存 prompt 的字段；Key 为对象存储键，SHA256 为原始 prompt 的哈希
+type PromptRef struct {
+	Key        string   `json:"key"`
+	SHA256     string   `json:"sha256"`
+	Bytes      int      `json:"bytes"`
+	Reason     string   `json:"reason"` // sampled | failure
+	Redactions []string `json:"redactions,omitempty"`
+}
+
+// PromptLogger LLM prompt 留存（由 promptlog.Logger 实现）：按租户采样率或失败决定是否留存，脱敏后写入对象存储
+type PromptLogger interface {
+	// Log 不留存时返回 nil, nil
+	Log(ctx context.Context, rec PromptRecord) (*PromptRef, error)
+}
+
+// PromptLogEventSink 可选：NodeEventSink 实现时，留存的 prompt 以 llm_prompt_logged 事件引用（含失败调用）
+type PromptLogEventSink interface {
+	AppendLLMPromptLogged(ctx context.Context, jobID string, nodeID string, ref *PromptRef, failed bool) error
+}
+
+// promptDigest command_emitted 中代替完整 prompt 的摘要（配置 prompt 留存时不再把 prompt 写入事件）
+func promptDigest(prompt string) []byte {
+	sum := sha256.Sum256([]byte(prompt))
+	b, _ := json.Marshal(map[string]any{"prompt_sha256": hex.EncodeToString(sum[:]), "prompt_bytes": len(prompt)})
+	return b
+}
+
+// logPrompt 留存 prompt 并写 llm_prompt_logged；留存失败不影响 LLM 节点结果
+func (a *LLMNodeAdapter) logPrompt(ctx context.Context, rec PromptRecord) *PromptRef {
+	if a.PromptLog == nil || rec.JobID == "" {
+		return nil
+	}
+	ref, err := a.PromptLog.Log(ctx, rec)
+	if err != nil || ref == nil {
+		return nil
+	}
+	if sink, ok := a.CommandEventSink.(PromptLogEventSink); ok {
+		_ = sink.AppendLLMPromptLogged(ctx, rec.JobID, rec.NodeID, ref, rec.Error != "")
+	}
+	return ref
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recordingCommandSink 记录 command 与 llm_prompt_logged 事件
+type recordingCommandSink struct {
+	emitted   []byte
+	committed []byte
+	logged    []*PromptRef
+	failed    []bool
+}
+
+func (s *recordingCommandSink) AppendCommandEmitted(_ context.Context, _, _, _, _ string, input []byte) error {
+	s.emitted = input
+	return nil
+}
+
+func (s *recordingCommandSink) AppendCommandCommitted(_ context.Context, _, _, _ string, result []byte, _ string) error {
+	s.committed = result
+	return nil
+}
+
+func (s *recordingCommandSink) AppendLLMPromptLogged(_ context.Context, _, _ string, ref *PromptRef, failed bool) error {
+	s.logged = append(s.logged, ref)
+	s.failed = append(s.failed, failed)
+	return nil
+}
+
+// alwaysPromptLogger 总是留存，记录收到的内容
+type alwaysPromptLogger struct {
+	records []PromptRecord
+}
+
+func (l *alwaysPromptLogger) Log(_ context.Context, rec PromptRecord) (*PromptRef, error) {
+	l.records = append(l.records, rec)
+	reason := "sampled"
+	if rec.Error != "" {
+		reason = "failure"
+	}
+	return &PromptRef{Key: "prompts/" + rec.JobID + "/" + rec.NodeID, Reason: reason}, nil
+}
+
+type failingLLMGen struct{}
+
+func (failingLLMGen) Generate(context.Context, string) (string, error) {
+	return "", errors.New("rate limited")
+}
+
+func TestLLMNodeAdapter_PromptLogReferencesInsteadOfPrompt(t *testing.T) {
+	sink := &recordingCommandSink{}
+	logger := &alwaysPromptLogger{}
+	a := &LLMNodeAdapter{LLM: &fakeLLMGenWithMeta{}, CommandEventSink: sink, PromptLog: logger}
+	ctx := WithTenantID(WithJobID(context.Background(), "job-1"), "t1")
+	if _, err := a.runNode(ctx, "n1", map[string]any{"goal": "secret prompt"}, nil, &AgentDAGPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(sink.emitted), "secret prompt") || !strings.Contains(string(sink.emitted), "prompt_sha256") {
+		t.Fatalf("command_emitted should carry only the prompt digest: %s", sink.emitted)
+	}
+	var committed map[string]any
+	_ = json.Unmarshal(sink.committed, &committed)
+	if ref, _ := committed["prompt_ref"].(map[string]any); ref["key"] != "prompts/job-1/n1" {
+		t.Fatalf("command_committed should reference the logged prompt: %s", sink.committed)
+	}
+	if len(logger.records) != 1 || logger.records[0].TenantID != "t1" || logger.records[0].Prompt != "secret prompt" || logger.records[0].Response != "ok" {
+		t.Fatalf("unexpected prompt record: %+v", logger.records)
+	}
+	if len(sink.logged) != 1 || sink.failed[0] {
+		t.Fatal("llm_prompt_logged should be appended for the sampled call")
+	}
+}
+
+func TestLLMNodeAdapter_PromptLogOnFailure(t *testing.T) {
+	sink := &recordingCommandSink{}
+	logger := &alwaysPromptLogger{}
+	a := &LLMNodeAdapter{LLM: failingLLMGen{}, CommandEventSink: sink, PromptLog: logger}
+	_, err := a.runNode(WithJobID(context.Background(), "job-1"), "n1", map[string]any{"goal": "p"}, nil, &AgentDAGPayload{})
+	if err == nil {
+		t.Fatal("expected llm error")
+	}
+	if len(logger.records) != 1 || logger.records[0].Error != "rate limited" {
+		t.Fatalf("failed call should be logged with its error: %+v", logger.records)
+	}
+	if len(sink.failed) != 1 || !sink.failed[0] || sink.committed != nil {
+		t.Fatal("failed call should append llm_prompt_logged without command_committed")
+	}
+}
+
+func TestLLMNodeAdapter_NoPromptLogKeepsPrompt(t *testing.T) {
+	sink := &recordingCommandSink{}
+	a := &LLMNodeAdapter{LLM: &fakeLLMGenWithMeta{}, CommandEventSink: sink}
+	if _, err := a.runNode(WithJobID(context.Background(), "job-1"), "n1", map[string]any{"goal": "hello"}, nil, &AgentDAGPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sink.emitted), `"prompt":"hello"`) {
+		t.Fatalf("without prompt logging command_emitted keeps the prompt: %s", sink.emitted)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/time/rate"
 )
@@ -29,6 +30,12 @@ type ToolLimitConfig struct {
 	Burst         int     `yaml:"burst"`          // 令牌桶容量（可选，默认为 QPS）
 }
 
+// toolsInFlight 当前进程内正在执行的 Tool 调用数（无论是否配置限流）
+var toolsInFlight atomic.Int64
+
+// ToolsInFlight 返回当前进程内正在执行的 Tool 调用数，供 Worker 资源感知认领
+func ToolsInFlight() int { return int(toolsInFlight.Load()) }
+
 // ToolRateLimiter Tool 维度的限流器，支持 QPS + 并发控制
 type ToolRateLimiter struct {
 	mu       sync.RWMutex
@@ -169,6 +176,17 @@ func (t *ToolRateLimiter) Allow(toolName string) bool {
 	return true
 }
 
+// Keys 返回已创建限流器的工具名
+func (t *ToolRateLimiter) Keys() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	keys := make([]string, 0, len(t.limiters))
+	for k := range t.limiters {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
 // GetStats 获取限流统计信息
 func (t *ToolRateLimiter) GetStats(toolName string) map[string]interface{} {
 	t.mu.RLock()
@@ -0,0 +1,322 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/metrics"
+)
+
+// ReflectionPolicy 反思提议的生效策略
+const (
+	// ReflectionPolicyAutoApply 修订后的计划通过编译校验即替换剩余执行（并写入新的 PlanGenerated）
+	ReflectionPolicyAutoApply = "auto_apply"
+	// ReflectionPolicyRequireApproval 仅记录提议（status=pending_approval），按原计划继续，由人工审阅后决定是否重新提交
+	ReflectionPolicyRequireApproval = "require_approval"
+)
+
+// ReflectionTrigger 反思触发来源
+const (
+	ReflectionTriggerInterval = "every_n_steps"
+	ReflectionTriggerFailure  = "on_failure"
+	ReflectionTriggerNode     = "reflect_node"
+)
+
+// plan_evolution 中反思提议的状态
+const (
+	PlanEvolutionApplied         = "applied"
+	PlanEvolutionPendingApproval = "pending_approval"
+	PlanEvolutionNoChange        = "no_change"
+	PlanEvolutionRejected        = "rejected"
+)
+
+const (
+	defaultReflectionSummaryChars = 4000
+	defaultReflectionMaxRevisions = 3
+	reflectionStepResultChars     = 300
+)
+
+// ReflectionConfig Runner 自动注入反思步的条件与策略
+type ReflectionConfig struct {
+	EveryNSteps     int    // >0 时每完成 N 步反思一次
+	OnFailure       bool   // 步失败时反思一次；auto_apply 且给出修订计划时按新计划继续而非直接失败
+	Policy          string // auto_apply | require_approval，空为 require_approval
+	MaxSummaryChars int    // 轨迹摘要上限（字符），<=0 使用 4000
+	MaxRevisions    int    // 单次运行最多自动应用的修订次数，<=0 使用 3
+}
+
+// ReflectionRequest 提交给 Reflector 的复盘输入；Summary 为有界轨迹摘要
+type ReflectionRequest struct {
+	JobID   string
+	Goal    string
+	Trigger string
+	NodeID  string // 触发节点（失败节点或 reflect 节点）
+	Error   string // Trigger=on_failure 时的失败原因
+	Plan    *planner.TaskGraph
+	Summary string
+}
+
+// ReflectionProposal Reflector 的输出；Revise 为 true 且 TaskGraph 非空时为一次计划修订提议
+type ReflectionProposal struct {
+	Critique    string             `json:"critique"`
+	Revise      bool               `json:"revise"`
+	DiffSummary string             `json:"diff_summary,omitempty"`
+	TaskGraph   *planner.TaskGraph `json:"task_graph,omitempty"`
+}
+
+// Reflector 对已执行轨迹做复盘并给出可选的计划修订
+type Reflector interface {
+	Reflect(ctx context.Context, req *ReflectionRequest) (*ReflectionProposal, error)
+}
+
+// PlanEvolutionSink 可选：NodeEventSink 实现方额外实现时，反思提议以完整 payload 写入 plan_evolution；否则退化为 AppendPlanEvolution
+type PlanEvolutionSink interface {
+	AppendPlanEvolutionProposal(ctx context.Context, jobID string, pl *jobstore.PlanEvolutionPayload) error
+}
+
+// LLMReflector 基于 LLM 的 Reflector
+type LLMReflector struct {
+	LLM LLMGen
+}
+
+// NewLLMReflector 创建基于 LLM 的 Reflector
+func NewLLMReflector(llm LLMGen) *LLMReflector {
+	return &LLMReflector{LLM: llm}
+}
+
+// Reflect 实现 Reflector；LLM 输出无法解析时返回 error（不影响 Job 执行）
+func (r *LLMReflector) Reflect(ctx context.Context, req *ReflectionRequest) (*ReflectionProposal, error) {
+	if r == nil || r.LLM == nil {
+		return nil, fmt.Errorf("reflector: LLM 未配置")
+	}
+	if req == nil {
+		return nil, fmt.Errorf("reflector: request 为空")
+	}
+	reply, err := r.LLM.Generate(ctx, reflectionPrompt(req))
+	if err != nil {
+		return nil, fmt.Errorf("reflector: LLM 调用failed: %w", err)
+	}
+	return parseReflectionProposal(reply)
+}
+
+func reflectionPrompt(req *ReflectionRequest) string {
+	var b strings.Builder
+	b.WriteString("你是任务执行的复盘者。请审阅目标、当前计划与已执行轨迹，判断计划是否需要修订。\n")
+	b.WriteString("目标：")
+	b.WriteString(req.Goal)
+	b.WriteString("\n触发：")
+	b.WriteString(req.Trigger)
+	if req.NodeID != "" {
+		b.WriteString("（节点 ")
+		b.WriteString(req.NodeID)
+		b.WriteString("）")
+	}
+	if req.Error != "" {
+		b.WriteString("\n失败原因：")
+		b.WriteString(req.Error)
+	}
+	if req.Plan != nil {
+		if g, err := req.Plan.Marshal(); err == nil {
+			b.WriteString("\n当前计划：")
+			b.Write(g)
+		}
+	}
+	b.WriteString("\n已执行轨迹：\n")
+	b.WriteString(req.Summary)
+	b.WriteString("\n只输出 JSON：{\"critique\":\"复盘结论\",\"revise\":true/false,\"diff_summary\":\"修订要点\",\"task_graph\":{\"nodes\":[...],\"edges\":[...]}}。")
+	b.WriteString("revise 为 true 时 task_graph 为完整的新计划；已完成的节点保留原 id 即不会重复执行。")
+	return b.String()
+}
+
+func parseReflectionProposal(reply string) (*ReflectionProposal, error) {
+	reply = strings.TrimSpace(reply)
+	if idx := strings.Index(reply, "{"); idx >= 0 {
+		if end := strings.LastIndex(reply, "}"); end > idx {
+			reply = reply[idx : end+1]
+		}
+	}
+	var p ReflectionProposal
+	if err := json.Unmarshal([]byte(reply), &p); err != nil {
+		return nil, fmt.Errorf("reflector: 解析提议failed: %w", err)
+	}
+	if !p.Revise || p.TaskGraph == nil || len(p.TaskGraph.Nodes) == 0 {
+		p.Revise = false
+		p.TaskGraph = nil
+	}
+	return &p, nil
+}
+
+// SetReflection 设置自我反思（可选）；reflector 为 nil 时关闭。每 N 步或步失败时注入反思步，提议写入 plan_evolution 并按 Policy 决定是否生效
+func (r *Runner) SetReflection(reflector Reflector, cfg ReflectionConfig) {
+	r.reflector = reflector
+	if cfg.Policy != ReflectionPolicyAutoApply {
+		cfg.Policy = ReflectionPolicyRequireApproval
+	}
+	if cfg.MaxSummaryChars <= 0 {
+		cfg.MaxSummaryChars = defaultReflectionSummaryChars
+	}
+	if cfg.MaxRevisions <= 0 {
+		cfg.MaxRevisions = defaultReflectionMaxRevisions
+	}
+	r.reflection = cfg
+}
+
+// reflectionState 单次 RunForJob 内的反思计数
+type reflectionState struct {
+	planVersion       int // 当前计划版本，初始计划为 1
+	revisions         int // 已自动应用的修订次数
+	stepsSinceReflect int // 距上次反思完成的步数
+}
+
+// reflectOnTrace 执行一次反思并写 plan_evolution；auto_apply 且修订计划可编译时返回新计划与步，否则返回 nil
+func (r *Runner) reflectOnTrace(ctx context.Context, agent *runtime.Agent, j *JobForRunner, rs *reflectionState, trigger, nodeID, errMsg string, taskGraph *planner.TaskGraph, steps []SteppableStep, payload *AgentDAGPayload, completedSet map[string]struct{}) (*planner.TaskGraph, []SteppableStep) {
+	if r.reflector == nil {
+		return nil, nil
+	}
+	rs.stepsSinceReflect = 0
+	var results map[string]any
+	if payload != nil {
+		results = payload.Results
+	}
+	req := &ReflectionRequest{
+		JobID:   j.ID,
+		Goal:    j.Goal,
+		Trigger: trigger,
+		NodeID:  nodeID,
+		Error:   errMsg,
+		Plan:    taskGraph,
+		Summary: summarizeTraceForReflection(steps, completedSet, results, nodeID, errMsg, r.reflection.MaxSummaryChars),
+	}
+	proposal, err := r.reflector.Reflect(ctx, req)
+	if err != nil || proposal == nil {
+		metrics.ReflectionTotal.WithLabelValues(trigger, "error").Inc()
+		return nil, nil
+	}
+	pl := &jobstore.PlanEvolutionPayload{
+		PlanVersion: rs.planVersion,
+		DiffSummary: proposal.DiffSummary,
+		Source:      "reflection",
+		Trigger:     trigger,
+		NodeID:      nodeID,
+		Critique:    proposal.Critique,
+		Policy:      r.reflection.Policy,
+		Status:      PlanEvolutionNoChange,
+	}
+	var newGraph *planner.TaskGraph
+	var newSteps []SteppableStep
+	if proposal.Revise && proposal.TaskGraph != nil {
+		pl.PlanVersion = rs.planVersion + 1
+		pl.ProposedPlan, _ = proposal.TaskGraph.Marshal()
+		switch {
+		case r.reflection.Policy != ReflectionPolicyAutoApply:
+			pl.Status = PlanEvolutionPendingApproval
+		case rs.revisions >= r.reflection.MaxRevisions:
+			pl.Status = PlanEvolutionRejected
+			pl.DiffSummary = strings.TrimSpace(pl.DiffSummary + "（已达修订上限）")
+		default:
+			compiled, compErr := r.compiler.CompileSteppable(ctx, proposal.TaskGraph, agent)
+			if compErr != nil {
+				pl.Status = PlanEvolutionRejected
+				pl.DiffSummary = strings.TrimSpace(pl.DiffSummary + "（修订计划编译failed：" + compErr.Error() + "）")
+			} else {
+				pl.Status = PlanEvolutionApplied
+				newGraph, newSteps = proposal.TaskGraph, compiled
+				rs.planVersion++
+				rs.revisions++
+			}
+		}
+	}
+	if r.nodeEventSink != nil {
+		if sink, ok := r.nodeEventSink.(PlanEvolutionSink); ok {
+			_ = sink.AppendPlanEvolutionProposal(ctx, j.ID, pl)
+		} else {
+			_ = r.nodeEventSink.AppendPlanEvolution(ctx, j.ID, pl.PlanVersion, pl.DiffSummary)
+		}
+	}
+	// 修订生效后写入新的 PlanGenerated，使事件流 Replay 从修订后的计划恢复
+	if newGraph != nil && r.planGeneratedSink != nil {
+		if graphJSON, err := newGraph.Marshal(); err == nil {
+			_ = r.planGeneratedSink.AppendPlanGenerated(ctx, j.ID, graphJSON, j.Goal)
+		}
+	}
+	metrics.ReflectionTotal.WithLabelValues(trigger, pl.Status).Inc()
+	return newGraph, newSteps
+}
+
+// summarizeTraceForReflection 按计划顺序列出已完成步（及失败步）与截断后的结果；超出 maxChars 时保留最近的步
+func summarizeTraceForReflection(steps []SteppableStep, completedSet map[string]struct{}, results map[string]any, failedNodeID, errMsg string, maxChars int) string {
+	var lines []string
+	for _, s := range steps {
+		_, done := completedSet[s.NodeID]
+		failed := s.NodeID == failedNodeID && errMsg != ""
+		if !done && !failed {
+			continue
+		}
+		line := fmt.Sprintf("- [%s] %s", s.NodeType, s.NodeID)
+		if failed {
+			line += " failed: " + truncateStr(errMsg, reflectionStepResultChars)
+		} else if res, ok := results[s.NodeID]; ok && res != nil {
+			if b, err := json.Marshal(res); err == nil {
+				line += " => " + truncateStr(string(b), reflectionStepResultChars)
+			}
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return "（尚无已完成步）"
+	}
+	if maxChars <= 0 {
+		maxChars = defaultReflectionSummaryChars
+	}
+	total := 0
+	start := len(lines)
+	for start > 0 && total+len(lines[start-1])+1 <= maxChars {
+		start--
+		total += len(lines[start]) + 1
+	}
+	if start == len(lines) {
+		// 单步即超限：仍保留最后一步的截断内容
+		return truncateStr(lines[len(lines)-1], maxChars)
+	}
+	out := strings.Join(lines[start:], "\n")
+	if start > 0 {
+		out = fmt.Sprintf("（省略更早的 %d 步）\n", start) + out
+	}
+	return out
+}
+
+// ReflectNodeAdapter reflect 节点适配器；节点本身为直通，反思由 Runner 在该步完成后执行（需 SetReflection）
+type ReflectNodeAdapter struct{}
+
+func (ReflectNodeAdapter) ToDAGNode(task *planner.TaskNode, _ *runtime.Agent) (*compose.Lambda, error) {
+	return compose.InvokableLambda[*AgentDAGPayload, *AgentDAGPayload](func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		return p, nil
+	}), nil
+}
+
+func (ReflectNodeAdapter) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		return p, nil
+	}, nil
+}
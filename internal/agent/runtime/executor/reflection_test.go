@@ -0,0 +1,249 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// scriptedNodeAdapter 按节点 ID 记录调用次数；fail 中的节点返回 permanent failure
+type scriptedNodeAdapter struct {
+	mu    sync.Mutex
+	fail  map[string]bool
+	calls map[string]int
+}
+
+func (a *scriptedNodeAdapter) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, _ := a.ToNodeRunner(task, agent)
+	return compose.InvokableLambda[*AgentDAGPayload, *AgentDAGPayload](func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		return run(ctx, p)
+	}), nil
+}
+
+func (a *scriptedNodeAdapter) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	id := task.ID
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		a.mu.Lock()
+		if a.calls == nil {
+			a.calls = make(map[string]int)
+		}
+		a.calls[id]++
+		a.mu.Unlock()
+		if a.fail[id] {
+			return p, fmt.Errorf("node %s broke: %w", id, ErrPermanent)
+		}
+		if p.Results == nil {
+			p.Results = make(map[string]any)
+		}
+		p.Results[id] = "ok-" + id
+		return p, nil
+	}, nil
+}
+
+func (a *scriptedNodeAdapter) callsOf(id string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls[id]
+}
+
+type stubReflector struct {
+	mu       sync.Mutex
+	requests []*ReflectionRequest
+	proposal *ReflectionProposal
+}
+
+func (s *stubReflector) Reflect(ctx context.Context, req *ReflectionRequest) (*ReflectionProposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return s.proposal, nil
+}
+
+// reflectionSink 在 timeoutNodeSink 基础上记录 plan_evolution 提议
+type reflectionSink struct {
+	timeoutNodeSink
+	proposals []jobstore.PlanEvolutionPayload
+}
+
+func (s *reflectionSink) AppendPlanEvolutionProposal(ctx context.Context, jobID string, pl *jobstore.PlanEvolutionPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.proposals = append(s.proposals, *pl)
+	return nil
+}
+
+type countingPlanSink struct {
+	graphs [][]byte
+}
+
+func (s *countingPlanSink) AppendPlanGenerated(ctx context.Context, jobID string, taskGraphJSON []byte, goal string) error {
+	s.graphs = append(s.graphs, taskGraphJSON)
+	return nil
+}
+
+func newReflectionRunner(t *testing.T, jobID string, graph *planner.TaskGraph, adapter *scriptedNodeAdapter) (*Runner, *fakeJobStoreForRunner, *reflectionSink, *countingPlanSink) {
+	t.Helper()
+	eventStore := jobstore.NewMemoryStore()
+	graphBytes, _ := graph.Marshal()
+	planPl, _ := json.Marshal(map[string]interface{}{"task_graph": json.RawMessage(graphBytes), "goal": "g"})
+	if _, err := eventStore.Append(context.Background(), jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: planPl}); err != nil {
+		t.Fatal(err)
+	}
+	fakeJobStore := &fakeJobStoreForRunner{}
+	sink := &reflectionSink{}
+	planSink := &countingPlanSink{}
+	r := NewRunner(NewCompiler(map[string]NodeAdapter{planner.NodeWorkflow: adapter, planner.NodeReflect: ReflectNodeAdapter{}}))
+	r.SetCheckpointStores(runtime.NewCheckpointStoreMem(), fakeJobStore)
+	r.SetReplayContextBuilder(replay.NewReplayContextBuilder(eventStore))
+	r.SetNodeEventSink(sink)
+	r.SetPlanGeneratedSink(planSink)
+	return r, fakeJobStore, sink, planSink
+}
+
+func TestReflection_OnFailureAutoApply_ContinuesWithRevisedPlan(t *testing.T) {
+	graph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeWorkflow}, {ID: "n2", Type: planner.NodeWorkflow}},
+		Edges: []planner.TaskEdge{{From: "n1", To: "n2"}},
+	}
+	adapter := &scriptedNodeAdapter{fail: map[string]bool{"n2": true}}
+	r, jobStore, sink, planSink := newReflectionRunner(t, "job-reflect-fail", graph, adapter)
+	revised := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeWorkflow}, {ID: "n2b", Type: planner.NodeWorkflow}},
+		Edges: []planner.TaskEdge{{From: "n1", To: "n2b"}},
+	}
+	reflector := &stubReflector{proposal: &ReflectionProposal{Critique: "n2 不可用", Revise: true, DiffSummary: "n2 -> n2b", TaskGraph: revised}}
+	r.SetReflection(reflector, ReflectionConfig{OnFailure: true, Policy: ReflectionPolicyAutoApply})
+
+	if err := r.RunForJob(context.Background(), &runtime.Agent{ID: "a1"}, &JobForRunner{ID: "job-reflect-fail", AgentID: "a1", Goal: "g"}); err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if adapter.callsOf("n1") != 1 || adapter.callsOf("n2") != 1 || adapter.callsOf("n2b") != 1 {
+		t.Fatalf("calls = %v, want n1/n2/n2b once each", adapter.calls)
+	}
+	if _, status := jobStore.getLast(); status != 2 {
+		t.Fatalf("status = %d, want 2 (Completed)", status)
+	}
+	if len(reflector.requests) != 1 || reflector.requests[0].Trigger != ReflectionTriggerFailure || reflector.requests[0].NodeID != "n2" {
+		t.Fatalf("unexpected reflection requests: %+v", reflector.requests)
+	}
+	if !strings.Contains(reflector.requests[0].Summary, "n1") || !strings.Contains(reflector.requests[0].Summary, "n2 failed") {
+		t.Errorf("summary = %q", reflector.requests[0].Summary)
+	}
+	if len(sink.proposals) != 1 {
+		t.Fatalf("proposals = %d, want 1", len(sink.proposals))
+	}
+	pl := sink.proposals[0]
+	if pl.Status != PlanEvolutionApplied || pl.PlanVersion != 2 || pl.Policy != ReflectionPolicyAutoApply || len(pl.ProposedPlan) == 0 {
+		t.Errorf("proposal = %+v", pl)
+	}
+	if len(planSink.graphs) != 1 {
+		t.Errorf("PlanGenerated appended %d times, want 1", len(planSink.graphs))
+	}
+}
+
+func TestReflection_RequireApproval_RecordsPendingAndKeepsPlan(t *testing.T) {
+	graph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeWorkflow}, {ID: "n2", Type: planner.NodeWorkflow}},
+		Edges: []planner.TaskEdge{{From: "n1", To: "n2"}},
+	}
+	adapter := &scriptedNodeAdapter{}
+	r, jobStore, sink, planSink := newReflectionRunner(t, "job-reflect-approval", graph, adapter)
+	revised := &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n3", Type: planner.NodeWorkflow}}}
+	reflector := &stubReflector{proposal: &ReflectionProposal{Critique: "换个做法", Revise: true, TaskGraph: revised}}
+	r.SetReflection(reflector, ReflectionConfig{EveryNSteps: 1})
+
+	if err := r.RunForJob(context.Background(), &runtime.Agent{ID: "a1"}, &JobForRunner{ID: "job-reflect-approval", AgentID: "a1", Goal: "g"}); err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if adapter.callsOf("n3") != 0 {
+		t.Fatal("pending proposal must not be executed")
+	}
+	if _, status := jobStore.getLast(); status != 2 {
+		t.Fatalf("status = %d, want 2 (Completed)", status)
+	}
+	if len(sink.proposals) != 2 {
+		t.Fatalf("proposals = %d, want 2 (one per step)", len(sink.proposals))
+	}
+	for _, pl := range sink.proposals {
+		if pl.Status != PlanEvolutionPendingApproval || pl.Trigger != ReflectionTriggerInterval || pl.Policy != ReflectionPolicyRequireApproval {
+			t.Errorf("proposal = %+v", pl)
+		}
+	}
+	if len(planSink.graphs) != 0 {
+		t.Errorf("PlanGenerated appended %d times, want 0", len(planSink.graphs))
+	}
+}
+
+func TestReflection_ReflectNodeTriggersReflection(t *testing.T) {
+	graph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeWorkflow}, {ID: "r1", Type: planner.NodeReflect}},
+		Edges: []planner.TaskEdge{{From: "n1", To: "r1"}},
+	}
+	adapter := &scriptedNodeAdapter{}
+	r, _, sink, _ := newReflectionRunner(t, "job-reflect-node", graph, adapter)
+	reflector := &stubReflector{proposal: &ReflectionProposal{Critique: "按计划进行"}}
+	r.SetReflection(reflector, ReflectionConfig{})
+
+	if err := r.RunForJob(context.Background(), &runtime.Agent{ID: "a1"}, &JobForRunner{ID: "job-reflect-node", AgentID: "a1", Goal: "g"}); err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if len(sink.proposals) != 1 || sink.proposals[0].Trigger != ReflectionTriggerNode || sink.proposals[0].Status != PlanEvolutionNoChange {
+		t.Fatalf("proposals = %+v", sink.proposals)
+	}
+}
+
+func TestSummarizeTraceForReflection_Bounded(t *testing.T) {
+	var steps []SteppableStep
+	completed := make(map[string]struct{})
+	results := make(map[string]any)
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("n%02d", i)
+		steps = append(steps, SteppableStep{NodeID: id, NodeType: planner.NodeTool})
+		completed[id] = struct{}{}
+		results[id] = strings.Repeat("x", 1000)
+	}
+	out := summarizeTraceForReflection(steps, completed, results, "", "", 1000)
+	if len(out) > 1100 {
+		t.Fatalf("summary length = %d, want bounded", len(out))
+	}
+	if !strings.Contains(out, "n49") || !strings.Contains(out, "省略") {
+		t.Errorf("summary should keep latest steps and note omission: %q", out)
+	}
+}
+
+func TestParseReflectionProposal(t *testing.T) {
+	p, err := parseReflectionProposal("```json\n{\"critique\":\"ok\",\"revise\":true,\"task_graph\":{\"nodes\":[]}}\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Revise || p.TaskGraph != nil {
+		t.Errorf("revise without nodes should be dropped: %+v", p)
+	}
+	if _, err := parseReflectionProposal("not json"); err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	replaysandbox "rag-platform/internal/agent/replay/sandbox"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/metrics"
+)
+
+// ErrReplayPolicyDenied Replay 策略判定节点为副作用/外部操作且无已记录结果，禁止执行；job_failed 的 error 含此文本
+var ErrReplayPolicyDenied = errors.New("replay policy denied execution")
+
+// ReplayReasonNoRecordedResult replay_reexecuted 的 reason：事件流中无该步的已记录结果
+const ReplayReasonNoRecordedResult = "no_recorded_result"
+
+// ReplayReexecutionSink 可选：NodeEventSink 实现时，幂等工具在 Replay 中重新执行的决策写入 replay_reexecuted
+type ReplayReexecutionSink interface {
+	AppendReplayReexecuted(ctx context.Context, jobID string, pl *jobstore.ReplayReexecutedPayload) error
+}
+
+// 步骤完成方式（metrics.StepExecutionsTotal 的 mode 标签）
+const (
+	StepModeLive     = "live"     // 实际执行
+	StepModeInjected = "injected" // 从 Replay 注入结果，未执行；node_finished 以 attempt=0 写入
+)
+
+// catch-up 来源（metrics.ReplayCatchUpTotal 的 source 标签）
+const (
+	CatchUpSourceLedger      = "ledger"
+	CatchUpSourceEffectStore = "effect_store"
+)
+
+func metricNodeType(nodeType string) string {
+	if nodeType == "" {
+		return "unknown"
+	}
+	return nodeType
+}
+
+// recordStepMode 记录一个步骤以实际执行或 Replay 注入方式完成
+func recordStepMode(ctx context.Context, nodeType, mode string) {
+	metrics.StepExecutionsTotal.WithLabelValues(TenantIDFromContext(ctx), metricNodeType(nodeType), mode).Inc()
+}
+
+// recordCatchUp 记录一次恢复时的命令 catch-up（补写事件、不重执行）
+func recordCatchUp(ctx context.Context, source string) {
+	metrics.ReplayCatchUpTotal.WithLabelValues(TenantIDFromContext(ctx), source).Inc()
+}
+
+// replayPolicyDenied 记录 Replay 策略拒绝并返回包装 ErrReplayPolicyDenied 的错误
+func replayPolicyDenied(ctx context.Context, nodeID, nodeType string, kind replaysandbox.OperationKind) error {
+	metrics.ReplayPolicyDenialsTotal.WithLabelValues(TenantIDFromContext(ctx), metricNodeType(nodeType), string(kind)).Inc()
+	return fmt.Errorf("executor: replay 时副作用节点 %s 无已记录结果，forbidden执行: %w", nodeID, ErrReplayPolicyDenied)
+}
+
+// decideReplay tool 节点且策略实现 ToolReplayPolicy 时按工具名决策，否则按节点类型；返回解析到的工具名
+func (r *Runner) decideReplay(g *planner.TaskGraph, step SteppableStep, commandID string, replayCtx *replay.ReplayContext) (replaysandbox.ReplayDecision, string) {
+	if tp, ok := r.replayPolicy.(replaysandbox.ToolReplayPolicy); ok && step.NodeType == planner.NodeTool {
+		if toolName := toolNameOf(g, step.NodeID); toolName != "" {
+			return tp.DecideTool(step.NodeID, commandID, toolName, replayCtx), toolName
+		}
+	}
+	return r.replayPolicy.Decide(step.NodeID, commandID, step.NodeType, replayCtx), ""
+}
+
+// recordReplayReexecution 记录幂等工具在 Replay 中无已记录结果、按策略重新执行的决策（事件 + 指标）
+func (r *Runner) recordReplayReexecution(ctx context.Context, jobID string, step SteppableStep, stepID, commandID, toolName string, d replaysandbox.ReplayDecision) {
+	metrics.ReplayIdempotentReexecutionsTotal.WithLabelValues(TenantIDFromContext(ctx), toolName).Inc()
+	if sink, ok := r.nodeEventSink.(ReplayReexecutionSink); ok {
+		_ = sink.AppendReplayReexecuted(ctx, jobID, &jobstore.ReplayReexecutedPayload{
+			NodeID:           step.NodeID,
+			StepID:           stepID,
+			CommandID:        commandID,
+			ToolName:         toolName,
+			Reason:           ReplayReasonNoRecordedResult,
+			VerificationHint: d.VerificationHint,
+		})
+	}
+}
+
+func toolNameOf(g *planner.TaskGraph, nodeID string) string {
+	if g == nil {
+		return ""
+	}
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == nodeID {
+			return g.Nodes[i].ToolName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	replaysandbox "rag-platform/internal/agent/replay/sandbox"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestReplayPolicyDenied_WrapsSentinel(t *testing.T) {
+	err := replayPolicyDenied(WithTenantID(context.Background(), "t1"), "n1", "tool", replaysandbox.SideEffect)
+	if !errors.Is(err, ErrReplayPolicyDenied) {
+		t.Fatalf("expected ErrReplayPolicyDenied, got %v", err)
+	}
+	// job_failed 只保存错误文本，报表按文本识别拒绝
+	if !strings.Contains(err.Error(), ErrReplayPolicyDenied.Error()) || !strings.Contains(err.Error(), "n1") {
+		t.Errorf("error text = %q", err.Error())
+	}
+}
+
+// reexecSink 在 timeoutNodeSink 基础上记录 replay_reexecuted
+type reexecSink struct {
+	timeoutNodeSink
+	reexecuted []jobstore.ReplayReexecutedPayload
+}
+
+func (s *reexecSink) AppendReplayReexecuted(ctx context.Context, jobID string, pl *jobstore.ReplayReexecutedPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reexecuted = append(s.reexecuted, *pl)
+	return nil
+}
+
+func TestAdvance_IdempotentToolReexecutedWithoutRecordedResult(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job-idempotent-replay"
+	graph := &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeTool, ToolName: "lookup"}}}
+	graphBytes, _ := graph.Marshal()
+	newState := func() *replay.ExecutionState {
+		return replay.NewExecutionState(&replay.ReplayContext{
+			TaskGraphState:      graphBytes,
+			CompletedNodeIDs:    map[string]struct{}{},
+			CompletedCommandIDs: map[string]struct{}{},
+			CommandResults:      map[string][]byte{},
+		})
+	}
+	run := func(policy replaysandbox.ReplayPolicy) (int32, *reexecSink, error) {
+		var calls int32
+		r := NewRunner(NewCompiler(map[string]NodeAdapter{planner.NodeTool: &ToolNodeAdapter{Tools: &countToolExec{count: &calls}}}))
+		r.SetCheckpointStores(runtime.NewCheckpointStoreMem(), &fakeJobStoreForRunner{})
+		sink := &reexecSink{}
+		r.SetNodeEventSink(sink)
+		r.SetReplayPolicy(policy)
+		_, err := r.Advance(ctx, jobID, newState(), &runtime.Agent{ID: "a1"}, &JobForRunner{ID: jobID, AgentID: "a1", Goal: "g"})
+		return atomic.LoadInt32(&calls), sink, err
+	}
+
+	// 未声明幂等：无已记录结果时拒绝执行
+	calls, _, err := run(replaysandbox.DefaultPolicy{})
+	if !errors.Is(err, ErrReplayPolicyDenied) || calls != 0 {
+		t.Fatalf("default policy: calls=%d err=%v", calls, err)
+	}
+	lookup := func(name string) (string, bool) { return "GET /lookup/:id", name == "lookup" }
+	calls, sink, err := run(replaysandbox.NewIdempotentToolPolicy(nil, lookup))
+	if err != nil || calls != 1 {
+		t.Fatalf("idempotent policy: calls=%d err=%v", calls, err)
+	}
+	if len(sink.reexecuted) != 1 {
+		t.Fatalf("replay_reexecuted = %+v", sink.reexecuted)
+	}
+	got := sink.reexecuted[0]
+	if got.NodeID != "n1" || got.ToolName != "lookup" || got.Reason != ReplayReasonNoRecordedResult || got.VerificationHint != "GET /lookup/:id" {
+		t.Fatalf("payload = %+v", got)
+	}
+}
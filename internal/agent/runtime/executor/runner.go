@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"rag-platform/internal/agent/compat"
 	"rag-platform/internal/agent/determinism"
 	"rag-platform/internal/agent/planner"
 	"rag-platform/internal/agent/replay"
@@ -138,6 +139,16 @@ type JobStoreForRunner interface {
 	UpdateStatus(ctx context.Context, jobID string, status int) error
 }
 
+// Runner 写入的 Job 状态值，与 job.JobStatus 一致（executor 不依赖 job 包）；迁移是否合法由 job 状态机在 JobStore 中校验
+const (
+	statusPending   = 0
+	statusCompleted = 2
+	statusFailed    = 3
+	statusWaiting   = 5
+	statusParked    = 6
+	statusDeferred  = 8
+)
+
 // PlanGeneratedSink 规划结果事件化：Plan 成功后由 Runner 调用，便于 Trace/Replay 确定复现
 type PlanGeneratedSink interface {
 	AppendPlanGenerated(ctx context.Context, jobID string, taskGraphJSON []byte, goal string) error
@@ -205,10 +216,15 @@ type Runner struct {
 	recordedEffectsRecorder agenteffects.RecordedEffectsRecorder // 可选；2.0 Step Contract，step 内 Now/UUID/HTTP 经此记录
 	compensationRegistry    CompensationRegistry                 // 可选；compensatable_failure 时调用补偿并写 step_compensated
 	replayBuilder           replay.ReplayContextBuilder
-	replayPolicy            replaysandbox.ReplayPolicy // 可选；Replay 时按策略决定执行或注入
-	stepTimeout             time.Duration              // 可选；单步最大执行时间，超时按 retryable_failure（design/scheduler-correctness.md Step timeout）
-	stepValidators          []StepValidator            // 可选；Step Contract 2.0 校验（design/step-contract.md）
-	maxParallelSteps        int                        // 可选；>0 时同层节点可并行执行（design/dag-parallel-execution.md），0=仅顺序
+	replayPolicy            replaysandbox.ReplayPolicy                      // 可选；Replay 时按策略决定执行或注入
+	stepTimeout             time.Duration                                   // 可选；单步最大执行时间，超时按 retryable_failure（design/scheduler-correctness.md Step timeout）
+	stepValidators          []StepValidator                                 // 可选；Step Contract 2.0 校验（design/step-contract.md）
+	maxParallelSteps        int                                             // 可选；>0 时同层节点可并行执行（design/dag-parallel-execution.md），0=仅顺序
+	stepBoundaryGate        func(ctx context.Context, j *JobForRunner) bool // 可选；每个 step 边界调用，返回 true 时暂停 Job（租户维护窗口）
+	reflector               Reflector                                       // 可选；自我反思，每 N 步或失败时复盘轨迹并提出计划修订
+	reflection              ReflectionConfig
+	scratchpadLimits        runtime.ScratchpadLimits         // 可选；步骤间共享 scratchpad 的大小限制，零值用默认
+	workspaces              func(jobID string) sdk.Workspace // 可选；Job 级文件工作区，经 sdk.WorkspaceFromContext 提供给工具
 }
 
 // NewRunner 创建 Runner（仅编译与单次 Invoke）
@@ -216,6 +232,19 @@ func NewRunner(compiler *Compiler) *Runner {
 	return &Runner{compiler: compiler}
 }
 
+// SetWorkspaces 设置 Job 工作区提供者；RunForJob / Advance 执行前将该 Job 的工作区注入 ctx
+func (r *Runner) SetWorkspaces(provider func(jobID string) sdk.Workspace) {
+	r.workspaces = provider
+}
+
+// attachWorkspace 将 Job 工作区注入 ctx（未配置时原样返回）
+func (r *Runner) attachWorkspace(ctx context.Context, jobID string) context.Context {
+	if r.workspaces == nil || jobID == "" {
+		return ctx
+	}
+	return sdk.WithWorkspace(ctx, r.workspaces(jobID))
+}
+
 // SetCheckpointStores 设置 Checkpoint 与 Job 存储，启用 RunForJob 的 node-level checkpoint 与恢复
 func (r *Runner) SetCheckpointStores(cp runtime.CheckpointStore, js JobStoreForRunner) {
 	r.checkpointStore = cp
@@ -288,14 +317,19 @@ func (r *Runner) SetMaxParallelSteps(n int) {
 	r.maxParallelSteps = n
 }
 
+// SetStepBoundaryGate 设置 step 边界暂停判定（可选）；gate 返回 true 时 Job 置为 Deferred 并返回 ErrJobDeferred，已完成步由 checkpoint 保留
+func (r *Runner) SetStepBoundaryGate(gate func(ctx context.Context, j *JobForRunner) bool) {
+	r.stepBoundaryGate = gate
+}
+
 // nextRunnableBatch 返回下一可执行步的索引列表（同层或单步）。completedSet 的 key 为 effectiveStepID。
 // 若 levelGroups 为 nil 则按顺序返回第一个未完成的步。
-func (r *Runner) nextRunnableBatch(steps []SteppableStep, levelGroups [][]string, completedSet map[string]struct{}, jobID, decisionID string) []int {
+func (r *Runner) nextRunnableBatch(steps []SteppableStep, levelGroups [][]string, completedSet map[string]struct{}, jobID, decisionID string, parallel bool) []int {
 	nodeToIndex := make(map[string]int)
 	for i, s := range steps {
 		nodeToIndex[s.NodeID] = i
 	}
-	if levelGroups != nil && r.maxParallelSteps > 0 {
+	if levelGroups != nil && parallel {
 		for _, level := range levelGroups {
 			var indices []int
 			for _, nodeID := range level {
@@ -337,6 +371,7 @@ func (r *Runner) runParallelLevel(
 	type result struct {
 		idx     int
 		payload *AgentDAGPayload
+		pad     *runtime.ScratchpadStore
 		err     error
 	}
 	runCtx := WithJobID(ctx, j.ID)
@@ -352,6 +387,9 @@ func (r *Runner) runParallelLevel(
 	if replayCtx != nil && len(replayCtx.PendingToolInvocations) > 0 {
 		runCtx = WithPendingToolInvocations(runCtx, replayCtx.PendingToolInvocations)
 	}
+	if replayCtx != nil && len(replayCtx.ToolProgressStates) > 0 {
+		runCtx = WithToolProgressStates(runCtx, replayCtx.ToolProgressStates)
+	}
 	if replayCtx != nil && len(replayCtx.StateChangesByStep) > 0 {
 		m := make(map[string][]StateChangeForVerify)
 		for nodeID, recs := range replayCtx.StateChangesByStep {
@@ -371,6 +409,7 @@ func (r *Runner) runParallelLevel(
 		nodeIDsForBatch = append(nodeIDsForBatch, steps[idx].NodeID)
 	}
 	sort.Strings(nodeIDsForBatch)
+	r.beginTransactions(ctx, j.ID, taskGraph, steps, nodeIDsForBatch, payload.Results)
 	for _, nodeID := range nodeIDsForBatch {
 		if r.nodeEventSink != nil {
 			_ = r.nodeEventSink.AppendNodeStarted(ctx, j.ID, nodeID, 1, "")
@@ -388,9 +427,9 @@ func (r *Runner) runParallelLevel(
 		} else {
 			stepCtx = runtime.WithClock(stepCtx, func() time.Time { return time.Now() })
 		}
-		if r.stepTimeout > 0 {
+		if timeout := r.stepTimeoutFor(j); timeout > 0 {
 			var cancel context.CancelFunc
-			stepCtx, cancel = context.WithTimeout(stepCtx, r.stepTimeout)
+			stepCtx, cancel = context.WithTimeout(stepCtx, timeout)
 			defer cancel()
 		}
 		payloadCopy := &AgentDAGPayload{Goal: payload.Goal, AgentID: payload.AgentID, SessionID: payload.SessionID, Results: make(map[string]any)}
@@ -403,10 +442,14 @@ func (r *Runner) runParallelLevel(
 			if len(r.stepValidators) > 0 {
 				runErr = r.runStepValidators(sCtx, j.ID, eid, s.NodeID, s.NodeType, nil)
 			}
+			padCtx, pad, padErr := r.attachScratchpad(sCtx, payloadCopy, s.NodeID)
+			if runErr == nil {
+				runErr = padErr
+			}
 			if runErr == nil {
-				_, runErr = s.Run(sCtx, payloadCopy)
+				_, runErr = s.Run(padCtx, payloadCopy)
 			}
-			ch <- result{idx: idx, payload: payloadCopy, err: runErr}
+			ch <- result{idx: idx, payload: payloadCopy, pad: pad, err: runErr}
 		}()
 	}
 	var firstErr error
@@ -442,10 +485,27 @@ func (r *Runner) runParallelLevel(
 		if resultType == StepResultRetryableFailure {
 			metrics.StepRetriesTotal.WithLabelValues(tenant, nodeType, reason).Inc()
 		}
+		recordStepMode(ctx, step.NodeType, StepModeLive)
 		if r.nodeEventSink != nil {
 			_ = r.nodeEventSink.AppendNodeFinished(ctx, j.ID, step.NodeID, []byte("{}"), 0, string(resultType), 1, resultType, reason, effectiveStepID, "")
 		}
-		_ = r.jobStore.UpdateStatus(ctx, j.ID, 3)
+		// 同批已成功的事务成员副作用已提交，合并其结果后一并补偿
+		committed := make(map[string]any, len(payload.Results)+len(results))
+		for k, v := range payload.Results {
+			committed[k] = v
+		}
+		for _, res := range results {
+			if res.err == nil {
+				if v, ok := res.payload.Results[steps[res.idx].NodeID]; ok {
+					committed[steps[res.idx].NodeID] = v
+				}
+			}
+		}
+		rolledBack := r.rollbackTransactions(ctx, j.ID, taskGraph, steps, agent, step.NodeID, reason, committed)
+		_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
+		if rolledBack {
+			return fmt.Errorf("executor: 节点 %s parallel execution failed: %w: %w", step.NodeID, firstErr, ErrTransactionRolledBack)
+		}
 		return fmt.Errorf("executor: 节点 %s parallel execution failed: %w", step.NodeID, firstErr)
 	}
 	// Merge results (deterministic order by node ID)
@@ -465,13 +525,17 @@ func (r *Runner) runParallelLevel(
 				}
 				payload.Results[nodeID] = v
 			}
+			if err := mergeScratchpad(payload, res.pad); err != nil {
+				_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
+				return err
+			}
 			break
 		}
 	}
 	// NodeFinished for each (sorted by node ID)
 	payloadResultsMerged, err := marshalJSONForRunner(payload.Results, "parallel_payload_results_merged")
 	if err != nil {
-		_ = r.jobStore.UpdateStatus(ctx, j.ID, 3)
+		_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
 		return err
 	}
 	for _, nodeID := range nodeIDs {
@@ -481,23 +545,22 @@ func (r *Runner) runParallelLevel(
 			}
 			step := steps[res.idx]
 			effectiveStepID := DeterministicStepID(j.ID, runLoopDecisionID, res.idx, step.NodeType)
-			rt := StepResultPure
-			if step.NodeType == "tool" {
-				rt = StepResultSideEffectCommitted
-			}
+			rt := successResultType(step.NodeType)
+			recordStepMode(ctx, step.NodeType, StepModeLive)
 			if r.nodeEventSink != nil {
 				_ = r.nodeEventSink.AppendNodeFinished(ctx, j.ID, step.NodeID, payloadResultsMerged, 0, "ok", 1, rt, "", effectiveStepID, "")
 				_ = r.nodeEventSink.AppendStepCommitted(ctx, j.ID, step.NodeID, effectiveStepID, effectiveStepID, "")
 			}
 			if completedSet != nil {
 				completedSet[effectiveStepID] = struct{}{}
+				completedSet[step.NodeID] = struct{}{}
 			}
 			break
 		}
 	}
 	payloadResults, err := marshalJSONForRunner(payload.Results, "parallel_payload_results_checkpoint")
 	if err != nil {
-		_ = r.jobStore.UpdateStatus(ctx, j.ID, 3)
+		_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
 		return err
 	}
 	lastIdx := results[len(results)-1].idx
@@ -505,13 +568,14 @@ func (r *Runner) runParallelLevel(
 	cp := runtime.NewNodeCheckpoint(agent.ID, sessionID, j.ID, lastNodeID, graphBytes, payloadResults, nil)
 	cpID, saveErr := r.checkpointStore.Save(ctx, cp)
 	if saveErr != nil {
-		_ = r.jobStore.UpdateStatus(ctx, j.ID, 3)
+		_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
 		return fmt.Errorf("executor: save checkpoint failed: %w", saveErr)
 	}
 	if agent.Session != nil {
 		agent.Session.SetLastCheckpoint(cpID)
 	}
 	_ = r.jobStore.UpdateCursor(ctx, j.ID, cpID)
+	r.commitTransactions(ctx, j.ID, taskGraph, steps, nodeIDs, payload.Results)
 	return nil
 }
 
@@ -528,7 +592,7 @@ func (r *Runner) Run(ctx context.Context, agent *runtime.Agent, goal string) err
 		agent.SetStatus(runtime.StatusIdle)
 	}()
 
-	planOut, err := agent.Planner.Plan(ctx, goal, agent.Memory)
+	planOut, err := agent.Planner.Plan(planner.WithAgentID(ctx, agent.ID), goal, agent.Memory)
 	if err != nil {
 		agent.SetStatus(runtime.StatusFailed)
 		return fmt.Errorf("executor: Plan failed: %w", err)
@@ -573,6 +637,59 @@ type JobForRunner struct {
 	Goal     string
 	Cursor   string
 	TenantID string // 多租户；空则 "default"，供 metrics 等使用
+	// Features 版本协商后 Job 可用的特性（compat.Feature*）；nil 表示协商前创建的旧 Job，本地特性全部可用
+	Features []string
+	// StepTimeout 该 Job 的单步超时（如交互式 Job 更紧的超时）；>0 时与 Runner 全局 stepTimeout 取较小者
+	StepTimeout time.Duration
+	// Profile 提交时选择的执行档位；nil 时沿用 Runner 全局配置
+	Profile *ExecutionProfile
+}
+
+// stepTimeoutFor 返回 Job 生效的单步超时：Job 级与 Runner 全局取较小的非零值
+func (r *Runner) stepTimeoutFor(j *JobForRunner) time.Duration {
+	base := r.stepTimeout
+	if j != nil && j.Profile != nil && j.Profile.StepTimeout > 0 {
+		base = j.Profile.StepTimeout
+	}
+	if j == nil || j.StepTimeout <= 0 {
+		return base
+	}
+	if base > 0 && base < j.StepTimeout {
+		return base
+	}
+	return j.StepTimeout
+}
+
+// parallelFor 判定 Job 是否按层并行执行：执行档位优先，否则按 Runner maxParallelSteps；均需 Job 协商 parallel_dag
+func (r *Runner) parallelFor(j *JobForRunner) bool {
+	if !j.featureEnabled(compat.FeatureParallelDAG) {
+		return false
+	}
+	if j != nil && j.Profile != nil {
+		return j.Profile.Parallel
+	}
+	return r.maxParallelSteps > 0
+}
+
+// featureEnabled 协商后的 Job 仅启用其声明的特性，避免产生续跑 Worker 无法识别的事件
+func (j *JobForRunner) featureEnabled(feature string) bool {
+	if j == nil || j.Features == nil {
+		return true
+	}
+	for _, f := range j.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedEffectsFor 返回该 Job 可用的 Recorded Effects 记录器；Job 未协商 recorded_http 时不记录
+func (r *Runner) recordedEffectsFor(j *JobForRunner) agenteffects.RecordedEffectsRecorder {
+	if !j.featureEnabled(compat.FeatureRecordedHTTP) {
+		return nil
+	}
+	return r.recordedEffectsRecorder
 }
 
 // Advance 根据当前 state（仅由事件流或 Checkpoint 推导）执行下一原子步并写事件；若无下一步则标记完成并返回 done=true（plan 3.2 事件驱动循环）
@@ -580,6 +697,7 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 	if state == nil || state.ReplayContext == nil {
 		return true, nil
 	}
+	ctx = r.attachWorkspace(ctx, jobID)
 	taskGraph, gerr := state.TaskGraph()
 	if gerr != nil || taskGraph == nil {
 		return true, nil
@@ -613,9 +731,6 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 		startIndex = i
 		break
 	}
-	const statusCompleted = 2
-	const statusFailed = 3
-	const statusWaiting = 5
 	if startIndex < 0 || startIndex >= len(steps) {
 		_ = r.jobStore.UpdateStatus(ctx, jobID, statusCompleted)
 		return true, nil
@@ -634,7 +749,7 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 	// 命令级跳过与注入（同 runLoop）
 	if replayCtx != nil {
 		if r.replayPolicy != nil {
-			decision := r.replayPolicy.Decide(step.NodeID, commandID, step.NodeType, replayCtx)
+			decision, toolName := r.decideReplay(taskGraph, step, commandID, replayCtx)
 			if decision.Inject && len(decision.Result) > 0 {
 				var nodeResult interface{}
 				if err := json.Unmarshal(decision.Result, &nodeResult); err == nil {
@@ -648,13 +763,13 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 					_ = r.jobStore.UpdateStatus(ctx, jobID, statusFailed)
 					return false, err
 				}
-				if _, done := completedSet[effectiveStepID]; !done && r.nodeEventSink != nil {
-					rt := StepResultPure
-					if step.NodeType == "tool" {
-						rt = StepResultSideEffectCommitted
+				if _, done := completedSet[effectiveStepID]; !done {
+					recordStepMode(ctx, step.NodeType, StepModeInjected)
+					if r.nodeEventSink != nil {
+						rt := successResultType(step.NodeType)
+						_ = r.nodeEventSink.AppendNodeFinished(ctx, jobID, step.NodeID, payloadResults, 0, "", 0, rt, "", effectiveStepID, "")
+						_ = r.nodeEventSink.AppendStepCommitted(ctx, jobID, step.NodeID, effectiveStepID, commandID, "")
 					}
-					_ = r.nodeEventSink.AppendNodeFinished(ctx, jobID, step.NodeID, payloadResults, 0, "", 0, rt, "", effectiveStepID, "")
-					_ = r.nodeEventSink.AppendStepCommitted(ctx, jobID, step.NodeID, effectiveStepID, commandID, "")
 				}
 				cp := runtime.NewNodeCheckpoint(agent.ID, sessionID, jobID, step.NodeID, graphBytes, payloadResults, nil)
 				cpID, saveErr := r.checkpointStore.Save(ctx, cp)
@@ -668,9 +783,12 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 				_ = r.jobStore.UpdateCursor(ctx, jobID, cpID)
 				return false, nil
 			}
-			if !decision.Inject && (decision.Kind == replaysandbox.SideEffect || decision.Kind == replaysandbox.External) {
+			if !decision.Inject && (decision.Kind == replaysandbox.SideEffect || decision.Kind == replaysandbox.External) && !isBranchSkipped(taskGraph, step.NodeID, payload.Results) {
 				_ = r.jobStore.UpdateStatus(ctx, jobID, statusFailed)
-				return false, fmt.Errorf("executor: replay 时副作用节点 %s 无已记录结果，forbidden执行", step.NodeID)
+				return false, replayPolicyDenied(ctx, step.NodeID, step.NodeType, decision.Kind)
+			}
+			if !decision.Inject && decision.Kind == replaysandbox.Idempotent {
+				r.recordReplayReexecution(ctx, jobID, step, effectiveStepID, commandID, toolName, decision)
 			}
 		} else {
 			if _, committed := replayCtx.CompletedCommandIDs[commandID]; committed {
@@ -688,13 +806,13 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 					_ = r.jobStore.UpdateStatus(ctx, jobID, statusFailed)
 					return false, err
 				}
-				if _, done := completedSet[effectiveStepID]; !done && r.nodeEventSink != nil {
-					rt := StepResultPure
-					if step.NodeType == "tool" {
-						rt = StepResultSideEffectCommitted
+				if _, done := completedSet[effectiveStepID]; !done {
+					recordStepMode(ctx, step.NodeType, StepModeInjected)
+					if r.nodeEventSink != nil {
+						rt := successResultType(step.NodeType)
+						_ = r.nodeEventSink.AppendNodeFinished(ctx, jobID, step.NodeID, payloadResults, 0, "", 0, rt, "", effectiveStepID, "")
+						_ = r.nodeEventSink.AppendStepCommitted(ctx, jobID, step.NodeID, effectiveStepID, commandID, "")
 					}
-					_ = r.nodeEventSink.AppendNodeFinished(ctx, jobID, step.NodeID, payloadResults, 0, "", 0, rt, "", effectiveStepID, "")
-					_ = r.nodeEventSink.AppendStepCommitted(ctx, jobID, step.NodeID, effectiveStepID, commandID, "")
 				}
 				cp := runtime.NewNodeCheckpoint(agent.ID, sessionID, jobID, step.NodeID, graphBytes, payloadResults, nil)
 				cpID, saveErr := r.checkpointStore.Save(ctx, cp)
@@ -719,6 +837,7 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 		_ = r.jobStore.UpdateStatus(ctx, jobID, statusFailed)
 		return false, err
 	}
+	r.beginTransactions(ctx, jobID, taskGraph, steps, []string{step.NodeID}, payload.Results)
 	if r.nodeEventSink != nil {
 		_ = r.nodeEventSink.AppendNodeStarted(ctx, jobID, step.NodeID, 1, "")
 	}
@@ -730,6 +849,9 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 	if replayCtx != nil && len(replayCtx.PendingToolInvocations) > 0 {
 		ctx = WithPendingToolInvocations(ctx, replayCtx.PendingToolInvocations)
 	}
+	if replayCtx != nil && len(replayCtx.ToolProgressStates) > 0 {
+		ctx = WithToolProgressStates(ctx, replayCtx.ToolProgressStates)
+	}
 	if replayCtx != nil && len(replayCtx.StateChangesByStep) > 0 {
 		m := make(map[string][]StateChangeForVerify)
 		for nodeID, recs := range replayCtx.StateChangesByStep {
@@ -747,18 +869,19 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 	} else {
 		runCtx = runtime.WithClock(runCtx, func() time.Time { return time.Now() })
 	}
-	if r.stepTimeout > 0 {
+	if timeout := r.stepTimeoutFor(j); timeout > 0 {
 		var cancel context.CancelFunc
-		runCtx, cancel = context.WithTimeout(runCtx, r.stepTimeout)
+		runCtx, cancel = context.WithTimeout(runCtx, timeout)
 		defer cancel()
 	}
 	// 2.0 Deterministic Replay：标记 Replay 模式，step/effects 内可通过 determinism.IsReplay(ctx) 判断；ReplayGuard 可据此 panic
 	runCtx = determinism.WithReplay(runCtx, replayCtx != nil)
 	// 2.0 Step Contract：注入 RecordedEffects 与 sdk.RuntimeContext，step 内仅能通过 Runtime Now/UUID/HTTP
-	if r.recordedEffectsRecorder != nil || replayCtx != nil {
-		runCtx = agenteffects.WithRecordedEffects(runCtx, jobID, effectiveStepID, replayCtx, r.recordedEffectsRecorder)
+	if recorder := r.recordedEffectsFor(j); recorder != nil || replayCtx != nil {
+		runCtx = agenteffects.WithRecordedEffects(runCtx, jobID, effectiveStepID, replayCtx, recorder)
 	}
 	runCtx = sdk.WithRuntimeContext(runCtx, newRuntimeContextAdapter(jobID, effectiveStepID))
+	runCtx = r.attachEventEmitter(runCtx, jobID, step.NodeID, effectiveStepID)
 	var runErr error
 	if len(r.stepValidators) > 0 {
 		if err := r.runStepValidators(runCtx, jobID, effectiveStepID, step.NodeID, step.NodeType, nil); err != nil {
@@ -766,9 +889,22 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 		}
 	}
 	if runErr == nil {
-		payload, runErr = step.Run(runCtx, payload)
+		var pad *runtime.ScratchpadStore
+		runCtx, pad, runErr = r.attachScratchpad(runCtx, payload, step.NodeID)
+		if runErr == nil {
+			// 失败步骤可能返回 nil payload；保留原 payload，事务回滚需读取已提交成员的结果
+			var next *AgentDAGPayload
+			next, runErr = step.Run(runCtx, payload)
+			if next != nil {
+				payload = next
+			}
+			// 失败步骤的 scratchpad 写入丢弃；等待信号时保留，随 resumption 上下文持久化
+			if _, waiting := signalWaitFromError(runErr); runErr == nil || waiting {
+				commitScratchpad(payload, pad)
+			}
+		}
 	}
-	if correlationKey, waitReason, waitNow := signalWaitFromError(runErr); waitNow {
+	if wait, waitNow := signalWaitFromError(runErr); waitNow {
 		if r.nodeEventSink != nil {
 			resumptionCtx := map[string]interface{}{
 				"payload_results":  payload.Results,
@@ -780,9 +916,13 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 				_ = r.jobStore.UpdateStatus(ctx, jobID, statusFailed)
 				return false, err
 			}
-			_ = r.nodeEventSink.AppendJobWaiting(ctx, jobID, step.NodeID, "signal", waitReason, time.Now().Add(24*time.Hour), correlationKey, resumptionBytes)
+			_ = r.nodeEventSink.AppendJobWaiting(ctx, jobID, step.NodeID, wait.WaitKind, wait.Reason, time.Now().Add(24*time.Hour), wait.CorrelationKey, resumptionBytes)
+		}
+		if wait.Park {
+			_ = r.jobStore.UpdateStatus(ctx, jobID, statusParked)
+		} else {
+			_ = r.jobStore.UpdateStatus(ctx, jobID, statusWaiting)
 		}
-		_ = r.jobStore.UpdateStatus(ctx, jobID, statusWaiting)
 		return false, ErrJobWaiting
 	}
 	durationMs := time.Since(stepStart).Milliseconds()
@@ -812,11 +952,7 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 		metrics.StepRetriesTotal.WithLabelValues(tenant, nodeType, reason).Inc()
 	}
 	if resultType == StepResultSuccess {
-		if step.NodeType == "tool" {
-			resultType = StepResultSideEffectCommitted
-		} else {
-			resultType = StepResultPure
-		}
+		resultType = successResultType(step.NodeType)
 	}
 	payloadResults, err := marshalJSONForRunner(payload.Results, "advance_payload_results")
 	if err != nil {
@@ -826,6 +962,7 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 	if runErr != nil && len(payloadResults) == 0 {
 		payloadResults = []byte("{}")
 	}
+	recordStepMode(ctx, step.NodeType, StepModeLive)
 	if r.nodeEventSink != nil {
 		stateStr := "ok"
 		if resultType != StepResultSuccess && resultType != StepResultPure && resultType != StepResultSideEffectCommitted && resultType != StepResultCompensated {
@@ -843,8 +980,12 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 				}
 			}
 		}
+		rolledBack := r.rollbackTransactions(ctx, jobID, taskGraph, steps, agent, step.NodeID, reason, payload.Results)
 		_ = r.jobStore.UpdateStatus(ctx, jobID, statusFailed)
 		sf := &StepFailure{Type: resultType, Inner: runErr, NodeID: step.NodeID}
+		if rolledBack {
+			return false, fmt.Errorf("executor: 节点 %s execution failed (%s): %w: %w", step.NodeID, resultType, sf, ErrTransactionRolledBack)
+		}
 		return false, fmt.Errorf("executor: 节点 %s execution failed (%s): %w", step.NodeID, resultType, sf)
 	}
 	if r.nodeEventSink != nil {
@@ -861,9 +1002,16 @@ func (r *Runner) Advance(ctx context.Context, jobID string, state *replay.Execut
 		agent.Session.SetLastCheckpoint(cpID)
 	}
 	_ = r.jobStore.UpdateCursor(ctx, jobID, cpID)
+	r.commitTransactions(ctx, jobID, taskGraph, steps, []string{step.NodeID}, payload.Results)
 	return false, nil
 }
 
+// resumingFromRollback Job 游标仍指向最近一次手动回滚（checkpoint_rolled_back）的检查点，即回滚后尚未产生新检查点
+func (r *Runner) resumingFromRollback(ctx context.Context, j *JobForRunner) bool {
+	rctx, err := r.replayBuilder.BuildFromSnapshot(ctx, j.ID)
+	return err == nil && rctx != nil && rctx.RollbackCheckpointID != "" && rctx.RollbackCheckpointID == j.Cursor
+}
+
 func hasReplayProgress(rctx *replay.ReplayContext) bool {
 	if rctx == nil {
 		return false
@@ -880,6 +1028,8 @@ func (r *Runner) RunForJob(ctx context.Context, agent *runtime.Agent, j *JobForR
 	if agent == nil || j == nil {
 		return fmt.Errorf("executor: agent 或 job 为空")
 	}
+	ctx = r.attachWorkspace(ctx, j.ID)
+	ctx = WithExecutionProfile(ctx, j.Profile)
 	if r.checkpointStore == nil || r.jobStore == nil {
 		return r.Run(ctx, agent, j.Goal)
 	}
@@ -902,7 +1052,6 @@ func (r *Runner) RunForJob(ctx context.Context, agent *runtime.Agent, j *JobForR
 	var replayCtx *replay.ReplayContext
 
 	// 与 job.JobStatus 对应，避免 executor 依赖 job 包：2=Completed, 3=Failed
-	const statusFailed = 3
 	if j.Cursor != "" {
 		cp, loadErr := r.checkpointStore.Load(ctx, j.Cursor)
 		if loadErr != nil || cp == nil {
@@ -942,8 +1091,13 @@ func (r *Runner) RunForJob(ctx context.Context, agent *runtime.Agent, j *JobForR
 				return fmt.Errorf("executor: deserialize PayloadResults failed: %w", err)
 			}
 		}
-		// 有 replayBuilder 时从 checkpoint 构建 state，走事件驱动循环
-		if r.replayBuilder != nil {
+		// 有 replayBuilder 时从 checkpoint 构建 state，走事件驱动循环；
+		// 刚手动回滚到该检查点时，被作废的节点没有已记录结果、Replay 会拒绝执行，直接进入 runLoop 重新真实执行
+		rollbackResume := r.replayBuilder != nil && r.resumingFromRollback(ctx, j)
+		if rollbackResume {
+			ctx = WithRollbackResume(ctx)
+		}
+		if r.replayBuilder != nil && !rollbackResume {
 			rc := &replay.ReplayContext{
 				TaskGraphState:           cp.TaskGraphState,
 				CursorNode:               cp.CursorNode,
@@ -979,6 +1133,15 @@ func (r *Runner) RunForJob(ctx context.Context, agent *runtime.Agent, j *JobForR
 		if r.replayBuilder != nil {
 			rctx, rerr := r.replayBuilder.BuildFromSnapshot(ctx, j.ID)
 			if rerr == nil && rctx != nil {
+				// 计划审阅：Agent 要求审阅且当前计划尚未通过时不开始执行，挂起等待 POST /api/jobs/:id/plan/review 重新入队
+				if rctx.PlanReviewPending() && !hasReplayProgress(rctx) {
+					_ = r.jobStore.UpdateStatus(ctx, j.ID, statusParked)
+					// 审阅恰在挂起前送达时 API 看到的仍是 Running，不会唤醒：挂起后重读一次事件流，已通过则重新入队
+					if latest, _ := r.replayBuilder.BuildFromEvents(ctx, j.ID); latest != nil && !latest.PlanReviewPending() {
+						_ = r.jobStore.UpdateStatus(ctx, j.ID, statusPending)
+					}
+					return ErrJobWaiting
+				}
 				if recoveredGraph, rerr := rctx.TaskGraph(); rerr == nil && recoveredGraph != nil {
 					if len(rctx.WorkingMemorySnapshot) > 0 && agent != nil && agent.Session != nil {
 						var as runtime.AgentState
@@ -1049,28 +1212,58 @@ runLoop:
 		tenantCtx = j.TenantID
 	}
 	ctx = WithTenantID(ctx, tenantCtx)
-	const statusCompleted = 2 // 对应 job.StatusCompleted
-	const statusWaiting = 5   // 对应 job.StatusWaiting（design/job-state-machine.md）
+	if j.Profile != nil && j.Profile.ValidatePlan {
+		if err := planner.ValidateTaskGraph(taskGraph, nil); err != nil {
+			_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
+			return &StepFailure{Type: StepResultPermanentFailure, Inner: fmt.Errorf("executor: plan rejected by execution profile %s: %w", j.Profile.Name, err)}
+		}
+	}
 	graphBytes, _ := taskGraph.Marshal()
 	runLoopDecisionID := PlanDecisionID(graphBytes)
 	levelGroups, _ := LevelGroups(taskGraph)
+	// Self-Reflection：修订计划生效后替换剩余执行；已完成节点按 node_id 保留在 completedSet 中不会重复执行
+	reflectState := &reflectionState{planVersion: 1}
+	applyEvolvedPlan := func(g *planner.TaskGraph, s []SteppableStep) {
+		taskGraph, steps = g, s
+		graphBytes, _ = taskGraph.Marshal()
+		runLoopDecisionID = PlanDecisionID(graphBytes)
+		levelGroups, _ = LevelGroups(taskGraph)
+	}
 	for {
-		batch := r.nextRunnableBatch(steps, levelGroups, completedSet, j.ID, runLoopDecisionID)
+		parallel := r.parallelFor(j)
+		batch := r.nextRunnableBatch(steps, levelGroups, completedSet, j.ID, runLoopDecisionID, parallel)
 		if len(batch) == 0 {
 			_ = r.jobStore.UpdateStatus(ctx, j.ID, statusCompleted)
 			return nil
 		}
+		if r.stepBoundaryGate != nil && r.stepBoundaryGate(ctx, j) {
+			_ = r.jobStore.UpdateStatus(ctx, j.ID, statusDeferred)
+			return ErrJobDeferred
+		}
 		hasWait := false
+		var ready []int
 		for _, idx := range batch {
-			if isWaitLikeNodeType(steps[idx].NodeType) {
+			// join 节点可能挂起等待子 Job，与 wait 类节点一样不参与并行批次
+			if isWaitLikeNodeType(steps[idx].NodeType) || steps[idx].NodeType == planner.NodeJoin {
 				hasWait = true
-				break
+				continue
 			}
+			ready = append(ready, idx)
+		}
+		// 预取：先并行执行同层其余就绪节点，wait/join 节点留到下一批再挂起
+		if hasWait && parallel && j.Profile != nil && j.Profile.Prefetch && len(ready) > 0 {
+			batch, hasWait = ready, false
 		}
-		if len(batch) > 1 && r.maxParallelSteps > 0 && !hasWait {
+		if len(batch) > 1 && parallel && !hasWait {
 			if err := r.runParallelLevel(ctx, j, steps, batch, taskGraph, payload, agent, replayCtx, completedSet, graphBytes, runLoopDecisionID, sessionID); err != nil {
 				return err
 			}
+			reflectState.stepsSinceReflect += len(batch)
+			if r.reflector != nil && replayCtx == nil && r.reflection.EveryNSteps > 0 && reflectState.stepsSinceReflect >= r.reflection.EveryNSteps {
+				if g, s := r.reflectOnTrace(ctx, agent, j, reflectState, ReflectionTriggerInterval, steps[batch[len(batch)-1]].NodeID, "", taskGraph, steps, payload, completedSet); g != nil {
+					applyEvolvedPlan(g, s)
+				}
+			}
 			continue
 		}
 		i := batch[0]
@@ -1081,7 +1274,7 @@ runLoop:
 		// 命令级跳过（design/effect-system.md）：事件流中已 command_committed 的永不重放，仅注入结果并推进游标（或按 ReplayPolicy 决策）
 		if replayCtx != nil {
 			if r.replayPolicy != nil {
-				decision := r.replayPolicy.Decide(step.NodeID, commandID, step.NodeType, replayCtx)
+				decision, toolName := r.decideReplay(taskGraph, step, commandID, replayCtx)
 				if decision.Inject && len(decision.Result) > 0 {
 					var nodeResult interface{}
 					if err := json.Unmarshal(decision.Result, &nodeResult); err == nil {
@@ -1097,10 +1290,8 @@ runLoop:
 					}
 					if completedSet != nil {
 						if _, done := completedSet[effectiveStepID]; !done {
-							rt := StepResultPure
-							if step.NodeType == "tool" {
-								rt = StepResultSideEffectCommitted
-							}
+							recordStepMode(ctx, step.NodeType, StepModeInjected)
+							rt := successResultType(step.NodeType)
 							if r.nodeEventSink != nil {
 								_ = r.nodeEventSink.AppendNodeFinished(ctx, j.ID, step.NodeID, payloadResults, 0, "", 0, rt, "", effectiveStepID, "")
 								_ = r.nodeEventSink.AppendStepCommitted(ctx, j.ID, step.NodeID, effectiveStepID, commandID, "")
@@ -1120,9 +1311,12 @@ runLoop:
 					_ = r.jobStore.UpdateCursor(ctx, j.ID, cpID)
 					continue
 				}
-				if !decision.Inject && (decision.Kind == replaysandbox.SideEffect || decision.Kind == replaysandbox.External) {
+				if !decision.Inject && (decision.Kind == replaysandbox.SideEffect || decision.Kind == replaysandbox.External) && !isBranchSkipped(taskGraph, step.NodeID, payload.Results) {
 					_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
-					return fmt.Errorf("executor: replay 时副作用节点 %s 无已记录结果，forbidden执行", step.NodeID)
+					return replayPolicyDenied(ctx, step.NodeID, step.NodeType, decision.Kind)
+				}
+				if !decision.Inject && decision.Kind == replaysandbox.Idempotent {
+					r.recordReplayReexecution(ctx, j.ID, step, effectiveStepID, commandID, toolName, decision)
 				}
 			} else {
 				if _, committed := replayCtx.CompletedCommandIDs[commandID]; committed {
@@ -1142,10 +1336,8 @@ runLoop:
 					}
 					if completedSet != nil {
 						if _, done := completedSet[effectiveStepID]; !done {
-							rt := StepResultPure
-							if step.NodeType == "tool" {
-								rt = StepResultSideEffectCommitted
-							}
+							recordStepMode(ctx, step.NodeType, StepModeInjected)
+							rt := successResultType(step.NodeType)
 							if r.nodeEventSink != nil {
 								_ = r.nodeEventSink.AppendNodeFinished(ctx, j.ID, step.NodeID, payloadResults, 0, "", 0, rt, "", effectiveStepID, "")
 								_ = r.nodeEventSink.AppendStepCommitted(ctx, j.ID, step.NodeID, effectiveStepID, commandID, "")
@@ -1182,11 +1374,13 @@ runLoop:
 			_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
 			return err
 		}
+		r.beginTransactions(ctx, j.ID, taskGraph, steps, []string{step.NodeID}, payload.Results)
 		if r.nodeEventSink != nil {
 			_ = r.nodeEventSink.AppendNodeStarted(ctx, j.ID, step.NodeID, 1, "")
 		}
-		// Wait 节点：不执行，写 job_waiting 并置为 Waiting，由 API signal 后重新入队继续（design/job-state-machine.md）
-		if isWaitLikeNodeType(step.NodeType) {
+		// Wait 节点：不执行，写 job_waiting 并置为 Waiting，由 API signal 后重新入队继续（design/job-state-machine.md）；
+		// 未被分支选中的 wait 节点不挂起，经 step.Run 写入跳过标记
+		if isWaitLikeNodeType(step.NodeType) && !isBranchSkipped(taskGraph, step.NodeID, payload.Results) {
 			waitKind, reason, waitChannel := "", "", ""
 			var expiresAt time.Time
 			for _, n := range taskGraph.Nodes {
@@ -1263,7 +1457,7 @@ runLoop:
 			for _, n := range taskGraph.Nodes {
 				if n.ID == step.NodeID && n.Config != nil {
 					if park, ok := n.Config["park"].(bool); ok && park {
-						targetStatus = 6 // StatusParked
+						targetStatus = statusParked
 						break
 					}
 				}
@@ -1279,6 +1473,9 @@ runLoop:
 		if replayCtx != nil && len(replayCtx.PendingToolInvocations) > 0 {
 			ctx = WithPendingToolInvocations(ctx, replayCtx.PendingToolInvocations)
 		}
+		if replayCtx != nil && len(replayCtx.ToolProgressStates) > 0 {
+			ctx = WithToolProgressStates(ctx, replayCtx.ToolProgressStates)
+		}
 		if replayCtx != nil && len(replayCtx.StateChangesByStep) > 0 {
 			m := make(map[string][]StateChangeForVerify)
 			for nodeID, recs := range replayCtx.StateChangesByStep {
@@ -1299,18 +1496,19 @@ runLoop:
 		} else {
 			runCtx = runtime.WithClock(runCtx, func() time.Time { return time.Now() })
 		}
-		if r.stepTimeout > 0 {
+		if timeout := r.stepTimeoutFor(j); timeout > 0 {
 			var cancel context.CancelFunc
-			runCtx, cancel = context.WithTimeout(runCtx, r.stepTimeout)
+			runCtx, cancel = context.WithTimeout(runCtx, timeout)
 			defer cancel()
 		}
 		// 2.0 Deterministic Replay：标记 Replay 模式
 		runCtx = determinism.WithReplay(runCtx, replayCtx != nil)
 		// 2.0 Step Contract：注入 RecordedEffects 与 sdk.RuntimeContext
-		if r.recordedEffectsRecorder != nil || replayCtx != nil {
-			runCtx = agenteffects.WithRecordedEffects(runCtx, j.ID, effectiveStepID, replayCtx, r.recordedEffectsRecorder)
+		if recorder := r.recordedEffectsFor(j); recorder != nil || replayCtx != nil {
+			runCtx = agenteffects.WithRecordedEffects(runCtx, j.ID, effectiveStepID, replayCtx, recorder)
 		}
 		runCtx = sdk.WithRuntimeContext(runCtx, newRuntimeContextAdapter(j.ID, effectiveStepID))
+		runCtx = r.attachEventEmitter(runCtx, j.ID, step.NodeID, effectiveStepID)
 		var runErr error
 		if len(r.stepValidators) > 0 {
 			if err := r.runStepValidators(runCtx, j.ID, effectiveStepID, step.NodeID, step.NodeType, nil); err != nil {
@@ -1318,10 +1516,22 @@ runLoop:
 			}
 		}
 		if runErr == nil {
-			payload, runErr = step.Run(runCtx, payload)
+			var pad *runtime.ScratchpadStore
+			runCtx, pad, runErr = r.attachScratchpad(runCtx, payload, step.NodeID)
+			if runErr == nil {
+				var next *AgentDAGPayload
+				next, runErr = step.Run(runCtx, payload)
+				if next != nil {
+					payload = next
+				}
+				// 失败步骤的 scratchpad 写入丢弃；等待信号时保留，随 resumption 上下文持久化
+				if _, waiting := signalWaitFromError(runErr); runErr == nil || waiting {
+					commitScratchpad(payload, pad)
+				}
+			}
 		}
 		durationMs := time.Since(stepStart).Milliseconds()
-		if correlationKey, waitReason, waitNow := signalWaitFromError(runErr); waitNow {
+		if wait, waitNow := signalWaitFromError(runErr); waitNow {
 			if r.nodeEventSink != nil {
 				resumptionCtx := map[string]interface{}{
 					"payload_results":  payload.Results,
@@ -1333,9 +1543,13 @@ runLoop:
 					_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
 					return err
 				}
-				_ = r.nodeEventSink.AppendJobWaiting(ctx, j.ID, step.NodeID, "signal", waitReason, time.Now().Add(24*time.Hour), correlationKey, resumptionBytes)
+				_ = r.nodeEventSink.AppendJobWaiting(ctx, j.ID, step.NodeID, wait.WaitKind, wait.Reason, time.Now().Add(24*time.Hour), wait.CorrelationKey, resumptionBytes)
+			}
+			if wait.Park {
+				_ = r.jobStore.UpdateStatus(ctx, j.ID, statusParked)
+			} else {
+				_ = r.jobStore.UpdateStatus(ctx, j.ID, statusWaiting)
 			}
-			_ = r.jobStore.UpdateStatus(ctx, j.ID, statusWaiting)
 			return ErrJobWaiting
 		}
 		resultType, reason := ClassifyError(runErr)
@@ -1363,13 +1577,9 @@ runLoop:
 		if resultType == StepResultRetryableFailure {
 			metrics.StepRetriesTotal.WithLabelValues(tenant, nodeType, reason).Inc()
 		}
-		// 世界语义：tool 成功 = 已提交副作用；非 tool 成功 = 纯计算（Pure），replay 可重放
+		// 世界语义：tool 与声明副作用的自定义节点成功 = 已提交副作用；其余成功 = 纯计算（Pure），replay 可重放
 		if resultType == StepResultSuccess {
-			if step.NodeType == "tool" {
-				resultType = StepResultSideEffectCommitted
-			} else {
-				resultType = StepResultPure
-			}
+			resultType = successResultType(step.NodeType)
 		}
 		payloadResults, err := marshalJSONForRunner(payload.Results, "runloop_payload_results")
 		if err != nil {
@@ -1379,6 +1589,7 @@ runLoop:
 		if runErr != nil && len(payloadResults) == 0 {
 			payloadResults = []byte("{}")
 		}
+		recordStepMode(ctx, step.NodeType, StepModeLive)
 		if r.nodeEventSink != nil {
 			stateStr := "ok"
 			if resultType != StepResultSuccess && resultType != StepResultPure && resultType != StepResultSideEffectCommitted && resultType != StepResultCompensated {
@@ -1396,8 +1607,19 @@ runLoop:
 					}
 				}
 			}
+			// Self-Reflection on failure：auto_apply 下修订计划生效则按新计划继续，否则照常失败
+			if r.reflector != nil && replayCtx == nil && r.reflection.OnFailure {
+				if g, s := r.reflectOnTrace(ctx, agent, j, reflectState, ReflectionTriggerFailure, step.NodeID, reason, taskGraph, steps, payload, completedSet); g != nil {
+					applyEvolvedPlan(g, s)
+					continue
+				}
+			}
+			rolledBack := r.rollbackTransactions(ctx, j.ID, taskGraph, steps, agent, step.NodeID, reason, payload.Results)
 			_ = r.jobStore.UpdateStatus(ctx, j.ID, statusFailed)
 			sf := &StepFailure{Type: resultType, Inner: runErr, NodeID: step.NodeID}
+			if rolledBack {
+				return fmt.Errorf("executor: 节点 %s execution failed (%s): %w: %w", step.NodeID, resultType, sf, ErrTransactionRolledBack)
+			}
 			return fmt.Errorf("executor: 节点 %s execution failed (%s): %w", step.NodeID, resultType, sf)
 		}
 		if r.nodeEventSink != nil {
@@ -1462,6 +1684,23 @@ runLoop:
 		}
 		completedSet[effectiveStepID] = struct{}{}
 		completedSet[step.NodeID] = struct{}{}
+		r.commitTransactions(ctx, j.ID, taskGraph, steps, []string{step.NodeID}, payload.Results)
+		if r.reflector != nil && replayCtx == nil {
+			trigger := ""
+			if step.NodeType == planner.NodeReflect {
+				trigger = ReflectionTriggerNode
+			} else {
+				reflectState.stepsSinceReflect++
+				if r.reflection.EveryNSteps > 0 && reflectState.stepsSinceReflect >= r.reflection.EveryNSteps {
+					trigger = ReflectionTriggerInterval
+				}
+			}
+			if trigger != "" {
+				if g, s := r.reflectOnTrace(ctx, agent, j, reflectState, trigger, step.NodeID, "", taskGraph, steps, payload, completedSet); g != nil {
+					applyEvolvedPlan(g, s)
+				}
+			}
+		}
 		continue
 	}
 }
@@ -17,11 +17,14 @@ package executor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"rag-platform/internal/agent/compat"
 	"rag-platform/internal/agent/planner"
 	"rag-platform/internal/agent/replay"
 	"rag-platform/internal/agent/runtime"
@@ -118,6 +121,36 @@ func TestRunForJob_OnlyJobCreated_NoPlanGenerated_FailsAndSetsJobFailed(t *testi
 	}
 }
 
+// TestRunForJob_StepBoundaryGate_DefersJob 维护窗口：step 边界 gate 返回 true 时不执行下一步，Job 置为 Deferred 并返回 ErrJobDeferred
+func TestRunForJob_StepBoundaryGate_DefersJob(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job-maintenance-park"
+	eventStore := jobstore.NewMemoryStore()
+	graph := &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "lg1", Type: planner.NodeLangGraph}}}
+	appendPlanGeneratedForLangGraph(t, eventStore, jobID, graph)
+
+	client := &fakeLangGraphClient{invokeFunc: func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		return map[string]any{"ok": true}, nil
+	}}
+	fakeJobStore := &fakeJobStoreForRunner{}
+	runner := NewRunner(NewCompiler(map[string]NodeAdapter{planner.NodeLangGraph: &LangGraphNodeAdapter{Client: client}}))
+	runner.SetCheckpointStores(runtime.NewCheckpointStoreMem(), fakeJobStore)
+	runner.SetReplayContextBuilder(replay.NewReplayContextBuilder(eventStore))
+	runner.SetStepBoundaryGate(func(ctx context.Context, j *JobForRunner) bool { return j.TenantID == "t-maint" })
+
+	err := runner.RunForJob(ctx, &runtime.Agent{ID: "a1"}, &JobForRunner{ID: jobID, AgentID: "a1", Goal: "g", TenantID: "t-maint"})
+	if !errors.Is(err, ErrJobDeferred) {
+		t.Fatalf("RunForJob err = %v, want ErrJobDeferred", err)
+	}
+	if client.Calls() != 0 {
+		t.Fatalf("step executed %d times while parked", client.Calls())
+	}
+	const statusDeferred = 8
+	if _, gotStatus := fakeJobStore.getLast(); gotStatus != statusDeferred {
+		t.Errorf("UpdateStatus status = %d, want %d (Deferred)", gotStatus, statusDeferred)
+	}
+}
+
 // buildReplayableEventStream 构造含 PlanGenerated + command_committed + NodeFinished 的事件流，使 Replay 时所有步骤均从事件注入（design/effect-system.md）
 func buildReplayableEventStream(t *testing.T, store jobstore.JobStore, jobID string, taskGraph *planner.TaskGraph, nodeResults map[string][]byte) {
 	t.Helper()
@@ -347,3 +380,29 @@ func TestRunForJob_PlanOnly_ExecutesLLM(t *testing.T) {
 		t.Fatalf("UpdateStatus = %d, want %d", status, statusCompleted)
 	}
 }
+
+func TestJobForRunner_FeatureEnabled(t *testing.T) {
+	legacy := &JobForRunner{ID: "j1"}
+	negotiated := &JobForRunner{ID: "j2", Features: []string{compat.FeatureRecordedHTTP}}
+	if !legacy.featureEnabled(compat.FeatureParallelDAG) {
+		t.Fatal("legacy job should keep all local features")
+	}
+	if negotiated.featureEnabled(compat.FeatureParallelDAG) || !negotiated.featureEnabled(compat.FeatureRecordedHTTP) {
+		t.Fatal("negotiated job should only enable its features")
+	}
+	r := NewRunner(nil)
+	r.SetRecordedEffectsRecorder(nopRecorder{})
+	if r.recordedEffectsFor(&JobForRunner{Features: []string{}}) != nil || r.recordedEffectsFor(legacy) == nil {
+		t.Fatal("recorded effects should follow the recorded_http feature")
+	}
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) RecordTime(ctx context.Context, jobID, effectID string, t time.Time) error {
+	return nil
+}
+func (nopRecorder) RecordUUID(ctx context.Context, jobID, effectID, uuid string) error { return nil }
+func (nopRecorder) RecordHTTP(ctx context.Context, jobID, effectID string, req, resp []byte) error {
+	return nil
+}
@@ -136,3 +136,41 @@ func TestRunnerParallelLevel_TimeoutClassifiedAsRetryableFailure(t *testing.T) {
 		t.Fatalf("UpdateStatus status = %d, want %d (Failed)", gotStatus, statusFailed)
 	}
 }
+
+// TestRunnerParallelLevel_JobStepTimeoutTighterThanRunner 验证 Job 级 StepTimeout（如交互式 Job）比全局 step 超时更紧时生效。
+func TestRunnerParallelLevel_JobStepTimeoutTighterThanRunner(t *testing.T) {
+	r := NewRunner(nil)
+	r.SetStepTimeout(time.Minute)
+	r.jobStore = &fakeJobStoreForRunner{}
+	r.SetNodeEventSink(&timeoutNodeSink{})
+
+	steps := []SteppableStep{{
+		NodeID:   "n-chat",
+		NodeType: planner.NodeWorkflow,
+		Run: func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}}
+	g := &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n-chat", Type: planner.NodeWorkflow}}}
+	payload := &AgentDAGPayload{Goal: "chat", Results: map[string]any{}}
+	j := &JobForRunner{ID: "job-chat", AgentID: "a1", StepTimeout: 20 * time.Millisecond}
+
+	start := time.Now()
+	err := r.runParallelLevel(context.Background(), j, steps, []int{0}, g, payload, nil, nil, map[string]struct{}{}, nil, "d1", "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error should wrap context deadline exceeded, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("job step timeout not applied, elapsed %v", elapsed)
+	}
+
+	// 全局超时更紧时仍以全局为准
+	r.SetStepTimeout(10 * time.Millisecond)
+	if got := r.stepTimeoutFor(&JobForRunner{StepTimeout: time.Second}); got != 10*time.Millisecond {
+		t.Fatalf("stepTimeoutFor = %v, want 10ms", got)
+	}
+	if got := r.stepTimeoutFor(&JobForRunner{}); got != 10*time.Millisecond {
+		t.Fatalf("stepTimeoutFor without job timeout = %v, want 10ms", got)
+	}
+}
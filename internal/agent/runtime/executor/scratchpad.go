@@ -0,0 +1,152 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+
+	"rag-platform/internal/agent/runtime"
+)
+
+// ScratchpadResultKey payload.Results 中 scratchpad 的保留键；随 Results 写入 node_finished / state_checkpointed / checkpoint
+const ScratchpadResultKey = "_scratchpad"
+
+// scratchpadChangedKeyPrefix state_checkpointed changed_keys 中 scratchpad 条目的前缀（如 scratchpad.research.summary）
+const scratchpadChangedKeyPrefix = "scratchpad."
+
+var scratchpadPlaceholder = regexp.MustCompile(`\{\{scratchpad\.([A-Za-z0-9_.:-]+)\}\}`)
+
+// SetScratchpadLimits 设置 scratchpad 单值/总大小/key 数上限（可选）；零值字段使用默认值
+func (r *Runner) SetScratchpadLimits(l runtime.ScratchpadLimits) {
+	r.scratchpadLimits = l
+}
+
+// attachScratchpad 从 payload 载入 scratchpad 并注入 ctx；nodeID 记为本步写入者
+func (r *Runner) attachScratchpad(ctx context.Context, payload *AgentDAGPayload, nodeID string) (context.Context, *runtime.ScratchpadStore, error) {
+	var raw any
+	if payload != nil && payload.Results != nil {
+		raw = payload.Results[ScratchpadResultKey]
+	}
+	entries, err := runtime.LoadScratchpad(raw)
+	if err != nil {
+		return ctx, nil, err
+	}
+	pad := runtime.NewScratchpadStore(entries, r.scratchpadLimits, nodeID)
+	return runtime.WithScratchpad(ctx, pad), pad, nil
+}
+
+// commitScratchpad 将本步对 scratchpad 的变更写回 payload；无变更时不触碰 Results
+func commitScratchpad(payload *AgentDAGPayload, pad *runtime.ScratchpadStore) {
+	if payload == nil || len(pad.Changed()) == 0 {
+		return
+	}
+	if payload.Results == nil {
+		payload.Results = make(map[string]any)
+	}
+	snap := pad.Snapshot()
+	if len(snap) == 0 {
+		delete(payload.Results, ScratchpadResultKey)
+		return
+	}
+	payload.Results[ScratchpadResultKey] = snap
+}
+
+// mergeScratchpad 并行批次合并：仅把 pad 本步变更的 key 叠加到 dst，调用方按节点 ID 排序保证确定性
+func mergeScratchpad(dst *AgentDAGPayload, pad *runtime.ScratchpadStore) error {
+	changed := pad.Changed()
+	if dst == nil || len(changed) == 0 {
+		return nil
+	}
+	if dst.Results == nil {
+		dst.Results = make(map[string]any)
+	}
+	entries, err := runtime.LoadScratchpad(dst.Results[ScratchpadResultKey])
+	if err != nil {
+		return err
+	}
+	merged := make(map[string]runtime.ScratchpadEntry, len(entries)+len(changed))
+	for k, e := range entries {
+		merged[k] = e
+	}
+	for _, k := range changed {
+		if e, ok := pad.Entry(k); ok {
+			merged[k] = e
+		} else {
+			delete(merged, k)
+		}
+	}
+	if len(merged) == 0 {
+		delete(dst.Results, ScratchpadResultKey)
+		return nil
+	}
+	dst.Results[ScratchpadResultKey] = merged
+	return nil
+}
+
+// scratchpadChangedKeys 比较前后 scratchpad 快照，返回 scratchpad.<key> 形式的变更列表
+func scratchpadChangedKeys(before, after interface{}) []string {
+	mBefore, _ := before.(map[string]interface{})
+	mAfter, _ := after.(map[string]interface{})
+	seen := make(map[string]struct{}, len(mBefore)+len(mAfter))
+	for k := range mBefore {
+		seen[k] = struct{}{}
+	}
+	for k := range mAfter {
+		seen[k] = struct{}{}
+	}
+	var changed []string
+	for k := range seen {
+		if !jsonEqual(mBefore[k], mAfter[k]) {
+			changed = append(changed, scratchpadChangedKeyPrefix+k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// renderScratchpadPrompt 替换 prompt 中的 {{scratchpad.<key>}}：字符串原样插入，其他类型插入 JSON，未命中替换为空
+func renderScratchpadPrompt(ctx context.Context, prompt string) string {
+	pad := runtime.Scratchpad(ctx)
+	if pad == nil {
+		return prompt
+	}
+	return scratchpadPlaceholder.ReplaceAllStringFunc(prompt, func(m string) string {
+		key := scratchpadPlaceholder.FindStringSubmatch(m)[1]
+		e, ok := pad.Entry(key)
+		if !ok {
+			return ""
+		}
+		if e.Type == "string" {
+			var s string
+			if json.Unmarshal(e.Value, &s) == nil {
+				return s
+			}
+		}
+		return string(e.Value)
+	})
+}
+
+// setLLMScratchpadOutput llm 节点 config.scratchpad_out 非空时将生成结果写入该 key，供后续工具/LLM 步骤读取；非 Runner 执行（无 scratchpad）时忽略
+func setLLMScratchpadOutput(ctx context.Context, cfg map[string]any, resp string) error {
+	key, _ := cfg["scratchpad_out"].(string)
+	pad := runtime.Scratchpad(ctx)
+	if key == "" || pad == nil {
+		return nil
+	}
+	return pad.Set(key, resp)
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// promptRecorderLLM 记录每次 Generate 的 prompt
+type promptRecorderLLM struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (l *promptRecorderLLM) Generate(ctx context.Context, prompt string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prompts = append(l.prompts, prompt)
+	return "draft-v1", nil
+}
+
+// scratchpadToolExec 读取 scratchpad 中的 draft，写入 published 标记
+type scratchpadToolExec struct {
+	seenDraft string
+}
+
+func (s *scratchpadToolExec) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	draft, _, err := runtime.ScratchpadKey[string]("draft").Get(ctx)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	s.seenDraft = draft
+	if err := runtime.ScratchpadKey[map[string]any]("publish.meta").Set(ctx, map[string]any{"channel": "blog"}); err != nil {
+		return ToolResult{}, err
+	}
+	return ToolResult{Done: true, Output: "published"}, nil
+}
+
+func TestRunForJob_ScratchpadSharedAcrossSteps(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job-scratchpad"
+	eventStore := jobstore.NewMemoryStore()
+	taskGraph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "n1", Type: planner.NodeLLM, Config: map[string]any{"goal": "write", "scratchpad_out": "draft"}},
+			{ID: "n2", Type: planner.NodeTool, ToolName: "publish"},
+			{ID: "n3", Type: planner.NodeLLM, Config: map[string]any{"goal": "review {{scratchpad.draft}} via {{scratchpad.publish.meta}}{{scratchpad.missing}}"}},
+		},
+		Edges: []planner.TaskEdge{{From: "n1", To: "n2"}, {From: "n2", To: "n3"}},
+	}
+	graphBytes, err := taskGraph.Marshal()
+	if err != nil {
+		t.Fatalf("marshal graph: %v", err)
+	}
+	planPayload, _ := json.Marshal(map[string]interface{}{"task_graph": json.RawMessage(graphBytes), "goal": "g"})
+	if _, err := eventStore.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: planPayload}); err != nil {
+		t.Fatalf("append plan_generated: %v", err)
+	}
+
+	llm := &promptRecorderLLM{}
+	tools := &scratchpadToolExec{}
+	compiler := NewCompiler(map[string]NodeAdapter{
+		planner.NodeLLM:  &LLMNodeAdapter{LLM: llm},
+		planner.NodeTool: &ToolNodeAdapter{Tools: tools},
+	})
+	cpStore := runtime.NewCheckpointStoreMem()
+	runner := NewRunner(compiler)
+	runner.SetCheckpointStores(cpStore, &fakeJobStoreForRunner{})
+	runner.SetReplayContextBuilder(replay.NewReplayContextBuilder(eventStore))
+
+	if err := runner.RunForJob(ctx, &runtime.Agent{ID: "a1"}, &JobForRunner{ID: jobID, AgentID: "a1", Goal: "g"}); err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if tools.seenDraft != "draft-v1" {
+		t.Fatalf("tool saw draft %q", tools.seenDraft)
+	}
+	if len(llm.prompts) != 2 || llm.prompts[1] != `review draft-v1 via {"channel":"blog"}` {
+		t.Fatalf("prompts = %q", llm.prompts)
+	}
+
+	// 持久化：最后一个 checkpoint 的 payload 含 scratchpad，恢复时可原样重建
+	cps, _ := cpStore.ListByAgent(ctx, "a1")
+	var last *runtime.Checkpoint
+	for _, cp := range cps {
+		if cp.CursorNode == "n3" {
+			last = cp
+		}
+	}
+	if last == nil {
+		t.Fatal("no checkpoint saved after n3")
+	}
+	var results map[string]any
+	if err := json.Unmarshal(last.PayloadResults, &results); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := runtime.LoadScratchpad(results[ScratchpadResultKey])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries["draft"].Type != "string" || entries["draft"].SetBy != "n1" || entries["publish.meta"].SetBy != "n2" {
+		t.Fatalf("persisted scratchpad = %+v", entries)
+	}
+}
+
+func TestChangedKeysFromState_ExpandsScratchpad(t *testing.T) {
+	before := []byte(`{"n1":{"output":"a"},"_scratchpad":{"draft":{"type":"string","value":"\"a\""},"old":{"type":"bool","value":"true"}}}`)
+	after := []byte(`{"n1":{"output":"a"},"n2":{"output":"b"},"_scratchpad":{"draft":{"type":"string","value":"\"b\""},"new":{"type":"number","value":"1"}}}`)
+	got := ChangedKeysFromState(before, after)
+	want := map[string]bool{"n2": true, "scratchpad.draft": true, "scratchpad.new": true, "scratchpad.old": true}
+	if len(got) != len(want) {
+		t.Fatalf("changed = %v", got)
+	}
+	for _, k := range got {
+		if !want[k] {
+			t.Fatalf("unexpected changed key %q in %v", k, got)
+		}
+	}
+	if strings.Contains(strings.Join(got, ","), ScratchpadResultKey) {
+		t.Fatalf("raw scratchpad key leaked: %v", got)
+	}
+}
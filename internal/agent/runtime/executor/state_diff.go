@@ -118,10 +118,20 @@ func ChangedKeysFromState(stateBefore, stateAfter []byte) []string {
 			vBefore = mBefore[k]
 		}
 		vAfter = mAfter[k]
+		if k == ScratchpadResultKey {
+			// scratchpad 按条目展开为 scratchpad.<key>，Trace「本步变更」可直接定位
+			changed = append(changed, scratchpadChangedKeys(vBefore, vAfter)...)
+			continue
+		}
 		if !jsonEqual(vBefore, vAfter) {
 			changed = append(changed, k)
 		}
 	}
+	if mBefore != nil {
+		if _, ok := mAfter[ScratchpadResultKey]; !ok {
+			changed = append(changed, scratchpadChangedKeys(mBefore[ScratchpadResultKey], nil)...)
+		}
+	}
 	return changed
 }
 
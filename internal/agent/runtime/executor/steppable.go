@@ -147,6 +147,7 @@ func (c *Compiler) CompileSteppable(ctx context.Context, g *planner.TaskGraph, a
 	for i := range g.Nodes {
 		nodeByID[g.Nodes[i].ID] = &g.Nodes[i]
 	}
+	branched := g.HasBranches()
 	var steps []SteppableStep
 	for _, id := range order {
 		node := nodeByID[id]
@@ -161,6 +162,9 @@ func (c *Compiler) CompileSteppable(ctx context.Context, g *planner.TaskGraph, a
 		if err != nil {
 			return nil, fmt.Errorf("executor: 节点 %s ToNodeRunner failed: %w", id, err)
 		}
+		if branched {
+			run = guardBranchRoute(g, id, run)
+		}
 		steps = append(steps, SteppableStep{NodeID: id, NodeType: node.Type, Run: run})
 	}
 	return steps, nil
@@ -0,0 +1,277 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+)
+
+// ErrSupervisorBudgetExceeded 监督者超出总预算（子 Job 数或总时长），未结束的子 Job 已请求取消
+var ErrSupervisorBudgetExceeded = errors.New("supervisor budget exceeded")
+
+// ErrChildrenFailed 子任务重派次数用尽后仍有失败（join require_all）
+var ErrChildrenFailed = errors.New("supervisor children failed")
+
+// ChildSpec spawn 节点声明的子任务；Key 在节点内唯一，AgentID 为空时沿用监督者的 Agent
+type ChildSpec struct {
+	Key     string `json:"key"`
+	AgentID string `json:"agent_id,omitempty"`
+	Goal    string `json:"goal"`
+}
+
+// ChildOutcome 子任务最近一次派发的状态；Result 为子 Job 最后一步的输出（仅 completed 时有值）
+type ChildOutcome struct {
+	Key     string `json:"key"`
+	JobID   string `json:"job_id"`
+	AgentID string `json:"agent_id"`
+	Attempt int    `json:"attempt"`
+	Status  string `json:"status"`
+	Result  any    `json:"result,omitempty"`
+}
+
+// JoinPolicy join 节点的重派与预算
+type JoinPolicy struct {
+	// SpawnNodeID 汇合哪个 spawn 节点的子任务；空表示该 Job 的全部子任务
+	SpawnNodeID string
+	// MaxRedispatch 单个子任务失败/取消后最多重派次数
+	MaxRedispatch int
+	// MaxChildren 含重派在内的子 Job 总数上限；0 表示不限
+	MaxChildren int
+	// Timeout 自首个子 Job 创建起的总时长上限；0 表示不限
+	Timeout time.Duration
+	// RequireAll 重派用尽后仍有失败子任务时 join 失败；false 时汇总部分结果继续
+	RequireAll bool
+}
+
+// JoinResult 汇合结果：Done 为 false 时仍有子任务未终态；Settled 为已终态的子任务数，写入等待的 correlation_key 供唤醒判断
+type JoinResult struct {
+	Done     bool
+	Settled  int
+	Children []ChildOutcome
+}
+
+// ChildJobRuntime 监督者节点创建与汇合子 Job 的能力；由 job.Supervisor 实现（executor 不依赖 job 包）
+type ChildJobRuntime interface {
+	// SpawnChildren 为每个子任务创建子 Job（按 parent/node/key 幂等），返回各子任务的首次派发
+	SpawnChildren(ctx context.Context, parentJobID, nodeID string, specs []ChildSpec) ([]ChildOutcome, error)
+	// JoinChildren 读取子任务状态，按策略重派失败子任务；超出预算时取消未结束子任务并返回 ErrSupervisorBudgetExceeded
+	JoinChildren(ctx context.Context, parentJobID string, policy JoinPolicy) (*JoinResult, error)
+}
+
+// JoinCorrelationKey join 节点等待子 Job 的 correlation_key；settled 为挂起时已终态的子任务数，唤醒方据此判断是否有新的子任务结束
+func JoinCorrelationKey(jobID, nodeID string, settled int) string {
+	return "join-" + jobID + "-" + nodeID + "-" + strconv.Itoa(settled)
+}
+
+// ParseJoinSettled 从 JoinCorrelationKey 取出挂起时的 settled 数
+func ParseJoinSettled(correlationKey string) (int, bool) {
+	if !strings.HasPrefix(correlationKey, "join-") {
+		return 0, false
+	}
+	i := strings.LastIndex(correlationKey, "-")
+	n, err := strconv.Atoi(correlationKey[i+1:])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// SpawnNodeAdapter spawn 节点适配器；Config["children"] 为 [{key, agent_id, goal}]
+type SpawnNodeAdapter struct {
+	Children ChildJobRuntime
+}
+
+// ToDAGNode 实现 NodeAdapter
+func (a *SpawnNodeAdapter) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, err := a.ToNodeRunner(task, agent)
+	if err != nil {
+		return nil, err
+	}
+	return compose.InvokableLambda[*AgentDAGPayload, *AgentDAGPayload](func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) { return run(ctx, p) }), nil
+}
+
+// ToNodeRunner 实现 NodeAdapter
+func (a *SpawnNodeAdapter) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	if a.Children == nil {
+		return nil, fmt.Errorf("SpawnNodeAdapter: child job runtime not configured")
+	}
+	specs, err := childSpecsFromConfig(task.Config)
+	if err != nil {
+		return nil, fmt.Errorf("spawn 节点 %s: %w", task.ID, err)
+	}
+	taskID := task.ID
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		jobID := JobIDFromContext(ctx)
+		if jobID == "" {
+			return nil, fmt.Errorf("spawn 节点 %s: 缺少 job_id", taskID)
+		}
+		resolved := make([]ChildSpec, len(specs))
+		for i, spec := range specs {
+			if spec.AgentID == "" {
+				spec.AgentID = p.AgentID
+			}
+			resolved[i] = spec
+		}
+		children, err := a.Children.SpawnChildren(ctx, jobID, taskID, resolved)
+		if err != nil {
+			return nil, fmt.Errorf("spawn 节点 %s: %w", taskID, err)
+		}
+		if p.Results == nil {
+			p.Results = make(map[string]any)
+		}
+		p.Results[taskID] = map[string]any{"children": children}
+		return p, nil
+	}, nil
+}
+
+// childSpecsFromConfig 解析 Config["children"]；key 为空时按序号生成
+func childSpecsFromConfig(cfg map[string]any) ([]ChildSpec, error) {
+	raw, _ := cfg["children"].([]any)
+	if len(raw) == 0 {
+		return nil, errors.New("config.children 不能为空")
+	}
+	specs := make([]ChildSpec, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for i, item := range raw {
+		m, _ := item.(map[string]any)
+		goal, _ := m["goal"].(string)
+		if strings.TrimSpace(goal) == "" {
+			return nil, fmt.Errorf("children[%d].goal 不能为空", i)
+		}
+		key, _ := m["key"].(string)
+		if key == "" {
+			key = "child-" + strconv.Itoa(i+1)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("children key 重复: %s", key)
+		}
+		seen[key] = true
+		agentID, _ := m["agent_id"].(string)
+		specs = append(specs, ChildSpec{Key: key, AgentID: agentID, Goal: goal})
+	}
+	return specs, nil
+}
+
+// JoinNodeAdapter join 节点适配器；Config: spawn, max_redispatch（默认 1）, require_all（默认 true）, budget {max_children, timeout}
+type JoinNodeAdapter struct {
+	Children ChildJobRuntime
+}
+
+// ToDAGNode 实现 NodeAdapter
+func (a *JoinNodeAdapter) ToDAGNode(task *planner.TaskNode, agent *runtime.Agent) (*compose.Lambda, error) {
+	run, err := a.ToNodeRunner(task, agent)
+	if err != nil {
+		return nil, err
+	}
+	return compose.InvokableLambda[*AgentDAGPayload, *AgentDAGPayload](func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) { return run(ctx, p) }), nil
+}
+
+// ToNodeRunner 实现 NodeAdapter；子任务未全部终态时返回 SignalWaitRequired 挂起，子 Job 结束后监督者被重新入队并再次执行本节点
+func (a *JoinNodeAdapter) ToNodeRunner(task *planner.TaskNode, _ *runtime.Agent) (NodeRunner, error) {
+	if a.Children == nil {
+		return nil, fmt.Errorf("JoinNodeAdapter: child job runtime not configured")
+	}
+	policy, err := joinPolicyFromConfig(task.Config)
+	if err != nil {
+		return nil, fmt.Errorf("join 节点 %s: %w", task.ID, err)
+	}
+	taskID := task.ID
+	return func(ctx context.Context, p *AgentDAGPayload) (*AgentDAGPayload, error) {
+		jobID := JobIDFromContext(ctx)
+		if jobID == "" {
+			return nil, fmt.Errorf("join 节点 %s: 缺少 job_id", taskID)
+		}
+		res, err := a.Children.JoinChildren(ctx, jobID, policy)
+		if err != nil {
+			return nil, fmt.Errorf("join 节点 %s: %w", taskID, err)
+		}
+		if !res.Done {
+			return p, &SignalWaitRequired{
+				CorrelationKey: JoinCorrelationKey(jobID, taskID, res.Settled),
+				Reason:         "children_running",
+				WaitKind:       planner.WaitKindChildren,
+			}
+		}
+		completed, failed := 0, 0
+		for _, c := range res.Children {
+			if c.Status == "completed" {
+				completed++
+			} else {
+				failed++
+			}
+		}
+		if failed > 0 && policy.RequireAll {
+			return nil, fmt.Errorf("join 节点 %s: %w: %d/%d", taskID, ErrChildrenFailed, failed, len(res.Children))
+		}
+		if p.Results == nil {
+			p.Results = make(map[string]any)
+		}
+		p.Results[taskID] = map[string]any{
+			"children":  res.Children,
+			"completed": completed,
+			"failed":    failed,
+		}
+		return p, nil
+	}, nil
+}
+
+// joinPolicyFromConfig 解析 join 节点配置
+func joinPolicyFromConfig(cfg map[string]any) (JoinPolicy, error) {
+	policy := JoinPolicy{MaxRedispatch: 1, RequireAll: true}
+	if cfg == nil {
+		return policy, nil
+	}
+	policy.SpawnNodeID, _ = cfg["spawn"].(string)
+	if v, ok := configNumber(cfg["max_redispatch"]); ok && v >= 0 {
+		policy.MaxRedispatch = v
+	}
+	if v, ok := cfg["require_all"].(bool); ok {
+		policy.RequireAll = v
+	}
+	budget, _ := cfg["budget"].(map[string]any)
+	if v, ok := configNumber(budget["max_children"]); ok && v > 0 {
+		policy.MaxChildren = v
+	}
+	if s, ok := budget["timeout"].(string); ok && s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return policy, fmt.Errorf("budget.timeout 无效: %q", s)
+		}
+		policy.Timeout = d
+	}
+	return policy, nil
+}
+
+// configNumber 读取整数配置；计划经 JSON 反序列化后为 float64，代码构造的计划可能为 int
+func configNumber(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	default:
+		return 0, false
+	}
+}
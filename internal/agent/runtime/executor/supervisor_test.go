@@ -0,0 +1,125 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+)
+
+// fakeChildRuntime 记录 spawn 请求，join 返回预设结果
+type fakeChildRuntime struct {
+	specs  []ChildSpec
+	policy JoinPolicy
+	join   *JoinResult
+}
+
+func (f *fakeChildRuntime) SpawnChildren(_ context.Context, _, _ string, specs []ChildSpec) ([]ChildOutcome, error) {
+	f.specs = specs
+	out := make([]ChildOutcome, len(specs))
+	for i, s := range specs {
+		out[i] = ChildOutcome{Key: s.Key, JobID: "job-" + s.Key, AgentID: s.AgentID, Attempt: 1, Status: "pending"}
+	}
+	return out, nil
+}
+
+func (f *fakeChildRuntime) JoinChildren(_ context.Context, _ string, policy JoinPolicy) (*JoinResult, error) {
+	f.policy = policy
+	return f.join, nil
+}
+
+func TestSpawnNodeAdapter_DefaultsKeysAndAgent(t *testing.T) {
+	rt := &fakeChildRuntime{}
+	a := &SpawnNodeAdapter{Children: rt}
+	task := &planner.TaskNode{ID: "s1", Type: planner.NodeSpawn, Config: map[string]any{
+		"children": []any{
+			map[string]any{"goal": "research A"},
+			map[string]any{"key": "b", "agent_id": "writer", "goal": "write B"},
+		},
+	}}
+	run, err := a.ToNodeRunner(task, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := run(WithJobID(context.Background(), "parent"), &AgentDAGPayload{AgentID: "sup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.specs) != 2 || rt.specs[0].Key != "child-1" || rt.specs[0].AgentID != "sup" || rt.specs[1].AgentID != "writer" {
+		t.Fatalf("unexpected specs: %+v", rt.specs)
+	}
+	if _, ok := p.Results["s1"].(map[string]any)["children"]; !ok {
+		t.Fatalf("spawn output missing children: %+v", p.Results["s1"])
+	}
+
+	dup := &planner.TaskNode{ID: "s2", Type: planner.NodeSpawn, Config: map[string]any{
+		"children": []any{map[string]any{"key": "x", "goal": "a"}, map[string]any{"key": "x", "goal": "b"}},
+	}}
+	if _, err := a.ToNodeRunner(dup, nil); err == nil {
+		t.Fatal("duplicate child keys should be rejected")
+	}
+}
+
+func TestJoinNodeAdapter_WaitsThenAggregates(t *testing.T) {
+	rt := &fakeChildRuntime{join: &JoinResult{Done: false, Settled: 1}}
+	a := &JoinNodeAdapter{Children: rt}
+	task := &planner.TaskNode{ID: "j1", Type: planner.NodeJoin, Config: map[string]any{
+		"spawn": "s1", "max_redispatch": float64(2), "budget": map[string]any{"max_children": float64(5), "timeout": "10m"},
+	}}
+	run, err := a.ToNodeRunner(task, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithJobID(context.Background(), "parent")
+	_, err = run(ctx, &AgentDAGPayload{})
+	var wait *SignalWaitRequired
+	if !errors.As(err, &wait) || wait.WaitKind != planner.WaitKindChildren {
+		t.Fatalf("expected children wait, got %v", err)
+	}
+	if n, ok := ParseJoinSettled(wait.CorrelationKey); !ok || n != 1 {
+		t.Fatalf("correlation key %q should carry settled=1", wait.CorrelationKey)
+	}
+	if rt.policy.SpawnNodeID != "s1" || rt.policy.MaxRedispatch != 2 || rt.policy.MaxChildren != 5 || rt.policy.Timeout != 10*time.Minute || !rt.policy.RequireAll {
+		t.Fatalf("unexpected policy: %+v", rt.policy)
+	}
+
+	rt.join = &JoinResult{Done: true, Settled: 2, Children: []ChildOutcome{{Key: "a", Status: "completed", Result: "ok"}, {Key: "b", Status: "failed"}}}
+	if _, err := run(ctx, &AgentDAGPayload{}); !errors.Is(err, ErrChildrenFailed) {
+		t.Fatalf("require_all join should fail on failed child, got %v", err)
+	}
+
+	task.Config["require_all"] = false
+	run, _ = a.ToNodeRunner(task, nil)
+	p, err := run(ctx, &AgentDAGPayload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := p.Results["j1"].(map[string]any)
+	if out["completed"] != 1 || out["failed"] != 1 {
+		t.Fatalf("unexpected join output: %+v", out)
+	}
+}
+
+func TestJoinNodeAdapter_InvalidTimeout(t *testing.T) {
+	a := &JoinNodeAdapter{Children: &fakeChildRuntime{}}
+	task := &planner.TaskNode{ID: "j1", Type: planner.NodeJoin, Config: map[string]any{"budget": map[string]any{"timeout": "soon"}}}
+	if _, err := a.ToNodeRunner(task, nil); err == nil {
+		t.Fatal("invalid budget.timeout should be rejected")
+	}
+}
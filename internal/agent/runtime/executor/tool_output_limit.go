@@ -0,0 +1,137 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+	"rag-platform/pkg/metrics"
+)
+
+// defaultToolOutputSummaryBytes 摘要默认保留的输出开头字节数
+const defaultToolOutputSummaryBytes = 2048
+
+// toolOutputSpillDir 溢出的完整工具输出在 Job 工作区中的目录
+const toolOutputSpillDir = "tool-outputs/"
+
+// ToolOutputLimits 工具输出大小上限：超过上限的输出写入 Job 工作区（artifact），结果中替换为摘要 + 引用；
+// 下游 LLM 步、事件、Ledger 与 Trace 只看到摘要
+type ToolOutputLimits struct {
+	MaxBytes     int            // 默认上限（字节），<=0 不限
+	PerTool      map[string]int // 工具名 -> 上限，覆盖 MaxBytes；<0 表示该工具不限
+	SummaryBytes int            // 摘要保留的输出开头字节数，<=0 时默认 2048，且不超过上限
+}
+
+// LimitFor 返回工具的输出上限；0 表示不限
+func (l ToolOutputLimits) LimitFor(toolName string) int {
+	limit := l.MaxBytes
+	if n, ok := l.PerTool[toolName]; ok && n != 0 {
+		limit = n
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// ToolOutputSpillSink 可选：ToolEventSink 实现时，超限工具输出的溢出/截断决定记为 tool_output_spilled
+type ToolOutputSpillSink interface {
+	AppendToolOutputSpilled(ctx context.Context, jobID string, pl *jobstore.ToolOutputSpilledPayload) error
+}
+
+// limitToolOutput 输出超过该工具上限时写入工作区并返回摘要；工作区不可用或写入failed时仅截断，决定均写入事件
+func (a *ToolNodeAdapter) limitToolOutput(ctx context.Context, jobID, taskID, stepID, toolName, idempotencyKey, output string) string {
+	limit := a.OutputLimits.LimitFor(toolName)
+	if limit <= 0 || len(output) <= limit {
+		return output
+	}
+	sum := sha256.Sum256([]byte(output))
+	digest := hex.EncodeToString(sum[:])
+	pl := &jobstore.ToolOutputSpilledPayload{
+		NodeID:         taskID,
+		StepID:         stepID,
+		ToolName:       toolName,
+		IdempotencyKey: idempotencyKey,
+		OriginalBytes:  len(output),
+		LimitBytes:     limit,
+		SHA256:         digest,
+		At:             time.Now().UTC(),
+	}
+	result := "spilled"
+	ws, err := sdk.WorkspaceFromContext(ctx)
+	if err == nil {
+		var f sdk.WorkspaceFile
+		f, err = ws.WriteFile(ctx, toolOutputSpillDir+spillFileName(taskID)+"-"+digest[:12]+".txt", []byte(output))
+		if err == nil {
+			pl.URI = f.URI
+			if pl.URI == "" {
+				pl.URI = sdk.WorkspaceURI(ws.JobID(), f.Path)
+			}
+		}
+	}
+	if err != nil {
+		result = "truncated"
+		pl.SpillError = err.Error()
+	}
+	summaryBytes := a.OutputLimits.SummaryBytes
+	if summaryBytes <= 0 {
+		summaryBytes = defaultToolOutputSummaryBytes
+	}
+	summaryBytes = min(summaryBytes, limit)
+	pl.Summary = toolOutputSummary(output, summaryBytes, pl.URI)
+	metrics.ToolOutputSpilledTotal.WithLabelValues(TenantIDFromContext(ctx), toolName, result).Inc()
+	if sink, ok := a.ToolEventSink.(ToolOutputSpillSink); ok && jobID != "" {
+		_ = sink.AppendToolOutputSpilled(ctx, jobID, pl)
+	}
+	return pl.Summary
+}
+
+// toolOutputSummary 保留输出开头 n 字节（不截断 UTF-8 字符），并注明原始大小与完整输出的引用
+func toolOutputSummary(output string, n int, uri string) string {
+	head := output
+	if len(head) > n {
+		head = head[:n]
+		for len(head) > 0 && !utf8.ValidString(head) {
+			head = head[:len(head)-1]
+		}
+	}
+	if uri != "" {
+		return fmt.Sprintf("%s\n...[output truncated: %d of %d bytes shown; full output: %s]", head, len(head), len(output), uri)
+	}
+	return fmt.Sprintf("%s\n...[output truncated: %d of %d bytes shown]", head, len(head), len(output))
+}
+
+// spillFileName 将节点 ID 转为安全的文件名片段
+func spillFileName(taskID string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, taskID)
+	if name == "" {
+		return "step"
+	}
+	return name
+}
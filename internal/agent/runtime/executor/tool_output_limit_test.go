@@ -0,0 +1,147 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+)
+
+// spillSink 在 startedCapturingSink 基础上记录 tool_output_spilled
+type spillSink struct {
+	startedCapturingSink
+	spilled []jobstore.ToolOutputSpilledPayload
+}
+
+func (s *spillSink) AppendToolOutputSpilled(ctx context.Context, jobID string, pl *jobstore.ToolOutputSpilledPayload) error {
+	s.spilled = append(s.spilled, *pl)
+	return nil
+}
+
+// bigOutputTool 返回固定输出
+type bigOutputTool struct {
+	out string
+}
+
+func (t *bigOutputTool) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	return ToolResult{Done: true, Output: t.out}, nil
+}
+
+// failingWorkspace 写入总是超配额
+type failingWorkspace struct {
+	memWorkspace
+}
+
+func (w *failingWorkspace) WriteFile(context.Context, string, []byte) (sdk.WorkspaceFile, error) {
+	return sdk.WorkspaceFile{}, sdk.ErrWorkspaceQuotaExceeded
+}
+
+func TestToolOutputLimits_LimitFor(t *testing.T) {
+	l := ToolOutputLimits{MaxBytes: 1000, PerTool: map[string]int{"web_fetch": 200, "export": -1}}
+	if got := l.LimitFor("web_fetch"); got != 200 {
+		t.Errorf("web_fetch = %d", got)
+	}
+	if got := l.LimitFor("export"); got != 0 {
+		t.Errorf("export = %d, want unlimited", got)
+	}
+	if got := l.LimitFor("other"); got != 1000 {
+		t.Errorf("other = %d", got)
+	}
+	if got := (ToolOutputLimits{}).LimitFor("other"); got != 0 {
+		t.Errorf("zero value = %d", got)
+	}
+}
+
+func TestToolNodeAdapter_SpillsLargeOutput(t *testing.T) {
+	full := strings.Repeat("row,", 500)
+	ws := &memWorkspace{jobID: "job-spill", files: map[string][]byte{}}
+	sink := &spillSink{}
+	adapter := &ToolNodeAdapter{
+		Tools:         &bigOutputTool{out: full},
+		ToolEventSink: sink,
+		OutputLimits:  ToolOutputLimits{PerTool: map[string]int{"query": 1000}, SummaryBytes: 100},
+	}
+	ctx := sdk.WithWorkspace(WithJobID(context.Background(), "job-spill"), ws)
+	out, err := adapter.runNode(ctx, "n1", "query", nil, nil, &AgentDAGPayload{Results: map[string]any{}})
+	if err != nil {
+		t.Fatalf("runNode: %v", err)
+	}
+	if len(sink.spilled) != 1 {
+		t.Fatalf("tool_output_spilled events = %+v", sink.spilled)
+	}
+	ev := sink.spilled[0]
+	if ev.OriginalBytes != len(full) || ev.LimitBytes != 1000 || ev.URI == "" || ev.SpillError != "" {
+		t.Fatalf("event = %+v", ev)
+	}
+	_, path, err := sdk.ParseWorkspaceURI(ev.URI)
+	if err != nil {
+		t.Fatalf("uri %q: %v", ev.URI, err)
+	}
+	if string(ws.files[path]) != full {
+		t.Fatalf("spilled file %q does not hold the full output", path)
+	}
+	got, _ := out.Results["n1"].(map[string]any)["output"].(string)
+	if got != ev.Summary || !strings.HasPrefix(got, full[:100]) || !strings.Contains(got, ev.URI) {
+		t.Fatalf("result output = %q", got)
+	}
+
+	// 未超限的工具不受影响
+	sink.spilled = nil
+	adapter.Tools = &bigOutputTool{out: "small"}
+	out, err = adapter.runNode(ctx, "n2", "query", nil, nil, &AgentDAGPayload{Results: map[string]any{}})
+	if err != nil {
+		t.Fatalf("runNode: %v", err)
+	}
+	if len(sink.spilled) != 0 || out.Results["n2"].(map[string]any)["output"] != "small" {
+		t.Fatalf("small output changed: %v %+v", out.Results["n2"], sink.spilled)
+	}
+}
+
+func TestToolNodeAdapter_TruncatesWhenSpillFails(t *testing.T) {
+	full := strings.Repeat("日志", 400)
+	sink := &spillSink{}
+	adapter := &ToolNodeAdapter{
+		Tools:         &bigOutputTool{out: full},
+		ToolEventSink: sink,
+		OutputLimits:  ToolOutputLimits{MaxBytes: 301},
+	}
+	ws := &failingWorkspace{memWorkspace{jobID: "job-trunc", files: map[string][]byte{}}}
+	for name, ctx := range map[string]context.Context{
+		"no_workspace": WithJobID(context.Background(), "job-trunc"),
+		"quota":        sdk.WithWorkspace(WithJobID(context.Background(), "job-trunc"), ws),
+	} {
+		sink.spilled = nil
+		out, err := adapter.runNode(ctx, "n1", "logs", nil, nil, &AgentDAGPayload{Results: map[string]any{}})
+		if err != nil {
+			t.Fatalf("%s: runNode: %v", name, err)
+		}
+		if len(sink.spilled) != 1 || sink.spilled[0].URI != "" || sink.spilled[0].SpillError == "" {
+			t.Fatalf("%s: events = %+v", name, sink.spilled)
+		}
+		if name == "quota" && !strings.Contains(sink.spilled[0].SpillError, sdk.ErrWorkspaceQuotaExceeded.Error()) {
+			t.Fatalf("%s: spill error = %q", name, sink.spilled[0].SpillError)
+		}
+		got, _ := out.Results["n1"].(map[string]any)["output"].(string)
+		head, _, _ := strings.Cut(got, "\n...[")
+		// 摘要不超过上限且不截断多字节字符
+		if len(head) > 301 || !strings.HasPrefix(full, head) || strings.ContainsRune(head, '�') {
+			t.Fatalf("%s: summary head = %q (%d bytes)", name, head, len(head))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+	"rag-platform/pkg/metrics"
+)
+
+// ToolProgressSink 可选：ToolEventSink 实现时，长时工具经 sdk.CheckpointToolState 写入的步内检查点记为 tool_progress_state
+type ToolProgressSink interface {
+	AppendToolProgressState(ctx context.Context, jobID string, pl *jobstore.ToolProgressStatePayload) error
+}
+
+// toolProgressStatesContextKey 用于在 context 中传递 Replay 得到的未完成工具调用最近一次检查点 state（idempotency_key -> state JSON）
+type toolProgressStatesContextKey struct{}
+
+// WithToolProgressStates 将 Replay 得到的步内检查点放入 ctx；Activity Log Barrier 命中且有检查点时工具从该 state 续跑
+func WithToolProgressStates(ctx context.Context, states map[string][]byte) context.Context {
+	if len(states) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, toolProgressStatesContextKey{}, states)
+}
+
+// ToolProgressStatesFromContext 从 context 取出步内检查点
+func ToolProgressStatesFromContext(ctx context.Context) map[string][]byte {
+	m, _ := ctx.Value(toolProgressStatesContextKey{}).(map[string][]byte)
+	return m
+}
+
+// toolCheckpointer 实现 sdk.ToolCheckpointer，固定一次工具调用；last 为最近一次检查点，进程内重试时作为再入 state
+type toolCheckpointer struct {
+	sink           ToolProgressSink
+	jobID          string
+	nodeID         string
+	stepID         string
+	toolName       string
+	idempotencyKey string
+
+	mu   sync.Mutex
+	seq  int
+	last json.RawMessage
+}
+
+func (c *toolCheckpointer) CheckpointToolState(ctx context.Context, state json.RawMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	err := c.sink.AppendToolProgressState(ctx, c.jobID, &jobstore.ToolProgressStatePayload{
+		NodeID:         c.nodeID,
+		StepID:         c.stepID,
+		ToolName:       c.toolName,
+		IdempotencyKey: c.idempotencyKey,
+		Seq:            c.seq,
+		State:          state,
+		At:             time.Now().UTC(),
+	})
+	result := "ok"
+	if err != nil {
+		result = "error"
+	} else {
+		c.last = state
+	}
+	metrics.ToolProgressCheckpointsTotal.WithLabelValues(TenantIDFromContext(ctx), c.toolName, result).Inc()
+	return err
+}
+
+// lastState 返回最近一次成功写入的检查点 state（已解码）；无检查点时 ok=false
+func (c *toolCheckpointer) lastState() (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	raw := c.last
+	c.mu.Unlock()
+	if len(raw) == 0 {
+		return nil, false
+	}
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// attachToolCheckpointer 注入 sdk.ToolCheckpointer（ToolEventSink 未实现 ToolProgressSink 时原样返回）
+func (a *ToolNodeAdapter) attachToolCheckpointer(ctx context.Context, jobID, taskID, stepID, toolName, idempotencyKey string) (context.Context, *toolCheckpointer) {
+	sink, ok := a.ToolEventSink.(ToolProgressSink)
+	if !ok || jobID == "" {
+		return ctx, nil
+	}
+	c := &toolCheckpointer{sink: sink, jobID: jobID, nodeID: taskID, stepID: stepID, toolName: toolName, idempotencyKey: idempotencyKey}
+	return sdk.WithToolCheckpointer(ctx, c), c
+}
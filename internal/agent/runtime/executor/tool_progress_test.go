@@ -0,0 +1,156 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+)
+
+// progressSink 在 startedCapturingSink 基础上记录 tool_progress_state
+type progressSink struct {
+	startedCapturingSink
+	states []jobstore.ToolProgressStatePayload
+}
+
+func (s *progressSink) AppendToolProgressState(ctx context.Context, jobID string, pl *jobstore.ToolProgressStatePayload) error {
+	s.states = append(s.states, *pl)
+	return nil
+}
+
+type pageCursor struct {
+	Page int `json:"page"`
+}
+
+// pagingTool 逐页处理并在每页后写检查点；续跑时从检查点的页码继续，failAt>0 时处理到该页后返回可重试错误
+type pagingTool struct {
+	pages    int
+	failAt   int
+	resumed  bool
+	startsAt []int
+}
+
+func (t *pagingTool) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	var cur pageCursor
+	if ok, err := sdk.ResumedToolState(ctx, &cur); err != nil {
+		return ToolResult{}, err
+	} else if ok {
+		t.resumed = true
+	}
+	if m, ok := state.(map[string]interface{}); ok {
+		if p, ok := m["page"].(float64); ok {
+			cur.Page = int(p)
+		}
+	}
+	t.startsAt = append(t.startsAt, cur.Page)
+	for cur.Page < t.pages {
+		cur.Page++
+		if err := sdk.CheckpointToolState(ctx, cur); err != nil {
+			return ToolResult{}, err
+		}
+		if cur.Page == t.failAt {
+			t.failAt = 0
+			return ToolResult{}, fmt.Errorf("connection reset: %w", ErrRetryable)
+		}
+	}
+	return ToolResult{Done: true, Output: "done"}, nil
+}
+
+func TestToolNodeAdapter_CheckpointToolState(t *testing.T) {
+	tool := &pagingTool{pages: 3, failAt: 2}
+	sink := &progressSink{}
+	adapter := &ToolNodeAdapter{Tools: tool, ToolEventSink: sink, RetryPolicy: &RetryPolicy{MaxRetries: 1}}
+	ctx := WithJobID(context.Background(), "job-ckpt")
+	payload := &AgentDAGPayload{Results: map[string]any{}}
+
+	if _, err := adapter.runNode(ctx, "n1", "export", nil, nil, payload); err != nil {
+		t.Fatalf("runNode: %v", err)
+	}
+	if len(sink.states) != 3 {
+		t.Fatalf("tool_progress_state events = %+v", sink.states)
+	}
+	key := IdempotencyKey("job-ckpt", "n1", "export", nil)
+	for i, st := range sink.states {
+		if st.Seq != i+1 || st.NodeID != "n1" || st.ToolName != "export" || st.IdempotencyKey != key {
+			t.Fatalf("event %d = %+v", i, st)
+		}
+	}
+	// 进程内重试从最近检查点（page 2）继续
+	if len(tool.startsAt) != 2 || tool.startsAt[0] != 0 || tool.startsAt[1] != 2 {
+		t.Fatalf("attempt start pages = %v", tool.startsAt)
+	}
+	// 不在 Job 的工具调用中
+	if err := sdk.CheckpointToolState(context.Background(), pageCursor{}); !errors.Is(err, sdk.ErrToolCheckpointUnavailable) {
+		t.Fatalf("outside job: %v", err)
+	}
+}
+
+func TestToolNodeAdapter_ResumeFromToolProgressState(t *testing.T) {
+	jobID, taskID, toolName := "job-resume", "n1", "export"
+	key := IdempotencyKey(jobID, taskID, toolName, nil)
+	pending := map[string]struct{}{key: {}}
+
+	t.Run("no_checkpoint_still_fails", func(t *testing.T) {
+		tool := &pagingTool{pages: 3}
+		adapter := &ToolNodeAdapter{Tools: tool, ToolEventSink: &progressSink{}}
+		ctx := WithPendingToolInvocations(WithJobID(context.Background(), jobID), pending)
+		_, err := adapter.runNode(ctx, taskID, toolName, nil, nil, &AgentDAGPayload{Results: map[string]any{}})
+		var sf *StepFailure
+		if !errors.As(err, &sf) || sf.Type != StepResultPermanentFailure {
+			t.Fatalf("expected permanent failure, got %v", err)
+		}
+		if len(tool.startsAt) != 0 {
+			t.Fatalf("tool must not run without checkpoint")
+		}
+	})
+
+	t.Run("resumes_from_last_checkpoint", func(t *testing.T) {
+		tool := &pagingTool{pages: 3}
+		sink := &progressSink{}
+		adapter := &ToolNodeAdapter{Tools: tool, ToolEventSink: sink}
+		ctx := WithPendingToolInvocations(WithJobID(context.Background(), jobID), pending)
+		ctx = WithToolProgressStates(ctx, map[string][]byte{key: []byte(`{"page":2}`)})
+		out, err := adapter.runNode(ctx, taskID, toolName, nil, nil, &AgentDAGPayload{Results: map[string]any{}})
+		if err != nil {
+			t.Fatalf("runNode: %v", err)
+		}
+		if !tool.resumed || len(tool.startsAt) != 1 || tool.startsAt[0] != 2 {
+			t.Fatalf("resumed=%v start pages=%v", tool.resumed, tool.startsAt)
+		}
+		if len(sink.states) != 1 || sink.states[0].Seq != 1 || string(sink.states[0].State) != `{"page":3}` {
+			t.Fatalf("tool_progress_state events = %+v", sink.states)
+		}
+		if m, _ := out.Results[taskID].(map[string]any); m["output"] != "done" {
+			t.Fatalf("result = %v", out.Results[taskID])
+		}
+	})
+}
+
+func TestToolCheckpointer_StateTooLarge(t *testing.T) {
+	ctx := sdk.WithToolCheckpointer(context.Background(), &toolCheckpointer{sink: &progressSink{}})
+	if err := sdk.CheckpointToolState(ctx, pageCursor{Page: 1}); err != nil {
+		t.Fatalf("small state: %v", err)
+	}
+	if err := sdk.CheckpointToolState(ctx, json.RawMessage(`"`+strings.Repeat("x", sdk.MaxToolStateBytes)+`"`)); !errors.Is(err, sdk.ErrToolStateTooLarge) {
+		t.Fatalf("large state: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/metrics"
+)
+
+const (
+	// DefaultSemaphoreLeaseTTL 信号量许可租约；持有者崩溃后最迟该时长释放
+	DefaultSemaphoreLeaseTTL = 60 * time.Second
+	// DefaultSemaphorePollInterval 排队时首次重试间隔，之后指数退避至 maxSemaphorePoll
+	DefaultSemaphorePollInterval = 100 * time.Millisecond
+	maxSemaphorePoll             = 2 * time.Second
+)
+
+// ToolSemaphore 工具调用需获取的命名信号量
+type ToolSemaphore struct {
+	Name    string
+	Limit   int           // 全集群并发上限；<=0 时不限制
+	MaxWait time.Duration // 排队超过该时长以可重试失败结束本步；0 表示一直等待（直到 ctx 结束）
+}
+
+// SemaphoreWaitTimeoutError 排队等待信号量超过 MaxWait
+type SemaphoreWaitTimeoutError struct {
+	Semaphore string
+	Waited    time.Duration
+}
+
+func (e *SemaphoreWaitTimeoutError) Error() string {
+	return fmt.Sprintf("semaphore %q not acquired after %s", e.Semaphore, e.Waited.Round(time.Millisecond))
+}
+
+// ToolSemaphores 集群级工具并发令牌：执行前按名称顺序依次获取（避免交叉持有死锁），执行期间续租，结束后释放
+type ToolSemaphores struct {
+	Store jobstore.SemaphoreStore
+	// Resolve 按工具名返回需获取的信号量；返回空时不限制
+	Resolve func(toolName string) []ToolSemaphore
+	// LeaseTTL 许可租约，默认 DefaultSemaphoreLeaseTTL；执行期间每 LeaseTTL/3 续租一次
+	LeaseTTL time.Duration
+	// PollInterval 排队重试间隔初值，默认 DefaultSemaphorePollInterval
+	PollInterval time.Duration
+}
+
+func (s *ToolSemaphores) leaseTTL() time.Duration {
+	if s.LeaseTTL > 0 {
+		return s.LeaseTTL
+	}
+	return DefaultSemaphoreLeaseTTL
+}
+
+// Acquire 获取 toolName 需要的全部信号量；holder 在集群内唯一标识本次调用。
+// 返回的 release 必须调用；任一获取失败时已获取的许可会被释放
+func (s *ToolSemaphores) Acquire(ctx context.Context, toolName, holder string) (release func(), err error) {
+	noop := func() {}
+	if s == nil || s.Store == nil || s.Resolve == nil {
+		return noop, nil
+	}
+	var sems []ToolSemaphore
+	for _, sem := range s.Resolve(toolName) {
+		if sem.Name != "" && sem.Limit > 0 {
+			sems = append(sems, sem)
+		}
+	}
+	if len(sems) == 0 {
+		return noop, nil
+	}
+	sort.Slice(sems, func(a, b int) bool { return sems[a].Name < sems[b].Name })
+	var held []string
+	releaseAll := func() {
+		// 释放不随调用方 ctx 取消；失败时许可在租约到期后释放
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		for _, name := range held {
+			_ = s.Store.Release(rctx, name, holder)
+		}
+	}
+	for _, sem := range sems {
+		if err := s.acquireOne(ctx, sem, holder); err != nil {
+			releaseAll()
+			return noop, err
+		}
+		held = append(held, sem.Name)
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go s.renew(ctx, held, holder, stop, done)
+	return func() {
+		close(stop)
+		<-done
+		releaseAll()
+	}, nil
+}
+
+func (s *ToolSemaphores) acquireOne(ctx context.Context, sem ToolSemaphore, holder string) error {
+	ttl := s.leaseTTL()
+	poll := s.PollInterval
+	if poll <= 0 {
+		poll = DefaultSemaphorePollInterval
+	}
+	// 排队者须在 ttl 内重试，否则被移出队列
+	maxPoll := min(maxSemaphorePoll, ttl/3)
+	start := time.Now()
+	waiting := false
+	defer func() {
+		if waiting {
+			metrics.ToolSemaphoreWaiting.WithLabelValues(sem.Name).Dec()
+		}
+	}()
+	for {
+		granted, err := s.Store.TryAcquire(ctx, sem.Name, holder, sem.Limit, ttl)
+		if err != nil {
+			metrics.ToolSemaphoreAcquireTotal.WithLabelValues(sem.Name, "error").Inc()
+			return fmt.Errorf("acquire semaphore %q: %w", sem.Name, err)
+		}
+		if granted {
+			metrics.ToolSemaphoreAcquireTotal.WithLabelValues(sem.Name, "acquired").Inc()
+			metrics.ToolSemaphoreWaitSeconds.WithLabelValues(sem.Name).Observe(time.Since(start).Seconds())
+			return nil
+		}
+		if !waiting {
+			waiting = true
+			metrics.ToolSemaphoreWaiting.WithLabelValues(sem.Name).Inc()
+		}
+		wait := poll
+		if sem.MaxWait > 0 {
+			left := sem.MaxWait - time.Since(start)
+			if left <= 0 {
+				s.abandon(ctx, sem.Name, holder)
+				metrics.ToolSemaphoreAcquireTotal.WithLabelValues(sem.Name, "timeout").Inc()
+				return &SemaphoreWaitTimeoutError{Semaphore: sem.Name, Waited: time.Since(start)}
+			}
+			wait = min(wait, left)
+		}
+		select {
+		case <-ctx.Done():
+			s.abandon(ctx, sem.Name, holder)
+			metrics.ToolSemaphoreAcquireTotal.WithLabelValues(sem.Name, "cancelled").Inc()
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		poll = min(poll*2, maxPoll)
+	}
+}
+
+// abandon 放弃排队，让出队列位置
+func (s *ToolSemaphores) abandon(ctx context.Context, name, holder string) {
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	_ = s.Store.Release(rctx, name, holder)
+}
+
+// renew 持有期间周期续租，直到 stop 关闭
+func (s *ToolSemaphores) renew(ctx context.Context, names []string, holder string, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ttl := s.leaseTTL()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	rctx := context.WithoutCancel(ctx)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, name := range names {
+				_ = s.Store.Renew(rctx, name, holder, ttl)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestToolSemaphores_CapsConcurrency(t *testing.T) {
+	sems := &ToolSemaphores{
+		Store:        jobstore.NewSemaphoreStoreMem(),
+		PollInterval: time.Millisecond,
+		Resolve: func(toolName string) []ToolSemaphore {
+			if toolName == "erp.create" {
+				return []ToolSemaphore{{Name: "erp", Limit: 2}}
+			}
+			return nil
+		},
+	}
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := sems.Acquire(context.Background(), "erp.create", fmt.Sprintf("job/%d", i))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			release()
+		}(i)
+	}
+	wg.Wait()
+	if p := peak.Load(); p != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", p)
+	}
+	// 未绑定信号量的工具不排队
+	release, err := sems.Acquire(context.Background(), "search", "job/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestToolSemaphores_MaxWait(t *testing.T) {
+	store := jobstore.NewSemaphoreStoreMem()
+	sems := &ToolSemaphores{
+		Store:        store,
+		PollInterval: time.Millisecond,
+		Resolve: func(string) []ToolSemaphore {
+			return []ToolSemaphore{{Name: "a", Limit: 1}, {Name: "erp", Limit: 1, MaxWait: 20 * time.Millisecond}}
+		},
+	}
+	ctx := context.Background()
+	if ok, _ := store.TryAcquire(ctx, "erp", "other", 1, time.Minute); !ok {
+		t.Fatal("setup acquire")
+	}
+	_, err := sems.Acquire(ctx, "erp.create", "job/1")
+	var timeout *SemaphoreWaitTimeoutError
+	if !errors.As(err, &timeout) || timeout.Semaphore != "erp" {
+		t.Fatalf("err = %v", err)
+	}
+	// 超时后已获取的 "a" 被释放，队列中不残留 job/1
+	if ok, _ := store.TryAcquire(ctx, "a", "job/2", 1, time.Minute); !ok {
+		t.Fatal("semaphore a should have been released")
+	}
+	_ = store.Release(ctx, "erp", "other")
+	if ok, _ := store.TryAcquire(ctx, "erp", "job/3", 1, time.Minute); !ok {
+		t.Fatal("timed-out waiter should have left the queue")
+	}
+}
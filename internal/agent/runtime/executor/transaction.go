@@ -0,0 +1,301 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// ErrTransactionRolledBack 事务组成员failed且已对已提交成员执行补偿；Job 直接终止，不再重试（重试会在已撤销的副作用上继续执行）
+var ErrTransactionRolledBack = errors.New("executor: transaction rolled back")
+
+// TransactionEventSink 可选：NodeEventSink 实现时，事务组边界与结果写入 transaction_started / transaction_committed / transaction_rolled_back
+type TransactionEventSink interface {
+	AppendTransactionEvent(ctx context.Context, jobID string, typ jobstore.EventType, pl *jobstore.TransactionPayload) error
+}
+
+// transactionIndex 事务组成员（按 steps 执行顺序）与节点定义
+type transactionIndex struct {
+	nodes   map[string]*planner.TaskNode
+	members map[string][]string // transaction id -> node ids
+}
+
+func newTransactionIndex(g *planner.TaskGraph, steps []SteppableStep) *transactionIndex {
+	if g == nil {
+		return nil
+	}
+	idx := &transactionIndex{nodes: make(map[string]*planner.TaskNode), members: make(map[string][]string)}
+	for i := range g.Nodes {
+		if g.Nodes[i].Transaction != "" {
+			idx.nodes[g.Nodes[i].ID] = &g.Nodes[i]
+		}
+	}
+	if len(idx.nodes) == 0 {
+		return nil
+	}
+	for _, s := range steps {
+		if n := idx.nodes[s.NodeID]; n != nil {
+			idx.members[n.Transaction] = append(idx.members[n.Transaction], s.NodeID)
+		}
+	}
+	return idx
+}
+
+// transactionsOf 返回 nodeIDs 所属的事务组（去重，保持顺序）
+func (t *transactionIndex) transactionsOf(nodeIDs []string) []string {
+	var out []string
+	for _, id := range nodeIDs {
+		n := t.nodes[id]
+		if n == nil || slices.Contains(out, n.Transaction) {
+			continue
+		}
+		out = append(out, n.Transaction)
+	}
+	return out
+}
+
+// committedResult 成员已提交时返回其结果；tool failed时 Results 中为 {"error","at"}，不算提交
+func committedResult(results map[string]any, nodeID string) (any, bool) {
+	v, ok := results[nodeID]
+	if !ok || v == nil {
+		return nil, false
+	}
+	if m, isMap := v.(map[string]any); isMap {
+		_, done := m["done"]
+		_, output := m["output"]
+		if !done && !output {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func (r *Runner) appendTransactionEvent(ctx context.Context, jobID string, typ jobstore.EventType, pl *jobstore.TransactionPayload) {
+	if sink, ok := r.nodeEventSink.(TransactionEventSink); ok {
+		_ = sink.AppendTransactionEvent(ctx, jobID, typ, pl)
+	}
+}
+
+// beginTransactions 即将执行的节点是所属事务组的首个成员（组内尚无已提交成员）时写 transaction_started
+func (r *Runner) beginTransactions(ctx context.Context, jobID string, g *planner.TaskGraph, steps []SteppableStep, nodeIDs []string, results map[string]any) {
+	idx := newTransactionIndex(g, steps)
+	if idx == nil {
+		return
+	}
+	for _, tx := range idx.transactionsOf(nodeIDs) {
+		started := false
+		for _, m := range idx.members[tx] {
+			if _, ok := committedResult(results, m); ok {
+				started = true
+				break
+			}
+		}
+		if !started {
+			r.appendTransactionEvent(ctx, jobID, jobstore.TransactionStarted, &jobstore.TransactionPayload{TransactionID: tx, Members: idx.members[tx]})
+		}
+	}
+}
+
+// commitTransactions 节点成功后，所属事务组全部成员均已提交时写 transaction_committed
+func (r *Runner) commitTransactions(ctx context.Context, jobID string, g *planner.TaskGraph, steps []SteppableStep, nodeIDs []string, results map[string]any) {
+	idx := newTransactionIndex(g, steps)
+	if idx == nil {
+		return
+	}
+	for _, tx := range idx.transactionsOf(nodeIDs) {
+		all := true
+		for _, m := range idx.members[tx] {
+			if _, ok := committedResult(results, m); !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			r.appendTransactionEvent(ctx, jobID, jobstore.TransactionCommitted, &jobstore.TransactionPayload{TransactionID: tx, Members: idx.members[tx]})
+		}
+	}
+}
+
+// rollbackTransactions 步骤failed且 Job 即将终止时调用：对失败节点所属事务组及其它未完成的事务组，
+// 按提交逆序补偿已提交成员并写 transaction_rolled_back；至少补偿过一个成员时返回 true
+func (r *Runner) rollbackTransactions(ctx context.Context, jobID string, g *planner.TaskGraph, steps []SteppableStep, agent *runtime.Agent, failedNodeID, reason string, results map[string]any) bool {
+	idx := newTransactionIndex(g, steps)
+	if idx == nil {
+		return false
+	}
+	var txs []string
+	if n := idx.nodes[failedNodeID]; n != nil {
+		txs = append(txs, n.Transaction)
+	}
+	for _, s := range steps {
+		n := idx.nodes[s.NodeID]
+		if n == nil || slices.Contains(txs, n.Transaction) {
+			continue
+		}
+		committed, all := 0, true
+		for _, m := range idx.members[n.Transaction] {
+			if _, ok := committedResult(results, m); ok {
+				committed++
+			} else {
+				all = false
+			}
+		}
+		if committed > 0 && !all {
+			txs = append(txs, n.Transaction)
+		}
+	}
+	rolledBack := false
+	for _, tx := range txs {
+		members := idx.members[tx]
+		pl := &jobstore.TransactionPayload{TransactionID: tx, Members: members, Reason: reason}
+		if idx.nodes[failedNodeID] != nil && idx.nodes[failedNodeID].Transaction == tx {
+			pl.FailedNodeID = failedNodeID
+		}
+		for i := len(members) - 1; i >= 0; i-- {
+			id := members[i]
+			if id == failedNodeID {
+				continue
+			}
+			result, ok := committedResult(results, id)
+			if !ok {
+				continue
+			}
+			pl.Compensations = append(pl.Compensations, r.compensateMember(ctx, jobID, tx, idx.nodes[id], result, agent))
+			rolledBack = true
+		}
+		r.appendTransactionEvent(ctx, jobID, jobstore.TransactionRolledBack, pl)
+	}
+	return rolledBack
+}
+
+// compensateMember 执行单个成员的补偿：优先 TaskNode.Compensate 声明的工具（经 tool 适配器执行，带幂等键），
+// 其次 CompensationRegistry；均无时记为 skipped。成功后写 step_compensated
+func (r *Runner) compensateMember(ctx context.Context, jobID, tx string, node *planner.TaskNode, result any, agent *runtime.Agent) jobstore.TransactionCompensation {
+	out := jobstore.TransactionCompensation{NodeID: node.ID, Status: jobstore.CompensationStatusSkipped}
+	stepID := node.ID + ":compensate"
+	var err error
+	switch {
+	case node.Compensate != nil:
+		out.Tool = node.Compensate.Tool
+		err = r.runCompensationTool(ctx, jobID, stepID, node, result, agent)
+	case r.compensationRegistry != nil && r.compensationRegistry.GetCompensation(node.ID) != nil:
+		err = r.compensationRegistry.GetCompensation(node.ID)(ctx, jobID, node.ID, stepID, stepID)
+	default:
+		return out
+	}
+	if err != nil {
+		out.Status = jobstore.CompensationStatusFailed
+		out.Error = err.Error()
+		return out
+	}
+	out.Status = jobstore.CompensationStatusCompensated
+	if r.nodeEventSink != nil {
+		_ = r.nodeEventSink.AppendStepCompensated(ctx, jobID, node.ID, stepID, stepID, "transaction "+tx+" rolled back")
+	}
+	return out
+}
+
+func (r *Runner) runCompensationTool(ctx context.Context, jobID, stepID string, node *planner.TaskNode, result any, agent *runtime.Agent) error {
+	adapter, ok := r.compiler.Adapter(planner.NodeTool)
+	if !ok || adapter == nil {
+		return fmt.Errorf("tool adapter not registered")
+	}
+	task := &planner.TaskNode{ID: stepID, Type: planner.NodeTool, ToolName: node.Compensate.Tool, Config: resolveCompensationInput(node.Compensate.Input, result)}
+	run, err := adapter.ToNodeRunner(task, agent)
+	if err != nil {
+		return err
+	}
+	cctx := WithExecutionStepID(WithJobID(ctx, jobID), stepID)
+	p, err := run(cctx, &AgentDAGPayload{Results: make(map[string]any)})
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		if m, ok := p.Results[stepID].(map[string]any); ok {
+			if msg, _ := m["error"].(string); msg != "" {
+				return errors.New(msg)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCompensationInput 将 Input 中的 "$result" / "$result.a.b" 替换为被补偿节点结果中的对应字段；
+// 路径途经 JSON 字符串（如 tool output）时先解析再继续取值，取不到时为 nil
+func resolveCompensationInput(input map[string]any, result any) map[string]any {
+	out := make(map[string]any, len(input))
+	for k, v := range input {
+		out[k] = resolveCompensationValue(v, result)
+	}
+	return out
+}
+
+func resolveCompensationValue(v any, result any) any {
+	switch t := v.(type) {
+	case string:
+		if t == "$result" {
+			return result
+		}
+		if path, ok := strings.CutPrefix(t, "$result."); ok {
+			return lookupResultPath(result, strings.Split(path, "."))
+		}
+		return t
+	case map[string]any:
+		return resolveCompensationInput(t, result)
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = resolveCompensationValue(e, result)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func lookupResultPath(v any, path []string) any {
+	for _, key := range path {
+		if s, ok := v.(string); ok {
+			var decoded any
+			if json.Unmarshal([]byte(s), &decoded) != nil {
+				return nil
+			}
+			v = decoded
+		}
+		switch t := v.(type) {
+		case map[string]any:
+			v = t[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
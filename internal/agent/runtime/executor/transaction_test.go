@@ -0,0 +1,151 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// transactionSink 在 timeoutNodeSink 基础上记录事务组边界事件
+type transactionSink struct {
+	timeoutNodeSink
+	types    []jobstore.EventType
+	payloads []jobstore.TransactionPayload
+}
+
+func (s *transactionSink) AppendTransactionEvent(ctx context.Context, jobID string, typ jobstore.EventType, pl *jobstore.TransactionPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types = append(s.types, typ)
+	s.payloads = append(s.payloads, *pl)
+	return nil
+}
+
+// bookingToolExec 记录调用；book 在 failBook 时failed
+type bookingToolExec struct {
+	failBook bool
+	calls    []string
+	inputs   map[string]map[string]any
+}
+
+func (e *bookingToolExec) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	e.calls = append(e.calls, toolName)
+	if e.inputs == nil {
+		e.inputs = make(map[string]map[string]any)
+	}
+	e.inputs[toolName] = input
+	switch toolName {
+	case "charge":
+		return ToolResult{Done: true, Output: `{"id":"ch_1"}`}, nil
+	case "book":
+		if e.failBook {
+			return ToolResult{}, &StepFailure{Type: StepResultPermanentFailure, Inner: errors.New("no seats")}
+		}
+	}
+	return ToolResult{Done: true, Output: "ok"}, nil
+}
+
+func runTransactionJob(t *testing.T, tools *bookingToolExec) (*transactionSink, error) {
+	t.Helper()
+	ctx := context.Background()
+	jobID := "job-tx"
+	g := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "charge", Type: planner.NodeTool, ToolName: "charge", Transaction: "trip", Compensate: &planner.Compensation{Tool: "refund", Input: map[string]any{"charge_id": "$result.output.id"}}},
+			{ID: "hold", Type: planner.NodeTool, ToolName: "hold", Transaction: "trip"},
+			{ID: "book", Type: planner.NodeTool, ToolName: "book", Transaction: "trip", Compensate: &planner.Compensation{Tool: "cancel"}},
+		},
+		Edges: []planner.TaskEdge{{From: "charge", To: "hold"}, {From: "hold", To: "book"}},
+	}
+	eventStore := jobstore.NewMemoryStore()
+	graphBytes, _ := g.Marshal()
+	planPayload, _ := json.Marshal(map[string]interface{}{"task_graph": json.RawMessage(graphBytes), "goal": "g"})
+	if _, err := eventStore.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: planPayload}); err != nil {
+		t.Fatalf("append plan_generated: %v", err)
+	}
+	runner := NewRunner(NewCompiler(map[string]NodeAdapter{planner.NodeTool: &ToolNodeAdapter{Tools: tools}}))
+	runner.SetCheckpointStores(runtime.NewCheckpointStoreMem(), &fakeJobStoreForRunner{})
+	runner.SetReplayContextBuilder(replay.NewReplayContextBuilder(eventStore))
+	sink := &transactionSink{}
+	runner.SetNodeEventSink(sink)
+	err := runner.RunForJob(ctx, &runtime.Agent{ID: "a1"}, &JobForRunner{ID: jobID, AgentID: "a1", Goal: "g"})
+	return sink, err
+}
+
+func TestTransaction_Committed(t *testing.T) {
+	tools := &bookingToolExec{}
+	sink, err := runTransactionJob(t, tools)
+	if err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if want := []jobstore.EventType{jobstore.TransactionStarted, jobstore.TransactionCommitted}; !reflect.DeepEqual(sink.types, want) {
+		t.Fatalf("events = %v, want %v", sink.types, want)
+	}
+	if want := []string{"charge", "hold", "book"}; !reflect.DeepEqual(sink.payloads[1].Members, want) {
+		t.Fatalf("members = %v", sink.payloads[1].Members)
+	}
+}
+
+func TestTransaction_RolledBackInReverseOrder(t *testing.T) {
+	tools := &bookingToolExec{failBook: true}
+	sink, err := runTransactionJob(t, tools)
+	if !errors.Is(err, ErrTransactionRolledBack) {
+		t.Fatalf("err = %v, want ErrTransactionRolledBack", err)
+	}
+	var sf *StepFailure
+	if !errors.As(err, &sf) || sf.NodeID != "book" {
+		t.Fatalf("step failure = %+v", sf)
+	}
+	if want := []string{"charge", "hold", "book", "refund"}; !reflect.DeepEqual(tools.calls, want) {
+		t.Fatalf("tool calls = %v, want %v", tools.calls, want)
+	}
+	if got := tools.inputs["refund"]["charge_id"]; got != "ch_1" {
+		t.Fatalf("refund input = %v", tools.inputs["refund"])
+	}
+	if want := []jobstore.EventType{jobstore.TransactionStarted, jobstore.TransactionRolledBack}; !reflect.DeepEqual(sink.types, want) {
+		t.Fatalf("events = %v, want %v", sink.types, want)
+	}
+	rb := sink.payloads[1]
+	want := []jobstore.TransactionCompensation{
+		{NodeID: "hold", Status: jobstore.CompensationStatusSkipped},
+		{NodeID: "charge", Tool: "refund", Status: jobstore.CompensationStatusCompensated},
+	}
+	if rb.FailedNodeID != "book" || !reflect.DeepEqual(rb.Compensations, want) {
+		t.Fatalf("rolled back payload = %+v", rb)
+	}
+}
+
+func TestResolveCompensationInput(t *testing.T) {
+	result := map[string]any{"output": `{"items":[{"id":"a"}]}`, "done": true}
+	got := resolveCompensationInput(map[string]any{
+		"id":     "$result.output.items.0.id",
+		"all":    "$result",
+		"nested": map[string]any{"missing": "$result.state.x"},
+		"fixed":  "refund",
+	}, result)
+	want := map[string]any{"id": "a", "all": result, "nested": map[string]any{"missing": nil}, "fixed": "refund"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
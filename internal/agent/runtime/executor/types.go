@@ -26,6 +26,9 @@ import (
 // ErrJobWaiting 表示 Job 在 Wait 节点挂起，等待 signal/continue 后由其他 Worker 认领继续（design/job-state-machine.md）
 var ErrJobWaiting = errors.New("executor: job waiting for signal")
 
+// ErrJobDeferred 表示 Job 因租户维护窗口在 step 边界暂停（已置为 Deferred），窗口结束后恢复为 Pending 并从 checkpoint 继续
+var ErrJobDeferred = errors.New("executor: job deferred by maintenance window")
+
 // agentContextKey 用于在 context 中传递 *runtime.Agent（ToolExec 等可从 ctx 取 agent）
 type agentContextKey struct{}
 
@@ -53,6 +56,9 @@ type toolExecutionKeyContextKey struct{}
 // tenantIDContextKey 用于在 context 中传递 tenant ID，供 metrics 等使用
 type tenantIDContextKey struct{}
 
+// rollbackResumeContextKey 标记本次执行是手动回滚后从检查点重新执行
+type rollbackResumeContextKey struct{}
+
 var theAgentContextKey = agentContextKey{}
 var theJobIDContextKey = jobIDContextKey{}
 var theReplayContextKey = replayContextKey{}
@@ -62,6 +68,7 @@ var theApprovedCorrelationKeysContextKey = approvedCorrelationKeysContextKey{}
 var theExecutionStepIDContextKey = executionStepIDContextKey{}
 var theToolExecutionKeyContextKey = toolExecutionKeyContextKey{}
 var theTenantIDContextKey = tenantIDContextKey{}
+var theRollbackResumeContextKey = rollbackResumeContextKey{}
 
 // WithAgent 将 agent 放入 ctx，供 Runner.Invoke 时传入节点
 func WithAgent(ctx context.Context, agent *runtime.Agent) context.Context {
@@ -138,6 +145,17 @@ func ApprovedCorrelationKeysFromContext(ctx context.Context) map[string]struct{}
 	return m
 }
 
+// WithRollbackResume 标记本次执行为手动回滚后从检查点重新执行：Effect Store 中检查点之后的 effect 来自被作废的执行，LLM 节点不得据此注入
+func WithRollbackResume(ctx context.Context) context.Context {
+	return context.WithValue(ctx, theRollbackResumeContextKey, true)
+}
+
+// IsRollbackResume 本次执行是否为手动回滚后的重新执行
+func IsRollbackResume(ctx context.Context) bool {
+	v, _ := ctx.Value(theRollbackResumeContextKey).(bool)
+	return v
+}
+
 // StateChangeForVerify Confirmation Replay 时单条待校验的外部资源变更（由 Runner 从 ReplayContext 转换注入）
 type StateChangeForVerify struct {
 	ResourceType string
@@ -20,6 +20,8 @@ import "errors"
 type SignalWaitRequired struct {
 	CorrelationKey string
 	Reason         string
+	WaitKind       string // 可选；为空时按 signal 写入 job_waiting
+	Park           bool   // true 时 Job 置为 Parked（长时间等待人类介入）而非 Waiting
 }
 
 func (e *SignalWaitRequired) Error() string {
@@ -30,21 +32,25 @@ func (e *SignalWaitRequired) Error() string {
 	return "signal wait required: " + reason + " (" + e.CorrelationKey + ")"
 }
 
-func signalWaitFromError(runErr error) (correlationKey string, reason string, ok bool) {
+// signalWaitFromError 从 step 错误中提取等待请求；返回值的 Reason、WaitKind 已填充默认值
+func signalWaitFromError(runErr error) (SignalWaitRequired, bool) {
 	if runErr == nil {
-		return "", "", false
+		return SignalWaitRequired{}, false
 	}
 	var capReq *CapabilityRequiresApproval
 	if errors.As(runErr, &capReq) && capReq != nil && capReq.CorrelationKey != "" {
-		return capReq.CorrelationKey, "capability_approval", true
+		return SignalWaitRequired{CorrelationKey: capReq.CorrelationKey, Reason: "capability_approval", WaitKind: "signal"}, true
 	}
 	var sw *SignalWaitRequired
 	if errors.As(runErr, &sw) && sw != nil && sw.CorrelationKey != "" {
-		r := sw.Reason
-		if r == "" {
-			r = "signal_wait"
+		w := *sw
+		if w.Reason == "" {
+			w.Reason = "signal_wait"
 		}
-		return sw.CorrelationKey, r, true
+		if w.WaitKind == "" {
+			w.WaitKind = "signal"
+		}
+		return w, true
 	}
-	return "", "", false
+	return SignalWaitRequired{}, false
 }
@@ -0,0 +1,143 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay"
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/agent/sdk"
+)
+
+// memWorkspace 测试用内存工作区
+type memWorkspace struct {
+	jobID string
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (w *memWorkspace) JobID() string { return w.jobID }
+func (w *memWorkspace) WriteFile(_ context.Context, p string, data []byte) (sdk.WorkspaceFile, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files[p] = append([]byte(nil), data...)
+	return sdk.WorkspaceFile{Path: p, Size: int64(len(data)), URI: sdk.WorkspaceURI(w.jobID, p)}, nil
+}
+func (w *memWorkspace) Write(ctx context.Context, p string, r io.Reader) (sdk.WorkspaceFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return sdk.WorkspaceFile{}, err
+	}
+	return w.WriteFile(ctx, p, data)
+}
+func (w *memWorkspace) ReadFile(_ context.Context, p string) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data, ok := w.files[p]
+	if !ok {
+		return nil, sdk.ErrWorkspaceFileNotFound
+	}
+	return data, nil
+}
+func (w *memWorkspace) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	data, err := w.ReadFile(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+func (w *memWorkspace) Delete(context.Context, string) error { return nil }
+func (w *memWorkspace) List(context.Context) ([]sdk.WorkspaceFile, error) {
+	return nil, nil
+}
+func (w *memWorkspace) Usage(context.Context) (int64, int64, error) { return 0, 0, nil }
+
+// workspaceToolExec write 工具写文件，read 工具读取上一步写入的文件
+type workspaceToolExec struct {
+	read string
+}
+
+func (e *workspaceToolExec) Execute(ctx context.Context, toolName string, input map[string]any, state interface{}) (ToolResult, error) {
+	ws, err := sdk.WorkspaceFromContext(ctx)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	switch toolName {
+	case "write":
+		f, err := ws.WriteFile(ctx, "report.csv", []byte("a,b\n1,2\n"))
+		if err != nil {
+			return ToolResult{}, err
+		}
+		return ToolResult{Done: true, Output: f.URI}, nil
+	default:
+		data, err := ws.ReadFile(ctx, "report.csv")
+		if err != nil {
+			return ToolResult{}, err
+		}
+		e.read = string(data)
+		return ToolResult{Done: true, Output: "ok"}, nil
+	}
+}
+
+func TestRunForJob_WorkspaceSharedAcrossSteps(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job-workspace"
+	eventStore := jobstore.NewMemoryStore()
+	taskGraph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "n1", Type: planner.NodeTool, ToolName: "write"},
+			{ID: "n2", Type: planner.NodeTool, ToolName: "read"},
+		},
+		Edges: []planner.TaskEdge{{From: "n1", To: "n2"}},
+	}
+	graphBytes, err := taskGraph.Marshal()
+	if err != nil {
+		t.Fatalf("marshal graph: %v", err)
+	}
+	planPayload, _ := json.Marshal(map[string]interface{}{"task_graph": json.RawMessage(graphBytes), "goal": "g"})
+	if _, err := eventStore.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: planPayload}); err != nil {
+		t.Fatalf("append plan_generated: %v", err)
+	}
+
+	tools := &workspaceToolExec{}
+	runner := NewRunner(NewCompiler(map[string]NodeAdapter{planner.NodeTool: &ToolNodeAdapter{Tools: tools}}))
+	runner.SetCheckpointStores(runtime.NewCheckpointStoreMem(), &fakeJobStoreForRunner{})
+	runner.SetReplayContextBuilder(replay.NewReplayContextBuilder(eventStore))
+	workspaces := map[string]*memWorkspace{}
+	runner.SetWorkspaces(func(id string) sdk.Workspace {
+		if workspaces[id] == nil {
+			workspaces[id] = &memWorkspace{jobID: id, files: map[string][]byte{}}
+		}
+		return workspaces[id]
+	})
+
+	if err := runner.RunForJob(ctx, &runtime.Agent{ID: "a1"}, &JobForRunner{ID: jobID, AgentID: "a1", Goal: "g"}); err != nil {
+		t.Fatalf("RunForJob: %v", err)
+	}
+	if tools.read != "a,b\n1,2\n" {
+		t.Fatalf("read step saw %q", tools.read)
+	}
+	if len(workspaces) != 1 || workspaces[jobID] == nil {
+		t.Fatalf("workspaces = %v", workspaces)
+	}
+}
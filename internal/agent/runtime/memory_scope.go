@@ -0,0 +1,148 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// 记忆隔离：Job 只能读写所属租户、所属 Agent 的记忆与会话状态。底层存储仍按 agentID 取键，
+// MemoryScope 把租户并入该键（Owner），由 Scoped* 访问器统一构造，调用方无法拼出他人的键；
+// 跨 Agent 共享须经 SharingPolicy 显式放行的命名共享空间，共享空间同样限定在租户内。
+
+const (
+	scopeSep       = "\x1f"
+	defaultTenant  = "default"
+	sharedSpaceTag = scopeSep + "space" + scopeSep
+)
+
+var (
+	// ErrCrossScopeAccess 访问了不属于当前租户/Agent 的记忆或状态
+	ErrCrossScopeAccess = errors.New("memory: cross-scope access denied")
+	// ErrInvalidMemoryScope 租户或 Agent 为空、或含保留分隔符
+	ErrInvalidMemoryScope = errors.New("memory: invalid scope")
+	// ErrSharedSpaceDenied 共享空间未对当前 Agent 开放
+	ErrSharedSpaceDenied = errors.New("memory: shared space not allowed")
+)
+
+// MemoryScope 一次 Job 的记忆作用域：租户 + Agent；TenantID 为空视为 "default"
+type MemoryScope struct {
+	TenantID string
+	AgentID  string
+}
+
+func (s MemoryScope) tenant() string {
+	if s.TenantID == "" {
+		return defaultTenant
+	}
+	return s.TenantID
+}
+
+// Validate 校验作用域可用于构造存储键
+func (s MemoryScope) Validate() error {
+	if s.AgentID == "" || strings.Contains(s.AgentID, scopeSep) || strings.Contains(s.TenantID, scopeSep) {
+		return ErrInvalidMemoryScope
+	}
+	return nil
+}
+
+// Owner 底层存储使用的 agent 键：default 租户沿用原 agentID（兼容已有数据），其他租户为 租户+分隔符+agentID
+func (s MemoryScope) Owner() string {
+	if s.tenant() == defaultTenant {
+		return s.AgentID
+	}
+	return s.TenantID + scopeSep + s.AgentID
+}
+
+// SharedOwner 租户内命名共享空间的存储键；以分隔符开头，不会与任何合法 Owner 相同
+func (s MemoryScope) SharedOwner(space string) string {
+	return sharedSpaceTag + s.tenant() + scopeSep + space
+}
+
+// SharingPolicy 决定 Agent 能否访问租户内的命名共享空间；nil 策略拒绝一切共享
+type SharingPolicy interface {
+	AllowShared(scope MemoryScope, space string) bool
+}
+
+// SharedSpaces 静态共享策略：空间名 -> 可访问的 Agent ID 列表（各租户内同名空间互不相通）
+type SharedSpaces map[string][]string
+
+// AllowShared 实现 SharingPolicy
+func (p SharedSpaces) AllowShared(scope MemoryScope, space string) bool {
+	for _, id := range p[space] {
+		if id == scope.AgentID {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckShared 校验作用域可访问共享空间 space
+func CheckShared(policy SharingPolicy, scope MemoryScope, space string) error {
+	if err := scope.Validate(); err != nil {
+		return err
+	}
+	if space == "" || strings.Contains(space, scopeSep) {
+		return ErrInvalidMemoryScope
+	}
+	if policy == nil || !policy.AllowShared(scope, space) {
+		return ErrSharedSpaceDenied
+	}
+	return nil
+}
+
+// ScopedAgentStateStore 限定在单个租户/Agent 的会话状态访问器；Worker 与 API 按 Job 的租户与 Agent 构造
+type ScopedAgentStateStore struct {
+	store AgentStateStore
+	scope MemoryScope
+}
+
+// NewScopedAgentStateStore 以 scope 包装 store；scope 非法时返回 ErrInvalidMemoryScope
+func NewScopedAgentStateStore(store AgentStateStore, scope MemoryScope) (*ScopedAgentStateStore, error) {
+	if err := scope.Validate(); err != nil {
+		return nil, err
+	}
+	return &ScopedAgentStateStore{store: store, scope: scope}, nil
+}
+
+// Load 读取本作用域下 sessionID 的状态；存储返回了其他租户或 Agent 的状态时返回 ErrCrossScopeAccess
+func (s *ScopedAgentStateStore) Load(ctx context.Context, sessionID string) (*AgentState, error) {
+	state, err := s.store.LoadAgentState(ctx, s.scope.Owner(), sessionID)
+	if err != nil || state == nil {
+		return state, err
+	}
+	if (state.AgentID != "" && state.AgentID != s.scope.AgentID && state.AgentID != s.scope.Owner()) ||
+		(state.TenantID != "" && state.TenantID != s.scope.tenant()) {
+		return nil, ErrCrossScopeAccess
+	}
+	state.AgentID = s.scope.AgentID
+	return state, nil
+}
+
+// Save 写入本作用域下 sessionID 的状态；state 属于其他 Agent 时拒绝
+func (s *ScopedAgentStateStore) Save(ctx context.Context, sessionID string, state *AgentState) error {
+	if state == nil {
+		return nil
+	}
+	if state.AgentID != "" && state.AgentID != s.scope.AgentID {
+		return ErrCrossScopeAccess
+	}
+	cp := *state
+	cp.AgentID = s.scope.AgentID
+	cp.TenantID = s.scope.tenant()
+	return s.store.SaveAgentState(ctx, s.scope.Owner(), sessionID, &cp)
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScopedAgentStateStore_Isolation(t *testing.T) {
+	ctx := context.Background()
+	store := NewAgentStateStoreMem()
+	a, _ := NewScopedAgentStateStore(store, MemoryScope{TenantID: "tenant-a", AgentID: "agent-1"})
+	b, _ := NewScopedAgentStateStore(store, MemoryScope{TenantID: "tenant-b", AgentID: "agent-1"})
+	other, _ := NewScopedAgentStateStore(store, MemoryScope{TenantID: "tenant-a", AgentID: "agent-2"})
+
+	if err := a.Save(ctx, "s1", &AgentState{AgentID: "agent-1", Scratchpad: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if st, err := b.Load(ctx, "s1"); err != nil || st != nil {
+		t.Fatalf("other tenant read state: %+v, %v", st, err)
+	}
+	if st, err := other.Load(ctx, "s1"); err != nil || st != nil {
+		t.Fatalf("other agent read state: %+v, %v", st, err)
+	}
+	st, err := a.Load(ctx, "s1")
+	if err != nil || st == nil || st.Scratchpad != "secret" || st.AgentID != "agent-1" || st.TenantID != "tenant-a" {
+		t.Fatalf("own state = %+v, %v", st, err)
+	}
+	if err := other.Save(ctx, "s1", &AgentState{AgentID: "agent-1"}); !errors.Is(err, ErrCrossScopeAccess) {
+		t.Fatalf("saving another agent's state: err = %v", err)
+	}
+}
+
+func TestScopedAgentStateStore_DefaultTenantKeepsKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewAgentStateStoreMem()
+	_ = store.SaveAgentState(ctx, "agent-1", "s1", &AgentState{Scratchpad: "legacy"})
+	s, _ := NewScopedAgentStateStore(store, MemoryScope{AgentID: "agent-1"})
+	st, err := s.Load(ctx, "s1")
+	if err != nil || st == nil || st.Scratchpad != "legacy" {
+		t.Fatalf("default tenant should read unscoped state: %+v, %v", st, err)
+	}
+}
+
+func TestScopedAgentStateStore_RejectsForeignState(t *testing.T) {
+	ctx := context.Background()
+	store := NewAgentStateStoreMem()
+	// 存储层把其他租户的状态写到了本作用域的键下
+	_ = store.SaveAgentState(ctx, "agent-1", "s1", &AgentState{TenantID: "tenant-b"})
+	s, _ := NewScopedAgentStateStore(store, MemoryScope{TenantID: "default", AgentID: "agent-1"})
+	if _, err := s.Load(ctx, "s1"); !errors.Is(err, ErrCrossScopeAccess) {
+		t.Fatalf("err = %v, want ErrCrossScopeAccess", err)
+	}
+}
+
+func TestMemoryScope_Validate(t *testing.T) {
+	for _, sc := range []MemoryScope{{}, {AgentID: "a\x1fb"}, {TenantID: "t\x1f", AgentID: "a"}} {
+		if _, err := NewScopedAgentStateStore(NewAgentStateStoreMem(), sc); !errors.Is(err, ErrInvalidMemoryScope) {
+			t.Errorf("scope %q: err = %v", sc, err)
+		}
+	}
+	if err := CheckShared(SharedSpaces{"team": {"a"}}, MemoryScope{AgentID: "b"}, "team"); !errors.Is(err, ErrSharedSpaceDenied) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := CheckShared(nil, MemoryScope{AgentID: "a"}, "team"); !errors.Is(err, ErrSharedSpaceDenied) {
+		t.Fatalf("nil policy: err = %v", err)
+	}
+}
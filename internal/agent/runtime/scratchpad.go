@@ -0,0 +1,321 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Scratchpad 是同一 Job 内各步骤共享的结构化暂存区：工具与 LLM 步骤经 Scratchpad(ctx).Get/Set 交换中间数据，
+// 无需约定 payload.Results 的节点结果格式。Runner 在每步前从 payload 载入、步后写回，随 node_finished /
+// state_checkpointed / checkpoint 一并持久化，Replay 与恢复时原样重建。
+
+var (
+	// ErrScratchpadUnavailable 当前 ctx 未注入 scratchpad（非 Runner 执行的步骤）
+	ErrScratchpadUnavailable = errors.New("scratchpad: not available in this context")
+	// ErrScratchpadTypeMismatch 同一 key 已以其他类型写入；需先 Delete 再换类型
+	ErrScratchpadTypeMismatch = errors.New("scratchpad: type mismatch")
+	// ErrScratchpadLimitExceeded 超过单值、总大小或 key 数上限
+	ErrScratchpadLimitExceeded = errors.New("scratchpad: limit exceeded")
+	// ErrScratchpadInvalidKey key 为空、过长或含非法字符
+	ErrScratchpadInvalidKey = errors.New("scratchpad: invalid key")
+)
+
+const (
+	// DefaultScratchpadMaxValueBytes 单个值 JSON 编码后的默认上限
+	DefaultScratchpadMaxValueBytes = 64 << 10
+	// DefaultScratchpadMaxTotalBytes 整个 scratchpad 的默认上限（key + value）
+	DefaultScratchpadMaxTotalBytes = 1 << 20
+	// DefaultScratchpadMaxKeys 默认最多 key 数
+	DefaultScratchpadMaxKeys = 256
+
+	scratchpadMaxKeyLen = 128
+)
+
+var scratchpadKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// ScratchpadLimits scratchpad 大小限制；零值字段使用默认值
+type ScratchpadLimits struct {
+	MaxValueBytes int
+	MaxTotalBytes int
+	MaxKeys       int
+}
+
+func (l ScratchpadLimits) withDefaults() ScratchpadLimits {
+	if l.MaxValueBytes <= 0 {
+		l.MaxValueBytes = DefaultScratchpadMaxValueBytes
+	}
+	if l.MaxTotalBytes <= 0 {
+		l.MaxTotalBytes = DefaultScratchpadMaxTotalBytes
+	}
+	if l.MaxKeys <= 0 {
+		l.MaxKeys = DefaultScratchpadMaxKeys
+	}
+	return l
+}
+
+// ScratchpadEntry 单个条目的持久化形式；Type 为首次写入时的 JSON 类型（string/number/bool/object/array）
+type ScratchpadEntry struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+	SetBy string          `json:"set_by,omitempty"` // 最后写入的节点 ID
+}
+
+// ScratchpadStore 单步视角下的 scratchpad；并发安全，由 Runner 创建并经 WithScratchpad 注入
+type ScratchpadStore struct {
+	mu      sync.RWMutex
+	entries map[string]ScratchpadEntry
+	limits  ScratchpadLimits
+	writer  string
+	changed map[string]struct{}
+	size    int
+}
+
+// NewScratchpadStore 由持久化快照（LoadScratchpad 结果）构造；writer 为当前步骤节点 ID，记录到 SetBy
+func NewScratchpadStore(entries map[string]ScratchpadEntry, limits ScratchpadLimits, writer string) *ScratchpadStore {
+	s := &ScratchpadStore{
+		entries: make(map[string]ScratchpadEntry, len(entries)),
+		limits:  limits.withDefaults(),
+		writer:  writer,
+		changed: make(map[string]struct{}),
+	}
+	for k, e := range entries {
+		s.entries[k] = e
+		s.size += len(k) + len(e.Value)
+	}
+	return s
+}
+
+// LoadScratchpad 将 payload 中的持久化值（内存中的 map[string]ScratchpadEntry 或 JSON 解码后的 map）还原为条目
+func LoadScratchpad(raw any) (map[string]ScratchpadEntry, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case map[string]ScratchpadEntry:
+		return v, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("scratchpad: encode snapshot: %w", err)
+	}
+	var out map[string]ScratchpadEntry
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("scratchpad: decode snapshot: %w", err)
+	}
+	return out, nil
+}
+
+// WithScratchpad 将 scratchpad 注入 ctx
+func WithScratchpad(ctx context.Context, s *ScratchpadStore) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKeyScratchpad, s)
+}
+
+// Scratchpad 返回 ctx 中的 scratchpad；未注入时返回 nil，nil 上的 Get 返回未命中、Set 返回 ErrScratchpadUnavailable
+func Scratchpad(ctx context.Context) *ScratchpadStore {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(ctxKeyScratchpad).(*ScratchpadStore)
+	return s
+}
+
+// Get 将 key 对应的值解码到 out（指针）；未命中返回 false
+func (s *ScratchpadStore) Get(key string, out any) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	if out == nil {
+		return true, nil
+	}
+	if err := json.Unmarshal(e.Value, out); err != nil {
+		return true, fmt.Errorf("%w: key %q holds %s: %v", ErrScratchpadTypeMismatch, key, e.Type, err)
+	}
+	return true, nil
+}
+
+// Set 写入 key；值须可 JSON 编码，类型须与已有值一致，并受 ScratchpadLimits 约束
+func (s *ScratchpadStore) Set(key string, value any) error {
+	if s == nil {
+		return ErrScratchpadUnavailable
+	}
+	if err := validateScratchpadKey(key); err != nil {
+		return err
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("scratchpad: encode %q: %w", key, err)
+	}
+	typ := jsonKind(b)
+	if typ == "null" {
+		return fmt.Errorf("scratchpad: nil value for %q, use Delete", key)
+	}
+	if len(b) > s.limits.MaxValueBytes {
+		return fmt.Errorf("%w: value for %q is %d bytes (max %d)", ErrScratchpadLimitExceeded, key, len(b), s.limits.MaxValueBytes)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, exists := s.entries[key]
+	if exists && prev.Type != typ {
+		return fmt.Errorf("%w: key %q holds %s, got %s", ErrScratchpadTypeMismatch, key, prev.Type, typ)
+	}
+	if !exists && len(s.entries) >= s.limits.MaxKeys {
+		return fmt.Errorf("%w: %d keys (max %d)", ErrScratchpadLimitExceeded, len(s.entries), s.limits.MaxKeys)
+	}
+	size := s.size + len(b)
+	if exists {
+		size -= len(prev.Value)
+	} else {
+		size += len(key)
+	}
+	if size > s.limits.MaxTotalBytes {
+		return fmt.Errorf("%w: total %d bytes (max %d)", ErrScratchpadLimitExceeded, size, s.limits.MaxTotalBytes)
+	}
+	s.entries[key] = ScratchpadEntry{Type: typ, Value: b, SetBy: s.writer}
+	s.size = size
+	s.changed[key] = struct{}{}
+	return nil
+}
+
+// Delete 删除 key；不存在时无操作
+func (s *ScratchpadStore) Delete(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.size -= len(key) + len(e.Value)
+		delete(s.entries, key)
+		s.changed[key] = struct{}{}
+	}
+}
+
+// Keys 返回全部 key（字母序）
+func (s *ScratchpadStore) Keys() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.entries))
+	for k := range s.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Changed 返回本步写入或删除过的 key（字母序）
+func (s *ScratchpadStore) Changed() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.changed))
+	for k := range s.changed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Entry 返回单个条目的持久化形式
+func (s *ScratchpadStore) Entry(key string) (ScratchpadEntry, bool) {
+	if s == nil {
+		return ScratchpadEntry{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[key]
+	return e, ok
+}
+
+// Snapshot 返回可写回 payload 的条目副本
+func (s *ScratchpadStore) Snapshot() map[string]ScratchpadEntry {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]ScratchpadEntry, len(s.entries))
+	for k, e := range s.entries {
+		out[k] = e
+	}
+	return out
+}
+
+// ScratchpadKey 带类型的 key，编译期约束读写类型：
+//
+//	var summaryKey = runtime.ScratchpadKey[string]("research.summary")
+//	_ = summaryKey.Set(ctx, "...")
+//	v, ok, err := summaryKey.Get(ctx)
+type ScratchpadKey[T any] string
+
+// Get 从 ctx 的 scratchpad 读取并解码为 T
+func (k ScratchpadKey[T]) Get(ctx context.Context) (T, bool, error) {
+	var v T
+	ok, err := Scratchpad(ctx).Get(string(k), &v)
+	return v, ok, err
+}
+
+// Set 写入 ctx 的 scratchpad
+func (k ScratchpadKey[T]) Set(ctx context.Context, v T) error {
+	return Scratchpad(ctx).Set(string(k), v)
+}
+
+func validateScratchpadKey(key string) error {
+	if key == "" || len(key) > scratchpadMaxKeyLen || !scratchpadKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrScratchpadInvalidKey, key)
+	}
+	return nil
+}
+
+func jsonKind(b []byte) string {
+	for _, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '"':
+			return "string"
+		case '{':
+			return "object"
+		case '[':
+			return "array"
+		case 't', 'f':
+			return "bool"
+		case 'n':
+			return "null"
+		default:
+			return "number"
+		}
+	}
+	return "null"
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestScratchpad_TypedKeysAndLimits(t *testing.T) {
+	pad := NewScratchpadStore(nil, ScratchpadLimits{MaxValueBytes: 16, MaxTotalBytes: 40, MaxKeys: 2}, "n1")
+	ctx := WithScratchpad(context.Background(), pad)
+
+	count := ScratchpadKey[int]("count")
+	if err := count.Set(ctx, 3); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, ok, err := count.Get(ctx); err != nil || !ok || v != 3 {
+		t.Fatalf("Get = %v %v %v", v, ok, err)
+	}
+	if err := Scratchpad(ctx).Set("count", "three"); !errors.Is(err, ErrScratchpadTypeMismatch) {
+		t.Fatalf("type change err = %v", err)
+	}
+	if _, _, err := ScratchpadKey[string]("count").Get(ctx); !errors.Is(err, ErrScratchpadTypeMismatch) {
+		t.Fatalf("typed get err = %v", err)
+	}
+	if err := pad.Set("big", "0123456789abcdefgh"); !errors.Is(err, ErrScratchpadLimitExceeded) {
+		t.Fatalf("value limit err = %v", err)
+	}
+	if err := pad.Set("a", "x"); err != nil {
+		t.Fatalf("Set a: %v", err)
+	}
+	if err := pad.Set("b", "y"); !errors.Is(err, ErrScratchpadLimitExceeded) {
+		t.Fatalf("key limit err = %v", err)
+	}
+	if err := pad.Set("bad key", 1); !errors.Is(err, ErrScratchpadInvalidKey) {
+		t.Fatalf("invalid key err = %v", err)
+	}
+	pad.Delete("count")
+	if err := pad.Set("count", "three"); err != nil {
+		t.Fatalf("Set after Delete: %v", err)
+	}
+	if got := pad.Changed(); len(got) != 2 || got[0] != "a" || got[1] != "count" {
+		t.Fatalf("Changed = %v", got)
+	}
+}
+
+func TestScratchpad_SnapshotRoundTrip(t *testing.T) {
+	pad := NewScratchpadStore(nil, ScratchpadLimits{}, "n1")
+	_ = pad.Set("facts", []string{"a", "b"})
+	b, err := json.Marshal(map[string]any{"_scratchpad": pad.Snapshot()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	_ = json.Unmarshal(b, &decoded)
+	entries, err := LoadScratchpad(decoded["_scratchpad"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewScratchpadStore(entries, ScratchpadLimits{}, "n2")
+	var facts []string
+	if ok, err := restored.Get("facts", &facts); !ok || err != nil || len(facts) != 2 {
+		t.Fatalf("restored facts = %v %v %v", facts, ok, err)
+	}
+	if e, _ := restored.Entry("facts"); e.Type != "array" || e.SetBy != "n1" {
+		t.Fatalf("entry = %+v", e)
+	}
+	if len(restored.Changed()) != 0 {
+		t.Fatal("restored pad should start clean")
+	}
+}
+
+func TestScratchpad_Unavailable(t *testing.T) {
+	ctx := context.Background()
+	if ok, err := Scratchpad(ctx).Get("x", nil); ok || err != nil {
+		t.Fatalf("Get on missing pad = %v %v", ok, err)
+	}
+	if err := ScratchpadKey[string]("x").Set(ctx, "v"); !errors.Is(err, ErrScratchpadUnavailable) {
+		t.Fatalf("Set on missing pad = %v", err)
+	}
+}
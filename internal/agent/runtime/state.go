@@ -31,6 +31,7 @@ type ToolCallRecord struct {
 // AgentState 可序列化的 Agent/会话状态（供持久化与恢复）
 type AgentState struct {
 	AgentID        string           `json:"agent_id"`
+	TenantID       string           `json:"tenant_id,omitempty"` // 由 ScopedAgentStateStore 写入，读取时校验
 	SessionID      string           `json:"session_id"`
 	Messages       []Message        `json:"messages"`
 	Variables      map[string]any   `json:"variables,omitempty"`
@@ -45,6 +45,11 @@ func NewAgentStateStorePg(ctx context.Context, dsn string) (*AgentStateStorePg,
 	return &AgentStateStorePg{pool: pool}, nil
 }
 
+// NewAgentStateStorePgWithPool 基于已有连接池创建 AgentStateStore（连接池由 pgpool.Manager 统一管理）
+func NewAgentStateStorePgWithPool(pool *pgxpool.Pool) *AgentStateStorePg {
+	return &AgentStateStorePg{pool: pool}
+}
+
 // Close 关闭连接池
 func (s *AgentStateStorePg) Close() {
 	s.pool.Close()
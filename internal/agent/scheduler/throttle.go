@@ -0,0 +1,254 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceSample 一次资源采样；CPUPercent/MemoryPercent 为负表示当前平台无法采样
+type ResourceSample struct {
+	CPUPercent    float64
+	MemoryPercent float64
+	LLMInFlight   int
+	ToolInFlight  int
+}
+
+// ResourceSampler 采样主机资源与本 Worker 的 LLM/Tool 并发
+type ResourceSampler interface {
+	Sample() ResourceSample
+}
+
+// ThrottleThresholds 资源感知认领阈值；<=0 表示不检查该项
+type ThrottleThresholds struct {
+	MaxCPUPercent    float64
+	MaxMemoryPercent float64
+	MaxLLMInFlight   int
+	MaxToolInFlight  int
+}
+
+// 节流原因
+const (
+	ThrottleReasonCPU    = "cpu"
+	ThrottleReasonMemory = "memory"
+	ThrottleReasonLLM    = "llm_in_flight"
+	ThrottleReasonTool   = "tool_in_flight"
+)
+
+// ThrottleState 最近一次评估结果（随 Worker 状态心跳上报）
+type ThrottleState struct {
+	Throttled     bool      `json:"throttled"`
+	Reasons       []string  `json:"reasons,omitempty"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	LLMInFlight   int       `json:"llm_in_flight"`
+	ToolInFlight  int       `json:"tool_in_flight"`
+	SampledAt     time.Time `json:"sampled_at"`
+}
+
+// ClaimThrottle 资源感知认领：超过任一阈值时 Worker 暂停认领新 Job（已认领的 Job 不受影响）；采样结果按 interval 缓存，避免每次 Claim 读取 /proc
+type ClaimThrottle struct {
+	sampler    ResourceSampler
+	thresholds ThrottleThresholds
+	interval   time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	state ThrottleState
+}
+
+// NewClaimThrottle 创建认领节流器；interval<=0 时默认 5s
+func NewClaimThrottle(sampler ResourceSampler, thresholds ThrottleThresholds, interval time.Duration) *ClaimThrottle {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &ClaimThrottle{sampler: sampler, thresholds: thresholds, interval: interval, now: time.Now}
+}
+
+// Interval 返回采样间隔
+func (t *ClaimThrottle) Interval() time.Duration {
+	if t == nil {
+		return 5 * time.Second
+	}
+	return t.interval
+}
+
+// Evaluate 采样并评估是否需要节流；距上次采样不足 interval 时返回缓存结果
+func (t *ClaimThrottle) Evaluate() ThrottleState {
+	if t == nil || t.sampler == nil {
+		return ThrottleState{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if !t.state.SampledAt.IsZero() && now.Sub(t.state.SampledAt) < t.interval {
+		return t.copyStateLocked()
+	}
+	s := t.sampler.Sample()
+	st := ThrottleState{
+		CPUPercent:    s.CPUPercent,
+		MemoryPercent: s.MemoryPercent,
+		LLMInFlight:   s.LLMInFlight,
+		ToolInFlight:  s.ToolInFlight,
+		SampledAt:     now,
+	}
+	th := t.thresholds
+	if th.MaxCPUPercent > 0 && s.CPUPercent >= th.MaxCPUPercent {
+		st.Reasons = append(st.Reasons, ThrottleReasonCPU)
+	}
+	if th.MaxMemoryPercent > 0 && s.MemoryPercent >= th.MaxMemoryPercent {
+		st.Reasons = append(st.Reasons, ThrottleReasonMemory)
+	}
+	if th.MaxLLMInFlight > 0 && s.LLMInFlight >= th.MaxLLMInFlight {
+		st.Reasons = append(st.Reasons, ThrottleReasonLLM)
+	}
+	if th.MaxToolInFlight > 0 && s.ToolInFlight >= th.MaxToolInFlight {
+		st.Reasons = append(st.Reasons, ThrottleReasonTool)
+	}
+	st.Throttled = len(st.Reasons) > 0
+	t.state = st
+	return t.copyStateLocked()
+}
+
+// ShouldBackoff 是否应暂停认领；nil 时恒为 false
+func (t *ClaimThrottle) ShouldBackoff() bool {
+	if t == nil {
+		return false
+	}
+	return t.Evaluate().Throttled
+}
+
+func (t *ClaimThrottle) copyStateLocked() ThrottleState {
+	st := t.state
+	st.Reasons = append([]string(nil), t.state.Reasons...)
+	return st
+}
+
+// HostSampler 基于 /proc 的主机资源采样（Linux）；其他平台 CPU/内存返回 -1，仅检查 LLM/Tool 并发
+type HostSampler struct {
+	llmInFlight  func() int
+	toolInFlight func() int
+
+	mu        sync.Mutex
+	lastBusy  uint64
+	lastTotal uint64
+}
+
+// NewHostSampler 创建主机采样器；llmInFlight/toolInFlight 可为 nil
+func NewHostSampler(llmInFlight, toolInFlight func() int) *HostSampler {
+	return &HostSampler{llmInFlight: llmInFlight, toolInFlight: toolInFlight}
+}
+
+// Sample 实现 ResourceSampler；CPU 使用率为相邻两次采样间的平均值，首次采样为开机以来平均值
+func (h *HostSampler) Sample() ResourceSample {
+	s := ResourceSample{CPUPercent: -1, MemoryPercent: -1}
+	if busy, total, err := readProcStat(procStatPath); err == nil {
+		h.mu.Lock()
+		dBusy, dTotal := busy-h.lastBusy, total-h.lastTotal
+		h.lastBusy, h.lastTotal = busy, total
+		h.mu.Unlock()
+		if dTotal > 0 {
+			s.CPUPercent = float64(dBusy) * 100 / float64(dTotal)
+		}
+	}
+	if pct, err := readMemoryPercent(procMeminfoPath); err == nil {
+		s.MemoryPercent = pct
+	}
+	if h.llmInFlight != nil {
+		s.LLMInFlight = h.llmInFlight()
+	}
+	if h.toolInFlight != nil {
+		s.ToolInFlight = h.toolInFlight()
+	}
+	return s
+}
+
+var (
+	procStatPath    = "/proc/stat"
+	procMeminfoPath = "/proc/meminfo"
+)
+
+// readProcStat 读取 /proc/stat 汇总 cpu 行，返回 (busy, total) jiffies；idle 与 iowait 计为空闲
+func readProcStat(path string) (busy, total uint64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var idle uint64
+		for i, v := range fields[1:] {
+			n, errParse := strconv.ParseUint(v, 10, 64)
+			if errParse != nil {
+				return 0, 0, errParse
+			}
+			total += n
+			if i == 3 || i == 4 { // idle, iowait
+				idle += n
+			}
+		}
+		return total - idle, total, nil
+	}
+	if err := sc.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, fmt.Errorf("scheduler: no cpu line in %s", path)
+}
+
+// readMemoryPercent 读取 /proc/meminfo，返回 (MemTotal-MemAvailable)/MemTotal 百分比
+func readMemoryPercent(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var memTotal, memAvailable uint64
+	var haveTotal, haveAvailable bool
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		n, errParse := strconv.ParseUint(fields[1], 10, 64)
+		if errParse != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			memTotal, haveTotal = n, true
+		case "MemAvailable:":
+			memAvailable, haveAvailable = n, true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+	if !haveTotal || !haveAvailable || memTotal == 0 {
+		return 0, fmt.Errorf("scheduler: MemTotal/MemAvailable missing in %s", path)
+	}
+	return float64(memTotal-memAvailable) * 100 / float64(memTotal), nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeSampler struct {
+	sample ResourceSample
+	calls  int
+}
+
+func (f *fakeSampler) Sample() ResourceSample {
+	f.calls++
+	return f.sample
+}
+
+func TestClaimThrottle_Thresholds(t *testing.T) {
+	s := &fakeSampler{sample: ResourceSample{CPUPercent: 50, MemoryPercent: 95, LLMInFlight: 8, ToolInFlight: 1}}
+	th := NewClaimThrottle(s, ThrottleThresholds{MaxCPUPercent: 90, MaxMemoryPercent: 90, MaxLLMInFlight: 8}, time.Minute)
+	now := time.Unix(1000, 0)
+	th.now = func() time.Time { return now }
+
+	st := th.Evaluate()
+	if !st.Throttled {
+		t.Fatal("expected throttled")
+	}
+	if len(st.Reasons) != 2 || st.Reasons[0] != ThrottleReasonMemory || st.Reasons[1] != ThrottleReasonLLM {
+		t.Fatalf("reasons = %v", st.Reasons)
+	}
+
+	// interval 内复用缓存，不重新采样
+	s.sample = ResourceSample{CPUPercent: 10, MemoryPercent: 10}
+	if !th.ShouldBackoff() || s.calls != 1 {
+		t.Fatalf("expected cached throttled state, calls=%d", s.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if th.ShouldBackoff() {
+		t.Fatal("expected throttle to clear after resources recover")
+	}
+	if s.calls != 2 {
+		t.Fatalf("calls = %d, want 2", s.calls)
+	}
+
+	var nilThrottle *ClaimThrottle
+	if nilThrottle.ShouldBackoff() {
+		t.Fatal("nil throttle must not back off")
+	}
+}
+
+func TestHostSampler_ReadsProc(t *testing.T) {
+	dir := t.TempDir()
+	stat := filepath.Join(dir, "stat")
+	meminfo := filepath.Join(dir, "meminfo")
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(stat, "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 1 2 3 4 5\n")
+	write(meminfo, "MemTotal:       1000 kB\nMemFree:         100 kB\nMemAvailable:    250 kB\n")
+	oldStat, oldMem := procStatPath, procMeminfoPath
+	procStatPath, procMeminfoPath = stat, meminfo
+	defer func() { procStatPath, procMeminfoPath = oldStat, oldMem }()
+
+	h := NewHostSampler(func() int { return 3 }, nil)
+	s := h.Sample()
+	if s.CPUPercent != 20 {
+		t.Fatalf("first cpu = %v, want 20", s.CPUPercent)
+	}
+	if s.MemoryPercent != 75 {
+		t.Fatalf("memory = %v, want 75", s.MemoryPercent)
+	}
+	if s.LLMInFlight != 3 || s.ToolInFlight != 0 {
+		t.Fatalf("in flight = %d/%d", s.LLMInFlight, s.ToolInFlight)
+	}
+
+	// 第二次采样取增量：busy +300，total +400
+	write(stat, "cpu  300 0 200 700 200 0 0 0 0 0\n")
+	if s := h.Sample(); s.CPUPercent != 75 {
+		t.Fatalf("delta cpu = %v, want 75", s.CPUPercent)
+	}
+}
+
+func TestWorkerStatusStoreMem_ListSince(t *testing.T) {
+	ctx := context.Background()
+	store := NewWorkerStatusStoreMem()
+	now := time.Now()
+	_ = store.Report(ctx, &WorkerStatus{WorkerID: "w-b", Throttle: ThrottleState{Throttled: true, Reasons: []string{ThrottleReasonCPU}}, HeartbeatAt: now})
+	_ = store.Report(ctx, &WorkerStatus{WorkerID: "w-a", HeartbeatAt: now})
+	_ = store.Report(ctx, &WorkerStatus{WorkerID: "w-stale", HeartbeatAt: now.Add(-time.Hour)})
+
+	list, err := store.List(ctx, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].WorkerID != "w-a" || list[1].WorkerID != "w-b" {
+		t.Fatalf("list = %+v", list)
+	}
+	if !list[1].Throttle.Throttled || list[1].Throttle.Reasons[0] != ThrottleReasonCPU {
+		t.Fatalf("throttle state not preserved: %+v", list[1].Throttle)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WorkerStatus Worker 状态心跳：并发占用与资源感知节流状态，供 Scheduler 与看板识别自节流的 Worker
+type WorkerStatus struct {
+	WorkerID       string        `json:"worker_id"`
+	RunningJobs    int           `json:"running_jobs"`
+	MaxConcurrency int           `json:"max_concurrency"`
+	Throttle       ThrottleState `json:"throttle"`
+	HeartbeatAt    time.Time     `json:"heartbeat_at"`
+	// 版本握手：Worker 支持的事件 schema 版本与特性（compat.Handshake）；升级前的 Worker 为 0 / 空
+	Version            string   `json:"version,omitempty"`
+	EventSchemaVersion int      `json:"event_schema_version"`
+	Features           []string `json:"features,omitempty"`
+	// InspectURL Worker 自省端点（worker.inspect.advertise_url）；空表示未启用，API 据此代理 worker inspect
+	InspectURL string `json:"inspect_url,omitempty"`
+}
+
+// WorkerStatusStore Worker 状态心跳存储
+type WorkerStatusStore interface {
+	// Report 写入（覆盖）某 Worker 的最新状态
+	Report(ctx context.Context, st *WorkerStatus) error
+	// List 返回 HeartbeatAt 不早于 since 的 Worker 状态（按 worker_id 排序）
+	List(ctx context.Context, since time.Time) ([]*WorkerStatus, error)
+}
+
+// WorkerStatusStoreMem 内存实现（单进程 / 测试）
+type WorkerStatusStoreMem struct {
+	mu       sync.RWMutex
+	byWorker map[string]*WorkerStatus
+}
+
+// NewWorkerStatusStoreMem 创建内存 Worker 状态存储
+func NewWorkerStatusStoreMem() *WorkerStatusStoreMem {
+	return &WorkerStatusStoreMem{byWorker: make(map[string]*WorkerStatus)}
+}
+
+func (s *WorkerStatusStoreMem) Report(ctx context.Context, st *WorkerStatus) error {
+	if st == nil || st.WorkerID == "" {
+		return nil
+	}
+	cp := *st
+	cp.Throttle.Reasons = append([]string(nil), st.Throttle.Reasons...)
+	cp.Features = append([]string(nil), st.Features...)
+	if cp.HeartbeatAt.IsZero() {
+		cp.HeartbeatAt = time.Now()
+	}
+	s.mu.Lock()
+	s.byWorker[st.WorkerID] = &cp
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *WorkerStatusStoreMem) List(ctx context.Context, since time.Time) ([]*WorkerStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*WorkerStatus, 0, len(s.byWorker))
+	for _, st := range s.byWorker {
+		if st.HeartbeatAt.Before(since) {
+			continue
+		}
+		cp := *st
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].WorkerID < out[j].WorkerID })
+	return out, nil
+}
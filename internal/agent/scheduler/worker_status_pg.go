@@ -0,0 +1,91 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WorkerStatusStorePg PostgreSQL 实现，使用 worker_status 表（每 Worker 一行，心跳时 upsert）
+type WorkerStatusStorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewWorkerStatusStorePg 创建基于 PostgreSQL 的 Worker 状态存储
+func NewWorkerStatusStorePg(pool *pgxpool.Pool) *WorkerStatusStorePg {
+	return &WorkerStatusStorePg{pool: pool}
+}
+
+func (s *WorkerStatusStorePg) Report(ctx context.Context, st *WorkerStatus) error {
+	if st == nil || st.WorkerID == "" {
+		return nil
+	}
+	reasons, err := json.Marshal(st.Throttle.Reasons)
+	if err != nil {
+		return err
+	}
+	features, err := json.Marshal(st.Features)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO worker_status (worker_id, running_jobs, max_concurrency, throttled, throttle_reasons, cpu_percent, memory_percent, llm_in_flight, tool_in_flight, version, event_schema_version, features, inspect_url, heartbeat_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, now())
+		 ON CONFLICT (worker_id) DO UPDATE SET
+		   running_jobs = EXCLUDED.running_jobs, max_concurrency = EXCLUDED.max_concurrency,
+		   throttled = EXCLUDED.throttled, throttle_reasons = EXCLUDED.throttle_reasons,
+		   cpu_percent = EXCLUDED.cpu_percent, memory_percent = EXCLUDED.memory_percent,
+		   llm_in_flight = EXCLUDED.llm_in_flight, tool_in_flight = EXCLUDED.tool_in_flight,
+		   version = EXCLUDED.version, event_schema_version = EXCLUDED.event_schema_version, features = EXCLUDED.features,
+		   inspect_url = EXCLUDED.inspect_url, heartbeat_at = now()`,
+		st.WorkerID, st.RunningJobs, st.MaxConcurrency, st.Throttle.Throttled, reasons,
+		st.Throttle.CPUPercent, st.Throttle.MemoryPercent, st.Throttle.LLMInFlight, st.Throttle.ToolInFlight,
+		st.Version, st.EventSchemaVersion, features, st.InspectURL)
+	return err
+}
+
+func (s *WorkerStatusStorePg) List(ctx context.Context, since time.Time) ([]*WorkerStatus, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT worker_id, running_jobs, max_concurrency, throttled, throttle_reasons, cpu_percent, memory_percent, llm_in_flight, tool_in_flight,
+		        COALESCE(version, ''), event_schema_version, features, COALESCE(inspect_url, ''), heartbeat_at
+		 FROM worker_status WHERE heartbeat_at >= $1 ORDER BY worker_id`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*WorkerStatus
+	for rows.Next() {
+		var st WorkerStatus
+		var reasons, features []byte
+		if err := rows.Scan(&st.WorkerID, &st.RunningJobs, &st.MaxConcurrency, &st.Throttle.Throttled, &reasons,
+			&st.Throttle.CPUPercent, &st.Throttle.MemoryPercent, &st.Throttle.LLMInFlight, &st.Throttle.ToolInFlight,
+			&st.Version, &st.EventSchemaVersion, &features, &st.InspectURL, &st.HeartbeatAt); err != nil {
+			return nil, err
+		}
+		if len(reasons) > 0 {
+			_ = json.Unmarshal(reasons, &st.Throttle.Reasons)
+		}
+		if len(features) > 0 {
+			_ = json.Unmarshal(features, &st.Features)
+		}
+		st.Throttle.SampledAt = st.HeartbeatAt
+		out = append(out, &st)
+	}
+	return out, rows.Err()
+}
@@ -34,6 +34,28 @@ type wrappedTool struct {
 func (w *wrappedTool) Name() string        { return w.t.Name() }
 func (w *wrappedTool) Description() string { return w.t.Description() }
 
+// SandboxSafe 透传底层 tool.SandboxSafe 声明
+func (w *wrappedTool) SandboxSafe() bool {
+	s, ok := w.t.(tool.SandboxSafe)
+	return ok && s.SandboxSafe()
+}
+
+// Categories 透传底层 tool.Categorized 声明
+func (w *wrappedTool) Categories() []string {
+	if c, ok := w.t.(tool.Categorized); ok {
+		return c.Categories()
+	}
+	return nil
+}
+
+// RequiredCapability 透传底层 tool.CapabilityRequired 声明；空则 Registry 使用工具名
+func (w *wrappedTool) RequiredCapability() string {
+	if c, ok := w.t.(tool.CapabilityRequired); ok {
+		return c.RequiredCapability()
+	}
+	return ""
+}
+
 func (w *wrappedTool) Schema() map[string]any {
 	s := w.t.Schema()
 	b, _ := json.Marshal(s)
@@ -16,6 +16,7 @@ package tools
 
 import (
 	"context"
+	"time"
 
 	"rag-platform/internal/runtime/session"
 )
@@ -42,3 +43,67 @@ type ToolWithCapability interface {
 	// RequiredCapability 返回该工具所需能力标识，空则使用 Name()
 	RequiredCapability() string
 }
+
+// ToolWithVersion 可选接口：声明工具实现版本，写入 Manifest 并随 Job 执行环境固化；未实现时为 "1.0"
+type ToolWithVersion interface {
+	Tool
+	Version() string
+}
+
+// ToolVersion 返回工具声明的版本，未声明时为 "1.0"
+func ToolVersion(t Tool) string {
+	if v, ok := t.(ToolWithVersion); ok && v.Version() != "" {
+		return v.Version()
+	}
+	return "1.0"
+}
+
+// ToolWithCostHint 可选接口：声明单次调用的预期延迟与费用，供 Planner 成本感知规划；配置标注（Registry.Annotate）优先
+type ToolWithCostHint interface {
+	Tool
+	// ExpectedLatency 单次调用预期延迟；0 表示未知
+	ExpectedLatency() time.Duration
+	// CostPerCall 单次调用费用（USD）；0 表示免费或未知
+	CostPerCall() float64
+}
+
+// ToolWithCategories 可选接口：声明工具类别（如 network-write、payments、code-exec），供全局熔断按类别禁用；
+// 配置标注（Registry.Categorize）与声明合并
+type ToolWithCategories interface {
+	Tool
+	Categories() []string
+}
+
+// SemaphoreRef 工具调用需获取的集群级命名信号量；Limit 为全集群并发上限，配置 agent.tool_semaphores 中的同名上限优先
+type SemaphoreRef struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// ToolWithSemaphores 可选接口：声明调用前需获取的命名信号量（如共享同一 ERP 的工具声明 {erp, 2}），
+// 无论多少 Worker 运行，同名信号量的并发调用不超过上限；配置绑定（Registry.BindSemaphore）与声明合并
+type ToolWithSemaphores interface {
+	Tool
+	Semaphores() []SemaphoreRef
+}
+
+// ToolWithSandbox 可选接口：声明工具可在调试沙箱（POST /api/jobs/:id/nodes/:node_id/debug-run）中真实执行；
+// 未实现或返回 false 的工具在沙箱中只能使用录制结果
+type ToolWithSandbox interface {
+	Tool
+	SandboxSafe() bool
+}
+
+// IsSandboxSafe 工具是否可在调试沙箱中真实执行
+func IsSandboxSafe(t Tool) bool {
+	s, ok := t.(ToolWithSandbox)
+	return ok && s.SandboxSafe()
+}
+
+// ToolWithIdempotency 可选接口：显式声明工具幂等（同参数重复调用无额外副作用），Replay 时无已记录结果可重新执行而非failed；
+// VerificationHint 说明如何核对重执行结果（如「按 order_id 查询订单状态」），写入事件供审计。配置标注（Registry.MarkIdempotent）优先
+type ToolWithIdempotency interface {
+	Tool
+	Idempotent() bool
+	VerificationHint() string
+}
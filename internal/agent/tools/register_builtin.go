@@ -16,11 +16,12 @@ package tools
 
 import (
 	"rag-platform/internal/runtime/eino"
+	"rag-platform/internal/tool"
 	"rag-platform/internal/tool/builtin"
 )
 
-// RegisterBuiltin 将 tool/builtin 包装为 Session 感知工具并注册到 agent/tools.Registry
-func RegisterBuiltin(reg *Registry, engine *eino.Engine, generator eino.Generator) {
+// RegisterBuiltin 将 tool/builtin 包装为 Session 感知工具并注册到 agent/tools.Registry；extra 为按配置启用的额外内置工具（如 web_search、web_fetch）
+func RegisterBuiltin(reg *Registry, engine *eino.Engine, generator eino.Generator, extra ...tool.Tool) {
 	if reg == nil {
 		return
 	}
@@ -33,4 +34,9 @@ func RegisterBuiltin(reg *Registry, engine *eino.Engine, generator eino.Generato
 		reg.Register(Wrap(builtin.NewLLMGenerateTool(generator)))
 	}
 	reg.Register(Wrap(builtin.NewHTTPTool()))
+	for _, t := range extra {
+		if t != nil {
+			reg.Register(Wrap(t))
+		}
+	}
 }
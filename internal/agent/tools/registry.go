@@ -16,18 +16,170 @@ package tools
 
 import (
 	"encoding/json"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// CostHint 工具单次调用的预期延迟与费用（供 Planner 成本感知规划与计划 ETA/成本预估）
+type CostHint struct {
+	ExpectedLatency time.Duration
+	CostPerCall     float64 // 单次调用费用（USD）
+}
+
 // Registry Agent 可发现的工具注册表
 type Registry struct {
-	mu    sync.RWMutex
-	tools map[string]Tool
+	mu         sync.RWMutex
+	tools      map[string]Tool
+	costHints  map[string]CostHint // 配置注入的成本/延迟标注，优先于工具自身声明
+	categories map[string][]string // 配置注入的工具类别，与工具自身声明合并
+	idempotent map[string]string   // 配置注入的幂等声明：工具名 -> 校验提示，优先于工具自身声明
+	semaphores map[string][]string // 配置绑定的命名信号量：工具名 -> 信号量名，与工具自身声明合并
 }
 
 // NewRegistry 创建新 Registry
 func NewRegistry() *Registry {
-	return &Registry{tools: make(map[string]Tool)}
+	return &Registry{tools: make(map[string]Tool), costHints: make(map[string]CostHint), categories: make(map[string][]string), idempotent: make(map[string]string), semaphores: make(map[string][]string)}
+}
+
+// Annotate 为工具设置预期延迟与单次费用（如来自配置 agent.plan_cost.tools）；覆盖工具通过 ToolWithCostHint 的声明
+func (r *Registry) Annotate(name string, hint CostHint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.costHints[name] = hint
+}
+
+// CostHint 返回工具的成本/延迟标注；配置标注优先，其次 ToolWithCostHint 声明；均无时 ok=false
+func (r *Registry) CostHint(name string) (CostHint, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.costHintLocked(name)
+}
+
+func (r *Registry) costHintLocked(name string) (CostHint, bool) {
+	if h, ok := r.costHints[name]; ok {
+		return h, true
+	}
+	if t, ok := r.tools[name]; ok {
+		if w, ok := t.(ToolWithCostHint); ok {
+			h := CostHint{ExpectedLatency: w.ExpectedLatency(), CostPerCall: w.CostPerCall()}
+			return h, h.ExpectedLatency > 0 || h.CostPerCall > 0
+		}
+	}
+	return CostHint{}, false
+}
+
+// Categorize 为工具追加类别（如来自配置 agent.tool_categories）；与工具通过 ToolWithCategories 的声明合并
+func (r *Registry) Categorize(name string, categories ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories[name] = append(r.categories[name], categories...)
+}
+
+// Categories 返回工具的类别（配置标注与工具声明合并、去重、小写，按字母序）；未知工具仅返回配置标注
+func (r *Registry) Categories(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.categoriesLocked(name)
+}
+
+func (r *Registry) categoriesLocked(name string) []string {
+	all := append([]string(nil), r.categories[name]...)
+	if t, ok := r.tools[name]; ok {
+		if w, ok := t.(ToolWithCategories); ok {
+			all = append(all, w.Categories()...)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(all))
+	out := make([]string, 0, len(all))
+	for _, c := range all {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// BindSemaphore 为工具绑定命名信号量（如来自配置 agent.tool_semaphores）；与工具通过 ToolWithSemaphores 的声明合并
+func (r *Registry) BindSemaphore(name string, semaphores ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.semaphores[name] = append(r.semaphores[name], semaphores...)
+}
+
+// Semaphores 返回工具需获取的命名信号量（配置绑定与工具声明合并，按名称排序）；同名多次声明时取最小的非零上限，仅配置绑定的 Limit 为 0
+func (r *Registry) Semaphores(name string) []SemaphoreRef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.semaphoresLocked(name)
+}
+
+func (r *Registry) semaphoresLocked(name string) []SemaphoreRef {
+	var all []SemaphoreRef
+	for _, s := range r.semaphores[name] {
+		all = append(all, SemaphoreRef{Name: s})
+	}
+	if t, ok := r.tools[name]; ok {
+		if w, ok := t.(ToolWithSemaphores); ok {
+			all = append(all, w.Semaphores()...)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	byName := make(map[string]int, len(all))
+	var out []SemaphoreRef
+	for _, s := range all {
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Name == "" {
+			continue
+		}
+		i, ok := byName[s.Name]
+		if !ok {
+			byName[s.Name] = len(out)
+			out = append(out, s)
+			continue
+		}
+		if s.Limit > 0 && (out[i].Limit == 0 || s.Limit < out[i].Limit) {
+			out[i].Limit = s.Limit
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	return out
+}
+
+// MarkIdempotent 声明工具幂等（如来自配置 agent.idempotent_tools）；verificationHint 为重执行结果的核对方式，可为空
+func (r *Registry) MarkIdempotent(name, verificationHint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.idempotent[name] = verificationHint
+}
+
+// Idempotency 返回工具是否声明幂等及其校验提示；配置声明优先，其次 ToolWithIdempotency；签名与 sandbox.IdempotencyLookup 一致
+func (r *Registry) Idempotency(name string) (verificationHint string, idempotent bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.idempotencyLocked(name)
+}
+
+func (r *Registry) idempotencyLocked(name string) (string, bool) {
+	if hint, ok := r.idempotent[name]; ok {
+		return hint, true
+	}
+	if t, ok := r.tools[name]; ok {
+		if w, ok := t.(ToolWithIdempotency); ok && w.Idempotent() {
+			return w.VerificationHint(), true
+		}
+	}
+	return "", false
 }
 
 // Register 注册工具
@@ -72,11 +224,13 @@ func (r *Registry) List() []Tool {
 	return list
 }
 
-// ToolSchemaForLLM 供 LLM 使用的工具描述
+// ToolSchemaForLLM 供 LLM 使用的工具描述；含成本/延迟标注时 Planner 可据此做成本感知规划
 type ToolSchemaForLLM struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Parameters  map[string]any `json:"parameters"`
+	Name              string         `json:"name"`
+	Description       string         `json:"description"`
+	Parameters        map[string]any `json:"parameters"`
+	ExpectedLatencyMs int64          `json:"expected_latency_ms,omitempty"`
+	CostPerCall       float64        `json:"cost_per_call,omitempty"`
 }
 
 // ToolManifest 工具能力声明（可发现、可版本化）
@@ -88,6 +242,15 @@ type ToolManifest struct {
 	Timeout      string         `json:"timeout,omitempty"`
 	Version      string         `json:"version,omitempty"`
 	Capability   string         `json:"capability,omitempty"` // 所需 capability，供 RBAC/策略校验；空则用 name
+	Categories   []string       `json:"categories,omitempty"` // 工具类别（network-write、payments、code-exec 等），全局熔断按类别禁用
+	// ExpectedLatency 单次调用预期延迟（如 "1.5s"）；CostPerCall 单次调用费用（USD）；供 Planner 成本感知规划与计划预估
+	ExpectedLatency string  `json:"expected_latency,omitempty"`
+	CostPerCall     float64 `json:"cost_per_call,omitempty"`
+	// Idempotent 显式声明幂等：Replay 时无已记录结果可重新执行（其余工具一律视为已提交副作用）；VerificationHint 为重执行结果的核对方式
+	Idempotent       bool   `json:"idempotent,omitempty"`
+	VerificationHint string `json:"verification_hint,omitempty"`
+	// Semaphores 调用前需获取的集群级命名信号量（外部系统并发上限）
+	Semaphores []SemaphoreRef `json:"semaphores,omitempty"`
 }
 
 // SchemasForLLM 返回所有工具的 Schema 列表（JSON，供 Planner 使用）
@@ -96,11 +259,16 @@ func (r *Registry) SchemasForLLM() ([]byte, error) {
 	defer r.mu.RUnlock()
 	list := make([]ToolSchemaForLLM, 0, len(r.tools))
 	for _, t := range r.tools {
-		list = append(list, ToolSchemaForLLM{
+		item := ToolSchemaForLLM{
 			Name:        t.Name(),
 			Description: t.Description(),
 			Parameters:  t.Schema(),
-		})
+		}
+		if h, ok := r.costHintLocked(t.Name()); ok {
+			item.ExpectedLatencyMs = h.ExpectedLatency.Milliseconds()
+			item.CostPerCall = h.CostPerCall
+		}
+		list = append(list, item)
 	}
 	return json.Marshal(list)
 }
@@ -111,10 +279,16 @@ func (r *Registry) Manifests() []ToolManifest {
 	defer r.mu.RUnlock()
 	list := make([]ToolManifest, 0, len(r.tools))
 	for _, t := range r.tools {
-		m := ToolManifest{Name: t.Name(), Description: t.Description(), InputSchema: t.Schema(), OutputSchema: nil, Timeout: "", Version: "1.0"}
+		m := ToolManifest{Name: t.Name(), Description: t.Description(), InputSchema: t.Schema(), OutputSchema: nil, Timeout: "", Version: ToolVersion(t)}
 		if w, ok := t.(ToolWithCapability); ok && w.RequiredCapability() != "" {
 			m.Capability = w.RequiredCapability()
 		}
+		if h, ok := r.costHintLocked(t.Name()); ok {
+			m.applyCostHint(h)
+		}
+		m.Categories = r.categoriesLocked(t.Name())
+		m.VerificationHint, m.Idempotent = r.idempotencyLocked(t.Name())
+		m.Semaphores = r.semaphoresLocked(t.Name())
 		list = append(list, m)
 	}
 	return list
@@ -132,10 +306,23 @@ func (r *Registry) Manifest(name string) *ToolManifest {
 		InputSchema:  t.Schema(),
 		OutputSchema: nil,
 		Timeout:      "",
-		Version:      "1.0",
+		Version:      ToolVersion(t),
 	}
 	if w, ok := t.(ToolWithCapability); ok && w.RequiredCapability() != "" {
 		m.Capability = w.RequiredCapability()
 	}
+	if h, ok := r.CostHint(name); ok {
+		m.applyCostHint(h)
+	}
+	m.Categories = r.Categories(name)
+	m.VerificationHint, m.Idempotent = r.Idempotency(name)
+	m.Semaphores = r.Semaphores(name)
 	return m
 }
+
+func (m *ToolManifest) applyCostHint(h CostHint) {
+	if h.ExpectedLatency > 0 {
+		m.ExpectedLatency = h.ExpectedLatency.String()
+	}
+	m.CostPerCall = h.CostPerCall
+}
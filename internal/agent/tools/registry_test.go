@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"rag-platform/internal/runtime/session"
 )
@@ -74,3 +75,120 @@ func TestRegistry_SchemasForLLM(t *testing.T) {
 		t.Errorf("SchemasForLLM: %+v", list)
 	}
 }
+
+type costlyTool struct{ mockTool }
+
+func (costlyTool) ExpectedLatency() time.Duration { return 2 * time.Second }
+func (costlyTool) CostPerCall() float64           { return 0.01 }
+
+func TestRegistry_CostHints(t *testing.T) {
+	r := NewRegistry()
+	r.Register(costlyTool{mockTool{name: "crawl", desc: "crawl"}})
+	r.Register(mockTool{name: "search", desc: "search"})
+	r.Annotate("search", CostHint{ExpectedLatency: 300 * time.Millisecond, CostPerCall: 0.002})
+
+	m := r.Manifest("crawl")
+	if m == nil || m.ExpectedLatency != "2s" || m.CostPerCall != 0.01 {
+		t.Fatalf("crawl manifest = %+v", m)
+	}
+	if h, ok := r.CostHint("search"); !ok || h.CostPerCall != 0.002 {
+		t.Fatalf("search hint = %+v ok=%v", h, ok)
+	}
+	raw, err := r.SchemasForLLM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []ToolSchemaForLLM
+	if err := json.Unmarshal(raw, &list); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]ToolSchemaForLLM{}
+	for _, s := range list {
+		got[s.Name] = s
+	}
+	if got["search"].ExpectedLatencyMs != 300 || got["crawl"].CostPerCall != 0.01 {
+		t.Fatalf("schemas = %+v", got)
+	}
+}
+
+type categorizedTool struct {
+	mockTool
+}
+
+func (categorizedTool) Categories() []string { return []string{"network-write"} }
+
+func TestRegistry_Categories(t *testing.T) {
+	r := NewRegistry()
+	r.Register(categorizedTool{mockTool{name: "http", desc: "http"}})
+	r.Register(mockTool{name: "search", desc: "search"})
+	r.Categorize("http", "Payments", "network-write")
+
+	if got := r.Categories("http"); len(got) != 2 || got[0] != "network-write" || got[1] != "payments" {
+		t.Fatalf("http categories = %v", got)
+	}
+	if got := r.Categories("search"); got != nil {
+		t.Fatalf("search categories = %v", got)
+	}
+	if m := r.Manifest("http"); m == nil || len(m.Categories) != 2 {
+		t.Fatalf("http manifest = %+v", m)
+	}
+}
+
+type idempotentTool struct {
+	mockTool
+}
+
+func (idempotentTool) Idempotent() bool         { return true }
+func (idempotentTool) VerificationHint() string { return "query order by id" }
+
+func TestRegistry_Idempotency(t *testing.T) {
+	r := NewRegistry()
+	r.Register(idempotentTool{mockTool{name: "order.get", desc: "get"}})
+	r.Register(mockTool{name: "order.create", desc: "create"})
+	r.Register(mockTool{name: "search", desc: "search"})
+	r.MarkIdempotent("search", "")
+
+	if hint, ok := r.Idempotency("order.get"); !ok || hint != "query order by id" {
+		t.Fatalf("order.get = %q %v", hint, ok)
+	}
+	if _, ok := r.Idempotency("order.create"); ok {
+		t.Fatal("order.create must not be idempotent by default")
+	}
+	if _, ok := r.Idempotency("search"); !ok {
+		t.Fatal("search marked idempotent by config")
+	}
+	if m := r.Manifest("order.get"); m == nil || !m.Idempotent || m.VerificationHint != "query order by id" {
+		t.Fatalf("order.get manifest = %+v", m)
+	}
+	for _, m := range r.Manifests() {
+		if m.Name == "order.create" && m.Idempotent {
+			t.Fatalf("order.create manifest = %+v", m)
+		}
+	}
+}
+
+type semaphoreTool struct {
+	mockTool
+	refs []SemaphoreRef
+}
+
+func (s semaphoreTool) Semaphores() []SemaphoreRef { return s.refs }
+
+func TestRegistry_Semaphores(t *testing.T) {
+	r := NewRegistry()
+	r.Register(semaphoreTool{mockTool{name: "erp.create", desc: "create"}, []SemaphoreRef{{Name: "erp", Limit: 4}, {Name: "erp", Limit: 2}}})
+	r.Register(mockTool{name: "erp.query", desc: "query"})
+	r.BindSemaphore("erp.query", "erp")
+	r.BindSemaphore("erp.create", "billing")
+
+	got := r.Semaphores("erp.create")
+	if len(got) != 2 || got[0] != (SemaphoreRef{Name: "billing"}) || got[1] != (SemaphoreRef{Name: "erp", Limit: 2}) {
+		t.Fatalf("erp.create semaphores = %+v", got)
+	}
+	if got := r.Semaphores("erp.query"); len(got) != 1 || got[0].Name != "erp" || got[0].Limit != 0 {
+		t.Fatalf("erp.query semaphores = %+v", got)
+	}
+	if m := r.Manifest("erp.create"); m == nil || len(m.Semaphores) != 2 {
+		t.Fatalf("erp.create manifest = %+v", m)
+	}
+}
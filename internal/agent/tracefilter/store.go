@@ -0,0 +1,119 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracefilter Trace 概览的已保存筛选条件（按用户保存的 Agent、状态、时间范围组合）
+package tracefilter
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 筛选预设不存在
+var ErrNotFound = errors.New("tracefilter: not found")
+
+// Filters Trace 概览筛选条件；Window 为相对时间（如 "24h"，表示最近 24 小时），与 From/To 同时设置时以 From/To 为准
+type Filters struct {
+	AgentIDs []string   `json:"agent_ids,omitempty"`
+	Statuses []string   `json:"statuses,omitempty"`
+	Window   string     `json:"window,omitempty"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+}
+
+// Preset 用户保存的筛选预设（租户 + 用户内按 Name 唯一）
+type Preset struct {
+	TenantID  string    `json:"-"`
+	UserID    string    `json:"-"`
+	Name      string    `json:"name"`
+	Filters   Filters   `json:"filters"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store 筛选预设存储
+type Store interface {
+	// Put 创建或整体替换预设（按 TenantID+UserID+Name）
+	Put(ctx context.Context, p *Preset) error
+	// List 列出用户的全部预设（按名称排序）
+	List(ctx context.Context, tenantID, userID string) ([]*Preset, error)
+	// Delete 删除预设；不存在返回 ErrNotFound
+	Delete(ctx context.Context, tenantID, userID, name string) error
+}
+
+// StoreMem 内存实现
+type StoreMem struct {
+	mu    sync.RWMutex
+	items map[string]*Preset
+}
+
+// NewStoreMem 创建内存筛选预设存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{items: make(map[string]*Preset)}
+}
+
+func memKey(tenantID, userID, name string) string { return tenantID + "\x00" + userID + "\x00" + name }
+
+func clonePreset(p *Preset) *Preset {
+	cp := *p
+	cp.Filters.AgentIDs = append([]string(nil), p.Filters.AgentIDs...)
+	cp.Filters.Statuses = append([]string(nil), p.Filters.Statuses...)
+	return &cp
+}
+
+func (s *StoreMem) Put(ctx context.Context, p *Preset) error {
+	if p == nil {
+		return errors.New("preset is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := clonePreset(p)
+	now := time.Now()
+	k := memKey(cp.TenantID, cp.UserID, cp.Name)
+	if prev, ok := s.items[k]; ok {
+		cp.CreatedAt = prev.CreatedAt
+	} else {
+		cp.CreatedAt = now
+	}
+	cp.UpdatedAt = now
+	s.items[k] = cp
+	return nil
+}
+
+func (s *StoreMem) List(ctx context.Context, tenantID, userID string) ([]*Preset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Preset
+	for _, p := range s.items {
+		if p.TenantID == tenantID && p.UserID == userID {
+			out = append(out, clonePreset(p))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (s *StoreMem) Delete(ctx context.Context, tenantID, userID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := memKey(tenantID, userID, name)
+	if _, ok := s.items[k]; !ok {
+		return ErrNotFound
+	}
+	delete(s.items, k)
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracefilter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 trace_filter_presets 表
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的筛选预设存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+func (s *StorePg) Put(ctx context.Context, p *Preset) error {
+	if p == nil {
+		return errors.New("preset is nil")
+	}
+	filters, err := json.Marshal(p.Filters)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO trace_filter_presets (tenant_id, user_id, name, filters) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (tenant_id, user_id, name) DO UPDATE SET filters = EXCLUDED.filters, updated_at = now()`,
+		p.TenantID, p.UserID, p.Name, filters)
+	return err
+}
+
+func (s *StorePg) List(ctx context.Context, tenantID, userID string) ([]*Preset, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT name, filters, created_at, updated_at FROM trace_filter_presets WHERE tenant_id = $1 AND user_id = $2 ORDER BY name`,
+		tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Preset
+	for rows.Next() {
+		p := &Preset{TenantID: tenantID, UserID: userID}
+		var filters []byte
+		if err := rows.Scan(&p.Name, &filters, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if len(filters) > 0 {
+			_ = json.Unmarshal(filters, &p.Filters)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (s *StorePg) Delete(ctx context.Context, tenantID, userID, name string) error {
+	cmd, err := s.pool.Exec(ctx,
+		`DELETE FROM trace_filter_presets WHERE tenant_id = $1 AND user_id = $2 AND name = $3`, tenantID, userID, name)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+)
+
+// 验证结果的保证级别（Result.AssuranceLevel）
+const (
+	// AssuranceFull 由完整事件流重新计算全部证明（含 Replay 证明）
+	AssuranceFull = "full"
+	// AssuranceAttestation 原始事件已（部分）被留存策略删除，结果取自 Job 结束时固化的证明记录；无法重做 Replay 证明，也无法发现证明记录写入之前的篡改
+	AssuranceAttestation = "attestation"
+	// AssuranceNone 既无事件也无证明记录
+	AssuranceNone = "none"
+)
+
+// Attestation Job 进入终态时固化的紧凑证明记录：覆盖事件流前 EventCount 条（至最后一条终态事件），
+// 事件被留存策略删除后 /verify 据此给出降级的验证结果
+type Attestation struct {
+	JobID                     string            `json:"job_id"`
+	ExecutionHash             string            `json:"execution_hash"`
+	EventChainRootHash        string            `json:"event_chain_root_hash"`
+	EventCount                int               `json:"event_count"`
+	FirstEventID              string            `json:"first_event_id,omitempty"`
+	TerminalEvent             string            `json:"terminal_event"`
+	ToolInvocationLedgerProof LedgerProofResult `json:"tool_invocation_ledger_proof"`
+	CreatedAt                 time.Time         `json:"created_at"`
+}
+
+// AttestationStore 证明记录存储；同一 Job 仅保留最新一条（Job 重跑后再次进入终态时覆盖）
+type AttestationStore interface {
+	Put(ctx context.Context, a *Attestation) error
+	// Get 不存在时返回 nil, nil
+	Get(ctx context.Context, jobID string) (*Attestation, error)
+}
+
+func isTerminal(t jobstore.EventType) bool {
+	return t == jobstore.JobCompleted || t == jobstore.JobFailed || t == jobstore.JobCancelled
+}
+
+// NewAttestation 对事件流中截至最后一条终态事件的前缀生成证明记录；尚无终态事件时返回 nil
+func NewAttestation(jobID string, events []jobstore.JobEvent) *Attestation {
+	end := -1
+	for i, e := range events {
+		if isTerminal(e.Type) {
+			end = i
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+	prefix := events[:end+1]
+	return &Attestation{
+		JobID:                     jobID,
+		ExecutionHash:             ExecutionHash(prefix),
+		EventChainRootHash:        EventChainRoot(prefix),
+		EventCount:                len(prefix),
+		FirstEventID:              prefix[0].ID,
+		TerminalEvent:             string(events[end].Type),
+		ToolInvocationLedgerProof: LedgerProof(prefix),
+		CreatedAt:                 time.Now().UTC(),
+	}
+}
+
+// AttestationStoreMem 内存实现
+type AttestationStoreMem struct {
+	mu    sync.RWMutex
+	byJob map[string]Attestation
+}
+
+// NewAttestationStoreMem 创建内存证明记录存储
+func NewAttestationStoreMem() *AttestationStoreMem {
+	return &AttestationStoreMem{byJob: make(map[string]Attestation)}
+}
+
+func (s *AttestationStoreMem) Put(ctx context.Context, a *Attestation) error {
+	if a == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byJob[a.JobID] = *a
+	return nil
+}
+
+func (s *AttestationStoreMem) Get(ctx context.Context, jobID string) (*Attestation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.byJob[jobID]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// AttestingStore JobStore 装饰器：终态事件（job_completed/failed/cancelled）写入成功后固化证明记录；
+// 写入证明失败仅记录日志，不影响 Append 结果
+type AttestingStore struct {
+	jobstore.JobStore
+	attestations AttestationStore
+	logger       *log.Logger
+}
+
+// NewAttestingStore 包装 inner
+func NewAttestingStore(inner jobstore.JobStore, attestations AttestationStore, logger *log.Logger) *AttestingStore {
+	return &AttestingStore{JobStore: inner, attestations: attestations, logger: logger}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *AttestingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+// Append 先写入底层事件流，终态事件成功写入后生成并保存证明记录
+func (s *AttestingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	newVersion, err := s.JobStore.Append(ctx, jobID, expectedVersion, event)
+	if err != nil || !isTerminal(event.Type) {
+		return newVersion, err
+	}
+	ctx = context.WithoutCancel(ctx)
+	events, _, lerr := s.JobStore.ListEvents(ctx, jobID)
+	if lerr == nil {
+		lerr = s.attestations.Put(ctx, NewAttestation(jobID, events))
+	}
+	if lerr != nil && s.logger != nil {
+		s.logger.Warn("写入 Job 证明记录失败", "job_id", jobID, "error", lerr)
+	}
+	return newVersion, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AttestationStorePg PostgreSQL 实现，使用 job_attestations 表；不随 job_events 一起被留存策略清理
+type AttestationStorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewAttestationStorePg 创建基于 PostgreSQL 的证明记录存储
+func NewAttestationStorePg(pool *pgxpool.Pool) *AttestationStorePg {
+	return &AttestationStorePg{pool: pool}
+}
+
+func (s *AttestationStorePg) Put(ctx context.Context, a *Attestation) error {
+	if a == nil {
+		return nil
+	}
+	ledger, err := json.Marshal(a.ToolInvocationLedgerProof)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO job_attestations (job_id, execution_hash, event_chain_root_hash, event_count, first_event_id, terminal_event, ledger_proof, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (job_id) DO UPDATE SET execution_hash = EXCLUDED.execution_hash, event_chain_root_hash = EXCLUDED.event_chain_root_hash,
+		   event_count = EXCLUDED.event_count, first_event_id = EXCLUDED.first_event_id, terminal_event = EXCLUDED.terminal_event, ledger_proof = EXCLUDED.ledger_proof, created_at = EXCLUDED.created_at`,
+		a.JobID, a.ExecutionHash, a.EventChainRootHash, a.EventCount, a.FirstEventID, a.TerminalEvent, ledger, a.CreatedAt)
+	return err
+}
+
+func (s *AttestationStorePg) Get(ctx context.Context, jobID string) (*Attestation, error) {
+	var a Attestation
+	var ledger []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT job_id, execution_hash, event_chain_root_hash, event_count, first_event_id, terminal_event, ledger_proof, created_at FROM job_attestations WHERE job_id = $1`,
+		jobID).Scan(&a.JobID, &a.ExecutionHash, &a.EventChainRootHash, &a.EventCount, &a.FirstEventID, &a.TerminalEvent, &ledger, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(ledger, &a.ToolInvocationLedgerProof); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func appendAll(t *testing.T, store jobstore.JobStore, jobID string, types ...jobstore.EventType) {
+	t.Helper()
+	ctx := context.Background()
+	_, ver, err := store.ListEvents(ctx, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range types {
+		payload, _ := json.Marshal(map[string]string{"node_id": "n1", "result_type": "success", "idempotency_key": "k1"})
+		if ver, err = store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: payload}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAttestingStore_RecordsOnTerminalEvent(t *testing.T) {
+	ctx := context.Background()
+	atts := NewAttestationStoreMem()
+	store := NewAttestingStore(jobstore.NewMemoryStore(), atts, nil)
+
+	appendAll(t, store, "j1", jobstore.JobCreated, jobstore.ToolInvocationStarted, jobstore.ToolInvocationFinished, jobstore.NodeFinished)
+	if a, _ := atts.Get(ctx, "j1"); a != nil {
+		t.Fatalf("attestation before terminal event: %+v", a)
+	}
+	appendAll(t, store, "j1", jobstore.JobCompleted)
+	a, err := atts.Get(ctx, "j1")
+	if err != nil || a == nil {
+		t.Fatalf("attestation = %v, %v", a, err)
+	}
+	events, _, _ := store.ListEvents(ctx, "j1")
+	if a.EventCount != 5 || a.TerminalEvent != string(jobstore.JobCompleted) || a.FirstEventID != events[0].ID ||
+		a.EventChainRootHash != EventChainRoot(events) || a.ExecutionHash != ExecutionHash(events) || !a.ToolInvocationLedgerProof.OK {
+		t.Fatalf("attestation = %+v", a)
+	}
+}
+
+func TestComputeWithAttestation(t *testing.T) {
+	ctx := context.Background()
+	atts := NewAttestationStoreMem()
+	store := NewAttestingStore(jobstore.NewMemoryStore(), atts, nil)
+	appendAll(t, store, "j1", jobstore.JobCreated, jobstore.NodeFinished, jobstore.JobCompleted)
+	// 终态之后的审计事件不影响比对
+	appendAll(t, store, "j1", jobstore.AccessAudited)
+	events, _, _ := store.ListEvents(ctx, "j1")
+	att, _ := atts.Get(ctx, "j1")
+
+	res, err := ComputeWithAttestation(ctx, events, "j1", nil, att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AssuranceLevel != AssuranceFull || res.Attestation == nil || !res.Attestation.Match {
+		t.Fatalf("full result = %+v %+v", res, res.Attestation)
+	}
+
+	tampered := append([]jobstore.JobEvent(nil), events...)
+	tampered[1].Payload = []byte(`{"node_id":"n1","result_type":"failure"}`)
+	res, _ = ComputeWithAttestation(ctx, tampered, "j1", nil, att)
+	if res.Attestation.Match || len(res.Attestation.Mismatch) != 2 {
+		t.Fatalf("tampered result = %+v", res.Attestation)
+	}
+
+	// 留存策略删除了头部事件：结果取自证明记录，保证级别降级
+	res, _ = ComputeWithAttestation(ctx, events[2:], "j1", nil, att)
+	if res.AssuranceLevel != AssuranceAttestation || res.ExecutionHash != att.ExecutionHash ||
+		res.EventChainRootHash != att.EventChainRootHash || res.ReplayProofResult.OK || res.Attestation.RetainedEvents != 2 {
+		t.Fatalf("trimmed result = %+v", res)
+	}
+	res, _ = ComputeWithAttestation(ctx, nil, "j1", nil, att)
+	if res.AssuranceLevel != AssuranceAttestation {
+		t.Fatalf("purged result = %+v", res)
+	}
+	res, _ = ComputeWithAttestation(ctx, nil, "j2", nil, nil)
+	if res.AssuranceLevel != AssuranceNone {
+		t.Fatalf("no data result = %+v", res)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"rag-platform/internal/agent/environment"
 	"rag-platform/internal/agent/replay"
 	"rag-platform/internal/runtime/jobstore"
 )
@@ -32,6 +34,24 @@ type Result struct {
 	EventChainRootHash        string            `json:"event_chain_root_hash"`
 	ToolInvocationLedgerProof LedgerProofResult `json:"tool_invocation_ledger_proof"`
 	ReplayProofResult         ReplayProofResult `json:"replay_proof_result"`
+	// AssuranceLevel full | attestation | none；attestation 表示原始事件已被留存策略删除，结果取自证明记录
+	AssuranceLevel string `json:"assurance_level"`
+	// Attestation 存在证明记录时附带：与当前事件流的比对结果
+	Attestation *AttestationCheck `json:"attestation,omitempty"`
+	// Environment Job 创建时固化的执行环境与当前环境的比对（由 API 填充；不影响其余证明）
+	Environment *environment.Check `json:"environment,omitempty"`
+}
+
+// AttestationCheck 证明记录与当前事件流的比对
+type AttestationCheck struct {
+	AttestedAt     time.Time `json:"attested_at"`
+	AttestedEvents int       `json:"attested_events"`
+	RetainedEvents int       `json:"retained_events"`
+	// Match 事件完整时：重新计算的证明与记录一致；事件已删除时不可比对，恒为 false
+	Match bool `json:"match"`
+	// Mismatch 不一致的字段（execution_hash、event_chain_root_hash、tool_invocation_ledger_proof）
+	Mismatch []string `json:"mismatch,omitempty"`
+	Note     string   `json:"note,omitempty"`
 }
 
 // LedgerProofResult Tool invocation ledger 证明：每条 started 均有匹配的 finished 或确定性failed。
@@ -80,9 +100,67 @@ func Compute(ctx context.Context, events []jobstore.JobEvent, jobID string, repl
 		EventChainRootHash:        chainRoot,
 		ToolInvocationLedgerProof: ledgerProof,
 		ReplayProofResult:         ReplayProofResult{OK: replayOK, Error: replayErr},
+		AssuranceLevel:            AssuranceFull,
 	}, nil
 }
 
+// retentionTrimmed 事件流是否已被留存策略从头部删除：条数少于证明记录，或首条事件 ID 与记录不同
+func retentionTrimmed(events []jobstore.JobEvent, att *Attestation) bool {
+	if len(events) < att.EventCount {
+		return true
+	}
+	return att.FirstEventID != "" && events[0].ID != att.FirstEventID
+}
+
+// ComputeWithAttestation 在 Compute 基础上结合 Job 结束时的证明记录（att 可为 nil）：
+// 事件完整时重新计算并与证明记录比对（证明记录之后追加的审计类事件不参与比对）；
+// 事件少于证明记录覆盖的条数（已被留存策略删除）时，返回证明记录中的结果并将 AssuranceLevel 降为 attestation
+func ComputeWithAttestation(ctx context.Context, events []jobstore.JobEvent, jobID string, replayBuilder replay.ReplayContextBuilder, att *Attestation) (*Result, error) {
+	if att == nil {
+		res, err := Compute(ctx, events, jobID, replayBuilder)
+		if err == nil && len(events) == 0 {
+			res.AssuranceLevel = AssuranceNone
+		}
+		return res, err
+	}
+	check := &AttestationCheck{
+		AttestedAt:     att.CreatedAt,
+		AttestedEvents: att.EventCount,
+		RetainedEvents: len(events),
+	}
+	if retentionTrimmed(events, att) {
+		check.Note = "raw events were removed by retention; results are taken from the attestation recorded at job completion and replay proof is unavailable"
+		return &Result{
+			ExecutionHash:             att.ExecutionHash,
+			EventChainRootHash:        att.EventChainRootHash,
+			ToolInvocationLedgerProof: att.ToolInvocationLedgerProof,
+			ReplayProofResult:         ReplayProofResult{OK: false, Error: "events removed by retention"},
+			AssuranceLevel:            AssuranceAttestation,
+			Attestation:               check,
+		}, nil
+	}
+	res, err := Compute(ctx, events, jobID, replayBuilder)
+	if err != nil {
+		return nil, err
+	}
+	attested := events[:att.EventCount]
+	if ExecutionHash(attested) != att.ExecutionHash {
+		check.Mismatch = append(check.Mismatch, "execution_hash")
+	}
+	if EventChainRoot(attested) != att.EventChainRootHash {
+		check.Mismatch = append(check.Mismatch, "event_chain_root_hash")
+	}
+	if LedgerProof(attested).OK != att.ToolInvocationLedgerProof.OK {
+		check.Mismatch = append(check.Mismatch, "tool_invocation_ledger_proof")
+	}
+	check.Match = len(check.Mismatch) == 0
+	if !check.Match {
+		check.Note = "events differ from the attestation recorded at job completion"
+	}
+	res.Attestation = check
+	return res, nil
+}
+
 // EventChainRoot 计算事件链根 hash：H_i = SHA256(H_{i-1} || event_id || type || base64(payload))。
 func EventChainRoot(events []jobstore.JobEvent) string {
 	h := sha256.New()
@@ -0,0 +1,357 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/log"
+)
+
+const (
+	// DefaultPrefix 对象键默认前缀
+	DefaultPrefix = "warehouse/"
+	// DefaultInterval 默认导出间隔
+	DefaultInterval = 15 * time.Minute
+	// DefaultBatchSize 单批次最多扫描的终态 Job 数
+	DefaultBatchSize = 500
+	// DefaultSettleDelay 只导出 updated_at 早于 now-该时长的 Job，避免并发事务晚提交的 Job 落在已推进的水位线之前
+	DefaultSettleDelay = time.Minute
+	// maxBatchesPerRun 单轮最多连续导出的批次数，积压时分多轮追平
+	maxBatchesPerRun = 20
+)
+
+// Options 导出选项；零值字段取默认
+type Options struct {
+	Format      string        // csv（默认）| parquet
+	Prefix      string        // 对象键前缀，默认 warehouse/
+	BatchSize   int           // 单批次扫描的 Job 数
+	SettleDelay time.Duration // 水位线与当前时间的最小间隔
+	// Backfill 首次导出回溯的时长；0 表示导出全部历史
+	Backfill time.Duration
+	// Tenants 非空时只导出这些租户；ExcludeTenants 中的租户始终跳过
+	Tenants        []string
+	ExcludeTenants []string
+	// SampleRate 按 job_id 哈希确定性采样的比例 (0,1]；<=0 或 >=1 时全部导出。同一 Job 在各表中同进同出
+	SampleRate float64
+	// Costs 估算 jobs.cost 的工具/LLM 成本标注
+	Costs planner.CostModel
+}
+
+func (o Options) withDefaults() Options {
+	if o.Format == "" {
+		o.Format = FormatCSV
+	}
+	o.Prefix = object.KeyPrefix(o.Prefix, DefaultPrefix)
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.SettleDelay <= 0 {
+		o.SettleDelay = DefaultSettleDelay
+	}
+	return o
+}
+
+// Batch 一次导出的批次清单；写在 _batches/<batch_id>.json，最后写入，存在即表示该批次的数据文件完整
+type Batch struct {
+	BatchID       string         `json:"batch_id"`
+	SchemaVersion int            `json:"schema_version"`
+	Format        string         `json:"format"`
+	From          Watermark      `json:"from"`
+	To            Watermark      `json:"to"`
+	ScannedJobs   int            `json:"scanned_jobs"`
+	ExportedJobs  int            `json:"exported_jobs"`
+	Rows          map[string]int `json:"rows"`
+	Files         []string       `json:"files"`
+	ExportedAt    time.Time      `json:"exported_at"`
+}
+
+// Watermark 键集位置 (updated_at, job_id)
+type Watermark struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	JobID     string    `json:"job_id,omitempty"`
+}
+
+// Status 导出器运行状态
+type Status struct {
+	Format    string    `json:"format"`
+	Prefix    string    `json:"prefix"`
+	State     *State    `json:"state,omitempty"`
+	LastRunAt time.Time `json:"last_run_at,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	LastBatch *Batch    `json:"last_batch,omitempty"`
+	Schema    []Table   `json:"schema"`
+	Version   int       `json:"schema_version"`
+}
+
+// Exporter 周期将新终态 Job 导出到对象存储
+type Exporter struct {
+	jobs    job.TerminalJobScanner
+	events  jobstore.JobStore
+	objects object.Store
+	state   Store
+	opts    Options
+	logger  *log.Logger
+	now     func() time.Time
+
+	mu        sync.Mutex
+	lastRunAt time.Time
+	lastErr   string
+	lastBatch *Batch
+}
+
+// NewExporter 创建导出器
+func NewExporter(jobs job.TerminalJobScanner, events jobstore.JobStore, objects object.Store, state Store, opts Options, logger *log.Logger) *Exporter {
+	return &Exporter{jobs: jobs, events: events, objects: objects, state: state, opts: opts.withDefaults(), logger: logger, now: time.Now}
+}
+
+// StreamName 水位线记录名：前缀与表结构版本共同决定，换前缀或升级版本即从头导出
+func (e *Exporter) StreamName() string {
+	return fmt.Sprintf("%sv%d", e.opts.Prefix, SchemaVersion)
+}
+
+func (e *Exporter) versionPrefix() string {
+	return fmt.Sprintf("%sv%d/", e.opts.Prefix, SchemaVersion)
+}
+
+// Run 按 interval 周期导出直到 ctx 取消；启动时立即执行一轮，积压时一轮内连续导出多个批次
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for i := 0; i < maxBatchesPerRun && ctx.Err() == nil; i++ {
+			b, err := e.RunOnce(ctx)
+			if errors.Is(err, ErrConflict) {
+				break
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					e.logf("数据仓库导出failed", "error", err)
+				}
+				break
+			}
+			if b == nil || b.ScannedJobs < e.opts.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 导出水位线之后的一个批次并推进水位线；无新 Job 时返回 (nil, nil)
+func (e *Exporter) RunOnce(ctx context.Context) (*Batch, error) {
+	b, err := e.runOnce(ctx)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastRunAt = e.now()
+	e.lastErr = ""
+	if err != nil && !errors.Is(err, ErrConflict) {
+		e.lastErr = err.Error()
+	}
+	if b != nil {
+		e.lastBatch = b
+	}
+	return b, err
+}
+
+func (e *Exporter) runOnce(ctx context.Context) (*Batch, error) {
+	name := e.StreamName()
+	prev, err := e.state.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("read watermark: %w", err)
+	}
+	now := e.now()
+	from := Watermark{}
+	if prev != nil {
+		from = Watermark{UpdatedAt: prev.UpdatedAt, JobID: prev.JobID}
+	} else if e.opts.Backfill > 0 {
+		from.UpdatedAt = now.Add(-e.opts.Backfill)
+	}
+	jobs, err := e.jobs.ListTerminalAfter(ctx, from.UpdatedAt, from.JobID, now.Add(-e.opts.SettleDelay), e.opts.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("list terminal jobs: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	last := jobs[len(jobs)-1]
+	to := Watermark{UpdatedAt: last.UpdatedAt, JobID: last.ID}
+	b := &Batch{
+		BatchID:       batchID(name, from, to),
+		SchemaVersion: SchemaVersion,
+		Format:        e.opts.Format,
+		From:          from,
+		To:            to,
+		ScannedJobs:   len(jobs),
+		Rows:          map[string]int{},
+		ExportedAt:    now.UTC(),
+	}
+
+	rows := Rows{}
+	for _, j := range jobs {
+		if !e.include(j) {
+			continue
+		}
+		events, _, err := e.events.ListEvents(ctx, j.ID)
+		if err != nil {
+			// 事件已清理或暂不可读时仍导出 Job 行，步骤/调用明细缺失
+			e.logf("读取 Job 事件failed，仅导出 Job 行", "job_id", j.ID, "error", err)
+			events = nil
+		}
+		for table, rs := range Extract(j, events, e.opts.Costs, b.BatchID) {
+			rows[table] = append(rows[table], rs...)
+		}
+		b.ExportedJobs++
+	}
+
+	if b.ExportedJobs > 0 {
+		if err := e.ensureSchema(ctx); err != nil {
+			return nil, err
+		}
+		dt := to.UpdatedAt.UTC().Format("2006-01-02")
+		for _, t := range Tables {
+			rs := rows[t.Name]
+			if len(rs) == 0 {
+				continue
+			}
+			data, err := Encode(e.opts.Format, t, rs)
+			if err != nil {
+				return nil, err
+			}
+			// Hive 风格分区目录，外部表可按 dt 裁剪
+			key := fmt.Sprintf("%s%s/dt=%s/%s.%s", e.versionPrefix(), t.Name, dt, b.BatchID, e.opts.Format)
+			if err := e.objects.Put(ctx, key, bytes.NewReader(data), int64(len(data)), map[string]string{"content-type": ContentType(e.opts.Format)}); err != nil {
+				return nil, fmt.Errorf("put %s: %w", key, err)
+			}
+			b.Rows[t.Name] = len(rs)
+			b.Files = append(b.Files, key)
+		}
+		manifest, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		key := e.versionPrefix() + "_batches/" + b.BatchID + ".json"
+		if err := e.objects.Put(ctx, key, bytes.NewReader(manifest), int64(len(manifest)), map[string]string{"content-type": "application/json"}); err != nil {
+			return nil, fmt.Errorf("put %s: %w", key, err)
+		}
+	}
+
+	next := &State{Name: name, UpdatedAt: to.UpdatedAt, JobID: to.JobID, Batches: 1, Jobs: int64(b.ExportedJobs), LastBatchID: b.BatchID, ExportedAt: b.ExportedAt}
+	if prev != nil {
+		next.Batches += prev.Batches
+		next.Jobs += prev.Jobs
+		if b.ExportedJobs == 0 {
+			next.Batches, next.LastBatchID = prev.Batches, prev.LastBatchID
+		}
+	}
+	if err := e.state.Advance(ctx, prev, next); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// include 租户过滤与确定性采样
+func (e *Exporter) include(j *job.Job) bool {
+	for _, t := range e.opts.ExcludeTenants {
+		if t == j.TenantID {
+			return false
+		}
+	}
+	if len(e.opts.Tenants) > 0 {
+		found := false
+		for _, t := range e.opts.Tenants {
+			if t == j.TenantID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r := e.opts.SampleRate; r > 0 && r < 1 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(j.ID))
+		return float64(h.Sum32())/float64(1<<32) < r
+	}
+	return true
+}
+
+// ensureSchema 写入当前版本的表结构描述（_schema.json），供建外部表与下游校验
+func (e *Exporter) ensureSchema(ctx context.Context) error {
+	key := e.versionPrefix() + "_schema.json"
+	ok, err := e.objects.Exists(ctx, key)
+	if err != nil || ok {
+		return err
+	}
+	data, err := json.MarshalIndent(map[string]any{"schema_version": SchemaVersion, "format": e.opts.Format, "tables": Tables}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return e.objects.Put(ctx, key, bytes.NewReader(data), int64(len(data)), map[string]string{"content-type": "application/json"})
+}
+
+// batchID 由导出流与水位线区间确定：并发或重试导出同一区间时对象键相同，覆盖写入不产生重复文件
+func batchID(name string, from, to Watermark) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s|%s", name,
+		from.UpdatedAt.UTC().Format(time.RFC3339Nano), from.JobID, to.UpdatedAt.UTC().Format(time.RFC3339Nano), to.JobID)))
+	return to.UpdatedAt.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(sum[:6])
+}
+
+// Status 返回当前水位线与最近一轮的结果
+func (e *Exporter) Status(ctx context.Context) (*Status, error) {
+	st, err := e.state.Get(ctx, e.StreamName())
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &Status{
+		Format:    e.opts.Format,
+		Prefix:    e.versionPrefix(),
+		State:     st,
+		LastRunAt: e.lastRunAt,
+		LastError: e.lastErr,
+		LastBatch: e.lastBatch,
+		Schema:    Tables,
+		Version:   SchemaVersion,
+	}, nil
+}
+
+func (e *Exporter) logf(msg string, args ...any) {
+	if e.logger != nil {
+		e.logger.Warn(msg, args...)
+	}
+}
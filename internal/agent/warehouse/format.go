@@ -0,0 +1,96 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 文件格式
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// timestampLayout CSV 时间列格式（UTC、毫秒），BigQuery / Snowflake 均可直接解析为 TIMESTAMP
+const timestampLayout = "2006-01-02T15:04:05.000Z"
+
+// Encode 按格式编码一张表的行
+func Encode(format string, t Table, rows []Row) ([]byte, error) {
+	switch format {
+	case FormatCSV, "":
+		return encodeCSV(t, rows)
+	case FormatParquet:
+		return encodeParquet(t, rows)
+	default:
+		return nil, fmt.Errorf("warehouse: unknown format %q", format)
+	}
+}
+
+// ContentType 格式对应的对象 content-type
+func ContentType(format string) string {
+	if format == FormatParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// encodeCSV 首行为列名，NULL 写为空字段
+func encodeCSV(t Table, rows []Row) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Name
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	rec := make([]string, len(t.Columns))
+	for _, r := range rows {
+		if len(r) != len(t.Columns) {
+			return nil, fmt.Errorf("warehouse: %s row has %d values, want %d", t.Name, len(r), len(t.Columns))
+		}
+		for i, v := range r {
+			rec[i] = csvValue(v)
+		}
+		if err := w.Write(rec); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func csvValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case time.Time:
+		return x.UTC().Format(timestampLayout)
+	default:
+		return fmt.Sprint(x)
+	}
+}
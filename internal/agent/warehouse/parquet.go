@@ -0,0 +1,307 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 最小 Parquet 写入（format 2.x 兼容）：扁平 schema、全部列 OPTIONAL、单个 row group、每列一个 PLAIN 编码的 v1 数据页、不压缩。
+// 足以供 BigQuery / Snowflake / DuckDB / Spark 读取；批次大小由导出器控制，无需分页
+
+const parquetMagic = "PAR1"
+
+// Parquet 物理类型、转换类型与编码（parquet.thrift）
+const (
+	pqTypeInt64     = 2
+	pqTypeDouble    = 5
+	pqTypeByteArray = 6
+
+	pqConvertedUTF8            = 0
+	pqConvertedTimestampMillis = 9
+
+	pqRepetitionOptional = 1
+
+	pqEncodingPlain = 0
+	pqEncodingRLE   = 3
+
+	pqCodecUncompressed = 0
+	pqPageTypeData      = 0
+)
+
+func encodeParquet(t Table, rows []Row) ([]byte, error) {
+	for _, r := range rows {
+		if len(r) != len(t.Columns) {
+			return nil, fmt.Errorf("warehouse: %s row has %d values, want %d", t.Name, len(r), len(t.Columns))
+		}
+	}
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(t.Columns))
+	var totalSize int64
+	for ci, col := range t.Columns {
+		var levels []byte
+		var values bytes.Buffer
+		for _, r := range rows {
+			v := r[ci]
+			if v == nil {
+				levels = append(levels, 0)
+				continue
+			}
+			levels = append(levels, 1)
+			if err := plainValue(&values, col, v); err != nil {
+				return nil, fmt.Errorf("warehouse: %s.%s: %w", t.Name, col.Name, err)
+			}
+		}
+		// v1 数据页：定义级别（4 字节长度 + RLE/bit-packed 混合编码，位宽 1），无重复级别，随后为非空值
+		rle := rleLevels(levels)
+		var page bytes.Buffer
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(rle)))
+		page.Write(rle)
+		page.Write(values.Bytes())
+
+		var hdr thriftWriter
+		hdr.i32(1, pqPageTypeData)
+		hdr.i32(2, int32(page.Len()))
+		hdr.i32(3, int32(page.Len()))
+		hdr.structBegin(5)
+		hdr.i32(1, int32(len(rows)))
+		hdr.i32(2, pqEncodingPlain)
+		hdr.i32(3, pqEncodingRLE)
+		hdr.i32(4, pqEncodingRLE)
+		hdr.structEnd()
+		hdr.stop()
+
+		chunks[ci] = chunk{offset: int64(file.Len()), size: int64(hdr.buf.Len() + page.Len())}
+		totalSize += chunks[ci].size
+		file.Write(hdr.buf.Bytes())
+		file.Write(page.Bytes())
+	}
+
+	// FileMetaData
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.listBegin(2, thriftStruct, len(t.Columns)+1)
+	meta.elemBegin()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(t.Columns)))
+	meta.elemEnd()
+	for _, col := range t.Columns {
+		meta.elemBegin()
+		meta.i32(1, physicalType(col.Type))
+		meta.i32(3, pqRepetitionOptional)
+		meta.binary(4, col.Name)
+		switch col.Type {
+		case TypeString:
+			meta.i32(6, pqConvertedUTF8)
+		case TypeTimestamp:
+			meta.i32(6, pqConvertedTimestampMillis)
+		}
+		meta.elemEnd()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.listBegin(4, thriftStruct, 1)
+	meta.elemBegin()
+	meta.listBegin(1, thriftStruct, len(t.Columns))
+	for ci, col := range t.Columns {
+		c := chunks[ci]
+		meta.elemBegin()
+		meta.i64(2, c.offset)
+		meta.structBegin(3)
+		meta.i32(1, physicalType(col.Type))
+		meta.listBegin(2, thriftI32, 2)
+		meta.listI32(pqEncodingPlain)
+		meta.listI32(pqEncodingRLE)
+		meta.listBegin(3, thriftBinary, 1)
+		meta.listBinary(col.Name)
+		meta.i32(4, pqCodecUncompressed)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, c.size)
+		meta.i64(7, c.size)
+		meta.i64(9, c.offset)
+		meta.structEnd()
+		meta.elemEnd()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(len(rows)))
+	meta.elemEnd()
+	meta.binary(6, "aetheris warehouse exporter")
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	_ = binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.WriteString(parquetMagic)
+	return file.Bytes(), nil
+}
+
+func physicalType(t ColumnType) int32 {
+	switch t {
+	case TypeInt64, TypeTimestamp:
+		return pqTypeInt64
+	case TypeFloat64:
+		return pqTypeDouble
+	default:
+		return pqTypeByteArray
+	}
+}
+
+func plainValue(buf *bytes.Buffer, col Column, v any) error {
+	var b [8]byte
+	switch col.Type {
+	case TypeString:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("want string, got %T", v)
+		}
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(s)))
+		buf.Write(b[:4])
+		buf.WriteString(s)
+	case TypeInt64:
+		n, ok := v.(int64)
+		if !ok {
+			return fmt.Errorf("want int64, got %T", v)
+		}
+		binary.LittleEndian.PutUint64(b[:], uint64(n))
+		buf.Write(b[:])
+	case TypeFloat64:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("want float64, got %T", v)
+		}
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+		buf.Write(b[:])
+	case TypeTimestamp:
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("want time.Time, got %T", v)
+		}
+		binary.LittleEndian.PutUint64(b[:], uint64(ts.UnixMilli()))
+		buf.Write(b[:])
+	default:
+		return fmt.Errorf("unknown column type %q", col.Type)
+	}
+	return nil
+}
+
+// rleLevels 以 RLE 游程编码 0/1 级别序列：每段为 varint(len<<1) 加 1 字节取值
+func rleLevels(levels []byte) []byte {
+	var out []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		out = append(out, levels[i])
+		i = j
+	}
+	return out
+}
+
+// Thrift compact protocol 类型
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter 仅覆盖 Parquet 元数据所需的 thrift compact 编码
+type thriftWriter struct {
+	buf   bytes.Buffer
+	last  int16
+	stack []int16
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if d := id - w.last; d > 0 && d <= 15 {
+		w.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(uint64(zigzag(int64(id))))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(zigzag(v))
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *thriftWriter) structBegin(id int16) {
+	w.field(id, thriftStruct)
+	w.elemBegin()
+}
+
+func (w *thriftWriter) structEnd() { w.elemEnd() }
+
+// elemBegin / elemEnd 包裹嵌套结构体（字段或 list 元素）：字段 ID 增量从 0 重新计算
+func (w *thriftWriter) elemBegin() {
+	w.stack = append(w.stack, w.last)
+	w.last = 0
+}
+
+func (w *thriftWriter) elemEnd() {
+	w.stop()
+	w.last = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+func (w *thriftWriter) stop() { w.buf.WriteByte(0) }
+
+func (w *thriftWriter) listBegin(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(n))
+	}
+}
+
+func (w *thriftWriter) listI32(v int32) { w.varint(zigzag(int64(v))) }
+
+func (w *thriftWriter) listBinary(s string) {
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
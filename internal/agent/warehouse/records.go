@@ -0,0 +1,243 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package warehouse 数据仓库批量导出：周期按水位线扫描新终态 Job，将 Job / 步骤 / 工具调用 / LLM 调用规范化为星型模型事实表，
+// 以 CSV 或 Parquet 写入对象存储供 BigQuery、Snowflake 等外部表或批量加载，产品分析无需查询在线 Postgres。
+// 只导出 ID、状态、时间与计量，不含目标、提示词与工具参数/结果
+package warehouse
+
+import (
+	"encoding/json"
+	"time"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// SchemaVersion 导出表结构版本；列有不兼容变更时递增，对象写入新的 v<N>/ 前缀并从头导出
+const SchemaVersion = 1
+
+// ColumnType 列类型；CSV 按文本写出，Parquet 映射为对应物理/逻辑类型
+type ColumnType string
+
+const (
+	TypeString    ColumnType = "string"
+	TypeInt64     ColumnType = "int64"
+	TypeFloat64   ColumnType = "float64"
+	TypeTimestamp ColumnType = "timestamp" // UTC 毫秒
+)
+
+// Column 表的一列
+type Column struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type"`
+}
+
+// Table 一张导出表
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+}
+
+// 表名；jobs 为中心事实表，其余以 job_id 关联，tenant_id/agent_id 为维度键
+const (
+	TableJobs      = "jobs"
+	TableSteps     = "steps"
+	TableToolCalls = "tool_calls"
+	TableLLMCalls  = "llm_calls"
+)
+
+// Tables 当前 SchemaVersion 下的全部表；列顺序即文件列顺序
+var Tables = []Table{
+	{Name: TableJobs, Columns: []Column{
+		{"job_id", TypeString}, {"tenant_id", TypeString}, {"agent_id", TypeString}, {"status", TypeString}, {"profile", TypeString},
+		{"client", TypeString}, {"created_at", TypeTimestamp}, {"finished_at", TypeTimestamp}, {"duration_ms", TypeInt64},
+		{"retry_count", TypeInt64}, {"steps", TypeInt64}, {"failed_steps", TypeInt64}, {"tool_calls", TypeInt64}, {"llm_calls", TypeInt64},
+		{"cost", TypeFloat64}, {"failure_class", TypeString}, {"failed_node_id", TypeString}, {"batch_id", TypeString},
+	}},
+	{Name: TableSteps, Columns: []Column{
+		{"job_id", TypeString}, {"tenant_id", TypeString}, {"agent_id", TypeString}, {"node_id", TypeString}, {"node_type", TypeString},
+		{"attempt", TypeInt64}, {"started_at", TypeTimestamp}, {"finished_at", TypeTimestamp}, {"duration_ms", TypeInt64},
+		{"result_type", TypeString}, {"batch_id", TypeString},
+	}},
+	{Name: TableToolCalls, Columns: []Column{
+		{"job_id", TypeString}, {"tenant_id", TypeString}, {"agent_id", TypeString}, {"node_id", TypeString}, {"invocation_id", TypeString},
+		{"tool_name", TypeString}, {"started_at", TypeTimestamp}, {"finished_at", TypeTimestamp}, {"duration_ms", TypeInt64},
+		{"outcome", TypeString}, {"batch_id", TypeString},
+	}},
+	{Name: TableLLMCalls, Columns: []Column{
+		{"job_id", TypeString}, {"tenant_id", TypeString}, {"agent_id", TypeString}, {"node_id", TypeString}, {"model", TypeString},
+		{"provider", TypeString}, {"failover_from", TypeString}, {"committed_at", TypeTimestamp}, {"batch_id", TypeString},
+	}},
+}
+
+// Row 一行数据，与 Table.Columns 按位置对应；nil 为 NULL，时间为 time.Time
+type Row []any
+
+// Rows 一个批次按表名分组的行
+type Rows map[string][]Row
+
+// Extract 将一个终态 Job 及其事件规范化为各表的行；costs 用于估算 jobs.cost（零值时成本为 0）
+func Extract(j *job.Job, events []jobstore.JobEvent, costs planner.CostModel, batchID string) Rows {
+	out := Rows{}
+	base := func(vals ...any) Row {
+		return append(Row{j.ID, j.TenantID, j.AgentID}, vals...)
+	}
+
+	nodeTypes := make(map[string]string)
+	type open struct {
+		at      time.Time
+		attempt int
+	}
+	nodeStarts := make(map[string]open)
+	toolStarts := make(map[string]Row)
+	var toolOrder []string
+	var steps, failedSteps, llmCalls int64
+	for _, e := range events {
+		switch e.Type {
+		case jobstore.PlanGenerated, jobstore.PlanEvolution:
+			var pl struct {
+				TaskGraph *planner.TaskGraph `json:"task_graph"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.TaskGraph != nil {
+				for _, n := range pl.TaskGraph.Nodes {
+					nodeTypes[n.ID] = n.Type
+				}
+			}
+		case jobstore.NodeStarted:
+			var pl struct {
+				NodeID  string `json:"node_id"`
+				Attempt int    `json:"attempt"`
+			}
+			if json.Unmarshal(e.Payload, &pl) == nil && pl.NodeID != "" {
+				nodeStarts[pl.NodeID] = open{at: e.CreatedAt, attempt: pl.Attempt}
+			}
+		case jobstore.NodeFinished:
+			var pl struct {
+				NodeID     string `json:"node_id"`
+				Attempt    int    `json:"attempt"`
+				DurationMs int64  `json:"duration_ms"`
+				ResultType string `json:"result_type"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.NodeID == "" {
+				continue
+			}
+			if pl.ResultType == "" {
+				pl.ResultType = "success"
+			}
+			var started any
+			st, ok := nodeStarts[pl.NodeID]
+			if ok {
+				started = st.at
+				if pl.DurationMs <= 0 {
+					pl.DurationMs = e.CreatedAt.Sub(st.at).Milliseconds()
+				}
+				if pl.Attempt == 0 {
+					pl.Attempt = st.attempt
+				}
+				delete(nodeStarts, pl.NodeID)
+			}
+			steps++
+			if pl.ResultType != "success" {
+				failedSteps++
+			}
+			out[TableSteps] = append(out[TableSteps], base(pl.NodeID, nullable(nodeTypes[pl.NodeID]), int64(max(pl.Attempt, 1)),
+				started, e.CreatedAt, pl.DurationMs, pl.ResultType, batchID))
+		case jobstore.ToolInvocationStarted:
+			var pl struct {
+				NodeID       string `json:"node_id"`
+				InvocationID string `json:"invocation_id"`
+				ToolName     string `json:"tool_name"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.InvocationID == "" {
+				continue
+			}
+			if _, ok := toolStarts[pl.InvocationID]; !ok {
+				toolOrder = append(toolOrder, pl.InvocationID)
+			}
+			toolStarts[pl.InvocationID] = base(nullable(pl.NodeID), pl.InvocationID, pl.ToolName, e.CreatedAt, nil, nil, nil, batchID)
+		case jobstore.ToolInvocationFinished:
+			var pl struct {
+				InvocationID string `json:"invocation_id"`
+				Outcome      string `json:"outcome"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			if r, ok := toolStarts[pl.InvocationID]; ok {
+				// 列位置：finished_at=7, duration_ms=8, outcome=9
+				r[7] = e.CreatedAt
+				r[8] = e.CreatedAt.Sub(r[6].(time.Time)).Milliseconds()
+				r[9] = nullable(pl.Outcome)
+			}
+		case jobstore.CommandCommitted:
+			// LLM 步的模型信息可能在顶层或嵌在 result 内
+			var pl struct {
+				NodeID string  `json:"node_id"`
+				Result llmInfo `json:"result"`
+				llmInfo
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			info := pl.llmInfo
+			if info.Model == "" {
+				info = pl.Result
+			}
+			if info.Model == "" {
+				continue
+			}
+			llmCalls++
+			out[TableLLMCalls] = append(out[TableLLMCalls], base(nullable(pl.NodeID), info.Model, nullable(info.Provider),
+				nullable(info.FailoverFrom), e.CreatedAt, batchID))
+		}
+	}
+	for _, id := range toolOrder {
+		out[TableToolCalls] = append(out[TableToolCalls], toolStarts[id])
+	}
+
+	o := evalsuite.OutcomeFromEvents(j.ID, j.Status.String(), events, costs)
+	finished := j.UpdatedAt
+	if t := j.Terminal; t != nil && !t.At.IsZero() {
+		finished = t.At
+	}
+	if o.DurationMs == 0 && finished.After(j.CreatedAt) {
+		o.DurationMs = finished.Sub(j.CreatedAt).Milliseconds()
+	}
+	var failureClass, failedNode, client any
+	if t := j.Terminal; t != nil {
+		failureClass, failedNode = nullable(t.FailureClass), nullable(t.FailedNodeID)
+	}
+	if a := j.Attribution; a != nil {
+		client = nullable(a.Client)
+	}
+	out[TableJobs] = []Row{base(j.Status.String(), nullable(j.Profile), client, j.CreatedAt, finished, o.DurationMs,
+		int64(j.RetryCount), steps, failedSteps, int64(len(out[TableToolCalls])), llmCalls, o.Cost, failureClass, failedNode, batchID)}
+	return out
+}
+
+type llmInfo struct {
+	Model        string `json:"llm_model"`
+	Provider     string `json:"llm_provider"`
+	FailoverFrom string `json:"llm_failover_from"`
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
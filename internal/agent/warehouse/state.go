@@ -0,0 +1,79 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrConflict 水位线已被其他导出进程推进（CAS 失败）；本批次对象键确定，重复写入内容一致，可安全忽略
+var ErrConflict = errors.New("warehouse: watermark moved concurrently")
+
+// State 一个导出流（前缀 + 表结构版本）的水位线与累计计数；水位线为已导出的最后一个 Job 的 (updated_at, job_id)
+type State struct {
+	Name        string    `json:"name"`
+	UpdatedAt   time.Time `json:"watermark_updated_at"`
+	JobID       string    `json:"watermark_job_id"`
+	Batches     int64     `json:"batches"`
+	Jobs        int64     `json:"jobs"`
+	LastBatchID string    `json:"last_batch_id,omitempty"`
+	ExportedAt  time.Time `json:"exported_at"`
+}
+
+// Store 水位线存储
+type Store interface {
+	// Get 返回导出流状态；从未导出时返回 (nil, nil)
+	Get(ctx context.Context, name string) (*State, error)
+	// Advance 仅当当前水位线等于 prev（prev 为 nil 表示尚无记录）时写入 next，否则返回 ErrConflict
+	Advance(ctx context.Context, prev, next *State) error
+}
+
+// StoreMem 内存实现（单进程、测试用）
+type StoreMem struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// NewStoreMem 创建内存水位线存储
+func NewStoreMem() *StoreMem {
+	return &StoreMem{states: make(map[string]State)}
+}
+
+func (s *StoreMem) Get(ctx context.Context, name string) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[name]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (s *StoreMem) Advance(ctx context.Context, prev, next *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.states[next.Name]
+	if prev == nil && ok {
+		return ErrConflict
+	}
+	if prev != nil && (!ok || !cur.UpdatedAt.Equal(prev.UpdatedAt) || cur.JobID != prev.JobID) {
+		return ErrConflict
+	}
+	s.states[next.Name] = *next
+	return nil
+}
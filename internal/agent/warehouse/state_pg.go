@@ -0,0 +1,68 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 warehouse_export_state 表；多个 API 副本共享水位线
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的水位线存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+func (s *StorePg) Get(ctx context.Context, name string) (*State, error) {
+	var st State
+	err := s.pool.QueryRow(ctx,
+		`SELECT name, watermark_updated_at, watermark_job_id, batches, jobs, last_batch_id, exported_at FROM warehouse_export_state WHERE name = $1`,
+		name).Scan(&st.Name, &st.UpdatedAt, &st.JobID, &st.Batches, &st.Jobs, &st.LastBatchID, &st.ExportedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *StorePg) Advance(ctx context.Context, prev, next *State) error {
+	var sql string
+	args := []interface{}{next.Name, next.UpdatedAt, next.JobID, next.Batches, next.Jobs, next.LastBatchID, next.ExportedAt}
+	if prev == nil {
+		sql = `INSERT INTO warehouse_export_state (name, watermark_updated_at, watermark_job_id, batches, jobs, last_batch_id, exported_at)
+		       VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (name) DO NOTHING`
+	} else {
+		sql = `UPDATE warehouse_export_state SET watermark_updated_at = $2, watermark_job_id = $3, batches = $4, jobs = $5, last_batch_id = $6, exported_at = $7
+		       WHERE name = $1 AND watermark_updated_at = $8 AND watermark_job_id = $9`
+		args = append(args, prev.UpdatedAt, prev.JobID)
+	}
+	tag, err := s.pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrConflict
+	}
+	return nil
+}
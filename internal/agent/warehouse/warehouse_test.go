@@ -0,0 +1,457 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+)
+
+func appendEvent(t *testing.T, store jobstore.JobStore, jobID string, typ jobstore.EventType, at time.Time, payload any) {
+	t.Helper()
+	ctx := context.Background()
+	_, ver, err := store.ListEvents(ctx, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(payload)
+	if _, err := store.Append(ctx, jobID, ver, jobstore.JobEvent{Type: typ, Payload: raw, CreatedAt: at}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	events := jobstore.NewMemoryStore()
+	appendEvent(t, events, "j1", jobstore.PlanGenerated, t0, map[string]any{"task_graph": map[string]any{"nodes": []map[string]any{{"id": "n1", "type": "llm"}, {"id": "n2", "type": "tool"}}}})
+	appendEvent(t, events, "j1", jobstore.NodeStarted, t0.Add(time.Second), map[string]any{"node_id": "n1", "attempt": 1})
+	appendEvent(t, events, "j1", jobstore.CommandCommitted, t0.Add(2*time.Second), map[string]any{"node_id": "n1", "result": map[string]any{"llm_model": "gpt-4o", "llm_provider": "openai", "llm_failover_from": "claude"}})
+	appendEvent(t, events, "j1", jobstore.NodeFinished, t0.Add(3*time.Second), map[string]any{"node_id": "n1", "attempt": 1})
+	appendEvent(t, events, "j1", jobstore.NodeStarted, t0.Add(4*time.Second), map[string]any{"node_id": "n2", "attempt": 2})
+	appendEvent(t, events, "j1", jobstore.ToolInvocationStarted, t0.Add(4*time.Second), map[string]any{"node_id": "n2", "invocation_id": "inv-1", "tool_name": "search"})
+	appendEvent(t, events, "j1", jobstore.ToolInvocationFinished, t0.Add(5500*time.Millisecond), map[string]any{"invocation_id": "inv-1", "outcome": "success"})
+	appendEvent(t, events, "j1", jobstore.NodeFinished, t0.Add(6*time.Second), map[string]any{"node_id": "n2", "attempt": 2, "duration_ms": 1900, "result_type": "permanent_failure"})
+	list, _, _ := events.ListEvents(context.Background(), "j1")
+
+	j := &job.Job{ID: "j1", TenantID: "t1", AgentID: "a1", Status: job.StatusFailed, CreatedAt: t0, UpdatedAt: t0.Add(7 * time.Second),
+		Terminal: &job.TerminalInfo{FailureClass: "tool_error", FailedNodeID: "n2"}}
+	rows := Extract(j, list, planner.CostModel{}, "b1")
+
+	if got := len(rows[TableSteps]); got != 2 {
+		t.Fatalf("steps = %d, want 2", got)
+	}
+	s1, s2 := rows[TableSteps][0], rows[TableSteps][1]
+	if s1[3] != "n1" || s1[4] != "llm" || s1[5] != int64(1) || s1[8] != int64(2000) || s1[9] != "success" {
+		t.Errorf("step n1 = %v", s1)
+	}
+	if s2[4] != "tool" || s2[5] != int64(2) || s2[8] != int64(1900) || s2[9] != "permanent_failure" {
+		t.Errorf("step n2 = %v", s2)
+	}
+	tc := rows[TableToolCalls]
+	if len(tc) != 1 || tc[0][5] != "search" || tc[0][8] != int64(1500) || tc[0][9] != "success" {
+		t.Errorf("tool_calls = %v", tc)
+	}
+	llm := rows[TableLLMCalls]
+	if len(llm) != 1 || llm[0][4] != "gpt-4o" || llm[0][5] != "openai" || llm[0][6] != "claude" {
+		t.Errorf("llm_calls = %v", llm)
+	}
+	jr := rows[TableJobs][0]
+	if len(jr) != len(Tables[0].Columns) {
+		t.Fatalf("job row has %d values, want %d", len(jr), len(Tables[0].Columns))
+	}
+	if jr[3] != "failed" || jr[5] != nil || jr[10] != int64(2) || jr[11] != int64(1) || jr[12] != int64(1) || jr[13] != int64(1) || jr[15] != "tool_error" || jr[16] != "n2" || jr[17] != "b1" {
+		t.Errorf("job row = %v", jr)
+	}
+	for _, tbl := range Tables {
+		for _, r := range rows[tbl.Name] {
+			if len(r) != len(tbl.Columns) {
+				t.Errorf("%s row has %d values, want %d", tbl.Name, len(r), len(tbl.Columns))
+			}
+		}
+	}
+}
+
+type fixture struct {
+	jobs    *job.JobStoreMem
+	events  jobstore.JobStore
+	objects *object.MemoryStore
+	state   *StoreMem
+}
+
+func newFixture() *fixture {
+	return &fixture{jobs: job.NewJobStoreMem(), events: jobstore.NewMemoryStore(), objects: object.NewMemoryStore(), state: NewStoreMem()}
+}
+
+func (f *fixture) finish(t *testing.T, tenant string, status job.JobStatus) string {
+	t.Helper()
+	ctx := context.Background()
+	id, err := f.jobs.Create(ctx, &job.Job{AgentID: "a1", TenantID: tenant})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.jobs.UpdateStatus(ctx, id, job.StatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.jobs.UpdateStatus(ctx, id, status); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond) // 保证 updated_at 递增
+	return id
+}
+
+func (f *fixture) exporter(opts Options) *Exporter {
+	e := NewExporter(f.jobs, f.events, f.objects, f.state, opts, nil)
+	e.now = func() time.Time { return time.Now().Add(time.Hour) }
+	return e
+}
+
+func (f *fixture) read(t *testing.T, key string) []byte {
+	t.Helper()
+	rc, err := f.objects.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("get %s: %v", key, err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	return data
+}
+
+func (f *fixture) exportedJobIDs(t *testing.T) map[string]int {
+	t.Helper()
+	objs, err := f.objects.List(context.Background(), "warehouse/v1/jobs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	for _, o := range objs {
+		recs, err := csv.NewReader(bytes.NewReader(f.read(t, o.Path))).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range recs[1:] {
+			ids[r[0]]++
+		}
+	}
+	return ids
+}
+
+func TestExporterWatermark(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	var want []string
+	for i := 0; i < 5; i++ {
+		want = append(want, f.finish(t, "t1", job.StatusCompleted))
+	}
+	e := f.exporter(Options{BatchSize: 2})
+
+	var batches []*Batch
+	for {
+		b, err := e.RunOnce(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b == nil {
+			break
+		}
+		batches = append(batches, b)
+	}
+	if len(batches) != 3 {
+		t.Fatalf("batches = %d, want 3", len(batches))
+	}
+	for i := 1; i < len(batches); i++ {
+		if batches[i].From != batches[i-1].To {
+			t.Errorf("batch %d starts at %v, previous ended at %v", i, batches[i].From, batches[i-1].To)
+		}
+	}
+	ids := f.exportedJobIDs(t)
+	for _, id := range want {
+		if ids[id] != 1 {
+			t.Errorf("job %s exported %d times", id, ids[id])
+		}
+	}
+	if ok, _ := f.objects.Exists(ctx, "warehouse/v1/_schema.json"); !ok {
+		t.Error("schema file missing")
+	}
+	for _, b := range batches {
+		var m Batch
+		if err := json.Unmarshal(f.read(t, "warehouse/v1/_batches/"+b.BatchID+".json"), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Rows[TableJobs] != b.ExportedJobs || len(m.Files) == 0 {
+			t.Errorf("manifest = %+v", m)
+		}
+	}
+	st, _ := f.state.Get(ctx, e.StreamName())
+	if st == nil || st.Jobs != 5 || st.Batches != 3 || st.JobID != batches[2].To.JobID {
+		t.Errorf("state = %+v", st)
+	}
+
+	// 新 Job 从水位线继续
+	late := f.finish(t, "t1", job.StatusFailed)
+	b, err := e.RunOnce(ctx)
+	if err != nil || b == nil || b.ExportedJobs != 1 || b.To.JobID != late {
+		t.Fatalf("incremental batch = %+v, %v", b, err)
+	}
+}
+
+func TestExporterSettleDelay(t *testing.T) {
+	f := newFixture()
+	f.finish(t, "t1", job.StatusCompleted)
+	e := f.exporter(Options{SettleDelay: time.Minute})
+	e.now = time.Now
+	b, err := e.RunOnce(context.Background())
+	if err != nil || b != nil {
+		t.Fatalf("job inside settle window exported: %+v, %v", b, err)
+	}
+}
+
+func TestExporterTenantFilterAndSampling(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	keep := f.finish(t, "t1", job.StatusCompleted)
+	drop := f.finish(t, "t2", job.StatusCompleted)
+	excluded := f.finish(t, "t3", job.StatusCompleted)
+	e := f.exporter(Options{Tenants: []string{"t1", "t3"}, ExcludeTenants: []string{"t3"}})
+	b, err := e.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ScannedJobs != 3 || b.ExportedJobs != 1 {
+		t.Fatalf("batch = %+v", b)
+	}
+	ids := f.exportedJobIDs(t)
+	if ids[keep] != 1 || ids[drop] != 0 || ids[excluded] != 0 {
+		t.Errorf("exported = %v", ids)
+	}
+
+	// 采样按 job_id 确定：同一 Job 多次判定结果一致，比例大致符合
+	s := f.exporter(Options{SampleRate: 0.5})
+	n := 0
+	for i := 0; i < 1000; i++ {
+		j := &job.Job{ID: fmt.Sprintf("job-%d", i)}
+		in := s.include(j)
+		if in != s.include(j) {
+			t.Fatal("sampling not deterministic")
+		}
+		if in {
+			n++
+		}
+	}
+	if n < 400 || n > 600 {
+		t.Errorf("sampled %d of 1000 at rate 0.5", n)
+	}
+}
+
+func TestExporterConflictIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	f.finish(t, "t1", job.StatusCompleted)
+	e := f.exporter(Options{})
+	prev, _ := f.state.Get(ctx, e.StreamName())
+
+	b1, err := e.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 另一副本基于同一旧水位线导出：对象键相同，CAS 失败
+	st := f.state
+	f.state = NewStoreMem()
+	e2 := f.exporter(Options{})
+	b2, err := e2.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b1.BatchID != b2.BatchID || strings.Join(b1.Files, ",") != strings.Join(b2.Files, ",") {
+		t.Errorf("same range produced different batches: %v vs %v", b1.Files, b2.Files)
+	}
+	if err := st.Advance(ctx, prev, &State{Name: e.StreamName()}); err != ErrConflict {
+		t.Errorf("stale advance err = %v, want ErrConflict", err)
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	tbl := Table{Name: "x", Columns: []Column{{Name: "s", Type: TypeString}, {Name: "n", Type: TypeInt64}, {Name: "f", Type: TypeFloat64}, {Name: "ts", Type: TypeTimestamp}}}
+	ts := time.Date(2026, 3, 1, 10, 0, 0, 5e6, time.FixedZone("X", 3600))
+	data, err := Encode(FormatCSV, tbl, []Row{{"a,b", int64(3), 0.25, ts}, {nil, nil, nil, nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "s,n,f,ts\n\"a,b\",3,0.25,2026-03-01T09:00:00.005Z\n,,,\n"
+	if string(data) != want {
+		t.Errorf("csv = %q, want %q", data, want)
+	}
+	if _, err := Encode("orc", tbl, nil); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+// thriftReader 测试侧通用 thrift compact 解码，struct 解为 map[字段号]值
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case 3:
+		r.pos++
+		return int64(int8(r.b[r.pos-1]))
+	case 4, 5, 6:
+		u := r.uvarint()
+		return int64(u>>1) ^ -int64(u&1)
+	case 8:
+		n := int(r.uvarint())
+		r.pos += n
+		return string(r.b[r.pos-n : r.pos])
+	case 9:
+		h := r.b[r.pos]
+		r.pos++
+		n, et := int(h>>4), h&0x0f
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(et)
+		}
+		return list
+	case 12:
+		return r.structure()
+	}
+	panic(fmt.Sprintf("unsupported thrift type %d", typ))
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	out := map[int16]any{}
+	var last int16
+	for {
+		h := r.b[r.pos]
+		r.pos++
+		if h == 0 {
+			return out
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			u := r.uvarint()
+			id = int16(int64(u>>1) ^ -int64(u&1))
+		}
+		last = id
+		out[id] = r.value(h & 0x0f)
+	}
+}
+
+func TestEncodeParquetRoundTrip(t *testing.T) {
+	tbl := Table{Name: "x", Columns: []Column{{Name: "s", Type: TypeString}, {Name: "n", Type: TypeInt64}, {Name: "f", Type: TypeFloat64}, {Name: "ts", Type: TypeTimestamp}}}
+	ts := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	rows := []Row{{"héllo", int64(-7), 1.5, ts}, {nil, int64(42), nil, nil}, {"", nil, 2.0, ts}}
+	data, err := Encode(FormatParquet, tbl, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("missing magic")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := (&thriftReader{b: data[len(data)-8-metaLen : len(data)-8]}).structure()
+	if meta[3] != int64(3) {
+		t.Fatalf("num_rows = %v", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != 5 || schema[0].(map[int16]any)[5] != int64(4) || schema[1].(map[int16]any)[4] != "s" {
+		t.Fatalf("schema = %v", schema)
+	}
+	chunks := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+
+	decoded := make([][]any, len(tbl.Columns))
+	for ci, c := range chunks {
+		md := c.(map[int16]any)[3].(map[int16]any)
+		off := int(md[9].(int64))
+		r := &thriftReader{b: data, pos: off}
+		hdr := r.structure()
+		page := data[r.pos : r.pos+int(hdr[3].(int64))]
+		if int64(r.pos-off+len(page)) != md[6].(int64) {
+			t.Errorf("column %d chunk size mismatch", ci)
+		}
+		levLen := int(binary.LittleEndian.Uint32(page))
+		lr := &thriftReader{b: page[4 : 4+levLen]}
+		var levels []byte
+		for lr.pos < len(lr.b) {
+			run := int(lr.uvarint() >> 1)
+			v := lr.b[lr.pos]
+			lr.pos++
+			for k := 0; k < run; k++ {
+				levels = append(levels, v)
+			}
+		}
+		vals := page[4+levLen:]
+		for _, l := range levels {
+			if l == 0 {
+				decoded[ci] = append(decoded[ci], nil)
+				continue
+			}
+			switch tbl.Columns[ci].Type {
+			case TypeString:
+				n := int(binary.LittleEndian.Uint32(vals))
+				decoded[ci] = append(decoded[ci], string(vals[4:4+n]))
+				vals = vals[4+n:]
+			case TypeFloat64:
+				decoded[ci] = append(decoded[ci], math.Float64frombits(binary.LittleEndian.Uint64(vals)))
+				vals = vals[8:]
+			default:
+				decoded[ci] = append(decoded[ci], int64(binary.LittleEndian.Uint64(vals)))
+				vals = vals[8:]
+			}
+		}
+		if len(vals) != 0 {
+			t.Errorf("column %d has %d trailing bytes", ci, len(vals))
+		}
+	}
+	for ri, row := range rows {
+		for ci, v := range row {
+			got := decoded[ci][ri]
+			switch x := v.(type) {
+			case time.Time:
+				v = x.UnixMilli()
+			}
+			if got != v {
+				t.Errorf("row %d col %d = %v, want %v", ri, ci, got, v)
+			}
+		}
+	}
+}
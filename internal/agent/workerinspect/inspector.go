@@ -0,0 +1,324 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workerinspect Worker 内部状态自省：当前认领、执行中的 step、租约剩余时间、最近错误与限流器占用，
+// 供值班人员通过 `aetheris worker inspect` 排查异常 Worker 而无需挂调试器
+package workerinspect
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/scheduler"
+)
+
+// maxRecentErrors 最近错误环形缓冲容量
+const maxRecentErrors = 20
+
+// StepProgress 执行中的 step（node_started 已写入、node_finished 尚未写入）
+type StepProgress struct {
+	NodeID    string    `json:"node_id"`
+	Attempt   int       `json:"attempt,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+}
+
+// Claim 本 Worker 当前持有的 Job 认领；租约到期时间按最近一次成功心跳（无则认领时间）+ 租约时长估算
+type Claim struct {
+	JobID             string         `json:"job_id"`
+	AgentID           string         `json:"agent_id,omitempty"`
+	TenantID          string         `json:"tenant_id,omitempty"`
+	AttemptID         string         `json:"attempt_id,omitempty"`
+	ClaimedAt         time.Time      `json:"claimed_at"`
+	ElapsedMs         int64          `json:"elapsed_ms"`
+	LastHeartbeatAt   time.Time      `json:"last_heartbeat_at,omitempty"`
+	HeartbeatFailures int            `json:"heartbeat_failures,omitempty"`
+	LeaseExpiresAt    time.Time      `json:"lease_expires_at"`
+	LeaseRemainingMs  int64          `json:"lease_remaining_ms"`
+	Steps             []StepProgress `json:"steps,omitempty"`
+}
+
+// ErrorEntry 最近错误
+type ErrorEntry struct {
+	At    time.Time `json:"at"`
+	JobID string    `json:"job_id,omitempty"`
+	Op    string    `json:"op"`
+	Error string    `json:"error"`
+}
+
+// LimiterUsage 限流器占用；Saturation 为并发占用与每分钟 token 用量中较高的比例（0-1，未配置上限时为 0）
+type LimiterUsage struct {
+	Kind             string  `json:"kind"`
+	Key              string  `json:"key"`
+	InFlight         int     `json:"in_flight"`
+	MaxConcurrent    int     `json:"max_concurrent,omitempty"`
+	TokensUsedMinute int     `json:"tokens_used_minute,omitempty"`
+	TokensPerMinute  int     `json:"tokens_per_minute,omitempty"`
+	Saturation       float64 `json:"saturation"`
+}
+
+// Snapshot Worker 自省快照
+type Snapshot struct {
+	WorkerID        string                   `json:"worker_id"`
+	GeneratedAt     time.Time                `json:"generated_at"`
+	StartedAt       time.Time                `json:"started_at"`
+	RunningJobs     int                      `json:"running_jobs"`
+	MaxConcurrency  int                      `json:"max_concurrency"`
+	LeaseDurationMs int64                    `json:"lease_duration_ms"`
+	Claims          []Claim                  `json:"claims"`
+	Limiters        []LimiterUsage           `json:"limiters,omitempty"`
+	Throttle        *scheduler.ThrottleState `json:"throttle,omitempty"`
+	RecentErrors    []ErrorEntry             `json:"recent_errors"`
+}
+
+// StatsSource 限流器统计来源（llm.LLMRateLimiter、executor.ToolRateLimiter）
+type StatsSource interface {
+	Keys() []string
+	GetStats(key string) map[string]interface{}
+}
+
+type limiterSource struct {
+	kind string
+	src  StatsSource
+}
+
+type claimState struct {
+	Claim
+	steps map[string]StepProgress
+}
+
+// Inspector 汇总 Worker 内部状态；由 AgentJobRunner 在认领/心跳/释放时回调，由 ObservingStore 观察 step 起止
+type Inspector struct {
+	workerID       string
+	leaseDuration  time.Duration
+	maxConcurrency int
+	startedAt      time.Time
+	now            func() time.Time
+
+	mu       sync.Mutex
+	claims   map[string]*claimState
+	errors   []ErrorEntry
+	throttle *scheduler.ThrottleState
+	limiters []limiterSource
+}
+
+// NewInspector 创建 Worker 自省器
+func NewInspector(workerID string, leaseDuration time.Duration) *Inspector {
+	return &Inspector{
+		workerID:      workerID,
+		leaseDuration: leaseDuration,
+		startedAt:     time.Now(),
+		now:           time.Now,
+		claims:        make(map[string]*claimState),
+	}
+}
+
+// SetMaxConcurrency 设置 Job 并发上限（展示用）
+func (in *Inspector) SetMaxConcurrency(n int) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	in.maxConcurrency = n
+	in.mu.Unlock()
+}
+
+// AddLimiter 登记限流器；kind 如 llm、tool；src 为 nil 时忽略
+func (in *Inspector) AddLimiter(kind string, src StatsSource) {
+	if in == nil || src == nil {
+		return
+	}
+	in.mu.Lock()
+	in.limiters = append(in.limiters, limiterSource{kind: kind, src: src})
+	in.mu.Unlock()
+}
+
+// Claimed 记录认领成功并开始执行的 Job
+func (in *Inspector) Claimed(jobID, agentID, tenantID, attemptID string) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	in.claims[jobID] = &claimState{
+		Claim: Claim{JobID: jobID, AgentID: agentID, TenantID: tenantID, AttemptID: attemptID, ClaimedAt: in.now()},
+		steps: make(map[string]StepProgress),
+	}
+	in.mu.Unlock()
+}
+
+// Heartbeat 记录租约心跳结果；failed时计入最近错误
+func (in *Inspector) Heartbeat(jobID string, err error) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	c, ok := in.claims[jobID]
+	if err != nil {
+		if ok {
+			c.HeartbeatFailures++
+		}
+		in.recordLocked(jobID, "heartbeat", err)
+		return
+	}
+	if ok {
+		c.LastHeartbeatAt = in.now()
+	}
+}
+
+// Released 记录 Job 执行结束并释放认领；err 非 nil 时计入最近错误
+func (in *Inspector) Released(jobID string, err error) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	delete(in.claims, jobID)
+	if err != nil {
+		in.recordLocked(jobID, "run", err)
+	}
+	in.mu.Unlock()
+}
+
+// RecordError 记录一条错误（如认领failed）；jobID 可为空
+func (in *Inspector) RecordError(jobID, op string, err error) {
+	if in == nil || err == nil {
+		return
+	}
+	in.mu.Lock()
+	in.recordLocked(jobID, op, err)
+	in.mu.Unlock()
+}
+
+func (in *Inspector) recordLocked(jobID, op string, err error) {
+	in.errors = append(in.errors, ErrorEntry{At: in.now(), JobID: jobID, Op: op, Error: err.Error()})
+	if n := len(in.errors); n > maxRecentErrors {
+		in.errors = append([]ErrorEntry(nil), in.errors[n-maxRecentErrors:]...)
+	}
+}
+
+// ObserveThrottle 记录最近一次资源感知节流评估
+func (in *Inspector) ObserveThrottle(st scheduler.ThrottleState) {
+	if in == nil {
+		return
+	}
+	st.Reasons = append([]string(nil), st.Reasons...)
+	in.mu.Lock()
+	in.throttle = &st
+	in.mu.Unlock()
+}
+
+func (in *Inspector) stepStarted(jobID, nodeID string, attempt int) {
+	in.mu.Lock()
+	if c, ok := in.claims[jobID]; ok {
+		c.steps[nodeID] = StepProgress{NodeID: nodeID, Attempt: attempt, StartedAt: in.now()}
+	}
+	in.mu.Unlock()
+}
+
+func (in *Inspector) stepFinished(jobID, nodeID string) {
+	in.mu.Lock()
+	if c, ok := in.claims[jobID]; ok {
+		delete(c.steps, nodeID)
+	}
+	in.mu.Unlock()
+}
+
+// Snapshot 返回当前状态快照；认领按认领时间、最近错误按时间倒序
+func (in *Inspector) Snapshot() Snapshot {
+	in.mu.Lock()
+	now := in.now()
+	snap := Snapshot{
+		WorkerID:        in.workerID,
+		GeneratedAt:     now,
+		StartedAt:       in.startedAt,
+		RunningJobs:     len(in.claims),
+		MaxConcurrency:  in.maxConcurrency,
+		LeaseDurationMs: in.leaseDuration.Milliseconds(),
+		Claims:          make([]Claim, 0, len(in.claims)),
+		RecentErrors:    make([]ErrorEntry, 0, len(in.errors)),
+	}
+	for _, c := range in.claims {
+		cl := c.Claim
+		cl.ElapsedMs = now.Sub(cl.ClaimedAt).Milliseconds()
+		renewed := cl.ClaimedAt
+		if cl.LastHeartbeatAt.After(renewed) {
+			renewed = cl.LastHeartbeatAt
+		}
+		cl.LeaseExpiresAt = renewed.Add(in.leaseDuration)
+		cl.LeaseRemainingMs = cl.LeaseExpiresAt.Sub(now).Milliseconds()
+		for _, s := range c.steps {
+			s.ElapsedMs = now.Sub(s.StartedAt).Milliseconds()
+			cl.Steps = append(cl.Steps, s)
+		}
+		sort.Slice(cl.Steps, func(i, j int) bool { return cl.Steps[i].StartedAt.Before(cl.Steps[j].StartedAt) })
+		snap.Claims = append(snap.Claims, cl)
+	}
+	sort.Slice(snap.Claims, func(i, j int) bool { return snap.Claims[i].ClaimedAt.Before(snap.Claims[j].ClaimedAt) })
+	for i := len(in.errors) - 1; i >= 0; i-- {
+		snap.RecentErrors = append(snap.RecentErrors, in.errors[i])
+	}
+	if in.throttle != nil {
+		st := *in.throttle
+		snap.Throttle = &st
+	}
+	limiters := append([]limiterSource(nil), in.limiters...)
+	in.mu.Unlock()
+
+	for _, l := range limiters {
+		keys := l.src.Keys()
+		sort.Strings(keys)
+		for _, k := range keys {
+			if u, ok := usageOf(l.kind, k, l.src.GetStats(k)); ok {
+				snap.Limiters = append(snap.Limiters, u)
+			}
+		}
+	}
+	return snap
+}
+
+func usageOf(kind, key string, stats map[string]interface{}) (LimiterUsage, bool) {
+	if stats == nil {
+		return LimiterUsage{}, false
+	}
+	u := LimiterUsage{
+		Kind:             kind,
+		Key:              key,
+		InFlight:         intStat(stats, "current_concurrent"),
+		MaxConcurrent:    intStat(stats, "max_concurrent"),
+		TokensUsedMinute: intStat(stats, "tokens_used_minute"),
+		TokensPerMinute:  intStat(stats, "tokens_per_minute"),
+	}
+	if u.MaxConcurrent > 0 {
+		u.Saturation = float64(u.InFlight) / float64(u.MaxConcurrent)
+	}
+	if u.TokensPerMinute > 0 {
+		if s := float64(u.TokensUsedMinute) / float64(u.TokensPerMinute); s > u.Saturation {
+			u.Saturation = s
+		}
+	}
+	return u, true
+}
+
+func intStat(stats map[string]interface{}, key string) int {
+	switch v := stats[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workerinspect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+type fakeLimiter struct {
+	stats map[string]map[string]interface{}
+}
+
+func (f fakeLimiter) Keys() []string {
+	keys := make([]string, 0, len(f.stats))
+	for k := range f.stats {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (f fakeLimiter) GetStats(key string) map[string]interface{} { return f.stats[key] }
+
+func TestInspector_ClaimsStepsAndLease(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	in := NewInspector("w1", 30*time.Second)
+	in.now = func() time.Time { return now }
+	in.SetMaxConcurrency(4)
+
+	in.Claimed("job-1", "agent-1", "acme", "att-1")
+	store := WrapStore(jobstore.NewMemoryStore(), in)
+	ctx := context.Background()
+	started, _ := json.Marshal(map[string]interface{}{"node_id": "n1", "attempt": 2})
+	ver, err := store.Append(ctx, "job-1", 0, jobstore.JobEvent{JobID: "job-1", Type: jobstore.NodeStarted, Payload: started})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(10 * time.Second)
+	in.Heartbeat("job-1", nil)
+	now = now.Add(5 * time.Second)
+	in.Heartbeat("job-1", errors.New("lease lost"))
+
+	snap := in.Snapshot()
+	if snap.RunningJobs != 1 || snap.MaxConcurrency != 4 || len(snap.Claims) != 1 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	c := snap.Claims[0]
+	if c.ElapsedMs != 15000 || c.HeartbeatFailures != 1 {
+		t.Fatalf("claim = %+v", c)
+	}
+	// 租约从最近一次成功心跳起算：10s + 30s - 15s
+	if c.LeaseRemainingMs != 25000 {
+		t.Fatalf("lease remaining = %d", c.LeaseRemainingMs)
+	}
+	if len(c.Steps) != 1 || c.Steps[0].NodeID != "n1" || c.Steps[0].Attempt != 2 || c.Steps[0].ElapsedMs != 15000 {
+		t.Fatalf("steps = %+v", c.Steps)
+	}
+	if len(snap.RecentErrors) != 1 || snap.RecentErrors[0].Op != "heartbeat" {
+		t.Fatalf("errors = %+v", snap.RecentErrors)
+	}
+
+	finished, _ := json.Marshal(map[string]interface{}{"node_id": "n1"})
+	if _, err := store.Append(ctx, "job-1", ver, jobstore.JobEvent{JobID: "job-1", Type: jobstore.NodeFinished, Payload: finished}); err != nil {
+		t.Fatal(err)
+	}
+	if steps := in.Snapshot().Claims[0].Steps; len(steps) != 0 {
+		t.Fatalf("step should be cleared after node_finished: %+v", steps)
+	}
+
+	in.Released("job-1", errors.New("boom"))
+	snap = in.Snapshot()
+	if len(snap.Claims) != 0 || snap.RecentErrors[0].Op != "run" || snap.RecentErrors[0].JobID != "job-1" {
+		t.Fatalf("after release = %+v", snap)
+	}
+}
+
+func TestInspector_RecentErrorsBoundedAndLimiters(t *testing.T) {
+	in := NewInspector("w1", time.Minute)
+	for i := 0; i < maxRecentErrors+5; i++ {
+		in.RecordError("", "claim", fmt.Errorf("err-%d", i))
+	}
+	in.AddLimiter("llm", fakeLimiter{stats: map[string]map[string]interface{}{
+		"openai": {"current_concurrent": 1, "max_concurrent": 4, "tokens_used_minute": 900, "tokens_per_minute": 1000},
+	}})
+	in.AddLimiter("tool", fakeLimiter{stats: map[string]map[string]interface{}{
+		"search": {"current_concurrent": 2, "max_concurrent": 2},
+	}})
+	snap := in.Snapshot()
+	if len(snap.RecentErrors) != maxRecentErrors || snap.RecentErrors[0].Error != fmt.Sprintf("err-%d", maxRecentErrors+4) {
+		t.Fatalf("recent errors = %d, newest = %+v", len(snap.RecentErrors), snap.RecentErrors[0])
+	}
+	if len(snap.Limiters) != 2 {
+		t.Fatalf("limiters = %+v", snap.Limiters)
+	}
+	if l := snap.Limiters[0]; l.Kind != "llm" || l.Saturation != 0.9 {
+		t.Fatalf("llm limiter = %+v", l)
+	}
+	if l := snap.Limiters[1]; l.Kind != "tool" || l.Saturation != 1 {
+		t.Fatalf("tool limiter = %+v", l)
+	}
+}
+
+func TestHandler_Token(t *testing.T) {
+	in := NewInspector("w1", time.Minute)
+	srv := httptest.NewServer(Handler(in, "secret"))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("without token status = %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var snap Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || snap.WorkerID != "w1" {
+		t.Fatalf("status = %d snapshot = %+v", resp.StatusCode, snap)
+	}
+}
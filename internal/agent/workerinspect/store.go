@@ -0,0 +1,59 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workerinspect
+
+import (
+	"context"
+	"encoding/json"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// ObservingStore JobStore 装饰器：观察 node_started / node_finished 的 Append，维护 Inspector 中执行中的 step
+type ObservingStore struct {
+	jobstore.JobStore
+	in *Inspector
+}
+
+// WrapStore 包装 inner；in 为 nil 时原样返回 inner
+func WrapStore(inner jobstore.JobStore, in *Inspector) jobstore.JobStore {
+	if in == nil || inner == nil {
+		return inner
+	}
+	return &ObservingStore{JobStore: inner, in: in}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *ObservingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+func (s *ObservingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	ver, err := s.JobStore.Append(ctx, jobID, expectedVersion, event)
+	if err != nil || (event.Type != jobstore.NodeStarted && event.Type != jobstore.NodeFinished) {
+		return ver, err
+	}
+	var pl struct {
+		NodeID  string `json:"node_id"`
+		Attempt int    `json:"attempt"`
+	}
+	if json.Unmarshal(event.Payload, &pl) != nil || pl.NodeID == "" {
+		return ver, err
+	}
+	if event.Type == jobstore.NodeStarted {
+		s.in.stepStarted(jobID, pl.NodeID, pl.Attempt)
+	} else {
+		s.in.stepFinished(jobID, pl.NodeID)
+	}
+	return ver, err
+}
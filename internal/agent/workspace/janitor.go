@@ -0,0 +1,101 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/pkg/log"
+)
+
+// DefaultCleanupInterval Janitor 扫描间隔
+const DefaultCleanupInterval = 10 * time.Minute
+
+// Janitor 周期清理终态 Job 的工作区：终态后超过保留期（以 Job 最后更新时间计）即删除；
+// Job 记录已不存在的孤儿工作区在最近一次写入超过保留期后删除
+type Janitor struct {
+	m      *Manager
+	jobs   job.JobStore
+	logger *log.Logger
+	now    func() time.Time
+}
+
+// NewJanitor 创建清理器
+func NewJanitor(m *Manager, jobs job.JobStore, logger *log.Logger) *Janitor {
+	return &Janitor{m: m, jobs: jobs, logger: logger, now: time.Now}
+}
+
+// Run 按 interval 周期清理直到 ctx 取消；启动时立即执行一轮
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCleanupInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := j.RunOnce(ctx); err != nil && ctx.Err() == nil && j.logger != nil {
+			j.logger.Warn("工作区清理failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一轮清理，返回删除的工作区数
+func (j *Janitor) RunOnce(ctx context.Context) (int, error) {
+	workspaces, err := j.m.JobIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list workspaces: %w", err)
+	}
+	retention := j.m.Retention()
+	if retention < 0 {
+		retention = 0
+	}
+	now := j.now()
+	removed := 0
+	for jobID, lastWrite := range workspaces {
+		jb, err := j.jobs.Get(ctx, jobID)
+		if err != nil {
+			if j.logger != nil {
+				j.logger.Warn("读取 Job failed，跳过工作区清理", "job_id", jobID, "error", err)
+			}
+			continue
+		}
+		var expired bool
+		switch {
+		case jb == nil:
+			expired = now.Sub(lastWrite) >= retention
+		case jb.Status.IsTerminal():
+			expired = now.Sub(jb.UpdatedAt) >= retention
+		}
+		if !expired {
+			continue
+		}
+		if err := j.m.Remove(ctx, jobID); err != nil {
+			if j.logger != nil {
+				j.logger.Warn("删除工作区failed", "job_id", jobID, "error", err)
+			}
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/agent/sdk"
+)
+
+// Entry 存储中的单个文件；Key 为 <job_id>/<path>
+type Entry struct {
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// Store 工作区底层存储：本地磁盘或对象存储
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Open 读取文件；不存在时返回 sdk.ErrWorkspaceFileNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List 列出 key 以 prefix 开头的文件
+	List(ctx context.Context, prefix string) ([]Entry, error)
+}
+
+// LocalStore 本地磁盘存储：文件位于 <root>/<job_id>/<path>。API 与 Worker 分机部署时需共享该目录
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore 创建本地存储；root 不存在时创建
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "aetheris-workspaces")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("workspace: create root %s: %w", root, err)
+	}
+	return &LocalStore{root: root}, nil
+}
+
+func (s *LocalStore) path(key string) string { return filepath.Join(s.root, filepath.FromSlash(key)) }
+
+// Put 实现 Store；先写临时文件再重命名，读者不会看到半写入的文件
+func (s *LocalStore) Put(_ context.Context, key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Open 实现 Store
+func (s *LocalStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, sdk.ErrWorkspaceFileNotFound
+	}
+	return f, err
+}
+
+// Delete 实现 Store；同时删除因此变空的目录
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	p := s.path(key)
+	if err := os.Remove(p); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return sdk.ErrWorkspaceFileNotFound
+		}
+		return err
+	}
+	for dir := filepath.Dir(p); dir != s.root && strings.HasPrefix(dir, s.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// List 实现 Store
+func (s *LocalStore) List(_ context.Context, prefix string) ([]Entry, error) {
+	// 仅遍历 prefix 所在目录
+	start := s.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		start = s.path(prefix[:i])
+	}
+	var out []Entry
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		out = append(out, Entry{Key: key, Size: info.Size(), ModifiedAt: info.ModTime()})
+		return nil
+	})
+	return out, err
+}
+
+// ObjectStore 对象存储（S3 等）：对象键为 <prefix><job_id>/<path>
+type ObjectStore struct {
+	store  object.Store
+	prefix string
+}
+
+// NewObjectStore 基于 object.Store 创建工作区存储；prefix 为空时用 workspaces/
+func NewObjectStore(store object.Store, prefix string) *ObjectStore {
+	return &ObjectStore{store: store, prefix: object.KeyPrefix(prefix, "workspaces/")}
+}
+
+// Put 实现 Store
+func (s *ObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	return s.store.Put(ctx, s.prefix+key, bytes.NewReader(data), int64(len(data)), nil)
+}
+
+// Open 实现 Store
+func (s *ObjectStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	ok, err := s.store.Exists(ctx, s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sdk.ErrWorkspaceFileNotFound
+	}
+	return s.store.Get(ctx, s.prefix+key)
+}
+
+// Delete 实现 Store
+func (s *ObjectStore) Delete(ctx context.Context, key string) error {
+	ok, err := s.store.Exists(ctx, s.prefix+key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return sdk.ErrWorkspaceFileNotFound
+	}
+	return s.store.Delete(ctx, s.prefix+key)
+}
+
+// List 实现 Store
+func (s *ObjectStore) List(ctx context.Context, prefix string) ([]Entry, error) {
+	infos, err := s.store.List(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Entry, 0, len(infos))
+	for _, info := range infos {
+		key := strings.TrimPrefix(info.Path, s.prefix)
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		out = append(out, Entry{Key: key, Size: info.Size, ModifiedAt: time.Unix(info.CreatedAt, 0)})
+	}
+	return out, nil
+}
@@ -0,0 +1,280 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workspace 每个 Job 的隔离文件工作区：工具经 sdk.WorkspaceFromContext 读写文件，
+// 同一 Job 的多个步骤借此传递文件；写入受配额限制，Job 终态后由 Janitor 按保留期清理。
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/agent/sdk"
+)
+
+// 默认配置
+const (
+	DefaultQuota     = 100 * 1024 * 1024
+	DefaultRetention = 24 * time.Hour
+)
+
+// Options 工作区配置
+type Options struct {
+	Backend   string        // local | s3，仅用于展示
+	Quota     int64         // 单个 Job 的总字节数上限；<0 不限，0 用默认
+	Retention time.Duration // Job 终态后保留时长；<0 立即清理，0 用默认
+}
+
+// Manager 按 Job 创建工作区并负责清理
+type Manager struct {
+	store Store
+	opts  Options
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewManager 创建工作区管理器
+func NewManager(store Store, opts Options) *Manager {
+	if opts.Quota == 0 {
+		opts.Quota = DefaultQuota
+	}
+	if opts.Retention == 0 {
+		opts.Retention = DefaultRetention
+	}
+	return &Manager{store: store, opts: opts, locks: make(map[string]*sync.Mutex)}
+}
+
+// Backend 存储类型
+func (m *Manager) Backend() string { return m.opts.Backend }
+
+// Retention Job 终态后的保留时长（<0 表示立即清理）
+func (m *Manager) Retention() time.Duration { return m.opts.Retention }
+
+// For 返回 Job 的工作区（惰性：首次写入时才在存储中出现）
+func (m *Manager) For(jobID string) *Workspace {
+	return &Workspace{m: m, jobID: jobID}
+}
+
+// jobLock 同一 Job 的写入串行化，保证配额检查与写入原子（单进程内；同一 Job 同时只由一个 Worker 执行）
+func (m *Manager) jobLock(jobID string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.locks[jobID]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[jobID] = l
+	}
+	return l
+}
+
+// JobIDs 列出存在工作区文件的 Job 及其最近修改时间
+func (m *Manager) JobIDs(ctx context.Context) (map[string]time.Time, error) {
+	entries, err := m.store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]time.Time)
+	for _, e := range entries {
+		jobID, _, ok := strings.Cut(e.Key, "/")
+		if !ok {
+			continue
+		}
+		if e.ModifiedAt.After(out[jobID]) {
+			out[jobID] = e.ModifiedAt
+		}
+	}
+	return out, nil
+}
+
+// Remove 删除 Job 的全部工作区文件
+func (m *Manager) Remove(ctx context.Context, jobID string) error {
+	if err := validJobID(jobID); err != nil {
+		return err
+	}
+	l := m.jobLock(jobID)
+	l.Lock()
+	defer l.Unlock()
+	entries, err := m.store.List(ctx, jobID+"/")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := m.store.Delete(ctx, e.Key); err != nil && !errors.Is(err, sdk.ErrWorkspaceFileNotFound) {
+			return err
+		}
+	}
+	m.mu.Lock()
+	delete(m.locks, jobID)
+	m.mu.Unlock()
+	return nil
+}
+
+func validJobID(jobID string) error {
+	if jobID == "" || strings.ContainsAny(jobID, `/\`) || jobID == "." || jobID == ".." {
+		return fmt.Errorf("%w: job id %q", sdk.ErrWorkspaceInvalidPath, jobID)
+	}
+	return nil
+}
+
+// CleanPath 规范化工作区内的相对路径；空、绝对路径或跳出工作区时返回 sdk.ErrWorkspaceInvalidPath
+func CleanPath(p string) (string, error) {
+	rel := path.Clean(strings.ReplaceAll(strings.TrimSpace(p), "\\", "/"))
+	if p == "" || rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") || strings.HasPrefix(path.Base(rel), ".tmp-") {
+		return "", fmt.Errorf("%w: %q", sdk.ErrWorkspaceInvalidPath, p)
+	}
+	return rel, nil
+}
+
+// Workspace 单个 Job 的工作区，实现 sdk.Workspace
+type Workspace struct {
+	m     *Manager
+	jobID string
+}
+
+var _ sdk.Workspace = (*Workspace)(nil)
+
+// JobID 实现 sdk.Workspace
+func (w *Workspace) JobID() string { return w.jobID }
+
+func (w *Workspace) key(p string) (string, string, error) {
+	if err := validJobID(w.jobID); err != nil {
+		return "", "", err
+	}
+	rel, err := CleanPath(p)
+	if err != nil {
+		return "", "", err
+	}
+	return rel, w.jobID + "/" + rel, nil
+}
+
+// WriteFile 实现 sdk.Workspace
+func (w *Workspace) WriteFile(ctx context.Context, p string, data []byte) (sdk.WorkspaceFile, error) {
+	return w.Write(ctx, p, bytes.NewReader(data))
+}
+
+// Write 实现 sdk.Workspace；读取量超过剩余配额时返回 sdk.ErrWorkspaceQuotaExceeded 且不写入
+func (w *Workspace) Write(ctx context.Context, p string, r io.Reader) (sdk.WorkspaceFile, error) {
+	rel, key, err := w.key(p)
+	if err != nil {
+		return sdk.WorkspaceFile{}, err
+	}
+	l := w.m.jobLock(w.jobID)
+	l.Lock()
+	defer l.Unlock()
+	entries, err := w.m.store.List(ctx, w.jobID+"/")
+	if err != nil {
+		return sdk.WorkspaceFile{}, err
+	}
+	var used int64
+	for _, e := range entries {
+		if e.Key != key {
+			used += e.Size
+		}
+	}
+	var data []byte
+	if quota := w.m.opts.Quota; quota > 0 {
+		room := quota - used
+		if room < 0 {
+			room = 0
+		}
+		data, err = io.ReadAll(io.LimitReader(r, room+1))
+		if err == nil && int64(len(data)) > room {
+			return sdk.WorkspaceFile{}, fmt.Errorf("%w: %s would exceed %d bytes", sdk.ErrWorkspaceQuotaExceeded, rel, quota)
+		}
+	} else {
+		data, err = io.ReadAll(r)
+	}
+	if err != nil {
+		return sdk.WorkspaceFile{}, err
+	}
+	if err := w.m.store.Put(ctx, key, data); err != nil {
+		return sdk.WorkspaceFile{}, err
+	}
+	return sdk.WorkspaceFile{Path: rel, Size: int64(len(data)), ModifiedAt: time.Now().UTC(), URI: sdk.WorkspaceURI(w.jobID, rel)}, nil
+}
+
+// ReadFile 实现 sdk.Workspace
+func (w *Workspace) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	rc, err := w.Open(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// Open 实现 sdk.Workspace
+func (w *Workspace) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	_, key, err := w.key(p)
+	if err != nil {
+		return nil, err
+	}
+	return w.m.store.Open(ctx, key)
+}
+
+// Delete 实现 sdk.Workspace
+func (w *Workspace) Delete(ctx context.Context, p string) error {
+	_, key, err := w.key(p)
+	if err != nil {
+		return err
+	}
+	l := w.m.jobLock(w.jobID)
+	l.Lock()
+	defer l.Unlock()
+	return w.m.store.Delete(ctx, key)
+}
+
+// List 实现 sdk.Workspace
+func (w *Workspace) List(ctx context.Context) ([]sdk.WorkspaceFile, error) {
+	if err := validJobID(w.jobID); err != nil {
+		return nil, err
+	}
+	entries, err := w.m.store.List(ctx, w.jobID+"/")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]sdk.WorkspaceFile, 0, len(entries))
+	for _, e := range entries {
+		rel := strings.TrimPrefix(e.Key, w.jobID+"/")
+		out = append(out, sdk.WorkspaceFile{Path: rel, Size: e.Size, ModifiedAt: e.ModifiedAt.UTC(), URI: sdk.WorkspaceURI(w.jobID, rel)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// Usage 实现 sdk.Workspace；配额 <0（不限）时返回 0
+func (w *Workspace) Usage(ctx context.Context) (int64, int64, error) {
+	files, err := w.List(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	var used int64
+	for _, f := range files {
+		used += f.Size
+	}
+	quota := w.m.opts.Quota
+	if quota < 0 {
+		quota = 0
+	}
+	return used, quota, nil
+}
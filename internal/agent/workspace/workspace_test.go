@@ -0,0 +1,137 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/agent/sdk"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	return map[string]Store{
+		"local":  local,
+		"object": NewObjectStore(object.NewMemoryStore(), ""),
+	}
+}
+
+func TestWorkspace_ReadWriteList(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ws := NewManager(store, Options{Backend: name}).For("job-1")
+			f, err := ws.WriteFile(ctx, "out/report.md", []byte("# hi"))
+			if err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			if f.Path != "out/report.md" || f.Size != 4 || f.URI != sdk.WorkspaceURI("job-1", "out/report.md") {
+				t.Fatalf("unexpected file: %+v", f)
+			}
+			got, err := ws.ReadFile(ctx, "./out/report.md")
+			if err != nil || string(got) != "# hi" {
+				t.Fatalf("ReadFile = %q, %v", got, err)
+			}
+			files, err := ws.List(ctx)
+			if err != nil || len(files) != 1 || files[0].Path != "out/report.md" {
+				t.Fatalf("List = %+v, %v", files, err)
+			}
+			if err := ws.Delete(ctx, "out/report.md"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := ws.ReadFile(ctx, "out/report.md"); !errors.Is(err, sdk.ErrWorkspaceFileNotFound) {
+				t.Fatalf("ReadFile after delete: want ErrWorkspaceFileNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkspace_Quota(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ws := NewManager(store, Options{Quota: 10}).For("job-q")
+			if _, err := ws.WriteFile(ctx, "a.txt", []byte("123456")); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			if _, err := ws.WriteFile(ctx, "b.txt", []byte("123456")); !errors.Is(err, sdk.ErrWorkspaceQuotaExceeded) {
+				t.Fatalf("want ErrWorkspaceQuotaExceeded, got %v", err)
+			}
+			// 覆盖同名文件不重复计入旧内容
+			if _, err := ws.WriteFile(ctx, "a.txt", []byte("1234567890")); err != nil {
+				t.Fatalf("overwrite within quota: %v", err)
+			}
+			used, quota, err := ws.Usage(ctx)
+			if err != nil || used != 10 || quota != 10 {
+				t.Fatalf("Usage = %d/%d, %v", used, quota, err)
+			}
+		})
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	for _, p := range []string{"", "/", "../x", "a/../../x", "/etc/passwd"} {
+		if _, err := CleanPath(p); !errors.Is(err, sdk.ErrWorkspaceInvalidPath) {
+			t.Errorf("CleanPath(%q): want ErrWorkspaceInvalidPath, got %v", p, err)
+		}
+	}
+	if got, err := CleanPath("a/./b//c.txt"); err != nil || got != "a/b/c.txt" {
+		t.Errorf("CleanPath = %q, %v", got, err)
+	}
+}
+
+func TestJanitor_RemovesTerminalAndOrphans(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(store, Options{Retention: time.Hour})
+	jobs := job.NewJobStoreMem()
+	doneID, _ := jobs.Create(ctx, &job.Job{AgentID: "a"})
+	_ = jobs.UpdateStatus(ctx, doneID, job.StatusRunning)
+	_ = jobs.UpdateStatus(ctx, doneID, job.StatusCompleted)
+	runningID, _ := jobs.Create(ctx, &job.Job{AgentID: "a"})
+	_ = jobs.UpdateStatus(ctx, runningID, job.StatusRunning)
+	for _, id := range []string{doneID, runningID, "job-orphan"} {
+		if _, err := m.For(id).WriteFile(ctx, "f.txt", []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := NewJanitor(m, jobs, nil)
+	if n, err := j.RunOnce(ctx); err != nil || n != 0 {
+		t.Fatalf("within retention: removed %d, %v", n, err)
+	}
+	j.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if n, err := j.RunOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("after retention: removed %d, %v", n, err)
+	}
+	left, err := m.JobIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := left[runningID]; !ok || len(left) != 1 {
+		t.Fatalf("only running job workspace should remain, got %v", left)
+	}
+}
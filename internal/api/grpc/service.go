@@ -28,6 +28,8 @@ import (
 	appcore "rag-platform/internal/app"
 	"rag-platform/internal/pipeline/common"
 	"rag-platform/internal/runtime/eino"
+	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/auth"
 )
 
 // Server gRPC 服务端，持有 Engine 与 DocumentService
@@ -126,9 +128,11 @@ func (s *Server) Query(ctx context.Context, req *pb.QueryRequest) (*pb.QueryResp
 		topK = 10
 	}
 	q := &common.Query{
-		ID:        fmt.Sprintf("query-%d", time.Now().UnixNano()),
-		Text:      req.GetQuery(),
-		Metadata:  nil,
+		ID:       fmt.Sprintf("query-%d", time.Now().UnixNano()),
+		Text:     req.GetQuery(),
+		Metadata: nil,
+		// 文档 ACL 按调用方身份下推到向量检索
+		Filter:    vector.ACLFilter(auth.GetUserID(ctx), []string{string(auth.GetRole(ctx))}),
 		CreatedAt: time.Now(),
 	}
 	if req.Metadata != nil {
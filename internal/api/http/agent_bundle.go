@@ -0,0 +1,143 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/bundle"
+	"rag-platform/internal/agent/instance"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// ImportAgentRequest 导入 Agent 包请求；target_agent_id 为空时新建 Agent（name 为空则沿用包内名称）
+type ImportAgentRequest struct {
+	Bundle        *bundle.Bundle `json:"bundle"`
+	TargetAgentID string         `json:"target_agent_id"`
+	Name          string         `json:"name"`
+	OnConflict    string         `json:"on_conflict"` // fail（默认）| skip | overwrite
+}
+
+func (h *Handler) bundleSources() bundle.Sources {
+	return bundle.Sources{Config: h.agentConfig, Exemplars: h.plannerExemplars}
+}
+
+// ExportAgent 导出 Agent 可移植包：规格、会话记忆快照、Agent 配置（secret 仅含引用）与规划示例，不含 Job 历史
+// GET /api/agents/:id/export
+func (h *Handler) ExportAgent(ctx context.Context, c *app.RequestContext) {
+	if h.agentManager == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "agent.runtime_not_configured")})
+		return
+	}
+	agentID := c.Param("id")
+	agent, err := h.agentManager.Get(ctx, agentID)
+	if err != nil || agent == nil {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "agent.not_found")})
+		return
+	}
+	spec := bundle.AgentSpec{Name: agent.Name}
+	if h.agentInstanceStore != nil {
+		if inst, _ := h.agentInstanceStore.Get(ctx, agentID); inst != nil {
+			spec.BehaviorID = inst.BehaviorID
+			spec.Meta = inst.Meta
+		}
+	}
+	b, err := bundle.Export(ctx, agent, spec, h.bundleSources())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Export agent %s: %v", agentID, err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "agent.export_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, b)
+}
+
+// ImportAgent 导入 Agent 包：新建 Agent 或写入 target_agent_id，返回源 → 目标 ID 映射；
+// on_conflict=fail 且与目标已有状态冲突时返回 409 与冲突明细，不写入任何状态
+// POST /api/agents/import
+func (h *Handler) ImportAgent(ctx context.Context, c *app.RequestContext) {
+	if h.agentManager == nil || h.agentCreator == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "agent.runtime_not_configured")})
+		return
+	}
+	var req ImportAgentRequest
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+		return
+	}
+	policy, err := bundle.ParseConflictPolicy(req.OnConflict)
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := req.Bundle.Validate(); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	created := false
+	var agent *agentruntime.Agent
+	if req.TargetAgentID != "" {
+		agent, err = h.agentManager.Get(ctx, req.TargetAgentID)
+		if err != nil || agent == nil {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "agent.not_found")})
+			return
+		}
+	} else {
+		name := req.Name
+		if name == "" {
+			name = req.Bundle.Agent.Name
+		}
+		agent, err = h.agentCreator.Create(ctx, name)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "创建 Agent failed: %v", err)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "agent.create_failed")})
+			return
+		}
+		created = true
+		if h.agentInstanceStore != nil {
+			now := time.Now()
+			_ = h.agentInstanceStore.Create(ctx, &instance.AgentInstance{
+				ID:               agent.ID,
+				TenantID:         auth.GetTenantID(ctx),
+				Name:             agent.Name,
+				Status:           instance.StatusIdle,
+				DefaultSessionID: agent.Session.ID,
+				BehaviorID:       req.Bundle.Agent.BehaviorID,
+				Meta:             req.Bundle.Agent.Meta,
+				CreatedAt:        now,
+				UpdatedAt:        now,
+			})
+		}
+	}
+	res, err := bundle.Import(ctx, req.Bundle, agent, created, h.bundleSources(), policy)
+	if errors.Is(err, bundle.ErrConflict) {
+		c.JSON(consts.StatusConflict, map[string]interface{}{"error": i18n.T(ctx, "agent.import_conflict"), "result": res})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Import agent bundle into %s: %v", agent.ID, err)
+		c.JSON(consts.StatusInternalServerError, map[string]interface{}{"error": i18n.T(ctx, "agent.import_failed"), "details": err.Error(), "result": res})
+		return
+	}
+	c.JSON(consts.StatusOK, res)
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/runtime/agentconfig"
+	"rag-platform/pkg/i18n"
+)
+
+// AgentConfigRequest 设置 Agent 配置项请求；value 与 secret_ref 二选一（secret_ref 为 secret store 中的 key）
+type AgentConfigRequest struct {
+	Value     *string `json:"value"`
+	SecretRef string  `json:"secret_ref"`
+}
+
+// ListAgentConfig 列出 Agent 配置；secret 项只返回 secret_ref，不返回值
+// GET /api/agents/:id/config
+func (h *Handler) ListAgentConfig(ctx context.Context, c *app.RequestContext) {
+	if h.agentConfig == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "agent_config.disabled")})
+		return
+	}
+	agentID := c.Param("id")
+	list, err := h.agentConfig.List(ctx, agentID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List agent config: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "agent_config.get_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"agent_id": agentID,
+		"config":   list,
+	})
+}
+
+// SetAgentConfigEntry 新增或覆盖 Agent 配置项
+// PUT /api/agents/:id/config/:key
+func (h *Handler) SetAgentConfigEntry(ctx context.Context, c *app.RequestContext) {
+	if h.agentConfig == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "agent_config.disabled")})
+		return
+	}
+	key := c.Param("key")
+	if err := agentconfig.ValidateKey(key); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	var req AgentConfigRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+		return
+	}
+	req.SecretRef = strings.TrimSpace(req.SecretRef)
+	if (req.Value == nil) == (req.SecretRef == "") {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "agent_config.value_or_secret_ref")})
+		return
+	}
+	e := &agentconfig.Entry{AgentID: c.Param("id"), Key: key, SecretRef: req.SecretRef}
+	if req.Value != nil {
+		e.Value = *req.Value
+	}
+	if err := agentconfig.ValidateEntry(e); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.agentConfig.Set(ctx, e); err != nil {
+		hlog.CtxErrorf(ctx, "Set agent config: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "agent_config.save_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, e)
+}
+
+// DeleteAgentConfigEntry 删除 Agent 配置项
+// DELETE /api/agents/:id/config/:key
+func (h *Handler) DeleteAgentConfigEntry(ctx context.Context, c *app.RequestContext) {
+	if h.agentConfig == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "agent_config.disabled")})
+		return
+	}
+	key := c.Param("key")
+	if err := h.agentConfig.Delete(ctx, c.Param("id"), key); err != nil {
+		if errors.Is(err, agentconfig.ErrNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "agent_config.entry_not_found")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Delete agent config: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "agent_config.delete_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]string{"key": key, "status": "deleted"})
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/goaltemplate"
+	"rag-platform/pkg/i18n"
+)
+
+// GoalTemplateRequest 创建/替换目标模板请求（名称取自路径）
+type GoalTemplateRequest struct {
+	Description string               `json:"description"`
+	Goal        string               `json:"goal"`
+	Params      []goaltemplate.Param `json:"params"`
+}
+
+// RenderGoalTemplateRequest 校验参数并预览渲染后的目标
+type RenderGoalTemplateRequest struct {
+	Params map[string]any `json:"params"`
+}
+
+// ListGoalTemplates 列出 Agent 的目标模板（含参数 schema，供 chat/前端生成表单）
+// GET /api/agents/:id/templates
+func (h *Handler) ListGoalTemplates(ctx context.Context, c *app.RequestContext) {
+	if h.goalTemplates == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "goal_template.disabled")})
+		return
+	}
+	agentID := c.Param("id")
+	list, err := h.goalTemplates.List(ctx, agentID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List goal templates: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "goal_template.get_failed")})
+		return
+	}
+	if list == nil {
+		list = []*goaltemplate.Template{}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"agent_id": agentID, "templates": list})
+}
+
+// GetGoalTemplate 获取单个目标模板
+// GET /api/agents/:id/templates/:name
+func (h *Handler) GetGoalTemplate(ctx context.Context, c *app.RequestContext) {
+	t, ok := h.loadGoalTemplate(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(consts.StatusOK, t)
+}
+
+// PutGoalTemplate 创建或整体替换目标模板；goal 中的 {{param}} 须在 params 中声明
+// PUT /api/agents/:id/templates/:name
+func (h *Handler) PutGoalTemplate(ctx context.Context, c *app.RequestContext) {
+	if h.goalTemplates == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "goal_template.disabled")})
+		return
+	}
+	var req GoalTemplateRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.goal_and_params_required")})
+		return
+	}
+	t := &goaltemplate.Template{
+		AgentID:     c.Param("id"),
+		Name:        c.Param("name"),
+		Description: req.Description,
+		Goal:        req.Goal,
+		Params:      req.Params,
+	}
+	if err := t.Validate(); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.goalTemplates.Put(ctx, t); err != nil {
+		hlog.CtxErrorf(ctx, "Put goal template: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "goal_template.save_failed")})
+		return
+	}
+	saved, err := h.goalTemplates.Get(ctx, t.AgentID, t.Name)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Get goal template: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "goal_template.save_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, saved)
+}
+
+// DeleteGoalTemplate 删除目标模板
+// DELETE /api/agents/:id/templates/:name
+func (h *Handler) DeleteGoalTemplate(ctx context.Context, c *app.RequestContext) {
+	if h.goalTemplates == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "goal_template.disabled")})
+		return
+	}
+	name := c.Param("name")
+	if err := h.goalTemplates.Delete(ctx, c.Param("id"), name); err != nil {
+		if errors.Is(err, goaltemplate.ErrNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "goal_template.not_found")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Delete goal template: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "goal_template.delete_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]string{"name": name, "status": "deleted"})
+}
+
+// RenderGoalTemplate 校验参数并返回渲染后的目标，不创建 Job；校验失败返回 400 与逐参数错误
+// POST /api/agents/:id/templates/:name/render
+func (h *Handler) RenderGoalTemplate(ctx context.Context, c *app.RequestContext) {
+	t, ok := h.loadGoalTemplate(ctx, c)
+	if !ok {
+		return
+	}
+	var req RenderGoalTemplateRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+		return
+	}
+	goal, params, err := t.Render(req.Params)
+	if err != nil {
+		writeGoalTemplateError(ctx, c, err)
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"template": t.Name, "goal": goal, "params": params})
+}
+
+func (h *Handler) loadGoalTemplate(ctx context.Context, c *app.RequestContext) (*goaltemplate.Template, bool) {
+	if h.goalTemplates == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "goal_template.disabled")})
+		return nil, false
+	}
+	t, err := h.goalTemplates.Get(ctx, c.Param("id"), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, goaltemplate.ErrNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "goal_template.not_found")})
+			return nil, false
+		}
+		hlog.CtxErrorf(ctx, "Get goal template: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "goal_template.get_failed")})
+		return nil, false
+	}
+	return t, true
+}
+
+// writeGoalTemplateError 参数校验错误返回 400 与 fields，供表单逐项提示
+func writeGoalTemplateError(ctx context.Context, c *app.RequestContext, err error) {
+	var verr *goaltemplate.ValidationError
+	if errors.As(err, &verr) {
+		c.JSON(consts.StatusBadRequest, map[string]interface{}{"error": i18n.T(ctx, "goal_template.params_invalid"), "fields": verr.Fields})
+		return
+	}
+	c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/analytics"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/i18n"
+)
+
+const (
+	defaultAnalyticsWindow = 7 * 24 * time.Hour
+	maxAnalyticsWindow     = 90 * 24 * time.Hour
+)
+
+// GetAdminAnalytics GET /api/admin/analytics：窗口内全部租户 Job 的聚合使用指标（热门工具、各模型失败率、计划规模），
+// 覆盖租户数不足或由单个租户主导的分组被抑制；参数 window（默认 168h，最长 90 天）、limit（最多分析的 Job 数）、top
+func (h *Handler) GetAdminAnalytics(ctx context.Context, c *app.RequestContext) {
+	if h.jobStore == nil || h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	lister, ok := h.jobStore.(job.RecentJobLister)
+	if !ok {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "job.window_listing_unsupported")})
+		return
+	}
+	window := defaultAnalyticsWindow
+	if s := c.Query("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.window_invalid")})
+			return
+		}
+		window = min(d, maxAnalyticsWindow)
+	}
+	limit := maxBottleneckJobs
+	if s := c.Query("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = min(n, maxBottleneckJobs)
+		}
+	}
+	opts := h.analytics
+	if s := c.Query("top"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			opts.Top = n
+		}
+	}
+	to := time.Now()
+	from := to.Add(-window)
+	// 跨租户：不按请求者租户过滤
+	jobs, err := lister.ListUpdatedSince(ctx, "", from, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListUpdatedSince: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
+		return
+	}
+	eventsByJob := make(map[string][]jobstore.JobEvent, len(jobs))
+	for _, j := range jobs {
+		events, _, err := h.jobEventStore.ListEvents(ctx, j.ID)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ListEvents %s: %v", j.ID, err)
+			continue
+		}
+		eventsByJob[j.ID] = events
+	}
+	c.JSON(consts.StatusOK, analytics.Aggregate(jobs, eventsByJob, from, to, opts))
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/anomaly"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+const (
+	defaultAnomalyLimit = 100
+	maxAnomalyLimit     = anomaly.DefaultMaxAnomalies
+)
+
+// GetObservabilityAnomalies 返回最近偏离 Agent 行为基线的 Job 异常（按检测时间倒序）：
+// GET /api/observability/anomalies?agent_id=&kind=&window=24h&limit=100；指定 agent_id 时附带该 Agent 的基线；需 SetAnomalyDetector
+func (h *Handler) GetObservabilityAnomalies(ctx context.Context, c *app.RequestContext) {
+	if h.anomalyDetector == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "anomaly.disabled")})
+		return
+	}
+	f := anomaly.Filter{
+		TenantID: auth.GetTenantID(ctx),
+		AgentID:  c.Query("agent_id"),
+		Kind:     c.Query("kind"),
+		Limit:    defaultAnomalyLimit,
+	}
+	if s := c.Query("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.window_invalid")})
+			return
+		}
+		f.Since = time.Now().Add(-d)
+	}
+	if s := c.Query("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			f.Limit = min(n, maxAnomalyLimit)
+		}
+	}
+	list := h.anomalyDetector.List(f)
+	resp := map[string]interface{}{
+		"anomalies": list,
+		"total":     len(list),
+	}
+	if f.AgentID != "" {
+		if b, ok := h.anomalyDetector.Baseline(f.AgentID); ok {
+			resp["baseline"] = b
+		}
+	}
+	c.JSON(consts.StatusOK, resp)
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/anomaly"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestGetObservabilityAnomalies(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/observability/anomalies", handler.GetObservabilityAnomalies)
+	if got := ut.PerformRequest(s.Engine, "GET", "/api/observability/anomalies", nil).Result().StatusCode(); got != 503 {
+		t.Fatalf("disabled status = %d", got)
+	}
+
+	det := anomaly.NewDetector(anomaly.Options{MinSamples: 2}, planner.CostModel{}, nil)
+	for i := 0; i < 2; i++ {
+		det.Learn("a1", anomaly.Profile{ToolCalls: map[string]int{"search": 1}, Calls: 1})
+	}
+	events := []jobstore.JobEvent{{Type: jobstore.ToolInvocationStarted, Payload: []byte(`{"tool_name":"shell"}`)}}
+	det.Observe(&job.Job{ID: "j1", AgentID: "a1", Status: job.StatusCompleted}, events, true, time.Now())
+	handler.SetAnomalyDetector(det)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/observability/anomalies?agent_id=a1&window=1h", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var resp struct {
+		Anomalies []anomaly.Anomaly `json:"anomalies"`
+		Total     int               `json:"total"`
+		Baseline  *anomaly.Baseline `json:"baseline"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || resp.Anomalies[0].Kind != anomaly.KindNewTool || resp.Anomalies[0].Tool != "shell" {
+		t.Fatalf("unexpected response: %s", w.Result().Body())
+	}
+	if resp.Baseline == nil || resp.Baseline.Samples != 2 {
+		t.Fatalf("baseline = %+v", resp.Baseline)
+	}
+	if got := ut.PerformRequest(s.Engine, "GET", "/api/observability/anomalies?window=bad", nil).Result().StatusCode(); got != 400 {
+		t.Fatalf("bad window status = %d", got)
+	}
+}
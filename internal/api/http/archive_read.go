@@ -0,0 +1,138 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/api/http/middleware"
+	"rag-platform/internal/runtime/eventarchive"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/i18n"
+)
+
+// SetEventArchive 设置事件归档冷读器（可选）；Job 或其事件已从热存储清理时，trace/replay/events 从对象存储归档读取
+func (h *Handler) SetEventArchive(r *eventarchive.Reader) {
+	h.eventArchive = r
+}
+
+// coldRead 一次归档冷读的结果；响应中以 archive 字段返回来源与延迟预期
+type coldRead struct {
+	events []jobstore.JobEvent
+	info   eventarchive.ReadInfo
+}
+
+// getJobOrArchived 同 getJobAndCheckTenant；热存储中无此 Job 且配置了归档时，从归档 manifest 还原 Job 并一并返回归档事件
+func (h *Handler) getJobOrArchived(ctx context.Context, c *app.RequestContext, jobID string) (*job.Job, *coldRead, bool) {
+	if h.jobStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return nil, nil, false
+	}
+	endPhase := middleware.StartPhase(ctx, "job_load")
+	j, err := h.jobStore.Get(ctx, jobID)
+	endPhase()
+	if err == nil && j != nil {
+		if j.TenantID != requestTenantID(ctx) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
+			return nil, nil, false
+		}
+		return j, nil, true
+	}
+	if h.eventArchive == nil {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
+		return nil, nil, false
+	}
+	endPhase = middleware.StartPhase(ctx, "archive_load")
+	a, info, errArchive := h.eventArchive.Load(ctx, jobID)
+	endPhase()
+	if errArchive != nil {
+		if errors.Is(errArchive, eventarchive.ErrNotArchived) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
+		} else {
+			hlog.CtxErrorf(ctx, "archive cold read %s: %v", jobID, errArchive)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.archive_read_failed", errArchive.Error())})
+		}
+		return nil, nil, false
+	}
+	archived := a.Manifest.Job()
+	if archived.TenantID != requestTenantID(ctx) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
+		return nil, nil, false
+	}
+	return archived, &coldRead{events: a.Events, info: info}, true
+}
+
+// listJobEvents 返回 Job 事件：已冷读时直接用归档事件；热存储无事件（已清理、仅保留 Job 元数据）且有归档时改为冷读
+func (h *Handler) listJobEvents(ctx context.Context, jobID string, cold *coldRead) ([]jobstore.JobEvent, *coldRead, error) {
+	if cold != nil {
+		return cold.events, cold, nil
+	}
+	endPhase := middleware.StartPhase(ctx, "list_events")
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	endPhase()
+	if err != nil || len(events) > 0 || h.eventArchive == nil {
+		return events, nil, err
+	}
+	defer middleware.StartPhase(ctx, "archive_load")()
+	a, info, errArchive := h.eventArchive.Load(ctx, jobID)
+	if errArchive != nil {
+		if errors.Is(errArchive, eventarchive.ErrNotArchived) {
+			return events, nil, nil
+		}
+		return nil, nil, errArchive
+	}
+	return a.Events, &coldRead{events: a.Events, info: info}, nil
+}
+
+// coldEventStore 以归档事件响应该 Job 的读取（供 ReplayContextBuilder 等只接受 JobStore 的组件），其余方法透传
+type coldEventStore struct {
+	jobstore.JobStore
+	jobID  string
+	events []jobstore.JobEvent
+}
+
+func (s *coldEventStore) ListEvents(ctx context.Context, jobID string) ([]jobstore.JobEvent, int, error) {
+	if jobID != s.jobID {
+		return s.JobStore.ListEvents(ctx, jobID)
+	}
+	return s.events, len(s.events), nil
+}
+
+func (s *coldEventStore) ListEventsSince(ctx context.Context, jobID string, afterVersion int) ([]jobstore.JobEvent, int, error) {
+	if jobID != s.jobID {
+		return jobstore.EventsSince(ctx, s.JobStore, jobID, afterVersion)
+	}
+	if afterVersion >= len(s.events) {
+		return nil, len(s.events), nil
+	}
+	if afterVersion < 0 {
+		afterVersion = 0
+	}
+	return s.events[afterVersion:], len(s.events), nil
+}
+
+// eventStoreFor 冷读时返回以归档事件为准的只读视图，否则返回热存储
+func (h *Handler) eventStoreFor(jobID string, cold *coldRead) jobstore.JobStore {
+	if cold == nil {
+		return h.jobEventStore
+	}
+	return &coldEventStore{JobStore: h.jobEventStore, jobID: jobID, events: cold.events}
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/eventarchive"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+)
+
+func TestGetJobEvents_ColdReadFromArchive(t *testing.T) {
+	ctx := context.Background()
+	store := object.NewMemoryStore()
+	archived := &job.Job{ID: "job-archived", AgentID: "a1", TenantID: "default", Goal: "g", Status: job.StatusCompleted}
+	events := []jobstore.JobEvent{{ID: "e1", JobID: archived.ID, Type: jobstore.JobCreated, Payload: []byte(`{"goal":"g"}`)}}
+	if _, err := eventarchive.Write(ctx, store, "", archived, events, 0); err != nil {
+		t.Fatal(err)
+	}
+	other := &job.Job{ID: "job-other-tenant", AgentID: "a1", TenantID: "t2"}
+	if _, err := eventarchive.Write(ctx, store, "", other, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(job.NewJobStoreMem())
+	handler.SetJobEventStore(jobstore.NewMemoryStore())
+	handler.SetEventArchive(eventarchive.NewReader(store, "", eventarchive.ReaderOptions{}))
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/events", handler.GetJobEvents)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/job-archived/events", nil)
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("status = %d %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	var out struct {
+		Events  []map[string]interface{} `json:"events"`
+		Archive eventarchive.ReadInfo    `json:"archive"`
+	}
+	_ = json.Unmarshal(w.Result().Body(), &out)
+	if len(out.Events) != 1 || out.Events[0]["id"] != "e1" || out.Archive.Source != "archive" || out.Archive.ExpectedLatency == "" {
+		t.Fatalf("cold read response = %s", w.Result().Body())
+	}
+
+	for _, id := range []string{"job-other-tenant", "job-missing"} {
+		w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+id+"/events", nil)
+		if w.Result().StatusCode() != 404 {
+			t.Fatalf("%s: status = %d", id, w.Result().StatusCode())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/api/http/middleware"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestAgentMessage_RecordsAttribution(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	jobs := job.NewJobStoreMem()
+	events := job.NewAttributingStore(jobstore.NewMemoryStore(), jobs)
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+
+	mw := middleware.NewMiddleware()
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(mw.RequestID(), mw.InjectAuthContext())
+	s.POST("/api/agents/:id/message", handler.AgentMessage)
+	s.GET("/api/jobs/:id/events", handler.GetJobEvents)
+	body := `{"message":"hi"}`
+	w := ut.PerformRequest(s.Engine, "POST", "/api/agents/"+a.ID+"/message", &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)},
+		ut.Header{Key: "X-User-ID", Value: "alice"}, ut.Header{Key: "X-Aetheris-Client", Value: "cli"}, ut.Header{Key: "X-Request-ID", Value: "req-42"})
+	if w.Result().StatusCode() != 202 || string(w.Result().Header.Peek("X-Request-ID")) != "req-42" {
+		t.Fatalf("message: %d %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	var out map[string]interface{}
+	_ = json.Unmarshal(w.Result().Body(), &out)
+	jobID := out["job_id"].(string)
+	j, _ := jobs.Get(ctx, jobID)
+	want := jobstore.Attribution{UserID: "alice", Client: jobstore.ClientCLI, RequestID: "req-42"}
+	if j.Attribution == nil || *j.Attribution != want {
+		t.Fatalf("job attribution = %+v", j.Attribution)
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/events", nil)
+	if !strings.Contains(string(w.Result().Body()), `"request_id":"req-42"`) {
+		t.Fatalf("events missing attribution: %s", w.Result().Body())
+	}
+
+	// 未声明客户端与请求 ID 时归为 api 并生成请求 ID
+	w = ut.PerformRequest(s.Engine, "POST", "/api/agents/"+a.ID+"/message", &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+	_ = json.Unmarshal(w.Result().Body(), &out)
+	j, _ = jobs.Get(ctx, out["job_id"].(string))
+	if j.Attribution.Client != jobstore.ClientAPI || !strings.HasPrefix(j.Attribution.RequestID, "req-") {
+		t.Fatalf("default attribution = %+v", j.Attribution)
+	}
+}
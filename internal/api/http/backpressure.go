@@ -0,0 +1,54 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/pkg/i18n"
+)
+
+// SetBackpressure 设置消息受理背压（可选）；nil 时不限制
+func (h *Handler) SetBackpressure(gate *job.BackpressureGate) {
+	h.backpressureGate = gate
+}
+
+// writeBackpressure 写 429 + Retry-After（秒，向上取整）
+func writeBackpressure(ctx context.Context, c *app.RequestContext, level job.BackpressureLevel, retryAfter time.Duration) {
+	secs := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(secs))
+	c.JSON(consts.StatusTooManyRequests, map[string]interface{}{
+		"error":               i18n.T(ctx, "backpressure.rejected"),
+		"code":                "backpressure",
+		"level":               level.String(),
+		"retry_after_seconds": secs,
+	})
+}
+
+// backpressureSummary 背压状态（observability summary）；未启用时为 nil
+func (h *Handler) backpressureSummary(ctx context.Context) *job.BackpressureState {
+	if h.backpressureGate == nil {
+		return nil
+	}
+	st := h.backpressureGate.State(ctx)
+	return &st
+}
@@ -0,0 +1,459 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/utils"
+)
+
+const (
+	defaultBottleneckWindow  = 24 * time.Hour
+	maxBottleneckWindow      = 30 * 24 * time.Hour
+	defaultBottleneckJobs    = 500
+	maxBottleneckJobs        = 5000
+	defaultBottleneckTop     = 10
+	bottleneckHeatmapBuckets = 24
+)
+
+// DurationStat 一组耗时样本的聚合（毫秒）
+type DurationStat struct {
+	Key     string `json:"key"`
+	Count   int    `json:"count"`
+	TotalMs int64  `json:"total_ms"`
+	AvgMs   int64  `json:"avg_ms"`
+	P50Ms   int64  `json:"p50_ms"`
+	P95Ms   int64  `json:"p95_ms"`
+	MaxMs   int64  `json:"max_ms"`
+}
+
+// QueueExecutionStat 排队等待与实际执行耗时对比；QueueShare 为排队占总耗时比例（0~1）
+type QueueExecutionStat struct {
+	QueueWait  DurationStat `json:"queue_wait"`
+	Execution  DurationStat `json:"execution"`
+	QueueShare float64      `json:"queue_share"`
+}
+
+// RetryStat 某类步骤在窗口内的重试情况；Step 为 node type（tool 类型带工具名，如 tool:web_search）
+type RetryStat struct {
+	Step        string `json:"step"`
+	Jobs        int    `json:"jobs"`
+	Retries     int    `json:"retries"`
+	MaxAttempts int    `json:"max_attempts"`
+}
+
+// HeatmapRow 热力图一行：某 node type 在各时间桶内的步骤数与 p95 耗时
+type HeatmapRow struct {
+	Key   string        `json:"key"`
+	Cells []HeatmapCell `json:"cells"`
+}
+
+// HeatmapCell 热力图单元格
+type HeatmapCell struct {
+	Count int   `json:"count"`
+	P95Ms int64 `json:"p95_ms"`
+}
+
+// Heatmap 按时间桶 × node type 的步骤耗时热力图
+type Heatmap struct {
+	BucketSeconds int          `json:"bucket_seconds"`
+	Buckets       []time.Time  `json:"buckets"`
+	Rows          []HeatmapRow `json:"rows"`
+}
+
+// BottleneckReport GET /api/observability/bottlenecks 的响应体
+type BottleneckReport struct {
+	WindowSeconds    int                `json:"window_seconds"`
+	From             time.Time          `json:"from"`
+	To               time.Time          `json:"to"`
+	JobsAnalyzed     int                `json:"jobs_analyzed"`
+	StepDurations    DurationStat       `json:"step_durations"`
+	SlowestTools     []DurationStat     `json:"slowest_tools"`
+	SlowestNodeTypes []DurationStat     `json:"slowest_node_types"`
+	QueueVsExecution QueueExecutionStat `json:"queue_vs_execution"`
+	MostRetried      []RetryStat        `json:"most_retried"`
+	Heatmap          Heatmap            `json:"heatmap"`
+}
+
+type stepSample struct {
+	key string
+	at  time.Time
+	ms  int64
+}
+
+type retryAgg struct {
+	jobs, retries, maxAttempts int
+}
+
+// BuildBottleneckReport 由多个 Job 的事件流聚合瓶颈分析；仅统计 [from, to] 内的事件，top 限制各排行榜长度
+func BuildBottleneckReport(eventsByJob map[string][]jobstore.JobEvent, from, to time.Time, top int) BottleneckReport {
+	if top <= 0 {
+		top = defaultBottleneckTop
+	}
+	var steps []stepSample
+	toolMs := make(map[string][]int64)
+	var queueMs, execMs []int64
+	retries := make(map[string]*retryAgg)
+
+	for _, events := range eventsByJob {
+		nodeTypes := make(map[string]string)
+		nodeAttempts := make(map[string]int)
+		toolStarts := make(map[string]time.Time)
+		toolNames := make(map[string]string)
+		calledAt := make(map[string]time.Time)
+		hasInvocations := false
+		for _, e := range events {
+			if e.Type == jobstore.ToolInvocationStarted {
+				hasInvocations = true
+				break
+			}
+		}
+		var queuedAt, runningSince time.Time
+		var queued, exec int64
+		for _, e := range events {
+			inWindow := !e.CreatedAt.Before(from) && !e.CreatedAt.After(to)
+			var pl map[string]interface{}
+			if len(e.Payload) > 0 {
+				_ = json.Unmarshal(e.Payload, &pl)
+			}
+			getStr := func(k string) string {
+				if s, ok := pl[k].(string); ok {
+					return s
+				}
+				return ""
+			}
+			switch e.Type {
+			case jobstore.PlanGenerated, jobstore.PlanEvolution:
+				var p struct {
+					TaskGraph *planner.TaskGraph `json:"task_graph"`
+				}
+				if json.Unmarshal(e.Payload, &p) == nil && p.TaskGraph != nil {
+					for _, n := range p.TaskGraph.Nodes {
+						key := n.Type
+						if key == "" {
+							key = "unknown"
+						}
+						if n.ToolName != "" {
+							key += ":" + n.ToolName
+						}
+						nodeTypes[n.ID] = key
+					}
+				}
+			case jobstore.JobCreated, jobstore.JobQueued, jobstore.JobRequeued:
+				if !runningSince.IsZero() {
+					if inWindow {
+						exec += e.CreatedAt.Sub(runningSince).Milliseconds()
+					}
+					runningSince = time.Time{}
+				}
+				if queuedAt.IsZero() {
+					queuedAt = e.CreatedAt
+				}
+			case jobstore.JobWaiting:
+				if !runningSince.IsZero() {
+					if inWindow {
+						exec += e.CreatedAt.Sub(runningSince).Milliseconds()
+					}
+					runningSince = time.Time{}
+				}
+			case jobstore.JobCompleted, jobstore.JobFailed, jobstore.JobCancelled:
+				if !runningSince.IsZero() {
+					if inWindow {
+						exec += e.CreatedAt.Sub(runningSince).Milliseconds()
+					}
+					runningSince = time.Time{}
+				}
+				queuedAt = time.Time{}
+			case jobstore.ToolCalled:
+				if nodeID := getStr("node_id"); nodeID != "" {
+					calledAt[nodeID] = e.CreatedAt
+					toolNames[nodeID] = getStr("tool_name")
+				}
+			case jobstore.ToolReturned:
+				nodeID := getStr("node_id")
+				if start, ok := calledAt[nodeID]; ok && !hasInvocations {
+					if inWindow && toolNames[nodeID] != "" {
+						toolMs[toolNames[nodeID]] = append(toolMs[toolNames[nodeID]], e.CreatedAt.Sub(start).Milliseconds())
+					}
+					delete(calledAt, nodeID)
+				}
+			case jobstore.ToolInvocationStarted:
+				if id := getStr("invocation_id"); id != "" {
+					toolStarts[id] = e.CreatedAt
+					toolNames[id] = getStr("tool_name")
+				}
+			case jobstore.ToolInvocationFinished:
+				id := getStr("invocation_id")
+				if start, ok := toolStarts[id]; ok {
+					if inWindow && toolNames[id] != "" {
+						toolMs[toolNames[id]] = append(toolMs[toolNames[id]], e.CreatedAt.Sub(start).Milliseconds())
+					}
+					delete(toolStarts, id)
+				}
+			case jobstore.NodeFinished:
+				if !inWindow {
+					continue
+				}
+				nodeID := getStr("node_id")
+				key := nodeTypes[nodeID]
+				if key == "" {
+					key = "unknown"
+				}
+				var ms int64
+				if v, ok := pl["duration_ms"].(float64); ok {
+					ms = int64(v)
+				}
+				steps = append(steps, stepSample{key: key, at: e.CreatedAt, ms: ms})
+			}
+			// 首个执行信号结束排队
+			switch e.Type {
+			case jobstore.JobLeased, jobstore.JobRunning, jobstore.PlanGenerated, jobstore.NodeStarted:
+				if !queuedAt.IsZero() {
+					if inWindow {
+						queued += e.CreatedAt.Sub(queuedAt).Milliseconds()
+					}
+					queuedAt = time.Time{}
+				}
+				if runningSince.IsZero() {
+					runningSince = e.CreatedAt
+				}
+			}
+			if e.Type == jobstore.NodeStarted && inWindow {
+				if nodeID := getStr("node_id"); nodeID != "" {
+					attempt := 0
+					if v, ok := pl["attempt"].(float64); ok {
+						attempt = int(v)
+					}
+					nodeAttempts[nodeID] = max(nodeAttempts[nodeID]+1, attempt)
+				}
+			}
+		}
+		// 仍在执行中的 Job 计至窗口末尾
+		if !runningSince.IsZero() {
+			exec += to.Sub(runningSince).Milliseconds()
+		}
+		if queued > 0 {
+			queueMs = append(queueMs, queued)
+		}
+		if exec > 0 {
+			execMs = append(execMs, exec)
+		}
+		retriedKeys := make(map[string]bool)
+		for nodeID, attempts := range nodeAttempts {
+			if attempts <= 1 {
+				continue
+			}
+			key := nodeTypes[nodeID]
+			if key == "" {
+				key = "unknown"
+			}
+			agg := retries[key]
+			if agg == nil {
+				agg = &retryAgg{}
+				retries[key] = agg
+			}
+			agg.retries += attempts - 1
+			agg.maxAttempts = max(agg.maxAttempts, attempts)
+			if !retriedKeys[key] {
+				retriedKeys[key] = true
+				agg.jobs++
+			}
+		}
+	}
+
+	report := BottleneckReport{
+		WindowSeconds: int(to.Sub(from).Seconds()),
+		From:          from,
+		To:            to,
+		JobsAnalyzed:  len(eventsByJob),
+	}
+	all := make([]int64, 0, len(steps))
+	byType := make(map[string][]int64)
+	for _, s := range steps {
+		all = append(all, s.ms)
+		t, _, _ := strings.Cut(s.key, ":")
+		byType[t] = append(byType[t], s.ms)
+	}
+	report.StepDurations = summarizeDurations("all", all)
+	report.SlowestTools = rankDurations(toolMs, top)
+	report.SlowestNodeTypes = rankDurations(byType, top)
+	report.QueueVsExecution = QueueExecutionStat{
+		QueueWait: summarizeDurations("queue_wait", queueMs),
+		Execution: summarizeDurations("execution", execMs),
+	}
+	if total := report.QueueVsExecution.QueueWait.TotalMs + report.QueueVsExecution.Execution.TotalMs; total > 0 {
+		report.QueueVsExecution.QueueShare = float64(report.QueueVsExecution.QueueWait.TotalMs) / float64(total)
+	}
+	report.MostRetried = make([]RetryStat, 0, len(retries))
+	for k, agg := range retries {
+		report.MostRetried = append(report.MostRetried, RetryStat{Step: k, Jobs: agg.jobs, Retries: agg.retries, MaxAttempts: agg.maxAttempts})
+	}
+	sort.Slice(report.MostRetried, func(a, b int) bool {
+		x, y := report.MostRetried[a], report.MostRetried[b]
+		if x.Retries != y.Retries {
+			return x.Retries > y.Retries
+		}
+		return x.Step < y.Step
+	})
+	if len(report.MostRetried) > top {
+		report.MostRetried = report.MostRetried[:top]
+	}
+	report.Heatmap = buildHeatmap(steps, from, to)
+	return report
+}
+
+func summarizeDurations(key string, samples []int64) DurationStat {
+	st := DurationStat{Key: key, Count: len(samples)}
+	if len(samples) == 0 {
+		return st
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+	for _, v := range sorted {
+		st.TotalMs += v
+	}
+	st.AvgMs = st.TotalMs / int64(len(sorted))
+	st.P50Ms = utils.Percentile(sorted, 0.5)
+	st.P95Ms = utils.Percentile(sorted, 0.95)
+	st.MaxMs = sorted[len(sorted)-1]
+	return st
+}
+
+// rankDurations 按 p95 倒序返回前 top 项
+func rankDurations(groups map[string][]int64, top int) []DurationStat {
+	out := make([]DurationStat, 0, len(groups))
+	for k, samples := range groups {
+		out = append(out, summarizeDurations(k, samples))
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].P95Ms != out[b].P95Ms {
+			return out[a].P95Ms > out[b].P95Ms
+		}
+		return out[a].Key < out[b].Key
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+func buildHeatmap(steps []stepSample, from, to time.Time) Heatmap {
+	bucket := to.Sub(from) / bottleneckHeatmapBuckets
+	if bucket < time.Minute {
+		bucket = time.Minute
+	}
+	n := int(to.Sub(from)/bucket) + 1
+	if n > bottleneckHeatmapBuckets {
+		n = bottleneckHeatmapBuckets
+	}
+	hm := Heatmap{BucketSeconds: int(bucket.Seconds()), Buckets: make([]time.Time, n), Rows: []HeatmapRow{}}
+	for i := range hm.Buckets {
+		hm.Buckets[i] = from.Add(time.Duration(i) * bucket)
+	}
+	cells := make(map[string][][]int64)
+	for _, s := range steps {
+		t, _, _ := strings.Cut(s.key, ":")
+		i := int(s.at.Sub(from) / bucket)
+		if i < 0 || i >= n {
+			i = n - 1
+		}
+		if cells[t] == nil {
+			cells[t] = make([][]int64, n)
+		}
+		cells[t][i] = append(cells[t][i], s.ms)
+	}
+	keys := make([]string, 0, len(cells))
+	for k := range cells {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		row := HeatmapRow{Key: k, Cells: make([]HeatmapCell, n)}
+		for i, samples := range cells[k] {
+			st := summarizeDurations(k, samples)
+			row.Cells[i] = HeatmapCell{Count: st.Count, P95Ms: st.P95Ms}
+		}
+		hm.Rows = append(hm.Rows, row)
+	}
+	return hm
+}
+
+// GetObservabilityBottlenecks 返回窗口内的执行瓶颈分析（GET /api/observability/bottlenecks?window=24h）：
+// 最慢工具、最慢 node type、步骤 p95、排队 vs 执行、重试最多的步骤及热力图；需 JobStore 实现 job.RecentJobLister
+func (h *Handler) GetObservabilityBottlenecks(ctx context.Context, c *app.RequestContext) {
+	if h.jobStore == nil || h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	lister, ok := h.jobStore.(job.RecentJobLister)
+	if !ok {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "job.window_listing_unsupported")})
+		return
+	}
+	window := defaultBottleneckWindow
+	if s := c.Query("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.window_invalid")})
+			return
+		}
+		window = min(d, maxBottleneckWindow)
+	}
+	limit := defaultBottleneckJobs
+	if s := c.Query("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = min(n, maxBottleneckJobs)
+		}
+	}
+	top := defaultBottleneckTop
+	if s := c.Query("top"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			top = n
+		}
+	}
+	to := time.Now()
+	from := to.Add(-window)
+	jobs, err := lister.ListUpdatedSince(ctx, auth.GetTenantID(ctx), from, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListUpdatedSince: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
+		return
+	}
+	eventsByJob := make(map[string][]jobstore.JobEvent, len(jobs))
+	for _, j := range jobs {
+		events, _, err := h.jobEventStore.ListEvents(ctx, j.ID)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ListEvents %s: %v", j.ID, err)
+			continue
+		}
+		eventsByJob[j.ID] = events
+	}
+	c.JSON(consts.StatusOK, BuildBottleneckReport(eventsByJob, from, to, top))
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func bottleneckJobEvents(t *testing.T, t0 time.Time, toolMs int, retried bool) []jobstore.JobEvent {
+	t.Helper()
+	graph := map[string]interface{}{"nodes": []map[string]interface{}{
+		{"id": "n1", "type": "tool", "tool_name": "web_search"},
+		{"id": "n2", "type": "llm"},
+	}}
+	events := []jobstore.JobEvent{
+		narrativeEvent(t, jobstore.JobCreated, t0, "", map[string]interface{}{}),
+		narrativeEvent(t, jobstore.JobLeased, t0.Add(2*time.Second), "", map[string]interface{}{}),
+		narrativeEvent(t, jobstore.PlanGenerated, t0.Add(3*time.Second), "", map[string]interface{}{"task_graph": graph}),
+		narrativeEvent(t, jobstore.NodeStarted, t0.Add(4*time.Second), "", map[string]interface{}{"node_id": "n1", "attempt": 1}),
+	}
+	at := t0.Add(4 * time.Second)
+	if retried {
+		at = at.Add(time.Second)
+		events = append(events, narrativeEvent(t, jobstore.NodeStarted, at, "", map[string]interface{}{"node_id": "n1", "attempt": 2}))
+	}
+	toolEnd := at.Add(time.Duration(toolMs) * time.Millisecond)
+	events = append(events,
+		narrativeEvent(t, jobstore.ToolInvocationStarted, at, "", map[string]interface{}{"node_id": "n1", "invocation_id": "inv-1", "tool_name": "web_search"}),
+		narrativeEvent(t, jobstore.ToolInvocationFinished, toolEnd, "", map[string]interface{}{"node_id": "n1", "invocation_id": "inv-1", "outcome": "success"}),
+		narrativeEvent(t, jobstore.NodeFinished, toolEnd, "", map[string]interface{}{"node_id": "n1", "duration_ms": toolMs}),
+		narrativeEvent(t, jobstore.NodeStarted, toolEnd, "", map[string]interface{}{"node_id": "n2"}),
+		narrativeEvent(t, jobstore.NodeFinished, toolEnd.Add(500*time.Millisecond), "", map[string]interface{}{"node_id": "n2", "duration_ms": 500}),
+		narrativeEvent(t, jobstore.JobCompleted, toolEnd.Add(time.Second), "", map[string]interface{}{}),
+	)
+	return events
+}
+
+func TestBuildBottleneckReport(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	eventsByJob := map[string][]jobstore.JobEvent{
+		"job-1": bottleneckJobEvents(t, t0, 3000, true),
+		"job-2": bottleneckJobEvents(t, t0.Add(time.Hour), 1000, false),
+	}
+	report := BuildBottleneckReport(eventsByJob, t0.Add(-time.Hour), t0.Add(23*time.Hour), 5)
+
+	if report.JobsAnalyzed != 2 || report.WindowSeconds != 24*3600 {
+		t.Fatalf("unexpected header: %+v", report)
+	}
+	if len(report.SlowestTools) != 1 || report.SlowestTools[0].Key != "web_search" || report.SlowestTools[0].Count != 2 || report.SlowestTools[0].MaxMs != 3000 {
+		t.Fatalf("slowest tools = %+v", report.SlowestTools)
+	}
+	if len(report.SlowestNodeTypes) != 2 || report.SlowestNodeTypes[0].Key != "tool" || report.SlowestNodeTypes[0].P95Ms != 3000 {
+		t.Fatalf("slowest node types = %+v", report.SlowestNodeTypes)
+	}
+	if report.StepDurations.Count != 4 || report.StepDurations.P95Ms != 3000 {
+		t.Fatalf("step durations = %+v", report.StepDurations)
+	}
+	qe := report.QueueVsExecution
+	if qe.QueueWait.Count != 2 || qe.QueueWait.MaxMs != 2000 || qe.Execution.Count != 2 || qe.QueueShare <= 0 || qe.QueueShare >= 1 {
+		t.Fatalf("queue vs execution = %+v", qe)
+	}
+	if len(report.MostRetried) != 1 || report.MostRetried[0].Step != "tool:web_search" || report.MostRetried[0].Retries != 1 || report.MostRetried[0].MaxAttempts != 2 {
+		t.Fatalf("most retried = %+v", report.MostRetried)
+	}
+	if len(report.Heatmap.Buckets) != bottleneckHeatmapBuckets || len(report.Heatmap.Rows) != 2 || report.Heatmap.Rows[1].Key != "tool" {
+		t.Fatalf("heatmap = %+v", report.Heatmap)
+	}
+	if c := report.Heatmap.Rows[1].Cells[1]; c.Count != 1 || c.P95Ms != 3000 {
+		t.Fatalf("heatmap cell = %+v", c)
+	}
+}
+
+func TestBuildBottleneckReport_IgnoresEventsOutsideWindow(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	eventsByJob := map[string][]jobstore.JobEvent{"job-1": bottleneckJobEvents(t, t0, 3000, true)}
+	report := BuildBottleneckReport(eventsByJob, t0.Add(time.Hour), t0.Add(2*time.Hour), 5)
+	if report.StepDurations.Count != 0 || len(report.SlowestTools) != 0 || len(report.MostRetried) != 0 {
+		t.Fatalf("expected empty report, got %+v", report)
+	}
+}
+
+func TestGetObservabilityBottlenecks(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	jobID, err := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	ver := 0
+	for _, e := range bottleneckJobEvents(t, now.Add(-time.Minute), 200, true) {
+		e.JobID = jobID
+		if ver, err = events.Append(ctx, jobID, ver, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/observability/bottlenecks", handler.GetObservabilityBottlenecks)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/observability/bottlenecks?window=1h", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var report BottleneckReport
+	if err := json.Unmarshal(w.Result().Body(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.JobsAnalyzed != 1 || report.WindowSeconds != 3600 || len(report.SlowestTools) != 1 || len(report.MostRetried) != 1 {
+		t.Fatalf("unexpected report: %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/observability/bottlenecks?window=bogus", nil)
+	if got := w.Result().StatusCode(); got != 400 {
+		t.Fatalf("invalid window status = %d, want 400", got)
+	}
+}
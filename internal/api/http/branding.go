@@ -0,0 +1,118 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/branding"
+	"rag-platform/pkg/i18n"
+)
+
+// GetTenantBranding GET /api/tenant/branding
+func (h *Handler) GetTenantBranding(ctx context.Context, c *app.RequestContext) {
+	if h.branding == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "branding.disabled")})
+		return
+	}
+	b, err := h.branding.Get(ctx, requestTenantID(ctx))
+	if errors.Is(err, branding.ErrNotFound) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "branding.not_found")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "GetTenantBranding: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "branding.failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, b)
+}
+
+// PutTenantBranding PUT /api/tenant/branding：整体替换当前租户的品牌设置
+func (h *Handler) PutTenantBranding(ctx context.Context, c *app.RequestContext) {
+	if h.branding == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "branding.disabled")})
+		return
+	}
+	var b branding.Branding
+	if err := c.BindJSON(&b); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+		return
+	}
+	if err := b.Validate(); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "branding.invalid", err)})
+		return
+	}
+	b.TenantID = requestTenantID(ctx)
+	if err := h.branding.Put(ctx, &b); err != nil {
+		hlog.CtxErrorf(ctx, "PutTenantBranding: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "branding.failed")})
+		return
+	}
+	h.GetTenantBranding(ctx, c)
+}
+
+// DeleteTenantBranding DELETE /api/tenant/branding
+func (h *Handler) DeleteTenantBranding(ctx context.Context, c *app.RequestContext) {
+	if h.branding == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "branding.disabled")})
+		return
+	}
+	err := h.branding.Delete(ctx, requestTenantID(ctx))
+	if errors.Is(err, branding.ErrNotFound) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "branding.not_found")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "DeleteTenantBranding: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "branding.failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// tenantBranding 渲染时读取租户品牌；未配置、存储未启用或读取failed时返回 nil（按默认样式渲染）
+func (h *Handler) tenantBranding(ctx context.Context, tenantID string) *branding.Branding {
+	if h.branding == nil {
+		return nil
+	}
+	b, err := h.branding.Get(ctx, tenantID)
+	if err != nil {
+		if !errors.Is(err, branding.ErrNotFound) {
+			hlog.CtxWarnf(ctx, "load branding for tenant %s: %v", tenantID, err)
+		}
+		return nil
+	}
+	return b
+}
+
+// brandingJSON 证据包 branding.json 内容；无品牌时为 nil（不写入该文件）
+func (h *Handler) brandingJSON(ctx context.Context, tenantID string) []byte {
+	b := h.tenantBranding(ctx, tenantID)
+	if b.IsZero() {
+		return nil
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return data
+}
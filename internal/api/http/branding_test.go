@@ -0,0 +1,109 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"rag-platform/internal/agent/branding"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/proof"
+)
+
+func TestTenantBranding_TracePageAndEvidence(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	jobID, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", TenantID: "default", Goal: "refund order 42"})
+	ver, _ := events.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCreated})
+	_, _ = events.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCompleted})
+
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetBranding(branding.NewStoreMem())
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/tenant/branding", handler.GetTenantBranding)
+	s.PUT("/api/tenant/branding", handler.PutTenantBranding)
+	s.DELETE("/api/tenant/branding", handler.DeleteTenantBranding)
+	s.GET("/api/jobs/:id/trace/page", handler.GetJobTracePage)
+	s.POST("/api/jobs/:id/export", handler.ExportJobForensics)
+
+	do := func(method, path, body string) *protocol.Response {
+		if body == "" {
+			return ut.PerformRequest(s.Engine, method, path, nil).Result()
+		}
+		return ut.PerformRequest(s.Engine, method, path, &ut.Body{Body: strings.NewReader(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+	}
+
+	if resp := do("GET", "/api/tenant/branding", ""); resp.StatusCode() != 404 {
+		t.Fatalf("get before put: status %d", resp.StatusCode())
+	}
+	if resp := do("PUT", "/api/tenant/branding", `{"logo_url":"javascript:alert(1)"}`); resp.StatusCode() != 400 {
+		t.Fatalf("invalid logo: status %d", resp.StatusCode())
+	}
+	resp := do("PUT", "/api/tenant/branding", `{"title":"ACME <Audit>","logo_url":"https://cdn.example.com/logo.png","fields":[{"label":"Contract","value":"C-2026-001"}]}`)
+	if resp.StatusCode() != 200 {
+		t.Fatalf("put: status %d: %s", resp.StatusCode(), resp.Body())
+	}
+	var got branding.Branding
+	if err := json.Unmarshal(do("GET", "/api/tenant/branding", "").Body(), &got); err != nil || got.Title != "ACME <Audit>" || len(got.Fields) != 1 {
+		t.Fatalf("get = %+v, err = %v", got, err)
+	}
+
+	page := string(do("GET", "/api/jobs/"+jobID+"/trace/page", "").Body())
+	for _, want := range []string{
+		"<title>ACME &lt;Audit&gt; - Trace",
+		`id="trace-branding"`,
+		`<img src="https://cdn.example.com/logo.png"`,
+		"<dt>Contract</dt><dd>C-2026-001</dd>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("trace page missing %q", want)
+		}
+	}
+
+	resp = do("POST", "/api/jobs/"+jobID+"/export", "")
+	if resp.StatusCode() != 200 {
+		t.Fatalf("export: status %d: %s", resp.StatusCode(), resp.Body())
+	}
+	if result := proof.VerifyEvidenceZip(resp.Body()); !result.OK || result.Manifest.FileHashes["branding.json"] == "" {
+		t.Fatalf("package should verify and cover branding.json: %+v", result.Errors)
+	}
+	pkg, err := proof.ReadEvidenceZip(resp.Body())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packed branding.Branding
+	if err := json.Unmarshal(pkg.Branding, &packed); err != nil || packed.Title != "ACME <Audit>" {
+		t.Fatalf("branding.json = %s, err = %v", pkg.Branding, err)
+	}
+
+	if resp := do("DELETE", "/api/tenant/branding", ""); resp.StatusCode() != 200 {
+		t.Fatalf("delete: status %d", resp.StatusCode())
+	}
+	if page := string(do("GET", "/api/jobs/"+jobID+"/trace/page", "").Body()); strings.Contains(page, `id="trace-branding"`) {
+		t.Fatal("trace page still branded after delete")
+	}
+}
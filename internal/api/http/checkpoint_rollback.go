@@ -0,0 +1,175 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// ListJobCheckpoints 列出 Job 的节点检查点（按创建时间升序），current 标记 Job 游标当前指向的检查点（GET /api/jobs/:id/checkpoints）
+func (h *Handler) ListJobCheckpoints(ctx context.Context, c *app.RequestContext) {
+	lister, ok := h.checkpointStore.(agentruntime.JobCheckpointLister)
+	if !ok {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "checkpoint.store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	cps, err := lister.ListByJob(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListByJob: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "checkpoint.list_failed")})
+		return
+	}
+	items := make([]map[string]interface{}, 0, len(cps))
+	for _, cp := range cps {
+		items = append(items, map[string]interface{}{
+			"id":          cp.ID,
+			"cursor_node": cp.CursorNode,
+			"created_at":  cp.CreatedAt,
+			"current":     cp.ID == j.Cursor,
+		})
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":      jobID,
+		"cursor":      j.Cursor,
+		"checkpoints": items,
+	})
+}
+
+// RollbackJobCheckpointRequest POST /api/jobs/:id/checkpoints/:cp_id/rollback 请求体（可选）
+type RollbackJobCheckpointRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RollbackJobCheckpoint 将失败、等待或挂起中的 Job 游标重置到此前的检查点：追加 checkpoint_rolled_back 记录被作废的节点与步，
+// 游标指向该检查点并重新入队，之后完成的节点从检查点重新执行。检查点之后已有提交的副作用（成功的工具调用、未结束的工具调用、
+// state_changed、已消费的 signal）时重新执行会重复或丢失，返回 409 与 blockers（POST /api/jobs/:id/checkpoints/:cp_id/rollback）
+func (h *Handler) RollbackJobCheckpoint(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil || h.checkpointStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "checkpoint.store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	var req RollbackJobCheckpointRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+			return
+		}
+	}
+	switch j.Status {
+	case job.StatusFailed, job.StatusWaiting, job.StatusParked:
+	default:
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "checkpoint.rollback_status_invalid", j.Status.String())})
+		return
+	}
+	cp, err := h.checkpointStore.Load(ctx, c.Param("cp_id"))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Load checkpoint: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "checkpoint.load_failed")})
+		return
+	}
+	if cp == nil || cp.JobID != jobID {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "checkpoint.not_found")})
+		return
+	}
+	events, ver, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	plan, err := jobstore.PlanCheckpointRollback(events, cp.CursorNode)
+	if err != nil {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "checkpoint.rollback_not_reached", cp.CursorNode)})
+		return
+	}
+	if len(plan.Blockers) > 0 {
+		c.JSON(consts.StatusConflict, map[string]interface{}{
+			"error":             i18n.T(ctx, "checkpoint.rollback_blocked"),
+			"blockers":          plan.Blockers,
+			"invalidated_nodes": plan.InvalidatedNodes,
+		})
+		return
+	}
+	rolledBack := jobstore.CheckpointRolledBackPayload{
+		CheckpointID:     cp.ID,
+		CursorNode:       cp.CursorNode,
+		PayloadResults:   json.RawMessage(cp.PayloadResults),
+		InvalidatedNodes: plan.InvalidatedNodes,
+		InvalidatedSteps: plan.InvalidatedSteps,
+		Actor:            auth.GetUserID(ctx),
+		Reason:           req.Reason,
+		At:               time.Now().UTC(),
+	}
+	payload, errMarshal := marshalJSON(ctx, rolledBack, "checkpoint_rolled_back_payload")
+	if errMarshal != nil {
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.write_event_failed")})
+		return
+	}
+	if _, err := h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
+		JobID: jobID, Type: jobstore.CheckpointRolledBack, Payload: payload,
+	}); err != nil {
+		if errors.Is(err, jobstore.ErrVersionMismatch) {
+			c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "job.events_changed")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Append checkpoint_rolled_back: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.write_event_failed")})
+		return
+	}
+	if err := h.jobStore.UpdateCursor(ctx, jobID, cp.ID); err != nil {
+		hlog.CtxErrorf(ctx, "UpdateCursor: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "checkpoint.rollback_failed")})
+		return
+	}
+	if err := h.jobStore.UpdateStatus(job.WithTransitionReason(ctx, "checkpoint_rolled_back"), jobID, job.StatusPending); err != nil {
+		hlog.CtxErrorf(ctx, "UpdateStatus Pending: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "checkpoint.rollback_failed")})
+		return
+	}
+	if h.wakeupQueue != nil {
+		_ = h.wakeupQueue.NotifyReady(ctx, jobID)
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":            jobID,
+		"checkpoint_id":     cp.ID,
+		"cursor_node":       cp.CursorNode,
+		"invalidated_nodes": plan.InvalidatedNodes,
+		"invalidated_steps": plan.InvalidatedSteps,
+		"status":            "pending",
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func setupRollbackServer(t *testing.T, events ...jobstore.JobEvent) (job.JobStore, jobstore.JobStore, agentruntime.CheckpointStore, string, func(method, path string) (int, map[string]interface{})) {
+	t.Helper()
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	jobID, err := jobs.Create(ctx, &job.Job{AgentID: "agent-1", TenantID: "default", Goal: "write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventStore := jobstore.NewMemoryStore()
+	ver := 0
+	for _, e := range events {
+		e.JobID = jobID
+		if ver, err = eventStore.Append(ctx, jobID, ver, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cps := agentruntime.NewCheckpointStoreMem()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(eventStore)
+	handler.SetCheckpointStore(cps)
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/checkpoints", handler.ListJobCheckpoints)
+	s.POST("/api/jobs/:id/checkpoints/:cp_id/rollback", handler.RollbackJobCheckpoint)
+	do := func(method, path string) (int, map[string]interface{}) {
+		body := `{"reason":"bad draft"}`
+		w := ut.PerformRequest(s.Engine, method, path, &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+	return jobs, eventStore, cps, jobID, do
+}
+
+func nodeFinishedEvent(nodeID string) jobstore.JobEvent {
+	b, _ := json.Marshal(map[string]string{"node_id": nodeID, "step_id": "step-" + nodeID})
+	return jobstore.JobEvent{Type: jobstore.NodeFinished, Payload: b}
+}
+
+func failJob(t *testing.T, jobs job.JobStore, jobID string) {
+	t.Helper()
+	ctx := context.Background()
+	for _, s := range []job.JobStatus{job.StatusRunning, job.StatusFailed} {
+		if err := jobs.UpdateStatus(ctx, jobID, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRollbackJobCheckpoint_ResetsCursorAndRequeues(t *testing.T) {
+	ctx := context.Background()
+	jobs, events, cps, jobID, do := setupRollbackServer(t, nodeFinishedEvent("n1"), nodeFinishedEvent("n2"))
+	cp1, _ := cps.Save(ctx, agentruntime.NewNodeCheckpoint("agent-1", "", jobID, "n1", nil, []byte(`{"n1":"outline"}`), nil))
+	cp2, _ := cps.Save(ctx, agentruntime.NewNodeCheckpoint("agent-1", "", jobID, "n2", nil, nil, nil))
+	_ = jobs.UpdateCursor(ctx, jobID, cp2)
+
+	if code, out := do("POST", "/api/jobs/"+jobID+"/checkpoints/"+cp1+"/rollback"); code != 409 {
+		t.Fatalf("rollback of a pending job: %d %v", code, out)
+	}
+	failJob(t, jobs, jobID)
+	code, out := do("GET", "/api/jobs/"+jobID+"/checkpoints")
+	if list, _ := out["checkpoints"].([]interface{}); code != 200 || len(list) != 2 || out["cursor"] != cp2 {
+		t.Fatalf("list: %d %v", code, out)
+	}
+	code, out = do("POST", "/api/jobs/"+jobID+"/checkpoints/"+cp1+"/rollback")
+	if code != 200 {
+		t.Fatalf("rollback: %d %v", code, out)
+	}
+	if nodes, _ := out["invalidated_nodes"].([]interface{}); len(nodes) != 1 || nodes[0] != "n2" {
+		t.Fatalf("invalidated_nodes = %v", out["invalidated_nodes"])
+	}
+	j, _ := jobs.Get(ctx, jobID)
+	if j.Status != job.StatusPending || j.Cursor != cp1 {
+		t.Fatalf("job status = %s cursor = %s", j.Status, j.Cursor)
+	}
+	evs, _, _ := events.ListEvents(ctx, jobID)
+	last := evs[len(evs)-1]
+	var pl jobstore.CheckpointRolledBackPayload
+	if last.Type != jobstore.CheckpointRolledBack || json.Unmarshal(last.Payload, &pl) != nil {
+		t.Fatalf("last event = %s", last.Type)
+	}
+	if pl.CheckpointID != cp1 || pl.Reason != "bad draft" || len(pl.InvalidatedSteps) != 1 || pl.InvalidatedSteps[0] != "step-n2" {
+		t.Fatalf("payload = %+v", pl)
+	}
+}
+
+func TestRollbackJobCheckpoint_BlockedByCommittedToolCall(t *testing.T) {
+	ctx := context.Background()
+	started, _ := json.Marshal(map[string]string{"node_id": "n2", "tool_name": "email.send", "idempotency_key": "k2"})
+	finished, _ := json.Marshal(map[string]string{"node_id": "n2", "idempotency_key": "k2", "outcome": "success"})
+	jobs, _, cps, jobID, do := setupRollbackServer(t,
+		nodeFinishedEvent("n1"),
+		jobstore.JobEvent{Type: jobstore.ToolInvocationStarted, Payload: started},
+		jobstore.JobEvent{Type: jobstore.ToolInvocationFinished, Payload: finished},
+		nodeFinishedEvent("n2"),
+	)
+	cp1, _ := cps.Save(ctx, agentruntime.NewNodeCheckpoint("agent-1", "", jobID, "n1", nil, nil, nil))
+	other, _ := cps.Save(ctx, agentruntime.NewNodeCheckpoint("agent-1", "", "job-other", "n1", nil, nil, nil))
+	failJob(t, jobs, jobID)
+
+	if code, out := do("POST", "/api/jobs/"+jobID+"/checkpoints/"+other+"/rollback"); code != 404 {
+		t.Fatalf("checkpoint of another job: %d %v", code, out)
+	}
+	code, out := do("POST", "/api/jobs/"+jobID+"/checkpoints/"+cp1+"/rollback")
+	if code != 409 {
+		t.Fatalf("rollback across committed tool call: %d %v", code, out)
+	}
+	blockers, _ := out["blockers"].([]interface{})
+	if len(blockers) != 1 || blockers[0].(map[string]interface{})["tool_name"] != "email.send" {
+		t.Fatalf("blockers = %v", out["blockers"])
+	}
+	if j, _ := jobs.Get(ctx, jobID); j.Status != job.StatusFailed {
+		t.Fatalf("blocked rollback changed status to %s", j.Status)
+	}
+}
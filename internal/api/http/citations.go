@@ -0,0 +1,83 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/evidence"
+	"rag-platform/pkg/i18n"
+)
+
+// GetJobCitations 返回该 Job 中 RAG 回答引用的文档片段（按 step 分组，含 chunk id、相似度与原文偏移）
+// GET /api/jobs/:id/citations
+func (h *Handler) GetJobCitations(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "citation.get_failed")})
+		return
+	}
+	steps := evidence.CitationsFromEvents(evidenceEventsOf(events))
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":       jobID,
+		"steps":        steps,
+		"document_ids": citedDocumentIDs(steps),
+	})
+}
+
+// evidenceEventsOf 将事件流转换为 evidence 包的事件结构
+func evidenceEventsOf(events []jobstore.JobEvent) []evidence.Event {
+	out := make([]evidence.Event, 0, len(events))
+	for _, e := range events {
+		out = append(out, evidence.Event{
+			ID:        e.ID,
+			JobID:     e.JobID,
+			Type:      string(e.Type),
+			Payload:   append([]byte(nil), e.Payload...),
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return out
+}
+
+// citedDocumentIDs 被引用的文档 ID（去重，按首次出现顺序）
+func citedDocumentIDs(steps []evidence.StepCitations) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, s := range steps {
+		for _, cit := range s.Citations {
+			if cit.DocumentID == "" || seen[cit.DocumentID] {
+				continue
+			}
+			seen[cit.DocumentID] = true
+			out = append(out, cit.DocumentID)
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestAgentMessage_ClientTaskGraph(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	planned := false
+	handler.SetPlanAtJobCreation(func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+		planned = true
+		return &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "llm", Type: planner.NodeLLM}}}, nil
+	})
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/agents/:id/message", handler.AgentMessage)
+	post := func(body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, "POST", "/api/agents/"+a.ID+"/message", &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+
+	code, out := post(`{"message":"notify","task_graph":{"nodes":[{"id":"send","type":"tool","tool_name":"email.send","config":{"to":"a@example.com"}}],"edges":[]}}`)
+	if code != 202 {
+		t.Fatalf("submit: %d %v", code, out)
+	}
+	if planned {
+		t.Fatal("planner should not run for a client-supplied task graph")
+	}
+	evs, _, _ := events.ListEvents(ctx, out["job_id"].(string))
+	var plan struct {
+		TaskGraph  planner.TaskGraph `json:"task_graph"`
+		PlanSource string            `json:"plan_source"`
+	}
+	for _, e := range evs {
+		if e.Type == jobstore.PlanGenerated {
+			_ = json.Unmarshal(e.Payload, &plan)
+		}
+	}
+	if plan.PlanSource != "client" || len(plan.TaskGraph.Nodes) != 1 || plan.TaskGraph.Nodes[0].ToolName != "email.send" {
+		t.Fatalf("plan_generated = %+v", plan)
+	}
+
+	// 与服务端计划校验一致：有环的计划在创建 Job 前拒绝
+	code, out = post(`{"message":"loop","task_graph":{"nodes":[{"id":"a","type":"llm"},{"id":"b","type":"llm"}],"edges":[{"from":"a","to":"b"},{"from":"b","to":"a"}]}}`)
+	if code != 400 || !strings.Contains(out["error"].(string), "cycle") {
+		t.Fatalf("invalid graph: %d %v", code, out)
+	}
+}
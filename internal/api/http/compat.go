@@ -0,0 +1,73 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+
+	"rag-platform/internal/agent/compat"
+	"rag-platform/pkg/metrics"
+)
+
+// SetJobRequiredFeatures 设置所有新 Job 默认要求的特性（agent.compat.required_features），与请求的 required_features 合并后协商
+func (h *Handler) SetJobRequiredFeatures(features []string) {
+	h.jobRequiredFeatures = features
+}
+
+// unknownFeatures 返回本版本不识别的特性
+func unknownFeatures(features []string) []string {
+	var out []string
+	for _, f := range features {
+		if f = strings.TrimSpace(f); f != "" && !compat.KnownFeature(f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// negotiateJobFeatures 按在线 Worker 的版本握手协商新 Job 的 schema 与特性；无 Worker 状态存储或读取失败时按本地版本创建
+func (h *Handler) negotiateJobFeatures(ctx context.Context, requested []string) (compat.Decision, error) {
+	features := append(append([]string(nil), h.jobRequiredFeatures...), requested...)
+	var fleet []compat.Handshake
+	if h.workerStatus != nil {
+		statuses, err := h.workerStatus.List(ctx, time.Now().Add(-workerStatusTTL))
+		if err != nil {
+			hlog.CtxWarnf(ctx, "版本协商读取 Worker 状态failed，按本地版本创建 Job: %v", err)
+		}
+		for _, st := range statuses {
+			fleet = append(fleet, compat.Handshake{
+				WorkerID:           st.WorkerID,
+				Version:            st.Version,
+				EventSchemaVersion: st.EventSchemaVersion,
+				Features:           st.Features,
+			})
+		}
+	}
+	d, err := compat.Negotiate(fleet, features)
+	if err != nil {
+		return d, err
+	}
+	for _, f := range d.Degraded {
+		metrics.JobFeatureDegradedTotal.WithLabelValues(f).Inc()
+	}
+	if len(d.Degraded) > 0 {
+		hlog.CtxInfof(ctx, "版本协商：在线 Worker 不支持 %v，Job 降级执行", d.Degraded)
+	}
+	return d, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/compat"
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/agent/scheduler"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestAgentMessage_NegotiatesWithWorkerFleet(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	jobs := job.NewJobStoreMem()
+	statuses := scheduler.NewWorkerStatusStoreMem()
+	_ = statuses.Report(ctx, &scheduler.WorkerStatus{WorkerID: "old"}) // 升级前 Worker，无握手信息
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(jobstore.NewMemoryStore())
+	handler.SetWorkerStatusStore(statuses)
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/agents/:id/message", handler.AgentMessage)
+	do := func(body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, "POST", "/api/agents/"+a.ID+"/message", &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+
+	if code, out := do(`{"message":"hi","required_features":["teleport"]}`); code != 400 {
+		t.Fatalf("unknown feature: %d %v", code, out)
+	}
+	if code, out := do(`{"message":"hi","required_features":["recorded_http"]}`); code != 409 || out["code"] != "features_unavailable" {
+		t.Fatalf("unsupported feature: %d %v", code, out)
+	}
+	code, out := do(`{"message":"hi","required_features":["parallel_dag"]}`)
+	if code != 202 || len(out["degraded_features"].([]interface{})) != 1 {
+		t.Fatalf("degradable feature: %d %v", code, out)
+	}
+	j, _ := jobs.Get(ctx, out["job_id"].(string))
+	if features, negotiated := compat.JobFeatures(j.RequiredCapabilities); !negotiated || len(features) != 0 {
+		t.Fatalf("job caps = %v", j.RequiredCapabilities)
+	}
+
+	// 已升级 Worker 上线后同样的请求直接路由
+	hs := compat.Local("new", "v2")
+	_ = statuses.Report(ctx, &scheduler.WorkerStatus{WorkerID: hs.WorkerID, EventSchemaVersion: hs.EventSchemaVersion, Features: hs.Features})
+	if code, out = do(`{"message":"again","required_features":["recorded_http"]}`); code != 202 {
+		t.Fatalf("after upgrade: %d %v", code, out)
+	}
+}
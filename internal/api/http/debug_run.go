@@ -0,0 +1,78 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/replay/sandbox"
+	"rag-platform/pkg/i18n"
+)
+
+// DebugRunJobNode 时间旅行调试：加载该步录制的 state_before，在沙箱中执行修改后的工具入参或 prompt
+// （无副作用、HTTP 仅用录制、不写事件），返回假设结果与原始结果的 diff
+// POST /api/jobs/:id/nodes/:node_id/debug-run
+func (h *Handler) DebugRunJobNode(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
+		return
+	}
+	if h.debugRunner == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "debug.sandbox_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	nodeID := c.Param("node_id")
+	var req sandbox.DebugRunRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+			return
+		}
+	}
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	step, err := sandbox.LoadDebugStep(jobID, nodeID, events)
+	if err != nil {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	res, err := h.debugRunner.Run(ctx, step, req)
+	if err != nil {
+		if errors.Is(err, sandbox.ErrToolNotSandboxed) {
+			c.JSON(consts.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":       jobID,
+		"state_before": step.StateBefore,
+		"debug_run":    res,
+	})
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/debugsession"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// OpenDebugSessionRequest 开启调试会话：为 user_id 临时开放该 Job 的完整 payload；duration 默认 30m，最长 4h
+type OpenDebugSessionRequest struct {
+	UserID        string `json:"user_id"`
+	Justification string `json:"justification"`
+	Duration      string `json:"duration"`
+}
+
+// debugSessionView 会话及其当前是否有效
+type debugSessionView struct {
+	*debugsession.Session
+	Active bool `json:"active"`
+}
+
+type debugSessionCtxKey struct{}
+
+// debugSessionFrom 当前请求生效的调试会话（由 debugSessionContext 注入）；无则 nil
+func debugSessionFrom(ctx context.Context) *debugsession.Session {
+	s, _ := ctx.Value(debugSessionCtxKey{}).(*debugsession.Session)
+	return s
+}
+
+// debugSessionContext 受限查看者在该 Job 上有有效调试会话时，追加 accessed 审计记录并把会话注入 ctx（traceMaskFor 据此不遮蔽）；
+// 审计写入failed时不提权（fail closed）
+func (h *Handler) debugSessionContext(ctx context.Context, c *app.RequestContext, jobID string) context.Context {
+	if h.debugSessions == nil || auth.HasPermission(auth.GetRole(ctx), auth.PermissionTracePayloadView) {
+		return ctx
+	}
+	userID := auth.GetUserID(ctx)
+	if userID == "" {
+		return ctx
+	}
+	s, err := h.debugSessions.ActiveFor(ctx, requestTenantID(ctx), jobID, userID)
+	if err != nil || s == nil {
+		if err != nil {
+			hlog.CtxWarnf(ctx, "debug session lookup for job %s: %v", jobID, err)
+		}
+		return ctx
+	}
+	if err := h.debugSessions.RecordAccess(ctx, s, string(c.Method())+" "+string(c.Path())); err != nil {
+		hlog.CtxErrorf(ctx, "debug session %s: record access: %v", s.ID, err)
+		return ctx
+	}
+	return context.WithValue(ctx, debugSessionCtxKey{}, s)
+}
+
+// OpenDebugSession POST /api/jobs/:id/debug-sessions
+func (h *Handler) OpenDebugSession(ctx context.Context, c *app.RequestContext) {
+	if h.debugSessions == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "debug_session.disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	var req OpenDebugSessionRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+		return
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "debug_session.invalid", "duration must be a positive duration such as 30m")})
+			return
+		}
+		duration = d
+	}
+	s, err := debugsession.NewSession(requestTenantID(ctx), jobID, req.UserID, auth.GetUserID(ctx), req.Justification, duration, time.Now())
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "debug_session.invalid", err)})
+		return
+	}
+	if err := h.debugSessions.Open(ctx, s); err != nil {
+		hlog.CtxErrorf(ctx, "OpenDebugSession: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "debug_session.failed")})
+		return
+	}
+	hlog.CtxWarnf(ctx, "debug session %s opened on job %s for %s by %s until %s", s.ID, jobID, s.Grantee, s.OpenedBy, s.ExpiresAt.UTC().Format(time.RFC3339))
+	c.JSON(consts.StatusCreated, debugSessionView{Session: s, Active: true})
+}
+
+// ListDebugSessions GET /api/jobs/:id/debug-sessions
+func (h *Handler) ListDebugSessions(ctx context.Context, c *app.RequestContext) {
+	if h.debugSessions == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "debug_session.disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	list, err := h.debugSessions.List(ctx, requestTenantID(ctx), jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListDebugSessions: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "debug_session.failed")})
+		return
+	}
+	now := time.Now()
+	out := make([]debugSessionView, 0, len(list))
+	for _, s := range list {
+		out = append(out, debugSessionView{Session: s, Active: s.Active(now)})
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"job_id": jobID, "sessions": out, "audit": h.debugSessionAudit(ctx, jobID)})
+}
+
+// CloseDebugSession DELETE /api/jobs/:id/debug-sessions/:session_id：到期前提前关闭
+func (h *Handler) CloseDebugSession(ctx context.Context, c *app.RequestContext) {
+	if h.debugSessions == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "debug_session.disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	s, err := h.debugSessions.Close(ctx, requestTenantID(ctx), jobID, c.Param("session_id"), auth.GetUserID(ctx))
+	if errors.Is(err, debugsession.ErrNotFound) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "debug_session.not_found")})
+		return
+	}
+	if errors.Is(err, debugsession.ErrClosed) {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "debug_session.closed")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "CloseDebugSession: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "debug_session.failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, debugSessionView{Session: s, Active: false})
+}
+
+// debugSessionAudit 审计日志中的调试会话部分：该 Job 的记录，以及租户完整审计链的校验结果；未启用时返回 nil
+func (h *Handler) debugSessionAudit(ctx context.Context, jobID string) map[string]interface{} {
+	if h.debugSessions == nil {
+		return nil
+	}
+	chain, err := h.debugSessions.Audit(ctx, requestTenantID(ctx))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "debug session audit: %v", err)
+		return map[string]interface{}{"error": i18n.T(ctx, "debug_session.failed")}
+	}
+	entries := make([]*debugsession.AuditEntry, 0)
+	for _, e := range chain {
+		if e.JobID == jobID {
+			entries = append(entries, e)
+		}
+	}
+	out := map[string]interface{}{"entries": entries, "chain_length": len(chain), "chain_valid": true}
+	if err := debugsession.VerifyChain(chain); err != nil {
+		out["chain_valid"] = false
+		out["chain_error"] = err.Error()
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	hertzapp "github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"rag-platform/internal/agent/debugsession"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+)
+
+func TestDebugSession_GrantsTimeBoxedPayloadAccess(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	jobID, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g", TenantID: "default"})
+	e := narrativeEvent(t, jobstore.ToolReturned, time.Now(), "", map[string]interface{}{"node_id": "n1", "output": "customer record"})
+	e.JobID = jobID
+	if _, err := events.Append(ctx, jobID, 0, e); err != nil {
+		t.Fatal(err)
+	}
+	store := debugsession.NewStoreMem()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetDebugSessions(store)
+	withUser := func(ctx context.Context, c *hertzapp.RequestContext) {
+		ctx = auth.WithRole(ctx, auth.Role(c.Request.Header.Get("X-Role")))
+		c.Next(auth.WithUserID(ctx, c.Request.Header.Get("X-User")))
+	}
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/events", withUser, handler.GetJobEvents)
+	s.POST("/api/jobs/:id/debug-sessions", withUser, handler.OpenDebugSession)
+	s.GET("/api/jobs/:id/debug-sessions", withUser, handler.ListDebugSessions)
+	s.DELETE("/api/jobs/:id/debug-sessions/:session_id", withUser, handler.CloseDebugSession)
+
+	do := func(method, path, user, role, body string) *protocol.Response {
+		headers := []ut.Header{{Key: "X-User", Value: user}, {Key: "X-Role", Value: role}, {Key: "Content-Type", Value: "application/json"}}
+		var b *ut.Body
+		if body != "" {
+			b = &ut.Body{Body: strings.NewReader(body), Len: len(body)}
+		}
+		return ut.PerformRequest(s.Engine, method, path, b, headers...).Result()
+	}
+	viewerEvents := func(user string) string {
+		resp := do("GET", "/api/jobs/"+jobID+"/events", user, string(auth.RoleViewer), "")
+		if resp.StatusCode() != 200 {
+			t.Fatalf("events status = %d: %s", resp.StatusCode(), resp.Body())
+		}
+		return string(resp.Body())
+	}
+	base := "/api/jobs/" + jobID + "/debug-sessions"
+	admin := string(auth.RoleAdmin)
+
+	if strings.Contains(viewerEvents("alice"), "customer record") {
+		t.Fatal("viewer saw payload without a debug session")
+	}
+	if resp := do("POST", base, "root", admin, `{"user_id":"alice","justification":"x"}`); resp.StatusCode() != 400 {
+		t.Fatalf("short justification: status %d", resp.StatusCode())
+	}
+	if resp := do("POST", base, "root", admin, `{"user_id":"alice","justification":"ticket #4711: customer reports wrong refund","duration":"5h"}`); resp.StatusCode() != 400 {
+		t.Fatalf("duration above max: status %d", resp.StatusCode())
+	}
+	resp := do("POST", base, "root", admin, `{"user_id":"alice","justification":"ticket #4711: customer reports wrong refund","duration":"15m"}`)
+	if resp.StatusCode() != 201 {
+		t.Fatalf("open: status %d: %s", resp.StatusCode(), resp.Body())
+	}
+	var opened debugsession.Session
+	_ = json.Unmarshal(resp.Body(), &opened)
+	if opened.OpenedBy != "root" || opened.Grantee != "alice" || opened.ExpiresAt.Sub(opened.OpenedAt) != 15*time.Minute {
+		t.Fatalf("opened = %+v", opened)
+	}
+
+	if !strings.Contains(viewerEvents("alice"), "customer record") {
+		t.Fatal("grantee should see payloads during the session")
+	}
+	if strings.Contains(viewerEvents("bob"), "customer record") {
+		t.Fatal("session must not apply to other users")
+	}
+
+	if resp := do("DELETE", base+"/"+opened.ID, "root", admin, ""); resp.StatusCode() != 200 {
+		t.Fatalf("close: status %d", resp.StatusCode())
+	}
+	if resp := do("DELETE", base+"/"+opened.ID, "root", admin, ""); resp.StatusCode() != 409 {
+		t.Fatalf("second close: status %d", resp.StatusCode())
+	}
+	if strings.Contains(viewerEvents("alice"), "customer record") {
+		t.Fatal("closed session still grants access")
+	}
+
+	var list struct {
+		Sessions []struct {
+			ID     string `json:"id"`
+			Active bool   `json:"active"`
+		} `json:"sessions"`
+		Audit struct {
+			Entries    []debugsession.AuditEntry `json:"entries"`
+			ChainValid bool                      `json:"chain_valid"`
+		} `json:"audit"`
+	}
+	if err := json.Unmarshal(do("GET", base, "root", string(auth.RoleAuditor), "").Body(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sessions) != 1 || list.Sessions[0].Active || !list.Audit.ChainValid {
+		t.Fatalf("list = %+v", list)
+	}
+	var actions []string
+	for _, e := range list.Audit.Entries {
+		actions = append(actions, e.Action)
+	}
+	if got := strings.Join(actions, ","); got != "opened,accessed,closed" {
+		t.Fatalf("audit actions = %s", got)
+	}
+	if !strings.Contains(list.Audit.Entries[0].Detail, "ticket #4711") || list.Audit.Entries[1].Actor != "alice" {
+		t.Fatalf("audit entries = %+v", list.Audit.Entries)
+	}
+}
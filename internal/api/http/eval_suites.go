@@ -0,0 +1,239 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route/param"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/pkg/i18n"
+)
+
+// EvalSuiteRequest 创建/替换 Agent 回归集请求
+type EvalSuiteRequest struct {
+	Cases       []evalsuite.Case `json:"cases"`
+	CaseTimeout string           `json:"case_timeout"`
+}
+
+// StartEvalRunRequest 启动回归集运行的可选请求体
+type StartEvalRunRequest struct {
+	Label string `json:"label"`
+}
+
+// GetEvalSuite 获取 Agent 的回归集
+// GET /api/agents/:id/eval/suite
+func (h *Handler) GetEvalSuite(ctx context.Context, c *app.RequestContext) {
+	suite, ok := h.loadEvalSuite(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(consts.StatusOK, suite)
+}
+
+// PutEvalSuite 创建或整体替换 Agent 的回归集
+// PUT /api/agents/:id/eval/suite
+func (h *Handler) PutEvalSuite(ctx context.Context, c *app.RequestContext) {
+	if h.evalSuites == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "eval.disabled")})
+		return
+	}
+	var req EvalSuiteRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+		return
+	}
+	suite := &evalsuite.Suite{AgentID: c.Param("id"), Cases: req.Cases, CaseTimeout: req.CaseTimeout}
+	if err := suite.Validate(); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.evalSuites.PutSuite(ctx, suite); err != nil {
+		hlog.CtxErrorf(ctx, "Put eval suite: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "eval.save_failed")})
+		return
+	}
+	h.GetEvalSuite(ctx, c)
+}
+
+// StartEvalRun 异步运行 Agent 的回归集：逐个用例以目标创建 Job（与 POST /api/agents/:id/message 相同的受理路径），
+// 等待终态后按断言评估；返回 202 与运行中的报告，经 GET /api/agents/:id/eval/runs/:run_id 轮询进度。同一 Agent 同时只运行一次
+// POST /api/agents/:id/eval/runs
+func (h *Handler) StartEvalRun(ctx context.Context, c *app.RequestContext) {
+	if h.jobStore == nil || h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	suite, ok := h.loadEvalSuite(ctx, c)
+	if !ok {
+		return
+	}
+	var req StartEvalRunRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+			return
+		}
+	}
+	tenantID := requestTenantID(ctx)
+	runKey := tenantID + "\x00" + suite.AgentID
+	if _, running := h.evalRunning.LoadOrStore(runKey, struct{}{}); running {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "eval.run_in_progress")})
+		return
+	}
+	rep := evalsuite.NewReport(suite, tenantID, req.Label)
+	if err := h.evalSuites.SaveReport(ctx, rep); err != nil {
+		h.evalRunning.Delete(runKey)
+		hlog.CtxErrorf(ctx, "Save eval report: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "eval.save_failed")})
+		return
+	}
+	// 运行脱离请求生命周期，但保留认证上下文（租户、用户），用例 Job 与手工提交一样受租户配额、预算与 RBAC 约束
+	runCtx := context.WithoutCancel(ctx)
+	go func() {
+		defer h.evalRunning.Delete(runKey)
+		runner := evalsuite.NewRunner(&handlerEvalExecutor{h: h}, h.evalSuites)
+		done := runner.Run(runCtx, suite, rep)
+		hlog.CtxInfof(runCtx, "eval run %s for agent %s finished: %d/%d passed", done.ID, done.AgentID, done.Passed, done.Total)
+	}()
+	c.JSON(consts.StatusAccepted, rep)
+}
+
+// ListEvalRuns 列出当前租户下 Agent 回归集运行的历史报告（新到旧）
+// GET /api/agents/:id/eval/runs?limit=20
+func (h *Handler) ListEvalRuns(ctx context.Context, c *app.RequestContext) {
+	if h.evalSuites == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "eval.disabled")})
+		return
+	}
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+			return
+		}
+		limit = min(n, 200)
+	}
+	agentID := c.Param("id")
+	list, err := h.evalSuites.ListReports(ctx, requestTenantID(ctx), agentID, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List eval reports: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "eval.get_failed")})
+		return
+	}
+	if list == nil {
+		list = []*evalsuite.Report{}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"agent_id": agentID, "runs": list})
+}
+
+// GetEvalRun 获取单次运行报告（运行中时含已完成的用例）
+// GET /api/agents/:id/eval/runs/:run_id
+func (h *Handler) GetEvalRun(ctx context.Context, c *app.RequestContext) {
+	if h.evalSuites == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "eval.disabled")})
+		return
+	}
+	rep, err := h.evalSuites.GetReport(ctx, c.Param("run_id"))
+	if err != nil && !errors.Is(err, evalsuite.ErrNotFound) {
+		hlog.CtxErrorf(ctx, "Get eval report: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "eval.get_failed")})
+		return
+	}
+	if rep == nil || rep.AgentID != c.Param("id") || rep.TenantID != requestTenantID(ctx) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "eval.run_not_found")})
+		return
+	}
+	c.JSON(consts.StatusOK, rep)
+}
+
+func (h *Handler) loadEvalSuite(ctx context.Context, c *app.RequestContext) (*evalsuite.Suite, bool) {
+	if h.evalSuites == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "eval.disabled")})
+		return nil, false
+	}
+	suite, err := h.evalSuites.GetSuite(ctx, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, evalsuite.ErrNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "eval.suite_not_found")})
+			return nil, false
+		}
+		hlog.CtxErrorf(ctx, "Get eval suite: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "eval.get_failed")})
+		return nil, false
+	}
+	return suite, true
+}
+
+// handlerEvalExecutor 以进程内请求调用 AgentMessage 提交用例目标，复用背压、预算护栏、计划生成与事件写入；轮询 JobStore 等待终态
+type handlerEvalExecutor struct {
+	h *Handler
+}
+
+func (e *handlerEvalExecutor) Submit(ctx context.Context, agentID, goal string) (string, error) {
+	body, err := json.Marshal(AgentMessageRequest{Message: goal})
+	if err != nil {
+		return "", err
+	}
+	c := app.NewContext(0)
+	c.Params = append(c.Params, param.Param{Key: "id", Value: agentID})
+	c.Request.SetMethod(consts.MethodPost)
+	c.Request.Header.SetContentTypeBytes([]byte("application/json"))
+	c.Request.Header.Set("X-Aetheris-Client", "eval")
+	c.Request.SetBody(body)
+	e.h.AgentMessage(ctx, c)
+	var resp struct {
+		JobID string `json:"job_id"`
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(c.Response.Body(), &resp)
+	if c.Response.StatusCode() != consts.StatusAccepted || resp.JobID == "" {
+		return "", fmt.Errorf("message rejected (HTTP %d): %s", c.Response.StatusCode(), resp.Error)
+	}
+	return resp.JobID, nil
+}
+
+func (e *handlerEvalExecutor) Await(ctx context.Context, jobID string) (evalsuite.Outcome, error) {
+	ticker := time.NewTicker(jobWaitPollInterval)
+	defer ticker.Stop()
+	for {
+		j, err := e.h.jobStore.Get(ctx, jobID)
+		if err != nil {
+			return evalsuite.Outcome{}, err
+		}
+		if j != nil && j.Status.IsTerminal() {
+			events, _, err := e.h.jobEventStore.ListEvents(ctx, jobID)
+			if err != nil {
+				return evalsuite.Outcome{}, err
+			}
+			return evalsuite.OutcomeFromEvents(jobID, j.Status.String(), events, e.h.planCostModel), nil
+		}
+		select {
+		case <-ctx.Done():
+			return evalsuite.Outcome{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
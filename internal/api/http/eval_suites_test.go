@@ -0,0 +1,125 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestEvalSuite_RunThroughMessagePath(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetEvalSuites(evalsuite.NewStoreMem())
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/agents/:id/eval/suite", handler.GetEvalSuite)
+	s.PUT("/api/agents/:id/eval/suite", handler.PutEvalSuite)
+	s.POST("/api/agents/:id/eval/runs", handler.StartEvalRun)
+	s.GET("/api/agents/:id/eval/runs", handler.ListEvalRuns)
+	s.GET("/api/agents/:id/eval/runs/:run_id", handler.GetEvalRun)
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, method, path, &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+	base := "/api/agents/" + a.ID + "/eval"
+
+	if code, _ := do("POST", base+"/runs", ""); code != 404 {
+		t.Fatalf("run without suite: %d", code)
+	}
+	if code, _ := do("PUT", base+"/suite", `{"cases":[{"name":"x"}]}`); code != 400 {
+		t.Fatalf("case without goal should be rejected, got %d", code)
+	}
+	suite := `{"cases":[{"name":"refund","goal":"refund order 1","assert":{"must_call_tools":["refund.create"],"answer_contains":["refunded"]}}],"case_timeout":"5s"}`
+	if code, out := do("PUT", base+"/suite", suite); code != 200 || len(out["cases"].([]interface{})) != 1 {
+		t.Fatalf("put suite: %d %v", code, out)
+	}
+
+	// 模拟 Worker：认领用例 Job，写入工具调用与回答后完成
+	go func() {
+		for i := 0; i < 100; i++ {
+			list, _ := jobs.ListByAgent(ctx, a.ID, "")
+			for _, j := range list {
+				if j.Status != job.StatusPending {
+					continue
+				}
+				_, ver, _ := events.ListEvents(ctx, j.ID)
+				for _, e := range []struct {
+					typ jobstore.EventType
+					pl  string
+				}{
+					{jobstore.ToolInvocationStarted, `{"tool_name":"refund.create"}`},
+					{jobstore.NodeFinished, `{"node_id":"n1","payload_results":{"n1":"Order 1 refunded"}}`},
+					{jobstore.JobCompleted, `{}`},
+				} {
+					ver, _ = events.Append(ctx, j.ID, ver, jobstore.JobEvent{JobID: j.ID, Type: e.typ, Payload: []byte(e.pl)})
+				}
+				_ = jobs.UpdateStatus(ctx, j.ID, job.StatusRunning)
+				_ = jobs.UpdateStatus(ctx, j.ID, job.StatusCompleted)
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	code, out := do("POST", base+"/runs", `{"label":"prompt-v2"}`)
+	if code != 202 || out["status"] != evalsuite.ReportRunning {
+		t.Fatalf("start run: %d %v", code, out)
+	}
+	runID, _ := out["id"].(string)
+	if code, _ := do("POST", base+"/runs", ""); code != 409 {
+		t.Fatalf("concurrent run should conflict, got %d", code)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, out = do("GET", base+"/runs/"+runID, "")
+		if code != 200 {
+			t.Fatalf("get run: %d %v", code, out)
+		}
+		if out["status"] != evalsuite.ReportRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if out["status"] != evalsuite.ReportCompleted || out["passed"] != float64(1) || out["label"] != "prompt-v2" {
+		t.Fatalf("report = %v", out)
+	}
+	if code, out = do("GET", base+"/runs", ""); code != 200 || len(out["runs"].([]interface{})) != 1 {
+		t.Fatalf("history: %d %v", code, out)
+	}
+	if code, _ = do("GET", "/api/agents/other/eval/runs/"+runID, ""); code != 404 {
+		t.Fatalf("run of another agent: %d", code)
+	}
+}
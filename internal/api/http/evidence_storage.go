@@ -0,0 +1,134 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/i18n"
+)
+
+const defaultEvidenceURLExpiry = 15 * time.Minute
+
+// evidenceObjectKey 证据包对象键：<prefix><tenant>/<job_id>.zip
+func (h *Handler) evidenceObjectKey(tenantID, jobID string) string {
+	prefix := object.KeyPrefix(h.evidencePrefix, "evidence/")
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	return prefix + tenantID + "/" + jobID + ".zip"
+}
+
+// GetJobEvidence GET /api/jobs/:id/evidence：返回服务端存储的证据包预签名下载 URL。
+// 对象不存在、事件流已追加（event_version 变化）或 ?regenerate=true 时重新生成并上传，避免大包长时间同步下载
+func (h *Handler) GetJobEvidence(ctx context.Context, c *app.RequestContext) {
+	if h.evidenceStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "evidence.storage_disabled")})
+		return
+	}
+	presigner, ok := h.evidenceStore.(object.Presigner)
+	if !ok {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "evidence.presign_unsupported")})
+		return
+	}
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	regenerate := c.Query("regenerate") == "true"
+	_, ver, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	key := h.evidenceObjectKey(j.TenantID, jobID)
+	meta, metaErr := h.evidenceStore.GetMetadata(ctx, key)
+	if metaErr != nil || meta["event-version"] != strconv.Itoa(ver) {
+		regenerate = true
+	}
+	generated := false
+	if regenerate {
+		meta, err = h.storeEvidencePackage(ctx, key, jobID, ver)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "store evidence package for job %s: %v", jobID, err)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "evidence.generate_failed", err)})
+			return
+		}
+		generated = true
+	}
+	expiry := h.evidenceURLExpiry
+	if expiry <= 0 {
+		expiry = defaultEvidenceURLExpiry
+	}
+	url, err := presigner.PresignGet(ctx, key, expiry)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "PresignGet %s: %v", key, err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "evidence.presign_failed")})
+		return
+	}
+	size, _ := strconv.ParseInt(meta["size"], 10, 64)
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":        jobID,
+		"url":           url,
+		"expires_at":    time.Now().Add(expiry).UTC().Format(time.RFC3339),
+		"key":           key,
+		"size":          size,
+		"sha256":        meta["sha256"],
+		"event_version": ver,
+		"generated_at":  meta["generated-at"],
+		"regenerated":   generated,
+	})
+}
+
+// storeEvidencePackage 生成（按 PII 策略脱敏的）证据包并写入对象存储，返回写入的元数据
+func (h *Handler) storeEvidencePackage(ctx context.Context, key, jobID string, eventVersion int) (map[string]string, error) {
+	opts, err := h.exportRedactionOptions(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("build redaction policy: %w", err)
+	}
+	opts.Branding = h.brandingJSON(ctx, requestTenantID(ctx))
+	zipData, err := h.buildForensicsPackageWithOptions(ctx, jobID, opts)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(zipData)
+	meta := map[string]string{
+		"event-version": strconv.Itoa(eventVersion),
+		"generated-at":  time.Now().UTC().Format(time.RFC3339),
+		"sha256":        hex.EncodeToString(sum[:]),
+		"size":          strconv.Itoa(len(zipData)),
+	}
+	if err := h.evidenceStore.Put(ctx, key, bytes.NewReader(zipData), int64(len(zipData)), meta); err != nil {
+		return nil, fmt.Errorf("put %s: %w", key, err)
+	}
+	return meta, nil
+}
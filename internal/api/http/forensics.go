@@ -26,25 +26,76 @@ import (
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/runtime/piitag"
+	"rag-platform/pkg/i18n"
 	"rag-platform/pkg/proof"
 )
 
-// ExportJobForensics 导出 job 的完整证据包（ZIP 格式）
+// ExportJobForensicsRequest POST /api/jobs/:id/export 可选请求体：缩小长跑 Job 的证据包
+type ExportJobForensicsRequest struct {
+	// SinceSnapshot 以最新快照为基线，仅导出快照之后的事件；无快照时导出完整事件流
+	SinceSnapshot bool `json:"since_snapshot"`
+	// MaxPayloadBytes >0 时截断超出的事件 payload（保留原值 sha256）
+	MaxPayloadBytes int `json:"max_payload_bytes"`
+	// Include 选择性导出：tool_invocations / failed_steps，空为全部
+	Include string `json:"include"`
+}
+
+// ExportJobForensics 导出 job 的证据包（ZIP 格式）；请求体可选择快照基线、payload 截断与选择性导出
 // POST /api/jobs/:id/export
 func (h *Handler) ExportJobForensics(c context.Context, ctx *app.RequestContext) {
 	jobID := ctx.Param("id")
 	if jobID == "" {
 		ctx.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "job_id is required",
+			"error": i18n.T(c, "request.job_id_required"),
 		})
 		return
 	}
+	var req ExportJobForensicsRequest
+	if len(ctx.Request.Body()) > 0 {
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "request.invalid")})
+			return
+		}
+	}
+	if err := validateExportRequest(req); err != nil {
+		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "forensics.export_invalid_options", err)})
+		return
+	}
 
-	zipData, err := h.buildForensicsPackage(c, jobID)
+	opts, err := h.exportRedactionOptions(c, jobID)
+	if err != nil {
+		hlog.CtxErrorf(c, "failed to build redaction policy for job %s: %v", jobID, err)
+		ctx.JSON(consts.StatusInternalServerError, map[string]string{
+			"error": i18n.T(c, "forensics.redaction_policy_failed", err),
+		})
+		return
+	}
+	opts.MaxPayloadBytes = req.MaxPayloadBytes
+	opts.Include = req.Include
+	opts.Branding = h.brandingJSON(c, requestTenantID(c))
+	if req.SinceSnapshot && h.jobEventStore != nil {
+		if opts.RedactionEnabled {
+			ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "forensics.snapshot_redaction_unsupported")})
+			return
+		}
+		snap, err := h.jobEventStore.GetLatestSnapshot(c, jobID)
+		if err != nil {
+			hlog.CtxErrorf(c, "failed to load snapshot for job %s: %v", jobID, err)
+			ctx.JSON(consts.StatusInternalServerError, map[string]string{
+				"error": i18n.T(c, "forensics.package_failed", err),
+			})
+			return
+		}
+		if snap != nil {
+			opts.BaseSnapshot = &proof.SnapshotBase{Version: snap.Version, Data: snap.Snapshot, CreatedAt: snap.CreatedAt}
+		}
+	}
+	zipData, err := h.buildForensicsPackageWithOptions(c, jobID, opts)
 	if err != nil {
 		hlog.CtxErrorf(c, "failed to build forensics package for job %s: %v", jobID, err)
 		ctx.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("failed to build forensics package: %v", err),
+			"error": i18n.T(c, "forensics.package_failed", err),
 		})
 		return
 	}
@@ -55,24 +106,58 @@ func (h *Handler) ExportJobForensics(c context.Context, ctx *app.RequestContext)
 	ctx.Data(consts.StatusOK, "application/zip", zipData)
 }
 
-// buildForensicsPackage 构建与 proof.VerifyEvidenceZip 兼容的证据包
+// validateExportRequest 校验导出选项
+func validateExportRequest(req ExportJobForensicsRequest) error {
+	if req.MaxPayloadBytes < 0 {
+		return fmt.Errorf("max_payload_bytes must be >= 0")
+	}
+	switch req.Include {
+	case "", proof.IncludeToolInvocations, proof.IncludeFailedSteps:
+		return nil
+	default:
+		return fmt.Errorf("include must be %s or %s", proof.IncludeToolInvocations, proof.IncludeFailedSteps)
+	}
+}
+
+// buildForensicsPackage 构建与 proof.VerifyEvidenceZip 兼容的证据包（不脱敏，供一致性检查等内部使用）
 func (h *Handler) buildForensicsPackage(ctx context.Context, jobID string) ([]byte, error) {
+	return h.buildForensicsPackageWithOptions(ctx, jobID, proof.ExportOptions{})
+}
+
+// exportRedactionOptions 启用 PII 导出脱敏时，由该 Job 的 PII 标签生成脱敏策略；无标签或未启用时返回零值（不脱敏）。
+// 标签读取失败时返回 error，避免在应脱敏时导出原文
+func (h *Handler) exportRedactionOptions(ctx context.Context, jobID string) (proof.ExportOptions, error) {
+	if h.piiTags == nil || h.piiRedactMode == "" {
+		return proof.ExportOptions{}, nil
+	}
+	tags, err := h.piiTags.ListByJob(ctx, jobID)
+	if err != nil {
+		return proof.ExportOptions{}, err
+	}
+	policy := piitag.RedactionPolicy(tags, h.piiRedactMode, h.piiRedactSalt)
+	if policy == nil {
+		return proof.ExportOptions{}, nil
+	}
+	return proof.ExportOptions{RedactionEnabled: true, RedactionPolicy: policy, RedactionSalt: h.piiRedactSalt}, nil
+}
+
+// buildForensicsPackageWithOptions 按 opts（脱敏等）构建证据包；版本字段固定为当前 runtime
+func (h *Handler) buildForensicsPackageWithOptions(ctx context.Context, jobID string, opts proof.ExportOptions) ([]byte, error) {
 	if h.jobEventStore == nil {
 		return nil, fmt.Errorf("job event store is not configured")
 	}
 
 	jobAdapter := &proofJobStoreAdapter{store: h.jobEventStore}
 	ledgerAdapter := &proofLedgerAdapter{store: h.jobEventStore}
+	opts.RuntimeVersion = "2.0.0"
+	opts.SchemaVersion = "2.0"
 
 	return proof.ExportEvidenceZip(
 		ctx,
 		jobID,
 		jobAdapter,
 		ledgerAdapter,
-		proof.ExportOptions{
-			RuntimeVersion: "2.0.0",
-			SchemaVersion:  "2.0",
-		},
+		opts,
 	)
 }
 
@@ -34,6 +34,7 @@ import (
 	"rag-platform/pkg/auth"
 	"rag-platform/pkg/evidence"
 	"rag-platform/pkg/forensics"
+	"rag-platform/pkg/i18n"
 	"rag-platform/pkg/proof"
 )
 
@@ -61,13 +62,13 @@ func (h *Handler) ForensicsQuery(c context.Context, ctx *app.RequestContext) {
 	var req forensics.QueryRequest
 	if err := ctx.BindJSON(&req); err != nil {
 		ctx.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "invalid request",
+			"error": i18n.T(c, "request.invalid"),
 		})
 		return
 	}
 	if h.jobStore == nil || h.jobEventStore == nil {
 		ctx.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "forensics query requires job and event stores",
+			"error": i18n.T(c, "forensics.query_requires_stores"),
 		})
 		return
 	}
@@ -82,7 +83,7 @@ func (h *Handler) ForensicsQuery(c context.Context, ctx *app.RequestContext) {
 
 	if len(req.AgentFilter) == 0 {
 		ctx.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "agent_filter is required in current implementation",
+			"error": i18n.T(c, "forensics.agent_filter_required"),
 		})
 		return
 	}
@@ -103,13 +104,18 @@ func (h *Handler) ForensicsQuery(c context.Context, ctx *app.RequestContext) {
 	for _, s := range req.StatusFilter {
 		statusFilter[strings.ToLower(strings.TrimSpace(s))] = struct{}{}
 	}
+	userFilter := make(map[string]struct{}, len(req.UserFilter))
+	for _, u := range req.UserFilter {
+		userFilter[strings.TrimSpace(u)] = struct{}{}
+	}
+	requestID := strings.TrimSpace(req.RequestID)
 
 	jobMap := make(map[string]*job.Job)
 	for _, agentID := range req.AgentFilter {
 		jobs, err := h.jobStore.ListByAgent(c, strings.TrimSpace(agentID), tenantID)
 		if err != nil {
 			ctx.JSON(consts.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("list jobs failed for agent %s: %v", agentID, err),
+				"error": i18n.T(c, "forensics.list_agent_jobs_failed", agentID, err),
 			})
 			return
 		}
@@ -133,11 +139,23 @@ func (h *Handler) ForensicsQuery(c context.Context, ctx *app.RequestContext) {
 				continue
 			}
 		}
+		var attribution jobstore.Attribution
+		if j.Attribution != nil {
+			attribution = *j.Attribution
+		}
+		if len(userFilter) > 0 {
+			if _, ok := userFilter[attribution.UserID]; !ok {
+				continue
+			}
+		}
+		if requestID != "" && attribution.RequestID != requestID {
+			continue
+		}
 
 		events, _, err := h.jobEventStore.ListEvents(c, j.ID)
 		if err != nil {
 			ctx.JSON(consts.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("list events failed for job %s: %v", j.ID, err),
+				"error": i18n.T(c, "forensics.list_job_events_failed", j.ID, err),
 			})
 			return
 		}
@@ -160,6 +178,9 @@ func (h *Handler) ForensicsQuery(c context.Context, ctx *app.RequestContext) {
 			EventCount: len(events),
 			ToolCalls:  toolCalls,
 			KeyEvents:  keyEvents,
+			UserID:     attribution.UserID,
+			Client:     attribution.Client,
+			RequestID:  attribution.RequestID,
 		})
 	}
 
@@ -192,19 +213,19 @@ func (h *Handler) ForensicsBatchExport(c context.Context, ctx *app.RequestContex
 	}
 	if err := ctx.BindJSON(&req); err != nil {
 		ctx.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "invalid request",
+			"error": i18n.T(c, "request.invalid"),
 		})
 		return
 	}
 	if len(req.JobIDs) == 0 {
 		ctx.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "job_ids is required",
+			"error": i18n.T(c, "request.job_ids_required"),
 		})
 		return
 	}
 	if h.jobEventStore == nil {
 		ctx.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "forensics export requires job event store",
+			"error": i18n.T(c, "forensics.export_requires_event_store"),
 		})
 		return
 	}
@@ -221,6 +242,8 @@ func (h *Handler) ForensicsBatchExport(c context.Context, ctx *app.RequestContex
 	}
 	setForensicsTask(task)
 
+	// 导出在 202 返回后继续：脱离请求的取消与截止时间，保留认证上下文（租户、用户）
+	runCtx := context.WithoutCancel(c)
 	go func(jobIDs []string, id string) {
 		var err error
 		defer func() {
@@ -239,7 +262,7 @@ func (h *Handler) ForensicsBatchExport(c context.Context, ctx *app.RequestContex
 			setForensicsTask(t)
 		}()
 
-		if _, err = h.buildBatchForensicsPackage(c, jobIDs); err != nil {
+		if _, err = h.buildBatchForensicsPackage(runCtx, jobIDs); err != nil {
 			return
 		}
 	}(append([]string(nil), req.JobIDs...), taskID)
@@ -256,12 +279,12 @@ func (h *Handler) ForensicsBatchExport(c context.Context, ctx *app.RequestContex
 func (h *Handler) ForensicsExportStatus(c context.Context, ctx *app.RequestContext) {
 	taskID := strings.TrimSpace(ctx.Param("task_id"))
 	if taskID == "" {
-		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": "task_id is required"})
+		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "request.task_id_required")})
 		return
 	}
 	task, ok := getForensicsTask(taskID)
 	if !ok {
-		ctx.JSON(consts.StatusNotFound, map[string]string{"error": "task not found"})
+		ctx.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(c, "ingest.task_not_found")})
 		return
 	}
 	ctx.JSON(consts.StatusOK, task)
@@ -272,7 +295,7 @@ func (h *Handler) ForensicsExportStatus(c context.Context, ctx *app.RequestConte
 func (h *Handler) ForensicsConsistencyCheck(c context.Context, ctx *app.RequestContext) {
 	jobID := strings.TrimSpace(ctx.Param("job_id"))
 	if jobID == "" {
-		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": "job_id is required"})
+		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "request.job_id_required")})
 		return
 	}
 	if h.jobStore != nil {
@@ -284,7 +307,7 @@ func (h *Handler) ForensicsConsistencyCheck(c context.Context, ctx *app.RequestC
 	zipBytes, err := h.buildForensicsPackage(c, jobID)
 	if err != nil {
 		ctx.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("build forensics package failed: %v", err),
+			"error": i18n.T(c, "forensics.package_failed", err),
 		})
 		return
 	}
@@ -308,11 +331,11 @@ func (h *Handler) ForensicsConsistencyCheck(c context.Context, ctx *app.RequestC
 func (h *Handler) GetJobEvidenceGraph(c context.Context, ctx *app.RequestContext) {
 	jobID := strings.TrimSpace(ctx.Param("id"))
 	if jobID == "" {
-		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": "job_id is required"})
+		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "request.job_id_required")})
 		return
 	}
 	if h.jobEventStore == nil {
-		ctx.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "job event store is not configured"})
+		ctx.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(c, "job.event_store_not_configured")})
 		return
 	}
 	if h.jobStore != nil {
@@ -324,25 +347,15 @@ func (h *Handler) GetJobEvidenceGraph(c context.Context, ctx *app.RequestContext
 	events, _, err := h.jobEventStore.ListEvents(c, jobID)
 	if err != nil {
 		ctx.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("list events failed: %v", err),
+			"error": i18n.T(c, "job.list_events_failed_detail", err),
 		})
 		return
 	}
 
-	ev := make([]evidence.Event, 0, len(events))
-	for _, e := range events {
-		ev = append(ev, evidence.Event{
-			ID:        e.ID,
-			JobID:     e.JobID,
-			Type:      string(e.Type),
-			Payload:   append([]byte(nil), e.Payload...),
-			CreatedAt: e.CreatedAt,
-		})
-	}
-	graph, err := evidence.NewBuilder().BuildFromEvents(ev)
+	graph, err := evidence.NewBuilder().BuildFromEvents(evidenceEventsOf(events))
 	if err != nil {
 		ctx.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("build evidence graph failed: %v", err),
+			"error": i18n.T(c, "forensics.evidence_graph_failed", err),
 		})
 		return
 	}
@@ -355,23 +368,26 @@ func (h *Handler) GetJobEvidenceGraph(c context.Context, ctx *app.RequestContext
 func (h *Handler) GetJobAuditLog(c context.Context, ctx *app.RequestContext) {
 	jobID := strings.TrimSpace(ctx.Param("id"))
 	if jobID == "" {
-		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": "job_id is required"})
+		ctx.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(c, "request.job_id_required")})
 		return
 	}
 	if h.jobEventStore == nil {
-		ctx.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "job event store is not configured"})
+		ctx.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(c, "job.event_store_not_configured")})
 		return
 	}
+	var attribution *jobstore.Attribution
 	if h.jobStore != nil {
-		if _, ok := h.getJobAndCheckTenant(c, ctx, jobID); !ok {
+		j, ok := h.getJobAndCheckTenant(c, ctx, jobID)
+		if !ok {
 			return
 		}
+		attribution = j.Attribution
 	}
 
 	events, _, err := h.jobEventStore.ListEvents(c, jobID)
 	if err != nil {
 		ctx.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": fmt.Sprintf("list events failed: %v", err),
+			"error": i18n.T(c, "job.list_events_failed_detail", err),
 		})
 		return
 	}
@@ -387,6 +403,9 @@ func (h *Handler) GetJobAuditLog(c context.Context, ctx *app.RequestContext) {
 			"created_at": e.CreatedAt,
 			"type":       e.Type,
 		}
+		if e.Attribution != nil {
+			entry["attribution"] = e.Attribution
+		}
 		if len(e.Payload) > 0 {
 			var payload map[string]interface{}
 			if err := json.Unmarshal(e.Payload, &payload); err == nil {
@@ -398,11 +417,16 @@ func (h *Handler) GetJobAuditLog(c context.Context, ctx *app.RequestContext) {
 		auditLogs = append(auditLogs, entry)
 	}
 
-	ctx.JSON(consts.StatusOK, map[string]interface{}{
-		"job_id": jobID,
-		"count":  len(auditLogs),
-		"items":  auditLogs,
-	})
+	resp := map[string]interface{}{
+		"job_id":      jobID,
+		"attribution": attribution,
+		"count":       len(auditLogs),
+		"items":       auditLogs,
+	}
+	if dbg := h.debugSessionAudit(c, jobID); dbg != nil {
+		resp["debug_sessions"] = dbg
+	}
+	ctx.JSON(consts.StatusOK, resp)
 }
 
 func (h *Handler) buildBatchForensicsPackage(ctx context.Context, jobIDs []string) ([]byte, error) {
@@ -462,10 +486,17 @@ func extractToolCallsFromJobEvents(events []jobstore.JobEvent) []string {
 
 func extractKeyEventsFromJobEvents(events []jobstore.JobEvent) []string {
 	keyEventTypes := map[jobstore.EventType]struct{}{
-		jobstore.CriticalDecisionMade: {},
-		jobstore.HumanApprovalGiven:   {},
-		jobstore.PaymentExecuted:      {},
-		jobstore.EmailSent:            {},
+		jobstore.CriticalDecisionMade:  {},
+		jobstore.HumanApprovalGiven:    {},
+		jobstore.PaymentExecuted:       {},
+		jobstore.EmailSent:             {},
+		jobstore.LLMOutputReviewed:     {},
+		jobstore.AgentAnomalyDetected:  {},
+		jobstore.JobDiagnosis:          {},
+		jobstore.ReplayReexecuted:      {},
+		jobstore.CustomEvent:           {},
+		jobstore.TransactionRolledBack: {},
+		jobstore.ToolOutputSpilled:     {},
 	}
 	eventSet := make(map[string]struct{})
 	for _, event := range events {
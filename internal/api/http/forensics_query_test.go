@@ -11,6 +11,7 @@ import (
 	"github.com/cloudwego/hertz/pkg/common/ut"
 
 	"rag-platform/internal/agent/job"
+	"rag-platform/internal/api/http/middleware"
 	"rag-platform/internal/runtime/jobstore"
 )
 
@@ -174,3 +175,51 @@ func TestForensicsBatchExport_StatusFlow(t *testing.T) {
 		time.Sleep(20 * time.Millisecond)
 	}
 }
+
+// ctxCheckingStore 读取事件前稍作等待并检查 ctx，模拟在请求结束后才访问存储的后台导出
+type ctxCheckingStore struct {
+	jobstore.JobStore
+}
+
+func (s ctxCheckingStore) ListEvents(ctx context.Context, jobID string) ([]jobstore.JobEvent, int, error) {
+	time.Sleep(50 * time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	return s.JobStore.ListEvents(ctx, jobID)
+}
+
+// 请求截止时间中间件在 handler 返回时取消 ctx；后台批量导出不得随之取消
+func TestForensicsBatchExport_OutlivesRequestTimeout(t *testing.T) {
+	h, jobID := buildForensicsTestHandler(t)
+	h.SetJobEventStore(ctxCheckingStore{h.jobEventStore})
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(middleware.NewRequestTimeout(middleware.RequestTimeoutConfig{Default: time.Second}).Handler())
+	s.POST("/api/forensics/batch-export", h.ForensicsBatchExport)
+
+	body := []byte(`{"job_ids":["` + jobID + `"]}`)
+	w := ut.PerformRequest(s.Engine, "POST", "/api/forensics/batch-export", &ut.Body{Body: bytes.NewReader(body), Len: len(body)})
+	if got := w.Result().StatusCode(); got != 202 {
+		t.Fatalf("status = %d, want 202", got)
+	}
+	var resp struct {
+		TaskID string `json:"task_id"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		task, _ := getForensicsTask(resp.TaskID)
+		if task.Status == "completed" {
+			return
+		}
+		if task.Status == "failed" {
+			t.Fatalf("batch export cancelled with the request: %s", task.Error)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch export did not finish, status %q", task.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
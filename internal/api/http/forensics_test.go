@@ -3,10 +3,19 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
 	"rag-platform/pkg/proof"
 )
 
@@ -119,3 +128,131 @@ func TestNormalizeProofEventHashes_RebuildsInvalidChain(t *testing.T) {
 		t.Fatalf("second event prev_hash should point to first event hash")
 	}
 }
+
+// presigningMemoryStore 内存对象存储 + 伪预签名，供证据包存储测试
+type presigningMemoryStore struct {
+	*object.MemoryStore
+	puts int
+}
+
+func (s *presigningMemoryStore) Put(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error {
+	s.puts++
+	return s.MemoryStore.Put(ctx, path, data, size, metadata)
+}
+
+func (s *presigningMemoryStore) PresignGet(ctx context.Context, path string, expires time.Duration) (string, error) {
+	return "https://objects.example/" + path + "?expires=" + expires.String(), nil
+}
+
+func TestGetJobEvidence_StoresAndReusesPackage(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job_evidence_store"
+	meta := job.NewJobStoreMem()
+	if _, err := meta.Create(ctx, &job.Job{ID: jobID, AgentID: "a1"}); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	events := jobstore.NewMemoryStore()
+	ver, _ := events.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCreated})
+	store := &presigningMemoryStore{MemoryStore: object.NewMemoryStore()}
+	h := NewHandler(nil, nil)
+	h.SetJobStore(meta)
+	h.SetJobEventStore(events)
+	h.SetEvidenceStorage(store, "evidence/", 10*time.Minute)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/evidence", h.GetJobEvidence)
+
+	get := func(query string) map[string]interface{} {
+		t.Helper()
+		w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/evidence"+query, nil)
+		if got := w.Result().StatusCode(); got != 200 {
+			t.Fatalf("GET evidence%s status = %d, body %s", query, got, w.Result().Body())
+		}
+		var body map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &body)
+		return body
+	}
+
+	first := get("")
+	if first["regenerated"] != true || first["key"] != "evidence/default/"+jobID+".zip" || store.puts != 1 {
+		t.Fatalf("first request should generate package: %v puts=%d", first, store.puts)
+	}
+	if first["url"] != "https://objects.example/evidence/default/"+jobID+".zip?expires=10m0s" {
+		t.Fatalf("unexpected url: %v", first["url"])
+	}
+	rc, err := store.Get(ctx, "evidence/default/"+jobID+".zip")
+	if err != nil {
+		t.Fatalf("stored package: %v", err)
+	}
+	zipBytes, _ := io.ReadAll(rc)
+	rc.Close()
+	if res := proof.VerifyEvidenceZip(zipBytes); !res.OK {
+		t.Fatalf("stored package not verifiable: %v", res.Errors)
+	}
+
+	if again := get(""); again["regenerated"] != false || store.puts != 1 {
+		t.Fatalf("unchanged job should reuse stored package: %v puts=%d", again, store.puts)
+	}
+	_, _ = events.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCompleted})
+	if stale := get(""); stale["regenerated"] != true || store.puts != 2 {
+		t.Fatalf("new events should regenerate package: %v puts=%d", stale, store.puts)
+	}
+	if forced := get("?regenerate=true"); forced["regenerated"] != true || store.puts != 3 {
+		t.Fatalf("regenerate=true should rebuild: %v puts=%d", forced, store.puts)
+	}
+}
+
+func TestGetJobEvidence_Disabled(t *testing.T) {
+	h := NewHandler(nil, nil)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/evidence", h.GetJobEvidence)
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/j1/evidence", nil)
+	if got := w.Result().StatusCode(); got != 503 {
+		t.Fatalf("status = %d, want 503", got)
+	}
+}
+
+func TestExportJobForensics_Options(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job_forensics_options"
+	store := jobstore.NewMemoryStore()
+	ver := 0
+	for _, ev := range []jobstore.JobEvent{
+		{JobID: jobID, Type: jobstore.JobCreated},
+		{JobID: jobID, Type: jobstore.NodeStarted, Payload: []byte(`{"node_id":"n1"}`)},
+		{JobID: jobID, Type: jobstore.ToolInvocationStarted, Payload: []byte(`{"idempotency_key":"key-1","tool_name":"t"}`)},
+		{JobID: jobID, Type: jobstore.ToolInvocationFinished, Payload: []byte(`{"idempotency_key":"key-1","tool_name":"t","outcome":"success"}`)},
+		{JobID: jobID, Type: jobstore.JobCompleted},
+	} {
+		newVer, err := store.Append(ctx, jobID, ver, ev)
+		if err != nil {
+			t.Fatalf("append %s: %v", ev.Type, err)
+		}
+		ver = newVer
+	}
+	handler := NewHandler(nil, nil)
+	handler.SetJobEventStore(store)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/jobs/:id/export", handler.ExportJobForensics)
+
+	post := func(body string) *protocol.Response {
+		return ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/export",
+			&ut.Body{Body: strings.NewReader(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+	}
+
+	if resp := post(`{"include":"everything"}`); resp.StatusCode() != consts.StatusBadRequest {
+		t.Fatalf("unknown include: status %d, want 400", resp.StatusCode())
+	}
+	resp := post(`{"include":"tool_invocations","since_snapshot":true}`)
+	if resp.StatusCode() != consts.StatusOK {
+		t.Fatalf("status %d, body=%s", resp.StatusCode(), resp.Body())
+	}
+	result := proof.VerifyEvidenceZip(resp.Body())
+	if !result.OK {
+		t.Fatalf("selective package should verify, errors: %v", result.Errors)
+	}
+	// 内存存储无快照：回退为完整事件流上的选择性导出
+	if result.Manifest.BaseSnapshot != nil || result.Manifest.Include != proof.IncludeToolInvocations || len(result.Events) != 4 {
+		t.Fatalf("manifest = %+v, events = %d", result.Manifest, len(result.Events))
+	}
+}
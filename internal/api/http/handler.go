@@ -25,8 +25,11 @@ import (
 	"fmt"
 	"html"
 	"io"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudwego/eino/adk"
@@ -37,23 +40,55 @@ import (
 	"github.com/prometheus/common/expfmt"
 
 	"rag-platform/internal/agent"
+	"rag-platform/internal/agent/analytics"
+	"rag-platform/internal/agent/anomaly"
+	"rag-platform/internal/agent/branding"
+	"rag-platform/internal/agent/compat"
+	"rag-platform/internal/agent/debugsession"
+	"rag-platform/internal/agent/diagnosis"
+	"rag-platform/internal/agent/environment"
+	"rag-platform/internal/agent/eta"
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/goaltemplate"
+	"rag-platform/internal/agent/inbound"
 	"rag-platform/internal/agent/instance"
 	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/killswitch"
 	"rag-platform/internal/agent/messaging"
 	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/reconcile"
 	"rag-platform/internal/agent/replay"
+	"rag-platform/internal/agent/replay/sandbox"
 	agentruntime "rag-platform/internal/agent/runtime"
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/agent/scheduler"
 	"rag-platform/internal/agent/signal"
 	"rag-platform/internal/agent/tools"
+	"rag-platform/internal/agent/tracefilter"
 	"rag-platform/internal/agent/verify"
+	"rag-platform/internal/agent/workspace"
+	"rag-platform/internal/api/http/middleware"
 	appcore "rag-platform/internal/app"
 	"rag-platform/internal/model/llm"
 	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
+	"rag-platform/internal/runtime/agentconfig"
 	"rag-platform/internal/runtime/eino"
+	"rag-platform/internal/runtime/eventarchive"
+	"rag-platform/internal/runtime/eventsearch"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/runtime/piitag"
+	"rag-platform/internal/runtime/serviceaccount"
 	"rag-platform/internal/runtime/session"
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/object"
+	"rag-platform/internal/storage/residency"
+	"rag-platform/internal/storage/vector"
 	"rag-platform/pkg/auth"
+	"rag-platform/pkg/evidence"
+	"rag-platform/pkg/i18n"
 	"rag-platform/pkg/metrics"
+	"rag-platform/pkg/redaction"
 )
 
 // AgentRunner 可选的 Agent 入口（供 POST /api/agent/run 使用）；优先使用 RunWithSession
@@ -109,6 +144,104 @@ type Handler struct {
 	signalInbox signal.SignalInbox
 	// observabilityReader 可选；非 nil 时提供 GET /api/observability/summary（队列积压、卡住 Job）
 	observabilityReader job.ObservabilityReader
+	// piiTags 可选；非 nil 时提供 GET /api/jobs/:id/pii，piiRedactMode 非空时证据导出按标签字段脱敏
+	piiTags       piitag.TagStore
+	piiRedactMode redaction.RedactionMode
+	piiRedactSalt string
+	// maintenanceStore/maintenanceGate 可选；非 nil 时提供 /api/maintenance/windows，窗口内新建 Job 置为 Deferred
+	maintenanceStore job.MaintenanceStore
+	maintenanceGate  *job.MaintenanceGate
+	childJobStore    job.ChildJobStore
+	// checkpointStore 可选；非 nil 时提供 /api/jobs/:id/checkpoints 与手动回滚
+	checkpointStore agentruntime.CheckpointStore
+	// backpressureGate 可选；Pending 积压超过阈值时跳过可选工作并按租户优先级对新消息返回 429
+	backpressureGate *job.BackpressureGate
+	// promptStore 可选；LLM prompt 留存的对象存储，GET /api/jobs/:id/nodes/:node_id/prompt 按 llm_prompt_logged 引用读取
+	promptStore  object.Store
+	promptPrefix string
+	// eventArchive 可选；热存储中已清理的 Job 经此从对象存储归档冷读（trace/replay/events）
+	eventArchive *eventarchive.Reader
+	// killSwitchStore/killSwitchGate 可选；非 nil 时提供 /api/admin/killswitch（全局工具类别熔断）
+	killSwitchStore killswitch.Store
+	killSwitchGate  *killswitch.Gate
+	// residency 可选；数据驻留开启时提供 /api/admin/residency（租户所在区域查询与文档区域迁移）
+	residency *residency.Factory
+	// planCostModel 工具/LLM 成本与延迟标注，用于计划成本/ETA 预估（PlanGenerated、计划预览、Trace）
+	planCostModel planner.CostModel
+	// planBudget/planBudgetOnExceed Job 创建时的计划预算护栏（全局 + 租户 + Job 级预算）及超出时的处理（reject | approval）
+	planBudget         planner.BudgetPolicy
+	planBudgetOnExceed string
+	// workerStatus 可选；非 nil 时 GET /api/system/workers 附带 Worker 状态心跳（并发占用、资源感知节流状态）
+	workerStatus scheduler.WorkerStatusStore
+	// jobRequiredFeatures 所有新 Job 默认要求的特性（agent.compat.required_features），创建时与在线 Worker 版本协商
+	jobRequiredFeatures []string
+	// jobDedupWindow >0 时同一 Agent 在窗口内收到规范化后相同的目标，返回已有 Job 而不新建（agent.job_dedup）
+	jobDedupWindow time.Duration
+	// plannerExemplars/planValidity 可选；非 nil 时提供 /api/agents/:id/planner/exemplars（规划 few-shot 示例库与计划有效率 A/B 统计）
+	plannerExemplars planner.ExemplarStore
+	planValidity     *planner.PlanValidityTracker
+	// reconciler 可选；非 nil 时提供 GET /api/observability/drift（事件/账本/检查点漂移报告）
+	reconciler *reconcile.Reconciler
+	// etaEstimator 可选；非 nil 时 GET /api/jobs/:id 与 Trace 页附带时长预测与逐步 ETA
+	etaEstimator *eta.Estimator
+	// anomalyDetector 可选；非 nil 时提供 GET /api/observability/anomalies（Agent 行为基线与异常）
+	anomalyDetector *anomaly.Detector
+	// analytics GET /api/admin/analytics 跨租户聚合的抑制阈值；零值使用 analytics 默认
+	analytics analytics.Options
+	// workerInspectToken 代理 Worker 自省端点时携带的令牌（worker.inspect.token）
+	workerInspectToken string
+	// accessLogger 访问日志器，提供最近慢请求（api.access_log）；nil 时慢请求接口返回 503
+	accessLogger *middleware.AccessLogger
+	// workspaces 可选；非 nil 时提供 GET /api/jobs/:id/workspace（Job 工作区文件列表与下载）
+	workspaces *workspace.Manager
+	// traceMask 受限查看者（无 trace:view_payload）在 trace/replay/events 接口中的遮蔽策略；nil 时使用默认字段
+	traceMask *TraceMaskPolicy
+	// attestations 可选；Job 终态时固化的证明记录，事件被留存策略删除后 GET /api/jobs/:id/verify 据此降级验证
+	attestations verify.AttestationStore
+	// agentConfig 可选；非 nil 时提供 /api/agents/:id/config（Agent 级配置，工具经 sdk.ConfigFromContext 读取）
+	agentConfig agentconfig.Store
+	// debugRunner 可选；非 nil 时提供 POST /api/jobs/:id/nodes/:node_id/debug-run（沙箱中以录制状态试跑修改后的步骤）
+	debugRunner *sandbox.DebugRunner
+	// serviceAccounts 可选；非 nil 时提供 /api/service-accounts（Worker 服务账号签发、轮换、吊销）
+	serviceAccounts serviceaccount.Store
+	// freshnessStore 可选；非 nil 时提供 /api/knowledge/collections/:id/freshness（文档新鲜度报告）
+	freshnessStore             metadata.Store
+	freshnessPolicies          freshness.Policies
+	freshnessDefaultCollection string
+	// goalTemplates 可选；非 nil 时提供 /api/agents/:id/templates，AgentMessage 支持以 template+params 提交目标
+	goalTemplates goaltemplate.Store
+	// evalSuites 可选；非 nil 时提供 /api/agents/:id/eval（黄金目标回归集与运行报告）；evalRunning 记录运行中的 租户+Agent
+	evalSuites  evalsuite.Store
+	evalRunning sync.Map
+	// environment 可选；非 nil 时 Job 创建写入 job_environment，replay / verify 比对当前环境
+	environment *environment.Resolver
+	// readCache trace / events / replay 的短期服务端缓存；nil 时只做 ETag 协商
+	readCache *readCache
+	// planHistory 已终态 Job 的计划与成本缓存，供计划预览对比历史运行
+	planHistory planHistoryCache
+	// traceFilters 可选；非 nil 时提供 /api/trace/overview/presets（按用户保存的 Trace 概览筛选）
+	traceFilters tracefilter.Store
+	// branding 可选；非 nil 时提供 /api/tenant/branding，并在 Trace 页面与证据包中注入租户品牌
+	branding branding.Store
+	// debugSessions 可选；非 nil 时提供 /api/jobs/:id/debug-sessions（限时调试会话，临时开放 payload）
+	debugSessions debugsession.Store
+	// eventSearch 可选；非 nil 时提供 GET /api/search（租户内事件全文检索）
+	eventSearch eventsearch.Index
+	// inboundWebhooks 可选；非 nil 时提供 POST /api/webhooks/inbound/:channel（外部回调验签后转为 Job signal/message）
+	inboundWebhooks *inbound.Registry
+	// evidenceStore 可选；非 nil 时提供 GET /api/jobs/:id/evidence（证据包写入对象存储，返回预签名 URL）
+	evidenceStore     object.Store
+	evidencePrefix    string
+	evidenceURLExpiry time.Duration
+	// executionProfiles 可选；AgentMessage 的 profile 按此解析（agent.execution_profiles），nil 时不接受 profile
+	executionProfiles *agentexec.ExecutionProfiles
+	// readiness 可选；非 nil 时 GET /readyz 按启动预检结果返回 200/503
+	readiness ReadinessProbe
+}
+
+// ReadinessProbe 启动就绪状态（如 LLM 凭证预检），report 原样作为 /readyz 响应体
+type ReadinessProbe interface {
+	Readiness() (ready bool, report interface{})
 }
 
 // NewHandler 创建新的 HTTP 处理器
@@ -196,15 +329,210 @@ func (h *Handler) SetObservabilityReader(r job.ObservabilityReader) {
 	h.observabilityReader = r
 }
 
+// SetPIITagStore 设置 PII 标签存储（可选，用于 GET /api/jobs/:id/pii）
+func (h *Handler) SetPIITagStore(store piitag.TagStore) {
+	h.piiTags = store
+}
+
+// SetPIIExportRedaction 启用证据导出时按 PII 标签脱敏；mode 为空表示不脱敏
+func (h *Handler) SetPIIExportRedaction(mode redaction.RedactionMode, salt string) {
+	h.piiRedactMode = mode
+	h.piiRedactSalt = salt
+}
+
+// SetPlanCostModel 设置计划成本模型（来自工具标注与 agent.plan_cost 配置）
+func (h *Handler) SetPlanCostModel(m planner.CostModel) {
+	h.planCostModel = m
+}
+
+// SetPlanBudgetPolicy 设置 Job 创建时的计划预算护栏；onExceed 为 PlanBudgetApproval 时超出预算转为审批，其余按拒绝处理
+func (h *Handler) SetPlanBudgetPolicy(policy planner.BudgetPolicy, onExceed string) {
+	h.planBudget = policy
+	h.planBudgetOnExceed = onExceed
+}
+
+// SetJobDedupWindow 设置按目标哈希去重的时间窗口；<=0 关闭
+func (h *Handler) SetJobDedupWindow(d time.Duration) {
+	h.jobDedupWindow = d
+}
+
+// SetWorkerStatusStore 设置 Worker 状态心跳存储（可选，用于 /api/system/workers 展示自节流的 Worker）
+func (h *Handler) SetWorkerStatusStore(store scheduler.WorkerStatusStore) {
+	h.workerStatus = store
+}
+
+// SetDebugRunner 设置时间旅行调试沙箱（可选，用于 POST /api/jobs/:id/nodes/:node_id/debug-run）
+func (h *Handler) SetDebugRunner(runner *sandbox.DebugRunner) {
+	h.debugRunner = runner
+}
+
+// SetServiceAccounts 设置 Worker 服务账号存储（可选，用于 /api/service-accounts）
+func (h *Handler) SetServiceAccounts(store serviceaccount.Store) {
+	h.serviceAccounts = store
+}
+
+// SetFreshness 设置文档元数据存储与集合新鲜度策略（可选，用于 /api/knowledge/collections/:id/freshness）
+func (h *Handler) SetFreshness(store metadata.Store, policies freshness.Policies, defaultCollection string) {
+	h.freshnessStore = store
+	h.freshnessPolicies = policies
+	h.freshnessDefaultCollection = defaultCollection
+}
+
+// SetPlannerExemplars 设置规划示例库与计划有效率统计（可选，用于 /api/agents/:id/planner/exemplars）
+func (h *Handler) SetPlannerExemplars(store planner.ExemplarStore, validity *planner.PlanValidityTracker) {
+	h.plannerExemplars = store
+	h.planValidity = validity
+}
+
+// SetReconciler 设置漂移对账器（可选，用于 /api/observability/drift）
+func (h *Handler) SetReconciler(r *reconcile.Reconciler) {
+	h.reconciler = r
+}
+
+// SetETAEstimator 设置 Job 时长估计器（可选，用于 Job 详情与 Trace 页的 ETA）
+func (h *Handler) SetETAEstimator(e *eta.Estimator) {
+	h.etaEstimator = e
+}
+
+// SetAttestationStore 设置 Job 证明记录存储（可选，用于 /api/jobs/:id/verify 在事件被删除后的降级验证）
+func (h *Handler) SetAttestationStore(s verify.AttestationStore) {
+	h.attestations = s
+}
+
+// SetAnomalyDetector 设置 Agent 行为异常检测器（可选，用于 /api/observability/anomalies）
+func (h *Handler) SetAnomalyDetector(d *anomaly.Detector) {
+	h.anomalyDetector = d
+}
+
+// SetAnalytics 设置跨租户聚合分析的抑制阈值（可选，用于 /api/admin/analytics）
+func (h *Handler) SetAnalytics(opts analytics.Options) {
+	h.analytics = opts
+}
+
+// SetAccessLogger 设置访问日志器，供 GET /api/admin/slow-requests 读取最近慢请求
+func (h *Handler) SetAccessLogger(l *middleware.AccessLogger) {
+	h.accessLogger = l
+}
+
+// SetWorkerInspectToken 设置代理 Worker 自省端点时携带的 Bearer 令牌（可选，用于 /api/system/workers/:id/inspect）
+func (h *Handler) SetWorkerInspectToken(token string) {
+	h.workerInspectToken = token
+}
+
+// SetWorkspaces 设置 Job 工作区管理器（可选，用于 /api/jobs/:id/workspace）
+func (h *Handler) SetWorkspaces(m *workspace.Manager) {
+	h.workspaces = m
+}
+
+// SetTraceFilters 设置 Trace 概览筛选预设存储（可选，用于 /api/trace/overview/presets）
+func (h *Handler) SetTraceFilters(store tracefilter.Store) {
+	h.traceFilters = store
+}
+
+// SetBranding 设置租户品牌存储（可选，用于 /api/tenant/branding、Trace 页面页头与证据包 branding.json）
+func (h *Handler) SetBranding(store branding.Store) {
+	h.branding = store
+}
+
+// SetDebugSessions 设置限时调试会话存储（可选，用于 /api/jobs/:id/debug-sessions 与审计日志）
+func (h *Handler) SetDebugSessions(store debugsession.Store) {
+	h.debugSessions = store
+}
+
+// SetInboundWebhooks 设置入站 Webhook 通道（可选，用于 /api/webhooks/inbound/:channel）
+func (h *Handler) SetInboundWebhooks(reg *inbound.Registry) {
+	h.inboundWebhooks = reg
+}
+
+// SetExecutionProfiles 设置命名执行档位（可选，AgentMessage 按 profile 选择谨慎或快速执行）
+func (h *Handler) SetExecutionProfiles(p *agentexec.ExecutionProfiles) {
+	h.executionProfiles = p
+}
+
+// SetGoalTemplates 设置目标模板存储（可选，用于 /api/agents/:id/templates 与模板化消息）
+func (h *Handler) SetGoalTemplates(store goaltemplate.Store) {
+	h.goalTemplates = store
+}
+
+// SetEvalSuites 设置回归集存储（可选，用于 /api/agents/:id/eval）
+func (h *Handler) SetEvalSuites(store evalsuite.Store) {
+	h.evalSuites = store
+}
+
+// SetEnvironmentResolver 设置执行环境解析器（可选，用于 Job 环境固化与 replay / verify 比对）
+func (h *Handler) SetEnvironmentResolver(r *environment.Resolver) {
+	h.environment = r
+}
+
+// SetEvidenceStorage 设置证据包对象存储（可选；store 需实现 object.Presigner）；prefix 为对象键前缀，urlExpiry 为预签名 URL 有效期
+func (h *Handler) SetEvidenceStorage(store object.Store, prefix string, urlExpiry time.Duration) {
+	h.evidenceStore = store
+	h.evidencePrefix = prefix
+	h.evidenceURLExpiry = urlExpiry
+}
+
+// SetReadiness 设置启动就绪状态（可选，用于 /readyz）
+func (h *Handler) SetReadiness(p ReadinessProbe) {
+	h.readiness = p
+}
+
+// SetAgentConfig 设置 Agent 级配置存储（可选，用于 /api/agents/:id/config）
+func (h *Handler) SetAgentConfig(store agentconfig.Store) {
+	h.agentConfig = store
+}
+
+// SetKillSwitch 设置全局工具熔断存储与本进程判定（可选，用于 /api/admin/killswitch）
+func (h *Handler) SetKillSwitch(store killswitch.Store, gate *killswitch.Gate) {
+	h.killSwitchStore = store
+	h.killSwitchGate = gate
+}
+
+// SetResidency 设置数据驻留区域存储工厂（可选，用于 /api/admin/residency）
+func (h *Handler) SetResidency(f *residency.Factory) {
+	h.residency = f
+}
+
+// SetMaintenance 设置租户维护窗口存储与判定（可选，用于 /api/maintenance/windows 与新建 Job 暂缓）
+func (h *Handler) SetMaintenance(store job.MaintenanceStore, gate *job.MaintenanceGate) {
+	h.maintenanceStore = store
+	h.maintenanceGate = gate
+}
+
+// SetChildJobStore 设置监督者子 Job 关联存储（可选，用于 Trace 展示监督者 → 子 Job 层级）
+func (h *Handler) SetChildJobStore(store job.ChildJobStore) {
+	h.childJobStore = store
+}
+
+// SetCheckpointStore 设置节点检查点存储（可选，用于列出 Job 检查点与手动回滚）
+func (h *Handler) SetCheckpointStore(store agentruntime.CheckpointStore) {
+	h.checkpointStore = store
+}
+
+// traceHierarchyDepth Trace 中子 Job 层级的最大展开深度
+const traceHierarchyDepth = 3
+
+// jobHierarchy 返回 Job 的监督者层级；未配置存储或不在层级中时返回 nil
+func (h *Handler) jobHierarchy(ctx context.Context, jobID string) *job.JobHierarchy {
+	if h.childJobStore == nil || h.jobStore == nil {
+		return nil
+	}
+	hier, err := job.BuildHierarchy(ctx, h.jobStore, h.childJobStore, jobID, traceHierarchyDepth)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "BuildHierarchy: %v", err)
+		return nil
+	}
+	return hier
+}
+
 // getJobAndCheckTenant 按 jobID 取 Job 并校验当前请求租户；不通过时写 404 并返回 (nil, false)
 func (h *Handler) getJobAndCheckTenant(ctx context.Context, c *app.RequestContext, jobID string) (*job.Job, bool) {
 	if h.jobStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "Job 未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
 		return nil, false
 	}
 	j, err := h.jobStore.Get(ctx, jobID)
 	if err != nil || j == nil {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "任务not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
 		return nil, false
 	}
 	tid := auth.GetTenantID(ctx)
@@ -212,7 +540,7 @@ func (h *Handler) getJobAndCheckTenant(ctx context.Context, c *app.RequestContex
 		tid = "default"
 	}
 	if j.TenantID != tid {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "任务not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
 		return nil, false
 	}
 	return j, true
@@ -227,29 +555,45 @@ func (h *Handler) HealthCheck(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// Readyz 就绪检查：启动预检未完成或failed时返回 503，供负载均衡 / K8s readinessProbe 摘除实例；未设置预检时总是就绪
+func (h *Handler) Readyz(ctx context.Context, c *app.RequestContext) {
+	if h.readiness == nil {
+		c.JSON(consts.StatusOK, map[string]string{"status": "ready"})
+		return
+	}
+	ready, report := h.readiness.Readiness()
+	if !ready {
+		c.JSON(consts.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(consts.StatusOK, report)
+}
+
 // UploadDocument 上传文档
 func (h *Handler) UploadDocument(ctx context.Context, c *app.RequestContext) {
 	file, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请上传文件",
+			"error": i18n.T(ctx, "document.file_required"),
 		})
 		return
 	}
 
+	metadata, err := uploadMetadata(ctx, c, file.Filename, file.Size)
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
 	result, err := h.engine.ExecuteWorkflow(ctx, "ingest_pipeline", map[string]interface{}{
-		"file": file,
-		"metadata": map[string]interface{}{
-			"filename":    file.Filename,
-			"size":        file.Size,
-			"uploaded_at": time.Now(),
-		},
+		"file":     file,
+		"metadata": metadata,
 	})
 
 	if err != nil {
 		hlog.CtxErrorf(ctx, "上传文档failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "上传文档failed",
+			"error":   i18n.T(ctx, "document.upload_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -262,25 +606,73 @@ func (h *Handler) UploadDocument(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// uploadMetadata 组装入库元数据：文件信息 + 表单 metadata（JSON 对象，字符串值写入切片供检索过滤）
+// + 文档级 ACL（acl_roles / acl_users，逗号分隔；均为空表示公开）+ 所属租户（数据驻留路由与区域迁移）
+func uploadMetadata(ctx context.Context, c *app.RequestContext, filename string, size int64) (map[string]interface{}, error) {
+	metadata := make(map[string]interface{})
+	if raw := string(c.FormValue("metadata")); raw != "" {
+		var custom map[string]string
+		if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+			return nil, fmt.Errorf("metadata 须为字符串值的 JSON 对象")
+		}
+		for k, v := range custom {
+			metadata[k] = v
+		}
+	}
+	// ACL 键只能经 acl_roles / acl_users 设置、租户取自认证上下文，避免 metadata 伪造
+	delete(metadata, vector.MetaACLRoles)
+	delete(metadata, vector.MetaACLUsers)
+	delete(metadata, residency.MetaTenantID)
+	acl := vector.ACLMetadata([]string{string(c.FormValue("acl_roles"))}, []string{string(c.FormValue("acl_users"))})
+	for k, v := range acl {
+		metadata[k] = v
+	}
+	// 新鲜度：source_uri 可供定时重新抓取；source_updated_at 为源内容修改时间（RFC3339，缺省为入库时间）
+	if uri := strings.TrimSpace(string(c.FormValue("source_uri"))); uri != "" {
+		metadata[freshness.MetaSourceURI] = uri
+	}
+	if raw := strings.TrimSpace(string(c.FormValue("source_updated_at"))); raw != "" {
+		ts, ok := freshness.ParseTime(raw)
+		if !ok {
+			return nil, fmt.Errorf("source_updated_at 须为 RFC3339 时间")
+		}
+		metadata[freshness.MetaSourceUpdatedAt] = ts.UTC().Format(time.RFC3339)
+	}
+	if tenant := auth.GetTenantID(ctx); tenant != "" {
+		metadata[residency.MetaTenantID] = tenant
+	}
+	now := time.Now()
+	metadata["filename"] = filename
+	metadata["size"] = size
+	metadata["uploaded_at"] = now
+	freshness.Stamp(metadata, now)
+	return metadata, nil
+}
+
 // UploadDocumentAsync 异步入库：将文件入队后立即返回 202，由 Worker 消费执行 ingest_pipeline；需配置 postgres
 func (h *Handler) UploadDocumentAsync(ctx context.Context, c *app.RequestContext) {
 	if h.ingestQueue == nil {
 		c.JSON(consts.StatusNotImplemented, map[string]string{
-			"error": "异步入库requires配置 jobstore.type=postgres",
+			"error": i18n.T(ctx, "ingest.async_requires_postgres"),
 		})
 		return
 	}
 	file, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请上传文件",
+			"error": i18n.T(ctx, "document.file_required"),
 		})
 		return
 	}
+	metadata, err := uploadMetadata(ctx, c, file.Filename, file.Size)
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
 	opened, err := file.Open()
 	if err != nil {
 		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": "打开上传文件failed",
+			"error": i18n.T(ctx, "document.open_upload_failed"),
 		})
 		return
 	}
@@ -288,24 +680,20 @@ func (h *Handler) UploadDocumentAsync(ctx context.Context, c *app.RequestContext
 	data, err := io.ReadAll(opened)
 	if err != nil {
 		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": "读取上传文件failed",
+			"error": i18n.T(ctx, "document.read_upload_failed"),
 		})
 		return
 	}
 	payload := map[string]interface{}{
 		"content_base64": base64.StdEncoding.EncodeToString(data),
 		"filename":       file.Filename,
-		"metadata": map[string]interface{}{
-			"filename":    file.Filename,
-			"size":        file.Size,
-			"uploaded_at": time.Now(),
-		},
+		"metadata":       metadata,
 	}
 	taskID, err := h.ingestQueue.Enqueue(ctx, payload)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "入库任务入队failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "入库任务入队failed",
+			"error":   i18n.T(ctx, "ingest.enqueue_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -320,13 +708,13 @@ func (h *Handler) UploadDocumentAsync(ctx context.Context, c *app.RequestContext
 func (h *Handler) UploadStatus(ctx context.Context, c *app.RequestContext) {
 	if h.ingestQueue == nil {
 		c.JSON(consts.StatusNotImplemented, map[string]string{
-			"error": "任务状态查询requires配置 jobstore.type=postgres",
+			"error": i18n.T(ctx, "ingest.status_requires_postgres"),
 		})
 		return
 	}
 	taskID := c.Param("task_id")
 	if taskID == "" {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "缺少 task_id"})
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.task_id_required")})
 		return
 	}
 	status, result, errMsg, completedAt, err := h.ingestQueue.GetStatus(ctx, taskID)
@@ -335,7 +723,7 @@ func (h *Handler) UploadStatus(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 	if status == "" {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "任务not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
 		return
 	}
 	c.JSON(consts.StatusOK, map[string]interface{}{
@@ -353,7 +741,7 @@ func (h *Handler) ListDocuments(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "获取文档列表failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": "获取文档列表failed",
+			"error": i18n.T(ctx, "document.list_failed"),
 		})
 		return
 	}
@@ -371,7 +759,7 @@ func (h *Handler) GetDocument(ctx context.Context, c *app.RequestContext) {
 	document, err := h.docService.GetDocument(ctx, id)
 	if err != nil {
 		c.JSON(consts.StatusNotFound, map[string]string{
-			"error": "文档not found",
+			"error": i18n.T(ctx, "document.not_found"),
 		})
 		return
 	}
@@ -385,7 +773,7 @@ func (h *Handler) DeleteDocument(ctx context.Context, c *app.RequestContext) {
 
 	if err := h.docService.DeleteDocument(ctx, id); err != nil {
 		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": "删除文档failed",
+			"error": i18n.T(ctx, "document.delete_failed"),
 		})
 		return
 	}
@@ -396,6 +784,30 @@ func (h *Handler) DeleteDocument(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// ListDocumentVersions 文档版本历史（GET /documents/:id/versions），文档删除后仍可查询
+func (h *Handler) ListDocumentVersions(ctx context.Context, c *app.RequestContext) {
+	id := c.Param("id")
+	versions, err := h.docService.ListVersions(ctx, id)
+	if errors.Is(err, appcore.ErrVersionsUnsupported) {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "document.versions_unsupported")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取文档版本failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "document.versions_failed")})
+		return
+	}
+	if len(versions) == 0 {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "document.not_found")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"document_id": id,
+		"versions":    versions,
+		"current":     metadata.CurrentVersion(versions),
+	})
+}
+
 // ListCollections 列出集合
 func (h *Handler) ListCollections(ctx context.Context, c *app.RequestContext) {
 	c.JSON(consts.StatusOK, map[string]interface{}{
@@ -420,7 +832,7 @@ func (h *Handler) CreateCollection(ctx context.Context, c *app.RequestContext) {
 
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
@@ -446,26 +858,59 @@ func (h *Handler) DeleteCollection(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// CollectionFreshness 集合新鲜度报告（GET /api/knowledge/collections/:id/freshness）：
+// 各文档源时间、最近入库时间、是否超出 max_age、是否到期重新抓取
+func (h *Handler) CollectionFreshness(ctx context.Context, c *app.RequestContext) {
+	if h.freshnessStore == nil {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "document.metadata_store_not_configured")})
+		return
+	}
+	id := c.Param("id")
+	docs, err := freshness.ListCollection(ctx, h.freshnessStore, id, h.freshnessDefaultCollection)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取集合文档failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "knowledge.collection_documents_failed")})
+		return
+	}
+	report := freshness.BuildReport(id, docs, h.freshnessPolicies.For(id), time.Now())
+	if c.Query("stale") == "true" {
+		filtered := report.Documents[:0]
+		for _, d := range report.Documents {
+			if d.Stale || d.RefreshDue {
+				filtered = append(filtered, d)
+			}
+		}
+		report.Documents = filtered
+	}
+	c.JSON(consts.StatusOK, report)
+}
+
 // Query 查询
 // Deprecated: 请使用 POST /api/agents/{id}/message 以 Agent 为中心与系统交互。
 func (h *Handler) Query(ctx context.Context, c *app.RequestContext) {
 	var request struct {
 		Query    string                 `json:"query" binding:"required"`
 		Metadata map[string]interface{} `json:"metadata"`
+		Filter   *vector.FilterExpr     `json:"filter"`
 		TopK     int                    `json:"top_k"`
 	}
 
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
+	if err := request.Filter.Validate(); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "forensics.filter_invalid", err.Error())})
+		return
+	}
 
 	query := &common.Query{
 		ID:        fmt.Sprintf("query-%d", time.Now().UnixNano()),
 		Text:      request.Query,
 		Metadata:  request.Metadata,
+		Filter:    queryFilter(ctx, request.Filter),
 		CreatedAt: time.Now(),
 	}
 
@@ -477,7 +922,7 @@ func (h *Handler) Query(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "查询failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "查询failed",
+			"error":   i18n.T(ctx, "query.failed"),
 			"details": err.Error(),
 		})
 		return
@@ -489,21 +934,33 @@ func (h *Handler) Query(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// queryFilter 合并请求方过滤表达式与文档 ACL（按当前 user/role），下推到向量检索
+func queryFilter(ctx context.Context, requested *vector.FilterExpr) *vector.FilterExpr {
+	return vector.And(requested, vector.ACLFilter(auth.GetUserID(ctx), []string{string(auth.GetRole(ctx))}))
+}
+
 // BatchQuery 批量查询
 func (h *Handler) BatchQuery(ctx context.Context, c *app.RequestContext) {
 	var request struct {
 		Queries []struct {
 			Query    string                 `json:"query" binding:"required"`
 			Metadata map[string]interface{} `json:"metadata"`
+			Filter   *vector.FilterExpr     `json:"filter"`
 		} `json:"queries" binding:"required"`
 	}
 
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
+	for _, q := range request.Queries {
+		if err := q.Filter.Validate(); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "forensics.filter_invalid", err.Error())})
+			return
+		}
+	}
 
 	results := make([]interface{}, len(request.Queries))
 
@@ -512,6 +969,7 @@ func (h *Handler) BatchQuery(ctx context.Context, c *app.RequestContext) {
 			ID:        fmt.Sprintf("query-%d-%d", time.Now().UnixNano(), i),
 			Text:      q.Query,
 			Metadata:  q.Metadata,
+			Filter:    queryFilter(ctx, q.Filter),
 			CreatedAt: time.Now(),
 		}
 
@@ -582,24 +1040,47 @@ type workersLister interface {
 	ListActiveWorkerIDs(ctx context.Context) ([]string, error)
 }
 
-// SystemWorkers 返回当前有未过期租约的 Worker 列表（GET /api/system/workers，供 CLI aetheris workers）
+// workerStatusTTL 超过此时长未上报状态心跳的 Worker 不再展示
+const workerStatusTTL = 2 * time.Minute
+
+// SystemWorkers 返回当前有未过期租约的 Worker 列表（GET /api/system/workers，供 CLI aetheris workers）；配置 Worker 状态存储时附带各 Worker 的资源感知节流状态
 func (h *Handler) SystemWorkers(ctx context.Context, c *app.RequestContext) {
+	resp := map[string]interface{}{"workers": []string{}, "total": 0}
+	if h.workerStatus != nil {
+		statuses, err := h.workerStatus.List(ctx, time.Now().Add(-workerStatusTTL))
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ListWorkerStatus: %v", err)
+		} else {
+			throttled := []string{}
+			for _, st := range statuses {
+				if st.Throttle.Throttled {
+					throttled = append(throttled, st.WorkerID)
+				}
+			}
+			resp["statuses"] = statuses
+			resp["throttled_workers"] = throttled
+			resp["api_event_schema_version"] = compat.EventSchemaVersion
+		}
+	}
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusOK, map[string]interface{}{"workers": []string{}, "total": 0})
+		c.JSON(consts.StatusOK, resp)
 		return
 	}
-	wl, ok := h.jobEventStore.(workersLister)
+	wl, ok := jobstore.Find[workersLister](h.jobEventStore)
 	if !ok {
-		c.JSON(consts.StatusOK, map[string]interface{}{"workers": []string{}, "total": 0, "message": "事件存储unsupported列出 Worker"})
+		resp["message"] = "事件存储unsupported列出 Worker"
+		c.JSON(consts.StatusOK, resp)
 		return
 	}
 	ids, err := wl.ListActiveWorkerIDs(ctx)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListActiveWorkerIDs: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取 Worker 列表failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "worker.list_failed")})
 		return
 	}
-	c.JSON(consts.StatusOK, map[string]interface{}{"workers": ids, "total": len(ids)})
+	resp["workers"] = ids
+	resp["total"] = len(ids)
+	c.JSON(consts.StatusOK, resp)
 }
 
 // AgentRunRequest POST /api/agent/run 请求体
@@ -672,7 +1153,7 @@ func runADK(ctx context.Context, c *app.RequestContext, runner *adk.Runner, sess
 		if event.Err != nil {
 			hlog.CtxErrorf(ctx, "ADK Run 事件error: %v", event.Err)
 			c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-				"error":   "Agent execution failed",
+				"error":   i18n.T(ctx, "agent.execution_failed"),
 				"details": event.Err.Error(),
 			})
 			return
@@ -717,14 +1198,14 @@ type AgentResumeCheckpointRequest struct {
 func (h *Handler) AgentResumeCheckpoint(ctx context.Context, c *app.RequestContext) {
 	if h.adkRunner == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "ADK Runner not configured，无法 Resume",
+			"error": i18n.T(ctx, "adk.runner_not_configured_resume"),
 		})
 		return
 	}
 	var req AgentResumeCheckpointRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error，requires checkpoint_id",
+			"error": i18n.T(ctx, "request.checkpoint_id_required"),
 		})
 		return
 	}
@@ -732,7 +1213,7 @@ func (h *Handler) AgentResumeCheckpoint(ctx context.Context, c *app.RequestConte
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ADK Resume failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "Resume failed",
+			"error":   i18n.T(ctx, "job.resume_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -747,7 +1228,7 @@ func (h *Handler) AgentResumeCheckpoint(ctx context.Context, c *app.RequestConte
 		if event.Err != nil {
 			hlog.CtxErrorf(ctx, "ADK Resume 事件error: %v", event.Err)
 			c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-				"error":   "Agent execution failed",
+				"error":   i18n.T(ctx, "agent.execution_failed"),
 				"details": event.Err.Error(),
 			})
 			return
@@ -778,20 +1259,20 @@ func (h *Handler) AgentResumeCheckpoint(ctx context.Context, c *app.RequestConte
 func (h *Handler) AgentStream(ctx context.Context, c *app.RequestContext) {
 	if h.adkRunner == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "ADK Runner not configured",
+			"error": i18n.T(ctx, "adk.runner_not_configured"),
 		})
 		return
 	}
 	if h.sessionManager == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "SessionManager not configured",
+			"error": i18n.T(ctx, "session.manager_not_configured"),
 		})
 		return
 	}
 	var req AgentRunRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
@@ -799,7 +1280,7 @@ func (h *Handler) AgentStream(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Session GetOrCreate failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "获取或创建 Session failed",
+			"error":   i18n.T(ctx, "session.get_or_create_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -828,14 +1309,14 @@ func marshalJSON(ctx context.Context, v interface{}, scene string) ([]byte, erro
 func (h *Handler) AgentRun(ctx context.Context, c *app.RequestContext) {
 	if h.sessionManager == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "SessionManager not configured",
+			"error": i18n.T(ctx, "session.manager_not_configured"),
 		})
 		return
 	}
 	var req AgentRunRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
@@ -843,7 +1324,7 @@ func (h *Handler) AgentRun(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Session GetOrCreate failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "获取或创建 Session failed",
+			"error":   i18n.T(ctx, "session.get_or_create_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -854,7 +1335,7 @@ func (h *Handler) AgentRun(ctx context.Context, c *app.RequestContext) {
 	}
 	if h.agent == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent not configured",
+			"error": i18n.T(ctx, "agent.not_configured"),
 		})
 		return
 	}
@@ -862,7 +1343,7 @@ func (h *Handler) AgentRun(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Agent Run failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "Agent execution failed",
+			"error":   i18n.T(ctx, "agent.execution_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -890,14 +1371,14 @@ type CreateAgentRequest struct {
 func (h *Handler) CreateAgent(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil || h.agentCreator == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
 	var req CreateAgentRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
@@ -905,7 +1386,7 @@ func (h *Handler) CreateAgent(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "创建 Agent failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
-			"error":   "创建 Agent failed",
+			"error":   i18n.T(ctx, "agent.create_failed"),
 			"details": err.Error(),
 		})
 		return
@@ -918,14 +1399,35 @@ func (h *Handler) CreateAgent(ctx context.Context, c *app.RequestContext) {
 
 // AgentMessageRequest POST /api/agents/:id/message 请求体
 type AgentMessageRequest struct {
-	Message string `json:"message" binding:"required"`
+	Message  string         `json:"message"`
+	Template string         `json:"template"` // 可选；非空时按目标模板校验 params 并渲染为 message
+	Params   map[string]any `json:"params"`
+	// RequiredFeatures 可选；Job 要求 Worker 支持的特性（如 recorded_http、parallel_dag），按在线 Worker 版本协商
+	RequiredFeatures []string `json:"required_features"`
+	// Budget 可选；Job 级计划预算，与租户/全局预算合并取更严格者，Job 创建时校验计划预估
+	Budget *JobBudgetRequest `json:"budget"`
+	// Interactive 可选；交互式对话 Job 走预留的 interactive 调度通道，单步超时更紧
+	Interactive bool `json:"interactive"`
+	// Profile 可选；执行档位（如 conservative / aggressive），空则使用 agent.execution_profiles.default
+	Profile string `json:"profile"`
+	// TaskGraph 可选；代码构造的计划（见 pkg/taskgraph），经 planner.ValidateTaskGraph 校验后直接作为 PlanGenerated 落库，跳过 LLM 规划
+	TaskGraph *planner.TaskGraph `json:"task_graph"`
+}
+
+// requestAttribution 由请求 context 构造 Job 发起归属（用户、客户端、请求 ID）
+func requestAttribution(ctx context.Context) *jobstore.Attribution {
+	a := &jobstore.Attribution{UserID: auth.GetUserID(ctx), Client: auth.GetClient(ctx), RequestID: auth.GetRequestID(ctx)}
+	if a.IsZero() {
+		return nil
+	}
+	return a
 }
 
 // AgentMessage 向 Agent 发送消息：写入 Session；若已设置 JobStore 则创建 Job 由 JobRunner 拉取执行，否则通过 WakeAgent 触发（兼容旧行为）
 func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
@@ -933,17 +1435,69 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 	var req AgentMessageRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(consts.StatusBadRequest, map[string]string{
-			"error": "请求参数error",
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
 	agent, err := h.agentManager.Get(ctx, id)
 	if err != nil || agent == nil {
 		c.JSON(consts.StatusNotFound, map[string]string{
-			"error": "Agent not found",
+			"error": i18n.T(ctx, "agent.not_found"),
+		})
+		return
+	}
+	var templateParams map[string]any
+	if req.Template != "" {
+		if h.goalTemplates == nil {
+			c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "goal_template.disabled")})
+			return
+		}
+		t, errTpl := h.goalTemplates.Get(ctx, id, req.Template)
+		if errTpl != nil {
+			if errors.Is(errTpl, goaltemplate.ErrNotFound) {
+				c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "goal_template.not_found")})
+				return
+			}
+			hlog.CtxErrorf(ctx, "Get goal template: %v", errTpl)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "goal_template.get_failed")})
+			return
+		}
+		goal, params, errRender := t.Render(req.Params)
+		if errRender != nil {
+			writeGoalTemplateError(ctx, c, errRender)
+			return
+		}
+		req.Message, templateParams = goal, params
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{
+			"error": i18n.T(ctx, "request.invalid"),
 		})
 		return
 	}
+	if req.TaskGraph != nil {
+		if err := planner.ValidateTaskGraph(req.TaskGraph, nil); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "planner.graph_invalid", err.Error())})
+			return
+		}
+	}
+	jobBudget, errBudget := req.Budget.PlanBudget()
+	if errBudget != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "plan_budget.invalid")})
+		return
+	}
+	profile, okProfile := h.executionProfiles.Resolve(req.Profile)
+	if !okProfile {
+		c.JSON(consts.StatusBadRequest, map[string]interface{}{
+			"error":    i18n.T(ctx, "job.profile_unknown", req.Profile),
+			"profiles": h.executionProfiles.Names(),
+		})
+		return
+	}
+	// 并行档位需协商 parallel_dag；该特性可降级，无 Worker 支持时按顺序执行
+	if p := h.executionProfiles.Get(profile); p != nil && p.Parallel && !slices.Contains(req.RequiredFeatures, compat.FeatureParallelDAG) {
+		req.RequiredFeatures = append(req.RequiredFeatures, compat.FeatureParallelDAG)
+	}
 	if h.agentInstanceStore != nil {
 		inst, _ := h.agentInstanceStore.Get(ctx, id)
 		if inst == nil {
@@ -969,10 +1523,55 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 			return
 		}
 	}
+	// 目标去重窗口：无 Idempotency-Key 命中时，同 Agent、同租户在窗口内的相同目标（规范化哈希）返回已有 Job，避免客户端重试导致重复的高成本执行
+	if h.jobDedupWindow > 0 && h.jobStore != nil {
+		existing, errDedup := h.jobStore.FindRecentByGoalHash(ctx, id, tenantID, job.GoalHash(req.Message), time.Now().Add(-h.jobDedupWindow))
+		if errDedup != nil {
+			hlog.CtxWarnf(ctx, "目标去重查询failed，按新 Job 处理: %v", errDedup)
+		} else if existing != nil {
+			metrics.JobsDeduplicatedTotal.WithLabelValues(tenantID).Inc()
+			c.JSON(consts.StatusAccepted, map[string]interface{}{
+				"status":       "accepted",
+				"agent_id":     id,
+				"job_id":       existing.ID,
+				"job_status":   existing.Status.String(),
+				"deduplicated": true,
+				"dedup_window": h.jobDedupWindow.String(),
+			})
+			return
+		}
+	}
+	// 背压：积压超过阈值时按租户优先级返回 429 + Retry-After，而不是无界受理拖慢所有租户；幂等/去重命中的重试不受影响
+	if ok, retryAfter, level := h.backpressureGate.Admit(ctx, tenantID); !ok {
+		writeBackpressure(ctx, c, level, retryAfter)
+		return
+	}
+	// 版本协商：按在线 Worker 声明的 schema/特性决定 Job 的路由条件；不可降级的特性无 Worker 支持时拒绝
+	var negotiated compat.Decision
+	if h.jobStore != nil {
+		if unknown := unknownFeatures(req.RequiredFeatures); len(unknown) > 0 {
+			c.JSON(consts.StatusBadRequest, map[string]interface{}{
+				"error":            i18n.T(ctx, "job.feature_unknown", strings.Join(unknown, ", ")),
+				"unknown_features": unknown,
+			})
+			return
+		}
+		d, errNeg := h.negotiateJobFeatures(ctx, req.RequiredFeatures)
+		if errNeg != nil {
+			c.JSON(consts.StatusConflict, map[string]interface{}{
+				"error": i18n.T(ctx, "job.features_unavailable"),
+				"code":  "features_unavailable",
+				"cause": errNeg.Error(),
+			})
+			return
+		}
+		negotiated = d
+	}
 	agent.Session.AddMessage("user", req.Message)
 	if h.agentStateStore != nil {
-		state := agentruntime.SessionToAgentState(agent.Session)
-		_ = h.agentStateStore.SaveAgentState(ctx, id, agent.Session.ID, state)
+		if states, errScope := agentruntime.NewScopedAgentStateStore(h.agentStateStore, agentruntime.MemoryScope{TenantID: tenantID, AgentID: id}); errScope == nil {
+			_ = states.Save(ctx, agent.Session.ID, agentruntime.SessionToAgentState(agent.Session))
+		}
 	}
 	// JobStore 模式下由 AgentMessage 直接创建 Job，不再额外投递 inbox，避免同一消息重复建 Job。
 	if h.agentMessagingBus != nil && h.jobStore == nil {
@@ -980,36 +1579,88 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 	}
 	if h.jobStore != nil {
 		// 先创建 Job 得到稳定 jobID，再双写事件流，避免 Create failed时留下孤立事件；多租户写入 TenantID
-		j := &job.Job{AgentID: id, TenantID: tenantID, Goal: req.Message, Status: job.StatusPending, SessionID: agent.Session.ID, IdempotencyKey: idempotencyKey}
+		j := &job.Job{AgentID: id, TenantID: tenantID, Goal: req.Message, Status: job.StatusPending, SessionID: agent.Session.ID, IdempotencyKey: idempotencyKey, RequiredCapabilities: negotiated.Capabilities(), Attribution: requestAttribution(ctx)}
+		if req.Interactive {
+			job.MarkInteractive(j)
+		}
+		j.Profile = profile
+		// 租户维护窗口内：照常受理，但 Job 置为 Deferred，窗口结束后自动恢复调度
+		window := h.maintenanceGate.Active(ctx, tenantID)
+		if window != nil {
+			j.Status = job.StatusDeferred
+		}
+		endPhase := middleware.StartPhase(ctx, "job_create")
 		jobIDOut, errCreate := h.jobStore.Create(ctx, j)
+		endPhase()
 		if errCreate != nil {
 			hlog.CtxErrorf(ctx, "创建 Job failed: %v", errCreate)
 			c.JSON(consts.StatusInternalServerError, map[string]string{
-				"error": "创建任务failed",
+				"error": i18n.T(ctx, "job.create_failed"),
 			})
 			return
 		}
-		metrics.JobsTotal.WithLabelValues(tenantID, "pending").Inc()
+		metrics.JobsTotal.WithLabelValues(tenantID, j.Status.String()).Inc()
+		var planEstimate *planner.PlanEstimate
+		var planApproval map[string]interface{}
+		var planReview *jobstore.PlanReview
 		if h.jobEventStore != nil {
-			payload, errMarshal := marshalJSON(ctx, map[string]string{"agent_id": id, "goal": req.Message}, "job_created_payload")
+			createdPayload := map[string]interface{}{"agent_id": id, "goal": req.Message, "compat": negotiated}
+			if j.Interactive {
+				createdPayload["lane"] = job.LaneInteractive
+			}
+			if j.Profile != "" {
+				createdPayload["profile"] = j.Profile
+			}
+			if req.Template != "" {
+				createdPayload["template"] = req.Template
+				createdPayload["template_params"] = templateParams
+			}
+			payload, errMarshal := marshalJSON(ctx, createdPayload, "job_created_payload")
 			if errMarshal != nil {
 				c.JSON(consts.StatusInternalServerError, map[string]string{
-					"error": "创建任务事件failed",
+					"error": i18n.T(ctx, "job.create_event_failed"),
 				})
 				return
 			}
 			ver, errAppend := h.jobEventStore.Append(ctx, jobIDOut, 0, jobstore.JobEvent{
 				JobID: jobIDOut, Type: jobstore.JobCreated, Payload: payload,
 			})
+			if errAppend == nil {
+				ver = h.appendJobEnvironment(ctx, jobIDOut, ver)
+			}
 			if errAppend != nil {
 				hlog.CtxErrorf(ctx, "追加 JobCreated 事件failed（Job 已创建，可继续执行）: %v", errAppend)
-			} else if h.planAtJobCreation != nil {
+			} else if h.planAtJobCreation != nil || req.TaskGraph != nil {
 				// 1.0 Plan 事件化：Job 创建时即生成并持久化 TaskGraph，执行阶段只读
-				taskGraph, planErr := h.planAtJobCreation(ctx, id, req.Message)
+				// 记录实际生成计划的模型（故障切换链可能不是主模型），随 PlanGenerated 持久化供回放还原
+				planCtx, servedLLM := llm.WithServedModel(ctx)
+				planCtx, routedLLM := llm.WithRouteDecision(planCtx)
+				var taskGraph *planner.TaskGraph
+				var planErr error
+				if req.TaskGraph != nil {
+					// 客户端提交的计划：已校验，不调用 Planner
+					taskGraph = req.TaskGraph
+				} else {
+					endPhase = middleware.StartPhase(ctx, "plan")
+					taskGraph, planErr = h.planAtJobCreation(planCtx, id, req.Message)
+					endPhase()
+				}
+				// 计划预算护栏：按全局/租户/Job 预算校验预估，超出时拒绝（Job 置为 failed）或插入审批节点，避免执行到一半才耗尽预算
+				budget := h.planBudget.For(tenantID, jobBudget)
+				taskGraph, budgetErr, planErr := h.checkPlanBudget(taskGraph, planErr, budget)
+				if budgetErr != nil && (h.planBudgetOnExceed != PlanBudgetApproval || taskGraph == nil) {
+					h.rejectOverBudgetJob(ctx, c, jobIDOut, ver, budgetErr)
+					return
+				}
+				var approvalKey string
+				if budgetErr != nil {
+					approvalKey = PlanBudgetApprovalKey(jobIDOut)
+					taskGraph = gatePlanWithApproval(taskGraph, approvalKey, budgetErr.Error())
+				}
 				if planErr != nil {
 					hlog.CtxErrorf(ctx, "Job 创建时 Plan failed: %v", planErr)
 					c.JSON(consts.StatusInternalServerError, map[string]string{
-						"error": "规划failed，请重试",
+						"error": i18n.T(ctx, "planner.plan_failed"),
 					})
 					return
 				}
@@ -1020,14 +1671,49 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 						h := sha256.Sum256(graphBytes)
 						planHash = hex.EncodeToString(h[:])
 					}
-					payloadPlan, errMarshal := marshalJSON(ctx, map[string]interface{}{
+					// 成本/ETA 预估：按工具标注计算，随 PlanGenerated 持久化，供 Trace 与响应展示
+					planEstimate = planner.EstimateTaskGraph(taskGraph, h.planCostModel)
+					planPayload := map[string]interface{}{
 						"task_graph": json.RawMessage(graphBytes),
 						"goal":       req.Message,
 						"plan_hash":  planHash,
-					}, "plan_generated_payload")
+						"estimate":   planEstimate,
+					}
+					if !budget.IsZero() {
+						planPayload["budget"] = budget
+					}
+					if req.TaskGraph != nil {
+						planPayload["plan_source"] = "client"
+					}
+					// 计划审阅：Agent 设置 require_plan_approval 时计划随 review_required 持久化，Runner 在审阅通过前不执行
+					reviewRequired := h.planReviewRequired(ctx, id)
+					if reviewRequired {
+						planPayload["review_required"] = true
+					}
+					if budgetErr != nil {
+						planPayload["budget_exceeded"] = planBudgetErrorBody(budgetErr)
+						planPayload["approval_correlation_key"] = approvalKey
+						planApproval = map[string]interface{}{
+							"correlation_key": approvalKey,
+							"reason":          budgetErr.Error(),
+							"estimate":        budgetErr.Estimate,
+							"budget":          budgetErr.Budget,
+						}
+					}
+					if servedLLM.Model != "" {
+						planPayload["llm_model"] = servedLLM.Model
+						planPayload["llm_provider"] = servedLLM.Provider
+						if servedLLM.FailoverFrom != "" {
+							planPayload["llm_failover_from"] = servedLLM.FailoverFrom
+						}
+					}
+					if routedLLM.Model != "" {
+						planPayload["llm_routing"] = routedLLM
+					}
+					payloadPlan, errMarshal := marshalJSON(ctx, planPayload, "plan_generated_payload")
 					if errMarshal != nil {
 						c.JSON(consts.StatusInternalServerError, map[string]string{
-							"error": "计划事件serialize failed",
+							"error": i18n.T(ctx, "planner.serialize_event_failed"),
 						})
 						return
 					}
@@ -1037,10 +1723,21 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 					if errPlanAppend != nil {
 						hlog.CtxErrorf(ctx, "追加 PlanGenerated 事件failed: %v", errPlanAppend)
 						c.JSON(consts.StatusInternalServerError, map[string]string{
-							"error": "写入计划事件failed",
+							"error": i18n.T(ctx, "planner.write_event_failed"),
 						})
 						return
 					}
+					if reviewRequired {
+						planReview = &jobstore.PlanReview{Status: jobstore.PlanReviewPending, PlanHash: planHash}
+						reqPayload, _ := json.Marshal(jobstore.PlanReviewRequestedPayload{PlanHash: planHash, Reason: agentconfig.KeyRequirePlanApproval})
+						if v, errReview := h.jobEventStore.Append(ctx, jobIDOut, verPlan, jobstore.JobEvent{
+							JobID: jobIDOut, Type: jobstore.PlanReviewRequested, Payload: reqPayload,
+						}); errReview != nil {
+							hlog.CtxErrorf(ctx, "追加 PlanReviewRequested 事件failed（不影响审阅门）: %v", errReview)
+						} else {
+							verPlan = v
+						}
+					}
 					taskGraphSummary := string(graphBytes)
 					if len(graphBytes) > 512 {
 						taskGraphSummary = string(graphBytes[:512]) + "..."
@@ -1052,7 +1749,7 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 					}, "decision_snapshot_payload")
 					if errMarshal != nil {
 						hlog.CtxErrorf(ctx, "DecisionSnapshot serialize failed: %v", errMarshal)
-					} else {
+					} else if !h.backpressureGate.ShouldShed(ctx, "decision_snapshot") {
 						if _, err := h.jobEventStore.Append(ctx, jobIDOut, verPlan, jobstore.JobEvent{
 							JobID: jobIDOut, Type: jobstore.DecisionSnapshot, Payload: dsPayload,
 						}); err != nil {
@@ -1062,11 +1759,32 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 				}
 			}
 		}
-		c.JSON(consts.StatusAccepted, map[string]interface{}{
+		resp := map[string]interface{}{
 			"status":   "accepted",
 			"agent_id": id,
 			"job_id":   jobIDOut,
-		})
+			"lane":     job.LaneOf(j),
+		}
+		if j.Profile != "" {
+			resp["profile"] = j.Profile
+		}
+		if window != nil {
+			resp["deferred_until"] = window.EndsAt
+			resp["maintenance_window_id"] = window.ID
+		}
+		if planEstimate != nil {
+			resp["plan_estimate"] = planEstimate
+		}
+		if planApproval != nil {
+			resp["approval_required"] = planApproval
+		}
+		if planReview != nil {
+			resp["plan_review"] = planReview
+		}
+		if len(negotiated.Degraded) > 0 {
+			resp["degraded_features"] = negotiated.Degraded
+		}
+		c.JSON(consts.StatusAccepted, resp)
 		return
 	}
 	if h.agentScheduler != nil {
@@ -1082,7 +1800,7 @@ func (h *Handler) AgentMessage(ctx context.Context, c *app.RequestContext) {
 func (h *Handler) AgentState(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
@@ -1090,7 +1808,7 @@ func (h *Handler) AgentState(ctx context.Context, c *app.RequestContext) {
 	agent, err := h.agentManager.Get(ctx, id)
 	if err != nil || agent == nil {
 		c.JSON(consts.StatusNotFound, map[string]string{
-			"error": "Agent not found",
+			"error": i18n.T(ctx, "agent.not_found"),
 		})
 		return
 	}
@@ -1108,7 +1826,7 @@ func (h *Handler) AgentState(ctx context.Context, c *app.RequestContext) {
 func (h *Handler) AgentResume(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil || h.agentScheduler == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
@@ -1116,7 +1834,7 @@ func (h *Handler) AgentResume(ctx context.Context, c *app.RequestContext) {
 	agent, _ := h.agentManager.Get(ctx, id)
 	if agent == nil {
 		c.JSON(consts.StatusNotFound, map[string]string{
-			"error": "Agent not found",
+			"error": i18n.T(ctx, "agent.not_found"),
 		})
 		return
 	}
@@ -1131,7 +1849,7 @@ func (h *Handler) AgentResume(ctx context.Context, c *app.RequestContext) {
 func (h *Handler) AgentStop(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil || h.agentScheduler == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
@@ -1139,7 +1857,7 @@ func (h *Handler) AgentStop(ctx context.Context, c *app.RequestContext) {
 	agent, _ := h.agentManager.Get(ctx, id)
 	if agent == nil {
 		c.JSON(consts.StatusNotFound, map[string]string{
-			"error": "Agent not found",
+			"error": i18n.T(ctx, "agent.not_found"),
 		})
 		return
 	}
@@ -1154,26 +1872,26 @@ func (h *Handler) AgentStop(ctx context.Context, c *app.RequestContext) {
 func (h *Handler) ListAgentJobs(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
 	if h.jobStore == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Job 未启用",
+			"error": i18n.T(ctx, "job.store_disabled"),
 		})
 		return
 	}
 	id := c.Param("id")
 	if _, err := h.agentManager.Get(ctx, id); err != nil {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "Agent not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "agent.not_found")})
 		return
 	}
 	tenantID := auth.GetTenantID(ctx)
 	list, err := h.jobStore.ListByAgent(ctx, id, tenantID)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "列出 Job failed: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "列出任务failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
 		return
 	}
 	statusFilter := c.Query("status")
@@ -1197,7 +1915,7 @@ func (h *Handler) ListAgentJobs(ctx context.Context, c *app.RequestContext) {
 	}
 	out := make([]map[string]interface{}, 0, len(jobs))
 	for _, j := range jobs {
-		out = append(out, map[string]interface{}{
+		item := map[string]interface{}{
 			"id":          j.ID,
 			"agent_id":    j.AgentID,
 			"goal":        j.Goal,
@@ -1206,7 +1924,11 @@ func (h *Handler) ListAgentJobs(ctx context.Context, c *app.RequestContext) {
 			"retry_count": j.RetryCount,
 			"created_at":  j.CreatedAt,
 			"updated_at":  j.UpdatedAt,
-		})
+		}
+		if j.Terminal != nil {
+			item["terminal_info"] = j.Terminal
+		}
+		out = append(out, item)
 	}
 	c.JSON(consts.StatusOK, map[string]interface{}{
 		"jobs":  out,
@@ -1218,7 +1940,7 @@ func (h *Handler) ListAgentJobs(ctx context.Context, c *app.RequestContext) {
 func (h *Handler) GetAgentJob(ctx context.Context, c *app.RequestContext) {
 	if h.jobStore == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Job 未启用",
+			"error": i18n.T(ctx, "job.store_disabled"),
 		})
 		return
 	}
@@ -1226,11 +1948,11 @@ func (h *Handler) GetAgentJob(ctx context.Context, c *app.RequestContext) {
 	jobID := c.Param("job_id")
 	j, err := h.jobStore.Get(ctx, jobID)
 	if err != nil || j == nil {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "任务not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
 		return
 	}
 	if j.AgentID != id {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "任务not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
 		return
 	}
 	tid := auth.GetTenantID(ctx)
@@ -1238,10 +1960,10 @@ func (h *Handler) GetAgentJob(ctx context.Context, c *app.RequestContext) {
 		tid = "default"
 	}
 	if j.TenantID != tid {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "任务not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
 		return
 	}
-	c.JSON(consts.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"id":          j.ID,
 		"agent_id":    j.AgentID,
 		"goal":        j.Goal,
@@ -1250,14 +1972,18 @@ func (h *Handler) GetAgentJob(ctx context.Context, c *app.RequestContext) {
 		"retry_count": j.RetryCount,
 		"created_at":  j.CreatedAt,
 		"updated_at":  j.UpdatedAt,
-	})
+	}
+	if j.Terminal != nil {
+		resp["terminal_info"] = j.Terminal
+	}
+	c.JSON(consts.StatusOK, resp)
 }
 
 // ListAgents 列出所有 Agent
 func (h *Handler) ListAgents(ctx context.Context, c *app.RequestContext) {
 	if h.agentManager == nil {
 		c.JSON(consts.StatusServiceUnavailable, map[string]string{
-			"error": "Agent Runtime not configured",
+			"error": i18n.T(ctx, "agent.runtime_not_configured"),
 		})
 		return
 	}
@@ -1265,7 +1991,7 @@ func (h *Handler) ListAgents(ctx context.Context, c *app.RequestContext) {
 	if err != nil {
 		hlog.CtxErrorf(ctx, "列出 Agent failed: %v", err)
 		c.JSON(consts.StatusInternalServerError, map[string]string{
-			"error": "列出 Agent failed",
+			"error": i18n.T(ctx, "agent.list_failed"),
 		})
 		return
 	}
@@ -1284,10 +2010,23 @@ func (h *Handler) ListAgents(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+const (
+	// defaultJobWaitTimeout GET /api/jobs/:id/wait 未指定 timeout 时的最长等待
+	defaultJobWaitTimeout = 30 * time.Second
+	// maxJobWaitTimeout 长轮询等待上限，避免连接被代理/负载均衡中断
+	maxJobWaitTimeout = 2 * time.Minute
+	// jobWaitPollInterval 长轮询期间复查 Job 状态的间隔
+	jobWaitPollInterval = 500 * time.Millisecond
+	// jobWaitResponseTimeout 等待结束后组装响应（Job 详情、事件）的时限；请求截止时间已到时仍可返回结果
+	jobWaitResponseTimeout = 5 * time.Second
+	// jobWaitDeadlineMargin 请求带截止时间时，等待提前于截止时间结束的余量，留给组装响应
+	jobWaitDeadlineMargin = time.Second
+)
+
 // GetJob 按 job_id 返回 Job 元数据（供 Trace 等使用）；若 status 为 waiting 则附带 wait_correlation_key 供 JobSignal 使用（design/runtime-contract.md）
 func (h *Handler) GetJob(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "Job 未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
 		return
 	}
 	jobID := c.Param("id")
@@ -1295,6 +2034,11 @@ func (h *Handler) GetJob(ctx context.Context, c *app.RequestContext) {
 	if !ok {
 		return
 	}
+	c.JSON(consts.StatusOK, h.jobDetail(ctx, j))
+}
+
+// jobDetail 构建 GET /api/jobs/:id 响应体（GetJob 与 WaitJob 共用）
+func (h *Handler) jobDetail(ctx context.Context, j *job.Job) map[string]interface{} {
 	resp := map[string]interface{}{
 		"id":          j.ID,
 		"agent_id":    j.AgentID,
@@ -1305,8 +2049,24 @@ func (h *Handler) GetJob(ctx context.Context, c *app.RequestContext) {
 		"created_at":  j.CreatedAt,
 		"updated_at":  j.UpdatedAt,
 	}
-	if j.Status == job.StatusWaiting && h.jobEventStore != nil {
-		events, _, _ := h.jobEventStore.ListEvents(ctx, jobID)
+	if j.Terminal != nil {
+		resp["terminal_info"] = j.Terminal
+	}
+	if j.Attribution != nil {
+		resp["attribution"] = j.Attribution
+	}
+	resp["lane"] = job.LaneOf(j)
+	if j.Profile != "" {
+		resp["profile"] = j.Profile
+	}
+	var events []jobstore.JobEvent
+	if j.Status == job.StatusWaiting || h.etaEstimator != nil {
+		events, _, _ = h.jobEventStore.ListEvents(ctx, j.ID)
+	}
+	if h.etaEstimator != nil {
+		resp["eta"] = h.etaEstimator.Estimate(j, events, time.Now())
+	}
+	if j.Status == job.StatusWaiting {
 		for i := len(events) - 1; i >= 0; i-- {
 			if events[i].Type == jobstore.JobWaiting {
 				p, _ := jobstore.ParseJobWaitingPayload(events[i].Payload)
@@ -1318,9 +2078,82 @@ func (h *Handler) GetJob(ctx context.Context, c *app.RequestContext) {
 			}
 		}
 	}
+	return resp
+}
+
+// WaitJob 长轮询：阻塞至 Job 进入终态（completed/failed/cancelled）或 timeout（默认 30s，最长 2m，且不超过请求截止时间）到期，
+// 返回与 GET /api/jobs/:id 相同的 Job 详情，附带 terminal 与 timed_out；客户端断开时提前返回
+// GET /api/jobs/:id/wait?timeout=60s
+func (h *Handler) WaitJob(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil || h.jobStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	timeout := defaultJobWaitTimeout
+	if v := c.Query("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.timeout_invalid")})
+			return
+		}
+		timeout = min(d, maxJobWaitTimeout)
+	}
+	// 请求截止时间剩余不足以等待时直接按超时返回
+	deadlineSpent := false
+	if deadline, ok := ctx.Deadline(); ok && timeout > 0 {
+		timeout = max(0, min(timeout, time.Until(deadline)-jobWaitDeadlineMargin))
+		deadlineSpent = timeout == 0
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	timedOut := deadlineSpent && !j.Status.IsTerminal()
+	if !j.Status.IsTerminal() && timeout > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		// 事件追加时立即复查；Job 状态由执行方单独更新，故同时按固定间隔复查
+		events, _ := h.jobEventStore.Watch(waitCtx, jobID) // 订阅failed时 events 为 nil，仅按间隔复查
+		ticker := time.NewTicker(jobWaitPollInterval)
+		defer ticker.Stop()
+		for !j.Status.IsTerminal() {
+			select {
+			case <-waitCtx.Done():
+			case _, open := <-events:
+				if !open {
+					events = nil
+				}
+			case <-ticker.C:
+			}
+			if waitCtx.Err() != nil {
+				break
+			}
+			latest, err := h.jobStore.Get(ctx, jobID)
+			if err != nil || latest == nil {
+				hlog.CtxErrorf(ctx, "WaitJob get job %s: %v", jobID, err)
+				c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.get_failed")})
+				return
+			}
+			j = latest
+		}
+		// 等待时限或请求截止时间到期均视为超时；仅客户端断开（ctx 取消）不算
+		timedOut = !j.Status.IsTerminal() && !errors.Is(ctx.Err(), context.Canceled)
+	}
+	// 请求 ctx 可能已到截止时间，响应在独立的短时 ctx 上组装
+	respCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobWaitResponseTimeout)
+	defer cancel()
+	resp := h.jobDetail(respCtx, j)
+	resp["terminal"] = j.Status.IsTerminal()
+	resp["timed_out"] = timedOut
 	c.JSON(consts.StatusOK, resp)
 }
 
+// JobStopRequest POST /api/jobs/:id/stop 可选请求体：取消原因随终态元数据持久化并写入 job_cancelled
+type JobStopRequest struct {
+	Reason string `json:"reason"`
+}
+
 // JobStop 请求取消执行中的 Job（POST /api/jobs/:id/stop）；Worker 轮询到后取消 runCtx，Job 进入 CANCELLED
 func (h *Handler) JobStop(ctx context.Context, c *app.RequestContext) {
 	jobID := c.Param("id")
@@ -1329,14 +2162,30 @@ func (h *Handler) JobStop(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 	if j.Status == job.StatusCompleted || j.Status == job.StatusFailed || j.Status == job.StatusCancelled {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "任务已结束，无法取消"})
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "job.already_finished")})
 		return
 	}
+	var req JobStopRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+			return
+		}
+	}
 	if err := h.jobStore.RequestCancel(ctx, jobID); err != nil {
 		hlog.CtxErrorf(ctx, "RequestCancel failed: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "取消failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.cancel_failed")})
 		return
 	}
+	// 取消原因与发起者先行持久化，Worker 中断执行后写入 job_cancelled 并补全失败分类与补偿状态
+	reason := req.Reason
+	if reason == "" {
+		reason = "cancel_requested"
+	}
+	cancelInfo := &job.TerminalInfo{Reason: reason, Actor: auth.GetUserID(ctx), FailureClass: job.FailureClassCancelled}
+	if err := job.RecordTerminalInfo(ctx, h.jobStore, jobID, cancelInfo); err != nil {
+		hlog.CtxErrorf(ctx, "RecordTerminalInfo failed: %v", err)
+	}
 	c.JSON(consts.StatusOK, map[string]interface{}{
 		"job_id":  jobID,
 		"status":  "cancelling",
@@ -1370,23 +2219,31 @@ func lastEventIsWaitCompletedWithCorrelationKey(events []jobstore.JobEvent, corr
 // JobSignal 向挂起的 Job 发送 signal，写入 wait_completed 事件并将 Job 置回 Pending 供 Worker 认领继续
 func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "Job 或事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
 		return
 	}
-	jobID := c.Param("id")
-	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	j, ok := h.getJobAndCheckTenant(ctx, c, c.Param("id"))
 	if !ok {
 		return
 	}
-	if j.Status != job.StatusWaiting && j.Status != job.StatusParked {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "任务未在等待状态（Waiting/Parked），无法 signal"})
+	var req JobSignalRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "signal.correlation_key_required")})
 		return
 	}
+	c.JSON(h.deliverSignal(ctx, j, req))
+}
+
+// deliverSignal JobSignal 与入站 Webhook 共用：校验 Job 挂起且 correlation_key 一致，先写 inbox 再追加 wait_completed；返回 HTTP 状态码与响应体
+func (h *Handler) deliverSignal(ctx context.Context, j *job.Job, req JobSignalRequest) (int, interface{}) {
+	jobID := j.ID
+	if j.Status != job.StatusWaiting && j.Status != job.StatusParked {
+		return consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "signal.job_not_waiting")}
+	}
 	events, ver, err := h.jobEventStore.ListEvents(ctx, jobID)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取事件failed"})
-		return
+		return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")}
 	}
 	var waitPayload jobstore.JobWaitingPayload
 	for i := len(events) - 1; i >= 0; i-- {
@@ -1396,26 +2253,21 @@ func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 		}
 	}
 	if waitPayload.CorrelationKey == "" {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "job_waiting not found (missing correlation_key)"})
-		return
+		return consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "signal.waiting_not_found")}
 	}
-	var req JobSignalRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "请求体需包含 correlation_key"})
-		return
+	if req.CorrelationKey == "" {
+		return consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "signal.correlation_key_required")}
 	}
 	if req.CorrelationKey != waitPayload.CorrelationKey {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "correlation_key 与当前等待不匹配"})
-		return
+		return consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "signal.correlation_key_mismatch")}
 	}
 	// 幂等：若最后一条事件已是 wait_completed 且 correlation_key 一致，视为已送达，直接 200
 	if lastEventIsWaitCompletedWithCorrelationKey(events, req.CorrelationKey) {
-		c.JSON(consts.StatusOK, map[string]interface{}{
+		return consts.StatusOK, map[string]interface{}{
 			"job_id":  jobID,
 			"status":  j.Status,
 			"message": "signal 已送达（幂等）",
-		})
-		return
+		}
 	}
 	if req.Payload == nil {
 		req.Payload = make(map[string]interface{})
@@ -1423,8 +2275,7 @@ func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 	nodeID := waitPayload.NodeID
 	payloadBytes, errMarshal := marshalJSON(ctx, req.Payload, "job_signal_request_payload")
 	if errMarshal != nil {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "signal payload 非法，无法序列化"})
-		return
+		return consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "signal.payload_invalid")}
 	}
 	// 2.0 at-least-once：先写持久化 inbox，再 Append wait_completed，API 崩溃不丢 signal
 	var signalID string
@@ -1432,8 +2283,7 @@ func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 		signalID, err = h.signalInbox.Append(ctx, jobID, req.CorrelationKey, payloadBytes)
 		if err != nil {
 			hlog.CtxErrorf(ctx, "SignalInbox.Append: %v", err)
-			c.JSON(consts.StatusInternalServerError, map[string]string{"error": "写入 signal 收件箱failed"})
-			return
+			return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "signal.inbox_write_failed")}
 		}
 	}
 	evPayload, errMarshal := marshalJSON(ctx, map[string]interface{}{
@@ -1442,8 +2292,7 @@ func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 		"correlation_key": req.CorrelationKey,
 	}, "job_wait_completed_payload")
 	if errMarshal != nil {
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "构建 wait_completed 事件failed"})
-		return
+		return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "signal.build_event_failed")}
 	}
 	_, err = h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
 		JobID: jobID, Type: jobstore.WaitCompleted, Payload: evPayload,
@@ -1455,17 +2304,15 @@ func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 				if h.signalInbox != nil && signalID != "" {
 					_ = h.signalInbox.MarkAcked(ctx, jobID, signalID)
 				}
-				c.JSON(consts.StatusOK, map[string]interface{}{
+				return consts.StatusOK, map[string]interface{}{
 					"job_id":  jobID,
 					"status":  j.Status,
 					"message": "signal 已送达（并发幂等）",
-				})
-				return
+				}
 			}
 		}
 		hlog.CtxErrorf(ctx, "Append WaitCompleted: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "write event failed"})
-		return
+		return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.write_event_failed")}
 	}
 	if err := h.jobStore.UpdateStatus(ctx, jobID, job.StatusPending); err != nil {
 		hlog.CtxErrorf(ctx, "UpdateStatus Pending: %v", err)
@@ -1476,11 +2323,11 @@ func (h *Handler) JobSignal(ctx context.Context, c *app.RequestContext) {
 	if h.wakeupQueue != nil {
 		_ = h.wakeupQueue.NotifyReady(ctx, jobID)
 	}
-	c.JSON(consts.StatusOK, map[string]interface{}{
+	return consts.StatusOK, map[string]interface{}{
 		"job_id":  jobID,
 		"status":  "pending",
 		"message": "已发送 signal，Job 将重新入队执行",
-	})
+	}
 }
 
 // JobMessageRequest POST /api/jobs/:id/message 请求体；向 Job 投递信箱消息，若 Job 处于 Waiting 且 wait_type=message 且 channel/correlation_key 匹配则写入 wait_completed 并重新入队（design/agent-process-model.md Mailbox）
@@ -1494,23 +2341,43 @@ type JobMessageRequest struct {
 // JobMessage 向指定 Job 写入一条 agent_message 事件；若 Job 处于 Waiting 且当前 job_waiting 的 wait_type=message 且 channel 或 correlation_key 匹配，则追加 wait_completed 并将 Job 置为 Pending
 func (h *Handler) JobMessage(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "Job 或事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
 		return
 	}
-	jobID := c.Param("id")
-	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	j, ok := h.getJobAndCheckTenant(ctx, c, c.Param("id"))
 	if !ok {
 		return
 	}
 	var req JobMessageRequest
 	if err := c.BindJSON(&req); err != nil {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "请求体需为 JSON"})
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
 		return
 	}
+	c.JSON(h.deliverMessage(ctx, j, req))
+}
+
+// agentMessageDelivered 事件中是否已有该 message_id 的 agent_message（调用方显式指定 message_id 的重复投递按幂等处理）
+func agentMessageDelivered(events []jobstore.JobEvent, messageID string) bool {
+	for _, e := range events {
+		if e.Type != jobstore.AgentMessage {
+			continue
+		}
+		var m jobstore.AgentMessagePayload
+		if json.Unmarshal(e.Payload, &m) == nil && m.MessageID == messageID {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverMessage JobMessage 与入站 Webhook 共用：写入 agent_message，匹配当前 message 等待时解除等待；返回 HTTP 状态码与响应体
+func (h *Handler) deliverMessage(ctx context.Context, j *job.Job, req JobMessageRequest) (int, interface{}) {
+	jobID := j.ID
 	if req.Payload == nil {
 		req.Payload = make(map[string]interface{})
 	}
-	if req.MessageID == "" {
+	explicitID := req.MessageID != ""
+	if !explicitID {
 		req.MessageID = fmt.Sprintf("msg-%d", time.Now().UnixNano())
 	}
 	msgPayload := jobstore.AgentMessagePayload{
@@ -1521,22 +2388,26 @@ func (h *Handler) JobMessage(ctx context.Context, c *app.RequestContext) {
 	}
 	msgBytes, errMarshal := marshalJSON(ctx, msgPayload, "job_message_payload")
 	if errMarshal != nil {
-		c.JSON(consts.StatusBadRequest, map[string]string{"error": "message payload 非法，无法序列化"})
-		return
+		return consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "message.payload_invalid")}
 	}
 	events, ver, err := h.jobEventStore.ListEvents(ctx, jobID)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取事件failed"})
-		return
+		return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")}
+	}
+	if explicitID && agentMessageDelivered(events, req.MessageID) {
+		return consts.StatusOK, map[string]interface{}{
+			"job_id":     jobID,
+			"message_id": req.MessageID,
+			"message":    "消息已投递（幂等）",
+		}
 	}
 	_, err = h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
 		JobID: jobID, Type: jobstore.AgentMessage, Payload: msgBytes,
 	})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Append AgentMessage: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "写入消息failed"})
-		return
+		return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "message.write_failed")}
 	}
 	if h.agentMessagingBus != nil {
 		_, _ = h.agentMessagingBus.Send(ctx, "", j.AgentID, req.Payload, &messaging.SendOptions{Channel: req.Channel, Kind: messaging.KindUser})
@@ -1554,12 +2425,11 @@ func (h *Handler) JobMessage(ctx context.Context, c *app.RequestContext) {
 		if matches {
 			// 幂等：若最后一条事件已是 wait_completed 且 correlation_key 一致，视为已送达
 			if lastEventIsWaitCompletedWithCorrelationKey(events, waitPayload.CorrelationKey) {
-				c.JSON(consts.StatusOK, map[string]interface{}{
+				return consts.StatusOK, map[string]interface{}{
 					"job_id":  jobID,
 					"status":  "pending",
 					"message": "消息已投递并解除等待（幂等）",
-				})
-				return
+				}
 			}
 			evPayload, errMarshal := marshalJSON(ctx, map[string]interface{}{
 				"node_id":         waitPayload.NodeID,
@@ -1567,8 +2437,7 @@ func (h *Handler) JobMessage(ctx context.Context, c *app.RequestContext) {
 				"correlation_key": waitPayload.CorrelationKey,
 			}, "job_message_wait_completed_payload")
 			if errMarshal != nil {
-				c.JSON(consts.StatusInternalServerError, map[string]string{"error": "构建 wait_completed 事件failed"})
-				return
+				return consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "signal.build_event_failed")}
 			}
 			_, ver2, _ := h.jobEventStore.ListEvents(ctx, jobID)
 			_, _ = h.jobEventStore.Append(ctx, jobID, ver2, jobstore.JobEvent{
@@ -1578,36 +2447,48 @@ func (h *Handler) JobMessage(ctx context.Context, c *app.RequestContext) {
 			if h.wakeupQueue != nil {
 				_ = h.wakeupQueue.NotifyReady(ctx, jobID)
 			}
-			c.JSON(consts.StatusOK, map[string]interface{}{
+			return consts.StatusOK, map[string]interface{}{
 				"job_id":  jobID,
 				"status":  "pending",
 				"message": "已投递消息并解除等待，Job 将重新入队执行",
-			})
-			return
+			}
 		}
 	}
-	c.JSON(consts.StatusOK, map[string]interface{}{
+	return consts.StatusOK, map[string]interface{}{
 		"job_id":  jobID,
 		"message": "已写入 agent_message 事件",
-	})
+	}
 }
 
 // GetJobReplay 返回只读的 Replay 视图（从事件流推导，不触发任何执行）；含 current_state 供 Query 语义（design/agent-process-model.md）
 func (h *Handler) GetJobReplay(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
 		return
 	}
 	jobID := c.Param("id")
-	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+	j, cold, ok := h.getJobOrArchived(ctx, c, jobID)
+	if !ok {
 		return
 	}
-	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	ctx = h.debugSessionContext(ctx, c, jobID)
+	stepNodeID := c.Query("step_node_id")
+	key, cacheable := h.jobReadKey(ctx, "replay", jobID, j, cold, stepNodeID)
+	h.serveJobRead(ctx, c, key, cacheable, func() map[string]interface{} {
+		return h.jobReplayView(ctx, c, jobID, j, cold, stepNodeID)
+	})
+}
+
+// jobReplayView 构建 GetJobReplay 的响应；读取事件failed时写错误响应并返回 nil
+func (h *Handler) jobReplayView(ctx context.Context, c *app.RequestContext, jobID string, j *job.Job, cold *coldRead, stepNodeID string) map[string]interface{} {
+	events, cold, err := h.listJobEvents(ctx, jobID, cold)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取 Replay failed: " + err.Error()})
-		return
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.replay_failed", err.Error())})
+		return nil
 	}
+	envCheck := h.environmentCheck(events)
+	events = h.viewEvents(ctx, events)
 	timeline := make([]map[string]interface{}, 0, len(events))
 	for _, e := range events {
 		payload := json.RawMessage(e.Payload)
@@ -1627,11 +2508,10 @@ func (h *Handler) GetJobReplay(ctx context.Context, c *app.RequestContext) {
 		}
 		timeline = append(timeline, entry)
 	}
-	goal := ""
-	if h.jobStore != nil {
-		if j, _ := h.jobStore.Get(ctx, jobID); j != nil {
-			goal = j.Goal
-		}
+	goal := j.Goal
+	mask := h.traceMaskFor(ctx)
+	if mask.Masks("goal") && goal != "" {
+		goal = TraceMaskedValue
 	}
 	resp := map[string]interface{}{
 		"job_id":    jobID,
@@ -1639,9 +2519,14 @@ func (h *Handler) GetJobReplay(ctx context.Context, c *app.RequestContext) {
 		"read_only": true,
 		"timeline":  timeline,
 	}
-	stepNodeID := c.Query("step_node_id")
+	if cold != nil {
+		resp["archive"] = cold.info
+	}
+	if envCheck != nil {
+		resp["environment"] = envCheck
+	}
 	// Query 语义：当前执行状态（已完成节点、游标、阶段），不推进执行
-	builder := replay.NewReplayContextBuilder(h.jobEventStore)
+	builder := replay.NewReplayContextBuilder(h.eventStoreFor(jobID, cold))
 	if rc, errBuild := builder.BuildFromEvents(ctx, jobID); errBuild == nil && rc != nil {
 		completedIDs := make([]string, 0, len(rc.CompletedNodeIDs))
 		for id := range rc.CompletedNodeIDs {
@@ -1674,6 +2559,11 @@ func (h *Handler) GetJobReplay(ctx context.Context, c *app.RequestContext) {
 			if resultBytes, ok := rc.CommandResults[stepNodeID]; ok && len(resultBytes) > 0 {
 				stepResult = json.RawMessage(resultBytes)
 			}
+			// 回放上下文直接读取事件存储，受限查看者的步骤状态与结果在此遮蔽
+			if mask != nil {
+				stateAtStep = mask.MaskPayload(stateAtStep)
+				stepResult, _ = json.Marshal(TraceMaskedValue)
+			}
 			resp["step_replay"] = map[string]interface{}{
 				"step_node_id":  stepNodeID,
 				"state_at_step": stateAtStep,
@@ -1681,49 +2571,95 @@ func (h *Handler) GetJobReplay(ctx context.Context, c *app.RequestContext) {
 			}
 		}
 	}
-	c.JSON(consts.StatusOK, resp)
+	return resp
 }
 
 // GetJobEvents 返回该 Job 的原始事件列表
 func (h *Handler) GetJobEvents(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, cold, ok := h.getJobOrArchived(ctx, c, jobID)
+	if !ok {
 		return
 	}
+	ctx = h.debugSessionContext(ctx, c, jobID)
+	key, cacheable := h.jobReadKey(ctx, "events", jobID, j, cold)
+	h.serveJobRead(ctx, c, key, cacheable, func() map[string]interface{} {
+		return h.jobEventsView(ctx, c, jobID, cold)
+	})
+}
+
+// GetJobTransitions 返回该 Job 已记录的状态迁移（from/to/reason/at，按发生顺序）；JobStore 不记录迁移时返回空列表
+func (h *Handler) GetJobTransitions(ctx context.Context, c *app.RequestContext) {
 	jobID := c.Param("id")
 	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
 		return
 	}
-	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	out := make([]map[string]interface{}, 0)
+	if lister, ok := h.jobStore.(job.TransitionLister); ok {
+		list, err := lister.ListTransitions(ctx, jobID)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ListTransitions: %v", err)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_transitions_failed")})
+			return
+		}
+		for _, t := range list {
+			out = append(out, map[string]interface{}{
+				"from":   t.From.String(),
+				"to":     t.To.String(),
+				"reason": t.Reason,
+				"at":     t.At,
+			})
+		}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"job_id": jobID, "transitions": out})
+}
+
+// jobEventsView 构建 GetJobEvents 的响应；读取事件failed时写错误响应并返回 nil
+func (h *Handler) jobEventsView(ctx context.Context, c *app.RequestContext, jobID string, cold *coldRead) map[string]interface{} {
+	events, cold, err := h.listJobEvents(ctx, jobID, cold)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取事件failed"})
-		return
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return nil
 	}
+	events = h.viewEvents(ctx, events)
 	out := make([]map[string]interface{}, 0, len(events))
 	for _, e := range events {
 		payload := json.RawMessage(e.Payload)
 		if len(e.Payload) == 0 {
 			payload = []byte("null")
 		}
-		out = append(out, map[string]interface{}{
+		item := map[string]interface{}{
 			"id":         e.ID,
 			"job_id":     e.JobID,
 			"type":       string(e.Type),
 			"payload":    payload,
 			"created_at": e.CreatedAt,
-		})
+			"actor":      e.Actor,
+		}
+		if e.Attribution != nil {
+			item["attribution"] = e.Attribution
+		}
+		out = append(out, item)
 	}
-	c.JSON(consts.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"job_id": jobID,
 		"events": out,
-	})
+	}
+	if cold != nil {
+		resp["archive"] = cold.info
+	}
+	return resp
 }
 
 // GetJobVerify 返回 Job 执行验证证明（design/verification-mode.md）：execution_hash、event_chain_root_hash、ledger proof、replay proof
 func (h *Handler) GetJobVerify(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
 		return
 	}
 	jobID := c.Param("id")
@@ -1733,38 +2669,66 @@ func (h *Handler) GetJobVerify(ctx context.Context, c *app.RequestContext) {
 	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取事件failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
 		return
 	}
 	var replayBuilder replay.ReplayContextBuilder
 	if h.jobEventStore != nil {
 		replayBuilder = replay.NewReplayContextBuilder(h.jobEventStore)
 	}
-	result, err := verify.Compute(ctx, events, jobID, replayBuilder)
+	var att *verify.Attestation
+	if h.attestations != nil {
+		if att, err = h.attestations.Get(ctx, jobID); err != nil {
+			hlog.CtxErrorf(ctx, "Get attestation: %v", err)
+			att = nil
+		} else if att == nil {
+			// 早于证明记录功能结束的 Job：事件仍完整时补写，后续事件被删除后仍可验证
+			if att = verify.NewAttestation(jobID, events); att != nil {
+				if err := h.attestations.Put(ctx, att); err != nil {
+					hlog.CtxErrorf(ctx, "Put attestation: %v", err)
+				}
+			}
+		}
+	}
+	result, err := verify.ComputeWithAttestation(ctx, events, jobID, replayBuilder, att)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Verify Compute: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "验证计算failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "verify.failed")})
 		return
 	}
+	result.Environment = h.environmentCheck(events)
 	c.JSON(consts.StatusOK, result)
 }
 
 // GetJobTrace 返回执行时间线（由事件流派生）
 func (h *Handler) GetJobTrace(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
 		return
 	}
 	jobID := c.Param("id")
-	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+	j, cold, ok := h.getJobOrArchived(ctx, c, jobID)
+	if !ok {
 		return
 	}
-	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	ctx = h.debugSessionContext(ctx, c, jobID)
+	// 子 Job 的进展不产生父 Job 事件，层级摘要计入 key
+	hier := h.jobHierarchy(ctx, jobID)
+	key, cacheable := h.jobReadKey(ctx, "trace", jobID, j, cold, hierarchyFingerprint(hier))
+	h.serveJobRead(ctx, c, key, cacheable, func() map[string]interface{} {
+		return h.jobTraceView(ctx, c, jobID, j, cold, hier)
+	})
+}
+
+// jobTraceView 构建 GetJobTrace 的响应；读取事件failed时写错误响应并返回 nil
+func (h *Handler) jobTraceView(ctx context.Context, c *app.RequestContext, jobID string, j *job.Job, cold *coldRead, hier *job.JobHierarchy) map[string]interface{} {
+	events, cold, err := h.listJobEvents(ctx, jobID, cold)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取时间线failed: " + err.Error()})
-		return
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "trace.timeline_failed", err.Error())})
+		return nil
 	}
+	events = h.viewEvents(ctx, events)
 	timeline := make([]map[string]interface{}, 0, len(events))
 	nodeStarted := make(map[string]time.Time)
 	nodeDurations := make([]map[string]interface{}, 0)
@@ -1810,6 +2774,29 @@ func (h *Handler) GetJobTrace(ctx context.Context, c *app.RequestContext) {
 		"execution_tree":    executionTree,
 		"timeline_segments": narrative.TimelineSegments,
 		"steps":             narrative.Steps,
+		"citations":         evidence.CitationsFromEvents(evidenceEventsOf(events)),
+		"plan_estimate":     h.planEstimateFromEvents(events),
+	}
+	if j != nil && j.Terminal != nil {
+		resp["terminal_info"] = j.Terminal
+	}
+	if hier != nil {
+		resp["hierarchy"] = hier
+	}
+	if j.Attribution != nil {
+		resp["attribution"] = j.Attribution
+	}
+	if d := diagnosis.FromEvents(events); d != nil {
+		resp["diagnosis"] = d
+	}
+	if ce := jobstore.CustomEventsOf(events); len(ce) > 0 {
+		resp["custom_events"] = ce
+	}
+	if txs := jobstore.TransactionsOf(events); len(txs) > 0 {
+		resp["transactions"] = txs
+	}
+	if cold != nil {
+		resp["archive"] = cold.info
 	}
 	for _, e := range events {
 		if e.Type == jobstore.DecisionSnapshot && len(e.Payload) > 0 {
@@ -1820,26 +2807,28 @@ func (h *Handler) GetJobTrace(ctx context.Context, c *app.RequestContext) {
 			break
 		}
 	}
-	c.JSON(consts.StatusOK, resp)
+	return resp
 }
 
 // GetJobNode 返回某节点的相关事件与 payload（输入/输出等）
 func (h *Handler) GetJobNode(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
 		return
 	}
 	jobID := c.Param("id")
 	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
 		return
 	}
+	ctx = h.debugSessionContext(ctx, c, jobID)
 	nodeID := c.Param("node_id")
 	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取节点详情failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "trace.node_failed")})
 		return
 	}
+	events = h.viewEvents(ctx, events)
 	var nodeEvents []map[string]interface{}
 	for _, e := range events {
 		var pl map[string]interface{}
@@ -1870,16 +2859,17 @@ func (h *Handler) GetJobNode(ctx context.Context, c *app.RequestContext) {
 // GetJobCognitionTrace 返回 Trace 2.0 Cognition 聚合（design/trace-2.0-cognition.md）：reasoning_step_timeline、decision_tree、plan_evolution、tool_dependency_graph、memory_read_write
 func (h *Handler) GetJobCognitionTrace(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "事件存储未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
 		return
 	}
 	jobID := c.Param("id")
 	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取事件failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
 		return
 	}
+	events = h.viewEvents(ctx, events)
 	// reasoning_step_timeline: node_*, agent_thought_recorded, decision_made, tool_selected, tool_result_summarized
 	reasoningTypes := map[jobstore.EventType]bool{
 		jobstore.NodeStarted: true, jobstore.NodeFinished: true,
@@ -1971,7 +2961,7 @@ func (h *Handler) GetJobCognitionTrace(ctx context.Context, c *app.RequestContex
 // GetTraceOverviewPage 返回 Trace 聚合页（按 agent 展示多 Job 概览）。
 func (h *Handler) GetTraceOverviewPage(ctx context.Context, c *app.RequestContext) {
 	if h.jobStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "Job 未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
 		return
 	}
 	agentIDs := c.Query("agent_ids")
@@ -1983,13 +2973,25 @@ func (h *Handler) GetTraceOverviewPage(ctx context.Context, c *app.RequestContex
 	b.WriteString("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>Trace Overview</title><style>")
 	b.WriteString("body{font-family:-apple-system,BlinkMacSystemFont,Segoe UI,Arial,sans-serif;margin:1rem;} table{border-collapse:collapse;width:100%;margin-top:0.6rem;} th,td{border:1px solid #ddd;padding:0.4rem 0.6rem;text-align:left;} th{background:#f6f6f6;} .agent-block{margin:1rem 0;padding:0.8rem;border:1px solid #ddd;border-radius:6px;} .muted{color:#666;} input{padding:0.35rem 0.45rem;} button{padding:0.35rem 0.6rem;}")
 	b.WriteString("</style></head><body>")
-	b.WriteString("<h1>Trace UI 2.0 Overview</h1>")
-	b.WriteString("<p class=\"muted\">Multi-job aggregation by agent. Click trace links to inspect single-job details and step-level replay.</p>")
-	b.WriteString("<form id=\"q\"><label>Agent IDs (comma-separated): <input id=\"agent_ids\" name=\"agent_ids\" value=\"")
+	tr := func(key string) string { return html.EscapeString(i18n.T(ctx, key)) }
+	b.WriteString("<h1>" + tr("trace.overview.title") + "</h1>")
+	b.WriteString("<p class=\"muted\">" + tr("trace.overview.description") + "</p>")
+	b.WriteString("<form id=\"q\"><label>" + tr("trace.overview.agent_ids") + ": <input id=\"agent_ids\" name=\"agent_ids\" value=\"")
 	b.WriteString(html.EscapeString(agentIDs))
-	b.WriteString("\"/></label> <button type=\"submit\">Load</button></form>")
-	b.WriteString("<div id=\"content\"></div>")
-	b.WriteString("<script>(function(){ function esc(s){ return String(s||'').replace(/[&<>\\\"]/g,function(c){ return ({'&':'&amp;','<':'&lt;','>':'&gt;','\\\"':'&quot;'}[c]); }); } function parseIDs(){ var raw = document.getElementById('agent_ids').value || ''; return raw.split(',').map(function(s){ return s.trim(); }).filter(Boolean); } function renderBlock(agentID, jobs){ var html = '<div class=\"agent-block\"><h3>Agent: '+esc(agentID)+'</h3>'; html += '<table><thead><tr><th>Job ID</th><th>Status</th><th>Updated</th><th>Goal</th><th>Trace</th></tr></thead><tbody>'; if(!jobs || jobs.length===0){ html += '<tr><td colspan=\"5\" class=\"muted\">No jobs</td></tr>'; } else { jobs.forEach(function(j){ html += '<tr><td>'+esc(j.id)+'</td><td>'+esc(j.status)+'</td><td>'+esc(j.updated_at)+'</td><td>'+esc(j.goal)+'</td><td><a href=\"/api/jobs/'+encodeURIComponent(j.id)+'/trace/page\" target=\"_blank\">open trace</a></td></tr>'; }); } html += '</tbody></table></div>'; return html; } function load(){ var ids = parseIDs(); var content = document.getElementById('content'); if(ids.length===0){ content.innerHTML = '<p class=\"muted\">Enter at least one agent id.</p>'; return; } content.innerHTML = '<p class=\"muted\">Loading...</p>'; var reqs = ids.map(function(id){ return fetch('/api/agents/'+encodeURIComponent(id)+'/jobs?limit=50').then(function(r){ return r.ok ? r.json() : { jobs: [], _error: 'HTTP '+r.status }; }).then(function(data){ return { id:id, jobs:(data.jobs||[]), error:data._error||'' }; }).catch(function(e){ return { id:id, jobs:[], error:String(e) }; }); }); Promise.all(reqs).then(function(all){ var html=''; all.forEach(function(x){ html += renderBlock(x.id, x.jobs); if(x.error){ html += '<p class=\"muted\">'+esc(x.error)+'</p>'; } }); content.innerHTML = html; }); } document.getElementById('q').addEventListener('submit', function(e){ e.preventDefault(); load(); }); load(); })();</script>")
+	b.WriteString("\"/></label> <label>" + tr("trace.overview.window") + ": <input id=\"q_window\" size=\"6\" placeholder=\"24h\"/></label>")
+	b.WriteString(" <label>" + tr("trace.overview.from") + ": <input id=\"q_from\" type=\"datetime-local\"/></label> <label>" + tr("trace.overview.to") + ": <input id=\"q_to\" type=\"datetime-local\"/></label>")
+	b.WriteString("<p>" + tr("trace.overview.status") + ":")
+	for _, st := range traceOverviewStatuses {
+		b.WriteString(" <label><input type=\"checkbox\" class=\"q-status\" value=\"" + st + "\"/>" + st + "</label>")
+	}
+	b.WriteString("</p><p><label>" + tr("trace.overview.preset") + ": <select id=\"q_preset\"><option value=\"\"></option></select></label>")
+	b.WriteString(" <button type=\"button\" id=\"q_delete\">" + tr("trace.overview.delete_preset") + "</button> <input id=\"q_name\" size=\"12\"/> <button type=\"button\" id=\"q_save\">" + tr("trace.overview.save_preset") + "</button>")
+	b.WriteString(" <button type=\"submit\">" + tr("trace.overview.load") + "</button></p></form>")
+	b.WriteString("<div class=\"agent-block\"><h3>" + tr("trace.overview.jobs") + "</h3><table><thead><tr><th>Job ID</th><th>Agent</th><th>Status</th><th>Created</th><th>Updated</th><th>Goal</th><th>Trace</th></tr></thead><tbody id=\"rows\"></tbody></table>")
+	b.WriteString("<p id=\"status\" class=\"muted\"></p><button type=\"button\" id=\"more\" style=\"display:none\">" + tr("trace.overview.load_more") + "</button></div>")
+	b.WriteString("<div class=\"agent-block\" id=\"bottlenecks\"><h3>" + tr("trace.overview.bottleneck_heatmap") + "</h3><form id=\"bq\"><label>" + tr("trace.overview.window") + ": <input id=\"window\" value=\"24h\" size=\"6\"/></label> <button type=\"submit\">" + tr("trace.overview.analyze") + "</button></form><div id=\"heatmap\"><p class=\"muted\">Loading...</p></div></div>")
+	b.WriteString("<script>(function(){ function esc(s){ return String(s||'').replace(/[&<>\\\"]/g,function(c){ return ({'&':'&amp;','<':'&lt;','>':'&gt;','\\\"':'&quot;'}[c]); }); } function shade(v,maxv){ if(!v||!maxv){ return '#fff'; } var a=Math.min(1,v/maxv); return 'rgba(220,60,40,'+(0.1+0.8*a).toFixed(2)+')'; } function table(title,rows,cols){ var html='<h4>'+esc(title)+'</h4><table><thead><tr>'+cols.map(function(c){ return '<th>'+esc(c[0])+'</th>'; }).join('')+'</tr></thead><tbody>'; if(!rows||rows.length===0){ html+='<tr><td colspan=\"'+cols.length+'\" class=\"muted\">No data</td></tr>'; } (rows||[]).forEach(function(r){ html+='<tr>'+cols.map(function(c){ return '<td>'+esc(r[c[1]])+'</td>'; }).join('')+'</tr>'; }); return html+'</tbody></table>'; } function render(d){ var hm=d.heatmap||{rows:[],buckets:[]}; var maxv=0; hm.rows.forEach(function(r){ r.cells.forEach(function(c){ if(c.p95_ms>maxv){ maxv=c.p95_ms; } }); }); var html='<p class=\"muted\">'+esc(d.jobs_analyzed)+' jobs; step p95 '+esc(d.step_durations.p95_ms)+' ms; queue share '+esc(Math.round((d.queue_vs_execution.queue_share||0)*100))+'% (queue p95 '+esc(d.queue_vs_execution.queue_wait.p95_ms)+' ms, execution p95 '+esc(d.queue_vs_execution.execution.p95_ms)+' ms)</p>'; html+='<table><thead><tr><th>Node type</th>'+hm.buckets.map(function(b){ return '<th>'+esc(String(b).substr(11,5))+'</th>'; }).join('')+'</tr></thead><tbody>'; if(hm.rows.length===0){ html+='<tr><td class=\"muted\">No steps in window</td></tr>'; } hm.rows.forEach(function(r){ html+='<tr><td>'+esc(r.key)+'</td>'+r.cells.map(function(c){ return '<td style=\"background:'+shade(c.p95_ms,maxv)+'\" title=\"'+esc(c.count+' steps, p95 '+c.p95_ms+' ms')+'\">'+(c.count?esc(c.p95_ms):'')+'</td>'; }).join('')+'</tr>'; }); html+='</tbody></table>'; var statCols=[['Key','key'],['Count','count'],['P50 ms','p50_ms'],['P95 ms','p95_ms'],['Max ms','max_ms']]; html+=table('Slowest tools',d.slowest_tools,statCols); html+=table('Slowest node types',d.slowest_node_types,statCols); html+=table('Most retried steps',d.most_retried,[['Step','step'],['Jobs','jobs'],['Retries','retries'],['Max attempts','max_attempts']]); document.getElementById('heatmap').innerHTML=html; } function loadHeatmap(){ var w=document.getElementById('window').value||'24h'; fetch('/api/observability/bottlenecks?window='+encodeURIComponent(w)).then(function(r){ return r.ok ? r.json() : r.json().then(function(e){ throw new Error(e.error||('HTTP '+r.status)); }); }).then(render).catch(function(e){ document.getElementById('heatmap').innerHTML='<p class=\"muted\">'+esc(String(e))+'</p>'; }); } document.getElementById('bq').addEventListener('submit', function(e){ e.preventDefault(); loadHeatmap(); }); loadHeatmap(); })();</script>")
+	b.WriteString(traceOverviewScript)
 	b.WriteString("</body></html>")
 	c.WriteString(b.String())
 }
@@ -1997,7 +2999,7 @@ func (h *Handler) GetTraceOverviewPage(ctx context.Context, c *app.RequestContex
 // GetJobTracePage 返回简单 Trace 回放页（HTML）
 func (h *Handler) GetJobTracePage(ctx context.Context, c *app.RequestContext) {
 	if h.jobEventStore == nil || h.jobStore == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "Trace 未启用"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "trace.disabled")})
 		return
 	}
 	jobID := c.Param("id")
@@ -2005,25 +3007,257 @@ func (h *Handler) GetJobTracePage(ctx context.Context, c *app.RequestContext) {
 	if !ok {
 		return
 	}
+	ctx = h.debugSessionContext(ctx, c, jobID)
 	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
 	if err != nil {
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取事件failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
 		return
 	}
+	events = h.viewEvents(ctx, events)
+	if mask := h.traceMaskFor(ctx); mask.Masks("goal") && j.Goal != "" {
+		masked := *j
+		masked.Goal = TraceMaskedValue
+		j = &masked
+	}
 	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.WriteString(buildTraceHTML(jobID, j, events))
+	opts := TraceHTMLOptions{Locale: i18n.FromContext(ctx), Terminal: j.Terminal, Hierarchy: h.jobHierarchy(ctx, jobID), Attribution: j.Attribution, Branding: h.tenantBranding(ctx, j.TenantID)}
+	if h.etaEstimator != nil {
+		opts.ETA = h.etaEstimator.Estimate(j, events, time.Now())
+	}
+	c.WriteString(buildTraceHTML(jobID, j, events, opts))
 }
 
-func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string {
+func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent, opts TraceHTMLOptions) string {
 	status := "unknown"
 	goal := ""
 	if j != nil {
 		status = j.Status.String()
 		goal = j.Goal
 	}
+	return RenderTraceHTML(jobID, goal, status, events, opts)
+}
+
+// writeTraceBranding 渲染租户品牌页头（Logo、标题）与自定义字段；Logo 已由 branding.Validate 限定为 https 或 data:image URI
+func writeTraceBranding(b *strings.Builder, br *branding.Branding) {
+	if br.Title != "" || br.LogoURL != "" {
+		b.WriteString("<header class=\"trace-branding\" id=\"trace-branding\">")
+		if br.LogoURL != "" {
+			b.WriteString("<img src=\"")
+			b.WriteString(html.EscapeString(br.LogoURL))
+			b.WriteString("\" alt=\"")
+			b.WriteString(html.EscapeString(br.Title))
+			b.WriteString("\">")
+		}
+		if br.Title != "" {
+			b.WriteString("<span class=\"brand-title\">")
+			b.WriteString(html.EscapeString(br.Title))
+			b.WriteString("</span>")
+		}
+		b.WriteString("</header>")
+	}
+	if len(br.Fields) > 0 {
+		b.WriteString("<dl class=\"trace-brand-fields\" id=\"trace-brand-fields\">")
+		for _, f := range br.Fields {
+			b.WriteString("<dt>")
+			b.WriteString(html.EscapeString(f.Label))
+			b.WriteString("</dt><dd>")
+			b.WriteString(html.EscapeString(f.Value))
+			b.WriteString("</dd>")
+		}
+		b.WriteString("</dl>")
+	}
+}
+
+// writeTraceAttribution 渲染发起归属行（用户、客户端、请求 ID），空字段省略
+func writeTraceAttribution(b *strings.Builder, a *jobstore.Attribution, tr func(string) string) {
+	fields := []struct{ key, val string }{
+		{"trace.page.requested_by", a.UserID},
+		{"trace.page.client", a.Client},
+		{"trace.page.request_id", a.RequestID},
+	}
+	b.WriteString("<p class=\"trace-attribution\" id=\"trace-attribution\">")
+	first := true
+	for _, f := range fields {
+		if f.val == "" {
+			continue
+		}
+		if !first {
+			b.WriteString(" · ")
+		}
+		first = false
+		b.WriteString("<b>")
+		b.WriteString(tr(f.key))
+		b.WriteString(":</b> ")
+		b.WriteString(html.EscapeString(f.val))
+	}
+	b.WriteString("</p>")
+}
+
+// writeTraceTerminal 渲染终态元数据行，空字段省略
+func writeTraceTerminal(b *strings.Builder, t *job.TerminalInfo, tr func(string) string) {
+	fields := []struct{ key, val string }{
+		{"trace.page.terminal_reason", t.Reason},
+		{"trace.page.terminal_actor", t.Actor},
+		{"trace.page.terminal_failed_node", t.FailedNodeID},
+		{"trace.page.terminal_failure_class", t.FailureClass},
+		{"trace.page.terminal_compensation", t.Compensation},
+	}
+	b.WriteString("<p class=\"trace-terminal\" id=\"trace-terminal\">")
+	first := true
+	for _, f := range fields {
+		if f.val == "" {
+			continue
+		}
+		if !first {
+			b.WriteString(" · ")
+		}
+		first = false
+		b.WriteString("<b>")
+		b.WriteString(tr(f.key))
+		b.WriteString(":</b> ")
+		b.WriteString(html.EscapeString(f.val))
+	}
+	b.WriteString("</p>")
+}
+
+// writeTraceDiagnosis 渲染失败诊断：可能原因、修复建议与重试是否可能有效
+func writeTraceDiagnosis(b *strings.Builder, d *diagnosis.Diagnosis, tr func(string) string) {
+	retry := tr("trace.page.diagnosis_retry_unlikely")
+	if d.RetryLikelyToHelp {
+		retry = tr("trace.page.diagnosis_retry_likely")
+	}
+	b.WriteString("<div class=\"trace-diagnosis\" id=\"trace-diagnosis\"><p><b>")
+	b.WriteString(tr("trace.page.diagnosis"))
+	b.WriteString(":</b> ")
+	b.WriteString(html.EscapeString(d.ProbableCause))
+	b.WriteString("</p><p><b>")
+	b.WriteString(tr("trace.page.diagnosis_fix"))
+	b.WriteString(":</b> ")
+	b.WriteString(html.EscapeString(d.SuggestedFix))
+	b.WriteString(" · ")
+	b.WriteString(retry)
+	b.WriteString("</p></div>")
+}
+
+// writeTraceCustomEvents 渲染步骤发出的业务里程碑：事件名、所在节点与 payload
+func writeTraceCustomEvents(b *strings.Builder, events []jobstore.CustomEventPayload, tr func(string) string) {
+	b.WriteString("<div class=\"trace-milestones\" id=\"trace-milestones\"><p><b>")
+	b.WriteString(tr("trace.page.milestones"))
+	b.WriteString(":</b></p><ul>")
+	for _, e := range events {
+		b.WriteString("<li><code>")
+		b.WriteString(html.EscapeString(e.Name))
+		b.WriteString("</code>")
+		if e.NodeID != "" {
+			b.WriteString(" @ ")
+			b.WriteString(html.EscapeString(e.NodeID))
+		}
+		if len(e.Data) > 0 && string(e.Data) != "null" {
+			b.WriteString(" <span class=\"milestone-data\">")
+			b.WriteString(html.EscapeString(string(e.Data)))
+			b.WriteString("</span>")
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul></div>")
+}
+
+// writeTraceHierarchy 渲染监督者层级：上级监督者链接与子 Job 表（缩进表示嵌套层级）；links 为 false 时（离线查看）不生成 API 链接
+func writeTraceHierarchy(b *strings.Builder, hier *job.JobHierarchy, links bool, tr func(string) string) {
+	jobLink := func(id string) string {
+		if !links {
+			return html.EscapeString(id)
+		}
+		return "<a href=\"/api/jobs/" + url.PathEscape(id) + "/trace/page\">" + html.EscapeString(id) + "</a>"
+	}
+	b.WriteString("<div class=\"trace-hierarchy\" id=\"trace-hierarchy\">")
+	if hier.Parent != nil {
+		b.WriteString("<p><b>" + tr("trace.page.supervisor") + ":</b> ")
+		b.WriteString(jobLink(hier.Parent.ParentJobID))
+		b.WriteString(" &middot; " + tr("trace.page.node") + " ")
+		b.WriteString(html.EscapeString(hier.Parent.NodeID))
+		b.WriteString(" &middot; " + tr("trace.page.key") + " ")
+		b.WriteString(html.EscapeString(hier.Parent.Key))
+		b.WriteString("</p>")
+	}
+	if len(hier.Children) > 0 {
+		b.WriteString("<p><b>" + tr("trace.page.children") + ":</b> ")
+		writeTraceRollup(b, hier.Rollup, tr)
+		b.WriteString("</p><table><thead><tr><th>" + tr("trace.page.key") + "</th><th>" + tr("trace.page.job") + "</th><th>" + tr("trace.page.node") + "</th><th>" + tr("trace.page.attempt") + "</th><th>" + tr("trace.page.status") + "</th><th>" + tr("trace.page.goal") + "</th></tr></thead><tbody>")
+		var walk func(nodes []job.HierarchyNode, depth int)
+		walk = func(nodes []job.HierarchyNode, depth int) {
+			for _, n := range nodes {
+				b.WriteString("<tr><td style=\"padding-left:" + strconv.Itoa(6+depth*16) + "px\">")
+				b.WriteString(html.EscapeString(n.Key))
+				b.WriteString("</td><td>")
+				b.WriteString(jobLink(n.JobID))
+				b.WriteString("</td><td>")
+				b.WriteString(html.EscapeString(n.NodeID))
+				b.WriteString("</td><td>")
+				b.WriteString(strconv.Itoa(n.Attempt))
+				b.WriteString("</td><td>")
+				b.WriteString(html.EscapeString(n.Status))
+				if n.Rollup != nil {
+					b.WriteString(" (")
+					writeTraceRollup(b, n.Rollup, tr)
+					b.WriteString(")")
+				}
+				b.WriteString("</td><td>")
+				b.WriteString(html.EscapeString(n.Goal))
+				b.WriteString("</td></tr>")
+				walk(n.Children, depth+1)
+			}
+		}
+		walk(hier.Children, 0)
+		b.WriteString("</tbody></table>")
+	}
+	b.WriteString("</div>")
+}
+
+// writeTraceRollup 渲染子 Job 汇总：状态与完成/进行中/失败计数
+func writeTraceRollup(b *strings.Builder, r *job.ChildRollup, tr func(string) string) {
+	if r == nil {
+		return
+	}
+	b.WriteString(html.EscapeString(r.Status))
+	b.WriteString(" &middot; " + strconv.Itoa(r.Completed) + "/" + strconv.Itoa(r.Total) + " " + tr("trace.page.children_completed"))
+	if r.Active > 0 {
+		b.WriteString(", " + strconv.Itoa(r.Active) + " " + tr("trace.page.children_active"))
+	}
+	if r.Failed+r.Cancelled > 0 {
+		b.WriteString(", " + strconv.Itoa(r.Failed+r.Cancelled) + " " + tr("trace.page.children_failed"))
+	}
+}
+
+// TraceHTMLOptions 控制 Trace 页面渲染方式
+type TraceHTMLOptions struct {
+	// Offline 为 true 时页面不包含任何访问 API 的控件（如单步 replay），用于 CLI 离线查看证据包
+	Offline bool
+	// Notice 显示在页面顶部的提示（如证据包来源与校验结果），为空则不显示
+	Notice string
+	// ETA 时长预测与逐步 ETA，非 nil 时在页头 Status 下方显示
+	ETA *eta.Estimate
+	// Locale 页面标签语言（i18n 目录中的 trace.page.*）；空则使用默认语言
+	Locale string
+	// Terminal 终态元数据（取消原因、发起者、失败节点等），非 nil 时在 Status 下方显示
+	Terminal *job.TerminalInfo
+	// Hierarchy 监督者层级（监督者 → 子 Job 及汇总状态），非 nil 时在 Status 下方显示
+	Hierarchy *job.JobHierarchy
+	// Attribution 发起归属（用户、客户端、请求 ID）；为 nil 时取事件中记录的归属（离线查看证据包）
+	Attribution *jobstore.Attribution
+	// Branding 租户品牌（标题、Logo、自定义字段），非空时显示在页头并用作页面标题前缀
+	Branding *branding.Branding
+}
+
+// RenderTraceHTML 由事件流渲染自包含的 Trace 页面（样式与脚本全部内联，不依赖外部资源）；API 与 CLI 离线查看共用
+func RenderTraceHTML(jobID, goal, status string, events []jobstore.JobEvent, opts TraceHTMLOptions) string {
+	if status == "" {
+		status = "unknown"
+	}
 	escJobID := html.EscapeString(jobID)
 	escGoal := html.EscapeString(goal)
 	escStatus := html.EscapeString(status)
+	tr := func(key string) string { return html.EscapeString(i18n.Translate(opts.Locale, key)) }
 
 	tree := BuildExecutionTree(events)
 	flatSteps := FlattenSteps(tree)
@@ -2041,6 +3275,7 @@ func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string
 		})
 	}
 	dagNodes, dagEdges := DAGNodesAndEdges(tree)
+	dagGroups := DAGGroups(tree, jobstore.TransactionsOf(events))
 	traceData := map[string]interface{}{
 		"job_id":            jobID,
 		"goal":              goal,
@@ -2052,6 +3287,7 @@ func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string
 		"timeline":          timeline,
 		"dag_nodes":         dagNodes,
 		"dag_edges":         dagEdges,
+		"dag_groups":        dagGroups,
 	}
 	jsonBytes, err := json.Marshal(traceData)
 	if err != nil {
@@ -2060,7 +3296,12 @@ func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string
 	jsonStr := string(jsonBytes)
 
 	var b strings.Builder
-	b.WriteString("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>Trace ")
+	b.WriteString("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>")
+	if br := opts.Branding; br != nil && br.Title != "" {
+		b.WriteString(html.EscapeString(br.Title))
+		b.WriteString(" - ")
+	}
+	b.WriteString("Trace ")
 	b.WriteString(escJobID)
 	b.WriteString("</title><style>")
 	b.WriteString(".trace-layout{display:flex;gap:1rem;margin:1rem 0;min-height:400px;}")
@@ -2096,14 +3337,71 @@ func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string
 	b.WriteString(".dag-section h4{margin:0 0 0.5rem 0;}")
 	b.WriteString(".dag-container{min-height:120px;overflow:auto;}")
 	b.WriteString(".dag-container svg{font-size:12px;}")
+	b.WriteString(".attempt-history table{border-collapse:collapse;font-size:0.85em;margin-top:0.3rem;}")
+	b.WriteString(".attempt-history th,.attempt-history td{border:1px solid #ddd;padding:0.2rem 0.4rem;text-align:left;}")
+	b.WriteString(".attempt-history tr.attempt-failed td{background:#fdecea;}")
+	b.WriteString(".trace-eta table{border-collapse:collapse;font-size:0.85em;margin-top:0.3rem;}")
+	b.WriteString(".trace-eta th,.trace-eta td{border:1px solid #ddd;padding:0.2rem 0.4rem;text-align:left;}")
+	b.WriteString(".trace-hierarchy table{border-collapse:collapse;font-size:0.85em;margin-top:0.3rem;}")
+	b.WriteString(".trace-hierarchy th,.trace-hierarchy td{border:1px solid #ddd;padding:0.2rem 0.4rem;text-align:left;}")
+	b.WriteString(".trace-diagnosis{padding:0.2rem 0.8rem;background:#fdecea;border-left:3px solid #e57373;}")
+	b.WriteString(".trace-milestones{padding:0.2rem 0.8rem;background:#eef6ee;border-left:3px solid #66bb6a;} .trace-milestones ul{margin:0.2rem 0;} .milestone-data{color:#666;font-family:monospace;word-break:break-all;}")
+	b.WriteString(".trace-plan-review{padding:0.2rem 0.8rem;background:#e8f0fe;border-left:3px solid #5c85d6;} .trace-plan-review ul{margin:0.2rem 0;} .trace-plan-review textarea{width:100%;min-height:12em;font-family:monospace;font-size:0.85em;}")
+	b.WriteString(".trace-notice{padding:0.5rem 0.8rem;background:#fff8e1;border:1px solid #f0d58c;border-radius:6px;}")
+	b.WriteString(".trace-branding{display:flex;align-items:center;gap:0.8rem;padding-bottom:0.6rem;border-bottom:2px solid #ddd;} .trace-branding img{max-height:48px;max-width:200px;} .trace-branding .brand-title{font-size:1.4em;font-weight:600;}")
+	b.WriteString(".trace-brand-fields{display:grid;grid-template-columns:auto 1fr;gap:0.2rem 1rem;margin:0.6rem 0;} .trace-brand-fields dt{font-weight:600;} .trace-brand-fields dd{margin:0;}")
 	b.WriteString("</style></head><body>")
-	b.WriteString("<h1>Job: ")
+	if !opts.Branding.IsZero() {
+		writeTraceBranding(&b, opts.Branding)
+	}
+	if opts.Notice != "" {
+		b.WriteString("<p class=\"trace-notice\" id=\"trace-notice\">")
+		b.WriteString(html.EscapeString(opts.Notice))
+		b.WriteString("</p>")
+	}
+	b.WriteString("<h1>")
+	b.WriteString(tr("trace.page.job"))
+	b.WriteString(": ")
 	b.WriteString(escJobID)
-	b.WriteString("</h1><p><b>Goal:</b> ")
+	b.WriteString("</h1><p><b>")
+	b.WriteString(tr("trace.page.goal"))
+	b.WriteString(":</b> ")
 	b.WriteString(escGoal)
-	b.WriteString("</p><p><b>Status:</b> ")
+	b.WriteString("</p><p><b>")
+	b.WriteString(tr("trace.page.status"))
+	b.WriteString(":</b> ")
 	b.WriteString(escStatus)
 	b.WriteString("</p>")
+	attribution := opts.Attribution
+	for i := 0; attribution == nil && i < len(events); i++ {
+		attribution = events[i].Attribution
+	}
+	if !attribution.IsZero() {
+		writeTraceAttribution(&b, attribution, tr)
+	}
+	if opts.Terminal != nil {
+		writeTraceTerminal(&b, opts.Terminal, tr)
+	}
+	if d := diagnosis.FromEvents(events); d != nil {
+		writeTraceDiagnosis(&b, d, tr)
+	}
+	if ce := jobstore.CustomEventsOf(events); len(ce) > 0 {
+		writeTraceCustomEvents(&b, ce, tr)
+	}
+	if opts.ETA != nil {
+		writeTraceETA(&b, opts.ETA, tr)
+	}
+	if opts.Hierarchy != nil {
+		writeTraceHierarchy(&b, opts.Hierarchy, !opts.Offline, tr)
+	}
+	planReview := jobstore.PlanReviewOf(events)
+	if planReview != nil {
+		var taskGraph json.RawMessage
+		if plan := lastPlanGenerated(events); plan != nil {
+			taskGraph = plan.TaskGraph
+		}
+		writeTracePlanReview(&b, planReview, taskGraph, !opts.Offline, tr)
+	}
 	b.WriteString("<div class=\"event-filter-bar\" id=\"event-filter-bar\">")
 	b.WriteString("<label><input type=\"checkbox\" class=\"filter-type\" value=\"plan\" checked> plan</label>")
 	b.WriteString("<label><input type=\"checkbox\" class=\"filter-type\" value=\"node\" checked> node</label>")
@@ -2131,11 +3429,17 @@ func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string
 			b.WriteString("ms")
 		}
 		b.WriteString("</div>")
-		if st.State != "" && st.State != "ok" {
+		if (st.State != "" && st.State != "ok") || st.Attempts > 1 {
+			state := st.State
+			if state == "" {
+				state = "ok"
+			}
 			b.WriteString("<div class=\"state\">")
-			b.WriteString(html.EscapeString(st.State))
+			b.WriteString(html.EscapeString(state))
 			if st.Attempts > 1 {
-				b.WriteString(" &middot; attempt ")
+				b.WriteString(" &middot; ")
+				b.WriteString(tr("trace.page.attempt"))
+				b.WriteString(" ")
 				b.WriteString(strconv.Itoa(st.Attempts))
 			}
 			if st.WorkerID != "" {
@@ -2148,40 +3452,106 @@ func buildTraceHTML(jobID string, j *job.Job, events []jobstore.JobEvent) string
 	}
 
 	b.WriteString("</div><div class=\"detail-panel\" id=\"detail-panel\">")
-	b.WriteString("<p class=\"placeholder\" id=\"detail-placeholder\">Select a step or tree node.</p>")
+	b.WriteString("<p class=\"placeholder\" id=\"detail-placeholder\">" + tr("trace.page.select_step") + "</p>")
 	b.WriteString("<div id=\"detail-content\" style=\"display:none;\">")
-	b.WriteString("<h3>Step</h3><div class=\"step-view\" id=\"detail-step-view\"></div>")
-	b.WriteString("<h3>Replay control</h3><div><button id=\"replay-step-btn\" type=\"button\">Replay selected step</button><pre id=\"replay-step-result\"></pre></div>")
-	b.WriteString("<h3>Payload</h3><pre id=\"detail-payload\"></pre>")
-	b.WriteString("<h3>Tool I/O</h3><pre id=\"detail-tool-io\"></pre>")
-	b.WriteString("<h3>Reasoning</h3><div id=\"detail-reasoning\"></div>")
-	b.WriteString("<h3>What changed</h3><div id=\"detail-state-diff-section\"><div id=\"detail-state-diff\"></div></div>")
+	b.WriteString("<h3>" + tr("trace.page.step") + "</h3><div class=\"step-view\" id=\"detail-step-view\"></div>")
+	b.WriteString("<h3>" + tr("trace.page.attempts") + "</h3><div id=\"detail-attempts\"></div>")
+	if !opts.Offline {
+		b.WriteString("<h3>" + tr("trace.page.replay_control") + "</h3><div><button id=\"replay-step-btn\" type=\"button\">" + tr("trace.page.replay_step") + "</button><pre id=\"replay-step-result\"></pre></div>")
+	}
+	b.WriteString("<h3>" + tr("trace.page.payload") + "</h3><pre id=\"detail-payload\"></pre>")
+	b.WriteString("<h3>" + tr("trace.page.tool_io") + "</h3><pre id=\"detail-tool-io\"></pre>")
+	b.WriteString("<h3>" + tr("trace.page.reasoning") + "</h3><div id=\"detail-reasoning\"></div>")
+	b.WriteString("<h3>" + tr("trace.page.what_changed") + "</h3><div id=\"detail-state-diff-section\"><div id=\"detail-state-diff\"></div></div>")
 	b.WriteString("</div></div></div>")
-	b.WriteString("<div class=\"tree-section\"><details open><summary>Execution tree (User → Plan → Node → Tool)</summary>")
+	b.WriteString("<div class=\"tree-section\"><details open><summary>" + tr("trace.page.execution_tree") + "</summary>")
 	b.WriteString("<ul id=\"trace-tree\">")
 	b.WriteString(renderTraceTreeHTML(tree))
 	b.WriteString("</ul></details></div>")
-	b.WriteString("<div class=\"dag-section\"><h4>Execution DAG</h4><div class=\"dag-container\" id=\"dag-container\"></div></div>")
+	b.WriteString("<div class=\"dag-section\"><h4>" + tr("trace.page.execution_dag") + "</h4><div class=\"dag-container\" id=\"dag-container\"></div></div>")
 	b.WriteString("<script>window.__TRACE__ = ")
 	b.WriteString(jsonStr)
 	b.WriteString(";</script><script>")
 	writeTracePageScript(&b)
 	b.WriteString("</script><script>")
 	writeTraceFilterAndDAGScript(&b)
-	b.WriteString("</script><script>")
-	writeTraceReplayControlScript(&b)
+	if !opts.Offline {
+		b.WriteString("</script><script>")
+		writeTraceReplayControlScript(&b)
+		if planReview != nil && planReview.Status == jobstore.PlanReviewPending {
+			b.WriteString("</script><script>")
+			writeTracePlanReviewScript(&b)
+		}
+	}
 	b.WriteString("</script></body></html>")
 	return b.String()
 }
 
 // writeTraceFilterAndDAGScript writes JS for event-type filter and DAG visualization.
 func writeTraceFilterAndDAGScript(b *strings.Builder) {
-	b.WriteString("(function(){ var T = window.__TRACE__; function getFilterTypes(){ var types = []; document.querySelectorAll('.filter-type:checked').forEach(function(cb){ types.push(cb.value); }); return types; } function renderBar(){ var types = getFilterTypes(); var segs = (T.timeline_segments || []).filter(function(s){ return types.indexOf(s.type) >= 0; }); var bar = document.getElementById('timeline-bar'); if(!bar) return; bar.innerHTML = ''; segs.forEach(function(s){ var c = s.type; if(s.status === 'permanent_failure' || s.status === 'compensatable_failure') c += ' failed'; else if(s.status === 'retryable_failure') c += ' retryable'; var d = document.createElement('span'); d.className = 'seg ' + c; d.textContent = s.label + (s.duration_ms ? ' ' + s.duration_ms + 'ms' : ''); bar.appendChild(d); }); } function renderDAG(){ var nodes = T.dag_nodes || []; var edges = T.dag_edges || []; var el = document.getElementById('dag-container'); if(!el || nodes.length === 0) return; var w = Math.max(400, el.offsetWidth || 400); var h = Math.max(120, Math.min(300, nodes.length * 36)); var pad = 24; var boxW = 100; var boxH = 28; var byId = {}; nodes.forEach(function(n, i){ byId[n.id] = { n: n, x: pad + (i % 6) * (boxW + 40), y: pad + Math.floor(i / 6) * (boxH + 20) }; }); var svg = '<svg width=\"' + w + '\" height=\"' + h + '\" xmlns=\"http://www.w3.org/2000/svg\">'; edges.forEach(function(e){ var from = byId[e.from]; var to = byId[e.to]; if(from && to){ var x1 = from.x + boxW/2; var y1 = from.y + boxH; var x2 = to.x + boxW/2; var y2 = to.y; svg += '<line x1=\"' + x1 + '\" y1=\"' + y1 + '\" x2=\"' + x2 + '\" y2=\"' + y2 + '\" stroke=\"#999\" stroke-width=\"1\"/>'; } }); nodes.forEach(function(n){ var o = byId[n.id]; if(!o) return; var x = o.x; var y = o.y; var fill = '#cec'; if(n.type === 'plan') fill = '#cce'; if(n.type === 'tool') fill = '#eec'; svg += '<rect x=\"' + x + '\" y=\"' + y + '\" width=\"' + boxW + '\" height=\"' + boxH + '\" fill=\"' + fill + '\" stroke=\"#666\" rx=\"4\"/>'; svg += '<text x=\"' + (x + boxW/2) + '\" y=\"' + (y + boxH/2 + 4) + '\" text-anchor=\"middle\" font-size=\"11\">' + (n.label.length > 12 ? n.label.slice(0,11) + '…' : n.label) + '</text>'; }); svg += '</svg>'; el.innerHTML = svg; } renderBar(); renderDAG(); document.querySelectorAll('.filter-type').forEach(function(cb){ cb.addEventListener('change', renderBar); }); })();")
+	b.WriteString("(function(){ var T = window.__TRACE__; function getFilterTypes(){ var types = []; document.querySelectorAll('.filter-type:checked').forEach(function(cb){ types.push(cb.value); }); return types; } function renderBar(){ var types = getFilterTypes(); var segs = (T.timeline_segments || []).filter(function(s){ return types.indexOf(s.type) >= 0; }); var bar = document.getElementById('timeline-bar'); if(!bar) return; bar.innerHTML = ''; segs.forEach(function(s){ var c = s.type; if(s.status === 'permanent_failure' || s.status === 'compensatable_failure') c += ' failed'; else if(s.status === 'retryable_failure') c += ' retryable'; var d = document.createElement('span'); d.className = 'seg ' + c; d.textContent = s.label + (s.duration_ms ? ' ' + s.duration_ms + 'ms' : ''); bar.appendChild(d); }); } function renderDAG(){ var nodes = T.dag_nodes || []; var edges = T.dag_edges || []; var el = document.getElementById('dag-container'); if(!el || nodes.length === 0) return; var w = Math.max(400, el.offsetWidth || 400); var h = Math.max(120, Math.min(300, nodes.length * 36)); var pad = 24; var boxW = 100; var boxH = 28; var byId = {}; nodes.forEach(function(n, i){ byId[n.id] = { n: n, x: pad + (i % 6) * (boxW + 40), y: pad + Math.floor(i / 6) * (boxH + 20) }; }); var svg = '<svg width=\"' + w + '\" height=\"' + h + '\" xmlns=\"http://www.w3.org/2000/svg\">'; (T.dag_groups || []).forEach(function(g){ var ms = (g.members || []).map(function(id){ return byId[id]; }).filter(function(o){ return o; }); if(ms.length === 0) return; var x1 = Math.min.apply(null, ms.map(function(o){ return o.x; })) - 8; var y1 = Math.min.apply(null, ms.map(function(o){ return o.y; })) - 16; var x2 = Math.max.apply(null, ms.map(function(o){ return o.x; })) + boxW + 8; var y2 = Math.max.apply(null, ms.map(function(o){ return o.y; })) + boxH + 6; var stroke = '#999'; if(g.status === 'committed') stroke = '#393'; if(g.status === 'rolled_back') stroke = '#c33'; svg += '<rect x=\"' + x1 + '\" y=\"' + y1 + '\" width=\"' + (x2 - x1) + '\" height=\"' + (y2 - y1) + '\" fill=\"none\" stroke=\"' + stroke + '\" stroke-dasharray=\"4 3\" rx=\"6\"/>'; svg += '<text x=\"' + (x1 + 4) + '\" y=\"' + (y1 + 11) + '\" font-size=\"10\" fill=\"' + stroke + '\">' + g.label + ' · ' + g.status + '</text>'; }); edges.forEach(function(e){ var from = byId[e.from]; var to = byId[e.to]; if(from && to){ var x1 = from.x + boxW/2; var y1 = from.y + boxH; var x2 = to.x + boxW/2; var y2 = to.y; svg += '<line x1=\"' + x1 + '\" y1=\"' + y1 + '\" x2=\"' + x2 + '\" y2=\"' + y2 + '\" stroke=\"#999\" stroke-width=\"1\"/>'; } }); nodes.forEach(function(n){ var o = byId[n.id]; if(!o) return; var x = o.x; var y = o.y; var fill = '#cec'; if(n.type === 'plan') fill = '#cce'; if(n.type === 'tool') fill = '#eec'; svg += '<rect x=\"' + x + '\" y=\"' + y + '\" width=\"' + boxW + '\" height=\"' + boxH + '\" fill=\"' + fill + '\" stroke=\"#666\" rx=\"4\"/>'; svg += '<text x=\"' + (x + boxW/2) + '\" y=\"' + (y + boxH/2 + 4) + '\" text-anchor=\"middle\" font-size=\"11\">' + (n.label.length > 12 ? n.label.slice(0,11) + '…' : n.label) + '</text>'; }); svg += '</svg>'; el.innerHTML = svg; } renderBar(); renderDAG(); document.querySelectorAll('.filter-type').forEach(function(cb){ cb.addEventListener('change', renderBar); }); })();")
 }
 
 // writeTracePageScript writes the Trace page JS: timeline bar + select() with step view, reasoning, state diff.
 func writeTracePageScript(b *strings.Builder) {
-	b.WriteString("(function(){ var T = window.__TRACE__; var ph = document.getElementById('detail-placeholder'); var content = document.getElementById('detail-content'); var stepViewEl = document.getElementById('detail-step-view'); var payloadEl = document.getElementById('detail-payload'); var toolIoEl = document.getElementById('detail-tool-io'); var reasoningEl = document.getElementById('detail-reasoning'); var stateDiffEl = document.getElementById('detail-state-diff'); var segs = T.timeline_segments || []; var bar = document.getElementById('timeline-bar'); segs.forEach(function(s){ var c = s.type; if(s.status === 'permanent_failure' || s.status === 'compensatable_failure') c += ' failed'; else if(s.status === 'retryable_failure') c += ' retryable'; var d = document.createElement('span'); d.className = 'seg ' + c; d.textContent = s.label + (s.duration_ms ? ' ' + s.duration_ms + 'ms' : ''); bar.appendChild(d); }); function row(el,k,v){ if(!v) return; var p = document.createElement('div'); p.textContent = k + ':'; var p2 = document.createElement('div'); p2.textContent = v; el.appendChild(p); el.appendChild(p2); } function select(spanId){ document.querySelectorAll('.step-timeline .step').forEach(function(el){ el.classList.toggle('selected', el.getAttribute('data-span-id') === spanId); }); document.querySelectorAll('.tree-section [data-span-id]').forEach(function(el){ el.classList.toggle('selected', el.getAttribute('data-span-id') === spanId); }); var step = T.steps.find(function(s){ return s.span_id === spanId; }); if(!step){ ph.style.display='block'; content.style.display='none'; return; } ph.style.display='none'; content.style.display='block'; stepViewEl.innerHTML = ''; row(stepViewEl,'Step', step.label); row(stepViewEl,'State', step.state || 'ok'); row(stepViewEl,'Attempts', step.attempts ? String(step.attempts) : ''); row(stepViewEl,'Worker', step.worker_id); row(stepViewEl,'Duration', step.duration_ms ? step.duration_ms + 'ms' : ''); row(stepViewEl,'Result type', step.result_type); row(stepViewEl,'Reason', step.reason); var events = T.timeline.filter(function(e){ try{ var p = typeof e.payload === 'string' ? JSON.parse(e.payload) : e.payload; return (p && (p.trace_span_id === spanId || p.node_id === spanId)); }catch(_){ return false;} }); payloadEl.textContent = events.length ? JSON.stringify(events.map(function(e){ return { type: e.type, created_at: e.created_at, payload: e.payload }; }), null, 2) : ''; var io = []; var inv = step.tool_invocation; if(inv){ if(inv.input) io.push('Input: ' + (typeof inv.input === 'string' ? inv.input : JSON.stringify(inv.input))); if(inv.output) io.push('Output: ' + (typeof inv.output === 'string' ? inv.output : JSON.stringify(inv.output))); if(inv.summary) io.push('Summary: ' + inv.summary); if(inv.error) io.push('Error: ' + inv.error); if(inv.idempotent) io.push('Idempotent: true'); } if(!io.length){ var flat = (T.flat_steps || []).find(function(s){ return s.span_id === spanId; }); if(flat){ if(flat.input) io.push('Input: ' + (typeof flat.input === 'string' ? flat.input : JSON.stringify(flat.input))); if(flat.output) io.push('Output: ' + (typeof flat.output === 'string' ? flat.output : JSON.stringify(flat.output))); } } toolIoEl.textContent = io.length ? io.join('\\n\\n') : '(none)'; reasoningEl.innerHTML = ''; if(step.reasoning && step.reasoning.length){ step.reasoning.forEach(function(r){ var p = document.createElement('p'); p.innerHTML = '<strong>' + (r.role || '') + '</strong>: ' + (r.content || ''); reasoningEl.appendChild(p); }); } else { var p = document.createElement('p'); p.className = 'placeholder'; p.textContent = 'Reasoning snapshot (none recorded)'; reasoningEl.appendChild(p); } stateDiffEl.innerHTML = ''; if(step.state_diff && (step.state_diff.state_before || step.state_diff.state_after || (step.state_diff.changed_keys && step.state_diff.changed_keys.length) || (step.state_diff.state_changes && step.state_diff.state_changes.length))){ if(step.state_diff.changed_keys && step.state_diff.changed_keys.length){ var h4 = document.createElement('h4'); h4.textContent = 'Changed keys'; stateDiffEl.appendChild(h4); var ul = document.createElement('ul'); ul.className = 'changed-keys-list'; step.state_diff.changed_keys.forEach(function(k){ var li = document.createElement('li'); li.textContent = k; ul.appendChild(li); }); stateDiffEl.appendChild(ul); } var before = document.createElement('p'); before.textContent = 'Before: ' + (step.state_diff.state_before ? (typeof step.state_diff.state_before === 'string' ? step.state_diff.state_before : JSON.stringify(step.state_diff.state_before)) : '{}'); stateDiffEl.appendChild(before); var after = document.createElement('p'); after.textContent = 'After: ' + (step.state_diff.state_after ? (typeof step.state_diff.state_after === 'string' ? step.state_diff.state_after : JSON.stringify(step.state_diff.state_after)) : '{}'); stateDiffEl.appendChild(after); if(step.state_diff.tool_side_effects && step.state_diff.tool_side_effects.length){ var te = document.createElement('p'); te.textContent = 'Side effects: ' + step.state_diff.tool_side_effects.join('; '); stateDiffEl.appendChild(te); } if(step.state_diff.resource_refs && step.state_diff.resource_refs.length){ var rr = document.createElement('p'); rr.textContent = 'Resources: ' + step.state_diff.resource_refs.join(', '); stateDiffEl.appendChild(rr); } if(step.state_diff.state_changes && step.state_diff.state_changes.length){ var sch = document.createElement('h4'); sch.textContent = 'External state changed (audit)'; stateDiffEl.appendChild(sch); var ul = document.createElement('ul'); ul.className = 'state-changes-list'; step.state_diff.state_changes.forEach(function(c){ var li = document.createElement('li'); li.textContent = (c.resource_type || '') + ' ' + (c.resource_id || '') + ' ' + (c.operation || ''); ul.appendChild(li); }); stateDiffEl.appendChild(ul); } } else { var p = document.createElement('p'); p.className = 'placeholder'; p.textContent = 'State diff (none)'; stateDiffEl.appendChild(p); } } document.getElementById('step-timeline').addEventListener('click', function(ev){ var el = ev.target.closest('.step'); if(el) select(el.getAttribute('data-span-id')); }); document.getElementById('trace-tree').addEventListener('click', function(ev){ var el = ev.target.closest('[data-span-id]'); if(el) select(el.getAttribute('data-span-id')); }); })();")
+	b.WriteString("(function(){ var T = window.__TRACE__; var ph = document.getElementById('detail-placeholder'); var content = document.getElementById('detail-content'); var stepViewEl = document.getElementById('detail-step-view'); var payloadEl = document.getElementById('detail-payload'); var toolIoEl = document.getElementById('detail-tool-io'); var reasoningEl = document.getElementById('detail-reasoning'); var stateDiffEl = document.getElementById('detail-state-diff'); var segs = T.timeline_segments || []; var bar = document.getElementById('timeline-bar'); segs.forEach(function(s){ var c = s.type; if(s.status === 'permanent_failure' || s.status === 'compensatable_failure') c += ' failed'; else if(s.status === 'retryable_failure') c += ' retryable'; var d = document.createElement('span'); d.className = 'seg ' + c; d.textContent = s.label + (s.duration_ms ? ' ' + s.duration_ms + 'ms' : ''); bar.appendChild(d); }); function row(el,k,v){ if(!v) return; var p = document.createElement('div'); p.textContent = k + ':'; var p2 = document.createElement('div'); p2.textContent = v; el.appendChild(p); el.appendChild(p2); } function select(spanId){ document.querySelectorAll('.step-timeline .step').forEach(function(el){ el.classList.toggle('selected', el.getAttribute('data-span-id') === spanId); }); document.querySelectorAll('.tree-section [data-span-id]').forEach(function(el){ el.classList.toggle('selected', el.getAttribute('data-span-id') === spanId); }); var step = T.steps.find(function(s){ return s.span_id === spanId; }); if(!step){ ph.style.display='block'; content.style.display='none'; return; } ph.style.display='none'; content.style.display='block'; stepViewEl.innerHTML = ''; row(stepViewEl,'Step', step.label); row(stepViewEl,'Why planned', step.rationale); row(stepViewEl,'State', step.state || 'ok'); row(stepViewEl,'Attempts', step.attempts ? String(step.attempts) : ''); row(stepViewEl,'Worker', step.worker_id); row(stepViewEl,'Duration', step.duration_ms ? step.duration_ms + 'ms' : ''); row(stepViewEl,'Result type', step.result_type); row(stepViewEl,'Reason', step.reason); var attemptsEl = document.getElementById('detail-attempts'); attemptsEl.innerHTML = ''; var hist = step.attempt_history || []; if(hist.length){ var det = document.createElement('details'); det.className = 'attempt-history'; det.open = hist.length > 1; var sum = document.createElement('summary'); sum.textContent = hist.length + (hist.length > 1 ? ' attempts' : ' attempt'); det.appendChild(sum); var tbl = document.createElement('table'); tbl.innerHTML = '<thead><tr><th>#</th><th>Worker</th><th>Started</th><th>Duration</th><th>State</th><th>Failure reason</th></tr></thead>'; var tb = document.createElement('tbody'); hist.forEach(function(a){ var tr = document.createElement('tr'); var st = a.state || (a.end_time ? 'ok' : 'running'); if(st !== 'ok' && st !== 'running') tr.className = 'attempt-failed'; [String(a.attempt || ''), a.worker_id || '', a.start_time || '', a.duration_ms ? a.duration_ms + 'ms' : '', st, a.reason || (st !== 'ok' ? (a.result_type || '') : '')].forEach(function(v){ var td = document.createElement('td'); td.textContent = v; tr.appendChild(td); }); tb.appendChild(tr); }); tbl.appendChild(tb); det.appendChild(tbl); attemptsEl.appendChild(det); } else { var ap = document.createElement('p'); ap.className = 'placeholder'; ap.textContent = 'Attempt history (none)'; attemptsEl.appendChild(ap); } var events = T.timeline.filter(function(e){ try{ var p = typeof e.payload === 'string' ? JSON.parse(e.payload) : e.payload; return (p && (p.trace_span_id === spanId || p.node_id === spanId)); }catch(_){ return false;} }); payloadEl.textContent = events.length ? JSON.stringify(events.map(function(e){ return { type: e.type, created_at: e.created_at, payload: e.payload }; }), null, 2) : ''; var io = []; var inv = step.tool_invocation; if(inv){ if(inv.input) io.push('Input: ' + (typeof inv.input === 'string' ? inv.input : JSON.stringify(inv.input))); if(inv.output) io.push('Output: ' + (typeof inv.output === 'string' ? inv.output : JSON.stringify(inv.output))); if(inv.summary) io.push('Summary: ' + inv.summary); if(inv.error) io.push('Error: ' + inv.error); if(inv.idempotent) io.push('Idempotent: true'); } if(!io.length){ var flat = (T.flat_steps || []).find(function(s){ return s.span_id === spanId; }); if(flat){ if(flat.input) io.push('Input: ' + (typeof flat.input === 'string' ? flat.input : JSON.stringify(flat.input))); if(flat.output) io.push('Output: ' + (typeof flat.output === 'string' ? flat.output : JSON.stringify(flat.output))); } } toolIoEl.textContent = io.length ? io.join('\\n\\n') : '(none)'; reasoningEl.innerHTML = ''; if(step.reasoning && step.reasoning.length){ step.reasoning.forEach(function(r){ var p = document.createElement('p'); p.innerHTML = '<strong>' + (r.role || '') + '</strong>: ' + (r.content || ''); reasoningEl.appendChild(p); }); } else { var p = document.createElement('p'); p.className = 'placeholder'; p.textContent = 'Reasoning snapshot (none recorded)'; reasoningEl.appendChild(p); } stateDiffEl.innerHTML = ''; if(step.state_diff && (step.state_diff.state_before || step.state_diff.state_after || (step.state_diff.changed_keys && step.state_diff.changed_keys.length) || (step.state_diff.state_changes && step.state_diff.state_changes.length))){ if(step.state_diff.changed_keys && step.state_diff.changed_keys.length){ var h4 = document.createElement('h4'); h4.textContent = 'Changed keys'; stateDiffEl.appendChild(h4); var ul = document.createElement('ul'); ul.className = 'changed-keys-list'; step.state_diff.changed_keys.forEach(function(k){ var li = document.createElement('li'); li.textContent = k; ul.appendChild(li); }); stateDiffEl.appendChild(ul); } var before = document.createElement('p'); before.textContent = 'Before: ' + (step.state_diff.state_before ? (typeof step.state_diff.state_before === 'string' ? step.state_diff.state_before : JSON.stringify(step.state_diff.state_before)) : '{}'); stateDiffEl.appendChild(before); var after = document.createElement('p'); after.textContent = 'After: ' + (step.state_diff.state_after ? (typeof step.state_diff.state_after === 'string' ? step.state_diff.state_after : JSON.stringify(step.state_diff.state_after)) : '{}'); stateDiffEl.appendChild(after); if(step.state_diff.tool_side_effects && step.state_diff.tool_side_effects.length){ var te = document.createElement('p'); te.textContent = 'Side effects: ' + step.state_diff.tool_side_effects.join('; '); stateDiffEl.appendChild(te); } if(step.state_diff.resource_refs && step.state_diff.resource_refs.length){ var rr = document.createElement('p'); rr.textContent = 'Resources: ' + step.state_diff.resource_refs.join(', '); stateDiffEl.appendChild(rr); } if(step.state_diff.state_changes && step.state_diff.state_changes.length){ var sch = document.createElement('h4'); sch.textContent = 'External state changed (audit)'; stateDiffEl.appendChild(sch); var ul = document.createElement('ul'); ul.className = 'state-changes-list'; step.state_diff.state_changes.forEach(function(c){ var li = document.createElement('li'); li.textContent = (c.resource_type || '') + ' ' + (c.resource_id || '') + ' ' + (c.operation || ''); ul.appendChild(li); }); stateDiffEl.appendChild(ul); } } else { var p = document.createElement('p'); p.className = 'placeholder'; p.textContent = 'State diff (none)'; stateDiffEl.appendChild(p); } } document.getElementById('step-timeline').addEventListener('click', function(ev){ var el = ev.target.closest('.step'); if(el) select(el.getAttribute('data-span-id')); }); document.getElementById('trace-tree').addEventListener('click', function(ev){ var el = ev.target.closest('[data-span-id]'); if(el) select(el.getAttribute('data-span-id')); }); })();")
+}
+
+// writeTraceETA writes the duration prediction line and the per-step ETA table.
+// tr returns an escaped page label (trace.page.*).
+func writeTraceETA(b *strings.Builder, est *eta.Estimate, tr func(string) string) {
+	b.WriteString("<div class=\"trace-eta\" id=\"trace-eta\"><p><b>ETA:</b> ")
+	if est.ETA != nil {
+		b.WriteString(html.EscapeString(est.ETA.UTC().Format(time.RFC3339)))
+		b.WriteString(" (" + tr("trace.page.eta_remaining") + " ")
+		b.WriteString(formatETAMs(est.RemainingMs))
+		b.WriteString(")")
+	} else {
+		b.WriteString(tr("trace.page.eta_unknown"))
+	}
+	b.WriteString(" &middot; " + tr("trace.page.eta_predicted") + " ")
+	b.WriteString(formatETAMs(est.PredictedDurationMs))
+	b.WriteString(" &middot; " + tr("trace.page.eta_elapsed") + " ")
+	b.WriteString(formatETAMs(est.ElapsedMs))
+	b.WriteString(" &middot; " + tr("trace.page.eta_basis") + " ")
+	b.WriteString(html.EscapeString(est.Basis))
+	b.WriteString(", ")
+	b.WriteString(strconv.Itoa(est.Samples))
+	b.WriteString(" " + tr("trace.page.eta_samples") + ", ")
+	b.WriteString(html.EscapeString(est.Confidence))
+	b.WriteString(" " + tr("trace.page.eta_confidence") + "</p>")
+	if len(est.Steps) > 0 {
+		b.WriteString("<details><summary>" + tr("trace.page.step_eta") + "</summary><table><thead><tr><th>" + tr("trace.page.node") + "</th><th>" + tr("trace.page.key") + "</th><th>" + tr("trace.page.state") + "</th><th>" + tr("trace.page.predicted") + "</th><th>" + tr("trace.page.actual") + "</th><th>ETA</th></tr></thead><tbody>")
+		for _, s := range est.Steps {
+			b.WriteString("<tr><td>")
+			b.WriteString(html.EscapeString(s.NodeID))
+			b.WriteString("</td><td>")
+			b.WriteString(html.EscapeString(s.Key))
+			b.WriteString("</td><td>")
+			b.WriteString(html.EscapeString(s.State))
+			b.WriteString("</td><td>")
+			b.WriteString(formatETAMs(s.PredictedMs))
+			b.WriteString("</td><td>")
+			if s.State == "done" {
+				b.WriteString(formatETAMs(s.ActualMs))
+			}
+			b.WriteString("</td><td>")
+			if s.ETA != nil {
+				b.WriteString(html.EscapeString(s.ETA.UTC().Format(time.RFC3339)))
+			}
+			b.WriteString("</td></tr>")
+		}
+		b.WriteString("</tbody></table></details>")
+	}
+	b.WriteString("</div>")
+}
+
+func formatETAMs(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	if d < time.Second {
+		return d.String()
+	}
+	return d.Round(time.Second).String()
 }
 
 // writeTraceReplayControlScript writes JS for step-level replay query.
@@ -2241,13 +3611,17 @@ func renderTraceTreeHTML(root *ExecutionNode) string {
 	return b.String()
 }
 
-// GetObservabilitySummary 返回运维可观测性摘要：队列积压、卡住 Job 列表（2.0）；需 SetObservabilityReader
+// GetObservabilitySummary 返回运维可观测性摘要：队列积压、卡住 Job 列表（2.0）；需 SetObservabilityReader；配置入库队列时附 ingest（任务数与最近failed任务 ID）
 func (h *Handler) GetObservabilitySummary(ctx context.Context, c *app.RequestContext) {
 	if h.observabilityReader == nil {
 		c.JSON(consts.StatusOK, map[string]interface{}{
 			"queue_backlog":           map[string]int{"default": 0},
 			"stuck_job_ids":           []string{},
 			"stuck_threshold_seconds": 3600,
+			"maintenance":             h.maintenanceSummary(ctx),
+			"killswitch":              h.killSwitchSummary(ctx),
+			"backpressure":            h.backpressureSummary(ctx),
+			"ingest":                  h.ingestSummary(ctx),
 		})
 		return
 	}
@@ -2260,13 +3634,13 @@ func (h *Handler) GetObservabilitySummary(ctx context.Context, c *app.RequestCon
 	pending, err := h.observabilityReader.CountPending(ctx, "")
 	if err != nil {
 		hlog.CtxErrorf(ctx, "CountPending: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取积压数failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "queue.backlog_failed")})
 		return
 	}
 	stuck, err := h.observabilityReader.ListStuckRunningJobIDs(ctx, olderThan)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListStuckRunningJobIDs: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取卡住 Job failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_stuck_failed")})
 		return
 	}
 	metrics.QueueBacklog.WithLabelValues("default").Set(float64(pending))
@@ -2275,6 +3649,10 @@ func (h *Handler) GetObservabilitySummary(ctx context.Context, c *app.RequestCon
 		"queue_backlog":           map[string]int{"default": pending},
 		"stuck_job_ids":           stuck,
 		"stuck_threshold_seconds": int(olderThan.Seconds()),
+		"maintenance":             h.maintenanceSummary(ctx),
+		"killswitch":              h.killSwitchSummary(ctx),
+		"backpressure":            h.backpressureSummary(ctx),
+		"ingest":                  h.ingestSummary(ctx),
 	})
 }
 
@@ -2296,7 +3674,7 @@ func (h *Handler) GetObservabilityStuck(ctx context.Context, c *app.RequestConte
 	stuck, err := h.observabilityReader.ListStuckRunningJobIDs(ctx, olderThan)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "ListStuckRunningJobIDs: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]string{"error": "获取卡住 Job failed"})
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_stuck_failed")})
 		return
 	}
 	c.JSON(consts.StatusOK, map[string]interface{}{
@@ -2305,10 +3683,29 @@ func (h *Handler) GetObservabilityStuck(ctx context.Context, c *app.RequestConte
 	})
 }
 
+// GetObservabilityDrift 返回最近一轮事件/账本/检查点对账报告；尚未执行或 ?refresh=true 时同步执行一轮；需 SetReconciler
+func (h *Handler) GetObservabilityDrift(ctx context.Context, c *app.RequestContext) {
+	if h.reconciler == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "reconcile.disabled")})
+		return
+	}
+	report := h.reconciler.Last()
+	if report == nil || c.Query("refresh") == "true" {
+		var err error
+		report, err = h.reconciler.RunOnce(ctx)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "Reconcile: %v", err)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "reconcile.failed")})
+			return
+		}
+	}
+	c.JSON(consts.StatusOK, report)
+}
+
 // ListTools 返回所有工具的 Manifest 列表（GET /api/tools）
 func (h *Handler) ListTools(ctx context.Context, c *app.RequestContext) {
 	if h.toolsRegistry == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "工具注册表not configured"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "tool.registry_not_configured")})
 		return
 	}
 	manifests := h.toolsRegistry.Manifests()
@@ -2321,13 +3718,13 @@ func (h *Handler) ListTools(ctx context.Context, c *app.RequestContext) {
 // GetTool 返回指定名称工具的 Manifest（GET /api/tools/:name）
 func (h *Handler) GetTool(ctx context.Context, c *app.RequestContext) {
 	if h.toolsRegistry == nil {
-		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": "工具注册表not configured"})
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "tool.registry_not_configured")})
 		return
 	}
 	name := c.Param("name")
 	m := h.toolsRegistry.Manifest(name)
 	if m == nil {
-		c.JSON(consts.StatusNotFound, map[string]string{"error": "工具not found"})
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "tool.not_found")})
 		return
 	}
 	c.JSON(consts.StatusOK, m)
@@ -18,18 +18,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/require"
 
+	"rag-platform/internal/agent/bundle"
+	"rag-platform/internal/agent/eta"
+	"rag-platform/internal/agent/goaltemplate"
 	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/reconcile"
+	"rag-platform/internal/agent/replay/sandbox"
+	agentruntime "rag-platform/internal/agent/runtime"
 	"rag-platform/internal/agent/signal"
 	"rag-platform/internal/api/http/middleware"
+	"rag-platform/internal/pipeline/freshness"
+	"rag-platform/internal/runtime/agentconfig"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/runtime/serviceaccount"
+	"rag-platform/internal/storage/metadata"
 	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -48,6 +63,28 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+type stubReadiness struct{ ready bool }
+
+func (s stubReadiness) Readiness() (bool, interface{}) {
+	return s.ready, map[string]bool{"ready": s.ready}
+}
+
+func TestReadyz(t *testing.T) {
+	h := server.Default(server.WithHostPorts(":0"))
+	handler := NewHandler(nil, nil)
+	h.GET("/readyz", handler.Readyz)
+	for _, tc := range []struct {
+		probe ReadinessProbe
+		want  int
+	}{{nil, 200}, {stubReadiness{true}, 200}, {stubReadiness{false}, 503}} {
+		handler.SetReadiness(tc.probe)
+		w := ut.PerformRequest(h.Engine, "GET", "/readyz", nil)
+		if got := w.Result().StatusCode(); got != tc.want {
+			t.Errorf("probe %v: status %d, want %d", tc.probe, got, tc.want)
+		}
+	}
+}
+
 // setupJobSignalHandler 创建处于 StatusWaiting 的 job 及带 job_waiting 事件的事件流，返回 handler 与 jobID（design/runtime-contract.md JobSignal 契约测试用）
 func setupJobSignalHandler(t *testing.T) (*Handler, string) {
 	t.Helper()
@@ -59,6 +96,7 @@ func setupJobSignalHandler(t *testing.T) (*Handler, string) {
 	if err != nil {
 		t.Fatalf("Create job: %v", err)
 	}
+	require.NoError(t, meta.UpdateStatus(ctx, jobID, job.StatusRunning))
 	if err := meta.UpdateStatus(ctx, jobID, job.StatusWaiting); err != nil {
 		t.Fatalf("UpdateStatus Waiting: %v", err)
 	}
@@ -98,7 +136,7 @@ func TestJobSignal_WrongCorrelationKey(t *testing.T) {
 	handler, jobID := setupJobSignalHandler(t)
 	h := server.Default(server.WithHostPorts(":0"))
 	h.POST("/api/jobs/:id/signal", func(ctx context.Context, c *app.RequestContext) {
-		handler.JobSignal(ctx, c)
+		handler.JobSignal(i18n.WithLocale(ctx, i18n.Chinese), c)
 	})
 	body := []byte(`{"correlation_key":"wrong-key"}`)
 	w := ut.PerformRequest(h.Engine, "POST", "/api/jobs/"+jobID+"/signal", &ut.Body{Body: bytes.NewReader(body), Len: len(body)})
@@ -194,6 +232,7 @@ func TestJobSignal_SuccessAndIdempotentUnblock(t *testing.T) {
 	}
 
 	// 模拟重复请求命中“状态仍 Waiting（最终一致性）”场景，验证幂等逻辑只返回已送达，不重复追加事件。
+	require.NoError(t, handler.jobStore.UpdateStatus(ctx, jobID, job.StatusRunning))
 	if err := handler.jobStore.UpdateStatus(ctx, jobID, job.StatusWaiting); err != nil {
 		t.Fatalf("reset status to waiting: %v", err)
 	}
@@ -451,6 +490,45 @@ func TestJobStop_RBACAndTenantMatrix(t *testing.T) {
 	}
 }
 
+func TestJobStop_RecordsTerminalInfo(t *testing.T) {
+	ctx := context.Background()
+	meta := job.NewJobStoreMem()
+	jobID := "job-stop-reason"
+	if _, err := meta.Create(ctx, &job.Job{ID: jobID, AgentID: "agent-1", Goal: "goal", Status: job.StatusRunning, TenantID: "default"}); err != nil {
+		t.Fatalf("Create job: %v", err)
+	}
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(jobstore.NewMemoryStore())
+
+	h := server.Default(server.WithHostPorts(":0"))
+	h.POST("/api/jobs/:id/stop", func(c context.Context, reqCtx *app.RequestContext) {
+		handler.JobStop(auth.WithUserID(c, "alice"), reqCtx)
+	})
+	h.GET("/api/jobs/:id", func(c context.Context, reqCtx *app.RequestContext) {
+		handler.GetJob(c, reqCtx)
+	})
+
+	body := []byte(`{"reason":"budget exceeded"}`)
+	w := ut.PerformRequest(h.Engine, "POST", "/api/jobs/"+jobID+"/stop", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("stop status got %d, body=%s", got, w.Result().Body())
+	}
+
+	w = ut.PerformRequest(h.Engine, "GET", "/api/jobs/"+jobID, &ut.Body{Body: bytes.NewReader(nil), Len: 0})
+	var resp struct {
+		TerminalInfo *job.TerminalInfo `json:"terminal_info"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	ti := resp.TerminalInfo
+	if ti == nil || ti.Reason != "budget exceeded" || ti.Actor != "alice" || ti.FailureClass != job.FailureClassCancelled {
+		t.Fatalf("terminal_info = %+v, body=%s", ti, w.Result().Body())
+	}
+}
+
 func TestGetJobReplay_StepNodeID(t *testing.T) {
 	ctx := context.Background()
 	jobID := "job-replay-step"
@@ -628,3 +706,599 @@ func TestGetJobTrace_TenantIsolation(t *testing.T) {
 		}
 	})
 }
+
+func TestDebugRunJobNode_LLMPromptDiff(t *testing.T) {
+	ctx := context.Background()
+	jobID := "job-debug-run"
+	meta := job.NewJobStoreMem()
+	if _, err := meta.Create(ctx, &job.Job{ID: jobID, AgentID: "a1", Goal: "g1", Status: job.StatusCompleted}); err != nil {
+		t.Fatalf("Create job: %v", err)
+	}
+	eventStore := jobstore.NewMemoryStore()
+	_, _ = eventStore.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.StateCheckpointed, Payload: []byte(`{"node_id":"n1","state_before":{},"state_after":{"n1":{"output":"old"}}}`)})
+	_, _ = eventStore.Append(ctx, jobID, 1, jobstore.JobEvent{JobID: jobID, Type: jobstore.ReasoningSnapshot, Payload: []byte(`{"node_id":"n1","node_type":"llm"}`)})
+
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(eventStore)
+	handler.SetDebugRunner(&sandbox.DebugRunner{LLM: func(ctx context.Context, prompt string) (string, error) {
+		return "new", nil
+	}})
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/jobs/:id/nodes/:node_id/debug-run", func(ctx context.Context, c *app.RequestContext) {
+		handler.DebugRunJobNode(ctx, c)
+	})
+
+	body := []byte(`{"prompt":"try again"}`)
+	w := ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/nodes/n1/debug-run", &ut.Body{Body: bytes.NewReader(body), Len: len(body)}, ut.Header{Key: "Content-Type", Value: "application/json"})
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d, want 200: %s", got, w.Result().Body())
+	}
+	resp := w.Result().Body()
+	for _, want := range []string{`"source":"llm"`, `"changed":true`, `"original_output":{"output":"old"}`, `"hypothetical_output":{"output":"new"}`} {
+		if !bytes.Contains(resp, []byte(want)) {
+			t.Fatalf("response missing %s: %s", want, resp)
+		}
+	}
+
+	w = ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/nodes/missing/debug-run", &ut.Body{Body: bytes.NewReader(body), Len: len(body)}, ut.Header{Key: "Content-Type", Value: "application/json"})
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("missing node status = %d, want 404", got)
+	}
+}
+
+func TestPlannerExemplars_CRUD(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	handler.SetPlannerExemplars(planner.NewExemplarStoreMem(), planner.NewPlanValidityTracker())
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/agents/:id/planner/exemplars", handler.ListPlannerExemplars)
+	s.POST("/api/agents/:id/planner/exemplars", handler.CreatePlannerExemplar)
+	s.PUT("/api/agents/:id/planner/exemplars/:exemplar_id", handler.UpdatePlannerExemplar)
+	s.DELETE("/api/agents/:id/planner/exemplars/:exemplar_id", handler.DeletePlannerExemplar)
+	jsonHeader := ut.Header{Key: "Content-Type", Value: "application/json"}
+
+	bad := []byte(`{"goal":"g","graph":{"nodes":[{"id":"n1","type":"tool"}]}}`)
+	w := ut.PerformRequest(s.Engine, "POST", "/api/agents/a1/planner/exemplars", &ut.Body{Body: bytes.NewReader(bad), Len: len(bad)}, jsonHeader)
+	if got := w.Result().StatusCode(); got != 400 {
+		t.Fatalf("invalid graph status = %d, want 400", got)
+	}
+
+	body := []byte(`{"goal":"summarize report","graph":{"nodes":[{"id":"n1","type":"tool","tool_name":"knowledge.search"}]}}`)
+	w = ut.PerformRequest(s.Engine, "POST", "/api/agents/a1/planner/exemplars", &ut.Body{Body: bytes.NewReader(body), Len: len(body)}, jsonHeader)
+	if got := w.Result().StatusCode(); got != 201 {
+		t.Fatalf("create status = %d, want 201: %s", got, w.Result().Body())
+	}
+	var created planner.Exemplar
+	if err := json.Unmarshal(w.Result().Body(), &created); err != nil || created.ID == "" {
+		t.Fatalf("decode created exemplar: %v %s", err, w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "PUT", "/api/agents/a2/planner/exemplars/"+created.ID, &ut.Body{Body: bytes.NewReader(body), Len: len(body)}, jsonHeader)
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("update from other agent status = %d, want 404", got)
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/agents/a1/planner/exemplars", nil)
+	if !bytes.Contains(w.Result().Body(), []byte(created.ID)) || !bytes.Contains(w.Result().Body(), []byte(`"plan_validity"`)) {
+		t.Fatalf("list missing exemplar: %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "DELETE", "/api/agents/a1/planner/exemplars/"+created.ID, nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("delete status = %d, want 200", got)
+	}
+}
+
+func TestServiceAccounts_IssueRotateRevoke(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	handler.SetServiceAccounts(serviceaccount.NewStoreMem())
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(func(ctx context.Context, c *app.RequestContext) {
+		c.Next(auth.WithTenantID(ctx, string(c.GetHeader("X-Tenant-ID"))))
+	})
+	s.GET("/api/service-accounts", handler.ListServiceAccounts)
+	s.POST("/api/service-accounts", handler.CreateServiceAccount)
+	s.POST("/api/service-accounts/:id/rotate", handler.RotateServiceAccount)
+	s.DELETE("/api/service-accounts/:id", handler.RevokeServiceAccount)
+	jsonHeader := ut.Header{Key: "Content-Type", Value: "application/json"}
+	tenant1 := ut.Header{Key: "X-Tenant-ID", Value: "t1"}
+	tenant2 := ut.Header{Key: "X-Tenant-ID", Value: "t2"}
+
+	body := []byte(`{"name":"worker-a"}`)
+	w := ut.PerformRequest(s.Engine, "POST", "/api/service-accounts", &ut.Body{Body: bytes.NewReader(body), Len: len(body)}, jsonHeader, tenant1)
+	if got := w.Result().StatusCode(); got != 201 {
+		t.Fatalf("create status = %d, want 201: %s", got, w.Result().Body())
+	}
+	var created struct {
+		ID     string   `json:"id"`
+		Token  string   `json:"token"`
+		Role   string   `json:"role"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &created); err != nil || created.ID == "" || !strings.HasPrefix(created.Token, serviceaccount.TokenPrefix) {
+		t.Fatalf("decode created account: %v %s", err, w.Result().Body())
+	}
+	if created.Role != string(auth.RoleWorker) || len(created.Scopes) != 2 {
+		t.Fatalf("account should be scoped to claim/execute: %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/service-accounts", nil, tenant1)
+	if !bytes.Contains(w.Result().Body(), []byte(created.ID)) || bytes.Contains(w.Result().Body(), []byte(created.Token)) {
+		t.Fatalf("list should include account without token: %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "POST", "/api/service-accounts/"+created.ID+"/rotate", nil, tenant2)
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("rotate from other tenant status = %d, want 404", got)
+	}
+	rotate := []byte(`{"grace_period":"0s"}`)
+	w = ut.PerformRequest(s.Engine, "POST", "/api/service-accounts/"+created.ID+"/rotate", &ut.Body{Body: bytes.NewReader(rotate), Len: len(rotate)}, jsonHeader, tenant1)
+	if got := w.Result().StatusCode(); got != 200 || bytes.Contains(w.Result().Body(), []byte(created.Token)) {
+		t.Fatalf("rotate status = %d, want 200 with new token: %s", got, w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "DELETE", "/api/service-accounts/"+created.ID, nil, tenant1)
+	if got := w.Result().StatusCode(); got != 200 || !bytes.Contains(w.Result().Body(), []byte(`"revoked":true`)) {
+		t.Fatalf("revoke status = %d: %s", got, w.Result().Body())
+	}
+	w = ut.PerformRequest(s.Engine, "POST", "/api/service-accounts/"+created.ID+"/rotate", nil, tenant1)
+	if got := w.Result().StatusCode(); got != 409 {
+		t.Fatalf("rotate revoked status = %d, want 409", got)
+	}
+}
+
+func TestCollectionFreshness_Report(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	old := time.Now().Add(-60 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	_ = store.Create(context.Background(), &metadata.Document{ID: "d1", Name: "d1", Metadata: map[string]string{freshness.MetaSourceUpdatedAt: old}})
+	_ = store.Create(context.Background(), &metadata.Document{ID: "d2", Name: "d2", Metadata: map[string]string{freshness.MetaCollection: "kb"}})
+	handler := NewHandler(nil, nil)
+	handler.SetFreshness(store, freshness.Policies{Default: freshness.Policy{MaxAge: 30 * 24 * time.Hour}}, "default")
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/knowledge/collections/:id/freshness", handler.CollectionFreshness)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/knowledge/collections/default/freshness", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var report freshness.Report
+	if err := json.Unmarshal(w.Result().Body(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 1 || report.Stale != 1 || report.Documents[0].DocumentID != "d1" {
+		t.Fatalf("unexpected report: %s", w.Result().Body())
+	}
+}
+
+func TestAgentConfig_SetListDelete(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	handler.SetAgentConfig(agentconfig.NewStoreMem())
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/agents/:id/config", handler.ListAgentConfig)
+	s.PUT("/api/agents/:id/config/:key", handler.SetAgentConfigEntry)
+	s.DELETE("/api/agents/:id/config/:key", handler.DeleteAgentConfigEntry)
+	jsonHeader := ut.Header{Key: "Content-Type", Value: "application/json"}
+	put := func(key, body string) int {
+		w := ut.PerformRequest(s.Engine, "PUT", "/api/agents/a1/config/"+key, &ut.Body{Body: strings.NewReader(body), Len: len(body)}, jsonHeader)
+		return w.Result().StatusCode()
+	}
+
+	if got := put("endpoint", `{"value":"https://staging"}`); got != 200 {
+		t.Fatalf("put value status = %d", got)
+	}
+	if got := put("api_key", `{"secret_ref":"STAGING_KEY"}`); got != 200 {
+		t.Fatalf("put secret status = %d", got)
+	}
+	if got := put("both", `{"value":"x","secret_ref":"Y"}`); got != 400 {
+		t.Fatalf("put both status = %d, want 400", got)
+	}
+	w := ut.PerformRequest(s.Engine, "GET", "/api/agents/a1/config", nil)
+	var resp struct {
+		Config []agentconfig.Entry `json:"config"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Config) != 2 || resp.Config[0].Key != "api_key" || resp.Config[0].SecretRef != "STAGING_KEY" || resp.Config[0].Value != "" {
+		t.Fatalf("unexpected config: %s", w.Result().Body())
+	}
+	w = ut.PerformRequest(s.Engine, "DELETE", "/api/agents/a1/config/endpoint", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("delete status = %d", got)
+	}
+	w = ut.PerformRequest(s.Engine, "DELETE", "/api/agents/a1/config/endpoint", nil)
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("second delete status = %d, want 404", got)
+	}
+}
+
+func TestGetObservabilityDrift(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/observability/drift", handler.GetObservabilityDrift)
+	w := ut.PerformRequest(s.Engine, "GET", "/api/observability/drift", nil)
+	if got := w.Result().StatusCode(); got != 503 {
+		t.Fatalf("without reconciler status = %d, want 503", got)
+	}
+
+	jobs := job.NewJobStoreMem()
+	jobID, _ := jobs.Create(context.Background(), &job.Job{AgentID: "a1", Goal: "g"})
+	_ = jobs.UpdateCursor(context.Background(), jobID, "cp-missing")
+	handler.SetReconciler(reconcile.NewReconciler(jobs, jobstore.NewMemoryStore(), nil, agentruntime.NewCheckpointStoreMem(), reconcile.Options{}, nil))
+	w = ut.PerformRequest(s.Engine, "GET", "/api/observability/drift", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var report reconcile.Report
+	if err := json.Unmarshal(w.Result().Body(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.JobsChecked != 1 || report.Counts[reconcile.KindCheckpointMissing] != 1 {
+		t.Fatalf("unexpected report: %s", w.Result().Body())
+	}
+}
+
+func TestGetJob_IncludesETA(t *testing.T) {
+	ctx := context.Background()
+	meta := job.NewJobStoreMem()
+	eventStore := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(eventStore)
+	est := eta.NewEstimator(0)
+	handler.SetETAEstimator(est)
+
+	done := &job.Job{AgentID: "agent-1", Status: job.StatusCompleted, CreatedAt: time.Now().Add(-time.Minute), UpdatedAt: time.Now()}
+	est.Observe(done, nil)
+	jobID, _ := meta.Create(ctx, &job.Job{AgentID: "agent-1", Goal: "goal"})
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id", handler.GetJob)
+	s.GET("/api/jobs/:id/trace/page", handler.GetJobTracePage)
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID, nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var resp struct {
+		ETA *eta.Estimate `json:"eta"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ETA == nil || resp.ETA.Basis != eta.BasisAgent || resp.ETA.Samples != 1 || resp.ETA.ETA == nil {
+		t.Fatalf("unexpected eta: %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/trace/page", nil)
+	if body := string(w.Result().Body()); !strings.Contains(body, `id="trace-eta"`) {
+		t.Fatalf("trace page missing ETA: %s", body)
+	}
+}
+
+type testAgentCreator struct{ m *agentruntime.Manager }
+
+func (c testAgentCreator) Create(ctx context.Context, name string) (*agentruntime.Agent, error) {
+	return c.m.Create(ctx, name, nil, nil, nil, nil)
+}
+
+func TestExportImportAgent(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetAgentConfig(agentconfig.NewStoreMem())
+	handler.SetPlannerExemplars(planner.NewExemplarStoreMem(), planner.NewPlanValidityTracker())
+
+	src, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	src.Session.SetVariable("region", "eu")
+	_ = handler.agentConfig.Set(ctx, &agentconfig.Entry{AgentID: src.ID, Key: "api_base", Value: "https://staging"})
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/agents/:id/export", handler.ExportAgent)
+	s.POST("/api/agents/import", handler.ImportAgent)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/agents/"+src.ID+"/export", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("export status = %d: %s", got, w.Result().Body())
+	}
+	exported := append([]byte(nil), w.Result().Body()...)
+
+	importBody := func(target, onConflict string) *ut.Body {
+		b, _ := json.Marshal(map[string]interface{}{"bundle": json.RawMessage(exported), "target_agent_id": target, "on_conflict": onConflict})
+		return &ut.Body{Body: bytes.NewReader(b), Len: len(b)}
+	}
+	w = ut.PerformRequest(s.Engine, "POST", "/api/agents/import", importBody("", ""))
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("import status = %d: %s", got, w.Result().Body())
+	}
+	var res bundle.ImportResult
+	if err := json.Unmarshal(w.Result().Body(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Created || res.AgentID == src.ID || res.IDMap[src.ID] != res.AgentID || res.Applied["config"] != 1 {
+		t.Fatalf("unexpected import result: %s", w.Result().Body())
+	}
+	imported, _ := m.Get(ctx, res.AgentID)
+	if imported == nil || imported.Name != "support" {
+		t.Fatalf("imported agent = %+v", imported)
+	}
+	if v, _ := imported.Session.GetVariable("region"); v != "eu" {
+		t.Fatalf("memory not imported: region = %v", v)
+	}
+
+	// 再次导入到同一 Agent：配置冲突，默认 fail 返回 409
+	w = ut.PerformRequest(s.Engine, "POST", "/api/agents/import", importBody(res.AgentID, ""))
+	if got := w.Result().StatusCode(); got != 409 {
+		t.Fatalf("conflicting import status = %d, want 409: %s", got, w.Result().Body())
+	}
+	w = ut.PerformRequest(s.Engine, "POST", "/api/agents/import", importBody(res.AgentID, "overwrite"))
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("overwrite import status = %d: %s", got, w.Result().Body())
+	}
+	w = ut.PerformRequest(s.Engine, "POST", "/api/agents/import", importBody("agent-missing", ""))
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("missing target status = %d, want 404", got)
+	}
+}
+
+func TestWaitJob(t *testing.T) {
+	ctx := context.Background()
+	meta := job.NewJobStoreMem()
+	eventStore := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(eventStore)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/wait", handler.WaitJob)
+
+	jobID, _ := meta.Create(ctx, &job.Job{AgentID: "agent-1", Goal: "goal"})
+	wait := func(query string) map[string]interface{} {
+		t.Helper()
+		w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/wait"+query, nil)
+		if got := w.Result().StatusCode(); got != 200 {
+			t.Fatalf("status = %d: %s", got, w.Result().Body())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := wait("?timeout=50ms")
+	if resp["status"] != "pending" || resp["terminal"] != false || resp["timed_out"] != true {
+		t.Fatalf("timeout response = %v", resp)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		for _, st := range []job.JobStatus{job.StatusRunning, job.StatusCompleted} {
+			if err := meta.UpdateStatus(ctx, jobID, st); err != nil {
+				t.Errorf("UpdateStatus %v: %v", st, err)
+			}
+		}
+		_, ver, _ := eventStore.ListEvents(ctx, jobID)
+		_, _ = eventStore.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCompleted})
+	}()
+	start := time.Now()
+	resp = wait("?timeout=10s")
+	if resp["status"] != "completed" || resp["terminal"] != true || resp["timed_out"] != false {
+		t.Fatalf("completed response = %v", resp)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("wait did not return promptly after completion")
+	}
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/wait?timeout=soon", nil)
+	if got := w.Result().StatusCode(); got != 400 {
+		t.Fatalf("invalid timeout status = %d, want 400", got)
+	}
+}
+
+func TestWaitJob_RequestDeadline(t *testing.T) {
+	ctx := context.Background()
+	meta := job.NewJobStoreMem()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(jobstore.NewMemoryStore())
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(middleware.NewRequestTimeout(middleware.RequestTimeoutConfig{Default: jobWaitDeadlineMargin + 200*time.Millisecond}).Handler())
+	s.GET("/api/jobs/:id/wait", handler.WaitJob)
+	jobID, err := meta.Create(ctx, &job.Job{AgentID: "agent-1", Goal: "goal"})
+	require.NoError(t, err)
+
+	// 请求截止时间短于 timeout：等待在截止前结束，按超时返回 200 与 Job 详情
+	start := time.Now()
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/wait?timeout=30s", nil)
+	require.Equal(t, 200, w.Result().StatusCode(), string(w.Result().Body()))
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Result().Body(), &resp))
+	if resp["status"] != "pending" || resp["terminal"] != false || resp["timed_out"] != true {
+		t.Fatalf("deadline response = %v", resp)
+	}
+	if elapsed := time.Since(start); elapsed > jobWaitDeadlineMargin+200*time.Millisecond {
+		t.Fatalf("wait ran past the request deadline: %v", elapsed)
+	}
+}
+
+func TestGetJobTransitions(t *testing.T) {
+	ctx := context.Background()
+	meta := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(events)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/transitions", handler.GetJobTransitions)
+	jobID, err := meta.Create(ctx, &job.Job{AgentID: "agent-1", Goal: "goal"})
+	require.NoError(t, err)
+	_, err = events.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCreated})
+	require.NoError(t, err)
+	require.NoError(t, meta.UpdateStatus(ctx, jobID, job.StatusRunning))
+	require.NoError(t, meta.UpdateStatus(job.WithTransitionReason(ctx, "wait_node"), jobID, job.StatusWaiting))
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/transitions", nil)
+	require.Equal(t, 200, w.Result().StatusCode(), string(w.Result().Body()))
+	var resp struct {
+		Transitions []struct{ From, To, Reason string } `json:"transitions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Result().Body(), &resp))
+	require.Len(t, resp.Transitions, 2)
+	require.Equal(t, "running", resp.Transitions[1].From)
+	require.Equal(t, "wait_node", resp.Transitions[1].Reason)
+	// 迁移不进入事件流：Runner 读到的 version 不会被抢占
+	_, ver, err := events.ListEvents(ctx, jobID)
+	require.NoError(t, err)
+	require.Equal(t, 1, ver)
+}
+
+// setupReviewHandler 创建 Parked 于 llm 审阅门（wait_kind=review）的 job，resumption_context 中带生成草稿
+func setupReviewHandler(t *testing.T) (*Handler, jobstore.JobStore, string) {
+	t.Helper()
+	ctx := context.Background()
+	jobID := "j-review"
+	meta := job.NewJobStoreMem()
+	if _, err := meta.Create(ctx, &job.Job{ID: jobID, AgentID: "a1", Goal: "draft email"}); err != nil {
+		t.Fatalf("Create job: %v", err)
+	}
+	require.NoError(t, meta.UpdateStatus(ctx, jobID, job.StatusRunning))
+	require.NoError(t, meta.UpdateStatus(ctx, jobID, job.StatusParked))
+	eventStore := jobstore.NewMemoryStore()
+	resumption, _ := json.Marshal(map[string]interface{}{
+		"payload_results": map[string]interface{}{"draft": map[string]interface{}{"output": "Hi Bob"}},
+	})
+	payloadWait, _ := json.Marshal(jobstore.JobWaitingPayload{
+		NodeID: "draft", CorrelationKey: "review-" + jobID + "-draft", WaitType: "signal", WaitKind: planner.WaitKindReview, ResumptionContext: resumption,
+	})
+	_, _ = eventStore.Append(ctx, jobID, 0, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCreated})
+	_, _ = eventStore.Append(ctx, jobID, 1, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobWaiting, Payload: payloadWait})
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(meta)
+	handler.SetJobEventStore(eventStore)
+	return handler, eventStore, jobID
+}
+
+func TestReviewJobNode_EditRecordsEventAndResumes(t *testing.T) {
+	ctx := context.Background()
+	handler, eventStore, jobID := setupReviewHandler(t)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/jobs/:id/nodes/:node_id/review", handler.ReviewJobNode)
+
+	body := []byte(`{"output":"Hi Bob, thanks!"}`)
+	w := ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/nodes/draft/review", &ut.Body{Body: bytes.NewReader(body), Len: len(body)})
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("review status = %d, body %s", got, w.Result().Body())
+	}
+	events, _, _ := eventStore.ListEvents(ctx, jobID)
+	if len(events) != 4 || events[2].Type != jobstore.LLMOutputReviewed || events[3].Type != jobstore.WaitCompleted {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	var reviewed jobstore.LLMOutputReviewedPayload
+	_ = json.Unmarshal(events[2].Payload, &reviewed)
+	if reviewed.Decision != "edit" || reviewed.Original != "Hi Bob" || reviewed.Output != "Hi Bob, thanks!" {
+		t.Fatalf("unexpected review payload: %+v", reviewed)
+	}
+	var completed struct {
+		Payload map[string]interface{} `json:"payload"`
+	}
+	_ = json.Unmarshal(events[3].Payload, &completed)
+	if completed.Payload["output"] != "Hi Bob, thanks!" {
+		t.Fatalf("wait_completed should carry edited output, got %v", completed.Payload)
+	}
+	j, _ := handler.jobStore.Get(ctx, jobID)
+	if j.Status != job.StatusPending {
+		t.Fatalf("job status = %v, want pending", j.Status)
+	}
+
+	// 重复提交幂等
+	w = ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/nodes/draft/review", &ut.Body{Body: bytes.NewReader(body), Len: len(body)})
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("idempotent review status = %d", got)
+	}
+	if events, _, _ = eventStore.ListEvents(ctx, jobID); len(events) != 4 {
+		t.Fatalf("idempotent review should not append events, got %d", len(events))
+	}
+}
+
+func TestReviewJobNode_Validation(t *testing.T) {
+	handler, _, jobID := setupReviewHandler(t)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/jobs/:id/nodes/:node_id/review", handler.ReviewJobNode)
+
+	for _, tc := range []struct {
+		node, body string
+		want       int
+	}{
+		{"other", `{"decision":"approve"}`, 404},
+		{"draft", `{"decision":"edit"}`, 400},
+		{"draft", `{"decision":"reject"}`, 400},
+	} {
+		w := ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/nodes/"+tc.node+"/review", &ut.Body{Body: bytes.NewReader([]byte(tc.body)), Len: len(tc.body)})
+		if got := w.Result().StatusCode(); got != tc.want {
+			t.Errorf("node=%s body=%s: status %d, want %d", tc.node, tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestGoalTemplates_RenderAndMessage(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetGoalTemplates(goaltemplate.NewStoreMem())
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/agents/:id/templates", handler.ListGoalTemplates)
+	s.PUT("/api/agents/:id/templates/:name", handler.PutGoalTemplate)
+	s.POST("/api/agents/:id/templates/:name/render", handler.RenderGoalTemplate)
+	s.POST("/api/agents/:id/message", handler.AgentMessage)
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, method, path, &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+	base := "/api/agents/" + a.ID
+
+	if code, _ := do("PUT", base+"/templates/refund", `{"goal":"Refund {{order}} because {{why}}","params":[{"name":"order","required":true}]}`); code != 400 {
+		t.Fatalf("undeclared placeholder should be rejected, got %d", code)
+	}
+	tpl := `{"description":"Refund an order","goal":"Refund {{order}} ({{qty}} items)","params":[{"name":"order","required":true},{"name":"qty","type":"integer","default":1}]}`
+	if code, out := do("PUT", base+"/templates/refund", tpl); code != 200 || out["name"] != "refund" {
+		t.Fatalf("put template: %d %v", code, out)
+	}
+	if code, out := do("GET", base+"/templates", ""); code != 200 || len(out["templates"].([]interface{})) != 1 {
+		t.Fatalf("list templates: %d %v", code, out)
+	}
+	code, out := do("POST", base+"/templates/refund/render", `{"params":{"qty":"two"}}`)
+	if code != 400 || len(out["fields"].([]interface{})) != 2 {
+		t.Fatalf("render with bad params: %d %v", code, out)
+	}
+	if code, out = do("POST", base+"/templates/refund/render", `{"params":{"order":"ORD-1"}}`); code != 200 || out["goal"] != "Refund ORD-1 (1 items)" {
+		t.Fatalf("render: %d %v", code, out)
+	}
+	if code, _ = do("POST", base+"/message", `{"template":"refund","params":{}}`); code != 400 {
+		t.Fatalf("message with invalid params should be 400, got %d", code)
+	}
+	if code, _ = do("POST", base+"/message", `{"template":"missing"}`); code != 404 {
+		t.Fatalf("message with unknown template should be 404, got %d", code)
+	}
+	code, out = do("POST", base+"/message", `{"template":"refund","params":{"order":"ORD-7","qty":3}}`)
+	if code != 202 {
+		t.Fatalf("templated message: %d %v", code, out)
+	}
+	jobID, _ := out["job_id"].(string)
+	j, _ := jobs.Get(ctx, jobID)
+	if j == nil || j.Goal != "Refund ORD-7 (3 items)" {
+		t.Fatalf("job goal = %+v", j)
+	}
+	evs, _, _ := events.ListEvents(ctx, jobID)
+	if len(evs) == 0 || !strings.Contains(string(evs[0].Payload), `"template":"refund"`) {
+		t.Fatalf("job_created should record template: %+v", evs)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/inbound"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/metrics"
+)
+
+// InboundWebhook POST /api/webhooks/inbound/:channel：外部系统回调入口，不走平台鉴权，
+// 由通道配置（api.inbound_webhooks）验签后按字段映射转为 Job signal 或 message
+func (h *Handler) InboundWebhook(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("channel")
+	d, err := h.inboundWebhooks.Handle(name, inbound.Request{
+		Body:   c.Request.Body(),
+		Header: func(k string) string { return string(c.Request.Header.Peek(k)) },
+		Query:  func(k string) string { return c.Query(k) },
+	}, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, inbound.ErrUnknownChannel):
+			metrics.InboundWebhooksTotal.WithLabelValues("unknown", "rejected").Inc()
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "webhook.channel_not_found")})
+		case errors.Is(err, inbound.ErrVerification):
+			hlog.CtxWarnf(ctx, "inbound webhook %s: %v", name, err)
+			metrics.InboundWebhooksTotal.WithLabelValues(name, "unverified").Inc()
+			c.JSON(consts.StatusUnauthorized, map[string]string{"error": i18n.T(ctx, "webhook.verification_failed")})
+		default:
+			metrics.InboundWebhooksTotal.WithLabelValues(name, "unmapped").Inc()
+			c.JSON(consts.StatusUnprocessableEntity, map[string]string{"error": i18n.T(ctx, "webhook.mapping_failed", err.Error())})
+		}
+		return
+	}
+	if h.jobStore == nil || h.jobEventStore == nil {
+		metrics.InboundWebhooksTotal.WithLabelValues(name, "error").Inc()
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
+		return
+	}
+	// 通道限定租户时，其他租户的 Job 与不存在的 Job 同样返回 404
+	j, err := h.jobStore.Get(ctx, d.JobID)
+	if err != nil || j == nil || (d.TenantID != "" && j.TenantID != d.TenantID) {
+		metrics.InboundWebhooksTotal.WithLabelValues(name, "rejected").Inc()
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "job.not_found")})
+		return
+	}
+	var code int
+	var body interface{}
+	if d.Action == inbound.ActionMessage {
+		code, body = h.deliverMessage(ctx, j, JobMessageRequest{
+			MessageID:      d.MessageID,
+			Channel:        d.MessageChannel,
+			CorrelationKey: d.CorrelationKey,
+			Payload:        d.Payload,
+		})
+	} else {
+		code, body = h.deliverSignal(ctx, j, JobSignalRequest{CorrelationKey: d.CorrelationKey, Payload: d.Payload})
+	}
+	result := "delivered"
+	switch {
+	case code >= 500:
+		result = "error"
+	case code >= 400:
+		result = "rejected"
+	}
+	metrics.InboundWebhooksTotal.WithLabelValues(name, result).Inc()
+	c.JSON(code, body)
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/inbound"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestInboundWebhook_SignalAndMessage(t *testing.T) {
+	handler, jobID := setupJobSignalHandler(t)
+	reg, err := inbound.NewRegistry([]inbound.Channel{
+		{
+			Name:     "github",
+			TenantID: "default",
+			Verify:   inbound.Verification{Type: inbound.VerifyHMAC, Header: "X-Hub-Signature-256", Prefix: "sha256=", Secret: "s3cret"},
+			Mapping:  inbound.Mapping{JobID: "$.job", CorrelationKey: "$.key", Payload: "$.review"},
+		},
+		{
+			Name:     "other-tenant",
+			TenantID: "acme",
+			Verify:   inbound.Verification{Type: inbound.VerifyToken, Secret: "t"},
+			Mapping:  inbound.Mapping{JobID: "$.job", CorrelationKey: "$.key"},
+		},
+		{
+			Name:    "notify",
+			Action:  inbound.ActionMessage,
+			Verify:  inbound.Verification{Type: inbound.VerifyToken, Secret: "t"},
+			Mapping: inbound.Mapping{JobID: "$.job", MessageID: "$.id"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.SetInboundWebhooks(reg)
+	h := server.Default(server.WithHostPorts(":0"))
+	h.POST("/api/webhooks/inbound/:channel", func(ctx context.Context, c *app.RequestContext) {
+		handler.InboundWebhook(ctx, c)
+	})
+	post := func(channel, body string, hdr ...ut.Header) int {
+		w := ut.PerformRequest(h.Engine, "POST", "/api/webhooks/inbound/"+channel, &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)}, hdr...)
+		return w.Result().StatusCode()
+	}
+	sign := func(body string) ut.Header {
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(body))
+		return ut.Header{Key: "X-Hub-Signature-256", Value: "sha256=" + hex.EncodeToString(mac.Sum(nil))}
+	}
+	token := ut.Header{Key: "Authorization", Value: "Bearer t"}
+
+	body := `{"job":"` + jobID + `","key":"expected-key","review":{"state":"approved"}}`
+	if code := post("missing", body, sign(body)); code != 404 {
+		t.Fatalf("unknown channel: status %d, want 404", code)
+	}
+	if code := post("github", body, ut.Header{Key: "X-Hub-Signature-256", Value: "sha256=00"}); code != 401 {
+		t.Fatalf("bad signature: status %d, want 401", code)
+	}
+	if code := post("github", `{"key":"expected-key"}`, sign(`{"key":"expected-key"}`)); code != 422 {
+		t.Fatalf("missing job_id: status %d, want 422", code)
+	}
+	if code := post("other-tenant", body, token); code != 404 {
+		t.Fatalf("job outside channel tenant: status %d, want 404", code)
+	}
+	if code := post("github", body, sign(body)); code != 200 {
+		t.Fatalf("signal: status %d, want 200", code)
+	}
+	ctx := context.Background()
+	j, _ := handler.jobStore.Get(ctx, jobID)
+	if j.Status != job.StatusPending {
+		t.Fatalf("job status = %v, want pending", j.Status)
+	}
+	events, _, _ := handler.jobEventStore.ListEvents(ctx, jobID)
+	last := events[len(events)-1]
+	if last.Type != jobstore.WaitCompleted || !bytes.Contains(last.Payload, []byte(`"state":"approved"`)) {
+		t.Fatalf("last event = %s %s", last.Type, last.Payload)
+	}
+
+	msg := `{"job":"` + jobID + `","id":"evt_1","amount":5}`
+	for i := 0; i < 2; i++ {
+		if code := post("notify", msg, token); code != 200 {
+			t.Fatalf("message #%d: status %d, want 200", i, code)
+		}
+	}
+	events, _, _ = handler.jobEventStore.ListEvents(ctx, jobID)
+	n := 0
+	for _, e := range events {
+		if e.Type == jobstore.AgentMessage {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Fatalf("agent_message count = %d, want 1 (redelivery is idempotent)", n)
+	}
+}
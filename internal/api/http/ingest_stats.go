@@ -0,0 +1,174 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/ingestqueue"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/metrics"
+)
+
+// IngestStatsQueue 入库队列的统计与重试能力（可选）；postgres 入库队列实现
+type IngestStatsQueue interface {
+	Stats(ctx context.Context, tenant string, since time.Time) (*ingestqueue.Stats, error)
+	ListFailed(ctx context.Context, tenant string, limit int) ([]ingestqueue.FailedTask, error)
+	Retry(ctx context.Context, tenant, taskID string) (bool, error)
+}
+
+// ingestFailureView failed 任务 + 重试入口
+type ingestFailureView struct {
+	ingestqueue.FailedTask
+	Retry *ingestRetryAction `json:"retry,omitempty"`
+}
+
+// ingestRetryAction 重试按钮对应的请求
+type ingestRetryAction struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+func (h *Handler) ingestStatsQueue() (IngestStatsQueue, bool) {
+	if h.ingestQueue == nil {
+		return nil, false
+	}
+	q, ok := h.ingestQueue.(IngestStatsQueue)
+	return q, ok
+}
+
+// GetIngestStats 入库流水线统计：各状态任务数、窗口内各阶段耗时、按阶段/error分类聚合的failed数与最近failed任务（含重试入口）
+// GET /api/ingest/stats?window=24h&limit=20
+func (h *Handler) GetIngestStats(ctx context.Context, c *app.RequestContext) {
+	q, ok := h.ingestStatsQueue()
+	if !ok {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "ingest.stats_requires_postgres")})
+		return
+	}
+	window := 24 * time.Hour
+	if s := c.Query("window"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			window = d
+		}
+	}
+	limit := 20
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 && n <= 200 {
+		limit = n
+	}
+	tenant := auth.GetTenantID(ctx)
+	stats, err := q.Stats(ctx, tenant, time.Now().Add(-window))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "入库统计failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "ingest.stats_failed")})
+		return
+	}
+	failed, err := q.ListFailed(ctx, tenant, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "查询failed入库任务failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "ingest.stats_failed")})
+		return
+	}
+	if tenant == "" {
+		for status, n := range stats.Counts {
+			metrics.IngestTasks.WithLabelValues(status).Set(float64(n))
+		}
+	}
+	recent := make([]ingestFailureView, 0, len(failed))
+	for _, t := range failed {
+		v := ingestFailureView{FailedTask: t}
+		if t.Retryable {
+			v.Retry = &ingestRetryAction{Method: "POST", Path: "/api/ingest/tasks/" + t.TaskID + "/retry"}
+		}
+		recent = append(recent, v)
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"counts":          stats.Counts,
+		"since":           stats.Since,
+		"window_seconds":  int(window.Seconds()),
+		"stages":          stats.Stages,
+		"failures":        stats.Failures,
+		"recent_failures": recent,
+	})
+}
+
+// RetryIngestTask 将 failed 入库任务重置为 pending 由 Worker 重新认领；任务不存在返回 404，非 failed 返回 409
+// POST /api/ingest/tasks/:task_id/retry
+func (h *Handler) RetryIngestTask(ctx context.Context, c *app.RequestContext) {
+	q, ok := h.ingestStatsQueue()
+	if !ok {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "ingest.stats_requires_postgres")})
+		return
+	}
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.task_id_required")})
+		return
+	}
+	retried, err := q.Retry(ctx, auth.GetTenantID(ctx), taskID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "重试入库任务failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "ingest.retry_failed")})
+		return
+	}
+	if !retried {
+		status, _, _, _, err := h.ingestQueue.GetStatus(ctx, taskID)
+		if err != nil || status == "" {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "ingest.task_not_found")})
+			return
+		}
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "ingest.task_not_failed"), "status": status})
+		return
+	}
+	c.JSON(consts.StatusAccepted, map[string]interface{}{
+		"task_id": taskID,
+		"status":  "pending",
+	})
+}
+
+// ingestSummary 可观测性汇总中的入库部分：各状态任务数与最近failed任务 ID（可经 /api/documents/upload/status/:task_id 下钻）
+func (h *Handler) ingestSummary(ctx context.Context) map[string]interface{} {
+	q, ok := h.ingestStatsQueue()
+	if !ok {
+		return nil
+	}
+	out := map[string]interface{}{
+		"counts":                 map[string]int{},
+		"recent_failed_task_ids": []string{},
+	}
+	tenant := auth.GetTenantID(ctx)
+	if stats, err := q.Stats(ctx, tenant, time.Now().Add(-24*time.Hour)); err != nil {
+		hlog.CtxErrorf(ctx, "入库统计failed: %v", err)
+	} else {
+		out["counts"] = stats.Counts
+		out["failures"] = stats.Failures
+	}
+	if failed, err := q.ListFailed(ctx, tenant, 10); err != nil {
+		hlog.CtxErrorf(ctx, "查询failed入库任务failed: %v", err)
+	} else {
+		ids := make([]string, 0, len(failed))
+		for _, t := range failed {
+			ids = append(ids, t.TaskID)
+		}
+		out["recent_failed_task_ids"] = ids
+	}
+	return out
+}
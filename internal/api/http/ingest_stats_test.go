@@ -0,0 +1,145 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/ingestqueue"
+	"rag-platform/internal/pipeline/common"
+)
+
+// fakeIngestQueue 内存入库队列：tasks 记录 task_id → status
+type fakeIngestQueue struct {
+	tasks  map[string]string
+	failed []ingestqueue.FailedTask
+}
+
+func (q *fakeIngestQueue) Enqueue(ctx context.Context, payload map[string]interface{}) (string, error) {
+	return "t-new", nil
+}
+
+func (q *fakeIngestQueue) GetStatus(ctx context.Context, taskID string) (string, interface{}, string, interface{}, error) {
+	return q.tasks[taskID], nil, "", nil, nil
+}
+
+func (q *fakeIngestQueue) Stats(ctx context.Context, tenant string, since time.Time) (*ingestqueue.Stats, error) {
+	counts := map[string]int{}
+	for _, st := range q.tasks {
+		counts[st]++
+	}
+	return &ingestqueue.Stats{
+		Counts:   counts,
+		Since:    since,
+		Stages:   []ingestqueue.StageStat{{Stage: common.StageEmbed, Count: 3, Failures: 1, AvgMs: 120, P95Ms: 300}},
+		Failures: []ingestqueue.FailureCount{{Stage: common.StageEmbed, Class: common.ErrorClassRateLimited, Count: 1}},
+	}, nil
+}
+
+func (q *fakeIngestQueue) ListFailed(ctx context.Context, tenant string, limit int) ([]ingestqueue.FailedTask, error) {
+	return q.failed, nil
+}
+
+func (q *fakeIngestQueue) Retry(ctx context.Context, tenant, taskID string) (bool, error) {
+	if q.tasks[taskID] != "failed" {
+		return false, nil
+	}
+	q.tasks[taskID] = "pending"
+	return true, nil
+}
+
+func TestIngestStatsAndRetry(t *testing.T) {
+	q := &fakeIngestQueue{
+		tasks: map[string]string{"t1": "failed", "t2": "failed", "t3": "completed"},
+		failed: []ingestqueue.FailedTask{
+			{TaskID: "t1", Stage: common.StageEmbed, Class: common.ErrorClassRateLimited, Error: "429", Retryable: true},
+			{TaskID: "t2", Stage: common.StageParse, Class: common.ErrorClassInvalidInput, Error: "unsupported"},
+		},
+	}
+	handler := NewHandler(nil, nil)
+	handler.SetIngestQueue(q)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/ingest/stats", handler.GetIngestStats)
+	s.POST("/api/ingest/tasks/:task_id/retry", handler.RetryIngestTask)
+	s.GET("/api/observability/summary", handler.GetObservabilitySummary)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/ingest/stats?window=1h", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("stats status = %d: %s", got, w.Result().Body())
+	}
+	var stats struct {
+		Counts         map[string]int          `json:"counts"`
+		WindowSeconds  int                     `json:"window_seconds"`
+		Stages         []ingestqueue.StageStat `json:"stages"`
+		RecentFailures []struct {
+			TaskID string             `json:"task_id"`
+			Retry  *ingestRetryAction `json:"retry"`
+		} `json:"recent_failures"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Counts["failed"] != 2 || stats.WindowSeconds != 3600 || len(stats.Stages) != 1 || len(stats.RecentFailures) != 2 {
+		t.Fatalf("unexpected stats: %s", w.Result().Body())
+	}
+	if r := stats.RecentFailures[0].Retry; r == nil || r.Path != "/api/ingest/tasks/t1/retry" {
+		t.Fatalf("retryable failure should carry retry action: %s", w.Result().Body())
+	}
+	if stats.RecentFailures[1].Retry != nil {
+		t.Fatalf("invalid_input failure should not carry retry action")
+	}
+
+	w = ut.PerformRequest(s.Engine, "POST", "/api/ingest/tasks/t1/retry", nil)
+	if got := w.Result().StatusCode(); got != 202 || q.tasks["t1"] != "pending" {
+		t.Fatalf("retry status = %d task=%s", got, q.tasks["t1"])
+	}
+	w = ut.PerformRequest(s.Engine, "POST", "/api/ingest/tasks/t3/retry", nil)
+	if got := w.Result().StatusCode(); got != 409 {
+		t.Fatalf("retry completed status = %d, want 409", got)
+	}
+	w = ut.PerformRequest(s.Engine, "POST", "/api/ingest/tasks/nope/retry", nil)
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("retry missing status = %d, want 404", got)
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/observability/summary", nil)
+	var summary struct {
+		Ingest struct {
+			RecentFailedTaskIDs []string `json:"recent_failed_task_ids"`
+		} `json:"ingest"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Ingest.RecentFailedTaskIDs) != 2 || summary.Ingest.RecentFailedTaskIDs[0] != "t1" {
+		t.Fatalf("summary ingest = %s", w.Result().Body())
+	}
+}
+
+func TestIngestStats_RequiresQueue(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/ingest/stats", handler.GetIngestStats)
+	w := ut.PerformRequest(s.Engine, "GET", "/api/ingest/stats", nil)
+	if got := w.Result().StatusCode(); got != 501 {
+		t.Fatalf("status = %d, want 501", got)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/pkg/i18n"
+)
+
+// JobAssertRequest 轨迹断言请求
+type JobAssertRequest struct {
+	Assertions []evalsuite.TraceAssertion `json:"assertions"`
+}
+
+// AssertJob 在服务端按事件流评估已终态 Job 的声明式断言（步骤完成、工具调用次数、无 permanent failure、成本/耗时上限等），
+// 返回逐条 pass/fail；供 CI 以真实端到端运行作为发布门禁。断言按查看者可见的事件评估（受限查看者的回答内容被遮蔽）
+// POST /api/jobs/:id/assert
+func (h *Handler) AssertJob(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	var req JobAssertRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+		return
+	}
+	if err := evalsuite.ValidateTraceAssertions(req.Assertions); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !j.Status.IsTerminal() {
+		c.JSON(consts.StatusConflict, map[string]interface{}{
+			"error":  i18n.T(ctx, "eval.job_not_terminal"),
+			"status": j.Status.String(),
+		})
+		return
+	}
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, evalsuite.EvaluateTrace(jobID, j.Status.String(), h.viewEvents(ctx, events), req.Assertions, h.planCostModel))
+}
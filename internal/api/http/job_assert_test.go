@@ -0,0 +1,80 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestAssertJob(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/jobs/:id/assert", handler.AssertJob)
+	post := func(jobID, body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, "POST", "/api/jobs/"+jobID+"/assert", &ut.Body{Body: strings.NewReader(body), Len: len(body)}, ut.Header{Key: "Content-Type", Value: "application/json"})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+
+	id, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "refund order 1"})
+	ver := 0
+	for _, e := range []struct {
+		typ jobstore.EventType
+		pl  string
+	}{
+		{jobstore.ToolInvocationStarted, `{"tool_name":"refund.create"}`},
+		{jobstore.NodeFinished, `{"node_id":"n1","result_type":"side_effect_committed"}`},
+		{jobstore.JobCompleted, `{}`},
+	} {
+		ver, _ = events.Append(ctx, id, ver, jobstore.JobEvent{JobID: id, Type: e.typ, Payload: []byte(e.pl)})
+	}
+	assertions := `{"assertions":[{"type":"step_completed","step":"n1"},{"type":"tool_called","tool":"refund.create","max":1},{"type":"no_permanent_failures"},{"type":"status","status":"completed"}]}`
+
+	if code, out := post(id, assertions); code != 409 || out["status"] != "pending" {
+		t.Fatalf("running job: %d %v", code, out)
+	}
+	_ = jobs.UpdateStatus(ctx, id, job.StatusRunning)
+	_ = jobs.UpdateStatus(ctx, id, job.StatusCompleted)
+	if code, _ := post(id, `{"assertions":[{"type":"tool_called"}]}`); code != 400 {
+		t.Fatalf("invalid assertion status = %d, want 400", code)
+	}
+	if code, _ := post("missing", assertions); code != 404 {
+		t.Fatalf("missing job status = %d, want 404", code)
+	}
+	code, out := post(id, assertions)
+	if code != 200 || out["passed"] != true || out["total"] != 4.0 || out["failed"] != 0.0 {
+		t.Fatalf("assert: %d %v", code, out)
+	}
+	code, out = post(id, `{"assertions":[{"type":"tool_called","tool":"refund.create","min":2}]}`)
+	results, _ := out["results"].([]interface{})
+	if code != 200 || out["passed"] != false || len(results) != 1 || results[0].(map[string]interface{})["actual"] != 1.0 {
+		t.Fatalf("failing assert: %d %v", code, out)
+	}
+}
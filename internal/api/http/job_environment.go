@@ -0,0 +1,51 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+
+	"rag-platform/internal/agent/environment"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// appendJobEnvironment 在 job_created 之后写入 job_environment（当前解析出的执行环境）；失败只记日志，返回最新版本
+func (h *Handler) appendJobEnvironment(ctx context.Context, jobID string, ver int) int {
+	if h.environment == nil {
+		return ver
+	}
+	env := h.environment.Current()
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return ver
+	}
+	next, err := h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobEnvironment, Payload: payload})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "追加 job_environment 事件failed（不影响主流程）: %v", err)
+		return ver
+	}
+	return next
+}
+
+// environmentCheck 比对事件流中固化的执行环境与当前环境；未配置解析器时返回 nil
+func (h *Handler) environmentCheck(events []jobstore.JobEvent) *environment.Check {
+	if h.environment == nil {
+		return nil
+	}
+	return environment.Compare(events, h.environment.Current())
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/job"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/metrics"
+)
+
+// jobExportColumns CSV 列顺序，与 JobExportRow 的 JSON 字段一致
+var jobExportColumns = []string{
+	"job_id", "agent_id", "tenant_id", "status", "goal", "profile", "queue_class", "priority", "retry_count",
+	"created_at", "updated_at", "duration_ms", "tool_calls", "cost", "failure_class", "failed_node_id", "reason", "user_id", "client",
+}
+
+// JobExportRow 导出的一行 Job 元数据与指标；cost 按 plan_cost 标注估算，duration_ms 为 job_created 到终态事件（无事件时为 updated_at - created_at）
+type JobExportRow struct {
+	JobID        string    `json:"job_id"`
+	AgentID      string    `json:"agent_id"`
+	TenantID     string    `json:"tenant_id"`
+	Status       string    `json:"status"`
+	Goal         string    `json:"goal"`
+	Profile      string    `json:"profile,omitempty"`
+	QueueClass   string    `json:"queue_class,omitempty"`
+	Priority     int       `json:"priority"`
+	RetryCount   int       `json:"retry_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	DurationMs   int64     `json:"duration_ms"`
+	ToolCalls    int       `json:"tool_calls"`
+	Cost         float64   `json:"cost"`
+	FailureClass string    `json:"failure_class,omitempty"`
+	FailedNodeID string    `json:"failed_node_id,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	UserID       string    `json:"user_id,omitempty"`
+	Client       string    `json:"client,omitempty"`
+}
+
+func (r *JobExportRow) csvRecord() []string {
+	return []string{
+		r.JobID, r.AgentID, r.TenantID, r.Status, r.Goal, r.Profile, r.QueueClass, strconv.Itoa(r.Priority), strconv.Itoa(r.RetryCount),
+		r.CreatedAt.UTC().Format(time.RFC3339), r.UpdatedAt.UTC().Format(time.RFC3339), strconv.FormatInt(r.DurationMs, 10),
+		strconv.Itoa(r.ToolCalls), strconv.FormatFloat(r.Cost, 'f', -1, 64), r.FailureClass, r.FailedNodeID, r.Reason, r.UserID, r.Client,
+	}
+}
+
+// ExportJobs GET /api/jobs/export：按条件流式导出 Job 元数据、耗时、成本与结果，供表格 / BI 离线分析。
+// 参数 agent_ids（或 agent_id，逗号分隔）、status、since（如 7d、24h）或 from/to（RFC3339）、format（csv 默认 | json，json 为每行一个对象的 NDJSON）、cursor（从该位置继续）。
+// 按 created_at 倒序逐页（job.MaxPageLimit）读取并写出，不在内存中累积全部结果
+func (h *Handler) ExportJobs(ctx context.Context, c *app.RequestContext) {
+	if h.jobStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "job.export.invalid_format")})
+		return
+	}
+	agentIDs := splitCSV(c.Query("agent_ids"))
+	if len(agentIDs) == 0 {
+		agentIDs = splitCSV(c.Query("agent_id"))
+	}
+	q := job.PageQuery{AgentIDs: agentIDs, TenantID: auth.GetTenantID(ctx), Limit: job.MaxPageLimit, Cursor: c.Query("cursor")}
+	for _, s := range splitCSV(c.Query("status")) {
+		st, ok := job.ParseStatus(s)
+		if !ok {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.invalid_status")})
+			return
+		}
+		q.Statuses = append(q.Statuses, st)
+	}
+	if s := c.Query("since"); s != "" {
+		d, err := parseSince(s)
+		if err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.window_invalid")})
+			return
+		}
+		q.From = time.Now().Add(-d)
+	}
+	var errTime error
+	if s := c.Query("from"); s != "" {
+		q.From, errTime = time.Parse(time.RFC3339, s)
+	}
+	if s := c.Query("to"); s != "" && errTime == nil {
+		q.To, errTime = time.Parse(time.RFC3339, s)
+	}
+	if errTime != nil || (!q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To)) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.invalid_time")})
+		return
+	}
+	// 第一页同步读取，参数与存储错误仍可返回对应状态码
+	page, err := h.listJobPage(ctx, q)
+	if errors.Is(err, job.ErrInvalidPageCursor) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.invalid_cursor")})
+		return
+	}
+	if errors.Is(err, errAgentIDsRequired) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.agent_required")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ExportJobs: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
+		return
+	}
+
+	maskGoal := h.traceMaskFor(ctx).Masks("goal")
+	// 后续页在 handler 返回后写出：脱离请求的取消，但保留请求截止时间（api.timeouts.routes 可为导出单独放宽）
+	streamCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+	if deadline, ok := ctx.Deadline(); ok {
+		streamCtx, cancel = context.WithDeadline(streamCtx, deadline)
+	}
+	method, route := string(c.Method()), c.FullPath()
+	pr, pw := io.Pipe()
+	go func() {
+		defer cancel()
+		err := h.writeJobExport(streamCtx, pw, format, q, page, maskGoal)
+		if errors.Is(err, context.DeadlineExceeded) {
+			metrics.APIRequestTimeoutsTotal.WithLabelValues(method, route).Inc()
+			hlog.CtxWarnf(streamCtx, "ExportJobs: stream exceeded request deadline")
+		}
+		pw.CloseWithError(err)
+	}()
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="jobs.csv"`)
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.SetStatusCode(consts.StatusOK)
+	c.SetBodyStream(pr, -1)
+}
+
+// writeJobExport 从 first 开始按游标逐页写出；客户端断开时写入 pipe 失败即停止
+func (h *Handler) writeJobExport(ctx context.Context, w io.Writer, format string, q job.PageQuery, first *job.Page, maskGoal bool) error {
+	var cw *csv.Writer
+	var enc *json.Encoder
+	if format == "csv" {
+		cw = csv.NewWriter(w)
+		if err := cw.Write(jobExportColumns); err != nil {
+			return err
+		}
+	} else {
+		enc = json.NewEncoder(w)
+	}
+	page := first
+	for {
+		for _, j := range page.Jobs {
+			row := h.jobExportRow(ctx, j, maskGoal)
+			if cw != nil {
+				if err := cw.Write(row.csvRecord()); err != nil {
+					return err
+				}
+			} else if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		if cw != nil {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor = page.NextCursor
+		next, err := h.listJobPage(ctx, q)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ExportJobs: list page: %v", err)
+			return err
+		}
+		page = next
+	}
+}
+
+// jobExportRow 由 Job 元数据与事件流（若配置了事件存储）组装导出行
+func (h *Handler) jobExportRow(ctx context.Context, j *job.Job, maskGoal bool) *JobExportRow {
+	row := &JobExportRow{
+		JobID: j.ID, AgentID: j.AgentID, TenantID: j.TenantID, Status: j.Status.String(), Goal: j.Goal,
+		Profile: j.Profile, QueueClass: j.QueueClass, Priority: j.Priority, RetryCount: j.RetryCount,
+		CreatedAt: j.CreatedAt, UpdatedAt: j.UpdatedAt,
+	}
+	if maskGoal && row.Goal != "" {
+		row.Goal = TraceMaskedValue
+	}
+	if t := j.Terminal; t != nil {
+		row.FailureClass, row.FailedNodeID, row.Reason = t.FailureClass, t.FailedNodeID, t.Reason
+	}
+	if a := j.Attribution; a != nil {
+		row.UserID, row.Client = a.UserID, a.Client
+	}
+	if h.jobEventStore != nil {
+		if events, _, err := h.jobEventStore.ListEvents(ctx, j.ID); err == nil && len(events) > 0 {
+			o := evalsuite.OutcomeFromEvents(j.ID, row.Status, events, h.planCostModel)
+			row.DurationMs, row.Cost = o.DurationMs, o.Cost
+			for _, n := range o.ToolCalls {
+				row.ToolCalls += n
+			}
+		}
+	}
+	if row.DurationMs == 0 && j.Status.IsTerminal() && j.UpdatedAt.After(j.CreatedAt) {
+		row.DurationMs = j.UpdatedAt.Sub(j.CreatedAt).Milliseconds()
+	}
+	return row
+}
+
+// parseSince 解析回溯时长：time.ParseDuration 的格式，另支持天（如 7d）
+func parseSince(s string) (time.Duration, error) {
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil || days <= 0 {
+			return 0, errors.New("invalid since")
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid since")
+	}
+	return d, nil
+}
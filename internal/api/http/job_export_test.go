@@ -0,0 +1,118 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestExportJobs_CSVAndJSON(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	// 超过一页（job.MaxPageLimit）以覆盖游标续读
+	n := job.MaxPageLimit + 5
+	for i := 0; i < n; i++ {
+		agent := "a1"
+		if i%2 == 1 {
+			agent = "a2"
+		}
+		id := fmt.Sprintf("job-%03d", i)
+		if _, err := jobs.Create(ctx, &job.Job{ID: id, AgentID: agent, TenantID: "default", Goal: "g"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ver, _ := events.Append(ctx, "job-000", 0, jobstore.JobEvent{JobID: "job-000", Type: jobstore.JobCreated, Payload: []byte(`{}`)})
+	ver, _ = events.Append(ctx, "job-000", ver, jobstore.JobEvent{JobID: "job-000", Type: jobstore.ToolInvocationStarted, Payload: []byte(`{"tool_name":"search"}`)})
+	_, _ = events.Append(ctx, "job-000", ver, jobstore.JobEvent{JobID: "job-000", Type: jobstore.JobCompleted, Payload: []byte(`{}`)})
+	_ = jobs.UpdateStatus(ctx, "job-000", job.StatusRunning)
+	_ = jobs.UpdateStatus(ctx, "job-000", job.StatusCompleted)
+
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetPlanCostModel(planner.CostModel{Tools: map[string]planner.ToolCostHint{"search": {CostPerCall: 0.25}}})
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/export", handler.ExportJobs)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/export?agent_id=a1&since=7d", nil)
+	if code := w.Result().StatusCode(); code != 200 {
+		t.Fatalf("status = %d: %s", code, w.Result().Body())
+	}
+	records, err := csv.NewReader(bytes.NewReader(w.Result().Body())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(records[0], ",") != strings.Join(jobExportColumns, ",") {
+		t.Fatalf("header = %v", records[0])
+	}
+	if got := len(records) - 1; got != (n+1)/2 {
+		t.Fatalf("rows = %d, want %d", got, (n+1)/2)
+	}
+	var found bool
+	for _, r := range records[1:] {
+		if r[0] == "job-000" {
+			found = true
+			if r[3] != "completed" || r[12] != "1" || r[13] != "0.25" {
+				t.Fatalf("job-000 row = %v", r)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("job-000 missing")
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/export?agent_ids=a1,a2&format=json&status=completed", nil)
+	lines := strings.Split(strings.TrimSpace(string(w.Result().Body())), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("json lines = %d: %s", len(lines), w.Result().Body())
+	}
+	var row JobExportRow
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil || row.JobID != "job-000" || row.ToolCalls != 1 {
+		t.Fatalf("row = %+v err = %v", row, err)
+	}
+
+	for _, path := range []string{
+		"/api/jobs/export?agent_id=a1&format=xlsx",
+		"/api/jobs/export?agent_id=a1&since=-1d",
+		"/api/jobs/export?agent_id=a1&status=nope",
+		"/api/jobs/export?agent_id=a1&cursor=%%%",
+	} {
+		if code := ut.PerformRequest(s.Engine, "GET", path, nil).Result().StatusCode(); code != 400 {
+			t.Fatalf("%s status = %d, want 400", path, code)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	for s, ok := range map[string]bool{"7d": true, "24h": true, "90m": true, "0d": false, "x": false, "-2h": false} {
+		if _, err := parseSince(s); (err == nil) != ok {
+			t.Errorf("parseSince(%q) err = %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// ReviewJobNodeRequest POST /api/jobs/:id/nodes/:node_id/review 请求体；decision 为空时有 output 视为 edit，否则 approve
+type ReviewJobNodeRequest struct {
+	Decision string  `json:"decision"` // approve | edit
+	Output   *string `json:"output"`   // edit 时必填：替换 LLM 生成内容，供下游节点使用
+	Comment  string  `json:"comment"`
+}
+
+// ReviewJobNode 审阅挂起在 llm 审阅门上的节点：写入 llm_output_reviewed 与 wait_completed（payload 为审阅后的节点结果），并将 Job 置回 Pending 继续执行
+func (h *Handler) ReviewJobNode(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	nodeID := c.Param("node_id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	var req ReviewJobNodeRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+		return
+	}
+	if req.Decision == "" {
+		req.Decision = "approve"
+		if req.Output != nil {
+			req.Decision = "edit"
+		}
+	}
+	switch req.Decision {
+	case "approve":
+	case "edit":
+		if req.Output == nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "review.output_required")})
+			return
+		}
+	default:
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "review.decision_invalid")})
+		return
+	}
+	events, ver, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	var waitPayload jobstore.JobWaitingPayload
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == jobstore.JobWaiting {
+			waitPayload, _ = jobstore.ParseJobWaitingPayload(events[i].Payload)
+			break
+		}
+	}
+	if waitPayload.WaitKind != planner.WaitKindReview || waitPayload.NodeID != nodeID || waitPayload.CorrelationKey == "" {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "review.node_not_waiting")})
+		return
+	}
+	// 幂等：审阅已送达则直接返回
+	if lastEventIsWaitCompletedWithCorrelationKey(events, waitPayload.CorrelationKey) {
+		c.JSON(consts.StatusOK, map[string]interface{}{
+			"job_id":  jobID,
+			"node_id": nodeID,
+			"status":  j.Status,
+			"message": "审阅已送达（幂等）",
+		})
+		return
+	}
+	if j.Status != job.StatusWaiting && j.Status != job.StatusParked {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "review.job_not_waiting")})
+		return
+	}
+	result := reviewNodeResult(waitPayload.ResumptionContext, nodeID)
+	original, _ := result["output"].(string)
+	output := original
+	if req.Decision == "edit" {
+		output = *req.Output
+	}
+	reviewer := auth.GetUserID(ctx)
+	result["output"] = output
+	result["review"] = map[string]interface{}{
+		"decision": req.Decision,
+		"reviewer": reviewer,
+		"edited":   output != original,
+	}
+	reviewedBytes, errMarshal := marshalJSON(ctx, jobstore.LLMOutputReviewedPayload{
+		NodeID:   nodeID,
+		Decision: req.Decision,
+		Original: original,
+		Output:   output,
+		Reviewer: reviewer,
+		Comment:  req.Comment,
+	}, "llm_output_reviewed_payload")
+	if errMarshal != nil {
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "review.build_event_failed")})
+		return
+	}
+	resultBytes, errMarshal := marshalJSON(ctx, result, "review_node_result")
+	if errMarshal != nil {
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "review.build_result_failed")})
+		return
+	}
+	evPayload, errMarshal := marshalJSON(ctx, map[string]interface{}{
+		"node_id":         nodeID,
+		"payload":         json.RawMessage(resultBytes),
+		"correlation_key": waitPayload.CorrelationKey,
+	}, "review_wait_completed_payload")
+	if errMarshal != nil {
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "signal.build_event_failed")})
+		return
+	}
+	ver, err = h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
+		JobID: jobID, Type: jobstore.LLMOutputReviewed, Payload: reviewedBytes,
+	})
+	if err == nil {
+		_, err = h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
+			JobID: jobID, Type: jobstore.WaitCompleted, Payload: evPayload,
+		})
+	}
+	if err != nil {
+		if errors.Is(err, jobstore.ErrVersionMismatch) {
+			c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "job.events_changed")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Append review events: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.write_event_failed")})
+		return
+	}
+	if err := h.jobStore.UpdateStatus(ctx, jobID, job.StatusPending); err != nil {
+		hlog.CtxErrorf(ctx, "UpdateStatus Pending: %v", err)
+	}
+	if h.wakeupQueue != nil {
+		_ = h.wakeupQueue.NotifyReady(ctx, jobID)
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":   jobID,
+		"node_id":  nodeID,
+		"decision": req.Decision,
+		"output":   output,
+		"status":   "pending",
+		"message":  "审阅已记录，Job 将重新入队执行",
+	})
+}
+
+// reviewNodeResult 从 job_waiting 的 resumption_context 取出审阅节点的生成结果（payload_results[node_id]）；缺失时返回空 map
+func reviewNodeResult(resumption json.RawMessage, nodeID string) map[string]interface{} {
+	var rc struct {
+		PayloadResults map[string]json.RawMessage `json:"payload_results"`
+	}
+	out := make(map[string]interface{})
+	if len(resumption) == 0 || json.Unmarshal(resumption, &rc) != nil {
+		return out
+	}
+	if raw, ok := rc.PayloadResults[nodeID]; ok {
+		_ = json.Unmarshal(raw, &out)
+		if out == nil {
+			out = make(map[string]interface{})
+		}
+	}
+	return out
+}
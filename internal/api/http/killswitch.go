@@ -0,0 +1,164 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/killswitch"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// KillSwitchRequest 设置工具类别熔断；active 省略时为 true（开启熔断），false 表示解除
+type KillSwitchRequest struct {
+	Categories []string `json:"categories"`
+	Active     *bool    `json:"active"`
+	Reason     string   `json:"reason"`
+}
+
+// SetToolKillSwitch 按类别开启或解除全局工具熔断（跨所有租户）；变更写入审计记录并立即在本进程生效，Worker 在刷新间隔内生效
+// POST /api/admin/killswitch
+func (h *Handler) SetToolKillSwitch(ctx context.Context, c *app.RequestContext) {
+	if h.killSwitchStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "killswitch.disabled")})
+		return
+	}
+	var req KillSwitchRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "killswitch.categories_required")})
+		return
+	}
+	categories := make([]string, 0, len(req.Categories))
+	seen := make(map[string]bool, len(req.Categories))
+	for _, cat := range req.Categories {
+		cat = killswitch.NormalizeCategory(cat)
+		if cat == "" || seen[cat] {
+			continue
+		}
+		seen[cat] = true
+		categories = append(categories, cat)
+	}
+	if len(categories) == 0 {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "killswitch.categories_required")})
+		return
+	}
+	active := req.Active == nil || *req.Active
+	actor := auth.GetUserID(ctx)
+	now := time.Now()
+	for _, cat := range categories {
+		sw := &killswitch.Switch{Category: cat, Active: active, Reason: req.Reason, UpdatedBy: actor, UpdatedAt: now}
+		if err := h.killSwitchStore.Set(ctx, sw); err != nil {
+			hlog.CtxErrorf(ctx, "Set killswitch %s: %v", cat, err)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "killswitch.update_failed")})
+			return
+		}
+		hlog.CtxWarnf(ctx, "killswitch audit: category=%s active=%v actor=%q reason=%q", cat, active, actor, req.Reason)
+	}
+	h.killSwitchGate.Invalidate()
+	h.GetToolKillSwitch(ctx, c)
+}
+
+// GetToolKillSwitch 返回各工具类别的熔断状态与最近的变更记录
+// GET /api/admin/killswitch
+func (h *Handler) GetToolKillSwitch(ctx context.Context, c *app.RequestContext) {
+	if h.killSwitchStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "killswitch.disabled")})
+		return
+	}
+	switches, err := h.killSwitchStore.List(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List killswitches: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "killswitch.get_failed")})
+		return
+	}
+	history, err := h.killSwitchStore.History(ctx, 0)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List killswitch history: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "killswitch.get_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"switches":          mergeBuiltinCategories(switches),
+		"active_categories": activeCategories(switches),
+		"history":           history,
+	})
+}
+
+// mergeBuiltinCategories 补齐未设置过的内置类别（未熔断），按类别名排序
+func mergeBuiltinCategories(switches []*killswitch.Switch) []*killswitch.Switch {
+	out := append([]*killswitch.Switch(nil), switches...)
+	known := make(map[string]bool, len(switches))
+	for _, sw := range switches {
+		known[sw.Category] = true
+	}
+	for _, cat := range killswitch.BuiltinCategories {
+		if !known[cat] {
+			out = append(out, &killswitch.Switch{Category: cat})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Category < out[j].Category })
+	return out
+}
+
+func activeCategories(switches []*killswitch.Switch) []string {
+	out := []string{}
+	for _, sw := range switches {
+		if sw.Active {
+			out = append(out, sw.Category)
+		}
+	}
+	return out
+}
+
+// killSwitchSummary 可观测性汇总中的工具熔断部分：熔断中的类别
+func (h *Handler) killSwitchSummary(ctx context.Context) map[string]interface{} {
+	out := map[string]interface{}{"active_categories": []string{}}
+	if h.killSwitchStore == nil {
+		return out
+	}
+	switches, err := h.killSwitchStore.List(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List killswitches: %v", err)
+		return out
+	}
+	out["active_categories"] = activeCategories(switches)
+	var active []*killswitch.Switch
+	for _, sw := range switches {
+		if sw.Active {
+			active = append(active, sw)
+		}
+	}
+	if len(active) > 0 {
+		out["switches"] = active
+		reasons := make([]string, 0, len(active))
+		for _, sw := range active {
+			if sw.Reason != "" {
+				reasons = append(reasons, sw.Category+": "+sw.Reason)
+			}
+		}
+		if len(reasons) > 0 {
+			out["reason"] = strings.Join(reasons, "; ")
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/killswitch"
+)
+
+func TestToolKillSwitchEndpoints(t *testing.T) {
+	store := killswitch.NewStoreMem()
+	gate := killswitch.NewGate(store, time.Hour)
+	handler := NewHandler(nil, nil)
+	handler.SetKillSwitch(store, gate)
+	s := server.Default(server.WithHostPorts(":0"))
+	jsonHeader := ut.Header{Key: "Content-Type", Value: "application/json"}
+	s.POST("/api/admin/killswitch", handler.SetToolKillSwitch)
+	s.GET("/api/admin/killswitch", handler.GetToolKillSwitch)
+
+	body := `{"categories":["Payments","payments","code-exec"],"reason":"incident-42"}`
+	w := ut.PerformRequest(s.Engine, "POST", "/api/admin/killswitch",
+		&ut.Body{Body: strings.NewReader(body), Len: len(body)}, jsonHeader)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var resp struct {
+		Switches         []killswitch.Switch     `json:"switches"`
+		ActiveCategories []string                `json:"active_categories"`
+		History          []killswitch.AuditEntry `json:"history"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ActiveCategories) != 2 || len(resp.History) != 2 || len(resp.Switches) != len(killswitch.BuiltinCategories) {
+		t.Fatalf("unexpected response: %s", w.Result().Body())
+	}
+	// POST 后 gate 立即失效缓存，执行侧无需等待 ttl
+	if category, reason, blocked := gate.BlockedCategory(t.Context(), []string{"payments"}); !blocked || category != "payments" || reason != "incident-42" {
+		t.Fatalf("gate = %q %q %v", category, reason, blocked)
+	}
+
+	body = `{"categories":[" "]}`
+	w = ut.PerformRequest(s.Engine, "POST", "/api/admin/killswitch",
+		&ut.Body{Body: strings.NewReader(body), Len: len(body)}, jsonHeader)
+	if got := w.Result().StatusCode(); got != 400 {
+		t.Fatalf("empty categories status = %d, want 400", got)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// CreateMaintenanceWindowRequest 创建维护窗口请求；starts_at 为空表示立即开始
+type CreateMaintenanceWindowRequest struct {
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Reason      string    `json:"reason"`
+	ParkRunning bool      `json:"park_running"`
+}
+
+// requestTenantID 当前请求租户；空为 "default"
+func requestTenantID(ctx context.Context) string {
+	if tid := auth.GetTenantID(ctx); tid != "" {
+		return tid
+	}
+	return "default"
+}
+
+// CreateMaintenanceWindow 为当前租户创建维护窗口
+// POST /api/maintenance/windows
+func (h *Handler) CreateMaintenanceWindow(ctx context.Context, c *app.RequestContext) {
+	if h.maintenanceStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "maintenance.disabled")})
+		return
+	}
+	var req CreateMaintenanceWindowRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.ends_at_required")})
+		return
+	}
+	if req.StartsAt.IsZero() {
+		req.StartsAt = time.Now()
+	}
+	if req.EndsAt.IsZero() || !req.EndsAt.After(req.StartsAt) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "maintenance.ends_before_starts")})
+		return
+	}
+	w := &job.MaintenanceWindow{
+		TenantID:    requestTenantID(ctx),
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		Reason:      req.Reason,
+		ParkRunning: req.ParkRunning,
+		CreatedAt:   time.Now(),
+	}
+	id, err := h.maintenanceStore.Create(ctx, w)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "创建维护窗口 failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "maintenance.create_failed")})
+		return
+	}
+	w.ID = id
+	h.maintenanceGate.Invalidate()
+	c.JSON(consts.StatusCreated, w)
+}
+
+// ListMaintenanceWindows 列出当前租户的维护窗口（含是否生效）
+// GET /api/maintenance/windows
+func (h *Handler) ListMaintenanceWindows(ctx context.Context, c *app.RequestContext) {
+	if h.maintenanceStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "maintenance.disabled")})
+		return
+	}
+	windows, err := h.maintenanceStore.List(ctx, requestTenantID(ctx))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List maintenance windows: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "maintenance.get_failed")})
+		return
+	}
+	now := time.Now()
+	items := make([]map[string]interface{}, 0, len(windows))
+	for _, w := range windows {
+		items = append(items, map[string]interface{}{
+			"id":           w.ID,
+			"tenant_id":    w.TenantID,
+			"starts_at":    w.StartsAt,
+			"ends_at":      w.EndsAt,
+			"reason":       w.Reason,
+			"park_running": w.ParkRunning,
+			"created_at":   w.CreatedAt,
+			"active":       w.ActiveAt(now),
+		})
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"windows": items})
+}
+
+// DeleteMaintenanceWindow 删除（取消或提前结束）当前租户的维护窗口；Deferred Job 由控制器在下一轮恢复
+// DELETE /api/maintenance/windows/:id
+func (h *Handler) DeleteMaintenanceWindow(ctx context.Context, c *app.RequestContext) {
+	if h.maintenanceStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "maintenance.disabled")})
+		return
+	}
+	id := c.Param("id")
+	if err := h.maintenanceStore.Delete(ctx, requestTenantID(ctx), id); err != nil {
+		if errors.Is(err, job.ErrMaintenanceWindowNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "maintenance.not_found")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Delete maintenance window: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "maintenance.delete_failed")})
+		return
+	}
+	h.maintenanceGate.Invalidate()
+	c.JSON(consts.StatusOK, map[string]string{"id": id, "status": "deleted"})
+}
+
+// maintenanceSummary 可观测性汇总中的维护窗口部分：生效中的窗口与 Deferred Job 数
+func (h *Handler) maintenanceSummary(ctx context.Context) map[string]interface{} {
+	out := map[string]interface{}{
+		"active_windows": []*job.MaintenanceWindow{},
+		"deferred_jobs":  int64(0),
+	}
+	if h.maintenanceStore != nil {
+		if active, err := h.maintenanceStore.ListActive(ctx, time.Now()); err != nil {
+			hlog.CtxErrorf(ctx, "ListActive maintenance windows: %v", err)
+		} else if len(active) > 0 {
+			out["active_windows"] = active
+		}
+	}
+	if h.observabilityReader != nil {
+		if counts, err := h.observabilityReader.CountByStatus(ctx); err == nil {
+			out["deferred_jobs"] = counts[job.StatusDeferred.String()]
+		}
+	}
+	return out
+}
@@ -0,0 +1,302 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/metrics"
+	"rag-platform/pkg/utils"
+)
+
+// 访问日志与慢请求默认值
+const (
+	DefaultSlowPercentile = 0.99
+	DefaultSlowFloor      = 500 * time.Millisecond
+	DefaultSlowWindow     = 1000
+	DefaultSlowBuffer     = 200
+	// slowRecomputeEvery 每条路由每写入多少个样本重新计算一次分位阈值
+	slowRecomputeEvery = 50
+	// unmatchedRoute 未命中任何路由（404）的请求统一归入该路由，避免以原始路径为 label
+	unmatchedRoute = "unmatched"
+)
+
+// AccessLogConfig 访问日志与慢请求捕获配置
+type AccessLogConfig struct {
+	// SampleRate 成功请求（非 5xx、非慢请求）的日志采样率 (0,1]；<=0 或 >1 时为 1（全部记录）
+	SampleRate float64
+	// SlowThreshold 固定慢请求阈值；为 0 时按路由滚动分位数（SlowPercentile）自动判定
+	SlowThreshold time.Duration
+	// SlowPercentile 自动阈值分位数，默认 0.99
+	SlowPercentile float64
+	// SlowFloor 自动阈值下限：低于此耗时不算慢请求（避免快速路由的 p99 抖动），默认 500ms
+	SlowFloor time.Duration
+	// SlowWindow 每条路由保留的最近耗时样本数，默认 1000；样本不足 1/10 时不自动判定
+	SlowWindow int
+	// SlowBuffer 内存中保留的最近慢请求条数，默认 200
+	SlowBuffer int
+}
+
+// PhaseTiming 慢请求中 handler 内一段的耗时（相对请求开始）
+type PhaseTiming struct {
+	Name       string  `json:"name"`
+	StartMs    float64 `json:"start_ms"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// SlowRequest 一条慢请求记录
+type SlowRequest struct {
+	RequestID     string        `json:"request_id"`
+	Method        string        `json:"method"`
+	Route         string        `json:"route"`
+	Path          string        `json:"path"`
+	Status        int           `json:"status"`
+	TenantID      string        `json:"tenant_id,omitempty"`
+	LatencyMs     float64       `json:"latency_ms"`
+	ThresholdMs   float64       `json:"threshold_ms"`
+	RequestBytes  int           `json:"request_bytes"`
+	ResponseBytes int           `json:"response_bytes"`
+	At            time.Time     `json:"at"`
+	Phases        []PhaseTiming `json:"phases,omitempty"`
+}
+
+// accessRecord 单次请求的可变记录：下游中间件写入租户，handler 经 StartPhase 写入分段耗时
+type accessRecord struct {
+	start  time.Time
+	mu     sync.Mutex
+	tenant string
+	phases []PhaseTiming
+}
+
+type accessRecordKey struct{}
+
+func accessRecordFrom(ctx context.Context) *accessRecord {
+	r, _ := ctx.Value(accessRecordKey{}).(*accessRecord)
+	return r
+}
+
+// setAccessTenant 由 InjectAuthContext 调用：认证在全局访问日志之后才解析租户
+func setAccessTenant(ctx context.Context, tenantID string) {
+	if r := accessRecordFrom(ctx); r != nil {
+		r.mu.Lock()
+		r.tenant = tenantID
+		r.mu.Unlock()
+	}
+}
+
+// StartPhase 记录 handler 内一段耗时，返回结束函数（defer 调用）；请求被判定为慢请求时随记录给出分段明细。
+// 不在 AccessLogger 下时为空操作
+func StartPhase(ctx context.Context, name string) func() {
+	r := accessRecordFrom(ctx)
+	if r == nil {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() {
+		end := time.Now()
+		r.mu.Lock()
+		r.phases = append(r.phases, PhaseTiming{
+			Name:       name,
+			StartMs:    durationMs(begin.Sub(r.start)),
+			DurationMs: durationMs(end.Sub(begin)),
+		})
+		r.mu.Unlock()
+	}
+}
+
+// routeWindow 单条路由最近耗时样本与缓存的分位阈值
+type routeWindow struct {
+	samples   []time.Duration
+	next      int
+	sinceCalc int
+	threshold time.Duration
+}
+
+// AccessLogger 结构化访问日志（采样）+ 慢请求捕获；替代 Middleware.AccessLog
+type AccessLogger struct {
+	cfg AccessLogConfig
+
+	mu      sync.Mutex
+	windows map[string]*routeWindow
+	slow    []SlowRequest // 环形缓冲
+	slowPos int
+	slowLen int
+}
+
+// NewAccessLogger 创建访问日志器；cfg 零值为全部记录、按路由 p99（下限 500ms）自动判定慢请求
+func NewAccessLogger(cfg AccessLogConfig) *AccessLogger {
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.SlowPercentile <= 0 || cfg.SlowPercentile >= 1 {
+		cfg.SlowPercentile = DefaultSlowPercentile
+	}
+	if cfg.SlowFloor <= 0 {
+		cfg.SlowFloor = DefaultSlowFloor
+	}
+	if cfg.SlowWindow <= 0 {
+		cfg.SlowWindow = DefaultSlowWindow
+	}
+	if cfg.SlowBuffer <= 0 {
+		cfg.SlowBuffer = DefaultSlowBuffer
+	}
+	return &AccessLogger{cfg: cfg, windows: make(map[string]*routeWindow), slow: make([]SlowRequest, cfg.SlowBuffer)}
+}
+
+// Handler 访问日志中间件：记录 method、route、status、latency、tenant、请求/响应体大小；5xx 与慢请求总是记录，其余按采样率
+func (l *AccessLogger) Handler() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		rec := &accessRecord{start: time.Now()}
+		c.Next(context.WithValue(ctx, accessRecordKey{}, rec))
+		latency := time.Since(rec.start)
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := string(c.Method())
+		status := c.Response.StatusCode()
+		reqBytes := len(c.Request.Body())
+		respBytes := len(c.Response.Body())
+		rec.mu.Lock()
+		tenant := rec.tenant
+		phases := slices.Clone(rec.phases)
+		rec.mu.Unlock()
+
+		metrics.APIRequestDurationSeconds.WithLabelValues(method, route).Observe(latency.Seconds())
+		threshold, slow := l.observe(method+" "+route, latency)
+		if slow {
+			metrics.APISlowRequestsTotal.WithLabelValues(method, route).Inc()
+			l.addSlow(SlowRequest{
+				RequestID:     auth.GetRequestID(ctx),
+				Method:        method,
+				Route:         route,
+				Path:          string(c.Path()),
+				Status:        status,
+				TenantID:      tenant,
+				LatencyMs:     durationMs(latency),
+				ThresholdMs:   durationMs(threshold),
+				RequestBytes:  reqBytes,
+				ResponseBytes: respBytes,
+				At:            rec.start.UTC(),
+				Phases:        phases,
+			})
+			hlog.CtxWarnf(ctx, "slow_request method=%s route=%s path=%s status=%d latency_ms=%.1f threshold_ms=%.1f tenant=%s req_bytes=%d resp_bytes=%d request_id=%s phases=%s",
+				method, route, c.Path(), status, durationMs(latency), durationMs(threshold), tenant, reqBytes, respBytes, auth.GetRequestID(ctx), formatPhases(phases))
+			return
+		}
+		if status < 500 && l.cfg.SampleRate < 1 && rand.Float64() >= l.cfg.SampleRate {
+			return
+		}
+		hlog.CtxInfof(ctx, "access method=%s route=%s path=%s status=%d latency_ms=%.1f tenant=%s req_bytes=%d resp_bytes=%d client_ip=%s request_id=%s",
+			method, route, c.Path(), status, durationMs(latency), tenant, reqBytes, respBytes, c.ClientIP(), auth.GetRequestID(ctx))
+	}
+}
+
+// observe 记录样本并判定是否慢请求；返回判定所用阈值
+func (l *AccessLogger) observe(key string, latency time.Duration) (time.Duration, bool) {
+	if l.cfg.SlowThreshold > 0 {
+		return l.cfg.SlowThreshold, latency > l.cfg.SlowThreshold
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w := l.windows[key]
+	if w == nil {
+		w = &routeWindow{samples: make([]time.Duration, 0, l.cfg.SlowWindow)}
+		l.windows[key] = w
+	}
+	// 先按已有样本判定，避免本次耗时抬高自身阈值
+	threshold := max(w.threshold, l.cfg.SlowFloor)
+	slow := w.threshold > 0 && latency > threshold
+	if len(w.samples) < l.cfg.SlowWindow {
+		w.samples = append(w.samples, latency)
+	} else {
+		w.samples[w.next] = latency
+		w.next = (w.next + 1) % l.cfg.SlowWindow
+	}
+	w.sinceCalc++
+	if len(w.samples) >= max(l.cfg.SlowWindow/10, 1) && (w.threshold == 0 || w.sinceCalc >= slowRecomputeEvery) {
+		sorted := slices.Clone(w.samples)
+		slices.Sort(sorted)
+		w.threshold = utils.Percentile(sorted, l.cfg.SlowPercentile)
+		w.sinceCalc = 0
+	}
+	return threshold, slow
+}
+
+func (l *AccessLogger) addSlow(r SlowRequest) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.slow[l.slowPos] = r
+	l.slowPos = (l.slowPos + 1) % len(l.slow)
+	if l.slowLen < len(l.slow) {
+		l.slowLen++
+	}
+}
+
+// RecentSlow 返回最近的慢请求（新到旧）；route 非空时只返回该路由（不含 method），limit<=0 为全部
+func (l *AccessLogger) RecentSlow(route string, limit int) []SlowRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]SlowRequest, 0, l.slowLen)
+	for i := 1; i <= l.slowLen; i++ {
+		r := l.slow[(l.slowPos-i+len(l.slow))%len(l.slow)]
+		if route != "" && r.Route != route {
+			continue
+		}
+		out = append(out, r)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+// Thresholds 返回各路由（"METHOD route"）当前的慢请求阈值（毫秒）；固定阈值时为空
+func (l *AccessLogger) Thresholds() map[string]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]float64, len(l.windows))
+	for key, w := range l.windows {
+		if w.threshold > 0 {
+			out[key] = durationMs(max(w.threshold, l.cfg.SlowFloor))
+		}
+	}
+	return out
+}
+
+func formatPhases(phases []PhaseTiming) string {
+	if len(phases) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(phases))
+	for _, p := range phases {
+		parts = append(parts, p.Name+":"+time.Duration(p.DurationMs*float64(time.Millisecond)).Round(time.Microsecond).String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -81,6 +81,9 @@ func (a *AuditMiddleware) AuditAccess() app.HandlerFunc {
 
 // determineAction 根据 HTTP 方法和路径确定操作类型
 func determineAction(method string, path string) string {
+	if strings.Contains(path, "/admin/killswitch") && method == "POST" {
+		return "set_killswitch"
+	}
 	if strings.Contains(path, "/export") {
 		return "export_evidence"
 	}
@@ -21,6 +21,7 @@ import (
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 
 	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
 )
 
 // AuthZMiddleware 授权中间件（2.0-M2 RBAC）
@@ -41,20 +42,26 @@ func (a *AuthZMiddleware) RequirePermission(permission auth.Permission) app.Hand
 
 		if userID == "" || tenantID == "" {
 			c.JSON(consts.StatusUnauthorized, map[string]string{
-				"error": "authentication required",
+				"error": i18n.T(ctx, "auth.required"),
 			})
 			c.Abort()
 			return
 		}
 
+		endPhase := StartPhase(ctx, "authz")
 		allowed, err := a.rbac.CheckPermission(ctx, tenantID, userID, permission, "")
+		endPhase()
 		if err != nil || !allowed {
 			c.JSON(consts.StatusForbidden, map[string]string{
-				"error": "permission denied",
+				"error": i18n.T(ctx, "auth.permission_denied"),
 			})
 			c.Abort()
 			return
 		}
+		// 角色注入 ctx，供文档 ACL 检索过滤等下游按角色授权
+		if role, err := a.rbac.GetUserRole(ctx, tenantID, userID); err == nil && role != "" {
+			ctx = auth.WithRole(ctx, role)
+		}
 
 		c.Next(ctx)
 	}
@@ -67,7 +74,7 @@ func (a *AuthZMiddleware) TenantIsolation() app.HandlerFunc {
 
 		if tenantID == "" {
 			c.JSON(consts.StatusUnauthorized, map[string]string{
-				"error": "tenant context required",
+				"error": i18n.T(ctx, "auth.tenant_required"),
 			})
 			c.Abort()
 			return
@@ -16,6 +16,7 @@ package middleware
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,9 +25,15 @@ import (
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/hertz-contrib/jwt"
 
+	"rag-platform/internal/runtime/jobstore"
 	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/idgen"
 )
 
+// maxRequestIDLen 客户端传入的 X-Request-ID 最大长度，超出时改为服务端生成
+const maxRequestIDLen = 128
+
 // Middleware 中间件管理器
 type Middleware struct{}
 
@@ -40,8 +47,8 @@ func (m *Middleware) CORS() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID, X-Aetheris-Client")
+		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Max-Age", "86400")
 
@@ -54,6 +61,31 @@ func (m *Middleware) CORS() app.HandlerFunc {
 	}
 }
 
+// Locale 语言协商中间件：?lang= 优先，其次 Accept-Language；写入 context 供 i18n.T 翻译错误信息，并设置 Content-Language
+func (m *Middleware) Locale() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		locale := i18n.Match(c.Query("lang"))
+		if locale == "" {
+			locale = i18n.Negotiate(string(c.GetHeader("Accept-Language")))
+		}
+		c.Header("Content-Language", locale)
+		c.Next(i18n.WithLocale(ctx, locale))
+	}
+}
+
+// RequestID 请求 ID 中间件：沿用客户端的 X-Request-ID（如网关生成），否则生成新 ID；写入 context 与响应头，
+// 创建 Job 时作为发起归属的 request_id 持久化
+func (m *Middleware) RequestID() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		rid := strings.TrimSpace(string(c.GetHeader("X-Request-ID")))
+		if rid == "" || len(rid) > maxRequestIDLen {
+			rid = "req-" + idgen.NewID()
+		}
+		c.Header("X-Request-ID", rid)
+		c.Next(auth.WithRequestID(ctx, rid))
+	}
+}
+
 // Auth 认证中间件（未启用 JWT 时跳过认证）
 func (m *Middleware) Auth() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
@@ -179,6 +211,11 @@ func (m *Middleware) InjectAuthContext() app.HandlerFunc {
 		} else {
 			ctx = auth.WithUserID(ctx, "anonymous")
 		}
+
+		setAccessTenant(ctx, auth.GetTenantID(ctx))
+
+		// 客户端类型：X-Aetheris-Client（CLI / UI / SDK 设置），未声明时为 api
+		ctx = auth.WithClient(ctx, jobstore.NormalizeClient(string(c.GetHeader("X-Aetheris-Client"))))
 		c.Next(ctx)
 	}
 }
@@ -201,7 +238,7 @@ func (m *Middleware) RateLimit(rps int) app.HandlerFunc {
 			if count > rps {
 				mu.Unlock()
 				c.JSON(consts.StatusTooManyRequests, map[string]string{
-					"error": "请求过于频繁，请稍后再试",
+					"error": i18n.T(ctx, "request.rate_limited"),
 				})
 				c.Abort()
 				return
@@ -212,13 +249,39 @@ func (m *Middleware) RateLimit(rps int) app.HandlerFunc {
 	}
 }
 
-// AccessLog 访问日志中间件（使用 hlog）
+// AccessLog 访问日志中间件（使用 hlog）；未配置 AccessLogger 时使用，不做采样与慢请求捕获
 func (m *Middleware) AccessLog() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
 		start := time.Now()
 		c.Next(ctx)
 		latency := time.Since(start)
-		hlog.CtxInfof(ctx, "%s %s %s %d %s",
-			c.Method(), c.Path(), c.ClientIP(), c.Response.StatusCode(), latency)
+		hlog.CtxInfof(ctx, "%s %s %s %d %s request_id=%s",
+			c.Method(), c.Path(), c.ClientIP(), c.Response.StatusCode(), latency, auth.GetRequestID(ctx))
+	}
+}
+
+// statusMisdirectedRequest 421：请求发往了不持有该租户数据的区域（Hertz consts 未定义）
+const statusMisdirectedRequest = 421
+
+// TenantHomeResolver 解析租户数据所在区域（数据驻留）
+type TenantHomeResolver interface {
+	Home(tenantID string) (region, apiURL string, local bool)
+}
+
+// Residency 数据驻留：租户数据不在本部署区域时返回 421 与其区域 API 地址，避免该租户的 Job 与事件写入错误区域的 Postgres
+func (m *Middleware) Residency(resolver TenantHomeResolver) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		tenantID := auth.GetTenantID(ctx)
+		region, apiURL, local := resolver.Home(tenantID)
+		if !local {
+			c.JSON(statusMisdirectedRequest, map[string]string{
+				"error":   i18n.T(ctx, "residency.misdirected", tenantID, region),
+				"region":  region,
+				"api_url": apiURL,
+			})
+			c.Abort()
+			return
+		}
+		c.Next(ctx)
 	}
 }
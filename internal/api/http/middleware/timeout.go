@@ -0,0 +1,92 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/metrics"
+)
+
+// RequestTimeoutConfig 请求截止时间配置
+type RequestTimeoutConfig struct {
+	// Default 全局请求截止时间；<=0 时不设截止时间
+	Default time.Duration
+	// Routes 按路由模板（如 /api/jobs/:id/export）覆盖 Default；值 <=0 为该路由不设截止时间
+	Routes map[string]time.Duration
+}
+
+// RequestTimeout 为每个请求的 ctx 设置截止时间：handler 内经 ctx 调用的 store / LLM 到期即返回，不再无限占用 Hertz 协程
+type RequestTimeout struct {
+	def    time.Duration
+	routes map[string]time.Duration
+}
+
+// NewRequestTimeout 创建请求截止时间中间件；路由键不区分大小写（viper 加载的 map 键为小写）
+func NewRequestTimeout(cfg RequestTimeoutConfig) *RequestTimeout {
+	routes := make(map[string]time.Duration, len(cfg.Routes))
+	for route, d := range cfg.Routes {
+		routes[strings.ToLower(route)] = d
+	}
+	return &RequestTimeout{def: cfg.Default, routes: routes}
+}
+
+// For 返回路由模板 route 的截止时长；<=0 表示不设截止时间
+func (t *RequestTimeout) For(route string) time.Duration {
+	if d, ok := t.routes[strings.ToLower(route)]; ok {
+		return d
+	}
+	return t.def
+}
+
+// Handler 请求截止时间中间件；须在 Locale 之后注册。到期后 handler 返回 5xx（多为下游返回的 context deadline exceeded）时改写为 504，
+// 已写出的成功响应与流式响应保持不变
+func (t *RequestTimeout) Handler() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		route := c.FullPath()
+		d := t.For(route)
+		if d <= 0 || route == "" {
+			c.Next(ctx)
+			return
+		}
+		tctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		c.Next(tctx)
+		if !errors.Is(tctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		method := string(c.Method())
+		metrics.APIRequestTimeoutsTotal.WithLabelValues(method, route).Inc()
+		hlog.CtxWarnf(ctx, "request_timeout method=%s route=%s timeout=%s status=%d request_id=%s",
+			method, route, d, c.Response.StatusCode(), auth.GetRequestID(ctx))
+		if c.Response.StatusCode() < 500 || c.Response.IsBodyStream() {
+			return
+		}
+		c.Response.ResetBody()
+		c.JSON(consts.StatusGatewayTimeout, map[string]string{
+			"error":   i18n.T(ctx, "request.timeout", d),
+			"timeout": d.String(),
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/runtime/piitag"
+	"rag-platform/pkg/i18n"
+)
+
+// GetJobPII 返回该 Job 的 PII 检测汇总（类别计数、命中事件类型与字段路径；不含原始值）
+// GET /api/jobs/:id/pii
+func (h *Handler) GetJobPII(ctx context.Context, c *app.RequestContext) {
+	if h.piiTags == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "pii.disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	tags, err := h.piiTags.ListByJob(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListByJob pii tags: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "pii.get_tags_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, piitag.Summarize(jobID, tags))
+}
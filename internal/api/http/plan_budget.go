@@ -0,0 +1,165 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// 计划超出预算时的处理方式（agent.plan_cost.on_exceed）
+const (
+	// PlanBudgetReject 拒绝：Job 置为 failed（failure_class=plan_over_budget）并返回 422
+	PlanBudgetReject = "reject"
+	// PlanBudgetApproval 挂起审批：在计划入口插入 approval 节点，经 POST /api/jobs/:id/signal 批准后才执行
+	PlanBudgetApproval = "approval"
+)
+
+// planBudgetApprovalNodeID 预算审批节点 ID
+const planBudgetApprovalNodeID = "plan_budget_approval"
+
+// JobBudgetRequest 消息请求中的 Job 级预算；只能收紧租户/全局预算
+type JobBudgetRequest struct {
+	MaxCost float64 `json:"max_cost"`
+	MaxETA  string  `json:"max_eta"` // 如 "2m"
+}
+
+// PlanBudget 解析为计划预算；nil 为不限制
+func (r *JobBudgetRequest) PlanBudget() (planner.PlanBudget, error) {
+	if r == nil {
+		return planner.PlanBudget{}, nil
+	}
+	if r.MaxCost < 0 {
+		return planner.PlanBudget{}, errors.New("budget.max_cost must be >= 0")
+	}
+	b := planner.PlanBudget{MaxCost: r.MaxCost}
+	if r.MaxETA != "" {
+		d, err := time.ParseDuration(r.MaxETA)
+		if err != nil || d < 0 {
+			return planner.PlanBudget{}, errors.New("budget.max_eta must be a non-negative duration")
+		}
+		b.MaxETA = d
+	}
+	return b, nil
+}
+
+// checkPlanBudget 计划预算护栏：planErr 为 Planner 自身的预算错误时取其附带的计划，否则按租户/Job 预算校验 taskGraph。
+// 返回可继续使用的计划、超出时的结构化错误，以及与预算无关的规划错误
+func (h *Handler) checkPlanBudget(taskGraph *planner.TaskGraph, planErr error, budget planner.PlanBudget) (*planner.TaskGraph, *planner.PlanBudgetError, error) {
+	var budgetErr *planner.PlanBudgetError
+	if errors.As(planErr, &budgetErr) {
+		return budgetErr.Graph, budgetErr, nil
+	}
+	if planErr != nil {
+		return nil, nil, planErr
+	}
+	if taskGraph == nil || budget.IsZero() {
+		return taskGraph, nil, nil
+	}
+	if err := planner.ValidatePlanBudget(planner.EstimateTaskGraph(taskGraph, h.planCostModel), budget); errors.As(err, &budgetErr) {
+		return taskGraph, budgetErr, nil
+	}
+	return taskGraph, nil, nil
+}
+
+// planBudgetErrorBody 422 响应体：error 保持原文本，另附 code、超出维度、预估与预算
+func planBudgetErrorBody(err *planner.PlanBudgetError) map[string]interface{} {
+	return map[string]interface{}{
+		"error":    err.Error(),
+		"code":     planner.ErrPlanOverBudget.Error(),
+		"exceeded": err.Exceeded,
+		"estimate": err.Estimate,
+		"budget":   err.Budget,
+	}
+}
+
+// PlanBudgetApprovalKey 预算审批节点的 correlation_key；POST /api/jobs/:id/signal 以此批准超出预算的计划
+func PlanBudgetApprovalKey(jobID string) string {
+	return "plan-budget-" + jobID
+}
+
+// gatePlanWithApproval 在计划入口插入 approval 节点：所有无入边的节点改为依赖该节点，批准前不执行任何步骤
+func gatePlanWithApproval(g *planner.TaskGraph, correlationKey, reason string) *planner.TaskGraph {
+	nodeID := planBudgetApprovalNodeID
+	for exists := true; exists; {
+		exists = false
+		for _, n := range g.Nodes {
+			if n.ID == nodeID {
+				nodeID += "_"
+				exists = true
+				break
+			}
+		}
+	}
+	hasIncoming := make(map[string]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		hasIncoming[e.To] = true
+	}
+	out := &planner.TaskGraph{
+		Nodes: make([]planner.TaskNode, 0, len(g.Nodes)+1),
+		Edges: make([]planner.TaskEdge, 0, len(g.Edges)+len(g.Nodes)),
+	}
+	out.Nodes = append(out.Nodes, planner.TaskNode{
+		ID:   nodeID,
+		Type: planner.NodeApproval,
+		Config: map[string]any{
+			"correlation_key": correlationKey,
+			"reason":          reason,
+		},
+	})
+	out.Nodes = append(out.Nodes, g.Nodes...)
+	for _, n := range g.Nodes {
+		if !hasIncoming[n.ID] {
+			out.Edges = append(out.Edges, planner.TaskEdge{From: nodeID, To: n.ID})
+		}
+	}
+	out.Edges = append(out.Edges, g.Edges...)
+	return out
+}
+
+// rejectOverBudgetJob 拒绝超出预算的 Job：写入 job_failed、置为 failed 并持久化终态元数据，响应 422；
+// 先追加事件再更新 status
+func (h *Handler) rejectOverBudgetJob(ctx context.Context, c *app.RequestContext, jobID string, ver int, budgetErr *planner.PlanBudgetError) {
+	info := &job.TerminalInfo{Reason: budgetErr.Error(), Actor: "planner", FailureClass: job.FailureClassPlanOverBudget}
+	if h.jobEventStore != nil {
+		fields := info.EventFields()
+		fields["estimate"] = budgetErr.Estimate
+		fields["budget"] = budgetErr.Budget
+		if payload, err := marshalJSON(ctx, fields, "job_failed_payload"); err == nil {
+			if _, err := h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobFailed, Payload: payload}); err != nil {
+				hlog.CtxErrorf(ctx, "追加 job_failed 事件failed: %v", err)
+			}
+		}
+	}
+	if err := h.jobStore.UpdateStatus(job.WithTransitionReason(ctx, "plan_over_budget"), jobID, job.StatusFailed); err != nil {
+		hlog.CtxErrorf(ctx, "超出预算的 Job 置为 failed 失败: %v", err)
+	}
+	if err := job.RecordTerminalInfo(ctx, h.jobStore, jobID, info); err != nil {
+		hlog.CtxErrorf(ctx, "RecordTerminalInfo failed: %v", err)
+	}
+	body := planBudgetErrorBody(budgetErr)
+	body["job_id"] = jobID
+	body["status"] = job.StatusFailed.String()
+	c.JSON(consts.StatusUnprocessableEntity, body)
+}
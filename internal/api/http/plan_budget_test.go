@@ -0,0 +1,92 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/planner"
+)
+
+func budgetTestGraph() *planner.TaskGraph {
+	return &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "a", Type: planner.NodeTool, ToolName: "crawl"},
+			{ID: "b", Type: planner.NodeTool, ToolName: "crawl"},
+			{ID: "c", Type: planner.NodeLLM},
+		},
+		Edges: []planner.TaskEdge{{From: "a", To: "c"}, {From: "b", To: "c"}},
+	}
+}
+
+func TestPreviewAgentPlan_TenantBudget(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	handler.SetPlanAtJobCreation(func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+		return budgetTestGraph(), nil
+	})
+	handler.SetPlanCostModel(planner.CostModel{Tools: map[string]planner.ToolCostHint{"crawl": {ExpectedLatency: time.Second, CostPerCall: 0.05}}})
+	handler.SetPlanBudgetPolicy(planner.BudgetPolicy{Tenants: map[string]planner.PlanBudget{"default": {MaxCost: 1}}}, PlanBudgetReject)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/agents/:id/plan/preview", handler.PreviewAgentPlan)
+	post := func(body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, "POST", "/api/agents/a1/plan/preview", &ut.Body{Body: strings.NewReader(body), Len: len(body)}, ut.Header{Key: "Content-Type", Value: "application/json"})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+
+	if code, out := post(`{"message":"crawl two sites"}`); code != 200 {
+		t.Fatalf("within tenant budget: status %d %v", code, out)
+	}
+	code, out := post(`{"message":"crawl two sites","budget":{"max_cost":0.08}}`)
+	if code != 422 || out["code"] != planner.ErrPlanOverBudget.Error() || out["exceeded"] != "cost" {
+		t.Fatalf("job budget exceeded: status %d %v", code, out)
+	}
+	if budget, _ := out["budget"].(map[string]interface{}); budget["max_cost"] != 0.08 {
+		t.Fatalf("budget = %v", out["budget"])
+	}
+	if est, _ := out["estimate"].(map[string]interface{}); est["total_cost"] != 0.1 {
+		t.Fatalf("estimate = %v", out["estimate"])
+	}
+	if code, _ := post(`{"message":"x","budget":{"max_eta":"soon"}}`); code != 400 {
+		t.Fatalf("invalid budget status = %d, want 400", code)
+	}
+}
+
+func TestGatePlanWithApproval(t *testing.T) {
+	g := gatePlanWithApproval(budgetTestGraph(), PlanBudgetApprovalKey("job-1"), "over budget")
+	if len(g.Nodes) != 4 || g.Nodes[0].Type != planner.NodeApproval || g.Nodes[0].Config["correlation_key"] != "plan-budget-job-1" {
+		t.Fatalf("unexpected gate node: %+v", g.Nodes)
+	}
+	gated := map[string]bool{}
+	for _, e := range g.Edges {
+		if e.From == g.Nodes[0].ID {
+			gated[e.To] = true
+		}
+	}
+	if len(gated) != 2 || !gated["a"] || !gated["b"] {
+		t.Fatalf("approval should gate root nodes only, edges = %+v", g.Edges)
+	}
+	if err := planner.ValidateTaskGraph(g, nil); err != nil {
+		t.Fatalf("gated plan invalid: %v", err)
+	}
+}
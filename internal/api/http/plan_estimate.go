@@ -0,0 +1,107 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// PlanPreviewRequest POST /api/agents/:id/plan/preview 请求体
+type PlanPreviewRequest struct {
+	Message string            `json:"message" binding:"required"`
+	Budget  *JobBudgetRequest `json:"budget"` // 可选；与 POST /api/agents/:id/message 的 budget 相同，按租户/Job 预算校验
+}
+
+// PreviewAgentPlan 仅规划不创建 Job：返回任务图与按工具标注计算的成本/ETA 预估，便于用户在提交前确认预期；
+// 有相似目标的历史运行时附带 history（与过往成功计划的差异、成功率与成本）
+// POST /api/agents/:id/plan/preview
+func (h *Handler) PreviewAgentPlan(ctx context.Context, c *app.RequestContext) {
+	if h.planAtJobCreation == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "planner.not_configured")})
+		return
+	}
+	// 计划预览为可选工作：背压时最先被跳过，避免占用 LLM 配额拖慢真正的 Job
+	if h.backpressureGate.ShouldShed(ctx, "plan_preview") {
+		writeBackpressure(ctx, c, h.backpressureGate.Level(ctx), h.backpressureGate.RetryAfter())
+		return
+	}
+	var req PlanPreviewRequest
+	if err := c.BindJSON(&req); err != nil || req.Message == "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "message.empty")})
+		return
+	}
+	jobBudget, err := req.Budget.PlanBudget()
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "plan_budget.invalid")})
+		return
+	}
+	tenantID := auth.GetTenantID(ctx)
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	id := c.Param("id")
+	taskGraph, err := h.planAtJobCreation(ctx, id, req.Message)
+	taskGraph, budgetErr, err := h.checkPlanBudget(taskGraph, err, h.planBudget.For(tenantID, jobBudget))
+	if budgetErr != nil {
+		body := planBudgetErrorBody(budgetErr)
+		if taskGraph != nil {
+			body["task_graph"] = taskGraph
+		}
+		c.JSON(consts.StatusUnprocessableEntity, body)
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Plan preview failed: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "planner.plan_failed")})
+		return
+	}
+	body := map[string]interface{}{
+		"agent_id":   id,
+		"goal":       req.Message,
+		"task_graph": taskGraph,
+		"estimate":   planner.EstimateTaskGraph(taskGraph, h.planCostModel),
+		"rationale":  taskGraph.Rationales(),
+	}
+	// 相似目标的历史运行：与最相似成功运行的计划差异、成功率与成本
+	if hist := h.planHistoryFor(ctx, id, tenantID, req.Message, taskGraph); hist != nil {
+		body["history"] = hist
+	}
+	c.JSON(consts.StatusOK, body)
+}
+
+// planEstimateFromEvents 返回最近一次 PlanGenerated 的成本/ETA 预估；事件中无预估（旧数据）时按当前成本模型重新计算
+func (h *Handler) planEstimateFromEvents(events []jobstore.JobEvent) *planner.PlanEstimate {
+	payload := lastPlanGenerated(events)
+	if payload == nil {
+		return nil
+	}
+	if payload.Estimate != nil {
+		return payload.Estimate
+	}
+	var g planner.TaskGraph
+	if err := g.Unmarshal(payload.TaskGraph); err != nil {
+		return nil
+	}
+	return planner.EstimateTaskGraph(&g, h.planCostModel)
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"rag-platform/internal/agent/evalsuite"
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+const (
+	// planHistoryScanLimit 计划预览对比时扫描的该 Agent 最近 Job 数
+	planHistoryScanLimit = 200
+	// planHistoryMinSimilarity 目标相似度不低于该值的历史 Job 视为相似运行
+	planHistoryMinSimilarity = 0.5
+	// planHistoryMaxRuns 参与成功率与成本统计的相似运行上限（按相似度降序）
+	planHistoryMaxRuns = 20
+	// planHistoryCacheMaxEntries 已终态 Job 计划缓存的最大条目数
+	planHistoryCacheMaxEntries = 4096
+)
+
+// PlanHistory 计划预览与相似历史运行的对比：Diff 为最相似的成功运行的计划 → 本次计划；
+// 成功率按相似的已终态运行统计，成本/耗时为其中成功运行的平均值
+type PlanHistory struct {
+	BaselineJobID string            `json:"baseline_job_id,omitempty"`
+	BaselineGoal  string            `json:"baseline_goal,omitempty"`
+	Similarity    float64           `json:"similarity,omitempty"`
+	BaselineCost  float64           `json:"baseline_cost"`
+	Diff          *planner.PlanDiff `json:"diff,omitempty"`
+	SimilarRuns   int               `json:"similar_runs"`
+	Succeeded     int               `json:"succeeded"`
+	SuccessRate   float64           `json:"success_rate"`
+	AvgCost       float64           `json:"avg_cost"`
+	AvgDurationMs int64             `json:"avg_duration_ms"`
+}
+
+// priorRun 已终态 Job 的计划与执行结果（终态后不再变化，可缓存）
+type priorRun struct {
+	graph      *planner.TaskGraph
+	cost       float64
+	durationMs int64
+}
+
+// planHistoryCache 已终态 Job 的 priorRun 缓存；零值可用，满时先随机淘汰
+type planHistoryCache struct {
+	mu    sync.Mutex
+	items map[string]*priorRun
+}
+
+func (c *planHistoryCache) get(jobID string) (*priorRun, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.items[jobID]
+	return r, ok
+}
+
+func (c *planHistoryCache) put(jobID string, r *priorRun) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.items == nil {
+		c.items = make(map[string]*priorRun)
+	}
+	for id := range c.items {
+		if len(c.items) < planHistoryCacheMaxEntries {
+			break
+		}
+		delete(c.items, id)
+	}
+	c.items[jobID] = r
+}
+
+// planHistoryFor 在该 Agent 最近的 Job 中按目标相似度查找已终态的相似运行，统计成功率与成本，
+// 并与最相似的成功运行的计划做差异；无相似运行或未配置事件存储时返回 nil
+func (h *Handler) planHistoryFor(ctx context.Context, agentID, tenantID, goal string, g *planner.TaskGraph) *PlanHistory {
+	if h.jobStore == nil || h.jobEventStore == nil {
+		return nil
+	}
+	jobs, err := h.jobStore.ListByAgent(ctx, agentID, tenantID)
+	if err != nil {
+		return nil
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].CreatedAt.After(jobs[b].CreatedAt) })
+	if len(jobs) > planHistoryScanLimit {
+		jobs = jobs[:planHistoryScanLimit]
+	}
+	type similarJob struct {
+		j     *job.Job
+		score float64
+	}
+	var similar []similarJob
+	for _, j := range jobs {
+		if !j.Status.IsTerminal() {
+			continue
+		}
+		if score := planner.GoalSimilarity(goal, j.Goal); score >= planHistoryMinSimilarity {
+			similar = append(similar, similarJob{j: j, score: score})
+		}
+	}
+	if len(similar) == 0 {
+		return nil
+	}
+	sort.SliceStable(similar, func(a, b int) bool { return similar[a].score > similar[b].score })
+	if len(similar) > planHistoryMaxRuns {
+		similar = similar[:planHistoryMaxRuns]
+	}
+	hist := &PlanHistory{SimilarRuns: len(similar)}
+	var durationSum int64
+	for _, s := range similar {
+		if s.j.Status != job.StatusCompleted {
+			continue
+		}
+		run := h.priorRunOf(ctx, s.j)
+		hist.Succeeded++
+		hist.AvgCost += run.cost
+		durationSum += run.durationMs
+		if hist.Diff == nil && run.graph != nil {
+			hist.BaselineJobID, hist.BaselineGoal, hist.Similarity = s.j.ID, s.j.Goal, s.score
+			hist.BaselineCost = run.cost
+			hist.Diff = planner.DiffTaskGraphs(run.graph, g)
+		}
+	}
+	hist.SuccessRate = float64(hist.Succeeded) / float64(hist.SimilarRuns)
+	if hist.Succeeded > 0 {
+		hist.AvgCost /= float64(hist.Succeeded)
+		hist.AvgDurationMs = durationSum / int64(hist.Succeeded)
+	}
+	if hist.BaselineGoal != "" && h.traceMaskFor(ctx).Masks("goal") {
+		hist.BaselineGoal = TraceMaskedValue
+	}
+	return hist
+}
+
+// priorRunOf 读取已终态 Job 的计划与执行结果（带缓存）；事件读取failed时不缓存
+func (h *Handler) priorRunOf(ctx context.Context, j *job.Job) *priorRun {
+	if run, ok := h.planHistory.get(j.ID); ok {
+		return run
+	}
+	events, _, err := h.jobEventStore.ListEvents(ctx, j.ID)
+	if err != nil {
+		return &priorRun{}
+	}
+	o := evalsuite.OutcomeFromEvents(j.ID, j.Status.String(), events, h.planCostModel)
+	run := &priorRun{graph: taskGraphFromEvents(events), cost: o.Cost, durationMs: o.DurationMs}
+	h.planHistory.put(j.ID, run)
+	return run
+}
+
+// lastPlanGenerated 返回最近一次 plan_generated 的 payload；无或无法解析时返回 nil
+func lastPlanGenerated(events []jobstore.JobEvent) *planGeneratedPayload {
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.Type != jobstore.PlanGenerated || len(e.Payload) == 0 {
+			continue
+		}
+		var payload planGeneratedPayload
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			return nil
+		}
+		return &payload
+	}
+	return nil
+}
+
+// planGeneratedPayload plan_generated 事件中计划预览/对比关心的字段
+type planGeneratedPayload struct {
+	TaskGraph json.RawMessage       `json:"task_graph"`
+	Estimate  *planner.PlanEstimate `json:"estimate"`
+}
+
+// taskGraphFromEvents 返回最近一次 plan_generated 的任务图
+func taskGraphFromEvents(events []jobstore.JobEvent) *planner.TaskGraph {
+	payload := lastPlanGenerated(events)
+	if payload == nil {
+		return nil
+	}
+	var g planner.TaskGraph
+	if err := g.Unmarshal(payload.TaskGraph); err != nil {
+		return nil
+	}
+	return &g
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestPreviewAgentPlan_History(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetPlanAtJobCreation(func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+		return budgetTestGraph(), nil
+	})
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/agents/:id/plan/preview", handler.PreviewAgentPlan)
+	preview := func(goal string) map[string]interface{} {
+		body := `{"message":"` + goal + `"}`
+		w := ut.PerformRequest(s.Engine, "POST", "/api/agents/a1/plan/preview", &ut.Body{Body: strings.NewReader(body), Len: len(body)}, ut.Header{Key: "Content-Type", Value: "application/json"})
+		if w.Result().StatusCode() != 200 {
+			t.Fatalf("preview status %d: %s", w.Result().StatusCode(), w.Result().Body())
+		}
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return out
+	}
+
+	if out := preview("crawl two sites"); out["history"] != nil {
+		t.Fatalf("no prior runs, history = %v", out["history"])
+	}
+
+	// 历史运行：成功的 a→c 计划、失败的相似运行、不相关目标
+	prior := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "a", Type: planner.NodeTool, ToolName: "crawl"},
+			{ID: "c", Type: planner.NodeTool, ToolName: "summarize"},
+			{ID: "d", Type: planner.NodeLLM},
+		},
+		Edges: []planner.TaskEdge{{From: "a", To: "c"}, {From: "c", To: "d"}},
+	}
+	graphJSON, _ := prior.Marshal()
+	addRun := func(goal string, status job.JobStatus) string {
+		id, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: goal})
+		payload, _ := json.Marshal(map[string]json.RawMessage{"task_graph": graphJSON})
+		ver, _ := events.Append(ctx, id, 0, jobstore.JobEvent{JobID: id, Type: jobstore.PlanGenerated, Payload: payload})
+		_, _ = events.Append(ctx, id, ver, jobstore.JobEvent{JobID: id, Type: jobstore.JobCompleted, Payload: []byte(`{}`)})
+		_ = jobs.UpdateStatus(ctx, id, job.StatusRunning)
+		_ = jobs.UpdateStatus(ctx, id, status)
+		return id
+	}
+	okID := addRun("crawl two sites", job.StatusCompleted)
+	addRun("crawl two sites now", job.StatusFailed)
+	addRun("translate a document", job.StatusCompleted)
+	if id, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "crawl two sites"}); id == "" {
+		t.Fatal("create running job")
+	}
+
+	hist, _ := preview("crawl two sites")["history"].(map[string]interface{})
+	if hist == nil {
+		t.Fatal("expected history for a similar goal")
+	}
+	if hist["baseline_job_id"] != okID || hist["similarity"].(float64) < 0.99 {
+		t.Fatalf("baseline = %v (%v)", hist["baseline_job_id"], hist["similarity"])
+	}
+	if hist["similar_runs"] != 2.0 || hist["succeeded"] != 1.0 || hist["success_rate"] != 0.5 {
+		t.Fatalf("runs = %v/%v rate %v", hist["succeeded"], hist["similar_runs"], hist["success_rate"])
+	}
+	diff := hist["diff"].(map[string]interface{})
+	if added := diff["added"].([]interface{}); len(added) != 1 || added[0].(map[string]interface{})["id"] != "b" {
+		t.Fatalf("added = %v", diff["added"])
+	}
+	if removed := diff["removed"].([]interface{}); len(removed) != 1 || removed[0].(map[string]interface{})["id"] != "d" {
+		t.Fatalf("removed = %v", diff["removed"])
+	}
+	changed := diff["changed"].([]interface{})
+	if len(changed) != 1 || changed[0].(map[string]interface{})["id"] != "c" || diff["unchanged"] != 1.0 {
+		t.Fatalf("changed = %v unchanged = %v", changed, diff["unchanged"])
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/agentconfig"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// planReviewRequired Agent 是否设置了 require_plan_approval；读取配置失败时按需要审阅处理（宁可多挂起也不绕过审阅门）
+func (h *Handler) planReviewRequired(ctx context.Context, agentID string) bool {
+	required, err := agentconfig.RequirePlanApproval(ctx, h.agentConfig, agentID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取 Agent %s 的 %s failed，按需要审阅处理: %v", agentID, agentconfig.KeyRequirePlanApproval, err)
+		return true
+	}
+	return required
+}
+
+// GetJobPlanReview 查询 Job 当前计划的审阅状态与待审阅的 TaskGraph（GET /api/jobs/:id/plan/review）
+func (h *Handler) GetJobPlanReview(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	review := jobstore.PlanReviewOf(events)
+	if review == nil {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "plan_review.not_required")})
+		return
+	}
+	resp := map[string]interface{}{
+		"job_id":     jobID,
+		"job_status": j.Status.String(),
+		"status":     review.Status,
+		"plan_hash":  review.PlanHash,
+		"reviews":    review.Reviews,
+	}
+	if plan := lastPlanGenerated(events); plan != nil {
+		resp["task_graph"] = plan.TaskGraph
+		if plan.Estimate != nil {
+			resp["estimate"] = plan.Estimate
+		}
+	}
+	c.JSON(consts.StatusOK, resp)
+}
+
+// ReviewJobPlanRequest POST /api/jobs/:id/plan/review 请求体；decision 为空时有 task_graph 视为 edit，否则 approve
+type ReviewJobPlanRequest struct {
+	Decision  string             `json:"decision"`   // approve | edit | reject
+	TaskGraph *planner.TaskGraph `json:"task_graph"` // edit 时必填：替换待审阅的计划
+	PlanHash  string             `json:"plan_hash"`  // 可选：审阅人看到的计划哈希，与当前计划不一致时 409，避免审阅过期计划
+	Comment   string             `json:"comment"`
+}
+
+// ReviewJobPlan 审阅挂起在计划审阅门上的 Job：approve 记录审阅通过的计划哈希；edit 先追加编辑后的 PlanGenerated 再记录通过；
+// reject 记录驳回并取消 Job。通过后将挂起的 Job 置回 Pending 开始执行
+func (h *Handler) ReviewJobPlan(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.stores_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	j, ok := h.getJobAndCheckTenant(ctx, c, jobID)
+	if !ok {
+		return
+	}
+	var req ReviewJobPlanRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.body_json_required")})
+		return
+	}
+	if req.Decision == "" {
+		req.Decision = jobstore.PlanReviewApprove
+		if req.TaskGraph != nil {
+			req.Decision = jobstore.PlanReviewEdit
+		}
+	}
+	switch req.Decision {
+	case jobstore.PlanReviewApprove, jobstore.PlanReviewReject:
+	case jobstore.PlanReviewEdit:
+		if req.TaskGraph == nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "plan_review.task_graph_required")})
+			return
+		}
+		if err := planner.ValidateTaskGraph(req.TaskGraph, nil); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "planner.graph_invalid", err.Error())})
+			return
+		}
+	default:
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "plan_review.decision_invalid")})
+		return
+	}
+	if j.Status.IsTerminal() {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "job.already_finished")})
+		return
+	}
+	events, ver, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	review := jobstore.PlanReviewOf(events)
+	if review == nil {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "plan_review.not_required")})
+		return
+	}
+	if review.Status != jobstore.PlanReviewPending {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "plan_review.not_pending", review.Status)})
+		return
+	}
+	if req.PlanHash != "" && req.PlanHash != review.PlanHash {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "plan_review.plan_changed")})
+		return
+	}
+	reviewed := jobstore.PlanReviewedPayload{
+		Decision: req.Decision,
+		PlanHash: review.PlanHash,
+		Reviewer: auth.GetUserID(ctx),
+		Comment:  req.Comment,
+		At:       time.Now().UTC(),
+	}
+	if req.Decision == jobstore.PlanReviewEdit {
+		graphBytes, _ := req.TaskGraph.Marshal()
+		reviewed.OriginalPlanHash = review.PlanHash
+		reviewed.PlanHash = jobstore.PlanHash(graphBytes)
+		planPayload := map[string]interface{}{
+			"task_graph":      json.RawMessage(graphBytes),
+			"goal":            j.Goal,
+			"plan_hash":       reviewed.PlanHash,
+			"estimate":        planner.EstimateTaskGraph(req.TaskGraph, h.planCostModel),
+			"plan_source":     "review_edit",
+			"review_required": true,
+		}
+		payloadPlan, errMarshal := marshalJSON(ctx, planPayload, "plan_generated_payload")
+		if errMarshal != nil {
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "planner.serialize_event_failed")})
+			return
+		}
+		ver, err = h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
+			JobID: jobID, Type: jobstore.PlanGenerated, Payload: payloadPlan,
+		})
+		if err != nil {
+			h.writePlanReviewAppendError(ctx, c, err)
+			return
+		}
+	}
+	reviewedBytes, errMarshal := marshalJSON(ctx, reviewed, "plan_reviewed_payload")
+	if errMarshal != nil {
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "plan_review.build_event_failed")})
+		return
+	}
+	ver, err = h.jobEventStore.Append(ctx, jobID, ver, jobstore.JobEvent{
+		JobID: jobID, Type: jobstore.PlanReviewed, Payload: reviewedBytes,
+	})
+	if err != nil {
+		h.writePlanReviewAppendError(ctx, c, err)
+		return
+	}
+	resp := map[string]interface{}{
+		"job_id":    jobID,
+		"decision":  req.Decision,
+		"plan_hash": reviewed.PlanHash,
+		"reviewer":  reviewed.Reviewer,
+	}
+	if req.Decision == jobstore.PlanReviewReject {
+		h.cancelRejectedPlanJob(ctx, j, ver, reviewed)
+		resp["status"] = "cancelled"
+		resp["message"] = "计划已驳回，Job 已取消"
+		c.JSON(consts.StatusOK, resp)
+		return
+	}
+	if reviewed.OriginalPlanHash != "" {
+		resp["original_plan_hash"] = reviewed.OriginalPlanHash
+	}
+	// Runner 认领后发现审阅未通过会挂起（Parked）；仍为 Pending 的 Job 无需处理，认领时即按已通过的计划执行
+	if j.Status == job.StatusParked {
+		if err := h.jobStore.UpdateStatus(ctx, jobID, job.StatusPending); err != nil {
+			hlog.CtxErrorf(ctx, "UpdateStatus Pending: %v", err)
+		}
+		if h.wakeupQueue != nil {
+			_ = h.wakeupQueue.NotifyReady(ctx, jobID)
+		}
+	}
+	resp["status"] = "pending"
+	resp["message"] = "计划审阅已记录，Job 将开始执行"
+	c.JSON(consts.StatusOK, resp)
+}
+
+// writePlanReviewAppendError 审阅事件写入失败：并发写入返回 409，其余 500
+func (h *Handler) writePlanReviewAppendError(ctx context.Context, c *app.RequestContext, err error) {
+	if errors.Is(err, jobstore.ErrVersionMismatch) {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "job.events_changed")})
+		return
+	}
+	hlog.CtxErrorf(ctx, "Append plan review events: %v", err)
+	c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.write_event_failed")})
+}
+
+// cancelRejectedPlanJob 计划被驳回：未在执行的 Job 直接写入 job_cancelled 并置为 Cancelled；Runner 恰好持有时请求取消，由 Worker 收尾
+func (h *Handler) cancelRejectedPlanJob(ctx context.Context, j *job.Job, ver int, reviewed jobstore.PlanReviewedPayload) {
+	info := &job.TerminalInfo{Reason: "plan_rejected", Actor: reviewed.Reviewer, FailureClass: job.FailureClassCancelled}
+	if err := job.RecordTerminalInfo(ctx, h.jobStore, j.ID, info); err != nil {
+		hlog.CtxErrorf(ctx, "RecordTerminalInfo failed: %v", err)
+	}
+	if j.Status == job.StatusRunning {
+		if err := h.jobStore.RequestCancel(ctx, j.ID); err != nil {
+			hlog.CtxErrorf(ctx, "RequestCancel failed: %v", err)
+		}
+		return
+	}
+	pl := info.EventFields()
+	pl["goal"] = j.Goal
+	payload, _ := json.Marshal(pl)
+	if _, err := h.jobEventStore.Append(ctx, j.ID, ver, jobstore.JobEvent{JobID: j.ID, Type: jobstore.JobCancelled, Payload: payload}); err != nil {
+		hlog.CtxErrorf(ctx, "Append job_cancelled: %v", err)
+	}
+	if err := h.jobStore.UpdateStatus(ctx, j.ID, job.StatusCancelled); err != nil {
+		hlog.CtxErrorf(ctx, "UpdateStatus Cancelled: %v", err)
+	}
+}
+
+// writeTracePlanReview 渲染计划审阅状态、计划哈希与审阅记录；待审阅且在线查看时附带计划编辑框与审阅按钮
+func writeTracePlanReview(b *strings.Builder, review *jobstore.PlanReview, taskGraph json.RawMessage, interactive bool, tr func(string) string) {
+	b.WriteString("<div class=\"trace-plan-review\" id=\"trace-plan-review\"><p><b>")
+	b.WriteString(tr("trace.page.plan_review"))
+	b.WriteString(":</b> ")
+	b.WriteString(html.EscapeString(review.Status))
+	b.WriteString(" &middot; " + tr("trace.page.plan_hash") + " <code>")
+	b.WriteString(html.EscapeString(review.PlanHash))
+	b.WriteString("</code></p>")
+	if len(review.Reviews) > 0 {
+		b.WriteString("<ul>")
+		for _, r := range review.Reviews {
+			b.WriteString("<li>")
+			b.WriteString(html.EscapeString(r.Decision))
+			if r.Reviewer != "" {
+				b.WriteString(" &middot; ")
+				b.WriteString(html.EscapeString(r.Reviewer))
+			}
+			if !r.At.IsZero() {
+				b.WriteString(" &middot; ")
+				b.WriteString(html.EscapeString(r.At.UTC().Format(time.RFC3339)))
+			}
+			if r.Comment != "" {
+				b.WriteString(" &middot; ")
+				b.WriteString(html.EscapeString(r.Comment))
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	if interactive && review.Status == jobstore.PlanReviewPending {
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, taskGraph, "", "  ") != nil {
+			pretty.Reset()
+			pretty.Write(taskGraph)
+		}
+		b.WriteString("<textarea id=\"plan-review-graph\" data-plan-hash=\"")
+		b.WriteString(html.EscapeString(review.PlanHash))
+		b.WriteString("\">")
+		b.WriteString(html.EscapeString(pretty.String()))
+		b.WriteString("</textarea><p><input id=\"plan-review-comment\" type=\"text\" placeholder=\"")
+		b.WriteString(tr("trace.page.plan_review_comment"))
+		b.WriteString("\"> <button type=\"button\" class=\"plan-review-btn\" data-decision=\"approve\">")
+		b.WriteString(tr("trace.page.plan_review_approve"))
+		b.WriteString("</button> <button type=\"button\" class=\"plan-review-btn\" data-decision=\"edit\">")
+		b.WriteString(tr("trace.page.plan_review_edit"))
+		b.WriteString("</button> <button type=\"button\" class=\"plan-review-btn\" data-decision=\"reject\">")
+		b.WriteString(tr("trace.page.plan_review_reject"))
+		b.WriteString("</button></p><pre id=\"plan-review-result\"></pre>")
+	}
+	b.WriteString("</div>")
+}
+
+// writeTracePlanReviewScript writes JS that posts the plan review decision (edit sends the textarea graph) and reloads on success.
+func writeTracePlanReviewScript(b *strings.Builder) {
+	b.WriteString("(function(){ var T = window.__TRACE__ || {}; var ta = document.getElementById('plan-review-graph'); var out = document.getElementById('plan-review-result'); if(!ta || !out) return; document.querySelectorAll('.plan-review-btn').forEach(function(btn){ btn.addEventListener('click', function(){ var body = { decision: btn.getAttribute('data-decision'), plan_hash: ta.getAttribute('data-plan-hash') || '', comment: (document.getElementById('plan-review-comment') || {}).value || '' }; if(body.decision === 'edit'){ try { body.task_graph = JSON.parse(ta.value); } catch(err){ out.textContent = 'Invalid task graph JSON: ' + String(err); return; } } fetch('/api/jobs/' + encodeURIComponent(T.job_id || '') + '/plan/review', { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) }).then(function(r){ return r.json().then(function(data){ if(!r.ok){ throw new Error(data.error || ('HTTP ' + r.status)); } return data; }); }).then(function(){ window.location.reload(); }).catch(function(err){ out.textContent = 'Plan review failed: ' + String(err); }); }); }); })();")
+}
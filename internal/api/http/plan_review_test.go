@@ -0,0 +1,145 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/agentconfig"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func setupPlanReviewServer(t *testing.T) (*Handler, jobstore.JobStore, func(method, path, body string) (int, map[string]interface{}), string) {
+	t.Helper()
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	events := jobstore.NewMemoryStore()
+	cfg := agentconfig.NewStoreMem()
+	_ = cfg.Set(ctx, &agentconfig.Entry{AgentID: a.ID, Key: agentconfig.KeyRequirePlanApproval, Value: "true"})
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(job.NewJobStoreMem())
+	handler.SetJobEventStore(events)
+	handler.SetAgentConfig(cfg)
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.POST("/api/agents/:id/message", handler.AgentMessage)
+	s.GET("/api/jobs/:id/plan/review", handler.GetJobPlanReview)
+	s.POST("/api/jobs/:id/plan/review", handler.ReviewJobPlan)
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		w := ut.PerformRequest(s.Engine, method, path, &ut.Body{Body: bytes.NewReader([]byte(body)), Len: len(body)})
+		var out map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+	code, out := do("POST", "/api/agents/"+a.ID+"/message", `{"message":"notify","task_graph":{"nodes":[{"id":"send","type":"tool","tool_name":"email.send"}],"edges":[]}}`)
+	if code != 202 {
+		t.Fatalf("submit: %d %v", code, out)
+	}
+	review, _ := out["plan_review"].(map[string]interface{})
+	if review["status"] != jobstore.PlanReviewPending {
+		t.Fatalf("response plan_review = %v", out["plan_review"])
+	}
+	return handler, events, do, out["job_id"].(string)
+}
+
+// parkJob 模拟 Runner 认领后因审阅未通过而挂起
+func parkJob(t *testing.T, h *Handler, jobID string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := h.jobStore.UpdateStatus(ctx, jobID, job.StatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.jobStore.UpdateStatus(ctx, jobID, job.StatusParked); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReviewJobPlan_EditRecordsPlanAndResumes(t *testing.T) {
+	ctx := context.Background()
+	handler, events, do, jobID := setupPlanReviewServer(t)
+	evs, _, _ := events.ListEvents(ctx, jobID)
+	requested := false
+	for _, e := range evs {
+		requested = requested || e.Type == jobstore.PlanReviewRequested
+	}
+	if review := jobstore.PlanReviewOf(evs); review == nil || review.Status != jobstore.PlanReviewPending || !requested {
+		t.Fatalf("plan review not requested: %+v", review)
+	}
+	code, out := do("GET", "/api/jobs/"+jobID+"/plan/review", "")
+	if code != 200 || out["status"] != jobstore.PlanReviewPending || out["task_graph"] == nil {
+		t.Fatalf("get review: %d %v", code, out)
+	}
+	origHash, _ := out["plan_hash"].(string)
+	parkJob(t, handler, jobID)
+
+	if code, _ := do("POST", "/api/jobs/"+jobID+"/plan/review", `{"plan_hash":"stale"}`); code != 409 {
+		t.Fatalf("stale plan_hash status = %d, want 409", code)
+	}
+	code, out = do("POST", "/api/jobs/"+jobID+"/plan/review", `{"plan_hash":"`+origHash+`","comment":"cc legal","task_graph":{"nodes":[{"id":"send","type":"tool","tool_name":"email.send","config":{"cc":"legal@example.com"}}],"edges":[]}}`)
+	if code != 200 || out["decision"] != jobstore.PlanReviewEdit || out["original_plan_hash"] != origHash || out["plan_hash"] == origHash {
+		t.Fatalf("edit review: %d %v", code, out)
+	}
+	evs, _, _ = events.ListEvents(ctx, jobID)
+	n := len(evs)
+	if evs[n-2].Type != jobstore.PlanGenerated || evs[n-1].Type != jobstore.PlanReviewed {
+		t.Fatalf("unexpected tail events: %s, %s", evs[n-2].Type, evs[n-1].Type)
+	}
+	review := jobstore.PlanReviewOf(evs)
+	if review.Status != jobstore.PlanReviewApproved || review.PlanHash != out["plan_hash"] || len(review.Reviews) != 1 || review.Reviews[0].Comment != "cc legal" {
+		t.Fatalf("review = %+v", review)
+	}
+	if g := taskGraphFromEvents(evs); g == nil || g.Nodes[0].Config["cc"] != "legal@example.com" {
+		t.Fatalf("edited plan not current: %+v", g)
+	}
+	if j, _ := handler.jobStore.Get(ctx, jobID); j.Status != job.StatusPending {
+		t.Fatalf("job status = %v, want pending", j.Status)
+	}
+	if code, _ := do("POST", "/api/jobs/"+jobID+"/plan/review", `{"decision":"approve"}`); code != 409 {
+		t.Fatalf("second review status = %d, want 409", code)
+	}
+}
+
+func TestReviewJobPlan_RejectCancelsJob(t *testing.T) {
+	ctx := context.Background()
+	handler, events, do, jobID := setupPlanReviewServer(t)
+	parkJob(t, handler, jobID)
+	if code, _ := do("POST", "/api/jobs/"+jobID+"/plan/review", `{"decision":"edit"}`); code != 400 {
+		t.Fatalf("edit without task_graph status = %d, want 400", code)
+	}
+	if code, out := do("POST", "/api/jobs/"+jobID+"/plan/review", `{"decision":"reject","comment":"wrong recipient"}`); code != 200 {
+		t.Fatalf("reject: %d %v", code, out)
+	}
+	evs, _, _ := events.ListEvents(ctx, jobID)
+	if evs[len(evs)-1].Type != jobstore.JobCancelled {
+		t.Fatalf("last event = %s, want job_cancelled", evs[len(evs)-1].Type)
+	}
+	if review := jobstore.PlanReviewOf(evs); review.Status != jobstore.PlanReviewRejected {
+		t.Fatalf("review status = %s", review.Status)
+	}
+	j, _ := handler.jobStore.Get(ctx, jobID)
+	if j.Status != job.StatusCancelled || j.Terminal == nil || j.Terminal.Reason != "plan_rejected" {
+		t.Fatalf("job = %v %+v", j.Status, j.Terminal)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/pkg/i18n"
+)
+
+// PlannerExemplarRequest 创建/更新规划示例请求
+type PlannerExemplarRequest struct {
+	Goal     string             `json:"goal"`
+	Graph    *planner.TaskGraph `json:"graph"`
+	Note     string             `json:"note"`
+	Disabled bool               `json:"disabled"`
+}
+
+// ListPlannerExemplars 列出 Agent 的规划示例及各 A/B 分组的计划有效率
+// GET /api/agents/:id/planner/exemplars
+func (h *Handler) ListPlannerExemplars(ctx context.Context, c *app.RequestContext) {
+	if h.plannerExemplars == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "exemplar.disabled")})
+		return
+	}
+	agentID := c.Param("id")
+	list, err := h.plannerExemplars.List(ctx, agentID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List planner exemplars: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "exemplar.get_failed")})
+		return
+	}
+	if list == nil {
+		list = []*planner.Exemplar{}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"agent_id":      agentID,
+		"exemplars":     list,
+		"plan_validity": h.planValidity.Stats(agentID),
+	})
+}
+
+// CreatePlannerExemplar 为 Agent 新增规划示例；graph 须通过计划有效性校验
+// POST /api/agents/:id/planner/exemplars
+func (h *Handler) CreatePlannerExemplar(ctx context.Context, c *app.RequestContext) {
+	h.putPlannerExemplar(ctx, c, "")
+}
+
+// UpdatePlannerExemplar 更新 Agent 的规划示例（整体替换 goal/graph/note/disabled）
+// PUT /api/agents/:id/planner/exemplars/:exemplar_id
+func (h *Handler) UpdatePlannerExemplar(ctx context.Context, c *app.RequestContext) {
+	agentID, id := c.Param("id"), c.Param("exemplar_id")
+	if h.plannerExemplars != nil {
+		if _, err := h.plannerExemplars.Get(ctx, agentID, id); errors.Is(err, planner.ErrExemplarNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "exemplar.not_found")})
+			return
+		}
+	}
+	h.putPlannerExemplar(ctx, c, id)
+}
+
+func (h *Handler) putPlannerExemplar(ctx context.Context, c *app.RequestContext, id string) {
+	if h.plannerExemplars == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "exemplar.disabled")})
+		return
+	}
+	var req PlannerExemplarRequest
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Goal) == "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.goal_and_graph_required")})
+		return
+	}
+	if err := planner.ValidateTaskGraph(req.Graph, nil); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "planner.graph_invalid", err.Error())})
+		return
+	}
+	e := &planner.Exemplar{
+		ID:       id,
+		AgentID:  c.Param("id"),
+		Goal:     strings.TrimSpace(req.Goal),
+		Graph:    req.Graph,
+		Note:     req.Note,
+		Disabled: req.Disabled,
+	}
+	newID, err := h.plannerExemplars.Put(ctx, e)
+	if err != nil {
+		if errors.Is(err, planner.ErrExemplarNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "exemplar.not_found")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Put planner exemplar: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "exemplar.save_failed")})
+		return
+	}
+	saved, err := h.plannerExemplars.Get(ctx, e.AgentID, newID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Get planner exemplar: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "exemplar.save_failed")})
+		return
+	}
+	status := consts.StatusOK
+	if id == "" {
+		status = consts.StatusCreated
+	}
+	c.JSON(status, saved)
+}
+
+// DeletePlannerExemplar 删除 Agent 的规划示例
+// DELETE /api/agents/:id/planner/exemplars/:exemplar_id
+func (h *Handler) DeletePlannerExemplar(ctx context.Context, c *app.RequestContext) {
+	if h.plannerExemplars == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "exemplar.disabled")})
+		return
+	}
+	id := c.Param("exemplar_id")
+	if err := h.plannerExemplars.Delete(ctx, c.Param("id"), id); err != nil {
+		if errors.Is(err, planner.ErrExemplarNotFound) {
+			c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "exemplar.not_found")})
+			return
+		}
+		hlog.CtxErrorf(ctx, "Delete planner exemplar: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "exemplar.delete_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]string{"id": id, "status": "deleted"})
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/promptlog"
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/i18n"
+)
+
+// SetPromptLog 设置 LLM prompt 留存的对象存储（可选，用于 GET /api/jobs/:id/nodes/:node_id/prompt）；须与 Worker 使用同一存储
+func (h *Handler) SetPromptLog(store object.Store, prefix string) {
+	h.promptStore = store
+	h.promptPrefix = prefix
+}
+
+// GetJobNodePrompt 返回节点留存的 LLM prompt（已脱敏），按 llm_prompt_logged 事件顺序；重试的每次留存各一条
+func (h *Handler) GetJobNodePrompt(ctx context.Context, c *app.RequestContext) {
+	if h.promptStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "prompt_log.disabled")})
+		return
+	}
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	nodeID := c.Param("node_id")
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+		return
+	}
+	prompts := make([]map[string]interface{}, 0)
+	for _, e := range events {
+		if e.Type != jobstore.LLMPromptLogged {
+			continue
+		}
+		var pl struct {
+			NodeID    string              `json:"node_id"`
+			PromptRef *executor.PromptRef `json:"prompt_ref"`
+			Failed    bool                `json:"failed"`
+		}
+		if json.Unmarshal(e.Payload, &pl) != nil || pl.NodeID != nodeID || pl.PromptRef == nil {
+			continue
+		}
+		item := map[string]interface{}{"ref": pl.PromptRef, "failed": pl.Failed, "logged_at": e.CreatedAt}
+		entry, err := promptlog.Read(ctx, h.promptStore, h.promptPrefix, pl.PromptRef.Key)
+		if err != nil {
+			// 对象可能已按保留期过期
+			item["error"] = i18n.T(ctx, "prompt_log.read_failed", err.Error())
+		} else {
+			item["entry"] = entry
+		}
+		prompts = append(prompts, item)
+	}
+	if len(prompts) == 0 {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "prompt_log.not_found")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":  jobID,
+		"node_id": nodeID,
+		"prompts": prompts,
+	})
+}
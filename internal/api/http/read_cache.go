@@ -0,0 +1,203 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hjson "github.com/cloudwego/hertz/pkg/common/json"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// 只读 Job 视图服务端缓存默认值
+const (
+	DefaultReadCacheTTL        = 5 * time.Second
+	DefaultReadCacheMaxEntries = 1024
+)
+
+// readCache trace / events / replay 响应的短期服务端缓存：key 含最新事件版本，值为已序列化的响应体
+type readCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	now     func() time.Time
+	entries map[string]readCacheEntry
+}
+
+type readCacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+func newReadCache(ttl time.Duration, maxEntries int) *readCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultReadCacheMaxEntries
+	}
+	return &readCache{ttl: ttl, max: maxEntries, now: time.Now, entries: make(map[string]readCacheEntry)}
+}
+
+func (rc *readCache) get(key string) ([]byte, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !rc.now().Before(e.expiresAt) {
+		delete(rc.entries, key)
+		return nil, false
+	}
+	return e.body, true
+}
+
+func (rc *readCache) put(key string, body []byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	now := rc.now()
+	if len(rc.entries) >= rc.max {
+		for k, e := range rc.entries {
+			if !now.Before(e.expiresAt) {
+				delete(rc.entries, k)
+			}
+		}
+		// 仍满时随机淘汰一项：条目寿命很短，无需 LRU
+		for k := range rc.entries {
+			if len(rc.entries) < rc.max {
+				break
+			}
+			delete(rc.entries, k)
+		}
+	}
+	rc.entries[key] = readCacheEntry{body: body, expiresAt: now.Add(rc.ttl)}
+}
+
+// SetReadCache 设置 trace / events / replay 的服务端缓存时长与条目上限；ttl<=0 关闭服务端缓存（ETag 仍生效）
+func (h *Handler) SetReadCache(ttl time.Duration, maxEntries int) {
+	if ttl <= 0 {
+		h.readCache = nil
+		return
+	}
+	h.readCache = newReadCache(ttl, maxEntries)
+}
+
+// jobReadKey 由 (接口, job_id, 最新事件版本, 视图, extra) 派生缓存 key；视图区分是否遮蔽 payload，
+// 并包含 Job 元数据与当前执行环境（replay 的环境漂移）。无法确定版本（事件已清理、只剩归档）时 ok=false
+func (h *Handler) jobReadKey(ctx context.Context, endpoint, jobID string, j *job.Job, cold *coldRead, extra ...string) (string, bool) {
+	var version int
+	if cold != nil {
+		version = len(cold.events)
+	} else {
+		v, err := jobstore.CurrentVersion(ctx, h.jobEventStore, jobID)
+		if err != nil || v == 0 {
+			return "", false
+		}
+		version = v
+	}
+	view := "full"
+	if h.traceMaskFor(ctx) != nil {
+		view = "masked"
+	}
+	parts := []string{endpoint, jobID, strconv.Itoa(version), view}
+	if j != nil {
+		parts = append(parts, strconv.Itoa(int(j.Status)), strconv.FormatInt(j.UpdatedAt.UnixNano(), 10))
+	}
+	if h.environment != nil {
+		parts = append(parts, h.environment.Current().Hash)
+	}
+	parts = append(parts, extra...)
+	return strings.Join(parts, "|"), true
+}
+
+// hierarchyFingerprint 子 Job 层级（含各子 Job 状态）的摘要；无层级时为空
+func hierarchyFingerprint(hier *job.JobHierarchy) string {
+	if hier == nil {
+		return ""
+	}
+	b, err := json.Marshal(hier)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// readETag 强 ETag：key 的 sha256 前 16 字节
+func readETag(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches 判断 If-None-Match（可为逗号分隔列表或 *；弱比较）是否命中 etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// serveJobRead 为只读 Job 视图提供 ETag 与短期服务端缓存：If-None-Match 命中返回 304 且不读取事件，
+// 缓存命中直接返回已序列化的响应，否则调用 build（失败时 build 自行写错误响应并返回 nil）
+func (h *Handler) serveJobRead(ctx context.Context, c *app.RequestContext, key string, cacheable bool, build func() map[string]interface{}) {
+	if !cacheable {
+		if resp := build(); resp != nil {
+			c.JSON(consts.StatusOK, resp)
+		}
+		return
+	}
+	etag := readETag(key)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if inm := string(c.GetHeader("If-None-Match")); inm != "" && etagMatches(inm, etag) {
+		c.SetStatusCode(consts.StatusNotModified)
+		return
+	}
+	if h.readCache != nil {
+		if body, ok := h.readCache.get(key); ok {
+			c.Data(consts.StatusOK, "application/json; charset=utf-8", body)
+			return
+		}
+	}
+	resp := build()
+	if resp == nil {
+		c.Response.Header.Del("ETag")
+		c.Response.Header.Del("Cache-Control")
+		return
+	}
+	body, err := hjson.Marshal(resp)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "marshal job read response: %v", err)
+		c.JSON(consts.StatusOK, resp)
+		return
+	}
+	if h.readCache != nil {
+		h.readCache.put(key, body)
+	}
+	c.Data(consts.StatusOK, "application/json; charset=utf-8", body)
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	hertzapp "github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+)
+
+// countingEventStore 统计完整读取事件流的次数
+type countingEventStore struct {
+	jobstore.JobStore
+	lists atomic.Int32
+}
+
+func (s *countingEventStore) ListEvents(ctx context.Context, jobID string) ([]jobstore.JobEvent, int, error) {
+	s.lists.Add(1)
+	return s.JobStore.ListEvents(ctx, jobID)
+}
+
+func (s *countingEventStore) ListEventsSince(ctx context.Context, jobID string, afterVersion int) ([]jobstore.JobEvent, int, error) {
+	return jobstore.EventsSince(ctx, s.JobStore, jobID, afterVersion)
+}
+
+func TestJobReads_ETagAndServerCache(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := &countingEventStore{JobStore: jobstore.NewMemoryStore()}
+	jobID, err := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g", TenantID: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Now()
+	ver := 0
+	appendEvent := func(e jobstore.JobEvent) {
+		e.JobID = jobID
+		if ver, err = events.Append(ctx, jobID, ver, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendEvent(narrativeEvent(t, jobstore.NodeStarted, t0, "", map[string]interface{}{"node_id": "n1"}))
+	appendEvent(narrativeEvent(t, jobstore.ToolCalled, t0, "", map[string]interface{}{"node_id": "n1", "tool_name": "crm.lookup", "input": "secret"}))
+
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	handler.SetReadCache(time.Minute, 0)
+	withRole := func(ctx context.Context, c *hertzapp.RequestContext) {
+		c.Next(auth.WithRole(ctx, auth.Role(c.Request.Header.Get("X-Role"))))
+	}
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/trace", withRole, handler.GetJobTrace)
+	s.GET("/api/jobs/:id/events", withRole, handler.GetJobEvents)
+	s.GET("/api/jobs/:id/replay", withRole, handler.GetJobReplay)
+
+	get := func(path, role, ifNoneMatch string) (int, string, string) {
+		headers := []ut.Header{{Key: "X-Role", Value: role}}
+		if ifNoneMatch != "" {
+			headers = append(headers, ut.Header{Key: "If-None-Match", Value: ifNoneMatch})
+		}
+		w := ut.PerformRequest(s.Engine, "GET", path, nil, headers...)
+		r := w.Result()
+		return r.StatusCode(), string(r.Header.Peek("ETag")), string(r.Body())
+	}
+
+	for _, endpoint := range []string{"trace", "events", "replay"} {
+		path := "/api/jobs/" + jobID + "/" + endpoint
+		status, etag, body := get(path, string(auth.RoleAuditor), "")
+		if status != 200 || etag == "" {
+			t.Fatalf("%s: status=%d etag=%q", endpoint, status, etag)
+		}
+		lists := events.lists.Load()
+		if status, _, _ := get(path, string(auth.RoleAuditor), etag); status != 304 {
+			t.Fatalf("%s: If-None-Match status=%d, want 304", endpoint, status)
+		}
+		status, etag2, _ := get(path, string(auth.RoleAuditor), `"other", `+etag)
+		if status != 304 || etag2 != etag {
+			t.Fatalf("%s: list If-None-Match status=%d", endpoint, status)
+		}
+		status, _, body2 := get(path, string(auth.RoleAuditor), "")
+		if status != 200 || body2 != body {
+			t.Fatalf("%s: cached body differs", endpoint)
+		}
+		if got := events.lists.Load(); got != lists {
+			t.Fatalf("%s: event stream re-read %d times on cache hits", endpoint, got-lists)
+		}
+		// 受限查看者的遮蔽视图使用不同的 ETag 与缓存项
+		if _, viewerTag, viewerBody := get(path, string(auth.RoleViewer), ""); viewerTag == etag || viewerBody == body {
+			t.Fatalf("%s: viewer shares the auditor's cached view", endpoint)
+		}
+	}
+
+	path := "/api/jobs/" + jobID + "/trace"
+	_, etag, _ := get(path, string(auth.RoleAuditor), "")
+	appendEvent(narrativeEvent(t, jobstore.NodeFinished, t0.Add(time.Second), "", map[string]interface{}{"node_id": "n1"}))
+	status, newTag, body := get(path, string(auth.RoleAuditor), etag)
+	if status != 200 || newTag == etag {
+		t.Fatalf("after new event: status=%d etag changed=%v", status, newTag != etag)
+	}
+	if want := `"type":"node_finished"`; !strings.Contains(body, want) {
+		t.Fatalf("rebuilt trace lacks new event: %s", body)
+	}
+}
+
+func TestReadCache_ExpiryAndBound(t *testing.T) {
+	rc := newReadCache(time.Second, 2)
+	now := time.Unix(1000, 0)
+	rc.now = func() time.Time { return now }
+	rc.put("a", []byte("1"))
+	if b, ok := rc.get("a"); !ok || string(b) != "1" {
+		t.Fatal("expected hit")
+	}
+	now = now.Add(time.Second)
+	if _, ok := rc.get("a"); ok {
+		t.Fatal("expected expiry")
+	}
+	rc.put("b", []byte("2"))
+	rc.put("c", []byte("3"))
+	rc.put("d", []byte("4"))
+	if len(rc.entries) > 2 {
+		t.Fatalf("cache holds %d entries, max 2", len(rc.entries))
+	}
+	if _, ok := rc.get("d"); !ok {
+		t.Fatal("latest entry evicted")
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	for inm, want := range map[string]bool{`"x"`: true, `W/"x"`: true, `"y", "x"`: true, `*`: true, `"y"`: false} {
+		if got := etagMatches(inm, `"x"`); got != want {
+			t.Errorf("etagMatches(%s)=%v, want %v", inm, got, want)
+		}
+	}
+}
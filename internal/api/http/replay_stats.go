@@ -0,0 +1,288 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// ReplayStats 步骤实际执行与 Replay 注入的计数；InjectedRatio 为注入占已完成步骤比例（0~1）
+type ReplayStats struct {
+	LiveSteps       int     `json:"live_steps"`
+	InjectedSteps   int     `json:"injected_steps"`
+	InjectedRatio   float64 `json:"injected_ratio"`
+	CommandCatchUps int     `json:"command_catch_ups"`
+	PolicyDenials   int     `json:"policy_denials"`
+}
+
+func (s *ReplayStats) add(o ReplayStats) {
+	s.LiveSteps += o.LiveSteps
+	s.InjectedSteps += o.InjectedSteps
+	s.CommandCatchUps += o.CommandCatchUps
+	s.PolicyDenials += o.PolicyDenials
+}
+
+func (s *ReplayStats) finish() {
+	if total := s.LiveSteps + s.InjectedSteps; total > 0 {
+		s.InjectedRatio = float64(s.InjectedSteps) / float64(total)
+	}
+}
+
+// NodeTypeReplayStats 某 node type 的执行/注入计数（catch-up 与策略拒绝仅在 Job 级统计）
+type NodeTypeReplayStats struct {
+	NodeType string `json:"node_type"`
+	ReplayStats
+}
+
+// JobReplayStats 单个 Job 的执行/注入统计（GET /api/jobs/:id/replay/stats 的响应体）
+type JobReplayStats struct {
+	JobID string `json:"job_id"`
+	ReplayStats
+	ByNodeType []NodeTypeReplayStats `json:"by_node_type"`
+}
+
+// ReplayReport GET /api/observability/replay 的响应体：窗口内全体 Job 的执行/注入占比
+type ReplayReport struct {
+	WindowSeconds  int       `json:"window_seconds"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	JobsAnalyzed   int       `json:"jobs_analyzed"`
+	JobsWithReplay int       `json:"jobs_with_replay"`
+	ReplayStats
+	ByNodeType []NodeTypeReplayStats `json:"by_node_type"`
+	// TopJobs 注入步骤最多的 Job（节省重复执行最多）
+	TopJobs []JobReplayStats `json:"top_jobs"`
+	// DeniedJobs 因 Replay 策略拒绝而失败的 Job，通常提示策略配置与节点类型不匹配
+	DeniedJobs []string `json:"denied_jobs"`
+}
+
+// BuildJobReplayStats 由事件流统计 Job 的执行方式：node_finished 带 attempt 为实际执行，无 attempt 为 Replay 注入；
+// catch-up 为恢复时补写的 tool_invocation_finished（invocation_id 以 catchup- 开头）；策略拒绝见 job_failed 的 error。
+// from/to 非零时仅统计窗口内事件
+func BuildJobReplayStats(jobID string, events []jobstore.JobEvent, from, to time.Time) JobReplayStats {
+	out := JobReplayStats{JobID: jobID, ByNodeType: []NodeTypeReplayStats{}}
+	nodeTypes := make(map[string]string)
+	byType := make(map[string]*ReplayStats)
+	statsFor := func(nodeID string) *ReplayStats {
+		key := nodeTypes[nodeID]
+		if key == "" {
+			key = "unknown"
+		}
+		if byType[key] == nil {
+			byType[key] = &ReplayStats{}
+		}
+		return byType[key]
+	}
+	for _, e := range events {
+		var pl map[string]interface{}
+		if len(e.Payload) > 0 {
+			_ = json.Unmarshal(e.Payload, &pl)
+		}
+		getStr := func(k string) string {
+			s, _ := pl[k].(string)
+			return s
+		}
+		if e.Type == jobstore.PlanGenerated || e.Type == jobstore.PlanEvolution {
+			var p struct {
+				TaskGraph *planner.TaskGraph `json:"task_graph"`
+			}
+			if json.Unmarshal(e.Payload, &p) == nil && p.TaskGraph != nil {
+				for _, n := range p.TaskGraph.Nodes {
+					nodeTypes[n.ID] = n.Type
+				}
+			}
+			continue
+		}
+		if (!from.IsZero() && e.CreatedAt.Before(from)) || (!to.IsZero() && e.CreatedAt.After(to)) {
+			continue
+		}
+		switch e.Type {
+		case jobstore.NodeFinished:
+			st := statsFor(getStr("node_id"))
+			if attempt, _ := pl["attempt"].(float64); attempt > 0 {
+				st.LiveSteps++
+			} else {
+				st.InjectedSteps++
+			}
+		case jobstore.ToolInvocationFinished:
+			if strings.HasPrefix(getStr("invocation_id"), "catchup-") {
+				// catch-up 事件的 node_id 为确定性 step ID，无法对应 node type，仅计入 Job 级
+				out.CommandCatchUps++
+			}
+		case jobstore.JobFailed:
+			if strings.Contains(getStr("error"), agentexec.ErrReplayPolicyDenied.Error()) {
+				out.PolicyDenials++
+			}
+		}
+	}
+	keys := make([]string, 0, len(byType))
+	for k := range byType {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		st := *byType[k]
+		st.finish()
+		out.ReplayStats.add(st)
+		out.ByNodeType = append(out.ByNodeType, NodeTypeReplayStats{NodeType: k, ReplayStats: st})
+	}
+	out.ReplayStats.finish()
+	return out
+}
+
+// BuildReplayReport 聚合多个 Job 的执行/注入统计；top 限制 TopJobs 长度
+func BuildReplayReport(eventsByJob map[string][]jobstore.JobEvent, from, to time.Time, top int) ReplayReport {
+	if top <= 0 {
+		top = defaultBottleneckTop
+	}
+	report := ReplayReport{
+		WindowSeconds: int(to.Sub(from).Seconds()),
+		From:          from,
+		To:            to,
+		JobsAnalyzed:  len(eventsByJob),
+		ByNodeType:    []NodeTypeReplayStats{},
+		TopJobs:       []JobReplayStats{},
+		DeniedJobs:    []string{},
+	}
+	byType := make(map[string]*ReplayStats)
+	for jobID, events := range eventsByJob {
+		st := BuildJobReplayStats(jobID, events, from, to)
+		report.ReplayStats.add(st.ReplayStats)
+		for _, nt := range st.ByNodeType {
+			if byType[nt.NodeType] == nil {
+				byType[nt.NodeType] = &ReplayStats{}
+			}
+			byType[nt.NodeType].add(nt.ReplayStats)
+		}
+		if st.PolicyDenials > 0 {
+			report.DeniedJobs = append(report.DeniedJobs, jobID)
+		}
+		if st.InjectedSteps > 0 || st.CommandCatchUps > 0 {
+			report.JobsWithReplay++
+			report.TopJobs = append(report.TopJobs, st)
+		}
+	}
+	report.ReplayStats.finish()
+	keys := make([]string, 0, len(byType))
+	for k := range byType {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		st := *byType[k]
+		st.finish()
+		report.ByNodeType = append(report.ByNodeType, NodeTypeReplayStats{NodeType: k, ReplayStats: st})
+	}
+	sort.Strings(report.DeniedJobs)
+	sort.Slice(report.TopJobs, func(a, b int) bool {
+		x, y := report.TopJobs[a], report.TopJobs[b]
+		if x.InjectedSteps != y.InjectedSteps {
+			return x.InjectedSteps > y.InjectedSteps
+		}
+		return x.JobID < y.JobID
+	})
+	if len(report.TopJobs) > top {
+		report.TopJobs = report.TopJobs[:top]
+	}
+	return report
+}
+
+// GetJobReplayStats 单个 Job 的实际执行 vs Replay 注入统计（GET /api/jobs/:id/replay/stats）
+func (h *Handler) GetJobReplayStats(ctx context.Context, c *app.RequestContext) {
+	if h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.event_store_disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	events, _, err := h.jobEventStore.ListEvents(ctx, jobID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListEvents: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.replay_failed", err.Error())})
+		return
+	}
+	c.JSON(consts.StatusOK, BuildJobReplayStats(jobID, events, time.Time{}, time.Time{}))
+}
+
+// GetObservabilityReplay 窗口内全体 Job 的实际执行 vs Replay 注入占比、命令 catch-up 与策略拒绝
+// （GET /api/observability/replay?window=24h）；需 JobStore 实现 job.RecentJobLister
+func (h *Handler) GetObservabilityReplay(ctx context.Context, c *app.RequestContext) {
+	if h.jobStore == nil || h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	lister, ok := h.jobStore.(job.RecentJobLister)
+	if !ok {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "job.window_listing_unsupported")})
+		return
+	}
+	window := defaultBottleneckWindow
+	if s := c.Query("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.window_invalid")})
+			return
+		}
+		window = min(d, maxBottleneckWindow)
+	}
+	limit := defaultBottleneckJobs
+	if s := c.Query("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = min(n, maxBottleneckJobs)
+		}
+	}
+	top := defaultBottleneckTop
+	if s := c.Query("top"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			top = n
+		}
+	}
+	to := time.Now()
+	from := to.Add(-window)
+	jobs, err := lister.ListUpdatedSince(ctx, auth.GetTenantID(ctx), from, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListUpdatedSince: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
+		return
+	}
+	eventsByJob := make(map[string][]jobstore.JobEvent, len(jobs))
+	for _, j := range jobs {
+		events, _, err := h.jobEventStore.ListEvents(ctx, j.ID)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ListEvents %s: %v", j.ID, err)
+			continue
+		}
+		eventsByJob[j.ID] = events
+	}
+	c.JSON(consts.StatusOK, BuildReplayReport(eventsByJob, from, to, top))
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"testing"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func replayJobEvents(t *testing.T, t0 time.Time, denied bool) []jobstore.JobEvent {
+	t.Helper()
+	graph := map[string]interface{}{"nodes": []map[string]interface{}{
+		{"id": "n1", "type": "tool", "tool_name": "web_search"},
+		{"id": "n2", "type": "llm"},
+		{"id": "n3", "type": "llm"},
+	}}
+	events := []jobstore.JobEvent{
+		narrativeEvent(t, jobstore.PlanGenerated, t0, "", map[string]interface{}{"task_graph": graph}),
+		// n1 由 Replay 注入（attempt=0 不写入），n2 恢复时 catch-up 后实际执行完成，n3 实际执行
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(time.Second), "", map[string]interface{}{"node_id": "n1", "result_type": "side_effect_committed"}),
+		narrativeEvent(t, jobstore.ToolInvocationFinished, t0.Add(2*time.Second), "", map[string]interface{}{"node_id": "step-n2", "invocation_id": "catchup-key", "outcome": "success"}),
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(3*time.Second), "", map[string]interface{}{"node_id": "n2", "attempt": 1}),
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(4*time.Second), "", map[string]interface{}{"node_id": "n3", "attempt": 1}),
+	}
+	if denied {
+		events = append(events, narrativeEvent(t, jobstore.JobFailed, t0.Add(5*time.Second), "", map[string]interface{}{
+			"error": "executor: replay 时副作用节点 n4 无已记录结果，forbidden执行: replay policy denied execution",
+		}))
+	}
+	return events
+}
+
+func TestBuildJobReplayStats(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	st := BuildJobReplayStats("job-1", replayJobEvents(t, t0, true), time.Time{}, time.Time{})
+	if st.LiveSteps != 2 || st.InjectedSteps != 1 || st.CommandCatchUps != 1 || st.PolicyDenials != 1 {
+		t.Fatalf("stats = %+v", st.ReplayStats)
+	}
+	if st.InjectedRatio < 0.33 || st.InjectedRatio > 0.34 {
+		t.Errorf("injected_ratio = %v", st.InjectedRatio)
+	}
+	if len(st.ByNodeType) != 2 || st.ByNodeType[0].NodeType != "llm" || st.ByNodeType[0].LiveSteps != 2 || st.ByNodeType[1].InjectedSteps != 1 {
+		t.Errorf("by_node_type = %+v", st.ByNodeType)
+	}
+}
+
+func TestBuildReplayReport(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	liveOnly := []jobstore.JobEvent{
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(time.Second), "", map[string]interface{}{"node_id": "x", "attempt": 1}),
+	}
+	report := BuildReplayReport(map[string][]jobstore.JobEvent{
+		"job-a": replayJobEvents(t, t0, false),
+		"job-b": replayJobEvents(t, t0, true),
+		"job-c": liveOnly,
+	}, t0, t0.Add(time.Hour), 1)
+	if report.JobsAnalyzed != 3 || report.JobsWithReplay != 2 {
+		t.Errorf("jobs analyzed/with replay = %d/%d", report.JobsAnalyzed, report.JobsWithReplay)
+	}
+	if report.LiveSteps != 5 || report.InjectedSteps != 2 || report.CommandCatchUps != 2 || report.PolicyDenials != 1 {
+		t.Errorf("totals = %+v", report.ReplayStats)
+	}
+	if len(report.DeniedJobs) != 1 || report.DeniedJobs[0] != "job-b" {
+		t.Errorf("denied_jobs = %v", report.DeniedJobs)
+	}
+	if len(report.TopJobs) != 1 || report.TopJobs[0].JobID != "job-a" {
+		t.Errorf("top_jobs = %+v", report.TopJobs)
+	}
+}
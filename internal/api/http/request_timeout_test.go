@@ -0,0 +1,72 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/api/http/middleware"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(middleware.NewRequestTimeout(middleware.RequestTimeoutConfig{
+		Default: 20 * time.Millisecond,
+		Routes:  map[string]time.Duration{"/jobs/:id/export": time.Hour, "/Stream": 0},
+	}).Handler())
+	// 模拟挂起的存储调用：阻塞到 ctx 截止，返回 500
+	s.GET("/jobs/:id", func(ctx context.Context, c *app.RequestContext) {
+		<-ctx.Done()
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": ctx.Err().Error()})
+	})
+	deadline := func(ctx context.Context, c *app.RequestContext) {
+		d, ok := ctx.Deadline()
+		c.JSON(consts.StatusOK, map[string]any{"has_deadline": ok, "remaining_s": time.Until(d).Seconds()})
+	}
+	s.GET("/jobs/:id/export", deadline)
+	s.GET("/stream", deadline)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/jobs/j1", &ut.Body{Body: bytes.NewReader(nil), Len: 0})
+	if got := w.Result().StatusCode(); got != consts.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504: %s", got, w.Result().Body())
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Result().Body(), &body); err != nil || body["timeout"] != "20ms" || body["error"] == "" {
+		t.Fatalf("body = %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/jobs/j1/export", &ut.Body{Body: bytes.NewReader(nil), Len: 0})
+	var got struct {
+		HasDeadline bool    `json:"has_deadline"`
+		RemainingS  float64 `json:"remaining_s"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &got); err != nil || !got.HasDeadline || got.RemainingS < 3500 {
+		t.Fatalf("export override = %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/stream", &ut.Body{Body: bytes.NewReader(nil), Len: 0})
+	if err := json.Unmarshal(w.Result().Body(), &got); err != nil || got.HasDeadline {
+		t.Fatalf("route with 0 override should have no deadline: %s", w.Result().Body())
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/pkg/i18n"
+)
+
+// ResidencyMigrateRequest 将租户的文档与向量迁移到 to_region
+type ResidencyMigrateRequest struct {
+	TenantID string `json:"tenant_id"`
+	ToRegion string `json:"to_region"`
+}
+
+// GetTenantResidency 租户数据所在区域及该区域 API 地址（GET /api/admin/residency/tenants/:tenant）
+func (h *Handler) GetTenantResidency(ctx context.Context, c *app.RequestContext) {
+	if h.residency == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "residency.disabled")})
+		return
+	}
+	tenantID := c.Param("tenant")
+	region, apiURL, local := h.residency.Resolver().Home(tenantID)
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"tenant_id":    tenantID,
+		"region":       region,
+		"api_url":      apiURL,
+		"local":        local,
+		"local_region": h.residency.Resolver().LocalRegion(),
+		"regions":      h.residency.Resolver().Regions(),
+	})
+}
+
+// MigrateTenantResidency 将租户的文档元数据、版本历史与向量迁移到目标区域并改派租户（POST /api/admin/residency/migrate）。
+// 改派仅在本进程生效，需同步更新 residency.tenants 配置；Postgres 中的 Job 数据用 `aetheris migrate tenant-region` 迁移
+func (h *Handler) MigrateTenantResidency(ctx context.Context, c *app.RequestContext) {
+	if h.residency == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "residency.disabled")})
+		return
+	}
+	var req ResidencyMigrateRequest
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.TenantID) == "" || strings.TrimSpace(req.ToRegion) == "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "residency.migrate_invalid")})
+		return
+	}
+	if _, ok := h.residency.Resolver().Region(req.ToRegion); !ok {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "residency.region_unknown", req.ToRegion)})
+		return
+	}
+	from := h.residency.Resolver().RegionFor(req.TenantID)
+	if from == req.ToRegion {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "residency.already_in_region", req.TenantID, from)})
+		return
+	}
+	result, err := h.residency.MigrateDocuments(ctx, req.TenantID, from, req.ToRegion)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "MigrateDocuments %s %s→%s: %v", req.TenantID, from, req.ToRegion, err)
+		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
+			"error":    i18n.T(ctx, "residency.migrate_failed", err.Error()),
+			"progress": result,
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, result)
+}
@@ -28,7 +28,10 @@ type Router struct {
 	middleware            *middleware.Middleware
 	jwtAuth               *middleware.JWTAuth
 	authz                 *middleware.AuthZMiddleware
+	residency             middleware.TenantHomeResolver
 	forensicsExperimental bool
+	accessLogger          *middleware.AccessLogger
+	requestTimeout        *middleware.RequestTimeout
 }
 
 // NewRouter 创建新的 HTTP 路由器
@@ -50,17 +53,35 @@ func (r *Router) SetAuthZ(authz *middleware.AuthZMiddleware) {
 	r.authz = authz
 }
 
+// SetResidency 设置数据驻留解析器（可选）：非本区域租户的认证请求返回 421
+func (r *Router) SetResidency(resolver middleware.TenantHomeResolver) {
+	r.residency = resolver
+}
+
+// SetAccessLogger 设置访问日志器（采样 + 慢请求捕获），未设置时使用 Middleware.AccessLog
+func (r *Router) SetAccessLogger(l *middleware.AccessLogger) {
+	r.accessLogger = l
+}
+
+// SetRequestTimeout 设置请求截止时间中间件（api.timeout / api.timeouts.routes），未设置时请求不设截止时间
+func (r *Router) SetRequestTimeout(t *middleware.RequestTimeout) {
+	r.requestTimeout = t
+}
+
 // SetForensicsExperimental 设置 Forensics 查询类接口是否暴露（默认 false）
 func (r *Router) SetForensicsExperimental(enabled bool) {
 	r.forensicsExperimental = enabled
 }
 
-// authChain 返回认证链：authHandler + InjectAuthContext；若启用 RBAC 则追加 RequirePermission
+// authChain 返回认证链：authHandler + InjectAuthContext；若启用数据驻留则追加区域校验，若启用 RBAC 则追加 RequirePermission
 func (r *Router) authChain(permission auth.Permission) []app.HandlerFunc {
 	chain := []app.HandlerFunc{r.middleware.Auth(), r.middleware.InjectAuthContext()}
 	if r.jwtAuth != nil {
 		chain[0] = r.jwtAuth.MiddlewareFunc()
 	}
+	if r.residency != nil {
+		chain = append(chain, r.middleware.Residency(r.residency))
+	}
 	if r.authz != nil {
 		chain = append(chain, r.authz.RequirePermission(permission))
 	}
@@ -77,18 +98,31 @@ func (r *Router) Build(addr string, opts ...config.Option) *server.Hertz {
 	allOpts := append([]config.Option{server.WithHostPorts(addr)}, opts...)
 	h := server.Default(allOpts...)
 
-	// 全局中间件：访问日志、CORS
-	h.Use(r.middleware.AccessLog())
+	// 全局中间件：请求 ID、访问日志、CORS、语言协商、请求截止时间
+	h.Use(r.middleware.RequestID())
+	if r.accessLogger != nil {
+		h.Use(r.accessLogger.Handler())
+	} else {
+		h.Use(r.middleware.AccessLog())
+	}
 	h.Use(r.middleware.CORS())
+	h.Use(r.middleware.Locale())
+	if r.requestTimeout != nil {
+		h.Use(r.requestTimeout.Handler())
+	}
 
 	// Prometheus 抓取用；无认证，与 CI/运维约定一致
 	h.GET("/metrics", r.handler.SystemMetrics)
+	// 就绪探针：启动预检（model.preflight）结果；无认证
+	h.GET("/readyz", r.handler.Readyz)
 
 	api := h.Group("/api")
 	api.GET("/health", r.handler.HealthCheck)
 	if r.jwtAuth != nil {
 		api.POST("/login", r.jwtAuth.LoginHandler())
 	}
+	// 外部系统回调：由通道自身验签（api.inbound_webhooks），不走平台鉴权
+	api.POST("/webhooks/inbound/:channel", r.handler.InboundWebhook)
 
 	documents := api.Group("/documents")
 	{
@@ -97,15 +131,25 @@ func (r *Router) Build(addr string, opts ...config.Option) *server.Hertz {
 		documents.GET("/upload/status/:task_id", r.authChainWith(auth.PermissionJobView, r.handler.UploadStatus)...)
 		documents.GET("/", r.authChainWith(auth.PermissionJobView, r.handler.ListDocuments)...)
 		documents.GET("/:id", r.authChainWith(auth.PermissionJobView, r.handler.GetDocument)...)
+		documents.GET("/:id/versions", r.authChainWith(auth.PermissionJobView, r.handler.ListDocumentVersions)...)
 		documents.DELETE("/:id", r.authChainWith(auth.PermissionJobView, r.handler.DeleteDocument)...)
 	}
 
+	ingestGroup := api.Group("/ingest")
+	{
+		ingestGroup.GET("/stats", r.authChainWith(auth.PermissionJobView, r.handler.GetIngestStats)...)
+		ingestGroup.POST("/tasks/:task_id/retry", r.authChainWith(auth.PermissionJobCreate, r.handler.RetryIngestTask)...)
+	}
+
 	knowledge := api.Group("/knowledge")
 	{
 		knowledge.GET("/collections", r.authChainWith(auth.PermissionJobView, r.handler.ListCollections)...)
 		knowledge.POST("/collections", r.authChainWith(auth.PermissionJobView, r.handler.CreateCollection)...)
 		knowledge.DELETE("/collections/:id", r.authChainWith(auth.PermissionJobView, r.handler.DeleteCollection)...)
+		knowledge.GET("/collections/:id/freshness", r.authChainWith(auth.PermissionJobView, r.handler.CollectionFreshness)...)
 	}
+	// 集合新鲜度的短路径别名
+	api.GET("/collections/:id/freshness", r.authChainWith(auth.PermissionJobView, r.handler.CollectionFreshness)...)
 
 	// Deprecated: 请使用 POST /api/agents/{id}/message
 	query := api.Group("/query")
@@ -128,7 +172,27 @@ func (r *Router) Build(addr string, opts ...config.Option) *server.Hertz {
 		agents.POST("/", r.authChainWith(auth.PermissionAgentManage, r.handler.CreateAgent)...)
 		agents.GET("", r.authChainWith(auth.PermissionJobView, r.handler.ListAgents)...)
 		agents.GET("/", r.authChainWith(auth.PermissionJobView, r.handler.ListAgents)...)
+		agents.POST("/import", r.authChainWith(auth.PermissionAgentManage, r.handler.ImportAgent)...)
+		agents.GET("/:id/export", r.authChainWith(auth.PermissionAgentManage, r.handler.ExportAgent)...)
 		agents.POST("/:id/message", r.authChainWith(auth.PermissionJobCreate, r.handler.AgentMessage)...)
+		agents.POST("/:id/plan/preview", r.authChainWith(auth.PermissionJobCreate, r.handler.PreviewAgentPlan)...)
+		agents.GET("/:id/templates", r.authChainWith(auth.PermissionJobView, r.handler.ListGoalTemplates)...)
+		agents.GET("/:id/templates/:name", r.authChainWith(auth.PermissionJobView, r.handler.GetGoalTemplate)...)
+		agents.PUT("/:id/templates/:name", r.authChainWith(auth.PermissionAgentManage, r.handler.PutGoalTemplate)...)
+		agents.DELETE("/:id/templates/:name", r.authChainWith(auth.PermissionAgentManage, r.handler.DeleteGoalTemplate)...)
+		agents.POST("/:id/templates/:name/render", r.authChainWith(auth.PermissionJobView, r.handler.RenderGoalTemplate)...)
+		agents.GET("/:id/eval/suite", r.authChainWith(auth.PermissionJobView, r.handler.GetEvalSuite)...)
+		agents.PUT("/:id/eval/suite", r.authChainWith(auth.PermissionAgentManage, r.handler.PutEvalSuite)...)
+		agents.POST("/:id/eval/runs", r.authChainWith(auth.PermissionJobCreate, r.handler.StartEvalRun)...)
+		agents.GET("/:id/eval/runs", r.authChainWith(auth.PermissionJobView, r.handler.ListEvalRuns)...)
+		agents.GET("/:id/eval/runs/:run_id", r.authChainWith(auth.PermissionJobView, r.handler.GetEvalRun)...)
+		agents.GET("/:id/planner/exemplars", r.authChainWith(auth.PermissionJobView, r.handler.ListPlannerExemplars)...)
+		agents.POST("/:id/planner/exemplars", r.authChainWith(auth.PermissionAgentManage, r.handler.CreatePlannerExemplar)...)
+		agents.PUT("/:id/planner/exemplars/:exemplar_id", r.authChainWith(auth.PermissionAgentManage, r.handler.UpdatePlannerExemplar)...)
+		agents.DELETE("/:id/planner/exemplars/:exemplar_id", r.authChainWith(auth.PermissionAgentManage, r.handler.DeletePlannerExemplar)...)
+		agents.GET("/:id/config", r.authChainWith(auth.PermissionAgentManage, r.handler.ListAgentConfig)...)
+		agents.PUT("/:id/config/:key", r.authChainWith(auth.PermissionAgentManage, r.handler.SetAgentConfigEntry)...)
+		agents.DELETE("/:id/config/:key", r.authChainWith(auth.PermissionAgentManage, r.handler.DeleteAgentConfigEntry)...)
 		agents.GET("/:id/state", r.authChainWith(auth.PermissionJobView, r.handler.AgentState)...)
 		agents.POST("/:id/resume", r.authChainWith(auth.PermissionJobCreate, r.handler.AgentResume)...)
 		agents.POST("/:id/stop", r.authChainWith(auth.PermissionJobStop, r.handler.AgentStop)...)
@@ -139,24 +203,49 @@ func (r *Router) Build(addr string, opts ...config.Option) *server.Hertz {
 	// Execution Trace：Job 时间线与节点详情（可观测）
 	jobs := api.Group("/jobs")
 	{
+		jobs.GET("/export", r.authChainWith(auth.PermissionJobExport, r.handler.ExportJobs)...)
 		jobs.GET("/:id", r.authChainWith(auth.PermissionJobView, r.handler.GetJob)...)
+		jobs.GET("/:id/wait", r.authChainWith(auth.PermissionJobView, r.handler.WaitJob)...)
 		jobs.POST("/:id/stop", r.authChainWith(auth.PermissionJobStop, r.handler.JobStop)...)
 		jobs.POST("/:id/signal", r.authChainWith(auth.PermissionJobCreate, r.handler.JobSignal)...)
 		jobs.POST("/:id/message", r.authChainWith(auth.PermissionJobCreate, r.handler.JobMessage)...)
+		jobs.POST("/:id/nodes/:node_id/review", r.authChainWith(auth.PermissionJobCreate, r.handler.ReviewJobNode)...)
+		jobs.GET("/:id/plan/review", r.authChainWith(auth.PermissionJobView, r.handler.GetJobPlanReview)...)
+		jobs.POST("/:id/plan/review", r.authChainWith(auth.PermissionJobCreate, r.handler.ReviewJobPlan)...)
 		jobs.GET("/:id/events", r.authChainWith(auth.PermissionJobView, r.handler.GetJobEvents)...)
+		jobs.GET("/:id/transitions", r.authChainWith(auth.PermissionJobView, r.handler.GetJobTransitions)...)
+		jobs.GET("/:id/checkpoints", r.authChainWith(auth.PermissionTraceView, r.handler.ListJobCheckpoints)...)
+		jobs.POST("/:id/checkpoints/:cp_id/rollback", r.authChainWith(auth.PermissionJobRollback, r.handler.RollbackJobCheckpoint)...)
 		jobs.GET("/:id/replay", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobReplay)...)
+		jobs.GET("/:id/replay/stats", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobReplayStats)...)
 		jobs.GET("/:id/verify", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobVerify)...)
+		jobs.POST("/:id/assert", r.authChainWith(auth.PermissionTraceView, r.handler.AssertJob)...)
 		jobs.GET("/:id/trace", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobTrace)...)
 		jobs.GET("/:id/trace/cognition", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobCognitionTrace)...)
 		jobs.GET("/:id/nodes/:node_id", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobNode)...)
+		jobs.GET("/:id/nodes/:node_id/prompt", r.authChainWith(auth.PermissionTracePayloadView, r.handler.GetJobNodePrompt)...)
+		jobs.POST("/:id/nodes/:node_id/debug-run", r.authChainWith(auth.PermissionToolExecute, r.handler.DebugRunJobNode)...)
 		jobs.GET("/:id/trace/page", r.authChainWith(auth.PermissionTraceView, r.handler.GetJobTracePage)...)
 		jobs.POST("/:id/export", r.authChainWith(auth.PermissionJobExport, r.handler.ExportJobForensics)...)
+		jobs.GET("/:id/evidence", r.authChainWith(auth.PermissionJobExport, r.handler.GetJobEvidence)...)
+		jobs.GET("/:id/citations", r.authChainWith(auth.PermissionJobView, r.handler.GetJobCitations)...)
+		jobs.GET("/:id/workspace", r.authChainWith(auth.PermissionJobView, r.handler.GetJobWorkspace)...)
+		jobs.GET("/:id/workspace/files/*path", r.authChainWith(auth.PermissionJobView, r.handler.GetJobWorkspaceFile)...)
+		jobs.GET("/:id/pii", r.authChainWith(auth.PermissionAuditView, r.handler.GetJobPII)...)
+		jobs.POST("/:id/debug-sessions", r.authChainWith(auth.PermissionDebugSessionManage, r.handler.OpenDebugSession)...)
+		jobs.GET("/:id/debug-sessions", r.authChainWith(auth.PermissionAuditView, r.handler.ListDebugSessions)...)
+		jobs.DELETE("/:id/debug-sessions/:session_id", r.authChainWith(auth.PermissionDebugSessionManage, r.handler.CloseDebugSession)...)
 		if r.forensicsExperimental {
 			jobs.GET("/:id/evidence-graph", r.authChainWith(auth.PermissionAuditView, r.handler.GetJobEvidenceGraph)...)
 			jobs.GET("/:id/audit-log", r.authChainWith(auth.PermissionAuditView, r.handler.GetJobAuditLog)...)
 		}
 	}
 
+	sessions := api.Group("/sessions")
+	{
+		sessions.GET("/:id/export", r.authChainWith(auth.PermissionTraceView, r.handler.ExportSessionTranscript)...)
+	}
+
 	// 2.0-M3: Forensics 查询类接口（实验能力，默认不暴露）
 	if r.forensicsExperimental {
 		forensics := api.Group("/forensics")
@@ -179,10 +268,45 @@ func (r *Router) Build(addr string, opts ...config.Option) *server.Hertz {
 		system.GET("/status", r.authChainWith(auth.PermissionJobView, r.handler.SystemStatus)...)
 		system.GET("/metrics", r.authChainWith(auth.PermissionJobView, r.handler.SystemMetrics)...)
 		system.GET("/workers", r.authChainWith(auth.PermissionJobView, r.handler.SystemWorkers)...)
+		system.GET("/workers/:id/inspect", r.authChainWith(auth.PermissionWorkerInspect, r.handler.InspectWorker)...)
+	}
+	maintenance := api.Group("/maintenance")
+	{
+		maintenance.GET("/windows", r.authChainWith(auth.PermissionJobView, r.handler.ListMaintenanceWindows)...)
+		maintenance.POST("/windows", r.authChainWith(auth.PermissionAgentManage, r.handler.CreateMaintenanceWindow)...)
+		maintenance.DELETE("/windows/:id", r.authChainWith(auth.PermissionAgentManage, r.handler.DeleteMaintenanceWindow)...)
+	}
+	admin := api.Group("/admin")
+	{
+		admin.GET("/killswitch", r.authChainWith(auth.PermissionJobView, r.handler.GetToolKillSwitch)...)
+		admin.POST("/killswitch", r.authChainWith(auth.PermissionKillSwitchManage, r.handler.SetToolKillSwitch)...)
+		admin.GET("/residency/tenants/:tenant", r.authChainWith(auth.PermissionResidencyManage, r.handler.GetTenantResidency)...)
+		admin.POST("/residency/migrate", r.authChainWith(auth.PermissionResidencyManage, r.handler.MigrateTenantResidency)...)
+		admin.GET("/analytics", r.authChainWith(auth.PermissionAnalyticsView, r.handler.GetAdminAnalytics)...)
+		admin.GET("/slow-requests", r.authChainWith(auth.PermissionAPIDiagnose, r.handler.GetSlowRequests)...)
+	}
+	serviceAccounts := api.Group("/service-accounts")
+	{
+		serviceAccounts.GET("", r.authChainWith(auth.PermissionServiceAccountManage, r.handler.ListServiceAccounts)...)
+		serviceAccounts.POST("", r.authChainWith(auth.PermissionServiceAccountManage, r.handler.CreateServiceAccount)...)
+		serviceAccounts.POST("/:id/rotate", r.authChainWith(auth.PermissionServiceAccountManage, r.handler.RotateServiceAccount)...)
+		serviceAccounts.DELETE("/:id", r.authChainWith(auth.PermissionServiceAccountManage, r.handler.RevokeServiceAccount)...)
 	}
 	api.GET("/observability/summary", r.authChainWith(auth.PermissionJobView, r.handler.GetObservabilitySummary)...)
 	api.GET("/observability/stuck", r.authChainWith(auth.PermissionJobView, r.handler.GetObservabilityStuck)...)
+	api.GET("/observability/bottlenecks", r.authChainWith(auth.PermissionJobView, r.handler.GetObservabilityBottlenecks)...)
+	api.GET("/observability/replay", r.authChainWith(auth.PermissionJobView, r.handler.GetObservabilityReplay)...)
+	api.GET("/observability/drift", r.authChainWith(auth.PermissionJobView, r.handler.GetObservabilityDrift)...)
+	api.GET("/observability/anomalies", r.authChainWith(auth.PermissionJobView, r.handler.GetObservabilityAnomalies)...)
 	api.GET("/trace/overview/page", r.authChainWith(auth.PermissionTraceView, r.handler.GetTraceOverviewPage)...)
+	api.GET("/trace/overview", r.authChainWith(auth.PermissionTraceView, r.handler.GetTraceOverview)...)
+	api.GET("/trace/overview/presets", r.authChainWith(auth.PermissionTraceView, r.handler.ListTraceFilterPresets)...)
+	api.PUT("/trace/overview/presets/:name", r.authChainWith(auth.PermissionTraceView, r.handler.PutTraceFilterPreset)...)
+	api.DELETE("/trace/overview/presets/:name", r.authChainWith(auth.PermissionTraceView, r.handler.DeleteTraceFilterPreset)...)
+	api.GET("/tenant/branding", r.authChainWith(auth.PermissionTraceView, r.handler.GetTenantBranding)...)
+	api.PUT("/tenant/branding", r.authChainWith(auth.PermissionAgentManage, r.handler.PutTenantBranding)...)
+	api.DELETE("/tenant/branding", r.authChainWith(auth.PermissionAgentManage, r.handler.DeleteTenantBranding)...)
+	api.GET("/search", r.authChainWith(auth.PermissionTraceView, r.handler.Search)...)
 
 	return h
 }
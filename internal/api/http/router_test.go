@@ -3,6 +3,11 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -10,6 +15,7 @@ import (
 	"github.com/cloudwego/hertz/pkg/common/ut"
 
 	"rag-platform/internal/api/http/middleware"
+	"rag-platform/pkg/i18n"
 )
 
 func buildRouterForTest(forensicsExperimental bool) *server.Hertz {
@@ -82,3 +88,54 @@ func TestRouter_TraceOverviewPageRoute(t *testing.T) {
 		t.Fatalf("GET /api/trace/overview/page status = %d, want non-404", got)
 	}
 }
+
+func TestRouter_LocaleNegotiation(t *testing.T) {
+	s := buildRouterForTest(true)
+	body := []byte(`{}`)
+
+	w := ut.PerformRequest(s.Engine, "POST", "/api/forensics/query", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Accept-Language", Value: "zh-CN,zh;q=0.9,en;q=0.8"})
+	if got := w.Result().Header.Get("Content-Language"); got != i18n.Chinese {
+		t.Fatalf("Content-Language = %q, want zh", got)
+	}
+	if want := i18n.Translate(i18n.Chinese, "forensics.query_requires_stores"); !bytes.Contains(w.Result().Body(), []byte(want)) {
+		t.Fatalf("body = %s, want %q", w.Result().Body(), want)
+	}
+
+	// ?lang= 优先于 Accept-Language
+	w = ut.PerformRequest(s.Engine, "POST", "/api/forensics/query?lang=en", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Accept-Language", Value: "zh"})
+	if got := w.Result().Header.Get("Content-Language"); got != i18n.English {
+		t.Fatalf("Content-Language = %q, want en", got)
+	}
+	if want := i18n.Translate(i18n.English, "forensics.query_requires_stores"); !bytes.Contains(w.Result().Body(), []byte(want)) {
+		t.Fatalf("body = %s, want %q", w.Result().Body(), want)
+	}
+}
+
+// TestOpenAPISpecRoutesRegistered docs/openapi.json（Python 客户端据此生成）中的每个操作都须已在路由注册
+func TestOpenAPISpecRoutesRegistered(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "docs", "openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+	param := regexp.MustCompile(`\{[^}]+\}|:[^/]+`)
+	registered := make(map[string]bool)
+	for _, r := range buildRouterForTest(false).Routes() {
+		registered[r.Method+" "+param.ReplaceAllString(r.Path, "*")] = true
+	}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			key := strings.ToUpper(method) + " " + param.ReplaceAllString(path, "*")
+			if !registered[key] {
+				t.Errorf("%s %s in docs/openapi.json is not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/runtime/eventsearch"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// SetEventSearch 设置事件全文检索索引（可选，用于 GET /api/search）
+func (h *Handler) SetEventSearch(index eventsearch.Index) {
+	h.eventSearch = index
+}
+
+// searchScopesFor 返回查看者可检索的范围：受限查看者（trace 遮蔽生效）只能检索未被遮蔽的 goal 与 error，
+// 工具输出与推理内容不可检索，避免通过命中与否推断被遮蔽的 payload
+func (h *Handler) searchScopesFor(ctx context.Context) []eventsearch.Scope {
+	p := h.traceMaskFor(ctx)
+	if p == nil {
+		return eventsearch.Scopes
+	}
+	var out []eventsearch.Scope
+	if !p.Masks("goal") {
+		out = append(out, eventsearch.ScopeGoals)
+	}
+	if !p.Masks("error") {
+		out = append(out, eventsearch.ScopeErrors)
+	}
+	return out
+}
+
+// Search GET /api/search：在当前租户的 Job 事件中全文检索。
+// 参数 q（必填，空格分隔的词均须命中，支持 "短语" 与 -排除）、scope（events 默认 | goals | tool_outputs | errors | reasoning）、
+// agent_ids（或 agent_id，逗号分隔）、limit（默认 20，最大 100）、cursor。结果按事件时间倒序
+func (h *Handler) Search(ctx context.Context, c *app.RequestContext) {
+	if h.eventSearch == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "search.disabled")})
+		return
+	}
+	text := c.Query("q")
+	if len(eventsearch.Terms(text)) == 0 {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "search.query_required")})
+		return
+	}
+	scopes, err := eventsearch.ParseScope(c.Query("scope"))
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "search.invalid_scope")})
+		return
+	}
+	allowed := h.searchScopesFor(ctx)
+	if len(scopes) == 0 {
+		scopes = allowed
+	} else if !slices.Contains(allowed, scopes[0]) {
+		c.JSON(consts.StatusForbidden, map[string]string{"error": i18n.T(ctx, "search.scope_forbidden")})
+		return
+	}
+	if len(scopes) == 0 {
+		c.JSON(consts.StatusOK, &eventsearch.Result{Hits: []eventsearch.Hit{}})
+		return
+	}
+	agentIDs := splitCSV(c.Query("agent_ids"))
+	if len(agentIDs) == 0 {
+		agentIDs = splitCSV(c.Query("agent_id"))
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	res, err := h.eventSearch.Search(ctx, eventsearch.Query{
+		TenantID: auth.GetTenantID(ctx),
+		Q:        text,
+		Scopes:   scopes,
+		AgentIDs: agentIDs,
+		Limit:    limit,
+		Cursor:   c.Query("cursor"),
+	})
+	if errors.Is(err, eventsearch.ErrInvalidCursor) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.invalid_cursor")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Search: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "search.failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, res)
+}
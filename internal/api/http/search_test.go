@@ -0,0 +1,87 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	hertzapp "github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/runtime/eventsearch"
+	"rag-platform/pkg/auth"
+)
+
+func TestSearch_TenantScopedAndPermissionAware(t *testing.T) {
+	ctx := context.Background()
+	index := eventsearch.NewIndexMem()
+	now := time.Now()
+	_ = index.Add(ctx,
+		eventsearch.Doc{JobID: "j1", Version: 1, TenantID: "t1", AgentID: "a1", Scope: eventsearch.ScopeGoals, EventType: "job_created", Text: "reconcile invoices", CreatedAt: now},
+		eventsearch.Doc{JobID: "j1", Version: 3, TenantID: "t1", AgentID: "a1", Scope: eventsearch.ScopeToolOutputs, EventType: "tool_returned", Text: "invoice 42 overdue", CreatedAt: now.Add(time.Second)},
+		eventsearch.Doc{JobID: "j2", Version: 1, TenantID: "t2", AgentID: "a1", Scope: eventsearch.ScopeGoals, EventType: "job_created", Text: "invoice audit", CreatedAt: now},
+	)
+	handler := NewHandler(nil, nil)
+	s := server.Default(server.WithHostPorts(":0"))
+	withCaller := func(ctx context.Context, c *hertzapp.RequestContext) {
+		ctx = auth.WithTenantID(ctx, "t1")
+		c.Next(auth.WithRole(ctx, auth.Role(c.Request.Header.Get("X-Role"))))
+	}
+	s.GET("/api/search", withCaller, handler.Search)
+	get := func(query, role string) (int, eventsearch.Result) {
+		w := ut.PerformRequest(s.Engine, "GET", "/api/search?"+query, nil, ut.Header{Key: "X-Role", Value: role})
+		var res eventsearch.Result
+		_ = json.Unmarshal(w.Result().Body(), &res)
+		return w.Result().StatusCode(), res
+	}
+
+	if code, _ := get("q=invoice", string(auth.RoleAdmin)); code != 503 {
+		t.Fatalf("disabled search status = %d", code)
+	}
+	handler.SetEventSearch(index)
+
+	code, res := get("q=invoice", string(auth.RoleAdmin))
+	if code != 200 || len(res.Hits) != 2 || res.Hits[0].Scope != eventsearch.ScopeToolOutputs {
+		t.Fatalf("admin search = %d %+v", code, res.Hits)
+	}
+	for _, h := range res.Hits {
+		if h.JobID != "j1" {
+			t.Fatalf("hit from another tenant: %+v", h)
+		}
+	}
+	if code, res := get("q=invoice&scope=goals", string(auth.RoleAdmin)); code != 200 || len(res.Hits) != 1 || !strings.Contains(res.Hits[0].Snippet, eventsearch.HighlightStart) {
+		t.Fatalf("goals search = %d %+v", code, res.Hits)
+	}
+
+	// 受限查看者：工具输出不参与检索，显式请求该范围返回 403
+	code, res = get("q=invoice", string(auth.RoleViewer))
+	if code != 200 || len(res.Hits) != 1 || res.Hits[0].Scope != eventsearch.ScopeGoals {
+		t.Fatalf("viewer search = %d %+v", code, res.Hits)
+	}
+	if code, _ := get("q=invoice&scope=tool_outputs", string(auth.RoleViewer)); code != 403 {
+		t.Fatalf("viewer tool_outputs status = %d", code)
+	}
+
+	for _, query := range []string{"q=", "q=invoice&scope=bogus", "q=invoice&cursor=!!"} {
+		if code, _ := get(query, string(auth.RoleAdmin)); code != 400 {
+			t.Fatalf("%s status = %d", query, code)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/runtime/serviceaccount"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// ServiceAccountRequest 签发 Worker 服务账号请求
+type ServiceAccountRequest struct {
+	Name string `json:"name"`
+}
+
+// RotateServiceAccountRequest 轮换令牌请求；grace_period 为旧令牌宽限期（如 "10m"，"0s" 表示立即失效），空时默认 10m
+type RotateServiceAccountRequest struct {
+	GracePeriod string `json:"grace_period"`
+}
+
+// serviceAccountView 对外展示的账号信息（不含令牌 hash）
+func serviceAccountView(a *serviceaccount.Account) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":         a.ID,
+		"tenant_id":  a.TenantID,
+		"name":       a.Name,
+		"role":       a.Role,
+		"scopes":     a.Scopes(),
+		"created_at": a.CreatedAt,
+		"rotated_at": a.RotatedAt,
+		"revoked":    a.Revoked(),
+	}
+	if !a.PrevTokenExpiresAt.IsZero() {
+		out["prev_token_expires_at"] = a.PrevTokenExpiresAt
+	}
+	if a.Revoked() {
+		out["revoked_at"] = a.RevokedAt
+	}
+	return out
+}
+
+// getTenantServiceAccount 按 :id 获取当前租户的账号；不存在或属于其他租户时写 404 并返回 nil
+func (h *Handler) getTenantServiceAccount(ctx context.Context, c *app.RequestContext) *serviceaccount.Account {
+	if h.serviceAccounts == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "service_account.disabled")})
+		return nil
+	}
+	a, err := h.serviceAccounts.Get(ctx, c.Param("id"))
+	if errors.Is(err, serviceaccount.ErrNotFound) || (err == nil && a.TenantID != auth.GetTenantID(ctx)) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "service_account.not_found")})
+		return nil
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Get service account: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "service_account.get_failed")})
+		return nil
+	}
+	return a
+}
+
+// ListServiceAccounts 列出当前租户的 Worker 服务账号（含已吊销）
+// GET /api/service-accounts
+func (h *Handler) ListServiceAccounts(ctx context.Context, c *app.RequestContext) {
+	if h.serviceAccounts == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "service_account.disabled")})
+		return
+	}
+	list, err := h.serviceAccounts.List(ctx, auth.GetTenantID(ctx))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "List service accounts: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "service_account.get_failed")})
+		return
+	}
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, a := range list {
+		out = append(out, serviceAccountView(a))
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"service_accounts": out})
+}
+
+// CreateServiceAccount 为当前租户签发 Worker 服务账号（角色 worker：仅 job:claim / job:execute）；令牌仅在响应中返回一次
+// POST /api/service-accounts
+func (h *Handler) CreateServiceAccount(ctx context.Context, c *app.RequestContext) {
+	if h.serviceAccounts == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "service_account.disabled")})
+		return
+	}
+	var req ServiceAccountRequest
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.name_required")})
+		return
+	}
+	a, token, err := serviceaccount.Issue(ctx, h.serviceAccounts, auth.GetTenantID(ctx), strings.TrimSpace(req.Name))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Issue service account: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "service_account.issue_failed")})
+		return
+	}
+	out := serviceAccountView(a)
+	out["token"] = token
+	c.JSON(consts.StatusCreated, out)
+}
+
+// RotateServiceAccount 轮换服务账号令牌；旧令牌在 grace_period 内仍有效，Worker 在此期间切换到新令牌
+// POST /api/service-accounts/:id/rotate
+func (h *Handler) RotateServiceAccount(ctx context.Context, c *app.RequestContext) {
+	a := h.getTenantServiceAccount(ctx, c)
+	if a == nil {
+		return
+	}
+	grace := serviceaccount.DefaultRotationGrace
+	var req RotateServiceAccountRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+			return
+		}
+	}
+	if req.GracePeriod != "" {
+		d, err := time.ParseDuration(req.GracePeriod)
+		if err != nil || d < 0 {
+			c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.grace_period_invalid")})
+			return
+		}
+		grace = d
+	}
+	rotated, token, err := serviceaccount.Rotate(ctx, h.serviceAccounts, a.ID, grace)
+	if errors.Is(err, serviceaccount.ErrRevoked) {
+		c.JSON(consts.StatusConflict, map[string]string{"error": i18n.T(ctx, "service_account.revoked")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Rotate service account: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "service_account.rotate_failed")})
+		return
+	}
+	out := serviceAccountView(rotated)
+	out["token"] = token
+	c.JSON(consts.StatusOK, out)
+}
+
+// RevokeServiceAccount 吊销服务账号：当前与旧令牌立即失效，持有该令牌的 Worker 在下次校验后停止认领
+// DELETE /api/service-accounts/:id
+func (h *Handler) RevokeServiceAccount(ctx context.Context, c *app.RequestContext) {
+	a := h.getTenantServiceAccount(ctx, c)
+	if a == nil {
+		return
+	}
+	revoked, err := serviceaccount.Revoke(ctx, h.serviceAccounts, a.ID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Revoke service account: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "service_account.revoke_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, serviceAccountView(revoked))
+}
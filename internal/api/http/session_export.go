@@ -0,0 +1,259 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+// SessionTranscript 会话跨 Job 的可读对话记录，供客服交接与知识沉淀
+type SessionTranscript struct {
+	SessionID  string           `json:"session_id"`
+	AgentID    string           `json:"agent_id"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Turns      []TranscriptTurn `json:"turns"`
+}
+
+// TranscriptTurn 一轮对话，对应会话内的一个 Job
+type TranscriptTurn struct {
+	JobID     string               `json:"job_id"`
+	Status    string               `json:"status"`
+	CreatedAt time.Time            `json:"created_at"`
+	User      string               `json:"user"`
+	Assistant string               `json:"assistant,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	ToolCalls []TranscriptToolCall `json:"tool_calls,omitempty"`
+	TraceURL  string               `json:"trace_url"`
+}
+
+// TranscriptToolCall 内联的工具调用摘要（来自 tool_result_summarized），完整输入输出见 DetailURL
+type TranscriptToolCall struct {
+	NodeID    string `json:"node_id"`
+	ToolName  string `json:"tool_name"`
+	Summary   string `json:"summary,omitempty"`
+	Error     string `json:"error,omitempty"`
+	DetailURL string `json:"detail_url"`
+}
+
+// ExportSessionTranscript GET /api/sessions/:id/export：导出会话下全部 Job 的对话记录（用户消息、回答、内联工具调用与 Trace 链接）。
+// 参数 format（markdown 默认 | json）；仅包含当前租户的 Job，事件按 Trace 脱敏策略处理
+func (h *Handler) ExportSessionTranscript(ctx context.Context, c *app.RequestContext) {
+	if h.agentManager == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "agent.runtime_not_configured")})
+		return
+	}
+	if h.jobStore == nil || h.jobEventStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "markdown"))
+	if format != "markdown" && format != "json" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "session.export.invalid_format")})
+		return
+	}
+	sessionID := c.Param("id")
+	agentID := h.sessionAgentID(ctx, sessionID)
+	if agentID == "" {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "session.not_found")})
+		return
+	}
+	tenantID := auth.GetTenantID(ctx)
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	list, err := h.jobStore.ListByAgent(ctx, agentID, tenantID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ExportSessionTranscript: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
+		return
+	}
+	var jobs []*job.Job
+	for _, j := range list {
+		if j.SessionID == sessionID {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.SliceStable(jobs, func(a, b int) bool { return jobs[a].CreatedAt.Before(jobs[b].CreatedAt) })
+
+	out := &SessionTranscript{SessionID: sessionID, AgentID: agentID, ExportedAt: time.Now().UTC(), Turns: make([]TranscriptTurn, 0, len(jobs))}
+	maskGoal := h.traceMaskFor(ctx).Masks("goal")
+	for _, j := range jobs {
+		events, _, err := h.jobEventStore.ListEvents(ctx, j.ID)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "ExportSessionTranscript: list events %s: %v", j.ID, err)
+			c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_events_failed")})
+			return
+		}
+		turn := buildTranscriptTurn(j, h.viewEvents(ctx, events))
+		if maskGoal && turn.User != "" {
+			turn.User = TraceMaskedValue
+		}
+		out.Turns = append(out.Turns, turn)
+	}
+	if format == "json" {
+		c.JSON(consts.StatusOK, out)
+		return
+	}
+	c.Header("Content-Type", "text/markdown; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "session-"+sessionID+".md"))
+	c.WriteString(RenderTranscriptMarkdown(out))
+}
+
+// sessionAgentID 返回持有该会话的 Agent；不存在时返回空
+func (h *Handler) sessionAgentID(ctx context.Context, sessionID string) string {
+	agents, err := h.agentManager.List(ctx)
+	if err != nil {
+		return ""
+	}
+	for _, a := range agents {
+		if a != nil && a.Session != nil && a.Session.ID == sessionID {
+			return a.ID
+		}
+	}
+	return ""
+}
+
+// buildTranscriptTurn 由 Job 与事件流组装一轮对话：回答取最后一个 llm 节点的已提交结果
+func buildTranscriptTurn(j *job.Job, events []jobstore.JobEvent) TranscriptTurn {
+	turn := TranscriptTurn{
+		JobID:     j.ID,
+		Status:    j.Status.String(),
+		CreatedAt: j.CreatedAt,
+		User:      j.Goal,
+		TraceURL:  "/api/jobs/" + j.ID + "/trace/page",
+	}
+	if j.Status == job.StatusFailed && j.Terminal != nil {
+		turn.Error = j.Terminal.Reason
+	}
+	llmNodes := map[string]bool{}
+	if g := taskGraphFromEvents(events); g != nil {
+		for _, n := range g.Nodes {
+			if n.Type == planner.NodeLLM {
+				llmNodes[n.ID] = true
+			}
+		}
+	}
+	for _, e := range events {
+		switch e.Type {
+		case jobstore.ToolResultSummarized:
+			var pl struct {
+				NodeID   string `json:"node_id"`
+				ToolName string `json:"tool_name"`
+				Summary  string `json:"summary"`
+				Error    string `json:"error"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			turn.ToolCalls = append(turn.ToolCalls, TranscriptToolCall{
+				NodeID:    pl.NodeID,
+				ToolName:  pl.ToolName,
+				Summary:   pl.Summary,
+				Error:     pl.Error,
+				DetailURL: "/api/jobs/" + j.ID + "/nodes/" + pl.NodeID,
+			})
+		case jobstore.CommandCommitted:
+			var pl struct {
+				NodeID string          `json:"node_id"`
+				Result json.RawMessage `json:"result"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || !llmNodes[pl.NodeID] {
+				continue
+			}
+			if text := transcriptText(pl.Result); text != "" {
+				turn.Assistant = text
+			}
+		}
+	}
+	return turn
+}
+
+// transcriptText 从 llm 节点结果取可读文本：字符串本身或对象的 output 字段，其余原样保留 JSON
+func transcriptText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj map[string]interface{}
+	if json.Unmarshal(raw, &obj) == nil {
+		if s, ok := obj["output"].(string); ok {
+			return s
+		}
+	}
+	return string(raw)
+}
+
+// RenderTranscriptMarkdown 将会话记录渲染为 Markdown
+func RenderTranscriptMarkdown(t *SessionTranscript) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\n", t.SessionID)
+	fmt.Fprintf(&b, "- Agent: `%s`\n- Exported: %s\n- Turns: %d\n", t.AgentID, t.ExportedAt.Format(time.RFC3339), len(t.Turns))
+	for i, turn := range t.Turns {
+		fmt.Fprintf(&b, "\n## Turn %d · %s · %s\n\n", i+1, turn.CreatedAt.UTC().Format(time.RFC3339), turn.Status)
+		fmt.Fprintf(&b, "Job `%s` · [Full trace](%s)\n\n", turn.JobID, turn.TraceURL)
+		fmt.Fprintf(&b, "**User:**\n\n%s\n", markdownQuote(turn.User))
+		if len(turn.ToolCalls) > 0 {
+			b.WriteString("\n**Tool calls:**\n\n")
+			for _, tc := range turn.ToolCalls {
+				fmt.Fprintf(&b, "- `%s` (node `%s`)", tc.ToolName, tc.NodeID)
+				switch {
+				case tc.Error != "":
+					fmt.Fprintf(&b, " failed: %s", oneLine(tc.Error))
+				case tc.Summary != "":
+					fmt.Fprintf(&b, ": %s", oneLine(tc.Summary))
+				}
+				fmt.Fprintf(&b, " [details](%s)\n", tc.DetailURL)
+			}
+		}
+		if turn.Assistant != "" {
+			fmt.Fprintf(&b, "\n**Assistant:**\n\n%s\n", markdownQuote(turn.Assistant))
+		}
+		if turn.Error != "" {
+			fmt.Fprintf(&b, "\n**Error:** %s\n", oneLine(turn.Error))
+		}
+	}
+	return b.String()
+}
+
+// markdownQuote 多行文本渲染为引用块，避免消息内容中的标题/列表语法破坏文档结构
+func markdownQuote(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("> "+l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
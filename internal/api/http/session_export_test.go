@@ -0,0 +1,104 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	agentruntime "rag-platform/internal/agent/runtime"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestExportSessionTranscript(t *testing.T) {
+	ctx := context.Background()
+	m := agentruntime.NewManager()
+	a, _ := m.Create(ctx, "support", nil, nil, nil, nil)
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	handler := NewHandler(nil, nil)
+	handler.SetAgentRuntime(m, nil, testAgentCreator{m})
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+
+	jobID, _ := jobs.Create(ctx, &job.Job{AgentID: a.ID, TenantID: "default", Goal: "Where is my order #42?", SessionID: a.Session.ID})
+	_, _ = jobs.Create(ctx, &job.Job{AgentID: a.ID, TenantID: "default", Goal: "other session", SessionID: "session-other"})
+	appendAll := func(evs ...jobstore.JobEvent) {
+		for _, e := range evs {
+			_, ver, _ := events.ListEvents(ctx, jobID)
+			if _, err := events.Append(ctx, jobID, ver, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	appendAll(
+		jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: []byte(`{"task_graph":{"nodes":[{"id":"lookup","type":"tool","tool_name":"orders.get"},{"id":"reply","type":"llm"}],"edges":[{"from":"lookup","to":"reply"}]}}`)},
+		jobstore.JobEvent{JobID: jobID, Type: jobstore.CommandCommitted, Payload: []byte(`{"node_id":"lookup","result":{"status":"shipped"}}`)},
+		jobstore.JobEvent{JobID: jobID, Type: jobstore.ToolResultSummarized, Payload: []byte(`{"node_id":"lookup","tool_name":"orders.get","summary":"order 42 shipped"}`)},
+		jobstore.JobEvent{JobID: jobID, Type: jobstore.CommandCommitted, Payload: []byte(`{"node_id":"reply","result":{"output":"Your order has shipped."}}`)},
+	)
+
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/sessions/:id/export", handler.ExportSessionTranscript)
+	get := func(path string) (int, string) {
+		w := ut.PerformRequest(s.Engine, "GET", path, nil)
+		return w.Result().StatusCode(), string(w.Result().Body())
+	}
+
+	code, body := get("/api/sessions/" + a.Session.ID + "/export?format=json")
+	if code != 200 {
+		t.Fatalf("json export: %d %s", code, body)
+	}
+	var tr SessionTranscript
+	if err := json.Unmarshal([]byte(body), &tr); err != nil {
+		t.Fatal(err)
+	}
+	if tr.AgentID != a.ID || len(tr.Turns) != 1 {
+		t.Fatalf("transcript = %+v", tr)
+	}
+	turn := tr.Turns[0]
+	if turn.User != "Where is my order #42?" || turn.Assistant != "Your order has shipped." {
+		t.Errorf("turn = %+v", turn)
+	}
+	if len(turn.ToolCalls) != 1 || turn.ToolCalls[0].Summary != "order 42 shipped" || turn.ToolCalls[0].DetailURL != "/api/jobs/"+jobID+"/nodes/lookup" {
+		t.Errorf("tool calls = %+v", turn.ToolCalls)
+	}
+
+	code, body = get("/api/sessions/" + a.Session.ID + "/export")
+	if code != 200 {
+		t.Fatalf("markdown export: %d %s", code, body)
+	}
+	for _, want := range []string{"# Session " + a.Session.ID, "> Where is my order #42?", "`orders.get` (node `lookup`): order 42 shipped", "> Your order has shipped.", "/api/jobs/" + jobID + "/trace/page"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("markdown missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "other session") {
+		t.Error("markdown includes a job from another session")
+	}
+
+	if code, _ := get("/api/sessions/" + a.Session.ID + "/export?format=pdf"); code != 400 {
+		t.Errorf("invalid format: %d, want 400", code)
+	}
+	if code, _ := get("/api/sessions/session-missing/export"); code != 404 {
+		t.Errorf("unknown session: %d, want 404", code)
+	}
+}
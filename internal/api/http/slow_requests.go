@@ -0,0 +1,49 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/pkg/i18n"
+)
+
+const (
+	defaultSlowRequestsLimit = 50
+	maxSlowRequestsLimit     = 500
+)
+
+// GetSlowRequests GET /api/admin/slow-requests：最近捕获的慢请求（新到旧），含 handler 分段耗时；
+// ?route= 只看某一路由模板（如 /api/jobs/:id/trace），?limit= 默认 50
+func (h *Handler) GetSlowRequests(ctx context.Context, c *app.RequestContext) {
+	if h.accessLogger == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "access_log.disabled")})
+		return
+	}
+	limit := defaultSlowRequestsLimit
+	if s := c.Query("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = min(n, maxSlowRequestsLimit)
+		}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"slow_requests": h.accessLogger.RecentSlow(c.Query("route"), limit),
+		"thresholds_ms": h.accessLogger.Thresholds(),
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/api/http/middleware"
+	"rag-platform/pkg/auth"
+)
+
+func TestAccessLogger_CapturesSlowRequests(t *testing.T) {
+	logger := middleware.NewAccessLogger(middleware.AccessLogConfig{SlowThreshold: 30 * time.Millisecond, SampleRate: 0.5})
+	h := NewHandler(nil, nil)
+	h.SetAccessLogger(logger)
+	mw := middleware.NewMiddleware()
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(logger.Handler())
+	s.GET("/slow/:id", mw.InjectAuthContext(), func(ctx context.Context, c *app.RequestContext) {
+		end := middleware.StartPhase(ctx, "list_events")
+		time.Sleep(40 * time.Millisecond)
+		end()
+		c.String(200, "ok")
+	})
+	s.GET("/fast", func(ctx context.Context, c *app.RequestContext) { c.String(200, "ok") })
+	s.GET("/admin/slow-requests", h.GetSlowRequests)
+
+	req := func(path string, headers ...ut.Header) *ut.ResponseRecorder {
+		return ut.PerformRequest(s.Engine, "GET", path, &ut.Body{Body: bytes.NewReader(nil), Len: 0}, headers...)
+	}
+	req("/fast")
+	req("/slow/1", ut.Header{Key: "X-Tenant-ID", Value: "t1"})
+	req("/slow/2")
+
+	w := req("/admin/slow-requests?route=/slow/:id&limit=1")
+	if w.Code != 200 {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		SlowRequests []middleware.SlowRequest `json:"slow_requests"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.SlowRequests) != 1 {
+		t.Fatalf("slow requests = %+v", resp.SlowRequests)
+	}
+	got := resp.SlowRequests[0]
+	if got.Path != "/slow/2" || got.Route != "/slow/:id" || got.Method != "GET" || got.Status != 200 || got.TenantID != "default" || got.ThresholdMs != 30 {
+		t.Fatalf("newest slow request = %+v", got)
+	}
+	if len(got.Phases) != 1 || got.Phases[0].Name != "list_events" || got.Phases[0].DurationMs < 40 {
+		t.Fatalf("phases = %+v", got.Phases)
+	}
+	all := logger.RecentSlow("", 0)
+	if len(all) != 2 || all[1].TenantID != "t1" {
+		t.Fatalf("all slow requests = %+v", all)
+	}
+	// 不在 AccessLogger 下时 StartPhase 为空操作
+	middleware.StartPhase(auth.WithTenantID(context.Background(), "t"), "noop")()
+}
+
+func TestAccessLogger_AutoPercentileThreshold(t *testing.T) {
+	logger := middleware.NewAccessLogger(middleware.AccessLogConfig{SlowWindow: 100, SlowFloor: 20 * time.Millisecond})
+	s := server.Default(server.WithHostPorts(":0"))
+	s.Use(logger.Handler())
+	var delay time.Duration
+	s.GET("/work", func(ctx context.Context, c *app.RequestContext) {
+		time.Sleep(delay)
+		c.String(200, "ok")
+	})
+	do := func() { ut.PerformRequest(s.Engine, "GET", "/work", &ut.Body{Body: bytes.NewReader(nil), Len: 0}) }
+	delay = 2 * time.Millisecond
+	for range 20 {
+		do()
+	}
+	if n := len(logger.RecentSlow("", 0)); n != 0 {
+		t.Fatalf("steady requests flagged as slow: %d", n)
+	}
+	// p99 低于下限时取下限
+	if th := logger.Thresholds()["GET /work"]; th != 20 {
+		t.Fatalf("thresholds = %v", logger.Thresholds())
+	}
+	delay = 60 * time.Millisecond
+	do()
+	slow := logger.RecentSlow("/work", 0)
+	if len(slow) != 1 || slow[0].LatencyMs < 60 || slow[0].ThresholdMs != 20 {
+		t.Fatalf("slow = %+v", slow)
+	}
+}
+
+func TestGetSlowRequests_Disabled(t *testing.T) {
+	h := NewHandler(nil, nil)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/admin/slow-requests", h.GetSlowRequests)
+	w := ut.PerformRequest(s.Engine, "GET", "/admin/slow-requests", &ut.Body{Body: bytes.NewReader(nil), Len: 0})
+	if w.Code != 503 {
+		t.Fatalf("status = %d", w.Code)
+	}
+}
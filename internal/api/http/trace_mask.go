@@ -0,0 +1,136 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+)
+
+// TraceMaskedValue 受限查看者看到的被遮蔽字段值
+const TraceMaskedValue = "[masked]"
+
+// DefaultTraceMaskFields 默认遮蔽的事件 payload 字段：工具输入/输出与 LLM 内容；结构字段（node_id、step_id、时间、状态、耗时等）保留
+var DefaultTraceMaskFields = []string{
+	"input", "output", "result", "response", "prompt", "content", "messages",
+	"arguments", "args", "state_after", "state_changes", "payload_results", "summary", "thought",
+}
+
+// TraceMaskPolicy 视图级遮蔽策略：无 trace:view_payload 权限的查看者在 trace/replay/events 接口中看到的 payload 命中字段被替换为 TraceMaskedValue
+type TraceMaskPolicy struct {
+	fields map[string]bool
+}
+
+// NewTraceMaskPolicy 按字段名创建遮蔽策略（任意嵌套层级的同名键均遮蔽）；fields 为空时使用 DefaultTraceMaskFields
+func NewTraceMaskPolicy(fields []string) *TraceMaskPolicy {
+	if len(fields) == 0 {
+		fields = DefaultTraceMaskFields
+	}
+	p := &TraceMaskPolicy{fields: make(map[string]bool, len(fields))}
+	for _, f := range fields {
+		if f != "" {
+			p.fields[f] = true
+		}
+	}
+	return p
+}
+
+// Masks 判断字段是否被遮蔽
+func (p *TraceMaskPolicy) Masks(field string) bool {
+	return p != nil && p.fields[field]
+}
+
+// MaskPayload 返回遮蔽后的 payload；非 JSON 对象/数组的 payload 整体遮蔽
+func (p *TraceMaskPolicy) MaskPayload(payload []byte) []byte {
+	if len(payload) == 0 {
+		return payload
+	}
+	var v interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		out, _ := json.Marshal(TraceMaskedValue)
+		return out
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		out, _ := json.Marshal(TraceMaskedValue)
+		return out
+	}
+	out, err := json.Marshal(p.mask(v))
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+func (p *TraceMaskPolicy) mask(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if p.fields[k] {
+				if child != nil {
+					t[k] = TraceMaskedValue
+				}
+				continue
+			}
+			t[k] = p.mask(child)
+		}
+		return t
+	case []interface{}:
+		for i, child := range t {
+			t[i] = p.mask(child)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// MaskEvents 返回 payload 已遮蔽的事件副本（不修改入参）
+func (p *TraceMaskPolicy) MaskEvents(events []jobstore.JobEvent) []jobstore.JobEvent {
+	out := make([]jobstore.JobEvent, len(events))
+	for i, e := range events {
+		e.Payload = p.MaskPayload(e.Payload)
+		out[i] = e
+	}
+	return out
+}
+
+// SetTraceMaskPolicy 设置受限查看者的遮蔽策略；未设置时使用 DefaultTraceMaskFields
+func (h *Handler) SetTraceMaskPolicy(p *TraceMaskPolicy) {
+	h.traceMask = p
+}
+
+// traceMaskFor 返回当前查看者适用的遮蔽策略；具备 trace:view_payload 权限或处于有效调试会话时返回 nil（查看完整 payload）
+func (h *Handler) traceMaskFor(ctx context.Context) *TraceMaskPolicy {
+	if auth.HasPermission(auth.GetRole(ctx), auth.PermissionTracePayloadView) || debugSessionFrom(ctx) != nil {
+		return nil
+	}
+	if h.traceMask != nil {
+		return h.traceMask
+	}
+	return NewTraceMaskPolicy(nil)
+}
+
+// viewEvents 按查看者权限返回 trace/replay/events 接口使用的事件：受限查看者只看到结构，payload 内容被遮蔽
+func (h *Handler) viewEvents(ctx context.Context, events []jobstore.JobEvent) []jobstore.JobEvent {
+	if p := h.traceMaskFor(ctx); p != nil {
+		return p.MaskEvents(events)
+	}
+	return events
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	hertzapp "github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+)
+
+func TestTraceMaskPolicy_MaskPayload(t *testing.T) {
+	p := NewTraceMaskPolicy(nil)
+	in := []byte(`{"node_id":"n1","tool_name":"web_search","input":{"q":"secret"},"nested":{"output":"x","duration_ms":12},"items":[{"prompt":"p"}],"result":null}`)
+	var got map[string]interface{}
+	if err := json.Unmarshal(p.MaskPayload(in), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["node_id"] != "n1" || got["tool_name"] != "web_search" || got["input"] != TraceMaskedValue {
+		t.Fatalf("unexpected masked payload: %v", got)
+	}
+	nested := got["nested"].(map[string]interface{})
+	if nested["output"] != TraceMaskedValue || nested["duration_ms"] != float64(12) {
+		t.Fatalf("nested not masked: %v", nested)
+	}
+	if got["items"].([]interface{})[0].(map[string]interface{})["prompt"] != TraceMaskedValue {
+		t.Fatalf("array element not masked: %v", got["items"])
+	}
+	if got["result"] != nil {
+		t.Fatalf("null field should stay null: %v", got["result"])
+	}
+	if string(p.MaskPayload([]byte(`"raw llm text"`))) != `"[masked]"` {
+		t.Fatal("scalar payload should be masked wholesale")
+	}
+	if !NewTraceMaskPolicy([]string{"goal"}).Masks("goal") || p.Masks("goal") {
+		t.Fatal("custom fields should replace the defaults")
+	}
+}
+
+func TestGetJobEvents_MasksPayloadForRestrictedViewer(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	events := jobstore.NewMemoryStore()
+	jobID, err := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g", TenantID: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Now()
+	ver := 0
+	for _, e := range []jobstore.JobEvent{
+		narrativeEvent(t, jobstore.NodeStarted, t0, "", map[string]interface{}{"node_id": "n1"}),
+		narrativeEvent(t, jobstore.ToolCalled, t0, "", map[string]interface{}{"node_id": "n1", "tool_name": "crm.lookup", "input": map[string]interface{}{"email": "a@b.c"}}),
+		narrativeEvent(t, jobstore.ToolReturned, t0.Add(time.Second), "", map[string]interface{}{"node_id": "n1", "output": "customer record"}),
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(time.Second), "", map[string]interface{}{"node_id": "n1", "duration_ms": 1000}),
+	} {
+		e.JobID = jobID
+		if ver, err = events.Append(ctx, jobID, ver, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetJobEventStore(events)
+	withRole := func(ctx context.Context, c *hertzapp.RequestContext) {
+		c.Next(auth.WithRole(ctx, auth.Role(c.Request.Header.Get("X-Role"))))
+	}
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/events", withRole, handler.GetJobEvents)
+
+	get := func(role string) string {
+		w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/events", nil, ut.Header{Key: "X-Role", Value: role})
+		if got := w.Result().StatusCode(); got != 200 {
+			t.Fatalf("status = %d: %s", got, w.Result().Body())
+		}
+		return string(w.Result().Body())
+	}
+	viewer := get(string(auth.RoleViewer))
+	if strings.Contains(viewer, "a@b.c") || strings.Contains(viewer, "customer record") {
+		t.Fatalf("viewer saw payload content: %s", viewer)
+	}
+	if !strings.Contains(viewer, "crm.lookup") || !strings.Contains(viewer, `"duration_ms":1000`) {
+		t.Fatalf("viewer lost trace structure: %s", viewer)
+	}
+	auditor := get(string(auth.RoleAuditor))
+	if !strings.Contains(auditor, "a@b.c") || !strings.Contains(auditor, "customer record") {
+		t.Fatalf("auditor should see full payloads: %s", auditor)
+	}
+}
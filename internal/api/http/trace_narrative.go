@@ -16,8 +16,11 @@ package http
 
 import (
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
+	"rag-platform/internal/agent/planner"
 	"rag-platform/internal/runtime/jobstore"
 )
 
@@ -34,7 +37,21 @@ type TimelineSegment struct {
 	WorkerID   string     `json:"worker_id,omitempty"`
 }
 
+// StepAttempt is one execution attempt of a node step (node_started → node_finished).
+type StepAttempt struct {
+	Attempt    int        `json:"attempt"`
+	WorkerID   string     `json:"worker_id,omitempty"`
+	StartTime  *time.Time `json:"start_time,omitempty"`
+	EndTime    *time.Time `json:"end_time,omitempty"`
+	DurationMs int64      `json:"duration_ms,omitempty"`
+	State      string     `json:"state,omitempty"` // ok | failed | retryable | interrupted（无 node_finished 即被下一次 attempt 取代，如 Worker 租约丢失）
+	ResultType string     `json:"result_type,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
 // StepNarrative is the narrative view for one step (Temporal-style minimal debug unit).
+// For node steps that were retried, the top-level timing/state/worker fields describe the latest attempt and
+// AttemptHistory lists every attempt in order.
 type StepNarrative struct {
 	SpanID            string                 `json:"span_id"`
 	Type              string                 `json:"type"` // plan | node | tool
@@ -44,6 +61,7 @@ type StepNarrative struct {
 	ResultType        string                 `json:"result_type,omitempty"` // Phase A 世界语义: pure | success | side_effect_committed | retryable_failure | permanent_failure | compensatable_failure | compensated
 	Reason            string                 `json:"reason,omitempty"`
 	Attempts          int                    `json:"attempts,omitempty"`
+	AttemptHistory    []StepAttempt          `json:"attempt_history,omitempty"`
 	WorkerID          string                 `json:"worker_id,omitempty"`
 	DurationMs        int64                  `json:"duration_ms,omitempty"`
 	StartTime         *time.Time             `json:"start_time,omitempty"`
@@ -53,6 +71,7 @@ type StepNarrative struct {
 	LLMInvocation     *LLMInvocationSummary  `json:"llm_invocation,omitempty"` // LLM 调用元数据（model/provider/temperature），供审计与 trace
 	StateDiff         *StateDiff             `json:"state_diff,omitempty"`
 	ReasoningSnapshot json.RawMessage        `json:"reasoning_snapshot,omitempty"` // 该步的推理快照，供因果调试
+	Rationale         string                 `json:"rationale,omitempty"`          // planner's reason for this node (why this tool, why this order), from PlanGenerated
 	Evidence          interface{}            `json:"evidence,omitempty"`           // 决策依据（Evidence Graph）：rag_doc_ids、tool_invocation_ids 等，来自 reasoning_snapshot
 }
 
@@ -121,6 +140,8 @@ func BuildNarrative(events []jobstore.JobEvent) *Narrative {
 	// step index by node_id for attaching reasoning/tool/state to the right step
 	spanToStepIndex := make(map[string]int)
 	var stepIndex int
+	// node_id -> planner rationale from the latest PlanGenerated
+	rationale := make(map[string]string)
 
 	for _, e := range events {
 		var pl map[string]interface{}
@@ -180,6 +201,9 @@ func BuildNarrative(events []jobstore.JobEvent) *Narrative {
 			})
 			spanToStepIndex["plan"] = len(out.Steps) - 1
 			stepIndex++
+			for _, r := range planRationalesOf(e.Payload) {
+				rationale[r.NodeID] = r.Rationale
+			}
 
 		case jobstore.NodeStarted:
 			nodeID := getStr("node_id")
@@ -188,29 +212,59 @@ func BuildNarrative(events []jobstore.JobEvent) *Narrative {
 			}
 			nodeStartTime[nodeID] = e.CreatedAt
 			nodeStartPayload[nodeID] = pl
-			seg := TimelineSegment{
-				Type:      "node",
-				Label:     "Node " + nodeID,
-				NodeID:    nodeID,
-				StartTime: ptrTime(e.CreatedAt),
-				Attempt:   getInt("attempt"),
-				WorkerID:  getStr("worker_id"),
+			workerID := getStr("worker_id")
+			if workerID == "" {
+				workerID = workerIDFromActor(e.Actor)
 			}
-			if seg.Attempt == 0 {
-				seg.Attempt = 1
+			// 同一节点再次开始即为重试：复用该节点的 step，追加一条 attempt 记录
+			idx, retried := spanToStepIndex[nodeID]
+			retried = retried && idx < len(out.Steps) && out.Steps[idx].Type == "node"
+			attempt := getInt("attempt")
+			if attempt == 0 {
+				attempt = 1
+				if retried {
+					attempt = len(out.Steps[idx].AttemptHistory) + 1
+				}
 			}
-			out.TimelineSegments = append(out.TimelineSegments, seg)
-			out.Steps = append(out.Steps, StepNarrative{
-				SpanID:    nodeID,
+			label := "Node " + nodeID
+			if attempt > 1 {
+				label += " #" + strconv.Itoa(attempt)
+			}
+			out.TimelineSegments = append(out.TimelineSegments, TimelineSegment{
 				Type:      "node",
-				Label:     "Node " + nodeID,
+				Label:     label,
 				NodeID:    nodeID,
-				Attempts:  seg.Attempt,
-				WorkerID:  seg.WorkerID,
-				StartTime: &e.CreatedAt,
+				StartTime: ptrTime(e.CreatedAt),
+				Attempt:   attempt,
+				WorkerID:  workerID,
 			})
-			spanToStepIndex[nodeID] = len(out.Steps) - 1
-			stepIndex++
+			if !retried {
+				out.Steps = append(out.Steps, StepNarrative{
+					SpanID:    nodeID,
+					Type:      "node",
+					Label:     "Node " + nodeID,
+					NodeID:    nodeID,
+					Rationale: rationale[nodeID],
+				})
+				idx = len(out.Steps) - 1
+				spanToStepIndex[nodeID] = idx
+				stepIndex++
+			}
+			step := &out.Steps[idx]
+			if n := len(step.AttemptHistory); n > 0 && step.AttemptHistory[n-1].EndTime == nil {
+				step.AttemptHistory[n-1].State = "interrupted"
+			}
+			step.AttemptHistory = append(step.AttemptHistory, StepAttempt{
+				Attempt:   attempt,
+				WorkerID:  workerID,
+				StartTime: ptrTime(e.CreatedAt),
+			})
+			step.Attempts = max(attempt, len(step.AttemptHistory))
+			step.WorkerID = workerID
+			step.StartTime = ptrTime(e.CreatedAt)
+			step.EndTime = nil
+			step.DurationMs = 0
+			step.State, step.ResultType, step.Reason = "", "", ""
 
 		case jobstore.NodeFinished:
 			nodeID := getStr("node_id")
@@ -245,12 +299,21 @@ func BuildNarrative(events []jobstore.JobEvent) *Narrative {
 				}
 			}
 			if idx, ok := spanToStepIndex[nodeID]; ok && idx < len(out.Steps) {
-				out.Steps[idx].EndTime = &endAt
-				out.Steps[idx].DurationMs = durMs
-				out.Steps[idx].State = state
-				out.Steps[idx].ResultType = resultType
-				out.Steps[idx].Reason = reason
-				out.Steps[idx].Attempts = attempt
+				step := &out.Steps[idx]
+				step.EndTime = &endAt
+				step.DurationMs = durMs
+				step.State = state
+				step.ResultType = resultType
+				step.Reason = reason
+				step.Attempts = max(attempt, len(step.AttemptHistory))
+				if n := len(step.AttemptHistory); n > 0 && step.AttemptHistory[n-1].EndTime == nil {
+					a := &step.AttemptHistory[n-1]
+					a.EndTime = &endAt
+					a.DurationMs = durMs
+					a.State = state
+					a.ResultType = resultType
+					a.Reason = reason
+				}
 			}
 			delete(nodeStartTime, nodeID)
 			delete(nodeStartPayload, nodeID)
@@ -509,6 +572,18 @@ func BuildNarrative(events []jobstore.JobEvent) *Narrative {
 	return out
 }
 
+// workerIDFromActor extracts the worker ID from an event actor ("worker:<worker_id>[@<service_account_id>]").
+func workerIDFromActor(actor string) string {
+	id, ok := strings.CutPrefix(actor, "worker:")
+	if !ok {
+		return ""
+	}
+	if i := strings.Index(id, "@"); i >= 0 {
+		id = id[:i]
+	}
+	return id
+}
+
 func ptrTime(t time.Time) *time.Time {
 	return &t
 }
@@ -604,3 +679,18 @@ func jsonEqual(a, b interface{}) bool {
 	jb, _ := json.Marshal(b)
 	return string(ja) == string(jb)
 }
+
+// planRationalesOf returns the per-node rationale of a PlanGenerated payload (nil if the plan cannot be decoded).
+func planRationalesOf(payload []byte) []planner.NodeRationale {
+	var pl struct {
+		TaskGraph json.RawMessage `json:"task_graph"`
+	}
+	if err := json.Unmarshal(payload, &pl); err != nil || len(pl.TaskGraph) == 0 {
+		return nil
+	}
+	var g planner.TaskGraph
+	if err := g.Unmarshal(pl.TaskGraph); err != nil {
+		return nil
+	}
+	return g.Rationales()
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func narrativeEvent(t *testing.T, typ jobstore.EventType, at time.Time, actor string, payload map[string]interface{}) jobstore.JobEvent {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobstore.JobEvent{Type: typ, CreatedAt: at, Actor: actor, Payload: b}
+}
+
+func TestBuildNarrative_AggregatesAttempts(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	events := []jobstore.JobEvent{
+		narrativeEvent(t, jobstore.PlanGenerated, t0, "", map[string]interface{}{}),
+		// attempt 1：worker-a 执行后可重试失败
+		narrativeEvent(t, jobstore.NodeStarted, t0.Add(time.Second), "", map[string]interface{}{"node_id": "n1", "attempt": 1, "worker_id": "worker-a"}),
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(3*time.Second), "", map[string]interface{}{"node_id": "n1", "attempt": 1, "state": "retryable", "result_type": "retryable_failure", "reason": "timeout"}),
+		// attempt 2：worker-b 开始后丢失租约，没有 node_finished；worker 取自事件 actor
+		narrativeEvent(t, jobstore.NodeStarted, t0.Add(4*time.Second), "worker:worker-b@sa-1", map[string]interface{}{"node_id": "n1"}),
+		// attempt 3：worker-a 成功
+		narrativeEvent(t, jobstore.NodeStarted, t0.Add(10*time.Second), "", map[string]interface{}{"node_id": "n1", "attempt": 3, "worker_id": "worker-a"}),
+		narrativeEvent(t, jobstore.NodeFinished, t0.Add(11*time.Second), "", map[string]interface{}{"node_id": "n1", "attempt": 3, "duration_ms": 900, "result_type": "success"}),
+	}
+	n := BuildNarrative(events)
+
+	if len(n.Steps) != 2 {
+		t.Fatalf("steps = %d, want plan + one aggregated node step", len(n.Steps))
+	}
+	step := n.Steps[1]
+	if step.Attempts != 3 || len(step.AttemptHistory) != 3 {
+		t.Fatalf("attempts = %d, history = %d, want 3/3", step.Attempts, len(step.AttemptHistory))
+	}
+	if step.State != "ok" || step.WorkerID != "worker-a" || step.DurationMs != 900 {
+		t.Fatalf("step should describe latest attempt, got state=%q worker=%q duration=%d", step.State, step.WorkerID, step.DurationMs)
+	}
+	h := step.AttemptHistory
+	if h[0].WorkerID != "worker-a" || h[0].State != "retryable" || h[0].Reason != "timeout" || h[0].DurationMs != 2000 {
+		t.Fatalf("attempt 1 = %+v", h[0])
+	}
+	if h[1].Attempt != 2 || h[1].WorkerID != "worker-b" || h[1].State != "interrupted" || h[1].EndTime != nil {
+		t.Fatalf("attempt 2 = %+v", h[1])
+	}
+	if h[2].Attempt != 3 || h[2].State != "ok" || h[2].DurationMs != 900 {
+		t.Fatalf("attempt 3 = %+v", h[2])
+	}
+
+	var nodeSegs int
+	for _, seg := range n.TimelineSegments {
+		if seg.Type == "node" {
+			nodeSegs++
+		}
+	}
+	if nodeSegs != 3 {
+		t.Fatalf("timeline node segments = %d, want one per attempt", nodeSegs)
+	}
+
+	page := RenderTraceHTML("job-1", "goal", "completed", events, TraceHTMLOptions{})
+	if !strings.Contains(page, `id="detail-attempts"`) || !strings.Contains(page, "attempt 3") {
+		t.Fatal("trace page should render attempt history panel and attempt count")
+	}
+}
+
+func TestBuildNarrative_PlanRationale(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	graph := map[string]interface{}{
+		"nodes": []map[string]interface{}{
+			{"id": "n1", "type": "tool", "tool_name": "knowledge.search", "rationale": "先检索文档作为回答依据"},
+			{"id": "n2", "type": "llm"},
+		},
+	}
+	events := []jobstore.JobEvent{
+		narrativeEvent(t, jobstore.PlanGenerated, t0, "", map[string]interface{}{"task_graph": graph}),
+		narrativeEvent(t, jobstore.NodeStarted, t0.Add(time.Second), "", map[string]interface{}{"node_id": "n1"}),
+		narrativeEvent(t, jobstore.NodeStarted, t0.Add(2*time.Second), "", map[string]interface{}{"node_id": "n2"}),
+	}
+	n := BuildNarrative(events)
+	if len(n.Steps) != 3 || n.Steps[1].Rationale != "先检索文档作为回答依据" || n.Steps[2].Rationale != "" {
+		t.Fatalf("steps = %+v", n.Steps)
+	}
+	page := RenderTraceHTML("job-1", "goal", "running", events, TraceHTMLOptions{})
+	if !strings.Contains(page, "Why planned") || !strings.Contains(page, "先检索文档作为回答依据") {
+		t.Fatal("trace page should show the node rationale in the step detail panel")
+	}
+}
+
+func TestRenderTraceHTML_Hierarchy(t *testing.T) {
+	hier := &job.JobHierarchy{
+		Parent: &job.ChildJob{ParentJobID: "job-sup", NodeID: "s1", Key: "a"},
+		Children: []job.HierarchyNode{
+			{JobID: "job-c1", Key: "research", NodeID: "s2", Attempt: 2, Status: "completed"},
+		},
+		Rollup: &job.ChildRollup{Total: 1, Completed: 1, Status: "completed"},
+	}
+	page := RenderTraceHTML("job-1", "goal", "running", nil, TraceHTMLOptions{Hierarchy: hier})
+	if !strings.Contains(page, `id="trace-hierarchy"`) || !strings.Contains(page, `href="/api/jobs/job-sup/trace/page"`) || !strings.Contains(page, `href="/api/jobs/job-c1/trace/page"`) {
+		t.Fatal("trace page should link to supervisor and child traces")
+	}
+	if !strings.Contains(page, "1/1") {
+		t.Fatal("trace page should render child rollup")
+	}
+	offline := RenderTraceHTML("job-1", "goal", "running", nil, TraceHTMLOptions{Hierarchy: hier, Offline: true})
+	if strings.Contains(offline, "/api/jobs/job-c1") {
+		t.Fatal("offline trace page should not link to API")
+	}
+}
+
+func TestRenderTraceHTML_Diagnosis(t *testing.T) {
+	events := []jobstore.JobEvent{
+		{Type: jobstore.JobFailed, Payload: []byte(`{"error":"boom"}`)},
+		{Type: jobstore.JobDiagnosis, Payload: []byte(`{"probable_cause":"API key <expired>","suggested_fix":"rotate the key","retry_likely_to_help":true}`)},
+	}
+	page := RenderTraceHTML("job-1", "goal", "failed", events, TraceHTMLOptions{})
+	if !strings.Contains(page, `id="trace-diagnosis"`) || !strings.Contains(page, "API key &lt;expired&gt;") || !strings.Contains(page, "rotate the key") {
+		t.Fatal("trace page should render escaped diagnosis in header")
+	}
+	if strings.Contains(RenderTraceHTML("job-1", "goal", "failed", events[:1], TraceHTMLOptions{}), `id="trace-diagnosis"`) {
+		t.Fatal("trace page without job_diagnosis should not render diagnosis")
+	}
+}
+
+func TestRenderTraceHTML_CustomEvents(t *testing.T) {
+	events := []jobstore.JobEvent{
+		{Type: jobstore.NodeStarted, Payload: []byte(`{"node_id":"n1"}`)},
+		{Type: jobstore.CustomEvent, Payload: []byte(`{"name":"billing.invoice_drafted","node_id":"n1","data":{"note":"<draft>"}}`)},
+	}
+	page := RenderTraceHTML("job-1", "goal", "running", events, TraceHTMLOptions{})
+	if !strings.Contains(page, `id="trace-milestones"`) || !strings.Contains(page, "billing.invoice_drafted") || !strings.Contains(page, "&lt;draft&gt;") {
+		t.Fatal("trace page should render escaped custom events as milestones")
+	}
+	if strings.Contains(RenderTraceHTML("job-1", "goal", "running", events[:1], TraceHTMLOptions{}), `id="trace-milestones"`) {
+		t.Fatal("trace page without custom_event should not render milestones")
+	}
+}
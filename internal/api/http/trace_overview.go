@@ -0,0 +1,321 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/tracefilter"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/i18n"
+)
+
+const maxTracePresetName = 64
+
+// GetTraceOverview GET /api/trace/overview：Trace 概览的 JSON 数据源，服务端分页（created_at 倒序键集分页）。
+// 参数 agent_ids（逗号分隔，空为本租户全部 Agent）、status（逗号分隔）、from/to（RFC3339）或 window（如 24h）、limit（默认 50，最多 200）、cursor（上一页的 next_cursor）、
+// preset（已保存的筛选名，显式参数覆盖其中的同名条件）
+func (h *Handler) GetTraceOverview(ctx context.Context, c *app.RequestContext) {
+	if h.jobStore == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "job.store_disabled")})
+		return
+	}
+	var base tracefilter.Filters
+	if name := c.Query("preset"); name != "" {
+		p, ok := h.findTracePreset(ctx, c, name)
+		if !ok {
+			return
+		}
+		base = p.Filters
+	}
+	filters := traceOverviewFilters(c, base)
+	if key := validateTraceFilters(filters); key != "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, key)})
+		return
+	}
+	q := pageQueryOf(filters, time.Now())
+	q.TenantID = auth.GetTenantID(ctx)
+	q.Cursor = c.Query("cursor")
+	if s := c.Query("limit"); s != "" {
+		q.Limit, _ = strconv.Atoi(s)
+	}
+	page, err := h.listJobPage(ctx, q)
+	if errors.Is(err, job.ErrInvalidPageCursor) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.invalid_cursor")})
+		return
+	}
+	if errors.Is(err, errAgentIDsRequired) {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "trace.overview.agent_required")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListJobPage: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "job.list_failed")})
+		return
+	}
+	maskGoal := h.traceMaskFor(ctx).Masks("goal")
+	jobs := make([]map[string]interface{}, 0, len(page.Jobs))
+	for _, j := range page.Jobs {
+		goal := j.Goal
+		if maskGoal && goal != "" {
+			goal = TraceMaskedValue
+		}
+		item := map[string]interface{}{
+			"id":         j.ID,
+			"agent_id":   j.AgentID,
+			"status":     j.Status.String(),
+			"goal":       goal,
+			"created_at": j.CreatedAt,
+			"updated_at": j.UpdatedAt,
+		}
+		if j.Terminal != nil {
+			item["terminal_info"] = j.Terminal
+		}
+		jobs = append(jobs, item)
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"jobs":        jobs,
+		"next_cursor": page.NextCursor,
+		"filters":     filters,
+	})
+}
+
+var errAgentIDsRequired = errors.New("agent_ids required")
+
+// listJobPage 优先使用 job.PageLister；未实现时按 Agent 逐个列出后在内存分页（此时必须指定 agent_ids）
+func (h *Handler) listJobPage(ctx context.Context, q job.PageQuery) (*job.Page, error) {
+	if pl, ok := h.jobStore.(job.PageLister); ok {
+		return pl.ListPage(ctx, q)
+	}
+	if len(q.AgentIDs) == 0 {
+		return nil, errAgentIDsRequired
+	}
+	var all []*job.Job
+	for _, agentID := range q.AgentIDs {
+		list, err := h.jobStore.ListByAgent(ctx, agentID, q.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+	return job.PageOf(all, q)
+}
+
+// traceOverviewFilters 以 base（已保存的预设）为底，叠加请求中显式给出的筛选参数
+func traceOverviewFilters(c *app.RequestContext, base tracefilter.Filters) tracefilter.Filters {
+	f := base
+	agentIDs := c.Query("agent_ids")
+	if agentIDs == "" {
+		agentIDs = c.Query("agent_id")
+	}
+	if agentIDs != "" {
+		f.AgentIDs = splitCSV(agentIDs)
+	}
+	if s := c.Query("status"); s != "" {
+		f.Statuses = splitCSV(s)
+	}
+	if s := c.Query("window"); s != "" {
+		f.Window = s
+		f.From, f.To = nil, nil
+	}
+	if s := c.Query("from"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t = time.Time{}
+		}
+		f.From = &t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t = time.Time{}
+		}
+		f.To = &t
+	}
+	return f
+}
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// validateTraceFilters 校验筛选条件；返回 i18n 错误键，合法时为空
+func validateTraceFilters(f tracefilter.Filters) string {
+	for _, s := range f.Statuses {
+		if _, ok := job.ParseStatus(s); !ok {
+			return "trace.overview.invalid_status"
+		}
+	}
+	if f.Window != "" {
+		if d, err := time.ParseDuration(f.Window); err != nil || d <= 0 {
+			return "request.window_invalid"
+		}
+	}
+	if (f.From != nil && f.From.IsZero()) || (f.To != nil && f.To.IsZero()) {
+		return "trace.overview.invalid_time"
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return "trace.overview.invalid_time"
+	}
+	return ""
+}
+
+// pageQueryOf 将已校验的筛选条件转为分页查询；From/To 优先于 Window
+func pageQueryOf(f tracefilter.Filters, now time.Time) job.PageQuery {
+	q := job.PageQuery{AgentIDs: f.AgentIDs}
+	for _, s := range f.Statuses {
+		st, _ := job.ParseStatus(s)
+		q.Statuses = append(q.Statuses, st)
+	}
+	if f.From != nil {
+		q.From = *f.From
+	}
+	if f.To != nil {
+		q.To = *f.To
+	}
+	if f.From == nil && f.To == nil && f.Window != "" {
+		d, _ := time.ParseDuration(f.Window)
+		q.From = now.Add(-d)
+	}
+	return q
+}
+
+func (h *Handler) findTracePreset(ctx context.Context, c *app.RequestContext, name string) (*tracefilter.Preset, bool) {
+	if h.traceFilters == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_disabled")})
+		return nil, false
+	}
+	list, err := h.traceFilters.List(ctx, auth.GetTenantID(ctx), auth.GetUserID(ctx))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListTraceFilterPresets: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_failed")})
+		return nil, false
+	}
+	for _, p := range list {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "trace.overview.preset_not_found")})
+	return nil, false
+}
+
+// ListTraceFilterPresets GET /api/trace/overview/presets：当前用户保存的 Trace 概览筛选
+func (h *Handler) ListTraceFilterPresets(ctx context.Context, c *app.RequestContext) {
+	if h.traceFilters == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_disabled")})
+		return
+	}
+	list, err := h.traceFilters.List(ctx, auth.GetTenantID(ctx), auth.GetUserID(ctx))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListTraceFilterPresets: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_failed")})
+		return
+	}
+	if list == nil {
+		list = []*tracefilter.Preset{}
+	}
+	c.JSON(consts.StatusOK, map[string]interface{}{"presets": list})
+}
+
+// PutTraceFilterPreset PUT /api/trace/overview/presets/:name：保存（覆盖）当前用户的筛选预设，body 为 tracefilter.Filters
+func (h *Handler) PutTraceFilterPreset(ctx context.Context, c *app.RequestContext) {
+	if h.traceFilters == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_disabled")})
+		return
+	}
+	name := strings.TrimSpace(c.Param("name"))
+	if name == "" || utf8.RuneCountInString(name) > maxTracePresetName {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.name_required")})
+		return
+	}
+	var f tracefilter.Filters
+	if err := c.BindJSON(&f); err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "request.invalid")})
+		return
+	}
+	if key := validateTraceFilters(f); key != "" {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, key)})
+		return
+	}
+	p := &tracefilter.Preset{TenantID: auth.GetTenantID(ctx), UserID: auth.GetUserID(ctx), Name: name, Filters: f}
+	if err := h.traceFilters.Put(ctx, p); err != nil {
+		hlog.CtxErrorf(ctx, "PutTraceFilterPreset: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, p)
+}
+
+// DeleteTraceFilterPreset DELETE /api/trace/overview/presets/:name
+func (h *Handler) DeleteTraceFilterPreset(ctx context.Context, c *app.RequestContext) {
+	if h.traceFilters == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_disabled")})
+		return
+	}
+	err := h.traceFilters.Delete(ctx, auth.GetTenantID(ctx), auth.GetUserID(ctx), c.Param("name"))
+	if errors.Is(err, tracefilter.ErrNotFound) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "trace.overview.preset_not_found")})
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "DeleteTraceFilterPreset: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "trace.overview.presets_failed")})
+		return
+	}
+	c.JSON(consts.StatusOK, map[string]string{"name": c.Param("name"), "status": "deleted"})
+}
+
+// traceOverviewStatuses Trace 概览页可勾选的状态
+var traceOverviewStatuses = []string{"pending", "running", "waiting", "parked", "deferred", "completed", "failed", "cancelled"}
+
+// traceOverviewScript Trace 概览页脚本：按筛选条件请求 /api/trace/overview，「加载更多」按 next_cursor 追加下一页；已保存筛选经 /api/trace/overview/presets 读写
+const traceOverviewScript = `<script>(function(){
+function esc(s){ return String(s||'').replace(/[&<>"]/g,function(c){ return ({'&':'&amp;','<':'&lt;','>':'&gt;','"':'&quot;'}[c]); }); }
+function el(id){ return document.getElementById(id); }
+var cursor='', presets={};
+function statusBoxes(){ return [].slice.call(document.querySelectorAll('.q-status')); }
+function toISO(v){ return v ? new Date(v).toISOString() : ''; }
+function toLocal(v){ if(!v){ return ''; } var d=new Date(v); d.setMinutes(d.getMinutes()-d.getTimezoneOffset()); return d.toISOString().slice(0,16); }
+function filters(){ var f={agent_ids:el('agent_ids').value.split(',').map(function(s){ return s.trim(); }).filter(Boolean), statuses:statusBoxes().filter(function(x){ return x.checked; }).map(function(x){ return x.value; })}; var w=el('q_window').value.trim(), from=toISO(el('q_from').value), to=toISO(el('q_to').value); if(w){ f.window=w; } if(from){ f.from=from; } if(to){ f.to=to; } return f; }
+function apply(f){ f=f||{}; el('agent_ids').value=(f.agent_ids||[]).join(','); var st=f.statuses||[]; statusBoxes().forEach(function(x){ x.checked=st.indexOf(x.value)>=0; }); el('q_window').value=f.window||''; el('q_from').value=toLocal(f.from); el('q_to').value=toLocal(f.to); }
+function query(f){ var p=[]; if(f.agent_ids.length){ p.push('agent_ids='+encodeURIComponent(f.agent_ids.join(','))); } if(f.statuses.length){ p.push('status='+encodeURIComponent(f.statuses.join(','))); } ['window','from','to'].forEach(function(k){ if(f[k]){ p.push(k+'='+encodeURIComponent(f[k])); } }); if(cursor){ p.push('cursor='+encodeURIComponent(cursor)); } return p.join('&'); }
+function json(r){ return r.ok ? r.json() : r.json().then(function(e){ throw new Error(e.error||('HTTP '+r.status)); }); }
+function row(j){ return '<tr><td>'+esc(j.id)+'</td><td>'+esc(j.agent_id)+'</td><td>'+esc(j.status)+'</td><td>'+esc(j.created_at)+'</td><td>'+esc(j.updated_at)+'</td><td>'+esc(j.goal)+'</td><td><a href="/api/jobs/'+encodeURIComponent(j.id)+'/trace/page" target="_blank">open trace</a></td></tr>'; }
+function load(more){ var rows=el('rows'); if(!more){ cursor=''; rows.innerHTML=''; } el('status').textContent='Loading...'; fetch('/api/trace/overview?'+query(filters())).then(json).then(function(d){ rows.insertAdjacentHTML('beforeend',(d.jobs||[]).map(row).join('')); cursor=d.next_cursor||''; el('more').style.display=cursor?'':'none'; var n=rows.children.length; el('status').textContent=n===0?'No jobs':(n+(cursor?'+':'')+' jobs'); }).catch(function(e){ el('status').textContent=String(e); el('more').style.display='none'; }); }
+function loadPresets(selected){ fetch('/api/trace/overview/presets').then(json).then(function(d){ presets={}; var html='<option value=""></option>'; (d.presets||[]).forEach(function(p){ presets[p.name]=p.filters; html+='<option value="'+esc(p.name)+'">'+esc(p.name)+'</option>'; }); el('q_preset').innerHTML=html; el('q_preset').value=selected||''; }).catch(function(){}); }
+el('q_preset').addEventListener('change', function(){ var name=el('q_preset').value; if(name&&presets[name]){ apply(presets[name]); el('q_name').value=name; load(false); } });
+el('q_save').addEventListener('click', function(){ var name=el('q_name').value.trim()||el('q_preset').value; if(!name){ el('q_name').focus(); return; } fetch('/api/trace/overview/presets/'+encodeURIComponent(name),{method:'PUT',headers:{'Content-Type':'application/json'},body:JSON.stringify(filters())}).then(json).then(function(){ loadPresets(name); }).catch(function(e){ el('status').textContent=String(e); }); });
+el('q_delete').addEventListener('click', function(){ var name=el('q_preset').value; if(!name){ return; } fetch('/api/trace/overview/presets/'+encodeURIComponent(name),{method:'DELETE'}).then(json).then(function(){ loadPresets(''); }).catch(function(e){ el('status').textContent=String(e); }); });
+el('q').addEventListener('submit', function(e){ e.preventDefault(); load(false); });
+el('more').addEventListener('click', function(){ load(true); });
+loadPresets(''); load(false);
+})();</script>`
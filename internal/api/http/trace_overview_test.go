@@ -0,0 +1,101 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/tracefilter"
+)
+
+type traceOverviewResp struct {
+	Jobs []struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	} `json:"jobs"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestGetTraceOverview_PaginationAndPresets(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	for i := 0; i < 5; i++ {
+		if _, err := jobs.Create(ctx, &job.Job{ID: fmt.Sprintf("job-%d", i), AgentID: "a1", TenantID: "default", Goal: "g"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = jobs.UpdateStatus(ctx, "job-3", job.StatusFailed)
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	handler.SetTraceFilters(tracefilter.NewStoreMem())
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/trace/overview", handler.GetTraceOverview)
+	s.PUT("/api/trace/overview/presets/:name", handler.PutTraceFilterPreset)
+
+	get := func(path string) (int, traceOverviewResp) {
+		w := ut.PerformRequest(s.Engine, "GET", path, nil)
+		var out traceOverviewResp
+		_ = json.Unmarshal(w.Result().Body(), &out)
+		return w.Result().StatusCode(), out
+	}
+
+	seen := map[string]bool{}
+	path := "/api/trace/overview?agent_ids=a1&limit=2"
+	for pages := 0; ; pages++ {
+		code, out := get(path)
+		if code != 200 || pages > 3 {
+			t.Fatalf("status = %d pages = %d", code, pages)
+		}
+		for _, j := range out.Jobs {
+			seen[j.ID] = true
+		}
+		if out.NextCursor == "" {
+			break
+		}
+		path = "/api/trace/overview?agent_ids=a1&limit=2&cursor=" + out.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("paged jobs = %v", seen)
+	}
+
+	body, _ := json.Marshal(tracefilter.Filters{AgentIDs: []string{"a1"}, Statuses: []string{"failed"}, Window: "1h"})
+	w := ut.PerformRequest(s.Engine, "PUT", "/api/trace/overview/presets/failures", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("save preset status = %d: %s", got, w.Result().Body())
+	}
+	code, out := get("/api/trace/overview?preset=failures")
+	if code != 200 || len(out.Jobs) != 1 || out.Jobs[0].ID != "job-3" {
+		t.Fatalf("preset overview = %d %+v", code, out)
+	}
+	// 显式参数覆盖预设
+	if code, out = get("/api/trace/overview?preset=failures&status=pending"); code != 200 || len(out.Jobs) != 4 {
+		t.Fatalf("override overview = %d %+v", code, out)
+	}
+
+	for _, bad := range []string{"?status=bogus", "?from=yesterday", "?cursor=%%%", "?preset=missing"} {
+		if code, _ := get("/api/trace/overview" + bad); code != 400 && code != 404 {
+			t.Fatalf("%s status = %d", bad, code)
+		}
+	}
+}
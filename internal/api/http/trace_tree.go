@@ -360,3 +360,43 @@ func renderNodeHTML(n *ExecutionNode, depth int) string {
 	out += "</li>"
 	return out
 }
+
+// DAGGroup is a bounding box around DAG nodes (a transactional tool group) in the Execution DAG view.
+type DAGGroup struct {
+	ID      string   `json:"id"`
+	Label   string   `json:"label"`
+	Status  string   `json:"status"`  // open | committed | rolled_back
+	Members []string `json:"members"` // DAGNode ids (member node spans and their tool spans)
+}
+
+// DAGGroups maps transaction summaries onto the span ids produced by DAGNodesAndEdges.
+func DAGGroups(root *ExecutionNode, txs []jobstore.TransactionSummary) []DAGGroup {
+	if root == nil || len(txs) == 0 {
+		return nil
+	}
+	spans := make(map[string][]string)
+	var collect func(n *ExecutionNode, nodeID string)
+	collect = func(n *ExecutionNode, nodeID string) {
+		if n.Type == "node" && n.NodeID != "" {
+			nodeID = n.NodeID
+		}
+		if nodeID != "" && n.Type != "job" && n.Type != "plan" {
+			spans[nodeID] = append(spans[nodeID], n.SpanID)
+		}
+		for _, c := range n.Children {
+			collect(c, nodeID)
+		}
+	}
+	collect(root, "")
+	groups := make([]DAGGroup, 0, len(txs))
+	for _, tx := range txs {
+		g := DAGGroup{ID: tx.TransactionID, Label: "Transaction " + tx.TransactionID, Status: tx.Status}
+		for _, m := range tx.Members {
+			g.Members = append(g.Members, spans[m]...)
+		}
+		if len(g.Members) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
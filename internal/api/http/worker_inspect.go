@@ -0,0 +1,91 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/pkg/i18n"
+)
+
+const (
+	workerInspectTimeout  = 5 * time.Second
+	maxWorkerInspectBytes = 1 << 20
+)
+
+var workerInspectClient = &nethttp.Client{Timeout: workerInspectTimeout}
+
+// InspectWorker GET /api/system/workers/:id/inspect：代理到 Worker 上报的自省端点（worker.inspect），
+// 返回当前认领、执行中的 step 及耗时、租约剩余时间、最近错误与限流器占用
+func (h *Handler) InspectWorker(ctx context.Context, c *app.RequestContext) {
+	workerID := c.Param("id")
+	if h.workerStatus == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "worker.inspect_disabled")})
+		return
+	}
+	statuses, err := h.workerStatus.List(ctx, time.Now().Add(-workerStatusTTL))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "ListWorkerStatus: %v", err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "worker.list_failed")})
+		return
+	}
+	inspectURL := ""
+	found := false
+	for _, st := range statuses {
+		if st.WorkerID == workerID {
+			found = true
+			inspectURL = st.InspectURL
+			break
+		}
+	}
+	if !found {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "worker.not_found")})
+		return
+	}
+	if inspectURL == "" {
+		c.JSON(consts.StatusNotImplemented, map[string]string{"error": i18n.T(ctx, "worker.inspect_disabled")})
+		return
+	}
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, inspectURL, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "InspectWorker %s: %v", workerID, err)
+		c.JSON(consts.StatusBadGateway, map[string]string{"error": i18n.T(ctx, "worker.inspect_failed")})
+		return
+	}
+	if h.workerInspectToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.workerInspectToken)
+	}
+	resp, err := workerInspectClient.Do(req)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "InspectWorker %s: %v", workerID, err)
+		c.JSON(consts.StatusBadGateway, map[string]string{"error": i18n.T(ctx, "worker.inspect_failed")})
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWorkerInspectBytes))
+	if err != nil || resp.StatusCode != nethttp.StatusOK {
+		hlog.CtxErrorf(ctx, "InspectWorker %s: status=%d err=%v", workerID, resp.StatusCode, err)
+		c.JSON(consts.StatusBadGateway, map[string]string{"error": i18n.T(ctx, "worker.inspect_failed")})
+		return
+	}
+	c.Data(consts.StatusOK, "application/json", body)
+}
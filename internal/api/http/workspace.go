@@ -0,0 +1,103 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+
+	"rag-platform/internal/agent/workspace"
+	"rag-platform/pkg/agent/sdk"
+	"rag-platform/pkg/i18n"
+)
+
+// GetJobWorkspace 列出 Job 工作区中的文件（路径、大小、修改时间与 workspace:// 引用）及配额使用
+// GET /api/jobs/:id/workspace
+func (h *Handler) GetJobWorkspace(ctx context.Context, c *app.RequestContext) {
+	if h.workspaces == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "workspace.disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	ws := h.workspaces.For(jobID)
+	files, err := ws.List(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "workspace List %s: %v", jobID, err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "workspace.list_failed")})
+		return
+	}
+	var used int64
+	for _, f := range files {
+		used += f.Size
+	}
+	_, quota, _ := ws.Usage(ctx)
+	c.JSON(consts.StatusOK, map[string]interface{}{
+		"job_id":      jobID,
+		"backend":     h.workspaces.Backend(),
+		"used_bytes":  used,
+		"quota_bytes": quota,
+		"files":       files,
+	})
+}
+
+// GetJobWorkspaceFile 下载 Job 工作区中的文件
+// GET /api/jobs/:id/workspace/files/*path
+func (h *Handler) GetJobWorkspaceFile(ctx context.Context, c *app.RequestContext) {
+	if h.workspaces == nil {
+		c.JSON(consts.StatusServiceUnavailable, map[string]string{"error": i18n.T(ctx, "workspace.disabled")})
+		return
+	}
+	jobID := c.Param("id")
+	if _, ok := h.getJobAndCheckTenant(ctx, c, jobID); !ok {
+		return
+	}
+	rel, err := workspace.CleanPath(c.Param("path"))
+	if err != nil {
+		c.JSON(consts.StatusBadRequest, map[string]string{"error": i18n.T(ctx, "workspace.invalid_path")})
+		return
+	}
+	rc, err := h.workspaces.For(jobID).Open(ctx, rel)
+	if errors.Is(err, sdk.ErrWorkspaceFileNotFound) {
+		c.JSON(consts.StatusNotFound, map[string]string{"error": i18n.T(ctx, "workspace.file_not_found")})
+		return
+	}
+	var data []byte
+	if err == nil {
+		data, err = io.ReadAll(rc)
+		rc.Close()
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "workspace Open %s/%s: %v", jobID, rel, err)
+		c.JSON(consts.StatusInternalServerError, map[string]string{"error": i18n.T(ctx, "workspace.read_failed")})
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(rel))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(rel)))
+	c.Data(consts.StatusOK, contentType, data)
+}
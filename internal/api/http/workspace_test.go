@@ -0,0 +1,82 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/workspace"
+)
+
+func TestGetJobWorkspace(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	jobID, err := jobs.Create(ctx, &job.Job{AgentID: "a1", Goal: "g"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := workspace.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := workspace.NewManager(store, workspace.Options{Backend: "local"})
+	if _, err := m.For(jobID).WriteFile(ctx, "out/report.md", []byte("# report")); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHandler(nil, nil)
+	handler.SetJobStore(jobs)
+	s := server.Default(server.WithHostPorts(":0"))
+	s.GET("/api/jobs/:id/workspace", handler.GetJobWorkspace)
+	s.GET("/api/jobs/:id/workspace/files/*path", handler.GetJobWorkspaceFile)
+
+	w := ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/workspace", nil)
+	if got := w.Result().StatusCode(); got != 503 {
+		t.Fatalf("disabled status = %d, want 503", got)
+	}
+
+	handler.SetWorkspaces(m)
+	w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/workspace", nil)
+	if got := w.Result().StatusCode(); got != 200 {
+		t.Fatalf("status = %d: %s", got, w.Result().Body())
+	}
+	var resp struct {
+		UsedBytes int64 `json:"used_bytes"`
+		Files     []struct {
+			Path string `json:"path"`
+			URI  string `json:"uri"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.UsedBytes != 8 || len(resp.Files) != 1 || resp.Files[0].Path != "out/report.md" || resp.Files[0].URI != "workspace://"+jobID+"/out/report.md" {
+		t.Fatalf("unexpected listing: %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/workspace/files/out/report.md", nil)
+	if got := w.Result().StatusCode(); got != 200 || string(w.Result().Body()) != "# report" {
+		t.Fatalf("file status = %d body = %q", got, w.Result().Body())
+	}
+	w = ut.PerformRequest(s.Engine, "GET", "/api/jobs/"+jobID+"/workspace/files/missing.txt", nil)
+	if got := w.Result().StatusCode(); got != 404 {
+		t.Fatalf("missing file status = %d, want 404", got)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/internal/api/http/middleware"
+	"rag-platform/pkg/config"
+)
+
+// AccessLogConfigFrom 将 api.access_log 转为访问日志器配置；未配置的字段由 middleware 使用默认值
+func AccessLogConfigFrom(cfg *config.Config) middleware.AccessLogConfig {
+	if cfg == nil {
+		return middleware.AccessLogConfig{}
+	}
+	ac := cfg.API.AccessLog
+	threshold, _ := time.ParseDuration(ac.SlowThreshold)
+	floor, _ := time.ParseDuration(ac.SlowFloor)
+	return middleware.AccessLogConfig{
+		SampleRate:     ac.SampleRate,
+		SlowThreshold:  threshold,
+		SlowPercentile: ac.SlowPercentile,
+		SlowFloor:      floor,
+		SlowWindow:     ac.SlowWindow,
+		SlowBuffer:     ac.SlowBuffer,
+	}
+}
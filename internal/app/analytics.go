@@ -0,0 +1,29 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/analytics"
+	"rag-platform/pkg/config"
+)
+
+// AnalyticsOptionsFrom 将 analytics 配置转为跨租户聚合的抑制阈值；未配置的字段由 analytics 使用默认值
+func AnalyticsOptionsFrom(cfg *config.Config) analytics.Options {
+	if cfg == nil {
+		return analytics.Options{}
+	}
+	ac := cfg.Analytics
+	return analytics.Options{MinTenants: ac.MinTenants, MinJobs: ac.MinJobs, MaxTenantShare: ac.MaxTenantShare}
+}
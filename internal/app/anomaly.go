@@ -0,0 +1,47 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/internal/agent/anomaly"
+	"rag-platform/pkg/config"
+)
+
+// AnomalyOptionsFrom 将 agent.anomaly 转为检测参数；未配置的字段由 anomaly 使用默认值
+func AnomalyOptionsFrom(cfg *config.Config) anomaly.Options {
+	if cfg == nil {
+		return anomaly.Options{}
+	}
+	ac := cfg.Agent.Anomaly
+	return anomaly.Options{
+		MinSamples:            ac.MinSamples,
+		Factor:                ac.Factor,
+		Sigma:                 ac.Sigma,
+		Alpha:                 ac.Alpha,
+		DestructiveCategories: ac.DestructiveCategories,
+	}
+}
+
+// AnomalyNotifierFrom 按 agent.anomaly.webhook 创建告警出口；未配置 url 时返回 nil
+func AnomalyNotifierFrom(cfg *config.Config) anomaly.Notifier {
+	if cfg == nil || cfg.Agent.Anomaly.Webhook.URL == "" {
+		return nil
+	}
+	wh := cfg.Agent.Anomaly.Webhook
+	timeout, _ := time.ParseDuration(wh.Timeout)
+	return anomaly.NewWebhookNotifier(wh.URL, wh.Secret, timeout)
+}
@@ -26,6 +26,8 @@ import (
 	"rag-platform/internal/pipeline/ingest"
 	"rag-platform/internal/pipeline/query"
 	"rag-platform/internal/runtime/eino"
+	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/evidence"
 )
 
 // Embedder 用于查询向量化的接口（与 model/embedding.Embedder 一致）
@@ -63,24 +65,29 @@ func (a *retrieverAdapter) Retrieve(ctx context.Context, queryText, collection s
 		einoretriever.WithScoreThreshold(a.scoreThresh),
 		einoretriever.WithEmbedding(a.einoEmbedder),
 	}
-	docs, err := a.einoRetriever.Retrieve(ctx, queryText, opts...)
+	filter := retrievalFilter(ctx)
+	docs, err := a.einoRetriever.Retrieve(vector.WithFilter(ctx, filter), queryText, opts...)
 	if err != nil {
 		return nil, err
 	}
-	chunks := make([]eino.Chunk, len(docs))
-	for i, d := range docs {
+	chunks := make([]eino.Chunk, 0, len(docs))
+	for _, d := range docs {
+		if !docVisible(filter, d.MetaData) {
+			continue
+		}
 		docID := ""
 		if d.MetaData != nil {
 			if id, ok := d.MetaData["document_id"].(string); ok {
 				docID = id
 			}
 		}
-		chunks[i] = eino.Chunk{
+		chunks = append(chunks, eino.Chunk{
 			ID:         d.ID,
 			Content:    d.Content,
 			DocumentID: docID,
 			Metadata:   d.MetaData,
-		}
+			Score:      d.Score(),
+		})
 	}
 	return chunks, nil
 }
@@ -118,13 +125,20 @@ func (a *EinoRetrieverQueryAdapter) Execute(ctx *common.PipelineContext, input i
 	} else {
 		reqCtx = context.Background()
 	}
-	docs, err := a.EinoRetriever.Retrieve(reqCtx, q.Text, opts...)
+	filter := q.Filter
+	if filter == nil {
+		filter = retrievalFilter(reqCtx)
+	}
+	docs, err := a.EinoRetriever.Retrieve(vector.WithFilter(reqCtx, filter), q.Text, opts...)
 	if err != nil {
 		return nil, common.NewPipelineError("eino_retriever", "检索failed", err)
 	}
-	chunks := make([]common.Chunk, len(docs))
-	scores := make([]float64, len(docs))
-	for i, d := range docs {
+	chunks := make([]common.Chunk, 0, len(docs))
+	scores := make([]float64, 0, len(docs))
+	for _, d := range docs {
+		if !docVisible(filter, d.MetaData) {
+			continue
+		}
 		meta := make(map[string]interface{})
 		if d.MetaData != nil {
 			for k, v := range d.MetaData {
@@ -155,15 +169,15 @@ func (a *EinoRetrieverQueryAdapter) Execute(ctx *common.PipelineContext, input i
 				tokenCount, _ = strconv.Atoi(v)
 			}
 		}
-		chunks[i] = common.Chunk{
+		chunks = append(chunks, common.Chunk{
 			ID:         d.ID,
 			Content:    d.Content,
 			Metadata:   meta,
 			DocumentID: docID,
 			Index:      idx,
 			TokenCount: tokenCount,
-		}
-		scores[i] = 1.0
+		})
+		scores = append(scores, 1.0)
 	}
 	return &common.RetrievalResult{
 		Chunks:      chunks,
@@ -211,6 +225,9 @@ func (a *ragGeneratorAdapter) Generate(ctx context.Context, prompt string) (stri
 	for i, c := range chunks {
 		commonChunks[i] = common.Chunk{ID: c.ID, Content: c.Content, DocumentID: c.DocumentID, Metadata: c.Metadata}
 		scores[i] = 1.0
+		if c.Score > 0 {
+			scores[i] = c.Score
+		}
 	}
 	result := &common.RetrievalResult{Chunks: commonChunks, Scores: scores, TotalCount: len(commonChunks)}
 	// 构建 Query（需 embedding 供 generator 内部使用）
@@ -222,13 +239,53 @@ func (a *ragGeneratorAdapter) Generate(ctx context.Context, prompt string) (stri
 		}
 	}
 	q := &common.Query{Text: prompt, Embedding: emb}
-	genResult, err := a.generator.GenerateWithRetrieval(q, result)
+	genResult, err := a.generator.GenerateWithRetrieval(ctx, q, result)
 	if err != nil {
 		return "", err
 	}
+	// Agent step 内调用时记录参与回答的片段，写入该 step 的 evidence.citations
+	evidence.RecordCitations(ctx, citationsFromChunks(chunks, collection)...)
 	return genResult.Answer, nil
 }
 
+// citationsFromChunks 将检索片段转为引用；offset 取自切片时写入的 start_offset/end_offset 元数据
+func citationsFromChunks(chunks []eino.Chunk, collection string) []evidence.Citation {
+	out := make([]evidence.Citation, 0, len(chunks))
+	for i, c := range chunks {
+		cit := evidence.Citation{
+			ChunkID:    c.ID,
+			DocumentID: c.DocumentID,
+			Collection: collection,
+			Score:      c.Score,
+			Rank:       i + 1,
+		}
+		start, okStart := metadataInt(c.Metadata, "start_offset")
+		end, okEnd := metadataInt(c.Metadata, "end_offset")
+		if okStart && okEnd && end > start {
+			cit.Offset = &evidence.CitationSpan{Start: start, End: end}
+		}
+		out = append(out, cit)
+	}
+	return out
+}
+
+// metadataInt 读取元数据中的整数（向量库回读后可能为 float64 或 string）
+func metadataInt(meta map[string]interface{}, key string) (int, bool) {
+	switch v := meta[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
 // loaderAdapter 将 ingest.DocumentLoader 适配为 eino.DocumentLoader
 type loaderAdapter struct {
 	loader *ingest.DocumentLoader
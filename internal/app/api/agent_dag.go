@@ -16,17 +16,25 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/replay/sandbox"
 	"rag-platform/internal/agent/runtime"
 	agentexec "rag-platform/internal/agent/runtime/executor"
 	"rag-platform/internal/agent/tools"
 	"rag-platform/internal/model/llm"
+	"rag-platform/internal/runtime/egress"
 	"rag-platform/internal/runtime/eino"
 	runtimesession "rag-platform/internal/runtime/session"
+	"rag-platform/internal/tool/builtin"
 )
 
+// debugRunTimeout 单次 debug-run 的执行上限
+const debugRunTimeout = 2 * time.Minute
+
 // llmGenAdapter 将 llm.Client 适配为 executor.LLMGen
 type llmGenAdapter struct {
 	client llm.Client
@@ -39,6 +47,50 @@ func (a *llmGenAdapter) Generate(ctx context.Context, prompt string) (string, er
 	return a.client.GenerateWithContext(ctx, prompt, llm.GenerateOptions{MaxTokens: 4096, Temperature: 0.1})
 }
 
+// GenerateServed 生成并返回实际服务的模型；经故障切换链时记录切换来源，未经故障切换链时为客户端当前模型
+func (a *llmGenAdapter) GenerateServed(ctx context.Context, prompt string) (string, agentexec.LLMModelInfo, error) {
+	ctx, served := llm.WithServedModel(ctx)
+	resp, err := a.Generate(ctx, prompt)
+	if err != nil {
+		return "", agentexec.LLMModelInfo{}, err
+	}
+	info := a.ModelInfo(ctx)
+	if served.Model != "" {
+		info.Model = served.Model
+		info.Provider = served.Provider
+		info.FailoverFrom = served.FailoverFrom
+	}
+	return resp, info, nil
+}
+
+// GenerateRouted 按节点声明的要求生成：要求经 ctx 传给 llm.ModelRouter（未启用 model.routing 时由当前客户端服务，不产生路由决策）
+func (a *llmGenAdapter) GenerateRouted(ctx context.Context, prompt string, route agentexec.LLMRoute) (string, agentexec.LLMModelInfo, error) {
+	quality, err := llm.ParseQuality(route.Quality)
+	if err != nil {
+		return "", agentexec.LLMModelInfo{}, err
+	}
+	ctx = llm.WithRouteRequirement(ctx, llm.RouteRequirement{Quality: quality, MaxLatency: route.MaxLatency, Prefer: route.Prefer})
+	ctx, decision := llm.WithRouteDecision(ctx)
+	resp, info, err := a.GenerateServed(ctx, prompt)
+	if err != nil {
+		return "", info, err
+	}
+	if decision.Model != "" {
+		info.Routing, _ = json.Marshal(decision)
+	}
+	return resp, info, nil
+}
+
+// ModelInfo 返回客户端当前模型信息（temperature 与 Generate 一致）
+func (a *llmGenAdapter) ModelInfo(ctx context.Context) agentexec.LLMModelInfo {
+	info := agentexec.LLMModelInfo{Temperature: 0.1}
+	if a.client != nil {
+		info.Model = a.client.Model()
+		info.Provider = a.client.Provider()
+	}
+	return info
+}
+
 // toolExecAdapter 从 ctx 取 agent，将 runtime.Session 转为 runtime/session.Session 后调 agent/tools
 type toolExecAdapter struct {
 	reg *tools.Registry
@@ -101,18 +153,22 @@ func (a *workflowExecAdapter) ExecuteWorkflow(ctx context.Context, name string,
 
 // NewDAGCompiler 创建 TaskGraph→eino DAG 的编译器（注册 llm/tool/workflow 适配器）；toolEventSink/commandEventSink 可选；invocationStore 可选；effectStore 可选，非 nil 时启用两步提交与强 Replay catch-up；resourceVerifier 可选；attemptValidator 可选，非 nil 时 Ledger Commit 前校验 attempt（Lease fencing）
 func NewDAGCompiler(llmClient llm.Client, toolsReg *tools.Registry, engine *eino.Engine, toolEventSink agentexec.ToolEventSink, commandEventSink agentexec.CommandEventSink, invocationStore agentexec.ToolInvocationStore, effectStore agentexec.EffectStore, resourceVerifier agentexec.ResourceVerifier, attemptValidator agentexec.AttemptValidator) *agentexec.Compiler {
-	return NewDAGCompilerWithOptions(llmClient, toolsReg, engine, toolEventSink, commandEventSink, invocationStore, effectStore, resourceVerifier, attemptValidator, nil)
+	return NewDAGCompilerWithOptions(llmClient, toolsReg, engine, toolEventSink, commandEventSink, invocationStore, effectStore, resourceVerifier, attemptValidator, nil, nil)
 }
 
-// NewDAGCompilerWithOptions 创建 DAG 编译器，支持可选的 Tool 限流器。
-func NewDAGCompilerWithOptions(llmClient llm.Client, toolsReg *tools.Registry, engine *eino.Engine, toolEventSink agentexec.ToolEventSink, commandEventSink agentexec.CommandEventSink, invocationStore agentexec.ToolInvocationStore, effectStore agentexec.EffectStore, resourceVerifier agentexec.ResourceVerifier, attemptValidator agentexec.AttemptValidator, toolRateLimiter *agentexec.ToolRateLimiter) *agentexec.Compiler {
+// NewDAGCompilerWithOptions 创建 DAG 编译器，支持可选的 Tool 限流器与 Agent 级配置注入（agentConfig 非 nil 时工具可经 sdk.ConfigFromContext 读取）。
+func NewDAGCompilerWithOptions(llmClient llm.Client, toolsReg *tools.Registry, engine *eino.Engine, toolEventSink agentexec.ToolEventSink, commandEventSink agentexec.CommandEventSink, invocationStore agentexec.ToolInvocationStore, effectStore agentexec.EffectStore, resourceVerifier agentexec.ResourceVerifier, attemptValidator agentexec.AttemptValidator, toolRateLimiter *agentexec.ToolRateLimiter, agentConfig agentexec.AgentConfigResolver) *agentexec.Compiler {
 	toolAdapter := &agentexec.ToolNodeAdapter{
 		Tools:              &toolExecAdapter{reg: toolsReg},
 		ToolCapabilityFunc: toolsReg.GetCapability,
+		ToolCategoriesFunc: toolsReg.Categories,
 	}
 	if toolRateLimiter != nil {
 		toolAdapter.RateLimiter = toolRateLimiter
 	}
+	if agentConfig != nil {
+		toolAdapter.AgentConfig = agentConfig
+	}
 	if toolEventSink != nil {
 		toolAdapter.ToolEventSink = toolEventSink
 	}
@@ -144,6 +200,10 @@ func NewDAGCompilerWithOptions(llmClient llm.Client, toolsReg *tools.Registry, e
 	if commandEventSink != nil {
 		workflowAdapter.CommandEventSink = commandEventSink
 	}
+	branchAdapter := &agentexec.BranchNodeAdapter{}
+	if ds, ok := commandEventSink.(agentexec.DecisionEventSink); ok {
+		branchAdapter.DecisionSink = ds
+	}
 	adapters := map[string]agentexec.NodeAdapter{
 		planner.NodeLLM:       llmAdapter,
 		planner.NodeTool:      toolAdapter,
@@ -152,8 +212,135 @@ func NewDAGCompilerWithOptions(llmClient llm.Client, toolsReg *tools.Registry, e
 		planner.NodeApproval:  &agentexec.ApprovalNodeAdapter{},
 		planner.NodeCondition: &agentexec.ConditionNodeAdapter{},
 		planner.NodeLangGraph: &agentexec.LangGraphNodeAdapter{},
+		planner.NodeReflect:   &agentexec.ReflectNodeAdapter{},
+		planner.NodeSpawn:     &agentexec.SpawnNodeAdapter{},
+		planner.NodeJoin:      &agentexec.JoinNodeAdapter{},
+		planner.NodeBranch:    branchAdapter,
+	}
+	// 自定义节点类型（pkg/nodeplugin）；内建类型不可被插件覆盖
+	for nodeType, adapter := range agentexec.PluginNodeAdapters(commandEventSink) {
+		if _, builtin := adapters[nodeType]; !builtin {
+			adapters[nodeType] = adapter
+		}
+	}
+	compiler := agentexec.NewCompiler(adapters)
+	// map 节点的 body 经同一编译器中的适配器执行
+	mapAdapter := &agentexec.MapNodeAdapter{Compiler: compiler}
+	if es, ok := commandEventSink.(agentexec.MapIterationEventSink); ok {
+		mapAdapter.EventSink = es
+	}
+	compiler.Register(planner.NodeMap, mapAdapter)
+	return compiler
+}
+
+// SetChildJobRuntime 为编译器的 spawn/join 节点注入子 Job 创建与汇合能力（监督者模式）；未注入时含这两类节点的计划编译失败
+func SetChildJobRuntime(compiler *agentexec.Compiler, rt agentexec.ChildJobRuntime) {
+	if rt == nil {
+		return
+	}
+	if adapter, ok := compiler.Adapter(planner.NodeSpawn); ok {
+		if spawn, ok := adapter.(*agentexec.SpawnNodeAdapter); ok {
+			spawn.Children = rt
+		}
+	}
+	if adapter, ok := compiler.Adapter(planner.NodeJoin); ok {
+		if join, ok := adapter.(*agentexec.JoinNodeAdapter); ok {
+			join.Children = rt
+		}
+	}
+}
+
+// SetToolKillSwitch 为编译器的 tool 节点启用全局工具熔断（按 Registry 中的工具类别判定）
+func SetToolKillSwitch(compiler *agentexec.Compiler, ks agentexec.ToolKillSwitch) {
+	adapter, ok := compiler.Adapter(planner.NodeTool)
+	if !ok {
+		return
+	}
+	if toolAdapter, ok := adapter.(*agentexec.ToolNodeAdapter); ok {
+		toolAdapter.KillSwitch = ks
+	}
+}
+
+// SetToolOutputLimits 为编译器的 tool 节点设置输出大小上限；超限输出写入 Job 工作区并以摘要替换
+func SetToolOutputLimits(compiler *agentexec.Compiler, limits agentexec.ToolOutputLimits) {
+	adapter, ok := compiler.Adapter(planner.NodeTool)
+	if !ok {
+		return
+	}
+	if toolAdapter, ok := adapter.(*agentexec.ToolNodeAdapter); ok {
+		toolAdapter.OutputLimits = limits
+	}
+}
+
+// SetToolSemaphores 为编译器的 tool 节点启用集群级命名信号量（外部系统并发上限）
+func SetToolSemaphores(compiler *agentexec.Compiler, sems *agentexec.ToolSemaphores) {
+	adapter, ok := compiler.Adapter(planner.NodeTool)
+	if !ok || sems == nil {
+		return
+	}
+	if toolAdapter, ok := adapter.(*agentexec.ToolNodeAdapter); ok {
+		toolAdapter.Semaphores = sems
+	}
+}
+
+// SetToolEgress 为编译器的 tool 节点启用租户出网策略；http.request、web_fetch 与经 sdk.HTTPClient 访问外部的自定义工具受限
+func SetToolEgress(compiler *agentexec.Compiler, policies *egress.Policies) {
+	adapter, ok := compiler.Adapter(planner.NodeTool)
+	if !ok || policies == nil {
+		return
+	}
+	if toolAdapter, ok := adapter.(*agentexec.ToolNodeAdapter); ok {
+		toolAdapter.Egress = policies
+		toolAdapter.EgressClient = egress.NewClient(builtin.DefaultTimeout)
+	}
+}
+
+// SetPromptLogger 为编译器的 llm 节点启用 prompt 留存；command_emitted 改为只记录 prompt 摘要
+func SetPromptLogger(compiler *agentexec.Compiler, logger agentexec.PromptLogger) {
+	adapter, ok := compiler.Adapter(planner.NodeLLM)
+	if !ok || logger == nil {
+		return
+	}
+	if llmAdapter, ok := adapter.(*agentexec.LLMNodeAdapter); ok {
+		llmAdapter.PromptLog = logger
+	}
+}
+
+// SetToolCapabilityPolicy 为编译器的 tool 节点启用执行前 capability 校验（design/capability-policy.md）
+func SetToolCapabilityPolicy(compiler *agentexec.Compiler, checker agentexec.CapabilityPolicyChecker) {
+	adapter, ok := compiler.Adapter(planner.NodeTool)
+	if !ok || checker == nil {
+		return
+	}
+	if toolAdapter, ok := adapter.(*agentexec.ToolNodeAdapter); ok {
+		toolAdapter.CapabilityPolicyChecker = checker
+	}
+}
+
+// NewDAGReflector 创建基于 llmClient 的自我反思 Reflector（Runner.SetReflection 使用）
+func NewDAGReflector(llmClient llm.Client) agentexec.Reflector {
+	return agentexec.NewLLMReflector(&llmGenAdapter{client: llmClient})
+}
+
+// NewDebugRunner 创建时间旅行调试沙箱：LLM 节点用 llmClient 重新生成，工具节点仅真实执行声明 SandboxSafe 的工具
+func NewDebugRunner(llmClient llm.Client, toolsReg *tools.Registry) *sandbox.DebugRunner {
+	d := &sandbox.DebugRunner{Timeout: debugRunTimeout}
+	if llmClient != nil {
+		gen := &llmGenAdapter{client: llmClient}
+		d.LLM = gen.Generate
+	}
+	if toolsReg != nil {
+		exec := &toolExecAdapter{reg: toolsReg}
+		d.Tool = func(ctx context.Context, toolName string, input map[string]any, state interface{}) (sandbox.ToolOutcome, error) {
+			res, err := exec.Execute(ctx, toolName, input, state)
+			return sandbox.ToolOutcome{Output: res.Output, Err: res.Err, Done: res.Done, State: res.State}, err
+		}
+		d.SandboxSafe = func(toolName string) bool {
+			t, ok := toolsReg.Get(toolName)
+			return ok && tools.IsSandboxSafe(t)
+		}
 	}
-	return agentexec.NewCompiler(adapters)
+	return d
 }
 
 // NewDAGRunner 创建 DAG 执行 Runner
@@ -125,6 +125,6 @@ func PlanGoalForJobFunc(manager *runtime.Manager, p planGoaler) func(context.Con
 			working := memory.NewWorkingSession(agent.Session)
 			mem = memory.NewCompositeMemory(working, memory.NewEpisodic(1000))
 		}
-		return p.PlanGoal(ctx, goal, mem)
+		return p.PlanGoal(planner.WithAgentID(ctx, agentID), goal, mem)
 	}
 }
@@ -25,41 +25,74 @@ import (
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/grpc"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	hertzslog "github.com/hertz-contrib/logger/slog"
 	"github.com/hertz-contrib/obs-opentelemetry/provider"
 	hertztracing "github.com/hertz-contrib/obs-opentelemetry/tracing"
-	"github.com/jackc/pgx/v5/pgxpool"
 	apigrpc "rag-platform/internal/api/grpc"
 
 	"rag-platform/internal/agent"
+	"rag-platform/internal/agent/anomaly"
+	"rag-platform/internal/agent/branding"
+	"rag-platform/internal/agent/compat"
+	"rag-platform/internal/agent/debugsession"
+	"rag-platform/internal/agent/diagnosis"
+	"rag-platform/internal/agent/eta"
+	"rag-platform/internal/agent/evalsuite"
 	"rag-platform/internal/agent/executor"
+	"rag-platform/internal/agent/extworker"
+	"rag-platform/internal/agent/goaltemplate"
 	"rag-platform/internal/agent/instance"
 	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/killswitch"
 	"rag-platform/internal/agent/messaging"
 	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/promptlog"
+	"rag-platform/internal/agent/reconcile"
 	replaysandbox "rag-platform/internal/agent/replay/sandbox"
 	"rag-platform/internal/agent/runtime"
 	agentexec "rag-platform/internal/agent/runtime/executor"
 	"rag-platform/internal/agent/runtime/executor/verifier"
+	"rag-platform/internal/agent/scheduler"
 	"rag-platform/internal/agent/tools"
+	"rag-platform/internal/agent/tracefilter"
+	"rag-platform/internal/agent/verify"
+	"rag-platform/internal/agent/warehouse"
+	"rag-platform/internal/agent/workspace"
 	"rag-platform/internal/api/http"
 	"rag-platform/internal/api/http/middleware"
 	"rag-platform/internal/app"
 	"rag-platform/internal/einoext"
 	"rag-platform/internal/ingestqueue"
 	"rag-platform/internal/model/llm"
+	"rag-platform/internal/pipeline/freshness"
 	"rag-platform/internal/pipeline/ingest"
 	"rag-platform/internal/pipeline/query"
+	"rag-platform/internal/runtime/agentconfig"
 	"rag-platform/internal/runtime/eino"
+	"rag-platform/internal/runtime/eventarchive"
+	"rag-platform/internal/runtime/eventexport"
+	"rag-platform/internal/runtime/eventsearch"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/runtime/payloadcrypt"
+	"rag-platform/internal/runtime/piitag"
+	"rag-platform/internal/runtime/serviceaccount"
 	"rag-platform/internal/runtime/session"
 	"rag-platform/internal/splitter"
+	"rag-platform/internal/storage/object"
+	"rag-platform/internal/storage/pgpool"
+	"rag-platform/internal/storage/pgretry"
 	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/agent/sdk"
 	"rag-platform/pkg/auth"
 	"rag-platform/pkg/config"
+	"rag-platform/pkg/i18n"
+	"rag-platform/pkg/pii"
+	"rag-platform/pkg/redaction"
 )
 
 // otelProviderShutdown 用于优雅关闭时关闭 OpenTelemetry provider
@@ -77,6 +110,27 @@ type App struct {
 	grpcServer   *grpcRun
 	otelProvider otelProviderShutdown
 	jobScheduler *job.Scheduler
+	pgPools      *pgpool.Manager    // jobstore.type=postgres 时统一管理各组件连接池
+	eventRelay   *eventexport.Relay // event_export.enable 时非 nil
+	maintenance  *job.MaintenanceController
+	externalHost *extworker.Host // agent.external_workers 配置时非 nil
+	// freshnessStop 非 nil 时停止知识库定时重新抓取（storage.freshness.refresh_interval）
+	freshnessStop context.CancelFunc
+	// reconcileStop 非 nil 时停止周期漂移对账（jobstore.reconcile）
+	reconcileStop context.CancelFunc
+	// etaStop 非 nil 时停止 Job 时长学习（agent.eta）
+	etaStop context.CancelFunc
+	// anomalyStop 非 nil 时停止行为异常扫描（agent.anomaly）
+	anomalyStop context.CancelFunc
+	// diagnosisStop 非 nil 时停止失败诊断扫描（agent.diagnosis）
+	diagnosisStop context.CancelFunc
+	// workspaceStop 非 nil 时停止 Job 工作区清理（agent.workspace）
+	workspaceStop context.CancelFunc
+	// warehouseStop 非 nil 时停止数据仓库批量导出（warehouse_export）
+	warehouseStop context.CancelFunc
+	// readiness 启动预检结果（/readyz）；preflightLLM 为预检的各用途 LLM 客户端
+	readiness    *app.Readiness
+	preflightLLM map[string]llm.Client
 }
 
 // jobStoreForRunnerAdapter 将 job.JobStore 适配为 agentexec.JobStoreForRunner（status int）
@@ -110,8 +164,17 @@ func (g *grpcRun) GracefulStop() {
 }
 
 // NewApp 创建 API 应用（由 cmd/api 调用）
-func NewApp(bootstrap *app.Bootstrap) (*App, error) {
-	if err := validateProductionRuntimeConfig(bootstrap.Config); err != nil {
+func NewApp(bootstrap *app.Bootstrap, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// 启动前校验配置：问题带 YAML 路径与行号，warning 仅记录，存在 error 时拒绝启动
+	report := config.Validate(bootstrap.Config, config.ComponentAPI)
+	for _, w := range report.Warnings() {
+		bootstrap.Logger.Warn("配置告警", "issue", w.String())
+	}
+	if err := report.Err(); err != nil {
 		return nil, err
 	}
 	engine, err := eino.NewEngine(bootstrap.Config, bootstrap.Logger)
@@ -128,11 +191,22 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		defaultCollection = bootstrap.Config.Storage.Vector.Collection
 	}
 
+	// 知识库新鲜度策略（storage.freshness）：集合报告、定时重新抓取与回答过期提示共用
+	freshnessPolicies := freshnessPoliciesFromConfig(bootstrap.Config)
+	var queryOpts []eino.QueryWorkflowOption
+	if bootstrap.Config != nil && bootstrap.Config.Storage.Freshness.WarnOnAnswer {
+		queryOpts = append(queryOpts, eino.WithFreshnessWarnings(freshnessPolicies, defaultCollection))
+	}
+
 	// 装配并注册 query_pipeline（Retriever + Generator + queryEmbedder）；memory 或 redis 等由 einoext 工厂创建
 	vecCfg := bootstrap.Config.Storage.Vector
 	queryPipelineEnabled := bootstrap.Config != nil && (bootstrap.VectorStore != nil || (vecCfg.Type != "" && vecCfg.Type != "memory"))
 	if queryPipelineEnabled {
-		llmClient, errLLM := app.NewLLMClientFromConfig(bootstrap.Config)
+		// 生成调用优先使用 model.fallback.generation 故障切换链，未配置时使用 defaults.llm
+		llmClient, errLLM := app.NewLLMClientForUseCase(bootstrap.Config, app.LLMUseCaseGeneration)
+		if errLLM == nil && llmClient == nil {
+			llmClient, errLLM = app.NewLLMClientFromConfig(bootstrap.Config)
+		}
 		queryEmbedder, errEmb := app.NewQueryEmbedderFromConfig(bootstrap.Config)
 		if errLLM == nil && errEmb == nil && llmClient != nil && queryEmbedder != nil {
 			generator := query.NewGenerator(llmClient, 4096, 0.1)
@@ -146,7 +220,7 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 					})
 					if errRet == nil {
 						retrieverForWorkflow := query.NewRetriever(bootstrap.VectorStore, defaultCollection, 10, 0.3)
-						qwf := eino.NewQueryWorkflowExecutor(retrieverForWorkflow, generator, queryEmbedder, bootstrap.Logger)
+						qwf := eino.NewQueryWorkflowExecutor(retrieverForWorkflow, generator, queryEmbedder, bootstrap.Logger, queryOpts...)
 						_ = engine.RegisterWorkflow("query_pipeline", qwf)
 						retrieverAdapter := NewRetrieverAdapter(queryEmbedder, einoRetriever, 0.3)
 						ragGen := NewRAGGeneratorAdapter(retrieverAdapter, generator, queryEmbedder, defaultCollection)
@@ -156,7 +230,7 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 				}
 			} else {
 				retrieverForWorkflow := &EinoRetrieverQueryAdapter{EinoRetriever: einoRetriever, Embedder: queryEmbedder, TopK: 10}
-				qwf := eino.NewQueryWorkflowExecutor(retrieverForWorkflow, generator, queryEmbedder, bootstrap.Logger)
+				qwf := eino.NewQueryWorkflowExecutor(retrieverForWorkflow, generator, queryEmbedder, bootstrap.Logger, queryOpts...)
 				if err := engine.RegisterWorkflow("query_pipeline", qwf); err != nil {
 					bootstrap.Logger.Info("注册 query_pipeline failed，将使用占位实现", "error", err)
 				}
@@ -169,7 +243,24 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		}
 	}
 
+	if o.llm != nil {
+		llmClientForAgent = o.llm
+	}
+
+	// 按步骤的模型路由：llm 节点声明 quality / max_latency 时在候选模型中选择，未声明时仍由生成客户端服务
+	var llmRouter *llm.ModelRouter
+	if llmClientForAgent != nil {
+		r, err := app.NewLLMRouterFromConfig(bootstrap.Config, llmClientForAgent)
+		if err != nil {
+			return nil, fmt.Errorf("初始化模型路由failed: %w", err)
+		}
+		if r != nil {
+			llmRouter = r
+			llmClientForAgent = r
+		}
+	}
 	// LLM 限流：从配置加载 LLMRateLimiter 并包装 llmClientForAgent（防止打爆 Provider API）
+	var llmRateLimiter *llm.LLMRateLimiter
 	if llmClientForAgent != nil && bootstrap.Config != nil && len(bootstrap.Config.RateLimits.LLM) > 0 {
 		llmLimiterConfigs := make(map[string]llm.LLMLimitConfig, len(bootstrap.Config.RateLimits.LLM))
 		for provider, c := range bootstrap.Config.RateLimits.LLM {
@@ -180,6 +271,7 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 				TokensPerMinute:   c.TokensPerMinute,
 				RequestsPerMinute: c.RequestsPerMinute,
 				MaxConcurrent:     c.MaxConcurrent,
+				PlanningShare:     c.PlanningShare,
 			}
 		}
 		var llmDefaults *llm.LLMLimitConfig
@@ -188,12 +280,32 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 				TokensPerMinute:   d.TokensPerMinute,
 				RequestsPerMinute: d.RequestsPerMinute,
 				MaxConcurrent:     d.MaxConcurrent,
+				PlanningShare:     d.PlanningShare,
 			}
 		}
-		llmRateLimiter := llm.NewLLMRateLimiter(llmLimiterConfigs, llmDefaults)
+		llmRateLimiter = llm.NewLLMRateLimiter(llmLimiterConfigs, llmDefaults)
 		llmClientForAgent = llm.NewRateLimitedClient(llmClientForAgent, llmRateLimiter)
 		bootstrap.Logger.Info("LLM 限流已启用", "providers", len(llmLimiterConfigs))
 	}
+	// 规划调用：配置了 model.fallback.planner 时使用独立的故障切换链（共享限流器），否则与生成调用共用客户端
+	llmClientForPlanner := llmClientForAgent
+	if llmClientForAgent != nil {
+		plannerLLM, err := app.NewLLMClientForUseCase(bootstrap.Config, app.LLMUseCasePlanner)
+		if err != nil {
+			return nil, fmt.Errorf("初始化规划 LLM 故障切换链failed: %w", err)
+		}
+		if plannerLLM == nil && llmRouter != nil {
+			// 未配置规划故障切换链时，规划按 model.routing.planner_quality（默认 high）路由到最强模型
+			req, _ := app.PlannerRouteRequirement(bootstrap.Config)
+			plannerLLM = llmRouter.Pinned(req)
+		}
+		if plannerLLM != nil {
+			if llmRateLimiter != nil {
+				plannerLLM = llm.NewRateLimitedClient(plannerLLM, llmRateLimiter)
+			}
+			llmClientForPlanner = plannerLLM
+		}
+	}
 
 	// 装配并注册 ingest_pipeline（loader → parser → splitter → embedding → indexer）；Indexer 由 einoext 工厂创建
 	ingestPipelineEnabled := bootstrap.Config != nil && bootstrap.MetadataStore != nil && (bootstrap.VectorStore != nil || (vecCfg.Type != "" && vecCfg.Type != "memory"))
@@ -232,6 +344,8 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 			}
 			if docIndexer != nil {
 				if bootstrap.VectorStore != nil {
+					// 文档重新入库时由 indexer 删除旧版本切片（Eino Indexer 只写不删）
+					docIndexer.SetVectorStore(bootstrap.VectorStore)
 					if err := vector.EnsureIndex(context.Background(), bootstrap.VectorStore, defaultCollection, ingestEmbedder.Dimension(), "cosine"); err != nil {
 						bootstrap.Logger.Info("创建向量索引failed（首次写入时可能再创建）", "collection", defaultCollection, "error", err)
 					}
@@ -241,7 +355,11 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 				docSplitter := ingest.NewDocumentSplitter(1000, 100, 1000)
 				splitterEngine := splitter.NewEngine(ingestEmbedder)
 				docSplitter.SetEngine(splitterEngine, "structural")
-				iwf := eino.NewIngestWorkflowExecutor(loader, parser, docSplitter, docEmbedding, docIndexer, bootstrap.Logger)
+				var ingestOpts []eino.IngestWorkflowOption
+				if dedupPolicies := app.IngestDedupPoliciesFrom(bootstrap.Config); dedupPolicies.Enabled() {
+					ingestOpts = append(ingestOpts, eino.WithIngestDedup(ingest.NewDeduplicator(bootstrap.MetadataStore, dedupPolicies, defaultCollection)))
+				}
+				iwf := eino.NewIngestWorkflowExecutor(loader, parser, docSplitter, docEmbedding, docIndexer, bootstrap.Logger, ingestOpts...)
 				if err := engine.RegisterWorkflow("ingest_pipeline", iwf); err != nil {
 					bootstrap.Logger.Info("注册 ingest_pipeline failed，将使用占位实现", "error", err)
 				}
@@ -261,16 +379,67 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 	}
 
 	// Agent Runtime：agent/tools.Registry（Session 感知）+ Builtin + Planner + Executor + Memory + Agent
+	extraTools, err := app.BuiltinWebTools(bootstrap.Config)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 web 工具failed: %w", err)
+	}
+	codeExecTool, err := app.BuiltinCodeExecTool(bootstrap.Config)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 code_exec 工具failed: %w", err)
+	}
+	if codeExecTool != nil {
+		extraTools = append(extraTools, codeExecTool)
+	}
+	extraTools = append(extraTools, o.tools...)
+	capabilityPolicy, err := app.NewCapabilityPolicy(bootstrap.Config)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 capability policy failed: %w", err)
+	}
 	toolsReg := tools.NewRegistry()
-	tools.RegisterBuiltin(toolsReg, engine, generatorForAgent)
-	plannerAgent := planner.NewLLMPlanner(llmClientForAgent)
+	tools.RegisterBuiltin(toolsReg, engine, generatorForAgent, extraTools...)
+	// 外部语言 Worker（JSON-RPC over stdio）：其工具参与规划（及内存模式下的执行）
+	var externalHost *extworker.Host
+	if bootstrap.Config != nil && len(bootstrap.Config.Agent.ExternalWorkers) > 0 {
+		externalHost = extworker.NewHostFromConfig(bootstrap.Config.Agent.ExternalWorkers, "api", bootstrap.Logger)
+		n := externalHost.Start(context.Background(), toolsReg)
+		bootstrap.Logger.Info("外部 Worker 工具已注册", "workers", len(bootstrap.Config.Agent.ExternalWorkers), "tools", n)
+	}
+	// 工具成本/延迟标注（agent.plan_cost）：注入 Planner prompt，并用于 PlanGenerated 的成本/ETA 预估
+	llmCost, planBudget := app.ApplyPlanCostConfig(bootstrap.Config, toolsReg)
+	app.ApplyToolCategoriesConfig(bootstrap.Config, toolsReg)
+	app.ApplyIdempotentToolsConfig(bootstrap.Config, toolsReg)
+	app.ApplyToolSemaphoresConfig(bootstrap.Config, toolsReg)
+	nodePluginTypes, errPlugins := app.LoadNodePluginManifests(bootstrap.Config)
+	if errPlugins != nil {
+		return nil, errPlugins
+	}
+	if len(nodePluginTypes) > 0 {
+		bootstrap.Logger.Info("自定义节点类型已注册", "types", nodePluginTypes)
+	}
+	plannerAgent := planner.NewLLMPlanner(llmClientForPlanner)
 	execAgent := executor.NewSessionRegistryExecutor(toolsReg)
 	agentRunner := agent.New(plannerAgent, execAgent, toolsReg)
 	sessionStore := session.NewMemoryStore()
 	sessionManager := session.NewManager(sessionStore)
-	docService := app.NewDocumentService(bootstrap.MetadataStore)
+	docService := app.NewDocumentService(bootstrap.MetadataStore, bootstrap.VectorStore)
 	handler := http.NewHandler(engine, docService)
 	handler.SetAgent(agentRunner)
+	readiness := app.NewReadiness("api")
+	handler.SetReadiness(readiness)
+	var freshnessRefresher *freshness.Refresher
+	if bootstrap.MetadataStore != nil {
+		handler.SetFreshness(bootstrap.MetadataStore, freshnessPolicies, defaultCollection)
+		if ingestPipelineEnabled && freshnessPolicies.RefreshEnabled() {
+			freshnessRefresher = freshness.NewRefresher(bootstrap.MetadataStore, freshnessPolicies, defaultCollection, func(ctx context.Context, filename string, content []byte, meta map[string]interface{}) error {
+				_, err := engine.ExecuteWorkflow(ctx, "ingest_pipeline", map[string]interface{}{
+					"content":  content,
+					"filename": filename,
+					"metadata": meta,
+				})
+				return err
+			}, nil, bootstrap.Logger)
+		}
+	}
 	handler.SetSessionManager(sessionManager)
 	// 主 ADK Runner：当启用时 /api/agent/run、resume、stream 使用 ADK 执行
 	if engine != nil {
@@ -297,12 +466,21 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		v1Planner = planner.NewRulePlanner()
 		bootstrap.Logger.Info("v1 Agent 使用规则规划器（RulePlanner）")
 	} else {
-		llmPlanner := planner.NewLLMPlanner(llmClientForAgent)
+		llmPlanner := planner.NewLLMPlanner(llmClientForPlanner)
 		if schema, err := toolsReg.SchemasForLLM(); err == nil && len(schema) > 0 {
 			llmPlanner.SetToolsSchemaForGoal(schema)
 		}
+		llmPlanner.SetPlanCost(llmCost, planBudget)
+		if bootstrap.Config != nil {
+			llmPlanner.SetRequireRationale(bootstrap.Config.Agent.PlanRationale.Required)
+		}
 		v1Planner = llmPlanner
 	}
+	planCostModel := planner.CostModel{LLM: llmCost}
+	if schema, err := toolsReg.SchemasForLLM(); err == nil {
+		planCostModel = planner.CostModelFromSchemaJSON(schema)
+		planCostModel.LLM = llmCost
+	}
 	var dagCompiler *agentexec.Compiler
 	var dagRunner *agentexec.Runner
 	agentScheduler := runtime.NewScheduler(agentRuntimeManager, func(ctx context.Context, agentID string) {
@@ -316,7 +494,18 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 	// Job 元数据存储：postgres 时与 Worker 共享 jobs 表，否则内存（仅 API 进程内）
 	var jobStore job.JobStore
 	var jobEventStore jobstore.JobStore
+	// 连接池统一管理：各组件按 jobstore.pool.budgets 分配连接预算，避免多池叠加耗尽数据库连接；
+	// 小表存储（维护窗口、品牌、服务账号等）共用 jobsPool
+	var pgPools *pgpool.Manager
+	var jobsPool *pgxpool.Pool
 	if bootstrap.Config != nil && bootstrap.Config.JobStore.Type == "postgres" && bootstrap.Config.JobStore.DSN != "" {
+		pgPools = pgpool.NewManager(bootstrap.Config.JobStore.Pool)
+		// 预算总量须容纳本进程启用的全部组件，否则后申请的组件因预算耗尽导致启动failed
+		if err := pgPools.CheckBudget(pgpool.APIComponents(bootstrap.Config)); err != nil {
+			return nil, fmt.Errorf("Postgres 连接预算不足: %w", err)
+		}
+	}
+	if pgPools != nil {
 		dsn := bootstrap.Config.JobStore.DSN
 		leaseDur := 30 * time.Second
 		if bootstrap.Config.JobStore.LeaseDuration != "" {
@@ -324,28 +513,179 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 				leaseDur = d
 			}
 		}
-		pgEventStore, err := jobstore.NewPostgresStore(context.Background(), dsn, leaseDur)
+		eventPool, err := pgPools.Pool(context.Background(), pgpool.ComponentJobEvents, dsn)
 		if err != nil {
 			return nil, fmt.Errorf("初始化 JobStore 事件(postgres) failed: %w", err)
 		}
-		jobEventStore = pgEventStore
-		pgJobStore, err := job.NewJobStorePg(context.Background(), dsn)
+		jobEventStore = jobstore.NewPostgresStoreWithPool(eventPool, leaseDur)
+		// 事件载荷压缩：超过阈值的载荷以 zstd 落库，读取时透明解压
+		compressor, err := app.EventPayloadCompressorFrom(bootstrap.Config)
+		if err != nil {
+			return nil, fmt.Errorf("初始化事件载荷压缩failed: %w", err)
+		}
+		if s, ok := jobEventStore.(jobstore.PayloadCompressionSetter); ok && compressor != nil {
+			s.SetPayloadCompressor(compressor)
+		}
+		// Postgres 短暂不可用时读写按幂等性重试，熔断期间快速失败
+		var dbGuard *pgretry.Guard
+		if !bootstrap.Config.JobStore.Resilience.Disable {
+			dbGuard = pgretry.New("jobstore", pgretry.PolicyFromConfig(bootstrap.Config.JobStore.Resilience), bootstrap.Logger.Logger)
+			if s, ok := jobEventStore.(jobstore.ResilienceGuardSetter); ok {
+				s.SetResilienceGuard(dbGuard)
+			}
+		}
+		jobsPool, err = pgPools.Pool(context.Background(), pgpool.ComponentJobs, dsn)
 		if err != nil {
 			return nil, fmt.Errorf("初始化 Job 元数据(postgres) failed: %w", err)
 		}
+		pgJobStore := job.NewJobStorePgWithPool(jobsPool)
+		pgJobStore.SetResilienceGuard(dbGuard)
 		jobStore = pgJobStore
+		// 载荷级加密：jobs.goal 与事件中的 goal/message 只以密文落库（Worker 使用相同密钥解密执行）
+		keyring, err := payloadcrypt.NewFromConfig(bootstrap.Config.PayloadEncryption)
+		if err != nil {
+			return nil, fmt.Errorf("初始化载荷加密failed: %w", err)
+		}
+		if keyring != nil {
+			pgJobStore.SetGoalSealer(keyring)
+			jobEventStore = payloadcrypt.NewSealingStore(jobEventStore, keyring, bootstrap.Config.PayloadEncryption.Fields, payloadcrypt.TenantFromJobs(jobStore))
+			bootstrap.Logger.Info("载荷级加密已启用", "tenants", len(bootstrap.Config.PayloadEncryption.Keys))
+		}
 		bootstrap.Logger.Info("JobStore 使用 PostgreSQL 后端", "dsn", dsn)
 	} else {
 		jobStore = job.NewJobStoreMem()
 		jobEventStore = jobstore.NewMemoryStore()
 	}
-	var invocationStore agentexec.ToolInvocationStore
-	if bootstrap.Config != nil && bootstrap.Config.JobStore.Type == "postgres" && bootstrap.Config.JobStore.DSN != "" {
-		invPoolConfig, errPool := pgxpool.ParseConfig(bootstrap.Config.JobStore.DSN)
-		if errPool != nil {
-			return nil, fmt.Errorf("解析 ToolInvocationStore DSN failed: %w", errPool)
+	// 发起归属：每条事件记录创建 Job 的用户、客户端与原始请求 ID
+	jobEventStore = job.NewAttributingStore(jobEventStore, jobStore)
+	// 租户维护窗口：窗口内新建 Job 置为 Deferred，运行中 Job 按窗口配置在 step 边界暂停，窗口结束后自动恢复
+	var maintStore job.MaintenanceStore = job.NewMaintenanceStoreMem()
+	if jobsPool != nil {
+		maintStore = job.NewMaintenanceStorePg(jobsPool)
+	}
+	// 监督者子 Job 关联：spawn/join 节点由 Worker 写入，API 用于 Trace 层级展示
+	var childJobStore job.ChildJobStore = job.NewChildJobStoreMem()
+	if jobsPool != nil {
+		childJobStore = job.NewChildJobStorePg(jobsPool)
+	}
+	// 规划 few-shot 示例库：按 Agent 维护「目标 → 优质 TaskGraph」，LLM 规划时按相似度注入并统计计划有效率
+	var exemplarStore planner.ExemplarStore = planner.NewExemplarStoreMem()
+	if jobsPool != nil {
+		exemplarStore = planner.NewExemplarStorePg(jobsPool)
+	}
+	planValidity := planner.NewPlanValidityTracker()
+	if llmPlanner, ok := v1Planner.(*planner.LLMPlanner); ok {
+		var opts planner.ExemplarOptions
+		if bootstrap.Config != nil {
+			opts.TopK = bootstrap.Config.Agent.PlannerExemplars.TopK
+			opts.ControlRatio = bootstrap.Config.Agent.PlannerExemplars.ControlRatio
+		}
+		llmPlanner.SetExemplars(exemplarStore, opts, planValidity)
+	}
+	handler.SetPlannerExemplars(exemplarStore, planValidity)
+	// 目标模板：按 Agent 维护具名目标 + 参数 schema，chat/API 校验参数后渲染为 Job 目标
+	var goalTemplates goaltemplate.Store = goaltemplate.NewStoreMem()
+	if jobsPool != nil {
+		goalTemplates = goaltemplate.NewStorePg(jobsPool)
+	}
+	handler.SetGoalTemplates(goalTemplates)
+	// 回归集：按 Agent 维护黄金目标与断言，运行报告按租户保存历史
+	var evalSuites evalsuite.Store = evalsuite.NewStoreMem()
+	if jobsPool != nil {
+		evalSuites = evalsuite.NewStorePg(jobsPool)
+	}
+	handler.SetEvalSuites(evalSuites)
+	// Trace 概览筛选预设：按租户 + 用户保存的 Agent、状态、时间范围组合
+	var traceFilters tracefilter.Store = tracefilter.NewStoreMem()
+	if jobsPool != nil {
+		traceFilters = tracefilter.NewStorePg(jobsPool)
+	}
+	handler.SetTraceFilters(traceFilters)
+	// 租户品牌：Trace 页面页头与证据包离线报告的标题、Logo 与自定义字段
+	var brandingStore branding.Store = branding.NewStoreMem()
+	if jobsPool != nil {
+		brandingStore = branding.NewStorePg(jobsPool)
+	}
+	handler.SetBranding(brandingStore)
+	// 限时调试会话（break-glass）：临时开放 Job payload，开启 / 访问 / 关闭写入按租户哈希链接的审计记录
+	var debugSessions debugsession.Store = debugsession.NewStoreMem()
+	if jobsPool != nil {
+		debugSessions = debugsession.NewStorePg(jobsPool)
+	}
+	handler.SetDebugSessions(debugSessions)
+	// Worker 服务账号：签发/轮换/吊销 Worker 身份令牌（与 Worker 共享 service_accounts 表）
+	var saStore serviceaccount.Store = serviceaccount.NewStoreMem()
+	if jobsPool != nil {
+		saStore = serviceaccount.NewStorePg(jobsPool)
+	}
+	handler.SetServiceAccounts(saStore)
+	// 全局工具熔断：POST /api/admin/killswitch 按类别禁用工具，执行侧（本进程与 Worker）经 Gate 判定
+	var killSwitchStore killswitch.Store = killswitch.NewStoreMem()
+	if jobsPool != nil {
+		killSwitchStore = killswitch.NewStorePg(jobsPool)
+	}
+	killSwitchGate := app.NewKillSwitchGate(bootstrap.Config, killSwitchStore)
+	handler.SetKillSwitch(killSwitchStore, killSwitchGate)
+	maintGate := job.NewMaintenanceGate(maintStore, 0)
+	var maintController *job.MaintenanceController
+	if deferred, ok := jobStore.(job.DeferredJobStore); ok {
+		maintController = job.NewMaintenanceController(maintStore, deferred, 0)
+	}
+	// PII 检测：写入时扫描工具输入/输出与 LLM 消息，按类别打标签
+	var piiTags piitag.TagStore
+	if bootstrap.Config != nil && bootstrap.Config.PII.Enable {
+		piiTags = piitag.NewTagStoreMem()
+		if pgPools != nil {
+			piiPool, errPII := pgPools.Pool(context.Background(), pgpool.ComponentPIITags, bootstrap.Config.JobStore.DSN)
+			if errPII != nil {
+				return nil, fmt.Errorf("初始化 PII 标签存储(postgres) failed: %w", errPII)
+			}
+			piiTags = piitag.NewTagStorePg(piiPool)
+		}
+		jobEventStore = piitag.NewTaggingStore(jobEventStore, pii.NewDetector(bootstrap.Config.PII.Categories), piiTags, bootstrap.Config.PII.EventTypes, bootstrap.Logger)
+		bootstrap.Logger.Info("PII 检测已启用", "categories", bootstrap.Config.PII.Categories)
+	}
+	// 事件全文检索：写入时抽取目标、工具输出、错误与推理快照文本入索引，GET /api/search 按租户查询
+	if bootstrap.Config != nil && bootstrap.Config.EventSearch.Enable {
+		var searchIndex eventsearch.Index = eventsearch.NewIndexMem()
+		if pgPools != nil {
+			searchPool, errSearch := pgPools.Pool(context.Background(), pgpool.ComponentEventSearch, bootstrap.Config.JobStore.DSN)
+			if errSearch != nil {
+				return nil, fmt.Errorf("初始化事件检索索引(postgres) failed: %w", errSearch)
+			}
+			searchIndex = eventsearch.NewIndexPg(searchPool)
+		}
+		jobEventStore = app.WrapEventSearch(bootstrap.Config, jobEventStore, searchIndex, jobStore, bootstrap.Logger)
+		handler.SetEventSearch(searchIndex)
+	}
+	// 事件导出：选定事件经 outbox 异步发布到 Kafka/NATS（at-least-once）
+	var eventRelay *eventexport.Relay
+	if bootstrap.Config != nil && bootstrap.Config.EventExport.Enable {
+		var outbox eventexport.Outbox = eventexport.NewOutboxMem()
+		if pgPools != nil {
+			outboxPool, errOutbox := pgPools.Pool(context.Background(), pgpool.ComponentEventOutbox, bootstrap.Config.JobStore.DSN)
+			if errOutbox != nil {
+				return nil, fmt.Errorf("初始化事件导出 outbox(postgres) failed: %w", errOutbox)
+			}
+			outbox = eventexport.NewOutboxPg(outboxPool)
+		}
+		exportStore, relay, errExport := eventexport.NewFromConfig(bootstrap.Config.EventExport, jobEventStore, outbox, bootstrap.Logger)
+		if errExport != nil {
+			return nil, fmt.Errorf("初始化事件导出 failed: %w", errExport)
 		}
-		invPool, errPool := pgxpool.NewWithConfig(context.Background(), invPoolConfig)
+		jobEventStore = exportStore
+		eventRelay = relay
+	}
+	// Job 证明记录：终态事件写入后固化 execution_hash 与事件链根，事件被留存策略删除后 /verify 据此降级验证
+	var attestationStore verify.AttestationStore = verify.NewAttestationStoreMem()
+	if jobsPool != nil {
+		attestationStore = verify.NewAttestationStorePg(jobsPool)
+	}
+	jobEventStore = verify.NewAttestingStore(jobEventStore, attestationStore, bootstrap.Logger)
+	handler.SetAttestationStore(attestationStore)
+	var invocationStore agentexec.ToolInvocationStore
+	if pgPools != nil {
+		invPool, errPool := pgPools.Pool(context.Background(), pgpool.ComponentInvocations, bootstrap.Config.JobStore.DSN)
 		if errPool != nil {
 			return nil, fmt.Errorf("创建 ToolInvocationStore 连接池failed: %w", errPool)
 		}
@@ -355,11 +695,10 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 	}
 	var effectStore agentexec.EffectStore
 	if bootstrap.Config != nil && bootstrap.Config.EffectStore.Type == "postgres" && bootstrap.Config.EffectStore.DSN != "" {
-		effPoolConfig, errPool := pgxpool.ParseConfig(bootstrap.Config.EffectStore.DSN)
-		if errPool != nil {
-			return nil, fmt.Errorf("解析 EffectStore DSN failed: %w", errPool)
+		if pgPools == nil {
+			pgPools = pgpool.NewManager(bootstrap.Config.JobStore.Pool)
 		}
-		effPool, errPool := pgxpool.NewWithConfig(context.Background(), effPoolConfig)
+		effPool, errPool := pgPools.Pool(context.Background(), pgpool.ComponentEffects, bootstrap.Config.EffectStore.DSN)
 		if errPool != nil {
 			return nil, fmt.Errorf("创建 EffectStore 连接池failed: %w", errPool)
 		}
@@ -393,66 +732,206 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		toolRateLimiter = agentexec.NewToolRateLimiter(toolLimiterConfigs, toolDefaults)
 		bootstrap.Logger.Info("Tool 限流已启用", "tools", len(toolLimiterConfigs))
 	}
-	dagCompiler = NewDAGCompilerWithOptions(llmClientForAgent, toolsReg, engine, nodeEventSink, nodeEventSink, invocationStore, effectStore, resourceVerifier, NewAttemptValidator(jobEventStore), toolRateLimiter)
+	// Agent 级配置：API 管理，工具执行前解析（secret_ref 经 secret store）并注入 sdk.ConfigFromContext
+	var agentCfgStore agentconfig.Store = agentconfig.NewStoreMem()
+	if jobsPool != nil {
+		agentCfgStore = agentconfig.NewStorePg(jobsPool)
+	}
+	var secretsCfg config.SecretsConfig
+	if bootstrap.Config != nil {
+		secretsCfg = bootstrap.Config.Secrets
+	}
+	secretStore, errSecrets := agentconfig.NewSecretStoreFromConfig(secretsCfg)
+	if errSecrets != nil {
+		return nil, fmt.Errorf("初始化 secret store failed: %w", errSecrets)
+	}
+	handler.SetAgentConfig(agentCfgStore)
+	dagCompiler = NewDAGCompilerWithOptions(llmClientForAgent, toolsReg, engine, nodeEventSink, nodeEventSink, invocationStore, effectStore, resourceVerifier, NewAttemptValidator(jobEventStore), toolRateLimiter, agentconfig.NewResolver(agentCfgStore, secretStore))
+	SetToolKillSwitch(dagCompiler, killSwitchGate)
+	SetToolCapabilityPolicy(dagCompiler, capabilityPolicy)
+	SetToolOutputLimits(dagCompiler, app.ToolOutputLimitsFrom(bootstrap.Config))
+	// 集群级工具信号量：Postgres 模式下与 Worker 共享 tool_semaphore_* 表
+	semaphoreStore := jobstore.NewSemaphoreStoreMem()
+	if pgPools != nil {
+		semPool, errSem := pgPools.Pool(context.Background(), pgpool.ComponentToolSemaphores, bootstrap.Config.JobStore.DSN)
+		if errSem != nil {
+			return nil, fmt.Errorf("初始化工具信号量存储(postgres) failed: %w", errSem)
+		}
+		semaphoreStore = jobstore.NewSemaphoreStorePg(semPool)
+	}
+	SetToolSemaphores(dagCompiler, app.ToolSemaphoresFrom(bootstrap.Config, toolsReg, semaphoreStore))
+	egressPolicies, errEgress := app.ToolEgressFrom(bootstrap.Config, bootstrap.Logger)
+	if errEgress != nil {
+		return nil, fmt.Errorf("初始化租户出网策略 failed: %w", errEgress)
+	}
+	SetToolEgress(dagCompiler, egressPolicies)
+	// LLM prompt 留存：进程内执行的 llm 节点同样留存；handler 读取 Worker 写入的留存内容
+	var promptLogCfg config.PromptLogConfig
+	if bootstrap.Config != nil {
+		promptLogCfg = bootstrap.Config.Agent.PromptLog
+	}
+	promptLogger, errPromptLog := promptlog.NewFromConfig(context.Background(), promptLogCfg)
+	if errPromptLog != nil {
+		return nil, fmt.Errorf("初始化 prompt 留存failed: %w", errPromptLog)
+	}
+	if promptLogger != nil {
+		SetPromptLogger(dagCompiler, promptLogger)
+		handler.SetPromptLog(promptLogger.Store(), promptLogCfg.Prefix)
+	}
+	// 事件归档冷读：Job 或其事件已从热存储清理时，trace/replay/events 从对象存储归档读取
+	if bootstrap.Config != nil {
+		archiveReader, errArchive := eventarchive.NewReaderFromConfig(bootstrap.Config.JobStore.Archive)
+		if errArchive != nil {
+			return nil, fmt.Errorf("初始化事件归档冷读failed: %w", errArchive)
+		}
+		if archiveReader != nil {
+			handler.SetEventArchive(archiveReader)
+			bootstrap.Logger.Info("事件归档冷读已启用", "prefix", bootstrap.Config.JobStore.Archive.Prefix, "expected_latency", archiveReader.ExpectedLatency())
+		}
+	}
 	dagRunner = NewDAGRunner(dagCompiler)
 	var agentStateStore runtime.AgentStateStore
 	if bootstrap.Config != nil && bootstrap.Config.JobStore.Type == "postgres" && bootstrap.Config.JobStore.DSN != "" {
-		pgState, errState := runtime.NewAgentStateStorePg(context.Background(), bootstrap.Config.JobStore.DSN)
+		statePool, errState := pgPools.Pool(context.Background(), pgpool.ComponentAgentState, bootstrap.Config.JobStore.DSN)
 		if errState != nil {
 			return nil, fmt.Errorf("初始化 AgentStateStore(postgres) failed: %w", errState)
 		}
-		agentStateStore = pgState
+		agentStateStore = runtime.NewAgentStateStorePgWithPool(statePool)
 	} else {
 		agentStateStore = runtime.NewAgentStateStoreMem()
 	}
 	var agentInstanceStore instance.AgentInstanceStore
-	if bootstrap.Config != nil && bootstrap.Config.JobStore.Type == "postgres" && bootstrap.Config.JobStore.DSN != "" {
-		pgInst, errInst := instance.NewStorePg(context.Background(), bootstrap.Config.JobStore.DSN)
-		if errInst != nil {
-			return nil, fmt.Errorf("初始化 AgentInstanceStore(postgres) failed: %w", errInst)
-		}
-		agentInstanceStore = pgInst
+	if jobsPool != nil {
+		agentInstanceStore = instance.NewStorePgWithPool(jobsPool)
 	} else {
 		agentInstanceStore = instance.NewStoreMem()
 	}
 	handler.SetAgentInstanceStore(agentInstanceStore)
 	var agentMessagingBus messaging.AgentMessagingBus
 	if bootstrap.Config != nil && bootstrap.Config.JobStore.Type == "postgres" && bootstrap.Config.JobStore.DSN != "" {
-		pgBus, errBus := messaging.NewStorePg(context.Background(), bootstrap.Config.JobStore.DSN)
+		busPool, errBus := pgPools.Pool(context.Background(), pgpool.ComponentMessaging, bootstrap.Config.JobStore.DSN)
 		if errBus != nil {
 			return nil, fmt.Errorf("初始化 AgentMessagingBus(postgres) failed: %w", errBus)
 		}
-		agentMessagingBus = pgBus
+		agentMessagingBus = messaging.NewStorePgWithPool(busPool)
 	} else {
 		agentMessagingBus = messaging.NewStoreMem()
 	}
 	handler.SetAgentMessagingBus(agentMessagingBus)
 	if bootstrap.Config != nil && bootstrap.Config.JobStore.Type == "postgres" && bootstrap.Config.JobStore.DSN != "" {
-		ingestPoolConfig, errIngest := pgxpool.ParseConfig(bootstrap.Config.JobStore.DSN)
-		if errIngest == nil {
-			if ingestPool, errIngest := pgxpool.NewWithConfig(context.Background(), ingestPoolConfig); errIngest == nil {
-				handler.SetIngestQueue(ingestqueue.NewIngestQueuePg(ingestPool))
-			}
+		if ingestPool, errIngest := pgPools.Pool(context.Background(), pgpool.ComponentIngestQueue, bootstrap.Config.JobStore.DSN); errIngest == nil {
+			handler.SetIngestQueue(ingestqueue.NewIngestQueuePg(ingestPool))
+		} else {
+			bootstrap.Logger.Warn("创建入库队列连接池failed，异步入库不可用", "error", errIngest)
 		}
 	}
 	checkpointStore := runtime.NewCheckpointStoreMem()
 	if bootstrap.Config != nil && bootstrap.Config.CheckpointStore.Type == "postgres" && bootstrap.Config.CheckpointStore.DSN != "" {
-		cpPoolConfig, errPool := pgxpool.ParseConfig(bootstrap.Config.CheckpointStore.DSN)
-		if errPool != nil {
-			return nil, fmt.Errorf("解析 CheckpointStore DSN failed: %w", errPool)
+		if pgPools == nil {
+			pgPools = pgpool.NewManager(bootstrap.Config.JobStore.Pool)
 		}
-		cpPool, errPool := pgxpool.NewWithConfig(context.Background(), cpPoolConfig)
+		cpPool, errPool := pgPools.Pool(context.Background(), pgpool.ComponentCheckpoints, bootstrap.Config.CheckpointStore.DSN)
 		if errPool != nil {
 			return nil, fmt.Errorf("创建 CheckpointStore 连接池failed: %w", errPool)
 		}
 		checkpointStore = runtime.NewCheckpointStorePg(cpPool)
 	}
 	dagRunner.SetCheckpointStores(checkpointStore, &jobStoreForRunnerAdapter{JobStore: jobStore})
-	dagRunner.SetPlanGeneratedSink(NewPlanGeneratedSink(jobEventStore))
+	handler.SetCheckpointStore(checkpointStore)
+	// 漂移对账：非终态 Job 的事件流 / 工具调用账本 / 检查点交叉校验（jobstore.reconcile）
+	var reconciler *reconcile.Reconciler
+	if bootstrap.Config != nil && bootstrap.Config.JobStore.Reconcile.Enable && jobEventStore != nil {
+		if lister, ok := jobStore.(job.ActiveJobLister); ok {
+			reconciler = reconcile.NewReconciler(lister, jobEventStore, invocationStore, checkpointStore, reconcile.Options{
+				Lease:   parseDuration(bootstrap.Config.JobStore.LeaseDuration, reconcile.DefaultLease),
+				MaxJobs: bootstrap.Config.JobStore.Reconcile.MaxJobs,
+			}, bootstrap.Logger)
+			handler.SetReconciler(reconciler)
+		}
+	}
+	// Job 时长预测：周期学习已完成 Job，Job 详情与 Trace 页附带 ETA（agent.eta）
+	var etaLearner *eta.Learner
+	if bootstrap.Config != nil && bootstrap.Config.Agent.ETA.Enable && jobEventStore != nil {
+		if lister, ok := jobStore.(job.RecentJobLister); ok {
+			estimator := eta.NewEstimator(bootstrap.Config.Agent.ETA.Alpha)
+			etaLearner = eta.NewLearner(estimator, lister, jobEventStore, bootstrap.Logger)
+			handler.SetETAEstimator(estimator)
+		}
+	}
+	// 数据仓库导出：终态 Job 规范化为 jobs / steps / tool_calls / llm_calls 批量写入对象存储（warehouse_export）
+	var warehouseExporter *warehouse.Exporter
+	if bootstrap.Config != nil && bootstrap.Config.WarehouseExport.Enable && jobEventStore != nil {
+		whCfg := bootstrap.Config.WarehouseExport
+		scanner, ok := jobStore.(job.TerminalJobScanner)
+		if !ok {
+			return nil, fmt.Errorf("warehouse_export 需要支持按水位线扫描终态 Job 的 jobstore")
+		}
+		whObjects, errWh := object.NewStore(whCfg.Storage)
+		if errWh != nil {
+			return nil, fmt.Errorf("初始化数据仓库导出存储failed: %w", errWh)
+		}
+		var whState warehouse.Store = warehouse.NewStoreMem()
+		if jobsPool != nil {
+			whState = warehouse.NewStorePg(jobsPool)
+		}
+		warehouseExporter = warehouse.NewExporter(scanner, jobEventStore, whObjects, whState, warehouse.Options{
+			Format:         whCfg.Format,
+			Prefix:         whCfg.Prefix,
+			BatchSize:      whCfg.BatchSize,
+			SettleDelay:    parseDuration(whCfg.SettleDelay, warehouse.DefaultSettleDelay),
+			Backfill:       parseDuration(whCfg.BackfillWindow, 0),
+			Tenants:        whCfg.Tenants,
+			ExcludeTenants: whCfg.ExcludeTenants,
+			SampleRate:     whCfg.SampleRate,
+			Costs:          planCostModel,
+		}, bootstrap.Logger)
+	}
+	// Agent 行为异常：按 Agent 学习工具/调用量/成本/时长基线，偏离明显的终态 Job 写入事件并告警（agent.anomaly）
+	var anomalyLearner *anomaly.Learner
+	if bootstrap.Config != nil && bootstrap.Config.Agent.Anomaly.Enable && jobEventStore != nil {
+		if lister, ok := jobStore.(job.RecentJobLister); ok {
+			detector := anomaly.NewDetector(app.AnomalyOptionsFrom(bootstrap.Config), planCostModel, toolsReg.Categories)
+			anomalyLearner = anomaly.NewLearner(detector, lister, jobEventStore, app.AnomalyNotifierFrom(bootstrap.Config), bootstrap.Logger)
+			handler.SetAnomalyDetector(detector)
+		}
+	}
+	// Job 失败诊断：LLM 总结失败原因写入 job_diagnosis 事件，并附诊断投递失败 Webhook（agent.diagnosis）
+	var diagnosisScanner *diagnosis.Scanner
+	if bootstrap.Config != nil && jobEventStore != nil {
+		dc := bootstrap.Config.Agent.Diagnosis
+		notifier := app.FailureNotifierFrom(bootstrap.Config)
+		var diagnoser *diagnosis.Diagnoser
+		if dc.Enable && llmClientForAgent != nil {
+			diagnoser = diagnosis.NewDiagnoser(llmClientForAgent, app.DiagnosisOptionsFrom(bootstrap.Config))
+		} else if dc.Enable {
+			bootstrap.Logger.Warn("agent.diagnosis 已启用但未配置 LLM，仅投递失败通知")
+		}
+		if lister, ok := jobStore.(job.RecentJobLister); ok && (diagnoser != nil || notifier != nil) {
+			diagnosisScanner = diagnosis.NewScanner(diagnoser, lister, jobEventStore, notifier, bootstrap.Logger)
+		}
+	}
+	dagRunner.SetPlanGeneratedSink(NewPlanGeneratedSinkWithCostModel(jobEventStore, planCostModel))
 	dagRunner.SetNodeEventSink(nodeEventSink)
 	dagRunner.SetRecordedEffectsRecorder(NewRecordedEffectsRecorder(jobEventStore))
-	dagRunner.SetReplayContextBuilder(NewReplayContextBuilder(jobEventStore))
-	dagRunner.SetReplayPolicy(replaysandbox.DefaultPolicy{})
+	dagRunner.SetReplayContextBuilder(NewReplayContextBuilderWithConfig(jobEventStore, bootstrap.Config.Runtime.Replay))
+	// 显式声明幂等的工具（ToolManifest.idempotent / agent.idempotent_tools）Replay 时无已记录结果可重新执行
+	dagRunner.SetReplayPolicy(replaysandbox.NewIdempotentToolPolicy(replaysandbox.DefaultPolicy{}, toolsReg.Idempotency))
+	dagRunner.SetScratchpadLimits(app.ScratchpadLimitsFrom(bootstrap.Config))
+	// Job 工作区：工具经 sdk.WorkspaceFromContext 在步骤间传递文件，终态后按保留期清理（agent.workspace）
+	workspaces, err := app.NewWorkspaceManager(bootstrap.Config)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 Job 工作区failed: %w", err)
+	}
+	if workspaces != nil {
+		dagRunner.SetWorkspaces(func(jobID string) sdk.Workspace { return workspaces.For(jobID) })
+		handler.SetWorkspaces(workspaces)
+	}
+	dagRunner.SetStepBoundaryGate(func(ctx context.Context, j *agentexec.JobForRunner) bool {
+		return maintGate.ShouldPark(ctx, j.TenantID)
+	})
+	if reflectionCfg, ok := app.ReflectionConfigFrom(bootstrap.Config); ok && llmClientForAgent != nil {
+		dagRunner.SetReflection(NewDAGReflector(llmClientForAgent), reflectionCfg)
+	}
 	if bootstrap.Config != nil && bootstrap.Config.Worker.Timeout != "" {
 		if d, err := time.ParseDuration(bootstrap.Config.Worker.Timeout); err == nil && d > 0 {
 			dagRunner.SetStepTimeout(d)
@@ -482,6 +961,21 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 			}
 		}
 	}
+	// 交互式预留通道：额外槽位只认领 interactive Job，且其单步超时更紧
+	var laneCfg config.InteractiveLaneConfig
+	if bootstrap.Config != nil {
+		laneCfg = bootstrap.Config.Agent.JobScheduler.InteractiveLane
+	}
+	interactiveReserved, interactiveStepTimeout := app.InteractiveLaneFrom(laneCfg)
+	// 执行档位：提交时按 profile 解析，执行时据此设置并行、超时、计划校验与审批
+	var profilesCfg config.ExecutionProfilesConfig
+	if bootstrap.Config != nil {
+		profilesCfg = bootstrap.Config.Agent.ExecutionProfiles
+	}
+	executionProfiles, err := app.ExecutionProfilesFrom(profilesCfg)
+	if err != nil {
+		return nil, fmt.Errorf("agent.execution_profiles: %w", err)
+	}
 	runJob := func(ctx context.Context, j *job.Job) error {
 		agent, _ := agentRuntimeManager.Get(ctx, j.AgentID)
 		if agent == nil {
@@ -494,36 +988,55 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		if err := waitPlanReady(ctx, j.ID, 20*time.Second); err != nil {
 			return err
 		}
-		err := dagRunner.RunForJob(ctx, agent, &agentexec.JobForRunner{
-			ID: j.ID, AgentID: j.AgentID, Goal: j.Goal, Cursor: j.Cursor, TenantID: tenantID,
-		})
+		features, _ := compat.JobFeatures(j.RequiredCapabilities)
+		jr := &agentexec.JobForRunner{
+			ID: j.ID, AgentID: j.AgentID, Goal: j.Goal, Cursor: j.Cursor, TenantID: tenantID, Features: features,
+		}
+		if j.Interactive {
+			jr.StepTimeout = interactiveStepTimeout
+		}
+		jr.Profile = executionProfiles.Get(j.Profile)
+		err := dagRunner.RunForJob(ctx, agent, jr)
 		if agentStateStore != nil && agent.Session != nil {
-			_ = agentStateStore.SaveAgentState(ctx, j.AgentID, agent.Session.ID, runtime.SessionToAgentState(agent.Session))
+			if states, errScope := runtime.NewScopedAgentStateStore(agentStateStore, runtime.MemoryScope{TenantID: tenantID, AgentID: j.AgentID}); errScope == nil {
+				_ = states.Save(ctx, agent.Session.ID, runtime.SessionToAgentState(agent.Session))
+			}
+		}
+		if errors.Is(err, agentexec.ErrJobDeferred) || errors.Is(err, agentexec.ErrJobWaiting) {
+			// 维护窗口内在 step 边界暂停（Deferred），或在 Wait 节点挂起（Waiting/Parked）；恢复后继续执行，不写终端事件
+			return err
 		}
-		// 事件流补全：执行结束后追加 JobCompleted / JobFailed，便于审计与回放
+		// 事件流补全：执行结束后追加 JobCompleted / JobFailed（含终态元数据），便于审计与回放
 		if jobEventStore != nil {
-			_, ver, _ := jobEventStore.ListEvents(ctx, j.ID)
+			events, ver, _ := jobEventStore.ListEvents(ctx, j.ID)
 			evType := jobstore.JobCompleted
 			pl := map[string]interface{}{"goal": j.Goal}
+			var info *job.TerminalInfo
 			if err != nil {
 				evType = jobstore.JobFailed
+				info = app.FailureTerminalInfo(err, "api-scheduler", events)
+				for k, v := range info.EventFields() {
+					pl[k] = v
+				}
 				pl["error"] = err.Error()
 				var sf *agentexec.StepFailure
 				if errors.As(err, &sf) {
 					pl["result_type"] = string(sf.Type)
-					pl["node_id"] = sf.FailedNodeID()
-					pl["reason"] = err.Error()
 				}
 			}
 			payload, _ := json.Marshal(pl)
 			_, _ = jobEventStore.Append(ctx, j.ID, ver, jobstore.JobEvent{JobID: j.ID, Type: evType, Payload: payload})
+			if info != nil {
+				_ = job.RecordTerminalInfo(ctx, jobStore, j.ID, info)
+			}
 		}
 		return err
 	}
 	schedulerConfig := job.SchedulerConfig{
-		MaxConcurrency: 2,
-		RetryMax:       2,
-		Backoff:        time.Second,
+		MaxConcurrency:      2,
+		RetryMax:            2,
+		Backoff:             time.Second,
+		InteractiveReserved: interactiveReserved,
 	}
 	if bootstrap.Config != nil {
 		sc := bootstrap.Config.Agent.JobScheduler
@@ -541,22 +1054,135 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		}
 	}
 	jobScheduler := job.NewScheduler(jobStore, runJob, schedulerConfig)
+	jobScheduler.SetMaintenanceGate(maintGate)
+	if bootstrap.Config != nil {
+		if fair := app.FairShareFrom(bootstrap.Config.Agent.JobScheduler.FairShare, jobStore); fair != nil {
+			jobScheduler.SetFairShare(fair)
+			bootstrap.Logger.Info("租户公平调度已启用", "tenants", len(bootstrap.Config.Agent.JobScheduler.FairShare.Tenants))
+		}
+	}
 	handler.SetJobStore(jobStore)
+	handler.SetMaintenance(maintStore, maintGate)
+	handler.SetChildJobStore(childJobStore)
+	// 版本协商：新 Job 默认要求的特性，创建时按在线 Worker 的握手信息决定路由或降级
+	requiredFeatures, unknownFeatures := app.JobRequiredFeaturesFrom(bootstrap.Config)
+	if len(unknownFeatures) > 0 {
+		bootstrap.Logger.Warn("agent.compat.required_features 含未知特性，已忽略", "features", unknownFeatures)
+	}
+	handler.SetJobRequiredFeatures(requiredFeatures)
+	// Worker 状态心跳（资源感知认领节流状态）：由 Worker 写入 worker_status，API 只读展示
+	if jobsPool != nil {
+		handler.SetWorkerStatusStore(scheduler.NewWorkerStatusStorePg(jobsPool))
+	}
 	if pgStore, ok := jobStore.(*job.JobStorePg); ok {
 		handler.SetObservabilityReader(pgStore)
 	}
+	// 消息受理背压：按 Pending 积压分级跳过可选工作、对低优先级租户返回 429
+	if bootstrap.Config != nil && bootstrap.Config.Agent.Backpressure.Enable {
+		bp := bootstrap.Config.Agent.Backpressure
+		if pgStore, ok := jobStore.(*job.JobStorePg); ok {
+			policy := job.BackpressurePolicy{
+				ShedBacklog:     bp.ShedBacklog,
+				ThrottleBacklog: bp.ThrottleBacklog,
+				RejectBacklog:   bp.RejectBacklog,
+				RetryAfter:      parseDuration(bp.RetryAfter, 30*time.Second),
+				TenantPriority:  bp.TenantPriority,
+				DefaultPriority: bp.DefaultPriority,
+			}
+			handler.SetBackpressure(job.NewBackpressureGate(pgStore, policy, parseDuration(bp.SampleInterval, 5*time.Second)))
+		} else {
+			bootstrap.Logger.Warn("agent.backpressure 需要 jobstore.type=postgres，已忽略")
+		}
+	}
 	handler.SetJobEventStore(jobEventStore)
+	if piiTags != nil {
+		handler.SetPIITagStore(piiTags)
+		if bootstrap.Config.PII.RedactExport {
+			mode := redaction.RedactionMode(bootstrap.Config.PII.RedactMode)
+			if mode == "" {
+				mode = redaction.RedactionModeRedact
+			}
+			handler.SetPIIExportRedaction(mode, bootstrap.Config.PII.RedactSalt)
+		}
+	}
+	if bootstrap.Config != nil && bootstrap.Config.API.Forensics.Evidence.Enable {
+		evCfg := bootstrap.Config.API.Forensics.Evidence
+		evStore, errEv := object.NewStore(evCfg.Storage)
+		if errEv != nil {
+			return nil, fmt.Errorf("初始化证据包存储failed: %w", errEv)
+		}
+		prefix := evCfg.Prefix
+		if prefix == "" {
+			prefix = "evidence/"
+		}
+		if lc, ok := evStore.(object.LifecycleConfigurer); ok && evCfg.RetentionDays > 0 {
+			rule := object.LifecycleRule{ID: "aetheris-evidence", Prefix: prefix, ExpirationDays: evCfg.RetentionDays}
+			if errLC := lc.PutLifecycle(context.Background(), []object.LifecycleRule{rule}); errLC != nil {
+				bootstrap.Logger.Info("设置证据包生命周期规则failed", "error", errLC)
+			}
+		}
+		handler.SetEvidenceStorage(evStore, prefix, parseDuration(evCfg.URLExpiry, 15*time.Minute))
+	}
 	handler.SetAgentStateStore(agentStateStore)
 	handler.SetToolsRegistry(toolsReg)
+	handler.SetEnvironmentResolver(app.NewEnvironmentResolver(bootstrap.Config, toolsReg))
+	// trace / events / replay：ETag 协商 + 按 (job_id, 最新事件版本) 的短期服务端缓存
+	var readCacheCfg config.ReadCacheConfig
+	if bootstrap.Config != nil {
+		readCacheCfg = bootstrap.Config.API.ReadCache
+	}
+	handler.SetReadCache(parseDuration(readCacheCfg.TTL, http.DefaultReadCacheTTL), readCacheCfg.MaxEntries)
 	// 1.0 Plan 事件化：Job 创建时即生成并持久化 TaskGraph，执行阶段只读
 	if jobEventStore != nil {
 		handler.SetPlanAtJobCreation(PlanGoalForJobFunc(agentRuntimeManager, v1Planner))
 	}
+	handler.SetPlanCostModel(planCostModel)
+	handler.SetPlanBudgetPolicy(app.PlanBudgetPolicyFrom(bootstrap.Config), bootstrap.Config.Agent.PlanCost.OnExceed)
+	handler.SetDebugRunner(NewDebugRunner(llmClientForAgent, toolsReg))
+	handler.SetExecutionProfiles(executionProfiles)
+	if bootstrap.Config != nil && bootstrap.Config.Agent.JobDedup.Window != "" {
+		if d, err := time.ParseDuration(bootstrap.Config.Agent.JobDedup.Window); err == nil && d > 0 {
+			handler.SetJobDedupWindow(d)
+		}
+	}
 
+	if bootstrap.Config != nil {
+		i18nCfg := bootstrap.Config.API.I18n
+		if i18nCfg.CatalogDir != "" {
+			if err := i18n.LoadDir(i18nCfg.CatalogDir); err != nil {
+				return nil, fmt.Errorf("加载消息目录failed: %w", err)
+			}
+		}
+		if i18nCfg.DefaultLocale != "" {
+			i18n.SetDefaultLocale(i18nCfg.DefaultLocale)
+		}
+	}
 	mw := middleware.NewMiddleware()
 	router := http.NewRouter(handler, mw)
+	// 访问日志：采样 + 慢请求捕获（api.access_log），慢请求经 GET /api/admin/slow-requests 查看
+	accessLogger := middleware.NewAccessLogger(app.AccessLogConfigFrom(bootstrap.Config))
+	router.SetAccessLogger(accessLogger)
+	handler.SetAccessLogger(accessLogger)
+	// 请求截止时间：经 ctx 传入 store / LLM 调用，挂起的查询不再无限占用 Hertz 协程（api.timeout、api.timeouts.routes）
+	router.SetRequestTimeout(middleware.NewRequestTimeout(app.RequestTimeoutConfigFrom(bootstrap.Config)))
 	if bootstrap.Config != nil {
 		router.SetForensicsExperimental(bootstrap.Config.API.Forensics.Experimental)
+		handler.SetTraceMaskPolicy(http.NewTraceMaskPolicy(bootstrap.Config.API.TraceMasking.Fields))
+		handler.SetAnalytics(app.AnalyticsOptionsFrom(bootstrap.Config))
+		handler.SetWorkerInspectToken(bootstrap.Config.Worker.Inspect.Token)
+	}
+	// 入站 Webhook：外部系统回调验签后转为 Job signal/message（api.inbound_webhooks）
+	inboundWebhooks, err := app.InboundWebhooksFrom(bootstrap.Config)
+	if err != nil {
+		return nil, fmt.Errorf("初始化入站 Webhook failed: %w", err)
+	}
+	handler.SetInboundWebhooks(inboundWebhooks)
+	if bootstrap.Residency != nil {
+		handler.SetResidency(bootstrap.Residency)
+		if local := bootstrap.Residency.Resolver().LocalRegion(); local != "" {
+			router.SetResidency(bootstrap.Residency.Resolver())
+			bootstrap.Logger.Info("数据驻留已启用", "local_region", local)
+		}
 	}
 
 	if bootstrap.Config != nil && bootstrap.Config.API.Middleware.Auth && bootstrap.Config.API.Middleware.JWTKey != "" {
@@ -591,6 +1217,67 @@ func NewApp(bootstrap *app.Bootstrap) (*App, error) {
 		router:       router,
 		hertz:        nil,
 		jobScheduler: jobScheduler,
+		pgPools:      pgPools,
+		eventRelay:   eventRelay,
+		maintenance:  maintController,
+		externalHost: externalHost,
+		readiness:    readiness,
+		preflightLLM: map[string]llm.Client{app.LLMUseCaseGeneration: llmClientForAgent, app.LLMUseCasePlanner: llmClientForPlanner},
+	}
+	if pgPools != nil {
+		pgPools.Start(func(component string, err error) {
+			bootstrap.Logger.Warn("Postgres 连接池健康检查failed", "component", component, "error", err)
+		})
+		bootstrap.Logger.Info("Postgres 连接池管理已启用", "allocated_conns", pgPools.Allocated())
+	}
+	if eventRelay != nil {
+		eventRelay.Start(context.Background())
+		bootstrap.Logger.Info("事件导出已启用", "sink", bootstrap.Config.EventExport.Sink, "format", bootstrap.Config.EventExport.Format)
+	}
+	if maintController != nil {
+		maintController.Start(context.Background())
+	}
+	if freshnessRefresher != nil {
+		refreshCtx, cancel := context.WithCancel(context.Background())
+		appObj.freshnessStop = cancel
+		go freshnessRefresher.Run(refreshCtx, parseDuration(bootstrap.Config.Storage.Freshness.ScanInterval, freshness.DefaultScanInterval))
+		bootstrap.Logger.Info("知识库定时重新抓取已启用", "refresh_interval", bootstrap.Config.Storage.Freshness.RefreshInterval)
+	}
+	if reconciler != nil {
+		reconcileCtx, cancel := context.WithCancel(context.Background())
+		appObj.reconcileStop = cancel
+		go reconciler.Run(reconcileCtx, parseDuration(bootstrap.Config.JobStore.Reconcile.Interval, reconcile.DefaultInterval))
+		bootstrap.Logger.Info("漂移对账已启用", "interval", bootstrap.Config.JobStore.Reconcile.Interval)
+	}
+	if etaLearner != nil {
+		etaCtx, cancel := context.WithCancel(context.Background())
+		appObj.etaStop = cancel
+		go etaLearner.Run(etaCtx, parseDuration(bootstrap.Config.Agent.ETA.LearnInterval, eta.DefaultLearnInterval))
+		bootstrap.Logger.Info("Job 时长预测已启用", "learn_interval", bootstrap.Config.Agent.ETA.LearnInterval)
+	}
+	if warehouseExporter != nil {
+		whCtx, cancel := context.WithCancel(context.Background())
+		appObj.warehouseStop = cancel
+		go warehouseExporter.Run(whCtx, parseDuration(bootstrap.Config.WarehouseExport.Interval, warehouse.DefaultInterval))
+		bootstrap.Logger.Info("数据仓库导出已启用", "format", bootstrap.Config.WarehouseExport.Format, "prefix", warehouseExporter.StreamName())
+	}
+	if workspaces != nil {
+		wsCtx, cancel := context.WithCancel(context.Background())
+		appObj.workspaceStop = cancel
+		go workspace.NewJanitor(workspaces, jobStore, bootstrap.Logger).Run(wsCtx, parseDuration(bootstrap.Config.Agent.Workspace.CleanupInterval, workspace.DefaultCleanupInterval))
+		bootstrap.Logger.Info("Job 工作区已启用", "backend", workspaces.Backend(), "retention", workspaces.Retention())
+	}
+	if anomalyLearner != nil {
+		anomalyCtx, cancel := context.WithCancel(context.Background())
+		appObj.anomalyStop = cancel
+		go anomalyLearner.Run(anomalyCtx, parseDuration(bootstrap.Config.Agent.Anomaly.ScanInterval, anomaly.DefaultScanInterval))
+		bootstrap.Logger.Info("Agent 行为异常检测已启用", "scan_interval", bootstrap.Config.Agent.Anomaly.ScanInterval)
+	}
+	if diagnosisScanner != nil {
+		diagnosisCtx, cancel := context.WithCancel(context.Background())
+		appObj.diagnosisStop = cancel
+		go diagnosisScanner.Run(diagnosisCtx, parseDuration(bootstrap.Config.Agent.Diagnosis.ScanInterval, diagnosis.DefaultScanInterval))
+		bootstrap.Logger.Info("Job 失败诊断已启用", "llm", bootstrap.Config.Agent.Diagnosis.Enable, "webhook", bootstrap.Config.Agent.Diagnosis.Webhook.URL != "")
 	}
 	if bootstrap.Config != nil && bootstrap.Config.API.Grpc.Enable && bootstrap.Config.API.Grpc.Port > 0 {
 		gs, err := startGRPC(engine, docService, bootstrap.Config.API.Grpc.Port)
@@ -638,6 +1325,8 @@ func (a *App) Run(addr string) error {
 	)
 	hlog.SetLogger(hertzLogger)
 
+	// 连接读/写/空闲超时（api.timeouts），未配置时沿用 Hertz 默认
+	serverOpts := app.ServerTimeoutOptions(a.config.Config)
 	// 可选：启用链路追踪（OpenTelemetry）
 	if a.config.Config != nil && a.config.Config.Monitoring.Tracing.Enable {
 		serviceName := a.config.Config.Monitoring.Tracing.ServiceName
@@ -659,14 +1348,14 @@ func (a *App) Run(addr string) error {
 			p := provider.NewOpenTelemetryProvider(opts...)
 			a.otelProvider = p
 			tracerOpt, cfg := hertztracing.NewServerTracer()
-			a.hertz = a.router.Build(addr, tracerOpt)
+			a.hertz = a.router.Build(addr, append(serverOpts, tracerOpt)...)
 			a.hertz.Use(hertztracing.ServerMiddleware(cfg))
 			a.config.Logger.Info("链路追踪已启用", "service_name", serviceName, "endpoint", exportEndpoint)
 		} else {
-			a.hertz = a.router.Build(addr)
+			a.hertz = a.router.Build(addr, serverOpts...)
 		}
 	} else {
-		a.hertz = a.router.Build(addr)
+		a.hertz = a.router.Build(addr, serverOpts...)
 	}
 	// 单一执行权 / Control vs Data Plane：jobstore.type=postgres 时 API 不启动 Scheduler，不执行任何 Job（API = 控制面；Worker = 数据面，仅由 Worker 通过事件 Claim 执行）
 	jobSchedulerEnabled := false
@@ -676,12 +1365,32 @@ func (a *App) Run(addr string) error {
 			jobSchedulerEnabled = *a.config.Config.Agent.JobScheduler.Enabled
 		}
 	}
+	// LLM 启动预检：required 时同步执行、failed即退出；否则后台执行，完成前 /readyz 返回 503
+	if a.config.Config != nil && a.config.Config.Model.Preflight.Required {
+		if err := app.RunStartupPreflight(context.Background(), a.config.Config, a.readiness, a.preflightLLM, a.config.Logger); err != nil {
+			return err
+		}
+	} else {
+		go func() {
+			_ = app.RunStartupPreflight(context.Background(), a.config.Config, a.readiness, a.preflightLLM, a.config.Logger)
+		}()
+	}
 	if a.jobScheduler != nil && jobSchedulerEnabled {
 		go a.jobScheduler.Start(context.Background())
 	}
 	return a.hertz.Run()
 }
 
+// HTTPEngine 装配完整路由（含中间件与鉴权）但不监听端口、不启动 Scheduler；进程内集成测试经 ut.PerformRequest 直接驱动
+func (a *App) HTTPEngine() *route.Engine {
+	return a.router.Build(":0").Engine
+}
+
+// RunNextJob 同步认领并执行一条 Pending Job（内存 jobstore 的进程内调度），无可认领 Job 时返回 nil；与 Run 启动的 Scheduler 循环互斥使用
+func (a *App) RunNextJob(ctx context.Context) *job.Job {
+	return a.jobScheduler.RunOnce(ctx)
+}
+
 // Shutdown 优雅关闭（传入 ctx 以支持超时，如 cmd 层 WithTimeout）
 func (a *App) Shutdown(ctx context.Context) error {
 	if a.jobScheduler != nil {
@@ -701,6 +1410,37 @@ func (a *App) Shutdown(ctx context.Context) error {
 	if err := a.engine.Shutdown(); err != nil {
 		return err
 	}
+	if a.eventRelay != nil {
+		a.eventRelay.Stop()
+	}
+	if a.maintenance != nil {
+		a.maintenance.Stop()
+	}
+	if a.freshnessStop != nil {
+		a.freshnessStop()
+	}
+	if a.reconcileStop != nil {
+		a.reconcileStop()
+	}
+	if a.etaStop != nil {
+		a.etaStop()
+	}
+	if a.warehouseStop != nil {
+		a.warehouseStop()
+	}
+	if a.anomalyStop != nil {
+		a.anomalyStop()
+	}
+	if a.diagnosisStop != nil {
+		a.diagnosisStop()
+	}
+	if a.workspaceStop != nil {
+		a.workspaceStop()
+	}
+	a.externalHost.Close()
+	if a.pgPools != nil {
+		a.pgPools.Close()
+	}
 	return nil
 }
 
@@ -716,24 +1456,27 @@ func parseDuration(s string, defaultVal time.Duration) time.Duration {
 	return d
 }
 
-func validateProductionRuntimeConfig(cfg *config.Config) error {
+// freshnessPoliciesFromConfig 将 storage.freshness 转为按集合的新鲜度策略；无效时长视为未配置
+func freshnessPoliciesFromConfig(cfg *config.Config) freshness.Policies {
+	var p freshness.Policies
 	if cfg == nil {
-		return nil
+		return p
 	}
-	prod := cfg.Runtime.Profile == "prod" || cfg.Runtime.Strict
-	if !prod {
-		return nil
+	fc := cfg.Storage.Freshness
+	p.Default = freshness.Policy{
+		MaxAge:          parseDuration(fc.MaxAge, 0),
+		RefreshInterval: parseDuration(fc.RefreshInterval, 0),
 	}
-	if cfg.JobStore.Type != "postgres" || cfg.JobStore.DSN == "" {
-		return fmt.Errorf("production requires jobstore.type=postgres with dsn")
-	}
-	if cfg.EffectStore.Type != "postgres" || cfg.EffectStore.DSN == "" {
-		return fmt.Errorf("production requires effect_store.type=postgres with dsn")
-	}
-	if cfg.CheckpointStore.Type != "postgres" || cfg.CheckpointStore.DSN == "" {
-		return fmt.Errorf("production requires checkpoint_store.type=postgres with dsn")
+	if len(fc.Collections) > 0 {
+		p.Collections = make(map[string]freshness.Policy, len(fc.Collections))
+		for name, c := range fc.Collections {
+			p.Collections[name] = freshness.Policy{
+				MaxAge:          parseDuration(c.MaxAge, 0),
+				RefreshInterval: parseDuration(c.RefreshInterval, 0),
+			}
+		}
 	}
-	return nil
+	return p
 }
 
 // startGRPC 创建并启动 gRPC 服务（在 goroutine 中 Serve），返回 grpcRun 以便 Shutdown 时 GracefulStop
@@ -24,6 +24,7 @@ import (
 	runtimeeffects "rag-platform/internal/agent/runtime/effects"
 	agentexec "rag-platform/internal/agent/runtime/executor"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/config"
 )
 
 // Ensure node_sink implements the extended NodeEventSink with resultType/reason.
@@ -264,6 +265,9 @@ func (s *nodeEventSinkImpl) AppendToolInvocationStarted(ctx context.Context, job
 	if payload.ArgumentsHash != "" {
 		pl["arguments_hash"] = payload.ArgumentsHash
 	}
+	if len(payload.ConfigKeys) > 0 {
+		pl["config_keys"] = payload.ConfigKeys
+	}
 	payloadBytes, err := json.Marshal(pl)
 	if err != nil {
 		return err
@@ -332,6 +336,157 @@ func (s *nodeEventSinkImpl) AppendCommandEmitted(ctx context.Context, jobID stri
 	return err
 }
 
+// AppendLLMPromptLogged 实现 agentexec.PromptLogEventSink；写入 llm_prompt_logged，payload 为对象存储引用，不含 prompt 原文
+func (s *nodeEventSinkImpl) AppendLLMPromptLogged(ctx context.Context, jobID string, nodeID string, ref *agentexec.PromptRef, failed bool) error {
+	if s.store == nil || ref == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"node_id":    nodeID,
+		"prompt_ref": ref,
+		"failed":     failed,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{
+		JobID: jobID, Type: jobstore.LLMPromptLogged, Payload: payload,
+	})
+	return err
+}
+
+// AppendReplayReexecuted 实现 agentexec.ReplayReexecutionSink；幂等工具 Replay 时无已记录结果、按策略重新执行，写入 replay_reexecuted 供审计
+func (s *nodeEventSinkImpl) AppendReplayReexecuted(ctx context.Context, jobID string, pl *jobstore.ReplayReexecutedPayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.ReplayReexecuted, Payload: payload})
+	return err
+}
+
+// AppendDecisionMade 实现 agentexec.DecisionEventSink；branch 节点的分支选择写入 decision_made
+func (s *nodeEventSinkImpl) AppendDecisionMade(ctx context.Context, jobID string, pl *jobstore.DecisionMadePayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.DecisionMade, Payload: payload})
+	return err
+}
+
+// AppendMapIterationStarted 实现 agentexec.MapIterationEventSink；写入 map_iteration_started
+func (s *nodeEventSinkImpl) AppendMapIterationStarted(ctx context.Context, jobID string, pl *jobstore.MapIterationPayload) error {
+	return s.appendMapIteration(ctx, jobID, jobstore.MapIterationStarted, pl)
+}
+
+// AppendMapIterationFinished 实现 agentexec.MapIterationEventSink；写入 map_iteration_finished（含该次尝试的结果或错误）
+func (s *nodeEventSinkImpl) AppendMapIterationFinished(ctx context.Context, jobID string, pl *jobstore.MapIterationPayload) error {
+	return s.appendMapIteration(ctx, jobID, jobstore.MapIterationFinished, pl)
+}
+
+func (s *nodeEventSinkImpl) appendMapIteration(ctx context.Context, jobID string, typ jobstore.EventType, pl *jobstore.MapIterationPayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: payload})
+	return err
+}
+
+// AppendCustomEvent 实现 agentexec.CustomEventSink；写入 custom_event，不参与 Replay
+func (s *nodeEventSinkImpl) AppendCustomEvent(ctx context.Context, jobID string, pl *jobstore.CustomEventPayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.CustomEvent, Payload: payload})
+	return err
+}
+
+// AppendToolProgressState 实现 agentexec.ToolProgressSink；写入长时工具的步内检查点，参与 Replay（崩溃后从最近检查点续跑）
+func (s *nodeEventSinkImpl) AppendToolProgressState(ctx context.Context, jobID string, pl *jobstore.ToolProgressStatePayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.ToolProgressState, Payload: payload})
+	return err
+}
+
+// AppendToolOutputSpilled 实现 agentexec.ToolOutputSpillSink；记录超限工具输出的溢出/截断决定，不参与 Replay
+func (s *nodeEventSinkImpl) AppendToolOutputSpilled(ctx context.Context, jobID string, pl *jobstore.ToolOutputSpilledPayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.ToolOutputSpilled, Payload: payload})
+	return err
+}
+
+// AppendTransactionEvent 实现 agentexec.TransactionEventSink；写入事务组边界事件，不参与 Replay
+func (s *nodeEventSinkImpl) AppendTransactionEvent(ctx context.Context, jobID string, typ jobstore.EventType, pl *jobstore.TransactionPayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: typ, Payload: payload})
+	return err
+}
+
 // AppendStepCommitted 实现 NodeEventSink；写入 step_committed 显式屏障（2.0 Exactly-Once），顺序在 node_finished 之后
 func (s *nodeEventSinkImpl) AppendStepCommitted(ctx context.Context, jobID string, nodeID string, stepID string, commandID string, idempotencyKey string) error {
 	if s.store == nil {
@@ -538,11 +693,37 @@ func (s *nodeEventSinkImpl) AppendPlanEvolution(ctx context.Context, jobID strin
 	return err
 }
 
+// AppendPlanEvolutionProposal 实现 executor.PlanEvolutionSink；Self-Reflection 提议以完整 payload 写入 plan_evolution
+func (s *nodeEventSinkImpl) AppendPlanEvolutionProposal(ctx context.Context, jobID string, pl *jobstore.PlanEvolutionPayload) error {
+	if s.store == nil || pl == nil {
+		return nil
+	}
+	_, ver, err := s.store.ListEvents(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(pl)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanEvolution, Payload: payload})
+	return err
+}
+
 // NewReplayContextBuilder 创建从事件流重建 ReplayContext 的 Builder（供 Runner 无 Checkpoint 时恢复）
 func NewReplayContextBuilder(store jobstore.JobStore) replay.ReplayContextBuilder {
 	return replay.NewReplayContextBuilder(store)
 }
 
+// NewReplayContextBuilderWithConfig 按 runtime.replay 配置创建 Builder（增量构建、并行解析）
+func NewReplayContextBuilderWithConfig(store jobstore.JobStore, cfg config.ReplayConfig) replay.ReplayContextBuilder {
+	return replay.NewReplayContextBuilderWithOptions(store, replay.BuilderOptions{
+		Incremental:   cfg.Incremental,
+		MaxCachedJobs: cfg.MaxCachedJobs,
+		DecodeWorkers: cfg.DecodeWorkers,
+	})
+}
+
 // recordedEffectsRecorderImpl 将 Recorded Effects（time/uuid/http）追加到事件流，实现 runtime/effects.RecordedEffectsRecorder
 type recordedEffectsRecorderImpl struct {
 	store jobstore.JobStore
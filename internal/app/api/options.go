@@ -0,0 +1,38 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"rag-platform/internal/model/llm"
+	"rag-platform/internal/tool"
+)
+
+// Option NewApp 可选项；生产入口（cmd/api）不传，进程内集成测试（internal/testutil）用于注入确定性的工具与 LLM
+type Option func(*options)
+
+type options struct {
+	tools []tool.Tool
+	llm   llm.Client
+}
+
+// WithTools 额外注册工具（与内置工具、外部 Worker 工具共用注册表，参与规划与执行）
+func WithTools(tools ...tool.Tool) Option {
+	return func(o *options) { o.tools = append(o.tools, tools...) }
+}
+
+// WithLLMClient 使用给定客户端作为生成与规划 LLM（优先于 model 配置；限流与模型路由配置仍生效）
+func WithLLMClient(c llm.Client) Option {
+	return func(o *options) { o.llm = c }
+}
@@ -20,13 +20,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 
+	"rag-platform/internal/agent/planner"
 	"rag-platform/internal/agent/runtime/executor"
 	"rag-platform/internal/runtime/jobstore"
 )
 
 // PlanGeneratedSinkImpl 将 Plan 结果写入事件流，供 Trace/Replay 使用
 type PlanGeneratedSinkImpl struct {
-	store jobstore.JobStore
+	store     jobstore.JobStore
+	costModel *planner.CostModel // 可选；非 nil 时 payload 附带计划成本/ETA 预估
 }
 
 // NewPlanGeneratedSink 创建 PlanGenerated 事件写入器；store 为 nil 时不写入
@@ -34,6 +36,11 @@ func NewPlanGeneratedSink(store jobstore.JobStore) executor.PlanGeneratedSink {
 	return &PlanGeneratedSinkImpl{store: store}
 }
 
+// NewPlanGeneratedSinkWithCostModel 创建附带成本/ETA 预估的 PlanGenerated 事件写入器
+func NewPlanGeneratedSinkWithCostModel(store jobstore.JobStore, model planner.CostModel) executor.PlanGeneratedSink {
+	return &PlanGeneratedSinkImpl{store: store, costModel: &model}
+}
+
 // AppendPlanGenerated 实现 executor.PlanGeneratedSink
 func (s *PlanGeneratedSinkImpl) AppendPlanGenerated(ctx context.Context, jobID string, taskGraphJSON []byte, goal string) error {
 	if s.store == nil {
@@ -56,7 +63,7 @@ func (s *PlanGeneratedSinkImpl) AppendPlanGenerated(ctx context.Context, jobID s
 		h := sha256.Sum256([]byte(canonical))
 		planInputHash = hex.EncodeToString(h[:16])
 	}
-	payload, err := json.Marshal(map[string]interface{}{
+	fields := map[string]interface{}{
 		"task_graph":      json.RawMessage(taskGraphJSON),
 		"goal":            goal,
 		"plan_hash":       planHash,      // 决策记录完整性校验与调试（design/workflow-decision-record.md）
@@ -65,7 +72,14 @@ func (s *PlanGeneratedSinkImpl) AppendPlanGenerated(ctx context.Context, jobID s
 		"trace_span_id":   "plan",
 		"parent_span_id":  "root",
 		"step_index":      stepIndex,
-	})
+	}
+	if s.costModel != nil {
+		var g planner.TaskGraph
+		if errGraph := g.Unmarshal(taskGraphJSON); errGraph == nil {
+			fields["estimate"] = planner.EstimateTaskGraph(&g, *s.costModel)
+		}
+	}
+	payload, err := json.Marshal(fields)
 	if err != nil {
 		return err
 	}
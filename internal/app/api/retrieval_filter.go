@@ -0,0 +1,54 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/auth"
+)
+
+// retrievalFilter 返回本次检索应下推的过滤表达式：ctx 已注入（WithFilter）时直接使用；
+// 否则按请求方身份生成文档 ACL 过滤——Agent 执行链路以 "agent:<id>" 为用户，HTTP 请求以 auth 的 user/role 为准
+func retrievalFilter(ctx context.Context) *vector.FilterExpr {
+	if f := vector.FilterFromContext(ctx); f != nil {
+		return f
+	}
+	if agent := agentexec.AgentFromContext(ctx); agent != nil {
+		return vector.ACLFilter("agent:"+agent.ID, nil)
+	}
+	var roles []string
+	if userID := auth.GetUserID(ctx); userID != "" {
+		roles = []string{string(auth.GetRole(ctx))}
+		return vector.ACLFilter(userID, roles)
+	}
+	return vector.ACLFilter("", nil)
+}
+
+// docVisible 检索结果的兜底校验：后端未支持过滤下推（如 redis）时按文档元数据再过滤一次
+func docVisible(f *vector.FilterExpr, meta map[string]any) bool {
+	if f == nil {
+		return true
+	}
+	m := make(map[string]string, len(meta))
+	for k, v := range meta {
+		if s, ok := v.(string); ok {
+			m[k] = s
+		}
+	}
+	return f.Match(m)
+}
@@ -18,8 +18,10 @@ import (
 	"fmt"
 
 	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/residency"
 	"rag-platform/internal/storage/vector"
 	"rag-platform/pkg/config"
+	"rag-platform/pkg/idgen"
 	"rag-platform/pkg/log"
 )
 
@@ -29,6 +31,8 @@ type Bootstrap struct {
 	Logger        *log.Logger
 	MetadataStore metadata.Store
 	VectorStore   vector.Store
+	// Residency 数据驻留开启时的区域存储工厂；此时 MetadataStore / VectorStore 为按租户路由的存储
+	Residency *residency.Factory
 }
 
 // NewBootstrap 根据配置创建 Bootstrap（DB/Cache/Models/Storage）
@@ -44,6 +48,12 @@ func NewBootstrap(cfg *config.Config) (*Bootstrap, error) {
 		return nil, fmt.Errorf("初始化日志failed: %w", err)
 	}
 
+	if cfg != nil {
+		if err := idgen.Configure(cfg.ID.Strategy); err != nil {
+			return nil, fmt.Errorf("初始化 ID 生成策略failed: %w", err)
+		}
+	}
+
 	var metaStore metadata.Store
 	if cfg != nil {
 		metaStore, err = metadata.NewStore(cfg.Storage.Metadata)
@@ -64,10 +74,34 @@ func NewBootstrap(cfg *config.Config) (*Bootstrap, error) {
 		}
 	}
 
+	var residencyFactory *residency.Factory
+	if cfg != nil && cfg.Residency.Enable {
+		residencyFactory, err = NewResidencyFactory(cfg.Residency)
+		if err != nil {
+			return nil, err
+		}
+		metaStore = residency.NewMetadataStore(residencyFactory)
+		vecStore = residency.NewVectorStore(residencyFactory)
+	}
+
 	return &Bootstrap{
 		Config:        cfg,
 		Logger:        logger,
 		MetadataStore: metaStore,
 		VectorStore:   vecStore,
+		Residency:     residencyFactory,
 	}, nil
 }
+
+// NewResidencyFactory 按驻留配置创建各区域的元数据/向量存储
+func NewResidencyFactory(cfg config.ResidencyConfig) (*residency.Factory, error) {
+	resolver, err := residency.NewResolver(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("初始化数据驻留failed: %w", err)
+	}
+	factory, err := residency.NewFactory(resolver)
+	if err != nil {
+		return nil, fmt.Errorf("初始化数据驻留存储failed: %w", err)
+	}
+	return factory, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/tool/builtin"
+	"rag-platform/internal/tool/codeexec"
+	"rag-platform/pkg/config"
+)
+
+// BuiltinCodeExecTool 按 agent.code_exec 创建 code_exec 工具；未启用时返回 nil
+func BuiltinCodeExecTool(cfg *config.Config) (*builtin.CodeExecTool, error) {
+	if cfg == nil || !cfg.Agent.CodeExec.Enable {
+		return nil, nil
+	}
+	c := cfg.Agent.CodeExec
+	runner, err := codeexec.NewRunner(c.Backend, c.Binary, c.OCIRuntime, c.Images, c.WasmModules)
+	if err != nil {
+		return nil, err
+	}
+	return builtin.NewCodeExecTool(runner,
+		builtin.WithCodeExecLimits(codeexec.Limits{
+			Timeout:          parseOptionalDuration(c.Timeout),
+			CPUs:             c.CPUs,
+			MemoryMB:         c.MemoryMB,
+			PIDs:             c.PIDs,
+			Network:          c.Network,
+			MaxOutputBytes:   c.MaxOutputBytes,
+			MaxArtifactBytes: c.MaxArtifactBytes,
+			MaxArtifacts:     c.MaxArtifacts,
+		}),
+		builtin.WithCodeExecCapability(c.Capability),
+	), nil
+}
+
+// NewCapabilityPolicy 按 agent.capability_policy 创建执行前策略；启用 code_exec 且未显式配置其 capability 时，
+// 该 capability 默认 require_approval。均未配置时返回 nil（不校验）
+func NewCapabilityPolicy(cfg *config.Config) (executor.CapabilityPolicyChecker, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	pc := cfg.Agent.CapabilityPolicy
+	policies := make(map[string]string, len(pc.Capabilities)+1)
+	for capability, policy := range pc.Capabilities {
+		policies[capability] = policy
+	}
+	if cfg.Agent.CodeExec.Enable {
+		capability := cfg.Agent.CodeExec.Capability
+		if capability == "" {
+			capability = builtin.DefaultCodeExecCapability
+		}
+		if _, ok := policies[capability]; !ok {
+			policies[capability] = executor.CapabilityPolicyRequireApproval
+		}
+	}
+	if pc.Default == "" && len(policies) == 0 {
+		return nil, nil
+	}
+	return executor.NewStaticCapabilityPolicy(pc.Default, policies)
+}
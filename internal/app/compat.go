@@ -0,0 +1,35 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/compat"
+	"rag-platform/pkg/config"
+)
+
+// JobRequiredFeaturesFrom 读取 agent.compat.required_features；本版本不识别的特性单独返回（调用方告警后忽略），避免所有 Job 因无法协商被拒
+func JobRequiredFeaturesFrom(cfg *config.Config) (features, unknown []string) {
+	if cfg == nil {
+		return nil, nil
+	}
+	for _, f := range cfg.Agent.Compat.RequiredFeatures {
+		if compat.KnownFeature(f) {
+			features = append(features, f)
+		} else {
+			unknown = append(unknown, f)
+		}
+	}
+	return features, unknown
+}
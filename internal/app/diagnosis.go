@@ -0,0 +1,42 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/internal/agent/diagnosis"
+	"rag-platform/pkg/config"
+)
+
+// DiagnosisOptionsFrom 将 agent.diagnosis 转为诊断参数；未配置的字段由 diagnosis 使用默认值
+func DiagnosisOptionsFrom(cfg *config.Config) diagnosis.Options {
+	if cfg == nil {
+		return diagnosis.Options{}
+	}
+	dc := cfg.Agent.Diagnosis
+	timeout, _ := time.ParseDuration(dc.Timeout)
+	return diagnosis.Options{MaxEvents: dc.MaxEvents, MaxPayloadChars: dc.MaxPayloadChars, Timeout: timeout}
+}
+
+// FailureNotifierFrom 按 agent.diagnosis.webhook 创建失败通知出口；未配置 url 时返回 nil
+func FailureNotifierFrom(cfg *config.Config) diagnosis.Notifier {
+	if cfg == nil || cfg.Agent.Diagnosis.Webhook.URL == "" {
+		return nil
+	}
+	wh := cfg.Agent.Diagnosis.Webhook
+	timeout, _ := time.ParseDuration(wh.Timeout)
+	return diagnosis.NewWebhookNotifier(wh.URL, wh.Secret, timeout)
+}
@@ -16,10 +16,15 @@ package app
 
 import (
 	"context"
+	"errors"
 
 	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/vector"
 )
 
+// ErrVersionsUnsupported 元数据存储未实现 metadata.VersionStore，无版本历史可查
+var ErrVersionsUnsupported = errors.New("metadata store does not track document versions")
+
 // DocumentInfo 文档信息 DTO，供 API 层使用，不依赖 storage 具体类型
 type DocumentInfo struct {
 	ID          string            `json:"id"`
@@ -40,16 +45,18 @@ type DocumentService interface {
 	ListDocuments(ctx context.Context) ([]*DocumentInfo, error)
 	GetDocument(ctx context.Context, id string) (*DocumentInfo, error)
 	DeleteDocument(ctx context.Context, id string) error
+	ListVersions(ctx context.Context, id string) ([]*metadata.DocumentVersion, error)
 }
 
 // documentService 使用 metadata.Store 实现 DocumentService
 type documentService struct {
-	store metadata.Store
+	store    metadata.Store
+	vecStore vector.Store // 非空时删除文档同步删除其当前版本的切片
 }
 
-// NewDocumentService 创建文档门面（由 bootstrap 或 app 装配时调用）
-func NewDocumentService(store metadata.Store) DocumentService {
-	return &documentService{store: store}
+// NewDocumentService 创建文档门面（由 bootstrap 或 app 装配时调用）；vecStore 可为 nil
+func NewDocumentService(store metadata.Store, vecStore vector.Store) DocumentService {
+	return &documentService{store: store, vecStore: vecStore}
 }
 
 func (s *documentService) ListDocuments(ctx context.Context) ([]*DocumentInfo, error) {
@@ -72,10 +79,34 @@ func (s *documentService) GetDocument(ctx context.Context, id string) (*Document
 	return docToInfo(d), nil
 }
 
+// DeleteDocument 删除文档：先删除当前版本的切片，再标记版本为 deleted，最后删除文档记录
 func (s *documentService) DeleteDocument(ctx context.Context, id string) error {
+	if vs, ok := s.store.(metadata.VersionStore); ok {
+		versions, err := vs.ListVersions(ctx, id)
+		if err != nil {
+			return err
+		}
+		if cur := metadata.CurrentVersion(versions); cur != nil && s.vecStore != nil {
+			if err := vector.DeleteVectors(ctx, s.vecStore, cur.Collection, cur.ChunkIDs); err != nil {
+				return err
+			}
+		}
+		if err := vs.MarkDeleted(ctx, id); err != nil {
+			return err
+		}
+	}
 	return s.store.Delete(ctx, id)
 }
 
+// ListVersions 返回文档版本历史（文档删除后仍可查询）
+func (s *documentService) ListVersions(ctx context.Context, id string) ([]*metadata.DocumentVersion, error) {
+	vs, ok := s.store.(metadata.VersionStore)
+	if !ok {
+		return nil, ErrVersionsUnsupported
+	}
+	return vs.ListVersions(ctx, id)
+}
+
 func docToInfo(d *metadata.Document) *DocumentInfo {
 	if d == nil {
 		return nil
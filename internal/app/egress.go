@@ -0,0 +1,34 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/runtime/egress"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/log"
+)
+
+// ToolEgressFrom 按 agent.egress 创建租户出网策略；未启用时返回 nil（不限制）
+func ToolEgressFrom(cfg *config.Config, logger *log.Logger) (*egress.Policies, error) {
+	if cfg == nil || !cfg.Agent.Egress.Enable {
+		return nil, nil
+	}
+	ec := cfg.Agent.Egress
+	tenants := make(map[string]egress.TenantPolicy, len(ec.Tenants))
+	for tenant, tc := range ec.Tenants {
+		tenants[tenant] = egress.TenantPolicy{Trusted: tc.Trusted, Allow: tc.Allow}
+	}
+	return egress.NewPolicies(ec.DefaultAllow, tenants, logger)
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/compat"
+	"rag-platform/internal/agent/environment"
+	"rag-platform/internal/agent/tools"
+	"rag-platform/pkg/config"
+)
+
+// NewEnvironmentResolver 按当前配置与工具注册表创建 Job 执行环境解析器：
+// 模型取 defaults 与 fallback 链，策略哈希覆盖 capability_policy 与 execution_profiles，配置哈希覆盖整个 agent 配置块
+func NewEnvironmentResolver(cfg *config.Config, reg *tools.Registry) *environment.Resolver {
+	r := &environment.Resolver{PlatformVersion: compat.BuildVersion()}
+	if cfg != nil {
+		if m, ok := resolveModel(cfg, cfg.Model.Defaults.LLM, "default", 0); ok {
+			r.Models = append(r.Models, m)
+		}
+		for i, key := range cfg.Model.Fallback.Planner {
+			if m, ok := resolveModel(cfg, key, "planner", i); ok {
+				r.Models = append(r.Models, m)
+			}
+		}
+		for i, key := range cfg.Model.Fallback.Generation {
+			if m, ok := resolveModel(cfg, key, "generation", i); ok {
+				r.Models = append(r.Models, m)
+			}
+		}
+		if provider, modelKey, err := parseDefaultKey(cfg.Model.Defaults.Embedding); err == nil {
+			if mi, ok := cfg.Model.Embedding.Providers[provider].Models[modelKey]; ok {
+				r.Models = append(r.Models, environment.Model{Role: "embedding", Provider: provider, Name: mi.Name, Version: mi.Version})
+			}
+		}
+		r.PolicyHash = environment.HashJSON(map[string]any{
+			"capability_policy":  cfg.Agent.CapabilityPolicy,
+			"execution_profiles": cfg.Agent.ExecutionProfiles,
+		})
+		r.ConfigHash = environment.HashJSON(cfg.Agent)
+	}
+	if reg != nil {
+		r.Tools = func() []environment.Tool {
+			manifests := reg.Manifests()
+			out := make([]environment.Tool, 0, len(manifests))
+			for _, m := range manifests {
+				h := environment.HashJSON(m.InputSchema)
+				if len(h) > 16 {
+					h = h[:16]
+				}
+				out = append(out, environment.Tool{Name: m.Name, Version: m.Version, SchemaHash: h})
+			}
+			return out
+		}
+	}
+	return r
+}
+
+// resolveModel 将 provider.model_key 解析为模型名与版本；未配置的 key 跳过
+func resolveModel(cfg *config.Config, key, role string, rank int) (environment.Model, bool) {
+	if key == "" {
+		return environment.Model{}, false
+	}
+	provider, modelKey, err := parseDefaultKey(key)
+	if err != nil {
+		return environment.Model{}, false
+	}
+	mi, ok := cfg.Model.LLM.Providers[provider].Models[modelKey]
+	if !ok {
+		return environment.Model{}, false
+	}
+	return environment.Model{Role: role, Rank: rank, Provider: provider, Name: mi.Name, Version: mi.Version}, true
+}
@@ -0,0 +1,28 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/config"
+)
+
+// EventPayloadCompressorFrom 将 jobstore.compression 转为载荷压缩器；未启用时返回 nil（只解压不压缩）
+func EventPayloadCompressorFrom(cfg *config.Config) (*jobstore.PayloadCompressor, error) {
+	if cfg == nil || !cfg.JobStore.Compression.Enable {
+		return nil, nil
+	}
+	return jobstore.NewPayloadCompressor(cfg.JobStore.Compression.MinBytes, cfg.JobStore.Compression.Level)
+}
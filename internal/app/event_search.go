@@ -0,0 +1,43 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/eventsearch"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/runtime/payloadcrypt"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/log"
+)
+
+// EventSearchSkipFields 启用载荷加密时加密字段不入检索索引，避免明文副本落库
+func EventSearchSkipFields(cfg *config.Config) []string {
+	if cfg == nil || !cfg.PayloadEncryption.Enable {
+		return nil
+	}
+	if len(cfg.PayloadEncryption.Fields) > 0 {
+		return cfg.PayloadEncryption.Fields
+	}
+	return payloadcrypt.DefaultFields
+}
+
+// WrapEventSearch 按 event_search 配置用检索索引包装事件存储；未启用时原样返回
+func WrapEventSearch(cfg *config.Config, inner jobstore.JobStore, index eventsearch.Index, jobs job.JobStore, logger *log.Logger) jobstore.JobStore {
+	if cfg == nil || !cfg.EventSearch.Enable || index == nil {
+		return inner
+	}
+	return eventsearch.NewIndexingStore(inner, index, eventsearch.JobsResolver(jobs), EventSearchSkipFields(cfg), cfg.EventSearch.MaxDocBytes, logger)
+}
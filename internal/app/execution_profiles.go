@@ -0,0 +1,46 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/pkg/config"
+)
+
+// ExecutionProfilesFrom 将 agent.execution_profiles 与内置档位（conservative / aggressive）合并为档位表
+func ExecutionProfilesFrom(c config.ExecutionProfilesConfig) (*agentexec.ExecutionProfiles, error) {
+	overrides := make(map[string]*agentexec.ExecutionProfile, len(c.Profiles))
+	for name, pc := range c.Profiles {
+		var stepTimeout time.Duration
+		if pc.StepTimeout != "" {
+			d, err := time.ParseDuration(pc.StepTimeout)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("execution profile %q: invalid step_timeout %q", name, pc.StepTimeout)
+			}
+			stepTimeout = d
+		}
+		overrides[name] = &agentexec.ExecutionProfile{
+			Parallel:           pc.Parallel,
+			Prefetch:           pc.Prefetch,
+			StepTimeout:        stepTimeout,
+			ValidatePlan:       pc.ValidatePlan,
+			ApprovalCategories: pc.ApprovalCategories,
+		}
+	}
+	return agentexec.NewExecutionProfiles(c.Default, overrides)
+}
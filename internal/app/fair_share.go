@@ -0,0 +1,42 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/job"
+	"rag-platform/pkg/config"
+)
+
+// FairShareFrom 按 fair_share 配置创建租户公平调度；未启用或 store 不支持按租户统计负载时返回 nil
+func FairShareFrom(c config.FairShareConfig, store job.JobStore) *job.FairShare {
+	if !c.Enable {
+		return nil
+	}
+	loads, ok := store.(job.TenantLoadReader)
+	if !ok {
+		return nil
+	}
+	cfg := job.FairShareConfig{
+		DefaultWeight:       c.DefaultWeight,
+		StarvationThreshold: parseOptionalDuration(c.StarvationThreshold),
+		Tenants:             make(map[string]job.TenantShare, len(c.Tenants)),
+	}
+	for _, t := range c.Tenants {
+		if t.ID != "" {
+			cfg.Tenants[t.ID] = job.TenantShare{Weight: t.Weight, MinShare: t.MinShare}
+		}
+	}
+	return job.NewFairShare(loads, cfg)
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"rag-platform/internal/agent/inbound"
+	"rag-platform/pkg/config"
+)
+
+// InboundWebhooksFrom 按 api.inbound_webhooks 创建入站通道；未配置时返回 nil
+func InboundWebhooksFrom(cfg *config.Config) (*inbound.Registry, error) {
+	if cfg == nil || len(cfg.API.InboundWebhooks) == 0 {
+		return nil, nil
+	}
+	channels := make([]inbound.Channel, 0, len(cfg.API.InboundWebhooks))
+	for _, wc := range cfg.API.InboundWebhooks {
+		var tolerance time.Duration
+		if wc.Verify.Tolerance != "" {
+			d, err := time.ParseDuration(wc.Verify.Tolerance)
+			if err != nil {
+				return nil, fmt.Errorf("inbound webhook %q: invalid tolerance: %w", wc.Name, err)
+			}
+			tolerance = d
+		}
+		channels = append(channels, inbound.Channel{
+			Name:     wc.Name,
+			TenantID: wc.TenantID,
+			Action:   inbound.Action(wc.Action),
+			Verify: inbound.Verification{
+				Type:      wc.Verify.Type,
+				Header:    wc.Verify.Header,
+				Secret:    wc.Verify.Secret,
+				Algorithm: wc.Verify.Algorithm,
+				Encoding:  wc.Verify.Encoding,
+				Prefix:    wc.Verify.Prefix,
+				Scheme:    wc.Verify.Scheme,
+				Tolerance: tolerance,
+				Issuer:    wc.Verify.Issuer,
+				Audience:  wc.Verify.Audience,
+			},
+			Mapping: inbound.Mapping{
+				JobID:          wc.Mapping.JobID,
+				CorrelationKey: wc.Mapping.CorrelationKey,
+				Channel:        wc.Mapping.Channel,
+				MessageID:      wc.Mapping.MessageID,
+				Payload:        wc.Mapping.Payload,
+			},
+		})
+	}
+	return inbound.NewRegistry(channels)
+}
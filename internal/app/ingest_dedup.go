@@ -0,0 +1,47 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/pipeline/ingest"
+	"rag-platform/pkg/config"
+)
+
+// IngestDedupPoliciesFrom 将 storage.ingest.dedup 转为按集合的去重策略；未配置时 Enabled() 为 false
+func IngestDedupPoliciesFrom(cfg *config.Config) ingest.DedupPolicies {
+	var p ingest.DedupPolicies
+	if cfg == nil {
+		return p
+	}
+	dc := cfg.Storage.Ingest.Dedup
+	p.Default = ingest.DedupPolicy{
+		Enable:        dc.Enable,
+		NearDuplicate: dc.NearDuplicate,
+		Threshold:     dc.Threshold,
+		Action:        dc.Action,
+	}
+	if len(dc.Collections) > 0 {
+		p.Collections = make(map[string]ingest.CollectionDedupPolicy, len(dc.Collections))
+		for name, c := range dc.Collections {
+			p.Collections[name] = ingest.CollectionDedupPolicy{
+				Enable:        c.Enable,
+				NearDuplicate: c.NearDuplicate,
+				Threshold:     c.Threshold,
+				Action:        c.Action,
+			}
+		}
+	}
+	return p
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/pkg/config"
+)
+
+// 交互式通道默认值：预留 1 个额外槽位，单步超时 30s
+const (
+	defaultInteractiveReserved    = 1
+	defaultInteractiveStepTimeout = 30 * time.Second
+)
+
+// InteractiveLaneFrom 解析交互式预留通道配置，返回额外预留的槽位数（0 表示关闭）与交互式 Job 的单步超时
+func InteractiveLaneFrom(c config.InteractiveLaneConfig) (reserved int, stepTimeout time.Duration) {
+	reserved = c.Reserved
+	if reserved == 0 {
+		reserved = defaultInteractiveReserved
+	}
+	if reserved < 0 {
+		reserved = 0
+	}
+	stepTimeout = parseOptionalDuration(c.StepTimeout)
+	if stepTimeout <= 0 {
+		stepTimeout = defaultInteractiveStepTimeout
+	}
+	return reserved, stepTimeout
+}
@@ -17,6 +17,7 @@ package app
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"rag-platform/internal/model/embedding"
 	"rag-platform/internal/model/llm"
@@ -28,7 +29,111 @@ func NewLLMClientFromConfig(cfg *config.Config) (llm.Client, error) {
 	if cfg == nil || cfg.Model.Defaults.LLM == "" {
 		return nil, nil
 	}
-	provider, modelKey, err := parseDefaultKey(cfg.Model.Defaults.LLM)
+	return newLLMClientForKey(cfg, cfg.Model.Defaults.LLM)
+}
+
+// LLM 故障切换链的用途
+const (
+	LLMUseCasePlanner    = "planner"
+	LLMUseCaseGeneration = "generation"
+)
+
+// NewLLMClientForUseCase 根据 model.fallback 为指定用途创建故障切换链；该用途未配置链时返回 nil, nil，调用方沿用默认客户端
+func NewLLMClientForUseCase(cfg *config.Config, useCase string) (llm.Client, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	fb := cfg.Model.Fallback
+	var keys []string
+	switch useCase {
+	case LLMUseCasePlanner:
+		keys = fb.Planner
+	case LLMUseCaseGeneration:
+		keys = fb.Generation
+	default:
+		return nil, fmt.Errorf("unknown LLM use case %q", useCase)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	clients := make([]llm.Client, 0, len(keys))
+	for _, key := range keys {
+		c, err := newLLMClientForKey(cfg, key)
+		if err != nil {
+			return nil, fmt.Errorf("model.fallback.%s: %w", useCase, err)
+		}
+		clients = append(clients, c)
+	}
+	opts := llm.FallbackOptions{UnhealthyBelow: fb.UnhealthyBelow}
+	if fb.AttemptTimeout != "" {
+		d, err := time.ParseDuration(fb.AttemptTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("model.fallback.attempt_timeout: %w", err)
+		}
+		opts.AttemptTimeout = d
+	}
+	if fb.ProbeInterval != "" {
+		d, err := time.ParseDuration(fb.ProbeInterval)
+		if err != nil {
+			return nil, fmt.Errorf("model.fallback.probe_interval: %w", err)
+		}
+		opts.ProbeInterval = d
+	}
+	return llm.NewFallbackClient(useCase, clients, opts), nil
+}
+
+// NewLLMRouterFromConfig 根据 model.routing 创建按步骤选择模型的路由；未启用时返回 nil, nil。
+// def 为 llm 节点未声明要求时使用的客户端（通常为生成用途的故障切换链或 defaults.llm）
+func NewLLMRouterFromConfig(cfg *config.Config, def llm.Client) (*llm.ModelRouter, error) {
+	if cfg == nil || !cfg.Model.Routing.Enable {
+		return nil, nil
+	}
+	rc := cfg.Model.Routing
+	if len(rc.Candidates) == 0 {
+		return nil, fmt.Errorf("model.routing.candidates is empty")
+	}
+	specs := make([]llm.RouteCandidateSpec, 0, len(rc.Candidates))
+	for i, c := range rc.Candidates {
+		client, err := newLLMClientForKey(cfg, c.Model)
+		if err != nil {
+			return nil, fmt.Errorf("model.routing.candidates[%d]: %w", i, err)
+		}
+		quality, err := llm.ParseQuality(c.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("model.routing.candidates[%d]: %w", i, err)
+		}
+		spec := llm.RouteCandidateSpec{Client: client, Quality: quality, Cost: c.Cost}
+		if c.ExpectedLatency != "" {
+			d, err := time.ParseDuration(c.ExpectedLatency)
+			if err != nil {
+				return nil, fmt.Errorf("model.routing.candidates[%d].expected_latency: %w", i, err)
+			}
+			spec.ExpectedLatency = d
+		}
+		specs = append(specs, spec)
+	}
+	if _, err := PlannerRouteRequirement(cfg); err != nil {
+		return nil, err
+	}
+	return llm.NewModelRouter(def, specs, rc.LatencyAlpha), nil
+}
+
+// PlannerRouteRequirement 规划调用固定的路由要求（model.routing.planner_quality，默认 high）
+func PlannerRouteRequirement(cfg *config.Config) (llm.RouteRequirement, error) {
+	q := cfg.Model.Routing.PlannerQuality
+	if q == "" {
+		q = "high"
+	}
+	quality, err := llm.ParseQuality(q)
+	if err != nil {
+		return llm.RouteRequirement{}, fmt.Errorf("model.routing.planner_quality: %w", err)
+	}
+	return llm.RouteRequirement{Quality: quality}, nil
+}
+
+// newLLMClientForKey 按 provider.model_key 创建单个 LLM 客户端
+func newLLMClientForKey(cfg *config.Config, key string) (llm.Client, error) {
+	provider, modelKey, err := parseDefaultKey(key)
 	if err != nil {
 		return nil, err
 	}
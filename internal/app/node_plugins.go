@@ -0,0 +1,43 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/nodeplugin"
+)
+
+// LoadNodePluginManifests 注册 agent.node_plugins.manifest_dir 下的自定义节点 Manifest，返回全部已注册的节点类型
+// （含 Worker 经 build tag 编入的插件）；Manifest 无效时返回错误，避免以不一致的校验规则启动
+func LoadNodePluginManifests(cfg *config.Config) ([]string, error) {
+	if cfg != nil && cfg.Agent.NodePlugins.ManifestDir != "" {
+		manifests, err := nodeplugin.LoadManifestDir(cfg.Agent.NodePlugins.ManifestDir)
+		if err != nil {
+			return nil, fmt.Errorf("加载自定义节点 Manifest failed: %w", err)
+		}
+		for _, m := range manifests {
+			if err := nodeplugin.RegisterManifest(m); err != nil {
+				return nil, fmt.Errorf("注册自定义节点 Manifest failed: %w", err)
+			}
+		}
+	}
+	var types []string
+	for _, p := range nodeplugin.Plugins() {
+		types = append(types, p.Manifest.Type)
+	}
+	return types, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/tools"
+	"rag-platform/pkg/config"
+)
+
+// ApplyPlanCostConfig 将 agent.plan_cost 中的工具标注写入 Registry，并返回计划预估用的 LLM 标注与预算
+func ApplyPlanCostConfig(cfg *config.Config, reg *tools.Registry) (planner.ToolCostHint, planner.PlanBudget) {
+	if cfg == nil {
+		return planner.ToolCostHint{}, planner.PlanBudget{}
+	}
+	pc := cfg.Agent.PlanCost
+	if reg != nil {
+		for name, c := range pc.Tools {
+			reg.Annotate(name, tools.CostHint{ExpectedLatency: parseOptionalDuration(c.ExpectedLatency), CostPerCall: c.CostPerCall})
+		}
+	}
+	llmCost := planner.ToolCostHint{ExpectedLatency: parseOptionalDuration(pc.LLM.ExpectedLatency), CostPerCall: pc.LLM.CostPerCall}
+	return llmCost, planner.PlanBudget{MaxCost: pc.MaxCost, MaxETA: parseOptionalDuration(pc.MaxETA)}
+}
+
+// PlanBudgetPolicyFrom 返回 Job 创建时计划预算护栏的策略：全局预算与按租户预算
+func PlanBudgetPolicyFrom(cfg *config.Config) planner.BudgetPolicy {
+	if cfg == nil {
+		return planner.BudgetPolicy{}
+	}
+	pc := cfg.Agent.PlanCost
+	policy := planner.BudgetPolicy{Default: planner.PlanBudget{MaxCost: pc.MaxCost, MaxETA: parseOptionalDuration(pc.MaxETA)}}
+	if len(pc.Tenants) > 0 {
+		policy.Tenants = make(map[string]planner.PlanBudget, len(pc.Tenants))
+		for tenantID, b := range pc.Tenants {
+			policy.Tenants[tenantID] = planner.PlanBudget{MaxCost: b.MaxCost, MaxETA: parseOptionalDuration(b.MaxETA)}
+		}
+	}
+	return policy
+}
+
+func parseOptionalDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"rag-platform/internal/model/llm"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/log"
+)
+
+// 启动预检状态
+const (
+	StartupPending  = "pending"   // 预检进行中
+	StartupReady    = "ready"     // 预检通过
+	StartupNotReady = "not_ready" // 至少一个模型预检failed
+	StartupDisabled = "disabled"  // 未启用 model.preflight，视为就绪
+)
+
+// StartupReport 进程启动预检报告，经 startup_report 日志与 /readyz 输出
+type StartupReport struct {
+	Component  string               `json:"component"` // api | worker
+	Status     string               `json:"status"`
+	StartedAt  time.Time            `json:"started_at"`
+	DurationMs int64                `json:"duration_ms"`
+	Checks     []llm.PreflightCheck `json:"checks,omitempty"`
+}
+
+// Readiness 保存启动预检结果，供 /readyz 查询；并发安全
+type Readiness struct {
+	mu     sync.RWMutex
+	report StartupReport
+}
+
+// NewReadiness 创建就绪状态；预检完成前为 pending
+func NewReadiness(component string) *Readiness {
+	return &Readiness{report: StartupReport{Component: component, Status: StartupPending, StartedAt: time.Now().UTC()}}
+}
+
+// Readiness 返回是否就绪与当前报告；实现 http.ReadinessProbe
+func (r *Readiness) Readiness() (bool, interface{}) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	report := r.report
+	return report.Status == StartupReady || report.Status == StartupDisabled, report
+}
+
+// ServeHTTP 以 net/http 提供 /readyz（Worker 的 metrics 端口）：就绪 200，否则 503，body 为报告
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	ready, report := r.Readiness()
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// RunStartupPreflight 按 model.preflight 对各用途的 LLM 客户端做凭证校验与连接预热，写入 Readiness 并输出 startup_report 日志。
+// uses 为用途到客户端的映射（如 generation、planner），nil 客户端忽略；required 且未通过时返回 error
+func RunStartupPreflight(ctx context.Context, cfg *config.Config, readiness *Readiness, uses map[string]llm.Client, logger *log.Logger) error {
+	readiness.mu.RLock()
+	report := readiness.report
+	readiness.mu.RUnlock()
+	if cfg == nil || !cfg.Model.Preflight.Enable {
+		report.Status = StartupDisabled
+		readiness.set(report)
+		return nil
+	}
+	pc := cfg.Model.Preflight
+	opts := llm.PreflightOptions{Generate: pc.Generate}
+	if pc.Timeout != "" {
+		d, err := time.ParseDuration(pc.Timeout)
+		if err != nil {
+			return fmt.Errorf("model.preflight.timeout: %w", err)
+		}
+		opts.Timeout = d
+	}
+	clients := make(map[string]llm.Client, len(uses))
+	for name, c := range uses {
+		if c != nil {
+			clients[name] = c
+		}
+	}
+	start := time.Now()
+	report.StartedAt = start.UTC()
+	report.Checks = llm.Preflight(ctx, clients, opts)
+	report.DurationMs = time.Since(start).Milliseconds()
+	report.Status = StartupReady
+	var failed []string
+	for _, c := range report.Checks {
+		if !c.OK {
+			report.Status = StartupNotReady
+			failed = append(failed, c.Provider+"/"+c.Model+": "+c.Error)
+		}
+	}
+	readiness.set(report)
+	if logger != nil {
+		b, _ := json.Marshal(report)
+		if report.Status == StartupReady {
+			logger.Info("startup_report", "component", report.Component, "status", report.Status, "duration_ms", report.DurationMs, "report", string(b))
+		} else {
+			logger.Error("startup_report", "component", report.Component, "status", report.Status, "duration_ms", report.DurationMs, "report", string(b))
+		}
+	}
+	if pc.Required && len(failed) > 0 {
+		return fmt.Errorf("LLM 启动预检failed: %v", failed)
+	}
+	return nil
+}
+
+func (r *Readiness) set(report StartupReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report = report
+}
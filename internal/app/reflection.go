@@ -0,0 +1,35 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/pkg/config"
+)
+
+// ReflectionConfigFrom 将 agent.reflection 转为 Runner 的反思配置；未启用时 ok 为 false
+func ReflectionConfigFrom(cfg *config.Config) (agentexec.ReflectionConfig, bool) {
+	if cfg == nil || !cfg.Agent.Reflection.Enable {
+		return agentexec.ReflectionConfig{}, false
+	}
+	rc := cfg.Agent.Reflection
+	return agentexec.ReflectionConfig{
+		EveryNSteps:     rc.EveryNSteps,
+		OnFailure:       rc.OnFailure,
+		Policy:          rc.Policy,
+		MaxSummaryChars: rc.MaxSummaryChars,
+		MaxRevisions:    rc.MaxRevisions,
+	}, true
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	hconfig "github.com/cloudwego/hertz/pkg/common/config"
+
+	"rag-platform/internal/api/http/middleware"
+	"rag-platform/pkg/config"
+)
+
+// defaultRouteTimeouts 内置的路由截止时间，api.timeouts.routes 未配置该路由时生效：
+// 长轮询最长等待 2m，另留组装响应的时间；流式导出在 handler 返回后继续写出
+var defaultRouteTimeouts = map[string]time.Duration{
+	"/api/jobs/:id/wait": 3 * time.Minute,
+	"/api/jobs/export":   10 * time.Minute,
+}
+
+// RequestTimeoutConfigFrom 将 api.timeout 与 api.timeouts.routes 转为请求截止时间配置；无效时长视为未配置
+func RequestTimeoutConfigFrom(cfg *config.Config) middleware.RequestTimeoutConfig {
+	routes := make(map[string]time.Duration, len(defaultRouteTimeouts))
+	for route, d := range defaultRouteTimeouts {
+		routes[route] = d
+	}
+	if cfg == nil {
+		return middleware.RequestTimeoutConfig{Routes: routes}
+	}
+	def, _ := time.ParseDuration(cfg.API.Timeout)
+	for route, v := range cfg.API.Timeouts.Routes {
+		if d, err := time.ParseDuration(v); err == nil {
+			routes[route] = d
+		}
+	}
+	return middleware.RequestTimeoutConfig{Default: def, Routes: routes}
+}
+
+// ServerTimeoutOptions 将 api.timeouts 的连接超时转为 Hertz 选项；未配置或无效的项沿用 Hertz 默认
+func ServerTimeoutOptions(cfg *config.Config) []hconfig.Option {
+	if cfg == nil {
+		return nil
+	}
+	var opts []hconfig.Option
+	t := cfg.API.Timeouts
+	if d, err := time.ParseDuration(t.ReadTimeout); err == nil && d > 0 {
+		opts = append(opts, server.WithReadTimeout(d))
+	}
+	if d, err := time.ParseDuration(t.WriteTimeout); err == nil && d > 0 {
+		opts = append(opts, server.WithWriteTimeout(d))
+	}
+	if d, err := time.ParseDuration(t.IdleTimeout); err == nil && d > 0 {
+		opts = append(opts, server.WithIdleTimeout(d))
+	}
+	return opts
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"rag-platform/internal/agent/runtime"
+	"rag-platform/pkg/config"
+)
+
+// ScratchpadLimitsFrom 将 agent.scratchpad 转为 Runner 的 scratchpad 限制；未配置的字段由 runtime 使用默认值
+func ScratchpadLimitsFrom(cfg *config.Config) runtime.ScratchpadLimits {
+	if cfg == nil {
+		return runtime.ScratchpadLimits{}
+	}
+	sc := cfg.Agent.Scratchpad
+	return runtime.ScratchpadLimits{
+		MaxValueBytes: sc.MaxValueBytes,
+		MaxTotalBytes: sc.MaxTotalBytes,
+		MaxKeys:       sc.MaxKeys,
+	}
+}
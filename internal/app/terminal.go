@@ -0,0 +1,40 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+
+	"rag-platform/internal/agent/job"
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// FailureTerminalInfo 由执行错误构造 Job 失败的终态元数据：StepFailure 提供失败节点与分类，补偿状态由事件流判定
+func FailureTerminalInfo(err error, actor string, events []jobstore.JobEvent) *job.TerminalInfo {
+	info := &job.TerminalInfo{Actor: actor, FailureClass: job.FailureClassError}
+	if err != nil {
+		info.Reason = err.Error()
+	}
+	var sf *agentexec.StepFailure
+	if errors.As(err, &sf) {
+		info.FailedNodeID = sf.FailedNodeID()
+		if sf.Type != "" {
+			info.FailureClass = string(sf.Type)
+		}
+	}
+	info.Compensation = job.CompensationStatus(events, info.FailureClass)
+	return info
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/internal/agent/killswitch"
+	"rag-platform/internal/agent/tools"
+	"rag-platform/pkg/config"
+)
+
+// ApplyToolCategoriesConfig 将 agent.tool_categories（类别 -> 工具名）写入 Registry，供全局熔断按类别判定
+func ApplyToolCategoriesConfig(cfg *config.Config, reg *tools.Registry) {
+	if cfg == nil || reg == nil {
+		return
+	}
+	for category, names := range cfg.Agent.ToolCategories {
+		category = killswitch.NormalizeCategory(category)
+		if category == "" {
+			continue
+		}
+		for _, name := range names {
+			reg.Categorize(name, category)
+		}
+	}
+}
+
+// ApplyIdempotentToolsConfig 将 agent.idempotent_tools（工具名 -> 校验提示）写入 Registry，供 Replay 策略放开幂等工具重执行
+func ApplyIdempotentToolsConfig(cfg *config.Config, reg *tools.Registry) {
+	if cfg == nil || reg == nil {
+		return
+	}
+	for name, hint := range cfg.Agent.IdempotentTools {
+		reg.MarkIdempotent(name, hint)
+	}
+}
+
+// NewKillSwitchGate 创建执行侧熔断判定，刷新间隔取 agent.killswitch.refresh_interval
+func NewKillSwitchGate(cfg *config.Config, store killswitch.Store) *killswitch.Gate {
+	var ttl time.Duration
+	if cfg != nil {
+		ttl = parseOptionalDuration(cfg.Agent.KillSwitch.RefreshInterval)
+	}
+	return killswitch.NewGate(store, ttl)
+}
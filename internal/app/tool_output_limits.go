@@ -0,0 +1,33 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/pkg/config"
+)
+
+// ToolOutputLimitsFrom 将 agent.tool_output_limits 转为 tool 节点的输出上限；未配置时零值不限
+func ToolOutputLimitsFrom(cfg *config.Config) agentexec.ToolOutputLimits {
+	if cfg == nil {
+		return agentexec.ToolOutputLimits{}
+	}
+	tc := cfg.Agent.ToolOutputLimits
+	return agentexec.ToolOutputLimits{
+		MaxBytes:     tc.MaxBytes,
+		PerTool:      tc.Tools,
+		SummaryBytes: tc.SummaryBytes,
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/agent/tools"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/config"
+)
+
+// ApplyToolSemaphoresConfig 将 agent.tool_semaphores 中各信号量绑定的工具写入 Registry，与工具清单中的声明合并
+func ApplyToolSemaphoresConfig(cfg *config.Config, reg *tools.Registry) {
+	if cfg == nil || reg == nil {
+		return
+	}
+	for name, sc := range cfg.Agent.ToolSemaphores.Semaphores {
+		for _, tool := range sc.Tools {
+			reg.BindSemaphore(tool, name)
+		}
+	}
+}
+
+// ToolSemaphoresFrom 创建 tool 节点的集群级信号量：工具需获取的信号量取自 Registry（配置绑定 + 清单声明），
+// 上限与排队超时以 agent.tool_semaphores 为准，未配置时用清单声明的上限；reg 或 store 为 nil 时返回 nil（不限制）
+func ToolSemaphoresFrom(cfg *config.Config, reg *tools.Registry, store jobstore.SemaphoreStore) *agentexec.ToolSemaphores {
+	if reg == nil || store == nil {
+		return nil
+	}
+	var sc config.ToolSemaphoresConfig
+	if cfg != nil {
+		sc = cfg.Agent.ToolSemaphores
+	}
+	return &agentexec.ToolSemaphores{
+		Store:        store,
+		LeaseTTL:     parseOptionalDuration(sc.LeaseTTL),
+		PollInterval: parseOptionalDuration(sc.PollInterval),
+		Resolve: func(toolName string) []agentexec.ToolSemaphore {
+			refs := reg.Semaphores(toolName)
+			if len(refs) == 0 {
+				return nil
+			}
+			out := make([]agentexec.ToolSemaphore, 0, len(refs))
+			for _, ref := range refs {
+				sem := agentexec.ToolSemaphore{Name: ref.Name, Limit: ref.Limit}
+				if c, ok := sc.Semaphores[ref.Name]; ok {
+					if c.Limit > 0 {
+						sem.Limit = c.Limit
+					}
+					sem.MaxWait = parseOptionalDuration(c.MaxWait)
+				}
+				out = append(out, sem)
+			}
+			return out
+		},
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	"rag-platform/internal/tool"
+	"rag-platform/internal/tool/builtin"
+	"rag-platform/pkg/config"
+)
+
+// BuiltinWebTools 按 agent.web_tools 创建内置联网工具（web_search、web_fetch）；未配置时返回空
+func BuiltinWebTools(cfg *config.Config) ([]tool.Tool, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var out []tool.Tool
+	sc := cfg.Agent.WebTools.Search
+	if sc.Backend != "" {
+		backend, err := builtin.NewSearchBackend(sc.Backend, sc.APIKey, sc.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		ttl, size := webCacheSettings(sc.CacheTTL, sc.CacheSize)
+		out = append(out, builtin.NewWebSearchTool(backend,
+			builtin.WithWebSearchMaxResults(sc.MaxResults),
+			builtin.WithWebSearchTimeout(parseOptionalDuration(sc.Timeout)),
+			builtin.WithWebSearchCache(ttl, size),
+			builtin.WithWebSearchRateLimit(sc.QPS, sc.Burst),
+		))
+	}
+	fc := cfg.Agent.WebTools.Fetch
+	if fc.Enable {
+		ttl, size := webCacheSettings(fc.CacheTTL, fc.CacheSize)
+		out = append(out, builtin.NewWebFetchTool(
+			builtin.WithWebFetchUserAgent(fc.UserAgent),
+			builtin.WithWebFetchLimits(fc.MaxBytes, fc.MaxChars),
+			builtin.WithWebFetchTimeout(parseOptionalDuration(fc.Timeout)),
+			builtin.WithWebFetchCache(ttl, size),
+			builtin.WithWebFetchRateLimit(fc.QPS, fc.Burst),
+			builtin.WithWebFetchAllowPrivateNetworks(fc.AllowPrivateNetworks),
+		))
+	}
+	return out, nil
+}
+
+// webCacheSettings 空 ttl 用默认 15m，显式 "0s" 关闭缓存
+func webCacheSettings(ttl string, size int) (time.Duration, int) {
+	d := builtin.DefaultWebCacheTTL
+	if ttl != "" {
+		d = parseOptionalDuration(ttl)
+	}
+	if size <= 0 {
+		size = builtin.DefaultWebCacheSize
+	}
+	return d, size
+}
@@ -22,11 +22,16 @@ import (
 	"sync"
 	"time"
 
+	"rag-platform/internal/agent/compat"
 	"rag-platform/internal/agent/instance"
 	"rag-platform/internal/agent/job"
 	"rag-platform/internal/agent/messaging"
 	agentexec "rag-platform/internal/agent/runtime/executor"
+	"rag-platform/internal/agent/scheduler"
+	"rag-platform/internal/agent/workerinspect"
+	"rag-platform/internal/app"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/pgretry"
 	"rag-platform/pkg/log"
 	"rag-platform/pkg/metrics"
 )
@@ -43,9 +48,19 @@ type AgentJobRunner struct {
 	heartbeatTicker time.Duration
 	maxConcurrency  int
 	limiter         chan struct{}               // 信号量，限制同时执行的 Job 数，避免 goroutine/LLM 爆炸
+	laneLimiter     chan struct{}               // 可选；交互式预留通道信号量，额外槽位只认领 interactive Job
 	wakeupQueue     job.WakeupQueue             // 可选；非 nil 时无 job 时用 Receive(timeout) 替代固定 sleep，实现 signal/message 后立即唤醒（design/wakeup-index）
 	inboxReader     messaging.InboxReader       // 可选；非 nil 时轮询收件箱并创建 Job，实现 inbox-driven execution（design/plan.md Phase A）
 	instanceStore   instance.AgentInstanceStore // 可选；非 nil 时在 Job 认领/结束时更新 Instance.current_job_id（design/plan.md Phase B）
+	maintenance     *job.MaintenanceGate        // 可选；非 nil 时租户维护窗口内认领到的 Job 置为 Deferred 不执行
+	throttle        *scheduler.ClaimThrottle    // 可选；非 nil 时主机负载或 LLM/Tool 并发超过阈值时暂停认领
+	statusStore     scheduler.WorkerStatusStore // 可选；非 nil 时周期上报 Worker 状态心跳（含节流状态）
+	handshake       *compat.Handshake           // 可选；非 nil 时随状态心跳声明支持的事件 schema 与特性（版本协商）
+	supervisor      *job.Supervisor             // 可选；非 nil 时子 Job 被取消后唤醒在 join 节点等待的监督者
+	fair            *job.FairShare              // 可选；非 nil 时按租户加权公平从 jobStore 认领
+	inspector       *workerinspect.Inspector    // 可选；非 nil 时记录认领、心跳与错误，供 worker inspect 自省
+	inspectURL      string                      // 可选；随状态心跳上报的自省端点地址
+	dbGuard         *pgretry.Guard              // 可选；Postgres 降级期间跳过孤儿回收并静默等待，不把 DB 不可用记为 Job failed
 	logger          *log.Logger
 	stopCh          chan struct{}
 	wg              sync.WaitGroup
@@ -100,12 +115,76 @@ func (r *AgentJobRunner) SetInstanceStore(store instance.AgentInstanceStore) {
 	r.instanceStore = store
 }
 
+// SetMaintenanceGate 设置租户维护窗口判定；窗口内认领到的 Job 置为 Deferred，窗口结束后由 MaintenanceController 恢复
+func (r *AgentJobRunner) SetMaintenanceGate(g *job.MaintenanceGate) {
+	r.maintenance = g
+}
+
+// SetClaimThrottle 设置资源感知认领节流；超过阈值时跳过本轮认领，待下次轮询再评估
+func (r *AgentJobRunner) SetClaimThrottle(t *scheduler.ClaimThrottle) {
+	r.throttle = t
+}
+
+// SetWorkerStatusStore 设置 Worker 状态存储；非 nil 时按采样间隔上报并发占用与节流状态，供 Scheduler 与看板识别自节流的 Worker
+func (r *AgentJobRunner) SetWorkerStatusStore(store scheduler.WorkerStatusStore) {
+	r.statusStore = store
+}
+
+// SetHandshake 设置版本握手信息；随状态心跳写入 worker_status，API 创建 Job 时据此协商 schema 与特性。
+// 认领侧的过滤由 capabilities 中的 schema:/feature: 项完成（见 compat.AdvertisedCapabilities）
+func (r *AgentJobRunner) SetHandshake(h compat.Handshake) {
+	h.WorkerID = r.workerID
+	r.handshake = &h
+}
+
+// SetSupervisor 设置监督者运行时；子 Job 在本 Worker 被取消时通知其监督者
+func (r *AgentJobRunner) SetSupervisor(s *job.Supervisor) {
+	r.supervisor = s
+}
+
+// SetFairShare 设置租户加权公平认领；启用后即使未配置 capabilities 也先从 jobStore 按租户认领再在 eventStore 占租约
+func (r *AgentJobRunner) SetFairShare(f *job.FairShare) {
+	r.fair = f
+}
+
+// SetInspector 设置 Worker 自省器；认领、租约心跳、执行结束与认领failed时回调；advertiseURL 非空时随状态心跳上报
+func (r *AgentJobRunner) SetInspector(in *workerinspect.Inspector, advertiseURL string) {
+	in.SetMaxConcurrency(r.maxConcurrency)
+	r.inspector = in
+	r.inspectURL = advertiseURL
+}
+
+// SetDBGuard 设置 Postgres 弹性守卫；熔断打开期间暂停孤儿回收，认领因 DB 不可用failed时不记错误日志
+func (r *AgentJobRunner) SetDBGuard(g *pgretry.Guard) {
+	r.dbGuard = g
+}
+
+// SetInteractiveLane 为交互式 Job 额外预留 reserved 个并发槽位；reserved<=0 或 jobStore 未实现 job.InteractiveClaimer 时不启用
+func (r *AgentJobRunner) SetInteractiveLane(reserved int) {
+	if reserved <= 0 {
+		r.laneLimiter = nil
+		return
+	}
+	if _, ok := r.jobStore.(job.InteractiveClaimer); !ok {
+		return
+	}
+	r.laneLimiter = make(chan struct{}, reserved)
+}
+
 // Start 启动 Claim 循环；先占并发槽位再 Claim，执行后释放槽位（Backpressure）；capabilities 非空时按能力从 jobStore 选 Job 再在 eventStore 占租约；若 SetInboxReader 则同时启动 inbox 轮询
 func (r *AgentJobRunner) Start(ctx context.Context) {
 	if r.inboxReader != nil {
 		r.wg.Add(1)
 		go r.runInboxPollLoop(ctx)
 	}
+	if r.throttle != nil || r.statusStore != nil {
+		r.wg.Add(1)
+		go r.runStatusLoop(ctx)
+	}
+	if r.laneLimiter != nil {
+		r.wg.Add(1)
+		go r.runInteractiveLaneLoop(ctx)
+	}
 	r.wg.Add(1)
 	go func() {
 		defer r.wg.Done()
@@ -116,16 +195,41 @@ func (r *AgentJobRunner) Start(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			case r.limiter <- struct{}{}:
+				// 资源感知认领：主机负载或 LLM/Tool 并发超过阈值时本轮不认领，已在执行的 Job 不受影响
+				if st := r.throttle.Evaluate(); st.Throttled {
+					<-r.limiter
+					for _, reason := range st.Reasons {
+						metrics.WorkerClaimThrottledTotal.WithLabelValues(r.workerID, reason).Inc()
+					}
+					select {
+					case <-r.stopCh:
+						return
+					case <-ctx.Done():
+						return
+					case <-time.After(r.pollInterval):
+					}
+					continue
+				}
 				// 孤儿回收（design/runtime-contract.md §2）：以 event store 租约过期为准，且不回收 Blocked(JobWaiting) 的 Job
-				if reclaimed, err := job.ReclaimOrphanedFromEventStore(ctx, r.jobStore, r.jobEventStore); err == nil && reclaimed > 0 {
-					r.logger.Info("回收孤儿 Job", "reclaimed", reclaimed)
+				// DB 降级期间不回收：心跳可能只是暂时写不进去，此时回收会把仍在执行的 Job 重复派发
+				if !r.dbGuard.Degraded() {
+					if reclaimed, err := job.ReclaimOrphanedFromEventStore(ctx, r.jobStore, r.jobEventStore); err == nil && reclaimed > 0 {
+						r.logger.Info("回收孤儿 Job", "reclaimed", reclaimed)
+					}
 				}
 				var jobID string
-				if len(r.capabilities) > 0 {
-					// 按能力派发：先从 metadata store 认领能力匹配的 Job，再在 event store 占租约
-					j, errClaim := r.jobStore.ClaimNextPendingForWorker(ctx, "", r.capabilities, "")
+				if len(r.capabilities) > 0 || r.fair != nil {
+					// 按能力/租户公平派发：先从 metadata store 认领，再在 event store 占租约
+					var j *job.Job
+					var errClaim error
+					if r.fair != nil {
+						j, errClaim = r.fair.Claim(ctx, r.jobStore, "", r.capabilities)
+					} else {
+						j, errClaim = r.jobStore.ClaimNextPendingForWorker(ctx, "", r.capabilities, "")
+					}
 					if errClaim != nil || j == nil {
 						<-r.limiter
+						r.observeClaimError(errClaim)
 						if r.wakeupQueue != nil {
 							_, _ = r.wakeupQueue.Receive(ctx, r.pollInterval)
 						} else {
@@ -143,8 +247,11 @@ func (r *AgentJobRunner) Start(ctx context.Context) {
 					if errEvent != nil {
 						_ = r.jobStore.Requeue(ctx, j)
 						<-r.limiter
-						if errEvent != jobstore.ErrNoJob && errEvent != jobstore.ErrClaimNotFound {
+						if pgretry.IsUnavailable(errEvent) {
+							r.observeClaimError(errEvent)
+						} else if errEvent != jobstore.ErrNoJob && errEvent != jobstore.ErrClaimNotFound {
 							r.logger.Error("ClaimJob failed", "job_id", j.ID, "error", errEvent)
+							r.inspector.RecordError(j.ID, "claim", errEvent)
 						}
 						time.Sleep(r.pollInterval)
 						continue
@@ -176,7 +283,12 @@ func (r *AgentJobRunner) Start(ctx context.Context) {
 							}
 							continue
 						}
-						r.logger.Error("Claim failed", "error", err)
+						if pgretry.IsUnavailable(err) {
+							r.observeClaimError(err)
+						} else {
+							r.logger.Error("Claim failed", "error", err)
+							r.inspector.RecordError("", "claim", err)
+						}
 						time.Sleep(r.pollInterval)
 						continue
 					}
@@ -192,6 +304,144 @@ func (r *AgentJobRunner) Start(ctx context.Context) {
 	}()
 }
 
+// runInteractiveLaneLoop 交互式预留通道：占预留槽位后仅认领 interactive Job，再在 event store 占租约；与常规循环共享节流判定
+func (r *AgentJobRunner) runInteractiveLaneLoop(ctx context.Context) {
+	defer r.wg.Done()
+	claimer := r.jobStore.(job.InteractiveClaimer)
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case r.laneLimiter <- struct{}{}:
+			if st := r.throttle.Evaluate(); st.Throttled {
+				<-r.laneLimiter
+				if !r.waitPoll(ctx) {
+					return
+				}
+				continue
+			}
+			j, errClaim := claimer.ClaimNextInteractive(ctx, r.capabilities)
+			if errClaim != nil || j == nil {
+				<-r.laneLimiter
+				r.observeClaimError(errClaim)
+				if !r.waitPoll(ctx) {
+					return
+				}
+				continue
+			}
+			_, attemptID, errEvent := r.jobEventStore.ClaimJob(ctx, r.workerID, j.ID)
+			if errEvent != nil {
+				_ = r.jobStore.Requeue(ctx, j)
+				<-r.laneLimiter
+				if pgretry.IsUnavailable(errEvent) {
+					r.observeClaimError(errEvent)
+				} else if errEvent != jobstore.ErrNoJob && errEvent != jobstore.ErrClaimNotFound {
+					r.logger.Error("ClaimJob failed", "job_id", j.ID, "lane", job.LaneInteractive, "error", errEvent)
+				}
+				if !r.waitPoll(ctx) {
+					return
+				}
+				continue
+			}
+			r.wg.Add(1)
+			go func(claimedJobID, aid string) {
+				defer r.wg.Done()
+				defer func() { <-r.laneLimiter }()
+				r.executeJob(ctx, claimedJobID, aid)
+			}(j.ID, attemptID)
+		}
+	}
+}
+
+// observeClaimError 认领因 Postgres 不可用failed时计入 db_degraded 节流，其余错误维持原有静默行为
+func (r *AgentJobRunner) observeClaimError(err error) {
+	if pgretry.IsUnavailable(err) {
+		metrics.WorkerClaimThrottledTotal.WithLabelValues(r.workerID, "db_degraded").Inc()
+	}
+}
+
+// waitPoll 等待一个轮询间隔；Worker 停止或 ctx 结束时返回 false
+func (r *AgentJobRunner) waitPoll(ctx context.Context) bool {
+	select {
+	case <-r.stopCh:
+		return false
+	case <-ctx.Done():
+		return false
+	case <-time.After(r.pollInterval):
+		return true
+	}
+}
+
+// runStatusLoop 按采样间隔评估节流状态并上报 Worker 状态心跳与指标
+func (r *AgentJobRunner) runStatusLoop(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.throttle.Interval())
+	defer ticker.Stop()
+	wasThrottled := false
+	for {
+		st := r.throttle.Evaluate()
+		r.reportStatus(ctx, st)
+		if st.Throttled != wasThrottled {
+			if st.Throttled {
+				r.logger.Warn("Worker 资源超阈值，暂停认领新 Job", "worker_id", r.workerID, "reasons", st.Reasons,
+					"cpu_percent", st.CPUPercent, "memory_percent", st.MemoryPercent, "llm_in_flight", st.LLMInFlight, "tool_in_flight", st.ToolInFlight)
+			} else {
+				r.logger.Info("Worker 资源恢复，继续认领", "worker_id", r.workerID)
+			}
+			wasThrottled = st.Throttled
+		}
+		select {
+		case <-r.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *AgentJobRunner) reportStatus(ctx context.Context, st scheduler.ThrottleState) {
+	throttled := 0.0
+	if st.Throttled {
+		throttled = 1
+	}
+	metrics.WorkerThrottled.WithLabelValues(r.workerID).Set(throttled)
+	if !st.SampledAt.IsZero() {
+		r.inspector.ObserveThrottle(st)
+	}
+	if !st.SampledAt.IsZero() {
+		metrics.WorkerResourceUsage.WithLabelValues(r.workerID, "cpu_percent").Set(st.CPUPercent)
+		metrics.WorkerResourceUsage.WithLabelValues(r.workerID, "memory_percent").Set(st.MemoryPercent)
+		metrics.WorkerResourceUsage.WithLabelValues(r.workerID, "llm_in_flight").Set(float64(st.LLMInFlight))
+		metrics.WorkerResourceUsage.WithLabelValues(r.workerID, "tool_in_flight").Set(float64(st.ToolInFlight))
+	}
+	if r.statusStore == nil {
+		return
+	}
+	if st.SampledAt.IsZero() {
+		// 未启用节流：不采样主机资源，CPU/内存记为未知
+		st.CPUPercent, st.MemoryPercent = -1, -1
+	}
+	status := &scheduler.WorkerStatus{
+		WorkerID:       r.workerID,
+		RunningJobs:    len(r.limiter),
+		MaxConcurrency: r.maxConcurrency,
+		Throttle:       st,
+		HeartbeatAt:    time.Now(),
+		InspectURL:     r.inspectURL,
+	}
+	if r.handshake != nil {
+		status.Version = r.handshake.Version
+		status.EventSchemaVersion = r.handshake.EventSchemaVersion
+		status.Features = r.handshake.Features
+	}
+	if err := r.statusStore.Report(ctx, status); err != nil {
+		r.logger.Warn("上报 Worker 状态failed", "worker_id", r.workerID, "error", err)
+	}
+}
+
 // runInboxPollLoop 轮询收件箱：对有未消费消息的 agent 创建 Job 并 NotifyReady（design/plan.md Phase A）
 func (r *AgentJobRunner) runInboxPollLoop(ctx context.Context) {
 	defer r.wg.Done()
@@ -230,20 +480,31 @@ func (r *AgentJobRunner) executeJob(ctx context.Context, jobID string, attemptID
 		r.logger.Warn("Get Job failed or not found, skipping", "job_id", jobID, "error", err)
 		return
 	}
-	metrics.WorkerBusy.WithLabelValues(r.workerID).Inc()
-	defer metrics.WorkerBusy.WithLabelValues(r.workerID).Dec()
-	start := time.Now()
 	tenant := j.TenantID
 	if tenant == "" {
 		tenant = "default"
 	}
+	// 租户维护窗口内不开始执行：置为 Deferred，租约到期前不会被再次认领
+	if r.maintenance.InMaintenance(ctx, tenant) {
+		if j.Status != job.StatusDeferred {
+			_ = r.jobStore.UpdateStatus(ctx, jobID, job.StatusDeferred)
+			metrics.MaintenanceDeferredTotal.WithLabelValues(tenant, "deferred").Inc()
+		}
+		r.logger.Info("租户维护窗口内，Job 暂缓执行", "job_id", jobID, "tenant_id", tenant)
+		return
+	}
+	metrics.WorkerBusy.WithLabelValues(r.workerID).Inc()
+	defer metrics.WorkerBusy.WithLabelValues(r.workerID).Dec()
+	start := time.Now()
 	defer func() {
 		dur := time.Since(start).Seconds()
 		metrics.JobDuration.WithLabelValues(j.AgentID).Observe(dur)
 	}()
 	// 元数据与事件一致：Claim 成功后标记 Running，便于查询与运维
 	_ = r.jobStore.UpdateStatus(ctx, jobID, job.StatusRunning)
-	r.logger.Info("开始执行 Job", "job_id", jobID, "agent_id", j.AgentID, "goal", j.Goal)
+	job.ObserveLaneClaimed(j)
+	r.logger.Info("开始执行 Job", "job_id", jobID, "agent_id", j.AgentID, "goal", j.Goal, "lane", job.LaneOf(j))
+	r.inspector.Claimed(jobID, j.AgentID, tenant, attemptID)
 	runCtx, cancel := context.WithCancel(ctx)
 	runCtx = jobstore.WithAttemptID(runCtx, attemptID)
 	defer cancel()
@@ -258,9 +519,11 @@ func (r *AgentJobRunner) executeJob(ctx context.Context, jobID string, attemptID
 				close(heartbeatDone)
 				return
 			case <-ticker.C:
-				if err := r.jobEventStore.Heartbeat(runCtx, r.workerID, jobID); err != nil {
+				err := r.jobEventStore.Heartbeat(runCtx, r.workerID, jobID)
+				if err != nil {
 					r.logger.Warn("Heartbeat failed", "job_id", jobID, "error", err)
 				}
+				r.inspector.Heartbeat(jobID, err)
 			}
 		}
 	}()
@@ -290,6 +553,11 @@ func (r *AgentJobRunner) executeJob(ctx context.Context, jobID string, attemptID
 	// runJob 返回后主动结束 runCtx，确保 Heartbeat 协程退出，避免等待 heartbeatDone 时阻塞。
 	cancel()
 	<-heartbeatDone
+	if wasCanceled || errors.Is(err, agentexec.ErrJobDeferred) {
+		r.inspector.Released(jobID, nil)
+	} else {
+		r.inspector.Released(jobID, err)
+	}
 	if wasCanceled {
 		r.logger.Info("Job 已取消", "job_id", jobID)
 		dur := time.Since(start).Seconds()
@@ -297,10 +565,31 @@ func (r *AgentJobRunner) executeJob(ctx context.Context, jobID string, attemptID
 		metrics.JobFailTotal.WithLabelValues("cancelled").Inc()
 		metrics.JobsTotal.WithLabelValues(tenant, "cancelled").Inc()
 		metrics.JobLatencySeconds.WithLabelValues(tenant, "cancelled").Observe(dur)
-		_, ver, _ := r.jobEventStore.ListEvents(ctx, jobID)
-		payload, _ := json.Marshal(map[string]interface{}{"goal": j.Goal})
+		job.ObserveLaneFinished(j, "cancelled")
+		events, ver, _ := r.jobEventStore.ListEvents(ctx, jobID)
+		info := r.cancelTerminalInfo(ctx, jobID, events)
+		pl := info.EventFields()
+		pl["goal"] = j.Goal
+		payload, _ := json.Marshal(pl)
 		_, _ = r.jobEventStore.Append(runCtx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobCancelled, Payload: payload})
 		_ = r.jobStore.UpdateStatus(ctx, jobID, job.StatusCancelled)
+		if err := job.RecordTerminalInfo(ctx, r.jobStore, jobID, info); err != nil {
+			r.logger.Warn("记录终态元数据failed", "job_id", jobID, "error", err)
+		}
+		if err := r.supervisor.NotifyTerminal(ctx, jobID); err != nil {
+			r.logger.Warn("通知监督者failed", "job_id", jobID, "error", err)
+		}
+		return
+	}
+	if errors.Is(err, agentexec.ErrJobDeferred) {
+		// 维护窗口内在 step 边界暂停，已置为 Deferred；不写终端事件
+		r.logger.Info("Job 在 step 边界暂停（维护窗口）", "job_id", jobID, "tenant_id", tenant)
+		metrics.MaintenanceDeferredTotal.WithLabelValues(tenant, "parked").Inc()
+		return
+	}
+	if pgretry.IsUnavailable(err) {
+		// Postgres 暂不可用：不写 job_failed（大概率也写不进去），保留租约由过期后的孤儿回收重新派发
+		r.logger.Warn("Job 因数据库不可用中断，等待租约过期后重新调度", "job_id", jobID, "error", err)
 		return
 	}
 	if err != nil {
@@ -310,28 +599,59 @@ func (r *AgentJobRunner) executeJob(ctx context.Context, jobID string, attemptID
 		metrics.JobFailTotal.WithLabelValues("failed").Inc()
 		metrics.JobsTotal.WithLabelValues(tenant, "failed").Inc()
 		metrics.JobLatencySeconds.WithLabelValues(tenant, "failed").Observe(dur)
+		job.ObserveLaneFinished(j, "failed")
 		// Append job_failed so event stream has terminal event; include result_type when available
+		var events []jobstore.JobEvent
+		ver := 0
 		if r.jobEventStore != nil {
-			_, ver, _ := r.jobEventStore.ListEvents(ctx, jobID)
-			pl := map[string]interface{}{"goal": j.Goal, "error": err.Error()}
+			events, ver, _ = r.jobEventStore.ListEvents(ctx, jobID)
+		}
+		info := app.FailureTerminalInfo(err, "worker:"+r.workerID, events)
+		if r.jobEventStore != nil {
+			pl := info.EventFields()
+			pl["goal"] = j.Goal
+			pl["error"] = err.Error()
 			var sf *agentexec.StepFailure
 			if errors.As(err, &sf) {
 				pl["result_type"] = string(sf.Type)
-				pl["node_id"] = sf.FailedNodeID()
-				pl["reason"] = err.Error()
 			}
 			payload, _ := json.Marshal(pl)
 			_, _ = r.jobEventStore.Append(runCtx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.JobFailed, Payload: payload})
 		}
+		// 仅在 Job 已进入 Failed 且 runJob 未记录终态元数据时写入（Requeue 重试的 Job 不是终态）
+		if latest, _ := r.jobStore.Get(ctx, jobID); latest != nil && latest.Status == job.StatusFailed && latest.Terminal == nil {
+			if err := job.RecordTerminalInfo(ctx, r.jobStore, jobID, info); err != nil {
+				r.logger.Warn("记录终态元数据failed", "job_id", jobID, "error", err)
+			}
+		}
 		return
 	}
 	dur := time.Since(start).Seconds()
 	metrics.JobTotal.WithLabelValues("completed").Inc()
 	metrics.JobsTotal.WithLabelValues(tenant, "completed").Inc()
 	metrics.JobLatencySeconds.WithLabelValues(tenant, "completed").Observe(dur)
+	job.ObserveLaneFinished(j, "completed")
 	// 事件与状态已在 runJob 内写回（由注入的 runJob 负责 Append job_completed/job_failed 与 UpdateStatus）
 }
 
+// cancelTerminalInfo 构造取消的终态元数据：沿用 API 请求取消时写入的原因与发起者；无取消请求时视为 Worker 关闭中断
+func (r *AgentJobRunner) cancelTerminalInfo(ctx context.Context, jobID string, events []jobstore.JobEvent) *job.TerminalInfo {
+	info := &job.TerminalInfo{}
+	if latest, _ := r.jobStore.Get(ctx, jobID); latest != nil {
+		if latest.Terminal != nil {
+			*info = *latest.Terminal
+		}
+		if latest.CancelRequestedAt.IsZero() && info.Reason == "" {
+			info.Reason = "worker_shutdown"
+			info.Actor = "worker:" + r.workerID
+		}
+	}
+	info.FailureClass = job.FailureClassCancelled
+	info.Compensation = job.CompensationStatus(events, info.FailureClass)
+	info.At = time.Time{}
+	return info
+}
+
 // DefaultWorkerID 返回默认 Worker 标识（hostname 或 env）
 func DefaultWorkerID() string {
 	if id := os.Getenv("WORKER_ID"); id != "" {
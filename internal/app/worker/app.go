@@ -23,33 +23,56 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/prometheus/common/expfmt"
 
+	"rag-platform/internal/agent/compat"
+	"rag-platform/internal/agent/extworker"
 	"rag-platform/internal/agent/instance"
 	"rag-platform/internal/agent/job"
+	"rag-platform/internal/agent/killswitch"
+	"rag-platform/internal/agent/memory"
 	"rag-platform/internal/agent/messaging"
 	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/agent/promptlog"
 	"rag-platform/internal/agent/replay"
 	replaysandbox "rag-platform/internal/agent/replay/sandbox"
 	"rag-platform/internal/agent/runtime"
 	agentexec "rag-platform/internal/agent/runtime/executor"
 	"rag-platform/internal/agent/runtime/executor/verifier"
+	"rag-platform/internal/agent/scheduler"
 	"rag-platform/internal/agent/tools"
+	"rag-platform/internal/agent/verify"
+	"rag-platform/internal/agent/workerinspect"
+	"rag-platform/internal/agent/workspace"
 	"rag-platform/internal/app"
 	"rag-platform/internal/app/api"
 	"rag-platform/internal/ingestqueue"
 	llmmod "rag-platform/internal/model/llm"
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/runtime/agentconfig"
 	"rag-platform/internal/runtime/eino"
+	"rag-platform/internal/runtime/eventexport"
+	"rag-platform/internal/runtime/eventsearch"
 	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/runtime/payloadcrypt"
+	"rag-platform/internal/runtime/piitag"
+	"rag-platform/internal/runtime/serviceaccount"
 	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/pgpool"
+	"rag-platform/internal/storage/pgretry"
+	"rag-platform/internal/storage/residency"
 	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/agent/sdk"
+	"rag-platform/pkg/auth"
 	"rag-platform/pkg/config"
 	"rag-platform/pkg/log"
 	"rag-platform/pkg/metrics"
+	"rag-platform/pkg/pii"
 )
 
 // App Worker 应用（Pipeline 由 eino 调度；JobStore=postgres 时拉取 Agent Job 执行）
@@ -64,13 +87,23 @@ type App struct {
 	agentJobCancel context.CancelFunc
 	jobEventStore  jobstore.JobStore // 用于 Snapshot 自动化与 GC goroutine（仅 postgres 模式下非 nil）
 	replayBuilder  replay.ReplayContextBuilder
+	pgPools        *pgpool.Manager    // Agent Job 模式下统一管理各组件连接池
+	eventRelay     *eventexport.Relay // event_export.enable 时非 nil
+	maintenance    *job.MaintenanceController
+	externalHost   *extworker.Host                // agent.external_workers 配置时非 nil
+	effectBuffer   *agentexec.BufferedEffectStore // effect_store.local_buffer.enable 时非 nil
+	identity       *serviceaccount.Identity       // worker.service_account 配置令牌时非 nil
+	identityEvery  time.Duration                  // 服务账号令牌重新校验间隔
+	workspaceGC    *workspace.Janitor             // agent.workspace.enable 时非 nil
+	workspaceEvery time.Duration                  // 工作区清理间隔
+	inspector      *workerinspect.Inspector       // worker.inspect.enable 时非 nil
+	inspectAddr    string                         // 自省端点监听地址
+	readiness      *app.Readiness                 // 启动预检结果，经 metrics 端口的 /readyz 输出
+	preflightLLM   map[string]llmmod.Client       // 预检的各用途 LLM 客户端
 }
 
 // NewApp 创建新的 Worker 应用
 func NewApp(cfg *config.Config) (*App, error) {
-	if err := validateProductionRuntimeConfig(cfg); err != nil {
-		return nil, err
-	}
 	// 初始化日志
 	logCfg := &log.Config{}
 	if cfg != nil {
@@ -83,6 +116,15 @@ func NewApp(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("初始化日志failed: %w", err)
 	}
 
+	// 启动前校验配置：问题带 YAML 路径与行号，warning 仅记录，存在 error 时拒绝启动
+	report := config.Validate(cfg, config.ComponentWorker)
+	for _, w := range report.Warnings() {
+		logger.Warn("配置告警", "issue", w.String())
+	}
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
+
 	// 初始化存储
 	metadataStore, err := metadata.NewStore(cfg.Storage.Metadata)
 	if err != nil {
@@ -93,6 +135,15 @@ func NewApp(cfg *config.Config) (*App, error) {
 	if err != nil {
 		return nil, fmt.Errorf("初始化向量存储failed: %w", err)
 	}
+	if cfg.Residency.Enable {
+		// 数据驻留：入库按租户写入其所在区域的元数据/向量存储
+		factory, err := app.NewResidencyFactory(cfg.Residency)
+		if err != nil {
+			return nil, err
+		}
+		metadataStore = residency.NewMetadataStore(factory)
+		vectorStore = residency.NewVectorStore(factory)
+	}
 
 	// 初始化 eino 引擎（ingest 任务通过 ExecuteWorkflow(ctx, "ingest_pipeline", payload) 执行）
 	engine, err := eino.NewEngine(cfg, logger)
@@ -103,6 +154,7 @@ func NewApp(cfg *config.Config) (*App, error) {
 	appObj := &App{
 		config:        cfg,
 		logger:        logger,
+		readiness:     app.NewReadiness("worker"),
 		engine:        engine,
 		metadataStore: metadataStore,
 		vectorStore:   vectorStore,
@@ -118,20 +170,140 @@ func NewApp(cfg *config.Config) (*App, error) {
 				leaseDur = d
 			}
 		}
-		pgEventStore, err := jobstore.NewPostgresStore(context.Background(), dsn, leaseDur)
+		pgPools := pgpool.NewManager(cfg.JobStore.Pool)
+		appObj.pgPools = pgPools
+		// 预算总量须容纳本进程启用的全部组件，否则后申请的组件因预算耗尽导致启动failed或可选功能被关闭；
+		// 小表存储（维护窗口、熔断、服务账号等）共用 jobsPool
+		if err := pgPools.CheckBudget(pgpool.WorkerComponents(cfg)); err != nil {
+			return nil, fmt.Errorf("Postgres 连接预算不足: %w", err)
+		}
+		eventPool, err := pgPools.Pool(context.Background(), pgpool.ComponentJobEvents, dsn)
 		if err != nil {
 			return nil, fmt.Errorf("初始化 JobStore 事件(postgres) failed: %w", err)
 		}
-		pgJobStore, err := job.NewJobStorePg(context.Background(), dsn)
+		var pgEventStore jobstore.JobStore = jobstore.NewPostgresStoreWithPool(eventPool, leaseDur)
+		// 事件载荷压缩：与 API 使用同一阈值；读取始终按 payload_encoding 解压
+		compressor, err := app.EventPayloadCompressorFrom(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化事件载荷压缩failed: %w", err)
+		}
+		if s, ok := pgEventStore.(jobstore.PayloadCompressionSetter); ok && compressor != nil {
+			s.SetPayloadCompressor(compressor)
+		}
+		// Postgres 短暂不可用时的弹性：事件存储与 Job 元数据共享同一守卫（同一熔断状态），降级期间暂停认领、保留在执行 Job 的租约
+		var dbGuard *pgretry.Guard
+		if !cfg.JobStore.Resilience.Disable {
+			dbGuard = pgretry.New("jobstore", pgretry.PolicyFromConfig(cfg.JobStore.Resilience), logger.Logger)
+			if s, ok := pgEventStore.(jobstore.ResilienceGuardSetter); ok {
+				s.SetResilienceGuard(dbGuard)
+			}
+		}
+		jobsPool, err := pgPools.Pool(context.Background(), pgpool.ComponentJobs, dsn)
 		if err != nil {
 			return nil, fmt.Errorf("初始化 Job 元数据(postgres) failed: %w", err)
 		}
-		llmClientRaw, err := app.NewLLMClientFromConfig(cfg)
+		pgJobStore := job.NewJobStorePgWithPool(jobsPool)
+		pgJobStore.SetResilienceGuard(dbGuard)
+		// 发起归属：Worker 追加的事件同样记录创建 Job 的用户、客户端与原始请求 ID
+		pgEventStore = job.NewAttributingStore(pgEventStore, pgJobStore)
+		// 载荷级加密：与 API 共享密钥；执行时解密 jobs.goal 与事件中的 goal/message，新写入的事件同样只以密文落库
+		keyring, err := payloadcrypt.NewFromConfig(cfg.PayloadEncryption)
+		if err != nil {
+			return nil, fmt.Errorf("初始化载荷加密failed: %w", err)
+		}
+		if keyring != nil {
+			pgJobStore.SetGoalSealer(keyring)
+			pgEventStore = payloadcrypt.NewSealingStore(pgEventStore, keyring, cfg.PayloadEncryption.Fields, payloadcrypt.TenantFromJobs(pgJobStore))
+			logger.Info("Worker 载荷级加密已启用")
+		}
+		// PII 检测：写入时扫描工具输入/输出与 LLM 消息，按类别打标签（与 API 共享 event_pii_tags）
+		if cfg.PII.Enable {
+			piiPool, errPII := pgPools.Pool(context.Background(), pgpool.ComponentPIITags, dsn)
+			if errPII != nil {
+				return nil, fmt.Errorf("初始化 PII 标签存储(postgres) failed: %w", errPII)
+			}
+			pgEventStore = piitag.NewTaggingStore(pgEventStore, pii.NewDetector(cfg.PII.Categories), piitag.NewTagStorePg(piiPool), cfg.PII.EventTypes, logger)
+		}
+		// 事件全文检索：Worker 写入的工具输出、错误与推理快照同样入索引（与 API 共享 job_search_docs）
+		if cfg.EventSearch.Enable {
+			searchPool, errSearch := pgPools.Pool(context.Background(), pgpool.ComponentEventSearch, dsn)
+			if errSearch != nil {
+				return nil, fmt.Errorf("初始化事件检索索引(postgres) failed: %w", errSearch)
+			}
+			pgEventStore = app.WrapEventSearch(cfg, pgEventStore, eventsearch.NewIndexPg(searchPool), pgJobStore, logger)
+		}
+		// 事件导出：选定事件经 outbox 异步发布到 Kafka/NATS（at-least-once）
+		if cfg.EventExport.Enable {
+			outboxPool, errOutbox := pgPools.Pool(context.Background(), pgpool.ComponentEventOutbox, dsn)
+			if errOutbox != nil {
+				return nil, fmt.Errorf("初始化事件导出 outbox(postgres) failed: %w", errOutbox)
+			}
+			exportStore, relay, errExport := eventexport.NewFromConfig(cfg.EventExport, pgEventStore, eventexport.NewOutboxPg(outboxPool), logger)
+			if errExport != nil {
+				return nil, fmt.Errorf("初始化事件导出 failed: %w", errExport)
+			}
+			pgEventStore = exportStore
+			appObj.eventRelay = relay
+		}
+		// Job 证明记录：终态事件写入后固化 execution_hash 与事件链根（与 API 共享 job_attestations）
+		pgEventStore = verify.NewAttestingStore(pgEventStore, verify.NewAttestationStorePg(jobsPool), logger)
+		// Worker 服务账号：身份与事件归属（job_events.actor）；吊销后本 Worker 停止认领与追加。进程内校验，数据库权限仍由 DSN 决定
+		saCfg := cfg.Worker.ServiceAccount
+		var identity *serviceaccount.Identity
+		if saCfg.Token != "" || saCfg.TokenFile != "" || saCfg.Required {
+			identity = serviceaccount.NewIdentity(serviceaccount.NewStorePg(jobsPool), DefaultWorkerID(), saCfg.Token, saCfg.TokenFile)
+			if errSA := identity.Refresh(context.Background()); errSA != nil {
+				return nil, fmt.Errorf("Worker 服务账号认证failed: %w", errSA)
+			}
+			appObj.identity = identity
+			appObj.identityEvery = serviceaccount.DefaultRefreshInterval
+			if saCfg.RefreshInterval != "" {
+				if d, errParse := time.ParseDuration(saCfg.RefreshInterval); errParse == nil && d > 0 {
+					appObj.identityEvery = d
+				}
+			}
+			logger.Info("Worker 服务账号认证成功", "worker_id", DefaultWorkerID(), "service_account_id", identity.Account().ID, "tenant_id", identity.Account().TenantID)
+		} else {
+			logger.Warn("Worker 未配置服务账号令牌，事件仅归属到 worker_id（worker.service_account）")
+		}
+		pgEventStore = serviceaccount.NewScopedStore(pgEventStore, DefaultWorkerID(), identity)
+		// Worker 自省：观察 node_started/node_finished 维护执行中的 step，供 aetheris worker inspect
+		if cfg.Worker.Inspect.Enable {
+			appObj.inspector = workerinspect.NewInspector(DefaultWorkerID(), leaseDur)
+			pgEventStore = workerinspect.WrapStore(pgEventStore, appObj.inspector)
+		}
+		// 租户维护窗口：窗口内认领到的 Job 置为 Deferred、运行中 Job 按窗口配置在 step 边界暂停；窗口结束后自动恢复
+		maintStore := job.NewMaintenanceStorePg(jobsPool)
+		maintGate := job.NewMaintenanceGate(maintStore, 0)
+		appObj.maintenance = job.NewMaintenanceController(maintStore, pgJobStore, 0)
+		// 全局工具熔断：与 API 共享 tool_killswitches 表，按 agent.killswitch.refresh_interval 刷新
+		killSwitchGate := app.NewKillSwitchGate(cfg, killswitch.NewStorePg(jobsPool))
+		// 生成调用优先使用 model.fallback.generation 故障切换链，未配置时使用 defaults.llm
+		llmClientRaw, err := app.NewLLMClientForUseCase(cfg, app.LLMUseCaseGeneration)
+		if err == nil && llmClientRaw == nil {
+			llmClientRaw, err = app.NewLLMClientFromConfig(cfg)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("初始化 LLM 客户端failed: %w", err)
 		}
-		// LLM 限流包装
-		var llmClient llmmod.Client = llmClientRaw
+		plannerLLMRaw, err := app.NewLLMClientForUseCase(cfg, app.LLMUseCasePlanner)
+		if err != nil {
+			return nil, fmt.Errorf("初始化规划 LLM 故障切换链failed: %w", err)
+		}
+		// 按步骤的模型路由：llm 节点声明 quality / max_latency 时在候选模型中选择；未配置规划链时规划固定路由到最强模型
+		llmRouter, err := app.NewLLMRouterFromConfig(cfg, llmClientRaw)
+		if err != nil {
+			return nil, fmt.Errorf("初始化模型路由failed: %w", err)
+		}
+		if llmRouter != nil {
+			if plannerLLMRaw == nil {
+				req, _ := app.PlannerRouteRequirement(cfg)
+				plannerLLMRaw = llmRouter.Pinned(req)
+			}
+			llmClientRaw = llmRouter
+		}
+		// LLM 限流包装；未配置限流时 limiter 为 nil，仅统计进行中的调用数（供资源感知认领）
+		var llmRateLimiter *llmmod.LLMRateLimiter
 		if cfg != nil && len(cfg.RateLimits.LLM) > 0 {
 			llmLimiterConfigs := make(map[string]llmmod.LLMLimitConfig, len(cfg.RateLimits.LLM))
 			for provider, c := range cfg.RateLimits.LLM {
@@ -142,6 +314,7 @@ func NewApp(cfg *config.Config) (*App, error) {
 					TokensPerMinute:   c.TokensPerMinute,
 					RequestsPerMinute: c.RequestsPerMinute,
 					MaxConcurrent:     c.MaxConcurrent,
+					PlanningShare:     c.PlanningShare,
 				}
 			}
 			var llmDefaults *llmmod.LLMLimitConfig
@@ -150,42 +323,105 @@ func NewApp(cfg *config.Config) (*App, error) {
 					TokensPerMinute:   d.TokensPerMinute,
 					RequestsPerMinute: d.RequestsPerMinute,
 					MaxConcurrent:     d.MaxConcurrent,
+					PlanningShare:     d.PlanningShare,
 				}
 			}
-			llmRateLimiter := llmmod.NewLLMRateLimiter(llmLimiterConfigs, llmDefaults)
-			llmClient = llmmod.NewRateLimitedClient(llmClientRaw, llmRateLimiter)
+			llmRateLimiter = llmmod.NewLLMRateLimiter(llmLimiterConfigs, llmDefaults)
 			logger.Info("Worker LLM 限流已启用", "providers", len(llmLimiterConfigs))
 		}
+		rateLimitedLLM := llmmod.NewRateLimitedClient(llmClientRaw, llmRateLimiter)
+		var llmClient llmmod.Client = rateLimitedLLM
+		// 规划调用：配置了 model.fallback.planner 时使用独立的故障切换链（共享限流器）
+		var llmPlannerClient llmmod.Client = rateLimitedLLM
+		if plannerLLMRaw != nil {
+			llmPlannerClient = llmmod.NewRateLimitedClient(plannerLLMRaw, llmRateLimiter)
+		}
+		appObj.preflightLLM = map[string]llmmod.Client{app.LLMUseCaseGeneration: llmClient, app.LLMUseCasePlanner: llmPlannerClient}
+		extraTools, err := app.BuiltinWebTools(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化 web 工具failed: %w", err)
+		}
+		codeExecTool, err := app.BuiltinCodeExecTool(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化 code_exec 工具failed: %w", err)
+		}
+		if codeExecTool != nil {
+			extraTools = append(extraTools, codeExecTool)
+		}
+		capabilityPolicy, err := app.NewCapabilityPolicy(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化 capability policy failed: %w", err)
+		}
 		toolsReg := tools.NewRegistry()
-		tools.RegisterBuiltin(toolsReg, engine, nil)
+		tools.RegisterBuiltin(toolsReg, engine, nil, extraTools...)
+		// 外部语言 Worker（JSON-RPC over stdio）：其工具与内建工具一同参与规划与执行
+		if len(cfg.Agent.ExternalWorkers) > 0 {
+			appObj.externalHost = extworker.NewHostFromConfig(cfg.Agent.ExternalWorkers, DefaultWorkerID(), logger)
+			n := appObj.externalHost.Start(context.Background(), toolsReg)
+			logger.Info("外部 Worker 工具已注册", "workers", len(cfg.Agent.ExternalWorkers), "tools", n)
+		}
+		// 工具成本/延迟标注（agent.plan_cost）：用于 PlanGenerated 的成本/ETA 预估
+		llmCost, planBudget := app.ApplyPlanCostConfig(cfg, toolsReg)
+		app.ApplyToolCategoriesConfig(cfg, toolsReg)
+		app.ApplyIdempotentToolsConfig(cfg, toolsReg)
+		app.ApplyToolSemaphoresConfig(cfg, toolsReg)
+		nodePluginTypes, errPlugins := app.LoadNodePluginManifests(cfg)
+		if errPlugins != nil {
+			return nil, errPlugins
+		}
+		if len(nodePluginTypes) > 0 {
+			logger.Info("自定义节点类型已注册", "types", nodePluginTypes)
+		}
+		planCostModel := planner.CostModel{LLM: llmCost}
+		if schema, errSchema := toolsReg.SchemasForLLM(); errSchema == nil {
+			planCostModel = planner.CostModelFromSchemaJSON(schema)
+			planCostModel.LLM = llmCost
+		}
 		var v1Planner planner.Planner
 		if os.Getenv("PLANNER_TYPE") == "rule" {
 			v1Planner = planner.NewRulePlanner()
 			logger.Info("Worker 使用规则规划器")
 		} else {
-			v1Planner = planner.NewLLMPlanner(llmClient)
+			llmPlanner := planner.NewLLMPlanner(llmPlannerClient)
+			llmPlanner.SetPlanCost(llmCost, planBudget)
+			llmPlanner.SetRequireRationale(cfg.Agent.PlanRationale.Required)
+			v1Planner = llmPlanner
 		}
 		nodeEventSink := api.NewNodeEventSink(pgEventStore)
 		var invocationStore agentexec.ToolInvocationStore
-		if invPoolConfig, errPool := pgxpool.ParseConfig(dsn); errPool == nil {
-			if invPool, errPool := pgxpool.NewWithConfig(context.Background(), invPoolConfig); errPool == nil {
-				invocationStore = agentexec.NewToolInvocationStorePg(invPool)
-			}
+		invPool, errPool := optionalPool(pgPools, pgpool.ComponentInvocations, dsn, logger)
+		if errPool != nil {
+			return nil, errPool
+		}
+		if invPool != nil {
+			invocationStore = agentexec.NewToolInvocationStorePg(invPool)
 		}
 		if invocationStore == nil {
 			invocationStore = agentexec.NewToolInvocationStoreMem()
 		}
 		var effectStore agentexec.EffectStore
 		if cfg.EffectStore.Type == "postgres" && cfg.EffectStore.DSN != "" {
-			effPoolConfig, errPool := pgxpool.ParseConfig(cfg.EffectStore.DSN)
-			if errPool != nil {
-				return nil, fmt.Errorf("解析 EffectStore DSN failed: %w", errPool)
-			}
-			effPool, errPool := pgxpool.NewWithConfig(context.Background(), effPoolConfig)
+			effPool, errPool := pgPools.Pool(context.Background(), pgpool.ComponentEffects, cfg.EffectStore.DSN)
 			if errPool != nil {
 				return nil, fmt.Errorf("创建 EffectStore 连接池failed: %w", errPool)
 			}
 			effectStore = agentexec.NewEffectStorePg(effPool)
+			if lb := cfg.EffectStore.LocalBuffer; lb.Enable {
+				bufCfg := agentexec.BufferedEffectStoreConfig{Dir: lb.Dir, MaxPending: lb.MaxPending}
+				if bufCfg.Dir == "" {
+					bufCfg.Dir = filepath.Join("data", "effect-buffer", DefaultWorkerID())
+				}
+				if d, err := time.ParseDuration(lb.FlushInterval); err == nil && d > 0 {
+					bufCfg.FlushInterval = d
+				}
+				buffered, errBuf := agentexec.NewBufferedEffectStore(effectStore, bufCfg)
+				if errBuf != nil {
+					return nil, fmt.Errorf("初始化本地 effect 缓冲failed: %w", errBuf)
+				}
+				appObj.effectBuffer = buffered
+				effectStore = buffered
+				logger.Info("Effect 本地缓冲已启用", "dir", bufCfg.Dir, "recovered_pending", buffered.Pending())
+			}
 		} else {
 			effectStore = agentexec.NewEffectStoreMem()
 		}
@@ -209,35 +445,93 @@ func NewApp(cfg *config.Config) (*App, error) {
 			}
 			toolRateLimiter = agentexec.NewToolRateLimiter(toolLimiterConfigs, toolDefaults)
 		}
-		dagCompiler := api.NewDAGCompilerWithOptions(llmClient, toolsReg, engine, nodeEventSink, nodeEventSink, invocationStore, effectStore, resourceVerifier, api.NewAttemptValidator(pgEventStore), toolRateLimiter)
+		// Agent 级配置：与 API 共享 agent_config 表，工具执行前解析 secret_ref 并注入 sdk.ConfigFromContext
+		secretStore, errSecrets := agentconfig.NewSecretStoreFromConfig(cfg.Secrets)
+		if errSecrets != nil {
+			return nil, fmt.Errorf("初始化 secret store failed: %w", errSecrets)
+		}
+		agentCfgResolver := agentconfig.NewResolver(agentconfig.NewStorePg(jobsPool), secretStore)
+		dagCompiler := api.NewDAGCompilerWithOptions(llmClient, toolsReg, engine, nodeEventSink, nodeEventSink, invocationStore, effectStore, resourceVerifier, api.NewAttemptValidator(pgEventStore), toolRateLimiter, agentCfgResolver)
+		api.SetToolKillSwitch(dagCompiler, killSwitchGate)
+		api.SetToolCapabilityPolicy(dagCompiler, capabilityPolicy)
+		api.SetToolOutputLimits(dagCompiler, app.ToolOutputLimitsFrom(cfg))
+		// 集群级工具信号量：与 API 及其他 Worker 共享 tool_semaphore_* 表，排队先到先得
+		semPool, errSem := pgPools.Pool(context.Background(), pgpool.ComponentToolSemaphores, dsn)
+		if errSem != nil {
+			return nil, fmt.Errorf("初始化工具信号量存储(postgres) failed: %w", errSem)
+		}
+		api.SetToolSemaphores(dagCompiler, app.ToolSemaphoresFrom(cfg, toolsReg, jobstore.NewSemaphoreStorePg(semPool)))
+		egressPolicies, errEgress := app.ToolEgressFrom(cfg, logger)
+		if errEgress != nil {
+			return nil, fmt.Errorf("初始化租户出网策略 failed: %w", errEgress)
+		}
+		api.SetToolEgress(dagCompiler, egressPolicies)
+		// LLM prompt 留存：按租户采样或仅失败时脱敏写入对象存储，llm 事件只记录引用
+		promptLogger, err := promptlog.NewFromConfig(context.Background(), cfg.Agent.PromptLog)
+		if err != nil {
+			return nil, fmt.Errorf("初始化 prompt 留存failed: %w", err)
+		}
+		if promptLogger != nil {
+			api.SetPromptLogger(dagCompiler, promptLogger)
+			logger.Info("LLM prompt 留存已启用", "sample_rate", cfg.Agent.PromptLog.SampleRate, "failure_only", cfg.Agent.PromptLog.FailureOnly)
+		}
+		// 监督者 spawn/join 节点：子 Job 由本 Worker 规划并写 plan_generated，子 Job 结束后唤醒在 join 等待的监督者
+		supervisor := job.NewSupervisor(pgJobStore, pgEventStore, job.NewChildJobStorePg(jobsPool), func(ctx context.Context, agentID, goal string) (*planner.TaskGraph, error) {
+			return v1Planner.PlanGoal(planner.WithAgentID(ctx, agentID), goal, memory.NewCompositeMemory())
+		})
+		api.SetChildJobRuntime(dagCompiler, supervisor)
 		dagRunner := api.NewDAGRunner(dagCompiler)
 		checkpointStore := runtime.NewCheckpointStoreMem()
 		if cfg.CheckpointStore.Type == "postgres" && cfg.CheckpointStore.DSN != "" {
-			cpPoolConfig, errPool := pgxpool.ParseConfig(cfg.CheckpointStore.DSN)
-			if errPool != nil {
-				return nil, fmt.Errorf("解析 CheckpointStore DSN failed: %w", errPool)
-			}
-			cpPool, errPool := pgxpool.NewWithConfig(context.Background(), cpPoolConfig)
+			cpPool, errPool := pgPools.Pool(context.Background(), pgpool.ComponentCheckpoints, cfg.CheckpointStore.DSN)
 			if errPool != nil {
 				return nil, fmt.Errorf("创建 CheckpointStore 连接池failed: %w", errPool)
 			}
 			checkpointStore = runtime.NewCheckpointStorePg(cpPool)
 		}
-		agentStateStore, errState := runtime.NewAgentStateStorePg(context.Background(), dsn)
+		statePool, errState := pgPools.Pool(context.Background(), pgpool.ComponentAgentState, dsn)
 		if errState != nil {
 			return nil, fmt.Errorf("初始化 AgentStateStore(postgres) failed: %w", errState)
 		}
+		agentStateStore := runtime.NewAgentStateStorePgWithPool(statePool)
 		dagRunner.SetCheckpointStores(checkpointStore, &jobStoreForRunnerAdapter{JobStore: pgJobStore})
-		dagRunner.SetPlanGeneratedSink(api.NewPlanGeneratedSink(pgEventStore))
+		dagRunner.SetPlanGeneratedSink(api.NewPlanGeneratedSinkWithCostModel(pgEventStore, planCostModel))
 		dagRunner.SetNodeEventSink(nodeEventSink)
 		dagRunner.SetRecordedEffectsRecorder(api.NewRecordedEffectsRecorder(pgEventStore))
-		dagRunner.SetReplayContextBuilder(api.NewReplayContextBuilder(pgEventStore))
-		dagRunner.SetReplayPolicy(replaysandbox.DefaultPolicy{})
+		dagRunner.SetReplayContextBuilder(api.NewReplayContextBuilderWithConfig(pgEventStore, cfg.Runtime.Replay))
+		// 显式声明幂等的工具（ToolManifest.idempotent / agent.idempotent_tools）Replay 时无已记录结果可重新执行
+		dagRunner.SetReplayPolicy(replaysandbox.NewIdempotentToolPolicy(replaysandbox.DefaultPolicy{}, toolsReg.Idempotency))
+		dagRunner.SetScratchpadLimits(app.ScratchpadLimitsFrom(cfg))
+		workspaces, errWS := app.NewWorkspaceManager(cfg)
+		if errWS != nil {
+			return nil, fmt.Errorf("初始化 Job 工作区failed: %w", errWS)
+		}
+		if workspaces != nil {
+			dagRunner.SetWorkspaces(func(jobID string) sdk.Workspace { return workspaces.For(jobID) })
+			appObj.workspaceGC = workspace.NewJanitor(workspaces, pgJobStore, logger)
+			appObj.workspaceEvery = workspace.DefaultCleanupInterval
+			if d, errParse := time.ParseDuration(cfg.Agent.Workspace.CleanupInterval); errParse == nil && d > 0 {
+				appObj.workspaceEvery = d
+			}
+		}
+		dagRunner.SetStepBoundaryGate(func(ctx context.Context, j *agentexec.JobForRunner) bool {
+			return maintGate.ShouldPark(ctx, j.TenantID)
+		})
+		if reflectionCfg, ok := app.ReflectionConfigFrom(cfg); ok && llmClient != nil {
+			dagRunner.SetReflection(api.NewDAGReflector(llmClient), reflectionCfg)
+		}
 		if cfg.Worker.Timeout != "" {
 			if d, err := time.ParseDuration(cfg.Worker.Timeout); err == nil && d > 0 {
 				dagRunner.SetStepTimeout(d)
 			}
 		}
+		// 交互式预留通道：额外槽位只认领 interactive Job，且其单步超时更紧
+		interactiveReserved, interactiveStepTimeout := app.InteractiveLaneFrom(cfg.Worker.InteractiveLane)
+		// 执行档位须与 API 配置一致；未知档位（配置漂移）时按全局配置执行
+		executionProfiles, errProfiles := app.ExecutionProfilesFrom(cfg.Agent.ExecutionProfiles)
+		if errProfiles != nil {
+			return nil, fmt.Errorf("agent.execution_profiles: %w", errProfiles)
+		}
 		maxAttempts := cfg.Worker.MaxAttempts
 		if maxAttempts <= 0 {
 			maxAttempts = 3
@@ -271,39 +565,74 @@ func NewApp(cfg *config.Config) (*App, error) {
 			if sessionID == "" {
 				sessionID = j.AgentID
 			}
-			state, _ := agentStateStore.LoadAgentState(ctx, j.AgentID, sessionID)
+			tenantID := j.TenantID
+			if tenantID == "" {
+				tenantID = "default"
+			}
+			// 会话状态按 Job 的租户与 Agent 限定，不能读到其他租户同名 Agent 的会话
+			states, errScope := runtime.NewScopedAgentStateStore(agentStateStore, runtime.MemoryScope{TenantID: tenantID, AgentID: j.AgentID})
+			if errScope != nil {
+				return fmt.Errorf("agent state scope: %w", errScope)
+			}
 			sess := runtime.NewSession(sessionID, j.AgentID)
-			if state != nil {
+			state, errState := states.Load(ctx, sessionID)
+			if errState != nil {
+				logger.Warn("加载会话状态failed，使用空会话", "job_id", j.ID, "error", errState)
+			} else if state != nil {
 				runtime.ApplyAgentState(sess, state)
 			}
 			plannerProv := newPlannerProviderAdapter(v1Planner)
 			toolsProv := newToolsProviderAdapter(toolsReg)
 			agent := runtime.NewAgent(j.AgentID, j.AgentID, sess, nil, plannerProv, toolsProv)
-			tenantID := j.TenantID
-			if tenantID == "" {
-				tenantID = "default"
-			}
 			if err := waitPlanReady(ctx, j.ID, 20*time.Second); err != nil {
 				return err
 			}
-			err := dagRunner.RunForJob(ctx, agent, &agentexec.JobForRunner{
-				ID: j.ID, AgentID: j.AgentID, Goal: j.Goal, Cursor: j.Cursor, TenantID: tenantID,
-			})
-			if agentStateStore != nil && agent.Session != nil {
-				_ = agentStateStore.SaveAgentState(ctx, j.AgentID, agent.Session.ID, runtime.SessionToAgentState(agent.Session))
+			// 协商后的 Job 仅启用其声明的特性（如未协商 parallel_dag 则顺序执行），保证事件可被同 schema 的任意 Worker 续跑
+			features, _ := compat.JobFeatures(j.RequiredCapabilities)
+			jr := &agentexec.JobForRunner{
+				ID: j.ID, AgentID: j.AgentID, Goal: j.Goal, Cursor: j.Cursor, TenantID: tenantID, Features: features,
+			}
+			if j.Interactive {
+				jr.StepTimeout = interactiveStepTimeout
+			}
+			jr.Profile = executionProfiles.Get(j.Profile)
+			err := dagRunner.RunForJob(ctx, agent, jr)
+			if agent.Session != nil {
+				_ = states.Save(ctx, agent.Session.ID, runtime.SessionToAgentState(agent.Session))
 			}
 			_, ver, _ := pgEventStore.ListEvents(ctx, j.ID)
+			if pgretry.IsUnavailable(err) {
+				// 数据库暂不可用：不 Requeue、不计入 max_attempts，租约过期后由孤儿回收重新派发（从检查点恢复）
+				return err
+			}
 			if err != nil && errors.Is(err, agentexec.ErrJobWaiting) {
 				// Job 在 Wait 节点挂起，已写 job_waiting 并置为 Waiting；等待 signal 后重新入队，不写终端事件
+				// join 节点挂起期间若已有子 Job 结束，立即重新入队
+				if _, errResume := supervisor.Resume(ctx, j.ID); errResume != nil {
+					logger.Warn("监督者唤醒检查failed", "job_id", j.ID, "error", errResume)
+				}
+				return err
+			}
+			if err != nil && errors.Is(err, agentexec.ErrJobDeferred) {
+				// 维护窗口内在 step 边界暂停，已置为 Deferred；窗口结束后恢复，不写终端事件
 				return err
 			}
 			if err != nil {
 				// 毒任务保护：达到 max_attempts 后标记 Failed 并写 job_failed，不再调度；否则 Requeue（不写终端事件）供再次 Claim
-				if j.RetryCount+1 >= maxAttempts {
-					errStr := err.Error()
-					payload, _ := json.Marshal(map[string]interface{}{"goal": j.Goal, "error": errStr})
+				// 事务组已回滚（已提交成员均已补偿）时直接终止，重试会在已撤销的副作用上继续执行
+				if j.RetryCount+1 >= maxAttempts || errors.Is(err, agentexec.ErrTransactionRolledBack) {
+					events, _, _ := pgEventStore.ListEvents(ctx, j.ID)
+					info := app.FailureTerminalInfo(err, "worker:"+DefaultWorkerID(), events)
+					if info.FailureClass == job.FailureClassError {
+						info.FailureClass = job.FailureClassMaxAttempts
+					}
+					pl := info.EventFields()
+					pl["goal"] = j.Goal
+					pl["error"] = err.Error()
+					payload, _ := json.Marshal(pl)
 					_, _ = pgEventStore.Append(ctx, j.ID, ver, jobstore.JobEvent{JobID: j.ID, Type: jobstore.JobFailed, Payload: payload})
 					_ = pgJobStore.UpdateStatus(ctx, j.ID, job.StatusFailed)
+					_ = job.RecordTerminalInfo(ctx, pgJobStore, j.ID, info)
 				} else {
 					_ = pgJobStore.Requeue(ctx, j)
 				}
@@ -312,6 +641,11 @@ func NewApp(cfg *config.Config) (*App, error) {
 				_, _ = pgEventStore.Append(ctx, j.ID, ver, jobstore.JobEvent{JobID: j.ID, Type: jobstore.JobCompleted, Payload: payload})
 				_ = pgJobStore.UpdateStatus(ctx, j.ID, job.StatusCompleted)
 			}
+			if latest, _ := pgJobStore.Get(ctx, j.ID); latest != nil && latest.Status.IsTerminal() {
+				if errNotify := supervisor.NotifyTerminal(ctx, j.ID); errNotify != nil {
+					logger.Warn("通知监督者failed", "job_id", j.ID, "error", errNotify)
+				}
+			}
 			return err
 		}
 		pollInterval := 2 * time.Second
@@ -324,6 +658,8 @@ func NewApp(cfg *config.Config) (*App, error) {
 		if maxConcurrency <= 0 {
 			maxConcurrency = 2
 		}
+		// 版本握手：认领能力追加 schema:/feature: 项，仅认领本 Worker 能处理的 Job；心跳时声明供 API 协商
+		handshake := compat.Local(DefaultWorkerID(), compat.BuildVersion())
 		runner := NewAgentJobRunner(
 			DefaultWorkerID(),
 			pgEventStore,
@@ -332,20 +668,65 @@ func NewApp(cfg *config.Config) (*App, error) {
 			pollInterval,
 			leaseDur,
 			maxConcurrency,
-			cfg.Worker.Capabilities,
+			compat.AdvertisedCapabilities(cfg.Worker.Capabilities, handshake),
 			logger,
 		)
+		runner.SetHandshake(handshake)
+		runner.SetDBGuard(dbGuard)
 		// 唤醒队列：无 job 时用 Receive(pollInterval) 替代固定 sleep，API 侧 JobSignal/JobMessage 若设置同一 WakeupQueue 可立即唤醒（单进程部署时注入同一实例）
 		wakeupQueue := job.NewWakeupQueueMem(256)
 		runner.SetWakeupQueue(wakeupQueue)
+		supervisor.SetWakeupQueue(wakeupQueue)
+		runner.SetSupervisor(supervisor)
+		runner.SetMaintenanceGate(maintGate)
+		runner.SetInteractiveLane(interactiveReserved)
+		// 租户加权公平认领：按 jobs 表中各租户的 Pending/Running 计算份额，避免单个租户的积压饿死其他租户
+		if fair := app.FairShareFrom(cfg.Worker.FairShare, pgJobStore); fair != nil {
+			runner.SetFairShare(fair)
+			logger.Info("租户公平认领已启用", "tenants", len(cfg.Worker.FairShare.Tenants))
+		}
+		// 资源感知认领：采样主机 CPU/内存与本 Worker 的 LLM/Tool 并发，超过阈值时暂停认领；状态随心跳写入 worker_status
+		runner.SetWorkerStatusStore(scheduler.NewWorkerStatusStorePg(jobsPool))
+		if tc := cfg.Worker.Throttle; tc.Enable {
+			var sampleInterval time.Duration
+			if tc.SampleInterval != "" {
+				if d, errParse := time.ParseDuration(tc.SampleInterval); errParse == nil && d > 0 {
+					sampleInterval = d
+				}
+			}
+			runner.SetClaimThrottle(scheduler.NewClaimThrottle(
+				scheduler.NewHostSampler(rateLimitedLLM.InFlight, agentexec.ToolsInFlight),
+				scheduler.ThrottleThresholds{
+					MaxCPUPercent:    tc.MaxCPUPercent,
+					MaxMemoryPercent: tc.MaxMemoryPercent,
+					MaxLLMInFlight:   tc.MaxLLMInFlight,
+					MaxToolInFlight:  tc.MaxToolInFlight,
+				},
+				sampleInterval,
+			))
+			logger.Info("Worker 资源感知认领已启用", "max_cpu_percent", tc.MaxCPUPercent, "max_memory_percent", tc.MaxMemoryPercent,
+				"max_llm_in_flight", tc.MaxLLMInFlight, "max_tool_in_flight", tc.MaxToolInFlight)
+		}
 		// Inbox 驱动创建 Job：轮询 agent_messages 未消费消息，创建 Job 后 NotifyReady（design/plan.md Phase A）
-		if inboxStore, errInbox := messaging.NewStorePg(context.Background(), dsn); errInbox == nil {
-			runner.SetInboxReader(inboxStore)
+		inboxPool, errInbox := optionalPool(pgPools, pgpool.ComponentMessaging, dsn, logger)
+		if errInbox != nil {
+			return nil, errInbox
+		}
+		if inboxPool != nil {
+			runner.SetInboxReader(messaging.NewStorePgWithPool(inboxPool))
 			logger.Info("Worker Inbox 轮询已启用，支持 message arrival → job run")
 		}
 		// Instance current_job_id：Job 认领/结束时更新（design/plan.md Phase B）
-		if instanceStore, errInst := instance.NewStorePg(context.Background(), dsn); errInst == nil {
-			runner.SetInstanceStore(instanceStore)
+		runner.SetInstanceStore(instance.NewStorePgWithPool(jobsPool))
+		if appObj.inspector != nil {
+			if llmRateLimiter != nil {
+				appObj.inspector.AddLimiter("llm", llmRateLimiter)
+			}
+			if toolRateLimiter != nil {
+				appObj.inspector.AddLimiter("tool", toolRateLimiter)
+			}
+			appObj.inspectAddr = inspectAddr(cfg.Worker.Inspect.Addr)
+			runner.SetInspector(appObj.inspector, inspectAdvertiseURL(cfg.Worker.Inspect.AdvertiseURL, appObj.inspectAddr))
 		}
 		appObj.agentJobRunner = runner
 		appObj.jobEventStore = pgEventStore
@@ -360,10 +741,42 @@ func NewApp(cfg *config.Config) (*App, error) {
 func (a *App) Start() error {
 	a.logger.Info("启动 worker 应用")
 
+	if a.pgPools != nil {
+		a.pgPools.Start(func(component string, err error) {
+			a.logger.Warn("Postgres 连接池健康检查failed", "component", component, "error", err)
+		})
+	}
+
+	if a.eventRelay != nil {
+		a.eventRelay.Start(context.Background())
+		a.logger.Info("事件导出已启用", "sink", a.config.EventExport.Sink, "format", a.config.EventExport.Format)
+	}
+
+	if a.maintenance != nil {
+		a.maintenance.Start(context.Background())
+	}
+
+	// LLM 启动预检：required 时在认领 Job 前同步执行、failed即退出；否则后台执行
+	if a.config != nil && a.config.Model.Preflight.Required {
+		if err := app.RunStartupPreflight(context.Background(), a.config, a.readiness, a.preflightLLM, a.logger); err != nil {
+			return err
+		}
+	} else {
+		go func() {
+			_ = app.RunStartupPreflight(context.Background(), a.config, a.readiness, a.preflightLLM, a.logger)
+		}()
+	}
+
 	if a.agentJobRunner != nil {
 		ctx, cancel := context.WithCancel(context.Background())
 		a.agentJobCancel = cancel
 		a.agentJobRunner.Start(ctx)
+		if a.identity != nil {
+			go a.identity.Run(ctx, a.identityEvery, a.logger)
+		}
+		if a.workspaceGC != nil {
+			go a.workspaceGC.Run(ctx, a.workspaceEvery)
+		}
 	}
 
 	// 可选：Prometheus /metrics 端点；多 Worker 时可用 AETHERIS_WORKER_METRICS_PORT 指定不同端口避免冲突
@@ -384,6 +797,7 @@ func (a *App) Start() error {
 			w.Header().Set("Content-Type", string(expfmt.FmtText))
 			_, _ = w.Write(buf.Bytes())
 		})
+		mux.Handle("/readyz", a.readiness)
 		addr := fmt.Sprintf(":%d", port)
 		go func() {
 			if err := http.ListenAndServe(addr, mux); err != nil && err != http.ErrServerClosed {
@@ -393,6 +807,18 @@ func (a *App) Start() error {
 		a.logger.Info("Prometheus /metrics 已启用", "addr", addr)
 	}
 
+	// 可选：Worker 自省端点 /debug/inspect（worker.inspect）
+	if a.inspector != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/inspect", workerinspect.Handler(a.inspector, a.config.Worker.Inspect.Token))
+		go func() {
+			if err := http.ListenAndServe(a.inspectAddr, mux); err != nil && err != http.ErrServerClosed {
+				a.logger.Error("自省服务异常", "error", err)
+			}
+		}()
+		a.logger.Info("Worker 自省端点已启用", "addr", a.inspectAddr, "path", "/debug/inspect")
+	}
+
 	// Snapshot 自动化（2.0 performance）：每小时扫描事件数 > 1000 的 Job，自动创建快照减少 Replay 开销
 	if a.jobEventStore != nil {
 		go a.runSnapshotLoop()
@@ -435,7 +861,7 @@ func (a *App) runSnapshotLoop() {
 
 // triggerSnapshotsForHighEventJobs 对高事件量的 Job 触发快照创建
 func (a *App) triggerSnapshotsForHighEventJobs(eventThreshold, limit int) {
-	ss, ok := a.jobEventStore.(jobstore.SnapshotJobStore)
+	ss, ok := jobstore.Find[jobstore.SnapshotJobStore](a.jobEventStore)
 	if !ok {
 		return
 	}
@@ -570,21 +996,51 @@ func (a *App) Shutdown(ctx context.Context) error {
 		a.logger.Error("关闭 eino 引擎failed", "error", err)
 	}
 
+	// 5. 停止事件导出、维护窗口控制器并关闭 Postgres 连接池
+	if a.eventRelay != nil {
+		a.eventRelay.Stop()
+	}
+	if a.maintenance != nil {
+		a.maintenance.Stop()
+	}
+	a.externalHost.Close()
+	// 在关闭连接池前尽量刷完本地 effect 缓冲；未刷完的保留在 WAL，下次启动恢复
+	if a.effectBuffer != nil {
+		if err := a.effectBuffer.Close(ctx); err != nil {
+			a.logger.Warn("本地 effect 缓冲未完全刷出", "error", err)
+		}
+	}
+	if a.pgPools != nil {
+		a.pgPools.Close()
+	}
+
 	a.logger.Info("worker 应用关闭成功")
 	return nil
 }
 
+// optionalPool 申请可选组件的连接池：预算耗尽时返回错误使启动failed；其他错误记 error 日志并返回 nil，调用方关闭该功能
+func optionalPool(pgPools *pgpool.Manager, component, dsn string, logger *log.Logger) (*pgxpool.Pool, error) {
+	pool, err := pgPools.Pool(context.Background(), component, dsn)
+	if errors.Is(err, pgpool.ErrBudgetExhausted) {
+		return nil, fmt.Errorf("创建 %s 连接池failed: %w", component, err)
+	}
+	if err != nil {
+		logger.Error("创建连接池failed，相关功能已关闭", "component", component, "error", err)
+		return nil, nil
+	}
+	return pool, nil
+}
+
 // startWorkerQueue 启动工作队列消费者；每个入库任务应调用 a.engine.ExecuteWorkflow(ctx, "ingest_pipeline", taskPayload)
 func (a *App) startWorkerQueue() error {
 	if a.config == nil || a.config.JobStore.Type != "postgres" || a.config.JobStore.DSN == "" {
 		return nil
 	}
 	dsn := a.config.JobStore.DSN
-	poolConfig, err := pgxpool.ParseConfig(dsn)
-	if err != nil {
-		return fmt.Errorf("解析入库队列 DSN failed: %w", err)
+	if a.pgPools == nil {
+		a.pgPools = pgpool.NewManager(a.config.JobStore.Pool)
 	}
-	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	pool, err := a.pgPools.Pool(context.Background(), pgpool.ComponentIngestQueue, dsn)
 	if err != nil {
 		return fmt.Errorf("创建入库队列连接池failed: %w", err)
 	}
@@ -601,26 +1057,6 @@ func (a *App) startWorkerQueue() error {
 	return nil
 }
 
-func validateProductionRuntimeConfig(cfg *config.Config) error {
-	if cfg == nil {
-		return nil
-	}
-	prod := cfg.Runtime.Profile == "prod" || cfg.Runtime.Strict
-	if !prod {
-		return nil
-	}
-	if cfg.JobStore.Type != "postgres" || cfg.JobStore.DSN == "" {
-		return fmt.Errorf("production requires jobstore.type=postgres with dsn")
-	}
-	if cfg.EffectStore.Type != "postgres" || cfg.EffectStore.DSN == "" {
-		return fmt.Errorf("production requires effect_store.type=postgres with dsn")
-	}
-	if cfg.CheckpointStore.Type != "postgres" || cfg.CheckpointStore.DSN == "" {
-		return fmt.Errorf("production requires checkpoint_store.type=postgres with dsn")
-	}
-	return nil
-}
-
 func getHostname() string {
 	h, _ := os.Hostname()
 	if h == "" {
@@ -648,25 +1084,33 @@ func (a *App) runIngestQueueLoop(queue ingestqueue.IngestQueue, workerID string,
 		}
 		contentBase64, _ := payload["content_base64"].(string)
 		if contentBase64 == "" {
-			_ = queue.MarkFailed(ctx, taskID, "payload 缺少 content_base64")
+			_ = queue.MarkStageFailed(ctx, taskID, ingestqueue.Failure{Stage: common.StageLoad, Class: common.ErrorClassInvalidInput, Message: "payload 缺少 content_base64"})
 			continue
 		}
 		decoded, err := base64.StdEncoding.DecodeString(contentBase64)
 		if err != nil {
-			_ = queue.MarkFailed(ctx, taskID, "content_base64 解码failed: "+err.Error())
+			_ = queue.MarkStageFailed(ctx, taskID, ingestqueue.Failure{Stage: common.StageLoad, Class: common.ErrorClassInvalidInput, Message: "content_base64 解码failed: " + err.Error()})
 			continue
 		}
-		params := map[string]interface{}{"content": decoded}
+		// task_id 作为 ingest_id 贯穿阶段日志，便于从任务下钻到日志
+		params := map[string]interface{}{"content": decoded, "task_id": taskID}
 		if fn, ok := payload["filename"].(string); ok && fn != "" {
 			params["filename"] = fn
 		}
 		if meta, ok := payload["metadata"]; ok {
 			params["metadata"] = meta
 		}
+		// 恢复上传时的租户上下文，数据驻留开启时写入该租户所在区域的存储
+		if meta, ok := payload["metadata"].(map[string]interface{}); ok {
+			if tenant, _ := meta[residency.MetaTenantID].(string); tenant != "" {
+				ctx = auth.WithTenantID(ctx, tenant)
+			}
+		}
 		result, err := a.engine.ExecuteWorkflow(ctx, "ingest_pipeline", params)
 		if err != nil {
-			_ = queue.MarkFailed(ctx, taskID, err.Error())
-			a.logger.Error("入库任务执行failed", "task_id", taskID, "error", err)
+			failure := ingestqueue.FailureFromError(common.StageLoad, err)
+			_ = queue.MarkStageFailed(ctx, taskID, failure)
+			a.logger.Error("入库任务执行failed", "task_id", taskID, "stage", failure.Stage, "error_class", failure.Class, "error", err)
 			continue
 		}
 		if err := queue.MarkCompleted(ctx, taskID, result); err != nil {
@@ -0,0 +1,51 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"net"
+	"os"
+)
+
+const defaultInspectAddr = ":9095"
+
+// inspectAddr 自省端点监听地址：AETHERIS_WORKER_INSPECT_ADDR 优先（同机多 Worker 时避免端口冲突），其次配置，默认 :9095
+func inspectAddr(addr string) string {
+	if env := os.Getenv("AETHERIS_WORKER_INSPECT_ADDR"); env != "" {
+		return env
+	}
+	if addr != "" {
+		return addr
+	}
+	return defaultInspectAddr
+}
+
+// inspectAdvertiseURL 随状态心跳上报的自省端点地址；未配置时以主机名与监听端口推导
+func inspectAdvertiseURL(advertise, addr string) string {
+	if advertise != "" {
+		return advertise
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host, _ = os.Hostname()
+		if host == "" {
+			return ""
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/debug/inspect"
+}
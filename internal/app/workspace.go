@@ -0,0 +1,55 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+
+	"rag-platform/internal/agent/workspace"
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/config"
+)
+
+// NewWorkspaceManager 按 agent.workspace 创建 Job 工作区管理器；未启用时返回 nil
+func NewWorkspaceManager(cfg *config.Config) (*workspace.Manager, error) {
+	if cfg == nil || !cfg.Agent.Workspace.Enable {
+		return nil, nil
+	}
+	wc := cfg.Agent.Workspace
+	var store workspace.Store
+	backend := wc.Backend
+	switch backend {
+	case "", "local":
+		backend = "local"
+		local, err := workspace.NewLocalStore(wc.Root)
+		if err != nil {
+			return nil, err
+		}
+		store = local
+	case "object":
+		objStore, err := object.NewStore(wc.Storage)
+		if err != nil {
+			return nil, err
+		}
+		store = workspace.NewObjectStore(objStore, wc.Prefix)
+	default:
+		return nil, fmt.Errorf("unknown workspace backend %q (want local or object)", wc.Backend)
+	}
+	retention := parseOptionalDuration(wc.Retention)
+	if wc.Retention != "" && retention == 0 {
+		retention = -1
+	}
+	return workspace.NewManager(store, workspace.Options{Backend: backend, Quota: wc.QuotaBytes, Retention: retention}), nil
+}
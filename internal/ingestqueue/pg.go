@@ -23,6 +23,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"rag-platform/internal/pipeline/common"
 )
 
 // ingestQueuePg PostgreSQL 实现 IngestQueue，使用 ingest_tasks 表
@@ -99,6 +100,19 @@ func (q *ingestQueuePg) MarkFailed(ctx context.Context, taskID string, errMsg st
 	return err
 }
 
+// MarkStageFailed 实现 IngestQueue；各阶段耗时写入 result.stages 供 Stats 聚合
+func (q *ingestQueuePg) MarkStageFailed(ctx context.Context, taskID string, f Failure) error {
+	var resultJSON []byte
+	if len(f.Stages) > 0 {
+		resultJSON, _ = json.Marshal(map[string]interface{}{"stages": f.Stages})
+	}
+	_, err := q.pool.Exec(ctx,
+		`UPDATE ingest_tasks SET status = 'failed', error = $1, failed_stage = $2, error_class = $3, result = $4, completed_at = now() WHERE id = $5`,
+		f.Message, nullIfEmpty(f.Stage), nullIfEmpty(f.Class), resultJSON, taskID,
+	)
+	return err
+}
+
 // GetStatus 实现 IngestQueue
 func (q *ingestQueuePg) GetStatus(ctx context.Context, taskID string) (status string, result interface{}, errMsg string, completedAt interface{}, err error) {
 	var st string
@@ -128,3 +142,118 @@ func (q *ingestQueuePg) GetStatus(ctx context.Context, taskID string) (status st
 	}
 	return st, result, errMsg, completedAt, nil
 }
+
+// tenantFilter 租户过滤条件：tenant 为空时恒真；tenant 取自入队时写入的 payload.metadata.tenant_id
+const tenantFilter = `($1 = '' OR payload->'metadata'->>'tenant_id' = $1)`
+
+// Stats 实现 IngestQueue
+func (q *ingestQueuePg) Stats(ctx context.Context, tenant string, since time.Time) (*Stats, error) {
+	stats := &Stats{Counts: map[string]int{}, Since: since, Stages: []StageStat{}, Failures: []FailureCount{}}
+	rows, err := q.pool.Query(ctx, `SELECT status, count(*) FROM ingest_tasks WHERE `+tenantFilter+` GROUP BY status`, tenant)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.Counts[status] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.pool.Query(ctx,
+		`SELECT s->>'stage',
+       count(*),
+       count(*) FILTER (WHERE (s->>'error')::boolean),
+       avg((s->>'duration_ms')::float8),
+       percentile_cont(0.95) WITHIN GROUP (ORDER BY (s->>'duration_ms')::float8)
+FROM ingest_tasks, jsonb_array_elements(result->'stages') s
+WHERE `+tenantFilter+` AND completed_at >= $2 AND jsonb_typeof(result->'stages') = 'array'
+GROUP BY 1 ORDER BY 1`,
+		tenant, since)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var st StageStat
+		if err := rows.Scan(&st.Stage, &st.Count, &st.Failures, &st.AvgMs, &st.P95Ms); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.Stages = append(stats.Stages, st)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.pool.Query(ctx,
+		`SELECT COALESCE(failed_stage, ''), COALESCE(error_class, ''), count(*)
+FROM ingest_tasks WHERE `+tenantFilter+` AND status = 'failed' AND completed_at >= $2
+GROUP BY 1, 2 ORDER BY 3 DESC`,
+		tenant, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fc FailureCount
+		if err := rows.Scan(&fc.Stage, &fc.Class, &fc.Count); err != nil {
+			return nil, err
+		}
+		stats.Failures = append(stats.Failures, fc)
+	}
+	return stats, rows.Err()
+}
+
+// ListFailed 实现 IngestQueue
+func (q *ingestQueuePg) ListFailed(ctx context.Context, tenant string, limit int) ([]FailedTask, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := q.pool.Query(ctx,
+		`SELECT id, COALESCE(payload->>'filename', ''), COALESCE(failed_stage, ''), COALESCE(error_class, ''), COALESCE(error, ''), retries, created_at, COALESCE(completed_at, created_at)
+FROM ingest_tasks WHERE `+tenantFilter+` AND status = 'failed'
+ORDER BY completed_at DESC NULLS LAST LIMIT $2`,
+		tenant, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []FailedTask{}
+	for rows.Next() {
+		var t FailedTask
+		if err := rows.Scan(&t.TaskID, &t.Filename, &t.Stage, &t.Class, &t.Error, &t.Retries, &t.CreatedAt, &t.FailedAt); err != nil {
+			return nil, err
+		}
+		t.Retryable = common.Retryable(t.Class)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Retry 实现 IngestQueue
+func (q *ingestQueuePg) Retry(ctx context.Context, tenant, taskID string) (bool, error) {
+	tag, err := q.pool.Exec(ctx,
+		`UPDATE ingest_tasks SET status = 'pending', worker_id = NULL, claimed_at = NULL, completed_at = NULL,
+  result = NULL, error = NULL, failed_stage = NULL, error_class = NULL, retries = retries + 1
+WHERE `+tenantFilter+` AND id = $2 AND status = 'failed'`,
+		tenant, taskID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -14,7 +14,12 @@
 
 package ingestqueue
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"rag-platform/internal/pipeline/common"
+)
 
 // IngestQueue 入库任务队列：API 入队，Worker 认领并执行 ingest_pipeline
 type IngestQueue interface {
@@ -26,6 +31,70 @@ type IngestQueue interface {
 	MarkCompleted(ctx context.Context, taskID string, result interface{}) error
 	// MarkFailed 标记任务failed
 	MarkFailed(ctx context.Context, taskID string, errMsg string) error
+	// MarkStageFailed 标记任务failed并记录failed阶段、error分类与各阶段耗时
+	MarkStageFailed(ctx context.Context, taskID string, f Failure) error
 	// GetStatus 查询任务状态（供 API 状态查询）；返回 status, result, errMsg, completedAt；not found返回 nil
 	GetStatus(ctx context.Context, taskID string) (status string, result interface{}, errMsg string, completedAt interface{}, err error)
+	// Stats 统计各状态任务数，及 since 之后结束任务的阶段耗时与按阶段/分类聚合的failed数；tenant 为空不按租户过滤
+	Stats(ctx context.Context, tenant string, since time.Time) (*Stats, error)
+	// ListFailed 最近的 failed 任务（按结束时间倒序）
+	ListFailed(ctx context.Context, tenant string, limit int) ([]FailedTask, error)
+	// Retry 将 failed 任务重置为 pending 并累加 retries；任务不存在或非 failed 时返回 false
+	Retry(ctx context.Context, tenant, taskID string) (bool, error)
+}
+
+// Failure 入库任务failed详情
+type Failure struct {
+	Stage   string
+	Class   string
+	Message string
+	Stages  []common.StageTiming
+}
+
+// FailureFromError 由执行error组装 Failure；非阶段error记为 stage 阶段
+func FailureFromError(stage string, err error) Failure {
+	f := Failure{Stage: stage, Class: common.ClassifyError(err), Message: err.Error()}
+	if se, ok := common.GetStageError(err); ok {
+		f.Stage = se.Stage
+		f.Stages = se.Stages
+	}
+	return f
+}
+
+// FailedTask failed 任务摘要（供 failed 下钻与重试）
+type FailedTask struct {
+	TaskID    string    `json:"task_id"`
+	Filename  string    `json:"filename,omitempty"`
+	Stage     string    `json:"stage,omitempty"`
+	Class     string    `json:"class,omitempty"`
+	Error     string    `json:"error"`
+	Retries   int       `json:"retries"`
+	Retryable bool      `json:"retryable"`
+	CreatedAt time.Time `json:"created_at"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// StageStat 单阶段耗时统计（毫秒）
+type StageStat struct {
+	Stage    string  `json:"stage"`
+	Count    int     `json:"count"`
+	Failures int     `json:"failures"`
+	AvgMs    float64 `json:"avg_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+}
+
+// FailureCount 按阶段与error分类聚合的failed数
+type FailureCount struct {
+	Stage string `json:"stage"`
+	Class string `json:"class"`
+	Count int    `json:"count"`
+}
+
+// Stats 入库任务统计
+type Stats struct {
+	// Counts 各状态任务数（全量，不受 since 限制）
+	Counts   map[string]int `json:"counts"`
+	Since    time.Time      `json:"since"`
+	Stages   []StageStat    `json:"stages"`
+	Failures []FailureCount `json:"failures"`
 }
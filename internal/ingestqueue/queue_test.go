@@ -0,0 +1,37 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingestqueue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"rag-platform/internal/pipeline/common"
+)
+
+func TestFailureFromError(t *testing.T) {
+	stages := []common.StageTiming{{Stage: common.StageLoad, DurationMs: 3}, {Stage: common.StageEmbed, DurationMs: 900, Error: true}}
+	err := fmt.Errorf("workflow: %w", &common.StageError{Stage: common.StageEmbed, Class: common.ErrorClassRateLimited, Stages: stages, Err: errors.New("429")})
+	f := FailureFromError(common.StageLoad, err)
+	if f.Stage != common.StageEmbed || f.Class != common.ErrorClassRateLimited || len(f.Stages) != 2 || f.Message != err.Error() {
+		t.Fatalf("failure = %+v", f)
+	}
+
+	f = FailureFromError(common.StageLoad, errors.New("ingest_pipeline requires params"))
+	if f.Stage != common.StageLoad || f.Class != common.ErrorClassInternal || f.Stages != nil {
+		t.Fatalf("plain error failure = %+v", f)
+	}
+}
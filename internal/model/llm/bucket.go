@@ -0,0 +1,47 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import "context"
+
+// Bucket LLM 调用所属的限流桶
+type Bucket string
+
+const (
+	// BucketPlanning 规划调用（Planner 生成计划 / 下一步决策），决定 Job 提交的用户感知延迟
+	BucketPlanning Bucket = "planning"
+	// BucketExecution 执行调用（DAG llm 节点、RAG 生成、反思等）；未标注的调用均归入此桶
+	BucketExecution Bucket = "execution"
+)
+
+type contextKey string
+
+const bucketContextKey contextKey = "llm.bucket"
+
+// WithBucket 标注 ctx 中后续 LLM 调用所属的限流桶
+func WithBucket(ctx context.Context, bucket Bucket) context.Context {
+	return context.WithValue(ctx, bucketContextKey, bucket)
+}
+
+// BucketFromContext 读取调用所属的限流桶；未标注时为 BucketExecution
+func BucketFromContext(ctx context.Context) Bucket {
+	if ctx == nil {
+		return BucketExecution
+	}
+	if b, ok := ctx.Value(bucketContextKey).(Bucket); ok && b != "" {
+		return b
+	}
+	return BucketExecution
+}
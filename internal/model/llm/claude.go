@@ -192,3 +192,16 @@ func (c *ClaudeClient) SetModel(model string) {
 func (c *ClaudeClient) SetAPIKey(apiKey string) {
 	c.apiKey = apiKey
 }
+
+// Ping 实现 Pinger：GET /models 校验 API Key 并预热连接
+func (c *ClaudeClient) Ping(ctx context.Context) error {
+	response, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("x-api-key", c.apiKey).
+		SetHeader("anthropic-version", "2023-06-01").
+		Get(c.baseURL + "/models")
+	if err != nil {
+		return fmt.Errorf("Claude ping failed: %w", err)
+	}
+	return pingStatusError(c.provider, response.StatusCode(), response.String())
+}
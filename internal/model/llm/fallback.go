@@ -0,0 +1,302 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// 故障切换链默认参数
+const (
+	DefaultFallbackUnhealthyBelow = 0.5
+	DefaultFallbackProbeInterval  = 30 * time.Second
+	DefaultFallbackAlpha          = 0.3
+)
+
+// FallbackOptions 故障切换链参数
+type FallbackOptions struct {
+	// AttemptTimeout 单个模型单次调用超时；超时视为失败并切换到下一个模型，0 表示不限制
+	AttemptTimeout time.Duration
+	// UnhealthyBelow 健康分低于该值的模型被跳过（探测除外），默认 0.5
+	UnhealthyBelow float64
+	// ProbeInterval 不健康模型的探测间隔：到期后按链序再次尝试，成功即逐步恢复，默认 30s
+	ProbeInterval time.Duration
+	// Alpha 健康分 EWMA 平滑系数（成功记 1、失败记 0），默认 0.3
+	Alpha float64
+}
+
+// ServedModel 单次调用实际提供服务的模型；回放依赖它还原调用当时的模型
+type ServedModel struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// FailoverFrom 链首（主）模型，形如 provider/model；由主模型服务时为空
+	FailoverFrom string `json:"failover_from,omitempty"`
+	// Attempts 本次调用实际尝试的模型数
+	Attempts int `json:"attempts"`
+}
+
+const servedContextKey contextKey = "llm.served"
+
+// WithServedModel 返回带记录槽的 ctx；经 FallbackClient 的调用成功后将实际服务的模型写入返回的 ServedModel
+func WithServedModel(ctx context.Context) (context.Context, *ServedModel) {
+	served := &ServedModel{}
+	return context.WithValue(ctx, servedContextKey, served), served
+}
+
+func recordServed(ctx context.Context, served ServedModel) {
+	if ctx == nil {
+		return
+	}
+	if slot, ok := ctx.Value(servedContextKey).(*ServedModel); ok && slot != nil {
+		*slot = served
+	}
+}
+
+// MemberHealth 链中单个模型的健康快照
+type MemberHealth struct {
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Score     float64   `json:"score"`
+	Healthy   bool      `json:"healthy"`
+	LastError string    `json:"last_error,omitempty"`
+	LastProbe time.Time `json:"last_probe,omitempty"`
+}
+
+type fallbackMember struct {
+	client Client
+
+	mu        sync.Mutex
+	score     float64
+	lastErr   string
+	lastProbe time.Time
+}
+
+func (m *fallbackMember) label() string {
+	return m.client.Provider() + "/" + m.client.Model()
+}
+
+// FallbackClient 按用途（planner/generation）配置的模型故障切换链：主模型报错或超时时按序切换到下一个模型；
+// 每个模型维护 EWMA 健康分，不健康的模型被跳过，探测间隔到期后重新尝试，成功后自动恢复为主模型服务
+type FallbackClient struct {
+	useCase string
+	opts    FallbackOptions
+	members []*fallbackMember
+	now     func() time.Time
+}
+
+// NewFallbackClient 以有序的 clients 创建故障切换链；clients[0] 为主模型
+func NewFallbackClient(useCase string, clients []Client, opts FallbackOptions) *FallbackClient {
+	if opts.UnhealthyBelow <= 0 {
+		opts.UnhealthyBelow = DefaultFallbackUnhealthyBelow
+	}
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = DefaultFallbackProbeInterval
+	}
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = DefaultFallbackAlpha
+	}
+	c := &FallbackClient{useCase: useCase, opts: opts, now: time.Now}
+	for _, cl := range clients {
+		if cl == nil {
+			continue
+		}
+		m := &fallbackMember{client: cl, score: 1}
+		c.members = append(c.members, m)
+		metrics.LLMModelHealth.WithLabelValues(useCase, m.label()).Set(1)
+	}
+	return c
+}
+
+// order 返回本次调用的尝试顺序：健康模型与探测到期的不健康模型按链序在前，其余不健康模型作为兜底追加在后
+func (c *FallbackClient) order() []*fallbackMember {
+	now := c.now()
+	out := make([]*fallbackMember, 0, len(c.members))
+	var skipped []*fallbackMember
+	for _, m := range c.members {
+		m.mu.Lock()
+		usable := m.score >= c.opts.UnhealthyBelow
+		if !usable && now.Sub(m.lastProbe) >= c.opts.ProbeInterval {
+			m.lastProbe = now
+			usable = true
+		}
+		m.mu.Unlock()
+		if usable {
+			out = append(out, m)
+		} else {
+			skipped = append(skipped, m)
+		}
+	}
+	return append(out, skipped...)
+}
+
+func (c *FallbackClient) observe(m *fallbackMember, err error) {
+	m.mu.Lock()
+	v := 1.0
+	if err != nil {
+		v = 0
+		m.lastErr = err.Error()
+	} else {
+		m.lastErr = ""
+	}
+	m.score = c.opts.Alpha*v + (1-c.opts.Alpha)*m.score
+	score := m.score
+	m.mu.Unlock()
+	metrics.LLMModelHealth.WithLabelValues(c.useCase, m.label()).Set(score)
+}
+
+func (c *FallbackClient) call(ctx context.Context, fn func(ctx context.Context, cl Client) (string, error)) (string, error) {
+	if len(c.members) == 0 {
+		return "", fmt.Errorf("llm fallback %s: no models configured", c.useCase)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	primary := c.members[0]
+	var errs []error
+	for i, m := range c.order() {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.opts.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, c.opts.AttemptTimeout)
+		}
+		out, err := fn(attemptCtx, m.client)
+		cancel()
+		if err == nil {
+			c.observe(m, nil)
+			served := ServedModel{Provider: m.client.Provider(), Model: m.client.Model(), Attempts: i + 1}
+			if m != primary {
+				served.FailoverFrom = primary.label()
+				metrics.LLMFailoverTotal.WithLabelValues(c.useCase, primary.label(), m.label()).Inc()
+			}
+			recordServed(ctx, served)
+			return out, nil
+		}
+		if ctx.Err() != nil {
+			// 调用方取消或超时，不计入模型健康
+			return "", err
+		}
+		c.observe(m, err)
+		errs = append(errs, fmt.Errorf("%s: %w", m.label(), err))
+	}
+	return "", fmt.Errorf("llm fallback %s: all models failed: %w", c.useCase, errors.Join(errs...))
+}
+
+// Generate 生成文本
+func (c *FallbackClient) Generate(prompt string, options GenerateOptions) (string, error) {
+	return c.GenerateWithContext(context.Background(), prompt, options)
+}
+
+// GenerateWithContext 按故障切换链生成文本
+func (c *FallbackClient) GenerateWithContext(ctx context.Context, prompt string, options GenerateOptions) (string, error) {
+	return c.call(ctx, func(ctx context.Context, cl Client) (string, error) {
+		return cl.GenerateWithContext(ctx, prompt, options)
+	})
+}
+
+// Chat 聊天
+func (c *FallbackClient) Chat(messages []Message, options GenerateOptions) (string, error) {
+	return c.ChatWithContext(context.Background(), messages, options)
+}
+
+// ChatWithContext 按故障切换链聊天
+func (c *FallbackClient) ChatWithContext(ctx context.Context, messages []Message, options GenerateOptions) (string, error) {
+	return c.call(ctx, func(ctx context.Context, cl Client) (string, error) {
+		return cl.ChatWithContext(ctx, messages, options)
+	})
+}
+
+// preferred 返回当前首选模型（链序中第一个健康的模型；全部不健康时为主模型）
+func (c *FallbackClient) preferred() Client {
+	if len(c.members) == 0 {
+		return nil
+	}
+	for _, m := range c.members {
+		m.mu.Lock()
+		healthy := m.score >= c.opts.UnhealthyBelow
+		m.mu.Unlock()
+		if healthy {
+			return m.client
+		}
+	}
+	return c.members[0].client
+}
+
+// Model 返回当前首选模型名称
+func (c *FallbackClient) Model() string {
+	if p := c.preferred(); p != nil {
+		return p.Model()
+	}
+	return ""
+}
+
+// Provider 返回当前首选模型的提供商
+func (c *FallbackClient) Provider() string {
+	if p := c.preferred(); p != nil {
+		return p.Provider()
+	}
+	return ""
+}
+
+// SetModel 设置主模型的模型名称
+func (c *FallbackClient) SetModel(model string) {
+	if len(c.members) > 0 {
+		c.members[0].client.SetModel(model)
+	}
+}
+
+// SetAPIKey 设置主模型的 API Key
+func (c *FallbackClient) SetAPIKey(apiKey string) {
+	if len(c.members) > 0 {
+		c.members[0].client.SetAPIKey(apiKey)
+	}
+}
+
+// UseCase 返回该链的用途
+func (c *FallbackClient) UseCase() string { return c.useCase }
+
+// Unwrap 实现 Unwrapper，按链序返回成员客户端
+func (c *FallbackClient) Unwrap() []Client {
+	out := make([]Client, 0, len(c.members))
+	for _, m := range c.members {
+		out = append(out, m.client)
+	}
+	return out
+}
+
+// Health 返回链中各模型的健康快照（按链序）
+func (c *FallbackClient) Health() []MemberHealth {
+	out := make([]MemberHealth, 0, len(c.members))
+	for _, m := range c.members {
+		m.mu.Lock()
+		out = append(out, MemberHealth{
+			Provider:  m.client.Provider(),
+			Model:     m.client.Model(),
+			Score:     m.score,
+			Healthy:   m.score >= c.opts.UnhealthyBelow,
+			LastError: m.lastErr,
+			LastProbe: m.lastProbe,
+		})
+		m.mu.Unlock()
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyClient 按 fail 决定调用成败；block 为 true 时阻塞直到 ctx 结束
+type flakyClient struct {
+	provider, model string
+	fail            bool
+	block           bool
+	calls           int
+}
+
+func (f *flakyClient) GenerateWithContext(ctx context.Context, prompt string, _ GenerateOptions) (string, error) {
+	f.calls++
+	if f.block {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	if f.fail {
+		return "", errors.New("upstream 503")
+	}
+	return f.model + ":" + prompt, nil
+}
+
+func (f *flakyClient) Generate(prompt string, o GenerateOptions) (string, error) {
+	return f.GenerateWithContext(context.Background(), prompt, o)
+}
+
+func (f *flakyClient) ChatWithContext(ctx context.Context, msgs []Message, o GenerateOptions) (string, error) {
+	return f.GenerateWithContext(ctx, msgs[len(msgs)-1].Content, o)
+}
+
+func (f *flakyClient) Chat(msgs []Message, o GenerateOptions) (string, error) {
+	return f.ChatWithContext(context.Background(), msgs, o)
+}
+
+func (f *flakyClient) Model() string      { return f.model }
+func (f *flakyClient) Provider() string   { return f.provider }
+func (f *flakyClient) SetModel(m string)  { f.model = m }
+func (f *flakyClient) SetAPIKey(_ string) {}
+
+func TestFallbackClient_FailsOverAndRecordsServedModel(t *testing.T) {
+	primary := &flakyClient{provider: "openai", model: "gpt-4o", fail: true}
+	secondary := &flakyClient{provider: "claude", model: "sonnet"}
+	c := NewFallbackClient("generation", []Client{primary, secondary}, FallbackOptions{})
+
+	ctx, served := WithServedModel(context.Background())
+	out, err := c.GenerateWithContext(ctx, "hi", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "sonnet:hi", out)
+	assert.Equal(t, "claude", served.Provider)
+	assert.Equal(t, "sonnet", served.Model)
+	assert.Equal(t, "openai/gpt-4o", served.FailoverFrom)
+	assert.Equal(t, 2, served.Attempts)
+
+	ctx, served = WithServedModel(context.Background())
+	primary.fail = false
+	out, err = c.GenerateWithContext(ctx, "again", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o:again", out, "one failure does not drop the primary below the threshold")
+	assert.Empty(t, served.FailoverFrom)
+}
+
+func TestFallbackClient_AttemptTimeoutFailsOver(t *testing.T) {
+	primary := &flakyClient{provider: "openai", model: "gpt-4o", block: true}
+	secondary := &flakyClient{provider: "claude", model: "sonnet"}
+	c := NewFallbackClient("planner", []Client{primary, secondary}, FallbackOptions{AttemptTimeout: 10 * time.Millisecond})
+
+	out, err := c.ChatWithContext(context.Background(), []Message{{Role: "user", Content: "plan"}}, GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "sonnet:plan", out)
+}
+
+func TestFallbackClient_SkipsUnhealthyAndRecoversViaProbe(t *testing.T) {
+	primary := &flakyClient{provider: "openai", model: "gpt-4o", fail: true}
+	secondary := &flakyClient{provider: "claude", model: "sonnet"}
+	c := NewFallbackClient("generation", []Client{primary, secondary}, FallbackOptions{ProbeInterval: time.Minute, Alpha: 0.5})
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GenerateWithContext(context.Background(), "x", GenerateOptions{})
+		require.NoError(t, err)
+	}
+	health := c.Health()
+	require.Len(t, health, 2)
+	assert.False(t, health[0].Healthy)
+	assert.Equal(t, "upstream 503", health[0].LastError)
+	assert.Equal(t, "claude", c.Provider(), "preferred model moves off the unhealthy primary")
+
+	// 未到探测间隔：主模型被跳过
+	calls := primary.calls
+	_, err := c.GenerateWithContext(context.Background(), "x", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, calls, primary.calls)
+
+	// 主模型恢复、探测到期后重新由主模型服务，健康分逐步回升
+	primary.fail = false
+	now = now.Add(2 * time.Minute)
+	ctx, served := WithServedModel(context.Background())
+	out, err := c.GenerateWithContext(ctx, "x", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o:x", out)
+	assert.Empty(t, served.FailoverFrom)
+	for i := 0; i < 3 && !c.Health()[0].Healthy; i++ {
+		now = now.Add(2 * time.Minute)
+		_, err = c.GenerateWithContext(context.Background(), "x", GenerateOptions{})
+		require.NoError(t, err)
+	}
+	assert.True(t, c.Health()[0].Healthy)
+	assert.Equal(t, "openai", c.Provider())
+}
+
+func TestFallbackClient_AllFail(t *testing.T) {
+	c := NewFallbackClient("generation", []Client{
+		&flakyClient{provider: "openai", model: "a", fail: true},
+		&flakyClient{provider: "claude", model: "b", fail: true},
+	}, FallbackOptions{})
+	_, err := c.GenerateWithContext(context.Background(), "x", GenerateOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "openai/a")
+	assert.Contains(t, err.Error(), "claude/b")
+}
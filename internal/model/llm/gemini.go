@@ -210,3 +210,14 @@ func (c *GeminiClient) SetModel(model string) {
 func (c *GeminiClient) SetAPIKey(apiKey string) {
 	c.apiKey = apiKey
 }
+
+// Ping 实现 Pinger：GET /models/{model} 校验 API Key 与模型名并预热连接
+func (c *GeminiClient) Ping(ctx context.Context) error {
+	response, err := c.client.R().
+		SetContext(ctx).
+		Get(c.baseURL + "/models/" + c.model + "?key=" + c.apiKey)
+	if err != nil {
+		return fmt.Errorf("Gemini ping failed: %w", err)
+	}
+	return pingStatusError(c.provider, response.StatusCode(), response.String())
+}
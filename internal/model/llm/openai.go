@@ -202,3 +202,15 @@ func (c *OpenAIClient) SetModel(model string) {
 func (c *OpenAIClient) SetAPIKey(apiKey string) {
 	c.apiKey = apiKey
 }
+
+// Ping 实现 Pinger：GET /models 校验 API Key 并预热连接
+func (c *OpenAIClient) Ping(ctx context.Context) error {
+	response, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Authorization", "Bearer "+c.apiKey).
+		Get(c.baseURL + "/models")
+	if err != nil {
+		return fmt.Errorf("OpenAI ping failed: %w", err)
+	}
+	return pingStatusError(c.provider, response.StatusCode(), response.String())
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrCredentialsRejected Provider 拒绝凭证（401/403）
+var ErrCredentialsRejected = errors.New("llm: provider rejected credentials")
+
+// Pinger 可选接口：以一次轻量的鉴权请求（如列出模型）校验凭证，并预热客户端自身的 HTTP 连接池
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Unwrapper 可选接口：包装型客户端（故障切换链、路由、限流）返回其内部客户端，供启动预检逐个检查真实 Provider
+type Unwrapper interface {
+	Unwrap() []Client
+}
+
+// Leaves 展开包装型客户端（Unwrapper 及 EffectAdapter 式的单客户端 Unwrap），返回去重后的底层客户端（按首次出现顺序）
+func Leaves(c Client) []Client {
+	var out []Client
+	seen := map[Client]bool{}
+	var walk func(Client)
+	walk = func(c Client) {
+		if c == nil || seen[c] {
+			return
+		}
+		seen[c] = true
+		if u, ok := c.(Unwrapper); ok {
+			for _, inner := range u.Unwrap() {
+				walk(inner)
+			}
+			return
+		}
+		if u, ok := c.(interface{ Unwrap() Client }); ok {
+			walk(u.Unwrap())
+			return
+		}
+		out = append(out, c)
+	}
+	walk(c)
+	return out
+}
+
+// pingStatusError 将 Ping 的 HTTP 状态码转换为错误：401/403 包装 ErrCredentialsRejected
+func pingStatusError(provider string, status int, body string) error {
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return fmt.Errorf("%w: %s status %d: %s", ErrCredentialsRejected, provider, status, body)
+	}
+	return fmt.Errorf("llm: %s ping status %d: %s", provider, status, body)
+}
+
+// PreflightOptions 启动预检选项
+type PreflightOptions struct {
+	// Timeout 单个客户端检查（含可选生成）的超时，0 时为 10s
+	Timeout time.Duration
+	// Generate 凭证校验通过后额外执行一次极短生成，验证模型名与配额，并预热生成路径
+	Generate bool
+}
+
+// PreflightCheck 单个底层客户端的预检结果
+type PreflightCheck struct {
+	Provider string   `json:"provider"`
+	Model    string   `json:"model"`
+	Uses     []string `json:"uses"`              // 引用该客户端的用途，如 generation、planner
+	OK       bool     `json:"ok"`                // 凭证校验（及可选生成）均通过；Skipped 时为 true
+	Skipped  bool     `json:"skipped,omitempty"` // 客户端不支持 Ping 且未要求生成，未做网络检查
+	PingMs   int64    `json:"ping_ms,omitempty"`
+	GenMs    int64    `json:"generate_ms,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Preflight 对各用途引用的底层客户端逐个做凭证校验与连接预热；同一客户端被多个用途引用时只检查一次。
+// 结果按 provider/model 排序，便于日志与 /readyz 比对
+func Preflight(ctx context.Context, uses map[string]Client, opts PreflightOptions) []PreflightCheck {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	var order []Client
+	usesOf := map[Client][]string{}
+	names := make([]string, 0, len(uses))
+	for name := range uses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, leaf := range Leaves(uses[name]) {
+			if _, ok := usesOf[leaf]; !ok {
+				order = append(order, leaf)
+			}
+			usesOf[leaf] = append(usesOf[leaf], name)
+		}
+	}
+	out := make([]PreflightCheck, 0, len(order))
+	for _, c := range order {
+		out = append(out, preflightOne(ctx, c, usesOf[c], opts))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Provider+"/"+out[i].Model < out[j].Provider+"/"+out[j].Model
+	})
+	return out
+}
+
+func preflightOne(ctx context.Context, c Client, uses []string, opts PreflightOptions) PreflightCheck {
+	check := PreflightCheck{Provider: c.Provider(), Model: c.Model(), Uses: uses}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	p, ok := c.(Pinger)
+	if !ok && !opts.Generate {
+		check.OK, check.Skipped = true, true
+		return check
+	}
+	if ok {
+		start := time.Now()
+		err := p.Ping(ctx)
+		check.PingMs = time.Since(start).Milliseconds()
+		if err != nil {
+			check.Error = err.Error()
+			return check
+		}
+	}
+	if opts.Generate {
+		start := time.Now()
+		_, err := c.GenerateWithContext(ctx, "Reply with OK.", GenerateOptions{MaxTokens: 1})
+		check.GenMs = time.Since(start).Milliseconds()
+		if err != nil {
+			check.Error = "generate: " + strings.TrimSpace(err.Error())
+			return check
+		}
+	}
+	check.OK = true
+	return check
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIClient_Ping(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid api key"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+
+	good, _ := NewOpenAIClientWithBaseURL("gpt-4o", "good", srv.URL)
+	if err := good.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping with valid key: %v", err)
+	}
+	bad, _ := NewOpenAIClientWithBaseURL("gpt-4o", "bad", srv.URL)
+	if err := bad.Ping(context.Background()); !errors.Is(err, ErrCredentialsRejected) {
+		t.Fatalf("Ping with bad key = %v, want ErrCredentialsRejected", err)
+	}
+}
+
+type pingClient struct {
+	flakyClient
+	pingErr error
+	pings   int
+}
+
+func (p *pingClient) Ping(context.Context) error {
+	p.pings++
+	return p.pingErr
+}
+
+func TestLeaves_UnwrapsAndDedups(t *testing.T) {
+	a := &pingClient{flakyClient: flakyClient{provider: "openai", model: "a"}}
+	b := &pingClient{flakyClient: flakyClient{provider: "claude", model: "b"}}
+	chain := NewFallbackClient("generation", []Client{a, b}, FallbackOptions{})
+	router := NewModelRouter(chain, []RouteCandidateSpec{{Client: b}}, 0)
+	leaves := Leaves(NewRateLimitedClient(router, nil))
+	if len(leaves) != 2 || leaves[0] != Client(a) || leaves[1] != Client(b) {
+		t.Fatalf("Leaves = %v", leaves)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	gen := &pingClient{flakyClient: flakyClient{provider: "openai", model: "gen"}}
+	planner := &pingClient{flakyClient: flakyClient{provider: "claude", model: "plan"}, pingErr: ErrCredentialsRejected}
+	plain := &flakyClient{provider: "qwen", model: "plain"}
+	uses := map[string]Client{
+		"generation": NewFallbackClient("generation", []Client{gen, plain}, FallbackOptions{}),
+		"planner":    NewFallbackClient("planner", []Client{planner, gen}, FallbackOptions{}),
+	}
+
+	checks := Preflight(context.Background(), uses, PreflightOptions{})
+	if len(checks) != 3 {
+		t.Fatalf("checks = %+v", checks)
+	}
+	byModel := map[string]PreflightCheck{}
+	for _, c := range checks {
+		byModel[c.Model] = c
+	}
+	if c := byModel["gen"]; !c.OK || len(c.Uses) != 2 || gen.pings != 1 {
+		t.Errorf("shared client checked once for both uses: %+v (pings %d)", c, gen.pings)
+	}
+	if c := byModel["plan"]; c.OK || c.Error == "" {
+		t.Errorf("rejected credentials reported ok: %+v", c)
+	}
+	if c := byModel["plain"]; !c.OK || !c.Skipped || plain.calls != 0 {
+		t.Errorf("client without Ping: %+v", c)
+	}
+
+	plain.fail = true
+	checks = Preflight(context.Background(), map[string]Client{"generation": plain}, PreflightOptions{Generate: true})
+	if len(checks) != 1 || checks[0].OK || checks[0].Skipped || plain.calls != 1 {
+		t.Errorf("generate check = %+v (calls %d)", checks, plain.calls)
+	}
+}
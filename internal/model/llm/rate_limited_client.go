@@ -16,6 +16,7 @@ package llm
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"rag-platform/pkg/metrics"
@@ -26,6 +27,7 @@ import (
 type RateLimitedClient struct {
 	inner       Client
 	rateLimiter *LLMRateLimiter
+	inFlight    atomic.Int64 // 当前进行中的调用数（无论是否配置限流），供 Worker 资源感知认领
 }
 
 // NewRateLimitedClient 创建带限流的 LLM 客户端。rateLimiter 为 nil 时退化为直接调用。
@@ -40,27 +42,26 @@ func (c *RateLimitedClient) Generate(prompt string, options GenerateOptions) (st
 
 // GenerateWithContext 实现 Client.GenerateWithContext，调用前后执行限流。
 func (c *RateLimitedClient) GenerateWithContext(ctx context.Context, prompt string, options GenerateOptions) (string, error) {
+	var key string
 	if c.rateLimiter != nil {
 		provider := c.inner.Provider()
 		estimatedTokens := estimateTokens(prompt, options.MaxTokens)
-		start := time.Now()
-		if err := c.rateLimiter.Wait(ctx, provider, estimatedTokens); err != nil {
+		var err error
+		if key, err = c.wait(ctx, provider, estimatedTokens); err != nil {
 			return "", err
 		}
-		waited := time.Since(start)
-		if waited > 100*time.Millisecond {
-			metrics.RateLimitWaitSeconds.WithLabelValues("llm", provider).Observe(waited.Seconds())
-		}
-		defer c.rateLimiter.Release(provider)
+		defer c.rateLimiter.Release(key)
 	}
 
+	c.inFlight.Add(1)
 	result, err := c.inner.GenerateWithContext(ctx, prompt, options)
+	c.inFlight.Add(-1)
 	if err != nil {
 		return "", err
 	}
 	if c.rateLimiter != nil {
 		// 用 MaxTokens 近似记录实际用量（未来可从 response 中取 usage）
-		c.rateLimiter.RecordTokenUsage(c.inner.Provider(), options.MaxTokens)
+		c.rateLimiter.RecordTokenUsage(key, options.MaxTokens)
 	}
 	return result, nil
 }
@@ -72,31 +73,53 @@ func (c *RateLimitedClient) Chat(messages []Message, options GenerateOptions) (s
 
 // ChatWithContext 实现 Client.ChatWithContext，调用前后执行限流。
 func (c *RateLimitedClient) ChatWithContext(ctx context.Context, messages []Message, options GenerateOptions) (string, error) {
+	var key string
 	if c.rateLimiter != nil {
 		provider := c.inner.Provider()
 		promptText := messagesText(messages)
 		estimatedTokens := estimateTokens(promptText, options.MaxTokens)
-		start := time.Now()
-		if err := c.rateLimiter.Wait(ctx, provider, estimatedTokens); err != nil {
+		var err error
+		if key, err = c.wait(ctx, provider, estimatedTokens); err != nil {
 			return "", err
 		}
-		waited := time.Since(start)
-		if waited > 100*time.Millisecond {
-			metrics.RateLimitWaitSeconds.WithLabelValues("llm", provider).Observe(waited.Seconds())
-		}
-		defer c.rateLimiter.Release(provider)
+		defer c.rateLimiter.Release(key)
 	}
 
+	c.inFlight.Add(1)
 	result, err := c.inner.ChatWithContext(ctx, messages, options)
+	c.inFlight.Add(-1)
 	if err != nil {
 		return "", err
 	}
 	if c.rateLimiter != nil {
-		c.rateLimiter.RecordTokenUsage(c.inner.Provider(), options.MaxTokens)
+		c.rateLimiter.RecordTokenUsage(key, options.MaxTokens)
 	}
 	return result, nil
 }
 
+// wait 在调用所属桶（见 WithBucket）上排队获取许可，并记录按桶的排队深度与等待时间；返回用于 Release 的限流键。
+func (c *RateLimitedClient) wait(ctx context.Context, provider string, estimatedTokens int) (string, error) {
+	bucket := BucketFromContext(ctx)
+	key := c.rateLimiter.BucketKey(provider, bucket)
+	queue := metrics.LLMBucketQueueDepth.WithLabelValues(provider, string(bucket))
+	queue.Inc()
+	start := time.Now()
+	err := c.rateLimiter.Wait(ctx, key, estimatedTokens)
+	queue.Dec()
+	waited := time.Since(start)
+	metrics.LLMBucketWaitSeconds.WithLabelValues(provider, string(bucket)).Observe(waited.Seconds())
+	if err != nil {
+		return "", err
+	}
+	if waited > 100*time.Millisecond {
+		metrics.RateLimitWaitSeconds.WithLabelValues("llm", provider).Observe(waited.Seconds())
+	}
+	return key, nil
+}
+
+// InFlight 返回当前进行中的 LLM 调用数。
+func (c *RateLimitedClient) InFlight() int { return int(c.inFlight.Load()) }
+
 // Model 返回底层 Client 的模型名称。
 func (c *RateLimitedClient) Model() string { return c.inner.Model() }
 
@@ -109,6 +132,9 @@ func (c *RateLimitedClient) SetModel(model string) { c.inner.SetModel(model) }
 // SetAPIKey 代理到底层 Client。
 func (c *RateLimitedClient) SetAPIKey(apiKey string) { c.inner.SetAPIKey(apiKey) }
 
+// Unwrap 实现 Unwrapper
+func (c *RateLimitedClient) Unwrap() []Client { return []Client{c.inner} }
+
 // estimateTokens 粗略估算请求的 token 数（4 字符 ≈ 1 token）。
 func estimateTokens(text string, maxTokens int) int {
 	estimated := len(text) / 4
@@ -28,12 +28,16 @@ type LLMLimitConfig struct {
 	TokensPerMinute   int     `yaml:"tokens_per_minute"`   // 每分钟 token 配额
 	RequestsPerMinute float64 `yaml:"requests_per_minute"` // 每分钟请求数
 	MaxConcurrent     int     `yaml:"max_concurrent"`      // 最大并发请求数
+	// PlanningShare 规划调用独占的配额比例（0~1）；>0 时 Provider 配额拆分为 planning / execution 两个桶，
+	// 互不抢占，避免执行高峰拖慢 Job 提交时的规划（或规划突发饿死执行）；0 表示共用单桶
+	PlanningShare float64 `yaml:"planning_share"`
 }
 
 // LLMRateLimiter LLM Provider 维度的限流器，支持 token budget + RPS + 并发控制
 type LLMRateLimiter struct {
 	mu       sync.RWMutex
-	limiters map[string]*llmLimiter // provider -> limiter
+	limiters map[string]*llmLimiter // provider 或 provider/bucket -> limiter
+	configs  map[string]LLMLimitConfig
 	defaults *LLMLimitConfig
 }
 
@@ -61,19 +65,72 @@ func NewLLMRateLimiter(configs map[string]LLMLimitConfig, defaults *LLMLimitConf
 
 	limiter := &LLMRateLimiter{
 		limiters: make(map[string]*llmLimiter),
+		configs:  make(map[string]LLMLimitConfig, len(configs)),
 		defaults: defaults,
 	}
 
 	// 初始化配置的 provider limiters
 	for provider, config := range configs {
+		limiter.configs[provider] = config
 		limiter.addProviderLimiter(provider, config)
 	}
 
 	return limiter
 }
 
+// BucketKey 返回 provider 在指定桶下的限流键：未配置 PlanningShare 时为 provider 本身（共用单桶），
+// 否则为 "provider/bucket"，并按比例懒创建该桶的限流器
+func (l *LLMRateLimiter) BucketKey(provider string, bucket Bucket) string {
+	l.mu.RLock()
+	config, ok := l.configs[provider]
+	l.mu.RUnlock()
+	if !ok {
+		config = *l.defaults
+	}
+	if config.PlanningShare <= 0 || config.PlanningShare >= 1 {
+		return provider
+	}
+	if bucket != BucketPlanning {
+		bucket = BucketExecution
+	}
+	key := provider + "/" + string(bucket)
+	share := config.PlanningShare
+	if bucket == BucketExecution {
+		share = 1 - share
+	}
+	l.mu.Lock()
+	if _, exists := l.limiters[key]; !exists {
+		l.limiters[key] = newLLMLimiter(scaleLimitConfig(config, share))
+	}
+	l.mu.Unlock()
+	return key
+}
+
+// scaleLimitConfig 按比例缩放配额；各项至少保留 1，避免桶被配置为 0 而永久阻塞
+func scaleLimitConfig(config LLMLimitConfig, share float64) LLMLimitConfig {
+	out := LLMLimitConfig{}
+	if config.TokensPerMinute > 0 {
+		out.TokensPerMinute = max(1, int(float64(config.TokensPerMinute)*share))
+	}
+	if config.RequestsPerMinute > 0 {
+		out.RequestsPerMinute = max(1, config.RequestsPerMinute*share)
+	}
+	if config.MaxConcurrent > 0 {
+		out.MaxConcurrent = max(1, int(float64(config.MaxConcurrent)*share))
+	}
+	return out
+}
+
 // addProviderLimiter 添加 provider 限流器
 func (l *LLMRateLimiter) addProviderLimiter(provider string, config LLMLimitConfig) {
+	limiter := newLLMLimiter(config)
+	l.mu.Lock()
+	l.limiters[provider] = limiter
+	l.mu.Unlock()
+}
+
+// newLLMLimiter 按配置创建单个限流器
+func newLLMLimiter(config LLMLimitConfig) *llmLimiter {
 	limiter := &llmLimiter{
 		config:      config,
 		minuteStart: time.Now(),
@@ -103,10 +160,7 @@ func (l *LLMRateLimiter) addProviderLimiter(provider string, config LLMLimitConf
 	if config.MaxConcurrent > 0 {
 		limiter.semaphore = make(chan struct{}, config.MaxConcurrent)
 	}
-
-	l.mu.Lock()
-	l.limiters[provider] = limiter
-	l.mu.Unlock()
+	return limiter
 }
 
 // Wait 等待获取执行许可（阻塞直到可以执行）
@@ -202,6 +256,17 @@ func (l *LLMRateLimiter) RecordTokenUsage(provider string, actualTokens int) {
 	limiter.mu.Unlock()
 }
 
+// Keys 返回已配置的限流键（provider 或 provider/bucket）
+func (l *LLMRateLimiter) Keys() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	keys := make([]string, 0, len(l.limiters))
+	for k := range l.limiters {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
 // GetStats 获取限流统计信息
 func (l *LLMRateLimiter) GetStats(provider string) map[string]interface{} {
 	l.mu.RLock()
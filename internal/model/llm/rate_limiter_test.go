@@ -0,0 +1,66 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMRateLimiter_BucketKey(t *testing.T) {
+	l := NewLLMRateLimiter(map[string]LLMLimitConfig{
+		"split":  {RequestsPerMinute: 600, MaxConcurrent: 10, PlanningShare: 0.3},
+		"shared": {RequestsPerMinute: 600, MaxConcurrent: 10},
+	}, nil)
+
+	assert.Equal(t, "shared", l.BucketKey("shared", BucketPlanning))
+	assert.Equal(t, "split/planning", l.BucketKey("split", BucketPlanning))
+	assert.Equal(t, "split/execution", l.BucketKey("split", BucketExecution))
+	assert.Equal(t, "split/execution", l.BucketKey("split", ""))
+
+	assert.Equal(t, 3, l.GetStats("split/planning")["max_concurrent"])
+	assert.Equal(t, 7, l.GetStats("split/execution")["max_concurrent"])
+}
+
+func TestLLMRateLimiter_ExecutionSaturationDoesNotBlockPlanning(t *testing.T) {
+	l := NewLLMRateLimiter(map[string]LLMLimitConfig{
+		"test": {MaxConcurrent: 2, PlanningShare: 0.5},
+	}, nil)
+	exec := l.BucketKey("test", BucketExecution)
+	require.NoError(t, l.Wait(context.Background(), exec, 0))
+
+	// 执行桶已满：再次获取应超时
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Error(t, l.Wait(ctx, exec, 0))
+
+	// 规划桶独立，不受执行桶占满影响
+	client := NewRateLimitedClient(&mockClient{response: "ok"}, l)
+	planCtx, cancelPlan := context.WithTimeout(WithBucket(context.Background(), BucketPlanning), time.Second)
+	defer cancelPlan()
+	out, err := client.ChatWithContext(planCtx, []Message{{Role: "user", Content: "plan"}}, GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+	l.Release(exec)
+}
+
+func TestBucketFromContext_DefaultsToExecution(t *testing.T) {
+	assert.Equal(t, BucketExecution, BucketFromContext(context.Background()))
+	assert.Equal(t, BucketPlanning, BucketFromContext(WithBucket(context.Background(), BucketPlanning)))
+}
@@ -0,0 +1,486 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/metrics"
+)
+
+// 模型质量等级：节点声明的最低质量要求与候选模型的声明质量比较
+const (
+	QualityAny      = 0
+	QualityLow      = 1
+	QualityStandard = 2
+	QualityHigh     = 3
+)
+
+// ParseQuality 解析 low | standard | high；空字符串为 QualityAny
+func ParseQuality(s string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return QualityAny, nil
+	case "low":
+		return QualityLow, nil
+	case "standard", "medium":
+		return QualityStandard, nil
+	case "high":
+		return QualityHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown quality %q (want low, standard or high)", s)
+	}
+}
+
+// QualityName 质量等级的名称
+func QualityName(q int) string {
+	switch q {
+	case QualityLow:
+		return "low"
+	case QualityStandard:
+		return "standard"
+	case QualityHigh:
+		return "high"
+	default:
+		return "any"
+	}
+}
+
+// 偏好：满足要求的候选中按成本或延迟择优
+const (
+	PreferCost    = "cost"
+	PreferLatency = "latency"
+)
+
+// RouteRequirement 单个 LLM 步骤声明的模型要求
+type RouteRequirement struct {
+	// Quality 最低质量等级；QualityAny 不限
+	Quality int
+	// MaxLatency 期望的单次调用延迟上限；0 不限
+	MaxLatency time.Duration
+	// Prefer 满足要求的候选中优先 cost（默认）或 latency
+	Prefer string
+}
+
+// MarshalJSON 以可读形式记录要求（quality 名称、max_latency 时长字符串）
+func (r RouteRequirement) MarshalJSON() ([]byte, error) {
+	out := map[string]string{"quality": QualityName(r.Quality)}
+	if r.MaxLatency > 0 {
+		out["max_latency"] = r.MaxLatency.String()
+	}
+	if r.Prefer != "" {
+		out["prefer"] = r.Prefer
+	}
+	return json.Marshal(out)
+}
+
+// IsZero 是否未声明任何要求
+func (r RouteRequirement) IsZero() bool {
+	return r.Quality == QualityAny && r.MaxLatency <= 0 && r.Prefer == ""
+}
+
+// RouteCandidate 路由决策中单个候选模型的评估
+type RouteCandidate struct {
+	Model     string  `json:"model"` // provider/model
+	Quality   string  `json:"quality"`
+	Cost      float64 `json:"cost"`
+	LatencyMs int64   `json:"latency_ms"`
+	// LatencySource live（实测 EWMA）| declared（配置的 expected_latency）| unknown
+	LatencySource string `json:"latency_source"`
+	Eligible      bool   `json:"eligible"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// RouteDecision 单次路由的结果与理由，随 command_committed 持久化供审计与 Trace 展示
+type RouteDecision struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Outcome met（满足全部要求）| latency_unmet | quality_unmet | fallback（所选模型failed，改由默认客户端服务）
+	Outcome     string           `json:"outcome"`
+	Requirement RouteRequirement `json:"requirement"`
+	Rationale   string           `json:"rationale"`
+	Candidates  []RouteCandidate `json:"candidates,omitempty"`
+}
+
+const (
+	routeRequirementKey contextKey = "llm.route_requirement"
+	routeDecisionKey    contextKey = "llm.route_decision"
+)
+
+// WithRouteRequirement 在 ctx 中声明本次调用的模型要求；经 ModelRouter 的调用据此选择模型
+func WithRouteRequirement(ctx context.Context, req RouteRequirement) context.Context {
+	return context.WithValue(ctx, routeRequirementKey, req)
+}
+
+// RouteRequirementFromContext 取出 ctx 中声明的模型要求
+func RouteRequirementFromContext(ctx context.Context) (RouteRequirement, bool) {
+	if ctx == nil {
+		return RouteRequirement{}, false
+	}
+	req, ok := ctx.Value(routeRequirementKey).(RouteRequirement)
+	return req, ok
+}
+
+// WithRouteDecision 返回带记录槽的 ctx；经 ModelRouter 路由的调用完成后将决策写入返回的 RouteDecision
+func WithRouteDecision(ctx context.Context) (context.Context, *RouteDecision) {
+	d := &RouteDecision{}
+	return context.WithValue(ctx, routeDecisionKey, d), d
+}
+
+func recordRouteDecision(ctx context.Context, d RouteDecision) {
+	if slot, ok := ctx.Value(routeDecisionKey).(*RouteDecision); ok && slot != nil {
+		*slot = d
+	}
+}
+
+// RouteCandidateSpec 候选模型的声明属性
+type RouteCandidateSpec struct {
+	Client  Client
+	Quality int
+	// Cost 相对成本（如每千 token 价格），仅用于候选间比较
+	Cost float64
+	// ExpectedLatency 尚无实测数据时的预估延迟；0 为未知
+	ExpectedLatency time.Duration
+}
+
+type routeMember struct {
+	spec RouteCandidateSpec
+
+	mu      sync.Mutex
+	ewma    time.Duration
+	samples int
+}
+
+func (m *routeMember) label() string {
+	return m.spec.Client.Provider() + "/" + m.spec.Client.Model()
+}
+
+// latency 返回延迟估计及来源：有实测样本时为 EWMA，否则为声明值
+func (m *routeMember) latency() (time.Duration, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.samples > 0 {
+		return m.ewma, "live"
+	}
+	if m.spec.ExpectedLatency > 0 {
+		return m.spec.ExpectedLatency, "declared"
+	}
+	return 0, "unknown"
+}
+
+// DefaultRouteAlpha 延迟 EWMA 平滑系数
+const DefaultRouteAlpha = 0.2
+
+// ModelRouter 按步骤的延迟/质量要求在候选模型间选择：ctx 声明了 RouteRequirement 时路由，否则由默认客户端服务。
+// 每次调用按成功调用的耗时更新候选模型的延迟 EWMA，使选择随提供商实时延迟变化
+type ModelRouter struct {
+	def     Client
+	members []*routeMember
+	alpha   float64
+	now     func() time.Time
+}
+
+// NewModelRouter 创建路由；def 为未声明要求（或所选模型failed）时使用的客户端，可为 nil；alpha<=0 时取 DefaultRouteAlpha
+func NewModelRouter(def Client, candidates []RouteCandidateSpec, alpha float64) *ModelRouter {
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultRouteAlpha
+	}
+	r := &ModelRouter{def: def, alpha: alpha, now: time.Now}
+	for _, c := range candidates {
+		if c.Client == nil {
+			continue
+		}
+		r.members = append(r.members, &routeMember{spec: c})
+	}
+	return r
+}
+
+// Select 按要求选择模型：先筛出质量达标的候选（无则取质量最高者），再筛出延迟估计不超过 MaxLatency 的候选
+// （延迟未知视为达标，以便积累实测数据；无达标者取最快者），最后按 Prefer 取成本最低或延迟最低者
+func (r *ModelRouter) Select(req RouteRequirement) (Client, RouteDecision) {
+	d := RouteDecision{Requirement: req, Outcome: "met"}
+	if len(r.members) == 0 {
+		d.Outcome = "fallback"
+		d.Rationale = "no routing candidates configured; using default model"
+		return r.def, d
+	}
+	type scored struct {
+		m       *routeMember
+		latency time.Duration
+		idx     int
+	}
+	all := make([]scored, len(r.members))
+	d.Candidates = make([]RouteCandidate, len(r.members))
+	maxQuality := 0
+	for i, m := range r.members {
+		lat, src := m.latency()
+		all[i] = scored{m: m, latency: lat, idx: i}
+		d.Candidates[i] = RouteCandidate{Model: m.label(), Quality: QualityName(m.spec.Quality), Cost: m.spec.Cost, LatencyMs: lat.Milliseconds(), LatencySource: src}
+		maxQuality = max(maxQuality, m.spec.Quality)
+	}
+	var notes []string
+	pool := make([]scored, 0, len(all))
+	for _, s := range all {
+		if s.m.spec.Quality >= req.Quality {
+			pool = append(pool, s)
+		} else {
+			d.Candidates[s.idx].Reason = "quality " + QualityName(s.m.spec.Quality) + " < " + QualityName(req.Quality)
+		}
+	}
+	if len(pool) == 0 {
+		d.Outcome = "quality_unmet"
+		notes = append(notes, fmt.Sprintf("no candidate meets quality %s; using the highest available (%s)", QualityName(req.Quality), QualityName(maxQuality)))
+		for _, s := range all {
+			if s.m.spec.Quality == maxQuality {
+				pool = append(pool, s)
+				d.Candidates[s.idx].Reason = ""
+			}
+		}
+	}
+	if req.MaxLatency > 0 {
+		fast := make([]scored, 0, len(pool))
+		for _, s := range pool {
+			if s.latency <= req.MaxLatency {
+				fast = append(fast, s)
+			} else {
+				d.Candidates[s.idx].Reason = fmt.Sprintf("latency %s > %s", s.latency.Round(time.Millisecond), req.MaxLatency)
+			}
+		}
+		if len(fast) == 0 {
+			if d.Outcome == "met" {
+				d.Outcome = "latency_unmet"
+			}
+			notes = append(notes, fmt.Sprintf("no candidate within max_latency %s; using the fastest", req.MaxLatency))
+			sort.SliceStable(pool, func(i, j int) bool { return pool[i].latency < pool[j].latency })
+			pool = pool[:1]
+		} else {
+			pool = fast
+		}
+	}
+	byCost := func(i, j int) bool {
+		if pool[i].m.spec.Cost != pool[j].m.spec.Cost {
+			return pool[i].m.spec.Cost < pool[j].m.spec.Cost
+		}
+		return pool[i].latency < pool[j].latency
+	}
+	byLatency := func(i, j int) bool {
+		if pool[i].latency != pool[j].latency {
+			return pool[i].latency < pool[j].latency
+		}
+		return pool[i].m.spec.Cost < pool[j].m.spec.Cost
+	}
+	criterion := "cheapest"
+	if req.Prefer == PreferLatency {
+		criterion = "fastest"
+		sort.SliceStable(pool, byLatency)
+	} else {
+		sort.SliceStable(pool, byCost)
+	}
+	chosen := pool[0]
+	for _, s := range pool {
+		d.Candidates[s.idx].Eligible = true
+	}
+	d.Provider, d.Model = chosen.m.spec.Client.Provider(), chosen.m.spec.Client.Model()
+	c := d.Candidates[chosen.idx]
+	reason := fmt.Sprintf("%s of %d eligible candidate(s) for quality>=%s", criterion, len(pool), QualityName(req.Quality))
+	if req.MaxLatency > 0 {
+		reason += fmt.Sprintf(", max_latency %s", req.MaxLatency)
+	}
+	reason += fmt.Sprintf(": %s (quality %s, cost %g, latency %dms %s)", c.Model, c.Quality, c.Cost, c.LatencyMs, c.LatencySource)
+	d.Rationale = strings.Join(append(notes, reason), "; ")
+	return chosen.m.spec.Client, d
+}
+
+// observe 以成功调用的耗时更新延迟 EWMA
+func (r *ModelRouter) observe(cl Client, elapsed time.Duration) {
+	for _, m := range r.members {
+		if m.spec.Client != cl {
+			continue
+		}
+		m.mu.Lock()
+		if m.samples == 0 {
+			m.ewma = elapsed
+		} else {
+			m.ewma = time.Duration(r.alpha*float64(elapsed) + (1-r.alpha)*float64(m.ewma))
+		}
+		m.samples++
+		v := m.ewma.Seconds()
+		m.mu.Unlock()
+		metrics.LLMModelLatencySeconds.WithLabelValues(m.label()).Set(math.Round(v*1000) / 1000)
+		return
+	}
+}
+
+func (r *ModelRouter) call(ctx context.Context, fn func(ctx context.Context, cl Client) (string, error)) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, ok := RouteRequirementFromContext(ctx)
+	if !ok || req.IsZero() {
+		if r.def == nil {
+			return "", fmt.Errorf("llm router: no default model configured")
+		}
+		return fn(ctx, r.def)
+	}
+	cl, d := r.Select(req)
+	if cl == nil {
+		return "", fmt.Errorf("llm router: no model available")
+	}
+	start := r.now()
+	out, err := fn(ctx, cl)
+	if err == nil {
+		r.observe(cl, r.now().Sub(start))
+		recordServed(ctx, ServedModel{Provider: cl.Provider(), Model: cl.Model(), Attempts: 1})
+	} else if ctx.Err() == nil && r.def != nil && cl != r.def {
+		// 所选模型failed：改由默认客户端（可能是故障切换链）服务，决策记为 fallback
+		d.Outcome = "fallback"
+		d.Rationale += fmt.Sprintf("; %s/%s failed (%v), served by default model", d.Provider, d.Model, err)
+		out, err = fn(ctx, r.def)
+		if err == nil {
+			d.Provider, d.Model = r.def.Provider(), r.def.Model()
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	metrics.LLMRouteTotal.WithLabelValues(d.Provider+"/"+d.Model, QualityName(req.Quality), d.Outcome).Inc()
+	recordRouteDecision(ctx, d)
+	return out, nil
+}
+
+// Generate 生成文本
+func (r *ModelRouter) Generate(prompt string, options GenerateOptions) (string, error) {
+	return r.GenerateWithContext(context.Background(), prompt, options)
+}
+
+// GenerateWithContext 按 ctx 中的要求路由后生成文本
+func (r *ModelRouter) GenerateWithContext(ctx context.Context, prompt string, options GenerateOptions) (string, error) {
+	return r.call(ctx, func(ctx context.Context, cl Client) (string, error) {
+		return cl.GenerateWithContext(ctx, prompt, options)
+	})
+}
+
+// Chat 聊天
+func (r *ModelRouter) Chat(messages []Message, options GenerateOptions) (string, error) {
+	return r.ChatWithContext(context.Background(), messages, options)
+}
+
+// ChatWithContext 按 ctx 中的要求路由后聊天
+func (r *ModelRouter) ChatWithContext(ctx context.Context, messages []Message, options GenerateOptions) (string, error) {
+	return r.call(ctx, func(ctx context.Context, cl Client) (string, error) {
+		return cl.ChatWithContext(ctx, messages, options)
+	})
+}
+
+// Model 返回默认模型名称
+func (r *ModelRouter) Model() string {
+	if r.def != nil {
+		return r.def.Model()
+	}
+	return ""
+}
+
+// Provider 返回默认模型的提供商
+func (r *ModelRouter) Provider() string {
+	if r.def != nil {
+		return r.def.Provider()
+	}
+	return ""
+}
+
+// SetModel 设置默认模型的模型名称
+func (r *ModelRouter) SetModel(model string) {
+	if r.def != nil {
+		r.def.SetModel(model)
+	}
+}
+
+// SetAPIKey 设置默认模型的 API Key
+func (r *ModelRouter) SetAPIKey(apiKey string) {
+	if r.def != nil {
+		r.def.SetAPIKey(apiKey)
+	}
+}
+
+// Unwrap 实现 Unwrapper，返回默认客户端与全部候选
+func (r *ModelRouter) Unwrap() []Client {
+	out := make([]Client, 0, len(r.members)+1)
+	if r.def != nil {
+		out = append(out, r.def)
+	}
+	for _, m := range r.members {
+		out = append(out, m.spec.Client)
+	}
+	return out
+}
+
+// Pinned 返回固定要求的客户端：每次调用均按 req 路由（如规划调用固定要求 QualityHigh，使用最强模型）
+func (r *ModelRouter) Pinned(req RouteRequirement) Client {
+	return &pinnedRouteClient{ModelRouter: r, req: req}
+}
+
+type pinnedRouteClient struct {
+	*ModelRouter
+	req RouteRequirement
+}
+
+func (p *pinnedRouteClient) pin(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := RouteRequirementFromContext(ctx); ok {
+		return ctx
+	}
+	return WithRouteRequirement(ctx, p.req)
+}
+
+func (p *pinnedRouteClient) Generate(prompt string, options GenerateOptions) (string, error) {
+	return p.GenerateWithContext(context.Background(), prompt, options)
+}
+
+func (p *pinnedRouteClient) GenerateWithContext(ctx context.Context, prompt string, options GenerateOptions) (string, error) {
+	return p.ModelRouter.GenerateWithContext(p.pin(ctx), prompt, options)
+}
+
+func (p *pinnedRouteClient) Chat(messages []Message, options GenerateOptions) (string, error) {
+	return p.ChatWithContext(context.Background(), messages, options)
+}
+
+func (p *pinnedRouteClient) ChatWithContext(ctx context.Context, messages []Message, options GenerateOptions) (string, error) {
+	return p.ModelRouter.ChatWithContext(p.pin(ctx), messages, options)
+}
+
+// Model 返回按固定要求当前会选择的模型名称
+func (p *pinnedRouteClient) Model() string {
+	if cl, _ := p.Select(p.req); cl != nil {
+		return cl.Model()
+	}
+	return ""
+}
+
+// Provider 返回按固定要求当前会选择的模型提供商
+func (p *pinnedRouteClient) Provider() string {
+	if cl, _ := p.Select(p.req); cl != nil {
+		return cl.Provider()
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter() (*ModelRouter, *flakyClient, *flakyClient, *flakyClient, *flakyClient) {
+	def := &flakyClient{provider: "openai", model: "gpt-4o"}
+	mini := &flakyClient{provider: "openai", model: "gpt-4o-mini"}
+	std := &flakyClient{provider: "claude", model: "haiku"}
+	strong := &flakyClient{provider: "claude", model: "opus"}
+	r := NewModelRouter(def, []RouteCandidateSpec{
+		{Client: mini, Quality: QualityLow, Cost: 0.2, ExpectedLatency: 400 * time.Millisecond},
+		{Client: std, Quality: QualityStandard, Cost: 1, ExpectedLatency: time.Second},
+		{Client: strong, Quality: QualityHigh, Cost: 15, ExpectedLatency: 4 * time.Second},
+	}, 0)
+	return r, def, mini, std, strong
+}
+
+func TestModelRouter_SelectByQualityAndCost(t *testing.T) {
+	r, _, mini, std, strong := newTestRouter()
+
+	cl, d := r.Select(RouteRequirement{Quality: QualityLow})
+	assert.Equal(t, Client(mini), cl)
+	assert.Equal(t, "met", d.Outcome)
+	assert.Contains(t, d.Rationale, "cheapest of 3")
+
+	cl, d = r.Select(RouteRequirement{Quality: QualityStandard})
+	assert.Equal(t, Client(std), cl)
+	assert.False(t, d.Candidates[0].Eligible)
+	assert.Equal(t, "quality low < standard", d.Candidates[0].Reason)
+
+	cl, _ = r.Select(RouteRequirement{Quality: QualityHigh})
+	assert.Equal(t, Client(strong), cl)
+}
+
+func TestModelRouter_LatencyUsesLiveStats(t *testing.T) {
+	r, _, mini, std, _ := newTestRouter()
+	req := RouteRequirement{MaxLatency: 2 * time.Second}
+
+	cl, _ := r.Select(req)
+	assert.Equal(t, Client(mini), cl)
+
+	// 实测延迟高于声明值后，超出上限的候选不再被选中
+	r.observe(mini, 3*time.Second)
+	cl, d := r.Select(req)
+	assert.Equal(t, Client(std), cl)
+	assert.Equal(t, "live", d.Candidates[0].LatencySource)
+	assert.Contains(t, d.Candidates[0].Reason, "latency 3s > 2s")
+
+	// 无候选满足上限时取最快者
+	cl, d = r.Select(RouteRequirement{Quality: QualityHigh, MaxLatency: time.Second})
+	assert.Equal(t, "latency_unmet", d.Outcome)
+	assert.Equal(t, "opus", cl.Model())
+}
+
+func TestModelRouter_PreferLatency(t *testing.T) {
+	r, _, _, std, _ := newTestRouter()
+	r.observe(std, 100*time.Millisecond)
+	cl, d := r.Select(RouteRequirement{Prefer: PreferLatency})
+	assert.Equal(t, Client(std), cl)
+	assert.Contains(t, d.Rationale, "fastest")
+}
+
+func TestModelRouter_CallRecordsDecision(t *testing.T) {
+	r, def, mini, _, _ := newTestRouter()
+
+	// 未声明要求：默认客户端服务，不产生决策
+	ctx, decision := WithRouteDecision(context.Background())
+	out, err := r.GenerateWithContext(ctx, "hi", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o:hi", out)
+	assert.Empty(t, decision.Model)
+
+	ctx, decision = WithRouteDecision(WithRouteRequirement(context.Background(), RouteRequirement{Quality: QualityLow}))
+	ctx, served := WithServedModel(ctx)
+	out, err = r.GenerateWithContext(ctx, "sum", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o-mini:sum", out)
+	assert.Equal(t, "gpt-4o-mini", decision.Model)
+	assert.Equal(t, "gpt-4o-mini", served.Model)
+
+	// 所选模型failed时由默认客户端服务
+	mini.fail = true
+	ctx, decision = WithRouteDecision(WithRouteRequirement(context.Background(), RouteRequirement{Quality: QualityLow}))
+	out, err = r.GenerateWithContext(ctx, "sum", GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o:sum", out)
+	assert.Equal(t, "fallback", decision.Outcome)
+	assert.Equal(t, def.Model(), decision.Model)
+}
+
+func TestModelRouter_PinnedUsesStrongest(t *testing.T) {
+	r, _, _, _, strong := newTestRouter()
+	p := r.Pinned(RouteRequirement{Quality: QualityHigh})
+	assert.Equal(t, "opus", p.Model())
+	ctx, decision := WithRouteDecision(context.Background())
+	out, err := p.ChatWithContext(ctx, []Message{{Role: "user", Content: "plan"}}, GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "opus:plan", out)
+	assert.Equal(t, 1, strong.calls)
+	assert.Equal(t, "high", QualityName(decision.Requirement.Quality))
+}
+
+func TestRouteRequirement_JSON(t *testing.T) {
+	b, err := json.Marshal(RouteRequirement{Quality: QualityLow, MaxLatency: 2 * time.Second})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"quality":"low","max_latency":"2s"}`, string(b))
+}
+
+func TestParseQuality(t *testing.T) {
+	q, err := ParseQuality("High")
+	require.NoError(t, err)
+	assert.Equal(t, QualityHigh, q)
+	_, err = ParseQuality("ultra")
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 入库流水线阶段（指标 stage 标签、入库任务 failed_stage 取值）
+const (
+	StageLoad  = "load"
+	StageParse = "parse"
+	StageDedup = "dedup"
+	StageSplit = "split"
+	StageEmbed = "embed"
+	StageIndex = "index"
+)
+
+// 阶段error分类（指标 class 标签、入库任务 error_class 取值）
+const (
+	ErrorClassTimeout      = "timeout"
+	ErrorClassCanceled     = "canceled"
+	ErrorClassRateLimited  = "rate_limited"
+	ErrorClassAuth         = "auth"
+	ErrorClassNetwork      = "network"
+	ErrorClassInvalidInput = "invalid_input"
+	ErrorClassInternal     = "internal"
+)
+
+// StageTiming 单个阶段耗时
+type StageTiming struct {
+	Stage      string `json:"stage"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      bool   `json:"error,omitempty"`
+}
+
+// StageError 入库阶段failed：记录failed阶段、error分类与此前各阶段耗时
+type StageError struct {
+	Stage  string
+	Class  string
+	Stages []StageTiming
+	Err    error
+}
+
+// Error 实现 error 接口
+func (e *StageError) Error() string {
+	return fmt.Sprintf("ingest %s: %v", e.Stage, e.Err)
+}
+
+// Unwrap 实现 errors.Unwrap 接口
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// GetStageError 获取 StageError
+func GetStageError(err error) (*StageError, bool) {
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		return stageErr, true
+	}
+	return nil, false
+}
+
+// ClassifyError 将阶段error归类，便于按类聚合failed与判断是否值得重试
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if stageErr, ok := GetStageError(err); ok && stageErr.Class != "" {
+		return stageErr.Class
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrTimeout):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, ErrRateLimit):
+		return ErrorClassRateLimited
+	case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrForbidden):
+		return ErrorClassAuth
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrValidationFailed), IsValidationError(err):
+		return ErrorClassInvalidInput
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	// 上游 SDK 多只返回带状态码的文本，按关键字兜底
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, "timeout", "timed out", "deadline exceeded"):
+		return ErrorClassTimeout
+	case containsAny(msg, "429", "rate limit", "too many requests"):
+		return ErrorClassRateLimited
+	case containsAny(msg, "401", "403", "unauthorized", "forbidden", "invalid api key"):
+		return ErrorClassAuth
+	case containsAny(msg, "connection refused", "connection reset", "no such host", "broken pipe", "unexpected eof"):
+		return ErrorClassNetwork
+	case containsAny(msg, "unsupported", "exceeds limit", "超过限制", "file not found", "cannot find", "invalid"):
+		return ErrorClassInvalidInput
+	}
+	return ErrorClassInternal
+}
+
+// Retryable 该类error重试是否可能成功（输入与鉴权问题重试无意义）
+func Retryable(class string) bool {
+	switch class {
+	case ErrorClassInvalidInput, ErrorClassAuth:
+		return false
+	}
+	return true
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("embed: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{context.Canceled, ErrorClassCanceled},
+		{errors.New("error, status code: 429, message: Rate limit reached"), ErrorClassRateLimited},
+		{errors.New("status code: 401, invalid api key"), ErrorClassAuth},
+		{errors.New("dial tcp 127.0.0.1:6333: connect: connection refused"), ErrorClassNetwork},
+		{NewPipelineError("loader", "输入验证failed", errors.New("file size exceeds limit: 10 > 5")), ErrorClassInvalidInput},
+		{NewValidationError("content", "empty"), ErrorClassInvalidInput},
+		{errors.New("boom"), ErrorClassInternal},
+		{&StageError{Stage: StageEmbed, Class: ErrorClassNetwork, Err: errors.New("boom")}, ErrorClassNetwork},
+	}
+	for _, c := range cases {
+		if got := ClassifyError(c.err); got != c.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+}
+
+func TestStageError(t *testing.T) {
+	cause := errors.New("vector store down")
+	err := fmt.Errorf("workflow: %w", &StageError{Stage: StageIndex, Class: ErrorClassInternal, Err: cause})
+	se, ok := GetStageError(err)
+	if !ok || se.Stage != StageIndex {
+		t.Fatalf("GetStageError: ok=%v se=%v", ok, se)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("StageError should unwrap to cause")
+	}
+	if se.Error() != "ingest index: vector store down" {
+		t.Errorf("Error() = %q", se.Error())
+	}
+	if Retryable(ErrorClassInvalidInput) || !Retryable(ErrorClassTimeout) {
+		t.Error("Retryable mismatch")
+	}
+}
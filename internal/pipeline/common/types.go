@@ -17,6 +17,8 @@ package common
 import (
 	"context"
 	"time"
+
+	"rag-platform/internal/storage/vector"
 )
 
 // PipelineContext Pipeline 执行上下文
@@ -69,7 +71,9 @@ type Query struct {
 	Text      string                 `json:"text"`
 	Metadata  map[string]interface{} `json:"metadata"`
 	Embedding []float64              `json:"embedding,omitempty"`
-	CreatedAt time.Time              `json:"created_at"`
+	// Filter 下推到向量检索的过滤表达式（请求方过滤 + 文档 ACL）
+	Filter    *vector.FilterExpr `json:"filter,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
 }
 
 // RetrievalResult 检索结果
@@ -0,0 +1,218 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package freshness 跟踪知识库文档新鲜度：记录源时间戳与最近入库时间，按集合策略判定过期与到期刷新，
+// 并为 RAG 回答中引用的过期文档生成提示。
+package freshness
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"rag-platform/internal/storage/metadata"
+)
+
+// 文档/切片元数据中的新鲜度键（RFC3339 字符串，随 ingest 写入文档记录与切片）
+const (
+	MetaSourceUpdatedAt = "source_updated_at" // 源内容最后修改时间（上传时可指定，缺省为入库时间）
+	MetaIngestedAt      = "ingested_at"       // 最近一次（重新）入库时间
+	MetaSourceURI       = "source_uri"        // 可重新抓取的源地址（http(s):// 或 file://）；为空则不参与定时刷新
+	MetaCollection      = "collection"        // 文档所属集合
+	MetaFilename        = "filename"
+)
+
+// Policy 单个集合的新鲜度策略
+type Policy struct {
+	// MaxAge 源内容超过该时长视为过期；0 表示不判定过期
+	MaxAge time.Duration
+	// RefreshInterval 距上次入库超过该时长即到期重新抓取；0 表示不定时刷新
+	RefreshInterval time.Duration
+}
+
+// Policies 默认策略 + 按集合覆盖
+type Policies struct {
+	Default     Policy
+	Collections map[string]Policy
+}
+
+// For 返回集合生效的策略；未单独配置的字段沿用默认值
+func (p Policies) For(collection string) Policy {
+	out := p.Default
+	if c, ok := p.Collections[collection]; ok {
+		if c.MaxAge > 0 {
+			out.MaxAge = c.MaxAge
+		}
+		if c.RefreshInterval > 0 {
+			out.RefreshInterval = c.RefreshInterval
+		}
+	}
+	return out
+}
+
+// RefreshEnabled 是否有任一集合配置了定时刷新
+func (p Policies) RefreshEnabled() bool {
+	if p.Default.RefreshInterval > 0 {
+		return true
+	}
+	for _, c := range p.Collections {
+		if c.RefreshInterval > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DocumentFreshness 单文档新鲜度
+type DocumentFreshness struct {
+	DocumentID      string     `json:"document_id"`
+	Name            string     `json:"name"`
+	SourceURI       string     `json:"source_uri,omitempty"`
+	SourceUpdatedAt *time.Time `json:"source_updated_at,omitempty"`
+	IngestedAt      *time.Time `json:"ingested_at,omitempty"`
+	AgeSeconds      int64      `json:"age_seconds"`
+	Stale           bool       `json:"stale"`
+	RefreshDue      bool       `json:"refresh_due"`
+	NextRefreshAt   *time.Time `json:"next_refresh_at,omitempty"`
+}
+
+// Report 集合新鲜度报告
+type Report struct {
+	Collection             string              `json:"collection"`
+	MaxAgeSeconds          int64               `json:"max_age_seconds"`
+	RefreshIntervalSeconds int64               `json:"refresh_interval_seconds"`
+	Total                  int                 `json:"total"`
+	Stale                  int                 `json:"stale"`
+	RefreshDue             int                 `json:"refresh_due"`
+	OldestSourceUpdatedAt  *time.Time          `json:"oldest_source_updated_at,omitempty"`
+	Documents              []DocumentFreshness `json:"documents"`
+	GeneratedAt            time.Time           `json:"generated_at"`
+}
+
+// Stamp 写入入库时的新鲜度元数据：ingested_at 总是刷新为 now，source_updated_at 缺省为 now
+func Stamp(meta map[string]interface{}, now time.Time) {
+	ts := now.UTC().Format(time.RFC3339)
+	meta[MetaIngestedAt] = ts
+	if s, _ := meta[MetaSourceUpdatedAt].(string); strings.TrimSpace(s) == "" {
+		meta[MetaSourceUpdatedAt] = ts
+	}
+}
+
+// ParseTime 解析 RFC3339 或 Unix 秒时间戳；无法解析时返回 false
+func ParseTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, !t.IsZero()
+	case string:
+		s := strings.TrimSpace(t)
+		if s == "" {
+			return time.Time{}, false
+		}
+		if ts, err := time.Parse(time.RFC3339, s); err == nil {
+			return ts, true
+		}
+		if ts, err := time.Parse("2006-01-02", s); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Assess 按策略评估单个文档；源时间缺失时回退到入库时间，再回退到记录的 UpdatedAt
+func Assess(doc *metadata.Document, policy Policy, now time.Time) DocumentFreshness {
+	f := DocumentFreshness{DocumentID: doc.ID, Name: doc.Name}
+	if n := doc.Metadata[MetaFilename]; n != "" {
+		f.Name = n
+	}
+	f.SourceURI = doc.Metadata[MetaSourceURI]
+	ingested, okIngested := ParseTime(doc.Metadata[MetaIngestedAt])
+	if !okIngested && doc.UpdatedAt > 0 {
+		ingested, okIngested = time.Unix(doc.UpdatedAt, 0).UTC(), true
+	}
+	if okIngested {
+		f.IngestedAt = &ingested
+	}
+	source, okSource := ParseTime(doc.Metadata[MetaSourceUpdatedAt])
+	if !okSource && okIngested {
+		source, okSource = ingested, true
+	}
+	if okSource {
+		f.SourceUpdatedAt = &source
+		f.AgeSeconds = int64(now.Sub(source).Seconds())
+		f.Stale = policy.MaxAge > 0 && now.Sub(source) > policy.MaxAge
+	}
+	if policy.RefreshInterval > 0 && f.SourceURI != "" {
+		next := now
+		if okIngested {
+			next = ingested.Add(policy.RefreshInterval)
+		}
+		f.NextRefreshAt = &next
+		f.RefreshDue = !next.After(now)
+	}
+	return f
+}
+
+// ListCollection 列出属于集合的文档；未标注集合的文档归入 defaultCollection
+func ListCollection(ctx context.Context, store metadata.Store, collection, defaultCollection string) ([]*metadata.Document, error) {
+	docs, err := store.List(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*metadata.Document, 0, len(docs))
+	for _, d := range docs {
+		c := d.Metadata[MetaCollection]
+		if c == "" {
+			c = defaultCollection
+		}
+		if c == collection {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// BuildReport 生成集合新鲜度报告；文档按源时间由旧到新排序
+func BuildReport(collection string, docs []*metadata.Document, policy Policy, now time.Time) *Report {
+	r := &Report{
+		Collection:             collection,
+		MaxAgeSeconds:          int64(policy.MaxAge.Seconds()),
+		RefreshIntervalSeconds: int64(policy.RefreshInterval.Seconds()),
+		Documents:              make([]DocumentFreshness, 0, len(docs)),
+		GeneratedAt:            now,
+	}
+	for _, d := range docs {
+		f := Assess(d, policy, now)
+		if f.Stale {
+			r.Stale++
+		}
+		if f.RefreshDue {
+			r.RefreshDue++
+		}
+		if f.SourceUpdatedAt != nil && (r.OldestSourceUpdatedAt == nil || f.SourceUpdatedAt.Before(*r.OldestSourceUpdatedAt)) {
+			t := *f.SourceUpdatedAt
+			r.OldestSourceUpdatedAt = &t
+		}
+		r.Documents = append(r.Documents, f)
+	}
+	r.Total = len(r.Documents)
+	sort.SliceStable(r.Documents, func(i, j int) bool {
+		a, b := r.Documents[i].SourceUpdatedAt, r.Documents[j].SourceUpdatedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return r
+}
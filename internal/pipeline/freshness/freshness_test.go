@@ -0,0 +1,126 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freshness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/storage/metadata"
+)
+
+func seedDoc(t *testing.T, store metadata.Store, id string, meta map[string]string) {
+	t.Helper()
+	if err := store.Create(context.Background(), &metadata.Document{ID: id, Name: id, Status: "indexed", Metadata: meta}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildReport_StaleAndRefreshDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := metadata.NewMemoryStore()
+	seedDoc(t, store, "old", map[string]string{
+		MetaCollection:      "kb",
+		MetaSourceUpdatedAt: now.Add(-40 * 24 * time.Hour).Format(time.RFC3339),
+		MetaIngestedAt:      now.Add(-3 * 24 * time.Hour).Format(time.RFC3339),
+		MetaSourceURI:       "https://example.com/a.md",
+	})
+	seedDoc(t, store, "fresh", map[string]string{
+		MetaCollection:      "kb",
+		MetaSourceUpdatedAt: now.Add(-time.Hour).Format(time.RFC3339),
+		MetaIngestedAt:      now.Add(-time.Hour).Format(time.RFC3339),
+	})
+	seedDoc(t, store, "other", map[string]string{MetaCollection: "other"})
+
+	policies := Policies{
+		Default:     Policy{MaxAge: 90 * 24 * time.Hour},
+		Collections: map[string]Policy{"kb": {MaxAge: 30 * 24 * time.Hour, RefreshInterval: 24 * time.Hour}},
+	}
+	docs, err := ListCollection(ctx, store, "kb", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := BuildReport("kb", docs, policies.For("kb"), now)
+	if r.Total != 2 || r.Stale != 1 || r.RefreshDue != 1 {
+		t.Fatalf("report = total %d stale %d due %d", r.Total, r.Stale, r.RefreshDue)
+	}
+	if r.Documents[0].DocumentID != "old" || !r.Documents[0].Stale || !r.Documents[0].RefreshDue {
+		t.Fatalf("oldest document first and stale: %+v", r.Documents[0])
+	}
+	if r.Documents[1].Stale || r.Documents[1].RefreshDue {
+		t.Fatalf("fresh document without source_uri: %+v", r.Documents[1])
+	}
+}
+
+func TestWarnings_DedupByDocument(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-100 * 24 * time.Hour).Format(time.RFC3339)
+	chunks := []common.Chunk{
+		{ID: "c1", DocumentID: "d1", Metadata: map[string]interface{}{MetaSourceUpdatedAt: old, MetaFilename: "a.md"}},
+		{ID: "c2", DocumentID: "d1", Metadata: map[string]interface{}{MetaSourceUpdatedAt: old}},
+		{ID: "c3", DocumentID: "d2", Metadata: map[string]interface{}{MetaSourceUpdatedAt: now.Format(time.RFC3339)}},
+	}
+	if w := Warnings(chunks, Policies{}, "default", now); len(w) != 0 {
+		t.Fatalf("no max_age configured, warnings = %+v", w)
+	}
+	w := Warnings(chunks, Policies{Default: Policy{MaxAge: 30 * 24 * time.Hour}}, "default", now)
+	if len(w) != 1 || w[0].DocumentID != "d1" || w[0].Name != "a.md" {
+		t.Fatalf("warnings = %+v", w)
+	}
+}
+
+func TestRefresher_ReingestsDueDocuments(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := metadata.NewMemoryStore()
+	seedDoc(t, store, "due", map[string]string{
+		MetaSourceURI:  "https://example.com/a.md",
+		MetaIngestedAt: now.Add(-48 * time.Hour).Format(time.RFC3339),
+		MetaFilename:   "a.md",
+		"team":         "docs",
+	})
+	seedDoc(t, store, "recent", map[string]string{
+		MetaSourceURI:  "https://example.com/b.md",
+		MetaIngestedAt: now.Add(-time.Hour).Format(time.RFC3339),
+	})
+	modified := now.Add(-2 * time.Hour)
+	var got map[string]interface{}
+	var gotName string
+	r := NewRefresher(store, Policies{Default: Policy{RefreshInterval: 24 * time.Hour}}, "default",
+		func(ctx context.Context, filename string, content []byte, meta map[string]interface{}) error {
+			gotName, got = filename, meta
+			return nil
+		},
+		func(ctx context.Context, uri string) ([]byte, time.Time, error) {
+			return []byte("# updated"), modified, nil
+		}, nil)
+	r.now = func() time.Time { return now }
+	n, err := r.RunOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if gotName != "a.md" || got["team"] != "docs" || got[MetaSourceUpdatedAt] != modified.Format(time.RFC3339) || got[MetaIngestedAt] != now.Format(time.RFC3339) {
+		t.Fatalf("reingest metadata = %s %+v", gotName, got)
+	}
+	if _, err := store.Get(ctx, "due"); err == nil {
+		t.Fatal("old document record should be removed after refresh")
+	}
+	if _, err := store.Get(ctx, "recent"); err != nil {
+		t.Fatal("document not yet due must be kept")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freshness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/pkg/log"
+)
+
+// DefaultScanInterval 刷新调度扫描间隔
+const DefaultScanInterval = 5 * time.Minute
+
+// maxFetchBytes 单次抓取上限，避免异常源拖垮入库
+const maxFetchBytes = 64 << 20
+
+// ReingestFunc 以抓取到的最新内容重新入库；meta 为原文档元数据（已更新 ingested_at / source_updated_at）
+type ReingestFunc func(ctx context.Context, filename string, content []byte, meta map[string]interface{}) error
+
+// FetchFunc 按 source_uri 抓取内容，返回内容与源最后修改时间（未知时为零值）
+type FetchFunc func(ctx context.Context, uri string) ([]byte, time.Time, error)
+
+// Refresher 按集合 RefreshInterval 定时重新抓取带 source_uri 的文档并重新入库；
+// 重新入库成功后删除旧文档元数据，使新鲜度以新记录为准
+type Refresher struct {
+	store             metadata.Store
+	policies          Policies
+	defaultCollection string
+	reingest          ReingestFunc
+	fetch             FetchFunc
+	logger            *log.Logger
+	now               func() time.Time
+}
+
+// NewRefresher 创建刷新调度器；fetch 为 nil 时使用 HTTPFetch
+func NewRefresher(store metadata.Store, policies Policies, defaultCollection string, reingest ReingestFunc, fetch FetchFunc, logger *log.Logger) *Refresher {
+	if fetch == nil {
+		fetch = HTTPFetch(&http.Client{Timeout: time.Minute})
+	}
+	return &Refresher{
+		store:             store,
+		policies:          policies,
+		defaultCollection: defaultCollection,
+		reingest:          reingest,
+		fetch:             fetch,
+		logger:            logger,
+		now:               time.Now,
+	}
+}
+
+// RunOnce 扫描所有文档，刷新到期者；返回成功刷新的数量
+func (r *Refresher) RunOnce(ctx context.Context) (int, error) {
+	docs, err := r.store.List(ctx, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	refreshed := 0
+	for _, d := range docs {
+		if ctx.Err() != nil {
+			return refreshed, ctx.Err()
+		}
+		collection := d.Metadata[MetaCollection]
+		if collection == "" {
+			collection = r.defaultCollection
+		}
+		now := r.now()
+		if !Assess(d, r.policies.For(collection), now).RefreshDue {
+			continue
+		}
+		if err := r.refresh(ctx, d, now); err != nil {
+			r.logf("文档刷新failed", "document_id", d.ID, "source_uri", d.Metadata[MetaSourceURI], "error", err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+func (r *Refresher) refresh(ctx context.Context, d *metadata.Document, now time.Time) error {
+	uri := d.Metadata[MetaSourceURI]
+	content, modified, err := r.fetch(ctx, uri)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", uri, err)
+	}
+	meta := make(map[string]interface{}, len(d.Metadata))
+	for k, v := range d.Metadata {
+		meta[k] = v
+	}
+	// 源未提供修改时间时视为抓取时刻的内容
+	if modified.IsZero() {
+		modified = now
+	}
+	meta[MetaSourceUpdatedAt] = modified.UTC().Format(time.RFC3339)
+	Stamp(meta, now)
+	filename := d.Metadata[MetaFilename]
+	if filename == "" {
+		filename = d.Name
+	}
+	if err := r.reingest(ctx, filename, content, meta); err != nil {
+		return fmt.Errorf("reingest: %w", err)
+	}
+	// 版本化存储按来源原地更新文档（ID 不变），此时无旧记录可删
+	if vs, ok := r.store.(metadata.VersionStore); ok {
+		if cur, err := vs.FindBySource(ctx, metadata.URISourceKey(uri)); err == nil && cur != nil && cur.ID == d.ID {
+			r.logf("文档已刷新", "document_id", d.ID, "source_uri", uri)
+			return nil
+		}
+	}
+	if err := r.store.Delete(ctx, d.ID); err != nil {
+		return fmt.Errorf("删除旧文档元数据failed: %w", err)
+	}
+	r.logf("文档已刷新", "document_id", d.ID, "source_uri", uri)
+	return nil
+}
+
+// Run 以 interval 周期执行 RunOnce，直到 ctx 取消
+func (r *Refresher) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultScanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logf("新鲜度刷新扫描failed", "error", err)
+		} else if n > 0 {
+			r.logf("新鲜度刷新完成", "refreshed", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Refresher) logf(msg string, args ...any) {
+	if r.logger != nil {
+		r.logger.Info(msg, args...)
+	}
+}
+
+// HTTPFetch 返回支持 http(s):// 与 file:// 的抓取函数；源时间取 Last-Modified 或文件 mtime
+func HTTPFetch(client *http.Client) FetchFunc {
+	return func(ctx context.Context, uri string) ([]byte, time.Time, error) {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		switch u.Scheme {
+		case "file":
+			info, err := os.Stat(u.Path)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			f, err := os.Open(u.Path)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			defer f.Close()
+			data, err := io.ReadAll(io.LimitReader(f, maxFetchBytes))
+			return data, info.ModTime(), err
+		case "http", "https":
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				return nil, time.Time{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+			}
+			data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes))
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			var modified time.Time
+			if lm := resp.Header.Get("Last-Modified"); lm != "" {
+				modified, _ = http.ParseTime(lm)
+			}
+			return data, modified, nil
+		default:
+			return nil, time.Time{}, fmt.Errorf("unsupported source_uri scheme %q", u.Scheme)
+		}
+	}
+}
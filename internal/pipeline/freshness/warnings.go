@@ -0,0 +1,81 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freshness
+
+import (
+	"fmt"
+	"time"
+
+	"rag-platform/internal/pipeline/common"
+)
+
+// Warning 回答引用了超出新鲜度阈值的文档
+type Warning struct {
+	DocumentID      string    `json:"document_id"`
+	Name            string    `json:"name,omitempty"`
+	Collection      string    `json:"collection"`
+	SourceUpdatedAt time.Time `json:"source_updated_at"`
+	AgeSeconds      int64     `json:"age_seconds"`
+	MaxAgeSeconds   int64     `json:"max_age_seconds"`
+	Message         string    `json:"message"`
+}
+
+// Warnings 检查检索片段（元数据含 source_updated_at / ingested_at）并按文档去重返回过期提示；
+// 片段未标注集合时使用 defaultCollection 的策略，策略未设置 MaxAge 时不产生提示
+func Warnings(chunks []common.Chunk, policies Policies, defaultCollection string, now time.Time) []Warning {
+	var out []Warning
+	seen := make(map[string]bool)
+	for _, c := range chunks {
+		docID := c.DocumentID
+		if docID == "" {
+			docID, _ = c.Metadata["document_id"].(string)
+		}
+		if docID == "" || seen[docID] {
+			continue
+		}
+		collection, _ := c.Metadata[MetaCollection].(string)
+		if collection == "" {
+			collection = defaultCollection
+		}
+		policy := policies.For(collection)
+		if policy.MaxAge <= 0 {
+			continue
+		}
+		source, ok := ParseTime(c.Metadata[MetaSourceUpdatedAt])
+		if !ok {
+			source, ok = ParseTime(c.Metadata[MetaIngestedAt])
+		}
+		if !ok || now.Sub(source) <= policy.MaxAge {
+			continue
+		}
+		seen[docID] = true
+		name, _ := c.Metadata[MetaFilename].(string)
+		label := name
+		if label == "" {
+			label = docID
+		}
+		age := now.Sub(source)
+		out = append(out, Warning{
+			DocumentID:      docID,
+			Name:            name,
+			Collection:      collection,
+			SourceUpdatedAt: source,
+			AgeSeconds:      int64(age.Seconds()),
+			MaxAgeSeconds:   int64(policy.MaxAge.Seconds()),
+			Message:         fmt.Sprintf("document %q was last updated %s ago, beyond the %s freshness threshold", label, age.Truncate(time.Hour), policy.MaxAge),
+		})
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/residency"
+	"rag-platform/pkg/metrics"
+)
+
+// 去重写入文档记录的元数据键
+const (
+	MetaSimHash          = "simhash"           // 64 位 SimHash 指纹（十六进制），供后续上传做近似重复比对
+	MetaDuplicateSources = "duplicate_sources" // merge 时追加到已有文档：被并入的重复上传来源（逗号分隔）
+)
+
+// 重复文档的处理方式
+const (
+	DedupActionSkip  = "skip"
+	DedupActionMerge = "merge"
+)
+
+// DefaultNearDuplicateThreshold 近似重复默认相似度阈值（64 位指纹最多 6 位不同）
+const DefaultNearDuplicateThreshold = 0.9
+
+// DedupPolicy 单个集合的去重策略
+type DedupPolicy struct {
+	// Enable 内容哈希完全相同即判定为重复
+	Enable bool
+	// NearDuplicate 额外按 SimHash 相似度判定近似重复（需 Enable）
+	NearDuplicate bool
+	// Threshold 近似重复相似度阈值（0-1）；<=0 时用 DefaultNearDuplicateThreshold
+	Threshold float64
+	// Action skip（丢弃）| merge（记为已有文档的重复来源）；空为 skip
+	Action string
+}
+
+// CollectionDedupPolicy 单集合覆盖；nil / 零值字段沿用默认策略
+type CollectionDedupPolicy struct {
+	Enable        *bool
+	NearDuplicate *bool
+	Threshold     float64
+	Action        string
+}
+
+// DedupPolicies 默认策略 + 按集合覆盖
+type DedupPolicies struct {
+	Default     DedupPolicy
+	Collections map[string]CollectionDedupPolicy
+}
+
+// For 返回集合生效的去重策略
+func (p DedupPolicies) For(collection string) DedupPolicy {
+	out := p.Default
+	if c, ok := p.Collections[collection]; ok {
+		if c.Enable != nil {
+			out.Enable = *c.Enable
+		}
+		if c.NearDuplicate != nil {
+			out.NearDuplicate = *c.NearDuplicate
+		}
+		if c.Threshold > 0 {
+			out.Threshold = c.Threshold
+		}
+		if c.Action != "" {
+			out.Action = c.Action
+		}
+	}
+	if out.Threshold <= 0 || out.Threshold > 1 {
+		out.Threshold = DefaultNearDuplicateThreshold
+	}
+	if out.Action != DedupActionMerge {
+		out.Action = DedupActionSkip
+	}
+	return out
+}
+
+// Enabled 是否有任一集合启用去重
+func (p DedupPolicies) Enabled() bool {
+	if p.Default.Enable {
+		return true
+	}
+	for _, c := range p.Collections {
+		if c.Enable != nil && *c.Enable {
+			return true
+		}
+	}
+	return false
+}
+
+// DedupResult 文档被判定为重复时的处理结果，写入 ingest 任务结果的 dedup 字段
+type DedupResult struct {
+	Result      string  `json:"result"` // skipped | merged
+	Match       string  `json:"match"`  // exact | near
+	DuplicateOf string  `json:"duplicate_of"`
+	Similarity  float64 `json:"similarity"`
+	Collection  string  `json:"collection"`
+}
+
+// Deduplicator 入库去重：在切分与向量化之前将文档与同集合、同租户的已入库文档比对
+type Deduplicator struct {
+	store             metadata.Store
+	policies          DedupPolicies
+	defaultCollection string
+}
+
+// NewDeduplicator 创建去重器；defaultCollection 为文档未指定集合时的归属
+func NewDeduplicator(store metadata.Store, policies DedupPolicies, defaultCollection string) *Deduplicator {
+	if defaultCollection == "" {
+		defaultCollection = "default"
+	}
+	return &Deduplicator{store: store, policies: policies, defaultCollection: defaultCollection}
+}
+
+// Check 检查文档是否重复：未重复时返回 nil 并在 doc.Metadata 写入 content_hash / simhash，随文档记录保存供后续比对；
+// 重复时按策略 skip 或 merge（在已有文档记录追加 duplicate_sources），调用方不应继续入库。
+// 同一来源（source_uri / 文件名）再次入库由版本化处理，不做去重
+func (d *Deduplicator) Check(ctx context.Context, doc *common.Document) (*DedupResult, error) {
+	if d == nil || d.store == nil || doc == nil {
+		return nil, nil
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	collection := d.collectionOf(stringMeta(doc.Metadata, freshness.MetaCollection))
+	policy := d.policies.For(collection)
+	if !policy.Enable {
+		return nil, nil
+	}
+	hash := ContentHash(doc.Content)
+	doc.Metadata[MetaContentHash] = hash
+	var fingerprint uint64
+	if policy.NearDuplicate {
+		fingerprint = SimHash(doc.Content)
+		doc.Metadata[MetaSimHash] = strconv.FormatUint(fingerprint, 16)
+	}
+	if vs, ok := d.store.(metadata.VersionStore); ok {
+		if key := SourceKey(doc); key != "" {
+			existing, err := vs.FindBySource(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return nil, nil
+			}
+		}
+	}
+	docs, err := d.store.List(ctx, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+	tenant := stringMeta(doc.Metadata, residency.MetaTenantID)
+	var match *metadata.Document
+	res := &DedupResult{Collection: collection}
+	for _, cand := range docs {
+		if cand == nil || cand.ID == doc.ID || cand.Metadata[residency.MetaTenantID] != tenant || d.collectionOf(cand.Metadata[freshness.MetaCollection]) != collection {
+			continue
+		}
+		if cand.Metadata[MetaContentHash] == hash {
+			match, res.Match, res.Similarity = cand, "exact", 1
+			break
+		}
+		if !policy.NearDuplicate {
+			continue
+		}
+		other, err := strconv.ParseUint(cand.Metadata[MetaSimHash], 16, 64)
+		if err != nil {
+			continue
+		}
+		if sim := SimHashSimilarity(fingerprint, other); sim >= policy.Threshold && sim > res.Similarity {
+			match, res.Match, res.Similarity = cand, "near", sim
+		}
+	}
+	if match == nil {
+		return nil, nil
+	}
+	res.DuplicateOf = match.ID
+	res.Result = "skipped"
+	if policy.Action == DedupActionMerge {
+		if err := d.merge(ctx, match, doc); err != nil {
+			return nil, err
+		}
+		res.Result = "merged"
+	}
+	metrics.IngestDedupTotal.WithLabelValues(collection, res.Match, res.Result).Inc()
+	return res, nil
+}
+
+// merge 在已有文档记录追加本次上传的来源（source_uri 或文件名），不改动其切片与向量
+func (d *Deduplicator) merge(ctx context.Context, existing *metadata.Document, doc *common.Document) error {
+	source := stringMeta(doc.Metadata, freshness.MetaSourceURI)
+	if source == "" {
+		source = stringMeta(doc.Metadata, freshness.MetaFilename)
+	}
+	if source == "" {
+		source = doc.ID
+	}
+	updated := *existing
+	updated.Metadata = make(map[string]string, len(existing.Metadata)+1)
+	for k, v := range existing.Metadata {
+		updated.Metadata[k] = v
+	}
+	var sources []string
+	if prev := updated.Metadata[MetaDuplicateSources]; prev != "" {
+		sources = strings.Split(prev, ",")
+	}
+	for _, s := range sources {
+		if s == source {
+			return nil
+		}
+	}
+	updated.Metadata[MetaDuplicateSources] = strings.Join(append(sources, source), ",")
+	if err := d.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("merge duplicate into %s: %w", existing.ID, err)
+	}
+	return nil
+}
+
+func (d *Deduplicator) collectionOf(collection string) string {
+	if collection == "" {
+		return d.defaultCollection
+	}
+	return collection
+}
+
+func stringMeta(meta map[string]interface{}, key string) string {
+	s, _ := meta[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// SimHash 文本的 64 位 SimHash 指纹：按字母数字词切分（中日韩等文字逐字切分），以相邻 3 词为特征加权
+func SimHash(content string) uint64 {
+	tokens := simHashTokens(content)
+	if len(tokens) == 0 {
+		return 0
+	}
+	const shingle = 3
+	var weights [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(feature))
+		v := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if v&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(tokens) < shingle {
+		add(strings.Join(tokens, " "))
+	}
+	for i := 0; i+shingle <= len(tokens); i++ {
+		add(strings.Join(tokens[i:i+shingle], " "))
+	}
+	var out uint64
+	for i, w := range weights {
+		if w > 0 {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+// SimHashSimilarity 两个指纹的相似度：1 - 汉明距离/64
+func SimHashSimilarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
+
+func simHashTokens(content string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range strings.ToLower(content) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
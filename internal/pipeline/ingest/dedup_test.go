@@ -0,0 +1,122 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/residency"
+	"rag-platform/internal/storage/vector"
+)
+
+const dedupText = `Aetheris runs agent jobs as an event sourced process. Every step appends events to the job stream,
+and the runner rebuilds state from those events when a worker picks the job up again after a crash. Tools that change the
+outside world are recorded in a ledger so that replay never calls them twice. Plans are produced by the planner as task
+graphs, compiled into steppable nodes and executed in topological order with checkpoints after each node. Operators can
+inspect the trace of any job, export evidence packages for auditors and replay a job in a sandbox to debug a failure.`
+
+func uploadDoc(id, filename, tenant, content string) *common.Document {
+	return &common.Document{
+		ID:      id,
+		Content: content,
+		Metadata: map[string]interface{}{
+			freshness.MetaFilename:   filename,
+			residency.MetaTenantID:   tenant,
+			freshness.MetaCollection: "kb",
+		},
+		Chunks: []common.Chunk{{ID: id + "-c1", Content: content, Embedding: []float64{1, 0}, DocumentID: id}},
+	}
+}
+
+func TestDeduplicator(t *testing.T) {
+	ctx := context.Background()
+	vs := vector.NewMemoryStore()
+	if err := vector.EnsureIndex(ctx, vs, "kb", 2, "cosine"); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+	ms := metadata.NewMemoryStore()
+	idx := NewDocumentIndexer(vs, ms, 1, 10, "kb", "memory")
+	enable := true
+	d := NewDeduplicator(ms, DedupPolicies{
+		Default:     DedupPolicy{Enable: true},
+		Collections: map[string]CollectionDedupPolicy{"kb": {NearDuplicate: &enable, Action: DedupActionMerge}},
+	}, "kb")
+	ingest := func(doc *common.Document) *DedupResult {
+		t.Helper()
+		res, err := d.Check(ctx, doc)
+		if err != nil {
+			t.Fatalf("Check %s: %v", doc.ID, err)
+		}
+		if res == nil {
+			if _, err := idx.Execute(common.NewPipelineContext(ctx, doc.ID), doc); err != nil {
+				t.Fatalf("index %s: %v", doc.ID, err)
+			}
+		}
+		return res
+	}
+
+	if res := ingest(uploadDoc("d1", "a.txt", "t1", dedupText)); res != nil {
+		t.Fatalf("first upload reported duplicate: %+v", res)
+	}
+	res := ingest(uploadDoc("d2", "b.txt", "t1", dedupText))
+	if res == nil || res.Match != "exact" || res.Result != "merged" || res.DuplicateOf == "" {
+		t.Fatalf("exact duplicate = %+v", res)
+	}
+	edited := strings.Replace(dedupText, "crash", "restart", 1)
+	res = ingest(uploadDoc("d3", "c.txt", "t1", edited))
+	if res == nil || res.Match != "near" || res.Similarity < DefaultNearDuplicateThreshold {
+		t.Fatalf("near duplicate = %+v", res)
+	}
+	existing, err := ms.Get(ctx, res.DuplicateOf)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := existing.Metadata[MetaDuplicateSources]; got != "b.txt,c.txt" {
+		t.Errorf("duplicate_sources = %q", got)
+	}
+	if _, err := vs.Get(ctx, "kb", "d2-c1"); err == nil {
+		t.Error("duplicate should not be indexed")
+	}
+
+	// 其它租户、其它内容与同一来源再次入库均不判定为重复
+	if res := ingest(uploadDoc("d4", "a.txt", "t2", dedupText)); res != nil {
+		t.Errorf("other tenant: %+v", res)
+	}
+	if res := ingest(uploadDoc("d5", "e.txt", "t1", "An unrelated note about quarterly billing and invoices.")); res != nil {
+		t.Errorf("unrelated: %+v", res)
+	}
+	if res := ingest(uploadDoc("d6", "a.txt", "t1", edited)); res != nil {
+		t.Errorf("same source re-ingest: %+v", res)
+	}
+}
+
+func TestDedupPolicies_For(t *testing.T) {
+	off := false
+	p := DedupPolicies{
+		Default:     DedupPolicy{Enable: true, Action: "bogus"},
+		Collections: map[string]CollectionDedupPolicy{"raw": {Enable: &off}},
+	}
+	if got := p.For("kb"); !got.Enable || got.Action != DedupActionSkip || got.Threshold != DefaultNearDuplicateThreshold {
+		t.Errorf("default policy = %+v", got)
+	}
+	if p.For("raw").Enable {
+		t.Error("collection override should disable dedup")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 
 	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
 	"rag-platform/internal/storage/metadata"
 	"rag-platform/internal/storage/vector"
 )
@@ -146,16 +147,47 @@ func (i *DocumentIndexer) ProcessDocument(ctx *common.PipelineContext, doc *comm
 		}
 	}
 
-	// 存储文档元数据
-	if err := i.storeDocumentMetadata(ctx.Context, doc); err != nil {
-		return nil, common.NewPipelineError(i.name, "存储文档元数据failed", err)
+	// 新鲜度元数据（集合、入库时间、源时间）写入文档记录与切片，供新鲜度报告与回答过期提示使用
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	if _, ok := doc.Metadata[freshness.MetaCollection].(string); !ok {
+		doc.Metadata[freshness.MetaCollection] = i.defaultIndexName
+	}
+	if _, ok := doc.Metadata[freshness.MetaIngestedAt].(string); !ok {
+		freshness.Stamp(doc.Metadata, time.Now())
+	}
+
+	// 版本化：同一来源再次入库时沿用文档 ID；内容哈希未变则不改动向量库
+	plan, err := i.planVersion(ctx.Context, doc)
+	if err != nil {
+		return nil, common.NewPipelineError(i.name, "查询文档版本failed", err)
+	}
+	if plan != nil && plan.unchanged {
+		// 仍刷新文档记录（入库时间等），避免新鲜度刷新反复抓取未变化的源
+		if err := i.storeDocumentMetadata(ctx.Context, doc, plan.existing); err != nil {
+			return nil, common.NewPipelineError(i.name, "更新文档元数据failed", err)
+		}
+		doc.Metadata[MetaUnchanged] = true
+		return doc, nil
+	}
+
+	if plan == nil || plan.existing == nil {
+		// 存储文档元数据
+		if err := i.storeDocumentMetadata(ctx.Context, doc, nil); err != nil {
+			return nil, common.NewPipelineError(i.name, "存储文档元数据failed", err)
+		}
 	}
 
 	if i.einoIndexer != nil {
 		// 使用 Eino Indexer：common.Document -> []*schema.Document -> Store
 		einoDocs := make([]*schema.Document, 0, len(doc.Chunks))
+		docMeta := chunkMetadataFromDocument(doc)
 		for idx, chunk := range doc.Chunks {
 			meta := make(map[string]any)
+			for k, v := range docMeta {
+				meta[k] = v
+			}
 			meta["document_id"] = doc.ID
 			meta["content"] = chunk.Content
 			meta["index"] = strconv.Itoa(idx)
@@ -174,11 +206,23 @@ func (i *DocumentIndexer) ProcessDocument(ctx *common.PipelineContext, doc *comm
 		}
 	} else {
 		// 批量索引切片（原有 vector.Store 路径）
-		if err := i.indexChunks(doc); err != nil {
+		if err := i.indexChunks(ctx.Context, doc); err != nil {
 			return nil, common.NewPipelineError(i.name, "索引切片failed", err)
 		}
 	}
 
+	if plan != nil {
+		// 新切片写入后再切换当前版本并删除旧切片，替换过程中检索始终可命中完整的一版
+		if plan.existing != nil {
+			if err := i.storeDocumentMetadata(ctx.Context, doc, plan.existing); err != nil {
+				return nil, common.NewPipelineError(i.name, "更新文档元数据failed", err)
+			}
+		}
+		if err := i.commitVersion(ctx.Context, doc, plan); err != nil {
+			return nil, common.NewPipelineError(i.name, "记录文档版本failed", err)
+		}
+	}
+
 	// 更新文档元数据（记录实际使用的向量库类型与集合名）
 	doc.Metadata["indexed"] = true
 	doc.Metadata["indexer"] = i.name
@@ -188,8 +232,90 @@ func (i *DocumentIndexer) ProcessDocument(ctx *common.PipelineContext, doc *comm
 	return doc, nil
 }
 
-// storeDocumentMetadata 存储文档元数据
-func (i *DocumentIndexer) storeDocumentMetadata(ctx context.Context, doc *common.Document) error {
+// versionPlan 一次版本化入库的上下文
+type versionPlan struct {
+	store     metadata.VersionStore
+	sourceKey string
+	hash      string
+	existing  *metadata.Document        // 来源已对应的文档；nil 表示首次入库
+	prev      *metadata.DocumentVersion // existing 的当前版本
+	unchanged bool
+}
+
+// planVersion 元数据存储实现 VersionStore 且文档有来源键时返回版本计划（否则 nil，按旧逻辑新建文档）；
+// 来源已存在时将 doc.ID 与切片的 DocumentID 改为已有文档 ID
+func (i *DocumentIndexer) planVersion(ctx context.Context, doc *common.Document) (*versionPlan, error) {
+	vs, ok := i.metadataStore.(metadata.VersionStore)
+	if !ok {
+		return nil, nil
+	}
+	sourceKey := SourceKey(doc)
+	if sourceKey == "" {
+		return nil, nil
+	}
+	plan := &versionPlan{store: vs, sourceKey: sourceKey, hash: ContentHash(doc.Content)}
+	existing, err := vs.FindBySource(ctx, sourceKey)
+	if err != nil {
+		return nil, err
+	}
+	doc.Metadata[MetaSourceKey] = sourceKey
+	doc.Metadata[MetaContentHash] = plan.hash
+	if existing == nil {
+		return plan, nil
+	}
+	versions, err := vs.ListVersions(ctx, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	plan.existing = existing
+	plan.prev = metadata.CurrentVersion(versions)
+	doc.ID = existing.ID
+	for idx := range doc.Chunks {
+		doc.Chunks[idx].DocumentID = existing.ID
+	}
+	if plan.prev != nil && plan.prev.ContentHash == plan.hash {
+		plan.unchanged = true
+		doc.Metadata[MetaVersion] = strconv.Itoa(plan.prev.Version)
+	}
+	return plan, nil
+}
+
+// commitVersion 记录新版本为当前版本，并从向量库删除上一版本的切片
+func (i *DocumentIndexer) commitVersion(ctx context.Context, doc *common.Document, plan *versionPlan) error {
+	chunkIDs := make([]string, len(doc.Chunks))
+	for idx, chunk := range doc.Chunks {
+		chunkIDs[idx] = chunk.ID
+	}
+	version := 1
+	if plan.prev != nil {
+		version = plan.prev.Version + 1
+	}
+	size, _ := doc.Metadata["size"].(int64)
+	if err := plan.store.CommitVersion(ctx, &metadata.DocumentVersion{
+		DocumentID:  doc.ID,
+		Version:     version,
+		SourceKey:   plan.sourceKey,
+		ContentHash: plan.hash,
+		Collection:  i.defaultIndexName,
+		ChunkIDs:    chunkIDs,
+		Chunks:      len(chunkIDs),
+		Size:        size,
+	}); err != nil {
+		return err
+	}
+	doc.Metadata[MetaVersion] = strconv.Itoa(version)
+	if plan.prev == nil || i.vectorStore == nil {
+		return nil
+	}
+	indexName := plan.prev.Collection
+	if indexName == "" {
+		indexName = i.defaultIndexName
+	}
+	return vector.DeleteVectors(ctx, i.vectorStore, indexName, staleChunkIDs(plan.prev.ChunkIDs, chunkIDs))
+}
+
+// storeDocumentMetadata 存储文档元数据；existing 非空时覆盖已有记录（保留创建时间）
+func (i *DocumentIndexer) storeDocumentMetadata(ctx context.Context, doc *common.Document, existing *metadata.Document) error {
 	meta := make(map[string]string)
 	for k, v := range doc.Metadata {
 		if s, ok := v.(string); ok {
@@ -217,6 +343,13 @@ func (i *DocumentIndexer) storeDocumentMetadata(ctx context.Context, doc *common
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 	}
+	if existing != nil {
+		documentRecord.CreatedAt = existing.CreatedAt
+		if err := i.metadataStore.Update(ctx, documentRecord); err != nil {
+			return fmt.Errorf("update document record failed: %w", err)
+		}
+		return nil
+	}
 	if err := i.metadataStore.Create(ctx, documentRecord); err != nil {
 		return fmt.Errorf("create document record failed: %w", err)
 	}
@@ -224,12 +357,13 @@ func (i *DocumentIndexer) storeDocumentMetadata(ctx context.Context, doc *common
 }
 
 // indexChunks 索引切片
-func (i *DocumentIndexer) indexChunks(doc *common.Document) error {
+func (i *DocumentIndexer) indexChunks(ctx context.Context, doc *common.Document) error {
 	chunks := doc.Chunks
 	if len(chunks) == 0 {
 		return nil
 	}
 
+	docMeta := chunkMetadataFromDocument(doc)
 	// 分批处理
 	for start := 0; start < len(chunks); start += i.batchSize {
 		end := start + i.batchSize
@@ -238,7 +372,7 @@ func (i *DocumentIndexer) indexChunks(doc *common.Document) error {
 		}
 
 		batch := chunks[start:end]
-		if err := i.indexBatch(batch, doc.ID); err != nil {
+		if err := i.indexBatch(ctx, batch, doc.ID, docMeta); err != nil {
 			return fmt.Errorf("index batch failed: %w", err)
 		}
 	}
@@ -247,7 +381,7 @@ func (i *DocumentIndexer) indexChunks(doc *common.Document) error {
 }
 
 // indexBatch 索引批次（使用 vector.Store.Add）
-func (i *DocumentIndexer) indexBatch(chunks []common.Chunk, documentID string) error {
+func (i *DocumentIndexer) indexBatch(ctx context.Context, chunks []common.Chunk, documentID string, docMeta map[string]string) error {
 	if len(chunks) == 0 {
 		return nil
 	}
@@ -257,7 +391,10 @@ func (i *DocumentIndexer) indexBatch(chunks []common.Chunk, documentID string) e
 	}
 	vecs := make([]*vector.Vector, 0, len(chunks))
 	for idx, chunk := range chunks {
-		meta := make(map[string]string)
+		meta := make(map[string]string, len(docMeta)+4)
+		for k, v := range docMeta {
+			meta[k] = v
+		}
 		meta["document_id"] = documentID
 		meta["content"] = chunk.Content
 		meta["index"] = strconv.Itoa(idx)
@@ -268,7 +405,6 @@ func (i *DocumentIndexer) indexBatch(chunks []common.Chunk, documentID string) e
 			Metadata: meta,
 		})
 	}
-	ctx := context.Background()
 	if err := i.vectorStore.Add(ctx, indexName, vecs); err != nil {
 		return fmt.Errorf("index vector failed: %w", err)
 	}
@@ -294,3 +430,15 @@ func (i *DocumentIndexer) SetMetadataStore(store metadata.Store) {
 func (i *DocumentIndexer) GetMetadataStore() metadata.Store {
 	return i.metadataStore
 }
+
+// chunkMetadataFromDocument 文档级字符串元数据（含 acl_roles/acl_users）下发到每个切片，使检索可按元数据与 ACL 过滤；
+// 切片自身字段（document_id、content、index、token_count）优先
+func chunkMetadataFromDocument(doc *common.Document) map[string]string {
+	out := make(map[string]string, len(doc.Metadata))
+	for k, v := range doc.Metadata {
+		if s, ok := v.(string); ok && s != "" {
+			out[k] = s
+		}
+	}
+	return out
+}
@@ -143,6 +143,7 @@ func (s *DocumentSplitter) splitDocument(doc *common.Document) ([]common.Chunk,
 		if len(chunks) > s.maxChunks {
 			chunks = chunks[:s.maxChunks]
 		}
+		annotateChunkOffsets(content, chunks)
 		return chunks, nil
 	}
 
@@ -152,9 +153,34 @@ func (s *DocumentSplitter) splitDocument(doc *common.Document) ([]common.Chunk,
 	if len(chunks) > s.maxChunks {
 		chunks = chunks[:s.maxChunks]
 	}
+	annotateChunkOffsets(content, chunks)
 	return chunks, nil
 }
 
+// annotateChunkOffsets 为能在原文中逐字定位的切片写入 start_offset/end_offset（字节），供回答引用定位原文；
+// 切片经空白归一化等变换无法定位时不写入
+func annotateChunkOffsets(content string, chunks []common.Chunk) {
+	from := 0
+	for i := range chunks {
+		text := chunks[i].Content
+		if text == "" {
+			continue
+		}
+		idx := strings.Index(content[from:], text)
+		if idx < 0 {
+			continue
+		}
+		start := from + idx
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = make(map[string]interface{})
+		}
+		chunks[i].Metadata["start_offset"] = start
+		chunks[i].Metadata["end_offset"] = start + len(text)
+		// 相邻切片可能重叠，下一次从本片起点之后继续查找
+		from = start + 1
+	}
+}
+
 // splitByParagraph 按段落分割
 func (s *DocumentSplitter) splitByParagraph(content string) []string {
 	// 按换行符分割
@@ -0,0 +1,70 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
+	"rag-platform/internal/storage/metadata"
+)
+
+// 版本化写入文档/切片的元数据键
+const (
+	MetaSourceKey   = "source_key"
+	MetaContentHash = "content_hash"
+	MetaVersion     = "version"
+	MetaUnchanged   = "unchanged" // 内容哈希与当前版本一致，本次入库未改动向量库
+)
+
+// SourceKey 文档来源键：优先 source_uri，其次上传文件名；均为空时返回 ""（不做版本化）
+func SourceKey(doc *common.Document) string {
+	if doc == nil || doc.Metadata == nil {
+		return ""
+	}
+	if uri, _ := doc.Metadata[freshness.MetaSourceURI].(string); strings.TrimSpace(uri) != "" {
+		return metadata.URISourceKey(uri)
+	}
+	for _, key := range []string{"filename", "file_name"} {
+		if name, _ := doc.Metadata[key].(string); strings.TrimSpace(name) != "" {
+			return metadata.FileSourceKey(name)
+		}
+	}
+	return ""
+}
+
+// ContentHash 文档内容的 sha256（十六进制）
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// staleChunkIDs 返回 prev 中不在 next 里的切片 ID（新版本写入后需从向量库删除）
+func staleChunkIDs(prev, next []string) []string {
+	keep := make(map[string]struct{}, len(next))
+	for _, id := range next {
+		keep[id] = struct{}{}
+	}
+	var out []string
+	for _, id := range prev {
+		if _, ok := keep[id]; !ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/vector"
+)
+
+func versionedDoc(id, content string, chunkIDs ...string) *common.Document {
+	doc := &common.Document{
+		ID:       id,
+		Content:  content,
+		Metadata: map[string]interface{}{freshness.MetaSourceURI: "https://example.com/a"},
+	}
+	for _, cid := range chunkIDs {
+		doc.Chunks = append(doc.Chunks, common.Chunk{ID: cid, Content: content, Embedding: []float64{1, 0}, DocumentID: id})
+	}
+	return doc
+}
+
+func TestDocumentIndexer_ReingestReplacesChunks(t *testing.T) {
+	ctx := context.Background()
+	vs := vector.NewMemoryStore()
+	if err := vector.EnsureIndex(ctx, vs, "default", 2, "cosine"); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+	ms := metadata.NewMemoryStore()
+	idx := NewDocumentIndexer(vs, ms, 1, 10, "default", "memory")
+	pctx := common.NewPipelineContext(ctx, "t")
+
+	if _, err := idx.Execute(pctx, versionedDoc("doc-1", "v1", "c1", "c2")); err != nil {
+		t.Fatalf("first ingest: %v", err)
+	}
+	out, err := idx.Execute(pctx, versionedDoc("doc-2", "v2", "c3"))
+	if err != nil {
+		t.Fatalf("second ingest: %v", err)
+	}
+	if got := out.(*common.Document).ID; got != "doc-1" {
+		t.Errorf("re-ingest should keep document ID, got %s", got)
+	}
+	for _, id := range []string{"c1", "c2"} {
+		if _, err := vs.Get(ctx, "default", id); err == nil {
+			t.Errorf("stale chunk %s should be deleted", id)
+		}
+	}
+	if _, err := vs.Get(ctx, "default", "c3"); err != nil {
+		t.Errorf("new chunk missing: %v", err)
+	}
+	versions, _ := ms.ListVersions(ctx, "doc-1")
+	if len(versions) != 2 || versions[1].Version != 2 || versions[0].Status != metadata.VersionSuperseded {
+		t.Fatalf("versions: %+v", versions)
+	}
+
+	// 内容未变：不写入新切片，也不新增版本
+	out, err = idx.Execute(pctx, versionedDoc("doc-3", "v2", "c4"))
+	if err != nil {
+		t.Fatalf("unchanged ingest: %v", err)
+	}
+	if out.(*common.Document).Metadata[MetaUnchanged] != true {
+		t.Error("expected unchanged marker")
+	}
+	if _, err := vs.Get(ctx, "default", "c4"); err == nil {
+		t.Error("unchanged ingest should not index chunks")
+	}
+	if versions, _ := ms.ListVersions(ctx, "doc-1"); len(versions) != 2 {
+		t.Errorf("unchanged ingest should not add a version, got %d", len(versions))
+	}
+}
+
+func TestStaleChunkIDs(t *testing.T) {
+	got := staleChunkIDs([]string{"a", "b", "c"}, []string{"b", "d"})
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("staleChunkIDs: %v", got)
+	}
+}
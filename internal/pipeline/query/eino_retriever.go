@@ -96,9 +96,11 @@ func (m *MemoryRetriever) Retrieve(ctx context.Context, query string, opts ...ei
 	}
 	queryVector := vecs[0]
 
+	// 过滤表达式（ACL/元数据）经 ctx 传入，下推到向量检索
 	searchResults, err := m.vectorStore.Search(ctx, indexName, queryVector, &vector.SearchOptions{
 		TopK:      topK,
 		Threshold: threshold,
+		Expr:      vector.FilterFromContext(ctx),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("vector store search: %w", err)
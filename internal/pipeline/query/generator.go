@@ -15,6 +15,7 @@
 package query
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -73,7 +74,11 @@ func (g *Generator) Execute(ctx *common.PipelineContext, input interface{}) (int
 	}
 
 	// 处理生成
-	generationResult, err := g.generate(query, result)
+	genCtx := context.Background()
+	if ctx != nil && ctx.Context != nil {
+		genCtx = ctx.Context
+	}
+	generationResult, err := g.generate(genCtx, query, result)
 	if err != nil {
 		return nil, common.NewPipelineError(g.name, "生成回答failed", err)
 	}
@@ -113,20 +118,20 @@ func (g *Generator) ProcessQuery(query *common.Query) (*common.Query, error) {
 	return query, nil
 }
 
-// GenerateWithRetrieval 根据查询与检索结果生成回答（供 RAG 适配器调用）
-func (g *Generator) GenerateWithRetrieval(query *common.Query, result *common.RetrievalResult) (*common.GenerationResult, error) {
-	return g.generate(query, result)
+// GenerateWithRetrieval 根据查询与检索结果生成回答（供 RAG 适配器调用）；LLM 调用受 ctx 截止时间约束
+func (g *Generator) GenerateWithRetrieval(ctx context.Context, query *common.Query, result *common.RetrievalResult) (*common.GenerationResult, error) {
+	return g.generate(ctx, query, result)
 }
 
 // generate 生成回答
-func (g *Generator) generate(query *common.Query, result *common.RetrievalResult) (*common.GenerationResult, error) {
+func (g *Generator) generate(ctx context.Context, query *common.Query, result *common.RetrievalResult) (*common.GenerationResult, error) {
 	startTime := time.Now()
 
 	// 构建提示词
 	prompt := g.buildPrompt(query, result)
 
 	// 调用 LLM 生成回答
-	response, err := g.llmClient.Generate(prompt, llm.GenerateOptions{
+	response, err := g.llmClient.GenerateWithContext(ctx, prompt, llm.GenerateOptions{
 		Temperature:      g.temperature,
 		MaxTokens:        1024,
 		TopP:             0.9,
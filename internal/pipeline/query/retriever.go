@@ -118,6 +118,7 @@ func (r *Retriever) retrieve(query *common.Query) (*common.RetrievalResult, erro
 	opts := &vector.SearchOptions{
 		TopK:      r.topK,
 		Threshold: r.scoreThreshold,
+		Expr:      query.Filter,
 	}
 	if query.Metadata != nil {
 		opts.Filter = make(map[string]string)
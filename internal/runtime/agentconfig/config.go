@@ -0,0 +1,29 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig
+
+import (
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/secrets"
+)
+
+// NewSecretStoreFromConfig 按 secrets 配置创建 secret store；provider 为空时使用环境变量
+func NewSecretStoreFromConfig(cfg config.SecretsConfig) (secrets.Store, error) {
+	provider := cfg.Provider
+	if provider == "" {
+		provider = "env"
+	}
+	return secrets.NewStore(secrets.Config{Provider: provider, Config: cfg.Config})
+}
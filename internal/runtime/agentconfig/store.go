@@ -0,0 +1,192 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agentconfig Agent 级配置：按 Agent 管理 key/value（可引用 secret），在工具执行前解析并以
+// sdk.ConfigFromContext 注入，使同一工具按 Agent 访问不同环境；事件只记录 key，不记录 value。
+package agentconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/secrets"
+)
+
+var (
+	// ErrNotFound 配置项不存在
+	ErrNotFound = errors.New("agentconfig: not found")
+	// ErrInvalidKey key 不合法
+	ErrInvalidKey = errors.New("agentconfig: invalid key")
+)
+
+// KeyRequirePlanApproval 保留配置项：值为 true 时该 Agent 的 Job 在计划生成后挂起，计划经人工审阅通过后才开始执行
+const KeyRequirePlanApproval = "require_plan_approval"
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]{0,127}$`)
+
+// Entry 单个配置项；SecretRef 非空时 Value 不保存，执行前经 secrets.Store 按引用解析
+type Entry struct {
+	AgentID   string    `json:"agent_id"`
+	Key       string    `json:"key"`
+	Value     string    `json:"value,omitempty"`
+	SecretRef string    `json:"secret_ref,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsSecret 是否为 secret 引用
+func (e *Entry) IsSecret() bool { return e.SecretRef != "" }
+
+// ValidateKey 校验配置 key：字母或下划线开头，仅含字母数字与 _ . -，最长 128
+func ValidateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// ValidateEntry 校验保留配置项的取值（require_plan_approval 须为布尔值且不能是 secret 引用）
+func ValidateEntry(e *Entry) error {
+	if e.Key != KeyRequirePlanApproval {
+		return nil
+	}
+	if e.IsSecret() {
+		return fmt.Errorf("%s: secret_ref not allowed", e.Key)
+	}
+	if _, err := strconv.ParseBool(e.Value); err != nil {
+		return fmt.Errorf("%s: want true or false, got %q", e.Key, e.Value)
+	}
+	return nil
+}
+
+// RequirePlanApproval Agent 是否要求计划审阅；未设置为 false
+func RequirePlanApproval(ctx context.Context, store Store, agentID string) (bool, error) {
+	if store == nil || agentID == "" {
+		return false, nil
+	}
+	entries, err := store.List(ctx, agentID)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.Key == KeyRequirePlanApproval && !e.IsSecret() {
+			v, _ := strconv.ParseBool(e.Value)
+			return v, nil
+		}
+	}
+	return false, nil
+}
+
+// Store Agent 配置存储
+type Store interface {
+	// List 列出 Agent 的全部配置项，按 key 排序
+	List(ctx context.Context, agentID string) ([]*Entry, error)
+	// Set 新增或覆盖配置项
+	Set(ctx context.Context, e *Entry) error
+	// Delete 删除配置项；不存在返回 ErrNotFound
+	Delete(ctx context.Context, agentID, key string) error
+}
+
+// StoreMem 内存实现，用于单机与测试
+type StoreMem struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]*Entry
+}
+
+// NewStoreMem 创建内存 Store
+func NewStoreMem() *StoreMem {
+	return &StoreMem{entries: make(map[string]map[string]*Entry)}
+}
+
+func (s *StoreMem) List(ctx context.Context, agentID string) ([]*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Entry, 0, len(s.entries[agentID]))
+	for _, e := range s.entries[agentID] {
+		cp := *e
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func (s *StoreMem) Set(ctx context.Context, e *Entry) error {
+	if err := ValidateKey(e.Key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = time.Now().UTC()
+	}
+	if s.entries[e.AgentID] == nil {
+		s.entries[e.AgentID] = make(map[string]*Entry)
+	}
+	cp := *e
+	s.entries[e.AgentID][e.Key] = &cp
+	return nil
+}
+
+func (s *StoreMem) Delete(ctx context.Context, agentID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[agentID][key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries[agentID], key)
+	return nil
+}
+
+// Resolver 将 Agent 配置解析为明文 key/value（secret 引用经 secrets.Store 取值）；实现 executor.AgentConfigResolver
+type Resolver struct {
+	store   Store
+	secrets secrets.Store
+}
+
+// NewResolver 创建 Resolver；secretStore 为 nil 时含 secret 引用的配置解析失败
+func NewResolver(store Store, secretStore secrets.Store) *Resolver {
+	return &Resolver{store: store, secrets: secretStore}
+}
+
+// ResolveAgentConfig 返回 Agent 的全部配置；任一 secret 引用解析失败即返回错误，避免工具以不完整配置访问错误环境
+func (r *Resolver) ResolveAgentConfig(ctx context.Context, agentID string) (map[string]string, error) {
+	if r == nil || r.store == nil || agentID == "" {
+		return nil, nil
+	}
+	entries, err := r.store.List(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if !e.IsSecret() {
+			out[e.Key] = e.Value
+			continue
+		}
+		if r.secrets == nil {
+			return nil, fmt.Errorf("agent config %s: secret store not configured", e.Key)
+		}
+		v, err := r.secrets.Get(ctx, e.SecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("agent config %s: resolve secret %s: %w", e.Key, e.SecretRef, err)
+		}
+		out[e.Key] = v
+	}
+	return out, nil
+}
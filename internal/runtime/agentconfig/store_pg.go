@@ -0,0 +1,75 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StorePg PostgreSQL 实现，使用 agent_config 表（API 写、Worker 读）
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的 Agent 配置存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+func (s *StorePg) List(ctx context.Context, agentID string) ([]*Entry, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT agent_id, key, value, secret_ref, updated_at FROM agent_config WHERE agent_id = $1 ORDER BY key`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]*Entry, 0)
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.AgentID, &e.Key, &e.Value, &e.SecretRef, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
+
+func (s *StorePg) Set(ctx context.Context, e *Entry) error {
+	if err := ValidateKey(e.Key); err != nil {
+		return err
+	}
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = time.Now().UTC()
+	}
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO agent_config (agent_id, key, value, secret_ref, updated_at) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (agent_id, key) DO UPDATE SET value = EXCLUDED.value, secret_ref = EXCLUDED.secret_ref, updated_at = EXCLUDED.updated_at`,
+		e.AgentID, e.Key, e.Value, e.SecretRef, e.UpdatedAt)
+	return err
+}
+
+func (s *StorePg) Delete(ctx context.Context, agentID, key string) error {
+	cmd, err := s.pool.Exec(ctx, `DELETE FROM agent_config WHERE agent_id = $1 AND key = $2`, agentID, key)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"rag-platform/pkg/secrets"
+)
+
+func TestStoreMem_SetListDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	if err := s.Set(ctx, &Entry{AgentID: "a1", Key: "endpoint", Value: "https://prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, &Entry{AgentID: "a1", Key: "api_key", SecretRef: "PROD_API_KEY"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, &Entry{AgentID: "a1", Key: "bad key"}); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("invalid key err = %v", err)
+	}
+	list, _ := s.List(ctx, "a1")
+	if len(list) != 2 || list[0].Key != "api_key" || !list[0].IsSecret() || list[1].Value != "https://prod" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	if other, _ := s.List(ctx, "a2"); len(other) != 0 {
+		t.Fatalf("config leaked across agents: %+v", other)
+	}
+	if err := s.Delete(ctx, "a1", "endpoint"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a1", "endpoint"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second delete err = %v", err)
+	}
+}
+
+func TestResolver_ResolvesSecretRefs(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	_ = s.Set(ctx, &Entry{AgentID: "a1", Key: "endpoint", Value: "https://staging"})
+	_ = s.Set(ctx, &Entry{AgentID: "a1", Key: "api_key", SecretRef: "STAGING_KEY"})
+	sec := secrets.NewMemoryStore()
+	_ = sec.Set(ctx, "STAGING_KEY", "k-123")
+
+	values, err := NewResolver(s, sec).ResolveAgentConfig(ctx, "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["endpoint"] != "https://staging" || values["api_key"] != "k-123" {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	_ = s.Set(ctx, &Entry{AgentID: "a1", Key: "missing", SecretRef: "NOPE"})
+	if _, err := NewResolver(s, sec).ResolveAgentConfig(ctx, "a1"); err == nil {
+		t.Fatal("expected error for unresolvable secret")
+	}
+	if _, err := NewResolver(s, nil).ResolveAgentConfig(ctx, "a1"); err == nil {
+		t.Fatal("expected error without secret store")
+	}
+}
+
+func TestRequirePlanApproval(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreMem()
+	if on, err := RequirePlanApproval(ctx, s, "a1"); err != nil || on {
+		t.Fatalf("unset = %v, %v; want false", on, err)
+	}
+	if err := ValidateEntry(&Entry{AgentID: "a1", Key: KeyRequirePlanApproval, Value: "yes please"}); err == nil {
+		t.Fatal("non-bool value should be rejected")
+	}
+	if err := ValidateEntry(&Entry{AgentID: "a1", Key: KeyRequirePlanApproval, SecretRef: "X"}); err == nil {
+		t.Fatal("secret_ref should be rejected")
+	}
+	e := &Entry{AgentID: "a1", Key: KeyRequirePlanApproval, Value: "true"}
+	if err := ValidateEntry(e); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Set(ctx, e)
+	if on, _ := RequirePlanApproval(ctx, s, "a1"); !on {
+		t.Fatal("want plan approval required")
+	}
+	if on, _ := RequirePlanApproval(ctx, s, "a2"); on {
+		t.Fatal("setting leaked across agents")
+	}
+}
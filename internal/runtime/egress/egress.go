@@ -0,0 +1,330 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package egress 按租户限制工具执行中的出网目标（域名 / CIDR 白名单），经注入的 HTTP Transport 在拨号时生效
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/log"
+)
+
+// ReasonPolicyDenied 违规时步骤失败原因的前缀
+const ReasonPolicyDenied = "policy_denied"
+
+// ErrInvalidRule 白名单条目既不是域名也不是 IP / CIDR
+var ErrInvalidRule = errors.New("egress: invalid allow rule")
+
+// DeniedError 目标不在租户出网白名单内
+type DeniedError struct {
+	Tenant string
+	JobID  string
+	Tool   string
+	Host   string // 拨号目标 host:port
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s: egress to %s is not allowed for tenant %s", ReasonPolicyDenied, e.Host, e.Tenant)
+}
+
+// IsPolicyDenied 判断错误是否由出网策略拒绝导致
+func IsPolicyDenied(err error) bool {
+	var e *DeniedError
+	return errors.As(err, &e)
+}
+
+// Policy 单个租户的出网白名单；空白名单即 deny-all
+type Policy struct {
+	tenant  string
+	exact   map[string]struct{}
+	suffix  []string // "*.example.com" -> ".example.com"
+	nets    []netip.Prefix
+	logger  *log.Logger
+	resolve func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+// NewPolicy 解析白名单：域名（精确）、*.域名（仅子域名）、IP 或 CIDR
+func NewPolicy(tenant string, allow []string) (*Policy, error) {
+	p := &Policy{tenant: tenant, exact: make(map[string]struct{})}
+	for _, raw := range allow {
+		rule := strings.ToLower(strings.TrimSpace(raw))
+		switch {
+		case rule == "":
+			continue
+		case strings.Contains(rule, "/"):
+			prefix, err := netip.ParsePrefix(rule)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRule, raw)
+			}
+			p.nets = append(p.nets, prefix.Masked())
+		case strings.HasPrefix(rule, "*."):
+			if !validDomain(rule[2:]) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRule, raw)
+			}
+			p.suffix = append(p.suffix, rule[1:])
+		default:
+			if addr, err := netip.ParseAddr(rule); err == nil {
+				p.nets = append(p.nets, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+				continue
+			}
+			if !validDomain(rule) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRule, raw)
+			}
+			p.exact[strings.TrimSuffix(rule, ".")] = struct{}{}
+		}
+	}
+	return p, nil
+}
+
+func validDomain(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || strings.ContainsAny(s, " *:/@") {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Tenant 策略所属租户
+func (p *Policy) Tenant() string { return p.tenant }
+
+// AllowsHost 域名命中精确或通配规则
+func (p *Policy) AllowsHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if _, ok := p.exact[host]; ok {
+		return true
+	}
+	for _, s := range p.suffix {
+		if strings.HasSuffix(host, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAddr IP 落在 CIDR 规则内
+func (p *Policy) AllowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, n := range p.nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantPolicy 配置中单个租户的策略
+type TenantPolicy struct {
+	Trusted bool     // 可信租户不受限制
+	Allow   []string // 非可信租户的白名单
+}
+
+// Policies 按租户解析出网策略：可信租户不受限；其余租户（含未配置的）仅允许各自白名单，未配置的使用默认白名单（默认为空，即 deny-all）
+type Policies struct {
+	tenants      map[string]*Policy
+	trusted      map[string]struct{}
+	defaultAllow []string
+	logger       *log.Logger
+}
+
+// NewPolicies 校验并解析全部白名单
+func NewPolicies(defaultAllow []string, tenants map[string]TenantPolicy, logger *log.Logger) (*Policies, error) {
+	ps := &Policies{tenants: make(map[string]*Policy), trusted: make(map[string]struct{}), defaultAllow: defaultAllow, logger: logger}
+	if _, err := NewPolicy("", defaultAllow); err != nil {
+		return nil, err
+	}
+	for tenant, tp := range tenants {
+		if tp.Trusted {
+			ps.trusted[tenant] = struct{}{}
+			continue
+		}
+		p, err := NewPolicy(tenant, tp.Allow)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		p.logger = logger
+		ps.tenants[tenant] = p
+	}
+	return ps, nil
+}
+
+// For 返回租户的策略；可信租户返回 nil（不受限）
+func (ps *Policies) For(tenant string) *Policy {
+	if ps == nil {
+		return nil
+	}
+	if _, ok := ps.trusted[tenant]; ok {
+		return nil
+	}
+	if p, ok := ps.tenants[tenant]; ok {
+		return p
+	}
+	p, _ := NewPolicy(tenant, ps.defaultAllow)
+	p.logger = ps.logger
+	return p
+}
+
+// scope 单次工具调用的策略与首个违规记录
+type scope struct {
+	policy *Policy
+	jobID  string
+	tool   string
+
+	mu     sync.Mutex
+	denied *DeniedError
+}
+
+type scopeKey struct{}
+
+// WithPolicy 为一次工具调用注入策略；p 为 nil 时不受限。经 Transport 发出的请求在拨号时校验
+func WithPolicy(ctx context.Context, p *Policy, jobID, toolName string) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, scopeKey{}, &scope{policy: p, jobID: jobID, tool: toolName})
+}
+
+// PolicyFromContext 取出当前调用的策略；未注入时返回 nil
+func PolicyFromContext(ctx context.Context) *Policy {
+	if sc := scopeFrom(ctx); sc != nil {
+		return sc.policy
+	}
+	return nil
+}
+
+// Denied 返回本次调用的首个违规；工具吞掉拨号错误时执行器据此仍将步骤判为 policy_denied
+func Denied(ctx context.Context) *DeniedError {
+	sc := scopeFrom(ctx)
+	if sc == nil {
+		return nil
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.denied
+}
+
+func scopeFrom(ctx context.Context) *scope {
+	if ctx == nil {
+		return nil
+	}
+	sc, _ := ctx.Value(scopeKey{}).(*scope)
+	return sc
+}
+
+func (sc *scope) deny(addr string) error {
+	err := &DeniedError{Tenant: sc.policy.tenant, JobID: sc.jobID, Tool: sc.tool, Host: addr}
+	sc.mu.Lock()
+	if sc.denied == nil {
+		sc.denied = err
+	}
+	sc.mu.Unlock()
+	if sc.policy.logger != nil {
+		sc.policy.logger.Warn("egress denied", "tenant", err.Tenant, "job_id", err.JobID, "tool", err.Tool, "host", err.Host)
+	}
+	return err
+}
+
+// DialFunc 与 net.Dialer.DialContext 签名一致
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// GuardDial 包装 dial：ctx 中有策略时，域名须命中白名单，或解析出的 IP 落在 CIDR 规则内（此时直接拨该 IP，防 DNS 重绑定）
+func GuardDial(dial DialFunc) DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		sc := scopeFrom(ctx)
+		if sc == nil {
+			return dial(ctx, network, addr)
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if ip, err := netip.ParseAddr(host); err == nil {
+			if sc.policy.AllowsAddr(ip) {
+				return dial(ctx, network, addr)
+			}
+			return nil, sc.deny(addr)
+		}
+		if sc.policy.AllowsHost(host) {
+			return dial(ctx, network, addr)
+		}
+		if len(sc.policy.nets) == 0 {
+			return nil, sc.deny(addr)
+		}
+		resolve := sc.policy.resolve
+		if resolve == nil {
+			resolve = lookupHost
+		}
+		ips, err := resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if sc.policy.AllowsAddr(ip) {
+				return dial(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+			}
+		}
+		return nil, sc.deny(addr)
+	}
+}
+
+func lookupHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// Transport 返回受策略约束的 RoundTripper（base 为 nil 时克隆 http.DefaultTransport）。受限调用走独立的 Transport：
+// 不复用连接（连接池中的连接可能由其他租户建立，复用会绕过拨号校验），也不走环境代理（否则拨号目标是代理而非真实目的地）
+func Transport(base *http.Transport) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	restricted := base.Clone()
+	dial := restricted.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	restricted.DialContext = GuardDial(dial)
+	restricted.Proxy = nil
+	restricted.DisableKeepAlives = true
+	return &guardedTransport{open: base.Clone(), restricted: restricted}
+}
+
+type guardedTransport struct {
+	open       *http.Transport
+	restricted *http.Transport
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if scopeFrom(req.Context()) != nil {
+		return t.restricted.RoundTrip(req)
+	}
+	return t.open.RoundTrip(req)
+}
+
+// NewClient 创建受策略约束的 HTTP 客户端，供执行器经 sdk.WithHTTPClient 注入自定义工具
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport(nil)}
+}
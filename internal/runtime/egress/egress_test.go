@@ -0,0 +1,121 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestNewPolicy_Rules(t *testing.T) {
+	p, err := NewPolicy("acme", []string{"api.acme.com", "*.stripe.com", "10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, want := range map[string]bool{
+		"api.acme.com": true, "API.acme.com.": true, "www.acme.com": false,
+		"a.stripe.com": true, "stripe.com": false, "evilstripe.com": false,
+	} {
+		if got := p.AllowsHost(host); got != want {
+			t.Errorf("AllowsHost(%s)=%v, want %v", host, got, want)
+		}
+	}
+	for addr, want := range map[string]bool{"10.2.3.4": true, "11.0.0.1": false, "192.168.1.5": true, "::ffff:10.0.0.1": true} {
+		if got := p.AllowsAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("AllowsAddr(%s)=%v, want %v", addr, got, want)
+		}
+	}
+	for _, bad := range []string{"10.0.0.0/33", "*.", "http://x.com", "a..b"} {
+		if _, err := NewPolicy("t", []string{bad}); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("rule %q: err=%v, want ErrInvalidRule", bad, err)
+		}
+	}
+}
+
+func TestPolicies_For(t *testing.T) {
+	ps, err := NewPolicies([]string{"*.example.com"}, map[string]TenantPolicy{
+		"internal": {Trusted: true},
+		"acme":     {Allow: []string{"api.acme.com"}},
+		"locked":   {},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.For("internal") != nil {
+		t.Error("trusted tenant should be unrestricted")
+	}
+	if p := ps.For("acme"); p == nil || !p.AllowsHost("api.acme.com") || p.AllowsHost("a.example.com") {
+		t.Error("acme should use its own allowlist only")
+	}
+	if p := ps.For("locked"); p == nil || p.AllowsHost("a.example.com") {
+		t.Error("configured tenant with empty allowlist should deny all")
+	}
+	if p := ps.For("unknown"); p == nil || p.Tenant() != "unknown" || !p.AllowsHost("a.example.com") {
+		t.Error("unknown tenant should use the default allowlist")
+	}
+	if _, err := NewPolicies(nil, map[string]TenantPolicy{"x": {Allow: []string{"bad/rule"}}}, nil); err == nil {
+		t.Error("expected error for invalid tenant rule")
+	}
+}
+
+func TestTransport_EnforcesPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: Transport(nil)}
+	get := func(ctx context.Context, target string) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// 不受限调用先建立连接；受限调用不得复用
+	if err := get(context.Background(), srv.URL); err != nil {
+		t.Fatalf("unrestricted: %v", err)
+	}
+	deny, _ := NewPolicy("t1", []string{"api.acme.com"})
+	ctx := WithPolicy(context.Background(), deny, "job-1", "http.request")
+	err := get(ctx, srv.URL)
+	if !IsPolicyDenied(err) {
+		t.Fatalf("err=%v, want policy denied", err)
+	}
+	d := Denied(ctx)
+	if d == nil || d.Tenant != "t1" || d.JobID != "job-1" || d.Host != u.Host {
+		t.Fatalf("Denied=%+v", d)
+	}
+
+	allowCIDR, _ := NewPolicy("t2", []string{"127.0.0.0/8"})
+	ctx = WithPolicy(context.Background(), allowCIDR, "job-2", "http.request")
+	if err := get(ctx, srv.URL); err != nil || Denied(ctx) != nil {
+		t.Fatalf("CIDR allowed: err=%v denied=%v", err, Denied(ctx))
+	}
+
+	// 域名未命中时解析后按 CIDR 判定
+	allowCIDR.resolve = func(context.Context, string) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("127.0.0.1")}, nil
+	}
+	ctx = WithPolicy(context.Background(), allowCIDR, "job-3", "http.request")
+	if err := get(ctx, "http://internal.test:"+u.Port()); err != nil {
+		t.Fatalf("resolved into CIDR: %v", err)
+	}
+}
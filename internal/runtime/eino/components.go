@@ -22,6 +22,7 @@ type Chunk struct {
 	Content    string                 `json:"content"`
 	DocumentID string                 `json:"document_id"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Score      float64                `json:"score,omitempty"` // 检索相似度；检索器未提供时为 0
 }
 
 // Retriever 检索器（供 qa_agent 工具调用）
@@ -22,9 +22,11 @@ import (
 
 	"rag-platform/internal/model/embedding"
 	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/freshness"
 	"rag-platform/internal/pipeline/ingest"
 	"rag-platform/internal/pipeline/query"
 	"rag-platform/pkg/log"
+	"rag-platform/pkg/metrics"
 )
 
 // ingestWorkflowExecutor 执行 ingest 工作流：loader → parser → splitter → [embedding] → [indexer]
@@ -35,11 +37,23 @@ type ingestWorkflowExecutor struct {
 	embedding *ingest.DocumentEmbedding
 	indexer   *ingest.DocumentIndexer
 	logger    *log.Logger
+	// dedup 非 nil 时在切分前检查重复文档，重复则不再切分、向量化与入库
+	dedup *ingest.Deduplicator
+}
+
+// IngestWorkflowOption ingest 工作流可选配置
+type IngestWorkflowOption func(*ingestWorkflowExecutor)
+
+// WithIngestDedup 启用入库去重：重复文档按集合策略跳过或并入已有文档，结果的 dedup 字段说明命中的文档
+func WithIngestDedup(d *ingest.Deduplicator) IngestWorkflowOption {
+	return func(e *ingestWorkflowExecutor) {
+		e.dedup = d
+	}
 }
 
 // NewIngestWorkflowExecutor 创建可执行的 ingest 工作流（由 app 装配后注册到 Engine）
-func NewIngestWorkflowExecutor(loader *ingest.DocumentLoader, parser *ingest.DocumentParser, splitter *ingest.DocumentSplitter, embedding *ingest.DocumentEmbedding, indexer *ingest.DocumentIndexer, logger *log.Logger) WorkflowExecutor {
-	return &ingestWorkflowExecutor{
+func NewIngestWorkflowExecutor(loader *ingest.DocumentLoader, parser *ingest.DocumentParser, splitter *ingest.DocumentSplitter, embedding *ingest.DocumentEmbedding, indexer *ingest.DocumentIndexer, logger *log.Logger, opts ...IngestWorkflowOption) WorkflowExecutor {
+	e := &ingestWorkflowExecutor{
 		loader:    loader,
 		parser:    parser,
 		splitter:  splitter,
@@ -47,12 +61,20 @@ func NewIngestWorkflowExecutor(loader *ingest.DocumentLoader, parser *ingest.Doc
 		indexer:   indexer,
 		logger:    logger,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // Execute 实现 WorkflowExecutor
-// 请求 context 已带 HTTP 层 span，可在此处用 otel trace.SpanFromContext(ctx) 为 loader/parser/splitter/embedding/indexer 创建子 span 以细化链路。
+// 请求 context 已带 HTTP 层 span，可在此处用 otel trace.SpanFromContext(ctx) 为 load/parse/split/embed/index 创建子 span 以细化链路。
+// params["task_id"] 非空时（异步入库任务）作为 ingest_id，使日志与任务 ID 关联；阶段failed返回 *common.StageError。
 func (e *ingestWorkflowExecutor) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
-	ingestID := fmt.Sprintf("ingest-%d", time.Now().UnixNano())
+	ingestID, _ := params["task_id"].(string)
+	if ingestID == "" {
+		ingestID = fmt.Sprintf("ingest-%d", time.Now().UnixNano())
+	}
 	if e.logger != nil {
 		e.logger.Info("ingest_pipeline 开始", "ingest_id", ingestID)
 	}
@@ -77,107 +99,81 @@ func (e *ingestWorkflowExecutor) Execute(ctx context.Context, params map[string]
 		e.splitter = ingest.NewDocumentSplitter(1000, 100, 1000)
 	}
 
-	// loader
-	if e.logger != nil {
-		e.logger.Info("ingest 阶段开始", "ingest_id", ingestID, "ingest_step", "loader")
-	}
-	loaderStart := time.Now()
-	out, err := e.loader.Execute(pipeCtx, loaderInput)
+	run := &ingestRun{logger: e.logger, ingestID: ingestID}
+	var doc *common.Document
+	err := run.stage(common.StageLoad, func() (err error) {
+		doc, err = documentOut(e.loader.Execute(pipeCtx, loaderInput))
+		return err
+	})
 	if err != nil {
-		if e.logger != nil {
-			e.logger.Error("ingest 阶段failed", "ingest_id", ingestID, "ingest_step", "loader", "error", err)
-		}
-		return nil, fmt.Errorf("ingest loader: %w", err)
+		return nil, err
 	}
-	doc, ok := out.(*common.Document)
-	if !ok {
-		return nil, fmt.Errorf("ingest loader did not return *common.Document")
-	}
-	if e.logger != nil {
-		e.logger.Info("ingest 阶段完成", "ingest_id", ingestID, "ingest_step", "loader", "doc_id", doc.ID, "chunks", len(doc.Chunks), "duration_ms", time.Since(loaderStart).Milliseconds())
-	}
-
-	// parser
-	if e.logger != nil {
-		e.logger.Info("ingest 阶段开始", "ingest_id", ingestID, "ingest_step", "parser")
-	}
-	parserStart := time.Now()
-	out, err = e.parser.Execute(pipeCtx, doc)
-	if err != nil {
-		if e.logger != nil {
-			e.logger.Error("ingest 阶段failed", "ingest_id", ingestID, "ingest_step", "parser", "doc_id", doc.ID, "error", err)
+	// 请求方元数据（含文档 ACL）合并进文档，由 indexer 写入每个切片供检索过滤
+	if meta, ok := params["metadata"].(map[string]interface{}); ok {
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]interface{})
+		}
+		for k, v := range meta {
+			doc.Metadata[k] = v
 		}
-		return nil, fmt.Errorf("ingest parser: %w", err)
-	}
-	doc, ok = out.(*common.Document)
-	if !ok {
-		return nil, fmt.Errorf("ingest parser did not return *common.Document")
-	}
-	if e.logger != nil {
-		e.logger.Info("ingest 阶段完成", "ingest_id", ingestID, "ingest_step", "parser", "doc_id", doc.ID, "chunks", len(doc.Chunks), "duration_ms", time.Since(parserStart).Milliseconds())
 	}
 
-	// splitter
-	if e.logger != nil {
-		e.logger.Info("ingest 阶段开始", "ingest_id", ingestID, "ingest_step", "splitter")
+	if err := run.stage(common.StageParse, func() (err error) {
+		doc, err = documentOut(e.parser.Execute(pipeCtx, doc))
+		return err
+	}); err != nil {
+		return nil, err
 	}
-	splitterStart := time.Now()
-	out, err = e.splitter.Execute(pipeCtx, doc)
-	if err != nil {
-		if e.logger != nil {
-			e.logger.Error("ingest 阶段failed", "ingest_id", ingestID, "ingest_step", "splitter", "doc_id", doc.ID, "error", err)
+
+	// 去重（可选）：重复文档不再切分、向量化与入库
+	if e.dedup != nil {
+		var dup *ingest.DedupResult
+		if err := run.stage(common.StageDedup, func() (err error) {
+			dup, err = e.dedup.Check(ctx, doc)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		if dup != nil {
+			if e.logger != nil {
+				e.logger.Info("ingest_pipeline 重复文档", "ingest_id", ingestID, "result", dup.Result, "match", dup.Match, "duplicate_of", dup.DuplicateOf, "similarity", dup.Similarity)
+			}
+			return map[string]interface{}{
+				"status":    dup.Result,
+				"doc_id":    dup.DuplicateOf,
+				"chunks":    0,
+				"dedup":     dup,
+				"metadata":  params["metadata"],
+				"ingest_id": ingestID,
+				"stages":    run.timings,
+			}, nil
 		}
-		return nil, fmt.Errorf("ingest splitter: %w", err)
-	}
-	doc, ok = out.(*common.Document)
-	if !ok {
-		return nil, fmt.Errorf("ingest splitter did not return *common.Document")
 	}
-	if e.logger != nil {
-		e.logger.Info("ingest 阶段完成", "ingest_id", ingestID, "ingest_step", "splitter", "doc_id", doc.ID, "chunks", len(doc.Chunks), "duration_ms", time.Since(splitterStart).Milliseconds())
+
+	if err := run.stage(common.StageSplit, func() (err error) {
+		doc, err = documentOut(e.splitter.Execute(pipeCtx, doc))
+		return err
+	}); err != nil {
+		return nil, err
 	}
 
 	// embedding（可选）
 	if e.embedding != nil {
-		if e.logger != nil {
-			e.logger.Info("ingest 阶段开始", "ingest_id", ingestID, "ingest_step", "embedding")
-		}
-		embedStart := time.Now()
-		out, err = e.embedding.Execute(pipeCtx, doc)
-		if err != nil {
-			if e.logger != nil {
-				e.logger.Error("ingest 阶段failed", "ingest_id", ingestID, "ingest_step", "embedding", "doc_id", doc.ID, "error", err)
-			}
-			return nil, fmt.Errorf("ingest embedding: %w", err)
-		}
-		doc, ok = out.(*common.Document)
-		if !ok {
-			return nil, fmt.Errorf("ingest embedding did not return *common.Document")
-		}
-		if e.logger != nil {
-			e.logger.Info("ingest 阶段完成", "ingest_id", ingestID, "ingest_step", "embedding", "doc_id", doc.ID, "chunks", len(doc.Chunks), "duration_ms", time.Since(embedStart).Milliseconds())
+		if err := run.stage(common.StageEmbed, func() (err error) {
+			doc, err = documentOut(e.embedding.Execute(pipeCtx, doc))
+			return err
+		}); err != nil {
+			return nil, err
 		}
 	}
 
 	// indexer（可选）
 	if e.indexer != nil {
-		if e.logger != nil {
-			e.logger.Info("ingest 阶段开始", "ingest_id", ingestID, "ingest_step", "indexer")
-		}
-		indexerStart := time.Now()
-		out, err = e.indexer.Execute(pipeCtx, doc)
-		if err != nil {
-			if e.logger != nil {
-				e.logger.Error("ingest 阶段failed", "ingest_id", ingestID, "ingest_step", "indexer", "doc_id", doc.ID, "error", err)
-			}
-			return nil, fmt.Errorf("ingest indexer: %w", err)
-		}
-		doc, ok = out.(*common.Document)
-		if !ok {
-			return nil, fmt.Errorf("ingest indexer did not return *common.Document")
-		}
-		if e.logger != nil {
-			e.logger.Info("ingest 阶段完成", "ingest_id", ingestID, "ingest_step", "indexer", "doc_id", doc.ID, "chunks", len(doc.Chunks), "duration_ms", time.Since(indexerStart).Milliseconds())
+		if err := run.stage(common.StageIndex, func() (err error) {
+			doc, err = documentOut(e.indexer.Execute(pipeCtx, doc))
+			return err
+		}); err != nil {
+			return nil, err
 		}
 	}
 
@@ -185,13 +181,58 @@ func (e *ingestWorkflowExecutor) Execute(ctx context.Context, params map[string]
 		e.logger.Info("ingest_pipeline 完成", "ingest_id", ingestID, "doc_id", doc.ID, "chunks", len(doc.Chunks))
 	}
 	return map[string]interface{}{
-		"status":   "success",
-		"doc_id":   doc.ID,
-		"chunks":   len(doc.Chunks),
-		"metadata": params["metadata"],
+		"status":    "success",
+		"doc_id":    doc.ID,
+		"chunks":    len(doc.Chunks),
+		"metadata":  params["metadata"],
+		"ingest_id": ingestID,
+		"stages":    run.timings,
 	}, nil
 }
 
+// ingestRun 一次入库执行的阶段记录：逐阶段计时、上报指标并将failed包装为 *common.StageError
+type ingestRun struct {
+	logger   *log.Logger
+	ingestID string
+	timings  []common.StageTiming
+}
+
+func (r *ingestRun) stage(name string, fn func() error) error {
+	if r.logger != nil {
+		r.logger.Info("ingest 阶段开始", "ingest_id", r.ingestID, "ingest_step", name)
+	}
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+	r.timings = append(r.timings, common.StageTiming{Stage: name, DurationMs: elapsed.Milliseconds(), Error: err != nil})
+	if err != nil {
+		class := common.ClassifyError(err)
+		metrics.IngestStageDurationSeconds.WithLabelValues(name, "error").Observe(elapsed.Seconds())
+		metrics.IngestStageFailuresTotal.WithLabelValues(name, class).Inc()
+		if r.logger != nil {
+			r.logger.Error("ingest 阶段failed", "ingest_id", r.ingestID, "ingest_step", name, "error_class", class, "duration_ms", elapsed.Milliseconds(), "error", err)
+		}
+		return &common.StageError{Stage: name, Class: class, Stages: append([]common.StageTiming(nil), r.timings...), Err: err}
+	}
+	metrics.IngestStageDurationSeconds.WithLabelValues(name, "ok").Observe(elapsed.Seconds())
+	if r.logger != nil {
+		r.logger.Info("ingest 阶段完成", "ingest_id", r.ingestID, "ingest_step", name, "duration_ms", elapsed.Milliseconds())
+	}
+	return nil
+}
+
+// documentOut 校验阶段输出为 *common.Document
+func documentOut(out interface{}, err error) (*common.Document, error) {
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := out.(*common.Document)
+	if !ok {
+		return nil, fmt.Errorf("stage did not return *common.Document, got %T", out)
+	}
+	return doc, nil
+}
+
 // QueryRetrieverForWorkflow 供 query 工作流使用的检索器（*query.Retriever 或 Eino Retriever 适配器均实现此接口）
 type QueryRetrieverForWorkflow interface {
 	SetTopK(topK int)
@@ -204,16 +245,34 @@ type queryWorkflowExecutor struct {
 	generator     *query.Generator
 	queryEmbedder *embedding.Embedder
 	logger        *log.Logger
+	// freshnessPolicies 非 nil 时回答附带 freshness_warnings（引用的文档超出集合新鲜度阈值）
+	freshnessPolicies *freshness.Policies
+	defaultCollection string
+}
+
+// QueryWorkflowOption query 工作流可选配置
+type QueryWorkflowOption func(*queryWorkflowExecutor)
+
+// WithFreshnessWarnings 启用回答过期提示：检索片段所属文档源时间超出集合 MaxAge 时在结果中返回 freshness_warnings
+func WithFreshnessWarnings(policies freshness.Policies, defaultCollection string) QueryWorkflowOption {
+	return func(e *queryWorkflowExecutor) {
+		e.freshnessPolicies = &policies
+		e.defaultCollection = defaultCollection
+	}
 }
 
 // NewQueryWorkflowExecutor 创建可执行的 query 工作流（由 app 装配后注册到 Engine）
-func NewQueryWorkflowExecutor(retriever QueryRetrieverForWorkflow, generator *query.Generator, queryEmbedder *embedding.Embedder, logger *log.Logger) WorkflowExecutor {
-	return &queryWorkflowExecutor{
+func NewQueryWorkflowExecutor(retriever QueryRetrieverForWorkflow, generator *query.Generator, queryEmbedder *embedding.Embedder, logger *log.Logger, opts ...QueryWorkflowOption) WorkflowExecutor {
+	e := &queryWorkflowExecutor{
 		retriever:     retriever,
 		generator:     generator,
 		queryEmbedder: queryEmbedder,
 		logger:        logger,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // Execute 实现 WorkflowExecutor：embed query（若需）→ retriever → generator
@@ -291,11 +350,17 @@ func (e *queryWorkflowExecutor) Execute(ctx context.Context, params map[string]i
 		return nil, fmt.Errorf("generator did not return *common.GenerationResult")
 	}
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"status":          "success",
 		"query_id":        q.ID,
 		"answer":          genResult.Answer,
 		"references":      genResult.References,
 		"process_time_ms": genResult.ProcessTime.Milliseconds(),
-	}, nil
+	}
+	if e.freshnessPolicies != nil {
+		if warnings := freshness.Warnings(retrievalResult.Chunks, *e.freshnessPolicies, e.defaultCollection, time.Now()); len(warnings) > 0 {
+			result["freshness_warnings"] = warnings
+		}
+	}
+	return result, nil
 }
@@ -0,0 +1,69 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eino
+
+import (
+	"context"
+	"testing"
+
+	"rag-platform/internal/pipeline/common"
+	"rag-platform/internal/pipeline/ingest"
+)
+
+func TestIngestWorkflow_StageTimings(t *testing.T) {
+	exec := NewIngestWorkflowExecutor(nil, nil, nil, nil, nil, nil)
+	out, err := exec.Execute(context.Background(), map[string]interface{}{
+		"content": []byte("hello ingest pipeline"),
+		"task_id": "task-1",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	res := out.(map[string]interface{})
+	if res["ingest_id"] != "task-1" {
+		t.Errorf("ingest_id = %v, want task-1", res["ingest_id"])
+	}
+	stages, _ := res["stages"].([]common.StageTiming)
+	var names []string
+	for _, s := range stages {
+		names = append(names, s.Stage)
+	}
+	want := []string{common.StageLoad, common.StageParse, common.StageSplit}
+	if len(names) != len(want) {
+		t.Fatalf("stages = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("stages = %v, want %v", names, want)
+		}
+	}
+}
+
+func TestIngestWorkflow_StageError(t *testing.T) {
+	indexer := ingest.NewDocumentIndexer(nil, nil, 1, 1, "", "")
+	exec := NewIngestWorkflowExecutor(nil, nil, nil, nil, indexer, nil)
+	_, err := exec.Execute(context.Background(), map[string]interface{}{"content": []byte("hello")})
+	se, ok := common.GetStageError(err)
+	if !ok {
+		t.Fatalf("want *common.StageError, got %v", err)
+	}
+	if se.Stage != common.StageIndex || se.Class == "" {
+		t.Errorf("stage=%q class=%q", se.Stage, se.Class)
+	}
+	last := se.Stages[len(se.Stages)-1]
+	if len(se.Stages) != 4 || last.Stage != common.StageIndex || !last.Error {
+		t.Errorf("stages = %+v", se.Stages)
+	}
+}
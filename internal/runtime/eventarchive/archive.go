@@ -0,0 +1,258 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventarchive Job 事件冷存储归档：事件按分段写为 JSONL 对象，manifest 记录 Job 元数据与各分段校验和；
+// 热存储中的事件或 Job 被清理后，trace/replay/events 接口经 Reader 从对象存储冷读
+package eventarchive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+)
+
+// DefaultPrefix 对象键默认前缀
+const DefaultPrefix = "event-archive/"
+
+// DefaultSegmentSize 单个分段的默认事件数
+const DefaultSegmentSize = 1000
+
+// manifestName 每个 Job 归档目录下的 manifest 对象名；最后写入，存在即表示归档完整
+const manifestName = "manifest.json"
+
+var (
+	// ErrNotArchived Job 无归档（manifest 不存在）
+	ErrNotArchived = errors.New("eventarchive: job not archived")
+	// ErrSegmentCorrupt 分段内容与 manifest 中的校验和或事件数不一致
+	ErrSegmentCorrupt = errors.New("eventarchive: segment checksum mismatch")
+)
+
+// Segment 一个事件分段对象：Events[FirstIndex : FirstIndex+Count]
+type Segment struct {
+	Key        string `json:"key"`
+	FirstIndex int    `json:"first_index"`
+	Count      int    `json:"count"`
+	Bytes      int64  `json:"bytes"`
+	SHA256     string `json:"sha256"`
+}
+
+// Manifest 归档清单：Job 元数据快照与事件分段
+type Manifest struct {
+	JobID       string                `json:"job_id"`
+	TenantID    string                `json:"tenant_id"`
+	AgentID     string                `json:"agent_id"`
+	Goal        string                `json:"goal"`
+	Status      job.JobStatus         `json:"status"`
+	StatusName  string                `json:"status_name"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	Terminal    *job.TerminalInfo     `json:"terminal_info,omitempty"`
+	Attribution *jobstore.Attribution `json:"attribution,omitempty"`
+	ArchivedAt  time.Time             `json:"archived_at"`
+	EventCount  int                   `json:"event_count"`
+	Segments    []Segment             `json:"segments"`
+}
+
+// Job 由 manifest 还原 Job 元数据（仅含归档时快照的字段）
+func (m *Manifest) Job() *job.Job {
+	tenantID := m.TenantID
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	return &job.Job{
+		ID:          m.JobID,
+		TenantID:    tenantID,
+		AgentID:     m.AgentID,
+		Goal:        m.Goal,
+		Status:      m.Status,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+		Terminal:    m.Terminal,
+		Attribution: m.Attribution,
+	}
+}
+
+// eventRecord 分段中每行的事件编码；payload 为合法 JSON 时原样内嵌，否则以 base64 存放
+type eventRecord struct {
+	ID          string                `json:"id"`
+	JobID       string                `json:"job_id"`
+	Type        string                `json:"type"`
+	Payload     json.RawMessage       `json:"payload,omitempty"`
+	PayloadB64  []byte                `json:"payload_b64,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	PrevHash    string                `json:"prev_hash,omitempty"`
+	Hash        string                `json:"hash,omitempty"`
+	Actor       string                `json:"actor,omitempty"`
+	Attribution *jobstore.Attribution `json:"attribution,omitempty"`
+}
+
+func recordOf(e jobstore.JobEvent) eventRecord {
+	r := eventRecord{ID: e.ID, JobID: e.JobID, Type: string(e.Type), CreatedAt: e.CreatedAt, PrevHash: e.PrevHash, Hash: e.Hash, Actor: e.Actor, Attribution: e.Attribution}
+	if len(e.Payload) > 0 {
+		if json.Valid(e.Payload) {
+			r.Payload = json.RawMessage(e.Payload)
+		} else {
+			r.PayloadB64 = e.Payload
+		}
+	}
+	return r
+}
+
+func (r eventRecord) event() jobstore.JobEvent {
+	e := jobstore.JobEvent{ID: r.ID, JobID: r.JobID, Type: jobstore.EventType(r.Type), CreatedAt: r.CreatedAt, PrevHash: r.PrevHash, Hash: r.Hash, Actor: r.Actor, Attribution: r.Attribution}
+	if len(r.Payload) > 0 {
+		e.Payload = []byte(r.Payload)
+	} else if len(r.PayloadB64) > 0 {
+		e.Payload = r.PayloadB64
+	}
+	return e
+}
+
+// Archive 一个 Job 的完整归档
+type Archive struct {
+	Manifest *Manifest
+	Events   []jobstore.JobEvent
+}
+
+// ManifestKey 返回 Job 归档 manifest 的对象键；Job ID 全局唯一，键不含租户，租户校验以 manifest 为准
+func ManifestKey(prefix, jobID string) string {
+	return object.KeyPrefix(prefix, DefaultPrefix) + jobID + "/" + manifestName
+}
+
+func segmentKey(prefix, jobID string, idx int) string {
+	return fmt.Sprintf("%s%s/segment-%06d.jsonl", object.KeyPrefix(prefix, DefaultPrefix), jobID, idx)
+}
+
+// Write 将 Job 元数据与事件写入对象存储：先写各分段，最后写 manifest；segmentSize<=0 时用 DefaultSegmentSize。返回写入的 manifest
+func Write(ctx context.Context, store object.Store, prefix string, j *job.Job, events []jobstore.JobEvent, segmentSize int) (*Manifest, error) {
+	if j == nil {
+		return nil, errors.New("eventarchive: nil job")
+	}
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	m := &Manifest{
+		JobID:       j.ID,
+		TenantID:    j.TenantID,
+		AgentID:     j.AgentID,
+		Goal:        j.Goal,
+		Status:      j.Status,
+		StatusName:  j.Status.String(),
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+		Terminal:    j.Terminal,
+		Attribution: j.Attribution,
+		ArchivedAt:  time.Now().UTC(),
+		EventCount:  len(events),
+		Segments:    []Segment{},
+	}
+	for start, idx := 0, 0; start < len(events); start, idx = start+segmentSize, idx+1 {
+		end := start + segmentSize
+		if end > len(events) {
+			end = len(events)
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, e := range events[start:end] {
+			if err := enc.Encode(recordOf(e)); err != nil {
+				return nil, fmt.Errorf("eventarchive: encode event %s: %w", e.ID, err)
+			}
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		seg := Segment{Key: segmentKey(prefix, j.ID, idx), FirstIndex: start, Count: end - start, Bytes: int64(buf.Len()), SHA256: hex.EncodeToString(sum[:])}
+		if err := store.Put(ctx, seg.Key, bytes.NewReader(buf.Bytes()), seg.Bytes, map[string]string{"job_id": j.ID, "content-type": "application/x-ndjson"}); err != nil {
+			return nil, fmt.Errorf("eventarchive: put segment %s: %w", seg.Key, err)
+		}
+		m.Segments = append(m.Segments, seg)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	key := ManifestKey(prefix, j.ID)
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), map[string]string{"job_id": j.ID, "content-type": "application/json"}); err != nil {
+		return nil, fmt.Errorf("eventarchive: put manifest %s: %w", key, err)
+	}
+	return m, nil
+}
+
+// Read 从对象存储读取 Job 归档并校验各分段；manifest 不存在时返回 ErrNotArchived
+func Read(ctx context.Context, store object.Store, prefix, jobID string) (*Archive, error) {
+	key := ManifestKey(prefix, jobID)
+	ok, err := store.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("eventarchive: stat manifest %s: %w", key, err)
+	}
+	if !ok {
+		return nil, ErrNotArchived
+	}
+	data, err := readAll(ctx, store, key)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("eventarchive: decode manifest %s: %w", key, err)
+	}
+	events := make([]jobstore.JobEvent, 0, m.EventCount)
+	for _, seg := range m.Segments {
+		raw, err := readAll(ctx, store, seg.Key)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(raw)
+		if hex.EncodeToString(sum[:]) != seg.SHA256 {
+			return nil, fmt.Errorf("%w: %s", ErrSegmentCorrupt, seg.Key)
+		}
+		n := 0
+		sc := bufio.NewScanner(bytes.NewReader(raw))
+		sc.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+		for sc.Scan() {
+			var r eventRecord
+			if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+				return nil, fmt.Errorf("eventarchive: decode %s line %d: %w", seg.Key, n+1, err)
+			}
+			events = append(events, r.event())
+			n++
+		}
+		if n != seg.Count {
+			return nil, fmt.Errorf("%w: %s has %d events, manifest says %d", ErrSegmentCorrupt, seg.Key, n, seg.Count)
+		}
+	}
+	return &Archive{Manifest: &m, Events: events}, nil
+}
+
+func readAll(ctx context.Context, store object.Store, key string) ([]byte, error) {
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("eventarchive: get %s: %w", key, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("eventarchive: read %s: %w", key, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventarchive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+)
+
+func seedJob(t *testing.T, n int) (job.JobStore, jobstore.JobStore, string) {
+	t.Helper()
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	jobID, _ := jobs.Create(ctx, &job.Job{AgentID: "a1", TenantID: "t1", Goal: "g", Status: job.StatusCompleted})
+	events := jobstore.NewMemoryStore()
+	ver := 0
+	for i := 0; i < n; i++ {
+		payload := []byte(`{"i":1}`)
+		if i == 1 {
+			payload = []byte("not json")
+		}
+		v, err := events.Append(ctx, jobID, ver, jobstore.JobEvent{JobID: jobID, Type: jobstore.PlanGenerated, Payload: payload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ver = v
+	}
+	return jobs, events, jobID
+}
+
+func TestSink_WriteReadRoundtrip(t *testing.T) {
+	ctx := context.Background()
+	jobs, events, jobID := seedJob(t, 5)
+	store := object.NewMemoryStore()
+	ref, err := NewSink(store, "arch", 2, jobs, events).ArchiveEvidence(ctx, jobID, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "arch/"+jobID+"/manifest.json" {
+		t.Fatalf("ref = %s", ref)
+	}
+	a, err := Read(ctx, store, "arch", jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Manifest.Segments) != 3 || len(a.Events) != 5 || a.Manifest.Job().TenantID != "t1" {
+		t.Fatalf("manifest = %+v, events = %d", a.Manifest, len(a.Events))
+	}
+	orig, _, _ := events.ListEvents(ctx, jobID)
+	for i := range orig {
+		if orig[i].ID != a.Events[i].ID || !bytes.Equal(orig[i].Payload, a.Events[i].Payload) {
+			t.Fatalf("event %d = %+v, want %+v", i, a.Events[i], orig[i])
+		}
+	}
+	if _, err := NewSink(store, "arch", 2, jobs, events).ArchiveEvidence(ctx, jobID, "other"); err == nil {
+		t.Fatal("expected tenant mismatch error")
+	}
+}
+
+func TestRead_NotArchivedAndCorrupt(t *testing.T) {
+	ctx := context.Background()
+	store := object.NewMemoryStore()
+	if _, err := Read(ctx, store, "", "missing"); !errors.Is(err, ErrNotArchived) {
+		t.Fatalf("err = %v, want ErrNotArchived", err)
+	}
+	jobs, events, jobID := seedJob(t, 3)
+	if _, err := NewSink(store, "", 0, jobs, events).ArchiveEvidence(ctx, jobID, ""); err != nil {
+		t.Fatal(err)
+	}
+	key := segmentKey("", jobID, 0)
+	bad := []byte("{}\n")
+	_ = store.Put(ctx, key, bytes.NewReader(bad), int64(len(bad)), nil)
+	if _, err := Read(ctx, store, "", jobID); !errors.Is(err, ErrSegmentCorrupt) {
+		t.Fatalf("err = %v, want ErrSegmentCorrupt", err)
+	}
+}
+
+func TestReader_CachesArchive(t *testing.T) {
+	ctx := context.Background()
+	jobs, events, jobID := seedJob(t, 2)
+	store := object.NewMemoryStore()
+	if _, err := NewSink(store, "", 0, jobs, events).ArchiveEvidence(ctx, jobID, ""); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(store, "", ReaderOptions{})
+	_, info, err := r.Load(ctx, jobID)
+	if err != nil || info.CacheHit || info.Source != "archive" || info.ExpectedLatency != "5s" {
+		t.Fatalf("first load: %+v %v", info, err)
+	}
+	// 缓存命中后即使对象被删除也能读取
+	_ = store.Delete(ctx, ManifestKey("", jobID))
+	_, info, err = r.Load(ctx, jobID)
+	if err != nil || !info.CacheHit {
+		t.Fatalf("second load: %+v %v", info, err)
+	}
+	if _, _, err := NewReader(store, "", ReaderOptions{CacheSize: -1}).Load(ctx, jobID); !errors.Is(err, ErrNotArchived) {
+		t.Fatalf("uncached load err = %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventarchive
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"rag-platform/internal/storage/object"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/metrics"
+)
+
+// 冷读默认值：缓存 64 个 Job、保留 10m，对外声明的预期延迟 5s
+const (
+	DefaultCacheSize       = 64
+	DefaultCacheTTL        = 10 * time.Minute
+	DefaultExpectedLatency = 5 * time.Second
+)
+
+// ReaderOptions 冷读配置；零值字段使用默认值，CacheSize<0 关闭缓存
+type ReaderOptions struct {
+	CacheSize       int
+	CacheTTL        time.Duration
+	ExpectedLatency time.Duration
+}
+
+// ReadInfo 单次冷读的来源与耗时，随接口响应返回，调用方据此区分热/冷数据与延迟预期
+type ReadInfo struct {
+	Source          string    `json:"source"` // 固定为 archive
+	ArchiveRef      string    `json:"archive_ref"`
+	ArchivedAt      time.Time `json:"archived_at"`
+	Segments        int       `json:"segments"`
+	CacheHit        bool      `json:"cache_hit"`
+	FetchMs         int64     `json:"fetch_ms"`
+	ExpectedLatency string    `json:"expected_latency"`
+}
+
+type cacheEntry struct {
+	jobID    string
+	archive  *Archive
+	loadedAt time.Time
+}
+
+// Reader 归档冷读：按 Job 读取 manifest 与分段，结果按 LRU + TTL 缓存；可并发使用
+type Reader struct {
+	store  object.Store
+	prefix string
+	opts   ReaderOptions
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+// NewReader 创建冷读器；prefix 须与归档写入时一致
+func NewReader(store object.Store, prefix string, opts ReaderOptions) *Reader {
+	if opts.CacheSize == 0 {
+		opts.CacheSize = DefaultCacheSize
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	if opts.ExpectedLatency <= 0 {
+		opts.ExpectedLatency = DefaultExpectedLatency
+	}
+	return &Reader{store: store, prefix: prefix, opts: opts, lru: list.New(), items: make(map[string]*list.Element)}
+}
+
+// ExpectedLatency 返回对外声明的冷读预期延迟
+func (r *Reader) ExpectedLatency() time.Duration {
+	return r.opts.ExpectedLatency
+}
+
+// Load 返回 Job 的归档；未归档时返回 ErrNotArchived。返回的 Archive 为缓存共享，调用方不得修改
+func (r *Reader) Load(ctx context.Context, jobID string) (*Archive, ReadInfo, error) {
+	start := time.Now()
+	if a := r.cached(jobID); a != nil {
+		metrics.ArchiveColdReadsTotal.WithLabelValues("cache_hit").Inc()
+		return a, r.info(a, true, start), nil
+	}
+	a, err := Read(ctx, r.store, r.prefix, jobID)
+	metrics.ArchiveColdReadSeconds.Observe(time.Since(start).Seconds())
+	if err != nil {
+		if errors.Is(err, ErrNotArchived) {
+			metrics.ArchiveColdReadsTotal.WithLabelValues("not_found").Inc()
+		} else {
+			metrics.ArchiveColdReadsTotal.WithLabelValues("error").Inc()
+		}
+		return nil, ReadInfo{}, err
+	}
+	metrics.ArchiveColdReadsTotal.WithLabelValues("fetched").Inc()
+	r.put(jobID, a)
+	return a, r.info(a, false, start), nil
+}
+
+func (r *Reader) info(a *Archive, hit bool, start time.Time) ReadInfo {
+	return ReadInfo{
+		Source:          "archive",
+		ArchiveRef:      ManifestKey(r.prefix, a.Manifest.JobID),
+		ArchivedAt:      a.Manifest.ArchivedAt,
+		Segments:        len(a.Manifest.Segments),
+		CacheHit:        hit,
+		FetchMs:         time.Since(start).Milliseconds(),
+		ExpectedLatency: r.opts.ExpectedLatency.String(),
+	}
+}
+
+func (r *Reader) cached(jobID string) *Archive {
+	if r.opts.CacheSize < 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, ok := r.items[jobID]
+	if !ok {
+		return nil
+	}
+	ent := el.Value.(*cacheEntry)
+	if time.Since(ent.loadedAt) > r.opts.CacheTTL {
+		r.lru.Remove(el)
+		delete(r.items, jobID)
+		return nil
+	}
+	r.lru.MoveToFront(el)
+	return ent.archive
+}
+
+func (r *Reader) put(jobID string, a *Archive) {
+	if r.opts.CacheSize < 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.items[jobID]; ok {
+		el.Value = &cacheEntry{jobID: jobID, archive: a, loadedAt: time.Now()}
+		r.lru.MoveToFront(el)
+		return
+	}
+	r.items[jobID] = r.lru.PushFront(&cacheEntry{jobID: jobID, archive: a, loadedAt: time.Now()})
+	for r.lru.Len() > r.opts.CacheSize {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.items, oldest.Value.(*cacheEntry).jobID)
+	}
+}
+
+// NewReaderFromConfig 按 jobstore.archive 创建冷读器；未启用时返回 nil
+func NewReaderFromConfig(cfg config.EventArchiveConfig) (*Reader, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	opts := ReaderOptions{CacheSize: cfg.CacheSize}
+	for _, d := range []struct {
+		name string
+		raw  string
+		dst  *time.Duration
+	}{{"cache_ttl", cfg.CacheTTL, &opts.CacheTTL}, {"expected_latency", cfg.ExpectedLatency, &opts.ExpectedLatency}} {
+		if d.raw == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.raw)
+		if err != nil {
+			return nil, fmt.Errorf("jobstore.archive.%s: %w", d.name, err)
+		}
+		*d.dst = v
+	}
+	store, err := object.NewStore(cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("初始化事件归档对象存储failed: %w", err)
+	}
+	return NewReader(store, cfg.Prefix, opts), nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventarchive
+
+import (
+	"context"
+	"fmt"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/storage/object"
+)
+
+// Sink 实现 retention.ArchiveSink：将 Job 元数据与完整事件流按分段写入对象存储，返回 manifest 对象键作为归档引用
+type Sink struct {
+	store       object.Store
+	prefix      string
+	segmentSize int
+	jobs        job.JobStore
+	events      jobstore.JobStore
+}
+
+// NewSink 创建归档下沉；segmentSize<=0 时用 DefaultSegmentSize
+func NewSink(store object.Store, prefix string, segmentSize int, jobs job.JobStore, events jobstore.JobStore) *Sink {
+	return &Sink{store: store, prefix: prefix, segmentSize: segmentSize, jobs: jobs, events: events}
+}
+
+// ArchiveEvidence 归档 Job；tenantID 非空时校验 Job 归属
+func (s *Sink) ArchiveEvidence(ctx context.Context, jobID string, tenantID string) (string, error) {
+	j, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	if j == nil {
+		return "", fmt.Errorf("eventarchive: job %s not found", jobID)
+	}
+	if tenantID != "" && j.TenantID != "" && j.TenantID != tenantID {
+		return "", fmt.Errorf("eventarchive: job %s not in tenant %s", jobID, tenantID)
+	}
+	events, _, err := s.events.ListEvents(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := Write(ctx, s.store, s.prefix, j, events, s.segmentSize); err != nil {
+		return "", err
+	}
+	return ManifestKey(s.prefix, jobID), nil
+}
@@ -0,0 +1,183 @@
+package eventexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+type fakePublisher struct {
+	err  error
+	sent []Message
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, msgs []Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msgs...)
+	return nil
+}
+
+func (f *fakePublisher) Close() error { return nil }
+
+func TestExportingStore_EnqueuesSelectedTypesOnly(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewOutboxMem()
+	store := NewExportingStore(jobstore.NewMemoryStore(), outbox, []string{"job_completed"}, nil)
+
+	v, err := store.Append(ctx, "j1", 0, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCreated, Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := store.Append(ctx, "j1", v, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCompleted, Payload: []byte(`{"ok":true}`)}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	recs, _ := outbox.FetchPending(ctx, 10)
+	if len(recs) != 1 || recs[0].EventType != "job_completed" || recs[0].EventID != "j1:2" {
+		t.Fatalf("expected one job_completed record with id j1:2, got %+v", recs)
+	}
+
+	// 版本冲突时不应入队
+	if _, err := store.Append(ctx, "j1", 0, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCompleted}); err == nil {
+		t.Fatal("expected version mismatch")
+	}
+	if c := outbox.Counts(); c[statusPending] != 0 {
+		t.Fatalf("rejected append must not enqueue, counts=%v", c)
+	}
+}
+
+type failingOutbox struct {
+	*OutboxMem
+	enqueues int
+}
+
+func (o *failingOutbox) Enqueue(ctx context.Context, rec Record) error {
+	o.enqueues++
+	return errors.New("outbox unavailable")
+}
+
+// 非事务 Outbox：事件已提交后入队失败不得把追加报告为失败
+func TestExportingStore_CommittedAppendNotReportedAsFailure(t *testing.T) {
+	ctx := context.Background()
+	events := jobstore.NewMemoryStore()
+	outbox := &failingOutbox{OutboxMem: NewOutboxMem()}
+	store := NewExportingStore(events, outbox, nil, nil)
+
+	if _, err := store.Append(ctx, "j1", 0, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCreated}); err != nil || outbox.enqueues != 0 {
+		t.Fatalf("unexported type must not touch outbox: %v (enqueues %d)", err, outbox.enqueues)
+	}
+	v, err := store.Append(ctx, "j1", 1, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCompleted})
+	if err != nil || v != 2 {
+		t.Fatalf("Append = %d, %v; want 2, nil", v, err)
+	}
+	if outbox.enqueues != 1 {
+		t.Fatalf("enqueues = %d, want 1", outbox.enqueues)
+	}
+	if _, ver, _ := events.ListEvents(ctx, "j1"); ver != 2 {
+		t.Fatalf("event stream version = %d, want 2", ver)
+	}
+}
+
+func TestRelay_PrunesFinishedRecords(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewOutboxMem()
+	for _, id := range []string{"e1", "e2", "e3"} {
+		_ = outbox.Enqueue(ctx, Record{JobID: "j1", EventID: id, EventType: "job_failed"})
+	}
+	recs, _ := outbox.FetchPending(ctx, 2)
+	_ = outbox.MarkDelivered(ctx, []int64{recs[0].ID})
+	_ = outbox.MarkFailed(ctx, recs[1].ID, "broker down", 1)
+
+	relay := NewRelay(outbox, &fakePublisher{}, JSONSerializer{}, RelayConfig{Retention: time.Hour}, nil)
+	if n, err := relay.Prune(ctx); err != nil || n != 0 {
+		t.Fatalf("records within retention pruned: %d, %v", n, err)
+	}
+	relay.cfg.Retention = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if n, err := relay.Prune(ctx); err != nil || n != 2 {
+		t.Fatalf("Prune = %d, %v; want 2", n, err)
+	}
+	if c := outbox.Counts(); c[statusPending] != 1 || len(c) != 1 {
+		t.Fatalf("pending records must be kept, counts=%v", c)
+	}
+}
+
+type snapshotStore struct {
+	jobstore.JobStore
+}
+
+func (snapshotStore) ListJobsWithHighEventCount(ctx context.Context, minEvents int, limit int) ([]string, error) {
+	return nil, nil
+}
+
+func TestExportingStore_FindPassesThroughDecorator(t *testing.T) {
+	store := NewExportingStore(snapshotStore{jobstore.NewMemoryStore()}, NewOutboxMem(), nil, nil)
+	if _, ok := jobstore.Find[jobstore.SnapshotJobStore](store); !ok {
+		t.Fatal("expected SnapshotJobStore to be reachable through Unwrap")
+	}
+	if !store.Selected(jobstore.ToolInvocationFinished) || store.Selected(jobstore.JobCreated) {
+		t.Fatal("default types not applied")
+	}
+}
+
+func TestRelay_DeliversAndMarks(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewOutboxMem()
+	_ = outbox.Enqueue(ctx, Record{JobID: "j1", EventID: "e1", EventType: "job_failed", Payload: []byte(`{"error":"x"}`)})
+	pub := &fakePublisher{}
+	relay := NewRelay(outbox, pub, JSONSerializer{}, RelayConfig{TopicPrefix: "acme"}, nil)
+
+	n, err := relay.RunOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if len(pub.sent) != 1 || pub.sent[0].Topic != "acme.job_failed" || string(pub.sent[0].Key) != "j1" {
+		t.Fatalf("unexpected messages: %+v", pub.sent)
+	}
+	var env Envelope
+	if err := json.Unmarshal(pub.sent[0].Value, &env); err != nil || env.EventID != "e1" || string(env.Payload) != `{"error":"x"}` {
+		t.Fatalf("unexpected envelope %+v (%v)", env, err)
+	}
+	if c := outbox.Counts(); c[statusDelivered] != 1 {
+		t.Fatalf("expected delivered, counts=%v", c)
+	}
+}
+
+func TestRelay_RetriesUntilDead(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewOutboxMem()
+	_ = outbox.Enqueue(ctx, Record{JobID: "j1", EventID: "e1", EventType: "job_failed"})
+	relay := NewRelay(outbox, &fakePublisher{err: errors.New("broker down")}, JSONSerializer{}, RelayConfig{MaxAttempts: 2}, nil)
+
+	_, _ = relay.RunOnce(ctx)
+	if c := outbox.Counts(); c[statusPending] != 1 {
+		t.Fatalf("first failure should return to pending, counts=%v", c)
+	}
+	_, _ = relay.RunOnce(ctx)
+	if c := outbox.Counts(); c[statusDead] != 1 {
+		t.Fatalf("second failure should be dead, counts=%v", c)
+	}
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Fatalf("dead records must not be fetched again, got %d", n)
+	}
+}
+
+func TestAvroSerializer_Encoding(t *testing.T) {
+	b, err := AvroSerializer{}.Serialize(Record{JobID: "j", EventID: "e", EventType: "t", Payload: []byte("{}")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 字符串长度以 zig-zag 编码：1 -> 0x02，2 -> 0x04
+	want := []byte{0x02, 'j', 0x02, 'e', 0x02, 't', 0x04, '{', '}', 0x00}
+	if string(b[:len(want)]) != string(want) {
+		t.Fatalf("avro prefix = %x, want %x", b[:len(want)], want)
+	}
+	if got := appendAvroLong(nil, -1); len(got) != 1 || got[0] != 0x01 {
+		t.Fatalf("zig-zag(-1) = %x", got)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventexport
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Record outbox 中的一条待导出事件
+type Record struct {
+	ID        int64
+	JobID     string
+	EventID   string
+	EventType string
+	Payload   []byte
+	Hash      string
+	CreatedAt time.Time
+	Attempts  int
+	LastError string
+}
+
+// Outbox 事件导出发件箱：Append 成功后写入，Relay 拉取发布，成功后标记 delivered（at-least-once）
+type Outbox interface {
+	// Enqueue 写入一条待导出记录
+	Enqueue(ctx context.Context, rec Record) error
+	// FetchPending 认领最多 limit 条待投递记录（按写入顺序）；实现应保证多实例并发拉取时不重复认领
+	FetchPending(ctx context.Context, limit int) ([]Record, error)
+	// MarkDelivered 标记记录已投递
+	MarkDelivered(ctx context.Context, ids []int64) error
+	// MarkFailed 记录投递失败；attempts 达到 maxAttempts 时标记为 dead 不再重试
+	MarkFailed(ctx context.Context, id int64, errMsg string, maxAttempts int) error
+	// Prune 删除 before 之前已投递或转为 dead 的记录，单次最多 limit 条，返回删除条数
+	Prune(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// TxOutbox 可选接口：支持在事件追加的数据库事务内写入记录（当前为 Postgres 实现），保证事件与 outbox 记录同时提交
+type TxOutbox interface {
+	EnqueueTx(ctx context.Context, tx pgx.Tx, rec Record) error
+}
+
+const (
+	statusPending   = "pending"
+	statusClaimed   = "claimed"
+	statusDelivered = "delivered"
+	statusDead      = "dead"
+)
+
+type memRecord struct {
+	Record
+	status     string
+	finishedAt time.Time
+}
+
+// OutboxMem 内存实现（单进程 / 测试）
+type OutboxMem struct {
+	mu     sync.Mutex
+	nextID int64
+	byID   map[int64]*memRecord
+}
+
+// NewOutboxMem 创建内存 Outbox
+func NewOutboxMem() *OutboxMem {
+	return &OutboxMem{byID: make(map[int64]*memRecord)}
+}
+
+func (o *OutboxMem) Enqueue(ctx context.Context, rec Record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.nextID++
+	rec.ID = o.nextID
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	o.byID[rec.ID] = &memRecord{Record: rec, status: statusPending}
+	return nil
+}
+
+func (o *OutboxMem) FetchPending(ctx context.Context, limit int) ([]Record, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ids := make([]int64, 0, len(o.byID))
+	for id, r := range o.byID {
+		if r.status == statusPending {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	out := make([]Record, 0, len(ids))
+	for _, id := range ids {
+		r := o.byID[id]
+		r.status = statusClaimed
+		out = append(out, r.Record)
+	}
+	return out, nil
+}
+
+func (o *OutboxMem) MarkDelivered(ctx context.Context, ids []int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, id := range ids {
+		if r, ok := o.byID[id]; ok {
+			r.status = statusDelivered
+			r.finishedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (o *OutboxMem) MarkFailed(ctx context.Context, id int64, errMsg string, maxAttempts int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	r, ok := o.byID[id]
+	if !ok {
+		return nil
+	}
+	r.Attempts++
+	r.LastError = errMsg
+	if maxAttempts > 0 && r.Attempts >= maxAttempts {
+		r.status = statusDead
+		r.finishedAt = time.Now()
+	} else {
+		r.status = statusPending
+	}
+	return nil
+}
+
+func (o *OutboxMem) Prune(ctx context.Context, before time.Time, limit int) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ids := make([]int64, 0)
+	for id, r := range o.byID {
+		if (r.status == statusDelivered || r.status == statusDead) && r.finishedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	for _, id := range ids {
+		delete(o.byID, id)
+	}
+	return len(ids), nil
+}
+
+// Counts 返回各状态记录数（供测试与诊断）
+func (o *OutboxMem) Counts() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := make(map[string]int)
+	for _, r := range o.byID {
+		out[r.status]++
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventexport
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// claimTimeout claimed 状态超过此时长视为认领者已崩溃，可被重新认领（保证 at-least-once）
+const claimTimeout = "5 minutes"
+
+// OutboxPg PostgreSQL 实现，使用 event_outbox 表；多实例通过 FOR UPDATE SKIP LOCKED 避免重复认领
+type OutboxPg struct {
+	pool *pgxpool.Pool
+}
+
+// NewOutboxPg 创建基于 PostgreSQL 的 Outbox；pool 与 JobStore 共用 DSN 即可
+func NewOutboxPg(pool *pgxpool.Pool) *OutboxPg {
+	return &OutboxPg{pool: pool}
+}
+
+func (o *OutboxPg) Enqueue(ctx context.Context, rec Record) error {
+	_, err := o.pool.Exec(ctx, enqueueSQL, enqueueArgs(rec)...)
+	return err
+}
+
+// EnqueueTx 实现 TxOutbox：在事件追加的事务内写入，随事件一同提交或回滚
+func (o *OutboxPg) EnqueueTx(ctx context.Context, tx pgx.Tx, rec Record) error {
+	_, err := tx.Exec(ctx, enqueueSQL, enqueueArgs(rec)...)
+	return err
+}
+
+const enqueueSQL = `INSERT INTO event_outbox (job_id, event_id, event_type, payload, hash, status) VALUES ($1, $2, $3, $4, $5, 'pending')`
+
+func enqueueArgs(rec Record) []any {
+	payload := rec.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	return []any{rec.JobID, rec.EventID, rec.EventType, payload, rec.Hash}
+}
+
+func (o *OutboxPg) FetchPending(ctx context.Context, limit int) ([]Record, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := o.pool.Query(ctx,
+		`WITH sel AS (
+  SELECT id FROM event_outbox
+  WHERE status = 'pending' OR (status = 'claimed' AND claimed_at < now() - interval '`+claimTimeout+`')
+  ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+)
+UPDATE event_outbox SET status = 'claimed', claimed_at = now()
+FROM sel WHERE event_outbox.id = sel.id
+RETURNING event_outbox.id, event_outbox.job_id, event_outbox.event_id, event_outbox.event_type,
+  event_outbox.payload, COALESCE(event_outbox.hash, ''), event_outbox.created_at, event_outbox.attempts, COALESCE(event_outbox.last_error, '')`,
+		limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Record
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.ID, &r.JobID, &r.EventID, &r.EventType, &r.Payload, &r.Hash, &r.CreatedAt, &r.Attempts, &r.LastError); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (o *OutboxPg) MarkDelivered(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := o.pool.Exec(ctx,
+		`UPDATE event_outbox SET status = 'delivered', delivered_at = now(), finished_at = now() WHERE id = ANY($1)`, ids)
+	return err
+}
+
+func (o *OutboxPg) MarkFailed(ctx context.Context, id int64, errMsg string, maxAttempts int) error {
+	_, err := o.pool.Exec(ctx,
+		`UPDATE event_outbox SET attempts = attempts + 1, last_error = $2,
+		 status = CASE WHEN $3 > 0 AND attempts + 1 >= $3 THEN 'dead' ELSE 'pending' END,
+		 finished_at = CASE WHEN $3 > 0 AND attempts + 1 >= $3 THEN now() END
+		 WHERE id = $1`,
+		id, errMsg, maxAttempts)
+	return err
+}
+
+// Prune 按 id 顺序分批删除；保留期从转为 delivered / dead 的时间（finished_at）起算
+func (o *OutboxPg) Prune(ctx context.Context, before time.Time, limit int) (int, error) {
+	if limit <= 0 {
+		limit = 1000
+	}
+	tag, err := o.pool.Exec(ctx,
+		`DELETE FROM event_outbox WHERE id IN (
+  SELECT id FROM event_outbox
+  WHERE status IN ('delivered', 'dead') AND finished_at < $1
+  ORDER BY id LIMIT $2
+)`, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventexport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 一条待发布消息
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// Publisher 消息发布端（Kafka / NATS）；Publish 返回 nil 表示下游已确认接收
+type Publisher interface {
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// NewPublisher 按 sink 类型创建 Publisher
+func NewPublisher(sink, endpoint string) (Publisher, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("eventexport: endpoint is required for sink %q", sink)
+	}
+	switch sink {
+	case "kafka_rest", "kafka":
+		return NewKafkaRESTPublisher(endpoint), nil
+	case "nats":
+		return NewNATSPublisher(endpoint), nil
+	default:
+		return nil, fmt.Errorf("eventexport: unsupported sink %q", sink)
+	}
+}
+
+// KafkaRESTPublisher 通过 Kafka REST Proxy（v2 binary 嵌入格式）发布，避免引入原生 Kafka 客户端依赖
+type KafkaRESTPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaRESTPublisher 创建 Kafka REST Proxy 发布端；endpoint 如 http://kafka-rest:8082
+func NewKafkaRESTPublisher(endpoint string) *KafkaRESTPublisher {
+	return &KafkaRESTPublisher{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type kafkaRESTRecord struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+func (p *KafkaRESTPublisher) Publish(ctx context.Context, msgs []Message) error {
+	byTopic := make(map[string][]kafkaRESTRecord)
+	var order []string
+	for _, m := range msgs {
+		if _, ok := byTopic[m.Topic]; !ok {
+			order = append(order, m.Topic)
+		}
+		byTopic[m.Topic] = append(byTopic[m.Topic], kafkaRESTRecord{
+			Key:   base64.StdEncoding.EncodeToString(m.Key),
+			Value: base64.StdEncoding.EncodeToString(m.Value),
+		})
+	}
+	for _, topic := range order {
+		body, err := json.Marshal(map[string]interface{}{"records": byTopic[topic]})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/topics/"+topic, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+		req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return err
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("kafka rest proxy: topic %s status %d: %s", topic, resp.StatusCode, strings.TrimSpace(string(respBody)))
+		}
+	}
+	return nil
+}
+
+func (p *KafkaRESTPublisher) Close() error { return nil }
+
+// NATSPublisher 基于 NATS 文本协议（CONNECT/PUB/PING）发布；每批以 PING/PONG 往返确认服务端已处理
+type NATSPublisher struct {
+	addr string
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewNATSPublisher 创建 NATS 发布端；addr 如 nats:4222 或 nats://nats:4222
+func NewNATSPublisher(addr string) *NATSPublisher {
+	return &NATSPublisher{addr: strings.TrimPrefix(addr, "nats://")}
+}
+
+func (p *NATSPublisher) connectLocked(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	rd := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := rd.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO") {
+		_ = conn.Close()
+		return fmt.Errorf("nats: unexpected greeting %q: %v", strings.TrimSpace(line), err)
+	}
+	if _, err := conn.Write([]byte("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"aetheris-event-export\"}\r\n")); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	p.conn, p.rd = conn, rd
+	return nil
+}
+
+func (p *NATSPublisher) resetLocked() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn, p.rd = nil, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, msgs []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.connectLocked(ctx); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		fmt.Fprintf(&buf, "PUB %s %d\r\n", m.Topic, len(m.Value))
+		buf.Write(m.Value)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("PING\r\n")
+	deadline := time.Now().Add(10 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = p.conn.SetDeadline(deadline)
+	if _, err := p.conn.Write(buf.Bytes()); err != nil {
+		p.resetLocked()
+		return err
+	}
+	for {
+		line, err := p.rd.ReadString('\n')
+		if err != nil {
+			p.resetLocked()
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			_, _ = p.conn.Write([]byte("PONG\r\n"))
+		case strings.HasPrefix(line, "-ERR"):
+			p.resetLocked()
+			return fmt.Errorf("nats: %s", line)
+		}
+	}
+}
+
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetLocked()
+	return nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventexport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/log"
+	"rag-platform/pkg/metrics"
+)
+
+// RelayConfig Relay 参数
+type RelayConfig struct {
+	TopicPrefix  string
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	Retention    time.Duration // delivered / dead 记录保留时长
+}
+
+const (
+	pruneInterval = 10 * time.Minute
+	pruneBatch    = 1000
+)
+
+// RelayConfigFrom 从 EventExportConfig 解析 RelayConfig 并填充默认值
+func RelayConfigFrom(cfg config.EventExportConfig) RelayConfig {
+	rc := RelayConfig{TopicPrefix: cfg.TopicPrefix, BatchSize: cfg.BatchSize, MaxAttempts: cfg.MaxAttempts}
+	if d, err := time.ParseDuration(cfg.PollInterval); err == nil && d > 0 {
+		rc.PollInterval = d
+	}
+	if d, err := time.ParseDuration(cfg.Retention); err == nil && d > 0 {
+		rc.Retention = d
+	}
+	return rc.withDefaults()
+}
+
+func (rc RelayConfig) withDefaults() RelayConfig {
+	if rc.TopicPrefix == "" {
+		rc.TopicPrefix = "aetheris"
+	}
+	if rc.PollInterval <= 0 {
+		rc.PollInterval = time.Second
+	}
+	if rc.BatchSize <= 0 {
+		rc.BatchSize = 100
+	}
+	if rc.MaxAttempts <= 0 {
+		rc.MaxAttempts = 10
+	}
+	if rc.Retention <= 0 {
+		rc.Retention = 7 * 24 * time.Hour
+	}
+	return rc
+}
+
+// Relay 后台轮询 Outbox，序列化后发布到 Publisher；发布成功才标记 delivered，失败回到 pending 等待重试（at-least-once）
+type Relay struct {
+	outbox     Outbox
+	publisher  Publisher
+	serializer Serializer
+	cfg        RelayConfig
+	logger     *log.Logger
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewRelay 创建 Relay
+func NewRelay(outbox Outbox, publisher Publisher, serializer Serializer, cfg RelayConfig, logger *log.Logger) *Relay {
+	return &Relay{
+		outbox:     outbox,
+		publisher:  publisher,
+		serializer: serializer,
+		cfg:        cfg.withDefaults(),
+		logger:     logger,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Topic 事件类型对应的 topic / subject
+func (r *Relay) Topic(eventType string) string {
+	return r.cfg.TopicPrefix + "." + eventType
+}
+
+// Start 启动后台轮询；一批取满时立即继续拉取，否则等待 PollInterval；每 pruneInterval 清理过期记录
+func (r *Relay) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		var lastPrune time.Time
+		for {
+			if time.Since(lastPrune) >= pruneInterval {
+				lastPrune = time.Now()
+				if _, err := r.Prune(ctx); err != nil && r.logger != nil {
+					r.logger.Warn("事件导出清理 outbox 失败", "error", err)
+				}
+			}
+			n, err := r.RunOnce(ctx)
+			if err != nil && r.logger != nil {
+				r.logger.Warn("事件导出拉取 outbox 失败", "error", err)
+			}
+			if n >= r.cfg.BatchSize && err == nil {
+				select {
+				case <-r.stopCh:
+					return
+				default:
+					continue
+				}
+			}
+			select {
+			case <-r.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(r.cfg.PollInterval):
+			}
+		}
+	}()
+}
+
+// Stop 优雅退出：关闭 stopCh，等待后台 goroutine 结束
+func (r *Relay) Stop() {
+	close(r.stopCh)
+	r.wg.Wait()
+	_ = r.publisher.Close()
+}
+
+// RunOnce 拉取并投递一批记录，返回本批记录数
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	recs, err := r.outbox.FetchPending(ctx, r.cfg.BatchSize)
+	if err != nil || len(recs) == 0 {
+		return 0, err
+	}
+	msgs := make([]Message, 0, len(recs))
+	sent := make([]Record, 0, len(recs))
+	for _, rec := range recs {
+		value, serr := r.serializer.Serialize(rec)
+		if serr != nil {
+			r.fail(ctx, rec, serr)
+			continue
+		}
+		msgs = append(msgs, Message{Topic: r.Topic(rec.EventType), Key: []byte(rec.JobID), Value: value})
+		sent = append(sent, rec)
+	}
+	if len(msgs) == 0 {
+		return len(recs), nil
+	}
+	if perr := r.publisher.Publish(ctx, msgs); perr != nil {
+		for _, rec := range sent {
+			r.fail(ctx, rec, perr)
+		}
+		return len(recs), nil
+	}
+	ids := make([]int64, 0, len(sent))
+	for _, rec := range sent {
+		ids = append(ids, rec.ID)
+		metrics.EventExportTotal.WithLabelValues(rec.EventType, "delivered").Inc()
+	}
+	return len(recs), r.outbox.MarkDelivered(ctx, ids)
+}
+
+// Prune 删除超过 Retention 的 delivered / dead 记录，返回删除条数
+func (r *Relay) Prune(ctx context.Context) (int, error) {
+	before := time.Now().Add(-r.cfg.Retention)
+	total := 0
+	for {
+		n, err := r.outbox.Prune(ctx, before, pruneBatch)
+		total += n
+		if err != nil || n < pruneBatch {
+			return total, err
+		}
+	}
+}
+
+func (r *Relay) fail(ctx context.Context, rec Record, cause error) {
+	result := "failed"
+	if rec.Attempts+1 >= r.cfg.MaxAttempts {
+		result = "dead"
+	}
+	metrics.EventExportTotal.WithLabelValues(rec.EventType, result).Inc()
+	if err := r.outbox.MarkFailed(ctx, rec.ID, cause.Error(), r.cfg.MaxAttempts); err != nil && r.logger != nil {
+		r.logger.Warn("事件导出标记失败出错", "id", rec.ID, "error", err)
+	}
+	if result == "dead" && r.logger != nil {
+		r.logger.Error("事件导出超过最大重试次数，已放弃", "job_id", rec.JobID, "event_id", rec.EventID, "error", cause)
+	}
+}
+
+// NewFromConfig 按配置包装 inner 并创建对应 Relay；调用方负责 Relay.Start/Stop
+func NewFromConfig(cfg config.EventExportConfig, inner jobstore.JobStore, outbox Outbox, logger *log.Logger) (*ExportingStore, *Relay, error) {
+	ser, err := NewSerializer(cfg.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := NewPublisher(cfg.Sink, cfg.Endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	store := NewExportingStore(inner, outbox, cfg.Types, logger)
+	return store, NewRelay(outbox, pub, ser, RelayConfigFrom(cfg), logger), nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventexport
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// Serializer 将 outbox 记录序列化为下游消息体
+type Serializer interface {
+	// Name 返回格式名（json | avro），写入消息头供消费方识别
+	Name() string
+	Serialize(rec Record) ([]byte, error)
+}
+
+// NewSerializer 按格式名创建 Serializer；空为 json
+func NewSerializer(format string) (Serializer, error) {
+	switch format {
+	case "", "json":
+		return JSONSerializer{}, nil
+	case "avro":
+		return AvroSerializer{}, nil
+	default:
+		return nil, fmt.Errorf("eventexport: unsupported format %q", format)
+	}
+}
+
+// Envelope 导出消息的统一结构（JSON 格式直接序列化；Avro 按 AvroSchema 字段顺序编码）
+type Envelope struct {
+	JobID     string          `json:"job_id"`
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+	CreatedAt int64           `json:"created_at"` // Unix 毫秒
+}
+
+func envelopeOf(rec Record) Envelope {
+	return Envelope{
+		JobID:     rec.JobID,
+		EventID:   rec.EventID,
+		EventType: rec.EventType,
+		Payload:   json.RawMessage(rec.Payload),
+		Hash:      rec.Hash,
+		CreatedAt: rec.CreatedAt.UnixMilli(),
+	}
+}
+
+// JSONSerializer JSON 格式
+type JSONSerializer struct{}
+
+func (JSONSerializer) Name() string { return "json" }
+
+func (JSONSerializer) Serialize(rec Record) ([]byte, error) {
+	env := envelopeOf(rec)
+	if len(env.Payload) == 0 || !json.Valid(env.Payload) {
+		env.Payload = nil
+	}
+	return json.Marshal(env)
+}
+
+// AvroSchema 导出消息的 Avro schema；payload 以 JSON 字符串承载，便于 schema 稳定
+const AvroSchema = `{"type":"record","name":"JobEvent","namespace":"io.aetheris.events","fields":[` +
+	`{"name":"job_id","type":"string"},` +
+	`{"name":"event_id","type":"string"},` +
+	`{"name":"event_type","type":"string"},` +
+	`{"name":"payload","type":"string"},` +
+	`{"name":"hash","type":"string"},` +
+	`{"name":"created_at","type":{"type":"long","logicalType":"timestamp-millis"}}]}`
+
+// AvroSerializer Avro 二进制编码（单条 datum，不含 Object Container 头；schema 见 AvroSchema）
+type AvroSerializer struct{}
+
+func (AvroSerializer) Name() string { return "avro" }
+
+func (AvroSerializer) Serialize(rec Record) ([]byte, error) {
+	env := envelopeOf(rec)
+	buf := make([]byte, 0, 64+len(rec.Payload))
+	buf = appendAvroString(buf, env.JobID)
+	buf = appendAvroString(buf, env.EventID)
+	buf = appendAvroString(buf, env.EventType)
+	buf = appendAvroString(buf, string(env.Payload))
+	buf = appendAvroString(buf, env.Hash)
+	buf = appendAvroLong(buf, env.CreatedAt)
+	return buf, nil
+}
+
+// appendAvroLong Avro long：zig-zag + 变长编码
+func appendAvroLong(buf []byte, v int64) []byte {
+	return binary.AppendUvarint(buf, uint64((v<<1)^(v>>63)))
+}
+
+// appendAvroString Avro string：长度（long）+ UTF-8 字节
+func appendAvroString(buf []byte, s string) []byte {
+	buf = appendAvroLong(buf, int64(len(s)))
+	return append(buf, s...)
+}
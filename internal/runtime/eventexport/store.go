@@ -0,0 +1,113 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventexport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+)
+
+// DefaultTypes 未配置 types 时默认导出的事件类型
+var DefaultTypes = []jobstore.EventType{
+	jobstore.JobCompleted,
+	jobstore.JobFailed,
+	jobstore.ToolInvocationFinished,
+	jobstore.StateChanged,
+	jobstore.CustomEvent,
+}
+
+// ExportingStore JobStore 装饰器：Append 时将选定类型的事件写入 Outbox，由 Relay 异步发布
+type ExportingStore struct {
+	jobstore.JobStore
+	outbox Outbox
+	types  map[jobstore.EventType]struct{}
+	logger *log.Logger
+}
+
+// NewExportingStore 包装 inner；types 为空时使用 DefaultTypes
+func NewExportingStore(inner jobstore.JobStore, outbox Outbox, types []string, logger *log.Logger) *ExportingStore {
+	set := make(map[jobstore.EventType]struct{})
+	for _, t := range types {
+		if t != "" {
+			set[jobstore.EventType(t)] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		for _, t := range DefaultTypes {
+			set[t] = struct{}{}
+		}
+	}
+	return &ExportingStore{JobStore: inner, outbox: outbox, types: set, logger: logger}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *ExportingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+// Selected 该事件类型是否导出
+func (s *ExportingStore) Selected(t jobstore.EventType) bool {
+	_, ok := s.types[t]
+	return ok
+}
+
+// Append 选定类型的事件与 outbox 记录在同一事务内写入（Outbox 实现 TxOutbox 且底层为 Postgres 时）；
+// 否则追加成功后入队，入队失败仅记录日志——事件已提交，不能再报告追加失败
+func (s *ExportingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	if !s.Selected(event.Type) {
+		return s.JobStore.Append(ctx, jobID, expectedVersion, event)
+	}
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	record := func(version int) Record {
+		eventID := event.ID
+		if eventID == "" {
+			// 底层实现生成的 ID 不回传；以 job_id:version 作为稳定去重键
+			eventID = fmt.Sprintf("%s:%d", jobID, version)
+		}
+		return Record{
+			JobID:     jobID,
+			EventID:   eventID,
+			EventType: string(event.Type),
+			Payload:   event.Payload,
+			Hash:      event.Hash,
+			CreatedAt: createdAt,
+		}
+	}
+	enqueued := false
+	if txo, ok := s.outbox.(TxOutbox); ok {
+		ctx = jobstore.WithAppendTxHook(ctx, func(ctx context.Context, tx pgx.Tx, version int) error {
+			if err := txo.EnqueueTx(ctx, tx, record(version)); err != nil {
+				return err
+			}
+			enqueued = true
+			return nil
+		})
+	}
+	newVersion, err := s.JobStore.Append(ctx, jobID, expectedVersion, event)
+	if err != nil || enqueued {
+		return newVersion, err
+	}
+	if qerr := s.outbox.Enqueue(context.WithoutCancel(ctx), record(newVersion)); qerr != nil && s.logger != nil {
+		s.logger.Error("事件导出入队失败", "job_id", jobID, "event_type", event.Type, "version", newVersion, "error", qerr)
+	}
+	return newVersion, nil
+}
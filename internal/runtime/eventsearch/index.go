@@ -0,0 +1,304 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventsearch 对 Job 事件做全文检索：写入时从目标、工具输出、错误与推理快照抽取文本入索引，查询按租户隔离
+package eventsearch
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Scope 检索范围
+type Scope string
+
+const (
+	ScopeGoals       Scope = "goals"        // job_created 中的 goal
+	ScopeToolOutputs Scope = "tool_outputs" // 工具返回、结果摘要与溢出记录
+	ScopeErrors      Scope = "errors"       // 任意事件顶层 error 字段
+	ScopeReasoning   Scope = "reasoning"    // 推理/决策快照与思考记录
+	// ScopeEvents 全部范围；仅用于查询参数，不作为文档范围
+	ScopeEvents Scope = "events"
+)
+
+// Scopes 全部文档范围
+var Scopes = []Scope{ScopeGoals, ScopeToolOutputs, ScopeErrors, ScopeReasoning}
+
+// ParseScope 解析查询参数中的范围；空或 events 返回 nil（全部范围）
+func ParseScope(s string) ([]Scope, error) {
+	switch Scope(s) {
+	case "", ScopeEvents:
+		return nil, nil
+	}
+	if !slices.Contains(Scopes, Scope(s)) {
+		return nil, ErrInvalidScope
+	}
+	return []Scope{Scope(s)}, nil
+}
+
+var (
+	// ErrInvalidScope 未知的检索范围
+	ErrInvalidScope = errors.New("eventsearch: invalid scope")
+	// ErrInvalidCursor 分页游标无法解析
+	ErrInvalidCursor = errors.New("eventsearch: invalid cursor")
+)
+
+// Doc 一条索引文档：一个事件在某个范围下抽取的文本
+type Doc struct {
+	JobID     string
+	Version   int // 事件写入后的 job version
+	TenantID  string
+	AgentID   string
+	Scope     Scope
+	EventType string
+	NodeID    string
+	Text      string
+	CreatedAt time.Time
+}
+
+// Query 检索条件；TenantID 必填，Scopes 为空表示全部范围
+type Query struct {
+	TenantID string
+	Q        string
+	Scopes   []Scope
+	AgentIDs []string
+	Limit    int
+	Cursor   string // 上一页的 NextCursor；空为第一页
+}
+
+// Hit 一条命中；Snippet 为命中词附近的文本片段，命中词以 HighlightStart/HighlightStop 包围
+type Hit struct {
+	JobID     string    `json:"job_id"`
+	Version   int       `json:"version"`
+	AgentID   string    `json:"agent_id"`
+	Scope     Scope     `json:"scope"`
+	EventType string    `json:"event_type"`
+	NodeID    string    `json:"node_id,omitempty"`
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Result 一页结果（按事件时间倒序）；NextCursor 为空表示没有更多
+type Result struct {
+	Hits       []Hit  `json:"hits"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Index 全文索引
+type Index interface {
+	Add(ctx context.Context, docs ...Doc) error
+	Search(ctx context.Context, q Query) (*Result, error)
+}
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// HighlightStart/HighlightStop 片段中命中词的标记
+	HighlightStart = "«"
+	HighlightStop  = "»"
+	snippetRunes   = 80
+)
+
+func (q Query) limit() int {
+	if q.Limit <= 0 {
+		return DefaultLimit
+	}
+	return min(q.Limit, MaxLimit)
+}
+
+type cursor struct {
+	createdAt time.Time
+	seq       int64
+}
+
+// encodeCursor 游标为 base64("<created_at unix micro>|<seq>")，对调用方不透明；微秒与 Postgres timestamptz 精度一致
+func encodeCursor(createdAt time.Time, seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createdAt.UnixMicro(), 10) + "|" + strconv.FormatInt(seq, 10)))
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, seq, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor{createdAt: time.UnixMicro(n), seq: id}, nil
+}
+
+// after 是否排在游标之后（created_at 倒序、seq 倒序）
+func (c *cursor) after(createdAt time.Time, seq int64) bool {
+	if c == nil {
+		return true
+	}
+	if !createdAt.Equal(c.createdAt) {
+		return createdAt.Before(c.createdAt)
+	}
+	return seq < c.seq
+}
+
+// Terms 将查询拆为小写词项（按空白分隔，去掉引号与前导 +/-）
+func Terms(q string) []string {
+	var out []string
+	for _, f := range strings.Fields(strings.ToLower(q)) {
+		f = strings.Trim(f, `"'+-`)
+		if f != "" && f != "or" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+type memDoc struct {
+	Doc
+	seq   int64
+	lower string
+}
+
+// IndexMem 内存实现：词项大小写不敏感的子串 AND 匹配
+type IndexMem struct {
+	mu   sync.RWMutex
+	docs []memDoc
+	seq  int64
+	keys map[string]bool
+}
+
+// NewIndexMem 创建内存 Index
+func NewIndexMem() *IndexMem {
+	return &IndexMem{keys: make(map[string]bool)}
+}
+
+func (s *IndexMem) Add(ctx context.Context, docs ...Doc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range docs {
+		key := d.JobID + "|" + strconv.Itoa(d.Version) + "|" + string(d.Scope)
+		if s.keys[key] {
+			continue
+		}
+		s.keys[key] = true
+		s.seq++
+		d.CreatedAt = d.CreatedAt.Truncate(time.Microsecond)
+		s.docs = append(s.docs, memDoc{Doc: d, seq: s.seq, lower: strings.ToLower(d.Text)})
+	}
+	return nil
+}
+
+func (s *IndexMem) Search(ctx context.Context, q Query) (*Result, error) {
+	cur, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	terms := Terms(q.Q)
+	if len(terms) == 0 {
+		return &Result{Hits: []Hit{}}, nil
+	}
+	s.mu.RLock()
+	var matched []memDoc
+	for _, d := range s.docs {
+		if d.TenantID != q.TenantID || !cur.after(d.CreatedAt, d.seq) {
+			continue
+		}
+		if len(q.Scopes) > 0 && !slices.Contains(q.Scopes, d.Scope) {
+			continue
+		}
+		if len(q.AgentIDs) > 0 && !slices.Contains(q.AgentIDs, d.AgentID) {
+			continue
+		}
+		if containsAll(d.lower, terms) {
+			matched = append(matched, d)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(matched, func(a, b int) bool {
+		if !matched[a].CreatedAt.Equal(matched[b].CreatedAt) {
+			return matched[a].CreatedAt.After(matched[b].CreatedAt)
+		}
+		return matched[a].seq > matched[b].seq
+	})
+	limit := q.limit()
+	res := &Result{Hits: []Hit{}}
+	for i, d := range matched {
+		if i == limit {
+			last := matched[limit-1]
+			res.NextCursor = encodeCursor(last.CreatedAt, last.seq)
+			break
+		}
+		res.Hits = append(res.Hits, Hit{
+			JobID: d.JobID, Version: d.Version, AgentID: d.AgentID, Scope: d.Scope,
+			EventType: d.EventType, NodeID: d.NodeID, Snippet: snippet(d.Text, terms), CreatedAt: d.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+func containsAll(text string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(text, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet 截取首个命中词附近的文本并标记命中词；ToLower 改变字节长度时退化为开头片段
+func snippet(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	at, n := -1, 0
+	if len(lower) == len(text) {
+		for _, t := range terms {
+			if i := strings.Index(lower, t); i >= 0 && (at < 0 || i < at) {
+				at, n = i, len(t)
+			}
+		}
+	}
+	if at < 0 {
+		return truncateRunes(text, snippetRunes)
+	}
+	before := text[:at]
+	if utf8.RuneCountInString(before) > snippetRunes/2 {
+		r := []rune(before)
+		before = "…" + string(r[len(r)-snippetRunes/2:])
+	}
+	return before + HighlightStart + text[at:at+n] + HighlightStop + truncateRunes(text[at+n:], snippetRunes/2)
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n]) + "…"
+}
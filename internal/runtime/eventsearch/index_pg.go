@@ -0,0 +1,104 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventsearch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IndexPg PostgreSQL 全文检索实现，使用 job_search_docs 表（'simple' 词典，websearch 语法）
+type IndexPg struct {
+	pool *pgxpool.Pool
+}
+
+// NewIndexPg 创建基于 PostgreSQL 的 Index
+func NewIndexPg(pool *pgxpool.Pool) *IndexPg {
+	return &IndexPg{pool: pool}
+}
+
+func (s *IndexPg) Add(ctx context.Context, docs ...Doc) error {
+	for _, d := range docs {
+		_, err := s.pool.Exec(ctx,
+			`INSERT INTO job_search_docs (job_id, version, scope, tenant_id, agent_id, event_type, node_id, body, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			 ON CONFLICT (job_id, version, scope) DO NOTHING`,
+			d.JobID, d.Version, string(d.Scope), d.TenantID, d.AgentID, d.EventType, d.NodeID, d.Text, d.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *IndexPg) Search(ctx context.Context, q Query) (*Result, error) {
+	cur, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Hits: []Hit{}}
+	if len(Terms(q.Q)) == 0 {
+		return res, nil
+	}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	tsq := `websearch_to_tsquery('simple', ` + arg(q.Q) + `)`
+	query := `SELECT id, job_id, version, agent_id, scope, event_type, node_id, created_at,
+		ts_headline('simple', body, ` + tsq + `, 'StartSel=` + HighlightStart + `, StopSel=` + HighlightStop + `, MaxWords=24, MinWords=8')
+		FROM job_search_docs WHERE tenant_id = ` + arg(q.TenantID) + ` AND tsv @@ ` + tsq
+	if len(q.Scopes) > 0 {
+		scopes := make([]string, 0, len(q.Scopes))
+		for _, sc := range q.Scopes {
+			scopes = append(scopes, string(sc))
+		}
+		query += ` AND scope = ANY(` + arg(scopes) + `)`
+	}
+	if len(q.AgentIDs) > 0 {
+		query += ` AND agent_id = ANY(` + arg(q.AgentIDs) + `)`
+	}
+	if cur != nil {
+		query += ` AND (created_at, id) < (` + arg(cur.createdAt) + `, ` + arg(cur.seq) + `)`
+	}
+	limit := q.limit()
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ` + arg(limit+1)
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lastSeq int64
+	for rows.Next() {
+		var h Hit
+		var seq int64
+		var scope string
+		if err := rows.Scan(&seq, &h.JobID, &h.Version, &h.AgentID, &scope, &h.EventType, &h.NodeID, &h.CreatedAt, &h.Snippet); err != nil {
+			return nil, err
+		}
+		if len(res.Hits) == limit {
+			last := res.Hits[limit-1]
+			res.NextCursor = encodeCursor(last.CreatedAt, lastSeq)
+			break
+		}
+		h.Scope = Scope(scope)
+		res.Hits = append(res.Hits, h)
+		lastSeq = seq
+	}
+	return res, rows.Err()
+}
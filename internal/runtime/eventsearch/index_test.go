@@ -0,0 +1,119 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventsearch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func TestIndexingStore_ExtractsScopesAndSearches(t *testing.T) {
+	ctx := context.Background()
+	jobs := job.NewJobStoreMem()
+	for _, j := range []*job.Job{
+		{ID: "j1", AgentID: "a1", TenantID: "t1", Goal: "g"},
+		{ID: "j2", AgentID: "a2", TenantID: "t2", Goal: "g"},
+	} {
+		if _, err := jobs.Create(ctx, j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := NewIndexMem()
+	store := NewIndexingStore(jobstore.NewMemoryStore(), index, JobsResolver(jobs), nil, 0, nil)
+
+	v, _ := store.Append(ctx, "j1", 0, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCreated, Payload: []byte(`{"goal":"Summarize the quarterly report"}`)})
+	v, _ = store.Append(ctx, "j1", v, jobstore.JobEvent{JobID: "j1", Type: jobstore.ToolInvocationFinished,
+		Payload: []byte(`{"node_id":"n1","idempotency_key":"invoice-key","output":{"rows":["invoice 42 overdue"]},"error":"upstream timeout"}`)})
+	_, _ = store.Append(ctx, "j1", v, jobstore.JobEvent{JobID: "j1", Type: jobstore.ReasoningSnapshot, Payload: []byte(`{"thought":"retry the invoice fetch"}`)})
+	_, _ = store.Append(ctx, "j2", 0, jobstore.JobEvent{JobID: "j2", Type: jobstore.JobCreated, Payload: []byte(`{"goal":"invoice audit"}`)})
+
+	res, err := index.Search(ctx, Query{TenantID: "t1", Q: "invoice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 {
+		t.Fatalf("expected tool output + reasoning hits in tenant t1 (idempotency_key is skipped), got %+v", res.Hits)
+	}
+	if res.Hits[0].Scope != ScopeReasoning || res.Hits[1].Scope != ScopeToolOutputs || res.Hits[1].NodeID != "n1" || res.Hits[1].AgentID != "a1" {
+		t.Fatalf("unexpected hits: %+v", res.Hits)
+	}
+	if !strings.Contains(res.Hits[1].Snippet, HighlightStart+"invoice"+HighlightStop) {
+		t.Fatalf("snippet not highlighted: %q", res.Hits[1].Snippet)
+	}
+
+	res, _ = index.Search(ctx, Query{TenantID: "t1", Q: "Upstream TIMEOUT", Scopes: []Scope{ScopeErrors}})
+	if len(res.Hits) != 1 || res.Hits[0].Version != 2 || res.Hits[0].EventType != "tool_invocation_finished" {
+		t.Fatalf("unexpected error hits: %+v", res.Hits)
+	}
+	res, _ = index.Search(ctx, Query{TenantID: "t1", Q: "quarterly", Scopes: []Scope{ScopeGoals}, AgentIDs: []string{"a2"}})
+	if len(res.Hits) != 0 {
+		t.Fatalf("agent filter ignored: %+v", res.Hits)
+	}
+	res, _ = index.Search(ctx, Query{TenantID: "t2", Q: "invoice"})
+	if len(res.Hits) != 1 || res.Hits[0].JobID != "j2" {
+		t.Fatalf("tenant isolation broken: %+v", res.Hits)
+	}
+}
+
+func TestExtract_SkipFieldsAndClip(t *testing.T) {
+	ev := jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCreated, Payload: []byte(`{"goal":"secret plan"}`)}
+	if docs := Extract(ev, 1, []string{"goal"}, 0); len(docs) != 0 {
+		t.Fatalf("encrypted goal must not be indexed: %+v", docs)
+	}
+	ev = jobstore.JobEvent{JobID: "j1", Type: jobstore.ToolReturned, Payload: []byte(`{"output":"héllo wörld"}`)}
+	docs := Extract(ev, 2, nil, 2)
+	if len(docs) != 1 || docs[0].Text != "h" {
+		t.Fatalf("expected clip at a rune boundary, got %+v", docs)
+	}
+	ev = jobstore.JobEvent{JobID: "j1", Type: jobstore.NodeStarted, Payload: []byte(`{"node_id":"n1"}`)}
+	if docs := Extract(ev, 3, nil, 0); len(docs) != 0 {
+		t.Fatalf("unindexed event produced docs: %+v", docs)
+	}
+}
+
+func TestIndexMem_CursorPaging(t *testing.T) {
+	ctx := context.Background()
+	index := NewIndexMem()
+	for v := 1; v <= 5; v++ {
+		_ = index.Add(ctx, Doc{JobID: "j1", Version: v, TenantID: "t1", Scope: ScopeToolOutputs, Text: "same text"})
+	}
+	_ = index.Add(ctx, Doc{JobID: "j1", Version: 1, TenantID: "t1", Scope: ScopeToolOutputs, Text: "same text"})
+
+	var seen []int
+	q := Query{TenantID: "t1", Q: "text", Limit: 2}
+	for {
+		res, err := index.Search(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range res.Hits {
+			seen = append(seen, h.Version)
+		}
+		if res.NextCursor == "" {
+			break
+		}
+		q.Cursor = res.NextCursor
+	}
+	if len(seen) != 5 || seen[0] != 5 || seen[4] != 1 {
+		t.Fatalf("paging = %v", seen)
+	}
+	if _, err := index.Search(ctx, Query{TenantID: "t1", Q: "text", Cursor: "!!"}); err != ErrInvalidCursor {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+)
+
+// DefaultMaxDocBytes 单条文档文本上限；超出部分截断
+const DefaultMaxDocBytes = 16 * 1024
+
+var scopeByType = map[jobstore.EventType]Scope{
+	jobstore.JobCreated:             ScopeGoals,
+	jobstore.ToolReturned:           ScopeToolOutputs,
+	jobstore.ToolInvocationFinished: ScopeToolOutputs,
+	jobstore.ToolResultSummarized:   ScopeToolOutputs,
+	jobstore.ToolOutputSpilled:      ScopeToolOutputs,
+	jobstore.ReasoningSnapshot:      ScopeReasoning,
+	jobstore.AgentThoughtRecorded:   ScopeReasoning,
+	jobstore.DecisionSnapshot:       ScopeReasoning,
+}
+
+// 不参与索引的结构字段：标识、哈希与时间
+var skipKeys = map[string]bool{
+	"id": true, "job_id": true, "node_id": true, "step_id": true, "agent_id": true, "tenant_id": true,
+	"idempotency_key": true, "invocation_id": true, "trace_id": true, "span_id": true, "request_id": true,
+	"sha256": true, "hash": true, "uri": true,
+}
+
+func skipKey(k string) bool {
+	return skipKeys[k] || strings.HasSuffix(k, "_id") || strings.HasSuffix(k, "_hash") || strings.HasSuffix(k, "_sha256") || strings.HasSuffix(k, "_at")
+}
+
+// Extract 从一条事件抽取各范围的文档（不含租户、Agent 与时间）；skipFields 中的字段（任意层级）不入索引，如已加密的 goal
+func Extract(event jobstore.JobEvent, version int, skipFields []string, maxBytes int) []Doc {
+	var m map[string]interface{}
+	if len(event.Payload) == 0 || json.Unmarshal(event.Payload, &m) != nil {
+		return nil
+	}
+	skip := make(map[string]bool, len(skipFields))
+	for _, f := range skipFields {
+		skip[f] = true
+	}
+	nodeID, _ := m["node_id"].(string)
+	newDoc := func(scope Scope, text string) Doc {
+		return Doc{JobID: event.JobID, Version: version, Scope: scope, EventType: string(event.Type), NodeID: nodeID, Text: clip(text, maxBytes)}
+	}
+	var docs []Doc
+	switch scope := scopeByType[event.Type]; scope {
+	case ScopeGoals:
+		if goal, _ := m["goal"].(string); goal != "" && !skip["goal"] {
+			docs = append(docs, newDoc(ScopeGoals, goal))
+		}
+	case ScopeToolOutputs, ScopeReasoning:
+		var parts []string
+		collectText(m, skip, &parts)
+		if len(parts) > 0 {
+			docs = append(docs, newDoc(scope, strings.Join(parts, "\n")))
+		}
+	}
+	if msg, _ := m["error"].(string); msg != "" && !skip["error"] {
+		docs = append(docs, newDoc(ScopeErrors, msg))
+	}
+	return docs
+}
+
+// collectText 按键名排序收集字符串叶子，保证同一 payload 的文本稳定
+func collectText(v interface{}, skip map[string]bool, out *[]string) {
+	switch t := v.(type) {
+	case string:
+		if s := strings.TrimSpace(t); s != "" {
+			*out = append(*out, s)
+		}
+	case []interface{}:
+		for _, e := range t {
+			collectText(e, skip, out)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			if !skip[k] && !skipKey(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			collectText(t[k], skip, out)
+		}
+	}
+}
+
+func clip(s string, maxBytes int) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxBytes], "")
+}
+
+// JobResolver 解析 job 所属租户与 Agent
+type JobResolver func(ctx context.Context, jobID string) (tenantID, agentID string, err error)
+
+// JobsResolver 从 Job 元数据解析租户与 Agent
+func JobsResolver(jobs job.JobStore) JobResolver {
+	return func(ctx context.Context, jobID string) (string, string, error) {
+		j, err := jobs.Get(ctx, jobID)
+		if err != nil {
+			return "", "", err
+		}
+		if j == nil {
+			return "", "", fmt.Errorf("job %s not found", jobID)
+		}
+		tenantID := j.TenantID
+		if tenantID == "" {
+			tenantID = "default"
+		}
+		return tenantID, j.AgentID, nil
+	}
+}
+
+const maxCachedJobs = 4096
+
+type jobInfo struct{ tenantID, agentID string }
+
+// IndexingStore JobStore 装饰器：Append 成功后抽取文本写入 Index；抽取或写入失败不影响 Append 结果
+type IndexingStore struct {
+	jobstore.JobStore
+	index      Index
+	resolve    JobResolver
+	skipFields []string
+	maxBytes   int
+	logger     *log.Logger
+
+	mu   sync.Mutex
+	jobs map[string]jobInfo
+}
+
+// NewIndexingStore 包装 inner；skipFields 为不入索引的字段（如载荷加密字段），maxBytes <= 0 时使用 DefaultMaxDocBytes
+func NewIndexingStore(inner jobstore.JobStore, index Index, resolve JobResolver, skipFields []string, maxBytes int, logger *log.Logger) *IndexingStore {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDocBytes
+	}
+	return &IndexingStore{JobStore: inner, index: index, resolve: resolve, skipFields: skipFields, maxBytes: maxBytes, logger: logger, jobs: make(map[string]jobInfo)}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *IndexingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+// Append 先写入底层事件流，成功后抽取文本入索引
+func (s *IndexingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	newVersion, err := s.JobStore.Append(ctx, jobID, expectedVersion, event)
+	if err != nil {
+		return newVersion, err
+	}
+	if event.JobID == "" {
+		event.JobID = jobID
+	}
+	docs := Extract(event, newVersion, s.skipFields, s.maxBytes)
+	if len(docs) == 0 {
+		return newVersion, nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	info, ierr := s.job(ctx, jobID)
+	if ierr != nil {
+		s.warn("解析 Job 租户失败，事件未入检索索引", jobID, event.Type, ierr)
+		return newVersion, nil
+	}
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	for i := range docs {
+		docs[i].TenantID, docs[i].AgentID, docs[i].CreatedAt = info.tenantID, info.agentID, createdAt
+	}
+	if aerr := s.index.Add(ctx, docs...); aerr != nil {
+		s.warn("写入事件检索索引失败", jobID, event.Type, aerr)
+	}
+	return newVersion, nil
+}
+
+func (s *IndexingStore) job(ctx context.Context, jobID string) (jobInfo, error) {
+	s.mu.Lock()
+	info, ok := s.jobs[jobID]
+	s.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+	tenantID, agentID, err := s.resolve(ctx, jobID)
+	if err != nil {
+		return jobInfo{}, err
+	}
+	info = jobInfo{tenantID: tenantID, agentID: agentID}
+	s.mu.Lock()
+	if len(s.jobs) >= maxCachedJobs {
+		clear(s.jobs)
+	}
+	s.jobs[jobID] = info
+	s.mu.Unlock()
+	return info, nil
+}
+
+func (s *IndexingStore) warn(msg, jobID string, eventType jobstore.EventType, err error) {
+	if s.logger != nil {
+		s.logger.Warn(msg, "job_id", jobID, "event_type", eventType, "error", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/klauspost/compress/zstd"
+
+	"rag-platform/pkg/metrics"
+)
+
+// PayloadEncodingZstd job_events.payload_encoding 取值：载荷以 zstd 压缩存于 payload_compressed，payload 列为 NULL；空串表示未压缩
+const PayloadEncodingZstd = "zstd"
+
+// DefaultCompressionMinBytes 未配置阈值时，仅压缩不小于该字节数的载荷
+const DefaultCompressionMinBytes = 4096
+
+// ErrUnknownPayloadEncoding 读到无法识别的 payload_encoding（如新版本写入的编码）
+var ErrUnknownPayloadEncoding = errors.New("jobstore: unknown payload encoding")
+
+// decoder 解压不依赖压缩配置：关闭压缩后仍可读取历史压缩行
+var decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+
+// PayloadCompressor 事件载荷压缩：超过阈值的载荷以 zstd 压缩落库，读取时透明解压；压缩后不更小则保留原文
+type PayloadCompressor struct {
+	minBytes int
+	encoder  *zstd.Encoder
+}
+
+// NewPayloadCompressor 创建压缩器；minBytes<=0 时取 DefaultCompressionMinBytes，level 为 fastest|default|better|best，空为 default
+func NewPayloadCompressor(minBytes int, level string) (*PayloadCompressor, error) {
+	if minBytes <= 0 {
+		minBytes = DefaultCompressionMinBytes
+	}
+	lvl := zstd.SpeedDefault
+	if level != "" {
+		ok, l := zstd.EncoderLevelFromString(strings.ToLower(level))
+		if !ok {
+			return nil, fmt.Errorf("jobstore: invalid compression level %q", level)
+		}
+		lvl = l
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(lvl), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &PayloadCompressor{minBytes: minBytes, encoder: enc}, nil
+}
+
+// MinBytes 触发压缩的载荷大小阈值
+func (c *PayloadCompressor) MinBytes() int {
+	return c.minBytes
+}
+
+// Encode 返回落库形式：压缩时 encoding=zstd 且 out 为压缩数据；否则 encoding 为空、out 为原载荷
+func (c *PayloadCompressor) Encode(payload []byte) (out []byte, encoding string) {
+	if c == nil || len(payload) < c.minBytes {
+		observeStoredPayload(len(payload), len(payload))
+		return payload, ""
+	}
+	start := time.Now()
+	compressed := c.encoder.EncodeAll(payload, make([]byte, 0, len(payload)/2))
+	metrics.EventPayloadCodecSeconds.WithLabelValues("compress").Observe(time.Since(start).Seconds())
+	if len(compressed) >= len(payload) {
+		observeStoredPayload(len(payload), len(payload))
+		return payload, ""
+	}
+	observeStoredPayload(len(payload), len(compressed))
+	metrics.EventPayloadCompressionRatio.Observe(float64(len(compressed)) / float64(len(payload)))
+	return compressed, PayloadEncodingZstd
+}
+
+// DecodePayload 按 payload_encoding 还原事件载荷；未压缩时原样返回 plain
+func DecodePayload(encoding string, plain, compressed []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return plain, nil
+	case PayloadEncodingZstd:
+		start := time.Now()
+		out, err := decoder.DecodeAll(compressed, nil)
+		metrics.EventPayloadCodecSeconds.WithLabelValues("decompress").Observe(time.Since(start).Seconds())
+		if err != nil {
+			return nil, fmt.Errorf("jobstore: decompress payload: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPayloadEncoding, encoding)
+	}
+}
+
+func observeStoredPayload(raw, stored int) {
+	metrics.EventPayloadBytesTotal.WithLabelValues("raw").Add(float64(raw))
+	metrics.EventPayloadBytesTotal.WithLabelValues("stored").Add(float64(stored))
+}
+
+// PayloadCompressionSetter 可选接口：支持载荷压缩的事件存储（当前为 Postgres 实现）
+type PayloadCompressionSetter interface {
+	SetPayloadCompressor(c *PayloadCompressor)
+}
+
+// SetPayloadCompressor 设置写入时的载荷压缩器；nil 关闭压缩（已压缩的行仍可读取）
+func (s *pgStore) SetPayloadCompressor(c *PayloadCompressor) {
+	s.compressor = c
+}
+
+// CompressionMigrationStats 存量载荷压缩/解压迁移的统计
+type CompressionMigrationStats struct {
+	Scanned     int64 `json:"scanned"`
+	Rewritten   int64 `json:"rewritten"`
+	BytesBefore int64 `json:"bytes_before"`
+	BytesAfter  int64 `json:"bytes_after"`
+}
+
+// CompressExistingPayloads 按 id 分批压缩存量未压缩事件中超过阈值的载荷；dryRun 时只统计不写回。
+// hash 基于原始载荷计算，压缩不影响 proof chain；可在服务运行时执行，每行单独更新
+func CompressExistingPayloads(ctx context.Context, pool *pgxpool.Pool, c *PayloadCompressor, batchSize int, dryRun bool) (CompressionMigrationStats, error) {
+	var stats CompressionMigrationStats
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	var lastID int64
+	for {
+		rows, err := pool.Query(ctx,
+			`SELECT id, payload::text FROM job_events
+			 WHERE id > $1 AND payload_encoding = '' AND payload IS NOT NULL AND octet_length(payload::text) >= $2
+			 ORDER BY id LIMIT $3`,
+			lastID, c.minBytes, batchSize)
+		if err != nil {
+			return stats, err
+		}
+		type row struct {
+			id      int64
+			payload []byte
+		}
+		var batch []row
+		for rows.Next() {
+			var r row
+			var text string
+			if err := rows.Scan(&r.id, &text); err != nil {
+				rows.Close()
+				return stats, err
+			}
+			r.payload = []byte(text)
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return stats, err
+		}
+		if len(batch) == 0 {
+			return stats, nil
+		}
+		for _, r := range batch {
+			lastID = r.id
+			stats.Scanned++
+			out, enc := c.Encode(r.payload)
+			if enc == "" {
+				continue
+			}
+			stats.Rewritten++
+			stats.BytesBefore += int64(len(r.payload))
+			stats.BytesAfter += int64(len(out))
+			if dryRun {
+				continue
+			}
+			if _, err := pool.Exec(ctx,
+				`UPDATE job_events SET payload = NULL, payload_compressed = $2, payload_encoding = $3 WHERE id = $1 AND payload_encoding = ''`,
+				r.id, out, enc); err != nil {
+				return stats, err
+			}
+		}
+	}
+}
+
+// DecompressExistingPayloads 将已压缩的事件载荷还原为 JSONB（回滚压缩或降级到不支持压缩的版本前执行）
+func DecompressExistingPayloads(ctx context.Context, pool *pgxpool.Pool, batchSize int, dryRun bool) (CompressionMigrationStats, error) {
+	var stats CompressionMigrationStats
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	var lastID int64
+	for {
+		rows, err := pool.Query(ctx,
+			`SELECT id, payload_encoding, payload_compressed FROM job_events
+			 WHERE id > $1 AND payload_encoding <> '' ORDER BY id LIMIT $2`,
+			lastID, batchSize)
+		if err != nil {
+			return stats, err
+		}
+		type row struct {
+			id         int64
+			encoding   string
+			compressed []byte
+		}
+		var batch []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.encoding, &r.compressed); err != nil {
+				rows.Close()
+				return stats, err
+			}
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return stats, err
+		}
+		if len(batch) == 0 {
+			return stats, nil
+		}
+		for _, r := range batch {
+			lastID = r.id
+			stats.Scanned++
+			plain, err := DecodePayload(r.encoding, nil, r.compressed)
+			if err != nil {
+				return stats, fmt.Errorf("job_events id=%d: %w", r.id, err)
+			}
+			stats.Rewritten++
+			stats.BytesBefore += int64(len(r.compressed))
+			stats.BytesAfter += int64(len(plain))
+			if dryRun {
+				continue
+			}
+			if _, err := pool.Exec(ctx,
+				`UPDATE job_events SET payload = $2, payload_compressed = NULL, payload_encoding = '' WHERE id = $1`,
+				r.id, plain); err != nil {
+				return stats, err
+			}
+		}
+	}
+}
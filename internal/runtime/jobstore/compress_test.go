@@ -0,0 +1,147 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPayloadCompressor_RoundTrip(t *testing.T) {
+	c, err := NewPayloadCompressor(64, "fastest")
+	if err != nil {
+		t.Fatalf("NewPayloadCompressor: %v", err)
+	}
+	payload := []byte(`{"result":"` + strings.Repeat("aetheris ", 200) + `"}`)
+	out, enc := c.Encode(payload)
+	if enc != PayloadEncodingZstd {
+		t.Fatalf("encoding = %q, want zstd", enc)
+	}
+	if len(out) >= len(payload) {
+		t.Fatalf("compressed %d bytes, raw %d", len(out), len(payload))
+	}
+	got, err := DecodePayload(enc, nil, out)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("round trip mismatch")
+	}
+}
+
+func TestPayloadCompressor_SkipsSmallAndIncompressible(t *testing.T) {
+	c, err := NewPayloadCompressor(64, "")
+	if err != nil {
+		t.Fatalf("NewPayloadCompressor: %v", err)
+	}
+	small := []byte(`{"a":1}`)
+	if out, enc := c.Encode(small); enc != "" || !bytes.Equal(out, small) {
+		t.Errorf("small payload should be stored as is, got encoding %q", enc)
+	}
+	random := make([]byte, 512)
+	_, _ = rand.Read(random)
+	if out, enc := c.Encode(random); enc != "" || !bytes.Equal(out, random) {
+		t.Errorf("incompressible payload should be stored as is, got encoding %q", enc)
+	}
+	var disabled *PayloadCompressor
+	if _, enc := disabled.Encode(bytes.Repeat([]byte("x"), 10000)); enc != "" {
+		t.Errorf("nil compressor should not compress, got encoding %q", enc)
+	}
+}
+
+func TestDecodePayload_Plain(t *testing.T) {
+	got, err := DecodePayload("", []byte(`{"a":1}`), nil)
+	if err != nil || string(got) != `{"a":1}` {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := DecodePayload("lz4", nil, []byte("x")); !errors.Is(err, ErrUnknownPayloadEncoding) {
+		t.Fatalf("want ErrUnknownPayloadEncoding, got %v", err)
+	}
+	if _, err := DecodePayload(PayloadEncodingZstd, nil, []byte("not zstd")); err == nil {
+		t.Fatal("want error for corrupt data")
+	}
+}
+
+func TestNewPayloadCompressor_InvalidLevel(t *testing.T) {
+	if _, err := NewPayloadCompressor(0, "ultra"); err == nil {
+		t.Fatal("want error for unknown level")
+	}
+	c, err := NewPayloadCompressor(0, "BEST")
+	if err != nil {
+		t.Fatalf("NewPayloadCompressor: %v", err)
+	}
+	if c.MinBytes() != DefaultCompressionMinBytes {
+		t.Errorf("MinBytes = %d, want default", c.MinBytes())
+	}
+}
+
+func TestPgStore_CompressedPayloads(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := newTestPgStore(t, ctx)
+	defer cleanup()
+	pg := store.(*pgStore)
+	large := []byte(`{"output":"` + strings.Repeat("tool output ", 100) + `"}`)
+
+	// 未启用压缩时写入一条，再启用压缩写入一条
+	if _, err := store.Append(ctx, "job-z", 0, JobEvent{Type: JobCreated, Payload: large}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	c, err := NewPayloadCompressor(128, "")
+	if err != nil {
+		t.Fatalf("NewPayloadCompressor: %v", err)
+	}
+	pg.SetPayloadCompressor(c)
+	if _, err := store.Append(ctx, "job-z", 1, JobEvent{Type: PlanGenerated, Payload: large}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	var compressedRows int
+	if err := pg.pool.QueryRow(ctx, `SELECT COUNT(*) FROM job_events WHERE job_id = 'job-z' AND payload_encoding = 'zstd'`).Scan(&compressedRows); err != nil {
+		t.Fatal(err)
+	}
+	if compressedRows != 1 {
+		t.Fatalf("compressed rows = %d, want 1", compressedRows)
+	}
+
+	stats, err := CompressExistingPayloads(ctx, pg.pool, c, 10, false)
+	if err != nil {
+		t.Fatalf("CompressExistingPayloads: %v", err)
+	}
+	if stats.Rewritten != 1 || stats.BytesAfter >= stats.BytesBefore {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	events, _, err := store.ListEvents(ctx, "job-z")
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	for _, e := range events {
+		if !strings.Contains(string(e.Payload), "tool output tool output") {
+			t.Errorf("event %s payload not restored: %q", e.Type, e.Payload)
+		}
+	}
+
+	if _, err := DecompressExistingPayloads(ctx, pg.pool, 10, false); err != nil {
+		t.Fatalf("DecompressExistingPayloads: %v", err)
+	}
+	if err := pg.pool.QueryRow(ctx, `SELECT COUNT(*) FROM job_events WHERE payload_encoding <> ''`).Scan(&compressedRows); err != nil {
+		t.Fatal(err)
+	}
+	if compressedRows != 0 {
+		t.Fatalf("compressed rows after decompress = %d", compressedRows)
+	}
+}
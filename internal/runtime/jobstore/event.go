@@ -15,7 +15,10 @@
 package jobstore
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -89,10 +92,53 @@ const (
 	HumanApprovalGiven   EventType = "human_approval_given"   // 人类审批
 	PaymentExecuted      EventType = "payment_executed"       // 支付执行
 	EmailSent            EventType = "email_sent"             // 邮件发送
+	LLMOutputReviewed    EventType = "llm_output_reviewed"    // llm 节点审阅门：人类通过或编辑生成内容
 
 	// 2.1: Evidence Export audit events
 	EvidenceExportRequested EventType = "evidence_export_requested" // 证据导出请求
 	EvidenceExportCompleted EventType = "evidence_export_completed" // 证据导出完成
+
+	// 行为异常：Job 明显偏离 Agent 行为基线（不参与 Replay，仅用于 Trace 与告警）
+	AgentAnomalyDetected EventType = "agent_anomaly_detected"
+
+	// LLM prompt 留存：prompt 脱敏后写入对象存储，事件只记录引用（不参与 Replay）
+	LLMPromptLogged EventType = "llm_prompt_logged"
+
+	// 幂等工具重执行：Replay 时显式声明幂等的工具无已记录结果，策略决定重新执行而非failed；记录该决策供审计（不参与 Replay）
+	ReplayReexecuted EventType = "replay_reexecuted"
+
+	// 失败诊断：Job 失败后 LLM 给出的可能原因、修复建议与重试是否可能有效（不参与 Replay，仅用于 Trace 与失败通知）
+	JobDiagnosis EventType = "job_diagnosis"
+
+	// 自定义业务事件：步骤经 sdk.EmitEvent 发出的领域里程碑（如 invoice_drafted），不参与 Replay，仅用于 Trace、失败通知与事件导出
+	CustomEvent EventType = "custom_event"
+
+	// 事务组：planner 标记同一 transaction 的 tool 节点全部提交，或失败时对已提交成员逐个补偿（不参与 Replay，仅用于 Trace 与审计）
+	TransactionStarted    EventType = "transaction_started"
+	TransactionCommitted  EventType = "transaction_committed"
+	TransactionRolledBack EventType = "transaction_rolled_back"
+
+	// 工具步内检查点：长时工具经 sdk.CheckpointToolState 周期持久化内部 state；Worker 在工具执行中崩溃后从最近一次 state 续跑（参与 Replay）
+	ToolProgressState EventType = "tool_progress_state"
+
+	// 工具输出溢出：输出超过该工具的大小上限，完整输出写入 Job 工作区，结果中只保留摘要与引用（不参与 Replay，已提交结果即摘要）
+	ToolOutputSpilled EventType = "tool_output_spilled"
+
+	// 执行环境固化：Job 创建时解析出的模型、工具版本、策略/配置哈希与平台版本（environment.Environment）；
+	// replay / verify 据此比对当前环境并报告差异（不参与 Replay）
+	JobEnvironment EventType = "job_environment"
+
+	// 计划审阅：Agent 设置 require_plan_approval 时，PlanGenerated 后 Job 挂起等待审阅；审阅通过（或编辑后通过）的计划哈希与审阅人记录在 plan_reviewed，
+	// Runner 仅在当前计划已通过审阅后开始执行（参与 Replay）
+	PlanReviewRequested EventType = "plan_review_requested"
+	PlanReviewed        EventType = "plan_reviewed"
+
+	// 手动检查点回滚：管理员把 Job 游标重置到此前某个检查点，之后完成的节点被作废、恢复时重新执行（参与 Replay）
+	CheckpointRolledBack EventType = "checkpoint_rolled_back"
+
+	// map 节点逐项迭代：每次尝试前后各写一条，payload 为 MapIterationPayload（不参与 Replay，逐项幂等由工具账本与 Effect Store 保证）
+	MapIterationStarted  EventType = "map_iteration_started"
+	MapIterationFinished EventType = "map_iteration_finished"
 )
 
 // JobWaitingPayload job_waiting 事件 payload 契约；只有携带相同 correlation_key 的 signal 才能解除该 block（design/runtime-contract.md）
@@ -123,6 +169,16 @@ type AgentMessagePayload struct {
 	Payload        map[string]interface{} `json:"payload"`
 }
 
+// LLMOutputReviewedPayload llm_output_reviewed 事件 payload；POST /api/jobs/:id/nodes/:node_id/review 写入，Output 为下游实际使用的内容
+type LLMOutputReviewedPayload struct {
+	NodeID   string `json:"node_id"`
+	Decision string `json:"decision"` // approve | edit
+	Original string `json:"original"`
+	Output   string `json:"output"`
+	Reviewer string `json:"reviewer,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
 // MemoryReadPayload memory_read 事件 payload（design/trace-2.0-cognition.md）
 type MemoryReadPayload struct {
 	JobID      string `json:"job_id,omitempty"`
@@ -147,6 +203,280 @@ type MemoryWritePayload struct {
 type PlanEvolutionPayload struct {
 	PlanVersion int    `json:"plan_version,omitempty"`
 	DiffSummary string `json:"diff_summary,omitempty"`
+	// 以下为 Self-Reflection 提议（source=reflection）：reflect 步对已执行轨迹的复盘与计划修订
+	Source       string          `json:"source,omitempty"`        // reflection
+	Trigger      string          `json:"trigger,omitempty"`       // every_n_steps | on_failure | reflect_node
+	NodeID       string          `json:"node_id,omitempty"`       // 触发反思的节点
+	Critique     string          `json:"critique,omitempty"`      // LLM 对轨迹的复盘结论
+	Policy       string          `json:"policy,omitempty"`        // auto_apply | require_approval
+	Status       string          `json:"status,omitempty"`        // applied | pending_approval | no_change | rejected
+	ProposedPlan json.RawMessage `json:"proposed_plan,omitempty"` // 修订后的 TaskGraph
+}
+
+// DecisionKindBranch branch 节点的分支选择（decision_made.kind）
+const DecisionKindBranch = "branch"
+
+// DecisionMadePayload decision_made 事件 payload（design/trace-event-schema-v0.9.md §4.3）；
+// kind=branch 时由 branch 节点写入表达式与求值结果，Replay 据此注入分支结果而不重新求值
+type DecisionMadePayload struct {
+	NodeID  string          `json:"node_id,omitempty"`
+	Content string          `json:"content"`
+	Kind    string          `json:"kind,omitempty"`
+	Expr    string          `json:"expr,omitempty"`
+	Outcome string          `json:"outcome,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+}
+
+// MapIterationPayload map_iteration_started / map_iteration_finished 事件 payload
+type MapIterationPayload struct {
+	NodeID string `json:"node_id"`
+	Index  int    `json:"index"`
+	// Key 迭代身份（<节点>[<item_key 或下标>]），body 节点的执行 ID 与工具幂等键由其派生
+	Key        string          `json:"key"`
+	Attempt    int             `json:"attempt"`
+	Status     string          `json:"status,omitempty"` // finished：ok | failed
+	Error      string          `json:"error,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	DurationMs int64           `json:"duration_ms,omitempty"`
+}
+
+// ReplayReexecutedPayload replay_reexecuted 事件 payload；随后该步按正常路径执行并写入 command_committed
+type ReplayReexecutedPayload struct {
+	NodeID           string `json:"node_id"`
+	StepID           string `json:"step_id,omitempty"`
+	CommandID        string `json:"command_id,omitempty"`
+	ToolName         string `json:"tool_name"`
+	Reason           string `json:"reason"`                      // no_recorded_result
+	VerificationHint string `json:"verification_hint,omitempty"` // Tool manifest 声明的结果核对方式
+}
+
+// CustomEventPayload custom_event 事件 payload；name 为 sdk.EmitEvent 的事件类型，data 为业务 payload
+type CustomEventPayload struct {
+	Name   string          `json:"name"`
+	NodeID string          `json:"node_id,omitempty"`
+	StepID string          `json:"step_id,omitempty"`
+	Data   json.RawMessage `json:"data"`
+	At     time.Time       `json:"at"`
+}
+
+// ToolProgressStatePayload tool_progress_state 事件 payload；按 idempotency_key 归属到一次工具调用，seq 为该次调用内的检查点序号
+type ToolProgressStatePayload struct {
+	NodeID         string          `json:"node_id"`
+	StepID         string          `json:"step_id,omitempty"`
+	ToolName       string          `json:"tool_name"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	Seq            int             `json:"seq"`
+	State          json.RawMessage `json:"state"`
+	At             time.Time       `json:"at"`
+}
+
+// ToolOutputSpilledPayload tool_output_spilled 事件 payload；uri 为空表示工作区不可用或写入failed，输出仅被截断
+type ToolOutputSpilledPayload struct {
+	NodeID         string    `json:"node_id"`
+	StepID         string    `json:"step_id,omitempty"`
+	ToolName       string    `json:"tool_name"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	OriginalBytes  int       `json:"original_bytes"`
+	LimitBytes     int       `json:"limit_bytes"`
+	SHA256         string    `json:"sha256"`        // 完整输出的 sha256
+	URI            string    `json:"uri,omitempty"` // workspace://<job_id>/tool-outputs/...
+	SpillError     string    `json:"spill_error,omitempty"`
+	Summary        string    `json:"summary"` // 替换进结果、供下游 LLM 步使用的摘要
+	At             time.Time `json:"at"`
+}
+
+// 计划审阅决定
+const (
+	PlanReviewApprove = "approve"
+	PlanReviewEdit    = "edit"
+	PlanReviewReject  = "reject"
+)
+
+// 计划审阅状态
+const (
+	PlanReviewPending  = "pending"
+	PlanReviewApproved = "approved"
+	PlanReviewRejected = "rejected"
+)
+
+// PlanReviewRequestedPayload plan_review_requested 事件 payload
+type PlanReviewRequestedPayload struct {
+	PlanHash string `json:"plan_hash"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// PlanReviewedPayload plan_reviewed 事件 payload；POST /api/jobs/:id/plan/review 写入。
+// edit 时先追加编辑后的 PlanGenerated，PlanHash 为编辑后计划的哈希，OriginalPlanHash 为被替换的计划
+type PlanReviewedPayload struct {
+	Decision         string    `json:"decision"` // approve | edit | reject
+	PlanHash         string    `json:"plan_hash"`
+	OriginalPlanHash string    `json:"original_plan_hash,omitempty"`
+	Reviewer         string    `json:"reviewer,omitempty"`
+	Comment          string    `json:"comment,omitempty"`
+	At               time.Time `json:"at"`
+}
+
+// PlanReview 由事件流推导的计划审阅状态
+type PlanReview struct {
+	Status   string                `json:"status"` // pending | approved | rejected
+	PlanHash string                `json:"plan_hash"`
+	Reviews  []PlanReviewedPayload `json:"reviews,omitempty"`
+}
+
+// PlanHash 计划哈希：task_graph JSON 的 sha256（十六进制），与 PlanGenerated 中的 plan_hash 一致
+func PlanHash(taskGraph []byte) string {
+	if len(taskGraph) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(taskGraph)
+	return hex.EncodeToString(sum[:])
+}
+
+// PlanGeneratedReview 解析 PlanGenerated payload 中的计划哈希（缺失时按 task_graph 计算）与是否需要审阅
+func PlanGeneratedReview(payload []byte) (planHash string, reviewRequired bool) {
+	var pl struct {
+		TaskGraph      json.RawMessage `json:"task_graph"`
+		PlanHash       string          `json:"plan_hash"`
+		ReviewRequired bool            `json:"review_required"`
+	}
+	if json.Unmarshal(payload, &pl) != nil {
+		return "", false
+	}
+	if pl.PlanHash == "" {
+		pl.PlanHash = PlanHash(pl.TaskGraph)
+	}
+	return pl.PlanHash, pl.ReviewRequired
+}
+
+// PlanReviewOf 推导当前计划（最后一条 PlanGenerated）的审阅状态；计划无需审阅且无审阅记录时返回 nil
+func PlanReviewOf(events []JobEvent) *PlanReview {
+	var out *PlanReview
+	required := false
+	var reviews []PlanReviewedPayload
+	status := PlanReviewPending
+	planHash := ""
+	for _, e := range events {
+		switch e.Type {
+		case PlanGenerated:
+			planHash, required = PlanGeneratedReview(e.Payload)
+			status = PlanReviewPending
+		case PlanReviewed:
+			var pl PlanReviewedPayload
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			if pl.At.IsZero() {
+				pl.At = e.CreatedAt
+			}
+			reviews = append(reviews, pl)
+			if pl.PlanHash != planHash {
+				continue
+			}
+			switch pl.Decision {
+			case PlanReviewApprove, PlanReviewEdit:
+				status = PlanReviewApproved
+			case PlanReviewReject:
+				status = PlanReviewRejected
+			}
+		}
+	}
+	if required || len(reviews) > 0 {
+		out = &PlanReview{Status: status, PlanHash: planHash, Reviews: reviews}
+		if !required && status == PlanReviewPending {
+			out.Status = PlanReviewApproved
+		}
+	}
+	return out
+}
+
+// CustomEventsOf 按顺序提取事件流中的自定义业务事件；无法解析的 payload 跳过
+func CustomEventsOf(events []JobEvent) []CustomEventPayload {
+	var out []CustomEventPayload
+	for _, e := range events {
+		if e.Type != CustomEvent {
+			continue
+		}
+		var pl CustomEventPayload
+		if json.Unmarshal(e.Payload, &pl) != nil || pl.Name == "" {
+			continue
+		}
+		if pl.At.IsZero() {
+			pl.At = e.CreatedAt
+		}
+		out = append(out, pl)
+	}
+	return out
+}
+
+// 事务组状态
+const (
+	TransactionStatusOpen       = "open"
+	TransactionStatusCommitted  = "committed"
+	TransactionStatusRolledBack = "rolled_back"
+)
+
+// 事务组成员补偿结果
+const (
+	CompensationStatusCompensated = "compensated"
+	CompensationStatusFailed      = "failed"
+	CompensationStatusSkipped     = "skipped" // 未声明 compensate 且无注册的补偿函数
+)
+
+// TransactionCompensation 回滚时单个已提交成员的补偿结果
+type TransactionCompensation struct {
+	NodeID string `json:"node_id"`
+	Tool   string `json:"tool,omitempty"`
+	Status string `json:"status"` // compensated | failed | skipped
+	Error  string `json:"error,omitempty"`
+}
+
+// TransactionPayload transaction_started / transaction_committed / transaction_rolled_back 事件 payload
+type TransactionPayload struct {
+	TransactionID string                    `json:"transaction_id"`
+	Members       []string                  `json:"members"`
+	FailedNodeID  string                    `json:"failed_node_id,omitempty"` // 仅 rolled_back
+	Reason        string                    `json:"reason,omitempty"`         // 仅 rolled_back
+	Compensations []TransactionCompensation `json:"compensations,omitempty"`  // 仅 rolled_back，按执行顺序（提交逆序）
+}
+
+// TransactionSummary 单个事务组的最终状态，供 Trace 分组展示
+type TransactionSummary struct {
+	TransactionPayload
+	Status string `json:"status"` // open | committed | rolled_back
+}
+
+// TransactionsOf 按首次出现顺序汇总事件流中的事务组；同一事务组以最后一次边界事件为准，无法解析的 payload 跳过
+func TransactionsOf(events []JobEvent) []TransactionSummary {
+	var out []TransactionSummary
+	idx := make(map[string]int)
+	for _, e := range events {
+		var status string
+		switch e.Type {
+		case TransactionStarted:
+			status = TransactionStatusOpen
+		case TransactionCommitted:
+			status = TransactionStatusCommitted
+		case TransactionRolledBack:
+			status = TransactionStatusRolledBack
+		default:
+			continue
+		}
+		var pl TransactionPayload
+		if json.Unmarshal(e.Payload, &pl) != nil || pl.TransactionID == "" {
+			continue
+		}
+		s := TransactionSummary{TransactionPayload: pl, Status: status}
+		if i, ok := idx[pl.TransactionID]; ok {
+			if len(s.Members) == 0 {
+				s.Members = out[i].Members
+			}
+			out[i] = s
+			continue
+		}
+		idx[pl.TransactionID] = len(out)
+		out = append(out, s)
+	}
+	return out
 }
 
 // JobEvent 单条不可变事件；Job 的真实形态是事件流
@@ -160,4 +490,39 @@ type JobEvent struct {
 	// 2.0-M1: Proof chain for tamper detection
 	PrevHash string // 上一个事件的 hash（SHA256）
 	Hash     string // 当前事件 hash（SHA256(JobID|Type|Payload|Timestamp|PrevHash)）
+
+	// Actor 追加该事件的主体（Worker 为 worker:<worker_id>[@<service_account_id>]，API 侧为空）；用于取证归属，不参与 hash
+	Actor string
+	// Attribution 发起该 Job 的用户、客户端与原始请求 ID（由 job.AttributingStore 按 Job 元数据填充）；不参与 hash
+	Attribution *Attribution
+}
+
+// 发起 Job 的客户端类型（Attribution.Client）
+const (
+	ClientAPI = "api" // API 直连（含 API key / 服务间调用）
+	ClientCLI = "cli"
+	ClientUI  = "ui"
+	ClientSDK = "sdk"
+)
+
+// Attribution Job 发起归属：创建 Job 的用户、客户端与原始请求 ID，使工具的每个副作用都能追溯到提出请求的人
+type Attribution struct {
+	UserID    string `json:"user_id,omitempty"`
+	Client    string `json:"client,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// IsZero 是否无任何归属信息
+func (a *Attribution) IsZero() bool {
+	return a == nil || (a.UserID == "" && a.Client == "" && a.RequestID == "")
+}
+
+// NormalizeClient 将客户端声明（X-Aetheris-Client）归一为已知类型，未知或为空时视为 api
+func NormalizeClient(client string) string {
+	switch c := strings.ToLower(strings.TrimSpace(client)); c {
+	case ClientCLI, ClientUI, ClientSDK:
+		return c
+	default:
+		return ClientAPI
+	}
 }
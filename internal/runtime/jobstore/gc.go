@@ -58,7 +58,7 @@ func GC(ctx context.Context, store JobStore, config GCConfig) error {
 		return nil
 	}
 
-	lifecycleStore, ok := store.(EffectLifecycleStore)
+	lifecycleStore, ok := Find[EffectLifecycleStore](store)
 	if !ok {
 		// 兼容unsupported effect lifecycle 的 JobStore（memory/legacy）
 		return nil
@@ -23,6 +23,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"rag-platform/pkg/idgen"
 	"rag-platform/pkg/metrics"
 )
 
@@ -72,6 +73,30 @@ func (s *memoryStore) ListEvents(ctx context.Context, jobID string) ([]JobEvent,
 	return out, version, nil
 }
 
+// ListEventsSince 实现 EventRangeLister：仅复制 afterVersion 之后的事件
+func (s *memoryStore) ListEventsSince(ctx context.Context, jobID string, afterVersion int) ([]JobEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := s.byJob[jobID]
+	version := len(events)
+	if afterVersion < 0 {
+		afterVersion = 0
+	}
+	if afterVersion >= version {
+		return nil, version, nil
+	}
+	out := make([]JobEvent, 0, version-afterVersion)
+	for i := afterVersion; i < version; i++ {
+		e := events[i]
+		if len(e.Payload) > 0 {
+			e.Payload = make([]byte, len(events[i].Payload))
+			copy(e.Payload, events[i].Payload)
+		}
+		out = append(out, e)
+	}
+	return out, version, nil
+}
+
 func (s *memoryStore) Append(ctx context.Context, jobID string, expectedVersion int, event JobEvent) (int, error) {
 	if jobID == "" {
 		return 0, ErrVersionMismatch
@@ -87,7 +112,7 @@ func (s *memoryStore) Append(ctx context.Context, jobID string, expectedVersion
 		}
 	}
 	if event.ID == "" {
-		event.ID = "ev-" + uuid.New().String()
+		event.ID = "ev-" + idgen.NewID()
 	}
 	event.JobID = jobID
 	if event.CreatedAt.IsZero() {
@@ -61,6 +61,56 @@ func TestMemoryStore_Append_ListEvents(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_ListEventsSince(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	jobID := "job-1"
+	for i, typ := range []EventType{JobCreated, PlanGenerated, NodeStarted} {
+		if _, err := s.Append(ctx, jobID, i, JobEvent{JobID: jobID, Type: typ}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	events, ver, err := EventsSince(ctx, s, jobID, 1)
+	if err != nil {
+		t.Fatalf("EventsSince: %v", err)
+	}
+	if ver != 3 || len(events) != 2 || events[0].Type != PlanGenerated || events[1].Type != NodeStarted {
+		t.Errorf("got version %d events %+v", ver, events)
+	}
+	events, ver, err = EventsSince(ctx, s, jobID, 3)
+	if err != nil || ver != 3 || len(events) != 0 {
+		t.Errorf("up to date: version %d, %d events, err %v", ver, len(events), err)
+	}
+}
+
+// passThroughStore 只透传读取的装饰器：不实现 EventRangeLister，全量 ListEvents 视为回退
+type passThroughStore struct {
+	JobStore
+	t *testing.T
+}
+
+func (s *passThroughStore) Unwrap() JobStore { return s.JobStore }
+
+func (s *passThroughStore) ListEvents(ctx context.Context, jobID string) ([]JobEvent, int, error) {
+	s.t.Fatal("EventsSince fell back to a full ListEvents through a pass-through decorator")
+	return nil, 0, nil
+}
+
+func TestEventsSince_ThroughDecorator(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMemoryStore()
+	jobID := "job-1"
+	for i, typ := range []EventType{JobCreated, PlanGenerated} {
+		if _, err := inner.Append(ctx, jobID, i, JobEvent{JobID: jobID, Type: typ}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	events, ver, err := EventsSince(ctx, &passThroughStore{JobStore: inner, t: t}, jobID, 1)
+	if err != nil || ver != 2 || len(events) != 1 || events[0].Type != PlanGenerated {
+		t.Errorf("got version %d events %+v err %v", ver, events, err)
+	}
+}
+
 func TestMemoryStore_Append_VersionMismatch(t *testing.T) {
 	ctx := context.Background()
 	s := NewMemoryStore()
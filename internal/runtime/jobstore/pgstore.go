@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"rag-platform/internal/storage/pgretry"
 	"rag-platform/pkg/metrics"
 )
 
@@ -37,6 +39,20 @@ const watchPollInterval = 500 * time.Millisecond
 type pgStore struct {
 	pool     *pgxpool.Pool
 	leaseDur time.Duration
+	// compressor 非 nil 时超过阈值的载荷以 zstd 压缩落库（见 compress.go）
+	compressor *PayloadCompressor
+	// guard 非 nil 时短暂的数据库错误按操作类别重试，认领在降级期间暂停（见 SetResilienceGuard）
+	guard *pgretry.Guard
+}
+
+// ResilienceGuardSetter 可选接口：支持短暂错误重试与降级的存储（当前为 Postgres 实现）
+type ResilienceGuardSetter interface {
+	SetResilienceGuard(g *pgretry.Guard)
+}
+
+// SetResilienceGuard 设置重试与熔断；nil 时数据库错误直接返回
+func (s *pgStore) SetResilienceGuard(g *pgretry.Guard) {
+	s.guard = g
 }
 
 // NewPostgresStore 创建基于 PostgreSQL 的 JobStore；dsn 为连接串，leaseDuration 为租约时长（≤0 则 30s）
@@ -59,14 +75,30 @@ func NewPostgresStore(ctx context.Context, dsn string, leaseDuration time.Durati
 	return &pgStore{pool: pool, leaseDur: leaseDuration}, nil
 }
 
+// NewPostgresStoreWithPool 基于已有连接池创建 JobStore（连接池由 pgpool.Manager 统一管理，调用方负责关闭）
+func NewPostgresStoreWithPool(pool *pgxpool.Pool, leaseDuration time.Duration) JobStore {
+	if leaseDuration <= 0 {
+		leaseDuration = defaultLeaseDuration
+	}
+	return &pgStore{pool: pool, leaseDur: leaseDuration}
+}
+
 // Close 关闭连接池（可选，用于优雅退出）
 func (s *pgStore) Close() {
 	s.pool.Close()
 }
 
-func (s *pgStore) ListEvents(ctx context.Context, jobID string) ([]JobEvent, int, error) {
+func (s *pgStore) ListEvents(ctx context.Context, jobID string) (events []JobEvent, version int, err error) {
+	err = s.guard.Do(ctx, "list_events", pgretry.Idempotent, func() error {
+		events, version, err = s.listEvents(ctx, jobID)
+		return err
+	})
+	return events, version, err
+}
+
+func (s *pgStore) listEvents(ctx context.Context, jobID string) ([]JobEvent, int, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, job_id, version, type, payload, created_at, prev_hash, hash FROM job_events WHERE job_id = $1 ORDER BY version`,
+		`SELECT id, job_id, version, type, payload, payload_encoding, payload_compressed, created_at, prev_hash, hash, actor, attribution FROM job_events WHERE job_id = $1 ORDER BY version`,
 		jobID)
 	if err != nil {
 		return nil, 0, err
@@ -77,11 +109,16 @@ func (s *pgStore) ListEvents(ctx context.Context, jobID string) ([]JobEvent, int
 		var e JobEvent
 		var id int64
 		var version int
-		var typeStr string
-		var payload []byte
-		if err := rows.Scan(&id, &e.JobID, &version, &typeStr, &payload, &e.CreatedAt, &e.PrevHash, &e.Hash); err != nil {
+		var typeStr, encoding string
+		var payload, compressed, attribution []byte
+		if err := rows.Scan(&id, &e.JobID, &version, &typeStr, &payload, &encoding, &compressed, &e.CreatedAt, &e.PrevHash, &e.Hash, &e.Actor, &attribution); err != nil {
+			return nil, 0, err
+		}
+		payload, err := DecodePayload(encoding, payload, compressed)
+		if err != nil {
 			return nil, 0, err
 		}
+		e.Attribution = ParseAttribution(attribution)
 		e.ID = strconv.FormatInt(id, 10)
 		e.Type = EventType(typeStr)
 		_ = version // 已按 version 排序，返回值用 len(events)
@@ -98,24 +135,145 @@ func (s *pgStore) ListEvents(ctx context.Context, jobID string) ([]JobEvent, int
 	return events, version, nil
 }
 
+// ListEventsSince 实现 EventRangeLister：仅查询 version > afterVersion 的事件；当前 version 取 MAX(version)
+func (s *pgStore) ListEventsSince(ctx context.Context, jobID string, afterVersion int) (events []JobEvent, version int, err error) {
+	err = s.guard.Do(ctx, "list_events_since", pgretry.Idempotent, func() error {
+		events, version, err = s.listEventsSince(ctx, jobID, afterVersion)
+		return err
+	})
+	return events, version, err
+}
+
+func (s *pgStore) listEventsSince(ctx context.Context, jobID string, afterVersion int) ([]JobEvent, int, error) {
+	var currentMax *int
+	if err := s.pool.QueryRow(ctx, `SELECT MAX(version) FROM job_events WHERE job_id = $1`, jobID).Scan(&currentMax); err != nil {
+		return nil, 0, err
+	}
+	version := 0
+	if currentMax != nil {
+		version = *currentMax
+	}
+	if afterVersion >= version {
+		return nil, version, nil
+	}
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, job_id, type, payload, payload_encoding, payload_compressed, created_at, prev_hash, hash, actor, attribution FROM job_events WHERE job_id = $1 AND version > $2 AND version <= $3 ORDER BY version`,
+		jobID, afterVersion, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var events []JobEvent
+	for rows.Next() {
+		var e JobEvent
+		var id int64
+		var typeStr, encoding string
+		var payload, compressed, attribution []byte
+		if err := rows.Scan(&id, &e.JobID, &typeStr, &payload, &encoding, &compressed, &e.CreatedAt, &e.PrevHash, &e.Hash, &e.Actor, &attribution); err != nil {
+			return nil, 0, err
+		}
+		payload, err := DecodePayload(encoding, payload, compressed)
+		if err != nil {
+			return nil, 0, err
+		}
+		e.Attribution = ParseAttribution(attribution)
+		e.ID = strconv.FormatInt(id, 10)
+		e.Type = EventType(typeStr)
+		if len(payload) > 0 {
+			e.Payload = make([]byte, len(payload))
+			copy(e.Payload, payload)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return events, version, nil
+}
+
+// Append 版本 CAS 保证重试不会重复写入；若上一次尝试的结果未知（连接在提交后断开），重试时遇到 version 冲突会核对
+// 该 version 上的事件 hash，与本次事件一致则视为已追加成功
 func (s *pgStore) Append(ctx context.Context, jobID string, expectedVersion int, event JobEvent) (int, error) {
 	if jobID == "" {
 		return 0, ErrVersionMismatch
 	}
+	event.JobID = jobID
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	var newVersion int
+	attempts := 0
+	err := s.guard.Do(ctx, "append", pgretry.Idempotent, func() error {
+		attempts++
+		v, err := s.append(ctx, jobID, expectedVersion, event)
+		if errors.Is(err, ErrVersionMismatch) && attempts > 1 && s.appendedAt(ctx, jobID, expectedVersion+1, event) {
+			v, err = expectedVersion+1, nil
+		}
+		newVersion = v
+		return err
+	})
+	return newVersion, err
+}
+
+// appendedAt 判断 version 上的事件是否即为 event（按 hash 核对）
+func (s *pgStore) appendedAt(ctx context.Context, jobID string, version int, event JobEvent) bool {
+	var hash, prevHash string
+	if err := s.pool.QueryRow(ctx, `SELECT hash, prev_hash FROM job_events WHERE job_id = $1 AND version = $2`, jobID, version).Scan(&hash, &prevHash); err != nil {
+		return false
+	}
+	payload := event.Payload
+	if payload == nil {
+		payload = []byte("null")
+	}
+	return hash == computeEventHash(jobID, event.Type, payload, event.CreatedAt, prevHash)
+}
+
+// AppendTxHook 在事件插入的同一事务内执行，version 为新事件的版本；返回错误时整个追加回滚
+type AppendTxHook func(ctx context.Context, tx pgx.Tx, version int) error
+
+type appendTxHookKey struct{}
+
+// WithAppendTxHook 为本次 Append 附加事务内写入（如事件导出 outbox）；仅 Postgres 实现执行，其余实现忽略
+func WithAppendTxHook(ctx context.Context, hook AppendTxHook) context.Context {
+	return context.WithValue(ctx, appendTxHookKey{}, hook)
+}
+
+func appendTxHookFrom(ctx context.Context) AppendTxHook {
+	hook, _ := ctx.Value(appendTxHookKey{}).(AppendTxHook)
+	return hook
+}
+
+// pgQuerier pgxpool.Pool 与 pgx.Tx 共有的查询方法
+type pgQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (s *pgStore) append(ctx context.Context, jobID string, expectedVersion int, event JobEvent) (int, error) {
+	var db pgQuerier = s.pool
+	hook := appendTxHookFrom(ctx)
+	var tx pgx.Tx
+	if hook != nil {
+		var err error
+		if tx, err = s.pool.Begin(ctx); err != nil {
+			return 0, err
+		}
+		defer tx.Rollback(ctx)
+		db = tx
+	}
 	attemptID := AttemptIDFromContext(ctx)
 	if attemptID != "" {
 		var claimAttemptID string
-		err := s.pool.QueryRow(ctx, `SELECT attempt_id FROM job_claims WHERE job_id = $1 AND expires_at > now()`, jobID).Scan(&claimAttemptID)
+		err := db.QueryRow(ctx, `SELECT attempt_id FROM job_claims WHERE job_id = $1 AND expires_at > now()`, jobID).Scan(&claimAttemptID)
+		if err != nil && !errNoRows(err) {
+			return 0, err
+		}
 		if err != nil || claimAttemptID != attemptID {
 			metrics.LeaseConflictTotal.WithLabelValues("unknown").Inc()
 			return 0, ErrStaleAttempt
 		}
 	}
 	newVersion := expectedVersion + 1
-	event.JobID = jobID
-	if event.CreatedAt.IsZero() {
-		event.CreatedAt = time.Now()
-	}
 	payload := event.Payload
 	if payload == nil {
 		payload = []byte("null")
@@ -123,7 +281,7 @@ func (s *pgStore) Append(ctx context.Context, jobID string, expectedVersion int,
 
 	// CAS：仅当当前 max(version) = expectedVersion 时插入
 	var currentMax *int
-	err := s.pool.QueryRow(ctx, `SELECT MAX(version) FROM job_events WHERE job_id = $1`, jobID).Scan(&currentMax)
+	err := db.QueryRow(ctx, `SELECT MAX(version) FROM job_events WHERE job_id = $1`, jobID).Scan(&currentMax)
 	if err != nil {
 		return 0, err
 	}
@@ -138,7 +296,7 @@ func (s *pgStore) Append(ctx context.Context, jobID string, expectedVersion int,
 	// 2.0-M1: 查询前一个事件的 hash（用于构建 proof chain）
 	var prevHash string
 	if expectedVersion > 0 {
-		err = s.pool.QueryRow(ctx,
+		err = db.QueryRow(ctx,
 			`SELECT hash FROM job_events WHERE job_id = $1 AND version = $2`,
 			jobID, expectedVersion).Scan(&prevHash)
 		if err != nil && !errNoRows(err) {
@@ -149,19 +307,41 @@ func (s *pgStore) Append(ctx context.Context, jobID string, expectedVersion int,
 	// 2.0-M1: 计算当前事件的 hash
 	eventHash := computeEventHash(jobID, event.Type, payload, event.CreatedAt, prevHash)
 
-	_, err = s.pool.Exec(ctx,
-		`INSERT INTO job_events (job_id, version, type, payload, created_at, prev_hash, hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		jobID, newVersion, string(event.Type), payload, event.CreatedAt, prevHash, eventHash)
+	// 载荷压缩：hash 基于原始载荷，压缩后 payload 列为 NULL、数据存于 payload_compressed
+	var plain, compressed []byte = payload, nil
+	stored, encoding := s.compressor.Encode(payload)
+	if encoding != "" {
+		plain, compressed = nil, stored
+	}
+	_, err = db.Exec(ctx,
+		`INSERT INTO job_events (job_id, version, type, payload, payload_encoding, payload_compressed, created_at, prev_hash, hash, actor, attribution) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		jobID, newVersion, string(event.Type), plain, encoding, compressed, event.CreatedAt, prevHash, eventHash, event.Actor, attributionToPg(event.Attribution))
 	if err != nil {
 		if isUniqueViolation(err) {
 			return 0, ErrVersionMismatch
 		}
 		return 0, err
 	}
+	if hook != nil {
+		if err := hook(ctx, tx, newVersion); err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return 0, err
+		}
+	}
 	return newVersion, nil
 }
 
-func (s *pgStore) Claim(ctx context.Context, workerID string) (string, int, string, error) {
+func (s *pgStore) Claim(ctx context.Context, workerID string) (jobID string, version int, attemptID string, err error) {
+	err = s.guard.Do(ctx, "claim", pgretry.Claim, func() error {
+		jobID, version, attemptID, err = s.claim(ctx, workerID)
+		return err
+	})
+	return jobID, version, attemptID, err
+}
+
+func (s *pgStore) claim(ctx context.Context, workerID string) (string, int, string, error) {
 	now := time.Now()
 	expires := now.Add(s.leaseDur)
 	attemptID := "attempt-" + uuid.New().String()
@@ -208,7 +388,15 @@ func (s *pgStore) Claim(ctx context.Context, workerID string) (string, int, stri
 	return claimedID, claimedVersion, attemptID, nil
 }
 
-func (s *pgStore) ClaimJob(ctx context.Context, workerID string, jobID string) (int, string, error) {
+func (s *pgStore) ClaimJob(ctx context.Context, workerID string, jobID string) (version int, attemptID string, err error) {
+	err = s.guard.Do(ctx, "claim_job", pgretry.Claim, func() error {
+		version, attemptID, err = s.claimJob(ctx, workerID, jobID)
+		return err
+	})
+	return version, attemptID, err
+}
+
+func (s *pgStore) claimJob(ctx context.Context, workerID string, jobID string) (int, string, error) {
 	now := time.Now()
 	expires := now.Add(s.leaseDur)
 	attemptID := "attempt-" + uuid.New().String()
@@ -238,11 +426,16 @@ func (s *pgStore) ClaimJob(ctx context.Context, workerID string, jobID string) (
 	return version, attemptID, nil
 }
 
+// Heartbeat 降级期间同样重试，尽量在数据库恢复前保持租约不过期
 func (s *pgStore) Heartbeat(ctx context.Context, workerID string, jobID string) error {
-	expires := time.Now().Add(s.leaseDur)
-	cmd, err := s.pool.Exec(ctx,
-		`UPDATE job_claims SET expires_at = $1 WHERE job_id = $2 AND worker_id = $3`,
-		expires, jobID, workerID)
+	var cmd pgconn.CommandTag
+	err := s.guard.Do(ctx, "heartbeat", pgretry.Idempotent, func() error {
+		var err error
+		cmd, err = s.pool.Exec(ctx,
+			`UPDATE job_claims SET expires_at = $1 WHERE job_id = $2 AND worker_id = $3`,
+			time.Now().Add(s.leaseDur), jobID, workerID)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -255,39 +448,37 @@ func (s *pgStore) Heartbeat(ctx context.Context, workerID string, jobID string)
 // GetCurrentAttemptID 返回该 job 当前持有租约的 attempt_id；无租约或已过期returned empty字符串
 func (s *pgStore) GetCurrentAttemptID(ctx context.Context, jobID string) (string, error) {
 	var attemptID string
-	err := s.pool.QueryRow(ctx, `SELECT attempt_id FROM job_claims WHERE job_id = $1 AND expires_at > now()`, jobID).Scan(&attemptID)
-	if err != nil {
+	err := s.guard.Do(ctx, "get_attempt", pgretry.Idempotent, func() error {
+		err := s.pool.QueryRow(ctx, `SELECT attempt_id FROM job_claims WHERE job_id = $1 AND expires_at > now()`, jobID).Scan(&attemptID)
 		if errNoRows(err) {
-			return "", nil
+			attemptID, err = "", nil
 		}
-		return "", err
-	}
-	return attemptID, nil
+		return err
+	})
+	return attemptID, err
 }
 
 // ListJobIDsWithExpiredClaim 返回租约已过期的 job_id 列表，供 metadata 侧回收孤儿
-func (s *pgStore) ListJobIDsWithExpiredClaim(ctx context.Context) ([]string, error) {
-	rows, err := s.pool.Query(ctx,
-		`SELECT job_id FROM job_claims WHERE expires_at <= now() ORDER BY job_id`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var ids []string
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		ids = append(ids, id)
-	}
-	return ids, rows.Err()
+func (s *pgStore) ListJobIDsWithExpiredClaim(ctx context.Context) (ids []string, err error) {
+	err = s.guard.Do(ctx, "list_expired_claims", pgretry.Idempotent, func() error {
+		ids, err = s.queryIDs(ctx, `SELECT job_id FROM job_claims WHERE expires_at <= now() ORDER BY job_id`)
+		return err
+	})
+	return ids, err
 }
 
 // ListActiveWorkerIDs 返回当前有未过期租约的 worker_id 列表（供运维 CLI / API 展示）
-func (s *pgStore) ListActiveWorkerIDs(ctx context.Context) ([]string, error) {
-	rows, err := s.pool.Query(ctx,
-		`SELECT DISTINCT worker_id FROM job_claims WHERE expires_at > now() ORDER BY worker_id`)
+func (s *pgStore) ListActiveWorkerIDs(ctx context.Context) (ids []string, err error) {
+	err = s.guard.Do(ctx, "list_active_workers", pgretry.Idempotent, func() error {
+		ids, err = s.queryIDs(ctx, `SELECT DISTINCT worker_id FROM job_claims WHERE expires_at > now() ORDER BY worker_id`)
+		return err
+	})
+	return ids, err
+}
+
+// queryIDs 执行返回单列字符串的查询
+func (s *pgStore) queryIDs(ctx context.Context, sql string) ([]string, error) {
+	rows, err := s.pool.Query(ctx, sql)
 	if err != nil {
 		return nil, err
 	}
@@ -494,3 +685,27 @@ func (s *pgStore) DeleteToolInvocations(ctx context.Context, refs []ToolInvocati
 	}
 	return nil
 }
+
+// attributionToPg 归属以 JSONB 落库；无归属时写 NULL
+func attributionToPg(a *Attribution) []byte {
+	if a.IsZero() {
+		return nil
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// ParseAttribution 解析 JSONB 中的归属；为空或无效时返回 nil
+func ParseAttribution(b []byte) *Attribution {
+	if len(b) == 0 {
+		return nil
+	}
+	var a Attribution
+	if err := json.Unmarshal(b, &a); err != nil || a.IsZero() {
+		return nil
+	}
+	return &a
+}
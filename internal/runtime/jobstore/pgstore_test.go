@@ -17,10 +17,13 @@ package jobstore
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 func testDSN(t *testing.T) string {
@@ -109,6 +112,34 @@ func TestPgStore_Append_VersionMismatch(t *testing.T) {
 	}
 }
 
+func TestPgStore_AppendTxHook(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := newTestPgStore(t, ctx)
+	defer cleanup()
+	jobID := "job-1"
+
+	// 钩子失败时事件一并回滚
+	failing := WithAppendTxHook(ctx, func(ctx context.Context, tx pgx.Tx, version int) error {
+		return errors.New("side write failed")
+	})
+	if _, err := store.Append(failing, jobID, 0, JobEvent{JobID: jobID, Type: JobCreated}); err == nil {
+		t.Fatal("expected hook error")
+	}
+	if _, ver, _ := store.ListEvents(ctx, jobID); ver != 0 {
+		t.Fatalf("event committed despite hook failure, version %d", ver)
+	}
+
+	var seen int
+	ok := WithAppendTxHook(ctx, func(ctx context.Context, tx pgx.Tx, version int) error {
+		seen = version
+		var n int
+		return tx.QueryRow(ctx, `SELECT COUNT(*) FROM job_events WHERE job_id = $1`, jobID).Scan(&n)
+	})
+	if v, err := store.Append(ok, jobID, 0, JobEvent{JobID: jobID, Type: JobCreated}); err != nil || v != 1 || seen != 1 {
+		t.Fatalf("Append = %d, %v; hook saw version %d", v, err, seen)
+	}
+}
+
 func TestPgStore_Claim_Heartbeat(t *testing.T) {
 	ctx := context.Background()
 	store, cleanup := newTestPgStore(t, ctx)
@@ -0,0 +1,168 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrCheckpointNotReached 检查点的游标节点不在当前已完成的节点中（从未完成，或已被此前的回滚作废）
+var ErrCheckpointNotReached = errors.New("jobstore: checkpoint cursor node is not a completed node")
+
+// 阻止回滚的副作用类型
+const (
+	RollbackBlockToolCommitted = "tool_committed" // 工具调用已成功提交
+	RollbackBlockToolInFlight  = "tool_in_flight" // 工具调用已开始、结果未知
+	RollbackBlockStateChanged  = "state_changed"  // 步骤已修改外部资源
+	RollbackBlockWaitCompleted = "wait_completed" // 已消费外部 signal / 审批
+)
+
+// CheckpointRolledBackPayload checkpoint_rolled_back 事件 payload；POST /api/jobs/:id/checkpoints/:cp_id/rollback 写入。
+// InvalidatedNodes / InvalidatedSteps 为检查点之后完成、被作废须重新执行的节点与确定性步 ID
+type CheckpointRolledBackPayload struct {
+	CheckpointID     string          `json:"checkpoint_id"`
+	CursorNode       string          `json:"cursor_node"`
+	PayloadResults   json.RawMessage `json:"payload_results,omitempty"`
+	InvalidatedNodes []string        `json:"invalidated_nodes"`
+	InvalidatedSteps []string        `json:"invalidated_steps,omitempty"`
+	Actor            string          `json:"actor,omitempty"`
+	Reason           string          `json:"reason,omitempty"`
+	At               time.Time       `json:"at"`
+}
+
+// RollbackBlocker 检查点之后已提交、回滚无法撤销的副作用
+type RollbackBlocker struct {
+	Kind     string `json:"kind"` // tool_committed | tool_in_flight | state_changed | wait_completed
+	NodeID   string `json:"node_id"`
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+// CheckpointRollback 回滚到某检查点的影响：被作废的节点与步，以及阻止回滚的副作用（非空时不可回滚）
+type CheckpointRollback struct {
+	InvalidatedNodes []string          `json:"invalidated_nodes"`
+	InvalidatedSteps []string          `json:"invalidated_steps,omitempty"`
+	Blockers         []RollbackBlocker `json:"blockers,omitempty"`
+}
+
+// PlanCheckpointRollback 由事件流推导回滚到 cursorNode 处检查点的影响：cursorNode 最后一次完成之后完成的节点全部作废；
+// 之后成功的工具调用、未结束的工具调用、state_changed 与 wait_completed 均为已提交副作用，重新执行会重复或丢失，列入 Blockers。
+// 此前回滚已作废的节点不再视为已完成
+func PlanCheckpointRollback(events []JobEvent, cursorNode string) (*CheckpointRollback, error) {
+	type finished struct {
+		index  int
+		stepID string
+	}
+	done := make(map[string]finished)
+	var order []string
+	for i, e := range events {
+		switch e.Type {
+		case NodeFinished:
+			var pl struct {
+				NodeID     string `json:"node_id"`
+				StepID     string `json:"step_id"`
+				ResultType string `json:"result_type"`
+			}
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.NodeID == "" {
+				continue
+			}
+			switch pl.ResultType {
+			case "", "success", "pure", "side_effect_committed", "compensated":
+			default:
+				continue
+			}
+			if _, ok := done[pl.NodeID]; !ok {
+				order = append(order, pl.NodeID)
+			}
+			done[pl.NodeID] = finished{index: i, stepID: pl.StepID}
+		case CheckpointRolledBack:
+			var pl CheckpointRolledBackPayload
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			for _, n := range pl.InvalidatedNodes {
+				delete(done, n)
+			}
+		}
+	}
+	cut, ok := done[cursorNode]
+	if !ok {
+		return nil, ErrCheckpointNotReached
+	}
+	out := &CheckpointRollback{InvalidatedNodes: []string{}}
+	for _, n := range order {
+		f, ok := done[n]
+		if !ok || f.index <= cut.index {
+			continue
+		}
+		out.InvalidatedNodes = append(out.InvalidatedNodes, n)
+		if f.stepID != "" {
+			out.InvalidatedSteps = append(out.InvalidatedSteps, f.stepID)
+		}
+	}
+
+	type toolCall struct {
+		NodeID         string `json:"node_id"`
+		ToolName       string `json:"tool_name"`
+		IdempotencyKey string `json:"idempotency_key"`
+		Outcome        string `json:"outcome"`
+	}
+	started := make(map[string]toolCall)
+	var startedOrder []string
+	for _, e := range events[cut.index+1:] {
+		switch e.Type {
+		case ToolInvocationStarted:
+			var pl toolCall
+			if json.Unmarshal(e.Payload, &pl) != nil || pl.IdempotencyKey == "" {
+				continue
+			}
+			if _, ok := started[pl.IdempotencyKey]; !ok {
+				startedOrder = append(startedOrder, pl.IdempotencyKey)
+			}
+			started[pl.IdempotencyKey] = pl
+		case ToolInvocationFinished:
+			var pl toolCall
+			if json.Unmarshal(e.Payload, &pl) != nil {
+				continue
+			}
+			call := started[pl.IdempotencyKey]
+			delete(started, pl.IdempotencyKey)
+			if pl.Outcome != "success" {
+				continue
+			}
+			if pl.NodeID == "" {
+				pl.NodeID = call.NodeID
+			}
+			out.Blockers = append(out.Blockers, RollbackBlocker{Kind: RollbackBlockToolCommitted, NodeID: pl.NodeID, ToolName: call.ToolName})
+		case StateChanged, WaitCompleted:
+			var pl struct {
+				NodeID string `json:"node_id"`
+			}
+			_ = json.Unmarshal(e.Payload, &pl)
+			kind := RollbackBlockStateChanged
+			if e.Type == WaitCompleted {
+				kind = RollbackBlockWaitCompleted
+			}
+			out.Blockers = append(out.Blockers, RollbackBlocker{Kind: kind, NodeID: pl.NodeID})
+		}
+	}
+	for _, key := range startedOrder {
+		if call, ok := started[key]; ok {
+			out.Blockers = append(out.Blockers, RollbackBlocker{Kind: RollbackBlockToolInFlight, NodeID: call.NodeID, ToolName: call.ToolName})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func rollbackEvent(t *testing.T, typ EventType, payload interface{}) JobEvent {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return JobEvent{Type: typ, Payload: b}
+}
+
+func TestPlanCheckpointRollback_InvalidatesLaterNodes(t *testing.T) {
+	events := []JobEvent{
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n1", "step_id": "s1"}),
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n2", "step_id": "s2"}),
+		rollbackEvent(t, ToolInvocationStarted, map[string]string{"node_id": "n3", "tool_name": "search", "idempotency_key": "k3"}),
+		rollbackEvent(t, ToolInvocationFinished, map[string]string{"node_id": "n3", "idempotency_key": "k3", "outcome": "failure"}),
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n3", "step_id": "s3", "result_type": "permanent_failure"}),
+	}
+	plan, err := PlanCheckpointRollback(events, "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.InvalidatedNodes) != 1 || plan.InvalidatedNodes[0] != "n2" {
+		t.Fatalf("InvalidatedNodes = %v, want [n2]", plan.InvalidatedNodes)
+	}
+	if len(plan.InvalidatedSteps) != 1 || plan.InvalidatedSteps[0] != "s2" {
+		t.Fatalf("InvalidatedSteps = %v, want [s2]", plan.InvalidatedSteps)
+	}
+	if len(plan.Blockers) != 0 {
+		t.Fatalf("failed tool call should not block rollback: %+v", plan.Blockers)
+	}
+}
+
+func TestPlanCheckpointRollback_BlockedByCommittedSideEffects(t *testing.T) {
+	events := []JobEvent{
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n1"}),
+		rollbackEvent(t, ToolInvocationStarted, map[string]string{"node_id": "n2", "tool_name": "email.send", "idempotency_key": "k2"}),
+		rollbackEvent(t, ToolInvocationFinished, map[string]string{"node_id": "n2", "idempotency_key": "k2", "outcome": "success"}),
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n2"}),
+		rollbackEvent(t, ToolInvocationStarted, map[string]string{"node_id": "n3", "tool_name": "payment.charge", "idempotency_key": "k3"}),
+	}
+	plan, err := PlanCheckpointRollback(events, "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RollbackBlocker{
+		{Kind: RollbackBlockToolCommitted, NodeID: "n2", ToolName: "email.send"},
+		{Kind: RollbackBlockToolInFlight, NodeID: "n3", ToolName: "payment.charge"},
+	}
+	if len(plan.Blockers) != len(want) {
+		t.Fatalf("Blockers = %+v, want %+v", plan.Blockers, want)
+	}
+	for i := range want {
+		if plan.Blockers[i] != want[i] {
+			t.Fatalf("Blockers[%d] = %+v, want %+v", i, plan.Blockers[i], want[i])
+		}
+	}
+	// 回滚到副作用之后的检查点不受影响
+	if plan, _ := PlanCheckpointRollback(events[:4], "n2"); len(plan.Blockers) != 0 || len(plan.InvalidatedNodes) != 0 {
+		t.Fatalf("rollback to n2 = %+v", plan)
+	}
+}
+
+func TestPlanCheckpointRollback_NodeInvalidatedByEarlierRollback(t *testing.T) {
+	events := []JobEvent{
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n1"}),
+		rollbackEvent(t, NodeFinished, map[string]string{"node_id": "n2"}),
+		rollbackEvent(t, CheckpointRolledBack, CheckpointRolledBackPayload{CheckpointID: "cp-1", CursorNode: "n1", InvalidatedNodes: []string{"n2"}}),
+	}
+	if _, err := PlanCheckpointRollback(events, "n2"); !errors.Is(err, ErrCheckpointNotReached) {
+		t.Fatalf("err = %v, want ErrCheckpointNotReached", err)
+	}
+	if _, err := PlanCheckpointRollback(events, "missing"); !errors.Is(err, ErrCheckpointNotReached) {
+		t.Fatalf("err = %v, want ErrCheckpointNotReached", err)
+	}
+}
@@ -54,8 +54,26 @@ ALTER TABLE jobs ADD COLUMN IF NOT EXISTS required_capabilities TEXT;
 CREATE INDEX IF NOT EXISTS idx_jobs_agent_id ON jobs (agent_id);
 CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
 CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at);
+-- Trace 概览服务端分页：按 Agent 的 created_at 倒序键集分页
+CREATE INDEX IF NOT EXISTS idx_jobs_agent_created ON jobs (agent_id, created_at DESC, id DESC);
 -- 同一 Agent 下幂等键唯一，用于 Idempotency-Key header 去重
 CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_agent_idempotency ON jobs (agent_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+-- 目标去重窗口：规范化目标的 sha256（job.GoalHash），同 Agent 在窗口内相同目标返回已有 Job（升级已有库时执行下两行）
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS goal_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_jobs_agent_goal_hash ON jobs (agent_id, goal_hash, created_at) WHERE goal_hash IS NOT NULL;
+-- 终态元数据（取消原因、发起者、失败节点、失败分类、补偿状态；job.TerminalInfo JSON）（升级已有库时执行下一行）
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS terminal_info JSONB;
+
+-- Job 状态迁移审计（job.TransitionLister）：与 jobs.status 的条件更新在同一条语句中写入，不进入版本化的事件流
+CREATE TABLE IF NOT EXISTS job_transitions (
+    id          BIGSERIAL PRIMARY KEY,
+    job_id      TEXT NOT NULL,
+    from_status INT NOT NULL,
+    to_status   INT NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_job_transitions_job ON job_transitions (job_id, id);
 
 -- Agent 状态表（会话/记忆快照），供 Worker 恢复与多实例共享
 CREATE TABLE IF NOT EXISTS agent_states (
@@ -140,6 +158,10 @@ CREATE TABLE IF NOT EXISTS ingest_tasks (
 
 CREATE INDEX IF NOT EXISTS idx_ingest_tasks_status ON ingest_tasks (status);
 CREATE INDEX IF NOT EXISTS idx_ingest_tasks_created_at ON ingest_tasks (created_at);
+-- failed 任务的失败阶段与error分类（GET /api/ingest/stats 聚合），retries 为手动重试次数
+ALTER TABLE ingest_tasks ADD COLUMN IF NOT EXISTS failed_stage TEXT;
+ALTER TABLE ingest_tasks ADD COLUMN IF NOT EXISTS error_class TEXT;
+ALTER TABLE ingest_tasks ADD COLUMN IF NOT EXISTS retries INT NOT NULL DEFAULT 0;
 
 -- Agent Instance 表（design/agent-instance-model.md）；2.0 第一公民身份
 CREATE TABLE IF NOT EXISTS agent_instances (
@@ -320,3 +342,295 @@ CREATE TABLE IF NOT EXISTS ledger_sync_log (
 );
 CREATE INDEX IF NOT EXISTS idx_ledger_sync_job ON ledger_sync_log (job_id);
 CREATE INDEX IF NOT EXISTS idx_ledger_sync_created ON ledger_sync_log (created_at);
+
+-- Event export outbox：选定事件 Append 后写入，由 Relay 发布到 Kafka/NATS（at-least-once）
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id            BIGSERIAL PRIMARY KEY,
+    job_id        TEXT NOT NULL,
+    event_id      TEXT NOT NULL,
+    event_type    TEXT NOT NULL,
+    payload       JSONB NOT NULL,
+    hash          TEXT,
+    status        TEXT NOT NULL DEFAULT 'pending', -- pending | claimed | delivered | dead
+    attempts      INT NOT NULL DEFAULT 0,
+    last_error    TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    claimed_at    TIMESTAMPTZ,
+    delivered_at  TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ -- 转为 delivered / dead 的时间，Relay 按此清理过期记录
+);
+ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS finished_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_status ON event_outbox (status, id);
+
+-- PII 标签：写入时检测事件 payload 中的 PII 类别与字段路径（不保存原始匹配值），供汇总与证据导出脱敏
+CREATE TABLE IF NOT EXISTS event_pii_tags (
+    job_id        TEXT NOT NULL,
+    version       INT NOT NULL,
+    event_type    TEXT NOT NULL,
+    categories    TEXT[] NOT NULL,
+    findings      JSONB NOT NULL DEFAULT '[]',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (job_id, version)
+);
+
+-- 监督者子 Job：spawn/join 节点派发的子 Job（每次重派一行），供 join 汇合、子 Job 结束时唤醒监督者与 Trace 层级展示
+CREATE TABLE IF NOT EXISTS job_children (
+    parent_job_id TEXT NOT NULL,
+    child_job_id  TEXT NOT NULL UNIQUE,
+    node_id       TEXT NOT NULL,
+    child_key     TEXT NOT NULL,
+    attempt       INT  NOT NULL DEFAULT 1,
+    agent_id      TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (parent_job_id, node_id, child_key, attempt)
+);
+
+-- 租户维护窗口：窗口内新建 Job 置为 Deferred（jobs.status=8），窗口结束后由控制器恢复为 Pending
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id            TEXT PRIMARY KEY,
+    tenant_id     TEXT NOT NULL,
+    starts_at     TIMESTAMPTZ NOT NULL,
+    ends_at       TIMESTAMPTZ NOT NULL,
+    reason        TEXT,
+    park_running  BOOLEAN NOT NULL DEFAULT false,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_range ON maintenance_windows (starts_at, ends_at);
+
+-- Worker 状态心跳：并发占用与资源感知认领节流状态（每 Worker 一行，心跳时 upsert），供 Scheduler 与看板识别自节流的 Worker
+CREATE TABLE IF NOT EXISTS worker_status (
+    worker_id         TEXT PRIMARY KEY,
+    running_jobs      INT NOT NULL DEFAULT 0,
+    max_concurrency   INT NOT NULL DEFAULT 0,
+    throttled         BOOLEAN NOT NULL DEFAULT false,
+    throttle_reasons  JSONB,
+    cpu_percent       DOUBLE PRECISION NOT NULL DEFAULT -1,
+    memory_percent    DOUBLE PRECISION NOT NULL DEFAULT -1,
+    llm_in_flight     INT NOT NULL DEFAULT 0,
+    tool_in_flight    INT NOT NULL DEFAULT 0,
+    heartbeat_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- 版本握手：Worker 支持的事件 schema 版本与特性，API 创建 Job 时据此协商（滚动升级兼容）
+ALTER TABLE worker_status ADD COLUMN IF NOT EXISTS version TEXT;
+ALTER TABLE worker_status ADD COLUMN IF NOT EXISTS event_schema_version INT NOT NULL DEFAULT 0;
+ALTER TABLE worker_status ADD COLUMN IF NOT EXISTS features JSONB;
+-- Worker 自省端点地址，API 据此代理 aetheris worker inspect
+ALTER TABLE worker_status ADD COLUMN IF NOT EXISTS inspect_url TEXT;
+
+-- 规划 few-shot 示例：运维按 Agent 精选的「目标 → 优质 TaskGraph」对，PlanGoal 时按目标相似度选取注入 prompt
+CREATE TABLE IF NOT EXISTS planner_exemplars (
+    id          TEXT PRIMARY KEY,
+    agent_id    TEXT NOT NULL,
+    goal        TEXT NOT NULL,
+    graph       JSONB NOT NULL,
+    note        TEXT,
+    disabled    BOOLEAN NOT NULL DEFAULT false,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_planner_exemplars_agent ON planner_exemplars (agent_id, created_at);
+
+-- Agent 目标模板：具名目标 + 参数 schema，chat/API 收集并校验参数后渲染为 Job 目标
+CREATE TABLE IF NOT EXISTS agent_goal_templates (
+    agent_id    TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    description TEXT,
+    goal        TEXT NOT NULL,
+    params      JSONB NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (agent_id, name)
+);
+
+-- Agent 回归集：黄金目标 + 期望结果断言；每个 Agent 一个
+CREATE TABLE IF NOT EXISTS agent_eval_suites (
+    agent_id     TEXT PRIMARY KEY,
+    cases        JSONB NOT NULL DEFAULT '[]',
+    case_timeout TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 回归集运行报告：按租户 + Agent 保存历史，report 为完整 JSON（逐用例结果、回归列表）
+CREATE TABLE IF NOT EXISTS agent_eval_reports (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    agent_id    TEXT NOT NULL,
+    status      TEXT NOT NULL,
+    report      JSONB NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_agent_eval_reports_agent ON agent_eval_reports (tenant_id, agent_id, started_at DESC);
+
+-- Trace 概览已保存筛选：按租户 + 用户保存的 Agent、状态、时间范围组合
+CREATE TABLE IF NOT EXISTS trace_filter_presets (
+    tenant_id   TEXT NOT NULL,
+    user_id     TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    filters     JSONB NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, user_id, name)
+);
+
+-- 租户品牌：Trace 页面页头与证据包离线报告展示的标题、Logo 与自定义字段
+CREATE TABLE IF NOT EXISTS tenant_branding (
+    tenant_id   TEXT PRIMARY KEY,
+    branding    JSONB NOT NULL DEFAULT '{}',
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Worker 服务账号：令牌仅存 SHA256，授予认领/执行权限；轮换后旧令牌在 prev_token_expires_at 前仍有效
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id                     TEXT PRIMARY KEY,
+    tenant_id              TEXT NOT NULL,
+    name                   TEXT NOT NULL,
+    role                   TEXT NOT NULL DEFAULT 'worker',
+    token_hash             TEXT NOT NULL,
+    prev_token_hash        TEXT,
+    prev_token_expires_at  TIMESTAMPTZ,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rotated_at             TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at             TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_service_accounts_token ON service_accounts (token_hash);
+CREATE INDEX IF NOT EXISTS idx_service_accounts_prev_token ON service_accounts (prev_token_hash);
+CREATE INDEX IF NOT EXISTS idx_service_accounts_tenant ON service_accounts (tenant_id, created_at);
+
+-- 事件归属：追加事件的主体（Worker 为 worker:<worker_id>[@<service_account_id>]，API 为空）；不参与 hash 计算
+ALTER TABLE job_events ADD COLUMN IF NOT EXISTS actor TEXT NOT NULL DEFAULT '';
+-- 发起归属：创建 Job 的用户、客户端与原始请求 ID（jobstore.Attribution JSON），随 jobs.attribution 写入每条事件；不参与 hash 计算
+ALTER TABLE job_events ADD COLUMN IF NOT EXISTS attribution JSONB;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS attribution JSONB;
+CREATE INDEX IF NOT EXISTS idx_jobs_attribution_user ON jobs ((attribution->>'user_id'), created_at) WHERE attribution IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_attribution_request ON jobs ((attribution->>'request_id')) WHERE attribution IS NOT NULL;
+-- 交互式对话 Job：预留调度通道只认领 interactive = true 的 Pending，常规通道按 interactive DESC, created_at 认领
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS interactive BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_jobs_pending_interactive ON jobs (created_at) WHERE status = 0 AND interactive;
+-- 执行档位：提交时选择的 agent.execution_profiles 名称，空为不套用档位
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS execution_profile TEXT;
+
+-- Agent 级配置：工具执行前解析并注入（sdk.ConfigFromContext）；secret_ref 非空时 value 为空，值由 secret store 按引用解析
+CREATE TABLE IF NOT EXISTS agent_config (
+    agent_id    TEXT NOT NULL,
+    key         TEXT NOT NULL,
+    value       TEXT NOT NULL DEFAULT '',
+    secret_ref  TEXT NOT NULL DEFAULT '',
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (agent_id, key)
+);
+
+-- 全局工具熔断：按工具类别（network-write、payments、code-exec 等）跨租户禁止执行；Worker 短周期刷新，被熔断的步骤以 killswitch_active 失败
+CREATE TABLE IF NOT EXISTS tool_killswitches (
+    category    TEXT PRIMARY KEY,
+    active      BOOLEAN NOT NULL DEFAULT false,
+    reason      TEXT NOT NULL DEFAULT '',
+    updated_by  TEXT NOT NULL DEFAULT '',
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 熔断开关变更记录（审计）
+CREATE TABLE IF NOT EXISTS tool_killswitch_audit (
+    id          TEXT PRIMARY KEY,
+    category    TEXT NOT NULL,
+    active      BOOLEAN NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    actor       TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_tool_killswitch_audit_created ON tool_killswitch_audit (created_at DESC);
+
+-- 限时调试会话（break-glass）：管理员填写理由后为 grantee 临时开放某个 Job 的 payload，expires_at 后自动失效
+CREATE TABLE IF NOT EXISTS debug_sessions (
+    id             TEXT PRIMARY KEY,
+    tenant_id      TEXT NOT NULL,
+    job_id         TEXT NOT NULL,
+    grantee        TEXT NOT NULL,
+    opened_by      TEXT NOT NULL DEFAULT '',
+    justification  TEXT NOT NULL,
+    opened_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    closed_at      TIMESTAMPTZ,
+    closed_by      TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_debug_sessions_job ON debug_sessions (tenant_id, job_id, grantee);
+
+-- 调试会话审计记录：按租户以 seq 组成哈希链（hash = sha256(prev_hash + 记录内容)），只追加
+CREATE TABLE IF NOT EXISTS debug_session_audit (
+    tenant_id   TEXT NOT NULL,
+    seq         BIGINT NOT NULL,
+    session_id  TEXT NOT NULL,
+    job_id      TEXT NOT NULL,
+    action      TEXT NOT NULL,
+    actor       TEXT NOT NULL DEFAULT '',
+    detail      TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL,
+    prev_hash   TEXT NOT NULL DEFAULT '',
+    hash        TEXT NOT NULL,
+    PRIMARY KEY (tenant_id, seq)
+);
+
+-- Job 证明记录：进入终态时固化 execution_hash、事件链根与 ledger 证明；事件被留存策略删除后 GET /api/jobs/:id/verify 据此降级验证
+CREATE TABLE IF NOT EXISTS job_attestations (
+    job_id                 TEXT PRIMARY KEY,
+    execution_hash         TEXT NOT NULL,
+    event_chain_root_hash  TEXT NOT NULL,
+    event_count            INT NOT NULL,
+    first_event_id         TEXT NOT NULL DEFAULT '',
+    terminal_event         TEXT NOT NULL,
+    ledger_proof           JSONB NOT NULL DEFAULT '{}',
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 事件载荷压缩：超过阈值的载荷以 zstd 压缩存于 payload_compressed（payload 为 NULL），payload_encoding 为 'zstd'；空串表示未压缩。
+-- 存量行可用 aetheris migrate compress-events 压缩，--decompress 还原（升级已有库时执行下两行）
+ALTER TABLE job_events ADD COLUMN IF NOT EXISTS payload_encoding TEXT NOT NULL DEFAULT '';
+ALTER TABLE job_events ADD COLUMN IF NOT EXISTS payload_compressed BYTEA;
+
+-- 集群级工具信号量（agent.tool_semaphores）：holders 为许可租约（持有者崩溃后 expires_at 到期释放），waiters 按 seq 先到先得
+CREATE TABLE IF NOT EXISTS tool_semaphore_holders (
+    name        TEXT NOT NULL,
+    holder      TEXT NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (name, holder)
+);
+CREATE TABLE IF NOT EXISTS tool_semaphore_waiters (
+    name        TEXT NOT NULL,
+    holder      TEXT NOT NULL,
+    seq         BIGSERIAL,
+    enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (name, holder)
+);
+CREATE INDEX IF NOT EXISTS idx_tool_semaphore_waiters_seq ON tool_semaphore_waiters (name, seq);
+
+-- 事件全文检索（event_search）：写入时从目标、工具输出、错误与推理快照抽取文本；tsv 由 body 生成，查询按 tenant_id 隔离
+CREATE TABLE IF NOT EXISTS job_search_docs (
+    id         BIGSERIAL PRIMARY KEY,
+    job_id     TEXT NOT NULL,
+    version    INT NOT NULL,
+    scope      TEXT NOT NULL,
+    tenant_id  TEXT NOT NULL DEFAULT 'default',
+    agent_id   TEXT NOT NULL DEFAULT '',
+    event_type TEXT NOT NULL,
+    node_id    TEXT NOT NULL DEFAULT '',
+    body       TEXT NOT NULL,
+    tsv        TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', body)) STORED,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (job_id, version, scope)
+);
+CREATE INDEX IF NOT EXISTS idx_job_search_docs_tsv ON job_search_docs USING GIN (tsv);
+CREATE INDEX IF NOT EXISTS idx_job_search_docs_tenant ON job_search_docs (tenant_id, created_at DESC, id DESC);
+
+-- 数据仓库导出（warehouse_export）水位线：每个导出流（前缀 + 表结构版本）一行，多副本以水位线 CAS 推进
+CREATE TABLE IF NOT EXISTS warehouse_export_state (
+    name                 TEXT PRIMARY KEY,
+    watermark_updated_at TIMESTAMPTZ NOT NULL,
+    watermark_job_id     TEXT NOT NULL,
+    batches              BIGINT NOT NULL DEFAULT 0,
+    jobs                 BIGINT NOT NULL DEFAULT 0,
+    last_batch_id        TEXT NOT NULL DEFAULT '',
+    exported_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_updated_id ON jobs (updated_at, id);
@@ -0,0 +1,115 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SemaphoreStore 集群级命名信号量：跨所有 Worker 限制对同一外部系统（如 ERP）的并发调用。
+// 排队者按首次 TryAcquire 的顺序（FIFO）获得许可；许可为租约，持有者崩溃后 ttl 到期自动释放
+type SemaphoreStore interface {
+	// TryAcquire holder 首次调用时入队；当前持有数加上排在其前面的排队数小于 limit 时获得许可（租约 ttl）。
+	// granted=false 时保持排队位置，调用方应以同一 holder 在 ttl 内重试，超过 ttl 未重试的排队者被移出
+	TryAcquire(ctx context.Context, name, holder string, limit int, ttl time.Duration) (granted bool, err error)
+	// Renew 延长 holder 持有的许可；执行时间可能超过 ttl 时周期调用
+	Renew(ctx context.Context, name, holder string, ttl time.Duration) error
+	// Release 释放许可或撤出排队；holder 不存在时为 no-op
+	Release(ctx context.Context, name, holder string) error
+}
+
+// semaphoreMem 单进程实现（内存模式 / 测试）
+type semaphoreMem struct {
+	mu   sync.Mutex
+	sems map[string]*memSemaphore
+	now  func() time.Time
+}
+
+type memSemaphore struct {
+	held  map[string]time.Time // holder -> 租约到期
+	queue []memWaiter          // 按入队顺序
+}
+
+type memWaiter struct {
+	holder   string
+	lastSeen time.Time
+}
+
+// NewSemaphoreStoreMem 创建内存信号量存储，仅在单进程内生效
+func NewSemaphoreStoreMem() SemaphoreStore {
+	return &semaphoreMem{sems: make(map[string]*memSemaphore), now: time.Now}
+}
+
+func (s *semaphoreMem) get(name string) *memSemaphore {
+	sem, ok := s.sems[name]
+	if !ok {
+		sem = &memSemaphore{held: make(map[string]time.Time)}
+		s.sems[name] = sem
+	}
+	return sem
+}
+
+func (s *semaphoreMem) TryAcquire(ctx context.Context, name, holder string, limit int, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	sem := s.get(name)
+	for h, exp := range sem.held {
+		if !exp.After(now) {
+			delete(sem.held, h)
+		}
+	}
+	if _, ok := sem.held[holder]; ok {
+		sem.held[holder] = now.Add(ttl)
+		return true, nil
+	}
+	sem.queue = slices.DeleteFunc(sem.queue, func(w memWaiter) bool {
+		return w.holder != holder && now.Sub(w.lastSeen) > ttl
+	})
+	pos := slices.IndexFunc(sem.queue, func(w memWaiter) bool { return w.holder == holder })
+	if pos < 0 {
+		sem.queue = append(sem.queue, memWaiter{holder: holder, lastSeen: now})
+		pos = len(sem.queue) - 1
+	}
+	sem.queue[pos].lastSeen = now
+	if len(sem.held)+pos >= limit {
+		return false, nil
+	}
+	sem.queue = slices.Delete(sem.queue, pos, pos+1)
+	sem.held[holder] = now.Add(ttl)
+	return true, nil
+}
+
+func (s *semaphoreMem) Renew(ctx context.Context, name, holder string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sem := s.get(name)
+	if _, ok := sem.held[holder]; ok {
+		sem.held[holder] = s.now().Add(ttl)
+	}
+	return nil
+}
+
+func (s *semaphoreMem) Release(ctx context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sem := s.get(name)
+	delete(sem.held, holder)
+	sem.queue = slices.DeleteFunc(sem.queue, func(w memWaiter) bool { return w.holder == holder })
+	return nil
+}
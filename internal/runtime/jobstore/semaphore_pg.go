@@ -0,0 +1,102 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// semaphorePg PostgreSQL 实现：tool_semaphore_holders 记录许可租约，tool_semaphore_waiters 以 seq 记录排队顺序；
+// 每次操作在同名 advisory 事务锁内完成，多 Worker 并发获取时不会超发
+type semaphorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewSemaphoreStorePg 创建基于 PostgreSQL 的集群级信号量存储
+func NewSemaphoreStorePg(pool *pgxpool.Pool) SemaphoreStore {
+	return &semaphorePg{pool: pool}
+}
+
+func (s *semaphorePg) TryAcquire(ctx context.Context, name, holder string, limit int, ttl time.Duration) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	ttlMs := ttl.Milliseconds()
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('tool_semaphore:' || $1))`, name); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tool_semaphore_holders WHERE name = $1 AND expires_at <= now()`, name); err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx,
+		`UPDATE tool_semaphore_holders SET expires_at = now() + $3 * interval '1 millisecond' WHERE name = $1 AND holder = $2`,
+		name, holder, ttlMs)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() > 0 {
+		return true, tx.Commit(ctx)
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM tool_semaphore_waiters WHERE name = $1 AND holder <> $2 AND last_seen < now() - $3 * interval '1 millisecond'`,
+		name, holder, ttlMs); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO tool_semaphore_waiters (name, holder, enqueued_at, last_seen) VALUES ($1, $2, now(), now())
+		 ON CONFLICT (name, holder) DO UPDATE SET last_seen = now()`, name, holder); err != nil {
+		return false, err
+	}
+	var held, ahead int
+	if err := tx.QueryRow(ctx,
+		`SELECT (SELECT count(*) FROM tool_semaphore_holders WHERE name = $1),
+		        (SELECT count(*) FROM tool_semaphore_waiters w WHERE w.name = $1
+		          AND w.seq < (SELECT seq FROM tool_semaphore_waiters WHERE name = $1 AND holder = $2))`,
+		name, holder).Scan(&held, &ahead); err != nil {
+		return false, err
+	}
+	if held+ahead >= limit {
+		return false, tx.Commit(ctx)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tool_semaphore_waiters WHERE name = $1 AND holder = $2`, name, holder); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO tool_semaphore_holders (name, holder, acquired_at, expires_at) VALUES ($1, $2, now(), now() + $3 * interval '1 millisecond')`,
+		name, holder, ttlMs); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (s *semaphorePg) Renew(ctx context.Context, name, holder string, ttl time.Duration) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE tool_semaphore_holders SET expires_at = now() + $3 * interval '1 millisecond' WHERE name = $1 AND holder = $2`,
+		name, holder, ttl.Milliseconds())
+	return err
+}
+
+func (s *semaphorePg) Release(ctx context.Context, name, holder string) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM tool_semaphore_holders WHERE name = $1 AND holder = $2`, name, holder); err != nil {
+		return err
+	}
+	_, err := s.pool.Exec(ctx, `DELETE FROM tool_semaphore_waiters WHERE name = $1 AND holder = $2`, name, holder)
+	return err
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreMem_LimitAndFIFO(t *testing.T) {
+	ctx := context.Background()
+	s := NewSemaphoreStoreMem()
+	ttl := time.Minute
+	try := func(holder string) bool {
+		t.Helper()
+		ok, err := s.TryAcquire(ctx, "erp", holder, 2, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if !try("a") || !try("b") {
+		t.Fatal("first two holders should acquire")
+	}
+	if try("c") || try("d") {
+		t.Fatal("limit 2 exceeded")
+	}
+	if !try("a") {
+		t.Fatal("re-acquire by holder should succeed")
+	}
+	_ = s.Release(ctx, "erp", "a")
+	// d 排在 c 之后，空出一个许可时只有 c 能获得
+	if try("d") {
+		t.Fatal("d jumped the queue")
+	}
+	if !try("c") {
+		t.Fatal("c should acquire after release")
+	}
+	if try("d") {
+		t.Fatal("limit 2 exceeded after c")
+	}
+	// 其他信号量互不影响
+	if ok, _ := s.TryAcquire(ctx, "billing", "d", 1, ttl); !ok {
+		t.Fatal("separate semaphore should be free")
+	}
+}
+
+func TestSemaphoreMem_LeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	s := &semaphoreMem{sems: make(map[string]*memSemaphore), now: func() time.Time { return now }}
+	ttl := 10 * time.Second
+	if ok, _ := s.TryAcquire(ctx, "erp", "crashed", 1, ttl); !ok {
+		t.Fatal("acquire")
+	}
+	if ok, _ := s.TryAcquire(ctx, "erp", "stale-waiter", 1, ttl); ok {
+		t.Fatal("limit exceeded")
+	}
+	now = now.Add(5 * time.Second)
+	_ = s.Renew(ctx, "erp", "crashed", ttl)
+	now = now.Add(8 * time.Second) // 续租后未到期；stale-waiter 已超过 ttl 未重试
+	if ok, _ := s.TryAcquire(ctx, "erp", "w", 1, ttl); ok {
+		t.Fatal("renewed lease should still be held")
+	}
+	now = now.Add(3 * time.Second)
+	if ok, _ := s.TryAcquire(ctx, "erp", "w", 1, ttl); !ok {
+		t.Fatal("expired lease and stale waiter should not block w")
+	}
+}
+
+func TestSemaphorePg_LimitAndFIFO(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := newTestPgStore(t, ctx)
+	defer cleanup()
+	pool := store.(*pgStore).pool
+	_, _ = pool.Exec(ctx, `DELETE FROM tool_semaphore_holders`)
+	_, _ = pool.Exec(ctx, `DELETE FROM tool_semaphore_waiters`)
+	s := NewSemaphoreStorePg(pool)
+	try := func(holder string) bool {
+		t.Helper()
+		ok, err := s.TryAcquire(ctx, "erp", holder, 1, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if !try("a") || try("b") || try("c") {
+		t.Fatal("limit 1 not enforced")
+	}
+	_ = s.Release(ctx, "erp", "a")
+	if try("c") || !try("b") {
+		t.Fatal("waiters should acquire in FIFO order")
+	}
+}
@@ -35,6 +35,11 @@ func (s *ShardedStore) ListEvents(ctx context.Context, jobID string) ([]JobEvent
 	return s.getShard(jobID).ListEvents(ctx, jobID)
 }
 
+// ListEventsSince 实现 EventRangeLister
+func (s *ShardedStore) ListEventsSince(ctx context.Context, jobID string, afterVersion int) ([]JobEvent, int, error) {
+	return EventsSince(ctx, s.getShard(jobID), jobID, afterVersion)
+}
+
 // Append 实现 JobStore 接口
 func (s *ShardedStore) Append(ctx context.Context, jobID string, expectedVersion int, event JobEvent) (int, error) {
 	return s.getShard(jobID).Append(ctx, jobID, expectedVersion, event)
@@ -47,6 +47,11 @@ type SnapshotPayload struct {
 	RecordedRandom           map[string]json.RawMessage `json:"recorded_random,omitempty"`
 	RecordedUUID             map[string]string          `json:"recorded_uuid,omitempty"`
 	RecordedHTTP             map[string]json.RawMessage `json:"recorded_http,omitempty"`
+	ToolProgressStates       map[string]json.RawMessage `json:"tool_progress_states,omitempty"`
+	PlanHash                 string                     `json:"plan_hash,omitempty"`
+	PlanReviewRequired       bool                       `json:"plan_review_required,omitempty"`
+	PlanApprovedHash         string                     `json:"plan_approved_hash,omitempty"`
+	RollbackCheckpointID     string                     `json:"rollback_checkpoint_id,omitempty"`
 }
 
 // SnapshotStore 快照存储接口，扩展 JobStore
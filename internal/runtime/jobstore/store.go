@@ -17,6 +17,7 @@ package jobstore
 import (
 	"context"
 	"errors"
+	"math"
 )
 
 var (
@@ -80,3 +81,55 @@ type JobStore interface {
 	// DeleteSnapshotsBefore 删除指定版本之前的所有快照（用于 compaction）
 	DeleteSnapshotsBefore(ctx context.Context, jobID string, beforeVersion int) error
 }
+
+// EventRangeLister 可选能力：仅返回 version 大于 afterVersion 的事件（按序）及当前 version；
+// 供增量重放（replay 增量构建）只读取新增事件，避免每步重读整条事件流。
+// 只透传读取的装饰器无需实现（EventsSince 经 Unwrap 穿透）；改写 ListEvents 结果的装饰器（如解密）须同时实现本接口
+type EventRangeLister interface {
+	ListEventsSince(ctx context.Context, jobID string, afterVersion int) ([]JobEvent, int, error)
+}
+
+// EventsSince 返回 store 中 afterVersion 之后的事件及当前 version；沿 Unwrap 链找到 EventRangeLister 时直接调用，否则回退为 ListEvents 后截取
+func EventsSince(ctx context.Context, store JobStore, jobID string, afterVersion int) ([]JobEvent, int, error) {
+	if l, ok := Find[EventRangeLister](store); ok {
+		return l.ListEventsSince(ctx, jobID, afterVersion)
+	}
+	events, version, err := store.ListEvents(ctx, jobID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if afterVersion < 0 {
+		afterVersion = 0
+	}
+	if afterVersion >= len(events) {
+		return nil, version, nil
+	}
+	return events[afterVersion:], version, nil
+}
+
+// CurrentVersion 返回该 job 的当前事件 version，实现 EventRangeLister 时不读取事件本身
+func CurrentVersion(ctx context.Context, store JobStore, jobID string) (int, error) {
+	_, version, err := EventsSince(ctx, store, jobID, math.MaxInt)
+	return version, err
+}
+
+// Wrapper 由 JobStore 装饰器（如事件导出）实现，暴露被包装的底层 JobStore，使可选能力探测（SnapshotJobStore、ListActiveWorkerIDs 等）可穿透装饰层
+type Wrapper interface {
+	Unwrap() JobStore
+}
+
+// Find 沿 Unwrap 链查找第一个实现 T 的 JobStore；用于替代直接类型断言
+func Find[T any](store JobStore) (T, bool) {
+	for store != nil {
+		if t, ok := store.(T); ok {
+			return t, true
+		}
+		w, ok := store.(Wrapper)
+		if !ok {
+			break
+		}
+		store = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
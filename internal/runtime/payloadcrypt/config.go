@@ -0,0 +1,56 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payloadcrypt
+
+import (
+	"fmt"
+
+	"rag-platform/pkg/config"
+)
+
+// NewFromConfig 按配置创建 Keyring；未启用时返回 nil, nil
+func NewFromConfig(cfg config.PayloadEncryptionConfig) (*Keyring, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	tenantKeys := make(map[string][]byte, len(cfg.Keys))
+	for tenant, s := range cfg.Keys {
+		k, err := DecodeKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("payload_encryption.keys.%s: %w", tenant, err)
+		}
+		if len(k) > 0 {
+			tenantKeys[tenant] = k
+		}
+	}
+	defaultKey, err := DecodeKey(cfg.DefaultKey)
+	if err != nil {
+		return nil, fmt.Errorf("payload_encryption.default_key: %w", err)
+	}
+	var retired [][]byte
+	for i, s := range cfg.RetiredKeys {
+		k, err := DecodeKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("payload_encryption.retired_keys[%d]: %w", i, err)
+		}
+		if len(k) > 0 {
+			retired = append(retired, k)
+		}
+	}
+	if len(tenantKeys) == 0 && len(defaultKey) == 0 {
+		return nil, fmt.Errorf("payload_encryption enabled but no keys configured")
+	}
+	return NewKeyring(tenantKeys, defaultKey, retired)
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package payloadcrypt 对 Job 目标与事件中的敏感字段做按租户的载荷级加密：API 写入前加密，
+// Worker 执行时解密；数据库中只保存密文与明文哈希，数据库管理员无法读取客户提示词。
+package payloadcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Prefix 密文信封前缀；信封格式为 "aeenc:v1:<key_id>:<base64(nonce|ciphertext)>"
+const Prefix = "aeenc:v1:"
+
+var (
+	// ErrUnknownKey 密文使用的密钥不在当前 Keyring 中（未配置或已移除）
+	ErrUnknownKey = errors.New("payloadcrypt: unknown key id")
+	// ErrMalformed 密文信封格式error
+	ErrMalformed = errors.New("payloadcrypt: malformed envelope")
+)
+
+// Keyring 按租户的 AES-256-GCM 密钥集合；密钥以 key_id（密钥 SHA256 前 8 字节 hex）标识，
+// 解密只依赖信封中的 key_id，因此轮换后旧密钥可作为 retired 密钥继续解密历史数据
+type Keyring struct {
+	tenants    map[string]*key
+	defaultKey *key
+	byID       map[string]*key
+}
+
+type key struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewKeyring 创建 Keyring；tenantKeys 为租户 → 32 字节密钥，defaultKey 用于未单独配置的租户（为空则这些租户不加密），
+// retired 为仅用于解密的历史密钥
+func NewKeyring(tenantKeys map[string][]byte, defaultKey []byte, retired [][]byte) (*Keyring, error) {
+	kr := &Keyring{tenants: make(map[string]*key, len(tenantKeys)), byID: make(map[string]*key)}
+	for tenant, raw := range tenantKeys {
+		k, err := kr.add(raw)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		kr.tenants[tenant] = k
+	}
+	if len(defaultKey) > 0 {
+		k, err := kr.add(defaultKey)
+		if err != nil {
+			return nil, fmt.Errorf("default key: %w", err)
+		}
+		kr.defaultKey = k
+	}
+	for i, raw := range retired {
+		if _, err := kr.add(raw); err != nil {
+			return nil, fmt.Errorf("retired key %d: %w", i, err)
+		}
+	}
+	return kr, nil
+}
+
+func (kr *Keyring) add(raw []byte) (*key, error) {
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes (AES-256), got %d", len(raw))
+	}
+	sum := sha256.Sum256(raw)
+	id := hex.EncodeToString(sum[:8])
+	if k, ok := kr.byID[id]; ok {
+		return k, nil
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	k := &key{id: id, aead: aead}
+	kr.byID[id] = k
+	return k, nil
+}
+
+// DecodeKey 解析配置中的密钥：base64（标准或 URL 编码）；"${ENV}" 形式从环境变量读取
+func DecodeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
+		s = strings.TrimSpace(os.Getenv(strings.TrimSuffix(strings.TrimPrefix(s, "${"), "}")))
+	}
+	if s == "" {
+		return nil, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// Enabled 租户是否有可用的加密密钥
+func (kr *Keyring) Enabled(tenantID string) bool {
+	return kr != nil && kr.keyFor(tenantID) != nil
+}
+
+func (kr *Keyring) keyFor(tenantID string) *key {
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	if k, ok := kr.tenants[tenantID]; ok {
+		return k
+	}
+	return kr.defaultKey
+}
+
+// Seal 用租户密钥加密 plaintext 并返回信封；租户无密钥或已是密文时原样返回
+func (kr *Keyring) Seal(tenantID, plaintext string) (string, error) {
+	if kr == nil || IsSealed(plaintext) {
+		return plaintext, nil
+	}
+	k := kr.keyFor(tenantID)
+	if k == nil {
+		return plaintext, nil
+	}
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("payloadcrypt: nonce: %w", err)
+	}
+	// key_id 作为附加数据，防止信封被替换到其他密钥下
+	sealed := k.aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.id))
+	return Prefix + k.id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密信封；非密文原样返回
+func (kr *Keyring) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	if kr == nil {
+		return "", ErrUnknownKey
+	}
+	rest := strings.TrimPrefix(value, Prefix)
+	id, body, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	k, ok := kr.byID[id]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, id)
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(body)
+	if err != nil || len(raw) < k.aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	plain, err := k.aead.Open(nil, raw[:k.aead.NonceSize()], raw[k.aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("payloadcrypt: decrypt: %w", err)
+	}
+	return string(plain), nil
+}
+
+// IsSealed 是否为 payloadcrypt 密文信封
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Hash 明文的 SHA256（hex），与密文一同保存，供在不解密的情况下比对/审计
+func Hash(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
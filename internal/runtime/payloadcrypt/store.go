@@ -0,0 +1,231 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payloadcrypt
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"rag-platform/internal/agent/job"
+	"rag-platform/internal/runtime/jobstore"
+)
+
+// DefaultFields 默认加密的事件载荷字段（顶层）
+var DefaultFields = []string{"goal", "message"}
+
+// HashSuffix 密文字段旁保存明文哈希的字段后缀，如 goal_sha256
+const HashSuffix = "_sha256"
+
+// SealPayload 加密 JSON 对象载荷中的 fields（顶层字符串字段），并写入 <field>_sha256；非对象或租户无密钥时原样返回
+func SealPayload(kr *Keyring, tenantID string, payload []byte, fields []string) ([]byte, error) {
+	if !kr.Enabled(tenantID) || len(payload) == 0 || len(fields) == 0 {
+		return payload, nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return payload, nil
+	}
+	changed := false
+	for _, f := range fields {
+		s, ok := m[f].(string)
+		if !ok || s == "" || IsSealed(s) {
+			continue
+		}
+		sealed, err := kr.Seal(tenantID, s)
+		if err != nil {
+			return nil, err
+		}
+		m[f] = sealed
+		m[f+HashSuffix] = Hash(s)
+		changed = true
+	}
+	if !changed {
+		return payload, nil
+	}
+	return json.Marshal(m)
+}
+
+// OpenPayload 解密载荷中所有顶层密文字段；无法解密（密钥缺失等）的字段保留密文
+func OpenPayload(kr *Keyring, payload []byte) []byte {
+	if !bytes.Contains(payload, []byte(Prefix)) {
+		return payload
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return payload
+	}
+	changed := false
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok || !IsSealed(s) {
+			continue
+		}
+		plain, err := kr.Open(s)
+		if err != nil {
+			continue
+		}
+		m[k] = plain
+		changed = true
+	}
+	if !changed {
+		return payload
+	}
+	out, err := json.Marshal(m)
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+// TenantResolver 返回 job 所属租户（通常查 Job 元数据）
+type TenantResolver func(ctx context.Context, jobID string) (string, error)
+
+// tenantCacheSize jobID -> 租户缓存的最大条目数，超出时淘汰最久未用的条目
+const tenantCacheSize = 4096
+
+// SealingStore JobStore 装饰器：Append 时按 job 所属租户加密载荷敏感字段，ListEvents/Watch 时解密；
+// 应直接包装持久化 Store，使其他装饰器（PII 打标、事件导出等）仍处理明文
+type SealingStore struct {
+	jobstore.JobStore
+	keyring  *Keyring
+	fields   []string
+	tenantOf TenantResolver
+
+	mu      sync.Mutex
+	lru     *list.List
+	tenants map[string]*list.Element
+}
+
+type tenantEntry struct {
+	jobID  string
+	tenant string
+}
+
+// NewSealingStore 包装 inner；fields 为空时使用 DefaultFields；tenantOf 为 nil 时所有 Job 使用 default 租户的密钥
+func NewSealingStore(inner jobstore.JobStore, keyring *Keyring, fields []string, tenantOf TenantResolver) *SealingStore {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+	return &SealingStore{JobStore: inner, keyring: keyring, fields: fields, tenantOf: tenantOf, lru: list.New(), tenants: make(map[string]*list.Element)}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *SealingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+// tenant 解析 job 所属租户，结果按 LRU 缓存；解析失败时返回错误，不以其他租户的密钥代替
+func (s *SealingStore) tenant(ctx context.Context, jobID string) (string, error) {
+	if s.tenantOf == nil {
+		return "", nil
+	}
+	s.mu.Lock()
+	if el, ok := s.tenants[jobID]; ok {
+		s.lru.MoveToFront(el)
+		t := el.Value.(*tenantEntry).tenant
+		s.mu.Unlock()
+		return t, nil
+	}
+	s.mu.Unlock()
+	resolved, err := s.tenantOf(ctx, jobID)
+	if err != nil {
+		return "", fmt.Errorf("payloadcrypt: resolve tenant of job %s: %w", jobID, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tenants[jobID]; !ok {
+		s.tenants[jobID] = s.lru.PushFront(&tenantEntry{jobID: jobID, tenant: resolved})
+		for s.lru.Len() > tenantCacheSize {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.tenants, oldest.Value.(*tenantEntry).jobID)
+		}
+	}
+	return resolved, nil
+}
+
+func (s *SealingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	if len(event.Payload) > 0 {
+		tenantID, err := s.tenant(ctx, jobID)
+		if err != nil {
+			return 0, err
+		}
+		sealed, err := SealPayload(s.keyring, tenantID, event.Payload, s.fields)
+		if err != nil {
+			return 0, err
+		}
+		event.Payload = sealed
+	}
+	return s.JobStore.Append(ctx, jobID, expectedVersion, event)
+}
+
+func (s *SealingStore) ListEvents(ctx context.Context, jobID string) ([]jobstore.JobEvent, int, error) {
+	events, ver, err := s.JobStore.ListEvents(ctx, jobID)
+	if err != nil {
+		return events, ver, err
+	}
+	for i := range events {
+		events[i].Payload = OpenPayload(s.keyring, events[i].Payload)
+	}
+	return events, ver, nil
+}
+
+// ListEventsSince 实现 jobstore.EventRangeLister，返回前解密
+func (s *SealingStore) ListEventsSince(ctx context.Context, jobID string, afterVersion int) ([]jobstore.JobEvent, int, error) {
+	events, ver, err := jobstore.EventsSince(ctx, s.JobStore, jobID, afterVersion)
+	if err != nil {
+		return events, ver, err
+	}
+	for i := range events {
+		events[i].Payload = OpenPayload(s.keyring, events[i].Payload)
+	}
+	return events, ver, nil
+}
+
+func (s *SealingStore) Watch(ctx context.Context, jobID string) (<-chan jobstore.JobEvent, error) {
+	in, err := s.JobStore.Watch(ctx, jobID)
+	if err != nil || in == nil {
+		return in, err
+	}
+	out := make(chan jobstore.JobEvent, cap(in))
+	go func() {
+		defer close(out)
+		for e := range in {
+			e.Payload = OpenPayload(s.keyring, e.Payload)
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// TenantFromJobs 基于 Job 元数据解析租户
+func TenantFromJobs(jobs job.JobStore) TenantResolver {
+	return func(ctx context.Context, jobID string) (string, error) {
+		j, err := jobs.Get(ctx, jobID)
+		if err != nil {
+			return "", err
+		}
+		if j == nil {
+			return "", fmt.Errorf("job %s not found", jobID)
+		}
+		return j.TenantID, nil
+	}
+}
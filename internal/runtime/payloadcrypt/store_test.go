@@ -0,0 +1,149 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payloadcrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestKeyring_SealOpenPerTenant(t *testing.T) {
+	kr, err := NewKeyring(map[string][]byte{"t1": testKey(1), "t2": testKey(2)}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := kr.Seal("t1", "summarize Q3 revenue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || strings.Contains(sealed, "revenue") {
+		t.Fatalf("expected ciphertext envelope, got %q", sealed)
+	}
+	plain, err := kr.Open(sealed)
+	if err != nil || plain != "summarize Q3 revenue" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+	// 未配置密钥且无 default_key 的租户不加密
+	if out, _ := kr.Seal("t3", "hello"); out != "hello" {
+		t.Fatalf("tenant without key should stay plaintext, got %q", out)
+	}
+	// 其他租户的 Keyring 无法解密
+	other, _ := NewKeyring(map[string][]byte{"t2": testKey(2)}, nil, nil)
+	if _, err := other.Open(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestKeyring_RetiredKeyDecrypts(t *testing.T) {
+	old, _ := NewKeyring(map[string][]byte{"t1": testKey(1)}, nil, nil)
+	sealed, _ := old.Seal("t1", "goal")
+	rotated, err := NewKeyring(map[string][]byte{"t1": testKey(9)}, nil, [][]byte{testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := rotated.Open(sealed); err != nil || plain != "goal" {
+		t.Fatalf("retired key should decrypt history: %q %v", plain, err)
+	}
+	resealed, _ := rotated.Seal("t1", "goal")
+	if strings.Split(resealed, ":")[2] == strings.Split(sealed, ":")[2] {
+		t.Fatal("new writes should use the current key")
+	}
+}
+
+func TestSealingStore_EventsStoreCiphertextAndHash(t *testing.T) {
+	ctx := context.Background()
+	kr, _ := NewKeyring(nil, testKey(7), nil)
+	inner := jobstore.NewMemoryStore()
+	store := NewSealingStore(inner, kr, nil, func(ctx context.Context, jobID string) (string, error) { return "acme", nil })
+
+	payload, _ := json.Marshal(map[string]string{"agent_id": "a1", "goal": "wire $10k to vendor"})
+	if _, err := store.Append(ctx, "job-1", 0, jobstore.JobEvent{JobID: "job-1", Type: jobstore.JobCreated, Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _, err := inner.ListEvents(ctx, "job-1")
+	if err != nil || len(raw) != 1 {
+		t.Fatalf("inner events: %v %d", err, len(raw))
+	}
+	var stored map[string]string
+	_ = json.Unmarshal(raw[0].Payload, &stored)
+	if !IsSealed(stored["goal"]) || stored["goal_sha256"] != Hash("wire $10k to vendor") || stored["agent_id"] != "a1" {
+		t.Fatalf("persisted payload should hold only ciphertext + hash: %s", raw[0].Payload)
+	}
+
+	events, _, err := store.ListEvents(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opened map[string]string
+	_ = json.Unmarshal(events[0].Payload, &opened)
+	if opened["goal"] != "wire $10k to vendor" {
+		t.Fatalf("ListEvents should decrypt: %s", events[0].Payload)
+	}
+}
+
+func TestSealingStore_UnknownTenantRejectsAppend(t *testing.T) {
+	ctx := context.Background()
+	kr, _ := NewKeyring(map[string][]byte{"acme": testKey(3)}, testKey(7), nil)
+	inner := jobstore.NewMemoryStore()
+	store := NewSealingStore(inner, kr, nil, func(ctx context.Context, jobID string) (string, error) {
+		return "", errors.New("jobs unavailable")
+	})
+	payload, _ := json.Marshal(map[string]string{"goal": "secret"})
+	if _, err := store.Append(ctx, "job-1", 0, jobstore.JobEvent{JobID: "job-1", Type: jobstore.JobCreated, Payload: payload}); err == nil {
+		t.Fatal("append must fail when the job's tenant cannot be resolved")
+	}
+	if events, _, _ := inner.ListEvents(ctx, "job-1"); len(events) != 0 {
+		t.Fatalf("nothing should be written, got %d events", len(events))
+	}
+}
+
+func TestSealingStore_TenantCacheBounded(t *testing.T) {
+	ctx := context.Background()
+	kr, _ := NewKeyring(nil, testKey(7), nil)
+	lookups := 0
+	store := NewSealingStore(jobstore.NewMemoryStore(), kr, nil, func(ctx context.Context, jobID string) (string, error) {
+		lookups++
+		return "acme", nil
+	})
+	payload := []byte(`{"goal":"g"}`)
+	for i := 0; i < tenantCacheSize+10; i++ {
+		id := fmt.Sprintf("job-%d", i)
+		if _, err := store.Append(ctx, id, 0, jobstore.JobEvent{JobID: id, Type: jobstore.JobCreated, Payload: payload}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(store.tenants); n != tenantCacheSize || store.lru.Len() != tenantCacheSize {
+		t.Fatalf("cache size = %d, want %d", n, tenantCacheSize)
+	}
+	last := fmt.Sprintf("job-%d", tenantCacheSize+9)
+	if _, err := store.Append(ctx, last, 1, jobstore.JobEvent{JobID: last, Type: jobstore.JobRunning, Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	if lookups != tenantCacheSize+10 {
+		t.Fatalf("recent job should hit the cache, lookups = %d", lookups)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piitag
+
+import (
+	"context"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/log"
+	"rag-platform/pkg/pii"
+)
+
+// DefaultEventTypes 未配置 event_types 时扫描的事件：工具输入/输出与 LLM 消息、推理内容
+var DefaultEventTypes = []jobstore.EventType{
+	jobstore.ToolCalled,
+	jobstore.ToolReturned,
+	jobstore.ToolInvocationStarted,
+	jobstore.ToolInvocationFinished,
+	jobstore.CommandCommitted,
+	jobstore.NodeFinished,
+	jobstore.AgentMessage,
+	jobstore.AgentThoughtRecorded,
+	jobstore.ReasoningSnapshot,
+	jobstore.DecisionSnapshot,
+	jobstore.ToolResultSummarized,
+}
+
+// TaggingStore JobStore 装饰器：Append 成功后检测 payload 中的 PII 并写入 TagStore；事件本身不做修改
+type TaggingStore struct {
+	jobstore.JobStore
+	detector *pii.Detector
+	tags     TagStore
+	types    map[jobstore.EventType]struct{}
+	logger   *log.Logger
+}
+
+// NewTaggingStore 包装 inner；eventTypes 为空时使用 DefaultEventTypes
+func NewTaggingStore(inner jobstore.JobStore, detector *pii.Detector, tags TagStore, eventTypes []string, logger *log.Logger) *TaggingStore {
+	set := make(map[jobstore.EventType]struct{})
+	for _, t := range eventTypes {
+		if t != "" {
+			set[jobstore.EventType(t)] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		for _, t := range DefaultEventTypes {
+			set[t] = struct{}{}
+		}
+	}
+	return &TaggingStore{JobStore: inner, detector: detector, tags: tags, types: set, logger: logger}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *TaggingStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+// Append 先写入底层事件流，成功后检测并保存标签；检测或保存失败不影响 Append 结果
+func (s *TaggingStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	newVersion, err := s.JobStore.Append(ctx, jobID, expectedVersion, event)
+	if err != nil {
+		return newVersion, err
+	}
+	if _, ok := s.types[event.Type]; !ok {
+		return newVersion, nil
+	}
+	findings := s.detector.ScanJSON(event.Payload)
+	if len(findings) == 0 {
+		return newVersion, nil
+	}
+	tag := Tag{
+		JobID:      jobID,
+		Version:    newVersion,
+		EventType:  string(event.Type),
+		Categories: pii.Categories(findings),
+		Findings:   findings,
+		CreatedAt:  time.Now(),
+	}
+	if serr := s.tags.Save(context.WithoutCancel(ctx), tag); serr != nil && s.logger != nil {
+		s.logger.Warn("保存 PII 标签失败", "job_id", jobID, "event_type", event.Type, "error", serr)
+	}
+	return newVersion, nil
+}
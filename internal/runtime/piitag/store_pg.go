@@ -0,0 +1,78 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piitag
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"rag-platform/pkg/pii"
+)
+
+// TagStorePg PostgreSQL 实现，使用 event_pii_tags 表
+type TagStorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewTagStorePg 创建基于 PostgreSQL 的 TagStore
+func NewTagStorePg(pool *pgxpool.Pool) *TagStorePg {
+	return &TagStorePg{pool: pool}
+}
+
+func (s *TagStorePg) Save(ctx context.Context, tag Tag) error {
+	findings, err := json.Marshal(tag.Findings)
+	if err != nil {
+		return err
+	}
+	categories := make([]string, 0, len(tag.Categories))
+	for _, c := range tag.Categories {
+		categories = append(categories, string(c))
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO event_pii_tags (job_id, version, event_type, categories, findings, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (job_id, version) DO NOTHING`,
+		tag.JobID, tag.Version, tag.EventType, categories, findings, tag.CreatedAt)
+	return err
+}
+
+func (s *TagStorePg) ListByJob(ctx context.Context, jobID string) ([]Tag, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT job_id, version, event_type, categories, findings, created_at
+		 FROM event_pii_tags WHERE job_id = $1 ORDER BY version`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Tag
+	for rows.Next() {
+		var t Tag
+		var categories []string
+		var findings []byte
+		if err := rows.Scan(&t.JobID, &t.Version, &t.EventType, &categories, &findings, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		for _, c := range categories {
+			t.Categories = append(t.Categories, pii.Category(c))
+		}
+		if len(findings) > 0 {
+			_ = json.Unmarshal(findings, &t.Findings)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,70 @@
+package piitag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/pii"
+	"rag-platform/pkg/redaction"
+)
+
+func TestTaggingStore_TagsSelectedEventsWithoutRawValues(t *testing.T) {
+	ctx := context.Background()
+	tags := NewTagStoreMem()
+	store := NewTaggingStore(jobstore.NewMemoryStore(), pii.NewDetector(nil), tags, nil, nil)
+
+	v, err := store.Append(ctx, "j1", 0, jobstore.JobEvent{JobID: "j1", Type: jobstore.JobCreated, Payload: []byte(`{"goal":"mail alice@example.com"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = store.Append(ctx, "j1", v, jobstore.JobEvent{JobID: "j1", Type: jobstore.ToolInvocationFinished, Payload: []byte(`{"input":{"to":"alice@example.com"},"output":"ok"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(ctx, "j1", v, jobstore.JobEvent{JobID: "j1", Type: jobstore.ToolReturned, Payload: []byte(`{"output":"nothing here"}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := tags.ListByJob(ctx, "j1")
+	if len(got) != 1 {
+		t.Fatalf("expected only the tool_invocation_finished event to be tagged (job_created not scanned by default), got %+v", got)
+	}
+	if got[0].Version != 2 || got[0].EventType != "tool_invocation_finished" || got[0].Findings[0].Path != "input.to" {
+		t.Fatalf("unexpected tag: %+v", got[0])
+	}
+	for _, f := range got[0].Findings {
+		if strings.Contains(f.Path, "@") {
+			t.Fatalf("tag must not contain raw values: %+v", f)
+		}
+	}
+
+	// 事件本身不被修改
+	events, _, _ := store.ListEvents(ctx, "j1")
+	if !strings.Contains(string(events[1].Payload), "alice@example.com") {
+		t.Fatal("tagging must not alter the stored payload")
+	}
+}
+
+func TestSummarizeAndRedactionPolicy(t *testing.T) {
+	tags := []Tag{
+		{JobID: "j1", Version: 2, EventType: "tool_returned", Categories: []pii.Category{pii.CategoryEmail},
+			Findings: []pii.Finding{{Path: "output.email", Category: pii.CategoryEmail}}},
+		{JobID: "j1", Version: 5, EventType: "tool_returned", Categories: []pii.Category{pii.CategoryEmail, pii.CategoryPhone},
+			Findings: []pii.Finding{{Path: "output.email", Category: pii.CategoryEmail}, {Path: "output.phone", Category: pii.CategoryPhone}}},
+	}
+	sum := Summarize("j1", tags)
+	if sum.TaggedEvents != 2 || sum.Categories[pii.CategoryEmail] != 2 || sum.Categories[pii.CategoryPhone] != 1 || len(sum.Fields) != 2 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+
+	policy := RedactionPolicy(tags, "", "")
+	masks := policy.EventRules["tool_returned"]
+	if len(masks) != 2 || masks[0].FieldPath != "output.email" || masks[0].Mode != redaction.RedactionModeRedact {
+		t.Fatalf("unexpected policy: %+v", policy)
+	}
+	if RedactionPolicy(nil, redaction.RedactionModeHash, "") != nil {
+		t.Fatal("no tags should yield nil policy")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piitag
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"rag-platform/pkg/pii"
+	"rag-platform/pkg/redaction"
+)
+
+// Tag 单条事件的 PII 标签：仅记录类别与字段路径，不保存原始匹配值
+type Tag struct {
+	JobID      string         `json:"job_id"`
+	Version    int            `json:"version"` // 事件写入后的 job version（即事件序号，从 1 开始）
+	EventType  string         `json:"event_type"`
+	Categories []pii.Category `json:"categories"`
+	Findings   []pii.Finding  `json:"findings"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// TagStore PII 标签存储
+type TagStore interface {
+	Save(ctx context.Context, tag Tag) error
+	// ListByJob 返回该 job 的全部标签（按 version 升序）
+	ListByJob(ctx context.Context, jobID string) ([]Tag, error)
+}
+
+// TagStoreMem 内存实现
+type TagStoreMem struct {
+	mu    sync.RWMutex
+	byJob map[string][]Tag
+}
+
+// NewTagStoreMem 创建内存 TagStore
+func NewTagStoreMem() *TagStoreMem {
+	return &TagStoreMem{byJob: make(map[string][]Tag)}
+}
+
+func (s *TagStoreMem) Save(ctx context.Context, tag Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byJob[tag.JobID] = append(s.byJob[tag.JobID], tag)
+	return nil
+}
+
+func (s *TagStoreMem) ListByJob(ctx context.Context, jobID string) ([]Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := append([]Tag(nil), s.byJob[jobID]...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// JobSummary 单个 job 的 PII 汇总
+type JobSummary struct {
+	JobID        string               `json:"job_id"`
+	TaggedEvents int                  `json:"tagged_events"`
+	Categories   map[pii.Category]int `json:"categories"`  // 类别 -> 命中事件数
+	EventTypes   map[string]int       `json:"event_types"` // 事件类型 -> 命中事件数
+	Fields       []pii.Finding        `json:"fields"`      // 去重后的字段路径与类别
+}
+
+// Summarize 汇总标签
+func Summarize(jobID string, tags []Tag) JobSummary {
+	sum := JobSummary{
+		JobID:      jobID,
+		Categories: make(map[pii.Category]int),
+		EventTypes: make(map[string]int),
+		Fields:     []pii.Finding{},
+	}
+	seen := make(map[pii.Finding]bool)
+	for _, t := range tags {
+		sum.TaggedEvents++
+		sum.EventTypes[t.EventType]++
+		for _, c := range t.Categories {
+			sum.Categories[c]++
+		}
+		for _, f := range t.Findings {
+			if f.Path != "" && !seen[f] {
+				seen[f] = true
+				sum.Fields = append(sum.Fields, f)
+			}
+		}
+	}
+	sort.Slice(sum.Fields, func(i, j int) bool {
+		if sum.Fields[i].Path != sum.Fields[j].Path {
+			return sum.Fields[i].Path < sum.Fields[j].Path
+		}
+		return sum.Fields[i].Category < sum.Fields[j].Category
+	})
+	return sum
+}
+
+// RedactionPolicy 由标签生成证据导出的脱敏策略：按事件类型汇总命中字段；无可定位字段时返回 nil
+func RedactionPolicy(tags []Tag, mode redaction.RedactionMode, salt string) *redaction.RedactionPolicy {
+	if mode == "" {
+		mode = redaction.RedactionModeRedact
+	}
+	byType := make(map[string][]pii.Finding)
+	for _, t := range tags {
+		byType[t.EventType] = append(byType[t.EventType], t.Findings...)
+	}
+	policy := &redaction.RedactionPolicy{EventRules: make(map[string][]redaction.FieldMask)}
+	for eventType, findings := range byType {
+		if masks := pii.FieldMasks(findings, mode, salt); len(masks) > 0 {
+			policy.EventRules[eventType] = masks
+		}
+	}
+	if len(policy.EventRules) == 0 {
+		return nil
+	}
+	return policy
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package serviceaccount Worker 服务账号：为每个 Worker 提供独立身份与事件归属（JobEvent.Actor），
+// 支持令牌轮换（旧令牌在宽限期内仍有效）与吊销。令牌在 Worker 进程内校验，不是安全边界：
+// Worker 仍持有数据库连接串，其数据库权限由 DSN 对应的角色决定。
+package serviceaccount
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"rag-platform/pkg/auth"
+)
+
+var (
+	// ErrNotFound 服务账号不存在
+	ErrNotFound = errors.New("serviceaccount: not found")
+	// ErrInvalidToken 令牌格式错误、不匹配任何账号或已过宽限期
+	ErrInvalidToken = errors.New("serviceaccount: invalid token")
+	// ErrRevoked 服务账号已吊销
+	ErrRevoked = errors.New("serviceaccount: revoked")
+)
+
+// TokenPrefix 服务账号令牌前缀，便于在日志与密钥扫描中识别
+const TokenPrefix = "aesa_"
+
+// DefaultRotationGrace 轮换后旧令牌的默认宽限期：Worker 在此期间重新读取新令牌即可无中断切换
+const DefaultRotationGrace = 10 * time.Minute
+
+// Account Worker 服务账号；库中仅保存令牌的 SHA256，明文令牌只在签发/轮换时返回一次
+type Account struct {
+	ID                 string
+	TenantID           string
+	Name               string
+	Role               auth.Role
+	TokenHash          string
+	PrevTokenHash      string    // 轮换前的令牌 hash，PrevTokenExpiresAt 之前仍可认证
+	PrevTokenExpiresAt time.Time // 零值表示无旧令牌
+	CreatedAt          time.Time
+	RotatedAt          time.Time
+	RevokedAt          time.Time // 零值表示未吊销
+}
+
+// Revoked 是否已吊销
+func (a *Account) Revoked() bool {
+	return a != nil && !a.RevokedAt.IsZero()
+}
+
+// Scopes 账号角色所授予的权限
+func (a *Account) Scopes() []auth.Permission {
+	if a == nil {
+		return nil
+	}
+	return auth.RolePermissions[a.Role]
+}
+
+// Can 账号是否持有指定权限；已吊销的账号不持有任何权限
+func (a *Account) Can(permission auth.Permission) bool {
+	if a == nil || a.Revoked() {
+		return false
+	}
+	return auth.HasPermission(a.Role, permission)
+}
+
+// matches 令牌 hash 是否为当前令牌，或仍在宽限期内的旧令牌
+func (a *Account) matches(hash string, now time.Time) bool {
+	if hash == "" {
+		return false
+	}
+	if a.TokenHash == hash {
+		return true
+	}
+	return a.PrevTokenHash == hash && now.Before(a.PrevTokenExpiresAt)
+}
+
+// NewToken 生成新的随机令牌（TokenPrefix + 32 字节 base64url）
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken 返回令牌的 SHA256（hex）；存储与查找均使用 hash
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue 为租户签发新的 Worker 服务账号，返回账号与明文令牌
+func Issue(ctx context.Context, store Store, tenantID, name string) (*Account, string, error) {
+	token, err := NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	a := &Account{
+		TenantID:  tenantID,
+		Name:      name,
+		Role:      auth.RoleWorker,
+		TokenHash: HashToken(token),
+		CreatedAt: now,
+		RotatedAt: now,
+	}
+	if err := store.Create(ctx, a); err != nil {
+		return nil, "", err
+	}
+	return a, token, nil
+}
+
+// Rotate 为账号签发新令牌；旧令牌在 grace 内仍可认证（grace<=0 时立即失效）
+func Rotate(ctx context.Context, store Store, id string, grace time.Duration) (*Account, string, error) {
+	a, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if a.Revoked() {
+		return nil, "", ErrRevoked
+	}
+	token, err := NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	a.PrevTokenHash, a.PrevTokenExpiresAt = "", time.Time{}
+	if grace > 0 {
+		a.PrevTokenHash = a.TokenHash
+		a.PrevTokenExpiresAt = now.Add(grace)
+	}
+	a.TokenHash = HashToken(token)
+	a.RotatedAt = now
+	if err := store.Update(ctx, a); err != nil {
+		return nil, "", err
+	}
+	return a, token, nil
+}
+
+// Revoke 吊销账号：当前与旧令牌均立即失效
+func Revoke(ctx context.Context, store Store, id string) (*Account, error) {
+	a, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Revoked() {
+		return a, nil
+	}
+	a.RevokedAt = time.Now()
+	a.PrevTokenHash, a.PrevTokenExpiresAt = "", time.Time{}
+	if err := store.Update(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Authenticate 以明文令牌认证服务账号；令牌无效返回 ErrInvalidToken，账号已吊销返回 ErrRevoked
+func Authenticate(ctx context.Context, store Store, token string) (*Account, error) {
+	token = strings.TrimSpace(token)
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	hash := HashToken(token)
+	a, err := store.FindByTokenHash(ctx, hash)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.Revoked() {
+		return nil, ErrRevoked
+	}
+	if !a.matches(hash, time.Now()) {
+		return nil, ErrInvalidToken
+	}
+	return a, nil
+}
+
+// Actor 事件归属标识：经服务账号认证的 Worker 为 "worker:<worker_id>@<account_id>"，未认证为 "worker:<worker_id>"
+func Actor(workerID string, a *Account) string {
+	actor := "worker:" + workerID
+	if a != nil && a.ID != "" {
+		actor += "@" + a.ID
+	}
+	return actor
+}
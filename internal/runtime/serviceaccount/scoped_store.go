@@ -0,0 +1,202 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/log"
+)
+
+// ErrUnauthorized Worker 身份缺失、已吊销或不具备所需权限时，ScopedStore 拒绝认领/追加
+var ErrUnauthorized = errors.New("serviceaccount: worker not authorized")
+
+// DefaultRefreshInterval Identity 重新校验令牌的默认间隔
+const DefaultRefreshInterval = time.Minute
+
+// Identity Worker 的服务账号身份：从配置令牌或令牌文件认证，并周期性重新校验以感知轮换与吊销
+type Identity struct {
+	store     Store
+	workerID  string
+	token     string
+	tokenFile string
+
+	mu      sync.RWMutex
+	account *Account
+	lastErr error
+}
+
+// NewIdentity 创建 Identity；token 与 tokenFile 二选一，tokenFile 优先且每次 Refresh 时重新读取
+func NewIdentity(store Store, workerID, token, tokenFile string) *Identity {
+	return &Identity{store: store, workerID: workerID, token: token, tokenFile: tokenFile}
+}
+
+// Configured 是否配置了令牌来源
+func (id *Identity) Configured() bool {
+	return id != nil && (id.token != "" || id.tokenFile != "")
+}
+
+// Refresh 读取令牌并重新认证；失败时清空当前账号，使后续认领/追加被拒绝
+func (id *Identity) Refresh(ctx context.Context) error {
+	token := id.token
+	if id.tokenFile != "" {
+		b, err := os.ReadFile(id.tokenFile)
+		if err != nil {
+			id.set(nil, fmt.Errorf("读取服务账号令牌文件failed: %w", err))
+			return id.Err()
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	a, err := Authenticate(ctx, id.store, token)
+	if err == nil && !a.Can(auth.PermissionJobClaim) {
+		a, err = nil, fmt.Errorf("%w: role %q lacks %s", ErrUnauthorized, a.Role, auth.PermissionJobClaim)
+	}
+	id.set(a, err)
+	return err
+}
+
+func (id *Identity) set(a *Account, err error) {
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	id.account, id.lastErr = a, err
+}
+
+// Account 当前认证通过的账号；未认证返回 nil
+func (id *Identity) Account() *Account {
+	id.mu.RLock()
+	defer id.mu.RUnlock()
+	return id.account
+}
+
+// Err 最近一次认证的error
+func (id *Identity) Err() error {
+	id.mu.RLock()
+	defer id.mu.RUnlock()
+	return id.lastErr
+}
+
+// Authorize 当前账号是否持有 permission
+func (id *Identity) Authorize(permission auth.Permission) error {
+	a := id.Account()
+	if a == nil || !a.Can(permission) {
+		return fmt.Errorf("%w: %s", ErrUnauthorized, permission)
+	}
+	return nil
+}
+
+// Actor 事件归属标识（见 Actor）
+func (id *Identity) Actor() string {
+	return Actor(id.workerID, id.Account())
+}
+
+// Run 按 interval 周期性 Refresh，直到 ctx 结束；账号状态变化时记录日志
+func (id *Identity) Run(ctx context.Context, interval time.Duration, logger *log.Logger) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := id.Account()
+			err := id.Refresh(ctx)
+			if logger == nil {
+				continue
+			}
+			after := id.Account()
+			switch {
+			case err != nil && before != nil:
+				logger.Error("Worker 服务账号认证failed，停止认领新 Job", "worker_id", id.workerID, "error", err)
+			case err == nil && before == nil:
+				logger.Info("Worker 服务账号认证恢复", "worker_id", id.workerID, "service_account_id", after.ID)
+			case err == nil && before.TokenHash != after.TokenHash:
+				logger.Info("Worker 服务账号令牌已轮换", "worker_id", id.workerID, "service_account_id", after.ID)
+			}
+		}
+	}
+}
+
+// ScopedStore JobStore 装饰器：将追加的事件归属到本 Worker（JobEvent.Actor），账号吊销或令牌过期后停止认领与追加。
+// 校验在 Worker 进程内进行，只约束按配置运行的 Worker，不能阻止持有 DSN 的进程直接访问数据库。
+// identity 为 nil 时不做校验，仅记录 worker 归属
+type ScopedStore struct {
+	jobstore.JobStore
+	workerID string
+	identity *Identity
+}
+
+// NewScopedStore 包装 inner
+func NewScopedStore(inner jobstore.JobStore, workerID string, identity *Identity) *ScopedStore {
+	return &ScopedStore{JobStore: inner, workerID: workerID, identity: identity}
+}
+
+// Unwrap 实现 jobstore.Wrapper
+func (s *ScopedStore) Unwrap() jobstore.JobStore { return s.JobStore }
+
+func (s *ScopedStore) authorize(permission auth.Permission) error {
+	if s.identity == nil {
+		return nil
+	}
+	return s.identity.Authorize(permission)
+}
+
+func (s *ScopedStore) actor() string {
+	if s.identity == nil {
+		return Actor(s.workerID, nil)
+	}
+	return s.identity.Actor()
+}
+
+func (s *ScopedStore) Append(ctx context.Context, jobID string, expectedVersion int, event jobstore.JobEvent) (int, error) {
+	if err := s.authorize(auth.PermissionJobExecute); err != nil {
+		return 0, err
+	}
+	if event.Actor == "" {
+		event.Actor = s.actor()
+	}
+	return s.JobStore.Append(ctx, jobID, expectedVersion, event)
+}
+
+func (s *ScopedStore) Claim(ctx context.Context, workerID string) (string, int, string, error) {
+	if err := s.authorize(auth.PermissionJobClaim); err != nil {
+		return "", 0, "", err
+	}
+	return s.JobStore.Claim(ctx, workerID)
+}
+
+func (s *ScopedStore) ClaimJob(ctx context.Context, workerID string, jobID string) (int, string, error) {
+	if err := s.authorize(auth.PermissionJobClaim); err != nil {
+		return 0, "", err
+	}
+	return s.JobStore.ClaimJob(ctx, workerID, jobID)
+}
+
+func (s *ScopedStore) Heartbeat(ctx context.Context, workerID string, jobID string) error {
+	if err := s.authorize(auth.PermissionJobClaim); err != nil {
+		return err
+	}
+	return s.JobStore.Heartbeat(ctx, workerID, jobID)
+}
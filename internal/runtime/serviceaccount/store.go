@@ -0,0 +1,107 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceaccount
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Store 服务账号存储
+type Store interface {
+	// Create 保存新账号；ID 为空时由实现生成
+	Create(ctx context.Context, a *Account) error
+	// Get 按 ID 获取；不存在返回 ErrNotFound
+	Get(ctx context.Context, id string) (*Account, error)
+	// List 列出租户下全部账号（含已吊销），按创建时间排序
+	List(ctx context.Context, tenantID string) ([]*Account, error)
+	// FindByTokenHash 按当前或轮换前的令牌 hash 查找；宽限期与吊销由调用方（Authenticate）判断
+	FindByTokenHash(ctx context.Context, hash string) (*Account, error)
+	// Update 覆盖令牌与吊销字段；不存在返回 ErrNotFound
+	Update(ctx context.Context, a *Account) error
+}
+
+// StoreMem 内存实现，用于单机与测试
+type StoreMem struct {
+	mu       sync.RWMutex
+	accounts map[string]*Account
+}
+
+// NewStoreMem 创建内存 Store
+func NewStoreMem() *StoreMem {
+	return &StoreMem{accounts: make(map[string]*Account)}
+}
+
+func (s *StoreMem) Create(ctx context.Context, a *Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.ID == "" {
+		a.ID = "sa-" + uuid.New().String()
+	}
+	cp := *a
+	s.accounts[a.ID] = &cp
+	return nil
+}
+
+func (s *StoreMem) Get(ctx context.Context, id string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (s *StoreMem) List(ctx context.Context, tenantID string) ([]*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Account, 0)
+	for _, a := range s.accounts {
+		if a.TenantID == tenantID {
+			cp := *a
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *StoreMem) FindByTokenHash(ctx context.Context, hash string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.accounts {
+		if hash != "" && (a.TokenHash == hash || a.PrevTokenHash == hash) {
+			cp := *a
+			return &cp, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *StoreMem) Update(ctx context.Context, a *Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[a.ID]; !ok {
+		return ErrNotFound
+	}
+	cp := *a
+	s.accounts[a.ID] = &cp
+	return nil
+}
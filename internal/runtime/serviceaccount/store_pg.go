@@ -0,0 +1,131 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"rag-platform/pkg/auth"
+)
+
+// StorePg PostgreSQL 实现，使用 service_accounts 表
+type StorePg struct {
+	pool *pgxpool.Pool
+}
+
+// NewStorePg 创建基于 PostgreSQL 的服务账号存储
+func NewStorePg(pool *pgxpool.Pool) *StorePg {
+	return &StorePg{pool: pool}
+}
+
+const accountColumns = `id, tenant_id, name, role, token_hash, COALESCE(prev_token_hash, ''), prev_token_expires_at, created_at, rotated_at, revoked_at`
+
+func (s *StorePg) Create(ctx context.Context, a *Account) error {
+	if a.ID == "" {
+		a.ID = "sa-" + uuid.New().String()
+	}
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO service_accounts (id, tenant_id, name, role, token_hash, created_at, rotated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		a.ID, a.TenantID, a.Name, string(a.Role), a.TokenHash, a.CreatedAt, a.RotatedAt)
+	return err
+}
+
+func (s *StorePg) Get(ctx context.Context, id string) (*Account, error) {
+	a, err := scanAccount(s.pool.QueryRow(ctx, `SELECT `+accountColumns+` FROM service_accounts WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *StorePg) List(ctx context.Context, tenantID string) ([]*Account, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+accountColumns+` FROM service_accounts WHERE tenant_id = $1 ORDER BY created_at`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]*Account, 0)
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *StorePg) FindByTokenHash(ctx context.Context, hash string) (*Account, error) {
+	if hash == "" {
+		return nil, ErrNotFound
+	}
+	a, err := scanAccount(s.pool.QueryRow(ctx,
+		`SELECT `+accountColumns+` FROM service_accounts WHERE token_hash = $1 OR prev_token_hash = $1 LIMIT 1`, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *StorePg) Update(ctx context.Context, a *Account) error {
+	cmd, err := s.pool.Exec(ctx,
+		`UPDATE service_accounts SET token_hash = $2, prev_token_hash = $3, prev_token_expires_at = $4, rotated_at = $5, revoked_at = $6 WHERE id = $1`,
+		a.ID, a.TokenHash, nullString(a.PrevTokenHash), nullTime(a.PrevTokenExpiresAt), a.RotatedAt, nullTime(a.RevokedAt))
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanAccount(row pgx.Row) (*Account, error) {
+	var a Account
+	var role string
+	var prevExpires, revoked *time.Time
+	if err := row.Scan(&a.ID, &a.TenantID, &a.Name, &role, &a.TokenHash, &a.PrevTokenHash, &prevExpires, &a.CreatedAt, &a.RotatedAt, &revoked); err != nil {
+		return nil, err
+	}
+	a.Role = auth.Role(role)
+	if prevExpires != nil {
+		a.PrevTokenExpiresAt = *prevExpires
+	}
+	if revoked != nil {
+		a.RevokedAt = *revoked
+	}
+	return &a, nil
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
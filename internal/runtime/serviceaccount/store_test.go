@@ -0,0 +1,148 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/pkg/auth"
+)
+
+func TestIssueAuthenticateRotateRevoke(t *testing.T) {
+	ctx := context.Background()
+	store := NewStoreMem()
+	a, token, err := Issue(ctx, store, "t1", "worker-a")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if a.Role != auth.RoleWorker || a.Can(auth.PermissionAgentManage) || !a.Can(auth.PermissionJobClaim) {
+		t.Fatalf("issued account should be scoped to claim/execute, got role %q", a.Role)
+	}
+	if a.TokenHash == token || a.TokenHash != HashToken(token) {
+		t.Fatal("store must keep only the token hash")
+	}
+	if got, err := Authenticate(ctx, store, token); err != nil || got.ID != a.ID {
+		t.Fatalf("Authenticate = %v, %v", got, err)
+	}
+	if _, err := Authenticate(ctx, store, TokenPrefix+"bogus"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unknown token err = %v, want ErrInvalidToken", err)
+	}
+
+	_, newToken, err := Rotate(ctx, store, a.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := Authenticate(ctx, store, token); err != nil {
+		t.Fatalf("old token should stay valid within grace: %v", err)
+	}
+	if _, err := Authenticate(ctx, store, newToken); err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	_, newerToken, err := Rotate(ctx, store, a.ID, 0)
+	if err != nil {
+		t.Fatalf("Rotate without grace: %v", err)
+	}
+	if _, err := Authenticate(ctx, store, newToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("rotated-out token err = %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := Revoke(ctx, store, a.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := Authenticate(ctx, store, newerToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("revoked token err = %v, want ErrRevoked", err)
+	}
+	if _, _, err := Rotate(ctx, store, a.ID, 0); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("rotate revoked err = %v, want ErrRevoked", err)
+	}
+}
+
+func TestScopedStore_AuthorizesAndAttributes(t *testing.T) {
+	ctx := context.Background()
+	accounts := NewStoreMem()
+	a, token, err := Issue(ctx, accounts, "t1", "worker-a")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	identity := NewIdentity(accounts, "w1", "", tokenFile)
+	if err := identity.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	inner := jobstore.NewMemoryStore()
+	store := NewScopedStore(inner, "w1", identity)
+
+	if _, err := store.Append(ctx, "job-1", 0, jobstore.JobEvent{Type: jobstore.JobCreated}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	events, _, _ := inner.ListEvents(ctx, "job-1")
+	if len(events) != 1 || events[0].Actor != "worker:w1@"+a.ID {
+		t.Fatalf("event actor = %+v, want worker:w1@%s", events, a.ID)
+	}
+	if _, ok := jobstore.Find[*ScopedStore](store); !ok {
+		t.Fatal("Find should locate ScopedStore")
+	}
+
+	// 轮换：令牌文件更新后 Refresh 即切换到新令牌
+	_, newToken, err := Rotate(ctx, accounts, a.ID, 0)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := identity.Refresh(ctx); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("stale token file err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := store.Append(ctx, "job-1", 1, jobstore.JobEvent{Type: jobstore.JobRunning}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("append with stale token err = %v, want ErrUnauthorized", err)
+	}
+	if err := os.WriteFile(tokenFile, []byte(newToken), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := identity.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh after rotation: %v", err)
+	}
+
+	// 吊销：重新校验后认领被拒绝
+	if _, err := Revoke(ctx, accounts, a.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := identity.Refresh(ctx); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("Refresh after revoke err = %v, want ErrRevoked", err)
+	}
+	if _, _, _, err := store.Claim(ctx, "w1"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("claim after revoke err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestScopedStore_WithoutIdentityAttributesWorker(t *testing.T) {
+	ctx := context.Background()
+	inner := jobstore.NewMemoryStore()
+	store := NewScopedStore(inner, "w2", nil)
+	if _, err := store.Append(ctx, "job-1", 0, jobstore.JobEvent{Type: jobstore.JobCreated}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	events, _, _ := inner.ListEvents(ctx, "job-1")
+	if len(events) != 1 || events[0].Actor != "worker:w2" {
+		t.Fatalf("event actor = %+v, want worker:w2", events)
+	}
+}
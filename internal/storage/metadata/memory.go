@@ -23,14 +23,18 @@ import (
 
 // MemoryStore 内存元数据存储实现
 type MemoryStore struct {
-	docs map[string]*Document
-	mu   sync.RWMutex
+	docs     map[string]*Document
+	sources  map[string]string             // source_key -> 文档 ID
+	versions map[string][]*DocumentVersion // 文档 ID -> 版本历史
+	mu       sync.RWMutex
 }
 
 // NewMemoryStore 创建新的内存元数据存储
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		docs: make(map[string]*Document),
+		docs:     make(map[string]*Document),
+		sources:  make(map[string]string),
+		versions: make(map[string][]*DocumentVersion),
 	}
 }
 
@@ -261,3 +265,71 @@ func (s *MemoryStore) Count(ctx context.Context, filter *Filter) (int64, error)
 func (s *MemoryStore) Close() error {
 	return nil
 }
+
+// FindBySource 实现 VersionStore
+func (s *MemoryStore) FindBySource(ctx context.Context, sourceKey string) (*Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.sources[sourceKey]
+	if !ok {
+		return nil, nil
+	}
+	return s.docs[id], nil
+}
+
+// CommitVersion 实现 VersionStore
+func (s *MemoryStore) CommitVersion(ctx context.Context, v *DocumentVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	for _, prev := range s.versions[v.DocumentID] {
+		if prev.Status == VersionCurrent {
+			prev.Status = VersionSuperseded
+			prev.SupersededAt = now
+		}
+	}
+	if v.CreatedAt == 0 {
+		v.CreatedAt = now
+	}
+	v.Status = VersionCurrent
+	s.versions[v.DocumentID] = append(s.versions[v.DocumentID], v)
+	if v.SourceKey != "" {
+		s.sources[v.SourceKey] = v.DocumentID
+	}
+	return nil
+}
+
+// ListVersions 实现 VersionStore
+func (s *MemoryStore) ListVersions(ctx context.Context, documentID string) ([]*DocumentVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*DocumentVersion, len(s.versions[documentID]))
+	for i, v := range s.versions[documentID] {
+		cp := *v
+		out[i] = &cp
+	}
+	return out, nil
+}
+
+// MarkDeleted 实现 VersionStore
+func (s *MemoryStore) MarkDeleted(ctx context.Context, documentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	for _, v := range s.versions[documentID] {
+		if v.Status == VersionCurrent {
+			v.Status = VersionDeleted
+			v.SupersededAt = now
+		}
+	}
+	for key, id := range s.sources {
+		if id == documentID {
+			delete(s.sources, key)
+		}
+	}
+	return nil
+}
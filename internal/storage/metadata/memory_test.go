@@ -90,3 +90,37 @@ func TestMemoryStore_List(t *testing.T) {
 		t.Errorf("List: expected 2, got %d", len(list))
 	}
 }
+
+func TestMemoryStore_Versions(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	_ = s.Create(ctx, &Document{ID: "d1"})
+	if err := s.CommitVersion(ctx, &DocumentVersion{DocumentID: "d1", Version: 1, SourceKey: "uri:a", ContentHash: "h1"}); err != nil {
+		t.Fatalf("CommitVersion: %v", err)
+	}
+	if err := s.CommitVersion(ctx, &DocumentVersion{DocumentID: "d1", Version: 2, SourceKey: "uri:a", ContentHash: "h2"}); err != nil {
+		t.Fatalf("CommitVersion: %v", err)
+	}
+	got, _ := s.FindBySource(ctx, "uri:a")
+	if got == nil || got.ID != "d1" {
+		t.Fatalf("FindBySource: %+v", got)
+	}
+	versions, _ := s.ListVersions(ctx, "d1")
+	if len(versions) != 2 || versions[0].Status != VersionSuperseded || versions[1].Status != VersionCurrent {
+		t.Fatalf("ListVersions: %+v", versions)
+	}
+	if cur := CurrentVersion(versions); cur == nil || cur.ContentHash != "h2" {
+		t.Errorf("CurrentVersion: %+v", cur)
+	}
+
+	if err := s.MarkDeleted(ctx, "d1"); err != nil {
+		t.Fatalf("MarkDeleted: %v", err)
+	}
+	if got, _ := s.FindBySource(ctx, "uri:a"); got != nil {
+		t.Errorf("FindBySource after delete: %+v", got)
+	}
+	versions, _ = s.ListVersions(ctx, "d1")
+	if CurrentVersion(versions) != nil || versions[1].Status != VersionDeleted {
+		t.Errorf("versions after delete: %+v", versions)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"strings"
+)
+
+// 文档版本状态
+const (
+	VersionCurrent    = "current"    // 当前生效版本
+	VersionSuperseded = "superseded" // 已被新版本替换，切片已从向量库删除
+	VersionDeleted    = "deleted"    // 文档已删除
+)
+
+// DocumentVersion 文档的一个入库版本；同一来源（source_uri 或文件名）重新入库时沿用文档 ID 并递增版本号
+type DocumentVersion struct {
+	DocumentID   string   `json:"document_id"`
+	Version      int      `json:"version"`
+	SourceKey    string   `json:"source_key"`
+	ContentHash  string   `json:"content_hash"` // 内容 sha256
+	Collection   string   `json:"collection"`
+	ChunkIDs     []string `json:"chunk_ids,omitempty"`
+	Chunks       int      `json:"chunks"`
+	Size         int64    `json:"size"`
+	Status       string   `json:"status"`
+	CreatedAt    int64    `json:"created_at"`
+	SupersededAt int64    `json:"superseded_at,omitempty"`
+}
+
+// VersionStore 可选接口：按来源查找文档并记录版本历史（MemoryStore 实现）；
+// 未实现时入库不做版本化，重复上传仍会生成新文档
+type VersionStore interface {
+	// FindBySource 返回来源当前对应的文档；不存在时返回 nil, nil
+	FindBySource(ctx context.Context, sourceKey string) (*Document, error)
+	// CommitVersion 记录 v 为当前版本，并将该文档之前的当前版本标记为 superseded
+	CommitVersion(ctx context.Context, v *DocumentVersion) error
+	// ListVersions 按版本号升序返回文档的版本历史（文档删除后仍保留）
+	ListVersions(ctx context.Context, documentID string) ([]*DocumentVersion, error)
+	// MarkDeleted 将文档当前版本标记为 deleted 并解除来源映射，使同一来源再次入库时生成新文档
+	MarkDeleted(ctx context.Context, documentID string) error
+}
+
+// URISourceKey 以 source_uri 为来源的来源键
+func URISourceKey(uri string) string {
+	return "uri:" + strings.TrimSpace(uri)
+}
+
+// FileSourceKey 以上传文件名为来源的来源键
+func FileSourceKey(name string) string {
+	return "file:" + strings.TrimSpace(name)
+}
+
+// CurrentVersion 返回版本历史中的当前版本；无则 nil
+func CurrentVersion(versions []*DocumentVersion) *DocumentVersion {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Status == VersionCurrent {
+			return versions[i]
+		}
+	}
+	return nil
+}
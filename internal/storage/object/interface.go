@@ -17,6 +17,7 @@ package object
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // Store 对象存储接口
@@ -37,6 +38,23 @@ type Store interface {
 	Close() error
 }
 
+// Presigner 可选能力：为对象生成限时免鉴权下载 URL（S3 预签名），供大文件直连对象存储下载
+type Presigner interface {
+	PresignGet(ctx context.Context, path string, expires time.Duration) (string, error)
+}
+
+// LifecycleConfigurer 可选能力：设置存储端对象过期规则（如证据包保留天数）
+type LifecycleConfigurer interface {
+	PutLifecycle(ctx context.Context, rules []LifecycleRule) error
+}
+
+// LifecycleRule 对象生命周期规则：Prefix 下的对象在 ExpirationDays 天后由存储端自动删除
+type LifecycleRule struct {
+	ID             string
+	Prefix         string
+	ExpirationDays int
+}
+
 // ObjectInfo 对象信息
 type ObjectInfo struct {
 	Path      string            `json:"path"`       // 对象路径
@@ -0,0 +1,428 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3MetaPrefix      = "x-amz-meta-"
+	s3MaxPresign      = 7 * 24 * time.Hour
+)
+
+// S3Options S3 兼容对象存储参数（AWS S3、MinIO、Ceph RGW 等）
+type S3Options struct {
+	Endpoint     string // 如 https://s3.us-east-1.amazonaws.com 或 http://minio:9000
+	Bucket       string
+	Region       string // 为空时 us-east-1
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool // true 时 URL 为 endpoint/bucket/key（MinIO 常用），否则 bucket.host/key
+	HTTPClient   *http.Client
+}
+
+// S3Store 基于 SigV4 签名的 S3 兼容对象存储实现；同时实现 Presigner 与 LifecycleConfigurer
+type S3Store struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3Store 创建 S3 兼容对象存储
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, fmt.Errorf("s3: endpoint 与 bucket 不能为空")
+	}
+	if opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, fmt.Errorf("s3: access_key 与 secret_key 不能为空")
+	}
+	u, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", opts.Endpoint)
+	}
+	region := opts.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &S3Store{
+		endpoint:  u,
+		bucket:    opts.Bucket,
+		region:    region,
+		accessKey: opts.AccessKey,
+		secretKey: opts.SecretKey,
+		pathStyle: opts.UsePathStyle,
+		client:    client,
+		now:       time.Now,
+	}, nil
+}
+
+// Put 上传对象；metadata 以 x-amz-meta-* 头写入
+func (s *S3Store) Put(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error {
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return fmt.Errorf("failed to read object data: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	for k, v := range metadata {
+		header.Set(s3MetaPrefix+strings.ToLower(k), v)
+	}
+	resp, err := s.do(ctx, http.MethodPut, path, nil, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3CheckResponse(resp, "put", path)
+}
+
+// Get 下载对象
+func (s *S3Store) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, path, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s3CheckResponse(resp, "get", path); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete 删除对象
+func (s *S3Store) Delete(ctx context.Context, path string) error {
+	resp, err := s.do(ctx, http.MethodDelete, path, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3CheckResponse(resp, "delete", path)
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List 列出对象（ListObjectsV2，自动翻页）；不含自定义元数据
+func (s *S3Store) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	var out []*ObjectInfo
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			q.Set("prefix", prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", q, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := s3CheckResponse(resp, "list", prefix); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		var res s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: decode list response: %w", err)
+		}
+		for _, c := range res.Contents {
+			info := &ObjectInfo{Path: c.Key, Size: c.Size}
+			if t, err := time.Parse(time.RFC3339, c.LastModified); err == nil {
+				info.CreatedAt = t.Unix()
+			}
+			out = append(out, info)
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return out, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// Exists 检查对象是否存在
+func (s *S3Store) Exists(ctx context.Context, path string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, path, nil, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err := s3CheckResponse(resp, "head", path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMetadata 获取对象元数据（x-amz-meta-* 去前缀）
+func (s *S3Store) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	resp, err := s.do(ctx, http.MethodHead, path, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := s3CheckResponse(resp, "head", path); err != nil {
+		return nil, err
+	}
+	meta := make(map[string]string)
+	for k, v := range resp.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, s3MetaPrefix) && len(v) > 0 {
+			meta[strings.TrimPrefix(lk, s3MetaPrefix)] = v[0]
+		}
+	}
+	return meta, nil
+}
+
+// Close 关闭存储连接
+func (s *S3Store) Close() error {
+	return nil
+}
+
+// PresignGet 实现 Presigner；生成 query 签名的 GET URL，expires 上限 7 天（SigV4 限制）
+func (s *S3Store) PresignGet(ctx context.Context, path string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > s3MaxPresign {
+		return "", fmt.Errorf("s3: presign expires must be in (0, 7d], got %s", expires)
+	}
+	now := s.now().UTC()
+	u := s.objectURL(path)
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	q.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	q.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	q.Set("X-Amz-SignedHeaders", "host")
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		s3EscapePath(u.Path),
+		s3CanonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	u.RawQuery = s3CanonicalQuery(q) + "&X-Amz-Signature=" + s.signature(now, canonical)
+	return u.String(), nil
+}
+
+// PutLifecycle 实现 LifecycleConfigurer；以 rules 覆盖 bucket 生命周期配置
+func (s *S3Store) PutLifecycle(ctx context.Context, rules []LifecycleRule) error {
+	var buf bytes.Buffer
+	buf.WriteString(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	for _, r := range rules {
+		if r.ExpirationDays <= 0 {
+			continue
+		}
+		buf.WriteString("<Rule><ID>")
+		_ = xml.EscapeText(&buf, []byte(r.ID))
+		buf.WriteString("</ID><Filter><Prefix>")
+		_ = xml.EscapeText(&buf, []byte(r.Prefix))
+		buf.WriteString("</Prefix></Filter><Status>Enabled</Status><Expiration><Days>")
+		buf.WriteString(strconv.Itoa(r.ExpirationDays))
+		buf.WriteString("</Days></Expiration></Rule>")
+	}
+	buf.WriteString(`</LifecycleConfiguration>`)
+	body := buf.Bytes()
+	sum := md5.Sum(body)
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	resp, err := s.do(ctx, http.MethodPut, "", url.Values{"lifecycle": {""}}, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3CheckResponse(resp, "put lifecycle", s.bucket)
+}
+
+// objectURL 返回对象（path 为空时为 bucket）的未签名 URL
+func (s *S3Store) objectURL(path string) *url.URL {
+	u := *s.endpoint
+	key := strings.TrimLeft(path, "/")
+	if s.pathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + s.bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	return &u
+}
+
+// do 构造并以 SigV4 头签名发送请求
+func (s *S3Store) do(ctx context.Context, method, path string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	now := s.now().UTC()
+	u := s.objectURL(path)
+	u.RawQuery = s3CanonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signed := map[string]string{"host": u.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" || lk == "content-md5" {
+			signed[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + signed[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonical := strings.Join([]string{
+		method,
+		s3EscapePath(u.Path),
+		u.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, s.scope(now), signedHeaders, s.signature(now, canonical)))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+func (s *S3Store) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature 按 SigV4 计算 canonical request 的签名
+func (s *S3Store) signature(t time.Time, canonicalRequest string) string {
+	h := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, t.Format("20060102T150405Z"), s.scope(t), hex.EncodeToString(h[:])}, "\n")
+	key := s3HMAC([]byte("AWS4"+s.secretKey), t.Format("20060102"))
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// s3Escape 按 SigV4 规则编码（仅保留 A-Za-z0-9-._~）；keepSlash 时保留路径分隔符
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return s3Escape(p, true)
+}
+
+func s3CanonicalQuery(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3CheckResponse(resp *http.Response, op, path string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("object with path %s not found", path)
+	}
+	return fmt.Errorf("s3: %s %s: status %d: %s", op, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
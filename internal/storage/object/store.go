@@ -16,16 +16,37 @@ package object
 
 import (
 	"fmt"
+	"strings"
 
 	"rag-platform/pkg/config"
 )
 
-// NewStore 根据配置创建对象存储（设计 struct.md 3.6；当前仅支持 memory）
+// NewStore 根据配置创建对象存储（设计 struct.md 3.6；支持 memory、s3）
 func NewStore(cfg config.ObjectConfig) (Store, error) {
 	switch cfg.Type {
 	case "", "memory":
 		return NewMemoryStore(), nil
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:     cfg.Endpoint,
+			Bucket:       cfg.Bucket,
+			Region:       cfg.Region,
+			AccessKey:    cfg.AccessKey,
+			SecretKey:    cfg.SecretKey,
+			UsePathStyle: cfg.UsePathStyle,
+		})
 	default:
 		return nil, fmt.Errorf("unsupported input type对象存储类型: %s", cfg.Type)
 	}
 }
+
+// KeyPrefix 规范化对象键前缀：为空时取 def，并保证以 / 结尾
+func KeyPrefix(prefix, def string) string {
+	if prefix == "" {
+		prefix = def
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
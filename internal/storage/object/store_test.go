@@ -0,0 +1,30 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "testing"
+
+func TestKeyPrefix(t *testing.T) {
+	for _, tt := range []struct{ prefix, def, want string }{
+		{"", "archive/", "archive/"},
+		{"logs", "archive/", "logs/"},
+		{"logs/", "archive/", "logs/"},
+		{"", "", ""},
+	} {
+		if got := KeyPrefix(tt.prefix, tt.def); got != tt.want {
+			t.Errorf("KeyPrefix(%q, %q) = %q, want %q", tt.prefix, tt.def, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/metrics"
+)
+
+// 组件名：用于预算配置（jobstore.pool.budgets）与指标 component 标签。
+// 只有写入频繁或长时间占用连接的组件使用独立连接池；品牌、筛选预设、服务账号等小表存储共用 jobs 连接池
+const (
+	ComponentJobEvents      = "jobstore"
+	ComponentJobs           = "jobs"
+	ComponentInvocations    = "tool_invocations"
+	ComponentEffects        = "effects"
+	ComponentCheckpoints    = "checkpoints"
+	ComponentAgentState     = "agent_state"
+	ComponentMessaging      = "messaging"
+	ComponentIngestQueue    = "ingest_queue"
+	ComponentEventOutbox    = "event_outbox"
+	ComponentPIITags        = "pii_tags"
+	ComponentToolSemaphores = "tool_semaphores"
+	ComponentEventSearch    = "event_search"
+)
+
+// APIComponents API 进程按 cfg 实际申请连接池的组件（按启动时的申请顺序）；启动时经 CheckBudget 校验预算总量
+func APIComponents(cfg *config.Config) []string {
+	out := eventComponents(cfg)
+	out = append(out, ComponentInvocations)
+	if usesPostgres(cfg.EffectStore.Type, cfg.EffectStore.DSN) {
+		out = append(out, ComponentEffects)
+	}
+	out = append(out, ComponentToolSemaphores, ComponentAgentState, ComponentMessaging, ComponentIngestQueue)
+	if usesPostgres(cfg.CheckpointStore.Type, cfg.CheckpointStore.DSN) {
+		out = append(out, ComponentCheckpoints)
+	}
+	return out
+}
+
+// WorkerComponents Worker 进程按 cfg 实际申请连接池的组件（按启动时的申请顺序）
+func WorkerComponents(cfg *config.Config) []string {
+	out := eventComponents(cfg)
+	out = append(out, ComponentInvocations)
+	if usesPostgres(cfg.EffectStore.Type, cfg.EffectStore.DSN) {
+		out = append(out, ComponentEffects)
+	}
+	out = append(out, ComponentToolSemaphores)
+	if usesPostgres(cfg.CheckpointStore.Type, cfg.CheckpointStore.DSN) {
+		out = append(out, ComponentCheckpoints)
+	}
+	return append(out, ComponentAgentState, ComponentMessaging, ComponentIngestQueue)
+}
+
+// eventComponents 事件写入路径上的组件：事件、Job 元数据，以及按开关启用的 PII 标签、全文检索与导出 outbox
+func eventComponents(cfg *config.Config) []string {
+	out := []string{ComponentJobEvents, ComponentJobs}
+	if cfg.PII.Enable {
+		out = append(out, ComponentPIITags)
+	}
+	if cfg.EventSearch.Enable {
+		out = append(out, ComponentEventSearch)
+	}
+	if cfg.EventExport.Enable {
+		out = append(out, ComponentEventOutbox)
+	}
+	return out
+}
+
+func usesPostgres(typ, dsn string) bool {
+	return typ == "postgres" && dsn != ""
+}
+
+const (
+	// defaultMaxTotalConns 未配置时的总预算：容纳 API 全部组件按 defaultComponentConns 分配
+	defaultMaxTotalConns       = 50
+	defaultComponentConns      = 4
+	defaultHealthCheckInterval = 15 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+)
+
+var (
+	// ErrBudgetExhausted 总连接预算已分配完，无法再为新组件创建连接池
+	ErrBudgetExhausted = errors.New("pgpool: connection budget exhausted")
+	// ErrDSNMismatch 同一组件以不同 DSN 重复申请连接池
+	ErrDSNMismatch = errors.New("pgpool: component already bound to a different dsn")
+	// ErrClosed Manager 已关闭，不再创建连接池
+	ErrClosed = errors.New("pgpool: manager closed")
+)
+
+// ComponentStats 单个组件连接池的快照（供日志、/api/system/status 等展示）
+type ComponentStats struct {
+	Component       string        `json:"component"`
+	MaxConns        int32         `json:"max_conns"`
+	InUse           int32         `json:"in_use"`
+	Idle            int32         `json:"idle"`
+	Total           int32         `json:"total"`
+	AcquireCount    int64         `json:"acquire_count"`
+	EmptyAcquire    int64         `json:"empty_acquire_count"`
+	AcquireDuration time.Duration `json:"acquire_duration"`
+	Healthy         bool          `json:"healthy"`
+}
+
+type poolEntry struct {
+	dsn     string
+	pool    *pgxpool.Pool
+	budget  int32
+	healthy bool
+}
+
+// Manager 统一管理进程内各组件的 pgxpool 连接池：按组件分配连接预算（总量受 MaxTotalConns 约束），
+// 周期性 Ping 健康检查，并将 in-use/idle/等待耗时等指标导出到 Prometheus，避免多池叠加耗尽数据库连接。
+type Manager struct {
+	mu        sync.Mutex
+	maxTotal  int
+	defConns  int
+	budgets   map[string]int
+	idleTime  time.Duration
+	interval  time.Duration
+	pools     map[string]*poolEntry
+	allocated int
+	closed    bool
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewManager 根据配置创建连接池管理器；未配置项使用默认值
+func NewManager(cfg config.PGPoolConfig) *Manager {
+	m := &Manager{
+		maxTotal: cfg.MaxTotalConns,
+		defConns: cfg.DefaultConns,
+		budgets:  make(map[string]int, len(cfg.Budgets)),
+		interval: defaultHealthCheckInterval,
+		pools:    make(map[string]*poolEntry),
+		stopCh:   make(chan struct{}),
+	}
+	if m.maxTotal <= 0 {
+		m.maxTotal = defaultMaxTotalConns
+	}
+	if m.defConns <= 0 {
+		m.defConns = defaultComponentConns
+	}
+	for k, v := range cfg.Budgets {
+		if v > 0 {
+			m.budgets[k] = v
+		}
+	}
+	if d, err := time.ParseDuration(cfg.MaxConnIdleTime); err == nil && d > 0 {
+		m.idleTime = d
+	}
+	if d, err := time.ParseDuration(cfg.HealthCheckInterval); err == nil && d > 0 {
+		m.interval = d
+	}
+	return m
+}
+
+// Pool 返回该组件的连接池；首次调用时按预算创建并 Ping，后续调用返回同一实例。
+// 创建与 Ping 在锁外进行，不阻塞其他组件的申请与 Stats/CheckHealth
+func (m *Manager) Pool(ctx context.Context, component, dsn string) (*pgxpool.Pool, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if e, ok := m.pools[component]; ok {
+		m.mu.Unlock()
+		return existingPool(e, component, dsn)
+	}
+	budget, err := m.reserveLocked(component)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := openPool(ctx, dsn, budget, m.idleTime)
+
+	m.mu.Lock()
+	if m.closed {
+		// 创建期间 Manager 已关闭（Close 已清零预算）：不再登记
+		m.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		pool.Close()
+		return nil, ErrClosed
+	}
+	if err != nil {
+		m.allocated -= int(budget)
+		m.mu.Unlock()
+		return nil, err
+	}
+	// 双重检查：并发申请同一组件时保留先登记的连接池，释放本次预算
+	if e, ok := m.pools[component]; ok {
+		m.allocated -= int(budget)
+		m.mu.Unlock()
+		pool.Close()
+		return existingPool(e, component, dsn)
+	}
+	m.pools[component] = &poolEntry{dsn: dsn, pool: pool, budget: budget, healthy: true}
+	m.mu.Unlock()
+	metrics.PgPoolHealthy.WithLabelValues(component).Set(1)
+	metrics.PgPoolConnections.WithLabelValues(component, "max").Set(float64(budget))
+	return pool, nil
+}
+
+func existingPool(e *poolEntry, component, dsn string) (*pgxpool.Pool, error) {
+	if e.dsn != dsn {
+		return nil, fmt.Errorf("%w: %s", ErrDSNMismatch, component)
+	}
+	return e.pool, nil
+}
+
+// openPool 按预算创建连接池并 Ping；Ping failed时关闭连接池
+func openPool(ctx context.Context, dsn string, budget int32, idleTime time.Duration) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = budget
+	if poolConfig.MinConns > budget {
+		poolConfig.MinConns = budget
+	}
+	if idleTime > 0 {
+		poolConfig.MaxConnIdleTime = idleTime
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// CheckBudget 校验 components 的预算之和不超过 max_total_conns；启动时在申请连接池前调用，
+// 避免后申请的组件被压缩到剩余量或因预算耗尽而failed
+func (m *Manager) CheckBudget(components []string) error {
+	need := 0
+	seen := make(map[string]bool, len(components))
+	for _, c := range components {
+		if !seen[c] {
+			seen[c] = true
+			need += m.budgetFor(c)
+		}
+	}
+	if need > m.maxTotal {
+		return fmt.Errorf("%w: %d components need %d connections but max_total_conns=%d; raise jobstore.pool.max_total_conns or lower jobstore.pool.budgets",
+			ErrBudgetExhausted, len(seen), need, m.maxTotal)
+	}
+	return nil
+}
+
+// budgetFor 组件的配置预算，未配置时为 default_conns
+func (m *Manager) budgetFor(component string) int {
+	if b, ok := m.budgets[component]; ok {
+		return b
+	}
+	return m.defConns
+}
+
+// reserveLocked 为组件预留连接预算；剩余预算不足配置值时按剩余量分配，剩余为 0 时返回 ErrBudgetExhausted
+func (m *Manager) reserveLocked(component string) (int32, error) {
+	want := m.budgetFor(component)
+	remaining := m.maxTotal - m.allocated
+	if remaining <= 0 {
+		return 0, fmt.Errorf("%w: %s (max_total_conns=%d)", ErrBudgetExhausted, component, m.maxTotal)
+	}
+	if want > remaining {
+		want = remaining
+	}
+	m.allocated += want
+	return int32(want), nil
+}
+
+// Allocated 返回已分配的连接预算总数
+func (m *Manager) Allocated() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.allocated
+}
+
+// Stats 返回所有组件连接池的快照，按组件名排序
+func (m *Manager) Stats() []ComponentStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]ComponentStats, 0, len(m.pools))
+	for component, e := range m.pools {
+		st := e.pool.Stat()
+		out = append(out, ComponentStats{
+			Component:       component,
+			MaxConns:        st.MaxConns(),
+			InUse:           st.AcquiredConns(),
+			Idle:            st.IdleConns(),
+			Total:           st.TotalConns(),
+			AcquireCount:    st.AcquireCount(),
+			EmptyAcquire:    st.EmptyAcquireCount(),
+			AcquireDuration: st.AcquireDuration(),
+			Healthy:         e.healthy,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Component < out[j].Component })
+	return out
+}
+
+// CheckHealth Ping 所有连接池并刷新指标；返回 Ping 失败的组件及错误
+func (m *Manager) CheckHealth(ctx context.Context) map[string]error {
+	m.mu.Lock()
+	entries := make(map[string]*poolEntry, len(m.pools))
+	for k, v := range m.pools {
+		entries[k] = v
+	}
+	m.mu.Unlock()
+
+	failed := make(map[string]error)
+	for component, e := range entries {
+		pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := e.pool.Ping(pingCtx)
+		cancel()
+		healthy := err == nil
+		if !healthy {
+			failed[component] = err
+		}
+		m.mu.Lock()
+		e.healthy = healthy
+		m.mu.Unlock()
+		if healthy {
+			metrics.PgPoolHealthy.WithLabelValues(component).Set(1)
+		} else {
+			metrics.PgPoolHealthy.WithLabelValues(component).Set(0)
+		}
+	}
+	m.exportStats()
+	return failed
+}
+
+// exportStats 将 pgxpool.Stat 写入 Prometheus 指标
+func (m *Manager) exportStats() {
+	for _, st := range m.Stats() {
+		metrics.PgPoolConnections.WithLabelValues(st.Component, "in_use").Set(float64(st.InUse))
+		metrics.PgPoolConnections.WithLabelValues(st.Component, "idle").Set(float64(st.Idle))
+		metrics.PgPoolConnections.WithLabelValues(st.Component, "total").Set(float64(st.Total))
+		metrics.PgPoolConnections.WithLabelValues(st.Component, "max").Set(float64(st.MaxConns))
+		metrics.PgPoolAcquireWaitSeconds.WithLabelValues(st.Component).Set(st.AcquireDuration.Seconds())
+		metrics.PgPoolEmptyAcquireCount.WithLabelValues(st.Component).Set(float64(st.EmptyAcquire))
+	}
+}
+
+// Start 启动后台健康检查与指标采样；onUnhealthy 可选，在某组件 Ping 失败时回调（用于日志告警）
+func (m *Manager) Start(onUnhealthy func(component string, err error)) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case <-ticker.C:
+			}
+			for component, err := range m.CheckHealth(context.Background()) {
+				if onUnhealthy != nil {
+					onUnhealthy(component, err)
+				}
+			}
+		}
+	}()
+}
+
+// Close 停止健康检查并关闭所有连接池
+func (m *Manager) Close() {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+	m.wg.Wait()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for component, e := range m.pools {
+		e.pool.Close()
+		delete(m.pools, component)
+	}
+	m.allocated = 0
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgpool
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"rag-platform/pkg/config"
+)
+
+func TestManager_ReserveBudgets(t *testing.T) {
+	m := NewManager(config.PGPoolConfig{
+		MaxTotalConns: 10,
+		DefaultConns:  3,
+		Budgets:       map[string]int{ComponentJobEvents: 6},
+	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	got, err := m.reserveLocked(ComponentJobEvents)
+	if err != nil || got != 6 {
+		t.Fatalf("jobstore budget: got %d, %v; want 6", got, err)
+	}
+	got, err = m.reserveLocked(ComponentJobs)
+	if err != nil || got != 3 {
+		t.Fatalf("jobs default budget: got %d, %v; want 3", got, err)
+	}
+	// 剩余 1，按剩余量分配
+	got, err = m.reserveLocked(ComponentMessaging)
+	if err != nil || got != 1 {
+		t.Fatalf("messaging clamped budget: got %d, %v; want 1", got, err)
+	}
+	if _, err := m.reserveLocked(ComponentAgentState); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("expected ErrBudgetExhausted, got %v", err)
+	}
+}
+
+func TestManager_CheckBudget(t *testing.T) {
+	m := NewManager(config.PGPoolConfig{MaxTotalConns: 10, DefaultConns: 3, Budgets: map[string]int{ComponentJobEvents: 6}})
+	if err := m.CheckBudget([]string{ComponentJobEvents, ComponentJobs, ComponentJobs}); err != nil {
+		t.Fatalf("6+3 fits 10: %v", err)
+	}
+	if err := m.CheckBudget([]string{ComponentJobEvents, ComponentJobs, ComponentMessaging}); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("6+3+3 exceeds 10: %v", err)
+	}
+	if err := NewManager(config.PGPoolConfig{}).CheckBudget(APIComponents(allEnabled())); err != nil {
+		t.Fatalf("defaults must fit every API component: %v", err)
+	}
+}
+
+func TestComponents_OnlyEnabled(t *testing.T) {
+	cfg := &config.Config{}
+	want := []string{ComponentJobEvents, ComponentJobs, ComponentInvocations, ComponentToolSemaphores, ComponentAgentState, ComponentMessaging, ComponentIngestQueue}
+	if got := APIComponents(cfg); !slices.Equal(got, want) {
+		t.Fatalf("api components with optional features off: %v", got)
+	}
+	if got := WorkerComponents(cfg); !slices.Equal(got, want) {
+		t.Fatalf("worker components with optional features off: %v", got)
+	}
+	// 未启用的组件不占预算：7 个组件按默认 4 连接需要 28
+	m := NewManager(config.PGPoolConfig{MaxTotalConns: 28})
+	if err := m.CheckBudget(APIComponents(cfg)); err != nil {
+		t.Fatalf("disabled components must not be budgeted: %v", err)
+	}
+	if err := m.CheckBudget(APIComponents(allEnabled())); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("all components exceed 28: %v", err)
+	}
+}
+
+// allEnabled 启用全部可选组件的配置
+func allEnabled() *config.Config {
+	cfg := &config.Config{}
+	cfg.PII.Enable = true
+	cfg.EventSearch.Enable = true
+	cfg.EventExport.Enable = true
+	cfg.EffectStore.Type, cfg.EffectStore.DSN = "postgres", "postgres://localhost/aetheris"
+	cfg.CheckpointStore.Type, cfg.CheckpointStore.DSN = "postgres", "postgres://localhost/aetheris"
+	return cfg
+}
+
+// TestShippedConfigBudgets 按 API / Worker 启动时的申请顺序，用随仓库发布的配置逐个预留：可选组件全部启用时每个组件都须拿到完整的配置预算
+func TestShippedConfigBudgets(t *testing.T) {
+	values := componentConstants(t)
+	for _, tc := range []struct {
+		config     string
+		appDir     string
+		components []string
+	}{
+		{"api.yaml", "api", APIComponents(allEnabled())},
+		{"worker.yaml", "worker", WorkerComponents(allEnabled())},
+	} {
+		t.Run(tc.config, func(t *testing.T) {
+			// 组件列表须与 app 代码实际申请的组件及顺序一致
+			if used := componentsUsedBy(t, filepath.Join("..", "..", "app", tc.appDir), values); !slices.Equal(used, tc.components) {
+				t.Fatalf("components allocated by internal/app/%s:\n%v\nlisted:\n%v", tc.appDir, used, tc.components)
+			}
+			cfg, err := config.LoadConfig(filepath.Join("..", "..", "..", "configs", tc.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range values {
+				if _, ok := cfg.JobStore.Pool.Budgets[c]; !ok {
+					t.Errorf("jobstore.pool.budgets has no entry for %s", c)
+				}
+			}
+			m := NewManager(cfg.JobStore.Pool)
+			if err := m.CheckBudget(tc.components); err != nil {
+				t.Fatal(err)
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			for _, c := range tc.components {
+				got, err := m.reserveLocked(c)
+				if err != nil || int(got) != m.budgetFor(c) {
+					t.Fatalf("%s: got %d, %v; want %d", c, got, err, m.budgetFor(c))
+				}
+			}
+		})
+	}
+}
+
+// componentConstants 解析 manager.go 中的 Component* 常量：常量名 → 组件名
+func componentConstants(t *testing.T) map[string]string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "manager.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		vs, ok := n.(*ast.ValueSpec)
+		if !ok || len(vs.Values) != 1 || !strings.HasPrefix(vs.Names[0].Name, "Component") {
+			return true
+		}
+		if lit, ok := vs.Values[0].(*ast.BasicLit); ok {
+			out[vs.Names[0].Name], _ = strconv.Unquote(lit.Value)
+		}
+		return true
+	})
+	return out
+}
+
+// componentsUsedBy 按源码顺序列出目录下非测试文件引用的 pgpool.Component* 组件（去重）
+func componentsUsedBy(t *testing.T, dir string, values map[string]string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var used []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "pgpool" {
+				if c, ok := values[sel.Sel.Name]; ok && !slices.Contains(used, c) {
+					used = append(used, c)
+				}
+			}
+			return true
+		})
+	}
+	return used
+}
+
+func TestManager_Defaults(t *testing.T) {
+	m := NewManager(config.PGPoolConfig{})
+	if m.maxTotal != defaultMaxTotalConns || m.defConns != defaultComponentConns {
+		t.Fatalf("defaults: max=%d def=%d", m.maxTotal, m.defConns)
+	}
+	if m.interval != defaultHealthCheckInterval {
+		t.Fatalf("interval: %v", m.interval)
+	}
+}
+
+func TestManager_PoolInvalidDSNReleasesBudget(t *testing.T) {
+	m := NewManager(config.PGPoolConfig{MaxTotalConns: 5})
+	if _, err := m.Pool(context.Background(), ComponentJobs, "postgres://%zz"); err == nil {
+		t.Fatal("expected parse error for invalid dsn")
+	}
+	if got := m.Allocated(); got != 0 {
+		t.Fatalf("allocated after failure: got %d, want 0", got)
+	}
+	m.Close()
+}
+
+func TestManager_PoolAfterClose(t *testing.T) {
+	m := NewManager(config.PGPoolConfig{MaxTotalConns: 5})
+	m.Close()
+	if _, err := m.Pool(context.Background(), ComponentJobs, "postgres://localhost/aetheris"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pgretry Postgres 短暂不可用（主从切换、重启、连接耗尽）时的韧性层：按错误分类有界退避重试，
+// 连续失败达到阈值后进入降级（熔断打开），Worker 据此暂停认领，已在执行的 Job 继续重试以度过切换窗口
+package pgretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"rag-platform/pkg/config"
+	"rag-platform/pkg/metrics"
+)
+
+// ErrDegraded 熔断打开期间的认领类操作直接返回，不访问数据库
+var ErrDegraded = errors.New("pgretry: database unavailable, running degraded")
+
+// UnavailableError 经 Guard 重试后仍失败的短暂错误（或降级拒绝）；Unwrap 为原始错误
+type UnavailableError struct {
+	Component string
+	Op        string
+	Err       error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s %s: database unavailable: %v", e.Component, e.Op, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error { return e.Err }
+
+// OpKind 操作类别，决定可重试的错误范围与熔断打开时的行为
+type OpKind int
+
+const (
+	// Idempotent 读或可重复执行的写（如续租、设置游标）：任意短暂错误都重试
+	Idempotent OpKind = iota
+	// NonIdempotent 不可重复执行的写（如追加事件）：仅在语句确定未执行时重试（连接失败、服务端拒绝）
+	NonIdempotent
+	// Claim 认领：不重试；熔断打开时每个冷却周期仅放行一次探测，其余返回 ErrDegraded
+	Claim
+)
+
+// Policy 重试与熔断参数；零值字段取默认值
+type Policy struct {
+	// MaxElapsed 单次操作重试的总时长上限，默认 15s（覆盖常见的主从切换窗口）
+	MaxElapsed time.Duration
+	// InitialBackoff 首次重试前等待，默认 200ms，之后翻倍并加抖动
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待上限，默认 2s
+	MaxBackoff time.Duration
+	// BreakerThreshold 连续短暂错误次数达到该值时进入降级，默认 5
+	BreakerThreshold int
+	// BreakerCooldown 降级期间认领探测的间隔，默认 5s
+	BreakerCooldown time.Duration
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = 15 * time.Second
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 200 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 2 * time.Second
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.BreakerThreshold <= 0 {
+		p.BreakerThreshold = 5
+	}
+	if p.BreakerCooldown <= 0 {
+		p.BreakerCooldown = 5 * time.Second
+	}
+	return p
+}
+
+// PolicyFromConfig 由 jobstore.resilience 配置构造 Policy；无法解析的时长取默认值
+func PolicyFromConfig(cfg config.PGResilienceConfig) Policy {
+	parse := func(s string) time.Duration {
+		d, _ := time.ParseDuration(s)
+		return d
+	}
+	return Policy{
+		MaxElapsed:       parse(cfg.MaxElapsed),
+		InitialBackoff:   parse(cfg.InitialBackoff),
+		MaxBackoff:       parse(cfg.MaxBackoff),
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  parse(cfg.BreakerCooldown),
+	}
+}
+
+// Guard 单个组件（如 jobstore、jobs）的重试与熔断状态；nil Guard 直接执行操作。多个 store 可共享同一 Guard
+type Guard struct {
+	component string
+	policy    Policy
+	logger    *slog.Logger
+	now       func() time.Time
+	sleep     func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	failures  int
+	openedAt  time.Time
+	lastProbe time.Time
+	lastErr   error
+}
+
+// New 创建 Guard；logger 为 nil 时使用 slog.Default()
+func New(component string, p Policy, logger *slog.Logger) *Guard {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	metrics.PgDegraded.WithLabelValues(component).Set(0)
+	return &Guard{component: component, policy: p.withDefaults(), logger: logger, now: time.Now, sleep: sleepCtx}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Degraded 是否处于降级（熔断打开）
+func (g *Guard) Degraded() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.openedAt.IsZero()
+}
+
+// DegradedSince 进入降级的时间；未降级时为零值
+func (g *Guard) DegradedSince() time.Time {
+	if g == nil {
+		return time.Time{}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.openedAt
+}
+
+// Do 执行 fn，短暂错误按 kind 有界退避重试；非短暂错误原样返回，短暂错误重试耗尽后以 *UnavailableError 包装返回。
+// op 为指标与日志中的操作名
+func (g *Guard) Do(ctx context.Context, op string, kind OpKind, fn func() error) error {
+	if g == nil {
+		return fn()
+	}
+	if kind == Claim && !g.allowClaim() {
+		return &UnavailableError{Component: g.component, Op: op, Err: ErrDegraded}
+	}
+	start := g.now()
+	backoff := g.policy.InitialBackoff
+	for {
+		err := fn()
+		if err == nil {
+			g.succeeded()
+			return nil
+		}
+		transient, safe := Classify(err)
+		if !transient {
+			// 数据库已正常应答（如唯一约束、无行），视为可用
+			if ctx.Err() == nil && !isContextErr(err) {
+				g.succeeded()
+			}
+			return err
+		}
+		g.failed(err)
+		unavailable := &UnavailableError{Component: g.component, Op: op, Err: err}
+		if kind == Claim || (kind == NonIdempotent && !safe) || ctx.Err() != nil {
+			return unavailable
+		}
+		wait := backoff/2 + rand.N(backoff/2+1)
+		if g.now().Sub(start)+wait > g.policy.MaxElapsed {
+			return unavailable
+		}
+		metrics.PgRetriesTotal.WithLabelValues(g.component, op).Inc()
+		if g.sleep(ctx, wait) != nil {
+			return unavailable
+		}
+		backoff = min(backoff*2, g.policy.MaxBackoff)
+	}
+}
+
+// allowClaim 降级期间每个冷却周期放行一次认领作为探测
+func (g *Guard) allowClaim() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.openedAt.IsZero() {
+		return true
+	}
+	now := g.now()
+	if now.Sub(g.lastProbe) < g.policy.BreakerCooldown {
+		return false
+	}
+	g.lastProbe = now
+	return true
+}
+
+func (g *Guard) succeeded() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures = 0
+	if g.openedAt.IsZero() {
+		return
+	}
+	degradedFor := g.now().Sub(g.openedAt)
+	g.openedAt, g.lastProbe, g.lastErr = time.Time{}, time.Time{}, nil
+	metrics.PgDegraded.WithLabelValues(g.component).Set(0)
+	g.logger.Info("Postgres 已恢复，退出降级模式", "component", g.component, "degraded_for", degradedFor.String())
+}
+
+func (g *Guard) failed(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures++
+	g.lastErr = err
+	if !g.openedAt.IsZero() || g.failures < g.policy.BreakerThreshold {
+		return
+	}
+	g.openedAt = g.now()
+	g.lastProbe = g.openedAt
+	metrics.PgDegraded.WithLabelValues(g.component).Set(1)
+	metrics.PgCircuitOpenTotal.WithLabelValues(g.component).Inc()
+	g.logger.Warn("Postgres 连续不可用，进入降级模式：暂停认领，执行中的操作继续重试", "component", g.component, "consecutive_failures", g.failures, "error", err)
+}
+
+// transientCodes 表示服务端暂时不可用、且语句未生效的 SQLSTATE（08 类连接异常另按前缀判断）
+var transientCodes = map[string]bool{
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+	"53300": true, // too_many_connections
+	"25006": true, // read_only_sql_transaction：切换期间连到了已降级的旧主库
+}
+
+// Classify 判断 err 是否为短暂的可用性错误（transient），以及语句是否确定未执行、可安全重放（safe）
+func Classify(err error) (transient, safe bool) {
+	if err == nil || isContextErr(err) {
+		return false, false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		t := transientCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+		return t, t
+	}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || pgconn.SafeToRetry(err) {
+		return true, true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true, false
+	}
+	return false, false
+}
+
+// IsUnavailable err 是否为经 Guard 返回的数据库不可用错误（重试耗尽或降级拒绝）；调用方据此避免把 Job 判为failed。
+// 未经 Guard 的网络错误（如工具调用）不算在内
+func IsUnavailable(err error) bool {
+	var u *UnavailableError
+	return errors.As(err, &u)
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
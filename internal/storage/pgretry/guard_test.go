@@ -0,0 +1,203 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// newTestGuard 使用可控时钟：sleep 只推进时间，不真正等待
+func newTestGuard(p Policy) (*Guard, *time.Time) {
+	g := New("test", p, nil)
+	now := time.Unix(1_700_000_000, 0)
+	g.now = func() time.Time { return now }
+	g.sleep = func(ctx context.Context, d time.Duration) error {
+		now = now.Add(d)
+		return ctx.Err()
+	}
+	return g, &now
+}
+
+var errShutdown = &pgconn.PgError{Code: "57P01", Message: "terminating connection due to administrator command"}
+
+func TestGuard_RetriesTransientUntilSuccess(t *testing.T) {
+	g, _ := newTestGuard(Policy{})
+	calls := 0
+	err := g.Do(context.Background(), "read", Idempotent, func() error {
+		calls++
+		if calls < 3 {
+			return errShutdown
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+	if g.Degraded() {
+		t.Fatal("guard should not be degraded after success")
+	}
+}
+
+func TestGuard_NonTransientReturnedAsIs(t *testing.T) {
+	g, _ := newTestGuard(Policy{})
+	want := &pgconn.PgError{Code: "23505"}
+	calls := 0
+	err := g.Do(context.Background(), "write", Idempotent, func() error {
+		calls++
+		return want
+	})
+	if calls != 1 || !errors.Is(err, want) || IsUnavailable(err) {
+		t.Fatalf("calls=%d err=%v", calls, err)
+	}
+}
+
+func TestGuard_GivesUpAfterMaxElapsed(t *testing.T) {
+	g, now := newTestGuard(Policy{MaxElapsed: 3 * time.Second})
+	start := *now
+	err := g.Do(context.Background(), "read", Idempotent, func() error { return errShutdown })
+	if !IsUnavailable(err) {
+		t.Fatalf("err = %v, want UnavailableError", err)
+	}
+	if !errors.Is(err, errShutdown) {
+		t.Fatalf("err should wrap the cause: %v", err)
+	}
+	if elapsed := now.Sub(start); elapsed > 3*time.Second {
+		t.Fatalf("elapsed %v exceeds MaxElapsed", elapsed)
+	}
+}
+
+func TestGuard_NonIdempotentRetriesOnlySafeErrors(t *testing.T) {
+	g, _ := newTestGuard(Policy{})
+	calls := 0
+	err := g.Do(context.Background(), "insert", NonIdempotent, func() error {
+		calls++
+		return fmt.Errorf("write: %w", io.ErrUnexpectedEOF)
+	})
+	if calls != 1 || !IsUnavailable(err) {
+		t.Fatalf("unsafe error: calls=%d err=%v", calls, err)
+	}
+
+	calls = 0
+	err = g.Do(context.Background(), "insert", NonIdempotent, func() error {
+		calls++
+		if calls == 1 {
+			return errShutdown
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("safe error: calls=%d err=%v", calls, err)
+	}
+}
+
+func TestGuard_BreakerOpensAndCloses(t *testing.T) {
+	g, now := newTestGuard(Policy{BreakerThreshold: 2, BreakerCooldown: 5 * time.Second})
+	ctx := context.Background()
+	fail := func() error { return errShutdown }
+
+	for i := 0; i < 2; i++ {
+		if err := g.Do(ctx, "claim", Claim, fail); !IsUnavailable(err) {
+			t.Fatalf("claim %d: %v", i, err)
+		}
+	}
+	if !g.Degraded() || g.DegradedSince().IsZero() {
+		t.Fatal("guard should be degraded after threshold failures")
+	}
+
+	// 降级期间认领快速失败，不调用 fn
+	called := false
+	err := g.Do(ctx, "claim", Claim, func() error { called = true; return nil })
+	if called || !errors.Is(err, ErrDegraded) {
+		t.Fatalf("claim while degraded: called=%v err=%v", called, err)
+	}
+
+	// 冷却后放行一次探测；探测成功即恢复
+	*now = now.Add(5 * time.Second)
+	if err := g.Do(ctx, "claim", Claim, func() error { called = true; return nil }); err != nil || !called {
+		t.Fatalf("probe: called=%v err=%v", called, err)
+	}
+	if g.Degraded() {
+		t.Fatal("guard should recover after successful probe")
+	}
+}
+
+func TestGuard_IdempotentRunsWhileDegraded(t *testing.T) {
+	g, _ := newTestGuard(Policy{BreakerThreshold: 1})
+	ctx := context.Background()
+	_ = g.Do(ctx, "claim", Claim, func() error { return errShutdown })
+	if !g.Degraded() {
+		t.Fatal("expected degraded")
+	}
+	// 续租等幂等操作在降级期间仍执行，成功即退出降级
+	if err := g.Do(ctx, "heartbeat", Idempotent, func() error { return nil }); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if g.Degraded() {
+		t.Fatal("expected recovery")
+	}
+}
+
+func TestGuard_NilRunsDirectly(t *testing.T) {
+	var g *Guard
+	want := errors.New("boom")
+	if err := g.Do(context.Background(), "x", Claim, func() error { return want }); err != want {
+		t.Fatalf("err = %v", err)
+	}
+	if g.Degraded() {
+		t.Fatal("nil guard is never degraded")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name            string
+		err             error
+		transient, safe bool
+	}{
+		{"admin_shutdown", errShutdown, true, true},
+		{"connection_failure", &pgconn.PgError{Code: "08006"}, true, true},
+		{"read_only", &pgconn.PgError{Code: "25006"}, true, true},
+		{"unique_violation", &pgconn.PgError{Code: "23505"}, false, false},
+		{"unexpected_eof", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true, false},
+		{"canceled", context.Canceled, false, false},
+		{"plain", errors.New("no rows"), false, false},
+	}
+	for _, c := range cases {
+		tr, safe := Classify(c.err)
+		if tr != c.transient || safe != c.safe {
+			t.Errorf("%s: Classify = (%v,%v), want (%v,%v)", c.name, tr, safe, c.transient, c.safe)
+		}
+	}
+}
+
+func TestIsUnavailable_OnlyGuardErrors(t *testing.T) {
+	if IsUnavailable(io.ErrUnexpectedEOF) {
+		t.Fatal("raw network errors must not count as unavailable")
+	}
+	err := fmt.Errorf("step: %w", &UnavailableError{Component: "jobstore", Op: "append", Err: errShutdown})
+	if !IsUnavailable(err) {
+		t.Fatal("wrapped UnavailableError should be detected")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/vector"
+)
+
+// DocumentMigration 租户文档区域迁移结果
+type DocumentMigration struct {
+	TenantID   string `json:"tenant_id"`
+	FromRegion string `json:"from_region"`
+	ToRegion   string `json:"to_region"`
+	Documents  int    `json:"documents"`
+	Vectors    int    `json:"vectors"`
+	Versions   int    `json:"versions"`
+	// MissingVectors 版本记录中存在但源区域已找不到的切片数（通常为并发删除）
+	MissingVectors int `json:"missing_vectors"`
+}
+
+// MigrateDocuments 将租户的文档元数据、版本历史与切片向量从 from 区域迁移到 to 区域，成功后删除源区域数据并将租户改派到 to。
+// 按文档逐个迁移：目标区域写入完成后才删除源区域，失败时已迁移的文档留在目标区域，重跑会跳过它们。
+// 切片依赖 metadata.VersionStore 记录的 chunk ID 定位，源区域未实现时返回 ErrVersionsUnsupported
+func (f *Factory) MigrateDocuments(ctx context.Context, tenantID, from, to string) (*DocumentMigration, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("residency: tenant_id 不能为空")
+	}
+	if from == to {
+		return nil, fmt.Errorf("residency: 源区域与目标区域相同: %s", from)
+	}
+	srcMeta, dstMeta := f.meta[from], f.meta[to]
+	if srcMeta == nil || dstMeta == nil {
+		return nil, fmt.Errorf("residency: 区域未定义: %s → %s", from, to)
+	}
+	srcVec, dstVec := f.vec[from], f.vec[to]
+	if srcVec == nil || dstVec == nil {
+		return nil, fmt.Errorf("residency: 区域 %s 或 %s 未配置内置向量存储", from, to)
+	}
+	srcVersions, ok := srcMeta.(metadata.VersionStore)
+	if !ok {
+		return nil, ErrVersionsUnsupported
+	}
+	dstVersions, ok := dstMeta.(metadata.VersionStore)
+	if !ok {
+		return nil, ErrVersionsUnsupported
+	}
+
+	docs, err := srcMeta.List(ctx, &metadata.Filter{Metadata: map[string]string{MetaTenantID: tenantID}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("residency: 列出租户文档: %w", err)
+	}
+	out := &DocumentMigration{TenantID: tenantID, FromRegion: from, ToRegion: to}
+	for _, doc := range docs {
+		versions, err := srcVersions.ListVersions(ctx, doc.ID)
+		if err != nil {
+			return out, fmt.Errorf("residency: 文档 %s 版本历史: %w", doc.ID, err)
+		}
+		current := metadata.CurrentVersion(versions)
+		var vectors []*vector.Vector
+		if current != nil {
+			for _, id := range current.ChunkIDs {
+				v, err := srcVec.Get(ctx, current.Collection, id)
+				if errors.Is(err, vector.ErrNotFound) {
+					out.MissingVectors++
+					continue
+				}
+				if err != nil {
+					return out, fmt.Errorf("residency: 读取切片 %s: %w", id, err)
+				}
+				vectors = append(vectors, v)
+			}
+			if len(vectors) > 0 {
+				if err := vector.EnsureIndex(ctx, dstVec, current.Collection, len(vectors[0].Values), "cosine"); err != nil {
+					return out, fmt.Errorf("residency: 目标区域索引: %w", err)
+				}
+				if err := dstVec.Add(ctx, current.Collection, vectors); err != nil {
+					return out, fmt.Errorf("residency: 写入目标区域切片: %w", err)
+				}
+			}
+		}
+		if err := dstMeta.Create(ctx, doc); err != nil {
+			if _, getErr := dstMeta.Get(ctx, doc.ID); getErr != nil {
+				return out, fmt.Errorf("residency: 写入目标区域文档 %s: %w", doc.ID, err)
+			}
+			// 上次迁移中断时已写入，视为已迁移
+		}
+		for _, v := range versions {
+			if v.Status == metadata.VersionDeleted {
+				continue
+			}
+			cp := *v
+			if err := dstVersions.CommitVersion(ctx, &cp); err != nil {
+				return out, fmt.Errorf("residency: 写入目标区域版本 %s v%d: %w", doc.ID, v.Version, err)
+			}
+			out.Versions++
+		}
+
+		// 目标区域完整后删除源区域：先删切片，再解除来源映射与文档记录
+		if current != nil {
+			if err := vector.DeleteVectors(ctx, srcVec, current.Collection, current.ChunkIDs); err != nil {
+				return out, fmt.Errorf("residency: 删除源区域切片: %w", err)
+			}
+		}
+		if err := srcVersions.MarkDeleted(ctx, doc.ID); err != nil {
+			return out, fmt.Errorf("residency: 解除源区域来源映射 %s: %w", doc.ID, err)
+		}
+		if err := srcMeta.Delete(ctx, doc.ID); err != nil {
+			return out, fmt.Errorf("residency: 删除源区域文档 %s: %w", doc.ID, err)
+		}
+		out.Documents++
+		out.Vectors += len(vectors)
+	}
+	if err := f.resolver.Assign(tenantID, to); err != nil {
+		return out, err
+	}
+	return out, nil
+}
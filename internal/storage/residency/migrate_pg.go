@@ -0,0 +1,198 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tenantTable 参与租户迁移的表：where 为选出租户数据的条件（%s 为租户 ID 字面量）；
+// skip 为不复制的自增主键列，由目标库重新生成，避免与目标库已有行冲突
+type tenantTable struct {
+	name  string
+	where string
+	skip  []string
+}
+
+const (
+	byTenant = "tenant_id = %s"
+	byJob    = "job_id IN (SELECT id FROM jobs WHERE tenant_id = %s)"
+	byAgent  = "agent_id IN (SELECT id FROM agent_instances WHERE tenant_id = %s)"
+	// byAgentScoped 会话状态与记忆另按 runtime.MemoryScope 的键保存：非 default 租户为 租户+\x1f+agentID，共享空间为 \x1fspace\x1f+租户+\x1f+空间名
+	byAgentScoped = "(agent_id IN (SELECT id FROM agent_instances WHERE tenant_id = %[1]s)" +
+		" OR starts_with(agent_id, %[1]s || chr(31))" +
+		" OR starts_with(agent_id, chr(31) || 'space' || chr(31) || %[1]s || chr(31)))"
+)
+
+// tenantTables 按复制顺序排列（先父表后子表），删除时逆序；未在此列出的表（签名密钥、Worker 状态、全局 kill switch 等）不属于租户数据
+var tenantTables = []tenantTable{
+	{name: "tenants", where: "id = %s"},
+	{name: "user_roles", where: byTenant},
+	{name: "service_accounts", where: byTenant},
+	{name: "maintenance_windows", where: byTenant},
+	{name: "access_audit_log", where: byTenant, skip: []string{"id"}},
+	{name: "agent_instances", where: byTenant},
+	{name: "agent_states", where: byAgentScoped},
+	{name: "agent_config", where: byAgent},
+	{name: "agent_goal_templates", where: byAgent},
+	{name: "agent_eval_suites", where: byAgent},
+	{name: "agent_eval_reports", where: byTenant},
+	{name: "agent_long_term_memory", where: byAgentScoped},
+	{name: "agent_episodic_chunks", where: byAgentScoped},
+	{name: "planner_exemplars", where: byAgent},
+	{name: "checkpoints", where: byAgent},
+	{name: "agent_messages", where: "to_agent_id IN (SELECT id FROM agent_instances WHERE tenant_id = %s)"},
+	{name: "jobs", where: byTenant},
+	{name: "job_tombstones", where: byTenant},
+	{name: "job_children", where: "parent_job_id IN (SELECT id FROM jobs WHERE tenant_id = %s)"},
+	{name: "job_events", where: byJob, skip: []string{"id"}},
+	{name: "job_snapshots", where: byJob},
+	{name: "job_claims", where: byJob},
+	{name: "job_transitions", where: byJob, skip: []string{"id"}},
+	{name: "tool_invocations", where: byJob},
+	{name: "effects", where: byJob, skip: []string{"id"}},
+	{name: "signal_inbox", where: byJob},
+	{name: "event_outbox", where: byJob, skip: []string{"id"}},
+	{name: "event_pii_tags", where: byJob},
+	{name: "ledger_sync_log", where: byJob, skip: []string{"id"}},
+	{name: "job_attestations", where: byJob},
+}
+
+// TableMigration 单表迁移行数
+type TableMigration struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// quoteLiteral 将字符串转为 SQL 字面量（COPY 不支持参数绑定）
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (t tenantTable) condition(tenantID string) string {
+	return fmt.Sprintf(t.where, quoteLiteral(tenantID))
+}
+
+// columns 返回表在 src 中需复制的列；表不存在时返回 nil（旧 schema 缺少的表跳过）
+func (t tenantTable) columns(ctx context.Context, src *pgxpool.Pool) ([]string, error) {
+	rows, err := src.Query(ctx, `SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position`, t.name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		skipped := false
+		for _, s := range t.skip {
+			if c == s {
+				skipped = true
+			}
+		}
+		if !skipped {
+			cols = append(cols, pgx.Identifier{c}.Sanitize())
+		}
+	}
+	return cols, rows.Err()
+}
+
+// MigratePostgres 将租户在 src 集群中的数据以 COPY 复制到 dst 集群。复制在 dst 的单个事务内完成，任一表失败整体回滚；
+// deleteSource 为 true 时复制提交后在 src 的单个事务内删除这些行。
+// 迁移期间应暂停该租户的写入（维护窗口），否则复制后写入的事件会随源库删除丢失
+func MigratePostgres(ctx context.Context, src, dst *pgxpool.Pool, tenantID string, deleteSource bool) ([]TableMigration, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("residency: tenant_id 不能为空")
+	}
+	srcConn, err := src.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("residency: 连接源集群: %w", err)
+	}
+	defer srcConn.Release()
+	tx, err := dst.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("residency: 开启目标集群事务: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var out []TableMigration
+	for _, t := range tenantTables {
+		cols, err := t.columns(ctx, src)
+		if err != nil {
+			return nil, fmt.Errorf("residency: 读取 %s 列: %w", t.name, err)
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		colList := strings.Join(cols, ", ")
+		copyOut := fmt.Sprintf("COPY (SELECT %s FROM %s WHERE %s) TO STDOUT", colList, t.name, t.condition(tenantID))
+		copyIn := fmt.Sprintf("COPY %s (%s) FROM STDIN", t.name, colList)
+		pr, pw := io.Pipe()
+		copyErr := make(chan error, 1)
+		go func() {
+			_, err := srcConn.Conn().PgConn().CopyTo(ctx, pw, copyOut)
+			_ = pw.CloseWithError(err)
+			copyErr <- err
+		}()
+		tag, err := tx.Conn().PgConn().CopyFrom(ctx, pr, copyIn)
+		_ = pr.CloseWithError(err)
+		if srcErr := <-copyErr; err == nil && srcErr != nil {
+			err = srcErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("residency: 复制 %s: %w", t.name, err)
+		}
+		out = append(out, TableMigration{Table: t.name, Rows: tag.RowsAffected()})
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("residency: 提交目标集群: %w", err)
+	}
+	if !deleteSource {
+		return out, nil
+	}
+
+	// 子表先删：job_id / agent_id 条件依赖 jobs 与 agent_instances 中的行
+	delTx, err := src.Begin(ctx)
+	if err != nil {
+		return out, fmt.Errorf("residency: 开启源集群事务: %w", err)
+	}
+	defer func() { _ = delTx.Rollback(ctx) }()
+	for i := len(tenantTables) - 1; i >= 0; i-- {
+		t := tenantTables[i]
+		var exists bool
+		if err := delTx.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", t.name).Scan(&exists); err != nil {
+			return out, fmt.Errorf("residency: 检查 %s: %w", t.name, err)
+		}
+		if !exists {
+			continue
+		}
+		if _, err := delTx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, t.condition(tenantID))); err != nil {
+			return out, fmt.Errorf("residency: 删除源集群 %s: %w", t.name, err)
+		}
+	}
+	if err := delTx.Commit(ctx); err != nil {
+		return out, fmt.Errorf("residency: 提交源集群删除: %w", err)
+	}
+	return out, nil
+}
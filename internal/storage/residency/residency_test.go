@@ -0,0 +1,194 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/auth"
+	"rag-platform/pkg/config"
+)
+
+func testFactory(t *testing.T) *Factory {
+	t.Helper()
+	r, err := NewResolver(config.ResidencyConfig{
+		Enable:        true,
+		DefaultRegion: "us",
+		Regions: map[string]config.RegionConfig{
+			"us": {APIURL: "https://us.example.com"},
+			"eu": {APIURL: "https://eu.example.com"},
+		},
+		Tenants: map[string]string{"acme": "eu"},
+	})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	f, err := NewFactory(r)
+	if err != nil {
+		t.Fatalf("NewFactory: %v", err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func TestResolver_RegionFor(t *testing.T) {
+	f := testFactory(t)
+	r := f.Resolver()
+	if got := r.RegionFor("acme"); got != "eu" {
+		t.Errorf("acme: got %s", got)
+	}
+	if got := r.RegionFor("ACME"); got != "eu" {
+		t.Errorf("ACME（配置键小写化）: got %s", got)
+	}
+	if got := r.RegionFor("globex"); got != "us" {
+		t.Errorf("globex: got %s", got)
+	}
+	if _, url, local := r.Home("acme"); url != "https://eu.example.com" || !local {
+		t.Errorf("Home without local_region: url=%s local=%v", url, local)
+	}
+	if err := r.Assign("globex", "mars"); err == nil {
+		t.Error("Assign to undefined region should fail")
+	}
+}
+
+func TestNewResolver_LocalRegion(t *testing.T) {
+	r, err := NewResolver(config.ResidencyConfig{
+		LocalRegion:   "us",
+		DefaultRegion: "us",
+		Regions:       map[string]config.RegionConfig{"us": {}, "eu": {APIURL: "https://eu.example.com"}},
+		Tenants:       map[string]string{"acme": "eu"},
+	})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	if region, url, local := r.Home("acme"); region != "eu" || url != "https://eu.example.com" || local {
+		t.Errorf("Home(acme): region=%s url=%s local=%v", region, url, local)
+	}
+	if _, _, local := r.Home("globex"); !local {
+		t.Error("globex should be served locally")
+	}
+}
+
+func TestRoutedStores_IsolateRegions(t *testing.T) {
+	f := testFactory(t)
+	meta := NewMetadataStore(f)
+	vec := NewVectorStore(f)
+	bg := context.Background()
+	if err := vector.EnsureIndex(bg, vec, "docs", 2, "cosine"); err != nil {
+		t.Fatalf("EnsureIndex（广播）: %v", err)
+	}
+	if err := vector.EnsureIndex(bg, vec, "docs", 2, "cosine"); err != nil {
+		t.Fatalf("EnsureIndex 重复调用: %v", err)
+	}
+
+	acme := auth.WithTenantID(bg, "acme")
+	globex := auth.WithTenantID(bg, "globex")
+	if err := meta.Create(acme, &metadata.Document{ID: "d1", Metadata: map[string]string{MetaTenantID: "acme"}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := vec.Add(acme, "docs", []*vector.Vector{{ID: "c1", Values: []float64{1, 0}}}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := f.MetadataStore("eu").Get(bg, "d1"); err != nil {
+		t.Errorf("acme 文档应落在 eu: %v", err)
+	}
+	if _, err := f.MetadataStore("us").Get(bg, "d1"); err == nil {
+		t.Error("acme 文档不应出现在 us")
+	}
+	if _, err := meta.Get(globex, "d1"); err == nil {
+		t.Error("globex 不应读到 eu 区域的文档")
+	}
+	if _, err := meta.Get(bg, "d1"); err != nil {
+		t.Errorf("无租户上下文应遍历全部区域: %v", err)
+	}
+	res, err := vec.Search(globex, "docs", []float64{1, 0}, &vector.SearchOptions{TopK: 5})
+	if err != nil || len(res) != 0 {
+		t.Errorf("globex 检索应为空: %v %v", res, err)
+	}
+
+	// 后台任务无租户上下文时按数据自带的 tenant_id 路由
+	if err := vec.Add(bg, "docs", []*vector.Vector{{ID: "c2", Values: []float64{0, 1}, Metadata: map[string]string{MetaTenantID: "acme"}}}); err != nil {
+		t.Fatalf("Add（无上下文）: %v", err)
+	}
+	if _, err := f.VectorStore("eu").Get(bg, "docs", "c2"); err != nil {
+		t.Errorf("c2 应落在 eu: %v", err)
+	}
+}
+
+func TestMigrateDocuments(t *testing.T) {
+	f := testFactory(t)
+	meta := NewMetadataStore(f)
+	vec := NewVectorStore(f)
+	bg := context.Background()
+	if err := vector.EnsureIndex(bg, vec, "docs", 2, "cosine"); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+	acme := auth.WithTenantID(bg, "acme")
+	doc := &metadata.Document{ID: "d1", Name: "a.txt", Metadata: map[string]string{MetaTenantID: "acme"}}
+	if err := meta.Create(acme, doc); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := vec.Add(acme, "docs", []*vector.Vector{{ID: "c1", Values: []float64{1, 0}}, {ID: "c2", Values: []float64{0, 1}}}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := meta.CommitVersion(acme, &metadata.DocumentVersion{DocumentID: "d1", Version: 1, SourceKey: metadata.FileSourceKey("a.txt"), Collection: "docs", ChunkIDs: []string{"c1", "c2"}}); err != nil {
+		t.Fatalf("CommitVersion: %v", err)
+	}
+
+	res, err := f.MigrateDocuments(bg, "acme", "eu", "us")
+	if err != nil {
+		t.Fatalf("MigrateDocuments: %v", err)
+	}
+	if res.Documents != 1 || res.Vectors != 2 || res.Versions != 1 {
+		t.Errorf("result: %+v", res)
+	}
+	if got := f.Resolver().RegionFor("acme"); got != "us" {
+		t.Errorf("迁移后应改派到 us，got %s", got)
+	}
+	if _, err := f.MetadataStore("eu").Get(bg, "d1"); err == nil {
+		t.Error("源区域文档应已删除")
+	}
+	if _, err := f.VectorStore("eu").Get(bg, "docs", "c1"); err == nil {
+		t.Error("源区域切片应已删除")
+	}
+	if found, _ := meta.FindBySource(acme, metadata.FileSourceKey("a.txt")); found == nil || found.ID != "d1" {
+		t.Errorf("迁移后按来源应在 us 找到 d1: %+v", found)
+	}
+	if _, err := vec.Get(acme, "docs", "c2"); err != nil {
+		t.Errorf("迁移后应从 us 读到切片: %v", err)
+	}
+}
+
+func TestTenantTables_ParentsFirst(t *testing.T) {
+	pos := make(map[string]int, len(tenantTables))
+	for i, tbl := range tenantTables {
+		pos[tbl.name] = i
+	}
+	for _, tbl := range tenantTables {
+		switch {
+		case strings.Contains(tbl.where, "FROM jobs") && pos[tbl.name] < pos["jobs"]:
+			t.Errorf("%s 须在 jobs 之后复制", tbl.name)
+		case strings.Contains(tbl.where, "FROM agent_instances") && pos[tbl.name] < pos["agent_instances"]:
+			t.Errorf("%s 须在 agent_instances 之后复制", tbl.name)
+		}
+	}
+	if got := (tenantTable{where: byTenant}).condition("o'brien"); got != "tenant_id = 'o''brien'" {
+		t.Errorf("condition: %s", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package residency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"rag-platform/pkg/config"
+)
+
+// MetaTenantID 文档与切片元数据中记录所属租户的键，供路由与区域迁移使用
+const MetaTenantID = "tenant_id"
+
+// Resolver 租户 → 区域解析；迁移完成后可通过 Assign 在运行时改派（重启后以配置为准）
+type Resolver struct {
+	mu            sync.RWMutex
+	regions       map[string]config.RegionConfig
+	tenants       map[string]string
+	defaultRegion string
+	localRegion   string
+}
+
+// NewResolver 由驻留配置创建 Resolver；default_region 与 tenants 引用的区域须已定义
+func NewResolver(cfg config.ResidencyConfig) (*Resolver, error) {
+	if len(cfg.Regions) == 0 {
+		return nil, fmt.Errorf("residency: 未配置区域")
+	}
+	if _, ok := cfg.Regions[cfg.DefaultRegion]; !ok {
+		return nil, fmt.Errorf("residency: default_region %q 未定义", cfg.DefaultRegion)
+	}
+	if cfg.LocalRegion != "" {
+		if _, ok := cfg.Regions[cfg.LocalRegion]; !ok {
+			return nil, fmt.Errorf("residency: local_region %q 未定义", cfg.LocalRegion)
+		}
+	}
+	r := &Resolver{
+		regions:       make(map[string]config.RegionConfig, len(cfg.Regions)),
+		tenants:       make(map[string]string, len(cfg.Tenants)),
+		defaultRegion: cfg.DefaultRegion,
+		localRegion:   cfg.LocalRegion,
+	}
+	for name, rc := range cfg.Regions {
+		r.regions[name] = rc
+	}
+	for tenant, region := range cfg.Tenants {
+		if _, ok := cfg.Regions[region]; !ok {
+			return nil, fmt.Errorf("residency: 租户 %s 指向未定义的区域 %q", tenant, region)
+		}
+		r.tenants[tenant] = region
+	}
+	return r, nil
+}
+
+// RegionFor 返回租户所在区域；未单独配置的租户（含空租户）归属 default_region。
+// 配置文件中的租户键会被小写化，故精确匹配失败时按小写再查一次
+func (r *Resolver) RegionFor(tenantID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if region, ok := r.tenants[tenantID]; ok {
+		return region
+	}
+	if region, ok := r.tenants[strings.ToLower(tenantID)]; ok {
+		return region
+	}
+	return r.defaultRegion
+}
+
+// Region 返回区域配置
+func (r *Resolver) Region(name string) (config.RegionConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rc, ok := r.regions[name]
+	return rc, ok
+}
+
+// Regions 按名称排序的全部区域
+func (r *Resolver) Regions() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.regions))
+	for name := range r.regions {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LocalRegion 本部署所在区域；空表示不限制
+func (r *Resolver) LocalRegion() string {
+	return r.localRegion
+}
+
+// Assign 运行时将租户改派到 region（区域迁移完成后调用）
+func (r *Resolver) Assign(tenantID, region string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.regions[region]; !ok {
+		return fmt.Errorf("residency: 区域 %q 未定义", region)
+	}
+	delete(r.tenants, strings.ToLower(tenantID))
+	r.tenants[tenantID] = region
+	return nil
+}
+
+// Home 返回租户所在区域及其 API 地址；local 表示本部署可服务该租户（未设置 local_region 时恒为 true）
+func (r *Resolver) Home(tenantID string) (region, apiURL string, local bool) {
+	region = r.RegionFor(tenantID)
+	rc, _ := r.Region(region)
+	return region, rc.APIURL, r.localRegion == "" || r.localRegion == region
+}
@@ -0,0 +1,429 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"rag-platform/internal/storage/metadata"
+	"rag-platform/internal/storage/vector"
+	"rag-platform/pkg/auth"
+)
+
+// ErrVersionsUnsupported 租户所在区域的元数据存储未实现 metadata.VersionStore
+var ErrVersionsUnsupported = errors.New("residency: 区域元数据存储不支持版本历史")
+
+// Factory 按区域创建并持有元数据/向量存储，按请求租户解析后端
+type Factory struct {
+	resolver *Resolver
+	meta     map[string]metadata.Store
+	vec      map[string]vector.Store
+}
+
+// NewFactory 为每个区域创建存储；区域向量存储为非 memory 类型时不创建（由 einoext 组件直连），该区域 VectorStore 返回 nil
+func NewFactory(resolver *Resolver) (*Factory, error) {
+	f := &Factory{
+		resolver: resolver,
+		meta:     make(map[string]metadata.Store),
+		vec:      make(map[string]vector.Store),
+	}
+	for _, name := range resolver.Regions() {
+		rc, _ := resolver.Region(name)
+		ms, err := metadata.NewStore(rc.Metadata)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("residency: 区域 %s 元数据存储: %w", name, err)
+		}
+		f.meta[name] = ms
+		if t := rc.Vector.Type; t == "" || t == "memory" {
+			vs, err := vector.NewStore(rc.Vector)
+			if err != nil {
+				_ = f.Close()
+				return nil, fmt.Errorf("residency: 区域 %s 向量存储: %w", name, err)
+			}
+			f.vec[name] = vs
+		}
+	}
+	return f, nil
+}
+
+// Resolver 返回租户区域解析器
+func (f *Factory) Resolver() *Resolver {
+	return f.resolver
+}
+
+// MetadataStore 返回区域的元数据存储
+func (f *Factory) MetadataStore(region string) metadata.Store {
+	return f.meta[region]
+}
+
+// VectorStore 返回区域的向量存储
+func (f *Factory) VectorStore(region string) vector.Store {
+	return f.vec[region]
+}
+
+// Close 关闭全部区域存储
+func (f *Factory) Close() error {
+	var errs []error
+	for _, s := range f.meta {
+		errs = append(errs, s.Close())
+	}
+	for _, s := range f.vec {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// regionFor 解析本次调用的区域：优先 context 中的租户，其次数据自带的 tenant_id（后台任务无租户上下文时）；
+// 均为空返回 ""，由调用方决定广播到全部区域或落到默认区域
+func (f *Factory) regionFor(ctx context.Context, meta map[string]string) string {
+	if tenant := auth.GetTenantID(ctx); tenant != "" {
+		return f.resolver.RegionFor(tenant)
+	}
+	if tenant := meta[MetaTenantID]; tenant != "" {
+		return f.resolver.RegionFor(tenant)
+	}
+	return ""
+}
+
+// MetadataStore 按租户路由到区域元数据存储；无租户上下文的读操作遍历全部区域
+type MetadataStore struct {
+	f *Factory
+}
+
+// NewMetadataStore 创建路由元数据存储
+func NewMetadataStore(f *Factory) *MetadataStore {
+	return &MetadataStore{f: f}
+}
+
+func (s *MetadataStore) target(ctx context.Context, meta map[string]string) metadata.Store {
+	region := s.f.regionFor(ctx, meta)
+	if region == "" {
+		region = s.f.resolver.RegionFor("")
+	}
+	return s.f.meta[region]
+}
+
+// scoped 有租户上下文时只返回其区域，否则返回全部区域
+func (s *MetadataStore) scoped(ctx context.Context) []metadata.Store {
+	if region := s.f.regionFor(ctx, nil); region != "" {
+		return []metadata.Store{s.f.meta[region]}
+	}
+	out := make([]metadata.Store, 0, len(s.f.meta))
+	for _, name := range s.f.resolver.Regions() {
+		out = append(out, s.f.meta[name])
+	}
+	return out
+}
+
+// Create 实现 metadata.Store
+func (s *MetadataStore) Create(ctx context.Context, doc *metadata.Document) error {
+	return s.target(ctx, doc.Metadata).Create(ctx, doc)
+}
+
+// Get 实现 metadata.Store
+func (s *MetadataStore) Get(ctx context.Context, id string) (*metadata.Document, error) {
+	var lastErr error
+	for _, st := range s.scoped(ctx) {
+		doc, err := st.Get(ctx, id)
+		if err == nil {
+			return doc, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Update 实现 metadata.Store
+func (s *MetadataStore) Update(ctx context.Context, doc *metadata.Document) error {
+	return s.target(ctx, doc.Metadata).Update(ctx, doc)
+}
+
+// Delete 实现 metadata.Store
+func (s *MetadataStore) Delete(ctx context.Context, id string) error {
+	st, err := s.owner(ctx, id)
+	if err != nil {
+		return err
+	}
+	return st.Delete(ctx, id)
+}
+
+// owner 返回持有文档的区域存储
+func (s *MetadataStore) owner(ctx context.Context, id string) (metadata.Store, error) {
+	var lastErr error
+	for _, st := range s.scoped(ctx) {
+		if _, err := st.Get(ctx, id); err != nil {
+			lastErr = err
+			continue
+		}
+		return st, nil
+	}
+	return nil, lastErr
+}
+
+// List 实现 metadata.Store；跨区域时按区域顺序拼接后再分页
+func (s *MetadataStore) List(ctx context.Context, filter *metadata.Filter, pagination *metadata.Pagination) ([]*metadata.Document, error) {
+	stores := s.scoped(ctx)
+	if len(stores) == 1 {
+		return stores[0].List(ctx, filter, pagination)
+	}
+	var all []*metadata.Document
+	for _, st := range stores {
+		docs, err := st.List(ctx, filter, nil)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, docs...)
+	}
+	if pagination != nil {
+		start := min(max(pagination.Offset, 0), len(all))
+		all = all[start:]
+		if pagination.Limit > 0 && pagination.Limit < len(all) {
+			all = all[:pagination.Limit]
+		}
+	}
+	return all, nil
+}
+
+// Count 实现 metadata.Store
+func (s *MetadataStore) Count(ctx context.Context, filter *metadata.Filter) (int64, error) {
+	var total int64
+	for _, st := range s.scoped(ctx) {
+		n, err := st.Count(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Close 实现 metadata.Store；区域存储由 Factory 关闭
+func (s *MetadataStore) Close() error {
+	return nil
+}
+
+func (s *MetadataStore) versions(st metadata.Store) (metadata.VersionStore, error) {
+	vs, ok := st.(metadata.VersionStore)
+	if !ok {
+		return nil, ErrVersionsUnsupported
+	}
+	return vs, nil
+}
+
+// FindBySource 实现 metadata.VersionStore
+func (s *MetadataStore) FindBySource(ctx context.Context, sourceKey string) (*metadata.Document, error) {
+	for _, st := range s.scoped(ctx) {
+		vs, err := s.versions(st)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := vs.FindBySource(ctx, sourceKey)
+		if err != nil || doc != nil {
+			return doc, err
+		}
+	}
+	return nil, nil
+}
+
+// CommitVersion 实现 metadata.VersionStore；写入持有该文档的区域
+func (s *MetadataStore) CommitVersion(ctx context.Context, v *metadata.DocumentVersion) error {
+	st, err := s.owner(ctx, v.DocumentID)
+	if err != nil {
+		st = s.target(ctx, nil)
+	}
+	vs, err := s.versions(st)
+	if err != nil {
+		return err
+	}
+	return vs.CommitVersion(ctx, v)
+}
+
+// ListVersions 实现 metadata.VersionStore
+func (s *MetadataStore) ListVersions(ctx context.Context, documentID string) ([]*metadata.DocumentVersion, error) {
+	for _, st := range s.scoped(ctx) {
+		vs, err := s.versions(st)
+		if err != nil {
+			return nil, err
+		}
+		out, err := vs.ListVersions(ctx, documentID)
+		if err != nil || len(out) > 0 {
+			return out, err
+		}
+	}
+	return nil, nil
+}
+
+// MarkDeleted 实现 metadata.VersionStore
+func (s *MetadataStore) MarkDeleted(ctx context.Context, documentID string) error {
+	st, err := s.owner(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	vs, err := s.versions(st)
+	if err != nil {
+		return err
+	}
+	return vs.MarkDeleted(ctx, documentID)
+}
+
+// VectorStore 按租户路由到区域向量存储；无租户上下文的索引管理操作作用于全部区域
+type VectorStore struct {
+	f *Factory
+}
+
+// NewVectorStore 创建路由向量存储
+func NewVectorStore(f *Factory) *VectorStore {
+	return &VectorStore{f: f}
+}
+
+func (s *VectorStore) target(ctx context.Context, meta map[string]string) (vector.Store, error) {
+	region := s.f.regionFor(ctx, meta)
+	if region == "" {
+		region = s.f.resolver.RegionFor("")
+	}
+	st := s.f.vec[region]
+	if st == nil {
+		return nil, fmt.Errorf("residency: 区域 %s 未配置内置向量存储", region)
+	}
+	return st, nil
+}
+
+func (s *VectorStore) scoped(ctx context.Context) []vector.Store {
+	if region := s.f.regionFor(ctx, nil); region != "" {
+		if st := s.f.vec[region]; st != nil {
+			return []vector.Store{st}
+		}
+		return nil
+	}
+	out := make([]vector.Store, 0, len(s.f.vec))
+	for _, name := range s.f.resolver.Regions() {
+		if st := s.f.vec[name]; st != nil {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// Create 实现 vector.Store；广播到全部区域时跳过已存在该索引的区域
+func (s *VectorStore) Create(ctx context.Context, index *vector.Index) error {
+	stores := s.scoped(ctx)
+	for _, st := range stores {
+		if len(stores) > 1 {
+			names, err := st.ListIndexes(ctx)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(names, index.Name) {
+				continue
+			}
+		}
+		if err := st.Create(ctx, index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add 实现 vector.Store；无租户上下文时按首个向量的 tenant_id 路由
+func (s *VectorStore) Add(ctx context.Context, indexName string, vectors []*vector.Vector) error {
+	var meta map[string]string
+	if len(vectors) > 0 && vectors[0] != nil {
+		meta = vectors[0].Metadata
+	}
+	st, err := s.target(ctx, meta)
+	if err != nil {
+		return err
+	}
+	return st.Add(ctx, indexName, vectors)
+}
+
+// Search 实现 vector.Store；只检索租户所在区域
+func (s *VectorStore) Search(ctx context.Context, indexName string, query []float64, options *vector.SearchOptions) ([]*vector.SearchResult, error) {
+	st, err := s.target(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return st.Search(ctx, indexName, query, options)
+}
+
+// Get 实现 vector.Store
+func (s *VectorStore) Get(ctx context.Context, indexName string, id string) (*vector.Vector, error) {
+	lastErr := vector.ErrNotFound
+	for _, st := range s.scoped(ctx) {
+		v, err := st.Get(ctx, indexName, id)
+		if err == nil {
+			return v, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Delete 实现 vector.Store；无租户上下文时从所有持有该向量的区域删除
+func (s *VectorStore) Delete(ctx context.Context, indexName string, id string) error {
+	deleted := false
+	for _, st := range s.scoped(ctx) {
+		err := st.Delete(ctx, indexName, id)
+		if err == nil {
+			deleted = true
+			continue
+		}
+		if !errors.Is(err, vector.ErrNotFound) {
+			return err
+		}
+	}
+	if !deleted {
+		return fmt.Errorf("vector %s: %w", id, vector.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteIndex 实现 vector.Store
+func (s *VectorStore) DeleteIndex(ctx context.Context, indexName string) error {
+	for _, st := range s.scoped(ctx) {
+		if err := st.DeleteIndex(ctx, indexName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListIndexes 实现 vector.Store；跨区域时返回去重后的并集
+func (s *VectorStore) ListIndexes(ctx context.Context) ([]string, error) {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, st := range s.scoped(ctx) {
+		names, err := st.ListIndexes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			if _, ok := seen[n]; !ok {
+				seen[n] = struct{}{}
+				out = append(out, n)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Close 实现 vector.Store；区域存储由 Factory 关闭
+func (s *VectorStore) Close() error {
+	return nil
+}
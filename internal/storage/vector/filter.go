@@ -0,0 +1,250 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// 文档级 ACL 元数据键：入库时写入每个切片，值为逗号分隔的角色/用户列表；两者皆空表示公开
+const (
+	MetaACLRoles = "acl_roles"
+	MetaACLUsers = "acl_users"
+)
+
+// 过滤表达式操作符
+const (
+	FilterOpEq     = "eq"     // metadata[key] == value
+	FilterOpNe     = "ne"     // metadata[key] != value
+	FilterOpIn     = "in"     // metadata[key] ∈ values
+	FilterOpHas    = "has"    // metadata[key] 为逗号分隔列表，且包含 values 中任一项（或 value）
+	FilterOpExists = "exists" // metadata[key] 非空
+	FilterOpAnd    = "and"
+	FilterOpOr     = "or"
+	FilterOpNot    = "not"
+)
+
+// FilterExpr 元数据过滤表达式，下推到向量检索（先过滤再打分/TopK），避免事后过滤截断结果
+type FilterExpr struct {
+	Op     string        `json:"op"`
+	Key    string        `json:"key,omitempty"`
+	Value  string        `json:"value,omitempty"`
+	Values []string      `json:"values,omitempty"`
+	Exprs  []*FilterExpr `json:"exprs,omitempty"`
+}
+
+// Validate 校验表达式结构（操作符合法、叶子节点有 key、组合节点有子表达式）
+func (f *FilterExpr) Validate() error {
+	if f == nil {
+		return nil
+	}
+	switch f.Op {
+	case FilterOpEq, FilterOpNe, FilterOpExists:
+		if f.Key == "" {
+			return fmt.Errorf("filter %s requires key", f.Op)
+		}
+	case FilterOpIn, FilterOpHas:
+		if f.Key == "" {
+			return fmt.Errorf("filter %s requires key", f.Op)
+		}
+		if len(f.Values) == 0 && f.Value == "" {
+			return fmt.Errorf("filter %s requires values", f.Op)
+		}
+	case FilterOpAnd, FilterOpOr:
+		if len(f.Exprs) == 0 {
+			return fmt.Errorf("filter %s requires exprs", f.Op)
+		}
+		for _, e := range f.Exprs {
+			if err := e.Validate(); err != nil {
+				return err
+			}
+		}
+	case FilterOpNot:
+		if len(f.Exprs) != 1 {
+			return fmt.Errorf("filter not requires exactly one expr")
+		}
+		return f.Exprs[0].Validate()
+	default:
+		return fmt.Errorf("unsupported filter op: %q", f.Op)
+	}
+	return nil
+}
+
+// Match 判断向量元数据是否满足表达式；nil 表达式匹配所有
+func (f *FilterExpr) Match(meta map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	switch f.Op {
+	case FilterOpEq:
+		return meta[f.Key] == f.Value
+	case FilterOpNe:
+		return meta[f.Key] != f.Value
+	case FilterOpIn:
+		v := meta[f.Key]
+		for _, want := range f.values() {
+			if v == want {
+				return true
+			}
+		}
+		return false
+	case FilterOpHas:
+		for _, item := range splitList(meta[f.Key]) {
+			for _, want := range f.values() {
+				if item == want {
+					return true
+				}
+			}
+		}
+		return false
+	case FilterOpExists:
+		return meta[f.Key] != ""
+	case FilterOpAnd:
+		for _, e := range f.Exprs {
+			if !e.Match(meta) {
+				return false
+			}
+		}
+		return true
+	case FilterOpOr:
+		for _, e := range f.Exprs {
+			if e.Match(meta) {
+				return true
+			}
+		}
+		return false
+	case FilterOpNot:
+		return len(f.Exprs) == 1 && !f.Exprs[0].Match(meta)
+	default:
+		return false
+	}
+}
+
+func (f *FilterExpr) values() []string {
+	if f.Value != "" {
+		return append([]string{f.Value}, f.Values...)
+	}
+	return f.Values
+}
+
+// And 组合多个表达式（忽略 nil）；全部为 nil 时返回 nil
+func And(exprs ...*FilterExpr) *FilterExpr {
+	var out []*FilterExpr
+	for _, e := range exprs {
+		if e != nil {
+			out = append(out, e)
+		}
+	}
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return out[0]
+	default:
+		return &FilterExpr{Op: FilterOpAnd, Exprs: out}
+	}
+}
+
+// MetadataFilter 将 key=value 等值条件转为表达式（兼容 SearchOptions.Filter 语义）
+func MetadataFilter(meta map[string]string) *FilterExpr {
+	exprs := make([]*FilterExpr, 0, len(meta))
+	for k, v := range meta {
+		exprs = append(exprs, &FilterExpr{Op: FilterOpEq, Key: k, Value: v})
+	}
+	return And(exprs...)
+}
+
+// ACLFilter 文档级 ACL：未设置 acl_roles/acl_users 的文档公开；否则 userID 在 acl_users 中或任一角色在 acl_roles 中才可见
+func ACLFilter(userID string, roles []string) *FilterExpr {
+	public := &FilterExpr{Op: FilterOpNot, Exprs: []*FilterExpr{{Op: FilterOpOr, Exprs: []*FilterExpr{
+		{Op: FilterOpExists, Key: MetaACLRoles},
+		{Op: FilterOpExists, Key: MetaACLUsers},
+	}}}}
+	exprs := []*FilterExpr{public}
+	if userID != "" {
+		exprs = append(exprs, &FilterExpr{Op: FilterOpHas, Key: MetaACLUsers, Value: userID})
+	}
+	var rs []string
+	for _, r := range roles {
+		if r != "" {
+			rs = append(rs, r)
+		}
+	}
+	if len(rs) > 0 {
+		exprs = append(exprs, &FilterExpr{Op: FilterOpHas, Key: MetaACLRoles, Values: rs})
+	}
+	return &FilterExpr{Op: FilterOpOr, Exprs: exprs}
+}
+
+// ACLMetadata 规范化入库时的 ACL 列表（去空白、去重），返回写入切片元数据的键值；列表为空时不写入
+func ACLMetadata(roles, users []string) map[string]string {
+	out := make(map[string]string)
+	if s := joinList(roles); s != "" {
+		out[MetaACLRoles] = s
+	}
+	if s := joinList(users); s != "" {
+		out[MetaACLUsers] = s
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func joinList(items []string) string {
+	seen := make(map[string]bool, len(items))
+	var out []string
+	for _, item := range items {
+		for _, p := range splitList(item) {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+type filterContextKey struct{}
+
+// WithFilter 将检索过滤表达式注入 ctx，供 Retriever 下推到向量检索（如 Agent 工具调用链路）
+func WithFilter(ctx context.Context, f *FilterExpr) context.Context {
+	if f == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, filterContextKey{}, f)
+}
+
+// FilterFromContext 取出 WithFilter 注入的过滤表达式；未注入时返回 nil
+func FilterFromContext(ctx context.Context) *FilterExpr {
+	if ctx == nil {
+		return nil
+	}
+	f, _ := ctx.Value(filterContextKey{}).(*FilterExpr)
+	return f
+}
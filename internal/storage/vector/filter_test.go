@@ -0,0 +1,112 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestFilterExpr_Match(t *testing.T) {
+	meta := map[string]string{"lang": "zh", "tags": "finance, legal", MetaACLUsers: "alice,bob"}
+	cases := []struct {
+		name string
+		expr *FilterExpr
+		want bool
+	}{
+		{"nil", nil, true},
+		{"eq", &FilterExpr{Op: FilterOpEq, Key: "lang", Value: "zh"}, true},
+		{"ne", &FilterExpr{Op: FilterOpNe, Key: "lang", Value: "zh"}, false},
+		{"in", &FilterExpr{Op: FilterOpIn, Key: "lang", Values: []string{"en", "zh"}}, true},
+		{"has", &FilterExpr{Op: FilterOpHas, Key: "tags", Value: "legal"}, true},
+		{"has miss", &FilterExpr{Op: FilterOpHas, Key: "tags", Values: []string{"hr"}}, false},
+		{"exists", &FilterExpr{Op: FilterOpExists, Key: "missing"}, false},
+		{"not", &FilterExpr{Op: FilterOpNot, Exprs: []*FilterExpr{{Op: FilterOpExists, Key: "missing"}}}, true},
+		{"and", And(&FilterExpr{Op: FilterOpEq, Key: "lang", Value: "zh"}, &FilterExpr{Op: FilterOpHas, Key: "tags", Value: "hr"}), false},
+		{"or", &FilterExpr{Op: FilterOpOr, Exprs: []*FilterExpr{{Op: FilterOpEq, Key: "lang", Value: "en"}, {Op: FilterOpHas, Key: MetaACLUsers, Value: "bob"}}}, true},
+	}
+	for _, tc := range cases {
+		if got := tc.expr.Match(meta); got != tc.want {
+			t.Errorf("%s: Match = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFilterExpr_Validate(t *testing.T) {
+	var f FilterExpr
+	if err := json.Unmarshal([]byte(`{"op":"and","exprs":[{"op":"eq","key":"lang","value":"zh"},{"op":"in","key":"k","values":["a"]}]}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	bad := []*FilterExpr{
+		{Op: "like", Key: "k"},
+		{Op: FilterOpEq},
+		{Op: FilterOpIn, Key: "k"},
+		{Op: FilterOpAnd},
+		{Op: FilterOpNot, Exprs: []*FilterExpr{{Op: FilterOpEq}}},
+	}
+	for _, b := range bad {
+		if err := b.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", b)
+		}
+	}
+}
+
+func TestACLFilter(t *testing.T) {
+	public := map[string]string{}
+	roleOnly := ACLMetadata([]string{" admin, auditor ", "admin"}, nil)
+	if roleOnly[MetaACLRoles] != "admin,auditor" {
+		t.Fatalf("ACLMetadata roles = %q", roleOnly[MetaACLRoles])
+	}
+	userOnly := ACLMetadata(nil, []string{"alice"})
+
+	alice := ACLFilter("alice", []string{"user"})
+	auditor := ACLFilter("carol", []string{"auditor"})
+	anonymous := ACLFilter("", nil)
+
+	checks := []struct {
+		name string
+		f    *FilterExpr
+		meta map[string]string
+		want bool
+	}{
+		{"public/alice", alice, public, true},
+		{"public/anonymous", anonymous, public, true},
+		{"role/alice", alice, roleOnly, false},
+		{"role/auditor", auditor, roleOnly, true},
+		{"user/alice", alice, userOnly, true},
+		{"user/auditor", auditor, userOnly, false},
+		{"user/anonymous", anonymous, userOnly, false},
+	}
+	for _, c := range checks {
+		if got := c.f.Match(c.meta); got != c.want {
+			t.Errorf("%s: Match = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFilterContext(t *testing.T) {
+	ctx := context.Background()
+	if FilterFromContext(ctx) != nil {
+		t.Fatal("expected nil filter")
+	}
+	f := &FilterExpr{Op: FilterOpExists, Key: "k"}
+	if got := FilterFromContext(WithFilter(ctx, f)); got != f {
+		t.Fatalf("FilterFromContext = %v, want %v", got, f)
+	}
+}
@@ -16,9 +16,13 @@ package vector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
+// ErrNotFound 索引或向量不存在（MemoryStore.Get/Delete 返回的错误包装此值）
+var ErrNotFound = errors.New("not found")
+
 // IndexInfo 索引元信息（与 Index 配合，便于查询）
 type IndexInfo struct {
 	Name      string
@@ -46,3 +50,13 @@ func EnsureIndex(ctx context.Context, s Store, name string, dimension int, dista
 		Distance:  distance,
 	})
 }
+
+// DeleteVectors 删除索引中的一组向量；已不存在的向量视为删除成功，便于重复清理
+func DeleteVectors(ctx context.Context, s Store, indexName string, ids []string) error {
+	for _, id := range ids {
+		if err := s.Delete(ctx, indexName, id); err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("删除向量 %s failed: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -57,6 +57,7 @@ type Vector struct {
 type SearchOptions struct {
 	TopK           int               `json:"top_k"`           // 返回前 K 个结果
 	Filter         map[string]string `json:"filter"`          // 元数据过滤
+	Expr           *FilterExpr       `json:"expr,omitempty"`  // 过滤表达式（ACL/元数据），与 Filter 同时生效
 	Threshold      float64           `json:"threshold"`       // 相似度阈值
 	IncludeVectors bool              `json:"include_vectors"` // 是否包含向量值
 }
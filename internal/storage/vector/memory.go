@@ -119,6 +119,9 @@ func (s *MemoryStore) Search(ctx context.Context, indexName string, query []floa
 				continue
 			}
 		}
+		if !options.Expr.Match(vector.Metadata) {
+			continue
+		}
 
 		// 计算相似度
 		score := s.calculateSimilarity(query, vector.Values, idx.index.Distance)
@@ -163,12 +166,12 @@ func (s *MemoryStore) Get(ctx context.Context, indexName string, id string) (*Ve
 
 	idx, exists := s.indexes[indexName]
 	if !exists {
-		return nil, fmt.Errorf("index with name %s not found", indexName)
+		return nil, fmt.Errorf("index with name %s %w", indexName, ErrNotFound)
 	}
 
 	vector, exists := idx.vectors[id]
 	if !exists {
-		return nil, fmt.Errorf("vector with ID %s not found", id)
+		return nil, fmt.Errorf("vector with ID %s %w", id, ErrNotFound)
 	}
 
 	return vector, nil
@@ -181,11 +184,11 @@ func (s *MemoryStore) Delete(ctx context.Context, indexName string, id string) e
 
 	idx, exists := s.indexes[indexName]
 	if !exists {
-		return fmt.Errorf("index with name %s not found", indexName)
+		return fmt.Errorf("index with name %s %w", indexName, ErrNotFound)
 	}
 
 	if _, exists := idx.vectors[id]; !exists {
-		return fmt.Errorf("vector with ID %s not found", id)
+		return fmt.Errorf("vector with ID %s %w", id, ErrNotFound)
 	}
 
 	delete(idx.vectors, id)
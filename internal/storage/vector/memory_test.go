@@ -83,3 +83,36 @@ func TestMemoryStore_Search_IndexNotFound(t *testing.T) {
 		t.Error("Search missing index should error")
 	}
 }
+
+func TestMemoryStore_Search_ExprPushDown(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	if err := s.Create(ctx, &Index{Name: "idx", Dimension: 2, Distance: "cosine"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	vecs := []*Vector{
+		// 最相似但受 ACL 限制
+		{ID: "secret", Values: []float64{1, 0}, Metadata: map[string]string{MetaACLRoles: "admin"}},
+		{ID: "public", Values: []float64{0.9, 0.1}, Metadata: map[string]string{"lang": "zh"}},
+		{ID: "other", Values: []float64{0.8, 0.2}, Metadata: map[string]string{"lang": "en"}},
+	}
+	if err := s.Add(ctx, "idx", vecs); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// TopK=1：过滤在打分/截断之前，受限文档不会挤掉可见结果
+	results, err := s.Search(ctx, "idx", []float64{1, 0}, &SearchOptions{TopK: 1, Expr: ACLFilter("alice", []string{"user"})})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "public" {
+		t.Fatalf("Search with ACL: got %+v, want [public]", results)
+	}
+	expr := And(ACLFilter("alice", []string{"user"}), &FilterExpr{Op: FilterOpEq, Key: "lang", Value: "en"})
+	results, err = s.Search(ctx, "idx", []float64{1, 0}, &SearchOptions{TopK: 3, Expr: expr})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "other" {
+		t.Fatalf("Search with ACL+metadata: got %+v, want [other]", results)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"rag-platform/internal/model/llm"
+	"rag-platform/internal/tool"
+)
+
+// FuncTool 确定性假工具：按 Fn 返回结果并记录每次调用的输入
+type FuncTool struct {
+	ToolName string
+	Fn       func(ctx context.Context, input map[string]any) (tool.ToolResult, error)
+
+	mu    sync.Mutex
+	calls []map[string]any
+}
+
+// NewFuncTool 创建假工具；fn 为 nil 时返回空结果
+func NewFuncTool(name string, fn func(ctx context.Context, input map[string]any) (tool.ToolResult, error)) *FuncTool {
+	return &FuncTool{ToolName: name, Fn: fn}
+}
+
+// StaticTool 每次调用都返回固定内容的假工具
+func StaticTool(name, content string) *FuncTool {
+	return NewFuncTool(name, func(context.Context, map[string]any) (tool.ToolResult, error) {
+		return tool.ToolResult{Content: content}, nil
+	})
+}
+
+func (f *FuncTool) Name() string        { return f.ToolName }
+func (f *FuncTool) Description() string { return "test tool " + f.ToolName }
+func (f *FuncTool) Schema() tool.Schema { return tool.Schema{Type: "object"} }
+
+// Execute 实现 tool.Tool
+func (f *FuncTool) Execute(ctx context.Context, input map[string]any) (tool.ToolResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, input)
+	f.mu.Unlock()
+	if f.Fn == nil {
+		return tool.ToolResult{}, nil
+	}
+	return f.Fn(ctx, input)
+}
+
+// Calls 返回已记录的调用输入（按调用顺序）
+func (f *FuncTool) Calls() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.calls...)
+}
+
+// StaticLLM 确定性假 LLM：每次生成都返回 Reply 并计数
+type StaticLLM struct {
+	Reply string
+
+	mu    sync.Mutex
+	calls int
+}
+
+// NewStaticLLM 创建固定回复的假 LLM
+func NewStaticLLM(reply string) *StaticLLM {
+	return &StaticLLM{Reply: reply}
+}
+
+// Calls 返回已发生的生成次数
+func (l *StaticLLM) Calls() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls
+}
+
+func (l *StaticLLM) reply() (string, error) {
+	l.mu.Lock()
+	l.calls++
+	l.mu.Unlock()
+	return l.Reply, nil
+}
+
+func (l *StaticLLM) Generate(string, llm.GenerateOptions) (string, error) { return l.reply() }
+func (l *StaticLLM) GenerateWithContext(context.Context, string, llm.GenerateOptions) (string, error) {
+	return l.reply()
+}
+func (l *StaticLLM) Chat([]llm.Message, llm.GenerateOptions) (string, error) { return l.reply() }
+func (l *StaticLLM) ChatWithContext(context.Context, []llm.Message, llm.GenerateOptions) (string, error) {
+	return l.reply()
+}
+func (l *StaticLLM) Model() string    { return "static" }
+func (l *StaticLLM) Provider() string { return "test" }
+func (l *StaticLLM) SetModel(string)  {}
+func (l *StaticLLM) SetAPIKey(string) {}
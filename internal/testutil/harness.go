@@ -0,0 +1,288 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil 端到端 handler 测试夹具：进程内装配完整 API 应用（内存存储），测试提交消息、
+// 逐步推进执行、注入 signal，并对事件序列做精确断言；不依赖 Postgres，也不依赖计时 sleep。
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/app"
+	"rag-platform/internal/app/api"
+	"rag-platform/internal/model/llm"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/tool"
+	"rag-platform/pkg/config"
+)
+
+// MaxSteps RunUntilIdle 的默认步数上限；超出视为执行不收敛（如 Job 反复重新入队），测试failed
+const MaxSteps = 1000
+
+// Options 夹具选项
+type Options struct {
+	// Config 应用配置；nil 时使用 DefaultConfig。jobstore 须为内存实现，Scheduler 循环不会启动
+	Config *config.Config
+	// Tools 额外注册的工具（参与规划与执行），通常为测试内的确定性假工具
+	Tools []tool.Tool
+	// LLM 生成与规划使用的客户端；nil 时 llm 节点与服务端规划不可用，提交时应携带 task_graph
+	LLM llm.Client
+}
+
+// DefaultConfig 夹具默认配置：内存 jobstore / 元数据 / 向量存储，不启用认证，失败不重试（Step 不会因 backoff sleep）
+func DefaultConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.JobStore.Type = "memory"
+	cfg.Storage.Metadata.Type = "memory"
+	cfg.Storage.Vector.Type = "memory"
+	cfg.Agent.JobScheduler.RetryMax = 0
+	return cfg
+}
+
+// Harness 进程内 API 应用；所有请求经完整路由（中间件、鉴权、handler），执行仅在 Step / RunUntilIdle 时同步发生
+type Harness struct {
+	t      testing.TB
+	app    *api.App
+	engine *route.Engine
+}
+
+// New 装配 API 应用；测试结束时自动关闭
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if cfg.JobStore.Type == "postgres" {
+		t.Fatalf("testutil: jobstore.type=postgres is not supported; the harness drives the in-process scheduler")
+	}
+	bootstrap, err := app.NewBootstrap(cfg)
+	if err != nil {
+		t.Fatalf("testutil: bootstrap: %v", err)
+	}
+	var appOpts []api.Option
+	if len(opts.Tools) > 0 {
+		appOpts = append(appOpts, api.WithTools(opts.Tools...))
+	}
+	if opts.LLM != nil {
+		appOpts = append(appOpts, api.WithLLMClient(opts.LLM))
+	}
+	a, err := api.NewApp(bootstrap, appOpts...)
+	if err != nil {
+		t.Fatalf("testutil: new app: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Shutdown(context.Background()) })
+	return &Harness{t: t, app: a, engine: a.HTTPEngine()}
+}
+
+// Response HTTP 响应
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// Decode 将响应体解析到 v；failed时测试failed
+func (r *Response) Decode(t testing.TB, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("testutil: decode response (status %d): %v; body %s", r.Status, err, r.Body)
+	}
+}
+
+// Map 将响应体解析为 JSON 对象；非对象时返回 nil
+func (r *Response) Map() map[string]interface{} {
+	var out map[string]interface{}
+	_ = json.Unmarshal(r.Body, &out)
+	return out
+}
+
+// Do 发送请求；body 为 nil、[]byte、string 或可 JSON 序列化的值
+func (h *Harness) Do(method, path string, body interface{}) *Response {
+	h.t.Helper()
+	var raw []byte
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		raw = b
+	case string:
+		raw = []byte(b)
+	default:
+		var err error
+		if raw, err = json.Marshal(b); err != nil {
+			h.t.Fatalf("testutil: marshal %s %s body: %v", method, path, err)
+		}
+	}
+	var reqBody *ut.Body
+	if raw != nil {
+		reqBody = &ut.Body{Body: bytes.NewReader(raw), Len: len(raw)}
+	}
+	w := ut.PerformRequest(h.engine, method, path, reqBody, ut.Header{Key: "Content-Type", Value: "application/json"})
+	res := w.Result()
+	return &Response{Status: res.StatusCode(), Body: append([]byte(nil), res.Body()...)}
+}
+
+// MustDo 发送请求并要求返回 want 状态码，返回解析后的 JSON 对象
+func (h *Harness) MustDo(method, path string, body interface{}, want int) map[string]interface{} {
+	h.t.Helper()
+	res := h.Do(method, path, body)
+	if res.Status != want {
+		h.t.Fatalf("testutil: %s %s: status %d, want %d; body %s", method, path, res.Status, want, res.Body)
+	}
+	return res.Map()
+}
+
+// CreateAgent 创建 Agent 并返回其 ID
+func (h *Harness) CreateAgent(name string) string {
+	h.t.Helper()
+	out := h.MustDo("POST", "/api/agents", map[string]string{"name": name}, 200)
+	id, _ := out["id"].(string)
+	if id == "" {
+		h.t.Fatalf("testutil: create agent %q: no id in %v", name, out)
+	}
+	return id
+}
+
+// Submit 向 Agent 发送消息创建 Job 并返回 Job ID；graph 非 nil 时作为客户端计划提交（不调用 Planner）
+func (h *Harness) Submit(agentID, message string, graph *planner.TaskGraph) string {
+	h.t.Helper()
+	body := map[string]interface{}{"message": message}
+	if graph != nil {
+		body["task_graph"] = graph
+	}
+	out := h.MustDo("POST", "/api/agents/"+agentID+"/message", body, 202)
+	id, _ := out["job_id"].(string)
+	if id == "" {
+		h.t.Fatalf("testutil: submit: no job_id in %v", out)
+	}
+	return id
+}
+
+// Step 同步认领并执行一条 Pending Job（执行到完成、失败或挂起），返回其 ID；无可认领 Job 时返回 false
+func (h *Harness) Step() (jobID string, ok bool) {
+	j := h.app.RunNextJob(context.Background())
+	if j == nil {
+		return "", false
+	}
+	return j.ID, true
+}
+
+// RunUntilIdle 反复 Step 直到没有可认领的 Job，返回执行次数；超过 MaxSteps 时测试failed
+func (h *Harness) RunUntilIdle() int {
+	h.t.Helper()
+	for n := 0; n < MaxSteps; n++ {
+		if _, ok := h.Step(); !ok {
+			return n
+		}
+	}
+	h.t.Fatalf("testutil: jobs still runnable after %d steps", MaxSteps)
+	return MaxSteps
+}
+
+// Status 返回 Job 当前状态（如 pending、waiting、completed）
+func (h *Harness) Status(jobID string) string {
+	h.t.Helper()
+	out := h.MustDo("GET", "/api/jobs/"+jobID, nil, 200)
+	status, _ := out["status"].(string)
+	return status
+}
+
+// AssertStatus 断言 Job 当前状态
+func (h *Harness) AssertStatus(jobID, want string) {
+	h.t.Helper()
+	if got := h.Status(jobID); got != want {
+		h.t.Fatalf("testutil: job %s status = %s, want %s; events %v", jobID, got, want, h.EventTypes(jobID))
+	}
+}
+
+// Event GET /api/jobs/:id/events 返回的单条事件
+type Event struct {
+	Type    jobstore.EventType `json:"type"`
+	Payload json.RawMessage    `json:"payload"`
+}
+
+// Events 经 API 读取 Job 的完整事件流
+func (h *Harness) Events(jobID string) []Event {
+	h.t.Helper()
+	res := h.Do("GET", "/api/jobs/"+jobID+"/events", nil)
+	if res.Status != 200 {
+		h.t.Fatalf("testutil: events %s: status %d; body %s", jobID, res.Status, res.Body)
+	}
+	var out struct {
+		Events []Event `json:"events"`
+	}
+	res.Decode(h.t, &out)
+	return out.Events
+}
+
+// BookkeepingEvents AssertEvents 默认忽略的簿记事件：执行环境固化，与执行语义无关
+var BookkeepingEvents = []jobstore.EventType{jobstore.JobEnvironment}
+
+// EventTypes 返回事件类型序列，忽略 BookkeepingEvents
+func (h *Harness) EventTypes(jobID string) []jobstore.EventType {
+	h.t.Helper()
+	skip := make(map[jobstore.EventType]bool, len(BookkeepingEvents))
+	for _, t := range BookkeepingEvents {
+		skip[t] = true
+	}
+	var out []jobstore.EventType
+	for _, e := range h.Events(jobID) {
+		if !skip[e.Type] {
+			out = append(out, e.Type)
+		}
+	}
+	return out
+}
+
+// AssertEvents 断言事件类型序列（忽略 BookkeepingEvents）与 want 完全一致
+func (h *Harness) AssertEvents(jobID string, want ...jobstore.EventType) {
+	h.t.Helper()
+	got := h.EventTypes(jobID)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		h.t.Fatalf("testutil: job %s events\n got: %v\nwant: %v", jobID, got, want)
+	}
+}
+
+// LastEvent 返回最后一条指定类型的事件；不存在时测试failed
+func (h *Harness) LastEvent(jobID string, typ jobstore.EventType) Event {
+	h.t.Helper()
+	events := h.Events(jobID)
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == typ {
+			return events[i]
+		}
+	}
+	h.t.Fatalf("testutil: job %s has no %s event", jobID, typ)
+	return Event{}
+}
+
+// Signal 向挂起在 Wait 节点的 Job 注入 signal；correlation_key 取自最近一条 job_waiting 事件
+func (h *Harness) Signal(jobID string, payload map[string]interface{}) {
+	h.t.Helper()
+	var waiting jobstore.JobWaitingPayload
+	if err := json.Unmarshal(h.LastEvent(jobID, jobstore.JobWaiting).Payload, &waiting); err != nil || waiting.CorrelationKey == "" {
+		h.t.Fatalf("testutil: job %s: job_waiting without correlation_key (%v)", jobID, err)
+	}
+	h.MustDo("POST", "/api/jobs/"+jobID+"/signal", map[string]interface{}{
+		"correlation_key": waiting.CorrelationKey,
+		"payload":         payload,
+	}, 200)
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/internal/runtime/jobstore"
+	"rag-platform/internal/tool"
+)
+
+func toolGraph(toolName string) *planner.TaskGraph {
+	return &planner.TaskGraph{Nodes: []planner.TaskNode{{ID: "n1", Type: planner.NodeTool, ToolName: toolName}}}
+}
+
+func TestHarness_ToolJobEventSequence(t *testing.T) {
+	echo := StaticTool("test.echo", "pong")
+	h := New(t, Options{Tools: []tool.Tool{echo}})
+	jobID := h.Submit(h.CreateAgent("echo"), "ping", toolGraph("test.echo"))
+	h.AssertStatus(jobID, "pending")
+
+	if n := h.RunUntilIdle(); n != 1 {
+		t.Fatalf("RunUntilIdle = %d, want 1", n)
+	}
+	h.AssertStatus(jobID, "completed")
+	h.AssertEvents(jobID,
+		jobstore.JobCreated,
+		jobstore.PlanGenerated,
+		jobstore.DecisionSnapshot,
+		jobstore.NodeStarted,
+		jobstore.ToolInvocationStarted,
+		jobstore.CommandEmitted,
+		jobstore.ToolCalled,
+		jobstore.ToolInvocationFinished,
+		jobstore.CommandCommitted,
+		jobstore.ToolReturned,
+		jobstore.ToolResultSummarized,
+		jobstore.NodeFinished,
+		jobstore.StepCommitted,
+		jobstore.StateCheckpointed,
+		jobstore.ReasoningSnapshot,
+		jobstore.JobCompleted,
+	)
+	if calls := echo.Calls(); len(calls) != 1 {
+		t.Fatalf("tool calls = %d, want 1", len(calls))
+	}
+}
+
+func TestHarness_StepIdle(t *testing.T) {
+	h := New(t, Options{})
+	if id, ok := h.Step(); ok {
+		t.Fatalf("Step on empty queue ran job %s", id)
+	}
+}
+
+func TestHarness_SignalResumesWaitingJob(t *testing.T) {
+	cfg := DefaultConfig()
+	// 恢复走事件驱动 replay：wait 之后首次执行的工具须声明幂等，否则按 replay 策略拒绝
+	cfg.Agent.IdempotentTools = map[string]string{"test.after": ""}
+	after := StaticTool("test.after", "done")
+	h := New(t, Options{Config: cfg, Tools: []tool.Tool{after}})
+	graph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "w", Type: planner.NodeWait, Config: map[string]any{"wait_kind": "signal", "correlation_key": "ck-1"}},
+			{ID: "n1", Type: planner.NodeTool, ToolName: "test.after"},
+		},
+		Edges: []planner.TaskEdge{{From: "w", To: "n1"}},
+	}
+	jobID := h.Submit(h.CreateAgent("waiter"), "wait for approval", graph)
+
+	h.RunUntilIdle()
+	h.AssertStatus(jobID, "waiting")
+	h.AssertEvents(jobID, jobstore.JobCreated, jobstore.PlanGenerated, jobstore.DecisionSnapshot, jobstore.NodeStarted, jobstore.JobWaiting)
+	if len(after.Calls()) != 0 {
+		t.Fatal("tool after wait ran before signal")
+	}
+	if id, ok := h.Step(); ok {
+		t.Fatalf("waiting job %s was claimable before signal", id)
+	}
+
+	h.Signal(jobID, map[string]interface{}{"approved": true})
+	h.AssertStatus(jobID, "pending")
+	h.LastEvent(jobID, jobstore.WaitCompleted)
+
+	h.RunUntilIdle()
+	h.AssertStatus(jobID, "completed")
+	if calls := after.Calls(); len(calls) != 1 {
+		t.Fatalf("tool calls after signal = %d, want 1", len(calls))
+	}
+}
+
+func TestHarness_PlanReviewGate(t *testing.T) {
+	echo := StaticTool("test.echo", "pong")
+	h := New(t, Options{Tools: []tool.Tool{echo}})
+	agentID := h.CreateAgent("reviewed")
+	h.MustDo("PUT", "/api/agents/"+agentID+"/config/require_plan_approval", map[string]string{"value": "true"}, 200)
+	jobID := h.Submit(agentID, "ping", toolGraph("test.echo"))
+
+	h.RunUntilIdle()
+	h.AssertStatus(jobID, "parked")
+	if len(echo.Calls()) != 0 {
+		t.Fatal("tool ran before plan approval")
+	}
+	review := h.MustDo("GET", "/api/jobs/"+jobID+"/plan/review", nil, 200)
+	h.MustDo("POST", "/api/jobs/"+jobID+"/plan/review", map[string]interface{}{
+		"action":    "approve",
+		"plan_hash": review["plan_hash"],
+	}, 200)
+
+	h.RunUntilIdle()
+	h.AssertStatus(jobID, "completed")
+	h.LastEvent(jobID, jobstore.PlanReviewed)
+	if len(echo.Calls()) != 1 {
+		t.Fatalf("tool calls = %d, want 1", len(echo.Calls()))
+	}
+}
+
+func TestHarness_CheckpointRollback(t *testing.T) {
+	gen := NewStaticLLM("draft")
+	h := New(t, Options{LLM: gen})
+	graph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "n1", Type: planner.NodeLLM, Config: map[string]any{"goal": "outline"}},
+			{ID: "n2", Type: planner.NodeLLM, Config: map[string]any{"goal": "draft"}},
+			{ID: "w", Type: planner.NodeWait, Config: map[string]any{"wait_kind": "signal", "correlation_key": "ck-draft"}},
+		},
+		Edges: []planner.TaskEdge{{From: "n1", To: "n2"}, {From: "n2", To: "w"}},
+	}
+	jobID := h.Submit(h.CreateAgent("writer"), "write", graph)
+	h.RunUntilIdle()
+	h.AssertStatus(jobID, "waiting")
+	if n := gen.Calls(); n != 2 {
+		t.Fatalf("llm calls = %d, want 2", n)
+	}
+
+	list := h.MustDo("GET", "/api/jobs/"+jobID+"/checkpoints", nil, 200)
+	cps, _ := list["checkpoints"].([]interface{})
+	cpID := ""
+	for _, item := range cps {
+		if cp, _ := item.(map[string]interface{}); cp["cursor_node"] == "n1" {
+			cpID, _ = cp["id"].(string)
+		}
+	}
+	if cpID == "" {
+		t.Fatalf("no checkpoint for n1 in %v", list)
+	}
+	resp := h.MustDo("POST", "/api/jobs/"+jobID+"/checkpoints/"+cpID+"/rollback", map[string]string{"reason": "bad draft"}, 200)
+	if nodes, _ := resp["invalidated_nodes"].([]interface{}); len(nodes) != 1 || nodes[0] != "n2" {
+		t.Fatalf("invalidated_nodes = %v, want [n2]", resp["invalidated_nodes"])
+	}
+	h.AssertStatus(jobID, "pending")
+	h.LastEvent(jobID, jobstore.CheckpointRolledBack)
+
+	h.RunUntilIdle()
+	h.AssertStatus(jobID, "waiting")
+	if n := gen.Calls(); n != 3 {
+		t.Fatalf("llm calls = %d, want 3 (n2 re-run after rollback)", n)
+	}
+}
+
+func TestHarness_BranchDecision(t *testing.T) {
+	gen := NewStaticLLM("found two documents")
+	h := New(t, Options{LLM: gen})
+	graph := &planner.TaskGraph{
+		Nodes: []planner.TaskNode{
+			{ID: "search", Type: planner.NodeLLM, Config: map[string]any{"goal": "search"}},
+			{ID: "check", Type: planner.NodeBranch, Config: map[string]any{"expr": "empty(search.output)"}},
+			{ID: "ask", Type: planner.NodeWait, Config: map[string]any{"wait_kind": planner.WaitKindUserInput}},
+			{ID: "summarize", Type: planner.NodeLLM, Config: map[string]any{"goal": "summarize"}},
+		},
+		Edges: []planner.TaskEdge{
+			{From: "search", To: "check"},
+			{From: "check", To: "ask", When: "true"},
+			{From: "check", To: "summarize", When: "false"},
+		},
+	}
+	jobID := h.Submit(h.CreateAgent("researcher"), "research", graph)
+	h.RunUntilIdle()
+	// 检索有结果：跳过询问用户，直接总结
+	h.AssertStatus(jobID, "completed")
+	if n := gen.Calls(); n != 2 {
+		t.Fatalf("llm calls = %d, want 2", n)
+	}
+	ev := h.LastEvent(jobID, jobstore.DecisionMade)
+	var pl jobstore.DecisionMadePayload
+	if err := json.Unmarshal(ev.Payload, &pl); err != nil {
+		t.Fatal(err)
+	}
+	if pl.NodeID != "check" || pl.Kind != jobstore.DecisionKindBranch || pl.Outcome != "false" {
+		t.Fatalf("decision_made = %+v", pl)
+	}
+}
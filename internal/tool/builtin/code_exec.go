@@ -0,0 +1,157 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"rag-platform/internal/tool"
+	"rag-platform/internal/tool/codeexec"
+)
+
+// DefaultCodeExecCapability code_exec 默认所需 capability，未在 capability policy 中显式配置时需审批
+const DefaultCodeExecCapability = "code_exec"
+
+// maxCodeBytes 单次提交代码与输入文件的总大小上限
+const maxCodeBytes = 1024 * 1024
+
+// CodeExecTool 实现 code_exec：在隔离运行时（容器 / WASM）执行 Python、JavaScript 片段，默认无网络，
+// 返回 stdout/stderr 与产出文件；结果含运行时镜像 digest，并经 HTTP 效应录制，Replay 不重新执行
+type CodeExecTool struct {
+	runner     codeexec.Runner
+	limits     codeexec.Limits
+	capability string
+}
+
+// CodeExecOption code_exec 配置选项
+type CodeExecOption func(*CodeExecTool)
+
+// WithCodeExecLimits 设置资源配额；零值字段使用默认值，调用方只能在 Timeout 内进一步缩短
+func WithCodeExecLimits(l codeexec.Limits) CodeExecOption {
+	return func(t *CodeExecTool) {
+		t.limits = l.WithDefaults()
+	}
+}
+
+// WithCodeExecCapability 设置 code_exec 所需 capability（默认 code_exec）
+func WithCodeExecCapability(c string) CodeExecOption {
+	return func(t *CodeExecTool) {
+		if c != "" {
+			t.capability = c
+		}
+	}
+}
+
+// NewCodeExecTool 创建 code_exec 工具
+func NewCodeExecTool(runner codeexec.Runner, opts ...CodeExecOption) *CodeExecTool {
+	t := &CodeExecTool{runner: runner, limits: codeexec.Limits{}.WithDefaults(), capability: DefaultCodeExecCapability}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Name 实现 tool.Tool
+func (t *CodeExecTool) Name() string { return "code_exec" }
+
+// RequiredCapability 实现 tool.CapabilityRequired
+func (t *CodeExecTool) RequiredCapability() string { return t.capability }
+
+// Categories 执行任意代码
+func (t *CodeExecTool) Categories() []string { return []string{"code-exec"} }
+
+// Description 实现 tool.Tool
+func (t *CodeExecTool) Description() string {
+	return fmt.Sprintf("在隔离沙箱中执行代码（%s），默认无网络。传入 language、code，可选 files（文件名->内容）；"+
+		"返回 exit_code、stdout、stderr 以及新写入工作目录的文件。", strings.Join(t.languages(), "、"))
+}
+
+// Schema 实现 tool.Tool
+func (t *CodeExecTool) Schema() tool.Schema {
+	return tool.Schema{
+		Type:        "object",
+		Description: "代码执行参数",
+		Properties: map[string]tool.SchemaProperty{
+			"language":        {Type: "string", Description: "语言：" + strings.Join(t.languages(), " | ")},
+			"code":            {Type: "string", Description: "要执行的代码"},
+			"files":           {Type: "object", Description: "写入工作目录的输入文件（相对路径 -> 文本内容，可选）"},
+			"timeout_seconds": {Type: "integer", Description: "执行超时秒数（可选，不超过配置上限）"},
+		},
+		Required: []string{"language", "code"},
+	}
+}
+
+func (t *CodeExecTool) languages() []string {
+	if t.runner == nil {
+		return nil
+	}
+	return t.runner.Languages()
+}
+
+// codeExecOutput code_exec 返回内容
+type codeExecOutput struct {
+	Language string `json:"language"`
+	*codeexec.Result
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Execute 实现 tool.Tool
+func (t *CodeExecTool) Execute(ctx context.Context, input map[string]any) (tool.ToolResult, error) {
+	if t.runner == nil {
+		return tool.ToolResult{Err: "code_exec runtime is not configured"}, nil
+	}
+	lang, _ := input["language"].(string)
+	lang = codeexec.NormalizeLanguage(lang)
+	code, _ := input["code"].(string)
+	if lang == "" || strings.TrimSpace(code) == "" {
+		return tool.ToolResult{Err: "language and code are required"}, nil
+	}
+	files := make(map[string]string)
+	size := len(code)
+	if raw, ok := input["files"].(map[string]any); ok {
+		for name, v := range raw {
+			s, ok := v.(string)
+			if !ok {
+				return tool.ToolResult{Err: fmt.Sprintf("file %q must be a string", name)}, nil
+			}
+			files[name] = s
+			size += len(s)
+		}
+	}
+	if size > maxCodeBytes {
+		return tool.ToolResult{Err: fmt.Sprintf("code and files exceed %d bytes", maxCodeBytes)}, nil
+	}
+	limits := t.limits
+	if n, ok := toInt(input["timeout_seconds"]); ok && n > 0 && time.Duration(n)*time.Second < limits.Timeout {
+		limits.Timeout = time.Duration(n) * time.Second
+	}
+	req := map[string]any{"tool": t.Name(), "language": lang, "code_sha256": sha256Hex([]byte(code)), "files": len(files), "backend": t.runner.Name()}
+	return recordedToolCall(ctx, req, func() tool.ToolResult {
+		res, err := t.runner.Run(ctx, codeexec.Spec{Language: lang, Code: code, Files: files, Limits: limits})
+		if err != nil {
+			return tool.ToolResult{Err: err.Error()}
+		}
+		raw, _ := json.Marshal(codeExecOutput{Language: lang, Result: res, DurationMs: res.Duration.Milliseconds()})
+		out := tool.ToolResult{Content: string(raw)}
+		if res.TimedOut {
+			out.Err = fmt.Sprintf("execution timed out after %s", limits.Timeout)
+		}
+		return out
+	}), nil
+}
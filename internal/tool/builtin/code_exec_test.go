@@ -0,0 +1,110 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agenteffects "rag-platform/internal/agent/runtime/effects"
+	"rag-platform/internal/tool/codeexec"
+)
+
+type stubCodeRunner struct {
+	last codeexec.Spec
+	res  codeexec.Result
+}
+
+func (s *stubCodeRunner) Name() string        { return "stub" }
+func (s *stubCodeRunner) Languages() []string { return []string{codeexec.LanguagePython} }
+func (s *stubCodeRunner) Run(_ context.Context, spec codeexec.Spec) (*codeexec.Result, error) {
+	s.last = spec
+	res := s.res
+	return &res, nil
+}
+
+type captureRecorder struct{ http map[string][]byte }
+
+func (c *captureRecorder) RecordTime(context.Context, string, string, time.Time) error { return nil }
+func (c *captureRecorder) RecordUUID(context.Context, string, string, string) error    { return nil }
+func (c *captureRecorder) RecordHTTP(_ context.Context, _, effectID string, _, resp []byte) error {
+	c.http[effectID] = resp
+	return nil
+}
+
+func TestCodeExecTool_RunsAndRecordsDigest(t *testing.T) {
+	runner := &stubCodeRunner{res: codeexec.Result{
+		ExitCode: 1,
+		Stdout:   "out",
+		Stderr:   "err",
+		Runtime:  codeexec.RuntimeInfo{Backend: "container", Image: "python:3.12-slim", Digest: "sha256:abc"},
+	}}
+	tool := NewCodeExecTool(runner, WithCodeExecLimits(codeexec.Limits{Timeout: 10 * time.Second, MemoryMB: 128}))
+	assert.Equal(t, "code_exec", tool.RequiredCapability())
+	assert.Equal(t, []string{"code-exec"}, tool.Categories())
+
+	rec := &captureRecorder{http: map[string][]byte{}}
+	ctx := agenteffects.WithRecordedEffects(context.Background(), "job-1", "step-1", nil, rec)
+	result, err := tool.Execute(ctx, map[string]any{
+		"language":        "py",
+		"code":            "print(1)",
+		"files":           map[string]any{"data.csv": "a,b"},
+		"timeout_seconds": float64(3),
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal([]byte(result.Content), &out))
+	assert.Equal(t, "python", out["language"])
+	assert.Equal(t, float64(1), out["exit_code"])
+	assert.Equal(t, "sha256:abc", out["runtime"].(map[string]any)["digest"])
+
+	assert.Equal(t, 3*time.Second, runner.last.Limits.Timeout)
+	assert.Equal(t, 128, runner.last.Limits.MemoryMB)
+	assert.False(t, runner.last.Limits.Network)
+	assert.Equal(t, map[string]string{"data.csv": "a,b"}, runner.last.Files)
+	assert.Contains(t, string(rec.http["step-1:http:0"]), `sha256:abc`)
+}
+
+func TestCodeExecTool_TimeoutAndValidation(t *testing.T) {
+	runner := &stubCodeRunner{res: codeexec.Result{TimedOut: true, ExitCode: -1}}
+	tool := NewCodeExecTool(runner)
+
+	result, err := tool.Execute(context.Background(), map[string]any{"language": "python", "code": "x", "timeout_seconds": 999})
+	require.NoError(t, err)
+	assert.Contains(t, result.Err, "timed out")
+	assert.Contains(t, result.Content, `"timed_out":true`)
+	assert.Equal(t, codeexec.DefaultTimeout, runner.last.Limits.Timeout)
+
+	for _, input := range []map[string]any{
+		{"language": "python"},
+		{"code": "x"},
+		{"language": "python", "code": "x", "files": map[string]any{"a": 1}},
+	} {
+		result, err = tool.Execute(context.Background(), input)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Err)
+	}
+
+	result, err = NewCodeExecTool(nil).Execute(context.Background(), map[string]any{"language": "python", "code": "x"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Err, "not configured")
+}
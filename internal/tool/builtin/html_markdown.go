@@ -0,0 +1,259 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipElements 抽取正文时整体跳过的元素（脚本、样式、导航与表单等非正文内容）
+var skipElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Head:     true,
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// htmlToMarkdown 解析 HTML，返回 <title> 与正文 Markdown；存在 <main> 或 <article> 时仅抽取其内容，链接按 base 解析为绝对地址
+func htmlToMarkdown(body []byte, base *url.URL) (string, string, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	title := ""
+	if n := findElement(doc, atom.Title); n != nil {
+		title = collapseSpace(textContent(n))
+	}
+	root := findElement(doc, atom.Main)
+	if root == nil {
+		root = findElement(doc, atom.Article)
+	}
+	if root == nil {
+		if root = findElement(doc, atom.Body); root == nil {
+			root = doc
+		}
+	}
+	w := &mdWriter{base: base}
+	w.walk(root)
+	md := blankLines.ReplaceAllString(w.buf.String(), "\n\n")
+	return title, strings.TrimSpace(md), nil
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if f := findElement(c, a); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// mdWriter 将 DOM 子树写为 Markdown
+type mdWriter struct {
+	buf     strings.Builder
+	base    *url.URL
+	listDep int
+	inPre   bool
+}
+
+func (w *mdWriter) block() {
+	s := w.buf.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	if strings.HasSuffix(s, "\n") {
+		w.buf.WriteString("\n")
+		return
+	}
+	w.buf.WriteString("\n\n")
+}
+
+func (w *mdWriter) text(s string) {
+	if w.inPre {
+		w.buf.WriteString(s)
+		return
+	}
+	collapsed := collapseSpace(s)
+	if collapsed == "" {
+		if s != "" && !strings.HasSuffix(w.buf.String(), " ") && !strings.HasSuffix(w.buf.String(), "\n") {
+			w.buf.WriteString(" ")
+		}
+		return
+	}
+	cur := w.buf.String()
+	if len(s) > 0 && (s[0] == ' ' || s[0] == '\n' || s[0] == '\t') && cur != "" && !strings.HasSuffix(cur, " ") && !strings.HasSuffix(cur, "\n") {
+		w.buf.WriteString(" ")
+	}
+	w.buf.WriteString(collapsed)
+	last := s[len(s)-1]
+	if last == ' ' || last == '\n' || last == '\t' {
+		w.buf.WriteString(" ")
+	}
+}
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+func (w *mdWriter) resolve(ref string) string {
+	if ref == "" || w.base == nil {
+		return ref
+	}
+	u, err := w.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func (w *mdWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if skipElements[n.DataAtom] {
+		return
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block()
+		level := int(n.Data[1] - '0')
+		w.buf.WriteString(strings.Repeat("#", level) + " ")
+		w.buf.WriteString(collapseSpace(textContent(n)))
+		w.block()
+	case atom.P, atom.Div, atom.Section, atom.Header, atom.Table, atom.Blockquote:
+		w.block()
+		if n.DataAtom == atom.Blockquote {
+			w.buf.WriteString("> ")
+		}
+		w.children(n)
+		w.block()
+	case atom.Br:
+		w.buf.WriteString("\n")
+	case atom.Hr:
+		w.block()
+		w.buf.WriteString("---")
+		w.block()
+	case atom.Ul, atom.Ol:
+		w.block()
+		w.listDep++
+		w.children(n)
+		w.listDep--
+		w.block()
+	case atom.Li:
+		if s := w.buf.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			w.buf.WriteString("\n")
+		}
+		w.buf.WriteString(strings.Repeat("  ", max(w.listDep-1, 0)) + "- ")
+		w.children(n)
+	case atom.Tr:
+		if s := w.buf.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			w.buf.WriteString("\n")
+		}
+		w.children(n)
+	case atom.Td, atom.Th:
+		w.buf.WriteString("| ")
+		w.children(n)
+		w.buf.WriteString(" ")
+	case atom.Pre:
+		w.block()
+		w.buf.WriteString("```\n")
+		w.inPre = true
+		w.buf.WriteString(strings.TrimRight(textContent(n), "\n"))
+		w.inPre = false
+		w.buf.WriteString("\n```")
+		w.block()
+	case atom.Code:
+		w.buf.WriteString("`" + textContent(n) + "`")
+	case atom.Strong, atom.B:
+		if t := collapseSpace(textContent(n)); t != "" {
+			w.buf.WriteString("**" + t + "**")
+		}
+	case atom.Em, atom.I:
+		if t := collapseSpace(textContent(n)); t != "" {
+			w.buf.WriteString("_" + t + "_")
+		}
+	case atom.A:
+		label := collapseSpace(textContent(n))
+		href := attr(n, "href")
+		if label == "" {
+			return
+		}
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			w.text(label)
+			return
+		}
+		w.buf.WriteString("[" + label + "](" + w.resolve(href) + ")")
+	case atom.Img:
+		if alt := collapseSpace(attr(n, "alt")); alt != "" {
+			w.buf.WriteString("![" + alt + "](" + w.resolve(attr(n, "src")) + ")")
+		}
+	default:
+		w.children(n)
+	}
+}
@@ -25,6 +25,8 @@ import (
 	"strings"
 	"time"
 
+	agenteffects "rag-platform/internal/agent/runtime/effects"
+	"rag-platform/internal/runtime/egress"
 	"rag-platform/internal/tool"
 )
 
@@ -68,8 +70,10 @@ func WithAllowedSchemes(schemes []string) HTTPToolOption {
 // NewHTTPTool 创建 http.request 工具
 func NewHTTPTool(opts ...HTTPToolOption) *HTTPTool {
 	t := &HTTPTool{
+		// 受租户出网策略约束（ctx 经 egress.WithPolicy 注入策略时生效）
 		client: &http.Client{
-			Timeout: DefaultTimeout,
+			Timeout:   DefaultTimeout,
+			Transport: egress.Transport(nil),
 		},
 		maxBodySize:    DefaultMaxBodySize,
 		allowedSchemes: []string{"http", "https"},
@@ -111,6 +115,12 @@ func validateMethod(method string) error {
 // Name 实现 tool.Tool
 func (t *HTTPTool) Name() string { return "http.request" }
 
+// SandboxSafe 实现 tool.SandboxSafe：沙箱中仅返回录制的 HTTP 响应
+func (t *HTTPTool) SandboxSafe() bool { return true }
+
+// Categories 可发送 POST/PUT/DELETE，归入 network-write，全局熔断该类别时禁用
+func (t *HTTPTool) Categories() []string { return []string{"network-write"} }
+
 // Description 实现 tool.Tool
 func (t *HTTPTool) Description() string {
 	return "发送 HTTP 请求。传入 method、url，可选 body、headers。"
@@ -151,6 +161,17 @@ func (t *HTTPTool) Execute(ctx context.Context, input map[string]any) (tool.Tool
 		return tool.ToolResult{Err: err.Error()}, nil
 	}
 
+	// 调试沙箱（debug-run）：只使用录制的 HTTP 响应，forbidden发起真实请求
+	if agenteffects.IsSandbox(ctx) {
+		_, resp, err := agenteffects.HTTP(ctx, "", func() ([]byte, []byte, error) {
+			return nil, nil, agenteffects.ErrHTTPNotRecorded
+		})
+		if err != nil {
+			return tool.ToolResult{Err: err.Error()}, nil
+		}
+		return tool.ToolResult{Content: string(resp)}, nil
+	}
+
 	var body io.Reader
 	if b, ok := input["body"].(string); ok && b != "" {
 		body = strings.NewReader(b)
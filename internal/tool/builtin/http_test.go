@@ -10,6 +10,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"rag-platform/internal/agent/replay"
+	agenteffects "rag-platform/internal/agent/runtime/effects"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -213,3 +216,30 @@ func TestValidateMethod(t *testing.T) {
 		assert.Error(t, err, "method %s should be invalid", m)
 	}
 }
+
+func TestHTTPTool_SandboxUsesRecordedOnly(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	input := map[string]any{"method": "GET", "url": server.URL}
+	tool := NewHTTPTool()
+
+	ctx := agenteffects.WithSandboxEffects(context.Background(), "job-1", "step-1", &replay.ReplayContext{})
+	result, err := tool.Execute(ctx, input)
+	require.NoError(t, err)
+	assert.Contains(t, result.Err, "未录制")
+
+	recorded := &replay.ReplayContext{RecordedHTTP: map[string][]byte{
+		"step-1:http:0": []byte(`{"status_code":200,"body":"recorded"}`),
+	}}
+	ctx = agenteffects.WithSandboxEffects(context.Background(), "job-1", "step-1", recorded)
+	result, err = tool.Execute(ctx, input)
+	require.NoError(t, err)
+	assert.Empty(t, result.Err)
+	assert.Contains(t, result.Content, "recorded")
+	assert.Equal(t, 0, hits)
+}
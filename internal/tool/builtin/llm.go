@@ -38,6 +38,9 @@ func NewLLMGenerateTool(gen PromptGenerator) *LLMGenerateTool {
 // Name 实现 tool.Tool
 func (t *LLMGenerateTool) Name() string { return "llm.generate" }
 
+// SandboxSafe 实现 tool.SandboxSafe：纯文本生成，无副作用
+func (t *LLMGenerateTool) SandboxSafe() bool { return true }
+
 // Description 实现 tool.Tool
 func (t *LLMGenerateTool) Description() string {
 	return "调用大模型根据提示生成文本。传入 prompt 即可。"
@@ -38,6 +38,9 @@ func NewRAGSearchTool(engine *eino.Engine) *RAGSearchTool {
 // Name 实现 tool.Tool
 func (t *RAGSearchTool) Name() string { return "knowledge.search" }
 
+// SandboxSafe 实现 tool.SandboxSafe：只读检索，无副作用
+func (t *RAGSearchTool) SandboxSafe() bool { return true }
+
 // Description 实现 tool.Tool
 func (t *RAGSearchTool) Description() string {
 	return "在知识库中检索与问题相关的文档片段并返回检索结果，可用于 RAG 问答。"
@@ -20,8 +20,8 @@ import (
 	"rag-platform/internal/tool/registry"
 )
 
-// RegisterBuiltin 将内置工具注册到 ToolRegistry（需传入已装配的 engine 与 generator）
-func RegisterBuiltin(reg *registry.Registry, engine *eino.Engine, generator eino.Generator) {
+// RegisterBuiltin 将内置工具注册到 ToolRegistry（需传入已装配的 engine 与 generator）；extra 为按配置启用的额外内置工具
+func RegisterBuiltin(reg *registry.Registry, engine *eino.Engine, generator eino.Generator, extra ...tool.Tool) {
 	if reg == nil {
 		return
 	}
@@ -34,6 +34,11 @@ func RegisterBuiltin(reg *registry.Registry, engine *eino.Engine, generator eino
 		reg.Register(NewLLMGenerateTool(generator))
 	}
 	reg.Register(NewHTTPTool())
+	for _, t := range extra {
+		if t != nil {
+			reg.Register(t)
+		}
+	}
 }
 
 // RegisterBuiltinWithTools 仅注册不依赖 engine 的通用工具（用于测试或最小装配）
@@ -0,0 +1,147 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"bufio"
+	"strings"
+)
+
+// robotsRules robots.txt 中适用于当前 User-Agent 的规则组（RFC 9309）
+type robotsRules struct {
+	allowAll    bool
+	disallowAll bool
+	rules       []robotsRule
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsAllowAll 与 robotsDisallowAll：robots.txt 不存在（4xx）时全部允许；不可达（5xx、网络错误）时全部禁止
+var (
+	robotsAllowAll    = &robotsRules{allowAll: true}
+	robotsDisallowAll = &robotsRules{disallowAll: true}
+)
+
+// parseRobots 解析 robots.txt，选取与 userAgent 产品名匹配最长的组，无匹配时使用 "*" 组
+func parseRobots(body, userAgent string) *robotsRules {
+	product := strings.ToLower(userAgent)
+	if i := strings.IndexAny(product, "/ "); i >= 0 {
+		product = product[:i]
+	}
+	type group struct {
+		agents []string
+		rules  []robotsRule
+	}
+	var groups []*group
+	var cur *group
+	lastWasAgent := false
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+		switch key {
+		case "user-agent":
+			if cur == nil || !lastWasAgent {
+				cur = &group{}
+				groups = append(groups, cur)
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			if cur == nil || val == "" {
+				continue
+			}
+			cur.rules = append(cur.rules, robotsRule{allow: key == "allow", pattern: val})
+		default:
+			lastWasAgent = false
+		}
+	}
+	var best *group
+	bestLen := -1
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*" && bestLen < 0:
+				best, bestLen = g, 0
+			case a != "*" && product != "" && strings.Contains(product, a) && len(a) > bestLen:
+				best, bestLen = g, len(a)
+			}
+		}
+	}
+	if best == nil {
+		return robotsAllowAll
+	}
+	return &robotsRules{rules: best.rules}
+}
+
+// allowed 判断路径（含查询串）是否允许抓取：匹配最长的规则生效，长度相同时 Allow 优先
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil || r.allowAll {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	if path == "" {
+		path = "/"
+	}
+	allow := true
+	bestLen := -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if l := len(rule.pattern); l > bestLen || (l == bestLen && rule.allow) {
+			allow, bestLen = rule.allow, l
+		}
+	}
+	return allow
+}
+
+// robotsMatch 前缀匹配，支持 "*" 通配与结尾 "$" 锚定
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if i == len(parts)-1 && anchored {
+			return strings.HasSuffix(path[pos:], parts[i])
+		}
+		idx := strings.Index(path[pos:], parts[i])
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(parts[i])
+	}
+	return !anchored || pos == len(path)
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	agenteffects "rag-platform/internal/agent/runtime/effects"
+	"rag-platform/internal/tool"
+)
+
+// DefaultWebCacheTTL web 工具结果默认缓存时长
+const DefaultWebCacheTTL = 15 * time.Minute
+
+// DefaultWebCacheSize web 工具默认缓存条目上限
+const DefaultWebCacheSize = 256
+
+// webCache 进程内 TTL 缓存，键为请求内容的 sha256；超出容量时淘汰最早过期的条目
+type webCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]webCacheEntry
+}
+
+type webCacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+func newWebCache(ttl time.Duration, max int) *webCache {
+	return &webCache{ttl: ttl, max: max, entries: make(map[string]webCacheEntry)}
+}
+
+// webCacheKey 由请求各部分计算缓存键
+func webCacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *webCache) get(key string) (string, bool) {
+	if c == nil || c.ttl <= 0 {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return e.value, true
+}
+
+func (c *webCache) put(key, value string) {
+	if c == nil || c.ttl <= 0 || c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = webCacheEntry{value: value, expiresAt: time.Now().Add(c.ttl)}
+	for len(c.entries) > c.max {
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range c.entries {
+			if oldestKey == "" || e.expiresAt.Before(oldest) {
+				oldestKey, oldest = k, e.expiresAt
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+// recordedToolCall 在 RecordedEffects 上下文中经 HTTP 效应录制整次工具结果：Replay 与调试沙箱直接注入录制结果，不发起请求；
+// 无效应上下文（如单独调用工具）时直接执行
+func recordedToolCall(ctx context.Context, request any, do func() tool.ToolResult) tool.ToolResult {
+	if !agenteffects.Active(ctx) {
+		return do()
+	}
+	_, resp, err := agenteffects.HTTP(ctx, "", func() ([]byte, []byte, error) {
+		reqJSON, _ := json.Marshal(request)
+		respJSON, err := json.Marshal(do())
+		return reqJSON, respJSON, err
+	})
+	if err != nil {
+		return tool.ToolResult{Err: err.Error()}
+	}
+	var res tool.ToolResult
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return tool.ToolResult{Err: "invalid recorded result: " + err.Error()}
+	}
+	return res
+}
+
+// sha256Hex 返回内容的 sha256 十六进制串
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,377 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/time/rate"
+
+	"rag-platform/internal/runtime/egress"
+	"rag-platform/internal/tool"
+)
+
+const (
+	// DefaultWebFetchUserAgent web_fetch 默认 User-Agent；robots.txt 按其产品名（aetheris）匹配规则组
+	DefaultWebFetchUserAgent = "aetheris/2.0 (+https://github.com/fanjia1024/Aetheris)"
+	// DefaultWebFetchMaxBytes 单页最大下载字节数，超出部分丢弃并标记 truncated
+	DefaultWebFetchMaxBytes = 2 * 1024 * 1024
+	// DefaultWebFetchMaxChars 返回正文最大字符数
+	DefaultWebFetchMaxChars = 20000
+	// DefaultRobotsTTL robots.txt 缓存时长
+	DefaultRobotsTTL = time.Hour
+)
+
+// errPrivateAddress 目标解析到内网/回环地址（防 SSRF）
+var errPrivateAddress = errors.New("destination resolves to a private or loopback address")
+
+// WebFetchTool 实现 web_fetch：抓取网页并抽取为 Markdown；遵守 robots.txt，限制下载大小，按 URL hash 缓存，
+// 在 Job 执行中经 HTTP 效应录制，Replay 时注入录制结果
+type WebFetchTool struct {
+	client       *http.Client
+	userAgent    string
+	maxBytes     int64
+	maxChars     int
+	cache        *webCache
+	limiter      *rate.Limiter
+	allowPrivate bool
+
+	robotsMu  sync.Mutex
+	robotsTTL time.Duration
+	robots    map[string]robotsCacheEntry
+}
+
+type robotsCacheEntry struct {
+	rules     *robotsRules
+	expiresAt time.Time
+}
+
+// WebFetchOption web_fetch 配置选项
+type WebFetchOption func(*WebFetchTool)
+
+// WithWebFetchUserAgent 设置 User-Agent（robots.txt 规则组匹配其产品名）
+func WithWebFetchUserAgent(ua string) WebFetchOption {
+	return func(t *WebFetchTool) {
+		if ua != "" {
+			t.userAgent = ua
+		}
+	}
+}
+
+// WithWebFetchLimits 设置最大下载字节数与返回正文最大字符数；<=0 保持默认
+func WithWebFetchLimits(maxBytes int64, maxChars int) WebFetchOption {
+	return func(t *WebFetchTool) {
+		if maxBytes > 0 {
+			t.maxBytes = maxBytes
+		}
+		if maxChars > 0 {
+			t.maxChars = maxChars
+		}
+	}
+}
+
+// WithWebFetchTimeout 设置单次请求超时
+func WithWebFetchTimeout(d time.Duration) WebFetchOption {
+	return func(t *WebFetchTool) {
+		if d > 0 {
+			t.client.Timeout = d
+		}
+	}
+}
+
+// WithWebFetchCache 设置结果缓存时长与条目上限；ttl<=0 关闭缓存
+func WithWebFetchCache(ttl time.Duration, size int) WebFetchOption {
+	return func(t *WebFetchTool) {
+		t.cache = newWebCache(ttl, size)
+	}
+}
+
+// WithWebFetchRateLimit 设置进程内抓取速率（次/秒）与突发；qps<=0 不限流
+func WithWebFetchRateLimit(qps float64, burst int) WebFetchOption {
+	return func(t *WebFetchTool) {
+		t.limiter = newToolLimiter(qps, burst)
+	}
+}
+
+// WithWebFetchAllowPrivateNetworks 允许抓取内网/回环地址（默认禁止，防 SSRF；仅用于测试或内网部署）
+func WithWebFetchAllowPrivateNetworks(allow bool) WebFetchOption {
+	return func(t *WebFetchTool) {
+		t.allowPrivate = allow
+	}
+}
+
+// NewWebFetchTool 创建 web_fetch 工具
+func NewWebFetchTool(opts ...WebFetchOption) *WebFetchTool {
+	t := &WebFetchTool{
+		client:    &http.Client{Timeout: DefaultTimeout},
+		userAgent: DefaultWebFetchUserAgent,
+		maxBytes:  DefaultWebFetchMaxBytes,
+		maxChars:  DefaultWebFetchMaxChars,
+		cache:     newWebCache(DefaultWebCacheTTL, DefaultWebCacheSize),
+		robotsTTL: DefaultRobotsTTL,
+		robots:    make(map[string]robotsCacheEntry),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	var base *http.Transport
+	if !t.allowPrivate {
+		dialer := &net.Dialer{Timeout: 10 * time.Second, Control: denyPrivateAddress}
+		base = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+	t.client.Transport = egress.Transport(base)
+	return t
+}
+
+// denyPrivateAddress 在拨号时校验解析后的 IP，拒绝内网、回环与链路本地地址（含重定向与 DNS 重绑定）
+func denyPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+// newToolLimiter qps<=0 时返回 nil（不限流）
+func newToolLimiter(qps float64, burst int) *rate.Limiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(qps)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return rate.NewLimiter(rate.Limit(qps), burst)
+}
+
+// Name 实现 tool.Tool
+func (t *WebFetchTool) Name() string { return "web_fetch" }
+
+// SandboxSafe 实现 tool.SandboxSafe：沙箱中仅返回录制结果
+func (t *WebFetchTool) SandboxSafe() bool { return true }
+
+// Categories 只读外网访问
+func (t *WebFetchTool) Categories() []string { return []string{"network-read"} }
+
+// Description 实现 tool.Tool
+func (t *WebFetchTool) Description() string {
+	return "抓取网页并抽取正文为 Markdown。传入 url，可选 max_chars；遵守 robots.txt。"
+}
+
+// Schema 实现 tool.Tool
+func (t *WebFetchTool) Schema() tool.Schema {
+	return tool.Schema{
+		Type:        "object",
+		Description: "网页抓取参数",
+		Properties: map[string]tool.SchemaProperty{
+			"url":       {Type: "string", Description: "http/https 网页地址"},
+			"max_chars": {Type: "integer", Description: "返回正文最大字符数（可选）"},
+		},
+		Required: []string{"url"},
+	}
+}
+
+// webFetchOutput web_fetch 返回内容
+type webFetchOutput struct {
+	URL         string `json:"url"`
+	FinalURL    string `json:"final_url,omitempty"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Content     string `json:"content"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	// ContentSHA256 下载原文的 sha256，便于审计核对
+	ContentSHA256 string `json:"content_sha256"`
+	Cached        bool   `json:"cached,omitempty"`
+}
+
+// Execute 实现 tool.Tool
+func (t *WebFetchTool) Execute(ctx context.Context, input map[string]any) (tool.ToolResult, error) {
+	rawURL, _ := input["url"].(string)
+	if rawURL == "" {
+		return tool.ToolResult{Err: "url is required"}, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return tool.ToolResult{Err: fmt.Sprintf("invalid url %q: only absolute http/https URLs are supported", rawURL)}, nil
+	}
+	maxChars := t.maxChars
+	if n, ok := toInt(input["max_chars"]); ok && n > 0 && n < maxChars {
+		maxChars = n
+	}
+	req := map[string]any{"tool": t.Name(), "url": u.String(), "max_chars": maxChars}
+	return recordedToolCall(ctx, req, func() tool.ToolResult { return t.fetch(ctx, u, maxChars) }), nil
+}
+
+func (t *WebFetchTool) fetch(ctx context.Context, u *url.URL, maxChars int) tool.ToolResult {
+	key := webCacheKey(t.Name(), u.String(), fmt.Sprint(maxChars))
+	if cached, ok := t.cache.get(key); ok {
+		var out webFetchOutput
+		if json.Unmarshal([]byte(cached), &out) == nil {
+			out.Cached = true
+			raw, _ := json.Marshal(out)
+			return tool.ToolResult{Content: string(raw)}
+		}
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return tool.ToolResult{Err: fmt.Sprintf("rate limit wait: %s", err.Error())}
+		}
+	}
+	if !t.robotsFor(ctx, u).allowed(u.EscapedPath() + queryPart(u)) {
+		return tool.ToolResult{Err: fmt.Sprintf("fetching %s is disallowed by robots.txt", u.String())}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return tool.ToolResult{Err: fmt.Sprintf("failed to create request: %s", err.Error())}
+	}
+	httpReq.Header.Set("User-Agent", t.userAgent)
+	httpReq.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return tool.ToolResult{Err: fmt.Sprintf("request failed: %s", err.Error())}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return tool.ToolResult{Err: fmt.Sprintf("fetch %s: HTTP %d", u.String(), resp.StatusCode)}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBytes+1))
+	if err != nil {
+		return tool.ToolResult{Err: fmt.Sprintf("failed to read response: %s", err.Error())}
+	}
+	out := webFetchOutput{URL: u.String(), StatusCode: resp.StatusCode}
+	if int64(len(body)) > t.maxBytes {
+		body = body[:t.maxBytes]
+		out.Truncated = true
+	}
+	out.ContentSHA256 = sha256Hex(body)
+	if final := resp.Request.URL.String(); final != out.URL {
+		out.FinalURL = final
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	out.ContentType = mediaType
+	switch {
+	case mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		title, md, err := htmlToMarkdown(body, resp.Request.URL)
+		if err != nil {
+			return tool.ToolResult{Err: fmt.Sprintf("failed to parse HTML: %s", err.Error())}
+		}
+		out.Title, out.Content = title, md
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "xml"):
+		out.Content = strings.ToValidUTF8(string(body), "")
+	default:
+		return tool.ToolResult{Err: fmt.Sprintf("unsupported content type %q", mediaType)}
+	}
+	if utf8.RuneCountInString(out.Content) > maxChars {
+		out.Content = string([]rune(out.Content)[:maxChars])
+		out.Truncated = true
+	}
+	raw, _ := json.Marshal(out)
+	t.cache.put(key, string(raw))
+	return tool.ToolResult{Content: string(raw)}
+}
+
+// robotsFor 返回目标站点的 robots 规则（按 scheme+host 缓存）
+func (t *WebFetchTool) robotsFor(ctx context.Context, u *url.URL) *robotsRules {
+	origin := u.Scheme + "://" + u.Host
+	t.robotsMu.Lock()
+	if e, ok := t.robots[origin]; ok && time.Now().Before(e.expiresAt) {
+		t.robotsMu.Unlock()
+		return e.rules
+	}
+	t.robotsMu.Unlock()
+
+	rules := t.loadRobots(ctx, origin)
+	t.robotsMu.Lock()
+	t.robots[origin] = robotsCacheEntry{rules: rules, expiresAt: time.Now().Add(t.robotsTTL)}
+	t.robotsMu.Unlock()
+	return rules
+}
+
+// loadRobots 拉取 robots.txt：4xx 视为无限制，5xx 与网络错误视为全部禁止（RFC 9309）
+func (t *WebFetchTool) loadRobots(ctx context.Context, origin string) *robotsRules {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return robotsDisallowAll
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			// 目标被 SSRF 防护拒绝：交由正文请求返回明确错误
+			return robotsAllowAll
+		}
+		return robotsDisallowAll
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return robotsDisallowAll
+	case resp.StatusCode >= 400:
+		return robotsAllowAll
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return robotsDisallowAll
+	}
+	return parseRobots(string(body), t.userAgent)
+}
+
+func queryPart(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	return "?" + u.RawQuery
+}
+
+// toInt 从 JSON 解码后的输入中取整数
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rag-platform/internal/agent/replay"
+	agenteffects "rag-platform/internal/agent/runtime/effects"
+)
+
+const testArticleHTML = `<!doctype html><html><head><title>Test Page</title><script>var x = 1;</script></head>
+<body><nav>menu</nav><main><h1>Hello</h1><p>Some <b>bold</b> text and a <a href="/next">link</a>.</p>
+<ul><li>one</li><li>two</li></ul><pre><code>fmt.Println()</code></pre></main><footer>footer</footer></body></html>`
+
+func newWebFetchTestServer(t *testing.T, robots string) (*httptest.Server, *int32) {
+	t.Helper()
+	var pageHits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, robots)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pageHits, 1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, testArticleHTML)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &pageHits
+}
+
+func decodeFetchOutput(t *testing.T, content string) webFetchOutput {
+	t.Helper()
+	var out webFetchOutput
+	require.NoError(t, json.Unmarshal([]byte(content), &out))
+	return out
+}
+
+func TestWebFetchTool_ExtractsMarkdownAndCaches(t *testing.T) {
+	srv, hits := newWebFetchTestServer(t, "")
+	tool := NewWebFetchTool(WithWebFetchAllowPrivateNetworks(true))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"url": srv.URL + "/article"})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+	out := decodeFetchOutput(t, result.Content)
+	assert.Equal(t, "Test Page", out.Title)
+	assert.Equal(t, 200, out.StatusCode)
+	assert.Equal(t, "text/html", out.ContentType)
+	assert.Contains(t, out.Content, "# Hello")
+	assert.Contains(t, out.Content, "**bold**")
+	assert.Contains(t, out.Content, "[link]("+srv.URL+"/next)")
+	assert.Contains(t, out.Content, "- one")
+	assert.Contains(t, out.Content, "```\nfmt.Println()\n```")
+	assert.NotContains(t, out.Content, "menu")
+	assert.NotContains(t, out.Content, "footer")
+	assert.NotContains(t, out.Content, "var x")
+	assert.NotEmpty(t, out.ContentSHA256)
+	assert.False(t, out.Cached)
+
+	result, err = tool.Execute(context.Background(), map[string]any{"url": srv.URL + "/article"})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+	assert.True(t, decodeFetchOutput(t, result.Content).Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(hits))
+}
+
+func TestWebFetchTool_RespectsRobots(t *testing.T) {
+	srv, hits := newWebFetchTestServer(t, "User-agent: *\nDisallow: /private\n\nUser-agent: aetheris\nDisallow: /secret\nAllow: /\n")
+	tool := NewWebFetchTool(WithWebFetchAllowPrivateNetworks(true))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"url": srv.URL + "/secret/page"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Err, "robots.txt")
+
+	// aetheris 分组优先于 *，/private 在该分组中允许
+	result, err = tool.Execute(context.Background(), map[string]any{"url": srv.URL + "/private/page"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(hits))
+}
+
+func TestWebFetchTool_Limits(t *testing.T) {
+	srv, _ := newWebFetchTestServer(t, "")
+	tool := NewWebFetchTool(WithWebFetchAllowPrivateNetworks(true), WithWebFetchLimits(0, 100))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"url": srv.URL, "max_chars": 5})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+	out := decodeFetchOutput(t, result.Content)
+	assert.True(t, out.Truncated)
+	assert.Equal(t, 5, len([]rune(out.Content)))
+
+	small := NewWebFetchTool(WithWebFetchAllowPrivateNetworks(true), WithWebFetchLimits(64, 0))
+	result, err = small.Execute(context.Background(), map[string]any{"url": srv.URL})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+	assert.True(t, decodeFetchOutput(t, result.Content).Truncated)
+}
+
+func TestWebFetchTool_RejectsPrivateAndInvalidURLs(t *testing.T) {
+	srv, hits := newWebFetchTestServer(t, "")
+	tool := NewWebFetchTool()
+
+	result, err := tool.Execute(context.Background(), map[string]any{"url": srv.URL})
+	require.NoError(t, err)
+	assert.Contains(t, result.Err, "private")
+	assert.Equal(t, int32(0), atomic.LoadInt32(hits))
+
+	for _, u := range []string{"", "file:///etc/passwd", "/relative"} {
+		result, err = tool.Execute(context.Background(), map[string]any{"url": u})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Err, u)
+	}
+}
+
+func TestWebFetchTool_SandboxUsesRecordedResult(t *testing.T) {
+	srv, hits := newWebFetchTestServer(t, "")
+	tool := NewWebFetchTool(WithWebFetchAllowPrivateNetworks(true))
+	recorded := &replay.ReplayContext{RecordedHTTP: map[string][]byte{
+		"step-1:http:0": []byte(`{"content":"{\"url\":\"recorded\"}"}`),
+	}}
+	ctx := agenteffects.WithSandboxEffects(context.Background(), "job-1", "step-1", recorded)
+	result, err := tool.Execute(ctx, map[string]any{"url": srv.URL})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+	assert.Equal(t, `{"url":"recorded"}`, result.Content)
+	assert.Equal(t, int32(0), atomic.LoadInt32(hits))
+}
+
+func TestRobotsRules(t *testing.T) {
+	rules := parseRobots(strings.Join([]string{
+		"User-agent: *",
+		"Disallow: /tmp/",
+		"Allow: /tmp/public",
+		"Disallow: /*.pdf$",
+		"Disallow: /search?",
+	}, "\n"), "aetheris/2.0")
+
+	cases := map[string]bool{
+		"/":                true,
+		"/tmp/x":           false,
+		"/tmp/public/a":    true,
+		"/docs/a.pdf":      false,
+		"/docs/a.pdf?x=1":  true,
+		"/search?q=go":     false,
+		"/search":          true,
+		"/tmpfile":         true,
+		"/tmp/public.html": true,
+	}
+	for path, want := range cases {
+		assert.Equal(t, want, rules.allowed(path), path)
+	}
+	assert.True(t, robotsAllowAll.allowed("/anything"))
+	assert.False(t, robotsDisallowAll.allowed("/anything"))
+}
@@ -0,0 +1,320 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"rag-platform/internal/tool"
+)
+
+// DefaultWebSearchMaxResults web_search 默认返回结果数
+const DefaultWebSearchMaxResults = 5
+
+// maxSearchResponseBytes 搜索后端响应体上限
+const maxSearchResponseBytes = 4 * 1024 * 1024
+
+// SearchResult 单条搜索结果
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// SearchBackend web_search 的可插拔搜索后端
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, client *http.Client, query string, limit int) ([]SearchResult, error)
+}
+
+// 内置搜索后端名称（agent.web_tools.search.backend）
+const (
+	SearchBackendSerpAPI = "serpapi"
+	SearchBackendBing    = "bing"
+	SearchBackendSearxNG = "searxng"
+)
+
+// NewSearchBackend 按名称创建搜索后端；endpoint 为空时使用官方地址（SearxNG 必填）
+func NewSearchBackend(name, apiKey, endpoint string) (SearchBackend, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case SearchBackendSerpAPI:
+		if apiKey == "" {
+			return nil, fmt.Errorf("serpapi backend requires api_key")
+		}
+		if endpoint == "" {
+			endpoint = "https://serpapi.com/search.json"
+		}
+		return &serpAPIBackend{apiKey: apiKey, endpoint: endpoint}, nil
+	case SearchBackendBing:
+		if apiKey == "" {
+			return nil, fmt.Errorf("bing backend requires api_key")
+		}
+		if endpoint == "" {
+			endpoint = "https://api.bing.microsoft.com/v7.0/search"
+		}
+		return &bingBackend{apiKey: apiKey, endpoint: endpoint}, nil
+	case SearchBackendSearxNG:
+		if endpoint == "" {
+			return nil, fmt.Errorf("searxng backend requires endpoint")
+		}
+		return &searxNGBackend{endpoint: strings.TrimSuffix(endpoint, "/") + "/search"}, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q (want %s, %s or %s)", name, SearchBackendSerpAPI, SearchBackendBing, SearchBackendSearxNG)
+	}
+}
+
+// getSearchJSON 发起 GET 并解码 JSON 响应
+func getSearchJSON(ctx context.Context, client *http.Client, endpoint string, params url.Values, headers map[string]string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSearchResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body[:min(len(body), 200)])))
+	}
+	return json.Unmarshal(body, out)
+}
+
+type serpAPIBackend struct{ apiKey, endpoint string }
+
+func (b *serpAPIBackend) Name() string { return SearchBackendSerpAPI }
+
+func (b *serpAPIBackend) Search(ctx context.Context, client *http.Client, query string, limit int) ([]SearchResult, error) {
+	var resp struct {
+		OrganicResults []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"organic_results"`
+	}
+	params := url.Values{"engine": {"google"}, "q": {query}, "num": {strconv.Itoa(limit)}, "api_key": {b.apiKey}}
+	if err := getSearchJSON(ctx, client, b.endpoint, params, nil, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]SearchResult, 0, len(resp.OrganicResults))
+	for _, r := range resp.OrganicResults {
+		out = append(out, SearchResult{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return out, nil
+}
+
+type bingBackend struct{ apiKey, endpoint string }
+
+func (b *bingBackend) Name() string { return SearchBackendBing }
+
+func (b *bingBackend) Search(ctx context.Context, client *http.Client, query string, limit int) ([]SearchResult, error) {
+	var resp struct {
+		WebPages struct {
+			Value []struct {
+				Name    string `json:"name"`
+				URL     string `json:"url"`
+				Snippet string `json:"snippet"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+	params := url.Values{"q": {query}, "count": {strconv.Itoa(limit)}}
+	if err := getSearchJSON(ctx, client, b.endpoint, params, map[string]string{"Ocp-Apim-Subscription-Key": b.apiKey}, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]SearchResult, 0, len(resp.WebPages.Value))
+	for _, r := range resp.WebPages.Value {
+		out = append(out, SearchResult{Title: r.Name, URL: r.URL, Snippet: r.Snippet})
+	}
+	return out, nil
+}
+
+type searxNGBackend struct{ endpoint string }
+
+func (b *searxNGBackend) Name() string { return SearchBackendSearxNG }
+
+func (b *searxNGBackend) Search(ctx context.Context, client *http.Client, query string, limit int) ([]SearchResult, error) {
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getSearchJSON(ctx, client, b.endpoint, url.Values{"q": {query}, "format": {"json"}}, nil, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]SearchResult, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		out = append(out, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return out, nil
+}
+
+// WebSearchTool 实现 web_search：经可插拔后端（SerpAPI、Bing、SearxNG）检索网页，按查询缓存结果，
+// 在 Job 执行中经 HTTP 效应录制，Replay 时注入录制结果
+type WebSearchTool struct {
+	backend    SearchBackend
+	client     *http.Client
+	maxResults int
+	cache      *webCache
+	limiter    *rate.Limiter
+}
+
+// WebSearchOption web_search 配置选项
+type WebSearchOption func(*WebSearchTool)
+
+// WithWebSearchMaxResults 设置默认与最大返回结果数
+func WithWebSearchMaxResults(n int) WebSearchOption {
+	return func(t *WebSearchTool) {
+		if n > 0 {
+			t.maxResults = n
+		}
+	}
+}
+
+// WithWebSearchTimeout 设置后端请求超时
+func WithWebSearchTimeout(d time.Duration) WebSearchOption {
+	return func(t *WebSearchTool) {
+		if d > 0 {
+			t.client.Timeout = d
+		}
+	}
+}
+
+// WithWebSearchCache 设置结果缓存时长与条目上限；ttl<=0 关闭缓存
+func WithWebSearchCache(ttl time.Duration, size int) WebSearchOption {
+	return func(t *WebSearchTool) {
+		t.cache = newWebCache(ttl, size)
+	}
+}
+
+// WithWebSearchRateLimit 设置进程内查询速率（次/秒）与突发；qps<=0 不限流
+func WithWebSearchRateLimit(qps float64, burst int) WebSearchOption {
+	return func(t *WebSearchTool) {
+		t.limiter = newToolLimiter(qps, burst)
+	}
+}
+
+// NewWebSearchTool 创建 web_search 工具
+func NewWebSearchTool(backend SearchBackend, opts ...WebSearchOption) *WebSearchTool {
+	t := &WebSearchTool{
+		backend:    backend,
+		client:     &http.Client{Timeout: DefaultTimeout},
+		maxResults: DefaultWebSearchMaxResults,
+		cache:      newWebCache(DefaultWebCacheTTL, DefaultWebCacheSize),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Name 实现 tool.Tool
+func (t *WebSearchTool) Name() string { return "web_search" }
+
+// SandboxSafe 实现 tool.SandboxSafe：沙箱中仅返回录制结果
+func (t *WebSearchTool) SandboxSafe() bool { return true }
+
+// Categories 只读外网访问
+func (t *WebSearchTool) Categories() []string { return []string{"network-read"} }
+
+// Description 实现 tool.Tool
+func (t *WebSearchTool) Description() string {
+	return "网页搜索。传入 query，可选 max_results；返回标题、URL 与摘要，可再用 web_fetch 读取正文。"
+}
+
+// Schema 实现 tool.Tool
+func (t *WebSearchTool) Schema() tool.Schema {
+	return tool.Schema{
+		Type:        "object",
+		Description: "网页搜索参数",
+		Properties: map[string]tool.SchemaProperty{
+			"query":       {Type: "string", Description: "搜索关键词"},
+			"max_results": {Type: "integer", Description: "返回结果数（可选）"},
+		},
+		Required: []string{"query"},
+	}
+}
+
+// webSearchOutput web_search 返回内容
+type webSearchOutput struct {
+	Query   string         `json:"query"`
+	Backend string         `json:"backend"`
+	Results []SearchResult `json:"results"`
+	Cached  bool           `json:"cached,omitempty"`
+}
+
+// Execute 实现 tool.Tool
+func (t *WebSearchTool) Execute(ctx context.Context, input map[string]any) (tool.ToolResult, error) {
+	query, _ := input["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return tool.ToolResult{Err: "query is required"}, nil
+	}
+	if t.backend == nil {
+		return tool.ToolResult{Err: "web_search backend is not configured"}, nil
+	}
+	limit := t.maxResults
+	if n, ok := toInt(input["max_results"]); ok && n > 0 && n < limit {
+		limit = n
+	}
+	req := map[string]any{"tool": t.Name(), "backend": t.backend.Name(), "query": query, "max_results": limit}
+	return recordedToolCall(ctx, req, func() tool.ToolResult { return t.search(ctx, query, limit) }), nil
+}
+
+func (t *WebSearchTool) search(ctx context.Context, query string, limit int) tool.ToolResult {
+	key := webCacheKey(t.Name(), t.backend.Name(), query, strconv.Itoa(limit))
+	if cached, ok := t.cache.get(key); ok {
+		var out webSearchOutput
+		if json.Unmarshal([]byte(cached), &out) == nil {
+			out.Cached = true
+			raw, _ := json.Marshal(out)
+			return tool.ToolResult{Content: string(raw)}
+		}
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return tool.ToolResult{Err: fmt.Sprintf("rate limit wait: %s", err.Error())}
+		}
+	}
+	results, err := t.backend.Search(ctx, t.client, query, limit)
+	if err != nil {
+		return tool.ToolResult{Err: fmt.Sprintf("%s search failed: %s", t.backend.Name(), err.Error())}
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	raw, _ := json.Marshal(webSearchOutput{Query: query, Backend: t.backend.Name(), Results: results})
+	t.cache.put(key, string(raw))
+	return tool.ToolResult{Content: string(raw)}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBackends_ParseResults(t *testing.T) {
+	var lastQuery atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery.Store(r.URL.RawQuery + "|" + r.Header.Get("Ocp-Apim-Subscription-Key"))
+		switch r.URL.Path {
+		case "/serp":
+			fmt.Fprint(w, `{"organic_results":[{"title":"S1","link":"https://a.example","snippet":"sa"}]}`)
+		case "/bing":
+			fmt.Fprint(w, `{"webPages":{"value":[{"name":"B1","url":"https://b.example","snippet":"sb"}]}}`)
+		case "/searx/search":
+			fmt.Fprint(w, `{"results":[{"title":"X1","url":"https://x.example","content":"sx"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		backend, apiKey, endpoint string
+		want                      SearchResult
+		wantQuery                 string
+	}{
+		{SearchBackendSerpAPI, "k1", srv.URL + "/serp", SearchResult{Title: "S1", URL: "https://a.example", Snippet: "sa"}, "api_key=k1&engine=google&num=3&q=golang|"},
+		{SearchBackendBing, "k2", srv.URL + "/bing", SearchResult{Title: "B1", URL: "https://b.example", Snippet: "sb"}, "count=3&q=golang|k2"},
+		{SearchBackendSearxNG, "", srv.URL + "/searx/", SearchResult{Title: "X1", URL: "https://x.example", Snippet: "sx"}, "format=json&q=golang|"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.backend, func(t *testing.T) {
+			backend, err := NewSearchBackend(tc.backend, tc.apiKey, tc.endpoint)
+			require.NoError(t, err)
+			results, err := backend.Search(context.Background(), http.DefaultClient, "golang", 3)
+			require.NoError(t, err)
+			assert.Equal(t, []SearchResult{tc.want}, results)
+			assert.Equal(t, tc.wantQuery, lastQuery.Load())
+		})
+	}
+}
+
+func TestNewSearchBackend_Validation(t *testing.T) {
+	for _, tc := range []struct{ name, key, endpoint string }{
+		{"serpapi", "", ""},
+		{"bing", "", ""},
+		{"searxng", "", ""},
+		{"duckduckgo", "k", "http://x"},
+	} {
+		_, err := NewSearchBackend(tc.name, tc.key, tc.endpoint)
+		assert.Error(t, err, tc.name)
+	}
+}
+
+type stubSearchBackend struct{ calls int32 }
+
+func (s *stubSearchBackend) Name() string { return "stub" }
+
+func (s *stubSearchBackend) Search(_ context.Context, _ *http.Client, query string, limit int) ([]SearchResult, error) {
+	atomic.AddInt32(&s.calls, 1)
+	out := make([]SearchResult, 0, 10)
+	for i := 0; i < 10; i++ {
+		out = append(out, SearchResult{Title: fmt.Sprintf("%s %d", query, i), URL: fmt.Sprintf("https://r%d.example", i)})
+	}
+	return out, nil
+}
+
+func TestWebSearchTool_LimitsAndCaches(t *testing.T) {
+	backend := &stubSearchBackend{}
+	tool := NewWebSearchTool(backend, WithWebSearchMaxResults(4))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"query": " go ", "max_results": float64(2)})
+	require.NoError(t, err)
+	require.Empty(t, result.Err)
+	var out webSearchOutput
+	require.NoError(t, json.Unmarshal([]byte(result.Content), &out))
+	assert.Equal(t, "go", out.Query)
+	assert.Equal(t, "stub", out.Backend)
+	assert.Len(t, out.Results, 2)
+
+	result, err = tool.Execute(context.Background(), map[string]any{"query": "go", "max_results": 100})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(result.Content), &out))
+	assert.Len(t, out.Results, 4)
+
+	result, err = tool.Execute(context.Background(), map[string]any{"query": "go", "max_results": 100})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(result.Content), &out))
+	assert.True(t, out.Cached)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&backend.calls))
+
+	result, err = tool.Execute(context.Background(), map[string]any{})
+	require.NoError(t, err)
+	assert.Contains(t, result.Err, "query is required")
+}
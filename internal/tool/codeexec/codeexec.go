@@ -0,0 +1,158 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeexec 在隔离运行时中执行代码片段（code_exec 工具）：容器（docker/podman，可换用 gVisor、
+// Kata/Firecracker 等 OCI runtime）或 WASM（wasmtime）；默认无网络，限制 CPU、内存、时长与输出，
+// 并返回运行时镜像/模块的 digest 以便复现。
+package codeexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 支持的语言
+const (
+	LanguagePython     = "python"
+	LanguageJavaScript = "javascript"
+)
+
+// 默认配额
+const (
+	DefaultTimeout          = 30 * time.Second
+	DefaultMemoryMB         = 256
+	DefaultCPUs             = 1.0
+	DefaultPIDs             = 64
+	DefaultMaxOutputBytes   = 64 * 1024
+	DefaultMaxArtifactBytes = 1024 * 1024
+	DefaultMaxArtifacts     = 16
+)
+
+// ErrUnsupportedLanguage 运行时未配置该语言
+var ErrUnsupportedLanguage = errors.New("codeexec: unsupported language")
+
+// Limits 单次执行的资源配额
+type Limits struct {
+	Timeout          time.Duration
+	CPUs             float64 // 容器 CPU 配额（--cpus）；WASM 后端仅受 Timeout 约束
+	MemoryMB         int
+	PIDs             int  // 容器进程数上限
+	Network          bool // 是否允许访问网络；默认 false
+	MaxOutputBytes   int  // stdout/stderr 各自保留的最大字节数
+	MaxArtifactBytes int  // 单个产出文件的最大字节数，超出时只记录大小与哈希
+	MaxArtifacts     int
+}
+
+// WithDefaults 将零值字段替换为默认配额
+func (l Limits) WithDefaults() Limits {
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultTimeout
+	}
+	if l.CPUs <= 0 {
+		l.CPUs = DefaultCPUs
+	}
+	if l.MemoryMB <= 0 {
+		l.MemoryMB = DefaultMemoryMB
+	}
+	if l.PIDs <= 0 {
+		l.PIDs = DefaultPIDs
+	}
+	if l.MaxOutputBytes <= 0 {
+		l.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	if l.MaxArtifactBytes <= 0 {
+		l.MaxArtifactBytes = DefaultMaxArtifactBytes
+	}
+	if l.MaxArtifacts <= 0 {
+		l.MaxArtifacts = DefaultMaxArtifacts
+	}
+	return l
+}
+
+// Spec 一次执行请求
+type Spec struct {
+	Language string
+	Code     string
+	// Files 写入工作目录的输入文件（相对路径 -> 内容）
+	Files  map[string]string
+	Limits Limits
+}
+
+// RuntimeInfo 实际使用的运行时；Digest 为镜像 digest（容器）或模块 sha256（WASM），用于复现
+type RuntimeInfo struct {
+	Backend    string `json:"backend"`
+	Image      string `json:"image"`
+	Digest     string `json:"digest,omitempty"`
+	OCIRuntime string `json:"oci_runtime,omitempty"`
+}
+
+// Artifact 执行产生或修改的文件；文本以 utf-8 返回，二进制以 base64 返回，超限时仅含大小与哈希
+type Artifact struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	Encoding  string `json:"encoding,omitempty"` // utf-8 | base64；省略表示内容未返回
+	Content   string `json:"content,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Result 执行结果
+type Result struct {
+	ExitCode        int           `json:"exit_code"`
+	Stdout          string        `json:"stdout"`
+	Stderr          string        `json:"stderr"`
+	StdoutTruncated bool          `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool          `json:"stderr_truncated,omitempty"`
+	TimedOut        bool          `json:"timed_out,omitempty"`
+	Duration        time.Duration `json:"-"`
+	Artifacts       []Artifact    `json:"artifacts,omitempty"`
+	Runtime         RuntimeInfo   `json:"runtime"`
+}
+
+// Runner 隔离执行后端
+type Runner interface {
+	// Name 后端名称（container、wasm）
+	Name() string
+	// Languages 已配置的语言
+	Languages() []string
+	// Run 执行代码；代码非零退出不是错误，仅在运行时本身不可用时返回 error
+	Run(ctx context.Context, spec Spec) (*Result, error)
+}
+
+// NormalizeLanguage 统一语言别名（py、js、node 等）
+func NormalizeLanguage(lang string) string {
+	switch strings.ToLower(strings.TrimSpace(lang)) {
+	case "python", "python3", "py":
+		return LanguagePython
+	case "javascript", "js", "node", "nodejs":
+		return LanguageJavaScript
+	default:
+		return strings.ToLower(strings.TrimSpace(lang))
+	}
+}
+
+// sourceFile 各语言的入口文件名
+func sourceFile(lang string) (string, error) {
+	switch lang {
+	case LanguagePython:
+		return "main.py", nil
+	case LanguageJavaScript:
+		return "main.js", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedLanguage, lang)
+	}
+}
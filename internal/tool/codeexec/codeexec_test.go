@@ -0,0 +1,160 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeexec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker 模拟 docker CLI：image inspect 返回固定 digest；run 记录参数、读取挂载目录中的输入并写出产出文件
+const fakeDocker = `#!/bin/sh
+if [ "$1" = "image" ]; then
+  echo '["python@sha256:0123abcd"]|sha256:feed'
+  exit 0
+fi
+if [ "$1" = "rm" ]; then exit 0; fi
+echo "$@" > "$ARGS_FILE"
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-v" ]; then dir="${2%%:*}"; fi
+  shift
+done
+case "$MODE" in
+  sleep) exec sleep 5 ;;
+  *)
+    cat "$dir/input.txt"
+    echo "warning" >&2
+    echo "result" > "$dir/out.txt"
+    printf 'bin\000ary' > "$dir/blob.bin"
+    exit 3 ;;
+esac
+`
+
+func newFakeDocker(t *testing.T, mode string) (*ContainerRunner, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script fake requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "docker")
+	require.NoError(t, os.WriteFile(bin, []byte(fakeDocker), 0o755))
+	argsFile := filepath.Join(dir, "args")
+	t.Setenv("ARGS_FILE", argsFile)
+	t.Setenv("MODE", mode)
+	return NewContainerRunner(bin, "runsc", nil), argsFile
+}
+
+func TestContainerRunner_RunCollectsOutputAndArtifacts(t *testing.T) {
+	r, argsFile := newFakeDocker(t, "")
+	res, err := r.Run(context.Background(), Spec{
+		Language: "py",
+		Code:     "print('hi')",
+		Files:    map[string]string{"input.txt": "hello input"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.ExitCode)
+	assert.Equal(t, "hello input", res.Stdout)
+	assert.Equal(t, "warning\n", res.Stderr)
+	assert.Equal(t, RuntimeInfo{Backend: "container", Image: DefaultPythonImage, Digest: "sha256:0123abcd", OCIRuntime: "runsc"}, res.Runtime)
+
+	// 未修改的输入文件与入口文件不作为产出返回
+	require.Len(t, res.Artifacts, 2)
+	assert.Equal(t, "blob.bin", res.Artifacts[0].Path)
+	assert.Equal(t, "base64", res.Artifacts[0].Encoding)
+	assert.Equal(t, "out.txt", res.Artifacts[1].Path)
+	assert.Equal(t, "utf-8", res.Artifacts[1].Encoding)
+	assert.Equal(t, "result\n", res.Artifacts[1].Content)
+
+	raw, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	args := string(raw)
+	for _, want := range []string{"--network none", "--runtime runsc", "--memory 256m", "--cpus 1", "--pids-limit 64", "--read-only", "--cap-drop ALL", "python@sha256:0123abcd python -I main.py"} {
+		assert.Contains(t, args, want)
+	}
+}
+
+func TestContainerRunner_Timeout(t *testing.T) {
+	r, _ := newFakeDocker(t, "sleep")
+	start := time.Now()
+	res, err := r.Run(context.Background(), Spec{Language: LanguagePython, Code: "while True: pass", Limits: Limits{Timeout: 200 * time.Millisecond}})
+	require.NoError(t, err)
+	assert.True(t, res.TimedOut)
+	assert.Equal(t, -1, res.ExitCode)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestContainerRunner_UnsupportedLanguage(t *testing.T) {
+	r := NewContainerRunner("docker", "", nil)
+	_, err := r.Run(context.Background(), Spec{Language: "ruby", Code: "puts 1"})
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+	assert.Equal(t, []string{LanguageJavaScript, LanguagePython}, r.Languages())
+}
+
+func TestContainerRunArgs_Network(t *testing.T) {
+	args := strings.Join(containerRunArgs("n", "", "/w", "img", Limits{Network: true}.WithDefaults(), []string{"node", "main.js"}), " ")
+	assert.NotContains(t, args, "--network")
+	assert.NotContains(t, args, "--runtime")
+	assert.True(t, strings.HasSuffix(args, "img node main.js"))
+}
+
+func TestWorkspace_RejectsEscapingPaths(t *testing.T) {
+	for _, name := range []string{"../x", "/etc/passwd", "a/../../x", "", "main.py"} {
+		_, err := newWorkspace(Spec{Language: LanguagePython, Code: "x", Files: map[string]string{name: "x"}})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestWorkspace_ArtifactLimits(t *testing.T) {
+	ws, err := newWorkspace(Spec{Language: LanguagePython, Code: "x"})
+	require.NoError(t, err)
+	defer ws.cleanup()
+	require.NoError(t, os.WriteFile(filepath.Join(ws.dir, "big.txt"), []byte(strings.Repeat("a", 100)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(ws.dir, "small.txt"), []byte("b"), 0o644))
+
+	arts, err := ws.artifacts(Limits{MaxArtifactBytes: 10, MaxArtifacts: 1})
+	require.NoError(t, err)
+	require.Len(t, arts, 1)
+	assert.Equal(t, "big.txt", arts[0].Path)
+	assert.True(t, arts[0].Truncated)
+	assert.Empty(t, arts[0].Content)
+	assert.Equal(t, int64(100), arts[0].Size)
+	assert.Equal(t, sha256Hex([]byte(strings.Repeat("a", 100))), arts[0].SHA256)
+}
+
+func TestWasmRunner_DigestAndArgs(t *testing.T) {
+	module := filepath.Join(t.TempDir(), "python.wasm")
+	require.NoError(t, os.WriteFile(module, []byte("\x00asm"), 0o644))
+	r := NewWasmRunner("", map[string]string{"py": module})
+	digest, err := r.moduleDigest(module)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:"+sha256Hex([]byte("\x00asm")), digest)
+	assert.Equal(t, []string{LanguagePython}, r.Languages())
+
+	args := wasmRunArgs("/w", module, "main.py", Limits{MemoryMB: 1})
+	assert.Equal(t, []string{"run", "--dir", "/w::/workspace", "-W", "max-memory-size=1048576", "--env", "HOME=/tmp", module, "/workspace/main.py"}, args)
+
+	_, err = NewRunner("wasm", "", "", nil, nil)
+	assert.Error(t, err)
+	_, err = NewRunner("firecracker", "", "", nil, nil)
+	assert.Error(t, err)
+}
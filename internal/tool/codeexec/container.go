@@ -0,0 +1,230 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeexec
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认镜像
+const (
+	DefaultPythonImage     = "python:3.12-slim"
+	DefaultJavaScriptImage = "node:22-slim"
+)
+
+// containerCLIError docker/podman 自身出错时的退出码（镜像不存在、参数错误、OCI runtime 不可用等）
+const containerCLIError = 125
+
+// ContainerRunner 以 docker/podman 容器执行代码：无网络（默认）、只读根文件系统、丢弃全部 capability、
+// 非特权用户，并按 Limits 设置 --cpus/--memory/--pids-limit。OCIRuntime 可指定 runsc（gVisor）或
+// kata-fc（Kata Containers + Firecracker）以获得虚拟机级隔离。镜像按 digest 固定后执行。
+type ContainerRunner struct {
+	Binary     string            // docker（默认）或 podman
+	OCIRuntime string            // 可选：--runtime
+	Images     map[string]string // 语言 -> 镜像，空则用默认镜像
+
+	mu      sync.Mutex
+	digests map[string]containerImage
+}
+
+type containerImage struct {
+	ref    string // 按 digest 固定的引用（repo@sha256:... 或 image ID）
+	digest string
+}
+
+// NewContainerRunner 创建容器后端；binary 为空用 docker
+func NewContainerRunner(binary, ociRuntime string, images map[string]string) *ContainerRunner {
+	if binary == "" {
+		binary = "docker"
+	}
+	merged := map[string]string{LanguagePython: DefaultPythonImage, LanguageJavaScript: DefaultJavaScriptImage}
+	for lang, img := range images {
+		if img != "" {
+			merged[NormalizeLanguage(lang)] = img
+		}
+	}
+	return &ContainerRunner{Binary: binary, OCIRuntime: ociRuntime, Images: merged, digests: make(map[string]containerImage)}
+}
+
+// Name 实现 Runner
+func (r *ContainerRunner) Name() string { return "container" }
+
+// Languages 实现 Runner
+func (r *ContainerRunner) Languages() []string {
+	out := make([]string, 0, len(r.Images))
+	for lang := range r.Images {
+		if _, err := sourceFile(lang); err == nil {
+			out = append(out, lang)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Run 实现 Runner
+func (r *ContainerRunner) Run(ctx context.Context, spec Spec) (*Result, error) {
+	spec.Language = NormalizeLanguage(spec.Language)
+	image, ok := r.Images[spec.Language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLanguage, spec.Language)
+	}
+	limits := spec.Limits.WithDefaults()
+	img, err := r.resolveImage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := newWorkspace(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.cleanup()
+
+	name := "aetheris-codeexec-" + randomSuffix()
+	args := containerRunArgs(name, r.OCIRuntime, ws.dir, img.ref, limits, interpreter(spec.Language, ws.entry))
+	runCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, r.Binary, args...)
+	cmd.WaitDelay = 5 * time.Second
+	stdout := &cappedBuffer{max: limits.MaxOutputBytes}
+	stderr := &cappedBuffer{max: limits.MaxOutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	start := time.Now()
+	runErr := cmd.Run()
+	res := &Result{
+		Duration: time.Since(start),
+		Runtime:  RuntimeInfo{Backend: r.Name(), Image: image, Digest: img.digest, OCIRuntime: r.OCIRuntime},
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		res.TimedOut = true
+		res.ExitCode = -1
+		// 客户端被杀后容器可能仍在运行
+		_ = exec.Command(r.Binary, "rm", "-f", name).Run()
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("codeexec: run %s: %w", r.Binary, runErr)
+		}
+		res.ExitCode = exitErr.ExitCode()
+		if res.ExitCode == containerCLIError {
+			return nil, fmt.Errorf("codeexec: %s run failed: %s", r.Binary, strings.TrimSpace(stderr.String()))
+		}
+	}
+	res.Stdout, res.StdoutTruncated = stdout.String(), stdout.truncated
+	res.Stderr, res.StderrTruncated = stderr.String(), stderr.truncated
+	if res.Artifacts, err = ws.artifacts(limits); err != nil {
+		return nil, fmt.Errorf("codeexec: collect artifacts: %w", err)
+	}
+	return res, nil
+}
+
+// containerRunArgs 组装 docker/podman run 参数
+func containerRunArgs(name, ociRuntime, dir, imageRef string, limits Limits, command []string) []string {
+	args := []string{"run", "--rm", "--name", name}
+	if !limits.Network {
+		args = append(args, "--network", "none")
+	}
+	if ociRuntime != "" {
+		args = append(args, "--runtime", ociRuntime)
+	}
+	mem := strconv.Itoa(limits.MemoryMB) + "m"
+	args = append(args,
+		"--cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64),
+		"--memory", mem,
+		"--memory-swap", mem,
+		"--pids-limit", strconv.Itoa(limits.PIDs),
+		"--read-only",
+		"--tmpfs", "/tmp:rw,size=64m",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", "65534:65534",
+		"-e", "HOME=/tmp",
+		"-v", dir+":/workspace:rw",
+		"-w", "/workspace",
+		imageRef,
+	)
+	return append(args, command...)
+}
+
+// interpreter 各语言在容器内的执行命令
+func interpreter(lang, entry string) []string {
+	switch lang {
+	case LanguageJavaScript:
+		return []string{"node", entry}
+	default:
+		return []string{"python", "-I", entry}
+	}
+}
+
+// resolveImage 解析镜像 digest（本地不存在时先拉取），结果在进程内缓存
+func (r *ContainerRunner) resolveImage(ctx context.Context, image string) (containerImage, error) {
+	r.mu.Lock()
+	if img, ok := r.digests[image]; ok {
+		r.mu.Unlock()
+		return img, nil
+	}
+	r.mu.Unlock()
+
+	img, err := r.inspectImage(ctx, image)
+	if err != nil {
+		if out, pullErr := exec.CommandContext(ctx, r.Binary, "pull", "--quiet", image).CombinedOutput(); pullErr != nil {
+			return containerImage{}, fmt.Errorf("codeexec: pull %s: %v: %s", image, pullErr, strings.TrimSpace(string(out)))
+		}
+		if img, err = r.inspectImage(ctx, image); err != nil {
+			return containerImage{}, err
+		}
+	}
+	r.mu.Lock()
+	r.digests[image] = img
+	r.mu.Unlock()
+	return img, nil
+}
+
+func (r *ContainerRunner) inspectImage(ctx context.Context, image string) (containerImage, error) {
+	out, err := exec.CommandContext(ctx, r.Binary, "image", "inspect", "--format", "{{json .RepoDigests}}|{{.Id}}", image).Output()
+	if err != nil {
+		return containerImage{}, fmt.Errorf("codeexec: inspect image %s: %w", image, err)
+	}
+	repoDigests, id, _ := strings.Cut(strings.TrimSpace(string(out)), "|")
+	var digests []string
+	_ = json.Unmarshal([]byte(repoDigests), &digests)
+	for _, d := range digests {
+		if _, digest, ok := strings.Cut(d, "@"); ok {
+			return containerImage{ref: d, digest: digest}, nil
+		}
+	}
+	if id == "" {
+		return containerImage{}, fmt.Errorf("codeexec: image %s has no digest", image)
+	}
+	// 本地构建、未推送的镜像没有 RepoDigests，以镜像 ID（配置的 sha256）固定
+	return containerImage{ref: id, digest: id}, nil
+}
+
+func randomSuffix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WasmRunner 以 wasmtime 执行 WASI 模块（如单文件 python.wasm、QuickJS qjs.wasm）：WASI 默认无网络、
+// 仅预打开 /workspace；内存由 max-memory-size 限制，时长由进程超时限制。Digest 为模块文件的 sha256。
+type WasmRunner struct {
+	Binary  string            // wasmtime（默认）
+	Modules map[string]string // 语言 -> 模块路径
+
+	mu      sync.Mutex
+	digests map[string]wasmDigest
+}
+
+type wasmDigest struct {
+	modTime time.Time
+	size    int64
+	digest  string
+}
+
+// NewWasmRunner 创建 WASM 后端；binary 为空用 wasmtime
+func NewWasmRunner(binary string, modules map[string]string) *WasmRunner {
+	if binary == "" {
+		binary = "wasmtime"
+	}
+	m := make(map[string]string, len(modules))
+	for lang, p := range modules {
+		if p != "" {
+			m[NormalizeLanguage(lang)] = p
+		}
+	}
+	return &WasmRunner{Binary: binary, Modules: m, digests: make(map[string]wasmDigest)}
+}
+
+// Name 实现 Runner
+func (r *WasmRunner) Name() string { return "wasm" }
+
+// Languages 实现 Runner
+func (r *WasmRunner) Languages() []string {
+	out := make([]string, 0, len(r.Modules))
+	for lang := range r.Modules {
+		if _, err := sourceFile(lang); err == nil {
+			out = append(out, lang)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Run 实现 Runner
+func (r *WasmRunner) Run(ctx context.Context, spec Spec) (*Result, error) {
+	spec.Language = NormalizeLanguage(spec.Language)
+	module, ok := r.Modules[spec.Language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLanguage, spec.Language)
+	}
+	limits := spec.Limits.WithDefaults()
+	digest, err := r.moduleDigest(module)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := newWorkspace(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.cleanup()
+
+	runCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, r.Binary, wasmRunArgs(ws.dir, module, ws.entry, limits)...)
+	cmd.WaitDelay = 5 * time.Second
+	stdout := &cappedBuffer{max: limits.MaxOutputBytes}
+	stderr := &cappedBuffer{max: limits.MaxOutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	start := time.Now()
+	runErr := cmd.Run()
+	res := &Result{
+		Duration: time.Since(start),
+		Runtime:  RuntimeInfo{Backend: r.Name(), Image: module, Digest: digest},
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		res.TimedOut = true
+		res.ExitCode = -1
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("codeexec: run %s: %w", r.Binary, runErr)
+		}
+		res.ExitCode = exitErr.ExitCode()
+	}
+	res.Stdout, res.StdoutTruncated = stdout.String(), stdout.truncated
+	res.Stderr, res.StderrTruncated = stderr.String(), stderr.truncated
+	if res.Artifacts, err = ws.artifacts(limits); err != nil {
+		return nil, fmt.Errorf("codeexec: collect artifacts: %w", err)
+	}
+	return res, nil
+}
+
+// wasmRunArgs 组装 wasmtime run 参数
+func wasmRunArgs(dir, module, entry string, limits Limits) []string {
+	args := []string{"run",
+		"--dir", dir + "::/workspace",
+		"-W", "max-memory-size=" + strconv.Itoa(limits.MemoryMB*1024*1024),
+		"--env", "HOME=/tmp",
+	}
+	if limits.Network {
+		args = append(args, "-S", "inherit-network", "-S", "allow-ip-name-lookup")
+	}
+	return append(args, module, "/workspace/"+entry)
+}
+
+// moduleDigest 计算模块 sha256（按修改时间与大小缓存）
+func (r *WasmRunner) moduleDigest(module string) (string, error) {
+	info, err := os.Stat(module)
+	if err != nil {
+		return "", fmt.Errorf("codeexec: wasm module: %w", err)
+	}
+	r.mu.Lock()
+	d, ok := r.digests[module]
+	r.mu.Unlock()
+	if ok && d.modTime.Equal(info.ModTime()) && d.size == info.Size() {
+		return d.digest, nil
+	}
+	sum, err := fileSHA256(module)
+	if err != nil {
+		return "", fmt.Errorf("codeexec: wasm module: %w", err)
+	}
+	digest := "sha256:" + sum
+	r.mu.Lock()
+	r.digests[module] = wasmDigest{modTime: info.ModTime(), size: info.Size(), digest: digest}
+	r.mu.Unlock()
+	return digest, nil
+}
+
+// NewRunner 按名称创建后端：container（默认）或 wasm
+func NewRunner(backend, binary, ociRuntime string, images, wasmModules map[string]string) (Runner, error) {
+	switch backend {
+	case "", "container":
+		return NewContainerRunner(binary, ociRuntime, images), nil
+	case "wasm":
+		if len(wasmModules) == 0 {
+			return nil, fmt.Errorf("codeexec: wasm backend requires wasm_modules")
+		}
+		return NewWasmRunner(binary, wasmModules), nil
+	default:
+		return nil, fmt.Errorf("codeexec: unknown backend %q (want container or wasm)", backend)
+	}
+}
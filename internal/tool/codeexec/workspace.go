@@ -0,0 +1,193 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeexec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// workspace 单次执行的临时工作目录，挂载为运行时内的 /workspace
+type workspace struct {
+	dir    string
+	entry  string            // 入口文件相对路径
+	inputs map[string]string // 写入的文件 -> sha256，未修改的输入文件不作为产出返回
+}
+
+// newWorkspace 创建工作目录并写入代码与输入文件；目录对运行时内的非特权用户可写
+func newWorkspace(spec Spec) (*workspace, error) {
+	entry, err := sourceFile(spec.Language)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "aetheris-codeexec-")
+	if err != nil {
+		return nil, err
+	}
+	ws := &workspace{dir: dir, entry: entry, inputs: make(map[string]string, len(spec.Files)+1)}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		ws.cleanup()
+		return nil, err
+	}
+	files := map[string]string{entry: spec.Code}
+	for name, content := range spec.Files {
+		rel, err := cleanRelPath(name)
+		if err != nil {
+			ws.cleanup()
+			return nil, err
+		}
+		if rel == entry {
+			ws.cleanup()
+			return nil, fmt.Errorf("codeexec: input file %q conflicts with the entry file", name)
+		}
+		files[rel] = content
+	}
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+			ws.cleanup()
+			return nil, err
+		}
+		if err := os.WriteFile(p, []byte(content), 0o666); err != nil {
+			ws.cleanup()
+			return nil, err
+		}
+		ws.inputs[rel] = sha256Hex([]byte(content))
+	}
+	return ws, nil
+}
+
+func (w *workspace) cleanup() { _ = os.RemoveAll(w.dir) }
+
+// cleanRelPath 校验输入文件名：必须是工作目录内的相对路径
+func cleanRelPath(name string) (string, error) {
+	rel := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("codeexec: invalid input file name %q", name)
+	}
+	return rel, nil
+}
+
+// artifacts 收集执行后新增或被修改的文件（按路径排序），最多 limits.MaxArtifacts 个
+func (w *workspace) artifacts(limits Limits) ([]Artifact, error) {
+	var out []Artifact
+	err := filepath.WalkDir(w.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(w.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		a := Artifact{Path: rel, Size: info.Size()}
+		data, err := readCapped(p, limits.MaxArtifactBytes)
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) < info.Size() {
+			a.Truncated = true
+			a.SHA256, err = fileSHA256(p)
+			if err != nil {
+				return err
+			}
+		} else {
+			a.SHA256 = sha256Hex(data)
+			if utf8.Valid(data) && !bytes.Contains(data, []byte{0}) {
+				a.Encoding, a.Content = "utf-8", string(data)
+			} else {
+				a.Encoding, a.Content = "base64", base64.StdEncoding.EncodeToString(data)
+			}
+		}
+		if h, ok := w.inputs[rel]; ok && h == a.SHA256 {
+			return nil
+		}
+		out = append(out, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	if len(out) > limits.MaxArtifacts {
+		out = out[:limits.MaxArtifacts]
+	}
+	return out, nil
+}
+
+func readCapped(p string, max int) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, int64(max)))
+}
+
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// cappedBuffer 只保留前 max 字节的输出，其余丢弃并标记截断
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string { return strings.ToValidUTF8(b.buf.String(), "�") }
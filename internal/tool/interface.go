@@ -45,3 +45,18 @@ type Tool interface {
 	Schema() Schema
 	Execute(ctx context.Context, input map[string]any) (ToolResult, error)
 }
+
+// SandboxSafe 可选接口：声明工具可在调试沙箱（debug-run）中真实执行——无副作用，或出站 HTTP 仅使用录制结果
+type SandboxSafe interface {
+	SandboxSafe() bool
+}
+
+// Categorized 可选接口：声明工具类别（如 network-write、payments、code-exec），供全局熔断按类别禁用
+type Categorized interface {
+	Categories() []string
+}
+
+// CapabilityRequired 可选接口：声明工具所需 capability，供 capability policy 校验（design/capability-policy.md）；未实现时使用工具名
+type CapabilityRequired interface {
+	RequiredCapability() string
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config Agent 级配置的只读访问器：由 Runtime 在工具执行前按 Agent 注入（含已解析的 secret 引用），
+// 同一工具可按 Agent 访问不同环境（如 staging/prod endpoint）。事件中只记录 key，不记录 value
+type Config struct {
+	values map[string]string
+}
+
+// NewConfig 由 key/value 构造 Config（拷贝 values）
+func NewConfig(values map[string]string) Config {
+	cp := make(map[string]string, len(values))
+	for k, v := range values {
+		cp[k] = v
+	}
+	return Config{values: cp}
+}
+
+type configKey struct{}
+
+// WithConfig 注入 Agent 配置；Runtime 在调用工具前调用
+func WithConfig(ctx context.Context, c Config) context.Context {
+	return context.WithValue(ctx, configKey{}, c)
+}
+
+// ConfigFromContext 取出当前 Agent 配置；未注入时返回空 Config（所有读取返回默认值）
+func ConfigFromContext(ctx context.Context) Config {
+	if ctx == nil {
+		return Config{}
+	}
+	c, _ := ctx.Value(configKey{}).(Config)
+	return c
+}
+
+// Get 返回 key 对应的原始值及是否存在
+func (c Config) Get(key string) (string, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// String 返回字符串值；不存在时返回 def
+func (c Config) String(key, def string) string {
+	if v, ok := c.values[key]; ok {
+		return v
+	}
+	return def
+}
+
+// Int 返回整数值；不存在或无法解析时返回 def
+func (c Config) Int(key string, def int) int {
+	if v, ok := c.values[key]; ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Bool 返回布尔值（true/false/1/0 等）；不存在或无法解析时返回 def
+func (c Config) Bool(key string, def bool) bool {
+	if v, ok := c.values[key]; ok {
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// Duration 返回时长（如 30s、5m）；不存在或无法解析时返回 def
+func (c Config) Duration(key string, def time.Duration) time.Duration {
+	if v, ok := c.values[key]; ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// Keys 返回全部 key（已排序）
+func (c Config) Keys() []string {
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// 自定义事件限制
+const (
+	// MaxEventTypeLength 事件类型最大长度
+	MaxEventTypeLength = 64
+	// MaxEventPayloadBytes payload 序列化为 JSON 后的最大字节数
+	MaxEventPayloadBytes = 16 << 10
+)
+
+// 自定义事件错误
+var (
+	// ErrEventsUnavailable 当前 ctx 未注入事件出口（不在 Job 执行中）
+	ErrEventsUnavailable = errors.New("events: not available")
+	// ErrInvalidEventType 事件类型为空、过长或不符合命名规则
+	ErrInvalidEventType = errors.New("events: invalid event type")
+	// ErrEventPayloadTooLarge payload 超过 MaxEventPayloadBytes
+	ErrEventPayloadTooLarge = errors.New("events: payload too large")
+)
+
+// eventTypePattern 小写字母开头，由小写字母、数字、下划线组成，可用 . 分段表示命名空间（如 billing.invoice_drafted）
+var eventTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+
+// EventEmitter 自定义业务事件出口；由 Runner 注入，写入 Job 事件流的 custom_event（不参与 Replay）
+type EventEmitter interface {
+	// EmitEvent eventType 与 payload 已通过校验，payload 为 JSON
+	EmitEvent(ctx context.Context, eventType string, payload json.RawMessage) error
+}
+
+type eventEmitterKey struct{}
+
+// WithEventEmitter 注入事件出口；Runtime 在执行 Step 前调用
+func WithEventEmitter(ctx context.Context, e EventEmitter) context.Context {
+	if e == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, eventEmitterKey{}, e)
+}
+
+// EventEmitterFromContext 取出事件出口；未注入时返回 nil
+func EventEmitterFromContext(ctx context.Context) EventEmitter {
+	if ctx == nil {
+		return nil
+	}
+	e, _ := ctx.Value(eventEmitterKey{}).(EventEmitter)
+	return e
+}
+
+// ValidateEventType 校验自定义事件类型
+func ValidateEventType(eventType string) error {
+	if len(eventType) == 0 || len(eventType) > MaxEventTypeLength || !eventTypePattern.MatchString(eventType) {
+		return fmt.Errorf("%w: %q", ErrInvalidEventType, eventType)
+	}
+	return nil
+}
+
+// EmitEvent 向当前 Job 事件流追加一条自定义业务事件（如 invoice_drafted），用于在 Trace、失败通知与事件导出中标记领域里程碑；
+// payload 可为任意可 JSON 序列化的值（nil 记为 null）。事件仅供观测，不参与 Replay：Replay 注入结果的步骤不会再次发出，
+// 失败后重试的步骤可能重复发出，消费方需按 step_id 容忍重复
+func EmitEvent(ctx context.Context, eventType string, payload any) error {
+	if err := ValidateEventType(eventType); err != nil {
+		return err
+	}
+	var raw json.RawMessage
+	switch p := payload.(type) {
+	case json.RawMessage:
+		if !json.Valid(p) {
+			return errors.New("events: payload is not valid JSON")
+		}
+		raw = p
+	default:
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("events: marshal payload: %w", err)
+		}
+		raw = b
+	}
+	if len(raw) > MaxEventPayloadBytes {
+		return fmt.Errorf("%w: %d bytes > %d", ErrEventPayloadTooLarge, len(raw), MaxEventPayloadBytes)
+	}
+	e := EventEmitterFromContext(ctx)
+	if e == nil {
+		return ErrEventsUnavailable
+	}
+	return e.EmitEvent(ctx, eventType, raw)
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"net/http"
+)
+
+type httpClientKey struct{}
+
+// WithHTTPClient 注入工具应使用的 HTTP 客户端；Runtime 在调用工具前调用（启用出网策略时该客户端按租户白名单限制目标）
+func WithHTTPClient(ctx context.Context, c *http.Client) context.Context {
+	return context.WithValue(ctx, httpClientKey{}, c)
+}
+
+// HTTPClient 返回 Runtime 注入的 HTTP 客户端；未注入时返回 http.DefaultClient。
+// 自定义工具应经此客户端访问外部服务，使租户出网策略生效
+func HTTPClient(ctx context.Context) *http.Client {
+	if ctx != nil {
+		if c, ok := ctx.Value(httpClientKey{}).(*http.Client); ok && c != nil {
+			return c
+		}
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MaxToolStateBytes 单次工具检查点 state 序列化为 JSON 后的最大字节数
+const MaxToolStateBytes = 256 << 10
+
+// 工具检查点错误
+var (
+	// ErrToolCheckpointUnavailable 当前 ctx 未注入检查点出口（不在 Job 的工具调用中，或事件存储不支持）
+	ErrToolCheckpointUnavailable = errors.New("tool checkpoint: not available")
+	// ErrToolStateTooLarge state 超过 MaxToolStateBytes
+	ErrToolStateTooLarge = errors.New("tool checkpoint: state too large")
+)
+
+// ToolCheckpointer 长时工具的步内检查点出口；由 Runtime 在工具调用前注入，写入 Job 事件流的 tool_progress_state
+type ToolCheckpointer interface {
+	// CheckpointToolState state 已序列化为 JSON 且未超限
+	CheckpointToolState(ctx context.Context, state json.RawMessage) error
+}
+
+type toolCheckpointerKey struct{}
+
+type resumedToolStateKey struct{}
+
+// WithToolCheckpointer 注入检查点出口；Runtime 在执行工具前调用
+func WithToolCheckpointer(ctx context.Context, c ToolCheckpointer) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, toolCheckpointerKey{}, c)
+}
+
+// ToolCheckpointerFromContext 取出检查点出口；未注入时返回 nil
+func ToolCheckpointerFromContext(ctx context.Context) ToolCheckpointer {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(toolCheckpointerKey{}).(ToolCheckpointer)
+	return c
+}
+
+// CheckpointToolState 持久化长时工具的内部进度（如已处理的分页游标、已上传的分片）；Worker 在工具执行中崩溃后，
+// 该次调用从最近一次 state 续跑而非重新开始：state 作为再入 state 传给工具，sdk 风格工具经 ResumedToolState 读取。
+// state 须可 JSON 序列化且不超过 MaxToolStateBytes；续跑时工具须自行保证已完成部分不重复产生副作用
+func CheckpointToolState(ctx context.Context, state any) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("tool checkpoint: marshal state: %w", err)
+	}
+	if len(raw) > MaxToolStateBytes {
+		return fmt.Errorf("%w: %d bytes > %d", ErrToolStateTooLarge, len(raw), MaxToolStateBytes)
+	}
+	c := ToolCheckpointerFromContext(ctx)
+	if c == nil {
+		return ErrToolCheckpointUnavailable
+	}
+	return c.CheckpointToolState(ctx, raw)
+}
+
+// WithResumedToolState 注入续跑时的工具 state（最近一次检查点）；Runtime 在崩溃恢复后再次调用工具前调用
+func WithResumedToolState(ctx context.Context, state json.RawMessage) context.Context {
+	if len(state) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, resumedToolStateKey{}, state)
+}
+
+// ResumedToolState 将最近一次检查点的 state 解码到 v；非续跑调用返回 false
+func ResumedToolState(ctx context.Context, v any) (bool, error) {
+	if ctx == nil {
+		return false, nil
+	}
+	raw, _ := ctx.Value(resumedToolStateKey{}).(json.RawMessage)
+	if len(raw) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("tool checkpoint: decode resumed state: %w", err)
+	}
+	return true, nil
+}
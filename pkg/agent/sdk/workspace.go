@@ -0,0 +1,101 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Workspace 错误
+var (
+	// ErrWorkspaceUnavailable 当前 ctx 未注入工作区（未启用 agent.workspace 或不在 Job 执行中）
+	ErrWorkspaceUnavailable = errors.New("workspace: not available")
+	// ErrWorkspaceQuotaExceeded 写入后将超过该 Job 工作区配额
+	ErrWorkspaceQuotaExceeded = errors.New("workspace: quota exceeded")
+	// ErrWorkspaceFileNotFound 文件不存在
+	ErrWorkspaceFileNotFound = errors.New("workspace: file not found")
+	// ErrWorkspaceInvalidPath 路径为空、绝对路径或跳出工作区
+	ErrWorkspaceInvalidPath = errors.New("workspace: invalid path")
+)
+
+// WorkspaceURIScheme 工作区文件作为 artifact 引用时的 URI scheme：workspace://<job_id>/<path>
+const WorkspaceURIScheme = "workspace://"
+
+// WorkspaceFile 工作区内文件信息
+type WorkspaceFile struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at,omitempty"`
+	URI        string    `json:"uri"` // workspace://<job_id>/<path>，可写入工具输出供后续步骤或 API 引用
+}
+
+// Workspace 单个 Job 的隔离工作区（本地磁盘或对象存储）：同一 Job 的各步骤经此传递文件，
+// 写入受配额限制，Job 终态后按保留期自动清理
+type Workspace interface {
+	JobID() string
+	// WriteFile 写入（覆盖）文件
+	WriteFile(ctx context.Context, path string, data []byte) (WorkspaceFile, error)
+	// Write 从 r 写入文件，读取量受剩余配额限制
+	Write(ctx context.Context, path string, r io.Reader) (WorkspaceFile, error)
+	ReadFile(ctx context.Context, path string) ([]byte, error)
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	Delete(ctx context.Context, path string) error
+	// List 列出全部文件（按路径排序）
+	List(ctx context.Context) ([]WorkspaceFile, error)
+	// Usage 已用字节数与配额（0 表示不限）
+	Usage(ctx context.Context) (used, quota int64, err error)
+}
+
+type workspaceKey struct{}
+
+// WithWorkspace 注入工作区；Runtime 在执行 Job 前调用
+func WithWorkspace(ctx context.Context, ws Workspace) context.Context {
+	if ws == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, workspaceKey{}, ws)
+}
+
+// WorkspaceFromContext 取出当前 Job 的工作区；未注入时返回 nil 与 ErrWorkspaceUnavailable
+func WorkspaceFromContext(ctx context.Context) (Workspace, error) {
+	if ctx != nil {
+		if ws, ok := ctx.Value(workspaceKey{}).(Workspace); ok {
+			return ws, nil
+		}
+	}
+	return nil, ErrWorkspaceUnavailable
+}
+
+// WorkspaceURI 返回工作区文件的 artifact 引用
+func WorkspaceURI(jobID, path string) string {
+	return WorkspaceURIScheme + jobID + "/" + strings.TrimPrefix(path, "/")
+}
+
+// ParseWorkspaceURI 解析 workspace://<job_id>/<path>
+func ParseWorkspaceURI(uri string) (jobID, path string, err error) {
+	rest, ok := strings.CutPrefix(uri, WorkspaceURIScheme)
+	if !ok {
+		return "", "", ErrWorkspaceInvalidPath
+	}
+	jobID, path, ok = strings.Cut(rest, "/")
+	if !ok || jobID == "" || path == "" {
+		return "", "", ErrWorkspaceInvalidPath
+	}
+	return jobID, path, nil
+}
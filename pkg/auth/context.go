@@ -24,6 +24,8 @@ const (
 	tenantIDKey contextKey = "auth.tenant_id"
 	userIDKey   contextKey = "auth.user_id"
 	roleKey     contextKey = "auth.role"
+	clientKey   contextKey = "auth.client"
+	requestKey  contextKey = "auth.request_id"
 )
 
 // WithTenantID 将 tenant_id 注入 context
@@ -64,3 +66,29 @@ func GetRole(ctx context.Context) Role {
 	}
 	return RoleUser // 默认 user 角色
 }
+
+// WithClient 将发起请求的客户端类型（cli / ui / sdk / api）注入 context
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey, client)
+}
+
+// GetClient 从 context 获取客户端类型
+func GetClient(ctx context.Context) string {
+	if v, ok := ctx.Value(clientKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// WithRequestID 将请求 ID 注入 context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestKey, requestID)
+}
+
+// GetRequestID 从 context 获取请求 ID
+func GetRequestID(ctx context.Context) string {
+	if v, ok := ctx.Value(requestKey).(string); ok {
+		return v
+	}
+	return ""
+}
@@ -29,7 +29,27 @@ const (
 	PermissionTraceView   Permission = "trace:view"
 	PermissionToolExecute Permission = "tool:execute"
 	PermissionAgentManage Permission = "agent:manage"
-	PermissionAuditView   Permission = "audit:view" // 查看审计日志
+	PermissionAuditView   Permission = "audit:view"  // 查看审计日志
+	PermissionJobClaim    Permission = "job:claim"   // Worker 认领 Job、续租
+	PermissionJobExecute  Permission = "job:execute" // Worker 执行 Job 并追加事件
+	// PermissionServiceAccountManage 管理 Worker 服务账号（签发、轮换、吊销令牌）
+	PermissionServiceAccountManage Permission = "service_account:manage"
+	// PermissionKillSwitchManage 开启/解除全局工具类别熔断（跨租户，仅管理员）
+	PermissionKillSwitchManage Permission = "killswitch:manage"
+	// PermissionResidencyManage 查看租户数据所在区域并在区域间迁移租户（跨租户，仅管理员）
+	PermissionResidencyManage Permission = "residency:manage"
+	// PermissionAnalyticsView 查看跨租户聚合使用指标（已做 k-匿名抑制，不含单个租户明细；仅管理员）
+	PermissionAnalyticsView Permission = "analytics:view"
+	// PermissionTracePayloadView 在 trace/replay/events 中查看完整 payload（工具输入输出、LLM 内容）；缺少时仅见结构，内容被遮蔽
+	PermissionTracePayloadView Permission = "trace:view_payload"
+	// PermissionWorkerInspect 查看 Worker 内部状态（当前认领、执行中的 step、租约、最近错误；跨租户）
+	PermissionWorkerInspect Permission = "worker:inspect"
+	// PermissionAPIDiagnose 查看 API 慢请求追踪（含路径与租户；跨租户）
+	PermissionAPIDiagnose Permission = "api:diagnose"
+	// PermissionDebugSessionManage 开启/关闭限时调试会话（break-glass），为指定用户临时开放某个 Job 的完整 payload（仅管理员）
+	PermissionDebugSessionManage Permission = "debug_session:manage"
+	// PermissionJobRollback 将 Job 游标回滚到此前的检查点，作废之后完成的步骤（仅管理员）
+	PermissionJobRollback Permission = "job:rollback"
 )
 
 // Role 角色
@@ -40,6 +60,8 @@ const (
 	RoleOperator Role = "operator" // 查看 + 导出 + 停止
 	RoleAuditor  Role = "auditor"  // 只读 + 导出 + 审计查看（不能创建/停止）
 	RoleUser     Role = "user"     // 基本操作（不能导出）
+	RoleWorker   Role = "worker"   // Worker 服务账号：仅认领与执行，无管理权限
+	RoleViewer   Role = "viewer"   // 受限查看：可见 Job 与 Trace 结构（步骤、耗时、状态），payload 内容被遮蔽
 )
 
 // RolePermissions 角色与权限映射
@@ -50,27 +72,51 @@ var RolePermissions = map[Role][]Permission{
 		PermissionJobStop,
 		PermissionJobExport,
 		PermissionTraceView,
+		PermissionTracePayloadView,
 		PermissionToolExecute,
 		PermissionAgentManage,
 		PermissionAuditView,
+		PermissionJobClaim,
+		PermissionJobExecute,
+		PermissionServiceAccountManage,
+		PermissionKillSwitchManage,
+		PermissionResidencyManage,
+		PermissionAnalyticsView,
+		PermissionWorkerInspect,
+		PermissionAPIDiagnose,
+		PermissionDebugSessionManage,
+		PermissionJobRollback,
 	},
 	RoleOperator: {
 		PermissionJobView,
 		PermissionJobStop,
 		PermissionJobExport,
 		PermissionTraceView,
+		PermissionTracePayloadView,
 		PermissionToolExecute,
+		PermissionWorkerInspect,
+		PermissionAPIDiagnose,
 	},
 	RoleAuditor: {
 		PermissionJobView,
 		PermissionJobExport,
 		PermissionTraceView,
+		PermissionTracePayloadView,
 		PermissionAuditView,
 	},
 	RoleUser: {
 		PermissionJobView,
 		PermissionJobCreate,
 		PermissionTraceView,
+		PermissionTracePayloadView,
+	},
+	RoleViewer: {
+		PermissionJobView,
+		PermissionTraceView,
+	},
+	RoleWorker: {
+		PermissionJobClaim,
+		PermissionJobExecute,
 	},
 }
 
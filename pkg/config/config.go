@@ -38,12 +38,131 @@ type Config struct {
 	Log             LogConfig             `mapstructure:"log"`
 	Monitoring      MonitoringConfig      `mapstructure:"monitoring"`
 	RateLimits      RateLimitsConfig      `mapstructure:"rate_limits"`
+	EventExport     EventExportConfig     `mapstructure:"event_export"`
+	PII             PIIConfig             `mapstructure:"pii"`
+	// EventSearch 事件全文检索：GET /api/search 按租户检索目标、工具输出、错误与推理快照
+	EventSearch EventSearchConfig `mapstructure:"event_search"`
+	// PayloadEncryption 目标/消息载荷级加密：API 写入前按租户加密，Worker 执行时解密，数据库只保存密文 + 明文哈希
+	PayloadEncryption PayloadEncryptionConfig `mapstructure:"payload_encryption"`
+	// Secrets Agent 配置中 secret_ref 的解析来源；API 与 Worker 须指向同一 provider
+	Secrets SecretsConfig `mapstructure:"secrets"`
+	// ID 新生成的 Job / 事件 / 工具调用 ID 的策略；API 与 Worker 应保持一致
+	ID IDConfig `mapstructure:"id"`
+	// Residency 租户数据驻留：按租户选择数据所在区域的 Postgres 集群与向量/元数据存储
+	Residency ResidencyConfig `mapstructure:"residency"`
+	// Analytics 跨租户聚合分析（GET /api/admin/analytics）的 k-匿名抑制阈值
+	Analytics AnalyticsConfig `mapstructure:"analytics"`
+	// WarehouseExport 周期将终态 Job 的规范化记录（jobs / steps / tool_calls / llm_calls）批量导出到对象存储，供数据仓库建外部表
+	WarehouseExport WarehouseExportConfig `mapstructure:"warehouse_export"`
+
+	sources []string // 加载时读取的 YAML 文件，供 Validate 定位行号
+}
+
+// AnalyticsConfig 跨租户聚合分析：分组覆盖的租户数、Job 数不足或单个租户占比过高时不输出该分组
+type AnalyticsConfig struct {
+	MinTenants     int     `mapstructure:"min_tenants"`      // k-匿名阈值，默认 5；窗口内租户数不足时整份报告被抑制
+	MinJobs        int     `mapstructure:"min_jobs"`         // 每个分组至少包含的 Job 数，默认 10
+	MaxTenantShare float64 `mapstructure:"max_tenant_share"` // 单个租户在分组中的 Job 数及计数占比上限（0~1），默认 0.5
+}
+
+// WarehouseExportConfig 数据仓库批量导出；按 (updated_at, job_id) 水位线增量导出，多副本通过 CAS 推进水位线
+type WarehouseExportConfig struct {
+	Enable         bool         `mapstructure:"enable"`
+	Format         string       `mapstructure:"format"`   // csv（默认）| parquet
+	Interval       string       `mapstructure:"interval"` // 导出周期，默认 15m
+	Storage        ObjectConfig `mapstructure:"storage"`
+	Prefix         string       `mapstructure:"prefix"`          // 对象键前缀，默认 "warehouse/"
+	BatchSize      int          `mapstructure:"batch_size"`      // 单批次最多导出的 Job 数，默认 500
+	SettleDelay    string       `mapstructure:"settle_delay"`    // 只导出结束超过该时长的 Job，默认 1m
+	BackfillWindow string       `mapstructure:"backfill_window"` // 首次导出回溯的时长，空为全部历史
+	Tenants        []string     `mapstructure:"tenants"`         // 非空时只导出这些租户
+	ExcludeTenants []string     `mapstructure:"exclude_tenants"` // 始终跳过的租户
+	SampleRate     float64      `mapstructure:"sample_rate"`     // 按 job_id 确定性采样比例 (0,1]，0 为全部导出
+}
+
+// ResidencyConfig 租户数据驻留配置。每个部署（API + Worker）归属 LocalRegion，
+// Postgres（jobstore / effect_store / checkpoint_store）使用该区域的 postgres_dsn；
+// 元数据与向量存储按请求租户路由到其所在区域；其他区域租户的 API 请求返回 421 与该区域 api_url
+type ResidencyConfig struct {
+	Enable        bool                    `mapstructure:"enable"`
+	LocalRegion   string                  `mapstructure:"local_region"`   // 本部署所在区域；空则不限制租户、不覆盖 DSN
+	DefaultRegion string                  `mapstructure:"default_region"` // 未在 tenants 中列出的租户所在区域
+	Regions       map[string]RegionConfig `mapstructure:"regions"`
+	Tenants       map[string]string       `mapstructure:"tenants"` // 租户 → 区域
+}
+
+// RegionConfig 单个区域的存储后端
+type RegionConfig struct {
+	PostgresDSN string         `mapstructure:"postgres_dsn"` // 该区域 Postgres 集群；为空则沿用各 store 自身的 dsn
+	Metadata    MetadataConfig `mapstructure:"metadata"`
+	Vector      VectorConfig   `mapstructure:"vector"`
+	APIURL      string         `mapstructure:"api_url"` // 该区域 API 地址，用于 421 响应引导客户端
+}
+
+// IDConfig ID 生成策略：uuid（默认）| ulid | ksuid；ulid/ksuid 按时间有序，改善主键索引局部性，已有 ID 不受影响
+type IDConfig struct {
+	Strategy string `mapstructure:"strategy"`
+}
+
+// SecretsConfig secret store 配置；Provider 为 env | memory | vault | k8s，空则 env
+type SecretsConfig struct {
+	Provider string            `mapstructure:"provider"`
+	Config   map[string]string `mapstructure:"config"` // provider 专属参数（如 vault 的 address/token/path_prefix）
+}
+
+// PayloadEncryptionConfig 载荷级加密配置；密钥为 base64 编码的 32 字节 AES-256 密钥，支持 "${ENV}" 从环境变量读取
+type PayloadEncryptionConfig struct {
+	Enable      bool              `mapstructure:"enable"`
+	Keys        map[string]string `mapstructure:"keys"`         // 租户 → 密钥
+	DefaultKey  string            `mapstructure:"default_key"`  // 未单独配置的租户使用；为空则这些租户不加密
+	RetiredKeys []string          `mapstructure:"retired_keys"` // 轮换后仅用于解密历史数据的旧密钥
+	Fields      []string          `mapstructure:"fields"`       // 加密的事件载荷顶层字段，空则 goal、message
+}
+
+// EventSearchConfig 事件全文检索：写入时抽取文本入索引（有 Postgres 时使用 job_search_docs 表，否则内存）；启用载荷加密时加密字段不入索引
+type EventSearchConfig struct {
+	Enable      bool `mapstructure:"enable"`
+	MaxDocBytes int  `mapstructure:"max_doc_bytes"` // 单条文档文本上限，0 为 16KiB
+}
+
+// PIIConfig 事件 PII 自动检测：写入时扫描工具输入/输出与 LLM 消息，按类别打标签（不保存原始匹配值）
+type PIIConfig struct {
+	Enable     bool     `mapstructure:"enable"`
+	Categories []string `mapstructure:"categories"`  // email | phone | credit_card，空则全部
+	EventTypes []string `mapstructure:"event_types"` // 扫描的事件类型，空则默认工具调用与推理类事件
+	// RedactExport 为 true 时证据导出（POST /api/jobs/:id/export）按标签字段自动脱敏
+	RedactExport bool   `mapstructure:"redact_export"`
+	RedactMode   string `mapstructure:"redact_mode"` // redact | hash | remove，空则 redact
+	RedactSalt   string `mapstructure:"redact_salt"` // hash 模式的 salt
+}
+
+// EventExportConfig 事件导出配置：将选定的 Job 事件经 outbox 以 at-least-once 语义发布到 Kafka/NATS
+type EventExportConfig struct {
+	Enable       bool     `mapstructure:"enable"`
+	Types        []string `mapstructure:"types"`         // 导出的事件类型，空则默认 job_completed/job_failed/tool_invocation_finished/state_changed/custom_event
+	Format       string   `mapstructure:"format"`        // json | avro，空则 json
+	Sink         string   `mapstructure:"sink"`          // kafka_rest（Kafka REST Proxy）| nats
+	Endpoint     string   `mapstructure:"endpoint"`      // 如 http://kafka-rest:8082 或 nats:4222
+	TopicPrefix  string   `mapstructure:"topic_prefix"`  // topic/subject 前缀，实际为 <prefix>.<event_type>；空则 "aetheris"
+	PollInterval string   `mapstructure:"poll_interval"` // outbox 轮询间隔，如 "1s"
+	BatchSize    int      `mapstructure:"batch_size"`    // 单次发布条数，<=0 时默认 100
+	MaxAttempts  int      `mapstructure:"max_attempts"`  // 单条最大投递次数，超过后标记 dead；<=0 时默认 10
+	Retention    string   `mapstructure:"retention"`     // outbox 中 delivered / dead 记录的保留时长，如 "72h"；空为 168h
 }
 
 // RuntimeConfig 运行时环境配置
 type RuntimeConfig struct {
 	Profile string `mapstructure:"profile"` // dev | prod
 	Strict  bool   `mapstructure:"strict"`  // true 时启用生产强校验门禁
+	// Replay Runner 每步之间从事件流重建 ReplayContext 的性能选项
+	Replay ReplayConfig `mapstructure:"replay"`
+}
+
+// ReplayConfig ReplayContext 构建选项；长 Job（上万事件）建议开启 incremental
+type ReplayConfig struct {
+	Incremental   bool `mapstructure:"incremental"`     // 按 job 缓存已构建状态，每步仅解析新增事件
+	MaxCachedJobs int  `mapstructure:"max_cached_jobs"` // 增量缓存的 job 数上限，<=0 时默认 1024
+	DecodeWorkers int  `mapstructure:"decode_workers"`  // 全量重建时并行解析事件 payload 的 goroutine 数，<=1 时串行
 }
 
 // RateLimitsConfig 限流配置（Tool + LLM）
@@ -64,19 +183,82 @@ type LLMRateLimitConfig struct {
 	TokensPerMinute   int     `mapstructure:"tokens_per_minute"`
 	RequestsPerMinute float64 `mapstructure:"requests_per_minute"`
 	MaxConcurrent     int     `mapstructure:"max_concurrent"`
+	PlanningShare     float64 `mapstructure:"planning_share"` // 规划调用独占的配额比例（0~1），其余归执行；0 表示规划与执行共用单桶
 }
 
 // JobStoreConfig 任务事件存储配置（事件流 + 租约）
 type JobStoreConfig struct {
-	Type          string `mapstructure:"type"`           // memory | postgres
-	DSN           string `mapstructure:"dsn"`            // Postgres 连接串，type=postgres 时必填
-	LeaseDuration string `mapstructure:"lease_duration"` // 租约时长，如 "30s"，空则默认 30s
+	Type          string       `mapstructure:"type"`           // memory | postgres
+	DSN           string       `mapstructure:"dsn"`            // Postgres 连接串，type=postgres 时必填
+	LeaseDuration string       `mapstructure:"lease_duration"` // 租约时长，如 "30s"，空则默认 30s
+	Pool          PGPoolConfig `mapstructure:"pool"`           // 各组件共享的连接池预算与健康检查
+	// Reconcile 事件/账本/检查点漂移对账（API 进程内周期执行）
+	Reconcile ReconcileConfig `mapstructure:"reconcile"`
+	// Archive 事件冷存储归档：热存储清理后 trace/replay/events 从对象存储冷读
+	Archive EventArchiveConfig `mapstructure:"archive"`
+	// Compression 事件载荷压缩（仅 postgres）
+	Compression EventCompressionConfig `mapstructure:"compression"`
+	// Resilience Postgres 短暂不可用时的重试与降级（仅 postgres）
+	Resilience PGResilienceConfig `mapstructure:"resilience"`
+}
+
+// PGResilienceConfig 事件与 Job 元数据存储的短暂错误重试与熔断；空字段取默认值
+type PGResilienceConfig struct {
+	Disable          bool   `mapstructure:"disable"`           // 关闭后数据库错误直接返回（旧行为）
+	MaxElapsed       string `mapstructure:"max_elapsed"`       // 单次操作重试总时长上限，空为 15s
+	InitialBackoff   string `mapstructure:"initial_backoff"`   // 首次重试等待，空为 200ms，之后翻倍
+	MaxBackoff       string `mapstructure:"max_backoff"`       // 单次等待上限，空为 2s
+	BreakerThreshold int    `mapstructure:"breaker_threshold"` // 连续短暂错误达到该次数进入降级（暂停认领），<=0 时默认 5
+	BreakerCooldown  string `mapstructure:"breaker_cooldown"`  // 降级期间认领探测间隔，空为 5s
+}
+
+// EventCompressionConfig 事件载荷压缩配置；读取始终按 payload_encoding 解压，关闭后历史压缩行仍可读
+type EventCompressionConfig struct {
+	Enable   bool   `mapstructure:"enable"`
+	MinBytes int    `mapstructure:"min_bytes"` // 仅压缩不小于该字节数的载荷，<=0 时默认 4096
+	Level    string `mapstructure:"level"`     // zstd 级别：fastest | default | better | best，空为 default
+}
+
+// EventArchiveConfig 事件归档冷读配置；须与写入归档的进程使用同一存储与前缀
+type EventArchiveConfig struct {
+	Enable          bool         `mapstructure:"enable"`
+	Storage         ObjectConfig `mapstructure:"storage"`
+	Prefix          string       `mapstructure:"prefix"`           // 对象键前缀，默认 "event-archive/"
+	CacheSize       int          `mapstructure:"cache_size"`       // 冷读缓存的 Job 数，0 时默认 64，<0 关闭缓存
+	CacheTTL        string       `mapstructure:"cache_ttl"`        // 冷读缓存保留时长，空为 10m
+	ExpectedLatency string       `mapstructure:"expected_latency"` // 冷读接口在响应中声明的预期延迟，空为 5s
+}
+
+// ReconcileConfig 漂移对账配置
+type ReconcileConfig struct {
+	Enable   bool   `mapstructure:"enable"`
+	Interval string `mapstructure:"interval"` // 对账间隔，如 "5m"，空则 5m
+	MaxJobs  int    `mapstructure:"max_jobs"` // 单轮最多对账的非终态 Job 数，<=0 时默认 1000
+}
+
+// PGPoolConfig Postgres 连接池管理配置：按组件分配连接预算，总量不超过 MaxTotalConns
+type PGPoolConfig struct {
+	MaxTotalConns       int            `mapstructure:"max_total_conns"`       // 单进程所有组件连接数上限，<=0 时默认 50
+	DefaultConns        int            `mapstructure:"default_conns"`         // 未在 budgets 中配置的组件默认预算，<=0 时默认 4
+	Budgets             map[string]int `mapstructure:"budgets"`               // 组件 -> 最大连接数，如 jobstore: 10
+	MaxConnIdleTime     string         `mapstructure:"max_conn_idle_time"`    // 空闲连接回收时间，如 "5m"
+	HealthCheckInterval string         `mapstructure:"health_check_interval"` // 健康检查与指标采样间隔，如 "15s"
 }
 
 // EffectStoreConfig 副作用存储配置
 type EffectStoreConfig struct {
 	Type string `mapstructure:"type"` // memory | postgres
 	DSN  string `mapstructure:"dsn"`  // Postgres 连接串，type=postgres 时必填
+	// LocalBuffer Worker 本地预写缓冲：effect 先落本地磁盘 WAL 即确认，再按序异步刷入 Postgres
+	LocalBuffer EffectLocalBufferConfig `mapstructure:"local_buffer"`
+}
+
+// EffectLocalBufferConfig Worker 本地 effect 缓冲配置
+type EffectLocalBufferConfig struct {
+	Enable        bool   `mapstructure:"enable"`
+	Dir           string `mapstructure:"dir"`            // 本地持久目录，默认 ./data/effect-buffer/<worker_id>
+	MaxPending    int    `mapstructure:"max_pending"`    // 未刷出记录上限，达到后 PutEffect 阻塞（背压），<=0 为 10000
+	FlushInterval string `mapstructure:"flush_interval"` // 失败重试与兜底轮询间隔，如 "200ms"
 }
 
 // CheckpointStoreConfig Checkpoint 存储配置
@@ -88,7 +270,338 @@ type CheckpointStoreConfig struct {
 // AgentConfig Agent 与 Job 调度相关配置
 type AgentConfig struct {
 	JobScheduler JobSchedulerConfig `mapstructure:"job_scheduler"`
-	ADK          AgentADKConfig     `mapstructure:"adk"` // Eino ADK 主 Runner（对话 run/resume/stream）
+	ADK          AgentADKConfig     `mapstructure:"adk"`        // Eino ADK 主 Runner（对话 run/resume/stream）
+	PlanCost     PlanCostConfig     `mapstructure:"plan_cost"`  // 工具成本/延迟标注与计划预算（成本感知规划、PlanGenerated 预估）
+	Reflection   ReflectionConfig   `mapstructure:"reflection"` // 自我反思：每 N 步或失败时复盘轨迹，提议写入 plan_evolution
+	JobDedup     JobDedupConfig     `mapstructure:"job_dedup"`  // 按目标哈希的服务端去重窗口
+	// Backpressure 消息受理背压：Pending 积压超过阈值时跳过可选工作、按租户优先级返回 429
+	Backpressure BackpressureConfig `mapstructure:"backpressure"`
+	// PlannerExemplars 规划 few-shot 示例库：按目标相似度选取示例注入 PlanGoal prompt，保留对照组统计计划有效率
+	PlannerExemplars PlannerExemplarsConfig `mapstructure:"planner_exemplars"`
+	// PlanRationale 节点规划理由：PlanGoal 要求 LLM 为每个节点说明为何选用、为何在此顺序，随 PlanGenerated 持久化
+	PlanRationale PlanRationaleConfig `mapstructure:"plan_rationale"`
+	// ETA Job 时长预测：按 Agent/计划形状滚动统计已完成 Job，GET /api/jobs/:id 与 Trace 页附带 ETA
+	ETA ETAConfig `mapstructure:"eta"`
+	// ExternalWorkers 外部语言 Worker（JSON-RPC over stdio）：进程提供的工具注册到工具表，步执行由 Go 宿主转发
+	ExternalWorkers []ExternalWorkerConfig `mapstructure:"external_workers"`
+	// NodePlugins 自定义节点类型（pkg/nodeplugin）：Manifest 目录，API 据此校验客户端提交的计划
+	NodePlugins NodePluginsConfig `mapstructure:"node_plugins"`
+	// ToolCategories 工具类别标注：类别 -> 工具名列表（如 payments: [stripe.charge]），与工具自身声明合并，供全局熔断按类别禁用
+	ToolCategories map[string][]string `mapstructure:"tool_categories"`
+	// IdempotentTools 幂等工具声明：工具名 -> 校验提示（可为空）；Replay 时此类工具无已记录结果可重新执行，重执行写入 replay_reexecuted 事件
+	IdempotentTools map[string]string `mapstructure:"idempotent_tools"`
+	// KillSwitch 全局工具熔断（POST /api/admin/killswitch）
+	KillSwitch KillSwitchConfig `mapstructure:"killswitch"`
+	// Scratchpad Job 内步骤共享暂存区（runtime.Scratchpad(ctx)）的大小限制
+	Scratchpad ScratchpadConfig `mapstructure:"scratchpad"`
+	// ToolOutputLimits 工具输出大小上限：超限输出写入 Job 工作区（需启用 workspace），结果与 LLM 步只看到摘要 + 引用
+	ToolOutputLimits ToolOutputLimitsConfig `mapstructure:"tool_output_limits"`
+	// ToolSemaphores 集群级命名信号量：限制对共享外部系统的并发调用（跨所有 Worker，经 jobstore 生效）
+	ToolSemaphores ToolSemaphoresConfig `mapstructure:"tool_semaphores"`
+	// Egress 按租户的出网白名单（域名 / CIDR），经注入的 HTTP 客户端在工具执行时生效，违规步骤以 policy_denied 失败
+	Egress EgressConfig `mapstructure:"egress"`
+	// Compat Worker/API 版本协商：新 Job 默认要求的特性
+	Compat CompatConfig `mapstructure:"compat"`
+	// Anomaly Agent 行为基线与异常检测（GET /api/observability/anomalies）
+	Anomaly AnomalyConfig `mapstructure:"anomaly"`
+	// Diagnosis Job 失败后的 LLM 诊断与失败 Webhook
+	Diagnosis DiagnosisConfig `mapstructure:"diagnosis"`
+	// WebTools 内置 web_search / web_fetch 工具（默认关闭）
+	WebTools WebToolsConfig `mapstructure:"web_tools"`
+	// CodeExec 内置 code_exec 工具：在容器 / WASM 沙箱中执行代码（默认关闭）
+	CodeExec CodeExecConfig `mapstructure:"code_exec"`
+	// CapabilityPolicy 工具执行前按 capability 的策略（design/capability-policy.md）
+	CapabilityPolicy CapabilityPolicyConfig `mapstructure:"capability_policy"`
+	// Workspace 每个 Job 的隔离文件工作区（sdk.WorkspaceFromContext、GET /api/jobs/:id/workspace）
+	Workspace WorkspaceConfig `mapstructure:"workspace"`
+	// PromptLog LLM prompt 留存：按租户采样或仅失败时留存，脱敏后写入对象存储，llm 事件只记录引用
+	PromptLog PromptLogConfig `mapstructure:"prompt_log"`
+	// ExecutionProfiles 命名执行档位：提交 Job 时按 profile 选择谨慎或快速执行（内置 conservative / aggressive）
+	ExecutionProfiles ExecutionProfilesConfig `mapstructure:"execution_profiles"`
+}
+
+// ExecutionProfilesConfig 执行档位；profiles 中与内置同名的档位整体覆盖内置定义。API 与 Worker 须使用相同配置
+type ExecutionProfilesConfig struct {
+	Default  string                            `mapstructure:"default"` // 未指定 profile 的 Job 使用的档位；空则不套用档位
+	Profiles map[string]ExecutionProfileConfig `mapstructure:"profiles"`
+}
+
+// ExecutionProfileConfig 单个执行档位
+type ExecutionProfileConfig struct {
+	Parallel           bool     `mapstructure:"parallel"`            // 同层节点并行执行（需 Job 协商 parallel_dag）；false 强制顺序
+	Prefetch           bool     `mapstructure:"prefetch"`            // 并行批次含 wait/join 节点时先执行其余就绪节点
+	StepTimeout        string   `mapstructure:"step_timeout"`        // 单步超时（如 "10m"），替代全局 step_timeout
+	ValidatePlan       bool     `mapstructure:"validate_plan"`       // 执行前严格校验计划，不通过则 Job 失败
+	ApprovalCategories []string `mapstructure:"approval_categories"` // 这些类别的工具执行前需审批（如 network-write、code-exec）
+}
+
+// PromptLogConfig LLM prompt 留存；API 与 Worker 须指向同一对象存储（API 经 GET /api/jobs/:id/nodes/:node_id/prompt 读取）
+type PromptLogConfig struct {
+	Enable            bool               `mapstructure:"enable"`
+	SampleRate        float64            `mapstructure:"sample_rate"`         // 成功调用的留存比例 0–1，默认 0（仅失败留存）
+	TenantSampleRates map[string]float64 `mapstructure:"tenant_sample_rates"` // 按租户覆盖 sample_rate
+	FailureOnly       bool               `mapstructure:"failure_only"`        // 只留存失败调用（忽略采样率）
+	Redact            PromptRedactConfig `mapstructure:"redact"`
+	Storage           ObjectConfig       `mapstructure:"storage"`
+	Prefix            string             `mapstructure:"prefix"`         // 对象键前缀，默认 "prompts/"
+	RetentionDays     int                `mapstructure:"retention_days"` // >0 时启动时为 prefix 设置生命周期过期规则
+}
+
+// PromptRedactConfig prompt 写入前的自动脱敏：PII 类别与自定义正则，命中内容替换为 [REDACTED:<规则>]
+type PromptRedactConfig struct {
+	PIICategories []string          `mapstructure:"pii_categories"` // email | phone | credit_card，空则全部
+	Patterns      map[string]string `mapstructure:"patterns"`       // 规则名 -> 正则（如 api_key: "sk-[A-Za-z0-9]{20,}"）
+}
+
+// WorkspaceConfig Job 工作区：本地磁盘或对象存储，按 Job 限额，终态后按保留期清理
+type WorkspaceConfig struct {
+	Enable  bool         `mapstructure:"enable"`
+	Backend string       `mapstructure:"backend"` // local（默认）| object
+	Root    string       `mapstructure:"root"`    // local 根目录，空为 $TMPDIR/aetheris-workspaces；API 与 Worker 分机部署时需共享
+	Storage ObjectConfig `mapstructure:"storage"` // backend=object 时的对象存储（通常 type: s3）
+	Prefix  string       `mapstructure:"prefix"`  // 对象键前缀，默认 "workspaces/"
+	// QuotaBytes 单个 Job 工作区总大小上限；0 为 100MiB，<0 不限
+	QuotaBytes int64 `mapstructure:"quota_bytes"`
+	// Retention Job 终态后保留时长，如 "24h"；空为 24h，"0s" 为下一轮清理即删除
+	Retention       string `mapstructure:"retention"`
+	CleanupInterval string `mapstructure:"cleanup_interval"` // 清理扫描间隔，空为 10m
+}
+
+// CapabilityPolicyConfig capability -> allow | require_approval | require_human | deny；未列出的使用 default。
+// 未配置且未启用 code_exec 时不做校验
+type CapabilityPolicyConfig struct {
+	Default      string            `mapstructure:"default"` // 空为 allow
+	Capabilities map[string]string `mapstructure:"capabilities"`
+}
+
+// CodeExecConfig code_exec 运行时与配额
+type CodeExecConfig struct {
+	Enable     bool   `mapstructure:"enable"`
+	Backend    string `mapstructure:"backend"`     // container（默认）| wasm
+	Binary     string `mapstructure:"binary"`      // container 为 docker（默认）/ podman，wasm 为 wasmtime（默认）
+	OCIRuntime string `mapstructure:"oci_runtime"` // 可选 --runtime：runsc（gVisor）、kata-fc（Firecracker）等
+	// Images 语言 -> 镜像（python、javascript），空为 python:3.12-slim / node:22-slim
+	Images map[string]string `mapstructure:"images"`
+	// WasmModules 语言 -> WASI 模块路径，backend=wasm 时必填
+	WasmModules      map[string]string `mapstructure:"wasm_modules"`
+	Timeout          string            `mapstructure:"timeout"`            // 单次执行超时，如 "30s"；空为 30s
+	CPUs             float64           `mapstructure:"cpus"`               // 容器 CPU 配额；<=0 为 1
+	MemoryMB         int               `mapstructure:"memory_mb"`          // 内存上限；<=0 为 256
+	PIDs             int               `mapstructure:"pids"`               // 容器进程数上限；<=0 为 64
+	Network          bool              `mapstructure:"network"`            // 允许网络访问；默认 false
+	MaxOutputBytes   int               `mapstructure:"max_output_bytes"`   // stdout/stderr 各自上限；<=0 为 64KiB
+	MaxArtifactBytes int               `mapstructure:"max_artifact_bytes"` // 单个产出文件返回内容上限；<=0 为 1MiB
+	MaxArtifacts     int               `mapstructure:"max_artifacts"`      // 产出文件数上限；<=0 为 16
+	// Capability 工具所需 capability，空为 code_exec；capability_policy 未配置该 capability 时默认 require_approval
+	Capability string `mapstructure:"capability"`
+}
+
+// WebToolsConfig 内置联网工具：search.backend 非空时注册 web_search，fetch.enable 时注册 web_fetch
+type WebToolsConfig struct {
+	Search WebSearchConfig `mapstructure:"search"`
+	Fetch  WebFetchConfig  `mapstructure:"fetch"`
+}
+
+// WebSearchConfig web_search 后端与限额
+type WebSearchConfig struct {
+	Backend    string  `mapstructure:"backend"`     // serpapi | bing | searxng；空则不注册
+	APIKey     string  `mapstructure:"api_key"`     // serpapi / bing 必填
+	Endpoint   string  `mapstructure:"endpoint"`    // 后端地址；searxng 必填，其余为空用官方地址
+	MaxResults int     `mapstructure:"max_results"` // 单次最多返回结果数；<=0 为 5
+	Timeout    string  `mapstructure:"timeout"`     // 后端请求超时，如 "15s"；空为 30s
+	QPS        float64 `mapstructure:"qps"`         // 进程内查询速率（次/秒）；<=0 不限流
+	Burst      int     `mapstructure:"burst"`       // 限流突发；<=0 取 qps（至少 1）
+	CacheTTL   string  `mapstructure:"cache_ttl"`   // 结果缓存时长，如 "15m"；空为 15m，"0s" 关闭
+	CacheSize  int     `mapstructure:"cache_size"`  // 缓存条目上限；<=0 为 256
+}
+
+// WebFetchConfig web_fetch 抓取限制
+type WebFetchConfig struct {
+	Enable    bool    `mapstructure:"enable"`
+	UserAgent string  `mapstructure:"user_agent"` // 请求与 robots.txt 匹配使用的 User-Agent；空为 aetheris/2.0
+	MaxBytes  int64   `mapstructure:"max_bytes"`  // 单页下载上限；<=0 为 2MiB
+	MaxChars  int     `mapstructure:"max_chars"`  // 返回 markdown 最大字符数；<=0 为 20000
+	Timeout   string  `mapstructure:"timeout"`    // 抓取超时，如 "20s"；空为 30s
+	QPS       float64 `mapstructure:"qps"`        // 进程内抓取速率（次/秒）；<=0 不限流
+	Burst     int     `mapstructure:"burst"`      // 限流突发；<=0 取 qps（至少 1）
+	CacheTTL  string  `mapstructure:"cache_ttl"`  // 按 URL 哈希缓存时长；空为 15m，"0s" 关闭
+	CacheSize int     `mapstructure:"cache_size"` // 缓存条目上限；<=0 为 256
+	// AllowPrivateNetworks 允许访问回环/内网地址（默认拒绝，防 SSRF）
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// AnomalyConfig 行为异常检测：按 Agent 学习常用工具、工具调用次数、成本与时长，偏离明显的 Job 记为异常
+type AnomalyConfig struct {
+	Enable       bool    `mapstructure:"enable"`
+	ScanInterval string  `mapstructure:"scan_interval"` // 扫描终态 Job 的间隔，如 "1m"，空则 1m
+	MinSamples   int     `mapstructure:"min_samples"`   // 基线至少学习的 Job 数，不足时不判定；<=0 为 10
+	Factor       float64 `mapstructure:"factor"`        // 调用次数/成本/时长超过基线均值的倍数；<=1 为 3
+	Sigma        float64 `mapstructure:"sigma"`         // 同时需超过均值 + sigma 倍标准差；<=0 为 3
+	Alpha        float64 `mapstructure:"alpha"`         // 指数滑动系数（0~1]，<=0 为 0.1
+	// DestructiveCategories 首次使用即为 high 异常的工具类别；空为 network-write、payments、code-exec
+	DestructiveCategories []string `mapstructure:"destructive_categories"`
+	// Webhook 可选告警出口；url 为空不投递
+	Webhook AnomalyWebhookConfig `mapstructure:"webhook"`
+}
+
+// AnomalyWebhookConfig 告警 Webhook（异常告警与失败通知共用）
+type AnomalyWebhookConfig struct {
+	URL     string `mapstructure:"url"`
+	Secret  string `mapstructure:"secret"`  // 非空时以 HMAC-SHA256 签名请求体（X-Aetheris-Signature）
+	Timeout string `mapstructure:"timeout"` // 单次投递超时，如 "5s"；空为 5s
+}
+
+// DiagnosisConfig Job 失败诊断：LLM 总结失败步骤输入、错误与最近事件，写入 job_diagnosis 事件
+type DiagnosisConfig struct {
+	Enable          bool   `mapstructure:"enable"`            // 启用 LLM 诊断；关闭时仍可单独配置 webhook 投递失败通知
+	ScanInterval    string `mapstructure:"scan_interval"`     // 扫描失败 Job 的间隔，如 "30s"，空则 30s
+	MaxEvents       int    `mapstructure:"max_events"`        // 提供给 LLM 的最近事件数；<=0 为 20
+	MaxPayloadChars int    `mapstructure:"max_payload_chars"` // 单个事件 payload 截断长度；<=0 为 2000
+	Timeout         string `mapstructure:"timeout"`           // 单次 LLM 调用超时，如 "30s"；空为 30s
+	// Webhook Job 失败通知（附诊断）；url 为空不投递
+	Webhook AnomalyWebhookConfig `mapstructure:"webhook"`
+}
+
+// CompatConfig 版本协商配置
+type CompatConfig struct {
+	// RequiredFeatures 所有新 Job 要求的特性（parallel_dag、recorded_http、scratchpad、review_gate）；
+	// 不可降级的特性无在线 Worker 支持时拒绝创建 Job（409），parallel_dag 则降级为顺序执行
+	RequiredFeatures []string `mapstructure:"required_features"`
+}
+
+// ToolOutputLimitsConfig 工具输出大小上限；API 与 Worker 须使用相同配置
+type ToolOutputLimitsConfig struct {
+	MaxBytes     int            `mapstructure:"max_bytes"`     // 所有工具的默认上限（字节），0 不限
+	SummaryBytes int            `mapstructure:"summary_bytes"` // 摘要保留的输出开头字节数，0 时默认 2048
+	Tools        map[string]int `mapstructure:"tools"`         // 工具名 -> 上限，覆盖 max_bytes；-1 表示该工具不限
+}
+
+// ToolSemaphoresConfig 集群级工具信号量；API 与 Worker 须使用相同配置
+type ToolSemaphoresConfig struct {
+	LeaseTTL     string                         `mapstructure:"lease_ttl"`     // 许可租约，持有者崩溃后最迟该时长释放；默认 60s
+	PollInterval string                         `mapstructure:"poll_interval"` // 排队重试间隔初值（指数退避至 2s）；默认 100ms
+	Semaphores   map[string]ToolSemaphoreConfig `mapstructure:"semaphores"`    // 信号量名 -> 上限与绑定的工具
+}
+
+// ToolSemaphoreConfig 单个命名信号量；Limit 覆盖工具清单中声明的同名上限
+type ToolSemaphoreConfig struct {
+	Limit   int      `mapstructure:"limit"`    // 全集群并发上限
+	Tools   []string `mapstructure:"tools"`    // 绑定的工具名，与工具清单中的 semaphores 声明合并
+	MaxWait string   `mapstructure:"max_wait"` // 排队超时（如 5m），超时后该步按可重试失败处理；空为一直等待
+}
+
+// EgressConfig 租户出网策略；启用后非可信租户仅能访问白名单目标，API 与 Worker 须使用相同配置
+type EgressConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// DefaultAllow 未在 tenants 中配置的租户的白名单；空即 deny-all
+	DefaultAllow []string                      `mapstructure:"default_allow"`
+	Tenants      map[string]EgressTenantConfig `mapstructure:"tenants"`
+}
+
+// EgressTenantConfig 单个租户的出网策略；规则为域名、*.域名（子域名）、IP 或 CIDR
+type EgressTenantConfig struct {
+	Trusted bool     `mapstructure:"trusted"` // 可信租户不受限制
+	Allow   []string `mapstructure:"allow"`
+}
+
+// ScratchpadConfig scratchpad 大小限制；0 使用默认（单值 64KiB、总计 1MiB、256 个 key）
+type ScratchpadConfig struct {
+	MaxValueBytes int `mapstructure:"max_value_bytes"`
+	MaxTotalBytes int `mapstructure:"max_total_bytes"`
+	MaxKeys       int `mapstructure:"max_keys"`
+}
+
+// KillSwitchConfig 全局工具熔断参数
+type KillSwitchConfig struct {
+	// RefreshInterval 执行侧刷新熔断状态的间隔（开关在各 Worker 上的最大生效延迟），如 "2s"；空为 2s
+	RefreshInterval string `mapstructure:"refresh_interval"`
+}
+
+// JobDedupConfig 目标去重：同 Agent、同租户在 window 内收到规范化后相同的目标时返回已有 Job（Idempotency-Key 之外的服务端兜底）
+type JobDedupConfig struct {
+	Window string `mapstructure:"window"` // 如 "10m"；空或 0 关闭
+}
+
+// BackpressureConfig 消息受理背压（POST /api/agents/:id/message）；阈值为 Pending Job 数，<=0 表示不启用该级别。需 jobstore.type=postgres
+type BackpressureConfig struct {
+	Enable          bool              `mapstructure:"enable"`
+	ShedBacklog     int               `mapstructure:"shed_backlog"`     // 积压达到后跳过可选工作（计划预览返回 429、不写 DecisionSnapshot）
+	ThrottleBacklog int               `mapstructure:"throttle_backlog"` // 积压达到后低优先级租户返回 429
+	RejectBacklog   int               `mapstructure:"reject_backlog"`   // 积压达到后仅高优先级租户可新建 Job
+	RetryAfter      string            `mapstructure:"retry_after"`      // 429 的 Retry-After，如 "30s"；空为 30s
+	SampleInterval  string            `mapstructure:"sample_interval"`  // 积压采样间隔，如 "5s"；空为 5s
+	TenantPriority  map[string]string `mapstructure:"tenant_priority"`  // 租户 → high | normal | low
+	DefaultPriority string            `mapstructure:"default_priority"` // 未列出的租户，空为 normal
+}
+
+// PlannerExemplarsConfig 规划示例注入参数（示例本身通过 /api/agents/:id/planner/exemplars 维护）
+type PlannerExemplarsConfig struct {
+	TopK         int     `mapstructure:"top_k"`         // 每次规划最多注入的示例数，<=0 为 3
+	ControlRatio float64 `mapstructure:"control_ratio"` // A/B 对照组比例（0~1），落入对照组的规划不注入示例；0 为全部注入
+}
+
+// PlanRationaleConfig 节点规划理由
+type PlanRationaleConfig struct {
+	Required bool `mapstructure:"required"` // 为 true 时有节点缺少理由则要求 LLM 补全一次；默认允许省略
+}
+
+// ETAConfig Job 时长预测配置
+type ETAConfig struct {
+	Enable        bool    `mapstructure:"enable"`
+	LearnInterval string  `mapstructure:"learn_interval"` // 扫描新完成 Job 的间隔，如 "1m"，空则 1m
+	Alpha         float64 `mapstructure:"alpha"`          // 指数滑动平均系数（0~1]，<=0 时默认 0.2
+}
+
+// NodePluginsConfig 自定义节点类型配置；插件代码经 cmd/worker 下的 build tag 文件编入 Worker
+type NodePluginsConfig struct {
+	// ManifestDir 目录下每个 *.json 为一个 Manifest 或 Manifest 数组；API 与 Worker 应指向同一份
+	ManifestDir string `mapstructure:"manifest_dir"`
+}
+
+// ExternalWorkerConfig 单个外部 Worker 进程（design/external-worker-protocol.md）
+type ExternalWorkerConfig struct {
+	Name           string            `mapstructure:"name"`
+	Command        string            `mapstructure:"command"`          // 如 python3
+	Args           []string          `mapstructure:"args"`             // 如 ["-m", "my_tools.worker"]
+	Env            map[string]string `mapstructure:"env"`              // 追加环境变量
+	Dir            string            `mapstructure:"dir"`              // 工作目录
+	StartTimeout   string            `mapstructure:"start_timeout"`    // initialize 握手超时，默认 10s
+	CallTimeout    string            `mapstructure:"call_timeout"`     // 单步执行超时，默认 60s
+	MaxOutputBytes int               `mapstructure:"max_output_bytes"` // 单步 output 上限，默认 1MiB
+}
+
+// ReflectionConfig 自我反思步配置；plan 中的 reflect 节点在 enable 时同样触发反思
+type ReflectionConfig struct {
+	Enable          bool   `mapstructure:"enable"`
+	EveryNSteps     int    `mapstructure:"every_n_steps"`     // >0 时每完成 N 步反思一次
+	OnFailure       bool   `mapstructure:"on_failure"`        // 步失败时反思
+	Policy          string `mapstructure:"policy"`            // auto_apply | require_approval（默认）
+	MaxSummaryChars int    `mapstructure:"max_summary_chars"` // 轨迹摘要上限，<=0 为 4000
+	MaxRevisions    int    `mapstructure:"max_revisions"`     // 单次运行最多自动应用的修订数，<=0 为 3
+}
+
+// PlanCostConfig 工具与 LLM 单次调用的成本/延迟标注，以及计划预算
+type PlanCostConfig struct {
+	Tools   map[string]CallCostConfig `mapstructure:"tools"`    // 工具名 -> 标注；覆盖工具自身声明
+	LLM     CallCostConfig            `mapstructure:"llm"`      // LLM 节点标注
+	MaxCost float64                   `mapstructure:"max_cost"` // 单个计划预估费用上限（USD），<=0 不限制
+	MaxETA  string                    `mapstructure:"max_eta"`  // 单个计划预估耗时上限，如 "2m"；空不限制
+	// Tenants 按租户的计划预算，与全局 max_cost / max_eta 合并取更严格者
+	Tenants map[string]PlanBudgetConfig `mapstructure:"tenants"`
+	// OnExceed Job 创建时计划超出预算的处理：reject（默认，Job 置为 failed 并返回 422）| approval（计划前插入审批节点，批准后才执行）
+	OnExceed string `mapstructure:"on_exceed"`
+}
+
+// PlanBudgetConfig 计划预算上限
+type PlanBudgetConfig struct {
+	MaxCost float64 `mapstructure:"max_cost"`
+	MaxETA  string  `mapstructure:"max_eta"`
+}
+
+// CallCostConfig 单次调用的预期延迟与费用
+type CallCostConfig struct {
+	ExpectedLatency string  `mapstructure:"expected_latency"` // 如 "1.5s"
+	CostPerCall     float64 `mapstructure:"cost_per_call"`    // USD
 }
 
 // AgentADKConfig ADK Runner 配置（主对话入口）
@@ -105,22 +618,136 @@ type JobSchedulerConfig struct {
 	RetryMax       int      `mapstructure:"retry_max"`       // 失败后最大重试次数（不含首次），<0 使用默认 2
 	Backoff        string   `mapstructure:"backoff"`         // 重试前等待时间，如 "1s"，空则默认 1s
 	Queues         []string `mapstructure:"queues"`          // 按优先级轮询的队列列表，如 ["realtime","default","background"]；空则不区分队列
+	// InteractiveLane 交互式对话 Job 的预留调度通道
+	InteractiveLane InteractiveLaneConfig `mapstructure:"interactive_lane"`
+	// FairShare 租户加权公平调度
+	FairShare FairShareConfig `mapstructure:"fair_share"`
+}
+
+// FairShareConfig 租户加权公平调度：按权重分配 Running 份额，避免单个租户的积压饿死其他租户
+type FairShareConfig struct {
+	Enable              bool                `mapstructure:"enable"`
+	DefaultWeight       float64             `mapstructure:"default_weight"`       // 未列出租户的权重，<=0 为 1
+	StarvationThreshold string              `mapstructure:"starvation_threshold"` // 其他租户仍被认领、自身有 Pending 却超过该时长未被认领视为饥饿，如 "5m"；空为 5m
+	Tenants             []TenantShareConfig `mapstructure:"tenants"`
+}
+
+// TenantShareConfig 单个租户的权重与最低份额；以列表配置以保留租户 ID 大小写
+type TenantShareConfig struct {
+	ID       string  `mapstructure:"id"`
+	Weight   float64 `mapstructure:"weight"`    // <=0 取 default_weight
+	MinShare float64 `mapstructure:"min_share"` // 最低份额（0~1，占全部 Running 的比例），有 Pending 且低于该份额时优先认领
+}
+
+// InteractiveLaneConfig 交互式 Job 预留通道：额外并发槽位只认领 interactive=true 的 Job，并使用更紧的 step 超时
+type InteractiveLaneConfig struct {
+	Reserved    int    `mapstructure:"reserved"`     // 额外预留的并发槽位（不占常规并发），0 使用默认 1，<0 关闭预留通道
+	StepTimeout string `mapstructure:"step_timeout"` // 交互式 Job 的单步超时，如 "30s"；空时默认 30s，与全局 step 超时取较小者
 }
 
 // APIConfig API 服务配置
 type APIConfig struct {
 	Port       int              `mapstructure:"port"`
 	Host       string           `mapstructure:"host"`
-	Timeout    string           `mapstructure:"timeout"`
+	Timeout    string           `mapstructure:"timeout"` // 请求截止时间：handler 内经 ctx 调用的 store / LLM 到期返回，响应 504；空或 "0s" 不限
 	CORS       CORSConfig       `mapstructure:"cors"`
 	Middleware MiddlewareConfig `mapstructure:"middleware"`
 	Forensics  ForensicsConfig  `mapstructure:"forensics"`
 	Grpc       GrpcConfig       `mapstructure:"grpc"`
+	I18n       I18nConfig       `mapstructure:"i18n"`
+	// TraceMasking 受限查看者（无 trace:view_payload 权限，如 viewer 角色）在 trace/replay/events 接口中的遮蔽策略
+	TraceMasking TraceMaskingConfig `mapstructure:"trace_masking"`
+	// InboundWebhooks 外部系统回调通道：POST /api/webhooks/inbound/{name} 验签后转为 Job signal/message
+	InboundWebhooks []InboundWebhookConfig `mapstructure:"inbound_webhooks"`
+	// AccessLog 结构化访问日志采样与慢请求捕获（GET /api/admin/slow-requests）
+	AccessLog AccessLogConfig `mapstructure:"access_log"`
+	// ReadCache trace / events / replay 的短期服务端缓存（按 job_id 与最新事件版本）；ETag / If-None-Match 始终生效
+	ReadCache ReadCacheConfig `mapstructure:"read_cache"`
+	// Timeouts 服务端连接超时与按路由覆盖的请求截止时间
+	Timeouts APITimeoutsConfig `mapstructure:"timeouts"`
+}
+
+// APITimeoutsConfig 服务端连接超时（未配置的项沿用 Hertz 默认）与按路由覆盖的 api.timeout
+type APITimeoutsConfig struct {
+	ReadTimeout  string `mapstructure:"read_timeout"`  // 读取请求的超时，空为 Hertz 默认 3m
+	WriteTimeout string `mapstructure:"write_timeout"` // 写响应的超时，空为不限；流式导出须留足时间
+	IdleTimeout  string `mapstructure:"idle_timeout"`  // keep-alive 空闲连接超时，空为 Hertz 默认 3m
+	// Routes 路由模板 → 截止时间，覆盖 api.timeout（如 "/api/jobs/export": "10m"）；"0s" 为该路由不设截止时间
+	Routes map[string]string `mapstructure:"routes"`
+}
+
+// ReadCacheConfig 只读 Job 视图缓存
+type ReadCacheConfig struct {
+	TTL        string `mapstructure:"ttl"`         // 缓存时长，空为 5s，"0s" 关闭服务端缓存
+	MaxEntries int    `mapstructure:"max_entries"` // 条目上限，<=0 为 1024
+}
+
+// AccessLogConfig API 访问日志；未配置时全部记录，按路由 p99（下限 500ms）自动判定慢请求
+type AccessLogConfig struct {
+	SampleRate     float64 `mapstructure:"sample_rate"`     // 成功请求日志采样率 (0,1]；5xx 与慢请求总是记录
+	SlowThreshold  string  `mapstructure:"slow_threshold"`  // 固定慢请求阈值，如 "2s"；空为按路由滚动分位数自动判定
+	SlowPercentile float64 `mapstructure:"slow_percentile"` // 自动阈值分位数，默认 0.99
+	SlowFloor      string  `mapstructure:"slow_floor"`      // 自动阈值下限，默认 "500ms"
+	SlowWindow     int     `mapstructure:"slow_window"`     // 每条路由保留的耗时样本数，默认 1000
+	SlowBuffer     int     `mapstructure:"slow_buffer"`     // 内存保留的最近慢请求条数，默认 200
+}
+
+// InboundWebhookConfig 一个入站 Webhook 通道
+type InboundWebhookConfig struct {
+	Name     string                      `mapstructure:"name"`
+	TenantID string                      `mapstructure:"tenant_id"` // 非空时只投递到该租户的 Job
+	Action   string                      `mapstructure:"action"`    // signal（默认）| message
+	Verify   InboundWebhookVerifyConfig  `mapstructure:"verify"`
+	Mapping  InboundWebhookMappingConfig `mapstructure:"mapping"`
+}
+
+// InboundWebhookVerifyConfig 通道验签；secret 支持 "${ENV}" 从环境变量读取
+type InboundWebhookVerifyConfig struct {
+	Type      string `mapstructure:"type"`      // hmac | jwt | token
+	Header    string `mapstructure:"header"`    // 空则 hmac 为 X-Signature（stripe 为 Stripe-Signature），jwt/token 为 Authorization
+	Secret    string `mapstructure:"secret"`    // HMAC 密钥 / JWT HS 密钥 / 静态 token
+	Algorithm string `mapstructure:"algorithm"` // hmac: sha256 | sha1 | sha512；jwt: HS256 | HS384 | HS512
+	Encoding  string `mapstructure:"encoding"`  // hmac 签名编码：hex（默认）| base64
+	Prefix    string `mapstructure:"prefix"`    // 签名值前缀，如 GitHub 的 "sha256="
+	Scheme    string `mapstructure:"scheme"`    // hmac 签名格式：空为直接对请求体签名，stripe 为 "t=..,v1=.."
+	Tolerance string `mapstructure:"tolerance"` // stripe 时间戳容差（空为 5m）/ jwt 时钟偏差，如 "1m"
+	Issuer    string `mapstructure:"issuer"`    // jwt 非空时校验 iss
+	Audience  string `mapstructure:"audience"`  // jwt 非空时校验 aud
+}
+
+// InboundWebhookMappingConfig 字段映射："$.a.b" 取 JSON 请求体字段，"header:Name"、"query:name"、"claim:name"（JWT），其他为字面量
+type InboundWebhookMappingConfig struct {
+	JobID          string `mapstructure:"job_id"`          // 空则 "$.job_id"
+	CorrelationKey string `mapstructure:"correlation_key"` // 空则 "$.correlation_key"；signal 必需
+	Channel        string `mapstructure:"channel"`         // message 的 channel，空则为通道名
+	MessageID      string `mapstructure:"message_id"`      // message 的 message_id，重复投递按幂等处理
+	Payload        string `mapstructure:"payload"`         // 空则整个请求体
+}
+
+// TraceMaskingConfig 视图级遮蔽：命中字段（任意嵌套层级）替换为 "[masked]"，步骤结构、耗时与状态保留
+type TraceMaskingConfig struct {
+	Fields []string `mapstructure:"fields"` // 遮蔽的 payload 字段名；空则使用默认（工具输入/输出与 LLM 内容），可加 "goal" 遮蔽 Job 目标
+}
+
+// I18nConfig 用户可见文案的语言：请求未带 Accept-Language（或无可用语言）时使用 default_locale
+type I18nConfig struct {
+	DefaultLocale string `mapstructure:"default_locale"` // en | zh 或 catalog_dir 中的语言，空则 en
+	CatalogDir    string `mapstructure:"catalog_dir"`    // 额外消息目录（<locale>.json），用于新增语言或覆盖内置文案
 }
 
 // ForensicsConfig 取证查询类接口配置
 type ForensicsConfig struct {
-	Experimental bool `mapstructure:"experimental"`
+	Experimental bool                  `mapstructure:"experimental"`
+	Evidence     EvidenceStorageConfig `mapstructure:"evidence"`
+}
+
+// EvidenceStorageConfig 证据包服务端存储：GET /api/jobs/:id/evidence 生成证据包写入对象存储并返回预签名 URL
+type EvidenceStorageConfig struct {
+	Enable        bool         `mapstructure:"enable"`
+	Storage       ObjectConfig `mapstructure:"storage"`        // 需支持预签名，通常 type: s3
+	Prefix        string       `mapstructure:"prefix"`         // 对象键前缀，默认 "evidence/"
+	URLExpiry     string       `mapstructure:"url_expiry"`     // 预签名 URL 有效期，默认 "15m"，最长 168h
+	RetentionDays int          `mapstructure:"retention_days"` // >0 时启动时为 prefix 设置生命周期过期规则
 }
 
 // GrpcConfig gRPC 服务配置
@@ -147,14 +774,49 @@ type MiddlewareConfig struct {
 
 // WorkerConfig Worker 服务配置
 type WorkerConfig struct {
-	Concurrency  int      `mapstructure:"concurrency"`
-	QueueSize    int      `mapstructure:"queue_size"`
-	RetryCount   int      `mapstructure:"retry_count"`
-	RetryDelay   string   `mapstructure:"retry_delay"`
-	Timeout      string   `mapstructure:"timeout"`
-	PollInterval string   `mapstructure:"poll_interval"` // Agent Job Claim 轮询间隔，如 "2s"
-	MaxAttempts  int      `mapstructure:"max_attempts"`  // Agent Job 最大执行次数（含首次），达此后标记 Failed 不再调度；<=0 时默认 3
-	Capabilities []string `mapstructure:"capabilities"`  // Worker 能力列表（如 llm, tool, rag）；Scheduler 仅派发 RequiredCapabilities 满足的 Job；空表示接受任意 Job
+	Concurrency  int                  `mapstructure:"concurrency"`
+	QueueSize    int                  `mapstructure:"queue_size"`
+	RetryCount   int                  `mapstructure:"retry_count"`
+	RetryDelay   string               `mapstructure:"retry_delay"`
+	Timeout      string               `mapstructure:"timeout"`
+	PollInterval string               `mapstructure:"poll_interval"` // Agent Job Claim 轮询间隔，如 "2s"
+	MaxAttempts  int                  `mapstructure:"max_attempts"`  // Agent Job 最大执行次数（含首次），达此后标记 Failed 不再调度；<=0 时默认 3
+	Capabilities []string             `mapstructure:"capabilities"`  // Worker 能力列表（如 llm, tool, rag）；Scheduler 仅派发 RequiredCapabilities 满足的 Job；空表示接受任意 Job
+	Throttle     WorkerThrottleConfig `mapstructure:"throttle"`      // 资源感知认领：主机负载或 LLM/Tool 并发超过阈值时暂停认领新 Job
+	// ServiceAccount Worker 服务账号：Worker 身份与事件归属，吊销后停止认领；进程内校验，不限制 DSN 的数据库权限
+	ServiceAccount WorkerServiceAccountConfig `mapstructure:"service_account"`
+	// InteractiveLane 交互式对话 Job 的预留认领通道，语义同 agent.job_scheduler.interactive_lane
+	InteractiveLane InteractiveLaneConfig `mapstructure:"interactive_lane"`
+	// FairShare 租户加权公平认领，语义同 agent.job_scheduler.fair_share；各 Worker 共享 jobs 表中的租户负载
+	FairShare FairShareConfig `mapstructure:"fair_share"`
+	// Inspect Worker 自省端点：当前认领、执行中的 step、租约剩余时间、最近错误与限流器占用（aetheris worker inspect）
+	Inspect WorkerInspectConfig `mapstructure:"inspect"`
+}
+
+// WorkerInspectConfig Worker 自省端点配置；AdvertiseURL 随状态心跳写入 worker_status，API 据此代理 GET /api/system/workers/:id/inspect
+type WorkerInspectConfig struct {
+	Enable       bool   `mapstructure:"enable"`
+	Addr         string `mapstructure:"addr"`          // 监听地址，如 ":9095"；空时默认 :9095，可用 AETHERIS_WORKER_INSPECT_ADDR 覆盖
+	AdvertiseURL string `mapstructure:"advertise_url"` // API 可访问的端点地址，如 "http://worker-1:9095/debug/inspect"；空时按主机名与端口推导
+	Token        string `mapstructure:"token"`         // 非空时要求 Authorization: Bearer <token>；API 代理时携带同一令牌
+}
+
+// WorkerServiceAccountConfig Worker 服务账号配置；Token 与 TokenFile 二选一，TokenFile 每次校验时重新读取以支持免重启轮换
+type WorkerServiceAccountConfig struct {
+	Token           string `mapstructure:"token"`
+	TokenFile       string `mapstructure:"token_file"`
+	Required        bool   `mapstructure:"required"`         // true 时未配置令牌或认证失败则拒绝启动
+	RefreshInterval string `mapstructure:"refresh_interval"` // 令牌重新校验间隔（感知轮换与吊销），如 "1m"；空时默认 1m
+}
+
+// WorkerThrottleConfig Worker 资源感知认领配置；阈值 <=0 表示不检查该项
+type WorkerThrottleConfig struct {
+	Enable           bool    `mapstructure:"enable"`
+	MaxCPUPercent    float64 `mapstructure:"max_cpu_percent"`    // 主机 CPU 使用率上限（0-100）
+	MaxMemoryPercent float64 `mapstructure:"max_memory_percent"` // 主机内存使用率上限（0-100）
+	MaxLLMInFlight   int     `mapstructure:"max_llm_in_flight"`  // 本 Worker 进行中的 LLM 调用数上限
+	MaxToolInFlight  int     `mapstructure:"max_tool_in_flight"` // 本 Worker 进行中的 Tool 调用数上限
+	SampleInterval   string  `mapstructure:"sample_interval"`    // 资源采样与状态心跳间隔，如 "5s"；空时默认 5s
 }
 
 // ModelConfig 模型配置
@@ -163,6 +825,43 @@ type ModelConfig struct {
 	Embedding EmbeddingConfig `mapstructure:"embedding"`
 	Vision    VisionConfig    `mapstructure:"vision"`
 	Defaults  DefaultsConfig  `mapstructure:"defaults"`
+	Fallback  FallbackConfig  `mapstructure:"fallback"`
+	Routing   RoutingConfig   `mapstructure:"routing"`
+	Preflight PreflightConfig `mapstructure:"preflight"`
+}
+
+// PreflightConfig 启动预检：校验 LLM Provider 凭证并预热 HTTP 连接池，结果经 /readyz 与 startup_report 日志输出
+type PreflightConfig struct {
+	Enable   bool   `mapstructure:"enable"`
+	Generate bool   `mapstructure:"generate"` // 凭证校验后额外执行一次极短生成（消耗少量 token），验证模型名与配额
+	Timeout  string `mapstructure:"timeout"`  // 单个模型检查超时，如 "10s"；空时默认 10s
+	Required bool   `mapstructure:"required"` // 预检failed时进程启动failed；默认仅 /readyz 返回 503
+}
+
+// RoutingConfig 按步骤的模型路由：llm 节点在 config 中声明 quality / max_latency / prefer 时，在候选模型中按声明属性与实测延迟选择
+type RoutingConfig struct {
+	Enable     bool                     `mapstructure:"enable"`
+	Candidates []RoutingCandidateConfig `mapstructure:"candidates"`
+	// PlannerQuality 规划调用固定要求的质量等级（low | standard | high）；空时默认 high，即规划使用最强模型。配置了 fallback.planner 时不生效
+	PlannerQuality string  `mapstructure:"planner_quality"`
+	LatencyAlpha   float64 `mapstructure:"latency_alpha"` // 实测延迟 EWMA 平滑系数，默认 0.2
+}
+
+// RoutingCandidateConfig 路由候选模型
+type RoutingCandidateConfig struct {
+	Model           string  `mapstructure:"model"`            // provider.model_key，如 "openai.gpt_4o_mini"
+	Quality         string  `mapstructure:"quality"`          // low | standard | high
+	Cost            float64 `mapstructure:"cost"`             // 相对成本（如每千 token 价格），仅用于候选间比较
+	ExpectedLatency string  `mapstructure:"expected_latency"` // 尚无实测数据时的预估延迟，如 "800ms"
+}
+
+// FallbackConfig 按用途的 LLM 故障切换链；链为空的用途使用 defaults.llm
+type FallbackConfig struct {
+	Planner        []string `mapstructure:"planner"`         // 规划调用的模型链，如 ["openai.gpt_4o", "claude.sonnet"]，首项为主模型
+	Generation     []string `mapstructure:"generation"`      // 生成调用（DAG llm 节点、RAG 生成等）的模型链
+	AttemptTimeout string   `mapstructure:"attempt_timeout"` // 单个模型单次调用超时，如 "30s"；空为不限制
+	UnhealthyBelow float64  `mapstructure:"unhealthy_below"` // 健康分低于该值的模型被跳过，默认 0.5
+	ProbeInterval  string   `mapstructure:"probe_interval"`  // 不健康模型的探测间隔，默认 30s
 }
 
 // LLMConfig LLM 模型配置
@@ -190,6 +889,7 @@ type ProviderConfig struct {
 // ModelInfo 模型信息
 type ModelInfo struct {
 	Name          string  `mapstructure:"name"`
+	Version       string  `mapstructure:"version"` // 可选的模型快照版本（如 "2024-08-06"），随 Job 执行环境固化
 	ContextWindow int     `mapstructure:"context_window"`
 	Temperature   float64 `mapstructure:"temperature"`
 	Dimension     int     `mapstructure:"dimension"`
@@ -206,17 +906,51 @@ type DefaultsConfig struct {
 
 // StorageConfig 存储配置
 type StorageConfig struct {
-	Metadata MetadataConfig `mapstructure:"metadata"`
-	Vector   VectorConfig   `mapstructure:"vector"`
-	Object   ObjectConfig   `mapstructure:"object"`
-	Cache    CacheConfig    `mapstructure:"cache"`
-	Ingest   IngestConfig   `mapstructure:"ingest"`
+	Metadata  MetadataConfig  `mapstructure:"metadata"`
+	Vector    VectorConfig    `mapstructure:"vector"`
+	Object    ObjectConfig    `mapstructure:"object"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Ingest    IngestConfig    `mapstructure:"ingest"`
+	Freshness FreshnessConfig `mapstructure:"freshness"`
+}
+
+// FreshnessConfig 知识库新鲜度：过期阈值、定时重新抓取与 RAG 回答过期提示
+type FreshnessConfig struct {
+	MaxAge          string                               `mapstructure:"max_age"`          // 默认过期阈值，如 "720h"；空表示不判定过期
+	RefreshInterval string                               `mapstructure:"refresh_interval"` // 默认重新抓取间隔（仅对带 source_uri 的文档），如 "24h"；空表示不刷新
+	ScanInterval    string                               `mapstructure:"scan_interval"`    // 刷新调度扫描间隔，如 "5m"；空时默认 5m
+	WarnOnAnswer    bool                                 `mapstructure:"warn_on_answer"`   // query_pipeline 回答引用过期文档时返回 freshness_warnings
+	Collections     map[string]CollectionFreshnessConfig `mapstructure:"collections"`      // 按集合覆盖
+}
+
+// CollectionFreshnessConfig 单集合新鲜度策略，空字段沿用默认值
+type CollectionFreshnessConfig struct {
+	MaxAge          string `mapstructure:"max_age"`
+	RefreshInterval string `mapstructure:"refresh_interval"`
 }
 
 // IngestConfig 入库管线配置（索引批大小、并发等）
 type IngestConfig struct {
-	BatchSize   int `mapstructure:"batch_size"`
-	Concurrency int `mapstructure:"concurrency"`
+	BatchSize   int               `mapstructure:"batch_size"`
+	Concurrency int               `mapstructure:"concurrency"`
+	Dedup       IngestDedupConfig `mapstructure:"dedup"`
+}
+
+// IngestDedupConfig 入库去重：同集合内容哈希完全相同的文档不再切分、向量化与入库；可选 SimHash 近似重复检测
+type IngestDedupConfig struct {
+	Enable        bool                                   `mapstructure:"enable"`
+	NearDuplicate bool                                   `mapstructure:"near_duplicate"` // 启用 SimHash 近似重复检测
+	Threshold     float64                                `mapstructure:"threshold"`      // 近似重复相似度阈值（0-1），默认 0.9
+	Action        string                                 `mapstructure:"action"`         // skip（默认，丢弃重复文档）| merge（记为已有文档的重复来源）
+	Collections   map[string]CollectionIngestDedupConfig `mapstructure:"collections"`    // 按集合覆盖
+}
+
+// CollectionIngestDedupConfig 单集合去重策略，未设置的字段沿用默认值
+type CollectionIngestDedupConfig struct {
+	Enable        *bool   `mapstructure:"enable"`
+	NearDuplicate *bool   `mapstructure:"near_duplicate"`
+	Threshold     float64 `mapstructure:"threshold"`
+	Action        string  `mapstructure:"action"`
 }
 
 // MetadataConfig 元数据存储配置
@@ -235,12 +969,15 @@ type VectorConfig struct {
 	Password   string `mapstructure:"password"`   // Redis 等后端密码，可选
 }
 
-// ObjectConfig 对象存储配置
+// ObjectConfig 对象存储配置（type: memory | s3；s3 兼容 AWS S3、MinIO 等）
 type ObjectConfig struct {
-	Type     string `mapstructure:"type"`
-	Endpoint string `mapstructure:"endpoint"`
-	Bucket   string `mapstructure:"bucket"`
-	Region   string `mapstructure:"region"`
+	Type         string `mapstructure:"type"`
+	Endpoint     string `mapstructure:"endpoint"`
+	Bucket       string `mapstructure:"bucket"`
+	Region       string `mapstructure:"region"`
+	AccessKey    string `mapstructure:"access_key"`
+	SecretKey    string `mapstructure:"secret_key"`
+	UsePathStyle bool   `mapstructure:"use_path_style"` // MinIO 等需 path-style URL
 }
 
 // CacheConfig 缓存配置
@@ -297,10 +1034,46 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := replaceEnvVars(&config); err != nil {
 		return nil, err
 	}
+	if err := applyResidency(&config); err != nil {
+		return nil, err
+	}
+	config.sources = []string{configPath}
 
 	return &config, nil
 }
 
+// applyResidency 校验驻留配置；设置 local_region 时以该区域的 postgres_dsn 覆盖 jobstore / effect_store / checkpoint_store 的 dsn
+func applyResidency(config *Config) error {
+	r := config.Residency
+	if !r.Enable {
+		return nil
+	}
+	if len(r.Regions) == 0 {
+		return fmt.Errorf("residency.regions 不能为空")
+	}
+	if _, ok := r.Regions[r.DefaultRegion]; !ok {
+		return fmt.Errorf("residency.default_region %q 未在 regions 中定义", r.DefaultRegion)
+	}
+	for tenant, region := range r.Tenants {
+		if _, ok := r.Regions[region]; !ok {
+			return fmt.Errorf("residency.tenants.%s 指向未定义的区域 %q", tenant, region)
+		}
+	}
+	if r.LocalRegion == "" {
+		return nil
+	}
+	local, ok := r.Regions[r.LocalRegion]
+	if !ok {
+		return fmt.Errorf("residency.local_region %q 未在 regions 中定义", r.LocalRegion)
+	}
+	if local.PostgresDSN != "" {
+		config.JobStore.DSN = local.PostgresDSN
+		config.EffectStore.DSN = local.PostgresDSN
+		config.CheckpointStore.DSN = local.PostgresDSN
+	}
+	return nil
+}
+
 // replaceEnvVars 替换配置中的环境变量
 func replaceEnvVars(config *Config) error {
 	// 替换模型 API Key
@@ -351,6 +1124,7 @@ func LoadAPIConfigWithModel() (*Config, error) {
 	modelCfg, err := LoadConfig("configs/model.yaml")
 	if err == nil {
 		cfg.Model = modelCfg.Model
+		cfg.sources = append(cfg.sources, modelCfg.sources...)
 	}
 	return cfg, nil
 }
@@ -374,6 +1148,7 @@ func LoadWorkerConfigWithModel() (*Config, error) {
 	modelCfg, err := LoadConfig(modelPath)
 	if err == nil {
 		cfg.Model = modelCfg.Model
+		cfg.sources = append(cfg.sources, modelCfg.sources...)
 	} else {
 		log.Printf("[config] 未加载 model 配置 %q，Worker 将无 LLM 配置: %v", modelPath, err)
 	}
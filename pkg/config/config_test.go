@@ -47,3 +47,50 @@ log:
 		t.Errorf("Log.Level: got %q", cfg.Log.Level)
 	}
 }
+
+func TestLoadConfig_ResidencyLocalRegion(t *testing.T) {
+	dir := t.TempDir()
+	yaml := `
+jobstore:
+  type: postgres
+  dsn: "postgres://default/aetheris"
+residency:
+  enable: true
+  local_region: eu
+  default_region: us
+  regions:
+    us:
+      postgres_dsn: "postgres://us/aetheris"
+    eu:
+      postgres_dsn: "postgres://eu/aetheris"
+      api_url: "https://eu.example.com"
+  tenants:
+    acme: eu
+`
+	path := filepath.Join(dir, "residency.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatalf("write temp config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.JobStore.DSN != "postgres://eu/aetheris" || cfg.EffectStore.DSN != "postgres://eu/aetheris" {
+		t.Errorf("local region dsn not applied: jobstore=%q effect=%q", cfg.JobStore.DSN, cfg.EffectStore.DSN)
+	}
+	if cfg.Residency.Tenants["acme"] != "eu" {
+		t.Errorf("tenants: got %v", cfg.Residency.Tenants)
+	}
+}
+
+func TestApplyResidency_UnknownRegion(t *testing.T) {
+	cfg := &Config{Residency: ResidencyConfig{
+		Enable:        true,
+		DefaultRegion: "us",
+		Regions:       map[string]RegionConfig{"us": {}},
+		Tenants:       map[string]string{"acme": "eu"},
+	}}
+	if err := applyResidency(cfg); err == nil {
+		t.Fatal("expected error for tenant mapped to undefined region")
+	}
+}
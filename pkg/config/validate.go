@@ -0,0 +1,535 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Component 校验目标进程，决定组件专属的交叉校验
+type Component string
+
+const (
+	ComponentAPI    Component = "api"
+	ComponentWorker Component = "worker"
+)
+
+// Severity 问题级别；仅 error 阻止启动
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue 一条配置问题；Path 为 YAML 中的点分路径，File/Line 为定位到的源文件与行号（未定位时为空）
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+
+	segs []string
+}
+
+func (i Issue) String() string {
+	loc := i.Path
+	if i.File != "" && i.Line > 0 {
+		loc = fmt.Sprintf("%s:%d %s", i.File, i.Line, i.Path)
+	}
+	return fmt.Sprintf("[%s] %s: %s", i.Severity, loc, i.Message)
+}
+
+// Report 校验结果
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// Errors 返回 error 级别的问题
+func (r *Report) Errors() []Issue { return r.filter(SeverityError) }
+
+// Warnings 返回 warning 级别的问题
+func (r *Report) Warnings() []Issue { return r.filter(SeverityWarning) }
+
+func (r *Report) filter(s Severity) []Issue {
+	var out []Issue
+	for _, i := range r.Issues {
+		if i.Severity == s {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Err 存在 error 级别问题时返回 *ValidationError，否则 nil
+func (r *Report) Err() error {
+	if errs := r.Errors(); len(errs) > 0 {
+		return &ValidationError{Issues: errs}
+	}
+	return nil
+}
+
+// ValidationError 启动前配置校验failed，逐条列出问题与位置
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "配置校验failed（%d 项）:", len(e.Issues))
+	for _, i := range e.Issues {
+		b.WriteString("\n  ")
+		b.WriteString(i.String())
+	}
+	return b.String()
+}
+
+// SourceFiles 返回加载该配置所读取的 YAML 文件（按加载顺序）
+func (c *Config) SourceFiles() []string {
+	return c.sources
+}
+
+// Validate 校验已加载的配置：源文件结构（未知字段、类型）、时长格式、存储类型与组件相关的交叉约束。
+// 问题路径会尽量定位到 SourceFiles 中的行号；不做连通性探测
+func Validate(cfg *Config, component Component) *Report {
+	r := &Report{}
+	if cfg == nil {
+		return r
+	}
+	for _, f := range cfg.sources {
+		issues, err := CheckFile(f)
+		if err != nil {
+			r.add(SeverityError, []string{}, "无法读取配置文件 %s: %v", f, err)
+			continue
+		}
+		r.Issues = append(r.Issues, issues...)
+	}
+	checkDurations(r, reflect.ValueOf(*cfg), nil)
+	checkStores(r, cfg, component)
+	checkLease(r, cfg)
+	checkWarehouseExport(r, cfg)
+	checkAPITimeouts(r, cfg)
+	if cfg.API.Port < 0 || cfg.API.Port > 65535 {
+		r.add(SeverityError, []string{"api", "port"}, "端口 %d 超出范围 1-65535", cfg.API.Port)
+	}
+	r.Locate(cfg.sources)
+	return r
+}
+
+func (r *Report) add(sev Severity, segs []string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{
+		Severity: sev,
+		Path:     strings.Join(segs, "."),
+		Message:  fmt.Sprintf(format, args...),
+		segs:     segs,
+	})
+}
+
+// checkStores 存储类型取值、postgres 必须带 dsn；prod/strict 要求三类存储均为 postgres，Worker 要求 jobstore 为 postgres
+func checkStores(r *Report, cfg *Config, component Component) {
+	prod := cfg.Runtime.Profile == "prod" || cfg.Runtime.Strict
+	switch cfg.Runtime.Profile {
+	case "", "dev", "prod":
+	default:
+		r.add(SeverityError, []string{"runtime", "profile"}, "未知取值 %q，应为 dev 或 prod", cfg.Runtime.Profile)
+	}
+	stores := []struct {
+		key      string
+		typ, dsn string
+		mustBePG bool
+	}{
+		{"jobstore", cfg.JobStore.Type, cfg.JobStore.DSN, prod || component == ComponentWorker},
+		{"effect_store", cfg.EffectStore.Type, cfg.EffectStore.DSN, prod},
+		{"checkpoint_store", cfg.CheckpointStore.Type, cfg.CheckpointStore.DSN, prod},
+	}
+	for _, s := range stores {
+		switch s.typ {
+		case "", "memory", "postgres":
+		default:
+			r.add(SeverityError, []string{s.key, "type"}, "未知取值 %q，应为 memory 或 postgres", s.typ)
+			continue
+		}
+		if s.typ == "postgres" && s.dsn == "" {
+			r.add(SeverityError, []string{s.key, "dsn"}, "type=postgres 时 dsn 必填")
+			continue
+		}
+		if s.mustBePG && s.typ != "postgres" {
+			why := "runtime.profile=prod / runtime.strict 要求 postgres"
+			if component == ComponentWorker && s.key == "jobstore" {
+				why = "Worker 通过 postgres 认领 Job，要求 type=postgres"
+			}
+			r.add(SeverityError, []string{s.key, "type"}, "当前为 %q；%s", orDefault(s.typ, "memory"), why)
+		}
+	}
+}
+
+// checkLease 租约时长须短于单步超时：租约到期前 Worker 须能完成或续租当前 step，否则持有者崩溃后恢复慢于超时判定
+func checkLease(r *Report, cfg *Config) {
+	lease := 30 * time.Second
+	if d, err := time.ParseDuration(cfg.JobStore.LeaseDuration); err == nil && d > 0 {
+		lease = d
+	}
+	check := func(segs []string, v string) {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return
+		}
+		if lease >= d {
+			r.add(SeverityError, segs, "单步超时 %s 不大于 jobstore.lease_duration %s；租约须短于单步超时", d, lease)
+		}
+	}
+	check([]string{"worker", "timeout"}, cfg.Worker.Timeout)
+	for name, p := range cfg.Agent.ExecutionProfiles.Profiles {
+		check([]string{"agent", "execution_profiles", "profiles", name, "step_timeout"}, p.StepTimeout)
+	}
+}
+
+// checkWarehouseExport 导出格式与采样比例取值
+func checkWarehouseExport(r *Report, cfg *Config) {
+	w := cfg.WarehouseExport
+	if !w.Enable {
+		return
+	}
+	switch w.Format {
+	case "", "csv", "parquet":
+	default:
+		r.add(SeverityError, []string{"warehouse_export", "format"}, "未知取值 %q，应为 csv 或 parquet", w.Format)
+	}
+	if w.SampleRate < 0 || w.SampleRate > 1 {
+		r.add(SeverityError, []string{"warehouse_export", "sample_rate"}, "取值 %v 超出范围，应在 0~1 之间", w.SampleRate)
+	}
+}
+
+// checkAPITimeouts api.timeouts.routes 的键须为以 / 开头的路由模板，值须为时长
+func checkAPITimeouts(r *Report, cfg *Config) {
+	for route, v := range cfg.API.Timeouts.Routes {
+		segs := []string{"api", "timeouts", "routes", route}
+		if !strings.HasPrefix(route, "/") {
+			r.add(SeverityError, segs, "路由 %q 应为以 / 开头的路由模板，如 /api/jobs/:id/export", route)
+		}
+		if _, err := time.ParseDuration(v); err != nil && !isEnvRef(v) {
+			r.add(SeverityError, segs, "无效的时长 %q（示例：500ms、30s、5m、1h）", v)
+		}
+	}
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// durationKeys 取值为 Go 时长字符串的字段名（及 _ 后缀）
+var durationKeys = []string{"timeout", "interval", "ttl", "duration", "delay", "backoff", "cooldown", "latency", "idle_time", "window", "max_age", "max_elapsed", "retention"}
+
+func isDurationKey(key string) bool {
+	for _, k := range durationKeys {
+		if key == k || strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEnvRef(s string) bool {
+	return strings.HasPrefix(s, "$")
+}
+
+// checkDurations 遍历配置结构，校验时长类字符串字段可被 time.ParseDuration 解析
+func checkDurations(r *Report, v reflect.Value, segs []string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			checkDurations(r, v.Elem(), segs)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, squash := fieldKey(f)
+			if name == "-" {
+				continue
+			}
+			fv := v.Field(i)
+			if squash {
+				checkDurations(r, fv, segs)
+				continue
+			}
+			p := appendSeg(segs, name)
+			if fv.Kind() == reflect.String {
+				s := fv.String()
+				if s == "" || isEnvRef(s) || !isDurationKey(name) {
+					continue
+				}
+				if _, err := time.ParseDuration(s); err != nil {
+					r.add(SeverityError, p, "无效的时长 %q（示例：500ms、30s、5m、1h）", s)
+				}
+				continue
+			}
+			checkDurations(r, fv, p)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			checkDurations(r, v.MapIndex(k), appendSeg(segs, fmt.Sprint(k.Interface())))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			checkDurations(r, v.Index(i), appendSeg(segs, strconv.Itoa(i)))
+		}
+	}
+}
+
+func appendSeg(segs []string, s string) []string {
+	out := make([]string, len(segs), len(segs)+1)
+	copy(out, segs)
+	return append(out, s)
+}
+
+// fieldKey 返回字段的 mapstructure 键（小写）及是否 squash
+func fieldKey(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("mapstructure")
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name), strings.Contains(opts, "squash")
+}
+
+// CheckFile 按 Config 结构检查 YAML 源文件：未知字段为 warning（拼写错误会被静默忽略），类型不符为 error；问题带行号
+func CheckFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []Issue{{Severity: SeverityError, File: path, Line: yamlErrorLine(err), Message: "YAML 语法错误: " + err.Error()}}, nil
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	var issues []Issue
+	checkNode(root.Content[0], reflect.TypeOf(Config{}), nil, path, &issues)
+	return issues, nil
+}
+
+// yamlErrorLine 从 "yaml: line N: ..." 中取行号
+func yamlErrorLine(err error) int {
+	msg := err.Error()
+	if i := strings.Index(msg, "line "); i >= 0 {
+		rest := msg[i+len("line "):]
+		if j := strings.IndexByte(rest, ':'); j > 0 {
+			if n, err := strconv.Atoi(rest[:j]); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+func checkNode(n *yaml.Node, t reflect.Type, segs []string, file string, issues *[]Issue) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	bad := func(format string, args ...interface{}) {
+		*issues = append(*issues, Issue{
+			Severity: SeverityError,
+			Path:     strings.Join(segs, "."),
+			File:     file,
+			Line:     n.Line,
+			Message:  fmt.Sprintf(format, args...),
+			segs:     segs,
+		})
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			bad("应为对象（key: value），实际为 %s", nodeKind(n))
+			return
+		}
+		fields := structFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Value == "<<" {
+				checkNode(v, t, segs, file, issues)
+				continue
+			}
+			p := appendSeg(segs, k.Value)
+			ft, ok := fields[strings.ToLower(k.Value)]
+			if !ok {
+				*issues = append(*issues, Issue{
+					Severity: SeverityWarning,
+					Path:     strings.Join(p, "."),
+					File:     file,
+					Line:     k.Line,
+					Message:  "未知配置项，将被忽略（检查拼写或缩进）",
+					segs:     p,
+				})
+				continue
+			}
+			checkNode(v, ft, p, file, issues)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			bad("应为映射（key: value），实际为 %s", nodeKind(n))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			checkNode(n.Content[i+1], t.Elem(), appendSeg(segs, n.Content[i].Value), file, issues)
+		}
+	case reflect.Slice, reflect.Array:
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				checkNode(item, t.Elem(), appendSeg(segs, strconv.Itoa(i)), file, issues)
+			}
+		case yaml.ScalarNode:
+			// viper 将逗号分隔的字符串解码为切片
+		default:
+			bad("应为列表，实际为 %s", nodeKind(n))
+		}
+	case reflect.Interface:
+	default:
+		if n.Kind != yaml.ScalarNode {
+			bad("应为单个值，实际为 %s", nodeKind(n))
+			return
+		}
+		if isEnvRef(n.Value) {
+			return
+		}
+		var err error
+		switch t.Kind() {
+		case reflect.Bool:
+			_, err = strconv.ParseBool(n.Value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			_, err = strconv.ParseInt(n.Value, 0, 64)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			_, err = strconv.ParseUint(n.Value, 0, 64)
+		case reflect.Float32, reflect.Float64:
+			_, err = strconv.ParseFloat(n.Value, 64)
+		}
+		if err != nil {
+			bad("无法解析为 %s: %q", t.Kind(), n.Value)
+		}
+	}
+}
+
+func structFields(t reflect.Type) map[string]reflect.Type {
+	out := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, squash := fieldKey(f)
+		if name == "-" {
+			continue
+		}
+		if squash {
+			for k, v := range structFields(f.Type) {
+				out[k] = v
+			}
+			continue
+		}
+		out[name] = f.Type
+	}
+	return out
+}
+
+func nodeKind(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "对象"
+	case yaml.SequenceNode:
+		return "列表"
+	default:
+		return fmt.Sprintf("%q", n.Value)
+	}
+}
+
+// Locate 为未定位的问题在源文件中查找对应键的行号；按文件顺序取第一个命中
+func (r *Report) Locate(files []string) {
+	var roots []*yaml.Node
+	var names []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var root yaml.Node
+		if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+			continue
+		}
+		roots = append(roots, root.Content[0])
+		names = append(names, f)
+	}
+	for i := range r.Issues {
+		is := &r.Issues[i]
+		if is.Line > 0 || is.Path == "" {
+			continue
+		}
+		segs := is.segs
+		if segs == nil {
+			segs = strings.Split(is.Path, ".")
+		}
+		for j, root := range roots {
+			if line := findLine(root, segs); line > 0 {
+				is.File, is.Line = names[j], line
+				break
+			}
+		}
+	}
+}
+
+// findLine 返回路径最深处可定位节点的行号；路径首段即不存在时返回 0
+func findLine(n *yaml.Node, segs []string) int {
+	line := 0
+	for _, s := range segs {
+		if n.Kind != yaml.MappingNode {
+			return line
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if strings.EqualFold(n.Content[i].Value, s) {
+				line = n.Content[i].Line
+				next = n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return line
+		}
+		n = next
+	}
+	return line
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadTestConfig(t *testing.T, yaml string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatalf("write temp config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+func findIssue(r *Report, path string) *Issue {
+	for i := range r.Issues {
+		if r.Issues[i].Path == path {
+			return &r.Issues[i]
+		}
+	}
+	return nil
+}
+
+func TestValidate_Valid(t *testing.T) {
+	cfg := loadTestConfig(t, `
+api:
+  port: 8080
+  timeout: 30s
+jobstore:
+  type: postgres
+  dsn: "postgres://localhost/aetheris"
+  lease_duration: "30s"
+worker:
+  timeout: 5m
+`)
+	r := Validate(cfg, ComponentWorker)
+	if len(r.Issues) != 0 {
+		t.Fatalf("unexpected issues: %v", r.Issues)
+	}
+	if r.Err() != nil {
+		t.Fatalf("Err: %v", r.Err())
+	}
+}
+
+func TestValidate_ReportsPathsAndLines(t *testing.T) {
+	cfg := loadTestConfig(t, `
+api:
+  port: 8080
+  timout: 30s
+jobstore:
+  type: memory
+  lease_duration: "2m"
+worker:
+  timeout: 1m
+  poll_interval: "2 seconds"
+`)
+	r := Validate(cfg, ComponentWorker)
+
+	typo := findIssue(r, "api.timout")
+	if typo == nil || typo.Severity != SeverityWarning || typo.Line != 4 {
+		t.Fatalf("unknown key: %+v", typo)
+	}
+	poll := findIssue(r, "worker.poll_interval")
+	if poll == nil || poll.Severity != SeverityError || poll.Line != 10 {
+		t.Fatalf("bad duration: %+v", poll)
+	}
+	lease := findIssue(r, "worker.timeout")
+	if lease == nil || lease.Severity != SeverityError || lease.Line != 9 {
+		t.Fatalf("lease vs step timeout: %+v", lease)
+	}
+	store := findIssue(r, "jobstore.type")
+	if store == nil || store.Line != 6 || !strings.Contains(store.Message, "Worker") {
+		t.Fatalf("worker jobstore: %+v", store)
+	}
+	if len(r.Warnings()) != 1 || len(r.Errors()) != 3 {
+		t.Fatalf("issues: %v", r.Issues)
+	}
+	var verr *ValidationError
+	if err := r.Err(); !errors.As(err, &verr) || len(verr.Issues) != 3 {
+		t.Fatalf("Err: %v", err)
+	}
+	if !strings.Contains(r.Err().Error(), "test.yaml:10 worker.poll_interval") {
+		t.Fatalf("error message lacks location: %v", r.Err())
+	}
+}
+
+func TestValidate_ProductionRequiresPostgres(t *testing.T) {
+	cfg := loadTestConfig(t, `
+runtime:
+  profile: prod
+jobstore:
+  type: postgres
+  dsn: "postgres://localhost/aetheris"
+effect_store:
+  type: postgres
+checkpoint_store:
+  type: memory
+`)
+	r := Validate(cfg, ComponentAPI)
+	if is := findIssue(r, "effect_store.dsn"); is == nil || is.Severity != SeverityError {
+		t.Fatalf("effect_store dsn: %v", r.Issues)
+	}
+	if is := findIssue(r, "checkpoint_store.type"); is == nil || is.Line != 10 {
+		t.Fatalf("checkpoint_store type: %v", r.Issues)
+	}
+	if findIssue(r, "jobstore.type") != nil {
+		t.Fatalf("jobstore should pass: %v", r.Issues)
+	}
+}
+
+func TestValidate_APITimeouts(t *testing.T) {
+	cfg := loadTestConfig(t, `
+api:
+  timeout: 30s
+  timeouts:
+    read_timeout: 1 minute
+    idle_timeout: 2m
+    routes:
+      "/api/jobs/export": 10m
+      "/api/jobs/:id/export": forever
+      "api/sessions/:id/export": 5m
+`)
+	r := Validate(cfg, ComponentAPI)
+	for _, path := range []string{"api.timeouts.read_timeout", "api.timeouts.routes./api/jobs/:id/export", "api.timeouts.routes.api/sessions/:id/export"} {
+		if is := findIssue(r, path); is == nil || is.Severity != SeverityError {
+			t.Errorf("%s: %v", path, r.Issues)
+		}
+	}
+	for _, path := range []string{"api.timeouts.idle_timeout", "api.timeouts.routes./api/jobs/export"} {
+		if findIssue(r, path) != nil {
+			t.Errorf("%s should pass: %v", path, r.Issues)
+		}
+	}
+}
+
+func TestValidate_WarehouseExport(t *testing.T) {
+	cfg := loadTestConfig(t, `
+warehouse_export:
+  enable: true
+  format: orc
+  settle_delay: 1 minute
+  backfill_window: 720h
+  sample_rate: 1.5
+`)
+	r := Validate(cfg, ComponentAPI)
+	for _, path := range []string{"warehouse_export.format", "warehouse_export.settle_delay", "warehouse_export.sample_rate"} {
+		if is := findIssue(r, path); is == nil || is.Severity != SeverityError {
+			t.Errorf("%s: %v", path, r.Issues)
+		}
+	}
+	if findIssue(r, "warehouse_export.backfill_window") != nil {
+		t.Errorf("backfill_window should pass: %v", r.Issues)
+	}
+}
+
+func TestCheckFile_TypeMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.yaml")
+	yaml := `
+api:
+  port: "eighty"
+  cors: true
+jobstore:
+  pool:
+    budgets:
+      jobstore: ten
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatalf("write temp config: %v", err)
+	}
+	issues, err := CheckFile(path)
+	if err != nil {
+		t.Fatalf("CheckFile: %v", err)
+	}
+	want := map[string]int{"api.port": 3, "api.cors": 4, "jobstore.pool.budgets.jobstore": 8}
+	if len(issues) != len(want) {
+		t.Fatalf("issues: %v", issues)
+	}
+	for _, is := range issues {
+		if line, ok := want[is.Path]; !ok || is.Line != line || is.Severity != SeverityError {
+			t.Errorf("unexpected issue: %+v", is)
+		}
+	}
+}
@@ -146,6 +146,25 @@ func (b *Builder) parseEvidence(evidenceMap map[string]interface{}) Evidence {
 		}
 	}
 
+	// 解析 citations（RAG 回答引用的片段），每个片段作为 rag_doc 证据节点
+	if raw, ok := evidenceMap["citations"]; ok {
+		evidence.Citations = parseCitations(raw)
+		for _, c := range evidence.Citations {
+			meta := map[string]any{"score": c.Score, "rank": c.Rank}
+			if c.DocumentID != "" {
+				meta["document_id"] = c.DocumentID
+			}
+			if c.Offset != nil {
+				meta["offset"] = c.Offset
+			}
+			evidence.Nodes = append(evidence.Nodes, EvidenceNode{
+				Type:     EvidenceTypeRAGDoc,
+				ID:       c.ChunkID,
+				Metadata: meta,
+			})
+		}
+	}
+
 	// 解析 tool_invocation_ids
 	if toolInvs, ok := evidenceMap["tool_invocation_ids"].([]interface{}); ok {
 		for _, invID := range toolInvs {
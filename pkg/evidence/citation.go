@@ -0,0 +1,122 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Citation 回答引用的检索片段（RAG 生成时由 Generator 记录，写入 step 的 evidence.citations）
+type Citation struct {
+	ChunkID    string        `json:"chunk_id"`
+	DocumentID string        `json:"document_id,omitempty"`
+	Collection string        `json:"collection,omitempty"`
+	Score      float64       `json:"score"`
+	Rank       int           `json:"rank"` // 检索结果中的名次，从 1 开始
+	Offset     *CitationSpan `json:"offset,omitempty"`
+}
+
+// CitationSpan 片段在原文档中的字节区间 [Start, End)；切片时无法定位原文时为空
+type CitationSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// StepCitations 单个 step 的引用
+type StepCitations struct {
+	StepID    string     `json:"step_id"`
+	NodeID    string     `json:"node_id"`
+	Citations []Citation `json:"citations"`
+}
+
+// CitationCollector 收集一次 step 执行中产生的引用；并发安全
+type CitationCollector struct {
+	mu    sync.Mutex
+	items []Citation
+}
+
+type citationCollectorKey struct{}
+
+// WithCitationCollector 在 ctx 上挂载新的引用收集器，供执行器在工具执行后取出
+func WithCitationCollector(ctx context.Context) (context.Context, *CitationCollector) {
+	c := &CitationCollector{}
+	return context.WithValue(ctx, citationCollectorKey{}, c), c
+}
+
+// RecordCitations 向 ctx 中的收集器追加引用；ctx 无收集器时忽略（非 Agent step 调用 Generator）
+func RecordCitations(ctx context.Context, citations ...Citation) {
+	if len(citations) == 0 {
+		return
+	}
+	c, _ := ctx.Value(citationCollectorKey{}).(*CitationCollector)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.items = append(c.items, citations...)
+	c.mu.Unlock()
+}
+
+// Citations 返回已收集的引用副本
+func (c *CitationCollector) Citations() []Citation {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.items) == 0 {
+		return nil
+	}
+	out := make([]Citation, len(c.items))
+	copy(out, c.items)
+	return out
+}
+
+// CitationsFromEvents 从 reasoning_snapshot 事件中提取各 step 的引用（按事件顺序）
+func CitationsFromEvents(events []Event) []StepCitations {
+	out := []StepCitations{}
+	for _, e := range events {
+		if e.Type != "reasoning_snapshot" && e.Type != "reasoning_snapshot_recorded" {
+			continue
+		}
+		var payload struct {
+			StepID   string `json:"step_id"`
+			NodeID   string `json:"node_id"`
+			Evidence struct {
+				Citations []Citation `json:"citations"`
+			} `json:"evidence"`
+		}
+		if err := json.Unmarshal(e.Payload, &payload); err != nil || len(payload.Evidence.Citations) == 0 {
+			continue
+		}
+		out = append(out, StepCitations{StepID: payload.StepID, NodeID: payload.NodeID, Citations: payload.Evidence.Citations})
+	}
+	return out
+}
+
+// parseCitations 解析 evidence.citations（map 形式，来自 JSON 反序列化）
+func parseCitations(raw interface{}) []Citation {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var out []Citation
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCitationCollector_RecordAndRead(t *testing.T) {
+	// 无收集器时忽略
+	RecordCitations(context.Background(), Citation{ChunkID: "ignored"})
+
+	ctx, c := WithCitationCollector(context.Background())
+	RecordCitations(ctx, Citation{ChunkID: "c1", Rank: 1}, Citation{ChunkID: "c2", Rank: 2})
+	got := c.Citations()
+	if len(got) != 2 || got[0].ChunkID != "c1" || got[1].ChunkID != "c2" {
+		t.Fatalf("Citations() = %+v", got)
+	}
+	got[0].ChunkID = "mutated"
+	if c.Citations()[0].ChunkID != "c1" {
+		t.Error("Citations() should return a copy")
+	}
+}
+
+func TestCitationsFromEvents_AndEvidenceGraph(t *testing.T) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"step_id": "step-1",
+		"node_id": "generate",
+		"evidence": map[string]interface{}{
+			"tool_invocation_ids": []string{"inv-1"},
+			"citations": []Citation{
+				{ChunkID: "c1", DocumentID: "doc-1", Score: 0.82, Rank: 1, Offset: &CitationSpan{Start: 0, End: 120}},
+				{ChunkID: "c2", DocumentID: "doc-2", Score: 0.61, Rank: 2},
+			},
+		},
+	})
+	events := []Event{
+		{ID: "1", JobID: "job-1", Type: "job_created", Payload: []byte(`{}`), CreatedAt: time.Now()},
+		{ID: "2", JobID: "job-1", Type: "reasoning_snapshot", Payload: payload, CreatedAt: time.Now()},
+	}
+
+	steps := CitationsFromEvents(events)
+	if len(steps) != 1 || steps[0].StepID != "step-1" || len(steps[0].Citations) != 2 {
+		t.Fatalf("CitationsFromEvents = %+v", steps)
+	}
+	if off := steps[0].Citations[0].Offset; off == nil || off.End != 120 {
+		t.Errorf("offset not preserved: %+v", off)
+	}
+
+	graph, err := NewBuilder().BuildFromEvents(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ragDocs int
+	for _, n := range graph.Nodes[0].Evidence.Nodes {
+		if n.Type == EvidenceTypeRAGDoc {
+			ragDocs++
+		}
+	}
+	if ragDocs != 2 {
+		t.Errorf("rag_doc evidence nodes = %d, want 2", ragDocs)
+	}
+}
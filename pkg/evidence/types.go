@@ -45,6 +45,7 @@ type Evidence struct {
 	InputKeys   []string             `json:"input_keys,omitempty"`   // 读取的 state keys（因果依赖）
 	OutputKeys  []string             `json:"output_keys,omitempty"`  // 写入的 state keys（因果依赖）
 	LLMDecision *LLMDecisionEvidence `json:"llm_decision,omitempty"` // LLM 决策详情
+	Citations   []Citation           `json:"citations,omitempty"`    // RAG 回答引用的检索片段
 }
 
 // LLMDecisionEvidence LLM 决策证据
@@ -26,6 +26,8 @@ type QueryRequest struct {
 	EventFilter  []string  `json:"event_filter"` // ["approve", "payment"]
 	AgentFilter  []string  `json:"agent_filter"`
 	StatusFilter []string  `json:"status_filter"`
+	UserFilter   []string  `json:"user_filter"` // 发起 Job 的 user_id
+	RequestID    string    `json:"request_id"`  // 发起 Job 的原始请求 ID
 	Limit        int       `json:"limit"`
 	Offset       int       `json:"offset"`
 }
@@ -53,6 +55,10 @@ type JobSummary struct {
 	EventCount int       `json:"event_count"`
 	ToolCalls  []string  `json:"tool_calls"` // 调用过的 tools
 	KeyEvents  []string  `json:"key_events"` // 关键事件类型
+	// 发起归属：创建 Job 的用户、客户端与原始请求 ID
+	UserID    string `json:"user_id,omitempty"`
+	Client    string `json:"client,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ConsistencyReport 一致性检查报告
@@ -0,0 +1,259 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n 用户可见文案的消息目录：API 错误信息、CLI 输出与 Trace 页面标签按消息 key 查表，
+// 语言由 Accept-Language（API）或 --lang / AETHERIS_LANG（CLI）选择。内置 en 与 zh 目录（locales/*.json），
+// 其他语言可通过 LoadDir 从目录加载 <locale>.json 或在代码中 Register。
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// English 英文（默认语言，也是所有 key 的最终回退）
+	English = "en"
+	// Chinese 简体中文
+	Chinese = "zh"
+)
+
+//go:embed locales/*.json
+var embeddedLocales embed.FS
+
+var (
+	mu            sync.RWMutex
+	catalogs      = make(map[string]map[string]string)
+	defaultLocale = English
+)
+
+func init() {
+	entries, err := embeddedLocales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		data, err := embeddedLocales.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: 解析内置目录 %s failed: %v", e.Name(), err))
+		}
+		Register(strings.TrimSuffix(e.Name(), ".json"), messages)
+	}
+}
+
+// Register 合并 locale 的消息（同 key 覆盖）；locale 按 Normalize 规整，如 zh-CN 与 zh_cn 视为同一语言
+func Register(locale string, messages map[string]string) {
+	locale = Normalize(locale)
+	if locale == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	m := catalogs[locale]
+	if m == nil {
+		m = make(map[string]string, len(messages))
+		catalogs[locale] = m
+	}
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// LoadDir 从目录加载 <locale>.json 消息文件（扁平 key → 文案），用于新增语言或覆盖内置文案
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("i18n: 解析 %s failed: %w", f, err)
+		}
+		Register(strings.TrimSuffix(filepath.Base(f), ".json"), messages)
+	}
+	return nil
+}
+
+// SetDefaultLocale 设置未协商出可用语言时使用的语言；locale 无目录时忽略
+func SetDefaultLocale(locale string) {
+	locale = Normalize(locale)
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := catalogs[locale]; ok {
+		defaultLocale = locale
+	}
+}
+
+// DefaultLocale 当前默认语言
+func DefaultLocale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// Locales 已注册的语言，按字母序
+func Locales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Normalize 规整语言标签：小写、下划线换为连字符，去掉编码后缀（如 zh_CN.UTF-8 → zh-cn）
+func Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+}
+
+// Match 返回 tag 对应的已注册语言：先精确匹配，再匹配主语言（zh-cn → zh）；无则返回空串
+func Match(tag string) string {
+	tag = Normalize(tag)
+	if tag == "" {
+		return ""
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		if _, ok := catalogs[tag[:i]]; ok {
+			return tag[:i]
+		}
+	}
+	return ""
+}
+
+// Negotiate 按 Accept-Language（含 q 权重）选择已注册语言；无可用语言时返回默认语言
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var cands []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			cands = append(cands, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].q > cands[j].q })
+	for _, c := range cands {
+		if l := Match(c.tag); l != "" {
+			return l
+		}
+	}
+	return DefaultLocale()
+}
+
+type localeKey struct{}
+
+// WithLocale 将语言写入 context，供 T 使用
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext 从 context 读取语言；未设置时返回默认语言
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if l, ok := ctx.Value(localeKey{}).(string); ok && l != "" {
+			return l
+		}
+	}
+	return DefaultLocale()
+}
+
+// T 按 context 中的语言翻译 key；args 非空时按 fmt 格式化
+func T(ctx context.Context, key string, args ...interface{}) string {
+	return Translate(FromContext(ctx), key, args...)
+}
+
+// Translate 翻译 key：依次查找 locale、其主语言、默认语言与英文目录，均无则返回 key 本身
+func Translate(locale, key string, args ...interface{}) string {
+	msg, ok := lookup(locale, key)
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+func lookup(locale, key string) (string, bool) {
+	locale = Normalize(locale)
+	mu.RLock()
+	defer mu.RUnlock()
+	chain := []string{locale}
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		chain = append(chain, locale[:i])
+	}
+	chain = append(chain, defaultLocale, English)
+	for _, l := range chain {
+		if msg, ok := catalogs[l][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// Keys 返回 locale 目录中的全部 key（不含回退），供校验各语言目录完整性
+func Keys(locale string) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	m := catalogs[Normalize(locale)]
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", English},
+		{"zh", Chinese},
+		{"zh-CN,zh;q=0.9,en;q=0.8", Chinese},
+		{"en-US,en;q=0.9,zh;q=0.8", English},
+		{"fr-FR, zh;q=0.5, en;q=0.3", Chinese},
+		{"en;q=0.2, zh_TW;q=0.7", Chinese},
+		{"fr, de;q=0.5", English},
+		{"zh;q=0, en", English},
+		{"*", English},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.header); got != tc.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]string{
+		"zh_CN.UTF-8": Chinese,
+		"ZH-hans":     Chinese,
+		"en_US":       English,
+		"C":           "",
+		"":            "",
+	}
+	for tag, want := range cases {
+		if got := Match(tag); got != want {
+			t.Errorf("Match(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestTranslate_FallbackAndFormat(t *testing.T) {
+	if got := Translate(Chinese, "job.not_found"); got == Translate(English, "job.not_found") {
+		t.Fatalf("zh and en messages should differ, got %q", got)
+	}
+	if got, want := Translate("zh-CN", "job.not_found"), Translate(Chinese, "job.not_found"); got != want {
+		t.Fatalf("zh-CN should fall back to zh: got %q want %q", got, want)
+	}
+	if got, want := Translate("fr", "job.not_found"), Translate(English, "job.not_found"); got != want {
+		t.Fatalf("unknown locale should fall back to default: got %q want %q", got, want)
+	}
+	if got := Translate(English, "no.such.key"); got != "no.such.key" {
+		t.Fatalf("missing key should return key, got %q", got)
+	}
+	if got := Translate(English, "cli.usage", "aetheris jobs <agent_id>"); got != "Usage: aetheris jobs <agent_id>" {
+		t.Fatalf("formatted = %q", got)
+	}
+}
+
+func TestT_UsesContextLocale(t *testing.T) {
+	ctx := WithLocale(context.Background(), Chinese)
+	if got, want := T(ctx, "auth.required"), Translate(Chinese, "auth.required"); got != want {
+		t.Fatalf("T = %q, want %q", got, want)
+	}
+	if got := FromContext(context.Background()); got != DefaultLocale() {
+		t.Fatalf("FromContext without locale = %q", got)
+	}
+}
+
+func TestLoadDir_AddsLanguage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"job.not_found": "Job nicht gefunden"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if got := Negotiate("de-DE,en;q=0.5"); got != "de" {
+		t.Fatalf("Negotiate after LoadDir = %q", got)
+	}
+	if got := Translate("de", "job.not_found"); got != "Job nicht gefunden" {
+		t.Fatalf("de message = %q", got)
+	}
+	// 未翻译的 key 回退到英文
+	if got, want := Translate("de", "auth.required"), Translate(English, "auth.required"); got != want {
+		t.Fatalf("de fallback = %q, want %q", got, want)
+	}
+}
+
+var verbRe = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// 内置目录必须覆盖同一组 key，且各语言的格式化占位符一致
+func TestBuiltinCatalogsConsistent(t *testing.T) {
+	en, zh := Keys(English), Keys(Chinese)
+	if !reflect.DeepEqual(en, zh) {
+		t.Fatalf("en has %d keys, zh has %d keys; catalogs must match", len(en), len(zh))
+	}
+	for _, k := range en {
+		ev := verbRe.FindAllString(Translate(English, k), -1)
+		zv := verbRe.FindAllString(Translate(Chinese, k), -1)
+		if !reflect.DeepEqual(ev, zv) {
+			t.Errorf("%s: format verbs differ: en %v, zh %v", k, ev, zv)
+		}
+	}
+}
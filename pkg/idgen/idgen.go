@@ -0,0 +1,91 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idgen 可插拔的 ID 生成策略：uuid（默认，随机分布）、ulid 与 ksuid（时间有序，进程内单调递增）。
+// Job、事件与工具调用 ID 经由 NewID 生成；策略只影响新生成的 ID，已有 ID 均为不透明字符串，继续有效。
+package idgen
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// Strategy ID 生成策略
+type Strategy string
+
+const (
+	// StrategyUUID 随机 UUIDv4（默认，与历史 ID 格式一致）
+	StrategyUUID Strategy = "uuid"
+	// StrategyULID 26 位 Crockford Base32 ULID：48 bit 毫秒时间戳 + 80 bit 随机，同一毫秒内递增
+	StrategyULID Strategy = "ulid"
+	// StrategyKSUID 27 位 Base62 KSUID：32 bit 秒级时间戳 + 128 bit 随机，同一秒内递增
+	StrategyKSUID Strategy = "ksuid"
+)
+
+// Generator 生成 ID；实现须并发安全
+type Generator interface {
+	NewID() string
+}
+
+// New 按策略创建生成器；空为 uuid
+func New(strategy string) (Generator, error) {
+	switch Strategy(strings.ToLower(strings.TrimSpace(strategy))) {
+	case "", StrategyUUID:
+		return uuidGenerator{}, nil
+	case StrategyULID:
+		return NewULIDGenerator(), nil
+	case StrategyKSUID:
+		return NewKSUIDGenerator(), nil
+	default:
+		return nil, fmt.Errorf("idgen: unknown strategy %q (uuid|ulid|ksuid)", strategy)
+	}
+}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string { return uuid.New().String() }
+
+type holder struct{ g Generator }
+
+var defaultGen atomic.Pointer[holder]
+
+func init() {
+	defaultGen.Store(&holder{g: uuidGenerator{}})
+}
+
+// SetDefault 设置进程级默认生成器（启动时按配置调用一次）；nil 恢复 uuid
+func SetDefault(g Generator) {
+	if g == nil {
+		g = uuidGenerator{}
+	}
+	defaultGen.Store(&holder{g: g})
+}
+
+// Configure 按策略名设置进程级默认生成器
+func Configure(strategy string) error {
+	g, err := New(strategy)
+	if err != nil {
+		return err
+	}
+	SetDefault(g)
+	return nil
+}
+
+// NewID 以进程级默认生成器生成 ID
+func NewID() string {
+	return defaultGen.Load().g.NewID()
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idgen
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew_Strategies(t *testing.T) {
+	for strategy, length := range map[string]int{"": 36, "uuid": 36, "ULID": 26, "ksuid": 27} {
+		g, err := New(strategy)
+		if err != nil {
+			t.Fatalf("New(%q): %v", strategy, err)
+		}
+		if id := g.NewID(); len(id) != length {
+			t.Fatalf("New(%q).NewID() = %q, want length %d", strategy, id, length)
+		}
+	}
+	if _, err := New("snowflake"); err == nil {
+		t.Fatal("unknown strategy should fail")
+	}
+}
+
+func TestEncoding_Bounds(t *testing.T) {
+	var zero16 [16]byte
+	var max16 [16]byte
+	for i := range max16 {
+		max16[i] = 0xff
+	}
+	if got := encodeULID(zero16); got != strings.Repeat("0", 26) {
+		t.Fatalf("ulid zero = %s", got)
+	}
+	if got := encodeULID(max16); got != "7"+strings.Repeat("Z", 25) {
+		t.Fatalf("ulid max = %s", got)
+	}
+	var zero20, max20 [20]byte
+	for i := range max20 {
+		max20[i] = 0xff
+	}
+	if got := encodeBase62(zero20); got != strings.Repeat("0", 27) {
+		t.Fatalf("ksuid zero = %s", got)
+	}
+	if got := encodeBase62(max20); got != "aWgEPTl1tmebfsQzFP4bxwgy80V" {
+		t.Fatalf("ksuid max = %s", got)
+	}
+}
+
+// 同一时钟刻度内与跨刻度生成的 ID 均严格递增
+func TestMonotonic(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+	ulid := NewULIDGenerator()
+	ulid.now = func() time.Time { return clock }
+	ksuid := NewKSUIDGenerator()
+	ksuid.now = func() time.Time { return clock }
+	for name, g := range map[string]Generator{"ulid": ulid, "ksuid": ksuid} {
+		clock = base
+		prev := g.NewID()
+		for i := 0; i < 2000; i++ {
+			if i%500 == 0 {
+				clock = clock.Add(time.Second)
+			} else if i%250 == 0 {
+				clock = clock.Add(-time.Second) // 时钟回拨不破坏单调性
+			}
+			id := g.NewID()
+			if id <= prev {
+				t.Fatalf("%s: %s not greater than %s at %d", name, id, prev, i)
+			}
+			prev = id
+		}
+	}
+}
+
+func TestConfigureDefault(t *testing.T) {
+	defer SetDefault(nil)
+	if err := Configure("ulid"); err != nil {
+		t.Fatal(err)
+	}
+	if id := NewID(); len(id) != 26 {
+		t.Fatalf("default ulid id = %q", id)
+	}
+	if err := Configure("bogus"); err == nil {
+		t.Fatal("unknown strategy should fail")
+	}
+	SetDefault(nil)
+	if id := NewID(); len(id) != 36 {
+		t.Fatalf("reset default id = %q", id)
+	}
+}
+
+// BenchmarkIndexLocality 以「新 ID 落在已有有序集合末尾」的比例近似 B-tree 主键插入的页局部性：
+// 越接近 1 越多插入命中最右叶子页（顺序追加），越接近 0 越多随机页分裂
+func BenchmarkIndexLocality(b *testing.B) {
+	for _, strategy := range []string{"uuid", "ulid", "ksuid"} {
+		b.Run(strategy, func(b *testing.B) {
+			g, _ := New(strategy)
+			ids := make([]string, 0, b.N)
+			appends := 0
+			for i := 0; i < b.N; i++ {
+				id := g.NewID()
+				if n := len(ids); n == 0 || id > ids[n-1] {
+					appends++
+					ids = append(ids, id)
+					continue
+				}
+				pos := sort.SearchStrings(ids, id)
+				ids = append(ids, "")
+				copy(ids[pos+1:], ids[pos:])
+				ids[pos] = id
+			}
+			b.ReportMetric(float64(appends)/float64(b.N), "append_ratio")
+		})
+	}
+}
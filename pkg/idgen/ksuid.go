@@ -0,0 +1,95 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+const (
+	// ksuidEpoch KSUID 时间戳纪元（2014-05-13T16:53:20Z）
+	ksuidEpoch = 1400000000
+	base62     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidLen   = 27
+)
+
+// KSUIDGenerator 单调 KSUID 生成器：同一秒内在上一个 ID 的 payload 上加一，payload 溢出时借用下一秒
+type KSUIDGenerator struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	lastTS  uint32
+	payload [16]byte
+	started bool
+}
+
+// NewKSUIDGenerator 创建 KSUID 生成器
+func NewKSUIDGenerator() *KSUIDGenerator {
+	return &KSUIDGenerator{now: time.Now}
+}
+
+// NewID 实现 Generator
+func (g *KSUIDGenerator) NewID() string {
+	g.mu.Lock()
+	ts := uint32(g.now().Unix() - ksuidEpoch)
+	if g.started && ts <= g.lastTS {
+		ts = g.lastTS
+		if incr(g.payload[:]) {
+			ts++
+			_, _ = rand.Read(g.payload[:])
+		}
+	} else {
+		_, _ = rand.Read(g.payload[:])
+	}
+	g.lastTS, g.started = ts, true
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], ts)
+	copy(b[4:], g.payload[:])
+	g.mu.Unlock()
+	return encodeBase62(b)
+}
+
+// incr 大端字节串加一，返回是否溢出
+func incr(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeBase62 160 bit → 27 字符（左侧补 0，定长保证字典序即数值序）
+func encodeBase62(b [20]byte) string {
+	// 以 5 个 uint32 表示大数，逐次除 62 取余
+	var parts [5]uint32
+	for i := range parts {
+		parts[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	var out [ksuidLen]byte
+	for i := ksuidLen - 1; i >= 0; i-- {
+		var rem uint64
+		for j := range parts {
+			v := rem<<32 | uint64(parts[j])
+			parts[j] = uint32(v / 62)
+			rem = v % 62
+		}
+		out[i] = base62[rem]
+	}
+	return string(out[:])
+}
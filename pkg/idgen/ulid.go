@@ -0,0 +1,88 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// crockford Crockford Base32 字母表（按 ASCII 升序，编码后字典序即时间序）
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator 单调 ULID 生成器：同一毫秒内在上一个 ID 的随机部分上加一，随机部分溢出时借用下一毫秒
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	lastMs  uint64
+	lastHi  uint16 // 随机部分高 16 bit
+	lastLo  uint64 // 随机部分低 64 bit
+	started bool
+}
+
+// NewULIDGenerator 创建 ULID 生成器
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{now: time.Now}
+}
+
+// NewID 实现 Generator
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	ms := uint64(g.now().UnixMilli())
+	if g.started && ms <= g.lastMs {
+		ms = g.lastMs
+		g.lastLo++
+		if g.lastLo == 0 {
+			g.lastHi++
+			if g.lastHi == 0 {
+				ms++
+				g.fillRandom()
+			}
+		}
+	} else {
+		g.fillRandom()
+	}
+	g.lastMs, g.started = ms, true
+	hi, lo := g.lastHi, g.lastLo
+	g.mu.Unlock()
+
+	var b [16]byte
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	binary.BigEndian.PutUint16(b[6:8], hi)
+	binary.BigEndian.PutUint64(b[8:], lo)
+	return encodeULID(b)
+}
+
+func (g *ULIDGenerator) fillRandom() {
+	var r [10]byte
+	_, _ = rand.Read(r[:])
+	g.lastHi = binary.BigEndian.Uint16(r[:2])
+	g.lastLo = binary.BigEndian.Uint64(r[2:])
+}
+
+// encodeULID 128 bit → 26 字符（首字符仅 3 bit）
+func encodeULID(b [16]byte) string {
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -33,16 +33,85 @@ func init() {
 		// 2.0 Rate limiting metrics
 		RateLimitWaitSeconds, RateLimitRejectionsTotal,
 		ToolConcurrentGauge, LLMConcurrentGauge,
+		LLMBucketQueueDepth, LLMBucketWaitSeconds,
+		LLMFailoverTotal, LLMModelHealth, LLMRouteTotal, LLMModelLatencySeconds,
 		JobParkedDuration,
 		// 3.0-M4 Advanced metrics
 		DecisionQualityScore, AnomalyDetectedTotal, SignatureVerificationTotal,
 		// P0 SLO metrics
 		JobStateGauge, StepDurationSeconds, LeaseConflictTotal, ToolInvocationTotal,
 		// Metrics MVP: tenant-aware + SLO
-		JobsTotal, JobLatencySeconds,
+		JobsTotal, JobsDeduplicatedTotal, JobLatencySeconds,
 		StepRetriesTotal, StepTimeoutTotal,
 		LeaseAcquireTotal, SchedulerTickDurationSeconds,
 		ToolInvocationsTotal, ToolErrorsTotal, ConfirmationReplayFailTotal, ConfirmationReplayWarnTotal,
+		// Postgres 连接池
+		PgPoolConnections, PgPoolAcquireWaitSeconds, PgPoolEmptyAcquireCount, PgPoolHealthy,
+		PgDegraded, PgRetriesTotal, PgCircuitOpenTotal,
+		// 事件导出
+		EventExportTotal,
+		// 维护窗口
+		MaintenanceWindowActive, MaintenanceDeferredTotal,
+		// 全局工具熔断
+		ToolKillSwitchActive, ToolKillSwitchBlockedTotal,
+		// 租户出网策略
+		ToolEgressDeniedTotal,
+		// Job 状态机
+		JobIllegalTransitionsTotal,
+		// Worker 资源感知认领
+		WorkerThrottled, WorkerClaimThrottledTotal, WorkerResourceUsage,
+		// Self-Reflection
+		ReflectionTotal,
+		// Planner few-shot
+		PlannerPlansTotal,
+		// Worker-local effect buffer
+		EffectBufferPending,
+		EffectBufferFlushLagSeconds,
+		EffectBufferFlushDelaySeconds,
+		EffectBufferFlushErrorsTotal,
+		EffectBufferBackpressureTotal,
+		// 事件/账本/检查点漂移对账
+		ReconcileDriftFindings, ReconcileRunsTotal,
+		// Job 时长预测
+		JobPredictedDurationSeconds, JobETAAbsErrorSeconds,
+		// Worker/API 版本协商
+		JobFeatureDegradedTotal,
+		// Replay 与实时执行占比
+		StepExecutionsTotal, ReplayCatchUpTotal, ReplayPolicyDenialsTotal, ReplayIdempotentReexecutionsTotal,
+		// 消息受理背压
+		BackpressureLevel, BackpressureRejectionsTotal, BackpressureShedTotal,
+		// 监督者子 Job
+		SupervisorChildrenTotal, SupervisorRedispatchTotal,
+		// LLM prompt 留存
+		LLMPromptsLoggedTotal, LLMPromptLogBytesTotal,
+		// 交互式/批处理调度通道
+		LaneJobLatencySeconds, LaneQueueWaitSeconds, LaneJobsFinishedTotal,
+		// 归档事件冷读
+		ArchiveColdReadsTotal, ArchiveColdReadSeconds,
+		// Job 失败诊断
+		JobDiagnosesTotal, JobDiagnosisSeconds,
+		// 租户公平调度
+		TenantFairShareTarget, TenantShareAttainment, TenantStarvationSeconds, TenantStarvationTotal,
+		// 自定义业务事件
+		CustomEventsTotal,
+		// 入站 Webhook
+		InboundWebhooksTotal,
+		// 入库去重
+		IngestDedupTotal,
+		// 入库流水线阶段
+		IngestStageDurationSeconds, IngestStageFailuresTotal, IngestTasks,
+		// 工具步内检查点
+		ToolProgressCheckpointsTotal, ToolResumesTotal,
+		// 工具输出大小上限
+		ToolOutputSpilledTotal,
+		// API 访问日志
+		APIRequestDurationSeconds, APISlowRequestsTotal,
+		// API 请求截止时间
+		APIRequestTimeoutsTotal,
+		// 事件载荷压缩
+		EventPayloadBytesTotal, EventPayloadCompressionRatio, EventPayloadCodecSeconds,
+		// 集群级工具信号量
+		ToolSemaphoreWaitSeconds, ToolSemaphoreAcquireTotal, ToolSemaphoreWaiting,
 	)
 }
 
@@ -93,6 +162,42 @@ var LLMTokensTotal = prometheus.NewCounterVec(
 	[]string{"direction"}, // input | output
 )
 
+// LLMFailoverTotal LLM 故障切换链由非主模型完成调用的次数
+var LLMFailoverTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_llm_failover_total",
+		Help: "LLM 故障切换链由非主模型完成调用的次数",
+	},
+	[]string{"use_case", "from", "to"},
+)
+
+// LLMModelHealth LLM 故障切换链中各模型的健康分（0~1）
+var LLMModelHealth = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_llm_model_health",
+		Help: "LLM 故障切换链中各模型的健康分（0~1）",
+	},
+	[]string{"use_case", "model"},
+)
+
+// LLMRouteTotal 按步骤选择模型的次数（outcome=met|latency_unmet|quality_unmet|fallback）
+var LLMRouteTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_llm_route_total",
+		Help: "按步骤延迟/质量要求选择模型的次数",
+	},
+	[]string{"model", "quality", "outcome"},
+)
+
+// LLMModelLatencySeconds 模型路由观测到的各模型调用延迟（EWMA）
+var LLMModelLatencySeconds = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_llm_model_latency_seconds",
+		Help: "模型路由观测到的各模型调用延迟（EWMA，秒）",
+	},
+	[]string{"model"},
+)
+
 // WorkerBusy 当前正在执行的 Job 数（每 Worker）
 var WorkerBusy = prometheus.NewGaugeVec(
 	prometheus.GaugeOpts{
@@ -156,6 +261,25 @@ var LLMConcurrentGauge = prometheus.NewGaugeVec(
 	[]string{"provider"},
 )
 
+// LLMBucketQueueDepth 按限流桶排队等待的 LLM 调用数（bucket=planning|execution）
+var LLMBucketQueueDepth = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_llm_bucket_queue_depth",
+		Help: "按限流桶排队等待的 LLM 调用数",
+	},
+	[]string{"provider", "bucket"},
+)
+
+// LLMBucketWaitSeconds 按限流桶的 LLM 调用排队等待时间（秒）
+var LLMBucketWaitSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_llm_bucket_wait_seconds",
+		Help:    "按限流桶的 LLM 调用排队等待时间（秒）",
+		Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 2, 5, 10, 30},
+	},
+	[]string{"provider", "bucket"},
+)
+
 // JobParkedDuration Job 处于 parked 状态的时长（秒）
 var JobParkedDuration = prometheus.NewHistogramVec(
 	prometheus.HistogramOpts{
@@ -242,6 +366,15 @@ var JobsTotal = prometheus.NewCounterVec(
 	[]string{"tenant", "status"},
 )
 
+// JobsDeduplicatedTotal 因目标去重窗口命中而返回已有 Job、未新建的请求数
+var JobsDeduplicatedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_jobs_deduplicated_total",
+		Help: "目标去重窗口内返回已有 Job 的请求数（按租户）",
+	},
+	[]string{"tenant"},
+)
+
 // JobLatencySeconds 从 created 到 done 的耗时直方图（tenant, status）
 var JobLatencySeconds = prometheus.NewHistogramVec(
 	prometheus.HistogramOpts{
@@ -324,6 +457,630 @@ var ConfirmationReplayWarnTotal = prometheus.NewCounterVec(
 	[]string{"tenant", "tool"},
 )
 
+// --- Postgres 连接池（internal/storage/pgpool 周期采样 pgxpool.Stat） ---
+
+// PgPoolConnections 各组件连接池连接数（component, state=in_use|idle|total|max）
+var PgPoolConnections = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_pg_pool_connections",
+		Help: "Postgres 连接池连接数（按组件与状态）",
+	},
+	[]string{"component", "state"},
+)
+
+// PgPoolAcquireWaitSeconds 各组件累计获取连接耗时（秒），取自 pgxpool.Stat().AcquireDuration
+var PgPoolAcquireWaitSeconds = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_pg_pool_acquire_wait_seconds",
+		Help: "Postgres 连接池累计获取连接耗时（秒）",
+	},
+	[]string{"component"},
+)
+
+// PgPoolEmptyAcquireCount 各组件累计"池空需等待"的获取次数；持续增长说明预算不足
+var PgPoolEmptyAcquireCount = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_pg_pool_empty_acquire",
+		Help: "Postgres 连接池因无空闲连接而等待的累计次数",
+	},
+	[]string{"component"},
+)
+
+// PgPoolHealthy 各组件连接池最近一次健康检查结果（1=健康, 0=Ping 失败）
+var PgPoolHealthy = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_pg_pool_healthy",
+		Help: "Postgres 连接池健康状态（1=健康, 0=异常）",
+	},
+	[]string{"component"},
+)
+
+// PgDegraded 各组件是否处于 Postgres 降级模式（1=熔断打开、Worker 暂停认领, 0=正常）；见 internal/storage/pgretry
+var PgDegraded = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_pg_degraded",
+		Help: "Postgres 降级模式（1=熔断打开, 0=正常）",
+	},
+	[]string{"component"},
+)
+
+// PgRetriesTotal 短暂错误后的重试次数（component, op）
+var PgRetriesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_pg_retries_total",
+		Help: "Postgres 短暂错误重试次数（按组件与操作）",
+	},
+	[]string{"component", "op"},
+)
+
+// PgCircuitOpenTotal 进入降级模式的次数
+var PgCircuitOpenTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_pg_circuit_open_total",
+		Help: "Postgres 熔断打开（进入降级模式）次数",
+	},
+	[]string{"component"},
+)
+
+// EventExportTotal 事件导出投递结果计数（event_type, result=delivered|failed|dead）
+var EventExportTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_event_export_total",
+		Help: "事件导出投递次数（按事件类型与结果）",
+	},
+	[]string{"event_type", "result"},
+)
+
+// MaintenanceWindowActive 租户维护窗口是否生效（1=窗口内）
+var MaintenanceWindowActive = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_maintenance_window_active",
+		Help: "租户维护窗口生效状态（1=窗口内）",
+	},
+	[]string{"tenant_id"},
+)
+
+// MaintenanceDeferredTotal 维护窗口导致的 Job 暂缓/恢复次数（action=deferred|parked|resumed）
+var MaintenanceDeferredTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_maintenance_jobs_total",
+		Help: "维护窗口内暂缓、暂停与窗口结束后恢复的 Job 数",
+	},
+	[]string{"tenant_id", "action"},
+)
+
+// ToolKillSwitchActive 工具类别全局熔断是否生效（1=熔断中）
+var ToolKillSwitchActive = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_tool_killswitch_active",
+		Help: "工具类别全局熔断状态（1=熔断中）",
+	},
+	[]string{"category"},
+)
+
+// ToolKillSwitchBlockedTotal 因类别熔断被拒绝（或执行中被中止）的工具调用次数
+var ToolKillSwitchBlockedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tool_killswitch_blocked_total",
+		Help: "因工具类别熔断失败的步骤数",
+	},
+	[]string{"category", "tool"},
+)
+
+// ToolEgressDeniedTotal 因目标不在租户出网白名单内失败（policy_denied）的工具调用次数
+var ToolEgressDeniedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tool_egress_denied_total",
+		Help: "因租户出网策略拒绝失败的工具调用数",
+	},
+	[]string{"tenant", "tool"},
+)
+
+// JobIllegalTransitionsTotal 被状态机拒绝的 Job 状态迁移次数（from/to 为状态名）
+var JobIllegalTransitionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_job_illegal_transitions_total",
+		Help: "被 Job 状态机拒绝的非法状态迁移次数",
+	},
+	[]string{"from", "to"},
+)
+
+// JobFeatureDegradedTotal 版本协商时因在线 Worker 不支持而降级去掉的 Job 特性次数
+var JobFeatureDegradedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_job_feature_degraded_total",
+		Help: "版本协商降级的 Job 特性数（滚动升级期间混合集群）",
+	},
+	[]string{"feature"},
+)
+
+// WorkerThrottled Worker 是否处于资源感知节流（1=暂停认领）
+var WorkerThrottled = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_worker_throttled",
+		Help: "Worker 资源感知节流状态（1=暂停认领新 Job）",
+	},
+	[]string{"worker_id"},
+)
+
+// WorkerClaimThrottledTotal 因资源超阈值跳过认领的次数（reason=cpu|memory|llm_in_flight|tool_in_flight）
+var WorkerClaimThrottledTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_worker_claim_throttled_total",
+		Help: "Worker 因资源超阈值跳过认领的次数",
+	},
+	[]string{"worker_id", "reason"},
+)
+
+// WorkerResourceUsage Worker 最近一次资源采样（resource=cpu_percent|memory_percent|llm_in_flight|tool_in_flight）
+var WorkerResourceUsage = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_worker_resource_usage",
+		Help: "Worker 最近一次资源采样值",
+	},
+	[]string{"worker_id", "resource"},
+)
+
+// ReflectionTotal 自我反思次数（trigger=every_n_steps|on_failure|reflect_node，status=applied|pending_approval|no_change|rejected|error）
+var ReflectionTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_reflection_total",
+		Help: "自我反思步执行次数（按触发来源与提议状态）",
+	},
+	[]string{"trigger", "status"},
+)
+
+// PlannerPlansTotal LLM 规划次数（variant=exemplars|control|none，valid=true|false），用于比较 few-shot 示例对计划有效率的影响
+var PlannerPlansTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_planner_plans_total",
+		Help: "PlanGoal 规划次数（按示例 A/B 分组与计划是否通过校验）",
+	},
+	[]string{"variant", "valid"},
+)
+
+// EffectBufferPending Worker 本地 effect 缓冲中尚未刷入 Postgres 的记录数
+var EffectBufferPending = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "aetheris_effect_buffer_pending",
+		Help: "Worker 本地 effect 缓冲中未刷出的记录数",
+	},
+)
+
+// EffectBufferFlushLagSeconds 最早一条未刷出记录的排队时长（秒），即当前刷写滞后
+var EffectBufferFlushLagSeconds = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "aetheris_effect_buffer_flush_lag_seconds",
+		Help: "Worker 本地 effect 缓冲的刷写滞后（最早未刷出记录的排队时长，秒）",
+	},
+)
+
+// EffectBufferFlushDelaySeconds 单条 effect 从写入本地 WAL 到刷入 Postgres 的耗时（秒）
+var EffectBufferFlushDelaySeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_effect_buffer_flush_delay_seconds",
+		Help:    "effect 从写入本地 WAL 到刷入下游存储的耗时（秒）",
+		Buckets: []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 30, 120},
+	},
+)
+
+// EffectBufferFlushErrorsTotal 刷入下游存储失败次数（失败后按序重试）
+var EffectBufferFlushErrorsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "aetheris_effect_buffer_flush_errors_total",
+		Help: "Worker 本地 effect 缓冲刷入下游存储失败次数",
+	},
+)
+
+// EffectBufferBackpressureTotal 未刷出记录达到上限、PutEffect 需等待的次数
+var EffectBufferBackpressureTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "aetheris_effect_buffer_backpressure_total",
+		Help: "Worker 本地 effect 缓冲达到 max_pending 触发背压的次数",
+	},
+)
+
+// ReconcileDriftFindings 最近一轮对账发现的漂移数（kind=committed_without_ledger|checkpoint_ahead_of_events|checkpoint_missing|stale_pending_invocation）
+var ReconcileDriftFindings = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_reconcile_drift_findings",
+		Help: "最近一轮事件/账本/检查点对账发现的漂移数",
+	},
+	[]string{"kind"},
+)
+
+// ReconcileRunsTotal 对账轮次（result=ok|error）
+var ReconcileRunsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_reconcile_runs_total",
+		Help: "事件/账本/检查点对账执行轮次",
+	},
+	[]string{"result"},
+)
+
+// JobPredictedDurationSeconds 按 Agent 的 Job 时长预测（滚动均值，秒）
+var JobPredictedDurationSeconds = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_job_predicted_duration_seconds",
+		Help: "按 Agent 滚动统计的 Job 预测时长（秒）",
+	},
+	[]string{"agent_id"},
+)
+
+// JobETAAbsErrorSeconds Job 完成时预测时长与实际时长的绝对误差（秒）
+var JobETAAbsErrorSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_job_eta_abs_error_seconds",
+		Help:    "Job 时长预测绝对误差（秒）",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	},
+)
+
+// StepExecutionsTotal 步骤完成数（mode=live 实际执行, mode=injected 从 Replay 注入结果未执行）
+var StepExecutionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_step_executions_total",
+		Help: "步骤完成数（mode=live 实际执行, mode=injected Replay 注入）",
+	},
+	[]string{"tenant", "node_type", "mode"},
+)
+
+// ReplayCatchUpTotal 恢复时由 Ledger/Effect Store 补写 command_committed 而未重执行的命令数
+var ReplayCatchUpTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_replay_catchup_total",
+		Help: "恢复时 catch-up 补写事件、未重执行的命令数（source=ledger|effect_store）",
+	},
+	[]string{"tenant", "source"},
+)
+
+// ReplayPolicyDenialsTotal Replay 策略判定副作用节点不可执行且无已记录结果、导致 Job failed 的次数
+var ReplayPolicyDenialsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_replay_policy_denials_total",
+		Help: "Replay 策略拒绝执行（副作用节点无已记录结果）的次数",
+	},
+	[]string{"tenant", "node_type", "kind"},
+)
+
+// ReplayIdempotentReexecutionsTotal Replay 时显式声明幂等的工具无已记录结果、按策略重新执行的次数
+var ReplayIdempotentReexecutionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_replay_idempotent_reexecutions_total",
+		Help: "Replay 时幂等工具无已记录结果而重新执行的次数",
+	},
+	[]string{"tenant", "tool"},
+)
+
+// BackpressureLevel 消息受理背压级别（0=normal 1=shedding 2=throttling 3=overloaded）
+var BackpressureLevel = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "aetheris_backpressure_level",
+		Help: "消息受理背压级别（0=normal 1=shedding 2=throttling 3=overloaded）",
+	},
+)
+
+// BackpressureRejectionsTotal 因背压返回 429 的消息数
+var BackpressureRejectionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_backpressure_rejections_total",
+		Help: "因背压返回 429 的消息数",
+	},
+	[]string{"tenant", "priority"},
+)
+
+// BackpressureShedTotal 背压时跳过的可选工作次数（work=plan_preview|decision_snapshot）
+var BackpressureShedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_backpressure_shed_total",
+		Help: "背压时跳过的可选工作次数",
+	},
+	[]string{"work"},
+)
+
+// SupervisorChildrenTotal 监督者 spawn/join 节点创建的子 Job 数（含重派）
+var SupervisorChildrenTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_supervisor_children_total",
+		Help: "监督者创建的子 Job 数（含重派）",
+	},
+	[]string{"tenant"},
+)
+
+// SupervisorRedispatchTotal join 节点重派失败子任务的次数
+var SupervisorRedispatchTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_supervisor_redispatch_total",
+		Help: "join 节点重派失败子任务的次数",
+	},
+	[]string{"tenant"},
+)
+
+// LLMPromptsLoggedTotal 留存到对象存储的 LLM prompt 数（reason=sampled|failure）
+var LLMPromptsLoggedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_llm_prompts_logged_total",
+		Help: "留存到对象存储的 LLM prompt 数",
+	},
+	[]string{"tenant", "reason"},
+)
+
+// LLMPromptLogBytesTotal 留存 prompt 写入对象存储的字节数
+var LLMPromptLogBytesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_llm_prompt_log_bytes_total",
+		Help: "留存 prompt 写入对象存储的字节数",
+	},
+	[]string{"tenant"},
+)
+
+// LaneJobLatencySeconds 按调度通道（interactive / batch）统计 Job 从创建到终态的耗时，交互式 p95 与批处理分开观测
+var LaneJobLatencySeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_lane_job_latency_seconds",
+		Help:    "按调度通道统计 Job 从创建到终态的耗时（秒）",
+		Buckets: []float64{0.25, 0.5, 1, 2, 5, 10, 30, 60, 120, 300, 600, 1800},
+	},
+	[]string{"lane"},
+)
+
+// LaneQueueWaitSeconds 按调度通道统计 Job 从创建到被认领的排队时间
+var LaneQueueWaitSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_lane_queue_wait_seconds",
+		Help:    "按调度通道统计 Job 排队等待认领的时间（秒）",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	},
+	[]string{"lane"},
+)
+
+// LaneJobsFinishedTotal 按调度通道统计到达终态的 Job 数（lane, status），用于批处理吞吐
+var LaneJobsFinishedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_lane_jobs_finished_total",
+		Help: "按调度通道统计到达终态的 Job 数",
+	},
+	[]string{"lane", "status"},
+)
+
+// ArchiveColdReadsTotal 归档事件冷读次数（result=cache_hit|fetched|not_found|error）
+var ArchiveColdReadsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_archive_cold_reads_total",
+		Help: "从对象存储冷读归档事件的次数",
+	},
+	[]string{"result"},
+)
+
+// ArchiveColdReadSeconds 未命中缓存时从对象存储读取并校验一个 Job 归档的耗时
+var ArchiveColdReadSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_archive_cold_read_seconds",
+		Help:    "从对象存储读取一个 Job 归档的耗时（秒）",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	},
+)
+
+// JobDiagnosesTotal 失败 Job 诊断次数（result=ok|error）
+var JobDiagnosesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_job_diagnoses_total",
+		Help: "失败 Job 的 LLM 诊断次数",
+	},
+	[]string{"result"},
+)
+
+// JobDiagnosisSeconds 单次失败诊断的 LLM 调用耗时
+var JobDiagnosisSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_job_diagnosis_seconds",
+		Help:    "失败 Job 诊断的 LLM 调用耗时（秒）",
+		Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60},
+	},
+)
+
+// TenantFairShareTarget 租户目标份额（权重 / 活跃租户权重和）
+var TenantFairShareTarget = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_tenant_fair_share_target",
+		Help: "租户在 Running Job 中的目标份额（0~1）",
+	},
+	[]string{"tenant"},
+)
+
+// TenantShareAttainment 租户份额达成率：实际 Running 占比 / 目标份额，<1 表示低于公平份额
+var TenantShareAttainment = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_tenant_share_attainment",
+		Help: "租户实际 Running 占比与目标份额之比",
+	},
+	[]string{"tenant"},
+)
+
+// TenantStarvationSeconds 租户有 Pending 期间其他租户仍被认领、自身未被认领的时长
+var TenantStarvationSeconds = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_tenant_starvation_seconds",
+		Help: "租户有 Pending Job 且其他租户仍被认领期间自身的等待时长（秒）",
+	},
+	[]string{"tenant"},
+)
+
+// TenantStarvationTotal 租户等待超过饥饿阈值的次数
+var TenantStarvationTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tenant_starvation_total",
+		Help: "租户有 Pending 却超过饥饿阈值未被认领的次数",
+	},
+	[]string{"tenant"},
+)
+
+// CustomEventsTotal 步骤经 sdk.EmitEvent 发出的自定义业务事件数（result=ok|error）
+var CustomEventsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_custom_events_total",
+		Help: "步骤发出的自定义业务事件数",
+	},
+	[]string{"tenant", "result"},
+)
+
+// InboundWebhooksTotal 入站 Webhook 请求数（result=delivered|rejected|unverified|unmapped|error）；未配置的通道记为 unknown
+var InboundWebhooksTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_inbound_webhooks_total",
+		Help: "外部系统入站 Webhook 请求数",
+	},
+	[]string{"channel", "result"},
+)
+
+// IngestDedupTotal 入库时判定为重复的文档数（match=exact|near，result=skipped|merged）
+var IngestDedupTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_ingest_dedup_total",
+		Help: "入库去重命中的文档数",
+	},
+	[]string{"collection", "match", "result"},
+)
+
+// IngestStageDurationSeconds 入库流水线各阶段耗时（stage=load|parse|dedup|split|embed|index，outcome=ok|error）
+var IngestStageDurationSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_ingest_stage_duration_seconds",
+		Help:    "入库流水线各阶段耗时",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30, 60, 120},
+	},
+	[]string{"stage", "outcome"},
+)
+
+// IngestStageFailuresTotal 入库流水线阶段failed次数（class=timeout|canceled|rate_limited|auth|network|invalid_input|internal）
+var IngestStageFailuresTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_ingest_stage_failures_total",
+		Help: "入库流水线阶段failed次数",
+	},
+	[]string{"stage", "class"},
+)
+
+// IngestTasks 异步入库任务数（status=pending|claimed|completed|failed），由 /api/ingest/stats 查询时刷新
+var IngestTasks = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_ingest_tasks",
+		Help: "异步入库任务数",
+	},
+	[]string{"status"},
+)
+
+// ToolProgressCheckpointsTotal 长时工具经 sdk.CheckpointToolState 写入的步内检查点数（result=ok|error）
+var ToolProgressCheckpointsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tool_progress_checkpoints_total",
+		Help: "长时工具写入的步内检查点数",
+	},
+	[]string{"tenant", "tool", "result"},
+)
+
+// ToolResumesTotal Worker 崩溃后工具从最近一次步内检查点续跑的次数
+var ToolResumesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tool_resumes_total",
+		Help: "工具从步内检查点续跑的次数",
+	},
+	[]string{"tenant", "tool"},
+)
+
+// ToolOutputSpilledTotal 超过大小上限的工具输出数（result=spilled 写入工作区，truncated 工作区不可用仅截断）
+var ToolOutputSpilledTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tool_output_spilled_total",
+		Help: "超过大小上限被替换为摘要的工具输出数",
+	},
+	[]string{"tenant", "tool", "result"},
+)
+
+// ToolSemaphoreWaitSeconds 获取集群级工具信号量的排队时长（秒，含无需等待的获取）
+var ToolSemaphoreWaitSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_tool_semaphore_wait_seconds",
+		Help:    "获取工具信号量的排队时长（秒）",
+		Buckets: []float64{0.01, 0.1, 0.5, 1, 5, 15, 60, 300},
+	},
+	[]string{"semaphore"},
+)
+
+// ToolSemaphoreAcquireTotal 工具信号量获取结果（result=acquired|timeout|cancelled|error）
+var ToolSemaphoreAcquireTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_tool_semaphore_acquire_total",
+		Help: "工具信号量获取次数（按结果）",
+	},
+	[]string{"semaphore", "result"},
+)
+
+// ToolSemaphoreWaiting 当前进程内正在排队等待信号量的工具调用数
+var ToolSemaphoreWaiting = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "aetheris_tool_semaphore_waiting",
+		Help: "当前进程内排队等待工具信号量的调用数",
+	},
+	[]string{"semaphore"},
+)
+
+// APIRequestDurationSeconds API 请求耗时（route 为路由模板，未命中路由为 unmatched）
+var APIRequestDurationSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_api_request_duration_seconds",
+		Help:    "API 请求耗时（秒）",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"method", "route"},
+)
+
+// APISlowRequestsTotal 被判定为慢请求（超过固定阈值或路由滚动 p99）的 API 请求数
+var APISlowRequestsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_api_slow_requests_total",
+		Help: "API 慢请求数",
+	},
+	[]string{"method", "route"},
+)
+
+// APIRequestTimeoutsTotal 超过请求截止时间（api.timeout / api.timeouts.routes）的 API 请求数
+var APIRequestTimeoutsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_api_request_timeouts_total",
+		Help: "超过截止时间的 API 请求数",
+	},
+	[]string{"method", "route"},
+)
+
+// EventPayloadBytesTotal 写入 Postgres 的事件载荷字节数（stage=raw 原始大小，stored 落库大小；stored/raw 即整体压缩率）
+var EventPayloadBytesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "aetheris_event_payload_bytes_total",
+		Help: "写入的事件载荷字节数（压缩前/落库）",
+	},
+	[]string{"stage"},
+)
+
+// EventPayloadCompressionRatio 单条被压缩载荷的压缩后/压缩前大小比
+var EventPayloadCompressionRatio = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_event_payload_compression_ratio",
+		Help:    "事件载荷压缩比（压缩后/压缩前）",
+		Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1},
+	},
+)
+
+// EventPayloadCodecSeconds 事件载荷压缩/解压耗时（op=compress|decompress），用于评估 CPU 开销
+var EventPayloadCodecSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "aetheris_event_payload_codec_seconds",
+		Help:    "事件载荷压缩/解压耗时（秒）",
+		Buckets: []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05},
+	},
+	[]string{"op"},
+)
+
 // WritePrometheus 将 Prometheus 文本格式写入 w（供 Hertz 等复用）
 func WritePrometheus(w io.Writer) error {
 	metrics, err := DefaultRegistry.Gather()
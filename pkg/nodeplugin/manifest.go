@@ -0,0 +1,254 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodeplugin 自定义节点类型插件：组织在不修改 executor 的前提下新增节点类型（如 spark_job、db_migration）。
+//
+// 插件包在 init 中调用 Register 注册 Manifest 与 Executor，经 cmd/worker 下带 build tag 的文件空导入编入 Worker：
+//
+//	//go:build nodeplugin_spark
+//
+//	package main
+//
+//	import _ "example.com/acme/aetheris-spark"
+//
+// Manifest 声明节点类型与 config 字段，API 校验客户端提交的 task_graph、Worker 编译节点前均按其校验配置；
+// 未编入插件代码的进程（如 API）可经 agent.node_plugins.manifest_dir 下的 JSON Manifest 只注册校验规则。
+package nodeplugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 字段类型（Field.Type）
+const (
+	FieldString  = "string"
+	FieldNumber  = "number"
+	FieldInteger = "integer"
+	FieldBoolean = "boolean"
+	FieldObject  = "object"
+	FieldArray   = "array"
+)
+
+// 节点副作用声明（Manifest.Effect）
+const (
+	// EffectPure 无外部副作用：Replay 时可重新执行
+	EffectPure = "pure"
+	// EffectSideEffect 有外部副作用（默认）：结果经 command_committed 记录，Replay 时注入已记录结果而不重执行
+	EffectSideEffect = "side_effect"
+)
+
+var typePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// Manifest 自定义节点类型声明
+type Manifest struct {
+	// Type 节点类型（TaskNode.type），小写字母开头，可含数字、_ . -
+	Type        string `json:"type"`
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Effect pure | side_effect，缺省 side_effect
+	Effect string `json:"effect,omitempty"`
+	// Config 节点 config 的字段声明
+	Config []Field `json:"config,omitempty"`
+	// AllowUnknownFields 为 true 时允许 config 出现未声明的字段
+	AllowUnknownFields bool `json:"allow_unknown_fields,omitempty"`
+}
+
+// Field config 字段声明
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Enum 非空时字段值须为其中之一（按 JSON 值比较）
+	Enum []any `json:"enum,omitempty"`
+}
+
+// Validate 校验 Manifest 自身
+func (m Manifest) Validate() error {
+	if !typePattern.MatchString(m.Type) {
+		return fmt.Errorf("nodeplugin: invalid node type %q", m.Type)
+	}
+	switch m.Effect {
+	case "", EffectPure, EffectSideEffect:
+	default:
+		return fmt.Errorf("nodeplugin: node type %q has invalid effect %q", m.Type, m.Effect)
+	}
+	seen := make(map[string]bool, len(m.Config))
+	for _, f := range m.Config {
+		if f.Name == "" {
+			return fmt.Errorf("nodeplugin: node type %q has a config field without name", m.Type)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("nodeplugin: node type %q declares config field %q twice", m.Type, f.Name)
+		}
+		seen[f.Name] = true
+		switch f.Type {
+		case FieldString, FieldNumber, FieldInteger, FieldBoolean, FieldObject, FieldArray:
+		default:
+			return fmt.Errorf("nodeplugin: node type %q field %q has invalid type %q", m.Type, f.Name, f.Type)
+		}
+		for _, v := range f.Enum {
+			if !matchesType(f.Type, v) {
+				return fmt.Errorf("nodeplugin: node type %q field %q enum value %v is not %s", m.Type, f.Name, v, f.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// SideEffect 节点是否有外部副作用
+func (m Manifest) SideEffect() bool {
+	return m.Effect != EffectPure
+}
+
+// ValidateConfig 按字段声明校验节点 config；返回全部问题（errors.Join）
+func (m Manifest) ValidateConfig(cfg map[string]any) error {
+	var errs []error
+	declared := make(map[string]bool, len(m.Config))
+	for _, f := range m.Config {
+		declared[f.Name] = true
+		v, ok := cfg[f.Name]
+		if !ok || v == nil {
+			if f.Required {
+				errs = append(errs, fmt.Errorf("config.%s is required", f.Name))
+			}
+			continue
+		}
+		if !matchesType(f.Type, v) {
+			errs = append(errs, fmt.Errorf("config.%s must be %s", f.Name, f.Type))
+			continue
+		}
+		if len(f.Enum) > 0 && !inEnum(f.Enum, v) {
+			errs = append(errs, fmt.Errorf("config.%s must be one of %s", f.Name, enumString(f.Enum)))
+		}
+	}
+	if !m.AllowUnknownFields {
+		var unknown []string
+		for k := range cfg {
+			if !declared[k] {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			errs = append(errs, fmt.Errorf("config.%s is not declared by %s", k, m.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadManifestDir 读取 dir 下全部 *.json Manifest（每个文件一个 Manifest 或 Manifest 数组）
+func LoadManifestDir(dir string) ([]Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var out []Manifest
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		ms, err := parseManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("nodeplugin: %s: %w", filepath.Base(p), err)
+		}
+		out = append(out, ms...)
+	}
+	return out, nil
+}
+
+func parseManifests(data []byte) ([]Manifest, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var ms []Manifest
+		if err := json.Unmarshal(data, &ms); err != nil {
+			return nil, err
+		}
+		return ms, nil
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return []Manifest{m}, nil
+}
+
+// matchesType 判断 JSON 解码后的值是否符合字段类型；整数兼容 float64 / json.Number 与 Go 整型
+func matchesType(typ string, v any) bool {
+	switch typ {
+	case FieldString:
+		_, ok := v.(string)
+		return ok
+	case FieldBoolean:
+		_, ok := v.(bool)
+		return ok
+	case FieldNumber:
+		_, ok := toFloat(v)
+		return ok
+	case FieldInteger:
+		f, ok := toFloat(v)
+		return ok && f == float64(int64(f))
+	case FieldObject:
+		_, ok := v.(map[string]any)
+		return ok
+	case FieldArray:
+		_, ok := v.([]any)
+		return ok
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func inEnum(enum []any, v any) bool {
+	want, _ := json.Marshal(v)
+	for _, e := range enum {
+		got, _ := json.Marshal(e)
+		if string(got) == string(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []any) string {
+	b, _ := json.Marshal(enum)
+	return string(b)
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var sparkManifest = Manifest{
+	Type:        "spark_job",
+	Version:     "1.0.0",
+	Description: "提交 Spark 作业",
+	Config: []Field{
+		{Name: "cluster", Type: FieldString, Required: true},
+		{Name: "executors", Type: FieldInteger},
+		{Name: "mode", Type: FieldString, Enum: []any{"batch", "streaming"}},
+		{Name: "conf", Type: FieldObject},
+	},
+}
+
+func TestManifest_ValidateConfig(t *testing.T) {
+	var cfg map[string]any
+	_ = json.Unmarshal([]byte(`{"cluster":"prod","executors":4,"mode":"batch","conf":{"a":"b"}}`), &cfg)
+	if err := sparkManifest.ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	cfg = nil
+	_ = json.Unmarshal([]byte(`{"executors":2.5,"mode":"adhoc","extra":1}`), &cfg)
+	err := sparkManifest.ValidateConfig(cfg)
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	for _, want := range []string{"config.cluster is required", "config.executors must be integer", "config.mode must be one of", "config.extra is not declared"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+
+	loose := sparkManifest
+	loose.AllowUnknownFields = true
+	if err := loose.ValidateConfig(map[string]any{"cluster": "c", "extra": 1}); err != nil {
+		t.Errorf("allow_unknown_fields: %v", err)
+	}
+}
+
+func TestManifest_Validate(t *testing.T) {
+	cases := []Manifest{
+		{Type: "Spark"},
+		{Type: "spark_job", Effect: "maybe"},
+		{Type: "spark_job", Config: []Field{{Name: "a", Type: "date"}}},
+		{Type: "spark_job", Config: []Field{{Name: "a", Type: FieldString}, {Name: "a", Type: FieldString}}},
+		{Type: "spark_job", Config: []Field{{Name: "a", Type: FieldInteger, Enum: []any{"x"}}}},
+	}
+	for _, m := range cases {
+		if m.Validate() == nil {
+			t.Errorf("manifest %+v should be invalid", m)
+		}
+	}
+	if err := sparkManifest.Validate(); err != nil || !sparkManifest.SideEffect() {
+		t.Fatalf("spark manifest: err=%v side_effect=%v", err, sparkManifest.SideEffect())
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	exec := ExecutorFunc(func(ctx context.Context, req *Request) (any, error) { return req.Config["cluster"], nil })
+	Register(sparkManifest, exec)
+	defer func() {
+		pluginsMu.Lock()
+		delete(plugins, sparkManifest.Type)
+		delete(plugins, "db_migration")
+		pluginsMu.Unlock()
+	}()
+
+	// 代码注册的 Manifest 优先于文件 Manifest
+	if err := RegisterManifest(Manifest{Type: "spark_job"}); err != nil {
+		t.Fatalf("RegisterManifest over code plugin: %v", err)
+	}
+	p, ok := Lookup("spark_job")
+	if !ok || p.Executor == nil || p.Manifest.Version != "1.0.0" {
+		t.Fatalf("Lookup = %+v %v", p, ok)
+	}
+	if err := ValidateNode("spark_job", map[string]any{}); err == nil {
+		t.Error("missing required field accepted")
+	}
+	if err := ValidateNode("nope", nil); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("unknown type err = %v", err)
+	}
+
+	if err := RegisterManifest(Manifest{Type: "db_migration"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterManifest(Manifest{Type: "db_migration"}); err == nil {
+		t.Error("duplicate manifest accepted")
+	}
+	if types := Plugins(); len(types) < 2 || types[0].Manifest.Type != "db_migration" {
+		t.Errorf("Plugins() = %+v", types)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register twice should panic")
+		}
+	}()
+	Register(sparkManifest, exec)
+}
+
+func TestLoadManifestDir(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"type":"spark_job","config":[{"name":"cluster","type":"string","required":true}]}`), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "b.json"), []byte(`[{"type":"db_migration"},{"type":"dbt_run","effect":"pure"}]`), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`ignored`), 0o600)
+	ms, err := LoadManifestDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 || ms[0].Type != "spark_job" || !ms[0].Config[0].Required || ms[2].SideEffect() {
+		t.Fatalf("manifests = %+v", ms)
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{`), 0o600)
+	if _, err := LoadManifestDir(dir); err == nil || !strings.Contains(err.Error(), "c.json") {
+		t.Fatalf("bad manifest err = %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownType 节点类型未注册
+var ErrUnknownType = errors.New("nodeplugin: unknown node type")
+
+// Request 一次自定义节点执行的输入
+type Request struct {
+	JobID   string
+	AgentID string
+	NodeID  string
+	Type    string
+	Goal    string
+	// Config 节点 config（已按 Manifest 校验）
+	Config map[string]any
+	// Results 上游节点输出（key 为节点 ID），只读
+	Results map[string]any
+}
+
+// Executor 自定义节点执行器；返回值写入该节点结果，须可 JSON 序列化
+type Executor interface {
+	Execute(ctx context.Context, req *Request) (any, error)
+}
+
+// ExecutorFunc 函数形式的 Executor
+type ExecutorFunc func(ctx context.Context, req *Request) (any, error)
+
+// Execute 实现 Executor
+func (f ExecutorFunc) Execute(ctx context.Context, req *Request) (any, error) {
+	return f(ctx, req)
+}
+
+// Plugin 已注册的节点类型；Executor 为 nil 表示仅注册了 Manifest（本进程只做校验）
+type Plugin struct {
+	Manifest Manifest
+	Executor Executor
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Plugin)
+)
+
+// Register 注册节点类型的 Manifest 与 Executor，供插件包 init 调用；Manifest 无效或类型重复注册时 panic
+func Register(m Manifest, e Executor) {
+	if e == nil {
+		panic(fmt.Sprintf("nodeplugin: Register %q with nil executor", m.Type))
+	}
+	if err := m.Validate(); err != nil {
+		panic(err)
+	}
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if p, ok := plugins[m.Type]; ok && p.Executor != nil {
+		panic(fmt.Sprintf("nodeplugin: node type %q registered twice", m.Type))
+	}
+	plugins[m.Type] = Plugin{Manifest: m, Executor: e}
+}
+
+// RegisterManifest 仅注册 Manifest（API 等未编入插件代码的进程用于校验）；
+// 该类型已由 Register 注册时保留代码中的 Manifest
+func RegisterManifest(m Manifest) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if p, ok := plugins[m.Type]; ok {
+		if p.Executor != nil {
+			return nil
+		}
+		return fmt.Errorf("nodeplugin: manifest for node type %q registered twice", m.Type)
+	}
+	plugins[m.Type] = Plugin{Manifest: m}
+	return nil
+}
+
+// Lookup 按节点类型查找
+func Lookup(nodeType string) (Plugin, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	p, ok := plugins[nodeType]
+	return p, ok
+}
+
+// Plugins 返回全部已注册节点类型（按类型排序）
+func Plugins() []Plugin {
+	pluginsMu.RLock()
+	out := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		out = append(out, p)
+	}
+	pluginsMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Manifest.Type < out[j].Manifest.Type })
+	return out
+}
+
+// ValidateNode 按已注册 Manifest 校验节点 config；未注册返回 ErrUnknownType
+func ValidateNode(nodeType string, cfg map[string]any) error {
+	p, ok := Lookup(nodeType)
+	if !ok {
+		return ErrUnknownType
+	}
+	return p.Manifest.ValidateConfig(cfg)
+}
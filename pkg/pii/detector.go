@@ -0,0 +1,306 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pii
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rag-platform/pkg/redaction"
+)
+
+// Category PII 类别
+type Category string
+
+const (
+	CategoryEmail      Category = "email"
+	CategoryPhone      Category = "phone"
+	CategoryCreditCard Category = "credit_card"
+)
+
+// AllCategories 支持的全部类别
+var AllCategories = []Category{CategoryEmail, CategoryPhone, CategoryCreditCard}
+
+// Finding 一处检测结果：仅记录字段路径与类别，不保留原始匹配值
+type Finding struct {
+	Path     string   `json:"path"` // JSON 字段路径（如 "input.email"、"messages.0.content"）；非 JSON 文本为空
+	Category Category `json:"category"`
+}
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phoneRe 带分隔符的号码（+1 415-555-0100、(021) 5555 0100）、E.164（+8613800138000）与中国大陆手机号
+	phoneRe = regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{2,4}\)|\d{2,4})[\s.\-]\d{3,4}[\s.\-]\d{3,4}|\+\d{10,14}|(?:^|[^\d])1[3-9]\d{9}(?:[^\d]|$)`)
+	// cardRe 13–19 位数字，可用空格或 - 分组；命中后再做 Luhn 校验
+	cardRe = regexp.MustCompile(`(?:\d[ \-]?){12,18}\d`)
+)
+
+// Detector 基于正则的 PII 分类器；零值不可用，使用 NewDetector
+type Detector struct {
+	enabled map[Category]bool
+}
+
+// NewDetector 创建检测器；categories 为空时启用全部类别，未知类别忽略
+func NewDetector(categories []string) *Detector {
+	d := &Detector{enabled: make(map[Category]bool)}
+	for _, c := range categories {
+		for _, known := range AllCategories {
+			if Category(strings.ToLower(strings.TrimSpace(c))) == known {
+				d.enabled[known] = true
+			}
+		}
+	}
+	if len(d.enabled) == 0 {
+		for _, c := range AllCategories {
+			d.enabled[c] = true
+		}
+	}
+	return d
+}
+
+// ScanText 返回文本中检测到的类别（去重、有序）
+func (d *Detector) ScanText(s string) []Category {
+	if s == "" {
+		return nil
+	}
+	var out []Category
+	cardSpans := [][]int(nil)
+	if d.enabled[CategoryCreditCard] {
+		for _, loc := range cardRe.FindAllStringIndex(s, -1) {
+			if luhnValid(s[loc[0]:loc[1]]) {
+				cardSpans = append(cardSpans, loc)
+			}
+		}
+		if len(cardSpans) > 0 {
+			out = append(out, CategoryCreditCard)
+		}
+	}
+	if d.enabled[CategoryEmail] && emailRe.MatchString(s) {
+		out = append(out, CategoryEmail)
+	}
+	if d.enabled[CategoryPhone] {
+		for _, loc := range phoneRe.FindAllStringIndex(s, -1) {
+			if !overlaps(loc, cardSpans) && phoneDigits(s[loc[0]:loc[1]]) {
+				out = append(out, CategoryPhone)
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// ScanJSON 遍历 JSON 中的字符串值（含对象键对应的值与数组元素）逐一检测；非合法 JSON 时按纯文本检测，Path 为空
+func (d *Detector) ScanJSON(data []byte) []Finding {
+	if len(data) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		var out []Finding
+		for _, c := range d.ScanText(string(data)) {
+			out = append(out, Finding{Category: c})
+		}
+		return out
+	}
+	var out []Finding
+	d.walk(v, "", &out)
+	return out
+}
+
+func (d *Detector) walk(v interface{}, path string, out *[]Finding) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			d.walk(t[k], joinPath(path, k), out)
+		}
+	case []interface{}:
+		for i, item := range t {
+			d.walk(item, joinPath(path, strconv.Itoa(i)), out)
+		}
+	case string:
+		for _, c := range d.ScanText(t) {
+			*out = append(*out, Finding{Path: path, Category: c})
+		}
+	case float64:
+		// 数字形式的卡号（JSON number）
+		if d.enabled[CategoryCreditCard] {
+			s := strconv.FormatFloat(t, 'f', -1, 64)
+			if len(s) >= 13 && len(s) <= 19 && luhnValid(s) {
+				*out = append(*out, Finding{Path: path, Category: CategoryCreditCard})
+			}
+		}
+	}
+}
+
+// RedactText 将文本中检测到的 PII 替换为 [REDACTED:<类别>]，返回替换后的文本与命中类别（去重、有序）
+func (d *Detector) RedactText(s string) (string, []Category) {
+	if s == "" {
+		return s, nil
+	}
+	type span struct {
+		start, end int
+		cat        Category
+	}
+	var spans []span
+	var taken [][]int
+	add := func(start, end int, cat Category) {
+		loc := []int{start, end}
+		if start >= end || overlaps(loc, taken) {
+			return
+		}
+		taken = append(taken, loc)
+		spans = append(spans, span{start, end, cat})
+	}
+	if d.enabled[CategoryCreditCard] {
+		for _, loc := range cardRe.FindAllStringIndex(s, -1) {
+			if luhnValid(s[loc[0]:loc[1]]) {
+				add(loc[0], loc[1], CategoryCreditCard)
+			}
+		}
+	}
+	if d.enabled[CategoryEmail] {
+		for _, loc := range emailRe.FindAllStringIndex(s, -1) {
+			add(loc[0], loc[1], CategoryEmail)
+		}
+	}
+	if d.enabled[CategoryPhone] {
+		for _, loc := range phoneRe.FindAllStringIndex(s, -1) {
+			start, end := loc[0], loc[1]
+			// 手机号模式含前后各一个非数字边界字符，替换时保留
+			for start < end && !isDigit(s[start]) && s[start] != '+' && s[start] != '(' {
+				start++
+			}
+			for end > start && !isDigit(s[end-1]) {
+				end--
+			}
+			if phoneDigits(s[start:end]) {
+				add(start, end, CategoryPhone)
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return s, nil
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	seen := make(map[Category]bool)
+	var cats []Category
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(s[last:sp.start])
+		b.WriteString("[REDACTED:" + string(sp.cat) + "]")
+		last = sp.end
+		if !seen[sp.cat] {
+			seen[sp.cat] = true
+			cats = append(cats, sp.cat)
+		}
+	}
+	b.WriteString(s[last:])
+	sort.Slice(cats, func(i, j int) bool { return cats[i] < cats[j] })
+	return b.String(), cats
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Categories 返回 findings 中出现的类别（去重、有序）
+func Categories(findings []Finding) []Category {
+	seen := make(map[Category]bool)
+	var out []Category
+	for _, f := range findings {
+		if !seen[f.Category] {
+			seen[f.Category] = true
+			out = append(out, f.Category)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// FieldMasks 将 findings 转为脱敏规则（按路径去重）；Path 为空的文本级检测无法定位字段，跳过
+func FieldMasks(findings []Finding, mode redaction.RedactionMode, salt string) []redaction.FieldMask {
+	seen := make(map[string]bool)
+	var out []redaction.FieldMask
+	for _, f := range findings {
+		if f.Path == "" || seen[f.Path] {
+			continue
+		}
+		seen[f.Path] = true
+		out = append(out, redaction.FieldMask{FieldPath: f.Path, Mode: mode, Salt: salt})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FieldPath < out[j].FieldPath })
+	return out
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// luhnValid 卡号 Luhn 校验（忽略空格与 -）
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		if n%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// phoneDigits 号码数字位数需在 7–15 之间（E.164 上限）
+func phoneDigits(s string) bool {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n >= 7 && n <= 15
+}
+
+func overlaps(loc []int, spans [][]int) bool {
+	for _, sp := range spans {
+		if loc[0] < sp[1] && sp[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
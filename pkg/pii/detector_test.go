@@ -0,0 +1,81 @@
+package pii
+
+import (
+	"reflect"
+	"testing"
+
+	"rag-platform/pkg/redaction"
+)
+
+func TestDetector_ScanText(t *testing.T) {
+	d := NewDetector(nil)
+	cases := []struct {
+		in   string
+		want []Category
+	}{
+		{"contact alice@example.com please", []Category{CategoryEmail}},
+		{"call +1 415-555-0100 tomorrow", []Category{CategoryPhone}},
+		{"手机 13800138000", []Category{CategoryPhone}},
+		{"card 4111 1111 1111 1111 exp 12/29", []Category{CategoryCreditCard}},
+		{"order 4111111111111112 is not a card", nil}, // Luhn 校验失败
+		{"job started at 2026-10-17T12:30:45Z", nil},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := d.ScanText(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ScanText(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDetector_RedactText(t *testing.T) {
+	d := NewDetector(nil)
+	got, cats := d.RedactText("mail alice@example.com, 手机 13800138000，card 4111 1111 1111 1111 ok")
+	want := "mail [REDACTED:email], 手机 [REDACTED:phone]，card [REDACTED:credit_card] ok"
+	if got != want {
+		t.Fatalf("RedactText = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(cats, []Category{CategoryCreditCard, CategoryEmail, CategoryPhone}) {
+		t.Fatalf("categories = %v", cats)
+	}
+	if got, cats := d.RedactText("nothing sensitive"); got != "nothing sensitive" || cats != nil {
+		t.Fatalf("clean text changed: %q %v", got, cats)
+	}
+}
+
+func TestDetector_CategoryFilter(t *testing.T) {
+	d := NewDetector([]string{"email"})
+	if got := d.ScanText("alice@example.com +1 415-555-0100"); !reflect.DeepEqual(got, []Category{CategoryEmail}) {
+		t.Fatalf("only email should be enabled, got %v", got)
+	}
+}
+
+func TestDetector_ScanJSON_PathsWithoutValues(t *testing.T) {
+	d := NewDetector(nil)
+	payload := []byte(`{"input":{"to":"bob@example.com","n":1},"messages":[{"content":"hi"},{"content":"card 4111-1111-1111-1111"}]}`)
+	got := d.ScanJSON(payload)
+	want := []Finding{
+		{Path: "input.to", Category: CategoryEmail},
+		{Path: "messages.1.content", Category: CategoryCreditCard},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ScanJSON = %+v, want %+v", got, want)
+	}
+
+	// 非 JSON 按文本检测，不含路径
+	if got := d.ScanJSON([]byte("reach me at bob@example.com")); len(got) != 1 || got[0].Path != "" {
+		t.Fatalf("plain text scan = %+v", got)
+	}
+}
+
+func TestFieldMasks_DedupAndSkipUnlocated(t *testing.T) {
+	masks := FieldMasks([]Finding{
+		{Path: "b", Category: CategoryEmail},
+		{Path: "a", Category: CategoryPhone},
+		{Path: "b", Category: CategoryPhone},
+		{Category: CategoryEmail},
+	}, redaction.RedactionModeHash, "s")
+	if len(masks) != 2 || masks[0].FieldPath != "a" || masks[1].FieldPath != "b" || masks[0].Mode != redaction.RedactionModeHash {
+		t.Fatalf("unexpected masks: %+v", masks)
+	}
+}
@@ -19,9 +19,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"rag-platform/pkg/evidence"
+	"rag-platform/pkg/redaction"
 )
 
 // ExportEvidenceZip 导出证据包为 ZIP 格式
@@ -50,6 +54,45 @@ func ExportEvidenceZip(
 		return nil, fmt.Errorf("hash chain validation failed: %w", err)
 	}
 
+	if err := validInclude(opts.Include); err != nil {
+		return nil, err
+	}
+	redact := opts.RedactionEnabled && opts.RedactionPolicy != nil
+	sourceEventCount := len(events)
+	sourceRootHash := events[len(events)-1].Hash
+
+	// 2.1 快照基线：仅保留快照之后的事件，首事件 prev_hash 续接基线事件 hash
+	baseHash := ""
+	var baseRef *SnapshotRef
+	if opts.BaseSnapshot != nil {
+		if redact {
+			return nil, ErrSnapshotWithRedaction
+		}
+		v := opts.BaseSnapshot.Version
+		if v <= 0 || v > len(events) {
+			return nil, fmt.Errorf("snapshot version %d out of range (1..%d)", v, len(events))
+		}
+		baseHash = events[v-1].Hash
+		baseRef = &SnapshotRef{Version: v, EventHash: baseHash, CreatedAt: opts.BaseSnapshot.CreatedAt, File: "snapshot.json"}
+		events = events[v:]
+	}
+
+	// 2.2 按策略脱敏：原链已校验通过，脱敏后基于新内容重建哈希链，manifest 记录原链尾 hash
+	originalRootHash := ""
+	if redact {
+		originalRootHash = sourceRootHash
+		events = redactEvents(events, opts.RedactionPolicy)
+	}
+
+	// 2.3 选择性导出与 payload 截断：改变了事件集合或内容时自基线重建哈希链
+	events = filterEvents(events, opts.Include)
+	events, truncated := truncateEvents(events, opts.MaxPayloadBytes)
+	if opts.Include != "" || truncated > 0 {
+		originalRootHash = sourceRootHash
+		events = relinkChain(events, baseHash)
+	}
+	partial := baseRef != nil || opts.Include != ""
+
 	// 3. 获取 ledger
 	var toolInvocations []ToolInvocation
 	if ledger != nil {
@@ -58,6 +101,9 @@ func ExportEvidenceZip(
 			return nil, fmt.Errorf("failed to list tool invocations: %w", err)
 		}
 	}
+	if partial {
+		toolInvocations = filterLedger(toolInvocations, events)
+	}
 
 	// 4. 生成文件内容
 	eventsNDJSON, err := eventsToNDJSON(events)
@@ -76,12 +122,33 @@ func ExportEvidenceZip(
 		return nil, fmt.Errorf("failed to serialize metadata: %w", err)
 	}
 
+	// 4.1 RAG 回答引用（取自 reasoning_snapshot，便于审阅者直接核对答案来源）
+	citationsJSON, err := json.MarshalIndent(extractCitations(events), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize citations: %w", err)
+	}
+
+	// 4.2 节点规划理由（取自 plan_generated 的 task_graph，便于审计查看「为何这样规划」）
+	rationaleJSON, err := json.MarshalIndent(extractPlanRationale(events), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize plan rationale: %w", err)
+	}
+
 	// 5. 计算文件哈希
 	fileHashes := map[string]string{
-		"events.ndjson": ComputeFileHash(eventsNDJSON),
-		"ledger.ndjson": ComputeFileHash(ledgerNDJSON),
-		"metadata.json": ComputeFileHash(metadataJSON),
+		"events.ndjson":       ComputeFileHash(eventsNDJSON),
+		"ledger.ndjson":       ComputeFileHash(ledgerNDJSON),
+		"metadata.json":       ComputeFileHash(metadataJSON),
+		"citations.json":      ComputeFileHash(citationsJSON),
+		"plan_rationale.json": ComputeFileHash(rationaleJSON),
+	}
+	if baseRef != nil {
+		fileHashes[baseRef.File] = ComputeFileHash(opts.BaseSnapshot.Data)
 	}
+	if len(opts.Branding) > 0 {
+		fileHashes["branding.json"] = ComputeFileHash(opts.Branding)
+	}
+	rootHash := chainTail(events, baseHash)
 
 	// 6. 生成 manifest
 	manifest := Manifest{
@@ -90,11 +157,25 @@ func ExportEvidenceZip(
 		ExportedAt:     time.Now().UTC(),
 		EventCount:     len(events),
 		LedgerCount:    len(toolInvocations),
-		FirstEventHash: events[0].Hash,
-		LastEventHash:  events[len(events)-1].Hash,
+		LastEventHash:  rootHash,
 		FileHashes:     fileHashes,
 		RuntimeVersion: opts.RuntimeVersion,
 		SchemaVersion:  opts.SchemaVersion,
+		BaseSnapshot:   baseRef,
+		Include:        opts.Include,
+	}
+	if len(events) > 0 {
+		manifest.FirstEventHash = events[0].Hash
+	}
+	if originalRootHash != "" {
+		manifest.Redacted = redact
+		manifest.OriginalRootHash = originalRootHash
+	}
+	if truncated > 0 {
+		manifest.TruncatedEvents = truncated
+	}
+	if partial || truncated > 0 {
+		manifest.SourceEventCount = sourceEventCount
 	}
 	if manifest.RuntimeVersion == "" {
 		manifest.RuntimeVersion = "2.0.0"
@@ -111,7 +192,7 @@ func ExportEvidenceZip(
 	// 7. 生成 proof summary
 	proofSummary := ProofSummary{
 		JobID:           jobID,
-		RootHash:        events[len(events)-1].Hash,
+		RootHash:        rootHash,
 		ChainValidated:  true,
 		LedgerValidated: true,
 		GeneratedBy:     fmt.Sprintf("aetheris %s", opts.RuntimeVersion),
@@ -129,11 +210,19 @@ func ExportEvidenceZip(
 	zw := zip.NewWriter(buf)
 
 	files := map[string][]byte{
-		"manifest.json": manifestJSON,
-		"events.ndjson": eventsNDJSON,
-		"ledger.ndjson": ledgerNDJSON,
-		"proof.json":    proofJSON,
-		"metadata.json": metadataJSON,
+		"manifest.json":       manifestJSON,
+		"events.ndjson":       eventsNDJSON,
+		"ledger.ndjson":       ledgerNDJSON,
+		"proof.json":          proofJSON,
+		"metadata.json":       metadataJSON,
+		"citations.json":      citationsJSON,
+		"plan_rationale.json": rationaleJSON,
+	}
+	if baseRef != nil {
+		files[baseRef.File] = opts.BaseSnapshot.Data
+	}
+	if len(opts.Branding) > 0 {
+		files["branding.json"] = opts.Branding
 	}
 
 	for filename, content := range files {
@@ -153,6 +242,36 @@ func ExportEvidenceZip(
 	return buf.Bytes(), nil
 }
 
+// ErrSnapshotWithRedaction 快照内容含未脱敏的执行状态，基于快照的导出不能与脱敏同时使用
+var ErrSnapshotWithRedaction = errors.New("snapshot-based export is not supported with redaction")
+
+// chainTail 返回链尾 hash；无事件时为基线 hash
+func chainTail(events []Event, baseHash string) string {
+	if len(events) == 0 {
+		return baseHash
+	}
+	return events[len(events)-1].Hash
+}
+
+// redactEvents 对有匹配规则的事件 payload 应用脱敏策略并重建哈希链；非 JSON 对象的 payload 保持原样
+func redactEvents(events []Event, policy *redaction.RedactionPolicy) []Event {
+	engine := redaction.NewEngine(policy, nil)
+	out := make([]Event, len(events))
+	prevHash := ""
+	for i, e := range events {
+		if e.Payload != "" && (len(policy.EventRules[e.Type]) > 0 || len(policy.GlobalRules) > 0) {
+			if redacted, err := engine.RedactData(e.Type, []byte(e.Payload)); err == nil {
+				e.Payload = string(redacted)
+			}
+		}
+		e.PrevHash = prevHash
+		e.Hash = ComputeEventHash(e)
+		out[i] = e
+		prevHash = e.Hash
+	}
+	return out
+}
+
 // eventsToNDJSON 将事件列表转换为 NDJSON 格式
 func eventsToNDJSON(events []Event) ([]byte, error) {
 	buf := new(bytes.Buffer)
@@ -181,12 +300,58 @@ func ledgerToNDJSON(ledger []ToolInvocation) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// extractCitations 从 reasoning_snapshot 事件中提取各 step 的 RAG 引用
+func extractCitations(events []Event) []evidence.StepCitations {
+	ev := make([]evidence.Event, 0, len(events))
+	for _, e := range events {
+		ev = append(ev, evidence.Event{ID: e.ID, JobID: e.JobID, Type: e.Type, Payload: []byte(e.Payload), CreatedAt: e.CreatedAt})
+	}
+	return evidence.CitationsFromEvents(ev)
+}
+
+// extractPlanRationale 按 plan_generated 顺序提取各节点的规划理由；无计划事件时返回空数组
+func extractPlanRationale(events []Event) []PlanRationale {
+	out := make([]PlanRationale, 0)
+	for _, e := range events {
+		if e.Type != "plan_generated" {
+			continue
+		}
+		var payload struct {
+			PlanHash  string `json:"plan_hash"`
+			TaskGraph struct {
+				Nodes []struct {
+					ID        string `json:"id"`
+					Type      string `json:"type"`
+					ToolName  string `json:"tool_name"`
+					Workflow  string `json:"workflow"`
+					Rationale string `json:"rationale"`
+				} `json:"nodes"`
+			} `json:"task_graph"`
+		}
+		if err := json.Unmarshal([]byte(e.Payload), &payload); err != nil {
+			continue
+		}
+		pr := PlanRationale{EventID: e.ID, PlanHash: payload.PlanHash, CreatedAt: e.CreatedAt, Nodes: make([]NodeRationale, 0, len(payload.TaskGraph.Nodes))}
+		for _, n := range payload.TaskGraph.Nodes {
+			tool := n.ToolName
+			if tool == "" {
+				tool = n.Workflow
+			}
+			pr.Nodes = append(pr.Nodes, NodeRationale{NodeID: n.ID, Type: n.Type, Tool: tool, Rationale: n.Rationale})
+		}
+		out = append(out, pr)
+	}
+	return out
+}
+
 func extractJobMetadata(jobID string, events []Event) JobMetadata {
 	metadata := JobMetadata{
-		JobID:     jobID,
-		Status:    "unknown",
-		CreatedAt: events[0].CreatedAt,
-		UpdatedAt: events[len(events)-1].CreatedAt,
+		JobID:  jobID,
+		Status: "unknown",
+	}
+	if len(events) > 0 {
+		metadata.CreatedAt = events[0].CreatedAt
+		metadata.UpdatedAt = events[len(events)-1].CreatedAt
 	}
 	for _, e := range events {
 		switch e.Type {
@@ -38,13 +38,21 @@ func ComputeEventHash(e Event) string {
 
 // ValidateChain 验证完整哈希链
 func ValidateChain(events []Event) error {
+	return ValidateChainFrom(events, "")
+}
+
+// ValidateChainFrom 验证自 baseHash 续接的哈希链：首事件 PrevHash 须等于 baseHash（完整链为空，快照基线包为基线事件 hash）
+func ValidateChainFrom(events []Event, baseHash string) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	// 第一个事件的 PrevHash 应该为空
-	if events[0].PrevHash != "" {
-		return fmt.Errorf("first event prev_hash should be empty, got: %s", events[0].PrevHash)
+	// 第一个事件的 PrevHash 应等于基线（完整链为空）
+	if events[0].PrevHash != baseHash {
+		if baseHash == "" {
+			return fmt.Errorf("first event prev_hash should be empty, got: %s", events[0].PrevHash)
+		}
+		return fmt.Errorf("first event prev_hash should be snapshot base %s, got: %s", baseHash, events[0].PrevHash)
 	}
 
 	// 验证第一个事件的 hash
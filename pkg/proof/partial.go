@@ -0,0 +1,230 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proof
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// truncateMinFieldBytes 小于该大小的顶层字段不截断
+const truncateMinFieldBytes = 64
+
+// truncateKeepFields 始终保留的索引字段：校验 ledger 一致性与按节点聚合依赖这些字段
+var truncateKeepFields = map[string]bool{
+	"node_id":         true,
+	"step_id":         true,
+	"idempotency_key": true,
+	"tool_name":       true,
+	"outcome":         true,
+	"result_type":     true,
+}
+
+// lifecycleEventTypes 选择性导出始终保留的 Job 生命周期事件，保证 metadata.json 可还原
+var lifecycleEventTypes = map[string]bool{
+	"job_created":   true,
+	"job_completed": true,
+	"job_failed":    true,
+	"job_cancelled": true,
+}
+
+// toolInvocationEventTypes 工具调用相关事件
+var toolInvocationEventTypes = map[string]bool{
+	"tool_invocation_started":  true,
+	"tool_invocation_finished": true,
+	"tool_called":              true,
+	"tool_returned":            true,
+}
+
+// validInclude 校验选择性导出范围
+func validInclude(include string) error {
+	switch include {
+	case "", IncludeToolInvocations, IncludeFailedSteps:
+		return nil
+	default:
+		return fmt.Errorf("unsupported include %q (want %s or %s)", include, IncludeToolInvocations, IncludeFailedSteps)
+	}
+}
+
+// filterEvents 按 include 选取事件；生命周期事件始终保留
+func filterEvents(events []Event, include string) []Event {
+	if include == "" {
+		return events
+	}
+	var failedNodes map[string]bool
+	if include == IncludeFailedSteps {
+		failedNodes = failedNodeIDs(events)
+	}
+	out := make([]Event, 0, len(events))
+	for _, e := range events {
+		keep := lifecycleEventTypes[e.Type]
+		switch include {
+		case IncludeToolInvocations:
+			keep = keep || toolInvocationEventTypes[e.Type]
+		case IncludeFailedSteps:
+			keep = keep || failedNodes[eventNodeID(e)]
+		}
+		if keep {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// failedNodeIDs 收集失败步骤的 node_id：node_finished 的 result_type 为 *_failure，或 job_failed 指向的节点
+func failedNodeIDs(events []Event) map[string]bool {
+	failed := make(map[string]bool)
+	for _, e := range events {
+		if e.Type != "node_finished" && e.Type != "job_failed" {
+			continue
+		}
+		var pl map[string]interface{}
+		if json.Unmarshal([]byte(e.Payload), &pl) != nil {
+			continue
+		}
+		nodeID, _ := pl["node_id"].(string)
+		if nodeID == "" {
+			continue
+		}
+		resultType, _ := pl["result_type"].(string)
+		if e.Type == "job_failed" || strings.HasSuffix(resultType, "_failure") {
+			failed[nodeID] = true
+		}
+	}
+	return failed
+}
+
+// eventNodeID 返回事件 payload 中的 node_id（无则 step_id）
+func eventNodeID(e Event) string {
+	var pl map[string]interface{}
+	if json.Unmarshal([]byte(e.Payload), &pl) != nil {
+		return ""
+	}
+	if id, _ := pl["node_id"].(string); id != "" {
+		return id
+	}
+	id, _ := pl["step_id"].(string)
+	return id
+}
+
+// truncateEvents 截断超出 maxBytes 的事件 payload，记录原 payload sha256；返回新事件列表与截断数
+func truncateEvents(events []Event, maxBytes int) ([]Event, int) {
+	if maxBytes <= 0 {
+		return events, 0
+	}
+	out := make([]Event, len(events))
+	n := 0
+	for i, e := range events {
+		if len(e.Payload) > maxBytes {
+			if truncated, ok := truncatePayload(e.Payload, maxBytes); ok {
+				e.OriginalPayloadHash = ComputeFileHash([]byte(e.Payload))
+				e.Payload = truncated
+				n++
+			}
+		}
+		out[i] = e
+	}
+	return out, n
+}
+
+// truncatePayload 将 JSON 对象中较大的顶层字段（由大到小）替换为截断标记，直到不超过 maxBytes 或无可截断字段；
+// 非 JSON 对象整体替换为截断标记
+func truncatePayload(payload string, maxBytes int) (string, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &obj); err != nil || obj == nil {
+		return truncationMarker([]byte(payload)), true
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(obj[keys[i]]) != len(obj[keys[j]]) {
+			return len(obj[keys[i]]) > len(obj[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	size := len(payload)
+	changed := false
+	for _, k := range keys {
+		if size <= maxBytes || len(obj[k]) < truncateMinFieldBytes {
+			break
+		}
+		if truncateKeepFields[k] {
+			continue
+		}
+		marker := json.RawMessage(truncationMarker(obj[k]))
+		size += len(marker) - len(obj[k])
+		obj[k] = marker
+		changed = true
+	}
+	if !changed {
+		return payload, false
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return payload, false
+	}
+	return string(data), true
+}
+
+// truncationMarker 截断标记：保留原值 sha256 与字节数
+func truncationMarker(original []byte) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"truncated": true,
+		"sha256":    ComputeFileHash(original),
+		"bytes":     len(original),
+	})
+	return string(data)
+}
+
+// filterLedger 仅保留在导出事件中出现过的工具调用，保证部分导出的 ledger 与事件一致
+func filterLedger(ledger []ToolInvocation, events []Event) []ToolInvocation {
+	keys := make(map[string]bool)
+	for _, e := range events {
+		if e.Type != "tool_invocation_started" && e.Type != "tool_invocation_finished" {
+			continue
+		}
+		var pl map[string]interface{}
+		if json.Unmarshal([]byte(e.Payload), &pl) != nil {
+			continue
+		}
+		if key, _ := pl["idempotency_key"].(string); key != "" {
+			keys[key] = true
+		}
+	}
+	out := make([]ToolInvocation, 0, len(ledger))
+	for _, inv := range ledger {
+		if keys[inv.IdempotencyKey] {
+			out = append(out, inv)
+		}
+	}
+	return out
+}
+
+// relinkChain 基于当前内容自 baseHash 重建哈希链（脱敏、截断或选择性导出后使用）
+func relinkChain(events []Event, baseHash string) []Event {
+	out := make([]Event, len(events))
+	prevHash := baseHash
+	for i, e := range events {
+		e.PrevHash = prevHash
+		e.Hash = ComputeEventHash(e)
+		out[i] = e
+		prevHash = e.Hash
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proof
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rag-platform/pkg/redaction"
+)
+
+// chainEvents 按 (type, payload) 构造带哈希链的事件
+func chainEvents(jobID string, specs [][2]string) []Event {
+	events := make([]Event, len(specs))
+	prevHash := ""
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, sp := range specs {
+		e := Event{
+			ID:        string(rune('a' + i)),
+			JobID:     jobID,
+			Type:      sp[0],
+			Payload:   sp[1],
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+			PrevHash:  prevHash,
+		}
+		e.Hash = ComputeEventHash(e)
+		prevHash = e.Hash
+		events[i] = e
+	}
+	return events
+}
+
+func TestEvidence_SnapshotBase(t *testing.T) {
+	jobID := "job_snapshot"
+	events := makeTestEvents(jobID, 10)
+	snapshot := []byte(`{"completed_node_ids":["n1","n2"]}`)
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID,
+		memJobStore{events: events}, memLedger{},
+		ExportOptions{BaseSnapshot: &SnapshotBase{Version: 6, Data: snapshot}},
+	)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	result := VerifyEvidenceZip(zipBytes)
+	if !result.OK {
+		t.Fatalf("snapshot-based package should verify, errors: %v", result.Errors)
+	}
+	if len(result.Events) != 4 || result.Events[0].Hash != events[6].Hash {
+		t.Fatalf("expected the 4 original events after the snapshot, got %d", len(result.Events))
+	}
+	m := result.Manifest
+	if m.BaseSnapshot == nil || m.BaseSnapshot.Version != 6 || m.BaseSnapshot.EventHash != events[5].Hash || m.SourceEventCount != 10 {
+		t.Fatalf("manifest base = %+v, source=%d", m.BaseSnapshot, m.SourceEventCount)
+	}
+	pkg, err := ReadEvidenceZip(zipBytes)
+	if err != nil || !bytes.Equal(pkg.Snapshot, snapshot) {
+		t.Fatalf("ReadEvidenceZip snapshot = %s, err=%v", pkg.Snapshot, err)
+	}
+
+	tampered := tamperZipFile(zipBytes, "snapshot.json", func(b []byte) []byte {
+		return bytes.Replace(b, []byte("n2"), []byte("n9"), 1)
+	})
+	if VerifyEvidenceZip(tampered).OK {
+		t.Fatal("tampered snapshot.json should fail verification")
+	}
+}
+
+func TestEvidence_SnapshotCoversAllEvents(t *testing.T) {
+	jobID := "job_snapshot_all"
+	events := makeTestEvents(jobID, 3)
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID,
+		memJobStore{events: events}, memLedger{},
+		ExportOptions{BaseSnapshot: &SnapshotBase{Version: 3, Data: []byte(`{}`)}},
+	)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	result := VerifyEvidenceZip(zipBytes)
+	if !result.OK || len(result.Events) != 0 {
+		t.Fatalf("empty tail should verify: ok=%v events=%d errors=%v", result.OK, len(result.Events), result.Errors)
+	}
+}
+
+func TestEvidence_SnapshotRejectsRedaction(t *testing.T) {
+	events := makeTestEvents("job_snap_redact", 3)
+	_, err := ExportEvidenceZip(context.Background(), "job_snap_redact",
+		memJobStore{events: events}, memLedger{},
+		ExportOptions{
+			BaseSnapshot:     &SnapshotBase{Version: 1, Data: []byte(`{}`)},
+			RedactionEnabled: true,
+			RedactionPolicy:  &redaction.RedactionPolicy{},
+		},
+	)
+	if !errors.Is(err, ErrSnapshotWithRedaction) {
+		t.Fatalf("err = %v, want ErrSnapshotWithRedaction", err)
+	}
+}
+
+func TestEvidence_TruncatedPayloads(t *testing.T) {
+	jobID := "job_truncate"
+	key := strings.Repeat("k", 80)
+	big := strings.Repeat("x", 2000)
+	events := chainEvents(jobID, [][2]string{
+		{"job_created", `{"goal":"g"}`},
+		{"tool_invocation_started", `{"idempotency_key":"` + key + `","tool_name":"search"}`},
+		{"tool_invocation_finished", `{"idempotency_key":"` + key + `","tool_name":"search","outcome":"success","result":"` + big + `"}`},
+		{"job_completed", `{}`},
+	})
+	ledger := []ToolInvocation{{JobID: jobID, IdempotencyKey: key, ToolName: "search", Committed: true}}
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID,
+		memJobStore{events: events}, memLedger{invocations: ledger},
+		ExportOptions{MaxPayloadBytes: 512},
+	)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	result := VerifyEvidenceZip(zipBytes)
+	if !result.OK || !result.LedgerValid {
+		t.Fatalf("truncated package should verify, errors: %v", result.Errors)
+	}
+	if result.Manifest.TruncatedEvents != 1 || result.Manifest.OriginalRootHash != events[3].Hash {
+		t.Fatalf("manifest = %+v", result.Manifest)
+	}
+	finished := result.Events[2]
+	if len(finished.Payload) > 512 || !strings.Contains(finished.Payload, key) {
+		t.Fatalf("truncated payload should keep idempotency_key and fit limit: %s", finished.Payload)
+	}
+	if !strings.Contains(finished.Payload, ComputeFileHash([]byte(`"`+big+`"`))) {
+		t.Fatalf("truncated field should retain sha256 of original value: %s", finished.Payload)
+	}
+	if finished.OriginalPayloadHash != ComputeFileHash([]byte(events[2].Payload)) {
+		t.Fatalf("original_payload_hash = %q", finished.OriginalPayloadHash)
+	}
+}
+
+func TestEvidence_SelectiveInclude(t *testing.T) {
+	jobID := "job_include"
+	events := chainEvents(jobID, [][2]string{
+		{"job_created", `{"goal":"g"}`},
+		{"node_started", `{"node_id":"n1"}`},
+		{"tool_invocation_started", `{"idempotency_key":"k1","tool_name":"search","node_id":"n1"}`},
+		{"tool_invocation_finished", `{"idempotency_key":"k1","tool_name":"search","outcome":"success","node_id":"n1"}`},
+		{"node_finished", `{"node_id":"n1","result_type":"success"}`},
+		{"node_started", `{"node_id":"n2"}`},
+		{"node_finished", `{"node_id":"n2","result_type":"permanent_failure"}`},
+		{"job_failed", `{"node_id":"n2"}`},
+	})
+	ledger := []ToolInvocation{{JobID: jobID, IdempotencyKey: "k1", ToolName: "search", Committed: true}}
+
+	for _, tc := range []struct {
+		include    string
+		wantTypes  []string
+		wantLedger int
+	}{
+		{IncludeToolInvocations, []string{"job_created", "tool_invocation_started", "tool_invocation_finished", "job_failed"}, 1},
+		{IncludeFailedSteps, []string{"job_created", "node_started", "node_finished", "job_failed"}, 0},
+	} {
+		t.Run(tc.include, func(t *testing.T) {
+			zipBytes, err := ExportEvidenceZip(context.Background(), jobID,
+				memJobStore{events: events}, memLedger{invocations: ledger},
+				ExportOptions{Include: tc.include},
+			)
+			if err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+			result := VerifyEvidenceZip(zipBytes)
+			if !result.OK {
+				t.Fatalf("selective package should verify, errors: %v", result.Errors)
+			}
+			var got []string
+			for _, e := range result.Events {
+				got = append(got, e.Type)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.wantTypes, ",") {
+				t.Fatalf("event types = %v, want %v", got, tc.wantTypes)
+			}
+			if result.Manifest.LedgerCount != tc.wantLedger || result.Manifest.Include != tc.include || result.Manifest.SourceEventCount != len(events) {
+				t.Fatalf("manifest = %+v", result.Manifest)
+			}
+		})
+	}
+
+	if _, err := ExportEvidenceZip(context.Background(), jobID, memJobStore{events: events}, memLedger{}, ExportOptions{Include: "bogus"}); err == nil {
+		t.Fatal("unknown include should be rejected")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proof
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReadEvidenceZip 解析证据包内容（不做完整性校验，校验请用 VerifyEvidenceZip），用于离线查看 trace
+func ReadEvidenceZip(zipBytes []byte) (*EvidencePackage, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zip: %w", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zipReader.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		files[f.Name] = data
+	}
+
+	pkg := &EvidencePackage{}
+	manifestData, ok := files["manifest.json"]
+	if !ok {
+		return nil, fmt.Errorf("manifest.json not found")
+	}
+	if err := json.Unmarshal(manifestData, &pkg.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	eventsData, ok := files["events.ndjson"]
+	if !ok {
+		return nil, fmt.Errorf("events.ndjson not found")
+	}
+	if pkg.Events, err = parseEventsNDJSON(eventsData); err != nil {
+		return nil, err
+	}
+	if data, ok := files["ledger.ndjson"]; ok {
+		if pkg.Ledger, err = parseLedgerNDJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	if data, ok := files["proof.json"]; ok {
+		if err := json.Unmarshal(data, &pkg.Proof); err != nil {
+			return nil, fmt.Errorf("failed to parse proof: %w", err)
+		}
+	}
+	if data, ok := files["metadata.json"]; ok {
+		if err := json.Unmarshal(data, &pkg.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	if data, ok := files["plan_rationale.json"]; ok {
+		if err := json.Unmarshal(data, &pkg.PlanRationale); err != nil {
+			return nil, fmt.Errorf("failed to parse plan rationale: %w", err)
+		}
+	}
+	if ref := pkg.Manifest.BaseSnapshot; ref != nil {
+		pkg.Snapshot = files[ref.File]
+	}
+	pkg.Branding = files["branding.json"]
+	if pkg.Metadata.JobID == "" {
+		pkg.Metadata.JobID = pkg.Manifest.JobID
+	}
+	return pkg, nil
+}
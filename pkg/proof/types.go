@@ -16,7 +16,10 @@ package proof
 
 import (
 	"context"
+	"encoding/json"
 	"time"
+
+	"rag-platform/pkg/redaction"
 )
 
 // EvidencePackage 证据包结构
@@ -26,6 +29,28 @@ type EvidencePackage struct {
 	Ledger   []ToolInvocation
 	Proof    ProofSummary
 	Metadata JobMetadata
+	// Snapshot 基于快照导出时的基线快照内容（snapshot.json），完整导出为空
+	Snapshot json.RawMessage
+	// PlanRationale 各次 plan_generated 的节点规划理由（plan_rationale.json），旧证据包为空
+	PlanRationale []PlanRationale
+	// Branding 导出时租户的品牌设置（branding.json），离线渲染报告页头；未配置品牌时为空
+	Branding json.RawMessage
+}
+
+// PlanRationale 一次 plan_generated 中各节点的规划理由，供审计查看「为何这样规划」
+type PlanRationale struct {
+	EventID   string          `json:"event_id"`
+	PlanHash  string          `json:"plan_hash,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Nodes     []NodeRationale `json:"nodes"`
+}
+
+// NodeRationale 单个节点的规划理由；Rationale 为空表示规划时未给出
+type NodeRationale struct {
+	NodeID    string `json:"node_id"`
+	Type      string `json:"type"`
+	Tool      string `json:"tool,omitempty"`
+	Rationale string `json:"rationale,omitempty"`
 }
 
 // Manifest 证据包清单
@@ -40,6 +65,25 @@ type Manifest struct {
 	FileHashes     map[string]string `json:"file_hashes"` // filename -> SHA256
 	RuntimeVersion string            `json:"runtime_version"`
 	SchemaVersion  string            `json:"schema_version"`
+	// Redacted 为 true 时 events.ndjson 已脱敏，哈希链基于脱敏后内容重建；OriginalRootHash 为脱敏前链尾 hash
+	Redacted         bool   `json:"redacted,omitempty"`
+	OriginalRootHash string `json:"original_root_hash,omitempty"`
+	// BaseSnapshot 非空时 events.ndjson 自快照之后开始，首事件 prev_hash 续接 BaseSnapshot.EventHash
+	BaseSnapshot *SnapshotRef `json:"base_snapshot,omitempty"`
+	// Include 选择性导出范围（tool_invocations / failed_steps），空为完整事件流；非空时哈希链基于选中事件重建
+	Include string `json:"include,omitempty"`
+	// TruncatedEvents payload 被截断的事件数；截断字段保留原值 sha256，哈希链基于截断后内容重建
+	TruncatedEvents int `json:"truncated_events,omitempty"`
+	// SourceEventCount 源事件流总数（含快照覆盖与未选中的事件）；仅部分导出时填写
+	SourceEventCount int `json:"source_event_count,omitempty"`
+}
+
+// SnapshotRef manifest 中的快照基线引用
+type SnapshotRef struct {
+	Version   int       `json:"version"`    // 快照覆盖的事件数（源事件流前 Version 条）
+	EventHash string    `json:"event_hash"` // 源事件流第 Version 条事件的 hash，即导出首事件的 prev_hash
+	CreatedAt time.Time `json:"created_at"`
+	File      string    `json:"file"` // 快照内容文件名（snapshot.json）
 }
 
 // ProofSummary 证明摘要
@@ -61,6 +105,8 @@ type Event struct {
 	CreatedAt time.Time `json:"created_at"`
 	PrevHash  string    `json:"prev_hash"`
 	Hash      string    `json:"hash"`
+	// OriginalPayloadHash payload 被截断时原 payload 的 sha256，便于持有原文者核对
+	OriginalPayloadHash string `json:"original_payload_hash,omitempty"`
 }
 
 // ToolInvocation 工具调用记录（对应 ledger）
@@ -96,6 +142,29 @@ type ExportOptions struct {
 	IncludeReasoning bool
 	RedactionEnabled bool   // 2.0-M2: 是否启用脱敏
 	RedactionSalt    string // 2.0-M2: Hash 模式的 salt
+	// RedactionPolicy 脱敏策略（如由 PII 标签生成）；RedactionEnabled 且非 nil 时对事件 payload 脱敏并重建哈希链
+	RedactionPolicy *redaction.RedactionPolicy
+	// BaseSnapshot 非 nil 时以快照为基线，仅导出快照之后的事件，快照内容打包为 snapshot.json；不可与脱敏同时使用
+	BaseSnapshot *SnapshotBase
+	// MaxPayloadBytes >0 时超出该大小的事件 payload 截断较大的顶层字段（保留原值 sha256）
+	MaxPayloadBytes int
+	// Include 选择性导出：空为全部事件，IncludeToolInvocations 仅工具调用，IncludeFailedSteps 仅失败步骤
+	Include string
+	// Branding 非空时原样写入 branding.json（租户品牌，供离线报告渲染），并计入 file_hashes
+	Branding []byte
+}
+
+// 选择性导出范围（ExportOptions.Include）
+const (
+	IncludeToolInvocations = "tool_invocations"
+	IncludeFailedSteps     = "failed_steps"
+)
+
+// SnapshotBase 导出基线快照（对应 jobstore.JobSnapshot）
+type SnapshotBase struct {
+	Version   int    // 快照覆盖的事件数
+	Data      []byte // 快照内容（SnapshotPayload JSON）
+	CreatedAt time.Time
 }
 
 // VerifyResult 验证结果
@@ -107,6 +176,8 @@ type VerifyResult struct {
 	LedgerValid    bool
 	HashChainValid bool
 	ManifestValid  bool
+	// Manifest 解析出的清单（含快照基线、选择性导出与截断信息），manifest 无法解析时为零值
+	Manifest Manifest
 }
 
 // JobStore 接口（用于导出）
@@ -72,6 +72,17 @@ func VerifyEvidenceZip(zipBytes []byte) VerifyResult {
 		return result
 	}
 	result.ManifestValid = true
+	result.Manifest = manifest
+
+	// 3.1 快照基线包：快照文件须存在且已登记哈希，事件链自基线事件 hash 续接
+	baseHash := ""
+	if ref := manifest.BaseSnapshot; ref != nil {
+		baseHash = ref.EventHash
+		if _, ok := manifest.FileHashes[ref.File]; !ok || ref.File == "" {
+			result.OK = false
+			result.Errors = append(result.Errors, fmt.Sprintf("snapshot file %q not covered by manifest file_hashes", ref.File))
+		}
+	}
 
 	// 4. 验证文件哈希
 	for filename, expectedHash := range manifest.FileHashes {
@@ -104,7 +115,7 @@ func VerifyEvidenceZip(zipBytes []byte) VerifyResult {
 	result.Events = events
 
 	// 6. 验证哈希链
-	if err := ValidateChain(events); err != nil {
+	if err := ValidateChainFrom(events, baseHash); err != nil {
 		result.OK = false
 		result.HashChainValid = false
 		result.Errors = append(result.Errors, fmt.Sprintf("hash chain invalid: %v", err))
@@ -140,10 +151,10 @@ func VerifyEvidenceZip(zipBytes []byte) VerifyResult {
 			result.OK = false
 			result.Errors = append(result.Errors, fmt.Sprintf("failed to parse proof: %v", err))
 		} else {
-			// 验证 root_hash == 最后一个事件的 hash
-			if len(events) > 0 && proofSummary.RootHash != events[len(events)-1].Hash {
+			// 验证 root_hash == 最后一个事件的 hash（快照基线包无新事件时为基线 hash）
+			if expected := chainTail(events, baseHash); expected != "" && proofSummary.RootHash != expected {
 				result.OK = false
-				result.Errors = append(result.Errors, fmt.Sprintf("proof root_hash mismatch: expected %s, got %s", events[len(events)-1].Hash, proofSummary.RootHash))
+				result.Errors = append(result.Errors, fmt.Sprintf("proof root_hash mismatch: expected %s, got %s", expected, proofSummary.RootHash))
 			}
 		}
 	}
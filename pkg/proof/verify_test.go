@@ -18,8 +18,11 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
+
+	"rag-platform/pkg/redaction"
 )
 
 // 测试 helper: 创建测试事件
@@ -86,6 +89,163 @@ func TestEvidence_Valid(t *testing.T) {
 	}
 }
 
+// TestReadEvidenceZip 解析证据包得到 manifest、事件与元信息
+func TestReadEvidenceZip(t *testing.T) {
+	jobID := "job_read_1"
+	events := makeTestEvents(jobID, 3)
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID,
+		memJobStore{events: events},
+		memLedger{},
+		ExportOptions{RuntimeVersion: "test", SchemaVersion: "2.0"},
+	)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	pkg, err := ReadEvidenceZip(zipBytes)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if pkg.Manifest.JobID != jobID || pkg.Metadata.JobID != jobID {
+		t.Errorf("job id mismatch: manifest=%q metadata=%q", pkg.Manifest.JobID, pkg.Metadata.JobID)
+	}
+	if len(pkg.Events) != 3 || pkg.Events[2].Hash != events[2].Hash {
+		t.Errorf("events not round-tripped: %+v", pkg.Events)
+	}
+	if pkg.Proof.RootHash != events[2].Hash {
+		t.Errorf("proof root hash = %q", pkg.Proof.RootHash)
+	}
+
+	if _, err := ReadEvidenceZip([]byte("not a zip")); err == nil {
+		t.Error("expected error for invalid zip")
+	}
+}
+
+// TestEvidence_RedactedExportStillVerifies 按策略脱敏后重建哈希链，证据包仍可验证且不含原文
+func TestEvidence_RedactedExportStillVerifies(t *testing.T) {
+	jobID := "job_test_redact"
+	events := makeTestEvents(jobID, 3)
+	events[1].Type = "tool_returned"
+	events[1].Payload = `{"output":{"email":"alice@example.com"},"tool":"crm"}`
+	prevHash := ""
+	for i := range events {
+		events[i].PrevHash = prevHash
+		events[i].Hash = ComputeEventHash(events[i])
+		prevHash = events[i].Hash
+	}
+	originalRoot := events[len(events)-1].Hash
+
+	policy := &redaction.RedactionPolicy{EventRules: map[string][]redaction.FieldMask{
+		"tool_returned": {{FieldPath: "output.email", Mode: redaction.RedactionModeRedact}},
+	}}
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID,
+		memJobStore{events: events},
+		memLedger{},
+		ExportOptions{RuntimeVersion: "test", RedactionEnabled: true, RedactionPolicy: policy},
+	)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if result := VerifyEvidenceZip(zipBytes); !result.OK {
+		t.Fatalf("redacted package should verify, got errors: %v", result.Errors)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(rc)
+		_ = rc.Close()
+		if bytes.Contains(buf.Bytes(), []byte("alice@example.com")) {
+			t.Errorf("%s still contains raw PII", f.Name)
+		}
+		if f.Name == "manifest.json" {
+			var m Manifest
+			if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+				t.Fatal(err)
+			}
+			if !m.Redacted || m.OriginalRootHash != originalRoot {
+				t.Errorf("manifest should record redaction, got redacted=%v original_root=%s", m.Redacted, m.OriginalRootHash)
+			}
+		}
+	}
+}
+
+// TestEvidence_IncludesCitations 证据包包含 reasoning_snapshot 中记录的 RAG 引用
+func TestEvidence_IncludesCitations(t *testing.T) {
+	jobID := "job_test_citations"
+	events := makeTestEvents(jobID, 2)
+	events[1].Type = "reasoning_snapshot"
+	events[1].Payload = `{"step_id":"s1","node_id":"n1","evidence":{"citations":[{"chunk_id":"c1","document_id":"d1","score":0.9,"rank":1,"offset":{"start":10,"end":42}}]}}`
+	events[1].PrevHash = events[0].Hash
+	events[1].Hash = ComputeEventHash(events[1])
+
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID, memJobStore{events: events}, memLedger{}, ExportOptions{RuntimeVersion: "test"})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if result := VerifyEvidenceZip(zipBytes); !result.OK {
+		t.Fatalf("package should verify, got errors: %v", result.Errors)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "citations.json" {
+			continue
+		}
+		rc, _ := f.Open()
+		var steps []struct {
+			StepID    string `json:"step_id"`
+			Citations []struct {
+				ChunkID string `json:"chunk_id"`
+			} `json:"citations"`
+		}
+		err := json.NewDecoder(rc).Decode(&steps)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 1 || steps[0].StepID != "s1" || len(steps[0].Citations) != 1 || steps[0].Citations[0].ChunkID != "c1" {
+			t.Fatalf("unexpected citations: %+v", steps)
+		}
+		return
+	}
+	t.Fatal("citations.json missing from evidence package")
+}
+
+// TestEvidence_IncludesPlanRationale 证据包包含 plan_generated 中各节点的规划理由
+func TestEvidence_IncludesPlanRationale(t *testing.T) {
+	jobID := "job_test_rationale"
+	events := makeTestEvents(jobID, 2)
+	events[1].Type = "plan_generated"
+	events[1].Payload = `{"plan_hash":"ph1","task_graph":{"nodes":[{"id":"n1","type":"tool","tool_name":"knowledge.search","rationale":"先检索依据"},{"id":"n2","type":"llm"}],"edges":[{"from":"n1","to":"n2"}]}}`
+	events[1].PrevHash = events[0].Hash
+	events[1].Hash = ComputeEventHash(events[1])
+
+	zipBytes, err := ExportEvidenceZip(context.Background(), jobID, memJobStore{events: events}, memLedger{}, ExportOptions{RuntimeVersion: "test"})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if result := VerifyEvidenceZip(zipBytes); !result.OK {
+		t.Fatalf("package should verify, got errors: %v", result.Errors)
+	}
+	pkg, err := ReadEvidenceZip(zipBytes)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(pkg.PlanRationale) != 1 || pkg.PlanRationale[0].PlanHash != "ph1" || pkg.PlanRationale[0].EventID != events[1].ID {
+		t.Fatalf("plan rationale = %+v", pkg.PlanRationale)
+	}
+	nodes := pkg.PlanRationale[0].Nodes
+	if len(nodes) != 2 || nodes[0].Tool != "knowledge.search" || nodes[0].Rationale != "先检索依据" || nodes[1].Rationale != "" {
+		t.Fatalf("nodes = %+v", nodes)
+	}
+}
+
 // TestEvidence_TamperEvent 篡改事件内容，验证失败
 func TestEvidence_TamperEvent(t *testing.T) {
 	jobID := "job_test_2"
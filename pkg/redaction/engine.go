@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
@@ -65,23 +66,23 @@ func (e *Engine) RedactData(eventType string, data []byte) ([]byte, error) {
 	return json.Marshal(obj)
 }
 
-// applyFieldMask 应用字段掩码
+// applyFieldMask 应用字段掩码；路径段为数字时可定位数组元素（如 "messages.0.content"）
 func (e *Engine) applyFieldMask(obj map[string]interface{}, mask FieldMask) {
 	// 解析 field path (e.g., "payload.email" -> ["payload", "email"])
 	parts := strings.Split(mask.FieldPath, ".")
 
 	// 定位字段
-	current := obj
+	var current interface{} = obj
 	for i := 0; i < len(parts)-1; i++ {
-		if next, ok := current[parts[i]].(map[string]interface{}); ok {
-			current = next
-		} else {
+		next, ok := childOf(current, parts[i])
+		if !ok {
 			return // 字段不存在
 		}
+		current = next
 	}
 
 	lastKey := parts[len(parts)-1]
-	value, exists := current[lastKey]
+	value, exists := childOf(current, lastKey)
 	if !exists {
 		return
 	}
@@ -89,22 +90,55 @@ func (e *Engine) applyFieldMask(obj map[string]interface{}, mask FieldMask) {
 	// 应用脱敏
 	switch mask.Mode {
 	case RedactionModeRedact:
-		current[lastKey] = "***REDACTED***"
+		setChild(current, lastKey, "***REDACTED***")
 
 	case RedactionModeHash:
 		strValue := fmt.Sprintf("%v", value)
 		hashValue := e.hashValue(strValue, mask.Salt)
-		current[lastKey] = hashValue
+		setChild(current, lastKey, hashValue)
 
 	case RedactionModeEncrypt:
 		strValue := fmt.Sprintf("%v", value)
 		encrypted, err := e.encryptValue(strValue)
 		if err == nil {
-			current[lastKey] = encrypted
+			setChild(current, lastKey, encrypted)
 		}
 
 	case RedactionModeRemove:
-		delete(current, lastKey)
+		if m, ok := current.(map[string]interface{}); ok {
+			delete(m, lastKey)
+		} else {
+			// 数组元素无法删除而不改变下标，置为 null
+			setChild(current, lastKey, nil)
+		}
+	}
+}
+
+// childOf 取对象字段或数组元素
+func childOf(container interface{}, key string) (interface{}, bool) {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		v, ok := c[key]
+		return v, ok
+	case []interface{}:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(c) {
+			return nil, false
+		}
+		return c[idx], true
+	}
+	return nil, false
+}
+
+// setChild 写回对象字段或数组元素（调用前已由 childOf 确认存在）
+func setChild(container interface{}, key string, value interface{}) {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		c[key] = value
+	case []interface{}:
+		if idx, err := strconv.Atoi(key); err == nil && idx >= 0 && idx < len(c) {
+			c[idx] = value
+		}
 	}
 }
 
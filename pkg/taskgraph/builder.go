@@ -0,0 +1,468 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taskgraph 以代码构造 TaskGraph：为偏好代码定义工作流（而非 LLM 规划）的团队提供流式 Builder，
+// 各节点类型使用带类型的配置结构，Build 时按服务端计划校验（planner.ValidateTaskGraph）检查，
+// 结果可经 Submitter 随 POST /api/agents/:id/message 的 task_graph 字段直接提交。
+//
+//	g, err := taskgraph.New().
+//		LLM("draft", taskgraph.LLMConfig{Goal: "起草回复邮件"}).
+//		Approval("review", taskgraph.WaitConfig{Reason: "人工审核草稿"}).DependsOn("draft").
+//		Tool("send_email", "email.send", taskgraph.ToolConfig{Input: SendEmailArgs{To: "a@example.com"}}).DependsOn("review").
+//		Build()
+package taskgraph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+)
+
+// WaitKind 等待节点的等待类型
+type WaitKind string
+
+const (
+	WaitUserInput WaitKind = planner.WaitKindUserInput
+	WaitWebhook   WaitKind = planner.WaitKindWebhook
+	WaitSchedule  WaitKind = planner.WaitKindSchedule
+	WaitCondition WaitKind = planner.WaitKindCondition
+	WaitMessage   WaitKind = planner.WaitKindMessage
+	// WaitSignal 等待 POST /api/jobs/:id/signal（approval 节点的默认类型）
+	WaitSignal WaitKind = "signal"
+)
+
+// Quality llm 节点要求的模型质量等级（启用 model.routing 时据此选择模型）
+type Quality string
+
+const (
+	QualityLow      Quality = "low"
+	QualityStandard Quality = "standard"
+	QualityHigh     Quality = "high"
+)
+
+// Prefer 满足要求的候选模型中的择优方式
+type Prefer string
+
+const (
+	PreferCost    Prefer = "cost"
+	PreferLatency Prefer = "latency"
+)
+
+// LLMConfig llm 节点配置
+type LLMConfig struct {
+	// Goal 本节点的提示词；空时使用 Job 目标
+	Goal string
+	// Review 生成后挂起，等待人类通过或编辑输出再继续
+	Review bool
+	// ScratchpadOut 非空时将生成结果写入 scratchpad 的该 key，供后续步骤读取
+	ScratchpadOut string
+	// Quality 最低模型质量；空为不限
+	Quality Quality
+	// MaxLatency 期望的单次调用延迟上限；0 为不限
+	MaxLatency time.Duration
+	// Prefer 满足要求的候选中优先成本（默认）或延迟
+	Prefer Prefer
+}
+
+// ToolConfig tool 节点配置
+type ToolConfig struct {
+	// Input 工具参数：struct 或 map，按 JSON 序列化后作为节点 config，须为 JSON 对象
+	Input any
+	// Transaction 事务组 ID：同组节点全部提交，或任一失败时对已提交成员执行补偿
+	Transaction string
+	// Compensate 事务组成员的补偿动作
+	Compensate *Compensation
+	// Rationale 选用该工具的理由，随 PlanGenerated 持久化供审计
+	Rationale string
+}
+
+// Compensation 补偿动作；Input 中形如 "$result.output.id" 的字符串取被补偿节点的结果字段
+type Compensation struct {
+	Tool  string
+	Input any
+}
+
+// WaitConfig wait / approval / condition 节点配置；零值字段取节点类型的默认值
+type WaitConfig struct {
+	Kind   WaitKind
+	Reason string
+	// CorrelationKey 唤醒时匹配的 key；空时由 Runner 生成
+	CorrelationKey string
+	// Channel Kind=WaitMessage 时等待的信箱 channel
+	Channel string
+	// ExpiresAt 等待过期时间；零值为 24h 后
+	ExpiresAt time.Time
+	// Park 挂起为 Parked（长期休眠）而非 Waiting
+	Park bool
+}
+
+// Child spawn 节点派发的子任务；Key 为空时按序号生成
+type Child struct {
+	Key     string `json:"key,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+	Goal    string `json:"goal"`
+}
+
+// JoinConfig join 节点配置
+type JoinConfig struct {
+	// Spawn 汇合的 spawn 节点 ID
+	Spawn string
+	// MaxRedispatch 失败子任务的最大重派次数；nil 时为 1
+	MaxRedispatch *int
+	// AllowPartial 允许部分子任务失败（默认要求全部成功）
+	AllowPartial bool
+	// MaxChildren 子任务数上限；0 不限
+	MaxChildren int
+	// Timeout 等待子任务的总时长上限；0 不限
+	Timeout time.Duration
+}
+
+// MapConfig map 节点配置；Body 为每项执行的子计划，其节点 config 中 "${item.xxx}"、"${index}" 取当前项
+type MapConfig struct {
+	// Items 列表来源，如 "search.output.items"
+	Items string
+	// ItemKey 项内作为唯一键的字段；空时按序号
+	ItemKey string
+	// MaxIterations 项数上限；0 为服务端默认值
+	MaxIterations int
+	// Parallelism 并行执行的项数；0 为 1（顺序执行）
+	Parallelism int
+	// MaxRetries 每项失败后的重试次数
+	MaxRetries int
+	// AllowPartial 允许部分项失败（默认任一项失败即 map 节点失败）
+	AllowPartial bool
+	// Body 每项执行的子计划
+	Body *Builder
+}
+
+// Builder 流式构造 TaskGraph；添加节点的方法返回 Builder 本身，DependsOn/Rationale 作用于最近添加的节点。
+// 构造过程中的错误累积到 Build 时一并返回
+type Builder struct {
+	nodes      []planner.TaskNode
+	edges      []planner.TaskEdge
+	knownTools map[string]bool
+	errs       []error
+}
+
+// New 创建空 Builder
+func New() *Builder {
+	return &Builder{}
+}
+
+// KnownTools 限定 tool 节点与补偿动作只能引用这些工具（与服务端按工具注册表校验一致）；不调用时不检查工具名
+func (b *Builder) KnownTools(names ...string) *Builder {
+	if b.knownTools == nil {
+		b.knownTools = make(map[string]bool, len(names))
+	}
+	for _, n := range names {
+		b.knownTools[n] = true
+	}
+	return b
+}
+
+// LLM 添加 llm 节点
+func (b *Builder) LLM(id string, cfg LLMConfig) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeLLM}, cfg.config())
+}
+
+// Tool 添加 tool 节点，调用 tool 工具
+func (b *Builder) Tool(id, tool string, cfg ToolConfig) *Builder {
+	n := planner.TaskNode{ID: id, Type: planner.NodeTool, ToolName: tool, Transaction: cfg.Transaction, Rationale: cfg.Rationale}
+	if c := cfg.Compensate; c != nil {
+		input, err := objectOf(c.Input)
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("node %q: compensate input: %w", id, err))
+		}
+		n.Compensate = &planner.Compensation{Tool: c.Tool, Input: input}
+	}
+	return b.add(n, cfg.Input)
+}
+
+// Workflow 添加 workflow 节点；params 为工作流参数
+func (b *Builder) Workflow(id, workflow string, params map[string]any) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeWorkflow, Workflow: workflow}, params)
+}
+
+// Wait 添加 wait 节点：挂起直到收到 signal/message 等唤醒
+func (b *Builder) Wait(id string, cfg WaitConfig) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeWait}, cfg.config())
+}
+
+// Approval 添加 approval 节点：默认等待 signal，常用于人类审批
+func (b *Builder) Approval(id string, cfg WaitConfig) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeApproval}, cfg.config())
+}
+
+// Condition 添加 condition 节点：默认等待外部条件达成
+func (b *Builder) Condition(id string, cfg WaitConfig) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeCondition}, cfg.config())
+}
+
+// Reflect 添加 reflect 节点：由 LLM 复盘已执行轨迹，可提出计划修订
+func (b *Builder) Reflect(id string) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeReflect}, nil)
+}
+
+// LangGraph 添加 langgraph 节点；input 合并进调用外部图执行器的输入
+func (b *Builder) LangGraph(id string, input map[string]any) *Builder {
+	var cfg map[string]any
+	if input != nil {
+		cfg = map[string]any{"input": input}
+	}
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeLangGraph}, cfg)
+}
+
+// Spawn 添加 spawn 节点：为每个子任务创建子 Job
+func (b *Builder) Spawn(id string, children ...Child) *Builder {
+	if len(children) == 0 {
+		b.errs = append(b.errs, fmt.Errorf("spawn node %q has no children", id))
+	}
+	seen := make(map[string]bool, len(children))
+	for i, c := range children {
+		if strings.TrimSpace(c.Goal) == "" {
+			b.errs = append(b.errs, fmt.Errorf("spawn node %q: children[%d] has no goal", id, i))
+		}
+		if c.Key != "" && seen[c.Key] {
+			b.errs = append(b.errs, fmt.Errorf("spawn node %q: duplicate child key %q", id, c.Key))
+		}
+		seen[c.Key] = true
+	}
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeSpawn}, map[string]any{"children": children})
+}
+
+// Join 添加 join 节点：等待 spawn 节点的子 Job 全部终态并汇总结果；未设置 DependsOn 时自动依赖 cfg.Spawn
+func (b *Builder) Join(id string, cfg JoinConfig) *Builder {
+	c := map[string]any{}
+	if cfg.Spawn != "" {
+		c["spawn"] = cfg.Spawn
+	}
+	if cfg.MaxRedispatch != nil {
+		c["max_redispatch"] = *cfg.MaxRedispatch
+	}
+	if cfg.AllowPartial {
+		c["require_all"] = false
+	}
+	if cfg.MaxChildren > 0 || cfg.Timeout > 0 {
+		budget := map[string]any{}
+		if cfg.MaxChildren > 0 {
+			budget["max_children"] = cfg.MaxChildren
+		}
+		if cfg.Timeout > 0 {
+			budget["timeout"] = cfg.Timeout.String()
+		}
+		c["budget"] = budget
+	}
+	b.add(planner.TaskNode{ID: id, Type: planner.NodeJoin}, c)
+	if cfg.Spawn != "" {
+		b.DependsOn(cfg.Spawn)
+	}
+	return b
+}
+
+// Branch 添加 branch 节点：按 expr 对已执行节点的结果确定性求值（如 "empty(search.output)"），
+// 下游节点用 DependsOnWhen 声明在哪个结果下执行
+func (b *Builder) Branch(id, expr string) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeBranch}, map[string]any{"expr": expr})
+}
+
+// Map 添加 map 节点：对 cfg.Items 解析出的列表逐项执行 cfg.Body
+func (b *Builder) Map(id string, cfg MapConfig) *Builder {
+	c := map[string]any{"items": cfg.Items}
+	if cfg.ItemKey != "" {
+		c["item_key"] = cfg.ItemKey
+	}
+	if cfg.MaxIterations > 0 {
+		c["max_iterations"] = cfg.MaxIterations
+	}
+	if cfg.Parallelism > 0 {
+		c["parallelism"] = cfg.Parallelism
+	}
+	if cfg.MaxRetries > 0 {
+		c["max_retries"] = cfg.MaxRetries
+	}
+	if cfg.AllowPartial {
+		c["allow_partial"] = true
+	}
+	if cfg.Body != nil {
+		for _, err := range cfg.Body.errs {
+			b.errs = append(b.errs, fmt.Errorf("map node %q: body: %w", id, err))
+		}
+		c["body"] = &planner.TaskGraph{Nodes: cfg.Body.nodes, Edges: append([]planner.TaskEdge{}, cfg.Body.edges...)}
+	}
+	return b.add(planner.TaskNode{ID: id, Type: planner.NodeMap}, c)
+}
+
+// Custom 添加自定义类型节点（pkg/nodeplugin）；cfg 为结构体或 map，按 JSON 转为 config。
+// 该类型须已在本进程注册（nodeplugin.Register 或 RegisterManifest），Build 时按其 Manifest 校验 config
+func (b *Builder) Custom(id, nodeType string, cfg any) *Builder {
+	return b.add(planner.TaskNode{ID: id, Type: nodeType}, cfg)
+}
+
+// DependsOn 最近添加的节点依赖 ids（添加 id → 该节点 的边）；重复的边只保留一条
+func (b *Builder) DependsOn(ids ...string) *Builder {
+	if len(b.nodes) == 0 {
+		b.errs = append(b.errs, errors.New("DependsOn called before any node was added"))
+		return b
+	}
+	to := b.nodes[len(b.nodes)-1].ID
+	for _, from := range ids {
+		e := planner.TaskEdge{From: from, To: to}
+		if !containsEdge(b.edges, e) {
+			b.edges = append(b.edges, e)
+		}
+	}
+	return b
+}
+
+// DependsOnWhen 最近添加的节点仅在 branch 节点 from 的结果为 outcome 时执行（布尔结果为 "true"/"false"）；
+// outcome 为 "default" 时在 from 未命中其它分支时执行
+func (b *Builder) DependsOnWhen(from, outcome string) *Builder {
+	if len(b.nodes) == 0 {
+		b.errs = append(b.errs, errors.New("DependsOnWhen called before any node was added"))
+		return b
+	}
+	if outcome == "" {
+		b.errs = append(b.errs, fmt.Errorf("DependsOnWhen(%q) needs an outcome", from))
+		return b
+	}
+	e := planner.TaskEdge{From: from, To: b.nodes[len(b.nodes)-1].ID, When: outcome}
+	if !containsEdge(b.edges, e) {
+		b.edges = append(b.edges, e)
+	}
+	return b
+}
+
+// Rationale 设置最近添加节点的规划理由
+func (b *Builder) Rationale(text string) *Builder {
+	if len(b.nodes) == 0 {
+		b.errs = append(b.errs, errors.New("Rationale called before any node was added"))
+		return b
+	}
+	b.nodes[len(b.nodes)-1].Rationale = text
+	return b
+}
+
+// Build 返回构造的 TaskGraph；构造错误与服务端计划校验错误一并返回
+func (b *Builder) Build() (*planner.TaskGraph, error) {
+	g := &planner.TaskGraph{
+		Nodes: append([]planner.TaskNode(nil), b.nodes...),
+		Edges: append([]planner.TaskEdge{}, b.edges...),
+	}
+	errs := append([]error(nil), b.errs...)
+	if err := planner.ValidateTaskGraph(g, b.knownTools); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// MustBuild 同 Build，出错时 panic；用于包级变量或测试中的固定计划
+func (b *Builder) MustBuild() *planner.TaskGraph {
+	g, err := b.Build()
+	if err != nil {
+		panic(fmt.Sprintf("taskgraph: %v", err))
+	}
+	return g
+}
+
+// add 追加节点；配置按 JSON 往返规范化为 map[string]any，与计划经 API 提交后 Worker 读到的形态一致
+func (b *Builder) add(n planner.TaskNode, cfg any) *Builder {
+	m, err := objectOf(cfg)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("node %q: config: %w", n.ID, err))
+	}
+	n.Config = m
+	b.nodes = append(b.nodes, n)
+	return b
+}
+
+func (c LLMConfig) config() map[string]any {
+	m := map[string]any{}
+	if c.Goal != "" {
+		m["goal"] = c.Goal
+	}
+	if c.Review {
+		m["review"] = true
+	}
+	if c.ScratchpadOut != "" {
+		m["scratchpad_out"] = c.ScratchpadOut
+	}
+	if c.Quality != "" {
+		m["quality"] = string(c.Quality)
+	}
+	if c.MaxLatency > 0 {
+		m["max_latency"] = c.MaxLatency.String()
+	}
+	if c.Prefer != "" {
+		m["prefer"] = string(c.Prefer)
+	}
+	return m
+}
+
+func (c WaitConfig) config() map[string]any {
+	m := map[string]any{}
+	if c.Kind != "" {
+		m["wait_kind"] = string(c.Kind)
+	}
+	if c.Reason != "" {
+		m["reason"] = c.Reason
+	}
+	if c.CorrelationKey != "" {
+		m["correlation_key"] = c.CorrelationKey
+	}
+	if c.Channel != "" {
+		m["channel"] = c.Channel
+	}
+	if !c.ExpiresAt.IsZero() {
+		m["expires_at"] = c.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if c.Park {
+		m["park"] = true
+	}
+	return m
+}
+
+// objectOf 将 struct/map 经 JSON 转为 map[string]any；nil 与空对象返回 nil，非 JSON 对象返回错误
+func objectOf(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("must encode to a JSON object, got %s", raw)
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func containsEdge(edges []planner.TaskEdge, e planner.TaskEdge) bool {
+	for _, x := range edges {
+		if x == e {
+			return true
+		}
+	}
+	return false
+}
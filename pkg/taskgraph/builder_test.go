@@ -0,0 +1,194 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskgraph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rag-platform/internal/agent/planner"
+	"rag-platform/pkg/nodeplugin"
+)
+
+type sendEmailArgs struct {
+	To      string `json:"to"`
+	Subject string `json:"subject,omitempty"`
+}
+
+func TestBuilder_Build(t *testing.T) {
+	g, err := New().
+		LLM("draft", LLMConfig{Goal: "起草回复", ScratchpadOut: "draft", Quality: QualityLow, MaxLatency: 2 * time.Second}).
+		Approval("review", WaitConfig{Reason: "人工审核", ExpiresAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}).DependsOn("draft").
+		Tool("send", "email.send", ToolConfig{
+			Input:       sendEmailArgs{To: "a@example.com"},
+			Transaction: "notify",
+			Compensate:  &Compensation{Tool: "email.recall", Input: map[string]any{"id": "$result.output.id"}},
+		}).DependsOn("review", "review").Rationale("审核通过后发送").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("graph = %+v", g)
+	}
+	send := g.Nodes[2]
+	if send.Type != planner.NodeTool || send.ToolName != "email.send" || send.Config["to"] != "a@example.com" {
+		t.Fatalf("tool node = %+v", send)
+	}
+	if _, ok := send.Config["subject"]; ok {
+		t.Fatal("omitempty field should not be in config")
+	}
+	if send.Transaction != "notify" || send.Compensate.Tool != "email.recall" || send.Rationale != "审核通过后发送" {
+		t.Fatalf("tool node extras = %+v", send)
+	}
+	if draft := g.Nodes[0].Config; draft["quality"] != "low" || draft["max_latency"] != "2s" || draft["scratchpad_out"] != "draft" {
+		t.Fatalf("llm config = %v", draft)
+	}
+	review := g.Nodes[1].Config
+	if review["reason"] != "人工审核" || review["expires_at"] != "2026-01-02T03:04:05Z" {
+		t.Fatalf("approval config = %v", review)
+	}
+	if _, ok := review["wait_kind"]; ok {
+		t.Fatal("wait_kind should default on the server side")
+	}
+}
+
+func TestBuilder_SpawnJoin(t *testing.T) {
+	two := 2
+	g, err := New().
+		Spawn("fan", Child{Goal: "调研 A"}, Child{Key: "b", AgentID: "researcher", Goal: "调研 B"}).
+		Join("gather", JoinConfig{Spawn: "fan", MaxRedispatch: &two, AllowPartial: true, Timeout: time.Minute}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(g.Edges) != 1 || g.Edges[0] != (planner.TaskEdge{From: "fan", To: "gather"}) {
+		t.Fatalf("edges = %+v", g.Edges)
+	}
+	// 配置按 JSON 往返规范化：与 Worker 从 PlanGenerated 读到的形态一致
+	children, ok := g.Nodes[0].Config["children"].([]any)
+	if !ok || len(children) != 2 {
+		t.Fatalf("children = %#v", g.Nodes[0].Config["children"])
+	}
+	join := g.Nodes[1].Config
+	if join["max_redispatch"] != float64(2) || join["require_all"] != false || join["budget"].(map[string]any)["timeout"] != "1m0s" {
+		t.Fatalf("join config = %v", join)
+	}
+}
+
+func TestBuilder_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		b    *Builder
+		want string
+	}{
+		{"empty", New(), "no nodes"},
+		{"duplicate", New().LLM("a", LLMConfig{}).LLM("a", LLMConfig{}), "duplicate node id"},
+		{"unknown dep", New().LLM("a", LLMConfig{}).DependsOn("missing"), "unknown node"},
+		{"cycle", New().LLM("a", LLMConfig{}).DependsOn("b").LLM("b", LLMConfig{}).DependsOn("a"), "cycle"},
+		{"depends first", New().DependsOn("a").LLM("a", LLMConfig{}), "before any node"},
+		{"unknown tool", New().KnownTools("search").Tool("t", "email.send", ToolConfig{}), "unknown tool"},
+		{"tool input not object", New().Tool("t", "search", ToolConfig{Input: []string{"x"}}), "JSON object"},
+		{"spawn without goal", New().Spawn("s", Child{Key: "a"}), "has no goal"},
+		{"when on non-branch", New().LLM("a", LLMConfig{}).LLM("b", LLMConfig{}).DependsOnWhen("a", "true"), "not a branch node"},
+		{"bad branch expr", New().Branch("c", "len("), "branch node"},
+		{"map body wait", New().Tool("s", "search", ToolConfig{}).Map("m", MapConfig{Items: "s.output", Body: New().Wait("w", WaitConfig{})}).DependsOn("s"), "not allowed in a map body"},
+	}
+	for _, c := range cases {
+		_, err := c.b.Build()
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: err = %v, want %q", c.name, err, c.want)
+		}
+	}
+}
+
+func TestBuilder_Branch(t *testing.T) {
+	g, err := New().
+		Tool("search", "search", ToolConfig{}).
+		Branch("check", "empty(search.output)").DependsOn("search").
+		Wait("ask", WaitConfig{Kind: WaitUserInput}).DependsOnWhen("check", "true").
+		LLM("summarize", LLMConfig{}).DependsOnWhen("check", "false").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if g.Nodes[1].Config["expr"] != "empty(search.output)" {
+		t.Fatalf("branch node = %+v", g.Nodes[1])
+	}
+	if len(g.Edges) != 3 || g.Edges[1].When != "true" || g.Edges[2].When != "false" {
+		t.Fatalf("edges = %+v", g.Edges)
+	}
+}
+
+func TestBuilder_Map(t *testing.T) {
+	g, err := New().
+		Tool("search", "search", ToolConfig{}).
+		Map("each", MapConfig{
+			Items: "search.output.items", ItemKey: "url", Parallelism: 4, AllowPartial: true,
+			Body: New().
+				Tool("fetch", "search", ToolConfig{Input: map[string]any{"q": "${item.url}"}}).
+				LLM("sum", LLMConfig{Goal: "总结 ${item.title}"}).DependsOn("fetch"),
+		}).DependsOn("search").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	cfg, err := planner.ParseMapConfig(&g.Nodes[1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Parallelism != 4 || !cfg.AllowPartial || cfg.ItemKey != "url" || len(cfg.Body.Nodes) != 2 || len(cfg.Body.Edges) != 1 {
+		t.Fatalf("map config = %+v", cfg)
+	}
+}
+
+func TestBuilder_Custom(t *testing.T) {
+	_ = nodeplugin.RegisterManifest(nodeplugin.Manifest{
+		Type:   "taskgraph_test.spark",
+		Config: []nodeplugin.Field{{Name: "cluster", Type: nodeplugin.FieldString, Required: true}, {Name: "executors", Type: nodeplugin.FieldInteger}},
+	})
+	type sparkConfig struct {
+		Cluster   string `json:"cluster"`
+		Executors int    `json:"executors,omitempty"`
+	}
+	g, err := New().
+		Custom("etl", "taskgraph_test.spark", sparkConfig{Cluster: "prod", Executors: 8}).
+		LLM("report", LLMConfig{Goal: "汇总 ETL 结果"}).DependsOn("etl").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if g.Nodes[0].Type != "taskgraph_test.spark" || g.Nodes[0].Config["cluster"] != "prod" {
+		t.Fatalf("custom node = %+v", g.Nodes[0])
+	}
+
+	_, err = New().Custom("etl", "taskgraph_test.spark", map[string]any{"executors": 2}).Build()
+	if err == nil || !strings.Contains(err.Error(), "config.cluster is required") {
+		t.Fatalf("missing field err = %v", err)
+	}
+	if _, err := New().Custom("x", "taskgraph_test.unregistered", nil).Build(); err == nil {
+		t.Fatal("unregistered custom type accepted")
+	}
+}
+
+func TestBuilder_MustBuildPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustBuild should panic on invalid graph")
+		}
+	}()
+	New().MustBuild()
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"rag-platform/internal/agent/planner"
+)
+
+// Submitter 经 POST /api/agents/:id/message 提交代码构造的计划；服务端校验后直接写入 PlanGenerated，不调用 LLM 规划
+type Submitter struct {
+	// BaseURL API 地址，如 http://localhost:8080
+	BaseURL string
+	// HTTPClient 为 nil 时使用 http.DefaultClient
+	HTTPClient *http.Client
+	// Header 随请求发送的额外请求头（如 Authorization、X-Tenant-ID）
+	Header http.Header
+}
+
+// SubmitOptions 提交时可选的 Job 参数，与 AgentMessageRequest 同名字段一致
+type SubmitOptions struct {
+	Profile          string
+	Interactive      bool
+	RequiredFeatures []string
+	// IdempotencyKey 非空时作为 Idempotency-Key 请求头，重复提交返回已有 Job
+	IdempotencyKey string
+}
+
+// Submitted 提交结果
+type Submitted struct {
+	JobID        string          `json:"job_id"`
+	AgentID      string          `json:"agent_id"`
+	Status       string          `json:"status"`
+	Lane         string          `json:"lane,omitempty"`
+	PlanEstimate json.RawMessage `json:"plan_estimate,omitempty"`
+}
+
+// SubmitError 服务端拒绝提交（如计划校验failed返回 400）
+type SubmitError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *SubmitError) Error() string {
+	return fmt.Sprintf("submit task graph: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Submit 以 goal 为 Job 目标、g 为执行计划创建 Job
+func (s *Submitter) Submit(ctx context.Context, agentID, goal string, g *planner.TaskGraph, opts *SubmitOptions) (*Submitted, error) {
+	if g == nil {
+		return nil, fmt.Errorf("submit task graph: nil graph")
+	}
+	body := map[string]any{"message": goal, "task_graph": g}
+	if opts != nil {
+		if opts.Profile != "" {
+			body["profile"] = opts.Profile
+		}
+		if opts.Interactive {
+			body["interactive"] = true
+		}
+		if len(opts.RequiredFeatures) > 0 {
+			body["required_features"] = opts.RequiredFeatures
+		}
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimRight(s.BaseURL, "/") + "/api/agents/" + url.PathEscape(agentID) + "/message"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range s.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts != nil && opts.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", opts.IdempotencyKey)
+	}
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		var e struct {
+			Error string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(respBody))
+		if json.Unmarshal(respBody, &e) == nil && e.Error != "" {
+			msg = e.Error
+		}
+		return nil, &SubmitError{StatusCode: resp.StatusCode, Message: msg}
+	}
+	var out Submitted
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("submit task graph: decode response: %w", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 fanjia1024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubmitter_Submit(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/agents/agent-1/message" || r.Header.Get("X-Tenant-ID") != "acme" || r.Header.Get("Idempotency-Key") != "k1" {
+			t.Errorf("request = %s %v", r.URL.Path, r.Header)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"accepted","agent_id":"agent-1","job_id":"job-9","lane":"default"}`))
+	}))
+	defer srv.Close()
+
+	g := New().LLM("draft", LLMConfig{Goal: "hi"}).MustBuild()
+	s := &Submitter{BaseURL: srv.URL + "/", Header: http.Header{"X-Tenant-ID": {"acme"}}}
+	out, err := s.Submit(context.Background(), "agent-1", "say hi", g, &SubmitOptions{Profile: "conservative", IdempotencyKey: "k1"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if out.JobID != "job-9" {
+		t.Fatalf("out = %+v", out)
+	}
+	if got["message"] != "say hi" || got["profile"] != "conservative" {
+		t.Fatalf("body = %v", got)
+	}
+	tg, _ := got["task_graph"].(map[string]any)
+	if nodes, _ := tg["nodes"].([]any); len(nodes) != 1 {
+		t.Fatalf("task_graph = %v", got["task_graph"])
+	}
+}
+
+func TestSubmitter_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid graph: task graph has a cycle"}`))
+	}))
+	defer srv.Close()
+
+	g := New().LLM("a", LLMConfig{}).MustBuild()
+	_, err := (&Submitter{BaseURL: srv.URL}).Submit(context.Background(), "agent-1", "x", g, nil)
+	var se *SubmitError
+	if !errors.As(err, &se) || se.StatusCode != 400 || se.Message != "invalid graph: task graph has a cycle" {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -15,6 +15,11 @@
 // Package utils 通用小工具，不依赖 internal（设计 struct.md 4）
 package utils
 
+import (
+	"cmp"
+	"math"
+)
+
 // CoalesceString 返回第一个非空字符串
 func CoalesceString(ss ...string) string {
 	for _, s := range ss {
@@ -32,3 +37,13 @@ func DefaultInt(v, defaultVal int) int {
 	}
 	return v
 }
+
+// Percentile 返回已升序排序样本的 p 分位（nearest-rank，p 取 0~1）；样本为空时返回零值
+func Percentile[T cmp.Ordered](sorted []T, p float64) T {
+	if len(sorted) == 0 {
+		var zero T
+		return zero
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
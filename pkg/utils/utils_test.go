@@ -56,3 +56,18 @@ func TestDefaultInt(t *testing.T) {
 		}
 	}
 }
+
+func TestPercentile(t *testing.T) {
+	sorted := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for _, tt := range []struct {
+		p    float64
+		want int
+	}{{0, 1}, {0.5, 5}, {0.95, 10}, {1, 10}} {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile([]float64(nil), 0.5); got != 0 {
+		t.Errorf("empty: got %v", got)
+	}
+}